@@ -70,6 +70,7 @@ type RelationshipNode struct {
 	ForeignKey      string // Optional metadata
 	OnDelete        string // restrict, cascade, set_null, no_action
 	OnUpdate        string // cascade, restrict, etc.
+	Kind            string // has_one, or empty for the kind implied by the type
 	LeadingComment  string // Comment on line(s) before this relationship
 	TrailingComment string // Comment at end of relationship line
 	Location        SourceLocation
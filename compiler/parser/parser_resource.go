@@ -405,6 +405,10 @@ func (p *Parser) parseRelationshipMetadata(name, targetType string, nullable boo
 					rel.OnDelete = "no_action"
 					p.advance()
 				}
+			case "kind":
+				if p.check(lexer.TOKEN_IDENTIFIER) {
+					rel.Kind = p.advance().Lexeme
+				}
 			case "on_update":
 				if p.check(lexer.TOKEN_IDENTIFIER) {
 					value := p.advance().Lexeme
@@ -17,6 +17,7 @@ type Config struct {
 	CheckCycles bool
 	Complexity  bool
 	Impact      bool
+	Indexes     bool
 	Report      bool
 	Resource    string
 }
@@ -26,6 +27,7 @@ type Report struct {
 	CircularDependencies []Cycle            `json:"circular_dependencies,omitempty"`
 	Complexity           []ComplexityMetric `json:"complexity,omitempty"`
 	Impact               []ImpactMetric     `json:"impact,omitempty"`
+	UnindexedForeignKeys []UnindexedKey     `json:"unindexed_foreign_keys,omitempty"`
 }
 
 type Cycle struct {
@@ -38,6 +40,15 @@ type ComplexityMetric struct {
 	Level    string `json:"level"` // "low", "medium", "high"
 }
 
+// UnindexedKey is a belongs_to foreign key no index starts with, so loading
+// the parent's children and enforcing its on_delete scan the table
+type UnindexedKey struct {
+	Resource     string `json:"resource"`
+	Relationship string `json:"relationship"`
+	Parent       string `json:"parent"`
+	Column       string `json:"column"`
+}
+
 type ImpactMetric struct {
 	Resource       string   `json:"resource"`
 	DependentCount int      `json:"dependent_count"`
@@ -65,7 +76,7 @@ func main() {
 	}
 
 	// Generate full report
-	if config.Report || config.CheckCycles || config.Complexity || config.Impact {
+	if config.Report || config.CheckCycles || config.Complexity || config.Impact || config.Indexes {
 		generateReport(registry, config)
 		return
 	}
@@ -85,6 +96,8 @@ func parseFlags() Config {
 		"Analyze dependency complexity")
 	flag.BoolVar(&config.Impact, "impact", false,
 		"Analyze dependency impact")
+	flag.BoolVar(&config.Indexes, "indexes", false,
+		"Find foreign keys without an index")
 	flag.BoolVar(&config.Report, "report", false,
 		"Generate full dependency report")
 
@@ -96,6 +109,7 @@ func parseFlags() Config {
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  %s Post              Analyze Post dependencies\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --check-cycles    Check for circular dependencies\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --indexes         Find unindexed foreign keys\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --report          Generate full report\n", os.Args[0])
 	}
 
@@ -178,6 +192,23 @@ func analyzeResource(registry *metadata.RegistryAPI, resourceName string) {
 	}
 	fmt.Println()
 
+	// Show indexes
+	if len(res.Indexes) > 0 {
+		fmt.Println("Indexes:")
+		for _, index := range res.Indexes {
+			unique := ""
+			if index.Unique {
+				unique = " unique"
+			}
+			fmt.Printf("  %s (%s)%s\n", index.Name, strings.Join(index.Columns, ", "), unique)
+		}
+		fmt.Println()
+	}
+	for _, key := range unindexedKeys(res) {
+		fmt.Printf("⚠️  %s.%s is not indexed: loading a %s's %s scans the table\n",
+			res.Name, key.Column, key.Parent, res.Name)
+	}
+
 	// Show routes
 	routes := registry.Routes(metadata.RouteFilter{
 		Resource: resourceName,
@@ -208,6 +239,12 @@ func generateReport(registry *metadata.RegistryAPI, config Config) {
 		report.Impact = impact
 	}
 
+	if config.Indexes || config.Report {
+		for _, res := range registry.Resources() {
+			report.UnindexedForeignKeys = append(report.UnindexedForeignKeys, unindexedKeys(&res)...)
+		}
+	}
+
 	if config.Format == "json" {
 		outputReportJSON(report)
 	} else {
@@ -281,7 +318,7 @@ func analyzeComplexity(registry *metadata.RegistryAPI) []ComplexityMetric {
 		}
 
 		// Calculate depth by counting maximum edge chain length
-		depth := calculateGraphDepth(graph, res.Name)
+		depth := metadata.GraphDepth(graph, res.Name)
 
 		level := "low"
 		if depth >= 4 {
@@ -305,35 +342,6 @@ func analyzeComplexity(registry *metadata.RegistryAPI) []ComplexityMetric {
 	return metrics
 }
 
-func calculateGraphDepth(graph *metadata.DependencyGraph, startNode string) int {
-	if len(graph.Edges) == 0 {
-		return 0
-	}
-
-	// Simple depth calculation: count maximum edge chain length
-	maxDepth := 0
-	visited := make(map[string]int)
-
-	var dfs func(node string, depth int)
-	dfs = func(node string, depth int) {
-		if depth > maxDepth {
-			maxDepth = depth
-		}
-		visited[node] = depth
-
-		for _, edge := range graph.Edges {
-			if edge.From == node {
-				if _, seen := visited[edge.To]; !seen {
-					dfs(edge.To, depth+1)
-				}
-			}
-		}
-	}
-
-	dfs(startNode, 0)
-	return maxDepth
-}
-
 func analyzeImpact(registry *metadata.RegistryAPI) []ImpactMetric {
 	resources := registry.Resources()
 	metrics := make([]ImpactMetric, 0, len(resources))
@@ -378,6 +386,46 @@ func analyzeImpact(registry *metadata.RegistryAPI) []ImpactMetric {
 	return metrics
 }
 
+// unindexedKeys returns the belongs_to foreign keys of res that neither lead
+// an index from @index or an index block nor are @unique or @primary
+func unindexedKeys(res *metadata.ResourceMetadata) []UnindexedKey {
+	indexed := make(map[string]bool)
+	for _, index := range res.Indexes {
+		if len(index.Columns) > 0 {
+			indexed[index.Columns[0]] = true
+		}
+	}
+	for _, field := range res.Fields {
+		for _, constraint := range field.Constraints {
+			if constraint == "@unique" || constraint == "@primary" {
+				column := field.Column
+				if column == "" {
+					column = field.Name
+				}
+				indexed[column] = true
+			}
+		}
+	}
+
+	var keys []UnindexedKey
+	for _, rel := range res.Relationships {
+		column := rel.ForeignKey
+		if column == "" {
+			column = rel.Name + "_id"
+		}
+		if rel.Type != "belongs_to" || indexed[column] {
+			continue
+		}
+		keys = append(keys, UnindexedKey{
+			Resource:     res.Name,
+			Relationship: rel.Name,
+			Parent:       rel.TargetResource,
+			Column:       column,
+		})
+	}
+	return keys
+}
+
 func outputReportJSON(report Report) {
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
@@ -480,6 +528,21 @@ func outputReportTable(report Report, config Config) {
 			fmt.Println()
 		}
 	}
+
+	// Indexes
+	if config.Indexes || config.Report {
+		fmt.Println("=== FOREIGN KEY INDEXES ===")
+		if len(report.UnindexedForeignKeys) == 0 {
+			fmt.Println("✓ Every foreign key is indexed")
+		} else {
+			fmt.Printf("⚠️  Found %d unindexed foreign keys:\n", len(report.UnindexedForeignKeys))
+			for _, key := range report.UnindexedForeignKeys {
+				fmt.Printf("  %s.%s (belongs_to %s)\n", key.Resource, key.Column, key.Parent)
+			}
+			fmt.Println("  Add @index to the field, or an index block that starts with it")
+		}
+		fmt.Println()
+	}
 }
 
 func getImpactDescription(edge metadata.DependencyEdge, res *metadata.ResourceMetadata) string {
@@ -0,0 +1,105 @@
+// Package analytics reports anonymized CLI usage to a self-hosted endpoint.
+//
+// Reporting is opt-in: nothing is sent unless a project configures
+// analytics.endpoint in conduit.yaml, and DO_NOT_TRACK always disables it.
+// Events never include arguments, file paths, source code or error messages,
+// and the project is identified only by a hash of its name.
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds how long a report may delay the CLI
+const DefaultTimeout = 2 * time.Second
+
+// Event is a single anonymized command run
+type Event struct {
+	Command        string    `json:"command"`
+	Success        bool      `json:"success"`
+	DurationMs     int64     `json:"duration_ms"`
+	ConduitVersion string    `json:"conduit_version"`
+	OS             string    `json:"os"`
+	Arch           string    `json:"arch"`
+	Project        string    `json:"project,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// NewEvent builds an event for a finished command
+func NewEvent(command, projectName, version string, success bool, duration time.Duration) Event {
+	return Event{
+		Command:        command,
+		Success:        success,
+		DurationMs:     duration.Milliseconds(),
+		ConduitVersion: version,
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+		Project:        ProjectID(projectName),
+		Timestamp:      time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// ProjectID returns a stable, anonymized identifier for a project name so
+// platform teams can count projects without learning their names
+func ProjectID(projectName string) string {
+	if projectName == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte("conduit:" + projectName))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Disabled reports whether the user opted out with DO_NOT_TRACK
+func Disabled() bool {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv("DO_NOT_TRACK")))
+	return value != "" && value != "0" && value != "false"
+}
+
+// Client posts events to a self-hosted endpoint
+type Client struct {
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client for endpoint with DefaultTimeout
+func NewClient(endpoint string) *Client {
+	return &Client{
+		Endpoint:   endpoint,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// Report sends event as JSON. Any non-2xx response is an error.
+func (c *Client) Report(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode analytics event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid analytics endpoint: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send analytics event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("analytics endpoint returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientReport(t *testing.T) {
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode event: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	event := NewEvent("build", "blog", "v0.5.0", true, 1500*time.Millisecond)
+	if err := NewClient(server.URL).Report(context.Background(), event); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+
+	if received.Command != "build" || !received.Success || received.DurationMs != 1500 {
+		t.Errorf("received = %+v", received)
+	}
+	if received.ConduitVersion != "v0.5.0" || received.OS == "" || received.Arch == "" {
+		t.Errorf("received = %+v", received)
+	}
+	if received.Project != ProjectID("blog") || strings.Contains(received.Project, "blog") {
+		t.Errorf("project = %q, want anonymized id", received.Project)
+	}
+}
+
+func TestClientReport_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := NewClient(server.URL).Report(context.Background(), Event{Command: "build"})
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Report() error = %v, want 500 status error", err)
+	}
+}
+
+func TestProjectID(t *testing.T) {
+	if ProjectID("") != "" {
+		t.Error("empty project name should have no id")
+	}
+	if ProjectID("blog") != ProjectID("blog") {
+		t.Error("project id should be stable")
+	}
+	if ProjectID("blog") == ProjectID("shop") {
+		t.Error("different projects should have different ids")
+	}
+	if len(ProjectID("blog")) != 16 {
+		t.Errorf("project id length = %d, want 16", len(ProjectID("blog")))
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"", false},
+		{"0", false},
+		{"false", false},
+		{"1", true},
+		{"true", true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("DO_NOT_TRACK", tt.value)
+		if got := Disabled(); got != tt.want {
+			t.Errorf("Disabled() with DO_NOT_TRACK=%q = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
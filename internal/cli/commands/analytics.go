@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/conduit-lang/conduit/internal/cli/analytics"
+	"github.com/conduit-lang/conduit/internal/cli/config"
+)
+
+// analyticsCommand returns the name reported for cmd, or "" when the command
+// is not tracked. Only build and introspection commands are reported.
+func analyticsCommand(cmd *cobra.Command) string {
+	if cmd == nil {
+		return ""
+	}
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	if path == "build" || strings.HasPrefix(path, "introspect ") {
+		return path
+	}
+	return ""
+}
+
+// reportUsage sends an anonymized event for a finished command when the
+// project configures analytics.endpoint. Failures are ignored so analytics
+// never affects the command's outcome.
+func reportUsage(cmd *cobra.Command, cmdErr error, duration time.Duration) {
+	name := analyticsCommand(cmd)
+	if name == "" || analytics.Disabled() || !config.InProject() {
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil || cfg.Analytics.Endpoint == "" {
+		return
+	}
+
+	event := analytics.NewEvent(name, cfg.ProjectName, Version, cmdErr == nil, duration)
+	ctx, cancel := context.WithTimeout(context.Background(), analytics.DefaultTimeout)
+	defer cancel()
+	_ = analytics.NewClient(cfg.Analytics.Endpoint).Report(ctx, event)
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/cli/analytics"
+)
+
+func TestAnalyticsCommand(t *testing.T) {
+	root := NewRootCommand()
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"build"}, "build"},
+		{[]string{"introspect", "resources"}, "introspect resources"},
+		{[]string{"migrate", "up"}, ""},
+		{[]string{"lint"}, ""},
+	}
+
+	for _, tt := range tests {
+		cmd, _, err := root.Find(tt.args)
+		if err != nil {
+			t.Fatalf("Find(%v) error = %v", tt.args, err)
+		}
+		if got := analyticsCommand(cmd); got != tt.want {
+			t.Errorf("analyticsCommand(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestReportUsage(t *testing.T) {
+	events := make(chan analytics.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event analytics.Event
+		json.NewDecoder(r.Body).Decode(&event)
+		events <- event
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	os.MkdirAll("app", 0755)
+	os.WriteFile("conduit.yaml", []byte("project_name: blog\nanalytics:\n  endpoint: "+server.URL+"\n"), 0644)
+	t.Setenv("DO_NOT_TRACK", "")
+
+	buildCmd, _, _ := NewRootCommand().Find([]string{"build"})
+	reportUsage(buildCmd, errors.New("compilation failed"), 250*time.Millisecond)
+
+	select {
+	case event := <-events:
+		if event.Command != "build" || event.Success || event.DurationMs != 250 {
+			t.Errorf("event = %+v", event)
+		}
+		if event.Project != analytics.ProjectID("blog") {
+			t.Errorf("project = %q, want anonymized id", event.Project)
+		}
+	default:
+		t.Fatal("expected an analytics event")
+	}
+
+	// Untracked commands and DO_NOT_TRACK send nothing
+	lintCmd, _, _ := NewRootCommand().Find([]string{"lint"})
+	reportUsage(lintCmd, nil, time.Second)
+	t.Setenv("DO_NOT_TRACK", "1")
+	reportUsage(buildCmd, nil, time.Second)
+
+	select {
+	case event := <-events:
+		t.Errorf("unexpected event %+v", event)
+	default:
+	}
+}
@@ -17,15 +17,23 @@ import (
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
 	"github.com/conduit-lang/conduit/internal/compiler/codegen"
 	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+	"github.com/conduit-lang/conduit/internal/compiler/metadata"
+	"github.com/conduit-lang/conduit/internal/compiler/owners"
 	"github.com/conduit-lang/conduit/internal/compiler/parser"
 	"github.com/conduit-lang/conduit/internal/compiler/typechecker"
 	"github.com/conduit-lang/conduit/internal/utils"
+	"github.com/conduit-lang/conduit/pkg/web/mail"
+	"github.com/conduit-lang/conduit/pkg/web/query"
+	"github.com/conduit-lang/conduit/pkg/web/serverless"
 )
 
 var (
 	buildJSON    bool
 	buildVerbose bool
 	buildOutput  string
+
+	buildStripSource bool
+	buildTarget      string
 )
 
 // NewBuildCommand creates the build command
@@ -54,13 +62,21 @@ The build process:
   conduit build --output dist/myapp
 
   # Build with verbose output and custom location
-  conduit build -v -o bin/production`,
+  conduit build -v -o bin/production
+
+  # Replace hook bodies in the embedded metadata with their hashes
+  conduit build --strip-source
+
+  # Build an AWS Lambda custom runtime (build/bootstrap, linux/amd64)
+  conduit build --target lambda`,
 		RunE: runBuild,
 	}
 
 	cmd.Flags().BoolVar(&buildJSON, "json", false, "Output errors in JSON format")
 	cmd.Flags().BoolVarP(&buildVerbose, "verbose", "v", false, "Show detailed build output")
 	cmd.Flags().StringVarP(&buildOutput, "output", "o", "", "Output binary path (default: build/app)")
+	cmd.Flags().BoolVar(&buildStripSource, "strip-source", false, "Replace hook bodies in the metadata with their hashes")
+	cmd.Flags().StringVar(&buildTarget, "target", "", "Where the app runs: server, lambda or cloudrun (default: build.target)")
 
 	return cmd
 }
@@ -81,6 +97,12 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Determine build target
+	target, err := resolveBuildTarget(buildTarget, cfg)
+	if err != nil {
+		return err
+	}
+
 	// Determine output path
 	outputPath := buildOutput
 	if outputPath == "" {
@@ -89,6 +111,10 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		} else {
 			outputPath = "build/app"
 		}
+		// Lambda runs the executable named bootstrap
+		if target == serverless.TargetLambda && outputPath == "build/app" {
+			outputPath = "build/bootstrap"
+		}
 	}
 
 	// Determine generated directory
@@ -131,6 +157,7 @@ Then run: conduit build`)
 
 	// Combine all resources from all files
 	allResources := make([]*ast.ResourceNode, 0)
+	resourceFiles := make(map[string]string)
 	var allErrors []errors.CompilerError
 
 	for _, file := range cdtFiles {
@@ -187,6 +214,9 @@ Then run: conduit build`)
 		}
 
 		// Add resources to combined list
+		for _, resource := range program.Resources {
+			resourceFiles[resource.Name] = file
+		}
 		allResources = append(allResources, program.Resources...)
 	}
 
@@ -200,13 +230,30 @@ Then run: conduit build`)
 		return fmt.Errorf("compilation failed")
 	}
 
-	// Create combined program
+	// Create combined program, with the timestamps of timestamps: true and
+	// @timestamps and the deleted_at of @soft_delete added before anything
+	// relies on them, such as a @default_scope reading self.deleted_at
+	timestamps := cfg != nil && cfg.Timestamps
 	program := &ast.Program{
-		Resources: allResources,
+		Resources: ast.WithSoftDeletes(ast.WithTimestamps(allResources, timestamps)),
+	}
+
+	// Mail templates are validated here so Mail.send can only name existing ones
+	mailFiles, mailTemplates, err := loadMailTemplates(cfg)
+	if err != nil {
+		return err
 	}
 
 	// Type check
 	tc := typechecker.NewTypeChecker()
+	tc.SetMailTemplates(mailTemplates.Names())
+	tc.SetTimestamps(timestamps)
+	if cfg != nil {
+		tc.SetNotifyChannels(notifyChannelNames(cfg))
+		if len(cfg.Auth.Providers) > 0 {
+			tc.SetLoginResource(cfg.Auth.Resource)
+		}
+	}
 	typeErrors := tc.CheckProgram(program)
 
 	if len(typeErrors) > 0 {
@@ -233,6 +280,13 @@ Then run: conduit build`)
 		return fmt.Errorf("type checking failed")
 	}
 
+	// Resources without @owner are owned by their file's CODEOWNERS owner
+	codeOwners, err := owners.Load(".")
+	if err != nil {
+		return err
+	}
+	program.Resources = owners.WithOwners(program.Resources, resourceFiles, codeOwners)
+
 	// Generate Go code
 	if buildVerbose {
 		infoColor.Println("Generating Go code...")
@@ -315,11 +369,139 @@ Or run conduit build from the conduit source directory.`)
 	}
 
 	gen := codegen.NewGenerator()
+
+	// Preflight is on unless disabled with database.preflight: false
+	if cfg == nil || cfg.Database.Preflight {
+		version, err := latestMigrationVersion("migrations")
+		if err != nil {
+			return fmt.Errorf("failed to read migrations: %w", err)
+		}
+		gen.SetPreflight(codegen.PreflightOptions{Enabled: true, MigrationVersion: version})
+	}
+
+	// The admin UI is opt-in with admin.enabled: true
+	gen.SetAdmin(cfg != nil && cfg.Admin.Enabled)
+
+	// So are the metadata routes, with introspection.enabled: true
+	if cfg != nil && cfg.Introspection.Enabled {
+		gen.SetIntrospection(codegen.IntrospectionOptions{
+			Enabled:  true,
+			Auth:     cfg.Introspection.Auth,
+			Exposure: cfg.Introspection.Sections,
+		})
+	}
+
+	// The API playground is on unless disabled with playground.enabled: false;
+	// the generated app still hides it in production unless playground.production is set
+	if cfg == nil || cfg.Playground.Enabled {
+		spec, err := playgroundSpec(program, moduleName, apiPrefix, serializationOptions(cfg))
+		if err != nil {
+			return err
+		}
+		gen.SetPlayground(codegen.PlaygroundOptions{
+			Enabled:         true,
+			Spec:            spec,
+			AllowProduction: cfg != nil && cfg.Playground.Production,
+		})
+	}
+
+	// Mail.send is delivered once mail.provider is set; until then sends are logged
+	if cfg != nil && cfg.Mail.Provider != "" {
+		gen.SetMail(codegen.MailOptions{
+			Enabled:      true,
+			Provider:     cfg.Mail.Provider,
+			From:         cfg.Mail.From,
+			SMTPHost:     cfg.Mail.SMTP.Host,
+			SMTPPort:     cfg.Mail.SMTP.Port,
+			SMTPUsername: cfg.Mail.SMTP.Username,
+			SESRegion:    cfg.Mail.SES.Region,
+			Templates:    mailFiles,
+		})
+	}
+
+	// Notify.send is delivered once notify.channels are configured
+	if cfg != nil && len(cfg.Notify.Channels) > 0 {
+		gen.SetNotify(notifyOptions(cfg.Notify))
+	}
+
+	// Social login routes are generated once auth.providers are configured
+	if cfg != nil && len(cfg.Auth.Providers) > 0 {
+		gen.SetAuth(authOptions(cfg.Auth))
+	}
+
+	// Resource routes are metered once quota.client is configured
+	if cfg != nil && cfg.Quota.Client != "" {
+		gen.SetQuota(quotaOptions(cfg.Quota))
+	}
+
+	// Server timeouts follow the server section, with the runtime defaults otherwise
+	if cfg != nil {
+		gen.SetServer(codegen.ServerOptions{
+			ReadTimeout:     cfg.Server.ReadTimeout,
+			WriteTimeout:    cfg.Server.WriteTimeout,
+			IdleTimeout:     cfg.Server.IdleTimeout,
+			ShutdownTimeout: cfg.Server.ShutdownTimeout,
+		})
+	}
+
+	// Request records are JSON or text as the logging section says
+	if cfg != nil {
+		gen.SetLogging(codegen.LoggingOptions{Format: cfg.Logging.Format})
+	}
+
+	// Panics and hook errors are reported once error_tracking.enabled is set
+	if cfg != nil && cfg.ErrorTracking.Enabled {
+		gen.SetErrorTracking(codegen.ErrorTrackingOptions{
+			Enabled:     true,
+			Environment: cfg.ErrorTracking.Environment,
+			Release:     cfg.ErrorTracking.Release,
+			Files:       resourceFiles,
+		})
+	}
+
+	// The profiler and profiling agent follow the profiling section
+	if cfg != nil {
+		gen.SetProfiling(profilingOptions(cfg, moduleName))
+	}
+
+	// The entrypoint and database pool follow build.target or --target
+	gen.SetTarget(target)
+
+	// JSON casing, envelopes and nulls follow the serialization section
+	gen.SetSerialization(serializationOptions(cfg))
+
+	// The generated app reports the compiler and commit it was built from
+	gen.SetBuildInfo(codegen.BuildInfoOptions{CompilerVersion: Version, GitCommit: gitCommit()})
+
+	// Hook bodies, file paths and documentation follow introspection.redact;
+	// --strip-source hashes hook bodies whatever it says
+	redaction := redactionPolicy(cfg)
+	if buildStripSource || (cfg != nil && cfg.Build.StripSource) {
+		redaction.Source = metadata.RedactHashed
+	}
+	gen.SetRedaction(redaction)
+	stripSource := redaction.Source == metadata.RedactHashed || redaction.Source == metadata.RedactOmitted
+
 	files, err := gen.GenerateProgram(program, moduleName, conduitPath, apiPrefix)
 	if err != nil {
 		return fmt.Errorf("code generation failed: %w", err)
 	}
 
+	// Resources whose metadata outgrows the budget only warn
+	budget := 0
+	if cfg != nil {
+		budget = cfg.Build.MetadataBudget
+	}
+	warnings, err := metadataBudgetWarnings(files["introspection/metadata.json"], budget, stripSource)
+	if err != nil {
+		return err
+	}
+	if !buildJSON {
+		for _, warning := range warnings {
+			warningColor.Printf("Warning: %s\n", warning)
+		}
+	}
+
 	// Create build/generated directory
 	if err := os.MkdirAll(generatedDir, 0755); err != nil {
 		return fmt.Errorf("failed to create build directory: %w", err)
@@ -350,8 +532,15 @@ Or run conduit build from the conduit source directory.`)
 			return fmt.Errorf("failed to create introspection directory: %w", err)
 		}
 
+		// Write and rename, so tools watching the file never read it half
+		// written
 		metadataPath := filepath.Join(introspectionDir, "metadata.json")
-		if err := os.WriteFile(metadataPath, []byte(metadataContent), 0644); err != nil {
+		tmpPath := metadataPath + ".tmp"
+		if err := os.WriteFile(tmpPath, []byte(metadataContent), 0644); err != nil {
+			return fmt.Errorf("failed to write metadata for CLI: %w", err)
+		}
+		if err := os.Rename(tmpPath, metadataPath); err != nil {
+			os.Remove(tmpPath)
 			return fmt.Errorf("failed to write metadata for CLI: %w", err)
 		}
 
@@ -420,6 +609,9 @@ Or run conduit build from the conduit source directory.`)
 	// Run go build from the generated directory
 	buildCmd := exec.Command("go", "build", "-o", absOutputPath)
 	buildCmd.Dir = generatedDir
+	if target == serverless.TargetLambda {
+		buildCmd.Env = lambdaBuildEnv(os.Environ())
+	}
 	buildCmd.Stdout = os.Stdout
 	buildCmd.Stderr = os.Stderr
 
@@ -431,6 +623,9 @@ Or run conduit build from the conduit source directory.`)
 	fmt.Println()
 	successColor.Printf("✓ Build successful in %.2fs\n", elapsed.Seconds())
 	infoColor.Printf("  Binary: %s\n", outputPath)
+	if target != "" && target != serverless.TargetServer {
+		infoColor.Printf("  Target: %s\n", target)
+	}
 	if apiPrefix != "" {
 		infoColor.Printf("  API Prefix: %s\n", apiPrefix)
 	}
@@ -438,6 +633,65 @@ Or run conduit build from the conduit source directory.`)
 	return nil
 }
 
+// gitCommit returns the commit checked out in the working directory, with a
+// -dirty suffix when there are uncommitted changes, or "" outside a repository
+func gitCommit() string {
+	output, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	commit := strings.TrimSpace(string(output))
+	if status, err := exec.Command("git", "status", "--porcelain").Output(); err == nil && len(status) > 0 {
+		commit += "-dirty"
+	}
+	return commit
+}
+
+// redactionPolicy maps the introspection.redact section onto the metadata
+// redaction policy
+func redactionPolicy(cfg *config.Config) metadata.RedactionPolicy {
+	if cfg == nil {
+		return metadata.RedactionPolicy{}
+	}
+	return metadata.RedactionPolicy{
+		Source:        cfg.Introspection.Redact.Source,
+		FilePaths:     cfg.Introspection.Redact.FilePaths,
+		Documentation: cfg.Introspection.Redact.Documentation,
+	}
+}
+
+// metadataBudgetWarnings describes each resource whose compressed metadata
+// exceeds budget bytes, suggesting --strip-source when hook bodies could be
+// dropped
+func metadataBudgetWarnings(metadataJSON string, budget int, stripped bool) ([]string, error) {
+	if metadataJSON == "" {
+		return nil, nil
+	}
+	if budget <= 0 {
+		budget = metadata.DefaultResourceBudget
+	}
+
+	meta, err := metadata.FromJSON(metadataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read generated metadata: %w", err)
+	}
+	over, err := metadata.OverBudget(meta, budget)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	for _, size := range over {
+		warning := fmt.Sprintf("resource %s has %d bytes of compressed metadata, over the budget of %d",
+			size.Resource, size.Compressed, budget)
+		if size.HookSource > 0 && !stripped {
+			warning += fmt.Sprintf("; %d bytes are hook source, which --strip-source replaces with hashes", size.HookSource)
+		}
+		warnings = append(warnings, warning)
+	}
+	return warnings, nil
+}
+
 func outputErrorsJSON(errs []errors.CompilerError) {
 	output := struct {
 		Success bool                   `json:"success"`
@@ -466,3 +720,154 @@ func outputErrorsTerminal(errs []errors.CompilerError, errorColor *color.Color)
 	}
 	fmt.Fprintln(os.Stderr)
 }
+
+// loadMailTemplates reads and parses the mail template directory
+// (mail.templates, default app/mail). A missing directory has no templates.
+func loadMailTemplates(cfg *config.Config) (map[string]string, *mail.Templates, error) {
+	dir := mail.DefaultTemplateDir
+	if cfg != nil && cfg.Mail.Templates != "" {
+		dir = cfg.Mail.Templates
+	}
+
+	files, err := mail.ReadTemplateDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read mail templates: %w", err)
+	}
+	templates, err := mail.ParseTemplates(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, templates, nil
+}
+
+// notifyChannelNames returns the notification channels Notify.send may name
+func notifyChannelNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Notify.Channels))
+	for name := range cfg.Notify.Channels {
+		names = append(names, name)
+	}
+	return names
+}
+
+// notifyOptions converts the notify section of conduit.yaml for the generator
+func notifyOptions(cfg config.NotifyConfig) codegen.NotifyOptions {
+	opts := codegen.NotifyOptions{
+		Enabled:  true,
+		Queue:    cfg.Queue,
+		Workers:  cfg.Workers,
+		Channels: make(map[string]codegen.NotifyChannel, len(cfg.Channels)),
+	}
+	for name, ch := range cfg.Channels {
+		opts.Channels[name] = codegen.NotifyChannel{
+			Provider:  ch.Provider,
+			RateLimit: ch.RateLimit,
+			From:      ch.From,
+			ProjectID: ch.ProjectID,
+			Topic:     ch.Topic,
+			TeamID:    ch.TeamID,
+			KeyID:     ch.KeyID,
+			Sandbox:   ch.Sandbox,
+		}
+	}
+	return opts
+}
+
+// authOptions converts the auth section of conduit.yaml for the generator
+func authOptions(cfg config.AuthConfig) codegen.AuthOptions {
+	return codegen.AuthOptions{
+		Enabled:   true,
+		Resource:  cfg.Resource,
+		Providers: cfg.Providers,
+		Redirect:  cfg.Redirect,
+		BaseURL:   cfg.BaseURL,
+	}
+}
+
+// serializationOptions converts the serialization section of conduit.yaml for
+// the generator and the API documentation
+func serializationOptions(cfg *config.Config) codegen.SerializationOptions {
+	if cfg == nil {
+		return codegen.SerializationOptions{}
+	}
+	return codegen.SerializationOptions{
+		Casing:       query.Casing(cfg.Serialization.Casing),
+		Envelope:     cfg.Serialization.Envelope,
+		IncludeNulls: cfg.Serialization.Nulls == "include",
+	}
+}
+
+// profilingOptions converts the profiling section of conduit.yaml for the
+// generator. Profiles are named after the project unless the section names
+// them.
+func profilingOptions(cfg *config.Config, moduleName string) codegen.ProfilingOptions {
+	profiling := cfg.Profiling
+	application := profiling.Application
+	if application == "" {
+		application = cfg.ProjectName
+	}
+	if application == "" {
+		application = moduleName
+	}
+	return codegen.ProfilingOptions{
+		Pprof:         profiling.Pprof,
+		BlockRate:     profiling.BlockRate,
+		MutexFraction: profiling.MutexFraction,
+		Agent:         profiling.Agent,
+		ServerAddress: profiling.ServerAddress,
+		Application:   application,
+		Interval:      profiling.Interval,
+		Tags:          profiling.Tags,
+	}
+}
+
+// resolveBuildTarget returns the target of --target, or of build.target when
+// the flag is not set. Config targets are validated on load; the flag is
+// checked here, including against mtls introspection, which needs the
+// application to terminate TLS.
+func resolveBuildTarget(flag string, cfg *config.Config) (string, error) {
+	target := flag
+	if target == "" {
+		if cfg == nil {
+			return "", nil
+		}
+		return cfg.Build.Target, nil
+	}
+	switch target {
+	case serverless.TargetServer:
+	case serverless.TargetLambda, serverless.TargetCloudRun:
+		if cfg != nil && cfg.Introspection.Enabled && cfg.Introspection.Auth == "mtls" {
+			return "", fmt.Errorf("introspection.auth mtls is not supported by --target %s; use bearer", target)
+		}
+	default:
+		return "", fmt.Errorf("--target must be server, lambda or cloudrun, got: %s", target)
+	}
+	return target, nil
+}
+
+// lambdaBuildEnv cross-compiles for Lambda: a static linux binary, for
+// x86_64 functions unless GOARCH picks another architecture such as arm64
+func lambdaBuildEnv(environ []string) []string {
+	env := append([]string{}, environ...)
+	env = append(env, "GOOS=linux", "CGO_ENABLED=0")
+	for _, kv := range environ {
+		if strings.HasPrefix(kv, "GOARCH=") {
+			return env
+		}
+	}
+	return append(env, "GOARCH=amd64")
+}
+
+// quotaOptions converts the quota section of conduit.yaml for the generator
+func quotaOptions(cfg config.QuotaConfig) codegen.QuotaOptions {
+	opts := codegen.QuotaOptions{
+		Enabled: true,
+		Client:  cfg.Client,
+		Header:  cfg.Header,
+		Limits:  codegen.QuotaLimits{Monthly: cfg.Monthly, RateLimit: cfg.RateLimit},
+		Clients: make(map[string]codegen.QuotaLimits, len(cfg.Clients)),
+	}
+	for name, limits := range cfg.Clients {
+		opts.Clients[name] = codegen.QuotaLimits{Monthly: limits.Monthly, RateLimit: limits.RateLimit}
+	}
+	return opts
+}
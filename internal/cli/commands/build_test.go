@@ -2,9 +2,12 @@ package commands
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/conduit-lang/conduit/compiler/errors"
+	"github.com/conduit-lang/conduit/internal/cli/config"
+	"github.com/conduit-lang/conduit/internal/compiler/codegen"
 	"github.com/fatih/color"
 )
 
@@ -31,6 +34,14 @@ func TestNewBuildCommand(t *testing.T) {
 	if cmd.Flags().Lookup("output") == nil {
 		t.Error("expected --output flag to be registered")
 	}
+
+	if cmd.Flags().Lookup("strip-source") == nil {
+		t.Error("expected --strip-source flag to be registered")
+	}
+
+	if cmd.Flags().Lookup("target") == nil {
+		t.Error("expected --target flag to be registered")
+	}
 }
 
 func TestOutputErrorsJSON(t *testing.T) {
@@ -92,6 +103,178 @@ func TestRunBuild_NoSourceFiles(t *testing.T) {
 	}
 }
 
+func TestLoadMailTemplates(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	// No template directory: no templates, so any Mail.send template is undefined
+	_, templates, err := loadMailTemplates(nil)
+	if err != nil {
+		t.Fatalf("expected no error without app/mail, got: %v", err)
+	}
+	if names := templates.Names(); len(names) != 0 {
+		t.Errorf("expected no templates, got %v", names)
+	}
+
+	if err := os.MkdirAll("app/mail", 0755); err != nil {
+		t.Fatalf("failed to create app/mail: %v", err)
+	}
+	os.WriteFile("app/mail/welcome.txt", []byte(`{{define "subject"}}Welcome{{end}}Hi {{.name}}`), 0644)
+	os.WriteFile("app/mail/README.md", []byte("not a template"), 0644)
+
+	files, templates, err := loadMailTemplates(nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if names := templates.Names(); len(names) != 1 || names[0] != "welcome" {
+		t.Errorf("expected [welcome], got %v", names)
+	}
+	if _, ok := files["welcome.txt"]; !ok || len(files) != 1 {
+		t.Errorf("expected only welcome.txt to be embedded, got %v", files)
+	}
+
+	// Templates must define a subject
+	os.WriteFile("app/mail/reset.html", []byte(`<p>Reset</p>`), 0644)
+	if _, _, err := loadMailTemplates(nil); err == nil || !containsString(err.Error(), "reset does not define a subject") {
+		t.Errorf("expected missing subject error, got: %v", err)
+	}
+}
+
+func TestNotifyOptions(t *testing.T) {
+	opts := notifyOptions(config.NotifyConfig{
+		Workers: 3,
+		Channels: map[string]config.NotifyChannelConfig{
+			"sms": {Provider: "twilio", From: "+15550001234", RateLimit: 60},
+		},
+	})
+
+	if !opts.Enabled || opts.Workers != 3 {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	want := codegen.NotifyChannel{Provider: "twilio", From: "+15550001234", RateLimit: 60}
+	if opts.Channels["sms"] != want {
+		t.Errorf("expected sms channel %+v, got %+v", want, opts.Channels["sms"])
+	}
+}
+
+func TestAuthOptions(t *testing.T) {
+	opts := authOptions(config.AuthConfig{
+		Resource:  "User",
+		Providers: []string{"google", "github"},
+		Redirect:  "/",
+	})
+
+	if !opts.Enabled || opts.Resource != "User" || opts.Redirect != "/" || opts.BaseURL != "" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if len(opts.Providers) != 2 || opts.Providers[0] != "google" {
+		t.Errorf("expected google and github, got %v", opts.Providers)
+	}
+}
+
+func TestQuotaOptions(t *testing.T) {
+	opts := quotaOptions(config.QuotaConfig{
+		Client:    "api_key",
+		Monthly:   10000,
+		RateLimit: 60,
+		Clients:   map[string]config.QuotaLimitsConfig{"acme": {Monthly: 1000000}},
+	})
+
+	if !opts.Enabled || opts.Client != "api_key" || opts.Header != "" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if opts.Limits != (codegen.QuotaLimits{Monthly: 10000, RateLimit: 60}) {
+		t.Errorf("unexpected default limits: %+v", opts.Limits)
+	}
+	if opts.Clients["acme"] != (codegen.QuotaLimits{Monthly: 1000000}) {
+		t.Errorf("unexpected client limits: %+v", opts.Clients)
+	}
+}
+
+func TestProfilingOptions(t *testing.T) {
+	cfg := &config.Config{ProjectName: "blog", Profiling: config.ProfilingConfig{Pprof: true, Agent: "pyroscope"}}
+	opts := profilingOptions(cfg, "blog-dir")
+	if !opts.Pprof || opts.Agent != "pyroscope" || opts.Application != "blog" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	cfg.Profiling.Application = "blog-api"
+	if opts := profilingOptions(cfg, "blog-dir"); opts.Application != "blog-api" {
+		t.Errorf("Application = %q, want the configured name", opts.Application)
+	}
+
+	// Without a project name profiles are named after the module
+	if opts := profilingOptions(&config.Config{}, "blog-dir"); opts.Application != "blog-dir" {
+		t.Errorf("Application = %q, want blog-dir", opts.Application)
+	}
+}
+
+func TestResolveBuildTarget(t *testing.T) {
+	cfg := &config.Config{Build: config.BuildConfig{Target: "cloudrun"}}
+	if target, err := resolveBuildTarget("", cfg); err != nil || target != "cloudrun" {
+		t.Errorf("resolveBuildTarget() = %q, %v, want build.target", target, err)
+	}
+	if target, err := resolveBuildTarget("lambda", cfg); err != nil || target != "lambda" {
+		t.Errorf("resolveBuildTarget() = %q, %v, want --target to win", target, err)
+	}
+	if target, err := resolveBuildTarget("", nil); err != nil || target != "" {
+		t.Errorf("resolveBuildTarget() = %q, %v, want the server default", target, err)
+	}
+	if _, err := resolveBuildTarget("fargate", cfg); err == nil || !strings.Contains(err.Error(), "--target must be") {
+		t.Errorf("expected an unknown target error, got %v", err)
+	}
+
+	cfg.Introspection = config.IntrospectionConfig{Enabled: true, Auth: "mtls"}
+	if _, err := resolveBuildTarget("lambda", cfg); err == nil || !strings.Contains(err.Error(), "mtls") {
+		t.Errorf("expected mtls to be rejected behind Lambda, got %v", err)
+	}
+}
+
+func TestLambdaBuildEnv(t *testing.T) {
+	env := strings.Join(lambdaBuildEnv([]string{"HOME=/root"}), " ")
+	if env != "HOME=/root GOOS=linux CGO_ENABLED=0 GOARCH=amd64" {
+		t.Errorf("lambdaBuildEnv() = %q", env)
+	}
+	env = strings.Join(lambdaBuildEnv([]string{"GOARCH=arm64"}), " ")
+	if strings.Contains(env, "amd64") {
+		t.Errorf("lambdaBuildEnv() = %q, want GOARCH kept", env)
+	}
+}
+
+func TestMetadataBudgetWarnings(t *testing.T) {
+	metadataJSON := `{"version": "1.0.0", "resources": [
+		{"name": "Post", "hooks": [{"timing": "before", "event": "create", "source_code": "self.slug = String.slugify(self.title)"}]},
+		{"name": "User"}
+	]}`
+
+	warnings, err := metadataBudgetWarnings(metadataJSON, 0, false)
+	if err != nil || len(warnings) != 0 {
+		t.Errorf("expected no warnings under the default budget, got %v, %v", warnings, err)
+	}
+
+	warnings, err = metadataBudgetWarnings(metadataJSON, 16, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "resource Post") || !strings.Contains(warnings[0], "--strip-source") {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	if strings.Contains(warnings[1], "--strip-source") {
+		t.Errorf("expected no --strip-source hint without hook source: %s", warnings[1])
+	}
+
+	warnings, _ = metadataBudgetWarnings(metadataJSON, 16, true)
+	if strings.Contains(warnings[0], "--strip-source") {
+		t.Errorf("expected no --strip-source hint once stripped: %s", warnings[0])
+	}
+
+	if _, err := metadataBudgetWarnings("not json", 0, false); err == nil {
+		t.Error("expected an error for invalid metadata")
+	}
+}
+
 func TestOutputErrorsTerminal(t *testing.T) {
 	errs := []errors.CompilerError{
 		{
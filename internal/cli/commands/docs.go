@@ -10,8 +10,11 @@ import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
+	"github.com/conduit-lang/conduit/internal/cli/config"
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/codegen"
 	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+	"github.com/conduit-lang/conduit/internal/compiler/owners"
 	"github.com/conduit-lang/conduit/internal/compiler/parser"
 	"github.com/conduit-lang/conduit/internal/docs"
 	"github.com/conduit-lang/conduit/internal/utils"
@@ -148,6 +151,7 @@ func runDocsGenerate(cmd *cobra.Command, args []string) error {
 		OutputDir:          docsOutput,
 		Formats:            formats,
 		BaseURL:            docsBaseURL,
+		Serialization:      projectSerialization(),
 	}
 
 	generator, err := docs.NewGenerator(config)
@@ -222,6 +226,7 @@ func runDocsServe(cmd *cobra.Command, args []string) error {
 				OutputDir:          docsOutput,
 				Formats:            []docs.Format{docs.FormatHTML},
 				BaseURL:            docsBaseURL,
+				Serialization:      projectSerialization(),
 			}
 
 			watchAndRegenerate(program, config)
@@ -233,6 +238,13 @@ func runDocsServe(cmd *cobra.Command, args []string) error {
 
 // Helper functions
 
+// projectSerialization returns the serialization settings of conduit.yaml so
+// the documented schemas match the generated API
+func projectSerialization() codegen.SerializationOptions {
+	cfg, _ := config.Load()
+	return serializationOptions(cfg)
+}
+
 func parseFormats(formatStr string) []docs.Format {
 	formats := make([]docs.Format, 0)
 	parts := splitAndTrim(formatStr, ",")
@@ -272,6 +284,7 @@ func parseConduitSources() (*ast.Program, error) {
 	program := &ast.Program{
 		Resources: make([]*ast.ResourceNode, 0),
 	}
+	resourceFiles := make(map[string]string)
 
 	for _, file := range files {
 		content, err := os.ReadFile(file)
@@ -298,9 +311,19 @@ func parseConduitSources() (*ast.Program, error) {
 		}
 
 		// Merge resources
+		for _, resource := range fileProgram.Resources {
+			resourceFiles[resource.Name] = file
+		}
 		program.Resources = append(program.Resources, fileProgram.Resources...)
 	}
 
+	// Resources without @owner are owned by their file's CODEOWNERS owner
+	codeOwners, err := owners.Load(".")
+	if err != nil {
+		return nil, err
+	}
+	program.Resources = owners.WithOwners(program.Resources, resourceFiles, codeOwners)
+
 	return program, nil
 }
 
@@ -400,3 +423,25 @@ func trimString(s string) string {
 
 	return s[start:end]
 }
+
+// playgroundSpec returns the OpenAPI specification embedded for the API
+// playground. The server URL is relative so "Try it out" calls the running
+// application whatever host and port it is served from.
+func playgroundSpec(program *ast.Program, projectName, apiPrefix string, serialization codegen.SerializationOptions) (string, error) {
+	serverURL := apiPrefix
+	if serverURL == "" {
+		serverURL = "/"
+	}
+
+	extractor := docs.NewExtractor()
+	extractor.SetSerialization(serialization)
+	doc := extractor.Extract(program, projectName, "1.0.0", "")
+	spec, err := docs.NewOpenAPIGenerator(&docs.Config{
+		ServerURLs:    []docs.ServerURL{{URL: serverURL, Description: "This server"}},
+		Serialization: serialization,
+	}).Spec(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate OpenAPI spec for the API playground: %w", err)
+	}
+	return string(spec), nil
+}
@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareGoVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.23", "1.23", 0},
+		{"1.23.1", "1.23", 1},
+		{"1.22.9", "1.23", -1},
+		{"1.27.1", "1.23", 1},
+		{"1.23rc1", "1.23", 0},
+		{"2.0", "1.23", 1},
+	}
+
+	for _, tt := range tests {
+		if got := compareGoVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareGoVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCheckGoToolchain(t *testing.T) {
+	original := goVersion
+	defer func() { goVersion = original }()
+
+	tests := []struct {
+		name       string
+		version    string
+		err        error
+		wantStatus string
+	}{
+		{"supported", "go1.23.4", nil, doctorOK},
+		{"too old", "go1.21.0", nil, doctorFail},
+		{"missing", "", errors.New("not found"), doctorFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goVersion = func() (string, error) { return tt.version, tt.err }
+			check := checkGoToolchain()
+			if check.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s (%s)", check.Status, tt.wantStatus, check.Message)
+			}
+			if check.Status == doctorFail && check.Fix == "" {
+				t.Error("failed check should suggest a fix")
+			}
+		})
+	}
+}
+
+func TestCheckConfigEnvVars(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "conduit.yml")
+	content := `database:
+  url: ${DOCTOR_TEST_DATABASE_URL}
+secrets:
+  api: ${DOCTOR_TEST_API_KEY}
+  again: ${DOCTOR_TEST_API_KEY}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCTOR_TEST_DATABASE_URL", "postgres://localhost/test")
+	os.Unsetenv("DOCTOR_TEST_API_KEY")
+
+	checks := checkConfigEnvVars(path)
+	if len(checks) != 2 {
+		t.Fatalf("expected 2 checks, got %d: %+v", len(checks), checks)
+	}
+	if checks[0].Name != "Environment DOCTOR_TEST_DATABASE_URL" || checks[0].Status != doctorOK {
+		t.Errorf("unexpected check: %+v", checks[0])
+	}
+	if checks[1].Name != "Environment DOCTOR_TEST_API_KEY" || checks[1].Status != doctorFail {
+		t.Errorf("unexpected check: %+v", checks[1])
+	}
+	if !strings.Contains(checks[1].Fix, "export DOCTOR_TEST_API_KEY=") {
+		t.Errorf("fix = %q", checks[1].Fix)
+	}
+}
+
+func TestCheckDatabase_UnsetURL(t *testing.T) {
+	for _, url := range []string{"", "${DATABASE_URL}"} {
+		db, check := checkDatabase(url)
+		if db != nil {
+			t.Errorf("checkDatabase(%q) returned a connection", url)
+		}
+		if check.Status != doctorFail || !strings.Contains(check.Fix, "DATABASE_URL") {
+			t.Errorf("checkDatabase(%q) = %+v", url, check)
+		}
+	}
+}
+
+func TestCheckBuildArtifacts(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	if err := os.MkdirAll("app", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll("build", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("app", "post.cdt"), []byte("resource Post {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("build", "app"), []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Make the binary older than the source
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join("build", "app"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := checkBuildArtifacts(filepath.Join("build", "app"), filepath.Join("build", "metadata.json"))
+	if len(checks) != 2 {
+		t.Fatalf("expected 2 checks, got %d", len(checks))
+	}
+	if checks[0].Status != doctorWarn || !strings.Contains(checks[0].Message, "older than app/post.cdt") {
+		t.Errorf("binary check = %+v", checks[0])
+	}
+	if checks[1].Status != doctorWarn || !strings.Contains(checks[1].Message, "does not exist") {
+		t.Errorf("metadata check = %+v", checks[1])
+	}
+
+	// Rebuilt binary is up to date
+	now := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join("build", "app"), now, now); err != nil {
+		t.Fatal(err)
+	}
+	checks = checkBuildArtifacts(filepath.Join("build", "app"), filepath.Join("build", "metadata.json"))
+	if checks[0].Status != doctorOK {
+		t.Errorf("binary check = %+v", checks[0])
+	}
+}
+
+func TestCheckSchemaDrift_NoDatabase(t *testing.T) {
+	check := checkSchemaDrift(nil)
+	if check.Status != doctorWarn {
+		t.Errorf("status = %s, want %s", check.Status, doctorWarn)
+	}
+}
+
+func TestExpectedSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	if err := os.MkdirAll("app", 0755); err != nil {
+		t.Fatal(err)
+	}
+	source := `resource Post {
+  title: string!
+  body: text! @column("legacy_body")
+}
+`
+	if err := os.WriteFile(filepath.Join("app", "post.cdt"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll("migrations", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("migrations", "20240101120000_init.up.sql"), []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := expectedSchema()
+	if err != nil {
+		t.Fatalf("expectedSchema() error = %v", err)
+	}
+	if schema.MigrationVersion != 20240101120000 {
+		t.Errorf("MigrationVersion = %d", schema.MigrationVersion)
+	}
+	if len(schema.Tables) != 1 || schema.Tables[0].Name != "posts" {
+		t.Fatalf("Tables = %+v", schema.Tables)
+	}
+	if got := strings.Join(schema.Tables[0].Columns, ","); got != "id,title,legacy_body" {
+		t.Errorf("Columns = %s", got)
+	}
+}
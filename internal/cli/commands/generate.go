@@ -18,12 +18,13 @@ func NewGenerateCommand() *cobra.Command {
 		Use:     "generate",
 		Aliases: []string{"g"},
 		Short:   "Code generation commands",
-		Long: `Generate boilerplate code for resources, controllers, and migrations.
+		Long: `Generate boilerplate code for resources, controllers, migrations, and integrations.
 
 Available generators:
-  resource   - Generate a new resource definition
-  controller - Generate a controller (stub)
-  migration  - Generate a database migration`,
+  resource    - Generate a new resource definition
+  controller  - Generate a controller (stub)
+  migration   - Generate a database migration
+  integration - Generate a payment provider integration (stripe)`,
 		Example: `  # Generate a new resource
   conduit generate resource User
 
@@ -33,6 +34,9 @@ Available generators:
   # Generate a database migration
   conduit generate migration create_users
 
+  # Scaffold Stripe webhooks and payments
+  conduit generate integration stripe
+
   # Use the short alias
   conduit g resource Comment`,
 	}
@@ -40,6 +44,7 @@ Available generators:
 	cmd.AddCommand(newGenerateResourceCommand())
 	cmd.AddCommand(newGenerateControllerCommand())
 	cmd.AddCommand(newGenerateMigrationCommand())
+	cmd.AddCommand(newGenerateIntegrationCommand())
 
 	return cmd
 }
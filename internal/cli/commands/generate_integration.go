@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// integrationFile is a resource written by an integration scaffold
+type integrationFile struct {
+	name    string // File name in app/
+	content string
+}
+
+// integrations are the scaffolds available to `conduit generate integration`,
+// by provider
+var integrations = map[string][]integrationFile{
+	"stripe": {
+		{name: "stripe_event.cdt", content: stripeEventTemplate},
+		{name: "payment.cdt", content: paymentTemplate},
+	},
+}
+
+const stripeEventTemplate = `/// Stripe webhook events, recorded once per Stripe event ID.
+/// Stripe delivers signed events to POST /webhooks/stripe. Deliveries are
+/// verified with STRIPE_WEBHOOK_SECRET, and an event that is redelivered after
+/// it was recorded is acknowledged without running the hooks below again.
+resource StripeEvent {
+  id: uuid! @primary @auto
+  event_id: string! @unique
+  event_type: string!
+  payload: json!
+  created_at: timestamp! @auto
+
+  @operations [list, get]
+  @webhook(stripe)
+
+  // Event processing runs once per event, in the transaction that records
+  // it. An error rolls the event back and Stripe redelivers it later.
+  @after create {
+    if self.event_type == "payment_intent.succeeded" {
+      // Mark the Payment with this payment intent succeeded
+    } elsif self.event_type == "payment_intent.payment_failed" {
+      // Mark the Payment with this payment intent failed
+    } elsif self.event_type == "charge.refunded" {
+      // Mark the Payment with this payment intent refunded
+    }
+  }
+}
+`
+
+const paymentTemplate = `/// A payment collected through Stripe, keyed by its PaymentIntent so every
+/// event about the same payment updates one record.
+resource Payment {
+  id: uuid! @primary @auto
+  stripe_payment_intent_id: string! @unique
+  amount: int! @min(0)
+  currency: string! @default("usd")
+  status: string! @default("pending") // pending, succeeded, failed or refunded
+  description: string?
+  created_at: timestamp! @auto
+  updated_at: timestamp! @auto_update
+}
+`
+
+func newGenerateIntegrationCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "integration [provider]",
+		Short: "Generate a payment provider integration",
+		Long: `Generate the resources that integrate a payment provider into the app/
+directory. Supported providers: ` + strings.Join(integrationProviders(), ", ") + `.
+
+The stripe integration writes:
+  app/stripe_event.cdt - Events received at POST /webhooks/stripe (@webhook),
+                         verified against STRIPE_WEBHOOK_SECRET and processed
+                         once per event ID by the resource's create hooks
+  app/payment.cdt      - A Payment resource keyed by the Stripe PaymentIntent
+
+Existing files are never overwritten.
+
+Examples:
+  conduit generate integration stripe`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			successColor := color.New(color.FgGreen, color.Bold)
+			infoColor := color.New(color.FgCyan)
+
+			provider := strings.ToLower(args[0])
+
+			// Check if app directory exists
+			if _, err := os.Stat("app"); os.IsNotExist(err) {
+				return fmt.Errorf("app/ directory not found - are you in a Conduit project?")
+			}
+
+			written, err := writeIntegration("app", provider)
+			if err != nil {
+				return err
+			}
+
+			for _, filename := range written {
+				successColor.Printf("✓ Created %s\n", filename)
+			}
+			infoColor.Println("\nNext steps:")
+			fmt.Println("  1. Set STRIPE_WEBHOOK_SECRET to the endpoint's signing secret (whsec_...)")
+			fmt.Println("  2. Add your event processing to the StripeEvent @after create hook")
+			fmt.Println("  3. Run 'conduit build' to compile")
+			fmt.Println("  4. Point a Stripe webhook endpoint at https://<your-host>/webhooks/stripe")
+			fmt.Println()
+
+			return nil
+		},
+	}
+}
+
+// writeIntegration writes the resources of provider's integration into dir
+// and returns their paths. Nothing is written if any of them already exists.
+func writeIntegration(dir, provider string) ([]string, error) {
+	files, ok := integrations[provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown integration %q (available: %s)", provider, strings.Join(integrationProviders(), ", "))
+	}
+
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = filepath.Join(dir, file.name)
+		if _, err := os.Stat(paths[i]); err == nil {
+			return nil, fmt.Errorf("file %s already exists", paths[i])
+		}
+	}
+
+	for i, file := range files {
+		if err := os.WriteFile(paths[i], []byte(file.content), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write file: %w", err)
+		}
+	}
+	return paths, nil
+}
+
+// integrationProviders returns the providers with an integration scaffold
+func integrationProviders() []string {
+	providers := make([]string, 0, len(integrations))
+	for provider := range integrations {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	return providers
+}
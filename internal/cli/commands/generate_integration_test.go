@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/codegen"
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+	"github.com/conduit-lang/conduit/internal/compiler/parser"
+	"github.com/conduit-lang/conduit/internal/compiler/typechecker"
+)
+
+func TestWriteIntegration_Stripe(t *testing.T) {
+	dir := t.TempDir()
+
+	written, err := writeIntegration(dir, "stripe")
+	if err != nil {
+		t.Fatalf("writeIntegration() error = %v", err)
+	}
+	want := []string{filepath.Join(dir, "stripe_event.cdt"), filepath.Join(dir, "payment.cdt")}
+	if strings.Join(written, ",") != strings.Join(want, ",") {
+		t.Errorf("writeIntegration() = %v, want %v", written, want)
+	}
+
+	// The scaffold compiles as written
+	program := &ast.Program{}
+	for _, path := range written {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens, lexErrors := lexer.New(string(source)).ScanTokens()
+		if len(lexErrors) > 0 {
+			t.Fatalf("%s: lexer errors: %v", path, lexErrors)
+		}
+		parsed, parseErrors := parser.New(tokens).Parse()
+		if len(parseErrors) > 0 {
+			t.Fatalf("%s: parse errors: %v", path, parseErrors)
+		}
+		program.Resources = append(program.Resources, parsed.Resources...)
+	}
+	if typeErrors := typechecker.NewTypeChecker().CheckProgram(program); len(typeErrors) > 0 {
+		t.Fatalf("type errors: %v", typeErrors)
+	}
+
+	event := program.Resources[0]
+	if event.Webhook == nil || event.Webhook.Provider != "stripe" {
+		t.Fatalf("StripeEvent webhook = %+v, want stripe", event.Webhook)
+	}
+
+	for _, resource := range program.Resources {
+		model, err := codegen.NewGenerator().GenerateResourceWithHooks(resource)
+		if err != nil {
+			t.Fatalf("GenerateResourceWithHooks(%s) error = %v", resource.Name, err)
+		}
+		if _, err := format.Source([]byte(model)); err != nil {
+			t.Fatalf("Generated %s model does not parse: %v", resource.Name, err)
+		}
+	}
+
+	handlers, err := codegen.NewGenerator().GenerateHandlers(program.Resources, "example.com/shop")
+	if err != nil {
+		t.Fatalf("GenerateHandlers() error = %v", err)
+	}
+	if _, err := format.Source([]byte(handlers)); err != nil {
+		t.Fatalf("Generated handlers do not parse: %v", err)
+	}
+	if !strings.Contains(handlers, `r.Post("/webhooks/stripe", WebhookStripeEventHandler(db))`) {
+		t.Error("Generated handlers should register the Stripe webhook route")
+	}
+}
+
+func TestWriteIntegration_NoOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "payment.cdt")
+	if err := os.WriteFile(existing, []byte("resource Payment {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := writeIntegration(dir, "stripe"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("writeIntegration() error = %v, want already exists", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stripe_event.cdt")); !os.IsNotExist(err) {
+		t.Error("No files should be written when one already exists")
+	}
+	if content, _ := os.ReadFile(existing); string(content) != "resource Payment {}\n" {
+		t.Error("Existing file was overwritten")
+	}
+}
+
+func TestWriteIntegration_UnknownProvider(t *testing.T) {
+	if _, err := writeIntegration(t.TempDir(), "paypal"); err == nil || !strings.Contains(err.Error(), "available: stripe") {
+		t.Fatalf("writeIntegration() error = %v, want unknown integration", err)
+	}
+}
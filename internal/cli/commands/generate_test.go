@@ -28,6 +28,7 @@ func TestNewGenerateCommand(t *testing.T) {
 		"resource",
 		"controller",
 		"migration",
+		"integration",
 	}
 
 	for _, expected := range expectedSubcommands {
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/tooling/dbimport"
+)
+
+func TestNewImportCommand(t *testing.T) {
+	cmd := NewImportCommand()
+
+	if cmd.Use != "import" {
+		t.Errorf("expected Use to be 'import', got %s", cmd.Use)
+	}
+
+	db, _, err := cmd.Find([]string{"db"})
+	if err != nil || db.Name() != "db" {
+		t.Fatal("expected subcommand db to be registered")
+	}
+
+	for _, flag := range []string{"dsn", "schema", "table", "external", "out", "force", "dry-run"} {
+		if db.Flags().Lookup(flag) == nil {
+			t.Errorf("expected flag --%s on db", flag)
+		}
+	}
+	if got := db.Flags().Lookup("schema").DefValue; got != "[public]" {
+		t.Errorf("expected --schema to default to public, got %s", got)
+	}
+
+	for _, name := range []string{"rails", "prisma"} {
+		sub, _, err := cmd.Find([]string{name})
+		if err != nil || sub.Name() != name {
+			t.Fatalf("expected subcommand %s to be registered", name)
+		}
+		for _, flag := range []string{"table", "external", "out", "force", "dry-run"} {
+			if sub.Flags().Lookup(flag) == nil {
+				t.Errorf("expected flag --%s on %s", flag, name)
+			}
+		}
+	}
+}
+
+func TestReadRailsModels(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "concerns"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"user.rb":               "class User < ApplicationRecord\nend\n",
+		"concerns/sluggable.rb": "module Sluggable\nend\n",
+		"README.md":             "not a model",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sources, err := readRailsModels(dir)
+	if err != nil || len(sources) != 2 {
+		t.Fatalf("expected the two Ruby files, got %d (%v)", len(sources), err)
+	}
+
+	sources, err = readRailsModels(filepath.Join(dir, "missing"))
+	if err != nil || len(sources) != 0 {
+		t.Errorf("expected no models for a missing directory, got %d (%v)", len(sources), err)
+	}
+}
+
+func TestSelectTables(t *testing.T) {
+	tables := []*dbimport.Table{
+		{Schema: "public", Name: "users"},
+		{Schema: "public", Name: "orders"},
+		{Schema: "billing", Name: "users"},
+	}
+
+	selected, err := selectTables(tables, nil)
+	if err != nil || len(selected) != 3 {
+		t.Fatalf("expected every table without names, got %d (%v)", len(selected), err)
+	}
+
+	selected, err = selectTables(tables, []string{"users"})
+	if err != nil || len(selected) != 2 {
+		t.Fatalf("expected users in both schemas, got %d (%v)", len(selected), err)
+	}
+
+	selected, err = selectTables(tables, []string{"billing.users", "orders"})
+	if err != nil || len(selected) != 2 || selected[0].Name != "orders" || selected[1].Schema != "billing" {
+		t.Fatalf("unexpected selection %+v (%v)", selected, err)
+	}
+
+	if _, err := selectTables(tables, []string{"invoices"}); err == nil {
+		t.Error("expected an error for a table that was not found")
+	}
+}
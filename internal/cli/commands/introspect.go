@@ -114,19 +114,38 @@ accurate, up-to-date information about your application's structure.`,
   # Discover common patterns
   conduit introspect patterns
 
+  # Search names, documentation, hooks and patterns
+  conduit introspect search slug
+
   # Output in JSON format for tooling
   conduit introspect resources --format json
 
+  # Export an OpenAPI 3.1 document
+  conduit introspect export --format openapi
+
+  # Serve the registry to AI agents over MCP
+  conduit introspect serve --mcp
+
+  # Compare two metadata files, failing on breaking changes
+  conduit introspect diff old.json new.json
+
   # Verbose output with all details
   conduit introspect resource Post --verbose`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// Disable color output if requested
-			if noColor {
+			if noColor || globalNoColor {
 				color.NoColor = true
 			}
 
-			// Skip metadata loading for stdlib command (doesn't need it)
-			if cmd.Name() == "stdlib" {
+			// cobra runs only this hook, not the root's, so the pin is
+			// enforced here too
+			if err := checkVersionPin(cmd); err != nil {
+				return err
+			}
+
+			// Skip metadata loading for stdlib command (doesn't need it) and
+			// diff, which reads the files it is given
+			if cmd.Name() == "stdlib" || cmd.Name() == "diff" {
 				return nil
 			}
 
@@ -152,13 +171,17 @@ accurate, up-to-date information about your application's structure.`,
 	cmd.AddCommand(newIntrospectDepsCommand())
 	cmd.AddCommand(newIntrospectPatternsCommand())
 	cmd.AddCommand(newIntrospectStdlibCommand())
+	cmd.AddCommand(newIntrospectExportCommand())
+	cmd.AddCommand(newIntrospectServeCommand())
+	cmd.AddCommand(newIntrospectDiffCommand())
+	cmd.AddCommand(newIntrospectSearchCommand())
 
 	return cmd
 }
 
 // newIntrospectResourcesCommand creates the 'introspect resources' command
 func newIntrospectResourcesCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "resources",
 		Short: "List all resources in the application",
 		Long: `List all resources in the application.
@@ -173,9 +196,16 @@ a specific resource.`,
   conduit introspect resources --format json
 
   # Show verbose output with all details
-  conduit introspect resources --verbose`,
+  conduit introspect resources --verbose
+
+  # List the resources a team owns
+  conduit introspect resources --owner payments-team`,
 		RunE: runIntrospectResourcesCommand,
 	}
+
+	cmd.Flags().String("owner", "", "Filter by owning team, from @owner or CODEOWNERS")
+
+	return cmd
 }
 
 // newIntrospectResourceCommand creates the 'introspect resource' command
@@ -295,7 +325,7 @@ func runIntrospectDepsCommand(cmd *cobra.Command, args []string) error {
 		// Map CLI type names to relationship types
 		switch typeFilter {
 		case "resource":
-			types = []string{"belongs_to", "has_many", "has_many_through"}
+			types = []string{"belongs_to", "has_one", "has_many", "has_many_through"}
 		case "middleware":
 			types = []string{"uses"}
 		case "function":
@@ -370,7 +400,7 @@ func getImpactDescription(edge metadata.DependencyEdge, graph *metadata.Dependen
 	}
 
 	// Handle relationship-based impacts
-	if edge.Relationship == "belongs_to" || edge.Relationship == "has_many" || edge.Relationship == "has_many_through" {
+	if edge.Relationship == "belongs_to" || edge.Relationship == "has_one" || edge.Relationship == "has_many" || edge.Relationship == "has_many_through" {
 		// Find the relationship metadata to get on_delete behavior
 		var relMeta *metadata.RelationshipMetadata
 		if sourceRes != nil {
@@ -584,6 +614,9 @@ patterns and conventions. This helps with:
   # Show patterns for a specific category
   conduit introspect patterns authentication
 
+  # Show copy-pasted fields and near-identical resources
+  conduit introspect patterns duplication
+
   # Filter by minimum frequency
   conduit introspect patterns --min-frequency 3
 
@@ -719,6 +752,10 @@ func runIntrospectResourcesCommand(cmd *cobra.Command, args []string) error {
 	// Get resources from the registry
 	resources := metadata.QueryResources()
 
+	// Apply the owner filter
+	ownerFilter, _ := cmd.Flags().GetString("owner")
+	resources = filterResourcesByOwner(resources, ownerFilter)
+
 	// Get the output writer
 	writer := cmd.OutOrStdout()
 
@@ -734,6 +771,24 @@ func runIntrospectResourcesCommand(cmd *cobra.Command, args []string) error {
 	}
 }
 
+// filterResourcesByOwner returns the resources owned by the given team
+// (case-insensitive, with or without a leading @); all of them when owner is
+// empty
+func filterResourcesByOwner(resources []metadata.ResourceMetadata, owner string) []metadata.ResourceMetadata {
+	owner = strings.TrimPrefix(owner, "@")
+	if owner == "" {
+		return resources
+	}
+
+	filtered := make([]metadata.ResourceMetadata, 0, len(resources))
+	for _, res := range resources {
+		if strings.EqualFold(res.Owner, owner) {
+			filtered = append(filtered, res)
+		}
+	}
+	return filtered
+}
+
 // ResourceCategory represents a category of resources
 type ResourceCategory struct {
 	Name      string
@@ -743,6 +798,8 @@ type ResourceCategory struct {
 // ResourceSummary contains summary information about a resource
 type ResourceSummary struct {
 	Name              string
+	Owner             string
+	Stability         string
 	FieldCount        int
 	RelationshipCount int
 	HookCount         int
@@ -758,6 +815,8 @@ func categorizeResources(resources []metadata.ResourceMetadata) []ResourceCatego
 	for _, res := range resources {
 		summary := ResourceSummary{
 			Name:              res.Name,
+			Owner:             res.Owner,
+			Stability:         res.Annotations().Stability,
 			FieldCount:        len(res.Fields),
 			RelationshipCount: len(res.Relationships),
 			HookCount:         len(res.Hooks),
@@ -880,6 +939,12 @@ func formatResourcesAsTable(resources []metadata.ResourceMetadata, writer io.Wri
 			// Verbose mode: show detailed information
 			for _, res := range category.Resources {
 				fmt.Fprintf(writer, "  %s\n", res.Name)
+				if res.Owner != "" {
+					fmt.Fprintf(writer, "    Owner: %s\n", res.Owner)
+				}
+				if res.Stability != "" {
+					fmt.Fprintf(writer, "    Stability: %s\n", res.Stability)
+				}
 				fmt.Fprintf(writer, "    Fields: %d\n", res.FieldCount)
 				fmt.Fprintf(writer, "    Relationships: %d\n", res.RelationshipCount)
 				fmt.Fprintf(writer, "    Hooks: %d\n", res.HookCount)
@@ -983,6 +1048,8 @@ func buildResourceSummaryOutput(resources []metadata.ResourceMetadata) interface
 	// Create summary data for structured output
 	type ResourceSummary struct {
 		Name              string              `json:"name" yaml:"name"`
+		Owner             string              `json:"owner,omitempty" yaml:"owner,omitempty"`
+		Stability         string              `json:"stability,omitempty" yaml:"stability,omitempty"`
 		FieldCount        int                 `json:"field_count" yaml:"field_count"`
 		RelationshipCount int                 `json:"relationship_count" yaml:"relationship_count"`
 		HookCount         int                 `json:"hook_count" yaml:"hook_count"`
@@ -1006,6 +1073,8 @@ func buildResourceSummaryOutput(resources []metadata.ResourceMetadata) interface
 	for _, res := range resources {
 		summary := ResourceSummary{
 			Name:              res.Name,
+			Owner:             res.Owner,
+			Stability:         res.Annotations().Stability,
 			FieldCount:        len(res.Fields),
 			RelationshipCount: len(res.Relationships),
 			HookCount:         len(res.Hooks),
@@ -1110,6 +1179,27 @@ func formatResourceAsTable(resource *metadata.ResourceMetadata, writer io.Writer
 	if resource.FilePath != "" {
 		fmt.Fprintf(writer, "File: %s\n", resource.FilePath)
 	}
+	if resource.Owner != "" {
+		fmt.Fprintf(writer, "Owner: %s\n", resource.Owner)
+	}
+	if stability := resource.Annotations().Stability; stability != "" {
+		fmt.Fprintf(writer, "Stability: %s\n", stability)
+	}
+	if meta := metadata.GetMetadata(); meta != nil {
+		if group := meta.ShardOf(resource.Name); group != nil {
+			fmt.Fprintf(writer, "Shard: by %s", group.Key)
+			var colocated []string
+			for _, name := range group.Resources {
+				if name != resource.Name {
+					colocated = append(colocated, name)
+				}
+			}
+			if len(colocated) > 0 {
+				fmt.Fprintf(writer, " (co-located with %s)", strings.Join(colocated, ", "))
+			}
+			fmt.Fprintln(writer)
+		}
+	}
 	if resource.Documentation != "" {
 		fmt.Fprintf(writer, "Docs: %s\n", resource.Documentation)
 	}
@@ -1200,10 +1290,14 @@ func formatResourceAsTable(resource *metadata.ResourceMetadata, writer io.Writer
 		if len(resource.Hooks) > 0 {
 			bold.Fprintln(writer, "LIFECYCLE HOOKS:")
 
-			// Group hooks by type
+			// Group hooks by type, and attach and detach hooks by relationship too
 			hooksByType := make(map[string][]metadata.HookMetadata)
 			for _, hook := range resource.Hooks {
-				hooksByType[hook.Type] = append(hooksByType[hook.Type], hook)
+				hookType := hook.Type
+				if hook.Relationship != "" {
+					hookType += "(" + hook.Relationship + ")"
+				}
+				hooksByType[hookType] = append(hooksByType[hookType], hook)
 			}
 
 			// Sort hook types for consistent output
@@ -1224,12 +1318,24 @@ func formatResourceAsTable(resource *metadata.ResourceMetadata, writer io.Writer
 				// Gather flags from all hooks of this type
 				flags := make(map[string]bool)
 				for _, hook := range hooks {
+					if hook.Batch {
+						flags["batch"] = true
+					}
 					if hook.Transaction {
 						flags["transaction"] = true
 					}
 					if hook.Async {
 						flags["async"] = true
 					}
+					if hook.Retry > 0 {
+						flags["retry"] = true
+					}
+					if hook.Policy == "warn" {
+						flags["warn"] = true
+					}
+					if hook.Timeout != "" {
+						flags["timeout "+hook.Timeout] = true
+					}
 				}
 
 				if len(flags) > 0 {
@@ -1329,6 +1435,12 @@ func formatResourceAsTable(resource *metadata.ResourceMetadata, writer io.Writer
 				yellow.Fprintf(writer, " [%s]", strings.Join(route.Middleware, ", "))
 			}
 			fmt.Fprintln(writer)
+			// Show the SQL behind each route in verbose mode
+			if verbose {
+				for _, query := range route.Queries {
+					fmt.Fprintf(writer, "    %s\n", query)
+				}
+			}
 		}
 	} else {
 		fmt.Fprintln(writer, "No auto-generated routes for this resource.")
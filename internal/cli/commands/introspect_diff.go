@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+// newIntrospectDiffCommand creates the 'introspect diff' command
+func newIntrospectDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <old.json> <new.json>",
+		Short: "Compare two metadata files",
+		Long: `Compare two metadata files and print the schema changes between them.
+
+The changelog lists added and removed resources, fields, relationships and
+routes, and fields whose type or nullability changed. Changes that may break
+existing clients are marked: removals, type and nullability changes, changed
+relationships, and new required fields without a default.
+
+The command exits with status 1 when there are breaking changes, so a CI job
+can hold back a deploy until they are reviewed.`,
+		Example: `  # Compare the deployed metadata with a new build
+  conduit introspect diff deployed/metadata.json build/introspection/metadata.json
+
+  # Write a changelog for a pull request
+  conduit introspect diff old.json new.json --format markdown
+
+  # Read the changes from a script
+  conduit introspect diff old.json new.json --format json`,
+		Args: cobra.ExactArgs(2),
+		RunE: runIntrospectDiffCommand,
+	}
+
+	return cmd
+}
+
+// runIntrospectDiffCommand executes the 'introspect diff' command
+func runIntrospectDiffCommand(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(outputFormat)
+	switch format {
+	case "table", "json", "markdown":
+	default:
+		return fmt.Errorf("unsupported diff format: %s (supported: table, json, markdown)", outputFormat)
+	}
+
+	old, err := readMetadataFile(args[0])
+	if err != nil {
+		return err
+	}
+	new, err := readMetadataFile(args[1])
+	if err != nil {
+		return err
+	}
+
+	diff := metadata.Diff(old, new)
+	out := cmd.OutOrStdout()
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			return fmt.Errorf("failed to encode changes: %w", err)
+		}
+	case "markdown":
+		formatDiffAsMarkdown(diff, out)
+	default:
+		formatDiffAsTable(diff, out)
+	}
+
+	if diff.HasBreaking() {
+		return fmt.Errorf("found %d breaking change(s)", diff.Breaking)
+	}
+	return nil
+}
+
+// readMetadataFile reads a metadata.json file written by 'conduit build'
+func readMetadataFile(path string) (*metadata.Metadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+
+	var meta metadata.Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata file %s: %w", path, err)
+	}
+	return &meta, nil
+}
+
+// formatDiffAsTable prints one line per change, marking breaking ones
+func formatDiffAsTable(diff *metadata.SchemaDiff, writer io.Writer) {
+	if len(diff.Changes) == 0 {
+		fmt.Fprintln(writer, "No schema changes.")
+		return
+	}
+
+	breakingColor := color.New(color.FgRed, color.Bold)
+	addedColor := color.New(color.FgGreen)
+	changedColor := color.New(color.FgYellow)
+
+	for _, change := range diff.Changes {
+		switch {
+		case change.Breaking:
+			breakingColor.Fprintf(writer, "%-10s", "BREAKING")
+		case strings.HasSuffix(change.Kind, "_added"):
+			addedColor.Fprintf(writer, "%-10s", "added")
+		default:
+			changedColor.Fprintf(writer, "%-10s", "changed")
+		}
+		fmt.Fprintf(writer, " %s\n", change.Description)
+	}
+
+	fmt.Fprintf(writer, "\n%d change(s), %d breaking\n", len(diff.Changes), diff.Breaking)
+}
+
+// formatDiffAsMarkdown prints the changes as a Markdown changelog with the
+// breaking changes first
+func formatDiffAsMarkdown(diff *metadata.SchemaDiff, writer io.Writer) {
+	fmt.Fprintln(writer, "# Schema changes")
+	fmt.Fprintln(writer)
+	if len(diff.Changes) == 0 {
+		fmt.Fprintln(writer, "No schema changes.")
+		return
+	}
+
+	sections := []struct {
+		title    string
+		breaking bool
+	}{
+		{"Breaking changes", true},
+		{"Other changes", false},
+	}
+	for _, section := range sections {
+		var lines []string
+		for _, change := range diff.Changes {
+			if change.Breaking == section.breaking {
+				lines = append(lines, "- "+change.Description)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(writer, "## %s\n\n%s\n\n", section.title, strings.Join(lines, "\n"))
+	}
+}
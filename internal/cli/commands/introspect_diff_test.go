@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+// writeDiffTestMetadata writes metadata with a Post resource and its list
+// route, plus the given fields, and returns the file's path
+func writeDiffTestMetadata(t *testing.T, name string, fields ...metadata.FieldMetadata) string {
+	t.Helper()
+	data, err := json.Marshal(&metadata.Metadata{
+		Version: "1.0",
+		Resources: []metadata.ResourceMetadata{
+			{Name: "Post", Fields: append([]metadata.FieldMetadata{{Name: "id", Type: "uuid!"}}, fields...)},
+		},
+		Routes: []metadata.RouteMetadata{
+			{Method: "GET", Path: "/posts", Handler: "ListPost", Resource: "Post", Operation: "list"},
+		},
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	return path
+}
+
+func runIntrospectDiff(t *testing.T, format string, args ...string) (string, error) {
+	t.Helper()
+	outputFormat = format
+	defer func() { outputFormat = "table" }()
+
+	cmd := newIntrospectDiffCommand()
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	err := cmd.RunE(cmd, args)
+	return buf.String(), err
+}
+
+func TestIntrospectDiffCommand(t *testing.T) {
+	old := writeDiffTestMetadata(t, "old.json", metadata.FieldMetadata{Name: "title", Type: "string!"})
+	compatible := writeDiffTestMetadata(t, "compatible.json",
+		metadata.FieldMetadata{Name: "title", Type: "string!"},
+		metadata.FieldMetadata{Name: "subtitle", Type: "string?", Nullable: true})
+	breaking := writeDiffTestMetadata(t, "breaking.json", metadata.FieldMetadata{Name: "title", Type: "text!"})
+
+	t.Run("has correct usage", func(t *testing.T) {
+		cmd := newIntrospectDiffCommand()
+		assert.Equal(t, "diff <old.json> <new.json>", cmd.Use)
+		assert.NotEmpty(t, cmd.Short)
+		assert.NotEmpty(t, cmd.Example)
+		assert.Error(t, cmd.Args(cmd, []string{old}))
+	})
+
+	t.Run("prints compatible changes", func(t *testing.T) {
+		out, err := runIntrospectDiff(t, "table", old, compatible)
+		require.NoError(t, err)
+		assert.Contains(t, out, "Added field Post.subtitle (string?)")
+		assert.Contains(t, out, "1 change(s), 0 breaking")
+	})
+
+	t.Run("fails on breaking changes", func(t *testing.T) {
+		out, err := runIntrospectDiff(t, "table", old, breaking)
+		require.EqualError(t, err, "found 1 breaking change(s)")
+		assert.Contains(t, out, "BREAKING")
+		assert.Contains(t, out, "Changed the type of Post.title from string to text")
+	})
+
+	t.Run("prints JSON", func(t *testing.T) {
+		out, err := runIntrospectDiff(t, "json", old, breaking)
+		require.Error(t, err)
+
+		var diff metadata.SchemaDiff
+		require.NoError(t, json.Unmarshal([]byte(out), &diff))
+		require.Len(t, diff.Changes, 1)
+		assert.Equal(t, metadata.FieldTypeChanged, diff.Changes[0].Kind)
+		assert.Equal(t, 1, diff.Breaking)
+	})
+
+	t.Run("prints Markdown", func(t *testing.T) {
+		out, err := runIntrospectDiff(t, "markdown", breaking, compatible)
+		require.Error(t, err)
+		assert.Contains(t, out, "## Breaking changes\n\n- Changed the type of Post.title from text to string\n")
+		assert.Contains(t, out, "## Other changes\n\n- Added field Post.subtitle (string?)\n")
+	})
+
+	t.Run("reports unchanged metadata", func(t *testing.T) {
+		out, err := runIntrospectDiff(t, "table", old, old)
+		require.NoError(t, err)
+		assert.Contains(t, out, "No schema changes.")
+	})
+
+	t.Run("rejects other formats and missing files", func(t *testing.T) {
+		_, err := runIntrospectDiff(t, "yaml", old, compatible)
+		assert.ErrorContains(t, err, "unsupported diff format")
+
+		_, err = runIntrospectDiff(t, "table", old, filepath.Join(t.TempDir(), "missing.json"))
+		assert.ErrorContains(t, err, "failed to read metadata file")
+	})
+}
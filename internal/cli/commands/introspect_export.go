@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/conduit-lang/conduit/internal/cli/config"
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+// newIntrospectExportCommand creates the 'introspect export' command
+func newIntrospectExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the application's API in another format",
+		Long: `Export the application's API from its metadata in another format.
+
+The openapi format is an OpenAPI 3.1 document with a schema for every resource
+and its input, every route with its parameters, request body and responses,
+and bearer authentication on the routes with auth middleware. API gateways and
+client generators can read it as it is.
+
+The graphql format is a GraphQL schema in SDL with an object type for every
+resource, including its relationships, enum types for enum fields, and Query
+and Mutation fields for the show, list, scope, create, update and delete
+routes, so the application can be placed behind a GraphQL gateway.
+
+The json, yaml, cbor and protobuf formats encode the metadata itself, for
+systems that cannot read JSON easily: yaml and cbor keep the JSON keys in
+order, and protobuf is a google.protobuf.Struct message that any protobuf
+runtime decodes with the well-known types.
+
+The server URL defaults to server.api_prefix in conduit.yaml, and responses are
+wrapped in {"data": ...} when serialization.envelope is set.`,
+		Example: `  # Print the OpenAPI document as JSON
+  conduit introspect export --format openapi
+
+  # Write it as YAML for an API gateway
+  conduit introspect export --format openapi --output openapi.yaml
+
+  # Set the title and server URL
+  conduit introspect export --format openapi --title "Blog API" --server https://api.example.com
+
+  # Write the GraphQL schema
+  conduit introspect export --format graphql --output schema.graphql
+
+  # Write the metadata as CBOR
+  conduit introspect export --format cbor --output metadata.cbor`,
+		Args: cobra.NoArgs,
+		RunE: runIntrospectExportCommand,
+	}
+
+	cmd.Flags().StringP("output", "o", "", "File to write (default: stdout); for openapi, YAML for .yaml and .yml files, JSON otherwise")
+	cmd.Flags().String("title", "", "OpenAPI document title (default: project_name from conduit.yaml)")
+	cmd.Flags().String("server", "", "OpenAPI server URL (default: server.api_prefix from conduit.yaml)")
+
+	return cmd
+}
+
+// runIntrospectExportCommand executes the 'introspect export' command
+func runIntrospectExportCommand(cmd *cobra.Command, args []string) error {
+	// The table default selects openapi, the first export format
+	format := strings.ToLower(outputFormat)
+	var exporter metadata.Exporter
+	switch format {
+	case "openapi", "table", "graphql":
+	default:
+		var err error
+		if exporter, err = metadata.ExporterFor(format); err != nil {
+			return fmt.Errorf("unsupported export format: %s (supported: openapi, graphql, %s)",
+				outputFormat, strings.Join(metadata.ExportFormats(), ", "))
+		}
+	}
+
+	meta := metadata.GetMetadata()
+	if meta == nil {
+		return fmt.Errorf("no metadata registered")
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	if exporter != nil {
+		data, err := exporter.Export(meta)
+		if err != nil {
+			return err
+		}
+		return writeExport(cmd, data, output, format+" metadata")
+	}
+	if format == "graphql" {
+		return writeExport(cmd, []byte(metadata.ToGraphQLSchema(meta)), output, "GraphQL schema")
+	}
+
+	opts := metadata.OpenAPIOptions{}
+	if cfg, err := config.Load(); err == nil && cfg != nil {
+		opts.Title = cfg.ProjectName
+		opts.ServerURL = cfg.Server.APIPrefix
+		opts.Envelope = cfg.Serialization.Envelope
+	}
+	if title, _ := cmd.Flags().GetString("title"); title != "" {
+		opts.Title = title
+	}
+	if server, _ := cmd.Flags().GetString("server"); server != "" {
+		opts.ServerURL = server
+	}
+
+	data, err := encodeOpenAPI(metadata.ExportOpenAPI(meta, opts), output)
+	if err != nil {
+		return err
+	}
+	return writeExport(cmd, data, output, "OpenAPI document")
+}
+
+// writeExport writes an exported document to the output file, or to stdout
+// when there is none
+func writeExport(cmd *cobra.Command, data []byte, output, description string) error {
+	if output == "" {
+		_, err := cmd.OutOrStdout().Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s to %s\n", description, output)
+	return nil
+}
+
+// encodeOpenAPI encodes the document as YAML for .yaml and .yml files and as
+// indented JSON otherwise
+func encodeOpenAPI(doc map[string]interface{}, output string) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".yaml", ".yml":
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+		}
+		return data, nil
+	default:
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+		}
+		return append(data, '\n'), nil
+	}
+}
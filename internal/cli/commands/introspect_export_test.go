@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+func registerExportTestMetadata(t *testing.T) {
+	t.Helper()
+	metadata.Reset()
+	data, err := json.Marshal(&metadata.Metadata{
+		Version:   "1.0",
+		Generated: time.Now(),
+		Resources: []metadata.ResourceMetadata{
+			{Name: "Post", Fields: []metadata.FieldMetadata{{Name: "id", Type: "uuid!", Required: true}}},
+		},
+		Routes: []metadata.RouteMetadata{
+			{Method: "GET", Path: "/posts/:id", Handler: "ShowPost", Resource: "Post", Operation: "show", ResponseBody: "Post"},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, metadata.RegisterMetadata(data))
+}
+
+func TestIntrospectExportCommand(t *testing.T) {
+	t.Run("has correct usage", func(t *testing.T) {
+		cmd := newIntrospectExportCommand()
+		assert.Equal(t, "export", cmd.Use)
+		assert.NotEmpty(t, cmd.Short)
+		assert.NotEmpty(t, cmd.Long)
+		assert.NotEmpty(t, cmd.Example)
+		assert.NotNil(t, cmd.Flags().Lookup("output"))
+	})
+
+	t.Run("prints OpenAPI JSON", func(t *testing.T) {
+		registerExportTestMetadata(t)
+		outputFormat = "openapi"
+		defer func() { outputFormat = "table" }()
+
+		cmd := newIntrospectExportCommand()
+		require.NoError(t, cmd.Flags().Set("title", "Blog"))
+		require.NoError(t, cmd.Flags().Set("server", "/api"))
+		buf := &bytes.Buffer{}
+		cmd.SetOut(buf)
+		require.NoError(t, cmd.RunE(cmd, []string{}))
+
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+		assert.Equal(t, metadata.OpenAPIVersion, doc["openapi"])
+		assert.Equal(t, "Blog", doc["info"].(map[string]interface{})["title"])
+		assert.Contains(t, doc["paths"], "/posts/{id}")
+		assert.Equal(t, "/api", doc["servers"].([]interface{})[0].(map[string]interface{})["url"])
+	})
+
+	t.Run("writes YAML files", func(t *testing.T) {
+		registerExportTestMetadata(t)
+		outputFormat = "openapi"
+		defer func() { outputFormat = "table" }()
+
+		path := filepath.Join(t.TempDir(), "openapi.yaml")
+		cmd := newIntrospectExportCommand()
+		require.NoError(t, cmd.Flags().Set("output", path))
+		buf := &bytes.Buffer{}
+		cmd.SetOut(buf)
+		require.NoError(t, cmd.RunE(cmd, []string{}))
+		assert.Contains(t, buf.String(), path)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var doc map[string]interface{}
+		require.NoError(t, yaml.Unmarshal(data, &doc))
+		assert.Equal(t, metadata.OpenAPIVersion, doc["openapi"])
+	})
+
+	t.Run("prints the GraphQL schema", func(t *testing.T) {
+		registerExportTestMetadata(t)
+		outputFormat = "graphql"
+		defer func() { outputFormat = "table" }()
+
+		cmd := newIntrospectExportCommand()
+		buf := &bytes.Buffer{}
+		cmd.SetOut(buf)
+		require.NoError(t, cmd.RunE(cmd, []string{}))
+
+		assert.Contains(t, buf.String(), "type Post {\n  id: ID!\n}")
+		assert.Contains(t, buf.String(), "type Query {\n  post(id: ID!): Post\n}")
+	})
+
+	t.Run("prints the metadata as JSON", func(t *testing.T) {
+		registerExportTestMetadata(t)
+		outputFormat = "json"
+		defer func() { outputFormat = "table" }()
+
+		cmd := newIntrospectExportCommand()
+		buf := &bytes.Buffer{}
+		cmd.SetOut(buf)
+		require.NoError(t, cmd.RunE(cmd, []string{}))
+
+		var meta metadata.Metadata
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &meta))
+		require.Len(t, meta.Resources, 1)
+		assert.Equal(t, "Post", meta.Resources[0].Name)
+		assert.Equal(t, "/posts/:id", meta.Routes[0].Path)
+	})
+
+	t.Run("writes the metadata as YAML", func(t *testing.T) {
+		registerExportTestMetadata(t)
+		outputFormat = "yaml"
+		defer func() { outputFormat = "table" }()
+
+		path := filepath.Join(t.TempDir(), "metadata.yaml")
+		cmd := newIntrospectExportCommand()
+		require.NoError(t, cmd.Flags().Set("output", path))
+		buf := &bytes.Buffer{}
+		cmd.SetOut(buf)
+		require.NoError(t, cmd.RunE(cmd, []string{}))
+		assert.Contains(t, buf.String(), "Wrote yaml metadata to "+path)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var doc map[string]interface{}
+		require.NoError(t, yaml.Unmarshal(data, &doc))
+		assert.Equal(t, "1.0", doc["version"])
+		assert.Equal(t, "Post", doc["resources"].([]interface{})[0].(map[string]interface{})["name"])
+	})
+
+	t.Run("prints the metadata as CBOR", func(t *testing.T) {
+		registerExportTestMetadata(t)
+		outputFormat = "cbor"
+		defer func() { outputFormat = "table" }()
+
+		cmd := newIntrospectExportCommand()
+		buf := &bytes.Buffer{}
+		cmd.SetOut(buf)
+		require.NoError(t, cmd.RunE(cmd, []string{}))
+
+		want, err := metadata.CBORExporter{}.Export(metadata.GetMetadata())
+		require.NoError(t, err)
+		assert.Equal(t, want, buf.Bytes())
+		// A map of 7 entries starting with "version": "1.0"
+		assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("\xa7\x67version\x631.0")), "unexpected start % x", buf.Bytes()[:12])
+	})
+
+	t.Run("prints the metadata as protobuf", func(t *testing.T) {
+		registerExportTestMetadata(t)
+		outputFormat = "protobuf"
+		defer func() { outputFormat = "table" }()
+
+		cmd := newIntrospectExportCommand()
+		buf := &bytes.Buffer{}
+		cmd.SetOut(buf)
+		require.NoError(t, cmd.RunE(cmd, []string{}))
+
+		want, err := metadata.ProtobufExporter{}.Export(metadata.GetMetadata())
+		require.NoError(t, err)
+		assert.Equal(t, want, buf.Bytes())
+		// The first Struct entry is "version": "1.0"
+		assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("\x0a\x10\x0a\x07version\x12\x05\x1a\x031.0")), "unexpected start % x", buf.Bytes()[:20])
+	})
+
+	t.Run("rejects other formats", func(t *testing.T) {
+		registerExportTestMetadata(t)
+		outputFormat = "xml"
+		defer func() { outputFormat = "table" }()
+
+		cmd := newIntrospectExportCommand()
+		cmd.SetOut(&bytes.Buffer{})
+		err := cmd.RunE(cmd, []string{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "supported: openapi, graphql, cbor, json, protobuf, yaml")
+	})
+}
@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+// searchSnippetWidth is how much of a matching line the table shows
+const searchSnippetWidth = 70
+
+// newIntrospectSearchCommand creates the 'introspect search' command
+func newIntrospectSearchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search <term>...",
+		Short: "Search names, documentation, hooks and patterns",
+		Long: `Search the application's metadata for text.
+
+Resource and field names, documentation, hook source code and pattern
+templates are searched, ignoring case. With several terms, only text holding
+all of them matches. Results are ranked with the best match first: names rank
+above documentation, patterns and hook code, and a whole name above one that
+starts with the term, contains it as a word or contains it at all.`,
+		Example: `  # Find everything mentioning slugs
+  conduit introspect search slug
+
+  # Find hooks that send email
+  conduit introspect search send email --kind hook
+
+  # Search one resource
+  conduit introspect search published --resource Post
+
+  # Output in JSON format for tooling
+  conduit introspect search slug --format json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runIntrospectSearchCommand,
+	}
+
+	cmd.Flags().StringSlice("kind", nil, "Only show matches of these kinds: resource, field, documentation, pattern, hook")
+	cmd.Flags().String("resource", "", "Only search this resource")
+	cmd.Flags().Int("limit", metadata.DefaultSearchLimit, "Maximum number of results, 0 for all")
+
+	return cmd
+}
+
+// runIntrospectSearchCommand executes the 'introspect search <term>...' command
+func runIntrospectSearchCommand(cmd *cobra.Command, args []string) error {
+	kinds, _ := cmd.Flags().GetStringSlice("kind")
+	resource, _ := cmd.Flags().GetString("resource")
+	limit, _ := cmd.Flags().GetInt("limit")
+	if limit == 0 {
+		limit = -1
+	}
+
+	query := strings.Join(args, " ")
+	results, err := metadata.GetRegistry().Search(query, metadata.SearchOptions{
+		Kinds:    kinds,
+		Resource: resource,
+		Limit:    limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	writer := cmd.OutOrStdout()
+	switch strings.ToLower(outputFormat) {
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(buildSearchOutput(query, results))
+	case "yaml", "yml":
+		encoder := yaml.NewEncoder(writer)
+		encoder.SetIndent(2)
+		defer encoder.Close()
+		return encoder.Encode(buildSearchOutput(query, results))
+	default:
+		return formatSearchResultsAsTable(query, results, writer)
+	}
+}
+
+// formatSearchResultsAsTable prints one line per result: its kind, where it
+// matched and the line of text that matched
+func formatSearchResultsAsTable(query string, results []metadata.SearchResult, writer io.Writer) error {
+	if len(results) == 0 {
+		fmt.Fprintf(writer, "No matches for %q.\n", query)
+		return nil
+	}
+
+	bold := color.New(color.Bold)
+	cyan := color.New(color.FgCyan)
+	faint := color.New(color.Faint)
+
+	noun := "matches"
+	if len(results) == 1 {
+		noun = "match"
+	}
+	bold.Fprintf(writer, "%d %s for %q\n", len(results), noun, query)
+	fmt.Fprintln(writer)
+
+	for _, result := range results {
+		cyan.Fprintf(writer, "%-14s", result.Kind)
+		fmt.Fprintf(writer, " %-30s ", searchLocation(result))
+		snippet := result.Snippet
+		if runes := []rune(snippet); len(runes) > searchSnippetWidth {
+			snippet = string(runes[:searchSnippetWidth-3]) + "..."
+		}
+		if snippet != result.Name {
+			faint.Fprint(writer, snippet)
+		}
+		fmt.Fprintln(writer)
+	}
+
+	return nil
+}
+
+// searchLocation names where a result matched, e.g. Post.slug, or
+// Post.before_create:2 for the second line of a hook
+func searchLocation(result metadata.SearchResult) string {
+	location := result.Name
+	if result.Resource != "" && result.Resource != result.Name {
+		location = result.Resource + "." + result.Name
+	}
+	if result.Line > 0 {
+		location += fmt.Sprintf(":%d", result.Line)
+	}
+	return location
+}
+
+// buildSearchOutput builds the structured output for search results
+func buildSearchOutput(query string, results []metadata.SearchResult) interface{} {
+	type Result struct {
+		Kind     string  `json:"kind" yaml:"kind"`
+		Resource string  `json:"resource,omitempty" yaml:"resource,omitempty"`
+		Name     string  `json:"name" yaml:"name"`
+		Line     int     `json:"line,omitempty" yaml:"line,omitempty"`
+		Snippet  string  `json:"snippet" yaml:"snippet"`
+		Score    float64 `json:"score" yaml:"score"`
+	}
+
+	type Output struct {
+		Query      string   `json:"query" yaml:"query"`
+		TotalCount int      `json:"total_count" yaml:"total_count"`
+		Results    []Result `json:"results" yaml:"results"`
+	}
+
+	output := Output{Query: query, TotalCount: len(results), Results: make([]Result, len(results))}
+	for i, result := range results {
+		output.Results[i] = Result(result)
+	}
+	return output
+}
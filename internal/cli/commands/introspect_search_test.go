@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+func registerSearchTestMetadata(t *testing.T) {
+	t.Helper()
+	metadata.Reset()
+	data, err := json.Marshal(&metadata.Metadata{
+		Version: "1.0",
+		Resources: []metadata.ResourceMetadata{
+			{
+				Name:   "Post",
+				Fields: []metadata.FieldMetadata{{Name: "slug", Type: "string!"}},
+				Hooks: []metadata.HookMetadata{
+					{Type: "before_create", SourceCode: "self.published_at = Time.now()\nself.slug = String.slugify(self.title)"},
+				},
+			},
+			{Name: "Author", Fields: []metadata.FieldMetadata{{Name: "email", Type: "email!"}}},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, metadata.RegisterMetadata(data))
+}
+
+func TestIntrospectSearchCommand(t *testing.T) {
+	color.NoColor = true
+
+	t.Run("has correct usage", func(t *testing.T) {
+		cmd := newIntrospectSearchCommand()
+		assert.Equal(t, "search <term>...", cmd.Use)
+		assert.NotEmpty(t, cmd.Short)
+		assert.NotEmpty(t, cmd.Long)
+		assert.NotEmpty(t, cmd.Example)
+		assert.NotNil(t, cmd.Flags().Lookup("kind"))
+		assert.NotNil(t, cmd.Flags().Lookup("resource"))
+		assert.Error(t, cmd.Args(cmd, []string{}))
+	})
+
+	t.Run("prints ranked matches", func(t *testing.T) {
+		registerSearchTestMetadata(t)
+		defer metadata.Reset()
+
+		cmd := newIntrospectSearchCommand()
+		buf := &bytes.Buffer{}
+		cmd.SetOut(buf)
+		require.NoError(t, cmd.RunE(cmd, []string{"slug"}))
+
+		output := buf.String()
+		assert.Contains(t, output, `2 matches for "slug"`)
+		assert.Contains(t, output, "Post.slug")
+		assert.Contains(t, output, "Post.before_create:2")
+		assert.Contains(t, output, "self.slug = String.slugify(self.title)")
+		assert.Less(t, bytes.Index(buf.Bytes(), []byte("Post.slug")), bytes.Index(buf.Bytes(), []byte("Post.before_create")))
+	})
+
+	t.Run("filters by kind and resource", func(t *testing.T) {
+		registerSearchTestMetadata(t)
+		defer metadata.Reset()
+
+		cmd := newIntrospectSearchCommand()
+		require.NoError(t, cmd.Flags().Set("kind", "hook"))
+		buf := &bytes.Buffer{}
+		cmd.SetOut(buf)
+		require.NoError(t, cmd.RunE(cmd, []string{"slug"}))
+		assert.Contains(t, buf.String(), `1 match for "slug"`)
+
+		cmd = newIntrospectSearchCommand()
+		require.NoError(t, cmd.Flags().Set("resource", "Author"))
+		buf = &bytes.Buffer{}
+		cmd.SetOut(buf)
+		require.NoError(t, cmd.RunE(cmd, []string{"slug"}))
+		assert.Contains(t, buf.String(), `No matches for "slug"`)
+
+		cmd = newIntrospectSearchCommand()
+		require.NoError(t, cmd.Flags().Set("kind", "route"))
+		assert.ErrorContains(t, cmd.RunE(cmd, []string{"slug"}), "unknown search kind")
+	})
+
+	t.Run("prints JSON", func(t *testing.T) {
+		registerSearchTestMetadata(t)
+		defer metadata.Reset()
+		outputFormat = "json"
+		defer func() { outputFormat = "table" }()
+
+		cmd := newIntrospectSearchCommand()
+		buf := &bytes.Buffer{}
+		cmd.SetOut(buf)
+		require.NoError(t, cmd.RunE(cmd, []string{"send", "email"}))
+
+		var output struct {
+			Query      string                  `json:"query"`
+			TotalCount int                     `json:"total_count"`
+			Results    []metadata.SearchResult `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &output))
+		assert.Equal(t, "send email", output.Query)
+		assert.Equal(t, 0, output.TotalCount)
+		assert.NotNil(t, output.Results)
+	})
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/conduit-lang/conduit/internal/mcp"
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+// newIntrospectServeCommand creates the 'introspect serve' command
+func newIntrospectServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve --mcp",
+		Short: "Serve the introspection registry to AI agents over MCP",
+		Long: `Run a Model Context Protocol (MCP) server over stdio that answers from the
+metadata of the last build, so AI agents and IDEs can query the application's
+structure instead of reading its source.
+
+The server offers these tools:
+  list_resources       Resources with their documentation and relationships
+  get_resource         Full metadata of one resource
+  list_routes          HTTP routes, filtered by method or resource
+  query_dependencies   What a resource depends on, or what depends on it
+  search_patterns      Patterns the code follows, most frequent first
+
+The metadata is reloaded after each 'conduit build' without restarting the
+server. Protocol messages use stdout; logs go to stderr.`,
+		Example: `  # Configure an MCP client to start the server in the project directory
+  conduit introspect serve --mcp
+
+  # Serve the metadata of another build
+  conduit introspect serve --mcp --metadata build/staging/metadata.json`,
+		Args: cobra.NoArgs,
+		RunE: runIntrospectServeCommand,
+	}
+
+	cmd.Flags().Bool("mcp", false, "Speak the Model Context Protocol over stdio")
+	return cmd
+}
+
+// runIntrospectServeCommand executes the 'introspect serve' command
+func runIntrospectServeCommand(cmd *cobra.Command, args []string) error {
+	if useMCP, _ := cmd.Flags().GetBool("mcp"); !useMCP {
+		return fmt.Errorf("choose a protocol to serve: --mcp")
+	}
+
+	path := metadataFile
+	if path == "" {
+		path = "build/introspection/metadata.json"
+	}
+	registry := metadata.GetRegistry()
+	watcher, err := registry.Watch(path)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	logger := log.New(cmd.ErrOrStderr(), "[MCP] ", log.LstdFlags)
+	go func() {
+		for update := range watcher.Subscribe() {
+			if update.Err != nil {
+				logger.Printf("Keeping the previous metadata: %v", update.Err)
+				continue
+			}
+			logger.Printf("Reloaded %s (%d resources)", path, len(update.Metadata.Resources))
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := mcp.NewServer(registry, Version)
+	server.SetLogger(logger)
+	logger.Printf("Serving %s over stdio", path)
+	return server.Serve(ctx, cmd.InOrStdin(), cmd.OutOrStdout())
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+func TestIntrospectServeCommand(t *testing.T) {
+	t.Run("requires a protocol", func(t *testing.T) {
+		cmd := newIntrospectServeCommand()
+		cmd.SetArgs([]string{})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		err := cmd.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--mcp")
+	})
+
+	t.Run("answers MCP requests on stdio", func(t *testing.T) {
+		dir := t.TempDir()
+		metadataPath := filepath.Join(dir, "metadata.json")
+		data, err := json.Marshal(&metadata.Metadata{
+			Version:   "1.0",
+			Resources: []metadata.ResourceMetadata{{Name: "Post"}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(metadataPath, data, 0644))
+		metadataFile = metadataPath
+		defer func() { metadataFile = "" }()
+		defer metadata.Reset()
+
+		cmd := newIntrospectServeCommand()
+		var out bytes.Buffer
+		cmd.SetIn(strings.NewReader(strings.Join([]string{
+			`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-06-18"}}`,
+			`{"jsonrpc": "2.0", "method": "notifications/initialized"}`,
+			`{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "list_resources"}}`,
+		}, "\n")))
+		cmd.SetOut(&out)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs([]string{"--mcp"})
+		require.NoError(t, cmd.Execute())
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[0], `"protocolVersion":"2025-06-18"`)
+		assert.Contains(t, lines[1], `\"name\": \"Post\"`)
+	})
+}
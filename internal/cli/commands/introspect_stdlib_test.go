@@ -71,9 +71,11 @@ func TestRunIntrospectStdlibCommand(t *testing.T) {
 		assert.Contains(t, output, "Array Functions")
 		assert.Contains(t, output, "Hash Functions")
 		assert.Contains(t, output, "UUID Functions")
+		assert.Contains(t, output, "Mail Functions")
+		assert.Contains(t, output, "Notify Functions")
 
 		// Should show total count
-		assert.Contains(t, output, "15 total")
+		assert.Contains(t, output, "17 total")
 	})
 
 	t.Run("lists specific namespace when filtered", func(t *testing.T) {
@@ -98,7 +100,7 @@ func TestRunIntrospectStdlibCommand(t *testing.T) {
 		assert.NotContains(t, output, "Array Functions")
 
 		// Should NOT show total count when filtered
-		assert.NotContains(t, output, "15 total")
+		assert.NotContains(t, output, "17 total")
 	})
 
 	t.Run("returns error for invalid namespace", func(t *testing.T) {
@@ -204,11 +206,11 @@ func TestStdlibJSONOutput(t *testing.T) {
 
 		totalCount, ok := result["total_count"].(float64)
 		require.True(t, ok)
-		assert.Equal(t, float64(15), totalCount)
+		assert.Equal(t, float64(17), totalCount)
 
 		namespaces, ok := result["namespaces"].([]interface{})
 		require.True(t, ok)
-		assert.Len(t, namespaces, 5)
+		assert.Len(t, namespaces, 7)
 	})
 
 	t.Run("outputs valid JSON for single namespace", func(t *testing.T) {
@@ -301,6 +303,8 @@ func TestStdlibNamespaceFiltering(t *testing.T) {
 		{"Array", 2, false},
 		{"Hash", 1, false},
 		{"UUID", 1, false},
+		{"Mail", 1, false},
+		{"Notify", 1, false},
 		{"Invalid", 0, true},
 		{"string", 0, true}, // Case-sensitive
 	}
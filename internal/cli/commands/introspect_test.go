@@ -50,6 +50,7 @@ func TestIntrospectCommand(t *testing.T) {
 			"deps",
 			"patterns",
 			"stdlib",
+			"export",
 		}
 
 		for _, name := range expectedCommands {
@@ -91,7 +92,8 @@ func TestRunIntrospectResourcesCommand(t *testing.T) {
 			Generated: time.Now(),
 			Resources: []metadata.ResourceMetadata{
 				{
-					Name: "User",
+					Name:  "User",
+					Owner: "identity-team",
 					Fields: []metadata.FieldMetadata{
 						{Name: "id", Type: "uuid", Required: true},
 						{Name: "email", Type: "string", Required: true},
@@ -111,7 +113,8 @@ func TestRunIntrospectResourcesCommand(t *testing.T) {
 					},
 				},
 				{
-					Name: "Post",
+					Name:  "Post",
+					Owner: "content-team",
 					Fields: []metadata.FieldMetadata{
 						{Name: "id", Type: "uuid", Required: true},
 						{Name: "title", Type: "string", Required: true},
@@ -240,6 +243,36 @@ func TestRunIntrospectResourcesCommand(t *testing.T) {
 		assert.Contains(t, output, "nested")
 	})
 
+	t.Run("filters by owner", func(t *testing.T) {
+		metadata.Reset()
+		data, err := json.Marshal(createTestMetadata())
+		require.NoError(t, err)
+		require.NoError(t, metadata.RegisterMetadata(data))
+
+		outputFormat = "json"
+		defer func() { outputFormat = "table" }()
+
+		cmd := newIntrospectResourcesCommand()
+		require.NoError(t, cmd.Flags().Set("owner", "@Content-Team"))
+		buf := &bytes.Buffer{}
+		cmd.SetOut(buf)
+
+		require.NoError(t, cmd.RunE(cmd, []string{}))
+
+		var output struct {
+			TotalCount int `json:"total_count"`
+			Resources  []struct {
+				Name  string `json:"name"`
+				Owner string `json:"owner"`
+			} `json:"resources"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &output))
+		assert.Equal(t, 1, output.TotalCount)
+		require.Len(t, output.Resources, 1)
+		assert.Equal(t, "Post", output.Resources[0].Name)
+		assert.Equal(t, "content-team", output.Resources[0].Owner)
+	})
+
 	t.Run("formats verbose table output", func(t *testing.T) {
 		// Setup test registry
 		metadata.Reset()
@@ -1120,6 +1153,10 @@ func TestRunIntrospectResourceCommand(t *testing.T) {
 					Resource:   "Post",
 					Operation:  "list",
 					Middleware: []string{"cache(300)"},
+					Queries: []string{
+						"SELECT id, title FROM posts ORDER BY id LIMIT $1 OFFSET $2",
+						"SELECT COUNT(*) FROM posts",
+					},
 				},
 				{
 					Method:     "POST",
@@ -1198,6 +1235,7 @@ func TestRunIntrospectResourceCommand(t *testing.T) {
 		assert.Contains(t, output, "POST /posts")
 		assert.Contains(t, output, "cache(300)")
 		assert.Contains(t, output, "auth")
+		assert.NotContains(t, output, "SELECT COUNT(*) FROM posts")
 	})
 
 	t.Run("formats verbose table output correctly", func(t *testing.T) {
@@ -1233,6 +1271,9 @@ func TestRunIntrospectResourceCommand(t *testing.T) {
 		assert.Contains(t, output, "create:")
 		assert.Contains(t, output, "list:")
 
+		// and the SQL behind each route
+		assert.Contains(t, output, "    SELECT id, title FROM posts ORDER BY id LIMIT $1 OFFSET $2\n    SELECT COUNT(*) FROM posts\n")
+
 		// Reset verbose flag
 		verbose = false
 	})
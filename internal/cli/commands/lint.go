@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/conduit-lang/conduit/internal/cli/config"
+	"github.com/conduit-lang/conduit/internal/tooling/lint"
+	"github.com/conduit-lang/conduit/internal/tooling/refactor"
+	"github.com/conduit-lang/conduit/internal/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintUnused    bool
+	lintBudgets   bool
+	lintStability bool
+	lintJSON      bool
+)
+
+// NewLintCommand creates the lint command
+func NewLintCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check Conduit source files for maintainability problems",
+		Long: `Run static analysis over the .cdt files in app/.
+
+Issues are reported with file, line and column. When no check is selected, all
+checks run.
+
+Checks:
+  --unused    Unused scopes, middleware declared in conduit.yml but never
+              applied, fields that are never read or exposed, and resources
+              without routes
+  --budgets   Complexity budgets declared under lint.budgets in conduit.yml;
+              exceeding a budget fails the command
+  --stability Stable resources with relationships to @stability(experimental)
+              resources; resources without @stability are stable, and
+              violations fail the command
+
+Budgets (0 or unset disables a budget):
+  lint:
+    budgets:
+      max_fields_per_resource: 30
+      max_dependency_depth: 4
+      max_hooks_per_event: 2
+
+Examples:
+  conduit lint
+  conduit lint --unused
+  conduit lint --budgets
+  conduit lint --stability
+  conduit lint --unused --json`,
+		RunE: runLint,
+	}
+
+	cmd.Flags().BoolVar(&lintUnused, "unused", false, "Report unused scopes, middleware, fields and resources")
+	cmd.Flags().BoolVar(&lintBudgets, "budgets", false, "Fail when complexity budgets from conduit.yml are exceeded")
+	cmd.Flags().BoolVar(&lintStability, "stability", false, "Fail when stable resources depend on experimental ones")
+	cmd.Flags().BoolVar(&lintJSON, "json", false, "Output issues in JSON format")
+
+	return cmd
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	successColor := color.New(color.FgGreen, color.Bold)
+	errorColor := color.New(color.FgRed, color.Bold)
+	warningColor := color.New(color.FgYellow)
+
+	if _, err := os.Stat("app"); os.IsNotExist(err) {
+		return fmt.Errorf("app/ directory not found - are you in a Conduit project?")
+	}
+
+	paths, err := utils.FindCdtFiles("app")
+	if err != nil {
+		return fmt.Errorf("failed to find .cdt files: %w", err)
+	}
+
+	sources, err := refactor.LoadFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	files := make([]lint.File, 0, len(sources))
+	for _, source := range sources {
+		files = append(files, lint.File{Path: source.Path, Program: source.Program})
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	runAll := !lintUnused && !lintBudgets && !lintStability
+	issues := make([]lint.Issue, 0)
+
+	if runAll || lintUnused {
+		issues = append(issues, lint.FindUnused(files, lint.UnusedOptions{
+			DeclaredMiddleware: cfg.Middleware,
+			ConfigPath:         configFilePath(),
+		})...)
+	}
+
+	if runAll || lintBudgets {
+		budgets := cfg.Lint.Budgets
+		issues = append(issues, lint.CheckBudgets(files, lint.Budgets{
+			MaxFieldsPerResource: budgets.MaxFieldsPerResource,
+			MaxDependencyDepth:   budgets.MaxDependencyDepth,
+			MaxHooksPerEvent:     budgets.MaxHooksPerEvent,
+		})...)
+	}
+
+	if runAll || lintStability {
+		issues = append(issues, lint.CheckStability(files)...)
+	}
+
+	if lintJSON {
+		data, err := json.MarshalIndent(issues, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode issues: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, issue := range issues {
+			if issue.Severity == lint.SeverityError {
+				errorColor.Println(issue.String())
+			} else {
+				warningColor.Println(issue.String())
+			}
+		}
+	}
+
+	if lint.HasErrors(issues) {
+		return fmt.Errorf("lint failed with %d issue(s)", len(issues))
+	}
+
+	if !lintJSON {
+		if len(issues) == 0 {
+			successColor.Println("✓ No issues found")
+		} else {
+			warningColor.Printf("%d warning(s)\n", len(issues))
+		}
+	}
+
+	return nil
+}
+
+// configFilePath returns the project config file name, preferring conduit.yml
+func configFilePath() string {
+	if _, err := os.Stat("conduit.yaml"); err == nil {
+		return "conduit.yaml"
+	}
+	return "conduit.yml"
+}
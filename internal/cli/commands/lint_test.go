@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewLintCommand(t *testing.T) {
+	cmd := NewLintCommand()
+
+	if cmd.Use != "lint" {
+		t.Errorf("expected Use to be 'lint', got %s", cmd.Use)
+	}
+
+	for _, flag := range []string{"unused", "budgets", "stability", "json"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("expected flag --%s", flag)
+		}
+	}
+}
+
+func TestRunLint_Unused(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	os.MkdirAll("app", 0755)
+	source := `resource Post {
+  id: uuid! @primary @auto
+
+  @scope drafts {
+    self.id == self.id
+  }
+}
+`
+	os.WriteFile(filepath.Join("app", "post.cdt"), []byte(source), 0644)
+
+	cmd := NewLintCommand()
+	cmd.SetArgs([]string{"--unused"})
+	defer func() { lintUnused, lintJSON = false, false }()
+
+	// Unused definitions are warnings and do not fail the command
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("lint failed: %v", err)
+	}
+}
+
+func TestRunLint_NotInProject(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	cmd := NewLintCommand()
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error outside a Conduit project")
+	}
+}
+
+func TestRunLint_BudgetExceeded(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	os.MkdirAll("app", 0755)
+	source := `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+  body: string!
+}
+`
+	os.WriteFile(filepath.Join("app", "post.cdt"), []byte(source), 0644)
+	os.WriteFile("conduit.yml", []byte("lint:\n  budgets:\n    max_fields_per_resource: 2\n"), 0644)
+
+	cmd := NewLintCommand()
+	cmd.SetArgs([]string{"--budgets"})
+	defer func() { lintBudgets = false }()
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected lint to fail when a budget is exceeded")
+	}
+}
+
+func TestRunLint_StableDependsOnExperimental(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	os.MkdirAll("app", 0755)
+	source := `resource Invoice {
+  id: uuid! @primary @auto
+  draft_id: uuid!
+
+  draft: Draft! {
+    foreign_key: "draft_id"
+  }
+}
+
+resource Draft {
+  id: uuid! @primary @auto
+
+  @stability(experimental)
+}
+`
+	os.WriteFile(filepath.Join("app", "invoice.cdt"), []byte(source), 0644)
+
+	cmd := NewLintCommand()
+	cmd.SetArgs([]string{"--stability"})
+	defer func() { lintStability = false }()
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected lint to fail when a stable resource depends on an experimental one")
+	}
+}
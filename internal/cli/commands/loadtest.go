@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/conduit-lang/conduit/internal/cli/config"
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/tooling/loadtest"
+	"github.com/conduit-lang/conduit/internal/tooling/refactor"
+	"github.com/conduit-lang/conduit/internal/utils"
+)
+
+// NewLoadtestCommand creates the loadtest command
+func NewLoadtestCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "loadtest",
+		Short: "Generate load testing scenarios from your resources",
+		Long: `Generate load testing scenarios from the resources in app/, so performance
+testing starts from the schema rather than hand-written scripts.`,
+	}
+
+	cmd.AddCommand(newLoadtestGenerateCommand())
+
+	return cmd
+}
+
+func newLoadtestGenerateCommand() *cobra.Command {
+	var (
+		output   string
+		mix      string
+		target   string
+		vus      int
+		duration string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a k6 script covering list, show and create routes",
+		Long: `Generate a k6 script that exercises the list, show and create routes of
+every resource.
+
+Create bodies are derived from field types and constraints: string lengths
+respect @min and @max, numbers stay within their bounds, enums use declared
+values, @unique fields get a per-request suffix, and foreign keys reference
+records created in the script's setup.
+
+Requests are weighted by --mix. Weights are relative, and operations left out
+of the mix receive no traffic.
+
+Examples:
+  conduit loadtest generate
+  conduit loadtest generate --mix list=80,show=15,create=5
+  conduit loadtest generate --vus 50 --duration 5m --output perf/api.js
+  k6 run loadtest/k6.js`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			successColor := color.New(color.FgGreen, color.Bold)
+			infoColor := color.New(color.FgCyan)
+
+			trafficMix, err := loadtest.ParseMix(mix)
+			if err != nil {
+				return err
+			}
+
+			if _, err := os.Stat("app"); os.IsNotExist(err) {
+				return fmt.Errorf("app/ directory not found - are you in a Conduit project?")
+			}
+
+			resources, err := loadResources()
+			if err != nil {
+				return err
+			}
+
+			opts := loadtest.Options{
+				BaseURL:  target,
+				Mix:      trafficMix,
+				VUs:      vus,
+				Duration: duration,
+			}
+			port := 3000
+			if cfg, err := config.Load(); err == nil {
+				if cfg.Server.Port != 0 {
+					port = cfg.Server.Port
+				}
+				opts.APIPrefix = cfg.Server.APIPrefix
+			}
+			if opts.BaseURL == "" {
+				opts.BaseURL = fmt.Sprintf("http://localhost:%d", port)
+			}
+
+			script, err := loadtest.GenerateK6(resources, opts)
+			if err != nil {
+				return err
+			}
+
+			if output == "-" {
+				fmt.Print(script)
+				return nil
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+			if err := os.WriteFile(output, []byte(script), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+
+			successColor.Printf("✓ Generated %s covering %d resource(s)\n", output, len(resources))
+			infoColor.Printf("  Traffic mix: %s\n", trafficMix)
+			fmt.Printf("  Run it with: k6 run %s\n", output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", filepath.Join("loadtest", "k6.js"), "Script path, or - for stdout")
+	cmd.Flags().StringVar(&mix, "mix", loadtest.DefaultMix.String(), "Relative weight of each operation")
+	cmd.Flags().StringVar(&target, "target", "", "Default base URL (default: http://localhost:<server.port>)")
+	cmd.Flags().IntVar(&vus, "vus", 10, "Concurrent virtual users")
+	cmd.Flags().StringVar(&duration, "duration", "1m", "Test duration")
+
+	return cmd
+}
+
+// loadResources parses every .cdt file in app/ and returns its resources
+func loadResources() ([]*ast.ResourceNode, error) {
+	paths, err := utils.FindCdtFiles("app")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find .cdt files: %w", err)
+	}
+
+	files, err := refactor.LoadFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []*ast.ResourceNode
+	for _, file := range files {
+		resources = append(resources, file.Program.Resources...)
+	}
+	return resources, nil
+}
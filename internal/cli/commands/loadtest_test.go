@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadtestGenerate(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	os.MkdirAll("app", 0755)
+	os.WriteFile("conduit.yaml", []byte("project_name: blog\nserver:\n  port: 8080\n"), 0644)
+	os.WriteFile(filepath.Join("app", "post.cdt"), []byte("resource Post {\n  id: uuid! @primary @auto\n  title: string! @min(3)\n}\n"), 0644)
+
+	cmd := NewLoadtestCommand()
+	cmd.SetArgs([]string{"generate", "--mix", "list=90,create=10"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("loadtest generate failed: %v", err)
+	}
+
+	script, err := os.ReadFile(filepath.Join("loadtest", "k6.js"))
+	if err != nil {
+		t.Fatalf("script not written: %v", err)
+	}
+	for _, want := range []string{
+		`"http://localhost:8080"`,
+		"list: 90,",
+		"show: 0,",
+		"title: sized(`Sample title`, 3, 0),",
+	} {
+		if !strings.Contains(string(script), want) {
+			t.Errorf("script missing %q", want)
+		}
+	}
+}
+
+func TestLoadtestGenerate_InvalidMix(t *testing.T) {
+	cmd := NewLoadtestCommand()
+	cmd.SetArgs([]string{"generate", "--mix", "update=10"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "unknown operation") {
+		t.Errorf("expected unknown operation error, got %v", err)
+	}
+}
@@ -80,8 +80,14 @@ func NewMigrateCommand() *cobra.Command {
 Migrations are stored in the migrations/ directory as SQL files.
 Each migration should have an up and down file:
   001_create_users.up.sql
-  001_create_users.down.sql`,
-		Example: `  # Apply all pending migrations
+  001_create_users.down.sql
+
+generate writes both files from the changes to the resources in app/ since
+the last generated migration.`,
+		Example: `  # Generate a migration from schema changes
+  conduit migrate generate
+
+  # Apply all pending migrations
   conduit migrate up
 
   # Apply migrations with detailed error output
@@ -94,13 +100,18 @@ Each migration should have an up and down file:
   conduit migrate down
 
   # Rollback to a specific version
-  conduit migrate rollback 003`,
+  conduit migrate rollback 003
+
+  # Renumber migrations that conflict with main after a merge
+  conduit migrate rebase`,
 	}
 
+	cmd.AddCommand(newMigrateGenerateCommand())
 	cmd.AddCommand(newMigrateUpCommand())
 	cmd.AddCommand(newMigrateDownCommand())
 	cmd.AddCommand(newMigrateStatusCommand())
 	cmd.AddCommand(newMigrateRollbackCommand())
+	cmd.AddCommand(newMigrateRebaseCommand())
 
 	return cmd
 }
@@ -643,3 +654,30 @@ func extractVersionFromFilename(filename string) (int64, string, error) {
 
 	return version, migrationName, nil
 }
+
+// latestMigrationVersion returns the highest migration version in dir, or 0 when
+// there are no migrations. Files with invalid names are skipped as in migrate up.
+func latestMigrationVersion(dir string) (int64, error) {
+	migrationFiles, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+	for _, file := range migrationFiles {
+		filename := filepath.Base(file)
+		if strings.Contains(filename, ".down.sql") {
+			continue
+		}
+
+		version, _, err := extractVersionFromFilename(filename)
+		if err != nil {
+			continue
+		}
+		if version > latest {
+			latest = version
+		}
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,281 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+	"github.com/conduit-lang/conduit/internal/compiler/parser"
+	"github.com/conduit-lang/conduit/internal/orm/migrate"
+	"github.com/conduit-lang/conduit/internal/orm/schema"
+	"github.com/conduit-lang/conduit/internal/tooling/build"
+	"github.com/conduit-lang/conduit/internal/utils"
+)
+
+// schemaMigrationOptions configures generateSchemaMigration
+type schemaMigrationOptions struct {
+	AppDir        string
+	MigrationsDir string
+	BuildDir      string // The schema snapshot is kept in .conduit next to it
+	Name          string // Generated from the changes when empty
+	DryRun        bool   // Don't write the migration or the snapshot
+	Baseline      bool   // Record the snapshot without writing a migration
+}
+
+// schemaMigration is the result of generateSchemaMigration
+type schemaMigration struct {
+	Migration *migrate.Migration // nil when the schema did not change
+	UpPath    string
+	DownPath  string
+}
+
+func newMigrateGenerateCommand() *cobra.Command {
+	opts := schemaMigrationOptions{
+		AppDir:        "app",
+		MigrationsDir: "migrations",
+		BuildDir:      "build",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "generate [name]",
+		Short: "Generate a migration from schema changes",
+		Long: `Compare the resources in app/ with the schema snapshot of the last generated
+migration and write the SQL that migrates between them:
+  {version}_{name}.up.sql
+  {version}_{name}.down.sql
+
+Added and removed resources become CREATE TABLE and DROP TABLE, changed fields
+ALTER COLUMN, and changed relationships replace their foreign keys, honoring
+on_delete and on_update. The snapshot is kept in .conduit/schema-snapshot.json
+and updated once the migration is written.
+
+Without a snapshot the migration creates every table. Projects whose database
+already matches app/ can record the snapshot with --baseline instead.`,
+		Example: `  # Generate a migration named after the changes
+  conduit migrate generate
+
+  # Generate a migration with a chosen name
+  conduit migrate generate add_author_to_posts
+
+  # Show the SQL without writing anything
+  conduit migrate generate --dry-run
+
+  # Start tracking an existing schema
+  conduit migrate generate --baseline`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.Name = args[0]
+			}
+			return runMigrateGenerate(opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Print the migration without writing it")
+	cmd.Flags().BoolVar(&opts.Baseline, "baseline", false, "Record the current schema without generating a migration")
+
+	return cmd
+}
+
+func runMigrateGenerate(opts schemaMigrationOptions) error {
+	successColor := color.New(color.FgGreen, color.Bold)
+	infoColor := color.New(color.FgCyan)
+	warningColor := color.New(color.FgYellow, color.Bold)
+
+	result, err := generateSchemaMigration(opts, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if opts.Baseline {
+		if opts.DryRun {
+			infoColor.Println("Would record the current schema")
+			return nil
+		}
+		successColor.Println("✓ Recorded the current schema")
+		infoColor.Println("  Future migrations are generated from this schema")
+		return nil
+	}
+
+	m := result.Migration
+	if m == nil {
+		infoColor.Println("Schema is up to date - no migration generated")
+		return nil
+	}
+
+	if opts.DryRun {
+		infoColor.Printf("-- %s\n", filepath.Base(result.UpPath))
+		fmt.Println(m.Up)
+		infoColor.Printf("-- %s\n", filepath.Base(result.DownPath))
+		fmt.Println(m.Down)
+	} else {
+		successColor.Println("✓ Generated migration files:")
+		infoColor.Printf("  %s\n", result.UpPath)
+		infoColor.Printf("  %s\n", result.DownPath)
+	}
+
+	if m.Breaking {
+		warningColor.Println("WARNING: Contains breaking changes - review the SQL before applying")
+	}
+	if m.DataLoss {
+		warningColor.Println("WARNING: May cause data loss - back up the database before applying")
+	}
+
+	if !opts.DryRun {
+		fmt.Println()
+		infoColor.Println("Next steps:")
+		fmt.Println("  1. Review the generated SQL")
+		fmt.Println("  2. Run 'conduit migrate up' to apply")
+	}
+
+	return nil
+}
+
+// generateSchemaMigration diffs the resources in the app directory against
+// the schema snapshot and writes the migration between them, then records the
+// new snapshot. The snapshot is left alone when writing the migration fails,
+// so the changes are generated again next time.
+func generateSchemaMigration(opts schemaMigrationOptions, now time.Time) (*schemaMigration, error) {
+	current, err := loadAppSchemas(opts.AppDir)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := build.NewSnapshotManager(opts.BuildDir)
+	if opts.Baseline {
+		if !opts.DryRun {
+			if err := snapshots.Save(current, now.Unix()); err != nil {
+				return nil, fmt.Errorf("failed to save schema snapshot: %w", err)
+			}
+		}
+		return &schemaMigration{}, nil
+	}
+
+	previous, err := snapshots.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema snapshot: %w", err)
+	}
+	if previous == nil {
+		latest, err := latestMigrationVersion(opts.MigrationsDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		if latest > 0 {
+			return nil, fmt.Errorf(`no schema snapshot found, but %s/ already has migrations
+
+Generating from an empty schema would create tables that may already exist.
+If the migrations match app/, record the current schema first:
+  conduit migrate generate --baseline`, opts.MigrationsDir)
+		}
+		previous = map[string]*schema.ResourceSchema{}
+	}
+
+	m, err := migrate.NewGenerator().GenerateMigration(previous, current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate migration: %w", err)
+	}
+	if m == nil {
+		return &schemaMigration{}, nil
+	}
+
+	// Versions are Unix times like those of conduit generate migration, after
+	// any migration already in the directory so migrate up applies it last
+	m.Version = now.Unix()
+	latest, err := latestMigrationVersion(opts.MigrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	if m.Version <= latest {
+		m.Version = latest + 1
+	}
+	if opts.Name != "" {
+		m.Name = opts.Name
+	}
+	m.Name = sanitizeMigrationName(m.Name)
+
+	base := fmt.Sprintf("%d_%s", m.Version, m.Name)
+	result := &schemaMigration{
+		Migration: m,
+		UpPath:    filepath.Join(opts.MigrationsDir, base+".up.sql"),
+		DownPath:  filepath.Join(opts.MigrationsDir, base+".down.sql"),
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if err := os.MkdirAll(opts.MigrationsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+	if err := os.WriteFile(result.UpPath, []byte(m.Up), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write up migration: %w", err)
+	}
+	if err := os.WriteFile(result.DownPath, []byte(m.Down), 0644); err != nil {
+		os.Remove(result.UpPath)
+		return nil, fmt.Errorf("failed to write down migration: %w", err)
+	}
+
+	if err := snapshots.Save(current, now.Unix()); err != nil {
+		return nil, fmt.Errorf("failed to save schema snapshot: %w", err)
+	}
+
+	return result, nil
+}
+
+// loadAppSchemas compiles the .cdt files in dir and returns the schemas of
+// their resources
+func loadAppSchemas(dir string) (map[string]*schema.ResourceSchema, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s/ directory not found - are you in a Conduit project?", dir)
+	}
+
+	files, err := utils.FindCdtFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find .cdt files: %w", err)
+	}
+
+	compiled := make([]*build.CompiledFile, 0, len(files))
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		tokens, lexErrors := lexer.New(string(source)).ScanTokens()
+		if len(lexErrors) > 0 {
+			var errMsgs []string
+			for _, err := range lexErrors {
+				errMsgs = append(errMsgs, err.Error())
+			}
+			return nil, fmt.Errorf("lexer errors in file %s:\n%s", file, strings.Join(errMsgs, "\n"))
+		}
+
+		program, parseErrors := parser.New(tokens).Parse()
+		if len(parseErrors) > 0 {
+			var errMsgs []string
+			for _, err := range parseErrors {
+				errMsgs = append(errMsgs, err.Error())
+			}
+			return nil, fmt.Errorf("parse errors in file %s:\n%s", file, strings.Join(errMsgs, "\n"))
+		}
+
+		compiled = append(compiled, &build.CompiledFile{Path: file, Program: program})
+	}
+
+	schemas, err := build.NewSchemaExtractor().ExtractSchemas(compiled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract schemas: %w", err)
+	}
+	return schemas, nil
+}
+
+// sanitizeMigrationName lowercases a migration name and replaces spaces,
+// dashes and dots with underscores, e.g. add_Post.title becomes add_post_title
+func sanitizeMigrationName(name string) string {
+	return strings.NewReplacer(" ", "_", "-", "_", ".", "_").Replace(strings.ToLower(name))
+}
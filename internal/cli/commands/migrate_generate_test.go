@@ -0,0 +1,226 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/compiler/codegen"
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+	"github.com/conduit-lang/conduit/internal/compiler/parser"
+)
+
+const generateUserSource = `resource User {
+  id: uuid! @primary @auto
+  name: string!
+}
+`
+
+// writeGenerateApp writes .cdt sources into the app directory of a
+// temporary project and returns options pointing at it
+func writeGenerateApp(t *testing.T, dir string, sources map[string]string) schemaMigrationOptions {
+	t.Helper()
+	appDir := filepath.Join(dir, "app")
+	if err := os.MkdirAll(appDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, source := range sources {
+		if err := os.WriteFile(filepath.Join(appDir, name), []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return schemaMigrationOptions{
+		AppDir:        appDir,
+		MigrationsDir: filepath.Join(dir, "migrations"),
+		BuildDir:      filepath.Join(dir, "build"),
+	}
+}
+
+func postSource(onDelete string) string {
+	return `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+  author: User! {
+    foreign_key: "author_id"
+    on_delete: ` + onDelete + `
+  }
+}
+`
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestGenerateSchemaMigration(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(1700000000, 0)
+
+	// The first migration creates every table
+	opts := writeGenerateApp(t, dir, map[string]string{
+		"user.cdt": generateUserSource,
+		"post.cdt": postSource("restrict"),
+	})
+	first, err := generateSchemaMigration(opts, now)
+	if err != nil {
+		t.Fatalf("generateSchemaMigration() error = %v", err)
+	}
+	if first.Migration == nil {
+		t.Fatal("expected a migration creating the tables")
+	}
+	if got, want := filepath.Base(first.UpPath), "1700000000_"; !strings.HasPrefix(got, want) || !strings.HasSuffix(got, ".up.sql") {
+		t.Errorf("up file = %s, want %s..up.sql", got, want)
+	}
+	if version, _, err := extractVersionFromFilename(filepath.Base(first.UpPath)); err != nil || version != 1700000000 {
+		t.Errorf("extractVersionFromFilename() = %d, %v", version, err)
+	}
+	up := readFile(t, first.UpPath)
+	for _, want := range []string{"CREATE TABLE", "ON DELETE RESTRICT"} {
+		if !strings.Contains(up, want) {
+			t.Errorf("up migration missing %q:\n%s", want, up)
+		}
+	}
+	if down := readFile(t, first.DownPath); !strings.Contains(down, "DROP TABLE") {
+		t.Errorf("down migration should drop the tables:\n%s", down)
+	}
+
+	// Nothing changed, so nothing is generated
+	unchanged, err := generateSchemaMigration(opts, now.Add(time.Second))
+	if err != nil {
+		t.Fatalf("generateSchemaMigration() error = %v", err)
+	}
+	if unchanged.Migration != nil {
+		t.Errorf("expected no migration, got %s", unchanged.Migration.Name)
+	}
+
+	// A changed on_delete replaces the foreign key, in a version after the
+	// first migration even when the clock is behind it
+	writeGenerateApp(t, dir, map[string]string{"post.cdt": postSource("cascade")})
+	opts.Name = "Cascade post-authors"
+	second, err := generateSchemaMigration(opts, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("generateSchemaMigration() error = %v", err)
+	}
+	if second.Migration == nil {
+		t.Fatal("expected a migration for the changed on_delete")
+	}
+	if got, want := filepath.Base(second.UpPath), "1700000001_cascade_post_authors.up.sql"; got != want {
+		t.Errorf("up file = %s, want %s", got, want)
+	}
+	up = readFile(t, second.UpPath)
+	if !strings.Contains(up, "DROP CONSTRAINT") || !strings.Contains(up, "ON DELETE CASCADE") {
+		t.Errorf("up migration should replace the foreign key:\n%s", up)
+	}
+	if down := readFile(t, second.DownPath); !strings.Contains(down, "ON DELETE RESTRICT") {
+		t.Errorf("down migration should restore ON DELETE RESTRICT:\n%s", down)
+	}
+}
+
+func TestGenerateSchemaMigration_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	opts := writeGenerateApp(t, dir, map[string]string{"user.cdt": generateUserSource})
+	opts.DryRun = true
+
+	result, err := generateSchemaMigration(opts, time.Now())
+	if err != nil {
+		t.Fatalf("generateSchemaMigration() error = %v", err)
+	}
+	if result.Migration == nil {
+		t.Fatal("expected a migration")
+	}
+	if _, err := os.Stat(opts.MigrationsDir); !os.IsNotExist(err) {
+		t.Error("dry run should not write migrations")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".conduit", "schema-snapshot.json")); !os.IsNotExist(err) {
+		t.Error("dry run should not write the snapshot")
+	}
+}
+
+func TestGenerateSchemaMigration_ModelTables(t *testing.T) {
+	dir := t.TempDir()
+	opts := writeGenerateApp(t, dir, map[string]string{"user.cdt": generateUserSource})
+	opts.DryRun = true
+
+	result, err := generateSchemaMigration(opts, time.Now())
+	if err != nil {
+		t.Fatalf("generateSchemaMigration() error = %v", err)
+	}
+	if result.Migration == nil {
+		t.Fatal("expected a migration")
+	}
+
+	tokens, lexErrors := lexer.New(generateUserSource).ScanTokens()
+	if len(lexErrors) > 0 {
+		t.Fatalf("lexer errors: %v", lexErrors)
+	}
+	program, parseErrors := parser.New(tokens).Parse()
+	if len(parseErrors) > 0 {
+		t.Fatalf("parse errors: %v", parseErrors)
+	}
+	model, err := codegen.NewGenerator().GenerateResource(program.Resources[0])
+	if err != nil {
+		t.Fatalf("GenerateResource() error = %v", err)
+	}
+
+	// The migration creates the table the generated model queries
+	if !strings.Contains(model, "FROM users") {
+		t.Fatalf("model should query users:\n%s", model)
+	}
+	if !strings.Contains(result.Migration.Up, `CREATE TABLE IF NOT EXISTS "users"`) {
+		t.Errorf("up migration should create users:\n%s", result.Migration.Up)
+	}
+	if !strings.Contains(result.Migration.Down, `DROP TABLE IF EXISTS "users"`) {
+		t.Errorf("down migration should drop users:\n%s", result.Migration.Down)
+	}
+}
+
+func TestGenerateSchemaMigration_Baseline(t *testing.T) {
+	dir := t.TempDir()
+	opts := writeGenerateApp(t, dir, map[string]string{"user.cdt": generateUserSource})
+	if err := os.MkdirAll(opts.MigrationsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(opts.MigrationsDir, "001_create_users.up.sql")
+	if err := os.WriteFile(existing, []byte("CREATE TABLE users (id uuid);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a snapshot, existing migrations may already create the tables
+	if _, err := generateSchemaMigration(opts, time.Now()); err == nil || !strings.Contains(err.Error(), "--baseline") {
+		t.Fatalf("expected an error suggesting --baseline, got %v", err)
+	}
+
+	baseline := opts
+	baseline.Baseline = true
+	if _, err := generateSchemaMigration(baseline, time.Now()); err != nil {
+		t.Fatalf("generateSchemaMigration(baseline) error = %v", err)
+	}
+
+	result, err := generateSchemaMigration(opts, time.Now())
+	if err != nil {
+		t.Fatalf("generateSchemaMigration() error = %v", err)
+	}
+	if result.Migration != nil {
+		t.Errorf("expected no migration after the baseline, got %s", result.Migration.Name)
+	}
+}
+
+func TestSanitizeMigrationName(t *testing.T) {
+	tests := map[string]string{
+		"add_Post.title":     "add_post_title",
+		"Add email-to users": "add_email_to_users",
+		"create_users":       "create_users",
+	}
+	for in, want := range tests {
+		if got := sanitizeMigrationName(in); got != want {
+			t.Errorf("sanitizeMigrationName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,467 @@
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+
+	"github.com/conduit-lang/conduit/internal/cli/config"
+)
+
+// migrationFile is a migration in the migrations directory
+type migrationFile struct {
+	Version  int64
+	Name     string // e.g. create_users
+	UpPath   string
+	DownPath string // Empty when there is no down migration
+	Up       string
+	Down     string
+	Base     bool // Present on the base branch, so possibly applied somewhere
+
+	from *migrationFile // Migration this one was rebased from
+}
+
+// rebaseStep moves a migration of the current branch behind the migrations
+// of the base branch, either by renumbering it or by merging it into the
+// branch migration before it
+type rebaseStep struct {
+	Migration  *migrationFile
+	Reason     string         // Why the migration conflicts
+	NewVersion int64          // Version the migration is renumbered to; 0 when merged
+	MergeInto  *migrationFile // Branch migration it is merged into; nil when renumbered
+}
+
+// rebaseChooser decides whether a conflicting migration is merged into the
+// given branch migration rather than renumbered
+type rebaseChooser func(step *rebaseStep, mergeTarget *migrationFile) (merge bool, err error)
+
+func newMigrateRebaseCommand() *cobra.Command {
+	var (
+		base     string
+		yes      bool
+		dryRun   bool
+		noVerify bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rebase",
+		Short: "Renumber migrations that conflict with another branch",
+		Long: `Move the migrations of the current branch behind those of a base branch.
+
+When two branches both generate migrations, the migrations of one can end up
+older than, or with the same version as, migrations of the other. After merging
+or rebasing onto the base branch, rebase finds the migrations that are not on
+the base branch and conflict with its migrations, and asks for each whether to
+renumber it after the base branch's latest migration or merge it into the
+previous migration of the current branch. Migrations on the base branch are
+never changed.
+
+Before writing any files, the resulting sequence is verified against a scratch
+database created next to DATABASE_URL: every up migration is applied in order,
+then every down migration in reverse. The scratch database is dropped
+afterwards.`,
+		Example: `  # Rebase onto main, choosing how to resolve each conflict
+  conduit migrate rebase
+
+  # Rebase onto another branch, renumbering every conflict
+  conduit migrate rebase --base origin/develop --yes
+
+  # Show the changes and verify them without writing files
+  conduit migrate rebase --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			successColor := color.New(color.FgGreen, color.Bold)
+			infoColor := color.New(color.FgCyan)
+			warningColor := color.New(color.FgYellow)
+			out := cmd.OutOrStdout()
+
+			onBase, err := baseMigrationFiles(base, "migrations")
+			if err != nil {
+				return err
+			}
+			migrations, err := loadMigrationFiles("migrations", onBase)
+			if err != nil {
+				return err
+			}
+
+			var choose rebaseChooser
+			if !yes {
+				choose = promptRebaseStep
+			}
+			steps, err := planMigrationRebase(migrations, base, choose)
+			if err != nil {
+				return err
+			}
+			if len(steps) == 0 {
+				infoColor.Fprintf(out, "No migrations conflict with %s\n", base)
+				return nil
+			}
+
+			infoColor.Fprintf(out, "Rebasing %d migration(s) onto %s:\n", len(steps), base)
+			for _, step := range steps {
+				if step.MergeInto != nil {
+					fmt.Fprintf(out, "  %s: merge into %s\n", filepath.Base(step.Migration.UpPath), filepath.Base(step.MergeInto.UpPath))
+				} else {
+					fmt.Fprintf(out, "  %s: renumber to %d\n", filepath.Base(step.Migration.UpPath), step.NewVersion)
+				}
+			}
+
+			sequence := rebasedMigrations(migrations, steps)
+			if noVerify {
+				warningColor.Fprintln(out, "Skipping verification against a scratch database")
+			} else {
+				dbURL := config.GetDatabaseURL()
+				if dbURL == "" {
+					return fmt.Errorf("DATABASE_URL not set; it is needed to create the scratch database (or use --no-verify)")
+				}
+				if err := verifyMigrationSequence(dbURL, sequence); err != nil {
+					return err
+				}
+				successColor.Fprintf(out, "✓ Verified %d migration(s) against a scratch database\n", len(sequence))
+			}
+
+			if dryRun {
+				warningColor.Fprintln(out, "Dry run - no files were written")
+				return nil
+			}
+			if err := writeMigrationRebase(steps, sequence); err != nil {
+				return err
+			}
+			successColor.Fprintf(out, "✓ Rebased %d migration(s)\n", len(steps))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&base, "base", "main", "Git branch or commit to rebase the migrations onto")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Renumber every conflicting migration without prompting")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show and verify the changes without writing files")
+	cmd.Flags().BoolVar(&noVerify, "no-verify", false, "Skip verifying the migrations against a scratch database")
+	cmd.Flags().BoolVarP(&migrateVerbose, "verbose", "v", false, "Show detailed error messages")
+
+	return cmd
+}
+
+// baseMigrationFiles returns the names of the migration files in dir on the
+// given git branch or commit
+func baseMigrationFiles(base, dir string) (map[string]bool, error) {
+	output, err := exec.Command("git", "ls-tree", "--name-only", base, "--", dir+"/").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("failed to list migrations on %s: %s", base, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("failed to list migrations on %s: %w", base, err)
+	}
+
+	files := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			files[filepath.Base(line)] = true
+		}
+	}
+	return files, nil
+}
+
+// loadMigrationFiles reads the migrations in dir, sorted by version. Files
+// with invalid names are skipped as in migrate up.
+func loadMigrationFiles(dir string, onBase map[string]bool) ([]*migrationFile, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find migration files: %w", err)
+	}
+
+	migrations := make([]*migrationFile, 0, len(files))
+	for _, file := range files {
+		filename := filepath.Base(file)
+		if strings.Contains(filename, ".down.sql") {
+			continue
+		}
+		version, name, err := extractVersionFromFilename(filename)
+		if err != nil {
+			continue
+		}
+
+		up, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", filename, err)
+		}
+		migration := &migrationFile{
+			Version: version,
+			Name:    name,
+			UpPath:  file,
+			Up:      string(up),
+			Base:    onBase[filename],
+		}
+		downFile := strings.Replace(file, ".up.sql", ".down.sql", 1)
+		if down, err := os.ReadFile(downFile); err == nil && downFile != file {
+			migration.DownPath = downFile
+			migration.Down = string(down)
+		}
+		migrations = append(migrations, migration)
+	}
+
+	sort.SliceStable(migrations, func(i, j int) bool {
+		if migrations[i].Version != migrations[j].Version {
+			return migrations[i].Version < migrations[j].Version
+		}
+		return migrations[i].UpPath < migrations[j].UpPath
+	})
+	return migrations, nil
+}
+
+// planMigrationRebase returns the steps moving the branch migrations behind
+// the base migrations: a branch migration conflicts when its version is not
+// newer than the latest base migration or than the branch migration before
+// it. choose decides how each conflict is resolved; with a nil choose every
+// conflicting migration is renumbered.
+func planMigrationRebase(migrations []*migrationFile, base string, choose rebaseChooser) ([]*rebaseStep, error) {
+	var baseLatest int64
+	for _, migration := range migrations {
+		if migration.Base && migration.Version > baseLatest {
+			baseLatest = migration.Version
+		}
+	}
+
+	steps := make([]*rebaseStep, 0)
+	next := baseLatest
+	var previous *migrationFile // Last branch migration kept in the sequence
+	for _, migration := range migrations {
+		if migration.Base {
+			continue
+		}
+		if migration.Version > next {
+			next = migration.Version
+			previous = migration
+			continue
+		}
+
+		step := &rebaseStep{Migration: migration, NewVersion: next + 1}
+		if migration.Version <= baseLatest {
+			step.Reason = fmt.Sprintf("not newer than %d, the latest migration on %s", baseLatest, base)
+		} else {
+			step.Reason = fmt.Sprintf("version %d is already taken", migration.Version)
+		}
+
+		merge := false
+		if choose != nil {
+			var err error
+			if merge, err = choose(step, previous); err != nil {
+				return nil, err
+			}
+		}
+		if merge && previous != nil {
+			step.NewVersion = 0
+			step.MergeInto = previous
+		} else {
+			next++
+			previous = migration
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// promptRebaseStep asks whether to renumber a conflicting migration or merge
+// it into the branch migration before it
+func promptRebaseStep(step *rebaseStep, mergeTarget *migrationFile) (bool, error) {
+	renumber := fmt.Sprintf("Renumber to %d", step.NewVersion)
+	options := []string{renumber}
+	if mergeTarget != nil {
+		options = append(options, "Merge into "+filepath.Base(mergeTarget.UpPath))
+	}
+
+	var answer string
+	prompt := &survey.Select{
+		Message: fmt.Sprintf("%s conflicts (%s):", filepath.Base(step.Migration.UpPath), step.Reason),
+		Options: options,
+	}
+	if err := survey.AskOne(prompt, &answer); err != nil {
+		return false, err
+	}
+	return answer != renumber, nil
+}
+
+// rebasedMigrations returns the migration sequence after the steps, sorted by
+// version. Merged migrations run after the migration they are merged into and
+// are rolled back before it.
+func rebasedMigrations(migrations []*migrationFile, steps []*rebaseStep) []*migrationFile {
+	stepOf := make(map[*migrationFile]*rebaseStep, len(steps))
+	for _, step := range steps {
+		stepOf[step.Migration] = step
+	}
+
+	rebased := make(map[*migrationFile]*migrationFile, len(migrations))
+	sequence := make([]*migrationFile, 0, len(migrations))
+	for _, migration := range migrations {
+		step := stepOf[migration]
+		if step != nil && step.MergeInto != nil {
+			target := rebased[step.MergeInto]
+			target.Up = joinSQL(target.Up, step.Migration.Up)
+			target.Down = joinSQL(step.Migration.Down, target.Down)
+			continue
+		}
+
+		copied := *migration
+		copied.from = migration
+		if step != nil {
+			copied.Version = step.NewVersion
+			copied.UpPath = renumberedPath(migration.UpPath, step.NewVersion)
+			if migration.DownPath != "" {
+				copied.DownPath = renumberedPath(migration.DownPath, step.NewVersion)
+			}
+		}
+		rebased[migration] = &copied
+		sequence = append(sequence, &copied)
+	}
+
+	sort.SliceStable(sequence, func(i, j int) bool { return sequence[i].Version < sequence[j].Version })
+	return sequence
+}
+
+// joinSQL concatenates two migrations' SQL, either of which may be empty
+func joinSQL(first, second string) string {
+	first, second = strings.TrimRight(first, "\n"), strings.TrimRight(second, "\n")
+	switch {
+	case first == "":
+		return second + "\n"
+	case second == "":
+		return first + "\n"
+	}
+	return first + "\n\n" + second + "\n"
+}
+
+// renumberedPath replaces the version at the start of a migration file name
+func renumberedPath(path string, version int64) string {
+	filename := filepath.Base(path)
+	digits := strings.IndexFunc(filename, func(r rune) bool { return r < '0' || r > '9' })
+	if digits < 0 {
+		digits = len(filename)
+	}
+	return filepath.Join(filepath.Dir(path), strconv.FormatInt(version, 10)+filename[digits:])
+}
+
+// verifyMigrationSequence applies the up migrations in order and then the
+// down migrations in reverse to a scratch database on the server of
+// databaseURL, which it drops afterwards
+func verifyMigrationSequence(databaseURL string, sequence []*migrationFile) error {
+	_, _, maintenanceURL, err := parseDBURL(databaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid DATABASE_URL: %w", err)
+	}
+	admin, err := sql.Open("pgx", maintenanceURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer admin.Close()
+
+	scratch := fmt.Sprintf("conduit_rebase_%d", time.Now().UnixNano())
+	identifier := pgx.Identifier{scratch}.Sanitize()
+	if _, err := admin.Exec("CREATE DATABASE " + identifier); err != nil {
+		return fmt.Errorf("failed to create scratch database: %w", stripCredentials(err))
+	}
+	defer admin.Exec("DROP DATABASE IF EXISTS " + identifier)
+
+	scratchURL, err := url.Parse(databaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid DATABASE_URL: %w", err)
+	}
+	scratchURL.Path = "/" + scratch
+	db, err := sql.Open("pgx", scratchURL.String())
+	if err != nil {
+		return fmt.Errorf("failed to connect to scratch database: %w", err)
+	}
+	defer db.Close()
+
+	for _, migration := range sequence {
+		if err := validateMigrationSQL(migration.Up); err != nil {
+			return fmt.Errorf("migration %s: %w", filepath.Base(migration.UpPath), err)
+		}
+		if _, err := db.Exec(migration.Up); err != nil {
+			return fmt.Errorf("migration %s failed on the scratch database: %s",
+				filepath.Base(migration.UpPath), categorizeDatabaseError(err, migrateVerbose))
+		}
+	}
+	for i := len(sequence) - 1; i >= 0; i-- {
+		migration := sequence[i]
+		if strings.TrimSpace(migration.Down) == "" {
+			continue
+		}
+		if _, err := db.Exec(migration.Down); err != nil {
+			return fmt.Errorf("rollback of %s failed on the scratch database: %s",
+				filepath.Base(migration.UpPath), categorizeDatabaseError(err, migrateVerbose))
+		}
+	}
+
+	// The database cannot be dropped while connected to it
+	return db.Close()
+}
+
+// writeMigrationRebase writes the rebased migrations: merged migrations are
+// removed, renumbered ones renamed and merge targets rewritten
+func writeMigrationRebase(steps []*rebaseStep, sequence []*migrationFile) error {
+	for _, migration := range sequence {
+		if migration.DownPath == "" && migration.Down != "" && !strings.HasSuffix(migration.UpPath, ".up.sql") {
+			return fmt.Errorf("cannot add a down migration for %s; rename it to end in .up.sql", migration.UpPath)
+		}
+	}
+
+	for _, step := range steps {
+		if step.MergeInto == nil {
+			continue
+		}
+		for _, path := range []string{step.Migration.UpPath, step.Migration.DownPath} {
+			if path == "" {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
+	}
+
+	// Renumbering only raises versions, so renaming the newest first never
+	// overwrites a file that is yet to be renamed
+	for i := len(sequence) - 1; i >= 0; i-- {
+		migration := sequence[i]
+		renames := [][2]string{{migration.from.UpPath, migration.UpPath}}
+		if migration.from.DownPath != "" {
+			renames = append(renames, [2]string{migration.from.DownPath, migration.DownPath})
+		}
+		for _, rename := range renames {
+			if rename[0] == rename[1] {
+				continue
+			}
+			if err := os.Rename(rename[0], rename[1]); err != nil {
+				return fmt.Errorf("failed to rename %s: %w", rename[0], err)
+			}
+		}
+	}
+
+	for _, migration := range sequence {
+		if migration.Up != migration.from.Up {
+			if err := os.WriteFile(migration.UpPath, []byte(migration.Up), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", migration.UpPath, err)
+			}
+		}
+		if migration.Down != migration.from.Down {
+			if migration.DownPath == "" {
+				migration.DownPath = strings.TrimSuffix(migration.UpPath, ".up.sql") + ".down.sql"
+			}
+			if err := os.WriteFile(migration.DownPath, []byte(migration.Down), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", migration.DownPath, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeRebaseMigrations writes up and down migrations into a temporary
+// migrations directory and loads them, marking the base ones
+func writeRebaseMigrations(t *testing.T, base []string, branch []string) (string, []*migrationFile) {
+	t.Helper()
+	dir := t.TempDir()
+	onBase := make(map[string]bool)
+	for i, names := range [][]string{base, branch} {
+		for _, name := range names {
+			up := name + ".up.sql"
+			if err := os.WriteFile(filepath.Join(dir, up), []byte("-- up "+name+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, name+".down.sql"), []byte("-- down "+name+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			onBase[up] = i == 0
+		}
+	}
+
+	migrations, err := loadMigrationFiles(dir, onBase)
+	if err != nil {
+		t.Fatalf("loadMigrationFiles() error = %v", err)
+	}
+	return dir, migrations
+}
+
+func TestPlanMigrationRebase_Renumber(t *testing.T) {
+	_, migrations := writeRebaseMigrations(t,
+		[]string{"100_create_users", "300_create_posts"},
+		[]string{"200_add_tags", "300_add_index", "400_add_comments", "301_add_likes"},
+	)
+
+	steps, err := planMigrationRebase(migrations, "main", nil)
+	if err != nil {
+		t.Fatalf("planMigrationRebase() error = %v", err)
+	}
+
+	want := map[string]int64{
+		"200_add_tags.up.sql":  301,
+		"300_add_index.up.sql": 302,
+		"301_add_likes.up.sql": 303,
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("got %d steps, want %d", len(steps), len(want))
+	}
+	for _, step := range steps {
+		name := filepath.Base(step.Migration.UpPath)
+		if step.NewVersion != want[name] {
+			t.Errorf("%s renumbered to %d, want %d", name, step.NewVersion, want[name])
+		}
+		if step.Migration.Base {
+			t.Errorf("base migration %s should never change", name)
+		}
+	}
+	if !strings.Contains(steps[0].Reason, "latest migration on main") {
+		t.Errorf("reason = %q", steps[0].Reason)
+	}
+}
+
+func TestPlanMigrationRebase_NoConflicts(t *testing.T) {
+	_, migrations := writeRebaseMigrations(t, []string{"100_create_users"}, []string{"200_add_tags"})
+
+	steps, err := planMigrationRebase(migrations, "main", nil)
+	if err != nil {
+		t.Fatalf("planMigrationRebase() error = %v", err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("expected no steps, got %d", len(steps))
+	}
+}
+
+func TestMigrationRebase_Merge(t *testing.T) {
+	dir, migrations := writeRebaseMigrations(t,
+		[]string{"100_create_users", "200_create_posts"},
+		[]string{"150_add_tags", "160_add_index"},
+	)
+
+	// Renumber the first conflict and merge the second into it
+	steps, err := planMigrationRebase(migrations, "main", func(step *rebaseStep, target *migrationFile) (bool, error) {
+		return target != nil, nil
+	})
+	if err != nil {
+		t.Fatalf("planMigrationRebase() error = %v", err)
+	}
+	if len(steps) != 2 || steps[0].NewVersion != 201 || steps[1].MergeInto != steps[0].Migration {
+		t.Fatalf("unexpected steps: %+v, %+v", steps[0], steps[1])
+	}
+
+	sequence := rebasedMigrations(migrations, steps)
+	if len(sequence) != 3 {
+		t.Fatalf("sequence has %d migrations, want 3", len(sequence))
+	}
+	merged := sequence[2]
+	if merged.Version != 201 || merged.Up != "-- up 150_add_tags\n\n-- up 160_add_index\n" || merged.Down != "-- down 160_add_index\n\n-- down 150_add_tags\n" {
+		t.Errorf("merged migration = %+v", merged)
+	}
+
+	if err := writeMigrationRebase(steps, sequence); err != nil {
+		t.Fatalf("writeMigrationRebase() error = %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.sql"))
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	want := "100_create_users.down.sql 100_create_users.up.sql 200_create_posts.down.sql 200_create_posts.up.sql 201_add_tags.down.sql 201_add_tags.up.sql"
+	if strings.Join(names, " ") != want {
+		t.Errorf("files = %v, want %s", names, want)
+	}
+	up, _ := os.ReadFile(filepath.Join(dir, "201_add_tags.up.sql"))
+	if string(up) != merged.Up {
+		t.Errorf("201_add_tags.up.sql = %q, want the merged SQL", up)
+	}
+}
+
+func TestWriteMigrationRebase_RenumbersOntoTakenNames(t *testing.T) {
+	dir, migrations := writeRebaseMigrations(t,
+		[]string{"100_create_users", "200_create_posts"},
+		[]string{"150_backfill", "201_backfill"},
+	)
+
+	steps, err := planMigrationRebase(migrations, "main", nil)
+	if err != nil {
+		t.Fatalf("planMigrationRebase() error = %v", err)
+	}
+	if err := writeMigrationRebase(steps, rebasedMigrations(migrations, steps)); err != nil {
+		t.Fatalf("writeMigrationRebase() error = %v", err)
+	}
+
+	for name, content := range map[string]string{
+		"201_backfill.up.sql": "-- up 150_backfill\n",
+		"202_backfill.up.sql": "-- up 201_backfill\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(data) != content {
+			t.Errorf("%s = %q (%v), want %q", name, data, err, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "150_backfill.up.sql")); !os.IsNotExist(err) {
+		t.Error("expected 150_backfill.up.sql to be renamed")
+	}
+}
+
+func TestRenumberedPath(t *testing.T) {
+	tests := map[string]string{
+		"migrations/001_create_users.up.sql": "migrations/42_create_users.up.sql",
+		"migrations/1700000000_add_tags.sql": "migrations/42_add_tags.sql",
+		"migrations/5_drop_index.down.sql":   "migrations/42_drop_index.down.sql",
+		"migrations/1700000000.up.sql":       "migrations/42.up.sql",
+	}
+	for path, want := range tests {
+		if got := renumberedPath(path, 42); got != filepath.FromSlash(want) {
+			t.Errorf("renumberedPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -19,6 +20,7 @@ func TestNewMigrateCommand(t *testing.T) {
 		"down",
 		"status",
 		"rollback",
+		"rebase",
 	}
 
 	for _, expected := range expectedSubcommands {
@@ -334,3 +336,34 @@ func TestExtractVersionFromFilename(t *testing.T) {
 		})
 	}
 }
+
+func TestLatestMigrationVersion(t *testing.T) {
+	dir := t.TempDir()
+
+	version, err := latestMigrationVersion(dir)
+	if err != nil {
+		t.Fatalf("latestMigrationVersion() error = %v", err)
+	}
+	if version != 0 {
+		t.Errorf("expected version 0 without migrations, got %d", version)
+	}
+
+	for _, name := range []string{
+		"001_init.sql",
+		"20240102150405_002_add_posts.up.sql",
+		"20240301000000_003_drop_tags.down.sql", // down migrations are ignored
+		"notes.sql",                             // invalid names are ignored
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("-- sql"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	version, err = latestMigrationVersion(dir)
+	if err != nil {
+		t.Fatalf("latestMigrationVersion() error = %v", err)
+	}
+	if version != 20240102150405 {
+		t.Errorf("expected version 20240102150405, got %d", version)
+	}
+}
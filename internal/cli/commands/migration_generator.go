@@ -220,7 +220,7 @@ func (g *MigrationSQLGenerator) generateUpMigration(resource *schema.ResourceSch
 	sql.WriteString(createTable)
 	sql.WriteString("\n")
 
-	// Generate indexes for @unique fields
+	// Generate indexes for @unique and @index fields and index blocks
 	indexSQL := g.generateIndexes(resource)
 	if indexSQL != "" {
 		sql.WriteString("\n")
@@ -251,7 +251,8 @@ func (g *MigrationSQLGenerator) generateDownMigration(resource *schema.ResourceS
 	return sql.String()
 }
 
-// generateIndexes generates CREATE INDEX statements for @unique fields
+// generateIndexes generates CREATE INDEX statements for @unique and @index
+// fields and index blocks
 func (g *MigrationSQLGenerator) generateIndexes(resource *schema.ResourceSchema) string {
 	var sql strings.Builder
 
@@ -261,32 +262,37 @@ func (g *MigrationSQLGenerator) generateIndexes(resource *schema.ResourceSchema)
 	}
 
 	for fieldName, field := range resource.Fields {
-		// Check if field has @unique annotation
-		hasUnique := false
+		hasUnique, hasIndex, isPrimary := false, false, false
 		for _, annotation := range field.Annotations {
-			if annotation.Name == "unique" {
+			switch annotation.Name {
+			case "unique":
 				hasUnique = true
-				break
+			case "index":
+				hasIndex = true
+			case "primary":
+				isPrimary = true
 			}
 		}
 
+		// Don't create index for primary key fields (already indexed); a
+		// unique index also serves @index lookups
+		if isPrimary || !(hasUnique || hasIndex) {
+			continue
+		}
+		create := "CREATE INDEX"
 		if hasUnique {
-			// Don't create index for primary key fields (already indexed)
-			isPrimary := false
-			for _, annotation := range field.Annotations {
-				if annotation.Name == "primary" {
-					isPrimary = true
-					break
-				}
-			}
-
-			if !isPrimary {
-				columnName := utilstrings.ToSnakeCase(fieldName)
-				indexName := fmt.Sprintf("idx_%s_%s", tableName, columnName)
-				sql.WriteString(fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s);\n",
-					quoteIdentifier(indexName), quoteIdentifier(tableName), quoteIdentifier(columnName)))
-			}
+			create = "CREATE UNIQUE INDEX"
 		}
+		columnName := utilstrings.ToSnakeCase(fieldName)
+		indexName := fmt.Sprintf("idx_%s_%s", tableName, columnName)
+		sql.WriteString(fmt.Sprintf("%s IF NOT EXISTS %s ON %s (%s);\n",
+			create, quoteIdentifier(indexName), codegen.QuoteTable(resource.Schema, tableName), quoteIdentifier(columnName)))
+	}
+
+	indexGen := codegen.NewIndexGenerator()
+	for _, index := range resource.Indexes {
+		sql.WriteString(indexGen.GenerateIndex(resource, index))
+		sql.WriteString("\n")
 	}
 
 	return sql.String()
@@ -0,0 +1,317 @@
+package commands
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/conduit-lang/conduit/internal/cli/config"
+)
+
+// distrolessBase is the runtime image for packaged applications. The binary is
+// built without cgo, so the static variant (no libc) is sufficient.
+const distrolessBase = "gcr.io/distroless/static-debian12:nonroot"
+
+// packageHashLength is how many characters of the source hash tag an artifact
+const packageHashLength = 12
+
+var (
+	packageDocker    bool
+	packageOutput    string
+	packageImage     string
+	packageGOOS      string
+	packageGOARCH    string
+	packageSkipBuild bool
+)
+
+// buildStaticBinary compiles the generated application without cgo. Tests
+// replace it to avoid invoking the Go toolchain.
+var buildStaticBinary = func(generatedDir, output, goos, goarch string) error {
+	cmd := exec.Command("go", "build", "-trimpath", "-ldflags", "-s -w", "-o", output)
+	cmd.Dir = generatedDir
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS="+goos, "GOARCH="+goarch)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// buildDockerImage runs docker build on the staged context. Tests replace it.
+var buildDockerImage = func(contextDir string, tags []string) error {
+	args := []string{"build"}
+	for _, tag := range tags {
+		args = append(args, "-t", tag)
+	}
+	args = append(args, contextDir)
+
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// NewPackageCommand creates the package command
+func NewPackageCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "package",
+		Short: "Package the application as a static binary or Docker image",
+		Long: `Build the generated application into a deployable artifact.
+
+The project is rebuilt, then compiled into a static binary (CGO_ENABLED=0) and
+staged together with the migrations and introspection metadata. Artifacts are
+tagged with the first 12 characters of the metadata source hash, so the same
+sources always produce the same tag.
+
+By default a tarball is written to dist/. With --docker, a distroless image is
+built instead and tagged <image>:<hash> and <image>:latest.
+
+Artifact layout:
+  app                  static binary (metadata is embedded)
+  migrations/          SQL migrations for 'conduit migrate' or your migration tool
+  metadata.json        introspection metadata
+
+Examples:
+  conduit package
+  conduit package --goarch arm64
+  conduit package --docker
+  conduit package --docker --image registry.example.com/blog`,
+		Args: cobra.NoArgs,
+		RunE: runPackage,
+	}
+
+	cmd.Flags().BoolVar(&packageDocker, "docker", false, "Build a distroless Docker image instead of a tarball")
+	cmd.Flags().StringVarP(&packageOutput, "output", "o", "dist", "Directory for staged artifacts")
+	cmd.Flags().StringVar(&packageImage, "image", "", "Docker image name (default: project_name)")
+	cmd.Flags().StringVar(&packageGOOS, "goos", "linux", "Target operating system")
+	cmd.Flags().StringVar(&packageGOARCH, "goarch", runtime.GOARCH, "Target architecture")
+	cmd.Flags().BoolVar(&packageSkipBuild, "skip-build", false, "Package the existing build without running 'conduit build'")
+
+	return cmd
+}
+
+func runPackage(cmd *cobra.Command, args []string) error {
+	successColor := color.New(color.FgGreen, color.Bold)
+	infoColor := color.New(color.FgCyan)
+
+	if _, err := os.Stat("app"); os.IsNotExist(err) {
+		return fmt.Errorf("app/ directory not found - are you in a Conduit project?")
+	}
+
+	if !packageSkipBuild {
+		if err := runBuild(cmd, nil); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+
+	cfg, _ := config.Load()
+	generatedDir := "build/generated"
+	if cfg != nil && cfg.Build.GeneratedDir != "" {
+		generatedDir = cfg.Build.GeneratedDir
+	}
+	name := packageName(cfg)
+
+	metadataPath := filepath.Join("build", "introspection", "metadata.json")
+	hash, err := readSourceHash(metadataPath)
+	if err != nil {
+		return err
+	}
+	tag := hash[:packageHashLength]
+
+	stageDir := filepath.Join(packageOutput, fmt.Sprintf("%s-%s-%s-%s", name, tag, packageGOOS, packageGOARCH))
+	if err := os.RemoveAll(stageDir); err != nil {
+		return fmt.Errorf("failed to clean %s: %w", stageDir, err)
+	}
+	if err := os.MkdirAll(stageDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", stageDir, err)
+	}
+
+	infoColor.Printf("Building static binary for %s/%s...\n", packageGOOS, packageGOARCH)
+	absBinary, err := filepath.Abs(filepath.Join(stageDir, "app"))
+	if err != nil {
+		return fmt.Errorf("failed to get absolute output path: %w", err)
+	}
+	if err := buildStaticBinary(generatedDir, absBinary, packageGOOS, packageGOARCH); err != nil {
+		return fmt.Errorf("static build failed: %w", err)
+	}
+
+	if err := stagePackageFiles(stageDir, metadataPath); err != nil {
+		return err
+	}
+
+	if packageDocker {
+		if err := os.WriteFile(filepath.Join(stageDir, "Dockerfile"), []byte(packageDockerfile(hash)), 0644); err != nil {
+			return fmt.Errorf("failed to write Dockerfile: %w", err)
+		}
+
+		image := packageImage
+		if image == "" {
+			image = name
+		}
+		tags := []string{image + ":" + tag, image + ":latest"}
+		infoColor.Printf("Building image %s...\n", tags[0])
+		if err := buildDockerImage(stageDir, tags); err != nil {
+			return fmt.Errorf("docker build failed: %w", err)
+		}
+
+		successColor.Printf("✓ Built image %s\n", tags[0])
+		infoColor.Printf("  Run it with: docker run -e DATABASE_URL=... -p 8080:8080 %s\n", tags[0])
+		return nil
+	}
+
+	archive := stageDir + ".tar.gz"
+	if err := writeTarball(archive, stageDir); err != nil {
+		return err
+	}
+
+	successColor.Printf("✓ Packaged %s\n", archive)
+	infoColor.Printf("  Source hash: %s\n", hash)
+	return nil
+}
+
+// packageName returns the artifact name: project_name, or the directory name
+func packageName(cfg *config.Config) string {
+	if cfg != nil && cfg.ProjectName != "" {
+		return cfg.ProjectName
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "app"
+	}
+	return filepath.Base(cwd)
+}
+
+// readSourceHash returns the source hash recorded in the build metadata
+func readSourceHash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s - run 'conduit build' first: %w", path, err)
+	}
+
+	var meta struct {
+		SourceHash string `json:"source_hash"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(meta.SourceHash) < packageHashLength {
+		return "", fmt.Errorf("%s has no source hash - run 'conduit build' to regenerate it", path)
+	}
+	return meta.SourceHash, nil
+}
+
+// stagePackageFiles copies the migrations and metadata next to the binary
+func stagePackageFiles(stageDir, metadataPath string) error {
+	if err := copyFile(metadataPath, filepath.Join(stageDir, "metadata.json")); err != nil {
+		return err
+	}
+
+	migrations, err := filepath.Glob(filepath.Join("migrations", "*.sql"))
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(stageDir, "migrations"), 0755); err != nil {
+		return fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+	for _, migration := range migrations {
+		if err := copyFile(migration, filepath.Join(stageDir, "migrations", filepath.Base(migration))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	return nil
+}
+
+// packageDockerfile returns a Dockerfile for the staged context. The source
+// hash is recorded as the OCI revision label.
+func packageDockerfile(hash string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by conduit package\n")
+	fmt.Fprintf(&b, "FROM %s\n\n", distrolessBase)
+	fmt.Fprintf(&b, "LABEL org.opencontainers.image.revision=%q\n", hash)
+	fmt.Fprintf(&b, "LABEL dev.conduit.source-hash=%q\n\n", hash)
+	fmt.Fprintf(&b, "COPY app /app\n")
+	fmt.Fprintf(&b, "COPY migrations /migrations\n")
+	fmt.Fprintf(&b, "COPY metadata.json /metadata.json\n\n")
+	fmt.Fprintf(&b, "ENV PORT=8080\n")
+	fmt.Fprintf(&b, "EXPOSE 8080\n")
+	fmt.Fprintf(&b, "USER nonroot:nonroot\n")
+	fmt.Fprintf(&b, "ENTRYPOINT [\"/app\"]\n")
+	return b.String()
+}
+
+// writeTarball archives dir into a gzipped tarball rooted at the directory name
+func writeTarball(archive, dir string) error {
+	f, err := os.Create(archive)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", archive, err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	root := filepath.Base(dir)
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(root, rel))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", dir, err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", archive, err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", archive, err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const testSourceHash = "6fe7c1ca7c26412d2c99f8305b28b407950853445777cf721827ec1eab346ecf"
+
+func setupPackageProject(t *testing.T) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	t.Cleanup(func() { os.Chdir(oldWd) })
+
+	os.MkdirAll("app", 0755)
+	os.MkdirAll(filepath.Join("build", "introspection"), 0755)
+	os.MkdirAll("migrations", 0755)
+	os.WriteFile("conduit.yaml", []byte("project_name: blog\n"), 0644)
+	os.WriteFile(filepath.Join("build", "introspection", "metadata.json"), []byte(`{"source_hash": "`+testSourceHash+`"}`), 0644)
+	os.WriteFile(filepath.Join("migrations", "001_init.up.sql"), []byte("CREATE TABLE posts ();"), 0644)
+}
+
+func stubPackageBuilds(t *testing.T) (*[]string, *[]string) {
+	t.Helper()
+	originalBinary, originalDocker := buildStaticBinary, buildDockerImage
+	t.Cleanup(func() { buildStaticBinary, buildDockerImage = originalBinary, originalDocker })
+
+	var targets, tags []string
+	buildStaticBinary = func(generatedDir, output, goos, goarch string) error {
+		targets = append(targets, goos+"/"+goarch)
+		return os.WriteFile(output, []byte("binary"), 0755)
+	}
+	buildDockerImage = func(contextDir string, imageTags []string) error {
+		tags = append(tags, imageTags...)
+		return nil
+	}
+	return &targets, &tags
+}
+
+func TestPackage_Tarball(t *testing.T) {
+	setupPackageProject(t)
+	targets, _ := stubPackageBuilds(t)
+
+	cmd := NewPackageCommand()
+	cmd.SetArgs([]string{"--skip-build", "--goarch", "arm64"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("package failed: %v", err)
+	}
+	if len(*targets) != 1 || (*targets)[0] != "linux/arm64" {
+		t.Errorf("targets = %v", *targets)
+	}
+
+	archive := filepath.Join("dist", "blog-6fe7c1ca7c26-linux-arm64.tar.gz")
+	f, err := os.Open(archive)
+	if err != nil {
+		t.Fatalf("tarball not written: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	want := []string{
+		"blog-6fe7c1ca7c26-linux-arm64/",
+		"blog-6fe7c1ca7c26-linux-arm64/app",
+		"blog-6fe7c1ca7c26-linux-arm64/metadata.json",
+		"blog-6fe7c1ca7c26-linux-arm64/migrations/",
+		"blog-6fe7c1ca7c26-linux-arm64/migrations/001_init.up.sql",
+	}
+	if strings.Join(names, "\n") != strings.Join(want, "\n") {
+		t.Errorf("archive entries = %v, want %v", names, want)
+	}
+}
+
+func TestPackage_Docker(t *testing.T) {
+	setupPackageProject(t)
+	_, tags := stubPackageBuilds(t)
+
+	cmd := NewPackageCommand()
+	cmd.SetArgs([]string{"--skip-build", "--docker", "--goarch", "amd64", "--image", "registry.example.com/blog"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("package failed: %v", err)
+	}
+
+	if strings.Join(*tags, ",") != "registry.example.com/blog:6fe7c1ca7c26,registry.example.com/blog:latest" {
+		t.Errorf("tags = %v", *tags)
+	}
+
+	dockerfile, err := os.ReadFile(filepath.Join("dist", "blog-6fe7c1ca7c26-linux-amd64", "Dockerfile"))
+	if err != nil {
+		t.Fatalf("Dockerfile not written: %v", err)
+	}
+	for _, want := range []string{
+		"FROM " + distrolessBase,
+		`LABEL org.opencontainers.image.revision="` + testSourceHash + `"`,
+		"COPY migrations /migrations",
+		`ENTRYPOINT ["/app"]`,
+	} {
+		if !strings.Contains(string(dockerfile), want) {
+			t.Errorf("Dockerfile missing %q:\n%s", want, dockerfile)
+		}
+	}
+}
+
+func TestReadSourceHash_MissingMetadata(t *testing.T) {
+	_, err := readSourceHash(filepath.Join(t.TempDir(), "metadata.json"))
+	if err == nil || !strings.Contains(err.Error(), "conduit build") {
+		t.Errorf("expected error suggesting 'conduit build', got %v", err)
+	}
+}
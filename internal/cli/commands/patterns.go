@@ -0,0 +1,181 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/conduit-lang/conduit/internal/cli/config"
+	"github.com/conduit-lang/conduit/internal/cli/patternstore"
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+// PatternStoreEnvVar names the shared pattern store, overriding patterns.store
+const PatternStoreEnvVar = "CONDUIT_PATTERN_STORE"
+
+// orgPatternsPath is where `conduit patterns sync` writes the merged patterns
+const orgPatternsPath = "build/introspection/org-patterns.json"
+
+var (
+	patternsStore    string
+	patternsProject  string
+	patternsPushOnly bool
+	patternsPullOnly bool
+)
+
+// NewPatternsCommand creates the patterns command group
+func NewPatternsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "patterns",
+		Short: "Share discovered patterns across projects",
+		Long: `Share the patterns discovered by 'conduit build' with other projects through a
+shared pattern store, so teams can learn the conventions of their organization.`,
+	}
+
+	cmd.AddCommand(newPatternsSyncCommand())
+	return cmd
+}
+
+func newPatternsSyncCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Push this project's patterns and pull the organization's",
+		Long: `Push the patterns of the last build to a shared pattern store, then pull the
+patterns of every project merged into ` + orgPatternsPath + `.
+
+A push replaces this project's previous contribution, so syncing again does
+not count the same usages twice. Merged patterns sum the frequencies of all
+projects, and their confidence is the projects' confidences weighted by
+frequency.
+
+The store is --store, else CONDUIT_PATTERN_STORE, else patterns.store in
+conduit.yaml. It is one of:
+  - a JSON file, e.g. on a shared drive
+  - an SQLite database, a path ending in .db, .sqlite or .sqlite3
+  - an http(s) URL that serves the store on GET and accepts it on PUT,
+    authenticated with CONDUIT_PATTERN_STORE_TOKEN when set
+
+Examples:
+  conduit patterns sync --store /mnt/shared/patterns.json
+  conduit patterns sync --store https://patterns.example.com/org --project billing
+  conduit patterns sync --pull-only`,
+		Args: cobra.NoArgs,
+		RunE: runPatternsSync,
+	}
+
+	cmd.Flags().StringVar(&patternsStore, "store", "", "Pattern store: JSON file, SQLite database or http(s) URL")
+	cmd.Flags().StringVar(&patternsProject, "project", "", "Name this project's patterns are pushed under (default: project_name)")
+	cmd.Flags().BoolVar(&patternsPushOnly, "push-only", false, "Push this project's patterns without pulling")
+	cmd.Flags().BoolVar(&patternsPullOnly, "pull-only", false, "Pull the merged patterns without pushing")
+	cmd.MarkFlagsMutuallyExclusive("push-only", "pull-only")
+
+	return cmd
+}
+
+func runPatternsSync(cmd *cobra.Command, args []string) error {
+	successColor := color.New(color.FgGreen, color.Bold)
+	infoColor := color.New(color.FgCyan)
+	out := cmd.OutOrStdout()
+
+	cfg, _ := config.Load()
+	location := patternsStore
+	if location == "" {
+		location = os.Getenv(PatternStoreEnvVar)
+	}
+	if location == "" && cfg != nil {
+		location = cfg.Patterns.Store
+	}
+	if location == "" {
+		return fmt.Errorf("no pattern store configured; pass --store, set %s or set patterns.store in conduit.yaml", PatternStoreEnvVar)
+	}
+
+	backend, err := patternstore.Open(location)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+	ctx := context.Background()
+
+	if !patternsPullOnly {
+		project, err := patternsProjectName(cfg)
+		if err != nil {
+			return err
+		}
+		if err := loadMetadataFromFile(); err != nil {
+			return err
+		}
+		patterns := metadata.QueryPatterns()
+
+		var result patternstore.MergeResult
+		err = backend.Update(ctx, func(store *patternstore.Store) error {
+			result = store.Merge(project, patterns, time.Now().UTC())
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to push patterns: %w", err)
+		}
+		successColor.Fprintf(out, "✓ Pushed %d pattern(s) as %s", len(patterns), project)
+		fmt.Fprintf(out, " (%d new, %d updated, %d removed)\n", result.Added, result.Updated, result.Removed)
+	}
+
+	if patternsPushOnly {
+		return nil
+	}
+
+	store, err := backend.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to pull patterns: %w", err)
+	}
+	merged := store.Merged()
+	if err := writeOrgPatterns(orgPatternsPath, merged); err != nil {
+		return err
+	}
+	successColor.Fprintf(out, "✓ Pulled %d pattern(s) from %d project(s)", len(merged), len(store.Projects()))
+	fmt.Fprintf(out, " to %s\n", orgPatternsPath)
+
+	for i, pattern := range merged {
+		if i == 10 {
+			infoColor.Fprintf(out, "  ... and %d more\n", len(merged)-i)
+			break
+		}
+		fmt.Fprintf(out, "  %-40s %-12s frequency %-4d confidence %.2f\n", pattern.Name, pattern.Category, pattern.Frequency, pattern.Confidence)
+	}
+	return nil
+}
+
+// patternsProjectName returns the name this project pushes its patterns
+// under: --project, else project_name, else the project directory's name
+func patternsProjectName(cfg *config.Config) (string, error) {
+	if patternsProject != "" {
+		return patternsProject, nil
+	}
+	if cfg != nil && cfg.ProjectName != "" {
+		return cfg.ProjectName, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine project name: %w", err)
+	}
+	return filepath.Base(wd), nil
+}
+
+// writeOrgPatterns writes the merged patterns as a JSON array
+func writeOrgPatterns(path string, patterns []metadata.PatternMetadata) error {
+	data, err := json.MarshalIndent(patterns, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode patterns: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
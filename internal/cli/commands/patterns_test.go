@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+// writePatternsMetadata writes a build's metadata with the given patterns
+func writePatternsMetadata(t *testing.T, dir string, patterns []metadata.PatternMetadata) {
+	t.Helper()
+	data, err := json.Marshal(metadata.Metadata{Version: "1.0.0", Patterns: patterns})
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Join(dir, "build", "introspection"), 0755)
+	if err := os.WriteFile(filepath.Join(dir, "build", "introspection", "metadata.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runPatternsSyncIn(t *testing.T, dir string, args ...string) (string, error) {
+	t.Helper()
+	oldWd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(oldWd)
+	defer metadata.Reset()
+	defer func() { patternsStore, patternsProject, patternsPushOnly, patternsPullOnly = "", "", false, false }()
+
+	cmd := NewPatternsCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(append([]string{"sync"}, args...))
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestPatternsSync(t *testing.T) {
+	store := filepath.Join(t.TempDir(), "patterns.json")
+	auth := metadata.PatternMetadata{ID: "pattern-auth", Name: "authenticated_handler", Category: "authentication", Template: "@on <operation>: [auth]"}
+
+	billing := t.TempDir()
+	auth.Frequency, auth.Confidence = 6, 0.9
+	writePatternsMetadata(t, billing, []metadata.PatternMetadata{auth})
+	out, err := runPatternsSyncIn(t, billing, "--store", store, "--project", "billing")
+	if err != nil {
+		t.Fatalf("sync billing error = %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Pushed 1 pattern(s) as billing") {
+		t.Errorf("output = %s", out)
+	}
+
+	blog := t.TempDir()
+	auth.Frequency, auth.Confidence = 2, 0.5
+	writePatternsMetadata(t, blog, []metadata.PatternMetadata{auth})
+	out, err = runPatternsSyncIn(t, blog, "--store", store, "--project", "blog")
+	if err != nil {
+		t.Fatalf("sync blog error = %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Pulled 1 pattern(s) from 2 project(s)") {
+		t.Errorf("output = %s", out)
+	}
+
+	data, err := os.ReadFile(filepath.Join(blog, orgPatternsPath))
+	if err != nil {
+		t.Fatalf("org patterns not written: %v", err)
+	}
+	var merged []metadata.PatternMetadata
+	if err := json.Unmarshal(data, &merged); err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 1 || merged[0].Frequency != 8 || merged[0].Confidence != 0.8 {
+		t.Errorf("org patterns = %+v, want frequency 8 and confidence 0.80", merged)
+	}
+}
+
+func TestPatternsSync_NoStore(t *testing.T) {
+	t.Setenv(PatternStoreEnvVar, "")
+	_, err := runPatternsSyncIn(t, t.TempDir(), "--pull-only")
+	if err == nil || !strings.Contains(err.Error(), "no pattern store configured") {
+		t.Errorf("error = %v, want no pattern store configured", err)
+	}
+}
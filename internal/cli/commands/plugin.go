@@ -0,0 +1,269 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/conduit-lang/conduit/internal/cli/config"
+)
+
+// PluginPrefix is the prefix of executables on PATH that become subcommands:
+// conduit-audit is run as `conduit audit`
+const PluginPrefix = "conduit-"
+
+// PluginAPIVersion is the version of the environment contract plugins are
+// run with. It changes only when a variable is removed or changes meaning;
+// new variables are added without changing it.
+const PluginAPIVersion = "1"
+
+// Environment variables set for plugins
+const (
+	PluginEnvAPIVersion  = "CONDUIT_PLUGIN_API"   // PluginAPIVersion
+	PluginEnvName        = "CONDUIT_PLUGIN_NAME"  // Subcommand name, e.g. audit
+	PluginEnvBin         = "CONDUIT_BIN"          // Path of the conduit executable, for calling back into it
+	PluginEnvVersion     = "CONDUIT_VERSION"      // Version of the conduit executable
+	PluginEnvProjectRoot = "CONDUIT_PROJECT_ROOT" // Project directory; empty outside a project
+	PluginEnvConfig      = "CONDUIT_CONFIG"       // conduit.yml or conduit.yaml; empty when there is none
+	PluginEnvMetadata    = "CONDUIT_METADATA"     // Introspection metadata, which may not exist before `conduit build`
+	PluginEnvFormat      = "CONDUIT_FORMAT"       // Output format: table (default), json or yaml
+	PluginEnvNoColor     = "CONDUIT_NO_COLOR"     // 1 when color output is disabled, otherwise empty
+)
+
+// Plugin is an executable discovered on PATH
+type Plugin struct {
+	Name string // Subcommand name, e.g. audit
+	Path string // Executable, e.g. /usr/local/bin/conduit-audit
+}
+
+// discoverPlugins returns the conduit-<name> executables in the directories
+// of pathList, sorted by name. As with the shell, the first directory
+// providing a name wins.
+func discoverPlugins(pathList string) []Plugin {
+	seen := make(map[string]bool)
+	plugins := make([]Plugin, 0)
+
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || seen[name] {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: path})
+		}
+	}
+
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// pluginName returns the subcommand name of a plugin executable file name
+func pluginName(file string) (string, bool) {
+	if runtime.GOOS == "windows" {
+		if !strings.EqualFold(filepath.Ext(file), ".exe") {
+			return "", false
+		}
+		file = strings.TrimSuffix(file, filepath.Ext(file))
+	}
+	name := strings.TrimPrefix(file, PluginPrefix)
+	if name == file || name == "" || strings.ContainsAny(name, " \t") {
+		return "", false
+	}
+	return name, true
+}
+
+// isExecutable reports whether path is a regular file the user may execute
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0111 != 0
+}
+
+// addPluginCommands adds a subcommand for every plugin on PATH. Built-in
+// commands always win over plugins of the same name.
+func addPluginCommands(rootCmd *cobra.Command) {
+	builtin := map[string]bool{"help": true}
+	for _, cmd := range rootCmd.Commands() {
+		builtin[cmd.Name()] = true
+		for _, alias := range cmd.Aliases {
+			builtin[alias] = true
+		}
+	}
+
+	for _, plugin := range discoverPlugins(os.Getenv("PATH")) {
+		if !builtin[plugin.Name] {
+			rootCmd.AddCommand(newPluginCommand(plugin))
+		}
+	}
+}
+
+// newPluginCommand creates the subcommand running a plugin
+func newPluginCommand(plugin Plugin) *cobra.Command {
+	return &cobra.Command{
+		Use:   plugin.Name,
+		Short: fmt.Sprintf("Plugin (%s)", plugin.Path),
+		Long: fmt.Sprintf(`Run the %s plugin.
+
+Conduit reads --format, --metadata and --no-color itself and passes them to the
+plugin in the environment, with the project's paths:
+
+  %s  %s
+  %s           path of the conduit executable
+  %s       version of the conduit executable
+  %s  project directory; empty outside a project
+  %s        conduit.yml or conduit.yaml; empty when there is none
+  %s      introspection metadata; may not exist before conduit build
+  %s        table, json or yaml
+  %s      1 when color output is disabled
+
+All other arguments, and everything after --, are passed to the plugin as they
+are.`, plugin.Path,
+			PluginEnvAPIVersion, PluginAPIVersion, PluginEnvBin, PluginEnvVersion, PluginEnvProjectRoot,
+			PluginEnvConfig, PluginEnvMetadata, PluginEnvFormat, PluginEnvNoColor),
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPlugin(cmd, plugin, args)
+		},
+	}
+}
+
+// pluginOptions are the conduit flags given to a plugin command
+type pluginOptions struct {
+	format   string
+	metadata string
+	noColor  bool
+}
+
+// parsePluginArgs removes --format, --metadata and --no-color from args,
+// stopping at --, which is removed too
+func parsePluginArgs(args []string) (pluginOptions, []string, error) {
+	opts := pluginOptions{format: "table"}
+	rest := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i+1:]...)
+			break
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "--no-color":
+			opts.noColor = true
+		case "--format", "--metadata":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("flag needs an argument: %s", name)
+				}
+				i++
+				value = args[i]
+			}
+			if name == "--format" {
+				opts.format = value
+			} else {
+				opts.metadata = value
+			}
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	return opts, rest, nil
+}
+
+// runPlugin runs the plugin with the given arguments, connected to the
+// command's input and output
+func runPlugin(cmd *cobra.Command, plugin Plugin, args []string) error {
+	opts, args, err := parsePluginArgs(args)
+	if err != nil {
+		return err
+	}
+
+	env, err := pluginEnv(plugin, opts)
+	if err != nil {
+		return err
+	}
+
+	child := exec.Command(plugin.Path, args...)
+	child.Env = append(os.Environ(), env...)
+	child.Stdin = cmd.InOrStdin()
+	child.Stdout = cmd.OutOrStdout()
+	child.Stderr = cmd.ErrOrStderr()
+
+	if err := child.Run(); err != nil {
+		return fmt.Errorf("plugin %s failed: %w", plugin.Name, err)
+	}
+	return nil
+}
+
+// pluginEnv returns the environment contract for running the plugin
+func pluginEnv(plugin Plugin, opts pluginOptions) ([]string, error) {
+	bin, err := os.Executable()
+	if err != nil {
+		bin = os.Args[0]
+	}
+
+	root, configPath, metadata := "", "", ""
+	if dir, err := projectRoot(); err == nil {
+		root = dir
+		for _, name := range []string{"conduit.yml", "conduit.yaml"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				configPath = filepath.Join(dir, name)
+				break
+			}
+		}
+		metadata = filepath.Join(dir, "build", "introspection", "metadata.json")
+	}
+	if opts.metadata != "" {
+		if metadata, err = filepath.Abs(opts.metadata); err != nil {
+			return nil, fmt.Errorf("invalid metadata path: %w", err)
+		}
+	}
+
+	noColor := ""
+	if opts.noColor || IsNoColor() {
+		noColor = "1"
+	}
+
+	return []string{
+		PluginEnvAPIVersion + "=" + PluginAPIVersion,
+		PluginEnvName + "=" + plugin.Name,
+		PluginEnvBin + "=" + bin,
+		PluginEnvVersion + "=" + Version,
+		PluginEnvProjectRoot + "=" + root,
+		PluginEnvConfig + "=" + configPath,
+		PluginEnvMetadata + "=" + metadata,
+		PluginEnvFormat + "=" + opts.format,
+		PluginEnvNoColor + "=" + noColor,
+	}, nil
+}
+
+// projectRoot returns the absolute project directory
+func projectRoot() (string, error) {
+	dir, err := config.GetProjectRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(dir)
+}
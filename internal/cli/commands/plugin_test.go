@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writePlugin writes a shell script plugin into dir
+func writePlugin(t *testing.T, dir, name, script string, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), mode); err != nil {
+		t.Fatalf("failed to write plugin: %v", err)
+	}
+	return path
+}
+
+func skipOnWindows(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins in these tests are shell scripts")
+	}
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	skipOnWindows(t)
+	first, second := t.TempDir(), t.TempDir()
+
+	audit := writePlugin(t, first, "conduit-audit", "exit 0\n", 0755)
+	writePlugin(t, second, "conduit-audit", "exit 1\n", 0755)
+	deploy := writePlugin(t, second, "conduit-deploy", "exit 0\n", 0755)
+	writePlugin(t, first, "conduit-notes", "exit 0\n", 0644)
+	writePlugin(t, first, "conduit-", "exit 0\n", 0755)
+	writePlugin(t, first, "other-tool", "exit 0\n", 0755)
+	if err := os.Mkdir(filepath.Join(first, "conduit-dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins := discoverPlugins(strings.Join([]string{first, filepath.Join(first, "missing"), second}, string(os.PathListSeparator)))
+
+	want := []Plugin{{Name: "audit", Path: audit}, {Name: "deploy", Path: deploy}}
+	if len(plugins) != len(want) {
+		t.Fatalf("discovered %v, want %v", plugins, want)
+	}
+	for i := range want {
+		if plugins[i] != want[i] {
+			t.Errorf("plugin %d = %v, want %v", i, plugins[i], want[i])
+		}
+	}
+}
+
+func TestAddPluginCommands_BuiltinsWin(t *testing.T) {
+	skipOnWindows(t)
+	dir := t.TempDir()
+	writePlugin(t, dir, "conduit-build", "exit 0\n", 0755)
+	writePlugin(t, dir, "conduit-help", "exit 0\n", 0755)
+	writePlugin(t, dir, "conduit-audit", "exit 0\n", 0755)
+	t.Setenv("PATH", dir)
+
+	cmd := NewRootCommand()
+
+	found := map[string]int{}
+	for _, sub := range cmd.Commands() {
+		found[sub.Name()]++
+	}
+	if found["audit"] != 1 {
+		t.Error("expected the audit plugin to be registered")
+	}
+	if found["build"] != 1 {
+		t.Errorf("expected only the built-in build command, found %d", found["build"])
+	}
+	if found["help"] != 0 {
+		t.Error("expected the help plugin to be ignored")
+	}
+}
+
+func TestParsePluginArgs(t *testing.T) {
+	opts, rest, err := parsePluginArgs([]string{
+		"check", "--format", "json", "--metadata=meta.json", "-v", "--no-color", "--", "--format", "raw",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.format != "json" || opts.metadata != "meta.json" || !opts.noColor {
+		t.Errorf("options = %+v", opts)
+	}
+	want := []string{"check", "-v", "--format", "raw"}
+	if strings.Join(rest, " ") != strings.Join(want, " ") {
+		t.Errorf("arguments = %v, want %v", rest, want)
+	}
+
+	if opts, _, _ := parsePluginArgs(nil); opts.format != "table" {
+		t.Errorf("default format = %q, want table", opts.format)
+	}
+
+	if _, _, err := parsePluginArgs([]string{"--format"}); err == nil {
+		t.Error("expected an error for --format without a value")
+	}
+}
+
+func TestRunPlugin_Environment(t *testing.T) {
+	skipOnWindows(t)
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, "conduit.yaml"), []byte("project_name: blog\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldWd, _ := os.Getwd()
+	os.Chdir(project)
+	defer os.Chdir(oldWd)
+	// Resolve symlinked temporary directories, as the working directory is
+	project, _ = os.Getwd()
+
+	bin := t.TempDir()
+	writePlugin(t, bin, "conduit-env", `echo "args=$*"
+env | grep '^CONDUIT_' | sort
+cat
+`, 0755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cmd := NewRootCommand()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetIn(strings.NewReader("from stdin\n"))
+	cmd.SetArgs([]string{"env", "--format", "json", "report", "--", "--metadata", "x"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("plugin failed: %v", err)
+	}
+
+	output := out.String()
+	for _, want := range []string{
+		"args=report --metadata x",
+		PluginEnvAPIVersion + "=" + PluginAPIVersion,
+		PluginEnvName + "=env",
+		PluginEnvProjectRoot + "=" + project,
+		PluginEnvConfig + "=" + filepath.Join(project, "conduit.yaml"),
+		PluginEnvMetadata + "=" + filepath.Join(project, "build", "introspection", "metadata.json"),
+		PluginEnvFormat + "=json",
+		"from stdin",
+	} {
+		if !strings.Contains(output, want+"\n") {
+			t.Errorf("plugin output is missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestRunPlugin_Failure(t *testing.T) {
+	skipOnWindows(t)
+	bin := t.TempDir()
+	writePlugin(t, bin, "conduit-fail", "exit 3\n", 0755)
+	t.Setenv("PATH", bin)
+	oldWd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(oldWd)
+
+	cmd := NewRootCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"fail"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "plugin fail failed: exit status 3") {
+		t.Errorf("error = %v, want the plugin's exit status", err)
+	}
+}
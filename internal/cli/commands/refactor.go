@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/tooling/refactor"
+	"github.com/conduit-lang/conduit/internal/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// NewRefactorCommand creates the refactor command
+func NewRefactorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "refactor",
+		Short: "Apply automated refactorings to Conduit source files",
+		Long: `Apply automated, source-preserving refactorings to .cdt files.
+
+Refactorings rewrite every reference to the affected element, keep comments and
+formatting intact, and generate the database migration that goes with them.`,
+	}
+
+	cmd.AddCommand(newRefactorRenameCommand())
+
+	return cmd
+}
+
+func newRefactorRenameCommand() *cobra.Command {
+	var (
+		dryRun    bool
+		skipBuild bool
+		dualWrite bool
+		until     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rename <Resource|Resource.field> <new_name>",
+		Short: "Rename a resource or field and cascade to all references",
+		Long: `Rename a resource or field across all .cdt files in app/.
+
+References in relationships, hooks, constraints and scopes are updated, the old
+name is recorded with @alias so API metadata stays backward compatible, and a
+rename migration is written to migrations/. The project is rebuilt afterwards.
+
+For zero-downtime deploys of a field rename, --dual-write adds and backfills the
+new column instead of renaming it, and marks the field with @dual_write so the
+generated code writes both columns until the old one is cleaned up.
+
+Examples:
+  conduit refactor rename Post BlogPost
+  conduit refactor rename Post.title headline
+  conduit refactor rename Post.title headline --dual-write --until 2026-12-01
+  conduit refactor rename Post BlogPost --dry-run`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			successColor := color.New(color.FgGreen, color.Bold)
+			infoColor := color.New(color.FgCyan)
+			warningColor := color.New(color.FgYellow)
+
+			target, err := refactor.ParseTarget(args[0])
+			if err != nil {
+				return err
+			}
+
+			if _, err := os.Stat("app"); os.IsNotExist(err) {
+				return fmt.Errorf("app/ directory not found - are you in a Conduit project?")
+			}
+
+			paths, err := utils.FindCdtFiles("app")
+			if err != nil {
+				return fmt.Errorf("failed to find .cdt files: %w", err)
+			}
+
+			files, err := refactor.LoadFiles(paths)
+			if err != nil {
+				return err
+			}
+
+			result, err := refactor.RenameWithOptions(files, target, args[1], refactor.RenameOptions{
+				DualWrite: dualWrite,
+				Until:     until,
+			})
+			if err != nil {
+				return fmt.Errorf("rename failed: %w", err)
+			}
+
+			version := time.Now().Unix()
+			upFile := filepath.Join("migrations", fmt.Sprintf("%d_%s.up.sql", version, result.Migration.Name))
+			downFile := filepath.Join("migrations", fmt.Sprintf("%d_%s.down.sql", version, result.Migration.Name))
+
+			if dryRun {
+				warningColor.Println("Dry run - no files were written")
+				fmt.Println()
+				infoColor.Printf("Would rename %s to %s (%d reference(s)):\n", target, args[1], result.References)
+				for _, path := range result.ChangedPaths() {
+					fmt.Printf("  %s\n", path)
+				}
+				fmt.Println()
+				if result.Migration.IsEmpty() {
+					infoColor.Println("No migration needed: the database table and columns keep their names")
+					return nil
+				}
+				infoColor.Printf("Would generate %s:\n", upFile)
+				fmt.Print(result.Migration.Up)
+				return nil
+			}
+
+			for _, path := range result.ChangedPaths() {
+				if err := os.WriteFile(path, []byte(result.Changed[path]), 0644); err != nil {
+					return fmt.Errorf("failed to write %s: %w", path, err)
+				}
+			}
+
+			if result.Migration.IsEmpty() {
+				successColor.Printf("✓ Renamed %s to %s (%d reference(s))\n", target, args[1], result.References)
+				for _, path := range result.ChangedPaths() {
+					infoColor.Printf("  %s\n", path)
+				}
+				infoColor.Println("No migration needed: the database table and columns keep their names")
+				fmt.Println()
+
+				if skipBuild {
+					infoColor.Println("Next steps:")
+					fmt.Println("  1. Run 'conduit build' to regenerate code")
+					return nil
+				}
+
+				return runBuild(cmd, nil)
+			}
+
+			if err := os.MkdirAll("migrations", 0755); err != nil {
+				return fmt.Errorf("failed to create migrations directory: %w", err)
+			}
+
+			created := time.Now().Format("2006-01-02 15:04:05")
+			upContent := fmt.Sprintf("-- Migration: %s\n-- Created: %s\n-- Generated by: conduit refactor rename %s %s\n--\n%s",
+				result.Migration.Name, created, target, args[1], result.Migration.Up)
+			downContent := fmt.Sprintf("-- Rollback migration: %s\n-- Created: %s\n-- Generated by: conduit refactor rename %s %s\n--\n%s",
+				result.Migration.Name, created, target, args[1], result.Migration.Down)
+
+			if err := os.WriteFile(upFile, []byte(upContent), 0644); err != nil {
+				return fmt.Errorf("failed to write up migration: %w", err)
+			}
+			if err := os.WriteFile(downFile, []byte(downContent), 0644); err != nil {
+				return fmt.Errorf("failed to write down migration: %w", err)
+			}
+
+			successColor.Printf("✓ Renamed %s to %s (%d reference(s))\n", target, args[1], result.References)
+			for _, path := range result.ChangedPaths() {
+				infoColor.Printf("  %s\n", path)
+			}
+			successColor.Println("✓ Generated migration files:")
+			infoColor.Printf("  %s\n", upFile)
+			infoColor.Printf("  %s\n", downFile)
+			fmt.Println()
+
+			if dualWrite {
+				infoColor.Println("The old column is still written while @dual_write is present.")
+				fmt.Println("Once no running instance reads it, remove @dual_write and drop the column.")
+				fmt.Println()
+			}
+
+			if skipBuild {
+				infoColor.Println("Next steps:")
+				fmt.Println("  1. Run 'conduit build' to regenerate code")
+				fmt.Println("  2. Run 'conduit migrate up' to apply the migration")
+				return nil
+			}
+
+			return runBuild(cmd, nil)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would change without writing files")
+	cmd.Flags().BoolVar(&skipBuild, "skip-build", false, "Do not rebuild the project after renaming")
+	cmd.Flags().BoolVar(&dualWrite, "dual-write", false, "Keep writing the old column during the transition (field renames only)")
+	cmd.Flags().StringVar(&until, "until", "", "Cleanup date (YYYY-MM-DD) recorded with --dual-write")
+
+	return cmd
+}
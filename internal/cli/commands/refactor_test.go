@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewRefactorCommand(t *testing.T) {
+	cmd := NewRefactorCommand()
+
+	if cmd.Use != "refactor" {
+		t.Errorf("expected Use to be 'refactor', got %s", cmd.Use)
+	}
+
+	rename, _, err := cmd.Find([]string{"rename"})
+	if err != nil || rename.Name() != "rename" {
+		t.Fatal("expected subcommand rename to be registered")
+	}
+
+	for _, flag := range []string{"dry-run", "skip-build"} {
+		if rename.Flags().Lookup(flag) == nil {
+			t.Errorf("expected flag --%s on rename", flag)
+		}
+	}
+}
+
+func TestRefactorRename_WritesSourcesAndMigration(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	if err := os.MkdirAll("app", 0755); err != nil {
+		t.Fatal(err)
+	}
+	source := `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+}
+`
+	if err := os.WriteFile(filepath.Join("app", "post.cdt"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewRefactorCommand()
+	cmd.SetArgs([]string{"rename", "Post.title", "headline", "--skip-build"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join("app", "post.cdt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `headline: string! @alias("title")`) {
+		t.Errorf("source was not rewritten:\n%s", content)
+	}
+
+	ups, _ := filepath.Glob(filepath.Join("migrations", "*_rename_posts_title_to_headline.up.sql"))
+	downs, _ := filepath.Glob(filepath.Join("migrations", "*_rename_posts_title_to_headline.down.sql"))
+	if len(ups) != 1 || len(downs) != 1 {
+		t.Fatalf("expected one up and one down migration, got %v %v", ups, downs)
+	}
+
+	up, _ := os.ReadFile(ups[0])
+	if !strings.Contains(string(up), "ALTER TABLE posts RENAME COLUMN title TO headline;") {
+		t.Errorf("unexpected up migration:\n%s", up)
+	}
+}
+
+func TestRefactorRename_ColumnOverrideSkipsMigration(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	os.MkdirAll("app", 0755)
+	source := "resource Post {\n  id: uuid! @primary @auto\n  title: string! @column(\"post_title\")\n}\n"
+	os.WriteFile(filepath.Join("app", "post.cdt"), []byte(source), 0644)
+
+	cmd := NewRefactorCommand()
+	cmd.SetArgs([]string{"rename", "Post.title", "headline", "--skip-build"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+
+	content, _ := os.ReadFile(filepath.Join("app", "post.cdt"))
+	if !strings.Contains(string(content), "headline: string!") {
+		t.Errorf("source was not rewritten:\n%s", content)
+	}
+	if _, err := os.Stat("migrations"); !os.IsNotExist(err) {
+		t.Error("expected no migrations directory for a field with @column")
+	}
+}
+
+func TestRefactorRename_DryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	os.MkdirAll("app", 0755)
+	source := "resource Post {\n  id: uuid! @primary @auto\n}\n"
+	os.WriteFile(filepath.Join("app", "post.cdt"), []byte(source), 0644)
+
+	cmd := NewRefactorCommand()
+	cmd.SetArgs([]string{"rename", "Post", "BlogPost", "--dry-run"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+
+	content, _ := os.ReadFile(filepath.Join("app", "post.cdt"))
+	if string(content) != source {
+		t.Errorf("dry run modified source:\n%s", content)
+	}
+	if _, err := os.Stat("migrations"); !os.IsNotExist(err) {
+		t.Error("dry run should not create migrations")
+	}
+}
+
+func TestRefactorRename_DualWrite(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	if err := os.MkdirAll("app", 0755); err != nil {
+		t.Fatal(err)
+	}
+	source := `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+}
+`
+	if err := os.WriteFile(filepath.Join("app", "post.cdt"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewRefactorCommand()
+	cmd.SetArgs([]string{"rename", "Post.title", "headline", "--dual-write", "--until", "2026-12-01", "--skip-build"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join("app", "post.cdt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `headline: string! @alias("title") @dual_write("title", "2026-12-01")`) {
+		t.Errorf("source was not rewritten:\n%s", content)
+	}
+
+	ups, _ := filepath.Glob(filepath.Join("migrations", "*_dual_write_posts_title_to_headline.up.sql"))
+	if len(ups) != 1 {
+		t.Fatalf("expected one dual-write migration, got %v", ups)
+	}
+}
@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/conduit-lang/conduit/internal/cli/config"
+	"github.com/conduit-lang/conduit/pkg/web/capture"
+)
+
+var (
+	replayTarget      string
+	replayHeaders     []string
+	replayCompareBody bool
+)
+
+// replayResult is the outcome of re-sending one captured request
+type replayResult struct {
+	Entry  capture.Entry
+	Status int
+	Body   string
+	Err    error
+}
+
+// Matches reports whether the replayed response matches the capture
+func (r replayResult) Matches(compareBody bool) bool {
+	if r.Err != nil || r.Status != r.Entry.Status {
+		return false
+	}
+	if compareBody && !r.Entry.ResponseTruncated {
+		return jsonEqual(r.Entry.ResponseBody, r.Body)
+	}
+	return true
+}
+
+// NewReplayCommand creates the replay command
+func NewReplayCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay <capture.ndjson>",
+		Short: "Re-send captured requests against a local build",
+		Long: `Re-send request/response pairs recorded by a generated application and
+report responses whose status (and optionally body) differ from the capture.
+
+Record traffic by starting an application with CONDUIT_CAPTURE set:
+
+  CONDUIT_CAPTURE=capture.ndjson ./build/app
+
+Captures are sanitized: sensitive headers and JSON fields such as passwords and
+tokens are recorded as [REDACTED]. Redacted headers are not sent; pass real
+values with --header when the target requires them.
+
+The command fails when any response differs, so it can gate a schema change.
+
+Examples:
+  conduit replay capture.ndjson
+  conduit replay capture.ndjson --target http://localhost:8080
+  conduit replay capture.ndjson --header "Authorization: Bearer dev-token"
+  conduit replay capture.ndjson --compare-body`,
+		Args: cobra.ExactArgs(1),
+		RunE: runReplay,
+	}
+
+	cmd.Flags().StringVar(&replayTarget, "target", "", "Base URL to replay against (default: http://localhost:<server.port>)")
+	cmd.Flags().StringArrayVarP(&replayHeaders, "header", "H", nil, "Header to add to every request (\"Name: value\"), repeatable")
+	cmd.Flags().BoolVar(&replayCompareBody, "compare-body", false, "Also compare JSON response bodies")
+
+	return cmd
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	successColor := color.New(color.FgGreen, color.Bold)
+	errorColor := color.New(color.FgRed, color.Bold)
+	infoColor := color.New(color.FgCyan)
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open capture: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := capture.Read(f)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", args[0], err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("%s contains no captured requests", args[0])
+	}
+
+	target := replayTarget
+	if target == "" {
+		port := 3000
+		if cfg, err := config.Load(); err == nil && cfg.Server.Port != 0 {
+			port = cfg.Server.Port
+		}
+		target = fmt.Sprintf("http://localhost:%d", port)
+	}
+
+	extraHeaders, err := parseReplayHeaders(replayHeaders)
+	if err != nil {
+		return err
+	}
+
+	infoColor.Printf("Replaying %d request(s) against %s\n\n", len(entries), target)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	mismatches := 0
+	for _, entry := range entries {
+		result := replayEntry(client, target, entry, extraHeaders)
+		line := fmt.Sprintf("%s %s", entry.Method, requestURI(entry))
+
+		switch {
+		case result.Err != nil:
+			mismatches++
+			errorColor.Printf("✗ %s: %v\n", line, result.Err)
+		case !result.Matches(replayCompareBody):
+			mismatches++
+			if result.Status != entry.Status {
+				errorColor.Printf("✗ %s: status %d, captured %d\n", line, result.Status, entry.Status)
+			} else {
+				errorColor.Printf("✗ %s: response body differs from capture\n", line)
+			}
+		default:
+			successColor.Printf("✓ %s %d\n", line, result.Status)
+		}
+	}
+
+	fmt.Println()
+	if mismatches > 0 {
+		return fmt.Errorf("%d of %d replayed request(s) differ from the capture", mismatches, len(entries))
+	}
+	successColor.Printf("✓ All %d request(s) matched\n", len(entries))
+	return nil
+}
+
+// replayEntry re-sends a captured request. Redacted headers are dropped,
+// extra headers override captured ones, and compression is not requested so
+// bodies can be compared.
+func replayEntry(client *http.Client, target string, entry capture.Entry, extraHeaders http.Header) replayResult {
+	result := replayResult{Entry: entry}
+
+	if entry.BodyTruncated {
+		result.Err = fmt.Errorf("request body was truncated in the capture")
+		return result
+	}
+
+	req, err := http.NewRequest(entry.Method, strings.TrimSuffix(target, "/")+requestURI(entry), strings.NewReader(entry.Body))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	for name, value := range entry.Headers {
+		switch http.CanonicalHeaderKey(name) {
+		case "Host", "Content-Length", "Accept-Encoding", "Connection":
+			continue
+		}
+		if capture.IsRedacted(value) {
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	for name, values := range extraHeaders {
+		req.Header[name] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Status = resp.StatusCode
+	result.Body = string(body)
+	return result
+}
+
+// requestURI returns the captured path with its query string
+func requestURI(entry capture.Entry) string {
+	if entry.Query == "" {
+		return entry.Path
+	}
+	return entry.Path + "?" + entry.Query
+}
+
+// parseReplayHeaders parses "Name: value" flags
+func parseReplayHeaders(values []string) (http.Header, error) {
+	header := http.Header{}
+	for _, value := range values {
+		name, v, ok := strings.Cut(value, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header %q (expected \"Name: value\")", value)
+		}
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(v))
+	}
+	return header, nil
+}
+
+// jsonEqual compares two bodies as JSON when both parse, and byte-for-byte
+// otherwise. Fields redacted in the capture match any replayed value.
+func jsonEqual(captured, replayed string) bool {
+	var a, b interface{}
+	if json.Unmarshal([]byte(captured), &a) != nil || json.Unmarshal([]byte(replayed), &b) != nil {
+		return bytes.Equal([]byte(captured), []byte(replayed))
+	}
+	return jsonValuesEqual(a, b)
+}
+
+func jsonValuesEqual(captured, replayed interface{}) bool {
+	if s, ok := captured.(string); ok && capture.IsRedacted(s) {
+		return true
+	}
+
+	switch c := captured.(type) {
+	case map[string]interface{}:
+		r, ok := replayed.(map[string]interface{})
+		if !ok || len(c) != len(r) {
+			return false
+		}
+		for key, value := range c {
+			other, exists := r[key]
+			if !exists || !jsonValuesEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		r, ok := replayed.([]interface{})
+		if !ok || len(c) != len(r) {
+			return false
+		}
+		for i := range c {
+			if !jsonValuesEqual(c[i], r[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return captured == replayed
+	}
+}
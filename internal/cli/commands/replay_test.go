@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/pkg/web/capture"
+)
+
+func writeCapture(t *testing.T, entries ...capture.Entry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "capture.ndjson")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rec := capture.NewRecorder(f)
+	for _, entry := range entries {
+		if err := rec.Record(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestReplay_SendsCapturedRequests(t *testing.T) {
+	var received []*http.Request
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r)
+		bodies = append(bodies, string(body))
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		w.Write([]byte(`{"data":{"id":"1","token":"new"}}`))
+	}))
+	defer server.Close()
+
+	path := writeCapture(t,
+		capture.Entry{Method: "GET", Path: "/posts", Query: "sort=-title", Status: 200,
+			Headers:      map[string]string{"Authorization": capture.Redacted, "Accept": "application/json"},
+			ResponseBody: `{"data":{"token":"[REDACTED]","id":"1"}}`},
+		capture.Entry{Method: "POST", Path: "/posts", Body: `{"title":"Hi"}`, Status: 201,
+			ResponseBody: `{"data":{"id":"1","token":"[REDACTED]"}}`},
+	)
+
+	cmd := NewReplayCommand()
+	cmd.SetArgs([]string{path, "--target", server.URL, "--header", "Authorization: Bearer dev", "--compare-body"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(received))
+	}
+	if received[0].URL.RawQuery != "sort=-title" || received[0].Header.Get("Accept") != "application/json" {
+		t.Errorf("first request = %s %s, headers %v", received[0].Method, received[0].URL, received[0].Header)
+	}
+	if got := received[0].Header.Get("Authorization"); got != "Bearer dev" {
+		t.Errorf("Authorization = %q, want the --header value instead of the redacted one", got)
+	}
+	if bodies[1] != `{"title":"Hi"}` {
+		t.Errorf("POST body = %q", bodies[1])
+	}
+}
+
+func TestReplay_ReportsMismatches(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	path := writeCapture(t, capture.Entry{Method: "GET", Path: "/posts", Status: 200})
+
+	cmd := NewReplayCommand()
+	cmd.SetArgs([]string{path, "--target", server.URL})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "1 of 1 replayed request(s) differ") {
+		t.Errorf("expected mismatch error, got %v", err)
+	}
+}
+
+func TestJSONEqual(t *testing.T) {
+	tests := []struct {
+		captured, replayed string
+		want               bool
+	}{
+		{`{"a":1,"b":[1,2]}`, `{"b":[1,2],"a":1}`, true},
+		{`{"a":1}`, `{"a":2}`, false},
+		{`{"a":"[REDACTED]"}`, `{"a":"anything"}`, true},
+		{`{"a":1}`, `{"a":1,"b":2}`, false},
+		{`plain`, `plain`, true},
+	}
+
+	for _, tt := range tests {
+		if got := jsonEqual(tt.captured, tt.replayed); got != tt.want {
+			t.Errorf("jsonEqual(%s, %s) = %v, want %v", tt.captured, tt.replayed, got, tt.want)
+		}
+	}
+}
+
+func TestParseReplayHeaders(t *testing.T) {
+	header, err := parseReplayHeaders([]string{"X-Tenant: acme", "Authorization:Bearer x"})
+	if err != nil {
+		t.Fatalf("parseReplayHeaders() error = %v", err)
+	}
+	data, _ := json.Marshal(header)
+	if header.Get("X-Tenant") != "acme" || header.Get("Authorization") != "Bearer x" {
+		t.Errorf("header = %s", data)
+	}
+
+	if _, err := parseReplayHeaders([]string{"no-colon"}); err == nil {
+		t.Error("expected error for header without a colon")
+	}
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"runtime"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -36,11 +37,14 @@ Features:
   • Sub-second compilation`),
 		SilenceUsage:  true,
 		SilenceErrors: true,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// Disable color output globally if requested
 			if globalNoColor {
 				color.NoColor = true
 			}
+
+			// Refuse to run a CLI version other than the one the project pins
+			return checkVersionPin(cmd)
 		},
 	}
 
@@ -63,8 +67,21 @@ Features:
 	rootCmd.AddCommand(NewDocsCommand())
 	rootCmd.AddCommand(NewIntrospectCommand())
 	rootCmd.AddCommand(NewTestPatternsCommand())
+	rootCmd.AddCommand(NewPatternsCommand())
 	rootCmd.AddCommand(NewCompletionCommand())
 	rootCmd.AddCommand(NewScaffoldCommand())
+	rootCmd.AddCommand(NewRefactorCommand())
+	rootCmd.AddCommand(NewLintCommand())
+	rootCmd.AddCommand(NewDoctorCommand())
+	rootCmd.AddCommand(NewUpgradeCommand())
+	rootCmd.AddCommand(NewReplayCommand())
+	rootCmd.AddCommand(NewLoadtestCommand())
+	rootCmd.AddCommand(NewPackageCommand())
+	rootCmd.AddCommand(NewImportCommand())
+
+	// Add conduit-<name> executables on PATH, after the built-in commands so
+	// those take precedence
+	addPluginCommands(rootCmd)
 
 	return rootCmd
 }
@@ -103,7 +120,10 @@ func NewVersionCommand() *cobra.Command {
 // Execute runs the root command
 func Execute() error {
 	rootCmd := NewRootCommand()
-	if err := rootCmd.Execute(); err != nil {
+	start := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	reportUsage(cmd, err, time.Since(start))
+	if err != nil {
 		errorColor := color.New(color.FgRed, color.Bold)
 		errorColor.Fprintf(rootCmd.ErrOrStderr(), "Error: %v\n", err)
 		return err
@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/conduit-lang/conduit/internal/cli/config"
+)
+
+// conduitInstallPath is the package installed by `conduit upgrade`
+const conduitInstallPath = "github.com/conduit-lang/conduit/cmd/conduit"
+
+// SkipVersionCheckEnv disables conduit_version enforcement, for example when
+// testing a development build against a pinned project
+const SkipVersionCheckEnv = "CONDUIT_SKIP_VERSION_CHECK"
+
+var (
+	upgradePin    bool
+	upgradeDryRun bool
+)
+
+// installConduit installs the given CLI version with the Go toolchain.
+// Tests replace it to avoid network access.
+var installConduit = func(version string) error {
+	cmd := exec.Command("go", "install", conduitInstallPath+"@"+version)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// versionCheckExempt lists commands that run regardless of conduit_version,
+// so a mismatched CLI can still report its version and install the pinned one
+var versionCheckExempt = map[string]bool{
+	"version":    true,
+	"upgrade":    true,
+	"new":        true,
+	"completion": true,
+	"help":       true,
+}
+
+// NewUpgradeCommand creates the upgrade command
+func NewUpgradeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade [version]",
+		Short: "Install another version of the Conduit CLI",
+		Long: `Install a Conduit CLI release with 'go install'.
+
+Without an argument, the version pinned by conduit_version in conduit.yaml is
+installed; outside a pinned project the latest release is installed. With --pin,
+the installed version is written to conduit_version so every team member
+generates code with the same compiler.
+
+When conduit_version is set, every command except version, upgrade, new and
+completion fails if the running CLI is a different version. Set
+CONDUIT_SKIP_VERSION_CHECK=1 to bypass the check.
+
+Examples:
+  conduit upgrade
+  conduit upgrade v0.5.0
+  conduit upgrade latest --pin
+  conduit upgrade v0.5.0 --dry-run`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runUpgrade,
+	}
+
+	cmd.Flags().BoolVar(&upgradePin, "pin", false, "Write the version to conduit_version in conduit.yaml")
+	cmd.Flags().BoolVar(&upgradeDryRun, "dry-run", false, "Show what would be installed without installing")
+
+	return cmd
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	successColor := color.New(color.FgGreen, color.Bold)
+	infoColor := color.New(color.FgCyan)
+
+	version := "latest"
+	if len(args) == 1 {
+		version = args[0]
+	} else if pinned := pinnedVersion(); pinned != "" {
+		version = pinned
+	}
+	version = normalizeVersion(version)
+
+	if upgradePin && version == "latest" {
+		return fmt.Errorf("cannot pin 'latest' - pass an explicit version such as v0.5.0")
+	}
+
+	infoColor.Printf("Installing %s@%s\n", conduitInstallPath, version)
+	if upgradeDryRun {
+		fmt.Printf("  go install %s@%s\n", conduitInstallPath, version)
+	} else if err := installConduit(version); err != nil {
+		return fmt.Errorf("failed to install conduit %s: %w", version, err)
+	}
+
+	if upgradePin {
+		path := configFilePath()
+		if upgradeDryRun {
+			fmt.Printf("  would set conduit_version: %s in %s\n", version, path)
+		} else {
+			if err := setPinnedVersion(path, version); err != nil {
+				return err
+			}
+			infoColor.Printf("Pinned conduit_version: %s in %s\n", version, path)
+		}
+	}
+
+	if !upgradeDryRun {
+		successColor.Printf("✓ Installed conduit %s\n", version)
+		fmt.Println("  Make sure $(go env GOPATH)/bin comes first on your PATH")
+	}
+	return nil
+}
+
+// checkVersionPin enforces conduit_version for commands run inside a project
+func checkVersionPin(cmd *cobra.Command) error {
+	if versionCheckExempt[cmd.Name()] || os.Getenv(SkipVersionCheckEnv) != "" {
+		return nil
+	}
+	return verifyPinnedVersion(pinnedVersion(), Version)
+}
+
+// verifyPinnedVersion returns an error when the running CLI does not match the
+// pinned version. Development builds cannot be compared and are allowed.
+func verifyPinnedVersion(pinned, running string) error {
+	if pinned == "" || running == "dev" {
+		return nil
+	}
+	if normalizeVersion(pinned) == normalizeVersion(running) {
+		return nil
+	}
+	return fmt.Errorf(`this project is pinned to conduit %s but you are running %s
+
+Run 'conduit upgrade' to install the pinned version, or set %s=1 to skip this check`,
+		normalizeVersion(pinned), normalizeVersion(running), SkipVersionCheckEnv)
+}
+
+// pinnedVersion returns conduit_version from the project config, if any
+func pinnedVersion() string {
+	if !config.InProject() {
+		return ""
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(cfg.ConduitVersion)
+}
+
+// normalizeVersion adds the "v" prefix used by release tags, so "0.5.0" and
+// "v0.5.0" refer to the same release
+func normalizeVersion(version string) string {
+	if version == "latest" || version == "" || strings.HasPrefix(version, "v") {
+		return version
+	}
+	return "v" + version
+}
+
+var conduitVersionLine = regexp.MustCompile(`(?m)^conduit_version:.*$`)
+
+// setPinnedVersion writes conduit_version to the config file, replacing an
+// existing pin or adding one after project_name
+func setPinnedVersion(path, version string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	line := "conduit_version: " + version
+	updated := string(content)
+	switch {
+	case conduitVersionLine.MatchString(updated):
+		updated = conduitVersionLine.ReplaceAllString(updated, line)
+	default:
+		projectName := regexp.MustCompile(`(?m)^project_name:.*$`)
+		if loc := projectName.FindStringIndex(updated); loc != nil {
+			updated = updated[:loc[1]] + "\n" + line + updated[loc[1]:]
+		} else {
+			updated = line + "\n" + updated
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
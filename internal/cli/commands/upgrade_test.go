@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestVerifyPinnedVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		pinned  string
+		running string
+		wantErr bool
+	}{
+		{"no pin", "", "v0.4.0", false},
+		{"matching", "v0.5.0", "v0.5.0", false},
+		{"matching without prefix", "0.5.0", "v0.5.0", false},
+		{"mismatch", "v0.5.0", "v0.4.0", true},
+		{"development build", "v0.5.0", "dev", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyPinnedVersion(tt.pinned, tt.running)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifyPinnedVersion(%q, %q) error = %v, wantErr %v", tt.pinned, tt.running, err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "conduit upgrade") {
+				t.Errorf("error should suggest 'conduit upgrade': %v", err)
+			}
+		})
+	}
+}
+
+func TestSetPinnedVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "adds pin after project name",
+			content: "project_name: blog\nserver:\n  port: 3000\n",
+			want:    "project_name: blog\nconduit_version: v0.5.0\nserver:\n  port: 3000\n",
+		},
+		{
+			name:    "replaces existing pin",
+			content: "project_name: blog\nconduit_version: v0.4.0\n",
+			want:    "project_name: blog\nconduit_version: v0.5.0\n",
+		},
+		{
+			name:    "adds pin without project name",
+			content: "server:\n  port: 3000\n",
+			want:    "conduit_version: v0.5.0\nserver:\n  port: 3000\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := t.TempDir() + "/conduit.yaml"
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := setPinnedVersion(path, "v0.5.0"); err != nil {
+				t.Fatalf("setPinnedVersion() error = %v", err)
+			}
+			got, _ := os.ReadFile(path)
+			if string(got) != tt.want {
+				t.Errorf("config = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpgrade_InstallsPinnedVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	os.MkdirAll("app", 0755)
+	os.WriteFile("conduit.yaml", []byte("project_name: blog\nconduit_version: 0.5.0\n"), 0644)
+
+	original := installConduit
+	defer func() { installConduit = original }()
+	var installed []string
+	installConduit = func(version string) error {
+		installed = append(installed, version)
+		return nil
+	}
+
+	cmd := NewUpgradeCommand()
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	if len(installed) != 1 || installed[0] != "v0.5.0" {
+		t.Errorf("installed = %v, want [v0.5.0]", installed)
+	}
+}
+
+func TestRootCommand_EnforcesVersionPin(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	os.MkdirAll("app", 0755)
+	os.WriteFile("conduit.yaml", []byte("project_name: blog\nconduit_version: v9.9.9\n"), 0644)
+
+	oldVersion := Version
+	Version = "v0.1.0"
+	defer func() { Version = oldVersion }()
+	t.Setenv(SkipVersionCheckEnv, "")
+
+	cmd := NewRootCommand()
+	cmd.SetArgs([]string{"lint"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "pinned to conduit v9.9.9") {
+		t.Fatalf("expected version pin error, got %v", err)
+	}
+
+	// Subcommands with their own pre-run hook are checked too
+	cmd = NewRootCommand()
+	cmd.SetArgs([]string{"introspect", "resources"})
+	err = cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "pinned to conduit v9.9.9") {
+		t.Fatalf("expected version pin error from introspect, got %v", err)
+	}
+
+	// Exempt commands still run
+	cmd = NewRootCommand()
+	cmd.SetArgs([]string{"version"})
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("version should ignore the pin: %v", err)
+	}
+
+	t.Setenv(SkipVersionCheckEnv, "1")
+	if err := checkVersionPin(NewLintCommand()); err != nil {
+		t.Errorf("check should be skipped with %s set: %v", SkipVersionCheckEnv, err)
+	}
+}
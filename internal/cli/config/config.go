@@ -5,21 +5,41 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 // Config represents the Conduit configuration
 type Config struct {
-	ProjectName string         `mapstructure:"project_name"`
-	Database    DatabaseConfig `mapstructure:"database"`
-	Server      ServerConfig   `mapstructure:"server"`
-	Build       BuildConfig    `mapstructure:"build"`
+	ProjectName    string              `mapstructure:"project_name"`
+	ConduitVersion string              `mapstructure:"conduit_version"` // CLI version the project is pinned to
+	Database       DatabaseConfig      `mapstructure:"database"`
+	Server         ServerConfig        `mapstructure:"server"`
+	Build          BuildConfig         `mapstructure:"build"`
+	Middleware     []string            `mapstructure:"middleware"` // Middleware available to resources
+	Lint           LintConfig          `mapstructure:"lint"`
+	Analytics      AnalyticsConfig     `mapstructure:"analytics"`
+	Patterns       PatternsConfig      `mapstructure:"patterns"`
+	Admin          AdminConfig         `mapstructure:"admin"`
+	Introspection  IntrospectionConfig `mapstructure:"introspection"`
+	Playground     PlaygroundConfig    `mapstructure:"playground"`
+	Mail           MailConfig          `mapstructure:"mail"`
+	Notify         NotifyConfig        `mapstructure:"notify"`
+	Auth           AuthConfig          `mapstructure:"auth"`
+	Quota          QuotaConfig         `mapstructure:"quota"`
+	Timestamps     bool                `mapstructure:"timestamps"` // Add created_at and updated_at to every resource
+	Serialization  SerializationConfig `mapstructure:"serialization"`
+	Logging        LoggingConfig       `mapstructure:"logging"`
+	ErrorTracking  ErrorTrackingConfig `mapstructure:"error_tracking"`
+	Profiling      ProfilingConfig     `mapstructure:"profiling"`
 }
 
 // DatabaseConfig represents database configuration
 type DatabaseConfig struct {
 	URL string `mapstructure:"url"`
+	// Preflight generates a startup check that the database schema matches the build
+	Preflight bool `mapstructure:"preflight"`
 }
 
 // ServerConfig represents server configuration
@@ -27,12 +47,192 @@ type ServerConfig struct {
 	Port      int    `mapstructure:"port"`
 	Host      string `mapstructure:"host"`
 	APIPrefix string `mapstructure:"api_prefix"`
+	// Timeouts of the generated server, e.g. 30s; unset keeps the defaults
+	ReadTimeout     time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout    time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout     time.Duration `mapstructure:"idle_timeout"`
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
 }
 
 // BuildConfig represents build configuration
 type BuildConfig struct {
 	Output       string `mapstructure:"output"`
 	GeneratedDir string `mapstructure:"generated_dir"`
+	// MetadataBudget is the compressed metadata size, in bytes, each resource
+	// should stay under; 0 uses the default of 2048
+	MetadataBudget int `mapstructure:"metadata_budget"`
+	// StripSource replaces hook bodies in the metadata with their hashes
+	StripSource bool `mapstructure:"strip_source"`
+	// Target is where the application runs: server (the default), lambda
+	// or cloudrun
+	Target string `mapstructure:"target"`
+}
+
+// AnalyticsConfig configures opt-in usage reporting to a self-hosted endpoint.
+// Nothing is reported when Endpoint is empty.
+type AnalyticsConfig struct {
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// PatternsConfig configures `conduit patterns sync`
+type PatternsConfig struct {
+	// Store is the shared pattern store: a JSON file, an SQLite database
+	// (.db, .sqlite or .sqlite3) or an http(s) URL
+	Store string `mapstructure:"store"`
+}
+
+// AdminConfig controls the generated /admin UI
+type AdminConfig struct {
+	// Enabled mounts the admin UI in the generated application
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// IntrospectionConfig controls the metadata routes served at /introspection
+type IntrospectionConfig struct {
+	// Enabled mounts the paginated metadata routes in the generated application
+	Enabled bool `mapstructure:"enabled"`
+	// Redact sets what the distributed metadata includes of each sensitive part
+	Redact RedactConfig `mapstructure:"redact"`
+	// Auth is how callers of the metadata routes authenticate: none, bearer or mtls
+	Auth string `mapstructure:"auth"`
+	// Sections sets resources, routes, patterns and hooks to public, internal
+	// or hidden; sections left out are internal when auth is set
+	Sections map[string]string `mapstructure:"sections"`
+}
+
+// RedactConfig chooses full, hashed or omitted for each part of the metadata
+// that can reveal business logic; empty means full
+type RedactConfig struct {
+	Source        string `mapstructure:"source"`        // Hook bodies
+	FilePaths     string `mapstructure:"file_paths"`    // Source file paths
+	Documentation string `mapstructure:"documentation"` // Resource documentation
+}
+
+// PlaygroundConfig controls the API playground served at /docs
+type PlaygroundConfig struct {
+	// Enabled embeds the OpenAPI specification and mounts the playground
+	Enabled bool `mapstructure:"enabled"`
+	// Production keeps the playground available when the app runs in production
+	Production bool `mapstructure:"production"`
+}
+
+// MailConfig configures delivery for Mail.send. Mail is disabled when Provider
+// is empty; secrets come from the environment of the running application.
+type MailConfig struct {
+	Provider  string         `mapstructure:"provider"`  // smtp, ses or sendgrid
+	From      string         `mapstructure:"from"`      // Sender address
+	Templates string         `mapstructure:"templates"` // Template directory
+	SMTP      MailSMTPConfig `mapstructure:"smtp"`
+	SES       MailSESConfig  `mapstructure:"ses"`
+}
+
+// MailSMTPConfig configures the smtp mail provider
+type MailSMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+}
+
+// MailSESConfig configures the ses mail provider
+type MailSESConfig struct {
+	Region string `mapstructure:"region"`
+}
+
+// NotifyConfig configures delivery for Notify.send. Notifications are disabled
+// when no channels are configured; secrets come from the environment of the
+// running application.
+type NotifyConfig struct {
+	Queue    string                         `mapstructure:"queue"`   // Job queue; "notify" when empty
+	Workers  int                            `mapstructure:"workers"` // Delivery workers; 2 when zero
+	Channels map[string]NotifyChannelConfig `mapstructure:"channels"`
+}
+
+// NotifyChannelConfig configures one notification channel
+type NotifyChannelConfig struct {
+	Provider  string `mapstructure:"provider"`   // twilio, fcm or apns
+	RateLimit int    `mapstructure:"rate_limit"` // Messages per minute; 0 is unlimited
+	From      string `mapstructure:"from"`       // twilio
+	ProjectID string `mapstructure:"project_id"` // fcm
+	Topic     string `mapstructure:"topic"`      // apns
+	TeamID    string `mapstructure:"team_id"`    // apns
+	KeyID     string `mapstructure:"key_id"`     // apns
+	Sandbox   bool   `mapstructure:"sandbox"`    // apns
+}
+
+// AuthConfig configures social login. Login routes are generated for each
+// provider, signing users in as records of Resource; client credentials come
+// from the environment of the running application.
+type AuthConfig struct {
+	Resource  string   `mapstructure:"resource"`  // Resource users sign in as
+	Providers []string `mapstructure:"providers"` // google, github
+	Redirect  string   `mapstructure:"redirect"`  // Where the browser goes after login; JSON is returned when empty
+	BaseURL   string   `mapstructure:"base_url"`  // Public URL callback URLs are built on; from the request when empty
+}
+
+// QuotaConfig configures per-client request metering. Quotas are disabled when
+// Client is empty; API keys come from the environment of the running
+// application.
+type QuotaConfig struct {
+	Client    string                       `mapstructure:"client"`     // api_key or user
+	Header    string                       `mapstructure:"header"`     // API key header; X-API-Key when empty
+	Monthly   int64                        `mapstructure:"monthly"`    // Requests per month; 0 is unlimited
+	RateLimit int                          `mapstructure:"rate_limit"` // Requests per minute; 0 is unlimited
+	Clients   map[string]QuotaLimitsConfig `mapstructure:"clients"`    // Limits by client name or user ID
+}
+
+// QuotaLimitsConfig overrides the default quota limits for one client
+type QuotaLimitsConfig struct {
+	Monthly   int64 `mapstructure:"monthly"`
+	RateLimit int   `mapstructure:"rate_limit"`
+}
+
+// SerializationConfig sets the JSON conventions of the generated API, applied
+// to responses, request bodies, query parameters and the OpenAPI specification
+type SerializationConfig struct {
+	Casing   string `mapstructure:"casing"`   // snake_case or camelCase keys; field names as declared when empty
+	Envelope bool   `mapstructure:"envelope"` // Wrap plain JSON records and lists in {"data": ...}
+	Nulls    string `mapstructure:"nulls"`    // omit or include null fields; omit when empty
+}
+
+// LoggingConfig sets the request log of the generated application
+type LoggingConfig struct {
+	Format string `mapstructure:"format"` // json or text records; text when empty
+}
+
+// ErrorTrackingConfig reports the panics and hook errors of the generated
+// application to a Sentry-compatible tracker. The DSN comes from the
+// environment of the running application.
+type ErrorTrackingConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	Environment string `mapstructure:"environment"` // e.g. production; SENTRY_ENVIRONMENT overrides it
+	Release     string `mapstructure:"release"`     // The build's git commit when empty
+}
+
+// ProfilingConfig serves the Go profiler of the generated application and
+// sends continuous profiles to a profiling agent. Agent credentials come from
+// the environment of the running application.
+type ProfilingConfig struct {
+	Pprof         bool              `mapstructure:"pprof"`          // Serve net/http/pprof on /debug/pprof
+	BlockRate     int               `mapstructure:"block_rate"`     // runtime.SetBlockProfileRate; 0 leaves it off
+	MutexFraction int               `mapstructure:"mutex_fraction"` // runtime.SetMutexProfileFraction; 0 leaves it off
+	Agent         string            `mapstructure:"agent"`          // pyroscope; none when empty
+	ServerAddress string            `mapstructure:"server_address"` // PYROSCOPE_SERVER_ADDRESS overrides it
+	Application   string            `mapstructure:"application"`    // project_name when empty
+	Interval      time.Duration     `mapstructure:"interval"`       // 10s when zero
+	Tags          map[string]string `mapstructure:"tags"`
+}
+
+// LintConfig represents `conduit lint` configuration
+type LintConfig struct {
+	Budgets BudgetConfig `mapstructure:"budgets"`
+}
+
+// BudgetConfig declares complexity budgets enforced by `conduit lint`.
+// A zero value disables the corresponding budget.
+type BudgetConfig struct {
+	MaxFieldsPerResource int `mapstructure:"max_fields_per_resource"`
+	MaxDependencyDepth   int `mapstructure:"max_dependency_depth"`
+	MaxHooksPerEvent     int `mapstructure:"max_hooks_per_event"`
 }
 
 // Load loads the configuration from conduit.yml or conduit.yaml
@@ -40,11 +240,14 @@ func Load() (*Config, error) {
 	v := viper.New()
 
 	// Set defaults
+	v.SetDefault("database.preflight", true)
+	v.SetDefault("playground.enabled", true)
 	v.SetDefault("server.port", 3000)
 	v.SetDefault("server.host", "localhost")
 	v.SetDefault("server.api_prefix", "")
 	v.SetDefault("build.output", "build/app")
 	v.SetDefault("build.generated_dir", "build/generated")
+	v.SetDefault("mail.templates", "app/mail")
 
 	// Set config name and paths
 	v.SetConfigName("conduit")
@@ -151,5 +354,181 @@ func validateConfig(cfg *Config) error {
 			return fmt.Errorf("server.api_prefix must not end with '/', got: %s", cfg.Server.APIPrefix)
 		}
 	}
+
+	// Server timeouts must be non-negative; zero keeps the default
+	server := cfg.Server
+	if server.ReadTimeout < 0 || server.WriteTimeout < 0 || server.IdleTimeout < 0 || server.ShutdownTimeout < 0 {
+		return fmt.Errorf("server timeouts must not be negative")
+	}
+
+	// Budgets must be non-negative; zero disables a budget
+	budgets := cfg.Lint.Budgets
+	if budgets.MaxFieldsPerResource < 0 || budgets.MaxDependencyDepth < 0 || budgets.MaxHooksPerEvent < 0 {
+		return fmt.Errorf("lint.budgets values must not be negative")
+	}
+
+	// Serverless platforms terminate TLS, so they cannot pass client
+	// certificates on to the application
+	switch cfg.Build.Target {
+	case "", "server":
+	case "lambda", "cloudrun":
+		if cfg.Introspection.Enabled && cfg.Introspection.Auth == "mtls" {
+			return fmt.Errorf("introspection.auth mtls is not supported by build.target %s; use bearer", cfg.Build.Target)
+		}
+	default:
+		return fmt.Errorf("build.target must be server, lambda or cloudrun, got: %s", cfg.Build.Target)
+	}
+
+	// Metadata redaction modes are full, hashed or omitted
+	for name, mode := range map[string]string{
+		"source":        cfg.Introspection.Redact.Source,
+		"file_paths":    cfg.Introspection.Redact.FilePaths,
+		"documentation": cfg.Introspection.Redact.Documentation,
+	} {
+		switch mode {
+		case "", "full", "hashed", "omitted":
+		default:
+			return fmt.Errorf("introspection.redact.%s must be full, hashed or omitted, got: %s", name, mode)
+		}
+	}
+
+	// Metadata routes need a known auth mode, and internal sections need auth
+	switch cfg.Introspection.Auth {
+	case "", "none", "bearer", "mtls":
+	default:
+		return fmt.Errorf("introspection.auth must be none, bearer or mtls, got: %s", cfg.Introspection.Auth)
+	}
+	for section, level := range cfg.Introspection.Sections {
+		switch section {
+		case "resources", "routes", "patterns", "hooks":
+		default:
+			return fmt.Errorf("introspection.sections.%s is not a section; use resources, routes, patterns or hooks", section)
+		}
+		switch level {
+		case "public", "hidden":
+		case "internal":
+			if cfg.Introspection.Auth == "" || cfg.Introspection.Auth == "none" {
+				return fmt.Errorf("introspection.sections.%s is internal but introspection.auth is not set", section)
+			}
+		default:
+			return fmt.Errorf("introspection.sections.%s must be public, internal or hidden, got: %s", section, level)
+		}
+	}
+
+	// Mail needs a known provider and a sender; provider secrets are checked at startup
+	switch cfg.Mail.Provider {
+	case "":
+	case "smtp", "ses", "sendgrid":
+		if cfg.Mail.From == "" {
+			return fmt.Errorf("mail.from is required when mail.provider is set")
+		}
+		if cfg.Mail.Provider == "smtp" && cfg.Mail.SMTP.Host == "" {
+			return fmt.Errorf("mail.smtp.host is required for the smtp provider")
+		}
+	default:
+		return fmt.Errorf("mail.provider must be smtp, ses or sendgrid, got: %s", cfg.Mail.Provider)
+	}
+
+	// Notification channels need a known provider and its required settings
+	if cfg.Notify.Workers < 0 {
+		return fmt.Errorf("notify.workers must not be negative")
+	}
+	for name, ch := range cfg.Notify.Channels {
+		if ch.RateLimit < 0 {
+			return fmt.Errorf("notify.channels.%s.rate_limit must not be negative", name)
+		}
+		switch ch.Provider {
+		case "twilio":
+			if ch.From == "" {
+				return fmt.Errorf("notify.channels.%s.from is required for the twilio provider", name)
+			}
+		case "fcm":
+		case "apns":
+			if ch.Topic == "" || ch.TeamID == "" || ch.KeyID == "" {
+				return fmt.Errorf("notify.channels.%s needs topic, team_id and key_id for the apns provider", name)
+			}
+		default:
+			return fmt.Errorf("notify.channels.%s.provider must be twilio, fcm or apns, got: %s", name, ch.Provider)
+		}
+	}
+
+	// Social login needs known providers and a resource to sign users in as
+	seen := make(map[string]bool, len(cfg.Auth.Providers))
+	for _, provider := range cfg.Auth.Providers {
+		if provider != "google" && provider != "github" {
+			return fmt.Errorf("auth.providers must contain google or github, got: %s", provider)
+		}
+		if seen[provider] {
+			return fmt.Errorf("auth.providers must not repeat a provider, got: %s", provider)
+		}
+		seen[provider] = true
+	}
+	if len(cfg.Auth.Providers) > 0 && cfg.Auth.Resource == "" {
+		return fmt.Errorf("auth.resource is required when auth.providers is set")
+	}
+	if cfg.Auth.BaseURL != "" && !strings.HasPrefix(cfg.Auth.BaseURL, "https://") && !strings.HasPrefix(cfg.Auth.BaseURL, "http://") {
+		return fmt.Errorf("auth.base_url must be an http or https URL, got: %s", cfg.Auth.BaseURL)
+	}
+	if cfg.Auth.Redirect != "" && !strings.HasPrefix(cfg.Auth.Redirect, "/") && !strings.HasPrefix(cfg.Auth.Redirect, "http") {
+		return fmt.Errorf("auth.redirect must be a path or an http URL, got: %s", cfg.Auth.Redirect)
+	}
+
+	// Quotas need a way to identify clients and non-negative limits
+	switch cfg.Quota.Client {
+	case "":
+		if cfg.Quota.Monthly != 0 || cfg.Quota.RateLimit != 0 || len(cfg.Quota.Clients) > 0 {
+			return fmt.Errorf("quota.client is required when quota limits are set")
+		}
+	case "api_key", "user":
+	default:
+		return fmt.Errorf("quota.client must be api_key or user, got: %s", cfg.Quota.Client)
+	}
+	if cfg.Quota.Header != "" && cfg.Quota.Client != "api_key" {
+		return fmt.Errorf("quota.header is only used with api_key clients")
+	}
+	if cfg.Quota.Monthly < 0 || cfg.Quota.RateLimit < 0 {
+		return fmt.Errorf("quota limits must not be negative")
+	}
+	for name, limits := range cfg.Quota.Clients {
+		if limits.Monthly < 0 || limits.RateLimit < 0 {
+			return fmt.Errorf("quota.clients.%s limits must not be negative", name)
+		}
+	}
+
+	// Serialization settings are applied by the code generator
+	switch cfg.Serialization.Casing {
+	case "", "snake_case", "camelCase":
+	default:
+		return fmt.Errorf("serialization.casing must be snake_case or camelCase, got: %s", cfg.Serialization.Casing)
+	}
+	switch cfg.Serialization.Nulls {
+	case "", "omit", "include":
+	default:
+		return fmt.Errorf("serialization.nulls must be omit or include, got: %s", cfg.Serialization.Nulls)
+	}
+
+	switch cfg.Logging.Format {
+	case "", "json", "text":
+	default:
+		return fmt.Errorf("logging.format must be json or text, got: %s", cfg.Logging.Format)
+	}
+
+	// Profiling needs a known agent and non-negative rates
+	profiling := cfg.Profiling
+	switch profiling.Agent {
+	case "", "pyroscope":
+	default:
+		return fmt.Errorf("profiling.agent must be pyroscope, got: %s", profiling.Agent)
+	}
+	if profiling.BlockRate < 0 || profiling.MutexFraction < 0 || profiling.Interval < 0 {
+		return fmt.Errorf("profiling rates and interval must not be negative")
+	}
+	if profiling.Interval > 0 && profiling.Interval < time.Second {
+		return fmt.Errorf("profiling.interval must be at least 1s, got: %s", profiling.Interval)
+	}
+	if profiling.Agent == "" && (profiling.ServerAddress != "" || profiling.Interval != 0 || len(profiling.Tags) > 0) {
+		return fmt.Errorf("profiling.agent is required when agent settings are set")
+	}
+
 	return nil
 }
@@ -3,7 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -38,6 +40,10 @@ func TestLoad(t *testing.T) {
 	if cfg.Build.GeneratedDir != "build/generated" {
 		t.Errorf("expected default generated dir 'build/generated', got %s", cfg.Build.GeneratedDir)
 	}
+
+	if !cfg.Database.Preflight {
+		t.Error("expected preflight to be enabled by default")
+	}
 }
 
 func TestLoadWithConfigFile(t *testing.T) {
@@ -50,6 +56,7 @@ func TestLoadWithConfigFile(t *testing.T) {
 	// Write config file
 	configContent := `
 project_name: test-project
+conduit_version: v0.5.0
 server:
   port: 8080
   host: 0.0.0.0
@@ -58,6 +65,7 @@ build:
   generated_dir: dist/generated
 database:
   url: postgresql://localhost/testdb
+  preflight: false
 `
 	os.WriteFile("conduit.yml", []byte(configContent), 0644)
 
@@ -70,6 +78,10 @@ database:
 		t.Errorf("expected project name 'test-project', got %s", cfg.ProjectName)
 	}
 
+	if cfg.ConduitVersion != "v0.5.0" {
+		t.Errorf("expected conduit version 'v0.5.0', got %s", cfg.ConduitVersion)
+	}
+
 	if cfg.Server.Port != 8080 {
 		t.Errorf("expected port 8080, got %d", cfg.Server.Port)
 	}
@@ -85,6 +97,10 @@ database:
 	if cfg.Database.URL != "postgresql://localhost/testdb" {
 		t.Errorf("expected database URL, got %s", cfg.Database.URL)
 	}
+
+	if cfg.Database.Preflight {
+		t.Error("expected preflight to be disabled by database.preflight: false")
+	}
 }
 
 func TestGetDatabaseURL(t *testing.T) {
@@ -288,6 +304,781 @@ func TestAPIPrefixDefault(t *testing.T) {
 	}
 }
 
+func TestServerTimeoutsConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	os.WriteFile("conduit.yml", []byte(`
+server:
+  read_timeout: 10s
+  shutdown_timeout: 1m
+`), 0644)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Server.ReadTimeout != 10*time.Second || cfg.Server.ShutdownTimeout != time.Minute {
+		t.Errorf("unexpected timeouts: %+v", cfg.Server)
+	}
+	if cfg.Server.WriteTimeout != 0 || cfg.Server.IdleTimeout != 0 {
+		t.Errorf("unset timeouts should be zero: %+v", cfg.Server)
+	}
+
+	os.WriteFile("conduit.yml", []byte("server:\n  idle_timeout: -5s\n"), 0644)
+	if _, err := Load(); err == nil || !contains(err.Error(), "server timeouts must not be negative") {
+		t.Errorf("expected negative timeout error, got %v", err)
+	}
+}
+
+func TestMailConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantError bool
+		errMsg    string
+	}{
+		{
+			name:   "mail disabled by default",
+			config: "",
+		},
+		{
+			name: "valid smtp provider",
+			config: `
+mail:
+  provider: smtp
+  from: "Blog <noreply@example.com>"
+  smtp:
+    host: smtp.example.com
+    port: 2525
+`,
+		},
+		{
+			name: "valid sendgrid provider",
+			config: `
+mail:
+  provider: sendgrid
+  from: noreply@example.com
+`,
+		},
+		{
+			name: "unknown provider",
+			config: `
+mail:
+  provider: mailgun
+  from: noreply@example.com
+`,
+			wantError: true,
+			errMsg:    "mail.provider must be smtp, ses or sendgrid",
+		},
+		{
+			name: "provider without from",
+			config: `
+mail:
+  provider: ses
+`,
+			wantError: true,
+			errMsg:    "mail.from is required",
+		},
+		{
+			name: "smtp without host",
+			config: `
+mail:
+  provider: smtp
+  from: noreply@example.com
+`,
+			wantError: true,
+			errMsg:    "mail.smtp.host is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			oldWd, _ := os.Getwd()
+			os.Chdir(tmpDir)
+			defer os.Chdir(oldWd)
+
+			os.WriteFile("conduit.yml", []byte(tt.config), 0644)
+
+			cfg, err := Load()
+
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("expected error containing %q, got nil", tt.errMsg)
+				} else if !contains(err.Error(), tt.errMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if cfg.Mail.Templates != "app/mail" {
+				t.Errorf("expected default mail.templates to be app/mail, got %q", cfg.Mail.Templates)
+			}
+		})
+	}
+}
+
+func TestNotifyConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantError bool
+		errMsg    string
+	}{
+		{
+			name: "valid channels",
+			config: `
+notify:
+  workers: 4
+  channels:
+    sms:
+      provider: twilio
+      from: "+15550001234"
+      rate_limit: 60
+    push:
+      provider: fcm
+    ios:
+      provider: apns
+      topic: com.example.app
+      team_id: TEAM
+      key_id: KEY
+`,
+		},
+		{
+			name: "unknown provider",
+			config: `
+notify:
+  channels:
+    fax:
+      provider: telecopier
+`,
+			wantError: true,
+			errMsg:    "notify.channels.fax.provider must be twilio, fcm or apns",
+		},
+		{
+			name: "twilio without from",
+			config: `
+notify:
+  channels:
+    sms:
+      provider: twilio
+`,
+			wantError: true,
+			errMsg:    "notify.channels.sms.from is required",
+		},
+		{
+			name: "apns without key",
+			config: `
+notify:
+  channels:
+    ios:
+      provider: apns
+      topic: com.example.app
+`,
+			wantError: true,
+			errMsg:    "needs topic, team_id and key_id",
+		},
+		{
+			name: "negative rate limit",
+			config: `
+notify:
+  channels:
+    push:
+      provider: fcm
+      rate_limit: -1
+`,
+			wantError: true,
+			errMsg:    "rate_limit must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			oldWd, _ := os.Getwd()
+			os.Chdir(tmpDir)
+			defer os.Chdir(oldWd)
+
+			os.WriteFile("conduit.yml", []byte(tt.config), 0644)
+
+			cfg, err := Load()
+
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("expected error containing %q, got nil", tt.errMsg)
+				} else if !contains(err.Error(), tt.errMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			sms := cfg.Notify.Channels["sms"]
+			if len(cfg.Notify.Channels) != 3 || sms.From != "+15550001234" || sms.RateLimit != 60 {
+				t.Errorf("unexpected channels: %+v", cfg.Notify.Channels)
+			}
+			if cfg.Notify.Workers != 4 {
+				t.Errorf("expected 4 workers, got %d", cfg.Notify.Workers)
+			}
+		})
+	}
+}
+
+func TestAuthConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantError bool
+		errMsg    string
+	}{
+		{
+			name: "valid providers",
+			config: `
+auth:
+  resource: User
+  providers: [google, github]
+  redirect: /
+  base_url: https://example.com
+`,
+		},
+		{
+			name: "unknown provider",
+			config: `
+auth:
+  resource: User
+  providers: [myspace]
+`,
+			wantError: true,
+			errMsg:    "auth.providers must contain google or github",
+		},
+		{
+			name: "repeated provider",
+			config: `
+auth:
+  resource: User
+  providers: [google, google]
+`,
+			wantError: true,
+			errMsg:    "auth.providers must not repeat a provider",
+		},
+		{
+			name: "missing resource",
+			config: `
+auth:
+  providers: [github]
+`,
+			wantError: true,
+			errMsg:    "auth.resource is required",
+		},
+		{
+			name: "relative base url",
+			config: `
+auth:
+  resource: User
+  providers: [github]
+  base_url: example.com
+`,
+			wantError: true,
+			errMsg:    "auth.base_url must be an http or https URL",
+		},
+		{
+			name: "bad redirect",
+			config: `
+auth:
+  resource: User
+  providers: [github]
+  redirect: dashboard
+`,
+			wantError: true,
+			errMsg:    "auth.redirect must be a path or an http URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			oldWd, _ := os.Getwd()
+			os.Chdir(tmpDir)
+			defer os.Chdir(oldWd)
+
+			os.WriteFile("conduit.yml", []byte(tt.config), 0644)
+
+			cfg, err := Load()
+
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("expected error containing %q, got nil", tt.errMsg)
+				} else if !contains(err.Error(), tt.errMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if cfg.Auth.Resource != "User" || len(cfg.Auth.Providers) != 2 || cfg.Auth.Providers[1] != "github" {
+				t.Errorf("unexpected auth config: %+v", cfg.Auth)
+			}
+			if cfg.Auth.BaseURL != "https://example.com" || cfg.Auth.Redirect != "/" {
+				t.Errorf("unexpected auth urls: %+v", cfg.Auth)
+			}
+		})
+	}
+}
+
+func TestQuotaConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantError bool
+		errMsg    string
+	}{
+		{
+			name: "valid quotas",
+			config: `
+quota:
+  client: api_key
+  header: X-Client-Key
+  monthly: 10000
+  rate_limit: 60
+  clients:
+    acme: {monthly: 1000000, rate_limit: 600}
+`,
+		},
+		{
+			name: "unknown client",
+			config: `
+quota:
+  client: ip
+`,
+			wantError: true,
+			errMsg:    "quota.client must be api_key or user",
+		},
+		{
+			name: "limits without client",
+			config: `
+quota:
+  monthly: 100
+`,
+			wantError: true,
+			errMsg:    "quota.client is required",
+		},
+		{
+			name: "header for users",
+			config: `
+quota:
+  client: user
+  header: X-Client-Key
+`,
+			wantError: true,
+			errMsg:    "quota.header is only used with api_key clients",
+		},
+		{
+			name: "negative limit",
+			config: `
+quota:
+  client: user
+  rate_limit: -1
+`,
+			wantError: true,
+			errMsg:    "quota limits must not be negative",
+		},
+		{
+			name: "negative client limit",
+			config: `
+quota:
+  client: api_key
+  clients:
+    acme: {monthly: -5}
+`,
+			wantError: true,
+			errMsg:    "quota.clients.acme limits must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			oldWd, _ := os.Getwd()
+			os.Chdir(tmpDir)
+			defer os.Chdir(oldWd)
+
+			os.WriteFile("conduit.yml", []byte(tt.config), 0644)
+
+			cfg, err := Load()
+
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("expected error containing %q, got nil", tt.errMsg)
+				} else if !contains(err.Error(), tt.errMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if cfg.Quota.Client != "api_key" || cfg.Quota.Header != "X-Client-Key" || cfg.Quota.Monthly != 10000 || cfg.Quota.RateLimit != 60 {
+				t.Errorf("unexpected quota config: %+v", cfg.Quota)
+			}
+			if acme := cfg.Quota.Clients["acme"]; acme.Monthly != 1000000 || acme.RateLimit != 600 {
+				t.Errorf("unexpected client limits: %+v", cfg.Quota.Clients)
+			}
+		})
+	}
+}
+
+func TestSerializationConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantError bool
+		errMsg    string
+	}{
+		{
+			name: "valid serialization",
+			config: `
+serialization:
+  casing: camelCase
+  envelope: true
+  nulls: include
+`,
+		},
+		{
+			name: "unknown casing",
+			config: `
+serialization:
+  casing: kebab-case
+`,
+			wantError: true,
+			errMsg:    "serialization.casing must be snake_case or camelCase",
+		},
+		{
+			name: "unknown nulls",
+			config: `
+serialization:
+  nulls: drop
+`,
+			wantError: true,
+			errMsg:    "serialization.nulls must be omit or include",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			oldWd, _ := os.Getwd()
+			os.Chdir(tmpDir)
+			defer os.Chdir(oldWd)
+
+			os.WriteFile("conduit.yml", []byte(tt.config), 0644)
+
+			cfg, err := Load()
+
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("expected error containing %q, got nil", tt.errMsg)
+				} else if !contains(err.Error(), tt.errMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if cfg.Serialization.Casing != "camelCase" || !cfg.Serialization.Envelope || cfg.Serialization.Nulls != "include" {
+				t.Errorf("unexpected serialization config: %+v", cfg.Serialization)
+			}
+		})
+	}
+}
+
+func TestLoggingConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		wantFormat string
+		errMsg     string
+	}{
+		{name: "default", config: "project_name: app\n", wantFormat: ""},
+		{name: "json", config: "logging:\n  format: json\n", wantFormat: "json"},
+		{name: "unknown format", config: "logging:\n  format: logfmt\n", errMsg: "logging.format must be json or text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			oldWd, _ := os.Getwd()
+			os.Chdir(tmpDir)
+			defer os.Chdir(oldWd)
+
+			os.WriteFile("conduit.yml", []byte(tt.config), 0644)
+
+			cfg, err := Load()
+
+			if tt.errMsg != "" {
+				if err == nil || !contains(err.Error(), tt.errMsg) {
+					t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if cfg.Logging.Format != tt.wantFormat {
+				t.Errorf("Logging.Format = %q, want %q", cfg.Logging.Format, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestErrorTrackingConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	// Disabled by default
+	os.WriteFile("conduit.yml", []byte("project_name: app\n"), 0644)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.ErrorTracking.Enabled {
+		t.Error("error tracking should be disabled by default")
+	}
+
+	os.WriteFile("conduit.yml", []byte("error_tracking:\n  enabled: true\n  environment: production\n  release: v1.2.0\n"), 0644)
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := ErrorTrackingConfig{Enabled: true, Environment: "production", Release: "v1.2.0"}
+	if cfg.ErrorTracking != want {
+		t.Errorf("ErrorTracking = %+v, want %+v", cfg.ErrorTracking, want)
+	}
+}
+
+func TestProfilingConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   ProfilingConfig
+		errMsg string
+	}{
+		{name: "default", config: "project_name: app\n"},
+		{
+			name:   "pprof and agent",
+			config: "profiling:\n  pprof: true\n  block_rate: 1\n  agent: pyroscope\n  server_address: http://pyroscope:4040\n  interval: 15s\n  tags:\n    region: eu\n",
+			want: ProfilingConfig{
+				Pprof:         true,
+				BlockRate:     1,
+				Agent:         "pyroscope",
+				ServerAddress: "http://pyroscope:4040",
+				Interval:      15 * time.Second,
+				Tags:          map[string]string{"region": "eu"},
+			},
+		},
+		{name: "unknown agent", config: "profiling:\n  agent: datadog\n", errMsg: "profiling.agent must be pyroscope"},
+		{name: "negative rate", config: "profiling:\n  mutex_fraction: -1\n", errMsg: "must not be negative"},
+		{name: "short interval", config: "profiling:\n  agent: pyroscope\n  interval: 100ms\n", errMsg: "profiling.interval must be at least 1s"},
+		{name: "settings without agent", config: "profiling:\n  server_address: http://pyroscope:4040\n", errMsg: "profiling.agent is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			oldWd, _ := os.Getwd()
+			os.Chdir(tmpDir)
+			defer os.Chdir(oldWd)
+
+			os.WriteFile("conduit.yml", []byte(tt.config), 0644)
+
+			cfg, err := Load()
+
+			if tt.errMsg != "" {
+				if err == nil || !contains(err.Error(), tt.errMsg) {
+					t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Profiling, tt.want) {
+				t.Errorf("Profiling = %+v, want %+v", cfg.Profiling, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTargetConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+		errMsg string
+	}{
+		{name: "default", config: "project_name: app\n"},
+		{name: "lambda", config: "build:\n  target: lambda\n", want: "lambda"},
+		{name: "cloudrun with bearer introspection", config: "build:\n  target: cloudrun\nintrospection:\n  enabled: true\n  auth: bearer\n", want: "cloudrun"},
+		{name: "unknown target", config: "build:\n  target: fargate\n", errMsg: "build.target must be server, lambda or cloudrun"},
+		{name: "mtls behind lambda", config: "build:\n  target: lambda\nintrospection:\n  enabled: true\n  auth: mtls\n", errMsg: "introspection.auth mtls is not supported by build.target lambda"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			oldWd, _ := os.Getwd()
+			os.Chdir(tmpDir)
+			defer os.Chdir(oldWd)
+
+			os.WriteFile("conduit.yml", []byte(tt.config), 0644)
+
+			cfg, err := Load()
+
+			if tt.errMsg != "" {
+				if err == nil || !contains(err.Error(), tt.errMsg) {
+					t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if cfg.Build.Target != tt.want {
+				t.Errorf("Build.Target = %q, want %q", cfg.Build.Target, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntrospectionRedactConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantError bool
+		errMsg    string
+	}{
+		{
+			name: "valid redaction",
+			config: `
+introspection:
+  redact:
+    source: hashed
+    file_paths: omitted
+    documentation: full
+`,
+		},
+		{
+			name: "unknown mode",
+			config: `
+introspection:
+  redact:
+    source: hidden
+`,
+			wantError: true,
+			errMsg:    "introspection.redact.source must be full, hashed or omitted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			oldWd, _ := os.Getwd()
+			os.Chdir(tmpDir)
+			defer os.Chdir(oldWd)
+
+			os.WriteFile("conduit.yml", []byte(tt.config), 0644)
+
+			cfg, err := Load()
+
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("expected error containing %q, got nil", tt.errMsg)
+				} else if !contains(err.Error(), tt.errMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			redact := cfg.Introspection.Redact
+			if redact.Source != "hashed" || redact.FilePaths != "omitted" || redact.Documentation != "full" {
+				t.Errorf("unexpected redact config: %+v", redact)
+			}
+		})
+	}
+}
+
+func TestIntrospectionAccessConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		errMsg string
+	}{
+		{
+			name: "valid access",
+			config: `
+introspection:
+  auth: bearer
+  sections:
+    routes: public
+    hooks: internal
+    patterns: hidden
+`,
+		},
+		{
+			name:   "unknown auth",
+			config: "introspection:\n  auth: basic\n",
+			errMsg: "introspection.auth must be none, bearer or mtls",
+		},
+		{
+			name:   "unknown section",
+			config: "introspection:\n  auth: mtls\n  sections:\n    scopes: public\n",
+			errMsg: "introspection.sections.scopes is not a section",
+		},
+		{
+			name:   "internal without auth",
+			config: "introspection:\n  sections:\n    hooks: internal\n",
+			errMsg: "introspection.sections.hooks is internal but introspection.auth is not set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			oldWd, _ := os.Getwd()
+			os.Chdir(tmpDir)
+			defer os.Chdir(oldWd)
+
+			os.WriteFile("conduit.yml", []byte(tt.config), 0644)
+
+			cfg, err := Load()
+
+			if tt.errMsg != "" {
+				if err == nil || !contains(err.Error(), tt.errMsg) {
+					t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if cfg.Introspection.Auth != "bearer" || cfg.Introspection.Sections["hooks"] != "internal" {
+				t.Errorf("unexpected introspection config: %+v", cfg.Introspection)
+			}
+		})
+	}
+}
+
 // Helper function to check if string contains substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
@@ -0,0 +1,358 @@
+package patternstore
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver for .db stores
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+// TokenEnvVar holds the bearer token sent to HTTP stores
+const TokenEnvVar = "CONDUIT_PATTERN_STORE_TOKEN"
+
+// DefaultTimeout bounds each request to an HTTP store
+const DefaultTimeout = 10 * time.Second
+
+// maxAttempts is how often an HTTP update is retried after a conflict
+const maxAttempts = 3
+
+// Open returns the backend for location: an http:// or https:// URL, an
+// SQLite database path ending in .db, .sqlite or .sqlite3, or the path of a
+// JSON file
+func Open(location string) (Backend, error) {
+	switch {
+	case location == "":
+		return nil, fmt.Errorf("no pattern store configured")
+	case strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://"):
+		return &httpBackend{
+			url:    location,
+			token:  os.Getenv(TokenEnvVar),
+			client: &http.Client{Timeout: DefaultTimeout},
+		}, nil
+	}
+
+	switch strings.ToLower(filepath.Ext(location)) {
+	case ".db", ".sqlite", ".sqlite3":
+		return openSQLite(location)
+	}
+	return &fileBackend{path: location}, nil
+}
+
+// decode reads a store document, filling in what an empty one lacks
+func decode(r io.Reader) (*Store, error) {
+	store := New()
+	if err := json.NewDecoder(r).Decode(store); err != nil {
+		return nil, fmt.Errorf("invalid pattern store: %w", err)
+	}
+	if store.Version != Version {
+		return nil, fmt.Errorf("unsupported pattern store version %q (expected %s)", store.Version, Version)
+	}
+	if store.Patterns == nil {
+		store.Patterns = []Pattern{}
+	}
+	return store, nil
+}
+
+// fileBackend keeps the store in a JSON file, e.g. on a shared drive
+type fileBackend struct {
+	path string
+}
+
+func (b *fileBackend) Load(ctx context.Context) (*Store, error) {
+	f, err := os.Open(b.path)
+	if os.IsNotExist(err) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pattern store: %w", err)
+	}
+	defer f.Close()
+	return decode(f)
+}
+
+// Update writes the store to a temporary file and renames it over the old
+// one, so readers never see a partial document
+func (b *fileBackend) Update(ctx context.Context, fn func(*Store) error) error {
+	store, err := b.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(store); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pattern store: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return fmt.Errorf("failed to create pattern store directory: %w", err)
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write pattern store: %w", err)
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write pattern store: %w", err)
+	}
+	return nil
+}
+
+func (b *fileBackend) Close() error { return nil }
+
+// sqliteSchema stores one row per pattern and one per project contribution
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS patterns (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	category TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	template TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS pattern_contributions (
+	pattern_id TEXT NOT NULL REFERENCES patterns(id),
+	project TEXT NOT NULL,
+	frequency INTEGER NOT NULL,
+	confidence REAL NOT NULL,
+	examples TEXT NOT NULL,
+	synced_at TEXT NOT NULL,
+	PRIMARY KEY (pattern_id, project)
+);
+CREATE TABLE IF NOT EXISTS pattern_store (
+	updated TEXT NOT NULL
+);`
+
+// sqliteBackend keeps the store in an SQLite database. Updates run in an
+// immediate transaction, so concurrent pushes wait for each other.
+type sqliteBackend struct {
+	db *sql.DB
+}
+
+func openSQLite(path string) (*sqliteBackend, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create pattern store directory: %w", err)
+	}
+	db, err := sql.Open("sqlite3", "file:"+path+"?_txlock=immediate&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pattern store: %w", err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open pattern store: %w", err)
+	}
+	return &sqliteBackend{db: db}, nil
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (b *sqliteBackend) Load(ctx context.Context) (*Store, error) {
+	return loadSQLite(ctx, b.db)
+}
+
+func loadSQLite(ctx context.Context, q querier) (*Store, error) {
+	store := New()
+	var updated string
+	err := q.QueryRowContext(ctx, `SELECT updated FROM pattern_store LIMIT 1`).Scan(&updated)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to read pattern store: %w", err)
+	}
+	if updated != "" {
+		store.Updated, _ = time.Parse(time.RFC3339Nano, updated)
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT p.id, p.name, p.category, p.description, p.template,
+		       c.project, c.frequency, c.confidence, c.examples, c.synced_at
+		FROM patterns p JOIN pattern_contributions c ON c.pattern_id = p.id
+		ORDER BY p.rowid, c.project`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pattern store: %w", err)
+	}
+	defer rows.Close()
+
+	index := make(map[string]int)
+	for rows.Next() {
+		var pattern Pattern
+		var project, examples, syncedAt string
+		var contribution Contribution
+		if err := rows.Scan(&pattern.ID, &pattern.Name, &pattern.Category, &pattern.Description, &pattern.Template,
+			&project, &contribution.Frequency, &contribution.Confidence, &examples, &syncedAt); err != nil {
+			return nil, fmt.Errorf("failed to read pattern store: %w", err)
+		}
+		if err := json.Unmarshal([]byte(examples), &contribution.Examples); err != nil {
+			return nil, fmt.Errorf("invalid examples of pattern %s: %w", pattern.ID, err)
+		}
+		contribution.SyncedAt, _ = time.Parse(time.RFC3339Nano, syncedAt)
+
+		i, ok := index[pattern.ID]
+		if !ok {
+			i = len(store.Patterns)
+			index[pattern.ID] = i
+			pattern.Projects = make(map[string]Contribution)
+			store.Patterns = append(store.Patterns, pattern)
+		}
+		store.Patterns[i].Projects[project] = contribution
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pattern store: %w", err)
+	}
+	return store, nil
+}
+
+func (b *sqliteBackend) Update(ctx context.Context, fn func(*Store) error) error {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to lock pattern store: %w", err)
+	}
+	defer tx.Rollback()
+
+	store, err := loadSQLite(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if err := fn(store); err != nil {
+		return err
+	}
+
+	for _, stmt := range []string{`DELETE FROM pattern_contributions`, `DELETE FROM patterns`, `DELETE FROM pattern_store`} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to write pattern store: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO pattern_store (updated) VALUES (?)`, store.Updated.UTC().Format(time.RFC3339Nano)); err != nil {
+		return fmt.Errorf("failed to write pattern store: %w", err)
+	}
+	for _, pattern := range store.Patterns {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO patterns (id, name, category, description, template) VALUES (?, ?, ?, ?, ?)`,
+			pattern.ID, pattern.Name, pattern.Category, pattern.Description, pattern.Template); err != nil {
+			return fmt.Errorf("failed to write pattern %s: %w", pattern.ID, err)
+		}
+		for project, contribution := range pattern.Projects {
+			examples := contribution.Examples
+			if examples == nil {
+				examples = []metadata.PatternExample{}
+			}
+			data, err := json.Marshal(examples)
+			if err != nil {
+				return fmt.Errorf("failed to encode examples of pattern %s: %w", pattern.ID, err)
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO pattern_contributions (pattern_id, project, frequency, confidence, examples, synced_at) VALUES (?, ?, ?, ?, ?, ?)`,
+				pattern.ID, project, contribution.Frequency, contribution.Confidence, string(data), contribution.SyncedAt.UTC().Format(time.RFC3339Nano)); err != nil {
+				return fmt.Errorf("failed to write pattern %s: %w", pattern.ID, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (b *sqliteBackend) Close() error { return b.db.Close() }
+
+// httpBackend reads the store with GET and writes it with PUT. Writes carry
+// the ETag of the document they were based on in If-Match (If-None-Match: *
+// for a new store); a 412 Precondition Failed response restarts the update.
+type httpBackend struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (b *httpBackend) Load(ctx context.Context) (*Store, error) {
+	store, _, err := b.get(ctx)
+	return store, err
+}
+
+// get returns the store and its ETag; the ETag is empty for a new store
+func (b *httpBackend) get(ctx context.Context) (*Store, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid pattern store URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	b.authorize(req)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch pattern store: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return New(), "", nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, "", fmt.Errorf("pattern store returned %s", resp.Status)
+	}
+	store, err := decode(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return store, resp.Header.Get("ETag"), nil
+}
+
+func (b *httpBackend) Update(ctx context.Context, fn func(*Store) error) error {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		store, etag, err := b.get(ctx)
+		if err != nil {
+			return err
+		}
+		if err := fn(store); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(store)
+		if err != nil {
+			return fmt.Errorf("failed to encode pattern store: %w", err)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, b.url, bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("invalid pattern store URL: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if etag != "" {
+			req.Header.Set("If-Match", etag)
+		} else {
+			req.Header.Set("If-None-Match", "*")
+		}
+		b.authorize(req)
+
+		resp, err := b.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to update pattern store: %w", err)
+		}
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusPreconditionFailed:
+			continue
+		case resp.StatusCode < 200 || resp.StatusCode >= 300:
+			return fmt.Errorf("pattern store returned %s", resp.Status)
+		}
+		return nil
+	}
+	return ErrConflict
+}
+
+// authorize adds the bearer token from TokenEnvVar, when set
+func (b *httpBackend) authorize(req *http.Request) {
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+}
+
+func (b *httpBackend) Close() error { return nil }
@@ -0,0 +1,210 @@
+// Package patternstore shares the patterns discovered by `conduit build`
+// between projects, so teams can learn the conventions of their organization
+// rather than of one repository.
+//
+// Each project pushes its patterns to a shared store under its own name. A
+// push replaces that project's previous contribution, so syncing again never
+// counts the same usages twice, and patterns a project no longer uses lose its
+// contribution. Pulling returns every pattern merged across projects: the
+// frequencies are summed and the confidence is the mean of the projects'
+// confidences, weighted by their frequencies.
+//
+// A store is a JSON file, an SQLite database (a path ending in .db, .sqlite or
+// .sqlite3) or an HTTP endpoint serving the JSON document on GET and accepting
+// it on PUT. See Open.
+package patternstore
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+// Version is the format version of stores written by this package
+const Version = "1"
+
+// MaxExamples is how many examples are kept per project and per pattern
+const MaxExamples = 3
+
+// ErrConflict is returned when the store kept changing while it was updated
+var ErrConflict = errors.New("pattern store was modified concurrently")
+
+// Contribution is one project's share of a pattern
+type Contribution struct {
+	Frequency  int                       `json:"frequency"`
+	Confidence float64                   `json:"confidence"`
+	Examples   []metadata.PatternExample `json:"examples,omitempty"`
+	SyncedAt   time.Time                 `json:"synced_at"`
+}
+
+// Pattern is a pattern of the store with the contributions of each project
+type Pattern struct {
+	ID          string                  `json:"id"`
+	Name        string                  `json:"name"`
+	Category    string                  `json:"category"`
+	Description string                  `json:"description,omitempty"`
+	Template    string                  `json:"template"`
+	Projects    map[string]Contribution `json:"projects"`
+}
+
+// Store is the shared document of all projects' patterns
+type Store struct {
+	Version  string    `json:"version"`
+	Updated  time.Time `json:"updated"`
+	Patterns []Pattern `json:"patterns"`
+}
+
+// MergeResult counts the patterns a push changed
+type MergeResult struct {
+	Added   int // Patterns no project had pushed before
+	Updated int // Patterns the project contributes to
+	Removed int // Patterns the project no longer uses
+}
+
+// Backend reads and writes a store
+type Backend interface {
+	// Load returns the store, which is empty when nothing was pushed yet
+	Load(ctx context.Context) (*Store, error)
+	// Update applies fn to the store and saves the result. Concurrent
+	// updates are serialized or retried, so no project's push is lost.
+	Update(ctx context.Context, fn func(*Store) error) error
+	// Close releases the backend's resources
+	Close() error
+}
+
+// New returns an empty store
+func New() *Store {
+	return &Store{Version: Version, Patterns: []Pattern{}}
+}
+
+// Merge replaces project's contribution with patterns, as discovered at now
+func (s *Store) Merge(project string, patterns []metadata.PatternMetadata, now time.Time) MergeResult {
+	var result MergeResult
+	index := make(map[string]int, len(s.Patterns))
+	for i, pattern := range s.Patterns {
+		index[pattern.ID] = i
+	}
+
+	pushed := make(map[string]bool, len(patterns))
+	for _, discovered := range patterns {
+		pushed[discovered.ID] = true
+		examples := discovered.Examples
+		if len(examples) > MaxExamples {
+			examples = examples[:MaxExamples]
+		}
+		contribution := Contribution{
+			Frequency:  discovered.Frequency,
+			Confidence: discovered.Confidence,
+			Examples:   examples,
+			SyncedAt:   now,
+		}
+
+		i, ok := index[discovered.ID]
+		if !ok {
+			index[discovered.ID] = len(s.Patterns)
+			s.Patterns = append(s.Patterns, Pattern{
+				ID:          discovered.ID,
+				Name:        discovered.Name,
+				Category:    discovered.Category,
+				Description: discovered.Description,
+				Template:    discovered.Template,
+				Projects:    map[string]Contribution{project: contribution},
+			})
+			result.Added++
+			continue
+		}
+		if s.Patterns[i].Projects == nil {
+			s.Patterns[i].Projects = make(map[string]Contribution)
+		}
+		s.Patterns[i].Projects[project] = contribution
+		result.Updated++
+	}
+
+	// Drop the project from patterns it no longer uses, and patterns no
+	// project uses any more
+	kept := s.Patterns[:0]
+	for _, pattern := range s.Patterns {
+		if _, ok := pattern.Projects[project]; ok && !pushed[pattern.ID] {
+			delete(pattern.Projects, project)
+			result.Removed++
+		}
+		if len(pattern.Projects) > 0 {
+			kept = append(kept, pattern)
+		}
+	}
+	s.Patterns = kept
+	s.Version = Version
+	s.Updated = now
+	return result
+}
+
+// Projects returns the names of the projects that pushed patterns, sorted
+func (s *Store) Projects() []string {
+	seen := make(map[string]bool)
+	var projects []string
+	for _, pattern := range s.Patterns {
+		for project := range pattern.Projects {
+			if !seen[project] {
+				seen[project] = true
+				projects = append(projects, project)
+			}
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// Merged returns the patterns of all projects, most frequent first
+func (s *Store) Merged() []metadata.PatternMetadata {
+	merged := make([]metadata.PatternMetadata, 0, len(s.Patterns))
+	for _, pattern := range s.Patterns {
+		merged = append(merged, pattern.Merged())
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Frequency != merged[j].Frequency {
+			return merged[i].Frequency > merged[j].Frequency
+		}
+		return merged[i].ID < merged[j].ID
+	})
+	return merged
+}
+
+// Merged returns the pattern with its frequency summed over all projects and
+// its confidence weighted by each project's frequency. Examples are taken from
+// the projects in name order.
+func (p Pattern) Merged() metadata.PatternMetadata {
+	merged := metadata.PatternMetadata{
+		ID:          p.ID,
+		Name:        p.Name,
+		Category:    p.Category,
+		Description: p.Description,
+		Template:    p.Template,
+		Examples:    []metadata.PatternExample{},
+	}
+
+	projects := make([]string, 0, len(p.Projects))
+	for project := range p.Projects {
+		projects = append(projects, project)
+	}
+	sort.Strings(projects)
+
+	var weighted float64
+	for _, project := range projects {
+		contribution := p.Projects[project]
+		merged.Frequency += contribution.Frequency
+		weighted += contribution.Confidence * float64(contribution.Frequency)
+		for _, example := range contribution.Examples {
+			if len(merged.Examples) < MaxExamples {
+				merged.Examples = append(merged.Examples, example)
+			}
+		}
+	}
+	if merged.Frequency > 0 {
+		merged.Confidence = math.Round(weighted/float64(merged.Frequency)*100) / 100
+	}
+	return merged
+}
@@ -0,0 +1,191 @@
+package patternstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+func testPattern(id string, frequency int, confidence float64) metadata.PatternMetadata {
+	return metadata.PatternMetadata{
+		ID:         id,
+		Name:       id + "_handler",
+		Category:   "middleware",
+		Template:   "@on list: [" + id + "]",
+		Examples:   []metadata.PatternExample{{Resource: "Post", Code: "@on list: [" + id + "]"}},
+		Frequency:  frequency,
+		Confidence: confidence,
+	}
+}
+
+func TestStore_Merge(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	store := New()
+
+	result := store.Merge("billing", []metadata.PatternMetadata{testPattern("auth", 6, 0.9), testPattern("cache", 2, 0.5)}, now)
+	if result != (MergeResult{Added: 2}) {
+		t.Errorf("first push = %+v, want 2 added", result)
+	}
+	result = store.Merge("blog", []metadata.PatternMetadata{testPattern("auth", 2, 0.5)}, now)
+	if result != (MergeResult{Updated: 1}) {
+		t.Errorf("second project = %+v, want 1 updated", result)
+	}
+
+	merged := store.Merged()
+	if len(merged) != 2 || merged[0].ID != "auth" {
+		t.Fatalf("Merged() = %+v", merged)
+	}
+	// (6*0.9 + 2*0.5) / 8 = 0.8
+	if merged[0].Frequency != 8 || merged[0].Confidence != 0.8 {
+		t.Errorf("auth frequency %d confidence %.2f, want 8 and 0.80", merged[0].Frequency, merged[0].Confidence)
+	}
+	if len(merged[0].Examples) != 2 {
+		t.Errorf("auth has %d examples, want one per project", len(merged[0].Examples))
+	}
+
+	// Pushing again replaces the project's contribution instead of adding to it
+	result = store.Merge("billing", []metadata.PatternMetadata{testPattern("auth", 6, 0.9)}, now)
+	if result != (MergeResult{Updated: 1, Removed: 1}) {
+		t.Errorf("re-push = %+v, want 1 updated and 1 removed", result)
+	}
+	merged = store.Merged()
+	if len(merged) != 1 || merged[0].Frequency != 8 {
+		t.Errorf("Merged() after re-push = %+v", merged)
+	}
+	if projects := store.Projects(); len(projects) != 2 || projects[0] != "billing" || projects[1] != "blog" {
+		t.Errorf("Projects() = %v", projects)
+	}
+}
+
+func testBackend(t *testing.T, backend Backend) {
+	t.Helper()
+	ctx := context.Background()
+	defer backend.Close()
+
+	store, err := backend.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() of a new store error = %v", err)
+	}
+	if len(store.Patterns) != 0 {
+		t.Fatalf("new store has %d patterns", len(store.Patterns))
+	}
+
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	for _, push := range []struct {
+		project  string
+		patterns []metadata.PatternMetadata
+	}{
+		{"billing", []metadata.PatternMetadata{testPattern("auth", 6, 0.9), testPattern("cache", 2, 0.5)}},
+		{"blog", []metadata.PatternMetadata{testPattern("auth", 2, 0.5)}},
+	} {
+		err := backend.Update(ctx, func(store *Store) error {
+			store.Merge(push.project, push.patterns, now)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Update(%s) error = %v", push.project, err)
+		}
+	}
+
+	store, err = backend.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	merged := store.Merged()
+	if len(merged) != 2 || merged[0].ID != "auth" || merged[0].Frequency != 8 || merged[0].Confidence != 0.8 {
+		t.Errorf("Merged() = %+v", merged)
+	}
+	if got := store.Patterns[0].Projects["blog"].SyncedAt; !got.Equal(now) {
+		t.Errorf("SyncedAt = %v, want %v", got, now)
+	}
+	if !store.Updated.Equal(now) {
+		t.Errorf("Updated = %v, want %v", store.Updated, now)
+	}
+}
+
+func TestFileBackend(t *testing.T) {
+	backend, err := Open(filepath.Join(t.TempDir(), "shared", "patterns.json"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	testBackend(t, backend)
+}
+
+func TestSQLiteBackend(t *testing.T) {
+	backend, err := Open(filepath.Join(t.TempDir(), "shared", "patterns.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, ok := backend.(*sqliteBackend); !ok {
+		t.Fatalf("Open() returned %T, want an SQLite backend", backend)
+	}
+	testBackend(t, backend)
+}
+
+// storeServer serves a store document with ETags, like a shared endpoint
+type storeServer struct {
+	mu        sync.Mutex
+	document  []byte
+	conflicts int // PUTs to reject with 412 before accepting one
+}
+
+func (s *storeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	etag := ""
+	if s.document != nil {
+		sum := sha256.Sum256(s.document)
+		etag = `"` + hex.EncodeToString(sum[:8]) + `"`
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if s.document == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write(s.document)
+	case http.MethodPut:
+		if s.conflicts > 0 || (etag != "" && r.Header.Get("If-Match") != etag) || (etag == "" && r.Header.Get("If-None-Match") != "*") {
+			s.conflicts--
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		s.document, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestHTTPBackend(t *testing.T) {
+	t.Setenv(TokenEnvVar, "secret")
+	server := &storeServer{conflicts: 1}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	backend, err := Open(ts.URL + "/patterns")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	testBackend(t, backend)
+
+	// An endpoint that keeps changing gives up after a few attempts
+	server.conflicts = maxAttempts
+	err = backend.Update(context.Background(), func(*Store) error { return nil })
+	if err != ErrConflict {
+		t.Errorf("Update() error = %v, want ErrConflict", err)
+	}
+}
@@ -2,7 +2,12 @@
 // It provides structures for representing resources, fields, types, hooks, validations, and expressions.
 package ast
 
-import "github.com/conduit-lang/conduit/internal/compiler/lexer"
+import (
+	"strings"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+)
 
 // SourceLocation tracks the position of an AST node in source code
 type SourceLocation struct {
@@ -42,11 +47,448 @@ type ResourceNode struct {
 	Relationships []*RelationshipNode
 	Scopes        []*ScopeNode
 	Computed      []*ComputedNode
-	Operations    []string // List of allowed operations (create, update, delete, etc.)
-	Middleware    []string // Middleware stack for this resource
+	Operations    []string            // List of allowed operations (create, update, delete, etc.)
+	Excluded      []string            // Operations left out by @operations all except [...]
+	OperationMods map[string][]string // Refinements of listed operations, e.g. create(admin_only)
+	Middleware    []string            // Middleware stack for this resource
+	Aliases       []string            // Former names kept for API backward compatibility (@alias)
+	CountStrategy string              // How list endpoints count records (@count); empty means exact
+	SLO           *SLONode            // Service level objectives (@slo); nil when none are declared
+	CacheControl  *CacheControlNode   // HTTP caching policy for reads (@cache_control); nil when responses are not cacheable
+	Changes       *ChangesNode        // Change feed for sync clients (@changes); nil when not served
+	Conflict      *ConflictNode       // Concurrent update policy (@conflict); nil means last write wins
+	Partition     *PartitionNode      // Range partitioning of the table (@partition); nil for a regular table
+	Materialized  *MaterializedNode   // Read-only materialized view (@materialized); nil for a table
+	CounterCaches []*CounterCacheNode // Counts of this resource kept on its parents (@counter_cache)
+	SearchIndex   *SearchIndexNode    // Fields indexed into the search backend (@search_index); nil when not searchable
+	Webhook       *WebhookNode        // Payment provider events recorded by a webhook route (@webhook); nil when none
+	Profiles      []*ProfileNode      // Fields rendered for each caller role (@profile); empty when every caller sees every field
+	Upsert        *UpsertNode         // Insert-or-update route keyed by a unique field (@upsert); nil when not served
+	Archivable    *ArchivableNode     // Archive and restore routes (@archivable); nil when records cannot be archived
+	SoftDelete    *SoftDeleteNode     // Deletes that set deleted_at instead of removing rows (@soft_delete); nil for hard deletes
+	DefaultScope  *DefaultScopeNode   // Condition every list and show query applies (@default_scope); nil when reads are unfiltered
+	Orderable     *OrderableNode      // Position column and move route (@orderable); nil when records are unordered
+	Tree          *TreeNode           // Children and ancestors routes (@tree); nil when records do not form a tree
+	IDStrategy    *IDStrategyNode     // How new IDs are generated (@id); nil for database sequences and random UUIDs
+	PrimaryKey    *PrimaryKeyNode     // Composite primary key (@primary); nil when the key is a single field
+	Timestamps    *TimestampsNode     // Whether created_at and updated_at are added (@timestamps); nil to follow the project setting
+	Schema        *SchemaNode         // PostgreSQL schema holding the table (@schema); nil for the default schema
+	External      *ExternalTableNode  // Existing table or view the resource reads (@external_table); nil for a table the app migrates
+	Owner         *OwnerNode          // Team that owns the resource (@owner, or CODEOWNERS); nil when unowned
+	Stability     *StabilityNode      // Lifecycle status (@stability); nil for a stable resource
+	Meta          *MetaNode           // Custom key-value metadata (@meta); nil when there is none
+	Shard         *ShardNode          // Shard key routing records to a database (@shard); nil when every record is in the default database
+	Indexes       []*IndexNode        // Indexes declared with index [...] blocks; AllIndexes adds those of @index fields
 	Loc           SourceLocation
 }
 
+// Count strategies accepted by the @count resource annotation
+const (
+	CountExact     = "exact"     // SELECT COUNT(*) on every list request
+	CountEstimated = "estimated" // PostgreSQL planner estimate
+	CountNone      = "none"      // No total count
+)
+
+// SLONode holds the service level objectives declared with @slo, e.g.
+// @slo(latency_p99: 300ms, availability: 99.9)
+type SLONode struct {
+	Latency      []LatencyObjective // Latency targets in declaration order
+	Availability float64            // Percentage of requests that must not fail with 5xx; zero when unset
+	Loc          SourceLocation
+}
+
+// LatencyObjective requires the given percentile of requests to complete
+// within Threshold, e.g. latency_p99: 300ms
+type LatencyObjective struct {
+	Percentile int
+	Threshold  time.Duration
+}
+
+// CacheControlNode is the caching policy declared with @cache_control, e.g.
+// @cache_control(max_age: 60, public: true). Durations are in seconds.
+type CacheControlNode struct {
+	MaxAge               int  // max-age for browsers and, without SharedMaxAge, shared caches
+	SharedMaxAge         int  // s-maxage for CDNs; zero when unset
+	StaleWhileRevalidate int  // stale-while-revalidate; zero when unset
+	Public               bool // public when true, private otherwise
+	Loc                  SourceLocation
+}
+
+// ChangesNode marks a resource declared with @changes, which serves a feed of
+// records created, updated and deleted since a cursor. The resource must
+// declare an @auto_update timestamp and a nullable deleted_at timestamp;
+// deletes become soft deletes so the feed can report them.
+type ChangesNode struct {
+	Loc SourceLocation
+}
+
+// SoftDeleteField is the field that marks a record of a @changes or
+// @soft_delete resource as deleted
+const SoftDeleteField = "deleted_at"
+
+// ConflictNode is the concurrent update policy declared with @conflict, e.g.
+// @conflict(strategy: merge(title, body)). Updates carry the version they were
+// based on in If-Match or If-Unmodified-Since; the strategy decides what
+// happens when that version is stale.
+type ConflictNode struct {
+	Strategy    string   // One of the Conflict* strategies
+	MergeFields []string // Fields a stale update may still write (merge only)
+	Loc         SourceLocation
+}
+
+// Strategies accepted by the @conflict resource annotation
+const (
+	ConflictLastWriteWins = "last_write_wins" // Stale updates overwrite newer data
+	ConflictReject        = "reject"          // Stale or unconditional updates fail
+	ConflictMerge         = "merge"           // Stale updates apply when they only touch MergeFields
+)
+
+// PartitionNode is the table partitioning declared with @partition, e.g.
+// @partition(by: created_at, interval: month). The table is range partitioned
+// on the timestamp Field with one partition per Interval, so queries bounded
+// on that field only scan the partitions they touch.
+type PartitionNode struct {
+	Field    string // Required timestamp the table is partitioned by
+	Interval string // One of the Partition* intervals
+	Loc      SourceLocation
+}
+
+// Intervals accepted by the @partition resource annotation
+const (
+	PartitionDay   = "day"
+	PartitionWeek  = "week"
+	PartitionMonth = "month"
+	PartitionYear  = "year"
+)
+
+// MaterializedNode marks a read-only resource backed by a PostgreSQL
+// materialized view, e.g.
+// @materialized(refresh: hourly, query: "SELECT ... FROM posts ...").
+// The query's columns are the resource's fields; the view is refreshed on
+// the Refresh schedule and served by list and show routes only.
+type MaterializedNode struct {
+	Query   string // SELECT statement defining the view
+	Refresh string // One of the Refresh* schedules
+	Loc     SourceLocation
+}
+
+// Refresh schedules accepted by the @materialized resource annotation
+const (
+	RefreshHourly = "hourly"
+	RefreshDaily  = "daily"
+	RefreshWeekly = "weekly"
+)
+
+// CounterCacheNode keeps a denormalized count of this resource on a parent it
+// belongs to, e.g. @counter_cache(comments_count on Post.comments). Column is
+// added to the parent as a read-only int!, incremented when a record is
+// created and decremented when it is deleted or moved to another parent.
+type CounterCacheNode struct {
+	Column       string // Counter column on the parent
+	Resource     string // Parent resource, the target of a belongs_to relationship
+	Relationship string // Parent's name for the counted collection
+	Loc          SourceLocation
+}
+
+// SearchIndexNode indexes a resource into the configured search backend
+// (Elasticsearch or Meilisearch), e.g. @search_index(title, body). Records are
+// indexed after every committed write and queried by GET /resources/search.
+type SearchIndexNode struct {
+	Fields []string // Fields copied into the search document, in declaration order
+	Loc    SourceLocation
+}
+
+// WebhookNode records the events a provider delivers to a signed webhook
+// route, e.g. @webhook(stripe) served at POST /webhooks/stripe. Each event is
+// stored once by its event_id, so create hooks process a redelivered event
+// only the first time it arrives.
+type WebhookNode struct {
+	Provider string // Event provider; only stripe is supported
+	Loc      SourceLocation
+}
+
+// Webhook providers
+const (
+	WebhookStripe = "stripe"
+)
+
+// Fields a @webhook resource records each event in
+const (
+	WebhookEventIDField   = "event_id"   // Provider's event ID, string! @unique
+	WebhookEventTypeField = "event_type" // Provider's event type, e.g. "payment_intent.succeeded"
+	WebhookPayloadField   = "payload"    // Verified request body, json!
+)
+
+// ProfileNode names the fields one role sees in responses, e.g. admin: * or
+// public: [id, title] in @profile(public: [id, title], admin: *). Callers see
+// the public profile together with the profiles of their roles.
+type ProfileNode struct {
+	Name   string   // Role the profile applies to, or public for every caller
+	Fields []string // Visible fields in declaration order; empty when All is set
+	All    bool     // true for *, which shows every field
+	Loc    SourceLocation
+}
+
+// ProfilePublic is the profile every caller sees, signed in or not
+const ProfilePublic = "public"
+
+// UpsertNode serves PUT /resources:upsert, which inserts a record or updates
+// the one it conflicts with, e.g. @upsert(on: [email]). The conflict target
+// must be a @unique or @primary field so INSERT ... ON CONFLICT can name it.
+type UpsertNode struct {
+	Fields []string // Conflict target fields, in declaration order
+	Loc    SourceLocation
+}
+
+// ArchivableNode marks a resource declared with @archivable, which serves
+// POST /resources/{id}/archive and /restore. Archiving sets the nullable
+// archived_at timestamp the resource must declare; archived records stay
+// readable by ID but are left out of lists unless filter[archived] asks for them.
+type ArchivableNode struct {
+	Loc SourceLocation
+}
+
+// ArchivedField is the field that marks a record of an @archivable resource as archived
+const ArchivedField = "archived_at"
+
+// SoftDeleteNode marks a resource declared with @soft_delete, whose deletes
+// set the nullable deleted_at timestamp instead of removing the row. The field
+// is added by WithSoftDeletes when the resource does not declare it. Deleted
+// records are left out of lists and single-record routes unless the request
+// asks for them with ?include_deleted=true.
+type SoftDeleteNode struct {
+	Loc SourceLocation
+}
+
+// DefaultScopeNode is the implicit filter declared with @default_scope, e.g.
+// @default_scope { self.status != "draft" }. Lists and single-record routes
+// only return records the condition holds for, unless an admin asks for
+// ?unscoped=true. The condition is translated to SQL by DefaultScopeSQL.
+type DefaultScopeNode struct {
+	Condition ExprNode
+	Loc       SourceLocation
+}
+
+// OrderableNode marks a resource declared with @orderable, whose records keep
+// a user-defined order in a position column and serve POST /resources/{id}/move,
+// e.g. @orderable(scope: list_id). Records are ordered within each value of the
+// scope field, or across the whole table when there is no scope.
+type OrderableNode struct {
+	Scope string // Field whose records are ordered together; empty for one order
+	Loc   SourceLocation
+}
+
+// PositionField is the int! field that holds a record's place in the order of
+// an @orderable resource. It is added to the resource unless declared.
+const PositionField = "position"
+
+// TreeNode marks a resource declared with @tree, whose records form a
+// hierarchy through a belongs_to relationship to the resource itself, e.g.
+// @tree(parent: parent, max_depth: 10). It serves GET /resources/{id}/children
+// and /ancestors, which follow the relationship at most MaxDepth levels.
+type TreeNode struct {
+	Parent   string // Relationship to the parent record; empty for the only one to the resource
+	MaxDepth int    // Levels a request may follow; 0 for DefaultTreeMaxDepth
+	Loc      SourceLocation
+}
+
+// DefaultTreeMaxDepth is the number of levels @tree routes follow at most
+// when the annotation sets no max_depth
+const DefaultTreeMaxDepth = 32
+
+// IDStrategyNode is the ID generation strategy declared with @id, e.g.
+// @id(strategy: ulid). Create() generates the ID of each new record unless
+// the client already generated one with the same strategy.
+type IDStrategyNode struct {
+	Strategy string // One of the IDStrategy* strategies
+	Loc      SourceLocation
+}
+
+// Strategies accepted by the @id resource annotation
+const (
+	IDStrategyULID      = "ulid"      // Time-ordered 26-character ULID strings
+	IDStrategyUUIDv7    = "uuidv7"    // Time-ordered version 7 UUIDs
+	IDStrategySnowflake = "snowflake" // Time-ordered 64-bit integers unique per node
+)
+
+// FieldType returns the type the id field of a resource generating its IDs
+// with the strategy must have
+func (n *IDStrategyNode) FieldType() string {
+	switch n.Strategy {
+	case IDStrategyULID:
+		return "ulid"
+	case IDStrategySnowflake:
+		return "int"
+	}
+	return "uuid"
+}
+
+// PrimaryKeyNode is a composite primary key declared with the resource
+// annotation @primary, e.g. @primary(region, code). Records are stored,
+// looked up and routed by the values of its fields, in order.
+type PrimaryKeyNode struct {
+	Fields []string
+	Loc    SourceLocation
+}
+
+// TimestampsNode turns the created_at and updated_at fields WithTimestamps
+// adds on or off for one resource: @timestamps adds them whatever the project
+// setting, and @timestamps(false) opts the resource out.
+type TimestampsNode struct {
+	Enabled bool
+	Loc     SourceLocation
+}
+
+// SchemaNode places a resource's table in a PostgreSQL schema other than the
+// default one, such as billing, so resource groups can be owned and granted
+// separately: @schema("billing")
+type SchemaNode struct {
+	Name string
+	Loc  SourceLocation
+}
+
+// ExternalTableNode backs a read-only resource with a table or view the
+// application does not own, e.g. @external_table("legacy.users"). Migrations
+// never create or alter it; the resource is served by list and show routes
+// only.
+type ExternalTableNode struct {
+	Table string // Table or view name, optionally schema-qualified
+	Loc   SourceLocation
+}
+
+// OwnerNode names the team that owns a resource, e.g. @owner("payments-team").
+// Resources without @owner may be given one from CODEOWNERS by
+// owners.WithOwners, with the location of the resource itself.
+type OwnerNode struct {
+	Team string
+	Loc  SourceLocation
+}
+
+// StabilityNode is the lifecycle status declared with @stability, e.g.
+// @stability(experimental). Resources without it are stable.
+type StabilityNode struct {
+	Level string // One of the Stability* levels
+	Loc   SourceLocation
+}
+
+// Levels accepted by the @stability resource annotation
+const (
+	StabilityExperimental = "experimental" // May change or be removed without notice
+	StabilityStable       = "stable"       // Changes stay backwards compatible
+	StabilityDeprecated   = "deprecated"   // Kept for existing clients; scheduled for removal
+)
+
+// StabilityLevel returns the lifecycle status of the resource, stable when it
+// declares none
+func (r *ResourceNode) StabilityLevel() string {
+	if r.Stability == nil {
+		return StabilityStable
+	}
+	return r.Stability.Level
+}
+
+// MetaNode holds the custom key-value pairs declared with @meta, e.g.
+// @meta(classification: "pii", cost_center: "cc-42"). They are not
+// interpreted by the compiler and reach the metadata verbatim. Several @meta
+// annotations on the same resource or field are merged into one node.
+type MetaNode struct {
+	Values map[string]string
+	Loc    SourceLocation // Location of the first @meta
+}
+
+// LabelsNode holds the display labels of an enum field's values, declared
+// with @labels(draft: "Draft", published: "Published"). Values without a
+// label are shown as themselves.
+type LabelsNode struct {
+	Values map[string]string // Label by enum value
+	Loc    SourceLocation
+}
+
+// ShardNode is the shard key declared with @shard(by: tenant_id). Each record
+// lives in the database the shard map assigns to its Field value, and
+// requests name that value so generated handlers query the right database.
+// Resources sharded by the same field are co-located, so relationships
+// between them stay within one shard.
+type ShardNode struct {
+	Field string // Required field whose value selects the shard
+	Loc   SourceLocation
+}
+
+// Colocated reports whether records of r and other always live in the same
+// database: both unsharded, or both sharded by the same field
+func (r *ResourceNode) Colocated(other *ResourceNode) bool {
+	if r.Shard == nil || other.Shard == nil {
+		return r.Shard == nil && other.Shard == nil
+	}
+	return r.Shard.Field == other.Shard.Field
+}
+
+// ShardGroup is a group of co-located resources, all sharded by Field
+type ShardGroup struct {
+	Field     string
+	Resources []string
+}
+
+// ShardGroups returns the co-located groups of @shard resources, one per
+// shard field in the order the fields are first declared
+func ShardGroups(resources []*ResourceNode) []ShardGroup {
+	var groups []ShardGroup
+	index := make(map[string]int)
+	for _, resource := range resources {
+		if resource.Shard == nil {
+			continue
+		}
+		i, ok := index[resource.Shard.Field]
+		if !ok {
+			i = len(groups)
+			index[resource.Shard.Field] = i
+			groups = append(groups, ShardGroup{Field: resource.Shard.Field})
+		}
+		groups[i].Resources = append(groups[i].Resources, resource.Name)
+	}
+	return groups
+}
+
+// Fields social login fills when it creates a record of the auth resource
+// (auth.resource in conduit.yaml)
+const (
+	LoginEmailField = "email" // Verified email the user is matched by, string! @unique
+	LoginNameField  = "name"  // Display name from the provider, optional
+)
+
+// SignedRequestMiddleware verifies HMAC-signed server-to-server requests. Its
+// argument names the environment variable holding the shared secret, e.g.
+// @middleware [signed_request(PARTNER_SECRET)].
+const SignedRequestMiddleware = "signed_request"
+
+// ParseMiddleware splits a middleware entry such as rate_limit(100/hour) into
+// its name and arguments; args is empty when the entry has none.
+func ParseMiddleware(mw string) (name, args string) {
+	name, args, ok := strings.Cut(mw, "(")
+	if !ok {
+		return strings.TrimSpace(mw), ""
+	}
+	return strings.TrimSpace(name), strings.TrimSpace(strings.TrimSuffix(args, ")"))
+}
+
+// SignedRequestSecret returns the secret reference of the resource's
+// signed_request middleware, or "" when its requests are not signed.
+func (r *ResourceNode) SignedRequestSecret() string {
+	for _, mw := range r.Middleware {
+		if name, args := ParseMiddleware(mw); name == SignedRequestMiddleware {
+			return strings.Trim(args, `"`)
+		}
+	}
+	return ""
+}
+
+// ReadOnly reports whether the resource is served by list and show routes
+// only: a @materialized view, or an @external_table the application does not
+// own.
+func (r *ResourceNode) ReadOnly() bool {
+	return r.Materialized != nil || r.External != nil
+}
+
 func (r *ResourceNode) node() {}
 
 // Location returns the source location of the resource node in the AST.
@@ -61,6 +503,8 @@ type FieldNode struct {
 	Nullable    bool              // true for ?, false for !
 	Default     ExprNode          // Default value expression
 	Constraints []*ConstraintNode // Field-level constraints (@min, @max, etc.)
+	Meta        *MetaNode         // Custom key-value metadata (@meta); nil when there is none
+	Labels      *LabelsNode       // Display labels of enum values (@labels); nil when there are none
 	Loc         SourceLocation
 }
 
@@ -71,6 +515,144 @@ func (f *FieldNode) Location() SourceLocation {
 	return f.Loc
 }
 
+// ColumnOverride returns the database column name set with @column("name"),
+// or an empty string when the field uses the default column name.
+func (f *FieldNode) ColumnOverride() string {
+	for _, constraint := range f.Constraints {
+		if constraint.Name != "column" || len(constraint.Arguments) != 1 {
+			continue
+		}
+		if lit, ok := constraint.Arguments[0].(*LiteralExpr); ok {
+			if column, ok := lit.Value.(string); ok {
+				return column
+			}
+		}
+	}
+	return ""
+}
+
+// DualWrite returns the legacy column set with @dual_write("column", "until")
+// and the optional cleanup date, or empty strings when the field is not in a
+// dual-write transition. While set, generated code writes the field to both
+// its own column and the legacy column.
+func (f *FieldNode) DualWrite() (column, until string) {
+	for _, constraint := range f.Constraints {
+		if constraint.Name != "dual_write" || len(constraint.Arguments) == 0 {
+			continue
+		}
+		if lit, ok := constraint.Arguments[0].(*LiteralExpr); ok {
+			column, _ = lit.Value.(string)
+		}
+		if len(constraint.Arguments) > 1 {
+			if lit, ok := constraint.Arguments[1].(*LiteralExpr); ok {
+				until, _ = lit.Value.(string)
+			}
+		}
+		return column, until
+	}
+	return "", ""
+}
+
+// HasConstraint reports whether the field carries the named constraint
+func (f *FieldNode) HasConstraint(name string) bool {
+	for _, constraint := range f.Constraints {
+		if constraint.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterOperators returns the operators listed in the field's
+// @filterable(...) annotation, in order
+func (f *FieldNode) FilterOperators() []string {
+	var operators []string
+	for _, constraint := range f.Constraints {
+		if constraint.Name != "filterable" {
+			continue
+		}
+		for _, arg := range constraint.Arguments {
+			if ident, ok := arg.(*IdentifierExpr); ok {
+				operators = append(operators, ident.Name)
+			}
+		}
+	}
+	return operators
+}
+
+// Geometry returns the GeoJSON geometry type stored by a point or polygon
+// field ("Point" or "Polygon"), or an empty string for any other field.
+func (f *FieldNode) Geometry() string {
+	if f.Type == nil || f.Type.Kind != TypePrimitive {
+		return ""
+	}
+	switch f.Type.Name {
+	case "point":
+		return "Point"
+	case "polygon":
+		return "Polygon"
+	}
+	return ""
+}
+
+// SpatialFields returns the point and polygon fields, which are stored as
+// PostGIS geography and accept ?filter[field][near]=lat,lng,radius.
+func (r *ResourceNode) SpatialFields() []string {
+	var fields []string
+	for _, field := range r.Fields {
+		if field.Geometry() != "" {
+			fields = append(fields, field.Name)
+		}
+	}
+	return fields
+}
+
+// FilterableFields returns the fields accepted by ?filter[...]. Fields opt in
+// with @filterable; a resource without any @filterable field allows all fields.
+func (r *ResourceNode) FilterableFields() []string {
+	return r.allowedFields("filterable")
+}
+
+// FilterOperatorNames are the operators @filterable may allow beyond
+// equality, as in @filterable(gte, lte, in)
+var FilterOperatorNames = []string{"ne", "gt", "gte", "lt", "lte", "in", "like", "null"}
+
+// FilterOperators returns the operators each field allows beyond equality
+// with @filterable(...), such as ?filter[views][gte]=100, or nil when no
+// field allows any.
+func (r *ResourceNode) FilterOperators() map[string][]string {
+	var operators map[string][]string
+	for _, field := range r.Fields {
+		if names := field.FilterOperators(); len(names) > 0 {
+			if operators == nil {
+				operators = make(map[string][]string)
+			}
+			operators[field.Name] = names
+		}
+	}
+	return operators
+}
+
+// SortableFields returns the fields accepted by ?sort=. Fields opt in with
+// @sortable; a resource without any @sortable field allows all fields.
+func (r *ResourceNode) SortableFields() []string {
+	return r.allowedFields("sortable")
+}
+
+func (r *ResourceNode) allowedFields(constraint string) []string {
+	var annotated, all []string
+	for _, field := range r.Fields {
+		all = append(all, field.Name)
+		if field.HasConstraint(constraint) {
+			annotated = append(annotated, field.Name)
+		}
+	}
+	if len(annotated) > 0 {
+		return annotated
+	}
+	return all
+}
+
 // TypeKind represents the kind of type
 type TypeKind int
 
@@ -111,15 +693,72 @@ func (t *TypeNode) Location() SourceLocation {
 
 // HookNode represents a lifecycle hook (@before/@after create/update/delete/save)
 type HookNode struct {
-	Timing        string   // "before" or "after"
-	Event         string   // "create", "update", "delete", "save"
-	Middleware    []string // Middleware stack for this hook
-	IsAsync       bool     // @async annotation
-	IsTransaction bool     // @transaction annotation
+	Timing        string        // "before" or "after"
+	Event         string        // "create", "update", "delete", "save", "attach", "detach"
+	Relationship  string        // has_many_through relationship of attach and detach hooks, e.g. @after attach(tags)
+	Batch         bool          // batch modifier: runs once per create with all the records created
+	Middleware    []string      // Middleware stack for this hook
+	IsAsync       bool          // @async annotation
+	IsTransaction bool          // @transaction annotation
+	OnError       *OnErrorNode  // @on_error failure policy; nil aborts on the first error
+	Timeout       time.Duration // @timeout: the hook's context is canceled after it; zero is no timeout
 	Body          []StmtNode
 	Loc           SourceLocation
 }
 
+// OnErrorNode is the failure policy of a hook declared with @on_error, e.g.
+// @on_error(retry: 3, backoff: exponential) or @on_error(policy: warn). A
+// failing hook is retried Retry times; if it still fails, abort fails the
+// operation and warn logs the error and lets the operation go on.
+type OnErrorNode struct {
+	Retry   int    // Attempts after the first failure
+	Backoff string // One of the Backoff* delays between attempts; set when Retry is
+	Policy  string // One of the HookPolicy* policies
+	Loc     SourceLocation
+}
+
+// Policies accepted by @on_error for hooks that still fail after their retries
+const (
+	HookPolicyAbort = "abort" // The error fails the operation (the default)
+	HookPolicyWarn  = "warn"  // The error is logged and the operation goes on
+)
+
+// Delays accepted by @on_error between the attempts of a hook
+const (
+	BackoffConstant    = "constant"    // The same delay before every retry
+	BackoffExponential = "exponential" // A delay doubling with every retry (the default)
+)
+
+// ErrorPolicy returns what happens to the operation when the hook still fails
+// after its retries: the @on_error policy, or abort without one
+func (h *HookNode) ErrorPolicy() string {
+	if h.OnError == nil {
+		return HookPolicyAbort
+	}
+	return h.OnError.Policy
+}
+
+// Retries returns the number of times a failing hook is retried
+func (h *HookNode) Retries() (retries int, backoff string) {
+	if h.OnError == nil {
+		return 0, ""
+	}
+	return h.OnError.Retry, h.OnError.Backoff
+}
+
+// Events of the hooks that run around linking and unlinking records through a
+// has_many_through relationship. The hook body sees the other record as a
+// variable named after its resource, e.g. tag for a Tag.
+const (
+	HookEventAttach = "attach"
+	HookEventDetach = "detach"
+)
+
+// BatchHookVariable is the variable batch hooks see the records of a create
+// as, e.g. @before create batch { ... }. There is no self: bulk creates run
+// the hook once with every record, and single creates with just the one.
+const BatchHookVariable = "records"
+
 func (h *HookNode) node() {}
 
 // Location returns the source location of the hook node in the AST.
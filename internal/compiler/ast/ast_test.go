@@ -0,0 +1,703 @@
+package ast_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+	"github.com/conduit-lang/conduit/internal/compiler/parser"
+)
+
+const blogSource = `resource User {
+  id: uuid! @primary @auto
+  email: string! @unique @min(5)
+  name: string?
+}
+
+resource Post {
+  @count(estimated)
+
+  id: uuid! @primary @auto
+  title: string! @min(5) @max(200)
+  slug: string! @unique @meta(pii: "no", owner: "seo-team")
+  published: bool! @default(false)
+  tags: array<string!>!
+  status: enum["draft", "published"]!
+  author_id: uuid! @column("author_ref")
+
+  author: User! {
+    foreign_key: "author_id"
+    on_delete: restrict
+  }
+
+  @before create @transaction {
+    self.slug = String.slugify(self.title)
+    let summary: string! = self.title ?? "untitled"
+    if self.published && !String.contains(self.title, "draft") {
+      self.status = "published"
+    } elsif self.author.name == null {
+      self.status = "draft"
+    } else {
+      self.status = self.status
+    }
+  }
+
+  @constraint title_required {
+    on: [create, update]
+    when: self.published == true
+    condition: String.length(self.title) >= (5 + 1)
+    error: "Published posts need a \"real\" title"
+  }
+}
+`
+
+func parse(t *testing.T, source string) *ast.Program {
+	t.Helper()
+
+	tokens, lexErrors := lexer.New(source).ScanTokens()
+	if len(lexErrors) > 0 {
+		t.Fatalf("lexer errors: %v", lexErrors)
+	}
+
+	program, parseErrors := parser.New(tokens).Parse()
+	if len(parseErrors) > 0 {
+		t.Fatalf("parse errors: %v", parseErrors)
+	}
+
+	return program
+}
+
+func TestPrint_RoundTrip(t *testing.T) {
+	program := parse(t, blogSource)
+	printed := ast.Print(program)
+
+	reparsed := parse(t, printed)
+	if reprinted := ast.Print(reparsed); reprinted != printed {
+		t.Errorf("printer is not idempotent\nfirst:\n%s\nsecond:\n%s", printed, reprinted)
+	}
+	if meta := reparsed.FindResource("Post").FindField("slug").Meta; meta == nil || !reflect.DeepEqual(meta.Values, map[string]string{"owner": "seo-team", "pii": "no"}) {
+		t.Errorf("field @meta did not survive the round trip: %+v", meta)
+	}
+
+	for _, want := range []string{
+		"resource Post {",
+		"  @count(estimated)",
+		"  title: string! @min(5) @max(200)",
+		"  published: bool! @default(false)",
+		`  slug: string! @unique @meta(owner: "seo-team", pii: "no")`,
+		"  tags: array<string!>!",
+		`  author_id: uuid! @column("author_ref")`,
+		`  status: enum["draft", "published"]!`,
+		`    foreign_key: "author_id"`,
+		"  @before create @transaction {",
+		"    self.slug = String.slugify(self.title)",
+		`    let summary: string! = self.title ?? "untitled"`,
+		`    if self.published && !String.contains(self.title, "draft") {`,
+		"    } elsif self.author.name == null {",
+		"    on: [create, update]",
+		"    condition: String.length(self.title) >= (5 + 1)",
+		`    error: "Published posts need a \"real\" title"`,
+	} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("printed source missing %q\n%s", want, printed)
+		}
+	}
+}
+
+func TestPrint_ResourceAnnotations(t *testing.T) {
+	program := parse(t, `resource Order {
+  id: uuid! @primary @auto
+  region: string!
+  code: string!
+  email: string!
+  title: string!
+  body: text!
+  tenant_id: uuid!
+  list_id: uuid!
+  status: string!
+  created_at: timestamp!
+  parent: Order? {}
+
+  @slo(latency_p99: 300ms, latency_p50: 0.5ms, availability: 99.9)
+  @cache_control(max_age: 60, s_maxage: 300, stale_while_revalidate: 30, public: true)
+  @changes
+  @conflict(strategy: merge(title, body))
+  @partition(by: created_at, interval: month)
+  @materialized(refresh: hourly, query: "SELECT * FROM orders")
+  @counter_cache(orders_count on Customer.orders)
+  @search_index(title, body)
+  @webhook(stripe)
+  @profile(public: [id, title], admin: *)
+  @upsert(on: [email])
+  @archivable
+  @soft_delete
+  @default_scope { self.status != "draft" }
+  @orderable(scope: list_id)
+  @tree(parent: parent, max_depth: 5)
+  @id(strategy: ulid)
+  @primary(region, code)
+  @timestamps(false)
+  @schema("billing")
+  @external_table("legacy.orders")
+  @owner("payments-team")
+  @meta(cost_center: "cc-42", classification: "pii")
+  @stability(experimental)
+  @shard(by: tenant_id)
+}
+`)
+	printed := ast.Print(program)
+
+	for _, want := range []string{
+		"@slo(latency_p99: 300ms, latency_p50: 0.5ms, availability: 99.9)",
+		"@cache_control(max_age: 60, s_maxage: 300, stale_while_revalidate: 30, public: true)",
+		"@changes",
+		"@conflict(strategy: merge(title, body))",
+		"@partition(by: created_at, interval: month)",
+		`@materialized(refresh: hourly, query: "SELECT * FROM orders")`,
+		"@counter_cache(orders_count on Customer.orders)",
+		"@search_index(title, body)",
+		"@webhook(stripe)",
+		"@profile(public: [id, title], admin: *)",
+		"@upsert(on: [email])",
+		"@archivable",
+		"@soft_delete",
+		`@default_scope { self.status != "draft" }`,
+		"@orderable(scope: list_id)",
+		"@tree(parent: parent, max_depth: 5)",
+		"@id(strategy: ulid)",
+		"@primary(region, code)",
+		"@timestamps(false)",
+		`@schema("billing")`,
+		`@external_table("legacy.orders")`,
+		`@owner("payments-team")`,
+		`@meta(classification: "pii", cost_center: "cc-42")`,
+		"@stability(experimental)",
+		"@shard(by: tenant_id)",
+	} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("printed source missing %q\n%s", want, printed)
+		}
+	}
+
+	if reprinted := ast.Print(parse(t, printed)); reprinted != printed {
+		t.Errorf("printer is not idempotent\nfirst:\n%s\nsecond:\n%s", printed, reprinted)
+	}
+}
+
+func TestPrint_FieldLabels(t *testing.T) {
+	program := parse(t, `resource Post {
+  status: enum["draft", "in-review", "published"]! @labels(published: "Published", "in-review": "In review", draft: "Draft")
+}
+`)
+	printed := ast.Print(program)
+
+	want := `  status: enum["draft", "in-review", "published"]! @labels(draft: "Draft", "in-review": "In review", published: "Published")`
+	if !strings.Contains(printed, want) {
+		t.Errorf("printed source missing %q\n%s", want, printed)
+	}
+
+	reparsed := parse(t, printed)
+	got := reparsed.FindResource("Post").FindField("status").Labels
+	if got == nil || !reflect.DeepEqual(got.Values, program.FindResource("Post").FindField("status").Labels.Values) {
+		t.Errorf("labels did not survive the round trip: %+v", got)
+	}
+	if reprinted := ast.Print(reparsed); reprinted != printed {
+		t.Errorf("printer is not idempotent\nfirst:\n%s\nsecond:\n%s", printed, reprinted)
+	}
+}
+
+func TestFieldNode_ColumnOverride(t *testing.T) {
+	post := parse(t, blogSource).FindResource("Post")
+
+	if got := post.FindField("author_id").ColumnOverride(); got != "author_ref" {
+		t.Errorf("ColumnOverride() = %q, want %q", got, "author_ref")
+	}
+	if got := post.FindField("title").ColumnOverride(); got != "" {
+		t.Errorf("ColumnOverride() = %q, want empty", got)
+	}
+}
+
+func TestFieldNode_DualWrite(t *testing.T) {
+	post := parse(t, `resource Post {
+  headline: string! @dual_write("title", "2026-12-01")
+  summary: text? @dual_write("abstract")
+  body: text!
+}
+`).FindResource("Post")
+
+	tests := []struct {
+		field      string
+		wantColumn string
+		wantUntil  string
+	}{
+		{"headline", "title", "2026-12-01"},
+		{"summary", "abstract", ""},
+		{"body", "", ""},
+	}
+	for _, tt := range tests {
+		column, until := post.FindField(tt.field).DualWrite()
+		if column != tt.wantColumn || until != tt.wantUntil {
+			t.Errorf("%s.DualWrite() = (%q, %q), want (%q, %q)", tt.field, column, until, tt.wantColumn, tt.wantUntil)
+		}
+	}
+}
+
+func TestRelationshipNode_HasManyThrough(t *testing.T) {
+	program := parse(t, `resource BlogPost {
+  tags: array<Tag!>! { through: "post_tags" }
+  related: array<BlogPost!>! {
+    foreign_key: "source_id"
+    through: "related_posts"
+  }
+
+  @after attach(tags) {
+    self.title = tag.name
+  }
+}
+`)
+	post := program.FindResource("BlogPost")
+
+	tests := []struct {
+		relationship string
+		wantOwner    string
+		wantTarget   string
+		wantVariable string
+	}{
+		{"tags", "blog_post_id", "tag_id", "tag"},
+		{"related", "source_id", "blog_post_id", "blogPost"},
+	}
+	for _, tt := range tests {
+		rel := post.FindRelationship(tt.relationship)
+		owner, target := rel.JoinColumns(post.Name)
+		if owner != tt.wantOwner || target != tt.wantTarget {
+			t.Errorf("%s.JoinColumns() = (%q, %q), want (%q, %q)", tt.relationship, owner, target, tt.wantOwner, tt.wantTarget)
+		}
+		if got := rel.HookVariable(); got != tt.wantVariable {
+			t.Errorf("%s.HookVariable() = %q, want %q", tt.relationship, got, tt.wantVariable)
+		}
+	}
+
+	printed := ast.Print(program)
+	for _, want := range []string{"tags: array<Tag!>! {", "@after attach(tags) {"} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("printed source missing %q\n%s", want, printed)
+		}
+	}
+}
+
+func TestRelationshipNode_HasOne(t *testing.T) {
+	program := parse(t, `resource BlogAuthor {
+  profile: Profile? { kind: has_one }
+  avatar: Image? {
+    kind: has_one
+    foreign_key: "owner_id"
+  }
+}
+
+resource Profile {
+  blog_author_id: uuid!
+}
+`)
+	author := program.FindResource("BlogAuthor")
+
+	if got := author.FindRelationship("profile").TargetForeignKey(author.Name); got != "blog_author_id" {
+		t.Errorf("profile.TargetForeignKey() = %q, want blog_author_id", got)
+	}
+	if got := author.FindRelationship("avatar").TargetForeignKey(author.Name); got != "owner_id" {
+		t.Errorf("avatar.TargetForeignKey() = %q, want owner_id", got)
+	}
+	if got := program.FindResource("Profile").HasOneColumns(program.Resources); strings.Join(got, ",") != "blog_author_id" {
+		t.Errorf("HasOneColumns() = %v, want [blog_author_id]", got)
+	}
+
+	printed := ast.Print(program)
+	if !strings.Contains(printed, "profile: Profile? {\n    kind: has_one\n  }") {
+		t.Errorf("printed source should keep the kind\n%s", printed)
+	}
+	if reprinted := ast.Print(parse(t, printed)); reprinted != printed {
+		t.Errorf("printing is not stable:\n%s\n---\n%s", printed, reprinted)
+	}
+}
+
+func TestResourceNode_QueryAllowLists(t *testing.T) {
+	program := parse(t, `resource Post {
+  title: string! @sortable
+  status: string! @filterable @sortable
+  body: text!
+}
+`)
+	post := program.FindResource("Post")
+
+	if got := post.FilterableFields(); strings.Join(got, ",") != "status" {
+		t.Errorf("FilterableFields() = %v, want [status]", got)
+	}
+	if got := post.SortableFields(); strings.Join(got, ",") != "title,status" {
+		t.Errorf("SortableFields() = %v, want [title status]", got)
+	}
+
+	user := parse(t, blogSource).FindResource("User")
+	if got := user.FilterableFields(); strings.Join(got, ",") != "id,email,name" {
+		t.Errorf("FilterableFields() without annotations = %v, want all fields", got)
+	}
+}
+
+func TestResourceNode_FilterOperators(t *testing.T) {
+	program := parse(t, `resource Post {
+  title: string! @filterable(like)
+  status: string! @filterable
+  views: int! @filterable(gte, lte)
+}
+
+resource Tag {
+  name: string! @filterable
+}`)
+
+	want := map[string][]string{"title": {"like"}, "views": {"gte", "lte"}}
+	if got := program.Resources[0].FilterOperators(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterOperators() = %v, want %v", got, want)
+	}
+	if got := program.Resources[1].FilterOperators(); got != nil {
+		t.Errorf("FilterOperators() without operators = %v, want nil", got)
+	}
+}
+
+func TestResourceNode_Operations(t *testing.T) {
+	program := parse(t, `resource Post {
+  title: string!
+
+  @operations [list, show, create(admin_only), update]
+  @middleware [auth]
+}
+
+resource Tag {
+  name: string!
+
+  @operations all except [delete]
+}
+
+resource Note {
+  body: text!
+}
+`)
+
+	allowed := func(resource *ast.ResourceNode) string {
+		var ops []string
+		for _, op := range ast.StandardOperations {
+			if resource.AllowsOperation(op) {
+				ops = append(ops, op)
+			}
+		}
+		return strings.Join(ops, ",")
+	}
+	post, tag, note := program.FindResource("Post"), program.FindResource("Tag"), program.FindResource("Note")
+	if got := allowed(post); got != "list,get,create,update" {
+		t.Errorf("Post operations = %s, want list,get,create,update", got)
+	}
+	if got := allowed(tag); got != "list,get,create,update" {
+		t.Errorf("Tag operations = %s, want list,get,create,update", got)
+	}
+	if got := allowed(note); got != "list,get,create,update,delete" {
+		t.Errorf("Note operations = %s, want every operation", got)
+	}
+
+	for op, want := range map[string]string{
+		"create":       "auth,admin_only",
+		"create_batch": "auth,admin_only",
+		"upsert":       "auth,admin_only",
+		"update":       "auth",
+		"archive":      "auth",
+		"list":         "auth",
+	} {
+		if got := strings.Join(post.OperationMiddleware(op), ","); got != want {
+			t.Errorf("OperationMiddleware(%s) = %s, want %s", op, got, want)
+		}
+	}
+	if len(post.Middleware) != 1 {
+		t.Errorf("OperationMiddleware() changed the resource middleware: %v", post.Middleware)
+	}
+
+	printed := ast.Print(program)
+	for _, want := range []string{
+		"  @operations [list, show, create(admin_only), update]",
+		"  @operations all except [delete]",
+	} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("printed source missing %q\n%s", want, printed)
+		}
+	}
+}
+
+func TestInspect_VisitsAllFieldAccesses(t *testing.T) {
+	program := parse(t, blogSource)
+
+	var fields []string
+	ast.Inspect(program, func(n ast.Node) bool {
+		if access, ok := n.(*ast.FieldAccessExpr); ok {
+			fields = append(fields, access.Field)
+		}
+		return true
+	})
+
+	joined := strings.Join(fields, ",")
+	for _, want := range []string{"slug", "title", "published", "author", "name", "status"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected to visit self.%s, visited %v", want, fields)
+		}
+	}
+}
+
+func TestInspect_PruneSubtree(t *testing.T) {
+	program := parse(t, blogSource)
+
+	hooks := 0
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.HookNode:
+			hooks++
+			return false
+		case *ast.AssignmentStmt:
+			t.Error("Inspect descended into a pruned hook")
+		}
+		return true
+	})
+
+	if hooks != 1 {
+		t.Errorf("expected 1 hook, got %d", hooks)
+	}
+}
+
+func TestRenameField_Cascades(t *testing.T) {
+	program := parse(t, blogSource)
+
+	count, err := ast.RenameField(program, "Post", "title", "headline")
+	if err != nil {
+		t.Fatalf("RenameField failed: %v", err)
+	}
+
+	if count != 4 {
+		t.Errorf("expected 4 rewritten references, got %d", count)
+	}
+
+	printed := ast.Print(program)
+	if strings.Contains(printed, "title:") || strings.Contains(printed, "self.title") {
+		t.Errorf("old field name still present:\n%s", printed)
+	}
+	if !strings.Contains(printed, "headline: string! @min(5) @max(200)") {
+		t.Errorf("renamed declaration missing:\n%s", printed)
+	}
+
+	// Cross-resource access through a relationship
+	count, err = ast.RenameField(program, "User", "name", "display_name")
+	if err != nil {
+		t.Fatalf("RenameField failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 cross-resource reference, got %d", count)
+	}
+	if !strings.Contains(ast.Print(program), "self.author.display_name == null") {
+		t.Errorf("relationship access was not rewritten")
+	}
+}
+
+func TestRenameField_ForeignKey(t *testing.T) {
+	program := parse(t, blogSource)
+
+	if _, err := ast.RenameField(program, "Post", "author_id", "writer_id"); err != nil {
+		t.Fatalf("RenameField failed: %v", err)
+	}
+
+	if fk := program.FindResource("Post").FindRelationship("author").ForeignKey; fk != "writer_id" {
+		t.Errorf("expected foreign key writer_id, got %s", fk)
+	}
+}
+
+func TestRenameField_Errors(t *testing.T) {
+	program := parse(t, blogSource)
+
+	tests := []struct {
+		resource, old, new string
+	}{
+		{"Missing", "title", "headline"},
+		{"Post", "missing", "headline"},
+		{"Post", "title", "slug"},
+		{"Post", "title", "author"},
+		{"Post", "title", "title"},
+	}
+
+	for _, tt := range tests {
+		if _, err := ast.RenameField(program, tt.resource, tt.old, tt.new); err == nil {
+			t.Errorf("RenameField(%s, %s, %s) should fail", tt.resource, tt.old, tt.new)
+		}
+	}
+}
+
+func TestRenameResource(t *testing.T) {
+	program := parse(t, blogSource)
+
+	count, err := ast.RenameResource(program, "User", "Author")
+	if err != nil {
+		t.Fatalf("RenameResource failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 reference, got %d", count)
+	}
+
+	printed := ast.Print(program)
+	if !strings.Contains(printed, "resource Author {") || !strings.Contains(printed, "author: Author! {") {
+		t.Errorf("resource was not renamed:\n%s", printed)
+	}
+
+	if _, err := ast.RenameResource(program, "Author", "Post"); err == nil {
+		t.Error("renaming onto an existing resource should fail")
+	}
+}
+
+func TestInsertFieldAndAddMiddleware(t *testing.T) {
+	program := parse(t, blogSource)
+	post := program.FindResource("Post")
+
+	field := &ast.FieldNode{
+		Name:     "subtitle",
+		Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string", Nullable: true},
+		Nullable: true,
+	}
+	if err := ast.InsertField(post, field, "title"); err != nil {
+		t.Fatalf("InsertField failed: %v", err)
+	}
+	if post.Fields[2].Name != "subtitle" {
+		t.Errorf("expected subtitle after title, got %s", post.Fields[2].Name)
+	}
+	if err := ast.InsertField(post, field, ""); err == nil {
+		t.Error("inserting a duplicate field should fail")
+	}
+
+	if !ast.AddMiddleware(post, "auth") {
+		t.Error("AddMiddleware should add a new middleware")
+	}
+	if ast.AddMiddleware(post, "auth") {
+		t.Error("AddMiddleware should not add a duplicate")
+	}
+
+	printed := ast.Print(post)
+	if !strings.Contains(printed, "@middleware [auth]") || !strings.Contains(printed, "subtitle: string?") {
+		t.Errorf("rewrites not printed:\n%s", printed)
+	}
+
+	// The rewritten program must still parse
+	parse(t, ast.Print(program))
+}
+
+func TestKeyFields(t *testing.T) {
+	program := parse(t, `resource Post {
+  title: string!
+}
+
+resource Country {
+  code: string! @primary
+  name: string!
+}
+
+resource Membership {
+  region: string!
+  code: int!
+
+  @primary(region, code)
+}`)
+
+	tests := []struct {
+		resource string
+		fields   string
+		natural  bool
+		path     string
+	}{
+		{"Post", "", false, "{id}"},
+		{"Country", "code", true, "{code}"},
+		{"Membership", "region,code", true, "{region}/{code}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			resource := program.FindResource(tt.resource)
+			var names []string
+			for _, field := range resource.KeyFields() {
+				names = append(names, field.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.fields {
+				t.Errorf("KeyFields() = %q, want %q", got, tt.fields)
+			}
+			if got := resource.HasNaturalKey(); got != tt.natural {
+				t.Errorf("HasNaturalKey() = %v, want %v", got, tt.natural)
+			}
+			if got := resource.MemberPath(); got != tt.path {
+				t.Errorf("MemberPath() = %q, want %q", got, tt.path)
+			}
+		})
+	}
+}
+
+func TestDefaultScopeSQL(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      string
+	}{
+		{"self.deleted_at == nil", "posts.deleted_at IS NULL"},
+		{"nil != self.deleted_at", "posts.deleted_at IS NOT NULL"},
+		{"self.published", "posts.published IS TRUE"},
+		{"not self.published", "posts.published IS NOT TRUE"},
+		{"not self.deleted_at", "posts.deleted_at IS NULL"},
+		{`self.status != "it's draft"`, "posts.status <> 'it''s draft'"},
+		{"self.author_id == self.editor_id", "posts.author_ref = posts.editor_id"},
+		{"self.views >= 10 or self.published_at < Time.now()", "(posts.views >= 10 OR posts.published_at < NOW())"},
+		{"not (self.published and self.deleted_at == nil)", "NOT ((posts.published IS TRUE AND posts.deleted_at IS NULL))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			resource := parse(t, defaultScopeSource(tt.condition)).FindResource("Post")
+			got, err := resource.DefaultScopeSQL("posts.")
+			if err != nil {
+				t.Fatalf("DefaultScopeSQL() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DefaultScopeSQL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, condition := range []string{
+		"self.title",
+		"self.missing == nil",
+		"String.length(self.title) > 0",
+		"self.views + 1 > 10",
+		"self.published == nil or self.status",
+	} {
+		t.Run(condition, func(t *testing.T) {
+			resource := parse(t, defaultScopeSource(condition)).FindResource("Post")
+			if _, err := resource.DefaultScopeSQL(""); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+
+	unscoped := parse(t, "resource Post {\n  title: string!\n}").FindResource("Post")
+	if got, err := unscoped.DefaultScopeSQL("posts."); got != "" || err != nil {
+		t.Errorf("DefaultScopeSQL() without a scope = %q, %v", got, err)
+	}
+}
+
+func defaultScopeSource(condition string) string {
+	return `resource Post {
+  title: string!
+  status: string!
+  published: bool!
+  views: int!
+  author_id: uuid! @column("author_ref")
+  editor_id: uuid!
+  published_at: timestamp?
+  deleted_at: timestamp?
+
+  @default_scope { ` + condition + ` }
+}`
+}
@@ -0,0 +1,40 @@
+package ast
+
+import (
+	"strings"
+	"unicode"
+)
+
+// JoinColumns returns the columns of a has_many_through relationship's join
+// table referencing the owning resource and the related one: the declared
+// foreign_key or the owner's name in snake_case with an _id suffix, and the
+// related resource's name in snake_case with an _id suffix.
+func (r *RelationshipNode) JoinColumns(owner string) (ownerColumn, targetColumn string) {
+	ownerColumn = r.ForeignKey
+	if ownerColumn == "" {
+		ownerColumn = snakeCase(owner) + "_id"
+	}
+	return ownerColumn, snakeCase(r.Type) + "_id"
+}
+
+// HookVariable returns the name attach and detach hooks of a has_many_through
+// relationship see the related record as: its resource name with a lowercase
+// first letter, e.g. tag for Tag.
+func (r *RelationshipNode) HookVariable() string {
+	if r.Type == "" {
+		return ""
+	}
+	return strings.ToLower(r.Type[:1]) + r.Type[1:]
+}
+
+// snakeCase converts a resource name such as BlogPost to blog_post
+func snakeCase(name string) string {
+	var sb strings.Builder
+	for i, c := range name {
+		if i > 0 && unicode.IsUpper(c) {
+			sb.WriteByte('_')
+		}
+		sb.WriteRune(unicode.ToLower(c))
+	}
+	return sb.String()
+}
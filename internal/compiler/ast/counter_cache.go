@@ -0,0 +1,100 @@
+package ast
+
+// CounterCacheConstraint marks the counter fields added to parent resources
+// by @counter_cache. Its arguments are the counted resource and the foreign
+// key column that references the parent.
+const CounterCacheConstraint = "counter_cache"
+
+// ForeignKeyColumn returns the column holding a belongs_to relationship's
+// foreign key: the declared foreign_key, or the relationship name with an _id
+// suffix.
+func (r *RelationshipNode) ForeignKeyColumn() string {
+	if r.ForeignKey != "" {
+		return r.ForeignKey
+	}
+	return r.Name + "_id"
+}
+
+// CounterCacheRelationship returns the belongs_to relationship a counter
+// cache counts through, or nil when the resource has no belongs_to
+// relationship to the counter's parent or more than one.
+func (r *ResourceNode) CounterCacheRelationship(counter *CounterCacheNode) *RelationshipNode {
+	var found *RelationshipNode
+	for _, rel := range r.Relationships {
+		if rel.Kind != RelationshipBelongsTo || rel.Type != counter.Resource {
+			continue
+		}
+		if found != nil {
+			return nil
+		}
+		found = rel
+	}
+	return found
+}
+
+// CounterCacheFields returns the fields of a resource that are maintained by
+// @counter_cache on another resource.
+func (r *ResourceNode) CounterCacheFields() []*FieldNode {
+	var fields []*FieldNode
+	for _, field := range r.Fields {
+		if field.IsCounterCache() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// IsCounterCache reports whether the field is a counter added by @counter_cache.
+func (f *FieldNode) IsCounterCache() bool {
+	for _, constraint := range f.Constraints {
+		if constraint.Name == CounterCacheConstraint {
+			return true
+		}
+	}
+	return false
+}
+
+// WithCounterCaches returns the resources with the counter column of every
+// @counter_cache added to its parent as an int! field defaulting to 0. Parents
+// that gain fields are copied, so the given resources are left unchanged and
+// the result can be computed again from the same parsed programs. Counters
+// whose parent is missing or already declares the column are skipped; the
+// type checker reports them.
+func WithCounterCaches(resources []*ResourceNode) []*ResourceNode {
+	result := make([]*ResourceNode, len(resources))
+	copy(result, resources)
+
+	index := make(map[string]int, len(result))
+	for i, resource := range result {
+		index[resource.Name] = i
+	}
+
+	for _, child := range resources {
+		for _, counter := range child.CounterCaches {
+			i, ok := index[counter.Resource]
+			rel := child.CounterCacheRelationship(counter)
+			if !ok || rel == nil || result[i].hasMember(counter.Column) {
+				continue
+			}
+
+			parent := *result[i]
+			parent.Fields = append(append([]*FieldNode(nil), parent.Fields...), &FieldNode{
+				Name:    counter.Column,
+				Type:    &TypeNode{Kind: TypePrimitive, Name: "int"},
+				Default: &LiteralExpr{Value: int64(0), Loc: counter.Loc},
+				Constraints: []*ConstraintNode{{
+					Name: CounterCacheConstraint,
+					Arguments: []ExprNode{
+						&LiteralExpr{Value: child.Name, Loc: counter.Loc},
+						&LiteralExpr{Value: rel.ForeignKeyColumn(), Loc: counter.Loc},
+					},
+					Loc: counter.Loc,
+				}},
+				Loc: counter.Loc,
+			})
+			result[i] = &parent
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,207 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultScopeSQL returns the condition of the resource's @default_scope as a
+// SQL boolean expression, with columns prefixed by qualifier such as "posts.",
+// or "" for a resource without one. Conditions may compare fields of self with
+// literals, nil and Time.now(), test bool and nullable fields on their own, and
+// combine those with and, or and not; the ! unwrap operator is ignored.
+// Anything else is an error, reported by the type checker. And and or are
+// parenthesized, so the result can be joined to other conditions with AND.
+func (r *ResourceNode) DefaultScopeSQL(qualifier string) (string, error) {
+	if r.DefaultScope == nil {
+		return "", nil
+	}
+	return r.scopeCondition(r.DefaultScope.Condition, qualifier)
+}
+
+// scopeCondition translates an expression used as a condition
+func (r *ResourceNode) scopeCondition(expr ExprNode, qualifier string) (string, error) {
+	switch e := expr.(type) {
+	case *ParenExpr:
+		return r.scopeCondition(e.Expr, qualifier)
+
+	case *LogicalExpr:
+		operator := "AND"
+		if e.Operator == "or" || e.Operator == "||" {
+			operator = "OR"
+		}
+		left, err := r.scopeCondition(e.Left, qualifier)
+		if err != nil {
+			return "", err
+		}
+		right, err := r.scopeCondition(e.Right, qualifier)
+		if err != nil {
+			return "", err
+		}
+		return "(" + left + " " + operator + " " + right + ")", nil
+
+	case *UnaryExpr:
+		// ! unwraps a nullable value, which SQL does not need
+		if e.Operator == "!" {
+			return r.scopeCondition(e.Operand, qualifier)
+		}
+		if e.Operator != "not" {
+			break
+		}
+		// not self.field tests the field directly, so NULL counts as false
+		if access, ok := e.Operand.(*FieldAccessExpr); ok {
+			return r.scopeTruth(access, qualifier, false)
+		}
+		operand, err := r.scopeCondition(e.Operand, qualifier)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + operand + ")", nil
+
+	case *FieldAccessExpr:
+		return r.scopeTruth(e, qualifier, true)
+
+	case *LiteralExpr:
+		if value, ok := e.Value.(bool); ok {
+			return strings.ToUpper(fmt.Sprint(value)), nil
+		}
+
+	case *BinaryExpr:
+		return r.scopeComparison(e, qualifier)
+	}
+
+	return "", fmt.Errorf("@default_scope cannot translate %s to SQL: use comparisons of self fields joined with and, or and not", describeExpr(expr))
+}
+
+// scopeTruth translates a field tested on its own: a bool field is true, any
+// other nullable field is set. Negated tests are the opposite.
+func (r *ResourceNode) scopeTruth(access *FieldAccessExpr, qualifier string, truth bool) (string, error) {
+	field, column, err := r.scopeColumn(access, qualifier)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case field.Type != nil && field.Type.Kind == TypePrimitive && field.Type.Name == "bool":
+		if truth {
+			return column + " IS TRUE", nil
+		}
+		return column + " IS NOT TRUE", nil
+	case field.Nullable:
+		if truth {
+			return column + " IS NOT NULL", nil
+		}
+		return column + " IS NULL", nil
+	}
+	return "", fmt.Errorf("@default_scope cannot test %s on its own: it is always set, so compare it with a value", access.Field)
+}
+
+// scopeComparison translates a comparison; == nil and != nil become IS NULL
+// and IS NOT NULL
+func (r *ResourceNode) scopeComparison(bin *BinaryExpr, qualifier string) (string, error) {
+	operators := map[string]string{"==": "=", "!=": "<>", "<": "<", "<=": "<=", ">": ">", ">=": ">="}
+	operator, ok := operators[bin.Operator]
+	if !ok {
+		return "", fmt.Errorf("@default_scope cannot translate the %s operator to SQL", bin.Operator)
+	}
+
+	left, err := r.scopeValue(bin.Left, qualifier)
+	if err != nil {
+		return "", err
+	}
+	right, err := r.scopeValue(bin.Right, qualifier)
+	if err != nil {
+		return "", err
+	}
+
+	if left == "NULL" {
+		left, right = right, left
+	}
+	if right == "NULL" {
+		switch bin.Operator {
+		case "==":
+			return left + " IS NULL", nil
+		case "!=":
+			return left + " IS NOT NULL", nil
+		}
+		return "", fmt.Errorf("@default_scope can only compare nil with == or !=")
+	}
+	return left + " " + operator + " " + right, nil
+}
+
+// scopeValue translates an operand of a comparison: a field of self, a
+// literal or Time.now()
+func (r *ResourceNode) scopeValue(expr ExprNode, qualifier string) (string, error) {
+	switch e := expr.(type) {
+	case *ParenExpr:
+		return r.scopeValue(e.Expr, qualifier)
+
+	case *UnaryExpr:
+		if e.Operator == "!" {
+			return r.scopeValue(e.Operand, qualifier)
+		}
+
+	case *FieldAccessExpr:
+		_, column, err := r.scopeColumn(e, qualifier)
+		return column, err
+
+	case *LiteralExpr:
+		switch value := e.Value.(type) {
+		case nil:
+			return "NULL", nil
+		case bool:
+			return strings.ToUpper(fmt.Sprint(value)), nil
+		case string:
+			return "'" + strings.ReplaceAll(value, "'", "''") + "'", nil
+		case int, int64, float64:
+			return fmt.Sprint(value), nil
+		}
+
+	case *CallExpr:
+		if e.Namespace == "Time" && e.Function == "now" && len(e.Arguments) == 0 {
+			return "NOW()", nil
+		}
+	}
+
+	return "", fmt.Errorf("@default_scope cannot translate %s to SQL: compare self fields with literals, nil or Time.now()", describeExpr(expr))
+}
+
+// scopeColumn resolves self.field to the field and its qualified column
+func (r *ResourceNode) scopeColumn(access *FieldAccessExpr, qualifier string) (*FieldNode, string, error) {
+	if _, ok := access.Object.(*SelfExpr); !ok {
+		return nil, "", fmt.Errorf("@default_scope can only read fields of self, not %s", describeExpr(access))
+	}
+	field := r.FindField(access.Field)
+	if field == nil {
+		return nil, "", fmt.Errorf("@default_scope cannot filter by %s: it is not a field of %s", access.Field, r.Name)
+	}
+
+	column := field.ColumnOverride()
+	if column == "" {
+		column = strings.ToLower(field.Name)
+	}
+	return field, qualifier + column, nil
+}
+
+// describeExpr names an expression for error messages
+func describeExpr(expr ExprNode) string {
+	switch e := expr.(type) {
+	case *CallExpr:
+		if e.Namespace != "" {
+			return e.Namespace + "." + e.Function + "()"
+		}
+		return e.Function + "()"
+	case *FieldAccessExpr:
+		if ident, ok := e.Object.(*IdentifierExpr); ok {
+			return ident.Name + "." + e.Field
+		}
+		return "a nested field access"
+	case *BinaryExpr:
+		return "the " + e.Operator + " operator"
+	case *UnaryExpr:
+		return "the " + e.Operator + " operator"
+	case *IdentifierExpr:
+		return e.Name
+	}
+	return "this expression"
+}
@@ -0,0 +1,40 @@
+package ast
+
+// RelationshipKindNames maps the kind: values of a relationship body to the
+// kinds they declare
+var RelationshipKindNames = map[string]RelationshipKind{
+	"belongs_to":       RelationshipBelongsTo,
+	"has_many":         RelationshipHasMany,
+	"has_many_through": RelationshipHasManyThrough,
+	"has_one":          RelationshipHasOne,
+}
+
+// TargetForeignKey returns the column of the related resource that references
+// the owning one in a has_one relationship: the declared foreign_key, or the
+// owner's name in snake_case with an _id suffix, e.g. user_id for a User's
+// profile.
+func (r *RelationshipNode) TargetForeignKey(owner string) string {
+	if r.ForeignKey != "" {
+		return r.ForeignKey
+	}
+	return snakeCase(owner) + "_id"
+}
+
+// HasOneColumns returns the columns of r that has_one relationships among
+// resources reference. Each holds at most one r per owning record.
+func (r *ResourceNode) HasOneColumns(resources []*ResourceNode) []string {
+	var columns []string
+	seen := make(map[string]bool)
+	for _, owner := range resources {
+		for _, rel := range owner.Relationships {
+			if rel.Kind != RelationshipHasOne || rel.Type != r.Name {
+				continue
+			}
+			if column := rel.TargetForeignKey(owner.Name); !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+	return columns
+}
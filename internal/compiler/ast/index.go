@@ -0,0 +1,46 @@
+package ast
+
+// IndexConstraint marks fields indexed with @index
+const IndexConstraint = "index"
+
+// IndexNode is a database index, declared on the resource with
+// index [author, published_at] { unique: true } or on a field with @index
+type IndexNode struct {
+	Fields []string // Fields and belongs_to relationships, in column order
+	Unique bool     // Rejects two records with the same values
+	Name   string   // From the name option; derived from the table and columns when empty
+	Loc    SourceLocation
+}
+
+// AllIndexes returns the indexes of a resource: one for each @index field in
+// declaration order, then those of its index blocks
+func (r *ResourceNode) AllIndexes() []*IndexNode {
+	var indexes []*IndexNode
+	for _, field := range r.Fields {
+		for _, constraint := range field.Constraints {
+			if constraint.Name == IndexConstraint {
+				indexes = append(indexes, &IndexNode{Fields: []string{field.Name}, Loc: constraint.Loc})
+				break
+			}
+		}
+	}
+	return append(indexes, r.Indexes...)
+}
+
+// IndexColumns returns the columns of an index: the column of each field, or
+// the foreign key of each belongs_to relationship. Names that are neither are
+// returned as they are; the type checker reports them.
+func (r *ResourceNode) IndexColumns(index *IndexNode) []string {
+	columns := make([]string, len(index.Fields))
+	for i, name := range index.Fields {
+		columns[i] = name
+		if field := r.FindField(name); field != nil {
+			if column := field.ColumnOverride(); column != "" {
+				columns[i] = column
+			}
+		} else if rel := r.FindRelationship(name); rel != nil && rel.Kind == RelationshipBelongsTo {
+			columns[i] = rel.ForeignKeyColumn()
+		}
+	}
+	return columns
+}
@@ -0,0 +1,92 @@
+package ast
+
+import "slices"
+
+// Standard operations served by generated routes. Show is another name for
+// get.
+const (
+	OperationList   = "list"
+	OperationGet    = "get"
+	OperationShow   = "show"
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// StandardOperations lists the standard operations in route order
+var StandardOperations = []string{OperationList, OperationGet, OperationCreate, OperationUpdate, OperationDelete}
+
+// AdminOnly restricts an operation to callers with the admin role, as in
+// @operations [list, create(admin_only)]
+const AdminOnly = "admin_only"
+
+// OperationModifiers are the refinements an operation may carry in
+// @operations
+var OperationModifiers = []string{AdminOnly}
+
+// IsStandardOperation reports whether op names a standard operation
+func IsStandardOperation(op string) bool {
+	return slices.Contains(StandardOperations, canonicalOperation(op))
+}
+
+// AllowsOperation reports whether @operations enables the standard operation
+// op: every operation without the annotation, the listed ones with a list,
+// and all but the excluded ones with all except [...]. Get and show match
+// each other.
+func (r *ResourceNode) AllowsOperation(op string) bool {
+	op = canonicalOperation(op)
+	matches := func(name string) bool { return canonicalOperation(name) == op }
+
+	if slices.ContainsFunc(r.Excluded, matches) {
+		return false
+	}
+	return len(r.Operations) == 0 || slices.ContainsFunc(r.Operations, matches)
+}
+
+// writesLike maps the write routes generated beside the standard operations
+// to the operations whose modifiers they share, so that a batch create or an
+// upsert is as restricted as a create
+var writesLike = map[string][]string{
+	"create_batch": {OperationCreate},
+	"patch":        {OperationUpdate},
+	"upsert":       {OperationCreate, OperationUpdate},
+	"archive":      {OperationUpdate},
+	"restore":      {OperationUpdate},
+	"move":         {OperationUpdate},
+}
+
+// HasOperationModifier reports whether @operations refines op with modifier,
+// e.g. create(admin_only). Routes writing like a standard operation, such as
+// create_batch, patch, upsert, archive, restore and move, share its modifiers.
+func (r *ResourceNode) HasOperationModifier(op, modifier string) bool {
+	ops := []string{canonicalOperation(op)}
+	if like, ok := writesLike[op]; ok {
+		ops = like
+	}
+	for name, mods := range r.OperationMods {
+		if slices.Contains(ops, canonicalOperation(name)) && slices.Contains(mods, modifier) {
+			return true
+		}
+	}
+	return false
+}
+
+// OperationMiddleware returns the middleware of op's routes: the resource
+// middleware followed by the operation's modifiers
+func (r *ResourceNode) OperationMiddleware(op string) []string {
+	middleware := r.Middleware
+	for _, modifier := range OperationModifiers {
+		if r.HasOperationModifier(op, modifier) {
+			middleware = append(slices.Clip(middleware), modifier)
+		}
+	}
+	return middleware
+}
+
+// canonicalOperation returns the name of op used in routes, get for show
+func canonicalOperation(op string) string {
+	if op == OperationShow {
+		return OperationGet
+	}
+	return op
+}
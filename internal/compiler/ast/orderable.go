@@ -0,0 +1,28 @@
+package ast
+
+// WithPositions returns the resources with the position field of every
+// @orderable resource that does not declare one added as an int! field
+// defaulting to 0. Resources that gain the field are copied, so the given
+// resources are left unchanged. Resources with another member named position
+// are skipped; the type checker reports them.
+func WithPositions(resources []*ResourceNode) []*ResourceNode {
+	result := make([]*ResourceNode, len(resources))
+	copy(result, resources)
+
+	for i, resource := range resources {
+		if resource.Orderable == nil || resource.hasMember(PositionField) {
+			continue
+		}
+
+		orderable := *resource
+		orderable.Fields = append(append([]*FieldNode(nil), resource.Fields...), &FieldNode{
+			Name:    PositionField,
+			Type:    &TypeNode{Kind: TypePrimitive, Name: "int"},
+			Default: &LiteralExpr{Value: int64(0), Loc: resource.Orderable.Loc},
+			Loc:     resource.Orderable.Loc,
+		})
+		result[i] = &orderable
+	}
+
+	return result
+}
@@ -0,0 +1,68 @@
+package ast
+
+import "strings"
+
+// KeyFields returns the primary key fields of a resource in order: the fields
+// named by a composite @primary, the field declared @primary, or the id
+// field. It returns nil when the resource declares none of them and its key
+// is the id column generated for it. Names without a field are skipped; the
+// type checker reports them.
+func (r *ResourceNode) KeyFields() []*FieldNode {
+	if r.PrimaryKey != nil {
+		var fields []*FieldNode
+		for _, name := range r.PrimaryKey.Fields {
+			if field := r.FindField(name); field != nil {
+				fields = append(fields, field)
+			}
+		}
+		return fields
+	}
+
+	for _, field := range r.Fields {
+		if field.HasConstraint("primary") {
+			return []*FieldNode{field}
+		}
+	}
+	if id := r.FindField("id"); id != nil {
+		return []*FieldNode{id}
+	}
+	return nil
+}
+
+// HasNaturalKey reports whether records of a resource are identified by
+// something other than id: a @primary field with another name, such as a
+// country code, or a composite key
+func (r *ResourceNode) HasNaturalKey() bool {
+	key := r.KeyFields()
+	return len(key) > 1 || (len(key) == 1 && key[0].Name != "id")
+}
+
+// KeyParams returns the route parameters identifying a record of the
+// resource, one per primary key field: id, or the names of the fields of a
+// natural key
+func (r *ResourceNode) KeyParams() []string {
+	if !r.HasNaturalKey() {
+		return []string{"id"}
+	}
+	var params []string
+	for _, field := range r.KeyFields() {
+		params = append(params, field.Name)
+	}
+	return params
+}
+
+// MemberPath returns the path of a record relative to its collection, each
+// key parameter in braces: {id}, or for example {code} or {region}/{code}
+func (r *ResourceNode) MemberPath() string {
+	params := r.KeyParams()
+	for i, param := range params {
+		params[i] = "{" + param + "}"
+	}
+	return strings.Join(params, "/")
+}
+
+// MemberPattern returns MemberPath in the :param form of route metadata: :id,
+// or for example :code or :region/:code
+func (r *ResourceNode) MemberPattern() string {
+	return ":" + strings.Join(r.KeyParams(), "/:")
+}
@@ -0,0 +1,760 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+)
+
+// printIndent is the indentation unit used by the printer, matching `conduit format`
+const printIndent = "  "
+
+// Print renders an AST node back to Conduit (.cdt) source. Programs, resources,
+// types, statements and expressions are supported. Comments are not part of
+// the AST and are therefore not reproduced.
+func Print(node Node) string {
+	p := &printer{}
+	p.node(node)
+	return p.buf.String()
+}
+
+// Fprint writes the Conduit source for node to w
+func Fprint(w io.Writer, node Node) error {
+	_, err := io.WriteString(w, Print(node))
+	return err
+}
+
+// printer accumulates Conduit source text
+type printer struct {
+	buf    strings.Builder
+	indent int
+}
+
+func (p *printer) line(format string, args ...interface{}) {
+	if format != "" {
+		p.buf.WriteString(strings.Repeat(printIndent, p.indent))
+		fmt.Fprintf(&p.buf, format, args...)
+	}
+	p.buf.WriteString("\n")
+}
+
+func (p *printer) node(node Node) {
+	switch n := node.(type) {
+	case *Program:
+		for i, resource := range n.Resources {
+			if i > 0 {
+				p.line("")
+			}
+			p.resource(resource)
+		}
+	case *ResourceNode:
+		p.resource(n)
+	case *FieldNode:
+		p.field(n)
+	case *RelationshipNode:
+		p.relationship(n)
+	case *HookNode:
+		p.hook(n)
+	case *TypeNode:
+		p.buf.WriteString(formatType(n, n.Nullable))
+	case StmtNode:
+		p.stmt(n)
+	case ExprNode:
+		p.buf.WriteString(formatExpr(n))
+	}
+}
+
+func (p *printer) resource(r *ResourceNode) {
+	if r.Documentation != "" {
+		for _, doc := range strings.Split(r.Documentation, "\n") {
+			p.line("/// %s", strings.TrimSpace(doc))
+		}
+	}
+
+	p.line("resource %s {", r.Name)
+	p.indent++
+
+	sections := 0
+	section := func() {
+		if sections > 0 {
+			p.line("")
+		}
+		sections++
+	}
+
+	annotations := resourceAnnotations(r)
+	if len(r.Aliases) > 0 || len(r.Operations) > 0 || len(r.Excluded) > 0 || len(r.Middleware) > 0 || r.CountStrategy != "" || len(annotations) > 0 {
+		section()
+		if len(r.Aliases) > 0 {
+			aliases := make([]string, len(r.Aliases))
+			for i, alias := range r.Aliases {
+				aliases[i] = quoteString(alias)
+			}
+			p.line("@alias(%s)", strings.Join(aliases, ", "))
+		}
+		if len(r.Operations) > 0 {
+			operations := make([]string, len(r.Operations))
+			for i, op := range r.Operations {
+				operations[i] = op
+				if mods := r.OperationMods[op]; len(mods) > 0 {
+					operations[i] += "(" + strings.Join(mods, ", ") + ")"
+				}
+			}
+			p.line("@operations [%s]", strings.Join(operations, ", "))
+		}
+		if len(r.Excluded) > 0 {
+			p.line("@operations all except [%s]", strings.Join(r.Excluded, ", "))
+		}
+		if len(r.Middleware) > 0 {
+			p.line("@middleware [%s]", strings.Join(r.Middleware, ", "))
+		}
+		if r.CountStrategy != "" {
+			p.line("@count(%s)", r.CountStrategy)
+		}
+		for _, annotation := range annotations {
+			p.line("%s", annotation)
+		}
+	}
+
+	if len(r.Fields) > 0 {
+		section()
+		for _, field := range r.Fields {
+			p.field(field)
+		}
+	}
+
+	for _, rel := range r.Relationships {
+		section()
+		p.relationship(rel)
+	}
+
+	for _, computed := range r.Computed {
+		section()
+		p.line("@computed %s: %s {", computed.Name, formatType(computed.Type, computed.Type.Nullable))
+		p.indent++
+		p.line("%s", formatExpr(computed.Body))
+		p.indent--
+		p.line("}")
+	}
+
+	for _, scope := range r.Scopes {
+		section()
+		args := ""
+		if len(scope.Arguments) > 0 {
+			args = "(" + formatArguments(scope.Arguments) + ")"
+		}
+		p.line("@scope %s%s {", scope.Name, args)
+		p.indent++
+		p.line("%s", formatExpr(scope.Condition))
+		p.indent--
+		p.line("}")
+	}
+
+	for _, hook := range r.Hooks {
+		section()
+		p.hook(hook)
+	}
+
+	for _, validation := range r.Validations {
+		section()
+		p.line("@validate %s {", validation.Name)
+		p.indent++
+		p.line("condition: %s", formatExpr(validation.Condition))
+		if validation.Error != "" {
+			p.line("error: %s", quoteString(validation.Error))
+		}
+		p.indent--
+		p.line("}")
+	}
+
+	for _, constraint := range r.Constraints {
+		section()
+		p.line("@constraint %s {", constraint.Name)
+		p.indent++
+		if len(constraint.On) > 0 {
+			p.line("on: [%s]", strings.Join(constraint.On, ", "))
+		}
+		if constraint.When != nil {
+			p.line("when: %s", formatExpr(constraint.When))
+		}
+		if constraint.Condition != nil {
+			p.line("condition: %s", formatExpr(constraint.Condition))
+		}
+		if constraint.Error != "" {
+			p.line("error: %s", quoteString(constraint.Error))
+		}
+		p.indent--
+		p.line("}")
+	}
+
+	if len(r.Indexes) > 0 {
+		section()
+		for _, index := range r.Indexes {
+			p.index(index)
+		}
+	}
+
+	p.indent--
+	p.line("}")
+}
+
+// resourceAnnotations renders the resource annotations that configure a
+// single feature, such as @slo or @upsert, in the order the parser lists them
+func resourceAnnotations(r *ResourceNode) []string {
+	var lines []string
+	if r.SLO != nil {
+		var objectives []string
+		for _, latency := range r.SLO.Latency {
+			objectives = append(objectives, fmt.Sprintf("latency_p%d: %s", latency.Percentile, formatLatency(latency.Threshold)))
+		}
+		if r.SLO.Availability > 0 {
+			objectives = append(objectives, "availability: "+strconv.FormatFloat(r.SLO.Availability, 'f', -1, 64))
+		}
+		lines = append(lines, "@slo("+strings.Join(objectives, ", ")+")")
+	}
+	if c := r.CacheControl; c != nil {
+		options := []string{fmt.Sprintf("max_age: %d", c.MaxAge)}
+		if c.SharedMaxAge > 0 {
+			options = append(options, fmt.Sprintf("s_maxage: %d", c.SharedMaxAge))
+		}
+		if c.StaleWhileRevalidate > 0 {
+			options = append(options, fmt.Sprintf("stale_while_revalidate: %d", c.StaleWhileRevalidate))
+		}
+		if c.Public {
+			options = append(options, "public: true")
+		}
+		lines = append(lines, "@cache_control("+strings.Join(options, ", ")+")")
+	}
+	if r.Changes != nil {
+		lines = append(lines, "@changes")
+	}
+	if r.Conflict != nil {
+		strategy := r.Conflict.Strategy
+		if strategy == ConflictMerge {
+			strategy += "(" + strings.Join(r.Conflict.MergeFields, ", ") + ")"
+		}
+		lines = append(lines, "@conflict(strategy: "+strategy+")")
+	}
+	if r.Partition != nil {
+		lines = append(lines, fmt.Sprintf("@partition(by: %s, interval: %s)", r.Partition.Field, r.Partition.Interval))
+	}
+	if r.Materialized != nil {
+		lines = append(lines, fmt.Sprintf("@materialized(refresh: %s, query: %s)", r.Materialized.Refresh, quoteString(r.Materialized.Query)))
+	}
+	for _, counter := range r.CounterCaches {
+		lines = append(lines, fmt.Sprintf("@counter_cache(%s on %s.%s)", counter.Column, counter.Resource, counter.Relationship))
+	}
+	if r.SearchIndex != nil {
+		lines = append(lines, "@search_index("+strings.Join(r.SearchIndex.Fields, ", ")+")")
+	}
+	if r.Webhook != nil {
+		lines = append(lines, "@webhook("+r.Webhook.Provider+")")
+	}
+	if len(r.Profiles) > 0 {
+		profiles := make([]string, len(r.Profiles))
+		for i, profile := range r.Profiles {
+			if profile.All {
+				profiles[i] = profile.Name + ": *"
+			} else {
+				profiles[i] = profile.Name + ": [" + strings.Join(profile.Fields, ", ") + "]"
+			}
+		}
+		lines = append(lines, "@profile("+strings.Join(profiles, ", ")+")")
+	}
+	if r.Upsert != nil {
+		lines = append(lines, "@upsert(on: ["+strings.Join(r.Upsert.Fields, ", ")+"])")
+	}
+	if r.Archivable != nil {
+		lines = append(lines, "@archivable")
+	}
+	if r.SoftDelete != nil {
+		lines = append(lines, "@soft_delete")
+	}
+	if r.DefaultScope != nil {
+		lines = append(lines, "@default_scope { "+formatExpr(r.DefaultScope.Condition)+" }")
+	}
+	if r.Orderable != nil {
+		if r.Orderable.Scope != "" {
+			lines = append(lines, "@orderable(scope: "+r.Orderable.Scope+")")
+		} else {
+			lines = append(lines, "@orderable")
+		}
+	}
+	if r.Tree != nil {
+		var options []string
+		if r.Tree.Parent != "" {
+			options = append(options, "parent: "+r.Tree.Parent)
+		}
+		if r.Tree.MaxDepth > 0 {
+			options = append(options, fmt.Sprintf("max_depth: %d", r.Tree.MaxDepth))
+		}
+		if len(options) > 0 {
+			lines = append(lines, "@tree("+strings.Join(options, ", ")+")")
+		} else {
+			lines = append(lines, "@tree")
+		}
+	}
+	if r.IDStrategy != nil {
+		lines = append(lines, "@id(strategy: "+r.IDStrategy.Strategy+")")
+	}
+	if r.PrimaryKey != nil {
+		lines = append(lines, "@primary("+strings.Join(r.PrimaryKey.Fields, ", ")+")")
+	}
+	if r.Timestamps != nil {
+		if r.Timestamps.Enabled {
+			lines = append(lines, "@timestamps")
+		} else {
+			lines = append(lines, "@timestamps(false)")
+		}
+	}
+	if r.Schema != nil {
+		lines = append(lines, "@schema("+quoteString(r.Schema.Name)+")")
+	}
+	if r.External != nil {
+		lines = append(lines, "@external_table("+quoteString(r.External.Table)+")")
+	}
+	if r.Owner != nil {
+		lines = append(lines, "@owner("+quoteString(r.Owner.Team)+")")
+	}
+	if r.Meta != nil && len(r.Meta.Values) > 0 {
+		lines = append(lines, "@meta("+formatPairs(r.Meta.Values)+")")
+	}
+	if r.Stability != nil {
+		lines = append(lines, "@stability("+r.Stability.Level+")")
+	}
+	if r.Shard != nil {
+		lines = append(lines, "@shard(by: "+r.Shard.Field+")")
+	}
+	return lines
+}
+
+// formatLatency renders an @slo latency threshold in whole seconds when it is
+// one, and in milliseconds otherwise
+func formatLatency(threshold time.Duration) string {
+	if threshold%time.Second == 0 {
+		return fmt.Sprintf("%ds", threshold/time.Second)
+	}
+	return strconv.FormatFloat(float64(threshold)/float64(time.Millisecond), 'f', -1, 64) + "ms"
+}
+
+func (p *printer) index(index *IndexNode) {
+	var options []string
+	if index.Unique {
+		options = append(options, "unique: true")
+	}
+	if index.Name != "" {
+		options = append(options, "name: "+quoteString(index.Name))
+	}
+	if len(options) == 0 {
+		p.line("index [%s]", strings.Join(index.Fields, ", "))
+		return
+	}
+	p.line("index [%s] { %s }", strings.Join(index.Fields, ", "), strings.Join(options, ", "))
+}
+
+func (p *printer) field(f *FieldNode) {
+	var sb strings.Builder
+	sb.WriteString(f.Name)
+	sb.WriteString(": ")
+	sb.WriteString(formatType(f.Type, f.Nullable))
+
+	hasDefault := false
+	for _, constraint := range f.Constraints {
+		if constraint.Name == "default" {
+			hasDefault = true
+		}
+		sb.WriteString(" ")
+		sb.WriteString(formatFieldConstraint(constraint))
+	}
+
+	if f.Default != nil && !hasDefault {
+		sb.WriteString(" @default(")
+		sb.WriteString(formatExpr(f.Default))
+		sb.WriteString(")")
+	}
+
+	if f.Labels != nil && len(f.Labels.Values) > 0 {
+		sb.WriteString(" @labels(")
+		sb.WriteString(formatPairs(f.Labels.Values))
+		sb.WriteString(")")
+	}
+	if f.Meta != nil && len(f.Meta.Values) > 0 {
+		sb.WriteString(" @meta(")
+		sb.WriteString(formatPairs(f.Meta.Values))
+		sb.WriteString(")")
+	}
+
+	p.line("%s", sb.String())
+}
+
+// formatPairs renders the key: "value" pairs of @meta and @labels in key
+// order. Keys that are not field names, such as the enum value "in-review",
+// are written as strings, which only @labels accepts.
+func formatPairs(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		name := key
+		if !isFieldName(key) {
+			name = quoteString(key)
+		}
+		pairs[i] = name + ": " + quoteString(values[key])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// isFieldName reports whether s lexes as a field name: an identifier or a
+// primitive type name
+func isFieldName(s string) bool {
+	if tokenType, ok := lexer.Keywords[s]; ok {
+		return lexer.IsType(tokenType)
+	}
+	return lexer.IsValidIdentifier(s)
+}
+
+func (p *printer) relationship(r *RelationshipNode) {
+	typeName := r.Type
+	if r.Kind == RelationshipHasMany || r.Kind == RelationshipHasManyThrough {
+		typeName = "array<" + typeName + nullMarker(false) + ">"
+	}
+	typeName += nullMarker(r.Nullable)
+
+	if r.ForeignKey == "" && r.OnDelete == "" && r.Through == "" && r.Kind != RelationshipHasOne {
+		p.line("%s: %s {}", r.Name, typeName)
+		return
+	}
+
+	p.line("%s: %s {", r.Name, typeName)
+	p.indent++
+	if r.Kind == RelationshipHasOne {
+		p.line("kind: has_one")
+	}
+	if r.ForeignKey != "" {
+		p.line("foreign_key: %s", quoteString(r.ForeignKey))
+	}
+	if r.Through != "" {
+		p.line("through: %s", quoteString(r.Through))
+	}
+	if r.OnDelete != "" {
+		p.line("on_delete: %s", r.OnDelete)
+	}
+	p.indent--
+	p.line("}")
+}
+
+func (p *printer) hook(h *HookNode) {
+	modifiers := ""
+	if h.Batch {
+		modifiers += " batch"
+	}
+	if h.IsTransaction {
+		modifiers += " @transaction"
+	}
+	if h.IsAsync {
+		modifiers += " @async"
+	}
+	if h.OnError != nil {
+		var options []string
+		if h.OnError.Retry > 0 {
+			options = append(options, fmt.Sprintf("retry: %d", h.OnError.Retry), "backoff: "+h.OnError.Backoff)
+		}
+		if h.OnError.Policy != HookPolicyAbort || h.OnError.Retry == 0 {
+			options = append(options, "policy: "+h.OnError.Policy)
+		}
+		modifiers += " @on_error(" + strings.Join(options, ", ") + ")"
+	}
+	if h.Timeout > 0 {
+		if h.Timeout%time.Second == 0 {
+			modifiers += fmt.Sprintf(" @timeout(%ds)", h.Timeout/time.Second)
+		} else {
+			modifiers += fmt.Sprintf(" @timeout(%dms)", h.Timeout/time.Millisecond)
+		}
+	}
+
+	event := h.Event
+	if h.Relationship != "" {
+		event += "(" + h.Relationship + ")"
+	}
+
+	p.line("@%s %s%s {", h.Timing, event, modifiers)
+	p.indent++
+	p.stmts(h.Body)
+	p.indent--
+	p.line("}")
+}
+
+func (p *printer) stmts(stmts []StmtNode) {
+	for _, stmt := range stmts {
+		p.stmt(stmt)
+	}
+}
+
+func (p *printer) stmt(stmt StmtNode) {
+	switch s := stmt.(type) {
+	case *ExprStmt:
+		p.line("%s", formatExpr(s.Expr))
+
+	case *AssignmentStmt:
+		p.line("%s = %s", formatExpr(s.Target), formatExpr(s.Value))
+
+	case *LetStmt:
+		if s.Type != nil {
+			p.line("let %s: %s = %s", s.Name, formatType(s.Type, s.Type.Nullable), formatExpr(s.Value))
+		} else {
+			p.line("let %s = %s", s.Name, formatExpr(s.Value))
+		}
+
+	case *ReturnStmt:
+		if s.Value != nil {
+			p.line("return %s", formatExpr(s.Value))
+		} else {
+			p.line("return")
+		}
+
+	case *IfStmt:
+		p.line("if %s {", formatExpr(s.Condition))
+		p.indent++
+		p.stmts(s.ThenBranch)
+		p.indent--
+		for _, branch := range s.ElsIfBranches {
+			p.line("} elsif %s {", formatExpr(branch.Condition))
+			p.indent++
+			p.stmts(branch.Body)
+			p.indent--
+		}
+		if len(s.ElseBranch) > 0 {
+			p.line("} else {")
+			p.indent++
+			p.stmts(s.ElseBranch)
+			p.indent--
+		}
+		p.line("}")
+
+	case *MatchStmt:
+		p.line("match %s {", formatExpr(s.Value))
+		p.indent++
+		for _, c := range s.Cases {
+			p.line("when %s {", formatExpr(c.Pattern))
+			p.indent++
+			p.stmts(c.Body)
+			p.indent--
+			p.line("}")
+		}
+		p.indent--
+		p.line("}")
+
+	case *BlockStmt:
+		if s.IsAsync {
+			p.line("@async {")
+		} else {
+			p.line("{")
+		}
+		p.indent++
+		p.stmts(s.Statements)
+		p.indent--
+		p.line("}")
+
+	case *RescueStmt:
+		p.stmts(s.Try)
+		p.line("rescue %s {", s.ErrorVar)
+		p.indent++
+		p.stmts(s.RescueBody)
+		p.indent--
+		p.line("}")
+	}
+}
+
+// formatFieldConstraint renders a field-level annotation such as @min(5)
+func formatFieldConstraint(c *ConstraintNode) string {
+	if len(c.Arguments) == 0 {
+		return "@" + c.Name
+	}
+	args := make([]string, len(c.Arguments))
+	for i, arg := range c.Arguments {
+		args[i] = formatExpr(arg)
+	}
+	return fmt.Sprintf("@%s(%s)", c.Name, strings.Join(args, ", "))
+}
+
+// formatArguments renders a scope or lambda parameter list
+func formatArguments(args []*ArgumentNode) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = arg.Name
+		if arg.Type != nil {
+			parts[i] += ": " + formatType(arg.Type, arg.Type.Nullable)
+		}
+		if arg.Default != nil {
+			parts[i] += " = " + formatExpr(arg.Default)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatType renders a type with the given outer nullability marker
+func formatType(t *TypeNode, nullable bool) string {
+	if t == nil {
+		return ""
+	}
+
+	var base string
+	switch t.Kind {
+	case TypeArray:
+		base = "array<" + formatType(t.ElementType, t.ElementType != nil && t.ElementType.Nullable) + ">"
+	case TypeHash:
+		base = "hash<" + formatType(t.KeyType, t.KeyType != nil && t.KeyType.Nullable) + ", " +
+			formatType(t.ValueType, t.ValueType != nil && t.ValueType.Nullable) + ">"
+	case TypeEnum:
+		values := make([]string, len(t.EnumValues))
+		for i, v := range t.EnumValues {
+			values[i] = quoteString(v)
+		}
+		base = "enum[" + strings.Join(values, ", ") + "]"
+	case TypeStruct:
+		fields := make([]string, len(t.StructFields))
+		for i, f := range t.StructFields {
+			fields[i] = f.Name + ": " + formatType(f.Type, f.Nullable)
+		}
+		base = "{ " + strings.Join(fields, ", ") + " }"
+	default:
+		base = t.Name
+	}
+
+	return base + nullMarker(nullable)
+}
+
+func nullMarker(nullable bool) string {
+	if nullable {
+		return "?"
+	}
+	return "!"
+}
+
+// formatExpr renders an expression as Conduit source
+func formatExpr(expr ExprNode) string {
+	switch e := expr.(type) {
+	case nil:
+		return ""
+	case *LiteralExpr:
+		return formatLiteral(e.Value)
+	case *IdentifierExpr:
+		return e.Name
+	case *SelfExpr:
+		return "self"
+	case *BinaryExpr:
+		return formatExpr(e.Left) + " " + e.Operator + " " + formatExpr(e.Right)
+	case *LogicalExpr:
+		return formatExpr(e.Left) + " " + e.Operator + " " + formatExpr(e.Right)
+	case *UnaryExpr:
+		if e.Operator == "not" {
+			return "not " + formatExpr(e.Operand)
+		}
+		return e.Operator + formatExpr(e.Operand)
+	case *CallExpr:
+		args := make([]string, len(e.Arguments))
+		for i, arg := range e.Arguments {
+			args[i] = formatExpr(arg)
+		}
+		name := e.Function
+		if e.Namespace != "" {
+			name = e.Namespace + "." + e.Function
+		}
+		return name + "(" + strings.Join(args, ", ") + ")"
+	case *FieldAccessExpr:
+		return formatExpr(e.Object) + "." + e.Field
+	case *SafeNavigationExpr:
+		return formatExpr(e.Object) + "?." + e.Field
+	case *ArrayLiteralExpr:
+		elems := make([]string, len(e.Elements))
+		for i, elem := range e.Elements {
+			elems[i] = formatExpr(elem)
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case *HashLiteralExpr:
+		pairs := make([]string, len(e.Pairs))
+		for i, pair := range e.Pairs {
+			pairs[i] = formatExpr(pair.Key) + ": " + formatExpr(pair.Value)
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	case *IndexExpr:
+		return formatExpr(e.Object) + "[" + formatExpr(e.Index) + "]"
+	case *NullCoalesceExpr:
+		return formatExpr(e.Left) + " ?? " + formatExpr(e.Right)
+	case *ParenExpr:
+		return "(" + formatExpr(e.Expr) + ")"
+	case *InterpolatedStringExpr:
+		var sb strings.Builder
+		sb.WriteString("\"")
+		for _, part := range e.Parts {
+			if lit, ok := part.(*LiteralExpr); ok {
+				if s, ok := lit.Value.(string); ok {
+					sb.WriteString(escapeString(s))
+					continue
+				}
+			}
+			sb.WriteString("#{" + formatExpr(part) + "}")
+		}
+		sb.WriteString("\"")
+		return sb.String()
+	case *RangeExpr:
+		op := ".."
+		if e.Exclusive {
+			op = "..."
+		}
+		return formatExpr(e.Start) + op + formatExpr(e.End)
+	case *LambdaExpr:
+		p := &printer{indent: 1}
+		p.stmts(e.Body)
+		return "|" + formatArguments(e.Parameters) + "| {\n" + p.buf.String() + "}"
+	}
+	return ""
+}
+
+// formatLiteral renders a literal value as Conduit source
+func formatLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return quoteString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		return s
+	case float32:
+		return formatLiteral(float64(v))
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// quoteString renders a string literal using the lexer's escape sequences
+func quoteString(s string) string {
+	return "\"" + escapeString(s) + "\""
+}
+
+var stringEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"\"", "\\\"",
+	"\n", "\\n",
+	"\t", "\\t",
+	"\r", "\\r",
+	"#{", "\\#{",
+)
+
+func escapeString(s string) string {
+	return stringEscaper.Replace(s)
+}
@@ -0,0 +1,291 @@
+package ast
+
+import "fmt"
+
+// FindResource returns the resource with the given name, or nil if the
+// program does not declare it.
+func (p *Program) FindResource(name string) *ResourceNode {
+	for _, resource := range p.Resources {
+		if resource.Name == name {
+			return resource
+		}
+	}
+	return nil
+}
+
+// FindField returns the field with the given name, or nil if the resource
+// does not declare it.
+func (r *ResourceNode) FindField(name string) *FieldNode {
+	for _, field := range r.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// FindRelationship returns the relationship with the given name, or nil if the
+// resource does not declare it.
+func (r *ResourceNode) FindRelationship(name string) *RelationshipNode {
+	for _, rel := range r.Relationships {
+		if rel.Name == name {
+			return rel
+		}
+	}
+	return nil
+}
+
+// hasMember reports whether the resource already declares a field, relationship
+// or computed field with the given name.
+func (r *ResourceNode) hasMember(name string) bool {
+	if r.FindField(name) != nil || r.FindRelationship(name) != nil {
+		return true
+	}
+	for _, computed := range r.Computed {
+		if computed.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RenameResource renames a resource and rewrites every reference to it:
+// relationship targets, resource-typed fields and namespaced calls such as
+// Post.find(). It returns the number of references rewritten, excluding the
+// declaration itself.
+func RenameResource(program *Program, oldName, newName string) (int, error) {
+	if oldName == newName {
+		return 0, fmt.Errorf("new name %q is the same as the old name", newName)
+	}
+
+	resource := program.FindResource(oldName)
+	if resource == nil {
+		return 0, fmt.Errorf("resource %q not found", oldName)
+	}
+
+	if program.FindResource(newName) != nil {
+		return 0, fmt.Errorf("resource %q already exists", newName)
+	}
+
+	resource.Name = newName
+
+	count := 0
+	Inspect(program, func(n Node) bool {
+		switch node := n.(type) {
+		case *RelationshipNode:
+			if node.Type == oldName {
+				node.Type = newName
+				count++
+			}
+		case *TypeNode:
+			if node.Kind == TypeResource && node.Name == oldName {
+				node.Name = newName
+				count++
+			}
+		case *CallExpr:
+			if node.Namespace == oldName {
+				node.Namespace = newName
+				count++
+			}
+		}
+		return true
+	})
+
+	return count, nil
+}
+
+// RenameField renames a field on a resource and rewrites every reference to it:
+// self.<field> accesses within the owning resource, foreign_key declarations
+// on its relationships, the fields its index blocks and annotations such as
+// @upsert name, and <relationship>.<field> accesses in resources that point
+// at it. It returns the number of references rewritten, excluding the
+// declaration itself.
+func RenameField(program *Program, resourceName, oldName, newName string) (int, error) {
+	if oldName == newName {
+		return 0, fmt.Errorf("new name %q is the same as the old name", newName)
+	}
+
+	resource := program.FindResource(resourceName)
+	if resource == nil {
+		return 0, fmt.Errorf("resource %q not found", resourceName)
+	}
+
+	field := resource.FindField(oldName)
+	if field == nil {
+		return 0, fmt.Errorf("field %s.%s not found", resourceName, oldName)
+	}
+
+	if resource.hasMember(newName) {
+		return 0, fmt.Errorf("%s.%s already exists", resourceName, newName)
+	}
+
+	// The position of an @orderable resource is found by its name
+	if resource.Orderable != nil && (oldName == PositionField || newName == PositionField) {
+		return 0, fmt.Errorf("%s.%s holds the order of @orderable records and must be named %s", resourceName, PositionField, PositionField)
+	}
+
+	field.Name = newName
+
+	count := 0
+	for _, rel := range resource.Relationships {
+		if rel.ForeignKey == oldName {
+			rel.ForeignKey = newName
+			count++
+		}
+	}
+	for _, index := range resource.Indexes {
+		count += renameNames(index.Fields, oldName, newName)
+	}
+	if resource.Upsert != nil {
+		count += renameNames(resource.Upsert.Fields, oldName, newName)
+	}
+	if resource.Orderable != nil {
+		count += renameName(&resource.Orderable.Scope, oldName, newName)
+	}
+	if resource.SearchIndex != nil {
+		count += renameNames(resource.SearchIndex.Fields, oldName, newName)
+	}
+	if resource.Partition != nil {
+		count += renameName(&resource.Partition.Field, oldName, newName)
+	}
+	if resource.Shard != nil {
+		count += renameName(&resource.Shard.Field, oldName, newName)
+	}
+	for _, profile := range resource.Profiles {
+		count += renameNames(profile.Fields, oldName, newName)
+	}
+	if resource.Conflict != nil {
+		count += renameNames(resource.Conflict.MergeFields, oldName, newName)
+	}
+	if resource.PrimaryKey != nil {
+		count += renameNames(resource.PrimaryKey.Fields, oldName, newName)
+	}
+
+	// self.<field> within the owning resource
+	Inspect(resource, func(n Node) bool {
+		if access, ok := n.(*FieldAccessExpr); ok && access.Field == oldName {
+			if _, isSelf := access.Object.(*SelfExpr); isSelf {
+				access.Field = newName
+				count++
+			}
+		}
+		return true
+	})
+
+	// self.<relationship>.<field> in any resource whose relationship targets the owner
+	for _, other := range program.Resources {
+		targets := make(map[string]bool)
+		for _, rel := range other.Relationships {
+			if rel.Type == resourceName {
+				targets[rel.Name] = true
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+
+		Inspect(other, func(n Node) bool {
+			var object ExprNode
+			var name *string
+			switch access := n.(type) {
+			case *FieldAccessExpr:
+				object, name = access.Object, &access.Field
+			case *SafeNavigationExpr:
+				object, name = access.Object, &access.Field
+			default:
+				return true
+			}
+
+			if *name != oldName || !isSelfRelationshipAccess(object, targets) {
+				return true
+			}
+
+			*name = newName
+			count++
+			return true
+		})
+	}
+
+	return count, nil
+}
+
+// renameNames replaces oldName with newName in a list of field names and
+// returns the number of names replaced
+func renameNames(names []string, oldName, newName string) int {
+	count := 0
+	for i, name := range names {
+		if name == oldName {
+			names[i] = newName
+			count++
+		}
+	}
+	return count
+}
+
+// renameName replaces a field name that is oldName with newName and returns
+// the number of names replaced
+func renameName(name *string, oldName, newName string) int {
+	if *name != oldName {
+		return 0
+	}
+	*name = newName
+	return 1
+}
+
+// isSelfRelationshipAccess reports whether expr is self.<rel> or self?.<rel>
+// where rel is one of the given relationship names.
+func isSelfRelationshipAccess(expr ExprNode, relationships map[string]bool) bool {
+	switch access := expr.(type) {
+	case *FieldAccessExpr:
+		_, isSelf := access.Object.(*SelfExpr)
+		return isSelf && relationships[access.Field]
+	case *SafeNavigationExpr:
+		_, isSelf := access.Object.(*SelfExpr)
+		return isSelf && relationships[access.Field]
+	}
+	return false
+}
+
+// InsertField adds a field to the resource. When after is non-empty the field
+// is inserted directly after the named field; otherwise it is appended.
+func InsertField(resource *ResourceNode, field *FieldNode, after string) error {
+	if field == nil || field.Name == "" {
+		return fmt.Errorf("field must have a name")
+	}
+
+	if field.Type == nil {
+		return fmt.Errorf("field %s must have a type", field.Name)
+	}
+
+	if resource.hasMember(field.Name) {
+		return fmt.Errorf("%s.%s already exists", resource.Name, field.Name)
+	}
+
+	if after == "" {
+		resource.Fields = append(resource.Fields, field)
+		return nil
+	}
+
+	for i, existing := range resource.Fields {
+		if existing.Name == after {
+			resource.Fields = append(resource.Fields, nil)
+			copy(resource.Fields[i+2:], resource.Fields[i+1:])
+			resource.Fields[i+1] = field
+			return nil
+		}
+	}
+
+	return fmt.Errorf("field %s.%s not found", resource.Name, after)
+}
+
+// AddMiddleware appends a middleware to the resource's middleware stack.
+// It returns false if the middleware is already present.
+func AddMiddleware(resource *ResourceNode, name string) bool {
+	for _, existing := range resource.Middleware {
+		if existing == name {
+			return false
+		}
+	}
+	resource.Middleware = append(resource.Middleware, name)
+	return true
+}
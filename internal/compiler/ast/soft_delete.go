@@ -0,0 +1,28 @@
+package ast
+
+// WithSoftDeletes returns the resources with the deleted_at field of every
+// @soft_delete resource that does not declare one added as a nullable
+// timestamp. Resources that gain the field are copied, so the given resources
+// are left unchanged. Read-only resources and resources with another member
+// named deleted_at are skipped; the type checker reports them.
+func WithSoftDeletes(resources []*ResourceNode) []*ResourceNode {
+	result := make([]*ResourceNode, len(resources))
+	copy(result, resources)
+
+	for i, resource := range resources {
+		if resource.SoftDelete == nil || resource.ReadOnly() || resource.hasMember(SoftDeleteField) {
+			continue
+		}
+
+		deletable := *resource
+		deletable.Fields = append(append([]*FieldNode(nil), resource.Fields...), &FieldNode{
+			Name:     SoftDeleteField,
+			Type:     &TypeNode{Kind: TypePrimitive, Name: "timestamp", Nullable: true},
+			Nullable: true,
+			Loc:      resource.SoftDelete.Loc,
+		})
+		result[i] = &deletable
+	}
+
+	return result
+}
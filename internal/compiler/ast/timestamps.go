@@ -0,0 +1,68 @@
+package ast
+
+// Fields WithTimestamps adds
+const (
+	CreatedAtField = "created_at" // timestamp! @auto, set when a record is created
+	UpdatedAtField = "updated_at" // timestamp! @auto_update, set whenever a record changes
+)
+
+// TimestampsEnabled reports whether WithTimestamps adds created_at and
+// updated_at to the resource: @timestamps decides when present, and all
+// (timestamps: true in conduit.yml) otherwise. Read-only resources have no
+// rows the application stamps and never get them.
+func (r *ResourceNode) TimestampsEnabled(all bool) bool {
+	if r.ReadOnly() {
+		return false
+	}
+	if r.Timestamps != nil {
+		return r.Timestamps.Enabled
+	}
+	return all
+}
+
+// WithTimestamps returns the resources with created_at and updated_at added to
+// every resource they are enabled for that does not declare them. Resources
+// that gain fields are copied, so the given resources are left unchanged.
+// Declared fields are kept as they are; the type checker reports the ones that
+// are not the timestamps the added fields would be.
+func WithTimestamps(resources []*ResourceNode, all bool) []*ResourceNode {
+	result := make([]*ResourceNode, len(resources))
+	copy(result, resources)
+
+	for i, resource := range resources {
+		if !resource.TimestampsEnabled(all) {
+			continue
+		}
+
+		loc := resource.Loc
+		if resource.Timestamps != nil {
+			loc = resource.Timestamps.Loc
+		}
+
+		var added []*FieldNode
+		for _, name := range []string{CreatedAtField, UpdatedAtField} {
+			if resource.hasMember(name) {
+				continue
+			}
+			constraint := "auto"
+			if name == UpdatedAtField {
+				constraint = "auto_update"
+			}
+			added = append(added, &FieldNode{
+				Name:        name,
+				Type:        &TypeNode{Kind: TypePrimitive, Name: "timestamp"},
+				Constraints: []*ConstraintNode{{Name: constraint, Loc: loc}},
+				Loc:         loc,
+			})
+		}
+		if len(added) == 0 {
+			continue
+		}
+
+		stamped := *resource
+		stamped.Fields = append(append([]*FieldNode(nil), resource.Fields...), added...)
+		result[i] = &stamped
+	}
+
+	return result
+}
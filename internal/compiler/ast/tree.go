@@ -0,0 +1,38 @@
+package ast
+
+// TreeParent returns the belongs_to relationship a @tree resource links each
+// record to its parent through: the one named by parent, or the only
+// belongs_to relationship to the resource itself. It returns nil when there is
+// no such relationship or, without parent, more than one.
+func (r *ResourceNode) TreeParent() *RelationshipNode {
+	if r.Tree == nil {
+		return nil
+	}
+
+	var found *RelationshipNode
+	for _, rel := range r.Relationships {
+		if rel.Kind != RelationshipBelongsTo || rel.Type != r.Name {
+			continue
+		}
+		if r.Tree.Parent != "" {
+			if rel.Name == r.Tree.Parent {
+				return rel
+			}
+			continue
+		}
+		if found != nil {
+			return nil
+		}
+		found = rel
+	}
+	return found
+}
+
+// DepthLimit returns the number of levels the routes of a @tree resource
+// follow at most
+func (t *TreeNode) DepthLimit() int {
+	if t.MaxDepth > 0 {
+		return t.MaxDepth
+	}
+	return DefaultTreeMaxDepth
+}
@@ -0,0 +1,275 @@
+package ast
+
+// Visitor is implemented by types that want to traverse the AST with Walk.
+// Visit is invoked for each node encountered by Walk. If the returned visitor
+// w is not nil, Walk visits each of the children of node with w, followed by a
+// call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order, starting with node.
+// Children are visited in source declaration order: fields, relationships,
+// hooks, validations, constraints, scopes, computed fields and the
+// @default_scope condition for resources, then statements and expressions
+// within each of them.
+func Walk(v Visitor, node Node) {
+	if node == nil || isNilNode(node) {
+		return
+	}
+
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, resource := range n.Resources {
+			Walk(v, resource)
+		}
+
+	case *ResourceNode:
+		for _, field := range n.Fields {
+			Walk(v, field)
+		}
+		for _, rel := range n.Relationships {
+			Walk(v, rel)
+		}
+		for _, hook := range n.Hooks {
+			Walk(v, hook)
+		}
+		for _, validation := range n.Validations {
+			Walk(v, validation)
+		}
+		for _, constraint := range n.Constraints {
+			Walk(v, constraint)
+		}
+		for _, scope := range n.Scopes {
+			Walk(v, scope)
+		}
+		for _, computed := range n.Computed {
+			Walk(v, computed)
+		}
+		if n.DefaultScope != nil {
+			walkExpr(v, n.DefaultScope.Condition)
+		}
+
+	case *FieldNode:
+		walkType(v, n.Type)
+		walkExpr(v, n.Default)
+		for _, constraint := range n.Constraints {
+			Walk(v, constraint)
+		}
+
+	case *TypeNode:
+		walkType(v, n.ElementType)
+		walkType(v, n.KeyType)
+		walkType(v, n.ValueType)
+		for _, field := range n.StructFields {
+			Walk(v, field)
+		}
+
+	case *RelationshipNode:
+		// Relationships have no child nodes
+
+	case *HookNode:
+		walkStmts(v, n.Body)
+
+	case *ValidationNode:
+		walkExpr(v, n.Condition)
+
+	case *ConstraintNode:
+		for _, arg := range n.Arguments {
+			walkExpr(v, arg)
+		}
+		walkExpr(v, n.When)
+		walkExpr(v, n.Condition)
+
+	case *ScopeNode:
+		for _, arg := range n.Arguments {
+			Walk(v, arg)
+		}
+		walkExpr(v, n.Condition)
+
+	case *ComputedNode:
+		walkType(v, n.Type)
+		walkExpr(v, n.Body)
+
+	case *ArgumentNode:
+		walkType(v, n.Type)
+		walkExpr(v, n.Default)
+
+	// Statements
+	case *ExprStmt:
+		walkExpr(v, n.Expr)
+
+	case *AssignmentStmt:
+		walkExpr(v, n.Target)
+		walkExpr(v, n.Value)
+
+	case *LetStmt:
+		walkType(v, n.Type)
+		walkExpr(v, n.Value)
+
+	case *ReturnStmt:
+		walkExpr(v, n.Value)
+
+	case *IfStmt:
+		walkExpr(v, n.Condition)
+		walkStmts(v, n.ThenBranch)
+		for _, branch := range n.ElsIfBranches {
+			walkExpr(v, branch.Condition)
+			walkStmts(v, branch.Body)
+		}
+		walkStmts(v, n.ElseBranch)
+
+	case *BlockStmt:
+		walkStmts(v, n.Statements)
+
+	case *RescueStmt:
+		walkStmts(v, n.Try)
+		walkStmts(v, n.RescueBody)
+
+	case *MatchStmt:
+		walkExpr(v, n.Value)
+		for _, c := range n.Cases {
+			walkExpr(v, c.Pattern)
+			walkStmts(v, c.Body)
+		}
+
+	// Expressions
+	case *LiteralExpr, *IdentifierExpr, *SelfExpr:
+		// Leaf nodes
+
+	case *BinaryExpr:
+		walkExpr(v, n.Left)
+		walkExpr(v, n.Right)
+
+	case *UnaryExpr:
+		walkExpr(v, n.Operand)
+
+	case *LogicalExpr:
+		walkExpr(v, n.Left)
+		walkExpr(v, n.Right)
+
+	case *CallExpr:
+		for _, arg := range n.Arguments {
+			walkExpr(v, arg)
+		}
+
+	case *FieldAccessExpr:
+		walkExpr(v, n.Object)
+
+	case *SafeNavigationExpr:
+		walkExpr(v, n.Object)
+
+	case *ArrayLiteralExpr:
+		for _, elem := range n.Elements {
+			walkExpr(v, elem)
+		}
+
+	case *HashLiteralExpr:
+		for _, pair := range n.Pairs {
+			walkExpr(v, pair.Key)
+			walkExpr(v, pair.Value)
+		}
+
+	case *IndexExpr:
+		walkExpr(v, n.Object)
+		walkExpr(v, n.Index)
+
+	case *NullCoalesceExpr:
+		walkExpr(v, n.Left)
+		walkExpr(v, n.Right)
+
+	case *ParenExpr:
+		walkExpr(v, n.Expr)
+
+	case *InterpolatedStringExpr:
+		for _, part := range n.Parts {
+			walkExpr(v, part)
+		}
+
+	case *RangeExpr:
+		walkExpr(v, n.Start)
+		walkExpr(v, n.End)
+
+	case *LambdaExpr:
+		for _, param := range n.Parameters {
+			Walk(v, param)
+		}
+		walkStmts(v, n.Body)
+	}
+
+	v.Visit(nil)
+}
+
+// inspector adapts a plain function to the Visitor interface
+type inspector func(Node) bool
+
+// Visit implements Visitor
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: it starts by calling f(node);
+// node must not be nil. If f returns true, Inspect invokes f recursively for
+// each of the non-nil children of node, followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// walkExpr walks an optional expression
+func walkExpr(v Visitor, expr ExprNode) {
+	if expr != nil {
+		Walk(v, expr)
+	}
+}
+
+// walkType walks an optional type node
+func walkType(v Visitor, t *TypeNode) {
+	if t != nil {
+		Walk(v, t)
+	}
+}
+
+// walkStmts walks a list of statements
+func walkStmts(v Visitor, stmts []StmtNode) {
+	for _, stmt := range stmts {
+		if stmt != nil {
+			Walk(v, stmt)
+		}
+	}
+}
+
+// isNilNode reports whether node is a typed nil pointer wrapped in the Node interface
+func isNilNode(node Node) bool {
+	switch n := node.(type) {
+	case *Program:
+		return n == nil
+	case *ResourceNode:
+		return n == nil
+	case *FieldNode:
+		return n == nil
+	case *TypeNode:
+		return n == nil
+	case *RelationshipNode:
+		return n == nil
+	case *HookNode:
+		return n == nil
+	case *ValidationNode:
+		return n == nil
+	case *ConstraintNode:
+		return n == nil
+	case *ScopeNode:
+		return n == nil
+	case *ComputedNode:
+		return n == nil
+	case *ArgumentNode:
+		return n == nil
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// archivedField returns the archived_at field of an @archivable resource, or
+// nil for resources whose records cannot be archived
+func archivedField(resource *ast.ResourceNode) *ast.FieldNode {
+	if resource.Archivable == nil {
+		return nil
+	}
+	return resource.FindField(ast.ArchivedField)
+}
+
+// generateArchive generates the Archive() and Restore() methods of an
+// @archivable resource. Both only touch archived_at, and the @auto_update
+// timestamp when there is one so conditional GETs and change feeds notice;
+// archiving an archived record or restoring an unarchived one changes nothing,
+// and neither changes a soft-deleted record. Lifecycle hooks do not run: the
+// record itself is not written.
+func (g *Generator) generateArchive(resource *ast.ResourceNode) {
+	receiverName := strings.ToLower(resource.Name[0:1])
+	archived := archivedField(resource)
+	modified := modificationField(resource)
+	tableName := g.sqlTable(resource)
+	column := g.fieldColumnName(archived)
+	live := ""
+	if deleted := softDeleteField(resource); deleted != nil {
+		live = " AND " + g.fieldColumnName(deleted) + " IS NULL"
+	}
+
+	// The key takes the first placeholders and the time the one after them
+	keys := g.keyValues(resource, receiverName)
+	now := fmt.Sprintf("$%d", len(keys)+1)
+	modifiedSet := ""
+	if modified != nil {
+		modifiedSet = ", " + g.fieldColumnName(modified) + " = " + now
+	}
+
+	g.writeLine("// Archive marks the %s archived, leaving it out of lists until it is restored", resource.Name)
+	g.writeLine("func (%s *%s) Archive(ctx context.Context, db *sql.DB) error {", receiverName, resource.Name)
+	g.indent++
+	g.writeLine("now := time.Now()")
+	g.writeLine("query := `UPDATE %s SET %s = %s%s WHERE %s AND %s IS NULL%s`",
+		tableName, column, now, modifiedSet, g.keyCondition(resource, 1), column, live)
+	g.writeLine("")
+	g.generateArchiveExec(resource, "archive", strings.Join(append(keys, "now"), ", "))
+	g.writeLine("if changed > 0 {")
+	g.indent++
+	g.writeLine("%s.%s = &now", receiverName, g.toGoFieldName(archived.Name))
+	if modified != nil {
+		g.writeLine("%s.%s = now", receiverName, g.toGoFieldName(modified.Name))
+	}
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return nil")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("// Restore clears the archived mark of the %s, returning it to lists", resource.Name)
+	g.writeLine("func (%s *%s) Restore(ctx context.Context, db *sql.DB) error {", receiverName, resource.Name)
+	g.indent++
+	args := strings.Join(keys, ", ")
+	if modified != nil {
+		g.writeLine("now := time.Now()")
+		args += ", now"
+	}
+	g.writeLine("query := `UPDATE %s SET %s = NULL%s WHERE %s AND %s IS NOT NULL%s`",
+		tableName, column, modifiedSet, g.keyCondition(resource, 1), column, live)
+	g.writeLine("")
+	g.generateArchiveExec(resource, "restore", args)
+	g.writeLine("if changed > 0 {")
+	g.indent++
+	g.writeLine("%s.%s = nil", receiverName, g.toGoFieldName(archived.Name))
+	if modified != nil {
+		g.writeLine("%s.%s = now", receiverName, g.toGoFieldName(modified.Name))
+	}
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return nil")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateArchiveExec executes an archive or restore query with args and
+// declares changed, the number of rows it updated
+func (g *Generator) generateArchiveExec(resource *ast.ResourceNode, action, args string) {
+	g.writeLine("result, err := db.ExecContext(ctx, query, %s)", args)
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("return fmt.Errorf(\"failed to %s %s: %%w\", err)", action, strings.ToLower(resource.Name))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("changed, err := result.RowsAffected()")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("return fmt.Errorf(\"failed to %s %s: %%w\", err)", action, strings.ToLower(resource.Name))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
+
+// generateArchiveHandler generates the handler of an @archivable resource's
+// archive or restore route (POST /resources/{id}/archive or /restore), which
+// responds with the record as it is afterwards
+func (g *Generator) generateArchiveHandler(resource *ast.ResourceNode, action string) {
+	resourceLower := strings.ToLower(resource.Name)
+	receiverName := strings.ToLower(resource.Name[0:1])
+	method := strings.ToUpper(action[:1]) + action[1:]
+
+	g.writeLine("// %s%sHandler handles POST %s/%s - %s a %s",
+		method, resource.Name, g.memberPath(resource), action, action, resourceLower)
+	g.writeLine("func %s%sHandler(db *sql.DB) http.HandlerFunc {", method, resource.Name)
+	g.indent++
+	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
+	g.indent++
+
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, %q)", resource.Name, action)
+	g.writeLine("")
+	g.generateVisibleFields(resource)
+
+	g.generateIDParsingCode(resource)
+
+	g.writeLine("// Fetch existing %s", resourceLower)
+	g.writeLine("%s, err := models.Find%sByID(ctx, db, %s)", receiverName, resource.Name, g.keyArgs(resource))
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("if err == sql.ErrNoRows {")
+	g.indent++
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusNotFound, fmt.Errorf(\"Not found\"))")
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, \"Not found\", http.StatusNotFound)")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to find %s: %%v\", err))", resourceLower)
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to find %s: %%v\", err), http.StatusInternalServerError)", resourceLower)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("if err := %s.%s(ctx, db); err != nil {", receiverName, method)
+	g.indent++
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to %s %s: %%v\", err))", action, resourceLower)
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to %s %s: %%v\", err), http.StatusInternalServerError)", action, resourceLower)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("// Content negotiation: JSON:API or legacy JSON")
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("if err := %s; err != nil {", renderJSONAPI(resource, "http.StatusOK", receiverName))
+	g.indent++
+	g.writeLine("respondWithError(w, \"Failed to encode response\", http.StatusInternalServerError)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("w.Header().Set(\"Content-Type\", \"application/json\")")
+	g.writeLine("if err := json.NewEncoder(w).Encode(%s); err != nil {", g.encodedRecord(resource, receiverName))
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to encode response: %v\", err), http.StatusInternalServerError)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+}
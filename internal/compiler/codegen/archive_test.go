@@ -0,0 +1,106 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const archiveSource = `resource Post {
+  @archivable
+
+  id: uuid! @primary @auto
+  title: string!
+  updated_at: timestamp! @auto_update
+  archived_at: timestamp?
+}`
+
+func TestGenerateResource_Archivable(t *testing.T) {
+	code := generateModel(t, parseResource(t, archiveSource))
+
+	archive := functionBody(t, code, "func (p *Post) Archive(ctx context.Context, db *sql.DB) error {")
+	assertContains(t, archive,
+		"UPDATE posts SET archived_at = $2, updated_at = $2 WHERE id = $1 AND archived_at IS NULL",
+		"db.ExecContext(ctx, query, p.ID, now)",
+		"p.ArchivedAt = &now",
+		"p.UpdatedAt = now",
+	)
+
+	restore := functionBody(t, code, "func (p *Post) Restore(ctx context.Context, db *sql.DB) error {")
+	assertContains(t, restore,
+		"UPDATE posts SET archived_at = NULL, updated_at = $2 WHERE id = $1 AND archived_at IS NOT NULL",
+		"p.ArchivedAt = nil",
+	)
+
+	// Default lists leave archived records out
+	if !strings.Contains(code, "FROM posts WHERE archived_at IS NULL ORDER BY id") {
+		t.Error("FindAllPost should leave archived records out")
+	}
+	if !strings.Contains(code, "SELECT COUNT(*) FROM posts WHERE archived_at IS NULL") {
+		t.Error("CountPost should leave archived records out")
+	}
+
+	// Only archiving and restoring set archived_at
+	if strings.Contains(functionBody(t, code, "func (p *Post) Update("), "archived_at") {
+		t.Error("Update should not set archived_at")
+	}
+	patch := functionBody(t, code, "func (p *Post) Patch(")
+	readOnly := patch[strings.Index(patch, "readOnlyFields"):strings.Index(patch, "validFields")]
+	if !strings.Contains(readOnly, `"archived_at": true,`) {
+		t.Error("Patch should reject archived_at as read-only")
+	}
+}
+
+func TestGenerateResource_ArchivableSoftDelete(t *testing.T) {
+	resource := parseResource(t, archiveSource)
+	resource.SoftDelete = &ast.SoftDeleteNode{}
+	resource = ast.WithSoftDeletes([]*ast.ResourceNode{resource})[0]
+
+	code := generateModel(t, resource)
+
+	// Deleted records are neither archived nor restored
+	archive := functionBody(t, code, "func (p *Post) Archive(ctx context.Context, db *sql.DB) error {")
+	if want := "WHERE id = $1 AND archived_at IS NULL AND deleted_at IS NULL`"; !strings.Contains(archive, want) {
+		t.Errorf("Archive missing %q:\n%s", want, archive)
+	}
+	restore := functionBody(t, code, "func (p *Post) Restore(ctx context.Context, db *sql.DB) error {")
+	if want := "WHERE id = $1 AND archived_at IS NOT NULL AND deleted_at IS NULL`"; !strings.Contains(restore, want) {
+		t.Errorf("Restore missing %q:\n%s", want, restore)
+	}
+}
+
+func TestGenerateHandlers_Archivable(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, archiveSource))
+
+	assertContains(t, code,
+		`r.Post("/posts/{id}/archive", ArchivePostHandler(db))`,
+		`r.Post("/posts/{id}/restore", RestorePostHandler(db))`,
+	)
+
+	list := functionBody(t, code, "func ListPostHandler(db *sql.DB) http.HandlerFunc {")
+	assertContains(t, list,
+		"archived, err := query.ParseArchived(filters)",
+		`Archived("archived_at", archived).`,
+	)
+
+	for _, action := range []string{"Archive", "Restore"} {
+		handler := functionBody(t, code, "func "+action+"PostHandler(db *sql.DB) http.HandlerFunc {")
+		assertContains(t, handler,
+			`instrument.WithOperation(r.Context(), "Post", "`+strings.ToLower(action)+`")`,
+			"p, err := models.FindPostByID(ctx, db, id)",
+			"if err := p."+action+"(ctx, db); err != nil {",
+			"response.RenderJSONAPI(w, http.StatusOK, p)",
+		)
+	}
+}
+
+func TestGenerateHandlers_NotArchivable(t *testing.T) {
+	resource := parseResource(t, archiveSource)
+	resource.Archivable = nil
+
+	code := generateHandlers(t, resource)
+	if strings.Contains(code, "ParseArchived") || strings.Contains(code, "/archive") {
+		t.Error("Resources without @archivable should not filter or serve archives")
+	}
+}
@@ -0,0 +1,252 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// throughRelationships returns the has_many_through relationships of a
+// resource, whose records are linked and unlinked through a join table
+func throughRelationships(resource *ast.ResourceNode) []*ast.RelationshipNode {
+	var rels []*ast.RelationshipNode
+	for _, rel := range resource.Relationships {
+		if rel.Kind == ast.RelationshipHasManyThrough && rel.Through != "" {
+			rels = append(rels, rel)
+		}
+	}
+	return rels
+}
+
+// hasRelationshipHook checks if a resource has an attach or detach hook on
+// the relationship
+func hasRelationshipHook(resource *ast.ResourceNode, timing, event string, rel *ast.RelationshipNode) bool {
+	for _, hook := range resource.Hooks {
+		if hook.Timing == timing && hook.Event == event && hook.Relationship == rel.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// generateAttach generates the Attach and Detach methods of a has_many_through
+// relationship, e.g. AttachTags and DetachTags, which insert and delete the
+// join table row linking the two records. Before hooks can stop the change by
+// returning an error; after hooks only run when a row was inserted or deleted,
+// so attaching a linked record or detaching an unlinked one skips them.
+func (g *Generator) generateAttach(resource *ast.ResourceNode, rel *ast.RelationshipNode) {
+	receiverName := strings.ToLower(resource.Name[0:1])
+	variable := rel.HookVariable()
+	ownerColumn, targetColumn := rel.JoinColumns(resource.Name)
+	suffix := g.toGoFieldName(rel.Name)
+	target := g.findResource(rel.Type)
+	if target == nil {
+		target = &ast.ResourceNode{Name: rel.Type}
+	}
+	keys := fmt.Sprintf("%s, %s", g.keyValue(resource, receiverName), g.keyValue(target, variable))
+
+	queries := map[string]string{
+		ast.HookEventAttach: fmt.Sprintf("INSERT INTO %s (%s, %s) VALUES ($1, $2) ON CONFLICT DO NOTHING", rel.Through, ownerColumn, targetColumn),
+		ast.HookEventDetach: fmt.Sprintf("DELETE FROM %s WHERE %s = $1 AND %s = $2", rel.Through, ownerColumn, targetColumn),
+	}
+	docs := map[string]string{
+		ast.HookEventAttach: fmt.Sprintf("links a %s to the %s through %s; attaching a linked %s changes nothing", rel.Type, resource.Name, rel.Through, rel.Type),
+		ast.HookEventDetach: fmt.Sprintf("unlinks a %s from the %s; detaching an unlinked %s changes nothing", rel.Type, resource.Name, rel.Type),
+	}
+
+	for i, event := range []string{ast.HookEventAttach, ast.HookEventDetach} {
+		if i > 0 {
+			g.writeLine("")
+		}
+		method := strings.Title(event) + suffix
+		hook := &ast.HookNode{Event: event, Relationship: rel.Name}
+
+		g.writeLine("// %s %s", method, docs[event])
+		g.writeLine("func (%s *%s) %s(ctx context.Context, db *sql.DB, %s *%s) error {",
+			receiverName, resource.Name, method, variable, rel.Type)
+		g.indent++
+
+		if hasRelationshipHook(resource, "before", event, rel) {
+			hook.Timing = "before"
+			g.writeLine("if err := %s.%s(ctx, db, %s); err != nil {", receiverName, g.hookMethodName(hook), variable)
+			g.indent++
+			g.writeLine("return fmt.Errorf(\"before %s hook failed: %%w\", err)", event)
+			g.indent--
+			g.writeLine("}")
+			g.writeLine("")
+		}
+
+		g.writeLine("query := `%s`", queries[event])
+		after := hasRelationshipHook(resource, "after", event, rel)
+		if after {
+			g.writeLine("result, err := db.ExecContext(ctx, query, %s)", keys)
+		} else {
+			g.writeLine("_, err := db.ExecContext(ctx, query, %s)", keys)
+		}
+		g.writeLine("if err != nil {")
+		g.indent++
+		g.writeLine("return fmt.Errorf(\"failed to %s %s: %%w\", err)", event, strings.ToLower(rel.Type))
+		g.indent--
+		g.writeLine("}")
+
+		if after {
+			hook.Timing = "after"
+			g.writeLine("changed, err := result.RowsAffected()")
+			g.writeLine("if err != nil {")
+			g.indent++
+			g.writeLine("return fmt.Errorf(\"failed to %s %s: %%w\", err)", event, strings.ToLower(rel.Type))
+			g.indent--
+			g.writeLine("}")
+			g.writeLine("if changed > 0 {")
+			g.indent++
+			g.writeLine("if err := %s.%s(ctx, db, %s); err != nil {", receiverName, g.hookMethodName(hook), variable)
+			g.indent++
+			g.writeLine("return fmt.Errorf(\"after %s hook failed: %%w\", err)", event)
+			g.indent--
+			g.writeLine("}")
+			g.indent--
+			g.writeLine("}")
+		}
+
+		g.writeLine("return nil")
+		g.indent--
+		g.writeLine("}")
+	}
+}
+
+// generateAttachHandler generates the handler of a has_many_through
+// relationship's attach or detach route (POST or DELETE
+// /resources/{id}/relationship/{target_id}), which responds 204 No Content
+// whether or not the records were linked before
+func (g *Generator) generateAttachHandler(resource *ast.ResourceNode, rel *ast.RelationshipNode, event string) {
+	resourceLower := strings.ToLower(resource.Name)
+	targetLower := strings.ToLower(rel.Type)
+	receiverName := strings.ToLower(resource.Name[0:1])
+	variable := rel.HookVariable()
+	method := strings.Title(event) + g.toGoFieldName(rel.Name)
+	verb, change := "POST", "attach a "+targetLower+" to"
+	if event == ast.HookEventDetach {
+		verb, change = "DELETE", "detach a "+targetLower+" from"
+	}
+
+	handler := strings.Title(event) + resource.Name + g.toGoFieldName(rel.Name) + "Handler"
+
+	g.writeLine("// %s handles %s %s - %s a %s", handler, verb, g.attachPath(resource, rel), change, resourceLower)
+	g.writeLine("func %s(db *sql.DB) http.HandlerFunc {", handler)
+	g.indent++
+	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
+	g.indent++
+
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, %q)", resource.Name, event+"_"+rel.Name)
+	g.writeLine("")
+
+	g.generateIDParsingCode(resource)
+	g.generateTargetIDParsing(rel)
+
+	g.writeLine("// Fetch both records")
+	g.writeLine("%s, err := models.Find%sByID(ctx, db, %s)", receiverName, resource.Name, g.keyArgs(resource))
+	g.generateAttachFindError(resourceLower)
+	g.writeLine("%s, err := models.Find%sByID(ctx, db, %sID)", variable, rel.Type, variable)
+	g.generateAttachFindError(targetLower)
+	g.writeLine("")
+
+	g.writeLine("if err := %s.%s(ctx, db, %s); err != nil {", receiverName, method, variable)
+	g.indent++
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to %s %s: %%v\", err))", event, targetLower)
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to %s %s: %%v\", err), http.StatusInternalServerError)", event, targetLower)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+	g.writeLine("w.WriteHeader(http.StatusNoContent)")
+
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateAttachFindError responds 404 when the record an attach or detach
+// handler just looked up does not exist, and 500 when the lookup failed
+func (g *Generator) generateAttachFindError(name string) {
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("if err == sql.ErrNoRows {")
+	g.indent++
+	g.generateMoveError("http.StatusNotFound", "Not found")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to find %s: %%v\", err))", name)
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to find %s: %%v\", err), http.StatusInternalServerError)", name)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateTargetIDParsing parses the ID of the record to attach or detach
+// from the {target_id} URL parameter into a variable such as tagID
+func (g *Generator) generateTargetIDParsing(rel *ast.RelationshipNode) {
+	param := g.toSnakeCase(rel.Type) + "_id"
+	name := rel.HookVariable() + "ID"
+
+	target := g.findResource(rel.Type)
+	if target == nil {
+		target = &ast.ResourceNode{Name: rel.Type}
+	}
+
+	g.writeLine("// Parse %s ID from URL", strings.ToLower(rel.Type))
+	switch g.getIDType(target) {
+	case "uuid":
+		g.imports["github.com/google/uuid"] = true
+		g.writeLine("%s, err := uuid.Parse(chi.URLParam(r, %q))", name, param)
+		g.writeLine("if err != nil {")
+	case "ulid":
+		g.writeLine("%s := chi.URLParam(r, %q)", name, param)
+		g.writeLine("if err := ids.ValidateULID(%s); err != nil {", name)
+	case "string", "text", "markdown":
+		g.writeLine("%s := chi.URLParam(r, %q)", name, param)
+		g.writeLine("")
+		return
+	default:
+		g.writeLine("%s, err := strconv.ParseInt(chi.URLParam(r, %q), 10, 64)", name, param)
+		g.writeLine("if err != nil {")
+	}
+	g.indent++
+	g.writeLine("respondWithError(w, \"Invalid %s ID\", http.StatusBadRequest)", strings.ToLower(rel.Type))
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
+
+// attachPath returns the route of the attach and detach handlers of a
+// has_many_through relationship, e.g. /posts/{id}/tags/{tag_id}
+func (g *Generator) attachPath(resource *ast.ResourceNode, rel *ast.RelationshipNode) string {
+	return fmt.Sprintf("%s/%s/{%s_id}", g.memberPath(resource), rel.Name, g.toSnakeCase(rel.Type))
+}
+
+// findResource returns the resource of the GenerateHandlers call with the
+// given name, or nil
+func (g *Generator) findResource(name string) *ast.ResourceNode {
+	for _, resource := range g.resources {
+		if resource.Name == name {
+			return resource
+		}
+	}
+	return nil
+}
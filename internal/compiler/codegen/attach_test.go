@@ -0,0 +1,87 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+)
+
+const attachSource = `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+
+  tags: array<Tag!>! {
+    through: "post_tags"
+  }
+
+  @before attach(tags) {
+    self.title = tag.name
+  }
+
+  @after detach(tags) {
+  }
+}
+
+resource Tag {
+  id: int! @primary @auto
+  name: string!
+}`
+
+func TestGenerateResource_Attach(t *testing.T) {
+	code, err := NewGenerator().GenerateResourceWithHooks(parseResources(t, attachSource)[0])
+	if err != nil {
+		t.Fatalf("GenerateResourceWithHooks failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+
+	attach := functionBody(t, code, "func (p *Post) AttachTags(ctx context.Context, db *sql.DB, tag *Tag) error {")
+	assertContains(t, attach,
+		"if err := p.BeforeAttachTags(ctx, db, tag); err != nil {",
+		"INSERT INTO post_tags (post_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		"_, err := db.ExecContext(ctx, query, p.ID, tag.ID)",
+	)
+	if strings.Contains(attach, "AfterAttachTags") {
+		t.Errorf("AttachTags should only call hooks that exist:\n%s", attach)
+	}
+
+	// After hooks only run when the link changed
+	detach := functionBody(t, code, "func (p *Post) DetachTags(ctx context.Context, db *sql.DB, tag *Tag) error {")
+	assertContains(t, detach,
+		"DELETE FROM post_tags WHERE post_id = $1 AND tag_id = $2",
+		"changed, err := result.RowsAffected()",
+		"if changed > 0 {",
+		"if err := p.AfterDetachTags(ctx, db, tag); err != nil {",
+	)
+
+	hook := functionBody(t, code, "func (p *Post) BeforeAttachTags(ctx context.Context, db *sql.DB, tag *Tag) error {")
+	if !strings.Contains(hook, "p.Title = tag.Name") {
+		t.Errorf("BeforeAttachTags should see the tag:\n%s", hook)
+	}
+	if !strings.Contains(code, "func (p *Post) AfterDetachTags(ctx context.Context, db *sql.DB, tag *Tag) error {") {
+		t.Error("Missing AfterDetachTags hook")
+	}
+}
+
+func TestGenerateHandlers_Attach(t *testing.T) {
+	code := generateHandlers(t, parseResources(t, attachSource)...)
+
+	assertContains(t, code,
+		`r.Post("/posts/{id}/tags/{tag_id}", AttachPostTagsHandler(db))`,
+		`r.Delete("/posts/{id}/tags/{tag_id}", DetachPostTagsHandler(db))`,
+	)
+
+	for _, event := range []string{"Attach", "Detach"} {
+		handler := functionBody(t, code, "func "+event+"PostTagsHandler(db *sql.DB) http.HandlerFunc {")
+		assertContains(t, handler,
+			"id, err := uuid.Parse(idStr)",
+			// Tags have integer IDs
+			`tagID, err := strconv.ParseInt(chi.URLParam(r, "tag_id"), 10, 64)`,
+			"p, err := models.FindPostByID(ctx, db, id)",
+			"tag, err := models.FindTagByID(ctx, db, tagID)",
+			"if err := p."+event+"Tags(ctx, db, tag); err != nil {",
+			"w.WriteHeader(http.StatusNoContent)",
+		)
+	}
+}
@@ -0,0 +1,206 @@
+package codegen
+
+import (
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/metadata"
+	"github.com/conduit-lang/conduit/pkg/web/oauth"
+)
+
+// AuthHandlersFile is the generated file holding the social login routes
+const AuthHandlersFile = "handlers/auth.go"
+
+// AuthOptions controls the social login routes generated for conduit.yaml's
+// auth section
+type AuthOptions struct {
+	// Enabled generates the login routes of Providers
+	Enabled bool
+	// Resource is the resource users sign in as
+	Resource string
+	// Providers are the login providers (google, github)
+	Providers []string
+	// Redirect is where the browser goes after login; the token is returned as JSON when empty
+	Redirect string
+	// BaseURL is the public URL callback URLs are built on; from the request when empty
+	BaseURL string
+}
+
+// SetAuth configures the social login generated by GenerateProgram
+func (g *Generator) SetAuth(opts AuthOptions) {
+	g.auth = opts
+}
+
+// GenerateAuthHandlers generates RegisterAuthRoutes, which serves the
+// authorize and callback routes of each provider, and the LinkFunc signing
+// provider identities in as records of the auth resource. An identity signs
+// in as the user it was first linked to; a new identity with a verified email
+// is linked to the user with that email, or to a new user created from it.
+func (g *Generator) GenerateAuthHandlers(resources []*ast.ResourceNode, moduleName string) string {
+	g.reset()
+
+	var resource *ast.ResourceNode
+	for _, r := range resources {
+		if r.Name == g.auth.Resource {
+			resource = r
+		}
+	}
+	receiverName := strings.ToLower(resource.Name[0:1])
+
+	g.writeLine("package handlers")
+	g.writeLine("")
+	g.imports["context"] = true
+	g.imports["database/sql"] = true
+	g.imports["errors"] = true
+	g.imports["fmt"] = true
+	g.imports["github.com/go-chi/chi/v5"] = true
+	g.imports["github.com/conduit-lang/conduit/pkg/web/oauth"] = true
+	g.imports[moduleName+"/models"] = true
+	g.writeImports()
+	g.writeLine("")
+
+	g.writeLine("// RegisterAuthRoutes registers the social login routes (outside the API prefix)")
+	g.writeLine("func RegisterAuthRoutes(r chi.Router, db *sql.DB, login *oauth.Login) {")
+	g.indent++
+	g.writeLine("link := link%s(db)", resource.Name)
+	for _, provider := range g.auth.Providers {
+		g.writeLine("r.Get(%q, login.Authorize(%q))", oauth.AuthorizePath(provider), provider)
+		g.writeLine("r.Get(%q, login.Callback(%q, link))", oauth.CallbackPath(provider), provider)
+	}
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	emailColumn := ast.LoginEmailField
+	if field := resource.FindField(ast.LoginEmailField); field != nil {
+		emailColumn = g.fieldColumnName(field)
+	}
+	userQuery := "SELECT " + g.keyColumn(resource) + "::text FROM " + g.sqlTable(resource) + " WHERE " + emailColumn + " = $1"
+
+	g.writeLine("// link%s signs provider identities in as %s records, creating one on first", resource.Name, resource.Name)
+	g.writeLine("// sign-in when no %s has the identity's verified email", resource.Name)
+	g.writeLine("func link%s(db *sql.DB) oauth.LinkFunc {", resource.Name)
+	g.indent++
+	g.writeLine("return func(ctx context.Context, identity *oauth.Identity) (string, error) {")
+	g.indent++
+	g.writeLine("if userID, linked, err := oauth.FindLink(ctx, db, identity); err != nil || linked {")
+	g.indent++
+	g.writeLine("return userID, err")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+	g.writeLine("// Only an address the provider verified may claim an existing %s", resource.Name)
+	g.writeLine("if identity.Email == \"\" || !identity.EmailVerified {")
+	g.indent++
+	g.writeLine("return \"\", oauth.ErrUnverifiedEmail")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+	g.writeLine("var userID string")
+	g.writeLine("err := db.QueryRowContext(ctx, `%s`, identity.Email).Scan(&userID)", userQuery)
+	g.writeLine("if errors.Is(err, sql.ErrNoRows) {")
+	g.indent++
+	g.writeLine("%s := models.%s{", receiverName, resource.Name)
+	g.indent++
+	g.writeLine("%s: identity.Email,", g.toGoFieldName(ast.LoginEmailField))
+	if name := resource.FindField(ast.LoginNameField); name != nil {
+		if name.Nullable {
+			g.writeLine("%s: &identity.Name,", g.toGoFieldName(ast.LoginNameField))
+		} else {
+			g.writeLine("%s: identity.Name,", g.toGoFieldName(ast.LoginNameField))
+		}
+	}
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("// Create includes validation and hooks")
+	g.writeLine("if err := %s.Create(ctx, db); err != nil {", receiverName)
+	g.indent++
+	g.writeLine("return \"\", err")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("userID = fmt.Sprint(%s)", g.keyValue(resource, receiverName))
+	g.indent--
+	g.writeLine("} else if err != nil {")
+	g.indent++
+	g.writeLine("return \"\", err")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+	g.writeLine("return userID, oauth.SaveLink(ctx, db, identity, userID)")
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+
+	return g.buf.String()
+}
+
+// generateAuthConfig configures social login from the build's conduit.yaml
+// settings; client credentials are read from the environment by oauth.Configure
+func (g *Generator) generateAuthConfig() {
+	quoted := make([]string, len(g.auth.Providers))
+	for i, provider := range g.auth.Providers {
+		quoted[i] = `"` + provider + `"`
+	}
+
+	g.writeLine("// Sign users in as %s with %s", g.auth.Resource, strings.Join(g.auth.Providers, " and "))
+	g.writeLine("login, err := oauth.Configure(context.Background(), db, oauth.Config{")
+	g.indent++
+	g.writeLine("Providers: []string{%s},", strings.Join(quoted, ", "))
+	if g.auth.BaseURL != "" {
+		g.writeLine("BaseURL:   %q,", g.auth.BaseURL)
+	}
+	if g.auth.Redirect != "" {
+		g.writeLine("Redirect:  %q,", g.auth.Redirect)
+	}
+	g.indent--
+	g.writeLine("})")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(%q, err)", "Failed to configure login: %v")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
+
+// authMetadata describes the configured login providers and their routes
+func (g *Generator) authMetadata() (*metadata.AuthMetadata, []metadata.RouteMetadata) {
+	auth := &metadata.AuthMetadata{Resource: g.auth.Resource}
+	var routes []metadata.RouteMetadata
+	for _, name := range g.auth.Providers {
+		provider, err := oauth.NewProvider(name, "", "")
+		if err != nil {
+			continue
+		}
+		protocol := "oauth2"
+		if provider.OIDC {
+			protocol = "oidc"
+		}
+		auth.Providers = append(auth.Providers, metadata.AuthProviderMetadata{
+			Name:          name,
+			Protocol:      protocol,
+			AuthorizePath: oauth.AuthorizePath(name),
+			CallbackPath:  oauth.CallbackPath(name),
+			Scopes:        provider.Scopes,
+		})
+		routes = append(routes,
+			metadata.RouteMetadata{
+				Method:      "GET",
+				Path:        oauth.AuthorizePath(name),
+				Handler:     "login.Authorize",
+				Resource:    g.auth.Resource,
+				Operation:   "login",
+				Description: "Sign in with " + name,
+			},
+			metadata.RouteMetadata{
+				Method:      "GET",
+				Path:        oauth.CallbackPath(name),
+				Handler:     "login.Callback",
+				Resource:    g.auth.Resource,
+				Operation:   "login_callback",
+				Description: "Complete sign-in with " + name,
+			},
+		)
+	}
+	return auth, routes
+}
@@ -0,0 +1,121 @@
+package codegen
+
+import (
+	"encoding/json"
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/metadata"
+)
+
+const authSource = `resource User {
+  id: uuid! @primary @auto
+  email: string! @unique
+  name: string?
+}`
+
+func authTestGenerator() *Generator {
+	g := NewGenerator()
+	g.SetAuth(AuthOptions{
+		Enabled:   true,
+		Resource:  "User",
+		Providers: []string{"google", "github"},
+		Redirect:  "/",
+	})
+	return g
+}
+
+func TestGenerateProgram_Auth(t *testing.T) {
+	files, err := authTestGenerator().GenerateProgram(&ast.Program{Resources: []*ast.ResourceNode{parseResource(t, authSource)}}, "example.com/app", "", "/api")
+	if err != nil {
+		t.Fatalf("GenerateProgram failed: %v", err)
+	}
+
+	handlers, ok := files[AuthHandlersFile]
+	if !ok {
+		t.Fatalf("Missing %s", AuthHandlersFile)
+	}
+	if _, err := format.Source([]byte(handlers)); err != nil {
+		t.Fatalf("Generated auth handlers do not parse: %v\n%s", err, handlers)
+	}
+	assertContains(t, handlers,
+		`"github.com/conduit-lang/conduit/pkg/web/oauth"`,
+		`"example.com/app/models"`,
+		"func RegisterAuthRoutes(r chi.Router, db *sql.DB, login *oauth.Login) {",
+		`r.Get("/auth/google", login.Authorize("google"))`,
+		`r.Get("/auth/google/callback", login.Callback("google", link))`,
+		`r.Get("/auth/github/callback", login.Callback("github", link))`,
+		"oauth.FindLink(ctx, db, identity)",
+		"return \"\", oauth.ErrUnverifiedEmail",
+		"SELECT id::text FROM users WHERE email = $1",
+		"Email: identity.Email,",
+		"Name: &identity.Name,",
+		"u.Create(ctx, db)",
+		"return userID, oauth.SaveLink(ctx, db, identity, userID)",
+	)
+
+	main := files["main.go"]
+	if _, err := format.Source([]byte(main)); err != nil {
+		t.Fatalf("Generated main does not parse: %v\n%s", err, main)
+	}
+	assertContains(t, main,
+		`"github.com/conduit-lang/conduit/pkg/web/oauth"`,
+		"login, err := oauth.Configure(context.Background(), db, oauth.Config{",
+		`Providers: []string{"google", "github"},`,
+		`Redirect:  "/",`,
+		"handlers.RegisterAuthRoutes(r, db, login)",
+	)
+	if strings.Contains(main, "BaseURL") {
+		t.Error("Main should leave an unset base URL to the runtime")
+	}
+
+	// Login routes are mounted outside the API prefix
+	if strings.Index(main, "handlers.RegisterAuthRoutes") > strings.Index(main, `r.Route("/api"`) {
+		t.Error("Login routes should be registered outside the API prefix")
+	}
+}
+
+func TestGenerateProgram_AuthDisabled(t *testing.T) {
+	files, err := NewGenerator().GenerateProgram(&ast.Program{Resources: []*ast.ResourceNode{parseResource(t, authSource)}}, "example.com/app", "", "")
+	if err != nil {
+		t.Fatalf("GenerateProgram failed: %v", err)
+	}
+	if _, ok := files[AuthHandlersFile]; ok {
+		t.Errorf("%s should not be generated without providers", AuthHandlersFile)
+	}
+	if strings.Contains(files["main.go"], "oauth.") {
+		t.Error("Main should not configure login without providers")
+	}
+}
+
+func TestGenerateMetadata_Auth(t *testing.T) {
+	metadataJSON, err := authTestGenerator().GenerateMetadata(&ast.Program{Resources: []*ast.ResourceNode{parseResource(t, authSource)}})
+	if err != nil {
+		t.Fatalf("GenerateMetadata() error = %v", err)
+	}
+
+	var meta metadata.Metadata
+	if err := json.Unmarshal([]byte(metadataJSON), &meta); err != nil {
+		t.Fatalf("Metadata does not parse: %v", err)
+	}
+	if meta.Auth == nil || meta.Auth.Resource != "User" || len(meta.Auth.Providers) != 2 {
+		t.Fatalf("Auth metadata = %+v", meta.Auth)
+	}
+	google, github := meta.Auth.Providers[0], meta.Auth.Providers[1]
+	if google.Protocol != "oidc" || google.CallbackPath != "/auth/google/callback" || google.Scopes[0] != "openid" {
+		t.Errorf("google = %+v", google)
+	}
+	if github.Protocol != "oauth2" || github.AuthorizePath != "/auth/github" {
+		t.Errorf("github = %+v", github)
+	}
+
+	operations := map[string]string{}
+	for _, route := range meta.Routes {
+		operations[route.Path] = route.Operation
+	}
+	if operations["/auth/github"] != "login" || operations["/auth/github/callback"] != "login_callback" {
+		t.Errorf("Login routes missing from metadata: %v", operations)
+	}
+}
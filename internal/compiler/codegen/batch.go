@@ -0,0 +1,175 @@
+package codegen
+
+import (
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// hasBatchHook checks if a resource has a batch create hook with the timing
+func hasBatchHook(resource *ast.ResourceNode, timing string) bool {
+	for _, hook := range resource.Hooks {
+		if hook.Batch && hook.Timing == timing && hook.Event == "create" {
+			return true
+		}
+	}
+	return false
+}
+
+// batchHookName returns the name of the function generated for a batch hook,
+// e.g. BeforeCreatePostBatch for @before create batch on Post
+func (g *Generator) batchHookName(resource *ast.ResourceNode, hook *ast.HookNode) string {
+	return g.hookMethodName(hook) + resource.Name + "Batch"
+}
+
+// generateBatchHookCall calls the batch create hook with the timing on records
+// when the resource has one. Dry runs return before after hooks.
+func (g *Generator) generateBatchHookCall(resource *ast.ResourceNode, timing, records string) {
+	if !hasBatchHook(resource, timing) {
+		return
+	}
+	if timing == "after" {
+		g.writeLine("if dryrun.Enabled(ctx) {")
+		g.indent++
+		g.writeLine("return nil")
+		g.indent--
+		g.writeLine("}")
+	}
+	hook := &ast.HookNode{Timing: timing, Event: "create", Batch: true}
+	g.writeLine("if err := %s(ctx, db, %s); err != nil {", g.batchHookName(resource, hook), records)
+	g.indent++
+	g.writeLine("return fmt.Errorf(\"%s create batch hook failed: %%w\", err)", timing)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
+
+// generateCreateWithBatchHooks generates the Create method of a resource with
+// batch create hooks, which runs them with just the one record around create,
+// the insert that bulk creates run for every record
+func (g *Generator) generateCreateWithBatchHooks(resource *ast.ResourceNode) {
+	receiverName := strings.ToLower(resource.Name[0:1])
+	records := "[]*" + resource.Name + "{" + receiverName + "}"
+
+	g.writeLine("// Create inserts a new %s into the database", resource.Name)
+	g.writeLine("func (%s *%s) Create(ctx context.Context, db *sql.DB) error {", receiverName, resource.Name)
+	g.indent++
+	g.generateBatchHookCall(resource, "before", records)
+	g.writeLine("if err := %s.create(ctx, db); err != nil {", receiverName)
+	g.indent++
+	g.writeLine("return err")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+	g.generateBatchHookCall(resource, "after", records)
+	g.writeLine("return nil")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateCreateBatch generates the bulk create function of a resource, e.g.
+// CreatePostBatch, which runs batch create hooks once for all the records and
+// per-record hooks for each. Records are inserted one by one, so a failure
+// leaves the ones before it created.
+func (g *Generator) generateCreateBatch(resource *ast.ResourceNode) {
+	resourceLower := strings.ToLower(resource.Name)
+	create := "Create"
+	if hasBatchHook(resource, "before") || hasBatchHook(resource, "after") {
+		create = "create"
+	}
+
+	g.writeLine("// Create%sBatch inserts the records in order, stopping at the first that", resource.Name)
+	g.writeLine("// fails; the records before it stay created")
+	g.writeLine("func Create%sBatch(ctx context.Context, db *sql.DB, records []*%s) error {", resource.Name, resource.Name)
+	g.indent++
+	g.generateBatchHookCall(resource, "before", "records")
+	g.writeLine("for i, record := range records {")
+	g.indent++
+	g.writeLine("if err := record.%s(ctx, db); err != nil {", create)
+	g.indent++
+	g.writeLine("return fmt.Errorf(\"%s %%d: %%w\", i, err)", resourceLower)
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+	g.generateBatchHookCall(resource, "after", "records")
+	g.writeLine("return nil")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateCreateBatchHandler generates the bulk create handler (POST
+// /resources/batch), which takes a JSON array of records and responds 201
+// with the created records
+func (g *Generator) generateCreateBatchHandler(resource *ast.ResourceNode) {
+	tableName := g.toTableName(resource.Name)
+
+	g.writeLine("// Create%sBatchHandler handles POST /%s/batch - create %s from a JSON array",
+		resource.Name, tableName, tableName)
+	g.writeLine("func Create%sBatchHandler(db *sql.DB) http.HandlerFunc {", resource.Name)
+	g.indent++
+	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
+	g.indent++
+
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, \"create_batch\")", resource.Name)
+	g.writeLine("")
+	g.generateVisibleFields(resource)
+
+	g.writeLine("// Limit request body size to prevent DoS attacks (10MB default)")
+	g.writeLine("r.Body = http.MaxBytesReader(w, r.Body, 10<<20)")
+	g.writeLine("var decoded []models.%s", resource.Name)
+	g.writeLine("if err := json.NewDecoder(r.Body).Decode(&decoded); err != nil {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Invalid request body: %v\", err), http.StatusBadRequest)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if len(decoded) == 0 {")
+	g.indent++
+	g.generateMoveError("http.StatusBadRequest", "Expected a non-empty array of "+tableName)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("records := make([]*models.%s, len(decoded))", resource.Name)
+	g.writeLine("for i := range decoded {")
+	g.indent++
+	g.writeLine("records[i] = &decoded[i]")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("// Create %s (includes validation and hooks)", tableName)
+	g.writeLine("if err := models.Create%sBatch(ctx, db, records); err != nil {", resource.Name)
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to create %s: %%v\", err), http.StatusUnprocessableEntity)", tableName)
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	created := "records"
+	if len(resource.Profiles) > 0 {
+		created = "created"
+		g.writeLine("created := make([]interface{}, len(records))")
+		g.writeLine("for i, record := range records {")
+		g.indent++
+		g.writeLine("created[i] = %s", maskedRecord(resource, "record"))
+		g.indent--
+		g.writeLine("}")
+	}
+	g.writeLine("w.Header().Set(\"Content-Type\", \"application/json\")")
+	g.writeLine("w.WriteHeader(http.StatusCreated)")
+	g.writeLine("if err := json.NewEncoder(w).Encode(%s); err != nil {", g.encodedBody(created))
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to encode response: %v\", err), http.StatusInternalServerError)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+}
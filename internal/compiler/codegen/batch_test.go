@@ -0,0 +1,99 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+)
+
+const batchSource = `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+
+  @before create batch {
+    let pending = records
+  }
+
+  @before create {
+    self.title = self.title
+  }
+}`
+
+func TestGenerateResource_BatchHooks(t *testing.T) {
+	code, err := NewGenerator().GenerateResourceWithHooks(parseResource(t, batchSource))
+	if err != nil {
+		t.Fatalf("GenerateResourceWithHooks failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+
+	// Create runs the batch hook with just the one record
+	create := functionBody(t, code, "func (p *Post) Create(ctx context.Context, db *sql.DB) error {")
+	assertContains(t, create,
+		"if err := BeforeCreatePostBatch(ctx, db, []*Post{p}); err != nil {",
+		"if err := p.create(ctx, db); err != nil {",
+	)
+	if strings.Contains(create, "AfterCreatePostBatch") {
+		t.Errorf("Create should only call batch hooks that exist:\n%s", create)
+	}
+
+	// The insert keeps the per-record hooks
+	insert := functionBody(t, code, "func (p *Post) create(ctx context.Context, db *sql.DB) error {")
+	if !strings.Contains(insert, "if err := p.BeforeCreate(ctx, db); err != nil {") {
+		t.Errorf("create should call the per-record hook:\n%s", insert)
+	}
+	if strings.Contains(insert, "BeforeCreatePostBatch") {
+		t.Errorf("create should not call batch hooks:\n%s", insert)
+	}
+
+	bulk := functionBody(t, code, "func CreatePostBatch(ctx context.Context, db *sql.DB, records []*Post) error {")
+	assertContains(t, bulk,
+		"if err := BeforeCreatePostBatch(ctx, db, records); err != nil {",
+		"if err := record.create(ctx, db); err != nil {",
+		`return fmt.Errorf("post %d: %w", i, err)`,
+	)
+	if strings.Index(bulk, "BeforeCreatePostBatch") > strings.Index(bulk, "record.create") {
+		t.Errorf("The batch hook should run once before the inserts:\n%s", bulk)
+	}
+
+	hook := functionBody(t, code, "func BeforeCreatePostBatch(ctx context.Context, db *sql.DB, records []*Post) error {")
+	if !strings.Contains(hook, "pending := records") {
+		t.Errorf("BeforeCreatePostBatch should see the records:\n%s", hook)
+	}
+	if !strings.Contains(code, "func (p *Post) BeforeCreate(ctx context.Context, db *sql.DB) error {") {
+		t.Error("Missing per-record BeforeCreate hook")
+	}
+}
+
+func TestGenerateResource_CreateBatchWithoutBatchHooks(t *testing.T) {
+	code := generateModel(t, parseResource(t, postSource))
+
+	bulk := functionBody(t, code, "func CreatePostBatch(ctx context.Context, db *sql.DB, records []*Post) error {")
+	if !strings.Contains(bulk, "if err := record.Create(ctx, db); err != nil {") {
+		t.Errorf("CreatePostBatch should create each record:\n%s", bulk)
+	}
+	if strings.Contains(code, "func (p *Post) create(") {
+		t.Error("Resources without batch hooks should not split Create")
+	}
+}
+
+func TestGenerateHandlers_CreateBatch(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, batchSource))
+
+	if !strings.Contains(code, `r.Post("/posts/batch", CreatePostBatchHandler(db))`) {
+		t.Error("Missing bulk create route")
+	}
+
+	handler := functionBody(t, code, "func CreatePostBatchHandler(db *sql.DB) http.HandlerFunc {")
+	assertContains(t, handler,
+		"var decoded []models.Post",
+		`respondWithError(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)`,
+		"if len(decoded) == 0 {",
+		"records[i] = &decoded[i]",
+		"if err := models.CreatePostBatch(ctx, db, records); err != nil {",
+		"w.WriteHeader(http.StatusCreated)",
+		"json.NewEncoder(w).Encode(records)",
+		`respondWithError(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)`,
+	)
+}
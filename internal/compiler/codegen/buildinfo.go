@@ -0,0 +1,38 @@
+package codegen
+
+// BuildInfoOptions describes the build reported by the generated main at
+// startup and on /version. GenerateMetadata fills in SourceHash and
+// SchemaVersion from the metadata it embeds, so GenerateProgram reports the
+// same source_hash as build/introspection/metadata.json.
+type BuildInfoOptions struct {
+	// CompilerVersion is the version of the conduit executable
+	CompilerVersion string
+	// GitCommit is the commit of the application source, empty outside a repository
+	GitCommit string
+	// SourceHash is the source_hash of the generated metadata
+	SourceHash string
+	// SchemaVersion is the version of the generated metadata
+	SchemaVersion string
+}
+
+// SetBuildInfo configures the build information generated by GenerateMain
+func (g *Generator) SetBuildInfo(opts BuildInfoOptions) {
+	g.build = opts
+}
+
+// generateBuildInfo logs the build before anything can fail at startup, so
+// operators can tell which schema an instance was built from even when it
+// does not come up
+func (g *Generator) generateBuildInfo() {
+	g.writeLine("// Build information, logged at startup and served on buildinfo.Path")
+	g.writeLine("build := buildinfo.New(buildinfo.Info{")
+	g.indent++
+	g.writeLine("CompilerVersion: %q,", g.build.CompilerVersion)
+	g.writeLine("SourceHash: %q,", g.build.SourceHash)
+	g.writeLine("GitCommit: %q,", g.build.GitCommit)
+	g.writeLine("SchemaVersion: %q,", g.build.SchemaVersion)
+	g.indent--
+	g.writeLine("})")
+	g.writeLine("log.Printf(%q, build)", "Build %s")
+	g.writeLine("")
+}
@@ -0,0 +1,54 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+func TestGenerateProgram_BuildInfo(t *testing.T) {
+	gen := NewGenerator()
+	gen.SetBuildInfo(BuildInfoOptions{CompilerVersion: "0.4.0", GitCommit: "9c1e2f"})
+	files, err := gen.GenerateProgram(&ast.Program{Resources: []*ast.ResourceNode{parseResource(t, searchSource)}}, "example.com/blog", "", "/api/v1")
+	if err != nil {
+		t.Fatalf("GenerateProgram failed: %v", err)
+	}
+
+	var meta struct {
+		Version    string `json:"version"`
+		SourceHash string `json:"source_hash"`
+	}
+	if err := json.Unmarshal([]byte(files["introspection/metadata.json"]), &meta); err != nil {
+		t.Fatalf("invalid metadata: %v", err)
+	}
+	if meta.SourceHash == "" {
+		t.Fatal("metadata has no source hash")
+	}
+
+	code := files["main.go"]
+	for _, exp := range []string{
+		`"github.com/conduit-lang/conduit/pkg/web/buildinfo"`,
+		`CompilerVersion: "0.4.0",`,
+		fmt.Sprintf("SourceHash: %q,", meta.SourceHash),
+		`GitCommit: "9c1e2f",`,
+		fmt.Sprintf("SchemaVersion: %q,", meta.Version),
+		`log.Printf("Build %s", build)`,
+		"r.Get(buildinfo.Path, buildinfo.Handler(build))",
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated main missing %q", exp)
+		}
+	}
+
+	// The build is logged before the database can fail startup, and served
+	// outside the API prefix like the health check
+	if strings.Index(code, "log.Printf(\"Build") > strings.Index(code, "initDB()") {
+		t.Error("the build should be logged before connecting to the database")
+	}
+	if strings.Index(code, "r.Get(buildinfo.Path") > strings.Index(code, `r.Route("/api/v1"`) {
+		t.Error("the build information should be mounted outside the API prefix")
+	}
+}
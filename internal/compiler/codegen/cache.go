@@ -0,0 +1,64 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// hasCacheControl reports whether any resource declares @cache_control
+func hasCacheControl(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if resource.CacheControl != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// cachePolicyLiteral returns the cache.Policy literal for a @cache_control annotation
+func cachePolicyLiteral(cc *ast.CacheControlNode) string {
+	literal := fmt.Sprintf("cache.Policy{MaxAge: %d", cc.MaxAge)
+	if cc.SharedMaxAge > 0 {
+		literal += fmt.Sprintf(", SharedMaxAge: %d", cc.SharedMaxAge)
+	}
+	if cc.StaleWhileRevalidate > 0 {
+		literal += fmt.Sprintf(", StaleWhileRevalidate: %d", cc.StaleWhileRevalidate)
+	}
+	if cc.Public {
+		literal += ", Public: true"
+	}
+	return literal + "}"
+}
+
+// generateCachedRoutes registers a resource's routes with caching headers on
+// reads and CDN purges on writes, for resources with @cache_control
+func (g *Generator) generateCachedRoutes(resource *ast.ResourceNode) {
+	tableName := g.toTableName(resource.Name)
+
+	g.writeLine("// Cache-Control and Surrogate-Key headers from @cache_control; writes purge the CDN")
+	// Records with a natural key are keyed by its route parameters
+	keyParams := ""
+	if resource.HasNaturalKey() {
+		for _, param := range resource.KeyParams() {
+			keyParams += fmt.Sprintf(", %q", param)
+		}
+	}
+	g.writeLine("cacheable := cache.Cacheable(%s, %q%s)", cachePolicyLiteral(resource.CacheControl), tableName, keyParams)
+	g.writeLine("purge := cache.PurgeOnWrite(%q%s)", tableName, keyParams)
+	g.generateOperationRoutes(resource, "cacheable", "purge")
+}
+
+// generateCachePurger configures the CDN purger used by @cache_control
+// resources from the environment
+func (g *Generator) generateCachePurger() {
+	g.writeLine("// Purge CDN caches on writes to @cache_control resources (CONDUIT_CACHE_PURGE_URL)")
+	g.writeLine("purger, err := cache.PurgerFromEnv()")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(%q, err)", "Failed to configure cache purging: %v")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("cache.SetPurger(purger)")
+	g.writeLine("")
+}
@@ -0,0 +1,71 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const cacheSource = `resource BlogPost {
+  @cache_control(max_age: 60, s_maxage: 600, public: true)
+
+  id: uuid! @primary
+  title: string!
+}
+
+resource Comment {
+  id: uuid! @primary
+}`
+
+func TestGenerateHandlers_CacheControl(t *testing.T) {
+	code := generateHandlers(t, parseResources(t, cacheSource)...)
+
+	expected := []string{
+		`"github.com/conduit-lang/conduit/pkg/web/cache"`,
+		`cacheable := cache.Cacheable(cache.Policy{MaxAge: 60, SharedMaxAge: 600, Public: true}, "blogposts")`,
+		`purge := cache.PurgeOnWrite("blogposts")`,
+		`r.With(cacheable).Get("/blogposts", ListBlogPostHandler(db))`,
+		`r.With(cacheable).Get("/blogposts/{id}", GetBlogPostHandler(db))`,
+		`r.With(purge).Post("/blogposts", CreateBlogPostHandler(db))`,
+		`r.With(purge).Delete("/blogposts/{id}", DeleteBlogPostHandler(db))`,
+		// Resources without @cache_control are registered as before
+		`r.Get("/comments", ListCommentHandler(db))`,
+	}
+	for _, exp := range expected {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated handlers missing %q", exp)
+		}
+	}
+}
+
+func TestGenerateMain_CacheControl(t *testing.T) {
+	code, err := NewGenerator().GenerateMain(parseResources(t, cacheSource), "example.com/blog", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	for _, exp := range []string{
+		`"github.com/conduit-lang/conduit/pkg/web/cache"`,
+		"purger, err := cache.PurgerFromEnv()",
+		"cache.SetPurger(purger)",
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated main missing %q", exp)
+		}
+	}
+
+	code, err = NewGenerator().GenerateMain(parseResources(t, cacheSource)[1:], "example.com/blog", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "cache.") {
+		t.Error("Generated main should not configure purging without @cache_control resources")
+	}
+}
+
+func TestCachePolicyLiteral(t *testing.T) {
+	got := cachePolicyLiteral(&ast.CacheControlNode{MaxAge: 30, StaleWhileRevalidate: 10})
+	if want := "cache.Policy{MaxAge: 30, StaleWhileRevalidate: 10}"; got != want {
+		t.Errorf("cachePolicyLiteral() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,226 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// hasChanges reports whether any resource declares @changes
+func hasChanges(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if resource.Changes != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// softDeleteField returns the deleted_at field of a @changes or @soft_delete
+// resource, or nil when records are deleted outright
+func softDeleteField(resource *ast.ResourceNode) *ast.FieldNode {
+	if resource.Changes == nil && resource.SoftDelete == nil {
+		return nil
+	}
+	for _, field := range resource.Fields {
+		if field.Name == ast.SoftDeleteField {
+			return field
+		}
+	}
+	return nil
+}
+
+// liveCondition returns the SQL condition, prefixed with " AND ", that
+// excludes soft-deleted rows, or "" for resources without soft deletes
+func (g *Generator) liveCondition(resource *ast.ResourceNode) string {
+	if field := softDeleteField(resource); field != nil {
+		return " AND " + g.fieldColumnName(field) + " IS NULL"
+	}
+	return ""
+}
+
+// listWhere returns a WHERE clause, with a leading space, that keeps the rows
+// lists show by default: neither soft-deleted (@changes, @soft_delete) nor archived
+// (@archivable), and in the default scope (@default_scope). Returns "" when
+// every row is listed.
+func (g *Generator) listWhere(resource *ast.ResourceNode) string {
+	conditions := g.listConditions(resource, "")
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// listConditions returns the conditions of listWhere, with columns prefixed
+// by qualifier, such as "t.", when a query joins the table to itself
+func (g *Generator) listConditions(resource *ast.ResourceNode, qualifier string) []string {
+	var conditions []string
+	if field := softDeleteField(resource); field != nil {
+		conditions = append(conditions, qualifier+g.fieldColumnName(field)+" IS NULL")
+	}
+	if field := archivedField(resource); field != nil {
+		conditions = append(conditions, qualifier+g.fieldColumnName(field)+" IS NULL")
+	}
+	if condition := g.defaultScope(resource, qualifier); condition != "" {
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
+
+// creationField returns the required @auto timestamp that records when a
+// record was created, used to tell created records from updated ones in a
+// change feed, or nil when the resource has none
+func creationField(resource *ast.ResourceNode) *ast.FieldNode {
+	for _, field := range resource.Fields {
+		if hasConstraint(field, "auto") && !field.Nullable && field.Type.Kind == ast.TypePrimitive && field.Type.Name == "timestamp" {
+			return field
+		}
+	}
+	return nil
+}
+
+// generateSoftDelete generates the statement that marks a @changes or
+// @soft_delete record as deleted, bumping its modification time, when it has
+// one, so conditional GETs and the change feed notice
+func (g *Generator) generateSoftDelete(resource *ast.ResourceNode, receiverName string) {
+	deleted := softDeleteField(resource)
+	modified := modificationField(resource)
+
+	if resource.Changes != nil {
+		g.writeLine("// Soft delete: keep a tombstone for the change feed (@changes)")
+	} else {
+		g.writeLine("// Soft delete: keep the row, hidden until ?include_deleted=true (@soft_delete)")
+	}
+	g.writeLine("now := time.Now()")
+	keys := g.keyValues(resource, receiverName)
+	now := fmt.Sprintf("$%d", len(keys)+1)
+	modifiedSet := ""
+	if modified != nil {
+		modifiedSet = ", " + g.fieldColumnName(modified) + " = " + now
+	}
+	g.writeLine("query := `UPDATE %s SET %s = %s%s WHERE %s AND %s IS NULL`",
+		g.sqlTable(resource), g.fieldColumnName(deleted), now, modifiedSet,
+		g.keyCondition(resource, 1), g.fieldColumnName(deleted))
+	g.writeLine("")
+
+	g.writeLine("// Execute soft DELETE")
+	g.writeLine("_, err = tx.ExecContext(ctx, query, %s, now)", strings.Join(keys, ", "))
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("return fmt.Errorf(\"failed to delete %s: %%w\", err)", strings.ToLower(resource.Name))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("%s.%s = &now", receiverName, g.toGoFieldName(deleted.Name))
+	if modified != nil {
+		g.writeLine("%s.%s = now", receiverName, g.toGoFieldName(modified.Name))
+	}
+	g.writeLine("")
+}
+
+// generateChangesHandler generates the change feed handler for a @changes
+// resource (GET /resources/changes)
+func (g *Generator) generateChangesHandler(resource *ast.ResourceNode) {
+	resourceLower := strings.ToLower(resource.Name)
+	tableName := g.toTableName(resource.Name)
+	modified := modificationField(resource)
+	deleted := softDeleteField(resource)
+
+	g.writeLine("// Changes%sHandler handles GET /%s/changes - %s created, updated and deleted since a cursor",
+		resource.Name, tableName, resourceLower+"s")
+	g.writeLine("func Changes%sHandler(db *sql.DB) http.HandlerFunc {", resource.Name)
+	g.indent++
+	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
+	g.indent++
+
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, \"changes\")", resource.Name)
+	g.writeLine("")
+	g.generateVisibleFields(resource)
+
+	g.writeLine("// Parse since/cursor and limit")
+	g.writeLine("req, err := changes.ParseRequest(r)")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusBadRequest, err)")
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, err.Error(), http.StatusBadRequest)")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("// Read changes in modification order, including tombstones")
+	if resource.HasNaturalKey() {
+		g.writeLine("changesQuery, args := changes.QueryByKey(%q, %q, %q, req)", g.sqlTable(resource), g.fieldColumnName(modified), g.keyColumn(resource))
+	} else {
+		g.writeLine("changesQuery, args := changes.Query(%q, %q, req)", g.sqlTable(resource), g.fieldColumnName(modified))
+	}
+	g.writeLine("rows, err := db.QueryContext(ctx, changesQuery, args...)")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to query %s changes: %%v\", err))", resourceLower)
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to query %s changes: %%v\", err), http.StatusInternalServerError)", resourceLower)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("defer rows.Close()")
+	g.writeLine("")
+
+	createdAt := "time.Time{}"
+	if created := creationField(resource); created != nil {
+		createdAt = "item." + g.toGoFieldName(created.Name)
+	}
+
+	g.writeLine("feed := changes.NewFeed(req)")
+	g.writeLine("for rows.Next() {")
+	g.indent++
+	g.writeLine("item := &models.%s{}", resource.Name)
+	g.writeLine("if err := rows.Scan(%s); err != nil {", g.generateScanFields(resource))
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to scan %s: %%v\", err), http.StatusInternalServerError)", resourceLower)
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("op := changes.Classify(item.%s != nil, %s, req.Cursor.Time)", g.toGoFieldName(deleted.Name), createdAt)
+	g.writeLine("if !feed.Add(op, %s, item.%s, %s) {", g.recordID(resource, "item"), g.toGoFieldName(modified.Name), maskedRecord(resource, "item"))
+	g.indent++
+	g.writeLine("break")
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if err := rows.Err(); err != nil {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Error iterating %s changes: %%v\", err), http.StatusInternalServerError)", resourceLower)
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("w.Header().Set(\"Content-Type\", \"application/json\")")
+	g.writeLine("w.Header().Set(\"Cache-Control\", \"no-store\")")
+	g.writeLine("if err := json.NewEncoder(w).Encode(feed); err != nil {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(%q, err), http.StatusInternalServerError)", "Failed to encode response: %v")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+}
@@ -0,0 +1,87 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+const changesSource = `resource Post {
+  @changes
+
+  id: uuid! @primary @auto
+  title: string!
+  updated_at: timestamp! @auto_update
+  deleted_at: timestamp?
+  created_at: timestamp! @auto
+}`
+
+func TestGenerateHandlers_Changes(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, changesSource))
+
+	expected := []string{
+		`"github.com/conduit-lang/conduit/pkg/web/changes"`,
+		"func ChangesPostHandler(db *sql.DB) http.HandlerFunc {",
+		"req, err := changes.ParseRequest(r)",
+		`changesQuery, args := changes.Query("posts", "updated_at", req)`,
+		"op := changes.Classify(item.DeletedAt != nil, item.CreatedAt, req.Cursor.Time)",
+		"if !feed.Add(op, item.ID, item.UpdatedAt, item) {",
+		`r.Get("/posts/changes", ChangesPostHandler(db))`,
+		// Lists skip tombstones unless ?include_deleted=true asks for them
+		"includeDeleted, err := query.ParseIncludeDeleted(r)",
+		`Deleted("deleted_at", includeDeleted).`,
+	}
+	for _, exp := range expected {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated handlers missing %q", exp)
+		}
+	}
+	if strings.Contains(code, `"time"`) {
+		t.Error("time should only be imported when there is no creation timestamp")
+	}
+
+	// Without a creation timestamp every live record is reported as updated
+	code = generateHandlers(t, parseResource(t, strings.Replace(changesSource, "\n  created_at: timestamp! @auto", "", 1)))
+	for _, exp := range []string{`"time"`, "op := changes.Classify(item.DeletedAt != nil, time.Time{}, req.Cursor.Time)"} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated handlers missing %q", exp)
+		}
+	}
+}
+
+func TestGenerateResource_SoftDelete(t *testing.T) {
+	code := generateModel(t, parseResource(t, changesSource))
+
+	expected := []string{
+		"query := `UPDATE posts SET deleted_at = $2, updated_at = $2 WHERE id = $1 AND deleted_at IS NULL`",
+		"_, err = tx.ExecContext(ctx, query, p.ID, now)",
+		"p.DeletedAt = &now",
+		"WHERE id = $1 AND deleted_at IS NULL`",
+		"FROM posts WHERE deleted_at IS NULL ORDER BY id LIMIT $1 OFFSET $2`",
+		"query := `SELECT COUNT(*) FROM posts WHERE deleted_at IS NULL`",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated model missing %q", exp)
+		}
+	}
+	if strings.Contains(code, "DELETE FROM posts") {
+		t.Error("@changes resources should not delete rows")
+	}
+	if strings.Count(code, "AND deleted_at IS NULL`") != 4 {
+		t.Errorf("FindByID, Update, Patch and Delete should skip tombstones:\n%s", code)
+	}
+
+	// The modification time is set on create so new records enter the feed
+	create := code[strings.Index(code, ") Create("):strings.Index(code, "func FindPostByID")]
+	if !strings.Contains(create, "p.UpdatedAt = time.Now()") {
+		t.Error("Create should set the @auto_update field of a @changes resource")
+	}
+
+	// Resources without @changes keep hard deletes
+	plain := parseResource(t, changesSource)
+	plain.Changes = nil
+	code = generateModel(t, plain)
+	if !strings.Contains(code, "DELETE FROM posts WHERE id = $1") || strings.Contains(code, "IS NULL") {
+		t.Error("resources without @changes should delete rows outright")
+	}
+}
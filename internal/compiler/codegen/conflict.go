@@ -0,0 +1,127 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// conflictVersionField returns the required @auto_update timestamp whose
+// value is the version of a @conflict record, or nil when the resource has no
+// conflict policy or no such field
+func conflictVersionField(resource *ast.ResourceNode) *ast.FieldNode {
+	if resource.Conflict == nil {
+		return nil
+	}
+	for _, field := range resource.Fields {
+		if hasConstraint(field, "auto_update") && !field.Nullable && field.Type.Kind == ast.TypePrimitive && field.Type.Name == "timestamp" {
+			return field
+		}
+	}
+	return nil
+}
+
+// checksConflicts reports whether update handlers of the resource must check
+// the request's precondition; last_write_wins only advertises versions
+func checksConflicts(resource *ast.ResourceNode) bool {
+	return conflictVersionField(resource) != nil && resource.Conflict.Strategy != ast.ConflictLastWriteWins
+}
+
+// hasConflict reports whether any resource advertises record versions
+func hasConflict(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if conflictVersionField(resource) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// conflictPolicy returns the conflict.Policy literal for a resource
+func conflictPolicy(conflict *ast.ConflictNode) string {
+	strategy := "conflict.LastWriteWins"
+	switch conflict.Strategy {
+	case ast.ConflictReject:
+		strategy = "conflict.Reject"
+	case ast.ConflictMerge:
+		strategy = "conflict.Merge"
+	}
+	if len(conflict.MergeFields) == 0 {
+		return fmt.Sprintf("conflict.Policy{Strategy: %s}", strategy)
+	}
+
+	fields := make([]string, len(conflict.MergeFields))
+	for i, field := range conflict.MergeFields {
+		fields[i] = fmt.Sprintf("%q", field)
+	}
+	return fmt.Sprintf("conflict.Policy{Strategy: %s, MergeFields: []string{%s}}", strategy, strings.Join(fields, ", "))
+}
+
+// generateLoadCurrent generates the lookup of the stored record that a PUT
+// request's precondition is checked against
+func (g *Generator) generateLoadCurrent(resource *ast.ResourceNode) {
+	resourceLower := strings.ToLower(resource.Name)
+
+	g.writeLine("// Load the stored version to resolve conflicting updates (@conflict)")
+	g.writeLine("current, err := models.Find%sByID(ctx, db, %s)", resource.Name, g.keyArgs(resource))
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("if err == sql.ErrNoRows {")
+	g.indent++
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusNotFound, fmt.Errorf(\"Not found\"))")
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, \"Not found\", http.StatusNotFound)")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to find %s: %%v\", err))", resourceLower)
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to find %s: %%v\", err), http.StatusInternalServerError)", resourceLower)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateConflictCheck generates the precondition check of an update
+// against the stored record held in recordVar
+func (g *Generator) generateConflictCheck(resource *ast.ResourceNode, recordVar string) {
+	version := conflictVersionField(resource)
+
+	g.writeLine("if cerr := conflict.Check(r, %s, %s.%s); cerr != nil {",
+		conflictPolicy(resource.Conflict), recordVar, g.toGoFieldName(version.Name))
+	g.indent++
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, cerr.Status, cerr)")
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, cerr.Message, cerr.Status)")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
+
+// generateETagHeader generates the ETag header carrying the version of the
+// record held in recordVar, which clients send back in If-Match
+func (g *Generator) generateETagHeader(resource *ast.ResourceNode, recordVar string) {
+	if version := conflictVersionField(resource); version != nil {
+		g.writeLine("w.Header().Set(\"ETag\", conflict.ETag(%s.%s))", recordVar, g.toGoFieldName(version.Name))
+	}
+}
@@ -0,0 +1,143 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const conflictSource = `resource Post {
+  @conflict(strategy: merge(title, body))
+
+  id: uuid! @primary @auto
+  title: string!
+  body: text!
+  updated_at: timestamp! @auto_update
+}`
+
+func TestGenerateHandlers_Conflict(t *testing.T) {
+	resource := parseResource(t, conflictSource)
+	code := generateHandlers(t, resource)
+
+	expected := []string{
+		`"github.com/conduit-lang/conduit/pkg/web/conflict"`,
+		"current, err := models.FindPostByID(ctx, db, id)",
+		`if cerr := conflict.Check(r, conflict.Policy{Strategy: conflict.Merge, MergeFields: []string{"title", "body"}}, current.UpdatedAt); cerr != nil {`,
+		`if cerr := conflict.Check(r, conflict.Policy{Strategy: conflict.Merge, MergeFields: []string{"title", "body"}}, existing.UpdatedAt); cerr != nil {`,
+		"response.RenderJSONAPIError(w, cerr.Status, cerr)",
+		"respondWithError(w, cerr.Message, cerr.Status)",
+		`w.Header().Set("ETag", conflict.ETag(result.UpdatedAt))`,
+	}
+	for _, exp := range expected {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated handlers missing %q", exp)
+		}
+	}
+
+	// PUT and PATCH advertise the new version in both response formats
+	if n := strings.Count(code, `w.Header().Set("ETag", conflict.ETag(p.UpdatedAt))`); n != 2 {
+		t.Errorf("Update handler sets ETag %d times, want 2", n)
+	}
+	if n := strings.Count(code, `w.Header().Set("ETag", conflict.ETag(existing.UpdatedAt))`); n != 2 {
+		t.Errorf("Patch handler sets ETag %d times, want 2", n)
+	}
+}
+
+func TestGenerateHandlers_ConflictLastWriteWins(t *testing.T) {
+	resource := parseResource(t, strings.Replace(conflictSource, "merge(title, body)", "last_write_wins", 1))
+	code := generateHandlers(t, resource)
+
+	if strings.Contains(code, "conflict.Check(") || strings.Contains(code, "current, err :=") {
+		t.Error("last_write_wins should not check preconditions")
+	}
+	if !strings.Contains(code, `w.Header().Set("ETag", conflict.ETag(result.UpdatedAt))`) {
+		t.Error("last_write_wins should still advertise record versions")
+	}
+
+	// Resources without @conflict are unchanged
+	resource.Conflict = nil
+	code = generateHandlers(t, resource)
+	if strings.Contains(code, "conflict") {
+		t.Error("resources without @conflict should not reference the conflict package")
+	}
+}
+
+func TestConflictPolicy(t *testing.T) {
+	tests := []struct {
+		conflict *ast.ConflictNode
+		want     string
+	}{
+		{&ast.ConflictNode{Strategy: ast.ConflictReject}, "conflict.Policy{Strategy: conflict.Reject}"},
+		{&ast.ConflictNode{Strategy: ast.ConflictLastWriteWins}, "conflict.Policy{Strategy: conflict.LastWriteWins}"},
+		{&ast.ConflictNode{Strategy: ast.ConflictMerge, MergeFields: []string{"title"}}, `conflict.Policy{Strategy: conflict.Merge, MergeFields: []string{"title"}}`},
+	}
+
+	for _, tt := range tests {
+		if got := conflictPolicy(tt.conflict); got != tt.want {
+			t.Errorf("conflictPolicy(%s) = %s, want %s", tt.conflict.Strategy, got, tt.want)
+		}
+	}
+}
+
+func TestGeneratedConflictReject_Runs(t *testing.T) {
+	t.Parallel()
+	source := strings.Replace(conflictSource, "merge(title, body)", "reject", 1)
+	runGenerated(t, source, map[string]string{"handlers/conflict_test.go": `package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/conduit-lang/conduit/pkg/web/conflict"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"example.com/app/handlers"
+)
+
+func TestUpdateChecksPreconditions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	router := chi.NewRouter()
+	handlers.RegisterPostRoutes(router, db)
+
+	id := uuid.New()
+	version := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		ifMatch string
+		want    int
+	}{
+		{"", http.StatusPreconditionRequired},
+		{conflict.ETag(version.Add(-time.Minute)), http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		// The stored version is read and nothing is written
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, title, body, updated_at FROM posts WHERE id = $1")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "body", "updated_at"}).AddRow(id, "Old", "Body", version))
+
+		req := httptest.NewRequest(http.MethodPut, "/posts/"+id.String(), strings.NewReader(` + "`" + `{"title": "New", "body": "Body"}` + "`" + `))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.ifMatch != "" {
+			req.Header.Set("If-Match", tt.ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("PUT with If-Match %q = %d, want %d: %s", tt.ifMatch, rec.Code, tt.want, rec.Body)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+`})
+}
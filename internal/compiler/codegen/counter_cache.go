@@ -0,0 +1,56 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// generateCounterCacheUpdates adjusts the @counter_cache columns on the
+// parents of a record by delta ("+ 1" or "- 1"). The parent is read from the
+// stored row inside the transaction, so a decrement before a write and an
+// increment after it move the count when the record changes parent, and
+// soft-deleted records are not counted.
+func (g *Generator) generateCounterCacheUpdates(resource *ast.ResourceNode, receiverName, delta string) {
+	if len(resource.CounterCaches) == 0 {
+		return
+	}
+
+	g.writeLine("// Keep @counter_cache columns on parents in step")
+	for _, counter := range resource.CounterCaches {
+		rel := resource.CounterCacheRelationship(counter)
+		if rel == nil {
+			continue
+		}
+		foreignKey := rel.ForeignKeyColumn()
+		if field := resource.FindField(foreignKey); field != nil {
+			foreignKey = g.fieldColumnName(field)
+		}
+		column := g.toDBColumnName(counter.Column)
+		parentTable, parentKey := g.toTableName(counter.Resource), "id"
+		if parent := g.findResource(counter.Resource); parent != nil {
+			parentTable, parentKey = g.sqlTable(parent), g.keyColumn(parent)
+		}
+
+		g.writeLine("if _, err := tx.ExecContext(ctx, `UPDATE %s SET %s = %s %s WHERE %s = (SELECT %s FROM %s WHERE %s%s)`, %s); err != nil {",
+			parentTable, column, column, delta, parentKey,
+			foreignKey, g.sqlTable(resource), g.keyCondition(resource, 1), g.liveCondition(resource),
+			strings.Join(g.keyValues(resource, receiverName), ", "))
+		g.indent++
+		g.writeLine("return fmt.Errorf(%q, err)", fmt.Sprintf("failed to update %s.%s: %%w", counter.Resource, counter.Column))
+		g.indent--
+		g.writeLine("}")
+	}
+	g.writeLine("")
+}
+
+// counterCacheColumns returns the columns of a resource that @counter_cache
+// maintains, which clients cannot write
+func (g *Generator) counterCacheColumns(resource *ast.ResourceNode) []string {
+	var columns []string
+	for _, field := range resource.CounterCacheFields() {
+		columns = append(columns, g.fieldColumnName(field))
+	}
+	return columns
+}
@@ -0,0 +1,111 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const counterCacheSource = `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+}
+
+resource Comment {
+  @counter_cache(comments_count on Post.comments)
+
+  id: uuid! @primary @auto
+  body: text!
+  post_id: uuid!
+
+  post: Post! {
+    foreign_key: "post_id"
+  }
+}`
+
+func TestWithCounterCaches(t *testing.T) {
+	resources := parseResources(t, counterCacheSource)
+	expanded := ast.WithCounterCaches(resources)
+
+	post := expanded[0]
+	counter := post.FindField("comments_count")
+	if counter == nil {
+		t.Fatalf("Expected comments_count on Post, got fields: %v", post.Fields)
+	}
+	if !counter.IsCounterCache() || counter.Nullable || counter.Type.Name != "int" {
+		t.Errorf("Unexpected counter field: %+v", counter)
+	}
+	if resources[0].FindField("comments_count") != nil {
+		t.Error("WithCounterCaches should not modify the given resources")
+	}
+	if expanded[1] != resources[1] {
+		t.Error("Resources without counters on them should be returned as is")
+	}
+}
+
+func TestGenerateMigrations_CounterCache(t *testing.T) {
+	sql, err := NewGenerator().GenerateMigrations(parseResources(t, counterCacheSource))
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+
+	if !strings.Contains(sql, "comments_count BIGINT NOT NULL DEFAULT 0") {
+		t.Errorf("Migration missing counter column on posts:\n%s", sql)
+	}
+}
+
+func TestGenerateResource_CounterCache(t *testing.T) {
+	resources := ast.WithCounterCaches(parseResources(t, counterCacheSource))
+	gen := NewGenerator()
+
+	code, err := gen.GenerateResource(resources[1])
+	if err != nil {
+		t.Fatalf("GenerateResource failed: %v", err)
+	}
+
+	increment := "UPDATE posts SET comments_count = comments_count + 1 WHERE id = (SELECT post_id FROM comments WHERE id = $1)"
+	decrement := "UPDATE posts SET comments_count = comments_count - 1 WHERE id = (SELECT post_id FROM comments WHERE id = $1)"
+	for _, fn := range []struct {
+		name string
+		want []string
+	}{
+		{"Create", []string{increment}},
+		{"Update", []string{decrement, "UPDATE comments SET", increment}},
+		{"Patch", []string{decrement, "UPDATE comments SET", increment}},
+		{"Delete", []string{decrement, "DELETE FROM comments"}},
+	} {
+		body := functionBody(t, code, "func (c *Comment) "+fn.name+"(")
+		last := -1
+		for _, want := range fn.want {
+			i := strings.Index(body, want)
+			if i < 0 {
+				t.Errorf("%s missing %q:\n%s", fn.name, want, body)
+				continue
+			}
+			if i < last {
+				t.Errorf("%s: %q is out of order:\n%s", fn.name, want, body)
+			}
+			last = i
+		}
+	}
+
+	// The counter is read-only on the parent
+	postCode, err := gen.GenerateResource(resources[0])
+	if err != nil {
+		t.Fatalf("GenerateResource failed: %v", err)
+	}
+	if !strings.Contains(postCode, "CommentsCount int") {
+		t.Errorf("Post struct missing counter field:\n%s", postCode)
+	}
+	if strings.Contains(functionBody(t, postCode, "func (p *Post) Create("), "comments_count") {
+		t.Error("Post.Create should not write the counter column")
+	}
+	if strings.Contains(functionBody(t, postCode, "func (p *Post) Update("), "comments_count") {
+		t.Error("Post.Update should not write the counter column")
+	}
+	patch := functionBody(t, postCode, "func (p *Post) Patch(")
+	if !strings.Contains(patch, `"comments_count": true,`) {
+		t.Errorf("Post.Patch should reject the counter column:\n%s", patch)
+	}
+}
@@ -11,9 +11,18 @@ import (
 func (g *Generator) generateCreate(resource *ast.ResourceNode) {
 	receiverName := strings.ToLower(resource.Name[0:1])
 
-	g.writeLine("// Create inserts a new %s into the database", resource.Name)
-	g.writeLine("func (%s *%s) Create(ctx context.Context, db *sql.DB) error {",
-		receiverName, resource.Name)
+	// Batch create hooks run around the insert, once per Create or bulk create
+	if hasBatchHook(resource, "before") || hasBatchHook(resource, "after") {
+		g.generateCreateWithBatchHooks(resource)
+		g.writeLine("")
+		g.writeLine("// create inserts a new %s into the database without running batch hooks", resource.Name)
+		g.writeLine("func (%s *%s) create(ctx context.Context, db *sql.DB) error {",
+			receiverName, resource.Name)
+	} else {
+		g.writeLine("// Create inserts a new %s into the database", resource.Name)
+		g.writeLine("func (%s *%s) Create(ctx context.Context, db *sql.DB) error {",
+			receiverName, resource.Name)
+	}
 	g.indent++
 
 	// 1. Generate @auto fields FIRST (UUIDs, timestamps)
@@ -53,6 +62,7 @@ func (g *Generator) generateCreate(resource *ast.ResourceNode) {
 	g.indent--
 	g.writeLine("}")
 	g.writeLine("")
+	g.generateShardCheck(resource, receiverName, "")
 
 	// 5. Begin transaction
 	g.writeLine("// Begin transaction")
@@ -64,6 +74,7 @@ func (g *Generator) generateCreate(resource *ast.ResourceNode) {
 	g.writeLine("}")
 	g.writeLine("defer tx.Rollback()")
 	g.writeLine("")
+	g.generatePlacement(resource, receiverName)
 
 	// 6. Build INSERT query
 	columns, placeholders, values := g.buildInsertQuery(resource)
@@ -71,19 +82,19 @@ func (g *Generator) generateCreate(resource *ast.ResourceNode) {
 	// Check if we need to return ID
 	needsReturningID := needsAutoID(resource)
 	if needsReturningID {
-		g.writeLine("query := `INSERT INTO %s (%s) VALUES (%s) RETURNING id`",
-			g.toTableName(resource.Name), strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+		g.writeLine("query := `INSERT INTO %s (%s) VALUES (%s) RETURNING %s`",
+			g.sqlTable(resource), strings.Join(columns, ", "), strings.Join(placeholders, ", "), g.keyColumn(resource))
 	} else {
 		g.writeLine("query := `INSERT INTO %s (%s) VALUES (%s)`",
-			g.toTableName(resource.Name), strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+			g.sqlTable(resource), strings.Join(columns, ", "), strings.Join(placeholders, ", "))
 	}
 	g.writeLine("")
 
 	// Execute INSERT
 	g.writeLine("// Execute INSERT")
 	if needsReturningID {
-		g.writeLine("err = tx.QueryRowContext(ctx, query, %s).Scan(&%s.ID)",
-			strings.Join(values, ", "), receiverName)
+		g.writeLine("err = tx.QueryRowContext(ctx, query, %s).Scan(&%s)",
+			strings.Join(values, ", "), g.keyValue(resource, receiverName))
 		g.writeLine("if err != nil {")
 		g.indent++
 		g.writeLine("return fmt.Errorf(\"failed to insert %s: %%w\", err)", strings.ToLower(resource.Name))
@@ -98,6 +109,8 @@ func (g *Generator) generateCreate(resource *ast.ResourceNode) {
 		g.writeLine("}")
 	}
 	g.writeLine("")
+	g.generateCounterCacheUpdates(resource, receiverName, "+ 1")
+	g.generateDryRunReturn("insert")
 
 	// 7. Call AfterCreate hook if it exists
 	if hasHook(resource, "after", "create") {
@@ -130,40 +143,66 @@ func (g *Generator) generateCreate(resource *ast.ResourceNode) {
 	g.writeLine("}")
 	g.writeLine("")
 
+	g.generateSearchIndexing(resource, receiverName, false)
+
 	g.writeLine("return nil")
 	g.indent--
 	g.writeLine("}")
 }
 
-// getIDGoType returns the Go type for the ID field
+// getIDGoType returns the Go type for the primary key field, int64 for the
+// generated id
 func (g *Generator) getIDGoType(resource *ast.ResourceNode) string {
-	for _, field := range resource.Fields {
-		if field.Name == "id" {
-			return g.toGoType(field)
-		}
-	}
-	// Default ID type if not explicitly defined
-	return "int64"
+	return g.toGoType(keyField(resource))
 }
 
-// generateFindByID generates the FindByID() function for a resource
+// generateFindByID generates the FindByID() function for a resource, the
+// FindByIDWithDeleted() function that also finds soft-deleted records of a
+// @changes or @soft_delete resource, and the FindByIDUnscoped() functions
+// that ignore a @default_scope
 func (g *Generator) generateFindByID(resource *ast.ResourceNode) {
-	idType := g.getIDGoType(resource)
+	live, scope := g.liveCondition(resource), g.scopeCondition(resource)
+
 	g.writeLine("// FindByID retrieves a %s by its ID", resource.Name)
-	g.writeLine("func Find%sByID(ctx context.Context, db *sql.DB, id %s) (*%s, error) {",
-		resource.Name, idType, resource.Name)
+	g.generateFind(resource, "ByID", live+scope)
+
+	if softDeleteField(resource) != nil {
+		g.writeLine("")
+		g.writeLine("// FindByIDWithDeleted retrieves a %s by its ID, even when it is soft-deleted", resource.Name)
+		g.generateFind(resource, "ByIDWithDeleted", scope)
+	}
+
+	if scope != "" {
+		g.writeLine("")
+		g.writeLine("// FindByIDUnscoped retrieves a %s by its ID, even outside its default scope", resource.Name)
+		g.generateFind(resource, "ByIDUnscoped", live)
+
+		if softDeleteField(resource) != nil {
+			g.writeLine("")
+			g.writeLine("// FindByIDUnscopedWithDeleted retrieves a %s by its ID, even when it is", resource.Name)
+			g.writeLine("// soft-deleted or outside its default scope")
+			g.generateFind(resource, "ByIDUnscopedWithDeleted", "")
+		}
+	}
+}
+
+// generateFind generates a Find<Resource><suffix>() function that reads the
+// record with the given key, restricted by condition, such as liveCondition
+func (g *Generator) generateFind(resource *ast.ResourceNode, suffix, condition string) {
+	g.writeLine("func Find%s%s(ctx context.Context, db *sql.DB, %s) (*%s, error) {",
+		resource.Name, suffix, g.keyParams(resource), resource.Name)
 	g.indent++
 
 	// Build SELECT query
 	columns, scanTargets := g.buildSelectQuery(resource)
 
-	g.writeLine("query := `SELECT %s FROM %s WHERE id = $1`",
-		strings.Join(columns, ", "), g.toTableName(resource.Name))
+	g.writeLine("query := `SELECT %s FROM %s WHERE %s%s`",
+		strings.Join(columns, ", "), g.sqlTable(resource), g.keyCondition(resource, 1), condition)
 	g.writeLine("")
 
 	g.writeLine("%s := &%s{}", strings.ToLower(resource.Name[0:1]), resource.Name)
-	g.writeLine("err := db.QueryRowContext(ctx, query, id).Scan(%s)",
-		strings.Join(scanTargets, ", "))
+	g.writeLine("err := db.QueryRowContext(ctx, query, %s).Scan(%s)",
+		g.keyArgs(resource), strings.Join(scanTargets, ", "))
 	g.writeLine("")
 
 	g.writeLine("if err != nil {")
@@ -224,6 +263,7 @@ func (g *Generator) generateUpdate(resource *ast.ResourceNode) {
 	g.indent--
 	g.writeLine("}")
 	g.writeLine("")
+	g.generateShardCheck(resource, receiverName, "")
 
 	// 5. Begin transaction
 	g.writeLine("// Begin transaction")
@@ -236,15 +276,16 @@ func (g *Generator) generateUpdate(resource *ast.ResourceNode) {
 	g.writeLine("defer tx.Rollback()")
 	g.writeLine("")
 
+	g.generateCounterCacheUpdates(resource, receiverName, "- 1")
 	// 6. Build UPDATE query
 	setClauses, values := g.buildUpdateQuery(resource)
 
-	g.writeLine("query := `UPDATE %s SET %s WHERE id = $%d`",
-		g.toTableName(resource.Name), strings.Join(setClauses, ", "), len(setClauses)+1)
+	g.writeLine("query := `UPDATE %s SET %s WHERE %s%s`",
+		g.sqlTable(resource), strings.Join(setClauses, ", "), g.keyCondition(resource, len(values)+1), g.liveCondition(resource))
 	g.writeLine("")
 
 	// Add ID to values
-	values = append(values, fmt.Sprintf("%s.ID", receiverName))
+	values = append(values, g.keyValues(resource, receiverName)...)
 
 	// Execute UPDATE
 	g.writeLine("// Execute UPDATE")
@@ -255,6 +296,8 @@ func (g *Generator) generateUpdate(resource *ast.ResourceNode) {
 	g.indent--
 	g.writeLine("}")
 	g.writeLine("")
+	g.generateCounterCacheUpdates(resource, receiverName, "+ 1")
+	g.generateDryRunReturn("update")
 
 	// 7. Call AfterUpdate hook if it exists
 	if hasHook(resource, "after", "update") {
@@ -287,6 +330,8 @@ func (g *Generator) generateUpdate(resource *ast.ResourceNode) {
 	g.writeLine("}")
 	g.writeLine("")
 
+	g.generateSearchIndexing(resource, receiverName, false)
+
 	g.writeLine("return nil")
 	g.indent--
 	g.writeLine("}")
@@ -324,9 +369,23 @@ func (g *Generator) generatePatch(resource *ast.ResourceNode) {
 	g.writeLine("// Validate no read-only fields are being updated")
 	g.writeLine("readOnlyFields := map[string]bool{")
 	g.indent++
-	g.writeLine(`"id": true,`)
-	g.writeLine(`"created_at": true,`)
-	g.writeLine(`"updated_at": true,`)
+	for _, field := range keyFields(resource) {
+		g.writeLine("%q: true,", g.jsonName(field.Name))
+	}
+	g.writeLine("%q: true,", g.jsonName("created_at"))
+	g.writeLine("%q: true,", g.jsonName("updated_at"))
+	for _, field := range resource.CounterCacheFields() {
+		g.writeLine("%q: true,", g.jsonName(field.Name))
+	}
+	if position := positionField(resource); position != nil {
+		g.writeLine("%q: true,", g.jsonName(position.Name))
+	}
+	if deleted := softDeleteField(resource); deleted != nil {
+		g.writeLine("%q: true,", g.jsonName(deleted.Name))
+	}
+	if archived := archivedField(resource); archived != nil {
+		g.writeLine("%q: true,", g.jsonName(archived.Name))
+	}
 	g.indent--
 	g.writeLine("}")
 	g.writeLine("for field := range partialData {")
@@ -345,9 +404,8 @@ func (g *Generator) generatePatch(resource *ast.ResourceNode) {
 	g.writeLine("validFields := map[string]bool{")
 	g.indent++
 	for _, field := range resource.Fields {
-		if field.Name != "id" && !hasConstraint(field, "auto") && !hasConstraint(field, "auto_update") {
-			columnName := g.toDBColumnName(field.Name)
-			g.writeLine("\"%s\": true,", columnName)
+		if !isKeyField(resource, field) && !hasConstraint(field, "auto") && !hasConstraint(field, "auto_update") && !field.IsCounterCache() && field != positionField(resource) && field != softDeleteField(resource) && field != archivedField(resource) {
+			g.writeLine("%q: true,", g.jsonName(field.Name))
 		}
 	}
 	g.indent--
@@ -423,6 +481,7 @@ func (g *Generator) generatePatch(resource *ast.ResourceNode) {
 	g.indent--
 	g.writeLine("}")
 	g.writeLine("")
+	g.generateShardCheck(resource, receiverName, "")
 
 	// Begin transaction
 	g.writeLine("// Begin transaction")
@@ -435,15 +494,16 @@ func (g *Generator) generatePatch(resource *ast.ResourceNode) {
 	g.writeLine("defer tx.Rollback()")
 	g.writeLine("")
 
+	g.generateCounterCacheUpdates(resource, receiverName, "- 1")
 	// Build UPDATE query for all fields (same as Update)
 	setClauses, values := g.buildUpdateQuery(resource)
 
-	g.writeLine("query := `UPDATE %s SET %s WHERE id = $%d`",
-		g.toTableName(resource.Name), strings.Join(setClauses, ", "), len(setClauses)+1)
+	g.writeLine("query := `UPDATE %s SET %s WHERE %s%s`",
+		g.sqlTable(resource), strings.Join(setClauses, ", "), g.keyCondition(resource, len(values)+1), g.liveCondition(resource))
 	g.writeLine("")
 
 	// Add ID to values
-	values = append(values, fmt.Sprintf("%s.ID", receiverName))
+	values = append(values, g.keyValues(resource, receiverName)...)
 
 	// Execute UPDATE
 	g.writeLine("// Execute UPDATE")
@@ -454,6 +514,8 @@ func (g *Generator) generatePatch(resource *ast.ResourceNode) {
 	g.indent--
 	g.writeLine("}")
 	g.writeLine("")
+	g.generateCounterCacheUpdates(resource, receiverName, "+ 1")
+	g.generateDryRunReturn("update")
 
 	// Call AfterUpdate hook if it exists
 	if hasHook(resource, "after", "update") {
@@ -486,6 +548,8 @@ func (g *Generator) generatePatch(resource *ast.ResourceNode) {
 	g.writeLine("}")
 	g.writeLine("")
 
+	g.generateSearchIndexing(resource, receiverName, false)
+
 	g.writeLine("return nil")
 	g.indent--
 	g.writeLine("}")
@@ -522,18 +586,23 @@ func (g *Generator) generateDelete(resource *ast.ResourceNode) {
 	g.writeLine("defer tx.Rollback()")
 	g.writeLine("")
 
-	// 3. Execute DELETE
-	g.writeLine("query := `DELETE FROM %s WHERE id = $1`", g.toTableName(resource.Name))
-	g.writeLine("")
+	g.generateCounterCacheUpdates(resource, receiverName, "- 1")
+	// 3. Execute DELETE, or mark the record deleted for @changes and @soft_delete resources
+	if softDeleteField(resource) != nil {
+		g.generateSoftDelete(resource, receiverName)
+	} else {
+		g.writeLine("query := `DELETE FROM %s WHERE %s`", g.sqlTable(resource), g.keyCondition(resource, 1))
+		g.writeLine("")
 
-	g.writeLine("// Execute DELETE")
-	g.writeLine("_, err = tx.ExecContext(ctx, query, %s.ID)", receiverName)
-	g.writeLine("if err != nil {")
-	g.indent++
-	g.writeLine("return fmt.Errorf(\"failed to delete %s: %%w\", err)", strings.ToLower(resource.Name))
-	g.indent--
-	g.writeLine("}")
-	g.writeLine("")
+		g.writeLine("// Execute DELETE")
+		g.writeLine("_, err = tx.ExecContext(ctx, query, %s)", strings.Join(g.keyValues(resource, receiverName), ", "))
+		g.writeLine("if err != nil {")
+		g.indent++
+		g.writeLine("return fmt.Errorf(\"failed to delete %s: %%w\", err)", strings.ToLower(resource.Name))
+		g.indent--
+		g.writeLine("}")
+		g.writeLine("")
+	}
 
 	// 4. Call AfterDelete hook if it exists
 	if hasHook(resource, "after", "delete") {
@@ -555,6 +624,8 @@ func (g *Generator) generateDelete(resource *ast.ResourceNode) {
 	g.writeLine("}")
 	g.writeLine("")
 
+	g.generateSearchIndexing(resource, receiverName, true)
+
 	g.writeLine("return nil")
 	g.indent--
 	g.writeLine("}")
@@ -570,8 +641,8 @@ func (g *Generator) generateFindAll(resource *ast.ResourceNode) {
 	// Build SELECT query
 	columns, _ := g.buildSelectQuery(resource)
 
-	g.writeLine("query := `SELECT %s FROM %s ORDER BY id LIMIT $1 OFFSET $2`",
-		strings.Join(columns, ", "), g.toTableName(resource.Name))
+	g.writeLine("query := `SELECT %s FROM %s%s ORDER BY %s LIMIT $1 OFFSET $2`",
+		strings.Join(columns, ", "), g.sqlTable(resource), g.listWhere(resource), strings.Join(g.listOrder(resource), ", "))
 	g.writeLine("")
 
 	g.writeLine("rows, err := db.QueryContext(ctx, query, limit, offset)")
@@ -619,7 +690,7 @@ func (g *Generator) generateCount(resource *ast.ResourceNode) {
 	g.indent++
 
 	g.writeLine("var count int")
-	g.writeLine("query := `SELECT COUNT(*) FROM %s`", g.toTableName(resource.Name))
+	g.writeLine("query := `SELECT COUNT(*) FROM %s%s`", g.sqlTable(resource), g.listWhere(resource))
 	g.writeLine("")
 
 	g.writeLine("err := db.QueryRowContext(ctx, query).Scan(&count)")
@@ -642,15 +713,26 @@ func (g *Generator) buildInsertQuery(resource *ast.ResourceNode) (columns, place
 
 	for _, field := range resource.Fields {
 		// Skip database-generated ID fields (int with auto_increment)
-		// But include client-generated UUID IDs
-		if field.Name == "id" && hasConstraint(field, "auto") && field.Type.Name != "uuid" {
+		// But include UUID, ULID and @id strategy IDs generated by Create()
+		if field == keyField(resource) && needsAutoID(resource) {
+			continue
+		}
+		// @counter_cache columns start at their default and are only
+		// adjusted by the counted resource
+		if field.IsCounterCache() {
 			continue
 		}
 
-		columnName := g.toDBColumnName(field.Name)
+		columnName := g.fieldColumnName(field)
 		columns = append(columns, columnName)
 		placeholders = append(placeholders, fmt.Sprintf("$%d", paramNum))
 		values = append(values, fmt.Sprintf("%s.%s", receiverName, g.toGoFieldName(field.Name)))
+
+		// @dual_write copies the value into the legacy column with the same parameter
+		if legacy, _ := field.DualWrite(); legacy != "" {
+			columns = append(columns, legacy)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", paramNum))
+		}
 		paramNum++
 	}
 
@@ -661,16 +743,18 @@ func (g *Generator) buildInsertQuery(resource *ast.ResourceNode) (columns, place
 func (g *Generator) buildSelectQuery(resource *ast.ResourceNode) (columns, scanTargets []string) {
 	receiverName := strings.ToLower(resource.Name[0:1])
 
-	// Always include ID
-	columns = append(columns, "id")
-	scanTargets = append(scanTargets, fmt.Sprintf("&%s.ID", receiverName))
+	// Always include the primary key first
+	columns = append(columns, g.keyColumns(resource)...)
+	for _, value := range g.keyValues(resource, receiverName) {
+		scanTargets = append(scanTargets, "&"+value)
+	}
 
 	for _, field := range resource.Fields {
-		if field.Name == "id" {
+		if isKeyField(resource, field) {
 			continue // Already added
 		}
 
-		columnName := g.toDBColumnName(field.Name)
+		columnName := g.fieldColumnName(field)
 		columns = append(columns, columnName)
 		// Note: For nullable fields (pointer types), scanTargets will be **T (pointer-to-pointer).
 		// The database/sql package handles this correctly:
@@ -688,14 +772,30 @@ func (g *Generator) buildUpdateQuery(resource *ast.ResourceNode) (setClauses, va
 	paramNum := 1
 
 	for _, field := range resource.Fields {
-		// Skip ID field
-		if field.Name == "id" {
+		// Skip primary key fields, @counter_cache columns, the @orderable
+		// position, which only Create() and Move() set, deleted_at, which
+		// only Delete() sets, and archived_at, which only Archive() and
+		// Restore() set
+		if isKeyField(resource, field) || field.IsCounterCache() || field == positionField(resource) || field == softDeleteField(resource) || field == archivedField(resource) {
 			continue
 		}
 
-		columnName := g.toDBColumnName(field.Name)
+		columnName := g.fieldColumnName(field)
 		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", columnName, paramNum))
 		values = append(values, fmt.Sprintf("%s.%s", receiverName, g.toGoFieldName(field.Name)))
+
+		// A record that changes @orderable scope goes last in its new order
+		if field == orderScopeField(resource) {
+			position := g.fieldColumnName(positionField(resource))
+			setClauses = append(setClauses, fmt.Sprintf(
+				"%s = CASE WHEN %s = $%d THEN %s ELSE (SELECT COALESCE(MAX(%s), 0) + %d FROM %s WHERE %s = $%d) END",
+				position, columnName, paramNum, position, position, PositionGap, g.sqlTable(resource), columnName, paramNum))
+		}
+
+		// @dual_write copies the value into the legacy column with the same parameter
+		if legacy, _ := field.DualWrite(); legacy != "" {
+			setClauses = append(setClauses, fmt.Sprintf("%s = $%d", legacy, paramNum))
+		}
 		paramNum++
 	}
 
@@ -707,15 +807,28 @@ func (g *Generator) generateAutoFields(resource *ast.ResourceNode, operation str
 	receiverName := strings.ToLower(resource.Name[0:1])
 
 	for _, field := range resource.Fields {
+		if operation == "create" && field.Name == "id" && resource.IDStrategy != nil {
+			g.generateStrategyID(resource, receiverName)
+			continue
+		}
+
 		if operation == "create" && hasConstraint(field, "auto") {
 			switch field.Type.Name {
 			case "uuid":
 				g.writeLine("%s.%s = uuid.New()", receiverName, g.toGoFieldName(field.Name))
+			case "ulid":
+				g.writeLine("%s.%s = ids.NewULID()", receiverName, g.toGoFieldName(field.Name))
 			case "timestamp":
 				g.writeLine("%s.%s = time.Now()", receiverName, g.toGoFieldName(field.Name))
 			}
 		}
 
+		// A new record was last modified when it was created; the change feed
+		// and list Last-Modified headers read it
+		if operation == "create" && hasConstraint(field, "auto_update") && !hasConstraint(field, "auto") && field.Type.Name == "timestamp" {
+			g.writeLine("%s.%s = time.Now()", receiverName, g.toGoFieldName(field.Name))
+		}
+
 		if operation == "update" && hasConstraint(field, "auto_update") {
 			switch field.Type.Name {
 			case "timestamp":
@@ -757,26 +870,29 @@ func hasAutoUpdateFields(resource *ast.ResourceNode) bool {
 
 // needsAutoID checks if the resource needs an auto-generated ID
 func needsAutoID(resource *ast.ResourceNode) bool {
-	for _, field := range resource.Fields {
-		if field.Name == "id" {
-			// If ID is explicitly defined but not auto, don't generate
-			if !hasConstraint(field, "auto") {
-				return false
-			}
-			// If ID is auto, check the type
-			if field.Type.Name == "uuid" {
-				return false // UUID is generated before insert
-			}
-			return true // Serial/auto-increment ID needs RETURNING
-		}
+	if hasImplicitID(resource) {
+		return true // Default ID needs RETURNING
+	}
+	// Composite keys are always supplied by the client
+	if hasCompositeKey(resource) {
+		return false
+	}
+	field := keyField(resource)
+	// If the key is explicitly defined but not auto, don't generate
+	if !hasConstraint(field, "auto") {
+		return false
+	}
+	// If the key is auto, check the type
+	if field.Type.Name == "uuid" || field.Type.Name == "ulid" || resource.IDStrategy != nil {
+		return false // Generated before insert
 	}
-	return true // Default ID needs RETURNING
+	return true // Serial/auto-increment ID needs RETURNING
 }
 
 // hasHook checks if a resource has a specific lifecycle hook
 func hasHook(resource *ast.ResourceNode, timing, event string) bool {
 	for _, hook := range resource.Hooks {
-		if hook.Timing == timing && hook.Event == event {
+		if hook.Timing == timing && hook.Event == event && !hook.Batch {
 			return true
 		}
 	}
@@ -0,0 +1,54 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// hasDefaultScope reports whether any resource declares @default_scope
+func hasDefaultScope(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if scoped(resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// scoped reports whether resource declares a @default_scope its queries apply
+func scoped(resource *ast.ResourceNode) bool {
+	condition, err := resource.DefaultScopeSQL("")
+	return err == nil && condition != ""
+}
+
+// checkDefaultScope returns an error when the resource's @default_scope does
+// not translate to SQL. Generation stops there rather than emit list and show
+// queries without the scope, which would serve the records it hides.
+func checkDefaultScope(resource *ast.ResourceNode) error {
+	if _, err := resource.DefaultScopeSQL(""); err != nil {
+		return fmt.Errorf("codegen: @default_scope of %s cannot be generated: %w", resource.Name, err)
+	}
+	return nil
+}
+
+// defaultScope returns the SQL condition of the resource's @default_scope,
+// with columns prefixed by qualifier, or "" when it has none. Generation
+// checks the scope with checkDefaultScope first; a condition that still does
+// not translate panics instead of leaving the queries unscoped.
+func (g *Generator) defaultScope(resource *ast.ResourceNode, qualifier string) string {
+	condition, err := resource.DefaultScopeSQL(qualifier)
+	if err != nil {
+		panic(fmt.Sprintf("codegen: @default_scope of %s cannot be generated: %v", resource.Name, err))
+	}
+	return condition
+}
+
+// scopeCondition returns the SQL condition, prefixed with " AND ", that keeps
+// the rows in the default scope, or "" for resources without one
+func (g *Generator) scopeCondition(resource *ast.ResourceNode) string {
+	if condition := g.defaultScope(resource, ""); condition != "" {
+		return " AND " + condition
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// defaultScopeSource declares a soft-deleted Post scoped to published, live
+// records
+const defaultScopeSource = `resource Post {
+  @soft_delete
+  @default_scope { self.published and self.deleted_at == null }
+
+  id: uuid! @primary @auto
+  title: string!
+  published: bool!
+  deleted_at: timestamp?
+}`
+
+func TestGenerateResource_DefaultScope(t *testing.T) {
+	code := generateModel(t, parseResource(t, defaultScopeSource))
+
+	const scope = "(published IS TRUE AND deleted_at IS NULL)"
+	finds := []struct {
+		function string
+		where    string
+	}{
+		{"func FindPostByID(", "WHERE id = $1 AND deleted_at IS NULL AND " + scope + "`"},
+		{"func FindPostByIDWithDeleted(", "WHERE id = $1 AND " + scope + "`"},
+		{"func FindPostByIDUnscoped(", "WHERE id = $1 AND deleted_at IS NULL`"},
+		{"func FindPostByIDUnscopedWithDeleted(", "WHERE id = $1`"},
+	}
+	for _, tt := range finds {
+		if body := functionBody(t, code, tt.function); !strings.Contains(body, tt.where) {
+			t.Errorf("%s missing %q:\n%s", tt.function, tt.where, body)
+		}
+	}
+
+	if !strings.Contains(code, "FROM posts WHERE deleted_at IS NULL AND "+scope+" ORDER BY id") {
+		t.Error("FindAllPost should apply the default scope")
+	}
+	if !strings.Contains(code, "SELECT COUNT(*) FROM posts WHERE deleted_at IS NULL AND "+scope) {
+		t.Error("CountPost should apply the default scope")
+	}
+}
+
+func TestGenerateHandlers_DefaultScope(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, defaultScopeSource))
+	if !strings.Contains(code, `"github.com/conduit-lang/conduit/pkg/web/scope"`) {
+		t.Error("Handlers should import the scope package")
+	}
+
+	list := functionBody(t, code, "func ListPostHandler(db *sql.DB) http.HandlerFunc {")
+	assertContains(t, list,
+		"unscoped, err := scope.Unscoped(r)",
+		"response.RenderJSONAPIError(w, scope.Status(err), err)",
+		`Scope("(posts.published IS TRUE AND posts.deleted_at IS NULL)", unscoped).`,
+	)
+
+	get := functionBody(t, code, "func GetPostHandler(db *sql.DB) http.HandlerFunc {")
+	assertContains(t, get,
+		"unscoped, err := scope.Unscoped(r)",
+		"respondWithError(w, err.Error(), scope.Status(err))",
+		"find = models.FindPostByIDUnscoped",
+		"find = models.FindPostByIDWithDeleted",
+		"find = models.FindPostByIDUnscopedWithDeleted",
+		"result, err := find(ctx, db, id)",
+	)
+
+	// Resources without a default scope take no unscoped
+	code = generateHandlers(t, parseResource(t, searchSource))
+	if strings.Contains(code, "scope.Unscoped") || strings.Contains(code, "pkg/web/scope") {
+		t.Error("Resources without a default scope should not accept unscoped")
+	}
+}
+
+func TestGenerate_UntranslatableDefaultScope(t *testing.T) {
+	resource := parseResource(t, defaultScopeSource)
+	// A bare string field is not a condition, so it has no SQL
+	resource.DefaultScope.Condition = &ast.FieldAccessExpr{Object: &ast.SelfExpr{}, Field: "title"}
+
+	if code, err := NewGenerator().GenerateResource(resource); err == nil || !strings.Contains(err.Error(), "@default_scope of Post") {
+		t.Errorf("GenerateResource() error = %v, want generation to stop\n%s", err, code)
+	}
+	if code, err := NewGenerator().GenerateHandlers([]*ast.ResourceNode{resource}, "example.com/blog"); err == nil || !strings.Contains(err.Error(), "@default_scope of Post") {
+		t.Errorf("GenerateHandlers() error = %v, want generation to stop\n%s", err, code)
+	}
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "@default_scope of Post") {
+			t.Errorf("defaultScope() recovered %v, want a panic naming the resource", r)
+		}
+	}()
+	NewGenerator().defaultScope(resource, "")
+}
+
+func TestGeneratedDefaultScope_Runs(t *testing.T) {
+	t.Parallel()
+	runGenerated(t, defaultScopeSource, map[string]string{"handlers/default_scope_test.go": `package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"example.com/app/handlers"
+)
+
+func TestGetIsScoped(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	router := chi.NewRouter()
+	handlers.RegisterPostRoutes(router, db)
+
+	id := uuid.New()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM posts WHERE id = $1 AND deleted_at IS NULL AND (published IS TRUE AND deleted_at IS NULL)")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "published", "deleted_at"}).AddRow(id, "Hello", true, nil))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts/"+id.String(), nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Hello") {
+		t.Errorf("GET = %d: %s", rec.Code, rec.Body)
+	}
+
+	// Only admins see past the scope, and anonymous callers are refused
+	// before anything is read
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts/"+id.String()+"?unscoped=true", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("GET ?unscoped=true = %d, want %d: %s", rec.Code, http.StatusForbidden, rec.Body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+`})
+}
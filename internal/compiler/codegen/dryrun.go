@@ -0,0 +1,27 @@
+package codegen
+
+// generateDryRunReturn ends a create or update whose write succeeded when the
+// context belongs to a dry run (?dry_run=true), before the after hooks run;
+// the deferred rollback undoes the write
+func (g *Generator) generateDryRunReturn(write string) {
+	g.writeLine("// A dry run stops before the after hooks; the deferred rollback undoes the %s", write)
+	g.writeLine("if dryrun.Enabled(ctx) {")
+	g.indent++
+	g.writeLine("return nil")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
+
+// generateDryRunRequest marks the context of a write handler as a dry run when
+// the request has ?dry_run=true, so the model rolls the write back
+func (g *Generator) generateDryRunRequest(operation string) {
+	g.writeLine("// ?dry_run=true runs the %s and rolls it back, responding with the result", operation)
+	g.writeLine("if dryrun.Requested(r) {")
+	g.indent++
+	g.writeLine("ctx = dryrun.With(ctx)")
+	g.writeLine("w.Header().Set(dryrun.Header, \"true\")")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
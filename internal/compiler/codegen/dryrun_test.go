@@ -0,0 +1,104 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const dryRunSource = `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+
+  @before create {
+    self.title = self.title
+  }
+
+  @after create {
+    self.title = self.title
+  }
+
+  @after update {
+    self.title = self.title
+  }
+}`
+
+func TestGenerateResource_DryRun(t *testing.T) {
+	code, err := NewGenerator().GenerateResourceWithHooks(parseResource(t, dryRunSource))
+	if err != nil {
+		t.Fatalf("GenerateResourceWithHooks failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+	if !strings.Contains(code, `"github.com/conduit-lang/conduit/pkg/web/dryrun"`) {
+		t.Error("Missing dryrun import")
+	}
+
+	// Dry runs return after the write and before the after hooks and the commit
+	tests := []struct {
+		signature string
+		write     string
+		after     string
+	}{
+		{"func (p *Post) Create(ctx context.Context, db *sql.DB) error {", "// Execute INSERT", "p.AfterCreate(ctx, tx)"},
+		{"func (p *Post) Update(ctx context.Context, db *sql.DB) error {", "// Execute UPDATE", "p.AfterUpdate(ctx, tx)"},
+		{"func (p *Post) Patch(ctx context.Context, db *sql.DB, partialJSON []byte) error {", "// Execute UPDATE", "p.AfterUpdate(ctx, tx)"},
+	}
+	for _, tt := range tests {
+		body := functionBody(t, code, tt.signature)
+		dryRun := strings.Index(body, "if dryrun.Enabled(ctx) {")
+		if dryRun < 0 {
+			t.Errorf("Missing dry run return:\n%s", body)
+			continue
+		}
+		if dryRun < strings.Index(body, tt.write) {
+			t.Errorf("The dry run should return after the write:\n%s", body)
+		}
+		if dryRun > strings.Index(body, tt.after) || dryRun > strings.Index(body, "tx.Commit()") {
+			t.Errorf("The dry run should return before the after hooks and the commit:\n%s", body)
+		}
+		if !strings.Contains(body, "defer tx.Rollback()") {
+			t.Errorf("The write should be rolled back:\n%s", body)
+		}
+	}
+}
+
+func TestGenerateResource_DryRunBatchHooks(t *testing.T) {
+	resource := parseResource(t, batchSource)
+	resource.Hooks = append(resource.Hooks, &ast.HookNode{Timing: "after", Event: "create", Batch: true})
+
+	code, err := NewGenerator().GenerateResourceWithHooks(resource)
+	if err != nil {
+		t.Fatalf("GenerateResourceWithHooks failed: %v", err)
+	}
+
+	create := functionBody(t, code, "func (p *Post) Create(ctx context.Context, db *sql.DB) error {")
+	dryRun := strings.Index(create, "if dryrun.Enabled(ctx) {")
+	if dryRun < 0 || dryRun > strings.Index(create, "AfterCreatePostBatch") {
+		t.Errorf("Dry runs should skip the after batch hook:\n%s", create)
+	}
+}
+
+func TestGenerateHandlers_DryRun(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, dryRunSource))
+
+	for _, handler := range []string{"CreatePostHandler", "UpdatePostHandler", "PatchPostHandler"} {
+		body := functionBody(t, code, "func "+handler+"(db *sql.DB) http.HandlerFunc {")
+		assertContains(t, body,
+			"if dryrun.Requested(r) {",
+			"ctx = dryrun.With(ctx)",
+			"w.Header().Set(dryrun.Header, \"true\")",
+		)
+	}
+
+	// Dry run creates respond 200 without a Location
+	create := functionBody(t, code, "func CreatePostHandler(db *sql.DB) http.HandlerFunc {")
+	assertContains(t, create,
+		"response.RenderJSONAPI(w, dryrun.Status(ctx, http.StatusCreated), &p)",
+		"w.WriteHeader(dryrun.Status(ctx, http.StatusCreated))",
+		"if !dryrun.Enabled(ctx) {\n\t\t\t\tw.Header().Set(\"Location\"",
+	)
+}
@@ -0,0 +1,102 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// enumFields returns the enum fields of resource
+func enumFields(resource *ast.ResourceNode) []*ast.FieldNode {
+	var fields []*ast.FieldNode
+	for _, field := range resource.Fields {
+		if field.Type != nil && field.Type.Kind == ast.TypeEnum {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// hasEnums reports whether any resource has an enum field, which the
+// generated application lists on enums.Path
+func hasEnums(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if len(enumFields(resource)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLabels reports whether resource declares @labels on an enum field
+func hasLabels(resource *ast.ResourceNode) bool {
+	for _, field := range enumFields(resource) {
+		if field.Labels != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// generateEnumCatalog generates Enums, the enum fields of every resource with
+// the display labels of their values
+func (g *Generator) generateEnumCatalog(resources []*ast.ResourceNode) {
+	g.writeLine("// Enums lists the enum fields of each resource with their display labels (@labels)")
+	g.writeLine("var Enums = enums.Catalog{")
+	g.indent++
+	for _, resource := range resources {
+		fields := enumFields(resource)
+		if len(fields) == 0 {
+			continue
+		}
+		g.writeLine("%q: {", resource.Name)
+		g.indent++
+		for _, field := range fields {
+			g.writeLine("%q: %s,", g.jsonName(field.Name), g.enumLiteral(field))
+		}
+		g.indent--
+		g.writeLine("},")
+	}
+	g.indent--
+	g.writeLine("}")
+}
+
+// enumLiteral returns the enums.Enum literal of an enum field; labeled fields
+// carry the key their labels are added under, cased like the field
+func (g *Generator) enumLiteral(field *ast.FieldNode) string {
+	literal := fmt.Sprintf("{Values: %s", g.stringSliceLiteral(field.Type.EnumValues))
+	if field.Labels != nil {
+		values := make([]string, 0, len(field.Labels.Values))
+		for value := range field.Labels.Values {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		pairs := ""
+		for i, value := range values {
+			if i > 0 {
+				pairs += ", "
+			}
+			pairs += fmt.Sprintf("%q: %q", value, field.Labels.Values[value])
+		}
+		literal += fmt.Sprintf(", Labels: map[string]string{%s}, LabelKey: %q", pairs, g.jsonName(field.Name+"_label"))
+	}
+	return literal + "}"
+}
+
+// generateLabelSet generates the package-level enums.Labels of a resource
+// with @labels
+func (g *Generator) generateLabelSet(resource *ast.ResourceNode) {
+	g.writeLine("// %s holds the display labels of %s enum fields, added with ?include_labels=true", g.resourceVarName(resource, "Labels"), resource.Name)
+	g.writeLine("var %s = Enums.Labels(%q)", g.resourceVarName(resource, "Labels"), resource.Name)
+}
+
+// labeledRecord returns the expression a handler encodes as legacy JSON for
+// record: the record itself, or the record with the labels of its enum
+// fields when the request asks for them
+func (g *Generator) labeledRecord(resource *ast.ResourceNode, record string) string {
+	if !hasLabels(resource) {
+		return record
+	}
+	return fmt.Sprintf("response.Labeled(%s, %s.For(r))", record, g.resourceVarName(resource, "Labels"))
+}
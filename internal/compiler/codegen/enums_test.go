@@ -0,0 +1,85 @@
+package codegen
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/pkg/web/query"
+)
+
+const labeledSource = `resource Post {
+  id: int! @primary @auto
+  title: string!
+  review_state: enum["draft", "in-review"]! @labels(draft: "Draft", "in-review": "In review")
+  kind: enum["note", "article"]?
+}`
+
+func TestGenerateHandlers_Labels(t *testing.T) {
+	gen := NewGenerator()
+	code, err := gen.GenerateHandlers([]*ast.ResourceNode{parseResource(t, labeledSource)}, "example.com/blog")
+	if err != nil {
+		t.Fatalf("GenerateHandlers failed: %v", err)
+	}
+
+	for _, exp := range []string{
+		`"github.com/conduit-lang/conduit/pkg/web/enums"`,
+		"var Enums = enums.Catalog{",
+		`"review_state": {Values: []string{"draft", "in-review"}, Labels: map[string]string{"draft": "Draft", "in-review": "In review"}, LabelKey: "review_state_label"},`,
+		`"kind": {Values: []string{"note", "article"}},`,
+		`var postLabels = Enums.Labels("Post")`,
+		"stream.Label(postLabels.For(r))",
+		"json.NewEncoder(w).Encode(response.Labeled(result, postLabels.For(r)))",
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated handlers missing %q", exp)
+		}
+	}
+
+	// Label keys follow the serialization casing, like the fields
+	gen = NewGenerator()
+	gen.SetSerialization(SerializationOptions{Casing: query.CamelCase})
+	code, err = gen.GenerateHandlers([]*ast.ResourceNode{parseResource(t, labeledSource)}, "example.com/blog")
+	if err != nil {
+		t.Fatalf("GenerateHandlers failed: %v", err)
+	}
+	if !strings.Contains(code, `"reviewState": {`) || !strings.Contains(code, `LabelKey: "reviewStateLabel"`) {
+		t.Error("Generated catalog should use camelCase keys")
+	}
+}
+
+func TestGenerateHandlers_WithoutLabels(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, searchSource))
+	for _, unexpected := range []string{"pkg/web/enums", "Enums", "response.Labeled", "stream.Label"} {
+		if strings.Contains(code, unexpected) {
+			t.Errorf("Generated handlers without enums should not contain %q", unexpected)
+		}
+	}
+}
+
+func TestGenerateMain_Enums(t *testing.T) {
+	code, err := NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, labeledSource)}, "example.com/blog", "/api/v1")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+
+	mount := "r.Get(enums.Path, enums.Handler(handlers.Enums))"
+	if !strings.Contains(code, mount) {
+		t.Fatalf("Generated main missing %q", mount)
+	}
+	// The catalog is API data, served under the prefix with the resources
+	if strings.Index(code, mount) < strings.Index(code, `r.Route("/api/v1"`) {
+		t.Error("the enum catalog should be mounted under the API prefix")
+	}
+}
+
+func TestGenerateResource_EnumFields(t *testing.T) {
+	code := generateModel(t, parseResource(t, labeledSource))
+	// Enum values are stored and exchanged as strings
+	for _, exp := range []string{`ReviewState\s+string`, `Kind\s+\*string`} {
+		if !regexp.MustCompile(exp).MatchString(code) {
+			t.Errorf("Generated model missing %s", exp)
+		}
+	}
+}
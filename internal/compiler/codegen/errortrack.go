@@ -0,0 +1,80 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// ErrorTrackingOptions controls the error tracker configured by the generated
+// main for conduit.yaml's error_tracking section. The DSN is read from
+// SENTRY_DSN at startup; without it tracking stays off.
+type ErrorTrackingOptions struct {
+	// Enabled reports panics and hook errors to the tracker
+	Enabled bool
+	// Environment events are tagged with, e.g. production
+	Environment string
+	// Release events are tagged with; the git commit, or else the source
+	// hash, of the build when empty
+	Release string
+	// Files are the .cdt files resources are declared in, keyed by resource
+	// name, for the source locations of events
+	Files map[string]string
+}
+
+// SetErrorTracking configures the error tracking generated by GenerateProgram
+func (g *Generator) SetErrorTracking(opts ErrorTrackingOptions) {
+	g.errorTracking = opts
+}
+
+// errorTrackingRelease returns the release of generated events
+func (g *Generator) errorTrackingRelease() string {
+	switch {
+	case g.errorTracking.Release != "":
+		return g.errorTracking.Release
+	case g.build.GitCommit != "":
+		return g.build.GitCommit
+	case len(g.build.SourceHash) > 12:
+		return g.build.SourceHash[:12]
+	}
+	return g.build.SourceHash
+}
+
+// sourceLocation returns the .cdt location of a line of resource, e.g.
+// app/post.cdt:20, or just the line when the file is unknown
+func (g *Generator) sourceLocation(resource *ast.ResourceNode, line int) string {
+	file := g.errorTracking.Files[resource.Name]
+	if file == "" {
+		return fmt.Sprintf("line %d", line)
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// generateErrorTrackingConfig sets up the tracker before anything that can
+// panic runs, and the resource locations reported with panics in handlers
+func (g *Generator) generateErrorTrackingConfig(resources []*ast.ResourceNode) {
+	g.writeLine("// Report panics and hook errors to the tracker at SENTRY_DSN, when it is set")
+	g.writeLine("if err := errortrack.Configure(errortrack.Config{")
+	g.indent++
+	g.writeLine("Environment: %q,", g.errorTracking.Environment)
+	g.writeLine("Release:     %q,", g.errorTrackingRelease())
+	g.indent--
+	g.writeLine("}); err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(%q, err)", "Failed to configure error tracking: %v")
+	g.indent--
+	g.writeLine("}")
+
+	sorted := make([]*ast.ResourceNode, len(resources))
+	copy(sorted, resources)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	g.writeLine("errortrack.SetSources(map[string]string{")
+	g.indent++
+	for _, resource := range sorted {
+		g.writeLine("%q: %q,", resource.Name, g.sourceLocation(resource, resource.Loc.Line))
+	}
+	g.indent--
+	g.writeLine("})")
+	g.writeLine("")
+}
@@ -0,0 +1,146 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+func TestGenerateMain_ErrorTracking(t *testing.T) {
+	resource := parseResource(t, authSource)
+	resource.Loc = ast.SourceLocation{Line: 3, Column: 1}
+
+	// Disabled by default
+	code, err := NewGenerator().GenerateMain([]*ast.ResourceNode{resource}, "example.com/app", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "errortrack") {
+		t.Error("Generated main should not track errors unless enabled")
+	}
+
+	g := NewGenerator()
+	g.SetBuildInfo(BuildInfoOptions{GitCommit: "abc1234"})
+	g.SetErrorTracking(ErrorTrackingOptions{
+		Enabled:     true,
+		Environment: "production",
+		Files:       map[string]string{resource.Name: "app/user.cdt"},
+	})
+	code, err = g.GenerateMain([]*ast.ResourceNode{resource}, "example.com/app", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated main does not parse: %v\n%s", err, code)
+	}
+
+	assertContains(t, code,
+		`"github.com/conduit-lang/conduit/pkg/web/errortrack"`,
+		`Environment: "production",`,
+		`Release:     "abc1234",`,
+		`log.Fatalf("Failed to configure error tracking: %v", err)`,
+		`"User": "app/user.cdt:3",`,
+		"errortrack.Flush(errortrack.FlushTimeout)",
+	)
+
+	// Panics are reported before Recoverer turns them into a 500, and the
+	// tracker is set up before anything is served
+	configure := strings.Index(code, "errortrack.Configure(")
+	recoverer := strings.Index(code, "r.Use(middleware.Recoverer)")
+	middleware := strings.Index(code, "r.Use(errortrack.Middleware)")
+	if !(configure < recoverer && recoverer < middleware) {
+		t.Errorf("Configure %d, Recoverer %d, errortrack.Middleware %d", configure, recoverer, middleware)
+	}
+
+	// Events are sent before the process exits, whether or not the server failed
+	if strings.Count(code, "errortrack.Flush(errortrack.FlushTimeout)") != 2 {
+		t.Error("Generated main should flush events on both exits")
+	}
+}
+
+func TestErrorTrackingRelease(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  ErrorTrackingOptions
+		build BuildInfoOptions
+		want  string
+	}{
+		{"configured", ErrorTrackingOptions{Release: "v1.2.0"}, BuildInfoOptions{GitCommit: "abc1234"}, "v1.2.0"},
+		{"git commit", ErrorTrackingOptions{}, BuildInfoOptions{GitCommit: "abc1234", SourceHash: "0123456789abcdef"}, "abc1234"},
+		{"source hash", ErrorTrackingOptions{}, BuildInfoOptions{SourceHash: "0123456789abcdef"}, "0123456789ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator()
+			g.SetErrorTracking(tt.opts)
+			g.SetBuildInfo(tt.build)
+			if got := g.errorTrackingRelease(); got != tt.want {
+				t.Errorf("errorTrackingRelease() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateHooks_ErrorTracking(t *testing.T) {
+	title := &ast.AssignmentStmt{
+		Target: &ast.FieldAccessExpr{Object: &ast.SelfExpr{}, Field: "title"},
+		Value:  &ast.FieldAccessExpr{Object: &ast.SelfExpr{}, Field: "title"},
+	}
+	resource := parseResource(t, postSource)
+	resource.Hooks = []*ast.HookNode{
+		{Timing: "before", Event: "create", Body: []ast.StmtNode{title},
+			Timeout: 2 * time.Second,
+			OnError: &ast.OnErrorNode{Policy: ast.HookPolicyWarn},
+			Loc:     ast.SourceLocation{Line: 12}},
+		{Timing: "after", Event: "create", Loc: ast.SourceLocation{Line: 20},
+			Body: []ast.StmtNode{&ast.BlockStmt{IsAsync: true, Statements: []ast.StmtNode{title}}}},
+		{Timing: "after", Event: "create", Batch: true, Loc: ast.SourceLocation{Line: 30}},
+	}
+
+	g := NewGenerator()
+	g.SetErrorTracking(ErrorTrackingOptions{Enabled: true, Files: map[string]string{"Post": "app/post.cdt"}})
+	code, err := g.GenerateResourceWithHooks(resource)
+	if err != nil {
+		t.Fatalf("GenerateResourceWithHooks failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+	if !strings.Contains(code, `"github.com/conduit-lang/conduit/pkg/web/errortrack"`) {
+		t.Error("Missing errortrack import")
+	}
+
+	// Tracking wraps the timeout and the failure policy, so it reports the
+	// error the operation fails with and sees the warnings it swallows
+	create := functionBody(t, code, "func (p *Post) BeforeCreate(ctx context.Context, db *sql.DB) error {")
+	want := `return errortrack.Hook(ctx, "Post.BeforeCreate", "app/post.cdt:12", func(ctx context.Context) error {`
+	if !strings.Contains(create, want) || strings.Index(create, want) > strings.Index(create, "hooks.WithTimeout") {
+		t.Errorf("BeforeCreate should run under errortrack.Hook first:\n%s", create)
+	}
+	if strings.Index(create, "hooks.WithTimeout") > strings.Index(create, "hooks.Run") {
+		t.Errorf("The timeout should still wrap the retries:\n%s", create)
+	}
+
+	// Panics in @async blocks are reported before they take the process down
+	after := functionBody(t, code, "func (p *Post) AfterCreate(ctx context.Context, db *sql.DB) error {")
+	if !strings.Contains(after, `defer errortrack.Recover(ctx, "Post.AfterCreate", "app/post.cdt:20")`) {
+		t.Errorf("The async block should report its panics:\n%s", after)
+	}
+
+	batch := functionBody(t, code, "func AfterCreatePostBatch(ctx context.Context, db *sql.DB, records []*Post) error {")
+	if !strings.Contains(batch, `return errortrack.Hook(ctx, "AfterCreatePostBatch", "app/post.cdt:30", func(ctx context.Context) error {`) {
+		t.Errorf("Batch hooks should be tracked too:\n%s", batch)
+	}
+
+	// Without error tracking hooks run as before
+	code, err = NewGenerator().GenerateResourceWithHooks(resource)
+	if err != nil {
+		t.Fatalf("GenerateResourceWithHooks failed: %v", err)
+	}
+	if strings.Contains(code, "errortrack") {
+		t.Error("Hooks should not be tracked unless enabled")
+	}
+}
@@ -0,0 +1,28 @@
+package codegen
+
+// generateListExplain explains the list query when the request has
+// ?explain=true in development, into a plan variable the handler adds to meta
+func (g *Generator) generateListExplain(resourceLower string) {
+	g.writeLine("// ?explain=true adds the SQL and its plan to meta (development only)")
+	g.writeLine("var plan *explain.Plan")
+	g.writeLine("if explain.Requested(r) {")
+	g.indent++
+	g.writeLine("plan, err = explain.Query(ctx, db, listQuery, args...)")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to explain %s query: %%v\", err))", resourceLower)
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to explain %s query: %%v\", err), http.StatusInternalServerError)", resourceLower)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
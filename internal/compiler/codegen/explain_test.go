@@ -0,0 +1,27 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateListHandler_Explain(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, postSource))
+	if !strings.Contains(code, `"github.com/conduit-lang/conduit/pkg/web/explain"`) {
+		t.Error("Missing explain import")
+	}
+
+	list := functionBody(t, code, "func ListPostHandler(db *sql.DB) http.HandlerFunc {")
+	assertContains(t, list,
+		"if explain.Requested(r) {",
+		"plan, err = explain.Query(ctx, db, listQuery, args...)",
+		"stream.Wrap()",
+		`meta["explain"] = plan`,
+	)
+
+	// The plan is of the query built from the request, and read before any row is streamed
+	explained := strings.Index(list, "explain.Query")
+	if explained < strings.Index(list, "qb.Build()") || explained > strings.Index(list, "db.QueryContext(ctx, listQuery") {
+		t.Errorf("The list query should be explained once built, before it runs:\n%s", list)
+	}
+}
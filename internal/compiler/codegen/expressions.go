@@ -351,6 +351,23 @@ func (g *Generator) generateStdlibCall(call *ast.CallExpr) string {
 		// Custom implementation - parse UUID string (returns nil on error)
 		return fmt.Sprintf("stdlib.UUIDParse(%s)", argsStr)
 
+	// ============================================================================
+	// Mail namespace - templated email, sent from @async hooks
+	// ============================================================================
+	case "Mail.send":
+		g.imports["github.com/conduit-lang/conduit/pkg/web/mail"] = true
+		if len(args) == 2 {
+			return fmt.Sprintf("mail.SendContext(ctx, %s, nil)", argsStr)
+		}
+		return fmt.Sprintf("mail.SendContext(ctx, %s)", argsStr)
+
+	// ============================================================================
+	// Notify namespace - SMS and push notifications, queued for delivery
+	// ============================================================================
+	case "Notify.send":
+		g.imports["github.com/conduit-lang/conduit/pkg/web/notify"] = true
+		return fmt.Sprintf("notify.SendContext(ctx, %s)", argsStr)
+
 	// ============================================================================
 	// Random namespace - random value generation
 	// ============================================================================
@@ -554,6 +571,10 @@ func (g *Generator) generateHashLiteral(hash *ast.HashLiteralExpr) string {
 	pairs := make([]string, len(hash.Pairs))
 	for i, pair := range hash.Pairs {
 		key := g.generateExpr(pair.Key)
+		if ident, ok := pair.Key.(*ast.IdentifierExpr); ok {
+			// Bare keys ({name: x}) are names, not variables
+			key = fmt.Sprintf("%q", ident.Name)
+		}
 		value := g.generateExpr(pair.Value)
 		pairs[i] = fmt.Sprintf("%s: %s", key, value)
 	}
@@ -441,6 +441,18 @@ func TestGenerateHashLiteral(t *testing.T) {
 			},
 			contains: []string{"map[string]interface{}", `"name": "John"`, `"age": 30`},
 		},
+		{
+			name: "hash with bare keys",
+			expr: &ast.HashLiteralExpr{
+				Pairs: []ast.HashPair{
+					{
+						Key:   &ast.IdentifierExpr{Name: "name"},
+						Value: &ast.LiteralExpr{Value: "John"},
+					},
+				},
+			},
+			contains: []string{`map[string]interface{}{"name": "John"}`},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,66 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const externalSource = `resource LegacyUser {
+  @external_table("legacy.users")
+
+  id: int! @primary
+  email: string!
+}`
+
+func TestGenerateMigrations_External(t *testing.T) {
+	sql, err := NewGenerator().GenerateMigrations([]*ast.ResourceNode{parseResource(t, externalSource), parseResource(t, postSource)})
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+	if strings.Contains(sql, "legacy") {
+		t.Errorf("Migration should not touch the external table:\n%s", sql)
+	}
+	if !strings.Contains(sql, "CREATE TABLE posts (") {
+		t.Errorf("Migration missing the posts table:\n%s", sql)
+	}
+}
+
+func TestGenerateResource_External(t *testing.T) {
+	code := generateModel(t, parseResource(t, externalSource))
+	assertContains(t, code,
+		`return "legacy.users"`,
+		"FROM legacy.users WHERE id = $1",
+	)
+	if strings.Contains(code, "CreatedAt") {
+		t.Error("External resources should not get timestamps")
+	}
+}
+
+func TestGenerateHandlers_External(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, externalSource))
+
+	assertContains(t, code,
+		`query.NewMappedBuilder("legacy.users", `,
+		`r.Get("/legacyusers", ListLegacyUserHandler(db))`,
+		`r.Get("/legacyusers/{id}", GetLegacyUserHandler(db))`,
+	)
+	for _, unwanted := range []string{"r.Post(", "r.Put(", "r.Patch(", "r.Delete("} {
+		if strings.Contains(code, unwanted) {
+			t.Errorf("External resources should only have read routes, found %q", unwanted)
+		}
+	}
+}
+
+func TestGenerateMain_External(t *testing.T) {
+	gen := NewGenerator()
+	gen.SetPreflight(PreflightOptions{Enabled: true})
+	code, err := gen.GenerateMain([]*ast.ResourceNode{parseResource(t, externalSource)}, "example.com/legacy", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if !strings.Contains(code, `{Name: "legacy.users", Columns: `) {
+		t.Errorf("Preflight should check the external table:\n%s", code)
+	}
+}
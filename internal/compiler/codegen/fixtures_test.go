@@ -0,0 +1,174 @@
+package codegen
+
+import (
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+	"github.com/conduit-lang/conduit/internal/compiler/parser"
+)
+
+// postSource is the smallest resource most generator tests start from
+const postSource = `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+}`
+
+// parseProgram parses Conduit source, failing the test on any lexer or
+// parser error.
+func parseProgram(t *testing.T, source string) *ast.Program {
+	t.Helper()
+	tokens, lexErrors := lexer.New(source).ScanTokens()
+	if len(lexErrors) > 0 {
+		t.Fatalf("lexer errors: %v\n%s", lexErrors, source)
+	}
+	program, parseErrors := parser.New(tokens).Parse()
+	if len(parseErrors) > 0 {
+		t.Fatalf("parse errors: %v\n%s", parseErrors, source)
+	}
+	return program
+}
+
+// parseResources parses Conduit source into its resources, in the order
+// they are declared.
+func parseResources(t *testing.T, source string) []*ast.ResourceNode {
+	t.Helper()
+	return parseProgram(t, source).Resources
+}
+
+// parseResource parses Conduit source declaring a single resource.
+func parseResource(t *testing.T, source string) *ast.ResourceNode {
+	t.Helper()
+	resources := parseResources(t, source)
+	if len(resources) != 1 {
+		t.Fatalf("expected one resource, got %d\n%s", len(resources), source)
+	}
+	return resources[0]
+}
+
+// generateModel generates the model of a resource, failing the test unless
+// generation succeeds and the code parses as Go.
+func generateModel(t *testing.T, resource *ast.ResourceNode) string {
+	t.Helper()
+	code, err := NewGenerator().GenerateResource(resource)
+	if err != nil {
+		t.Fatalf("GenerateResource failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+	return code
+}
+
+// generateHandlers generates the handlers of resources, failing the test
+// unless generation succeeds and the code parses as Go.
+func generateHandlers(t *testing.T, resources ...*ast.ResourceNode) string {
+	t.Helper()
+	code, err := NewGenerator().GenerateHandlers(resources, generatedModule)
+	if err != nil {
+		t.Fatalf("GenerateHandlers failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+	return code
+}
+
+// assertContains reports every wanted fragment missing from code.
+func assertContains(t *testing.T, code string, wants ...string) {
+	t.Helper()
+	for _, want := range wants {
+		if !strings.Contains(code, want) {
+			t.Errorf("Generated code missing %q:\n%s", want, code)
+		}
+	}
+}
+
+// functionBody returns the generated code from the function signature up to
+// the next top-level function.
+func functionBody(t *testing.T, code, signature string) string {
+	t.Helper()
+	start := strings.Index(code, signature)
+	if start < 0 {
+		t.Fatalf("Generated code missing %q", signature)
+	}
+	body := code[start:]
+	if end := strings.Index(body[len(signature):], "\nfunc "); end >= 0 {
+		body = body[:len(signature)+end]
+	}
+	return body
+}
+
+// generatedModule is the module name of programs runGenerated builds
+const generatedModule = "example.com/app"
+
+// runGenerated generates the program source declares, adds the test files
+// to it and runs them with go test, so tests can check what the generated
+// code does and not only how it reads. The module requires what this
+// repository does, go-sqlmock included, at the same versions.
+func runGenerated(t *testing.T, source string, tests map[string]string) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("compiling generated code is skipped in short mode")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	root, err := filepath.Abs(filepath.Join("..", "..", ".."))
+	if err != nil {
+		t.Fatalf("Failed to find the repository root: %v", err)
+	}
+	files, err := NewGenerator().GenerateProgram(parseProgram(t, source), generatedModule, root, "/api")
+	if err != nil {
+		t.Fatalf("GenerateProgram failed: %v", err)
+	}
+
+	goMod, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		t.Fatalf("Failed to read go.mod: %v", err)
+	}
+	goSum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatalf("Failed to read go.sum: %v", err)
+	}
+	files["go.mod"] = regexp.MustCompile(`(?m)^module .*$`).ReplaceAllString(string(goMod), "module "+generatedModule) +
+		"\nrequire github.com/conduit-lang/conduit v0.0.0\n\nreplace github.com/conduit-lang/conduit => " + root + "\n"
+	files["go.sum"] = string(goSum)
+
+	packages := map[string]bool{}
+	for name, code := range tests {
+		files[name] = code
+		packages["./"+filepath.ToSlash(filepath.Dir(name))] = true
+	}
+
+	dir := t.TempDir()
+	for name, code := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(name), err)
+		}
+		if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// Generated code does not pass vet yet, so only the tests are run
+	args := []string{"test", "-mod=mod", "-vet=off", "-count=1"}
+	for pkg := range packages {
+		args = append(args, pkg)
+	}
+	sort.Strings(args[4:])
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generated code tests failed: %v\n%s", err, output)
+	}
+}
@@ -8,13 +8,65 @@ import (
 	"strings"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/metadata"
 )
 
 // Generator transforms AST nodes into Go code
 type Generator struct {
-	buf     *bytes.Buffer
-	indent  int
-	imports map[string]bool
+	buf           *bytes.Buffer
+	indent        int
+	imports       map[string]bool
+	preflight     PreflightOptions
+	admin         bool
+	introspection IntrospectionOptions
+	redaction     metadata.RedactionPolicy
+	playground    PlaygroundOptions
+	mail          MailOptions
+	notify        NotifyOptions
+	auth          AuthOptions
+	quota         QuotaOptions
+	server        ServerOptions
+	build         BuildInfoOptions
+	serialization SerializationOptions
+	logging       LoggingOptions
+	errorTracking ErrorTrackingOptions
+	profiling     ProfilingOptions
+	target        string              // build target: server (when empty), lambda or cloudrun
+	resources     []*ast.ResourceNode // resources being generated, for code reading other resources' keys
+	batchHook     bool                // generating a batch hook, which has no receiver
+	trackedHook   [2]string           // name and source location of the hook being generated, when errors are tracked
+	routes        []registeredRoute   // routes registered by the Register<Resource>Routes being generated
+}
+
+// PreflightOptions controls the startup schema check in the generated main
+type PreflightOptions struct {
+	// Enabled generates a check that required tables and columns exist before serving
+	Enabled bool
+	// MigrationVersion is the latest migration the build expects; 0 skips the version check
+	MigrationVersion int64
+}
+
+// PlaygroundOptions controls the API playground served by the generated main
+type PlaygroundOptions struct {
+	// Enabled embeds the OpenAPI specification and mounts the playground at /docs
+	Enabled bool
+	// Spec is the OpenAPI specification JSON served to the playground
+	Spec string
+	// AllowProduction keeps the playground enabled when the environment is production
+	AllowProduction bool
+}
+
+// IntrospectionOptions configures the metadata routes mounted by GenerateMain
+type IntrospectionOptions struct {
+	// Enabled mounts the metadata routes at IntrospectionPath
+	Enabled bool
+	// Auth is how callers authenticate: none (the default), bearer or mtls.
+	// The bearer token is read from CONDUIT_INTROSPECTION_TOKEN at startup;
+	// mtls serves the application over TLS and verifies client certificates.
+	Auth string
+	// Exposure sets each section (resources, routes, patterns, hooks) to
+	// public, internal or hidden
+	Exposure map[string]string
 }
 
 // NewGenerator creates a new code generator
@@ -26,14 +78,57 @@ func NewGenerator() *Generator {
 	}
 }
 
+// SetPreflight configures the startup schema check generated by GenerateMain
+func (g *Generator) SetPreflight(opts PreflightOptions) {
+	g.preflight = opts
+}
+
+// SetAdmin enables the /admin UI generated by GenerateMain
+func (g *Generator) SetAdmin(enabled bool) {
+	g.admin = enabled
+}
+
+// SetIntrospection configures the /introspection metadata routes generated
+// by GenerateMain
+func (g *Generator) SetIntrospection(opts IntrospectionOptions) {
+	g.introspection = opts
+}
+
+// SetStripSource replaces hook bodies in the generated metadata with their
+// hashes
+func (g *Generator) SetStripSource(enabled bool) {
+	if enabled {
+		g.redaction.Source = metadata.RedactHashed
+	}
+}
+
+// SetRedaction sets which hook bodies, file paths and documentation the
+// generated metadata includes in full, hashed or not at all
+func (g *Generator) SetRedaction(policy metadata.RedactionPolicy) {
+	g.redaction = policy
+}
+
+// SetPlayground configures the API playground generated by GenerateMain
+func (g *Generator) SetPlayground(opts PlaygroundOptions) {
+	g.playground = opts
+}
+
 // GenerateProgram generates Go code for an entire program
 func (g *Generator) GenerateProgram(prog *ast.Program, moduleName string, conduitPath string, apiPrefix string) (map[string]string, error) {
 	files := make(map[string]string)
 
+	// Add the columns @counter_cache maintains to the parent resources, the
+	// position of @orderable resources, the deleted_at of @soft_delete
+	// resources and the timestamps of @timestamps resources
+	expanded := *prog
+	expanded.Resources = ast.WithSoftDeletes(ast.WithPositions(ast.WithCounterCaches(ast.WithTimestamps(prog.Resources, false))))
+	prog = &expanded
+
 	// Generate go.mod file
 	files["go.mod"] = g.GenerateGoMod(moduleName, conduitPath)
 
 	// Generate models for each resource (including hooks)
+	g.resources = prog.Resources
 	for _, resource := range prog.Resources {
 		code, err := g.GenerateResourceWithHooks(resource)
 		if err != nil {
@@ -50,6 +145,19 @@ func (g *Generator) GenerateProgram(prog *ast.Program, moduleName string, condui
 	}
 	files["handlers/handlers.go"] = handlers
 
+	// Generate social login routes for the providers in conduit.yaml
+	if g.auth.Enabled {
+		files[AuthHandlersFile] = g.GenerateAuthHandlers(prog.Resources, moduleName)
+	}
+
+	// Generate introspection metadata before main, which reports its
+	// source hash as the build's
+	metaJSON, err := g.GenerateMetadata(prog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate metadata: %w", err)
+	}
+	files["introspection/metadata.json"] = metaJSON
+
 	// Generate main entry point
 	mainCode, err := g.GenerateMain(prog.Resources, moduleName, apiPrefix)
 	if err != nil {
@@ -57,6 +165,11 @@ func (g *Generator) GenerateProgram(prog *ast.Program, moduleName string, condui
 	}
 	files["main.go"] = mainCode
 
+	// Generate Prometheus SLO rules for resources with @slo
+	if rules := g.GenerateSLORules(prog.Resources, apiPrefix); rules != "" {
+		files[SLORulesFile] = rules
+	}
+
 	// NOTE: Migration generation is now handled by the build system
 	// in internal/tooling/build/system.go:handleMigrations()
 	// This ensures:
@@ -64,13 +177,6 @@ func (g *Generator) GenerateProgram(prog *ast.Program, moduleName string, condui
 	// - Subsequent builds generate versioned migrations like {timestamp}_{seq}_{name}.sql
 	// - Schema changes are tracked via .conduit/schema-snapshot.json
 
-	// Generate introspection metadata
-	metaJSON, err := g.GenerateMetadata(prog)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate metadata: %w", err)
-	}
-	files["introspection/metadata.json"] = metaJSON
-
 	// Generate metadata accessor Go file
 	metaCode, err := g.GenerateMetadataAccessor(metaJSON)
 	if err != nil {
@@ -78,6 +184,16 @@ func (g *Generator) GenerateProgram(prog *ast.Program, moduleName string, condui
 	}
 	files["introspection/introspection.go"] = metaCode
 
+	// Embed the OpenAPI specification served by the API playground
+	if g.playground.Enabled {
+		files["introspection/openapi.go"] = g.GenerateOpenAPIAccessor(g.playground.Spec)
+	}
+
+	// Embed the mail templates sent with Mail.send
+	if g.mail.Enabled {
+		files[MailTemplatesFile] = g.GenerateMailTemplates(g.mail.Templates)
+	}
+
 	return files, nil
 }
 
@@ -106,7 +222,9 @@ func (g *Generator) GenerateGoMod(moduleName string, conduitPath string) string
 
 // GenerateResourceWithHooks generates a resource with lifecycle hooks
 func (g *Generator) GenerateResourceWithHooks(resource *ast.ResourceNode) (string, error) {
-	// Pre-scan hooks to collect imports before generating the resource
+	// Start from an empty import set so one model's imports don't leak into
+	// the next, then pre-scan hooks to collect imports before generating
+	g.imports = make(map[string]bool)
 	if len(resource.Hooks) > 0 {
 		g.collectHookImports(resource)
 	}
@@ -131,6 +249,15 @@ func (g *Generator) GenerateResourceWithHooks(resource *ast.ResourceNode) (strin
 // collectHookImports pre-scans hooks to collect all required imports
 func (g *Generator) collectHookImports(resource *ast.ResourceNode) {
 	for _, hook := range resource.Hooks {
+		if hook.OnError != nil || hook.Timeout > 0 {
+			g.imports["github.com/conduit-lang/conduit/pkg/web/hooks"] = true
+		}
+		if hook.Timeout > 0 {
+			g.imports["time"] = true
+		}
+		if g.errorTracking.Enabled {
+			g.imports["github.com/conduit-lang/conduit/pkg/web/errortrack"] = true
+		}
 		for _, stmt := range hook.Body {
 			g.collectStmtImports(stmt)
 		}
@@ -156,6 +283,9 @@ func (g *Generator) collectStmtImports(stmt ast.StmtNode) {
 			g.collectStmtImports(stmt)
 		}
 	case *ast.BlockStmt:
+		if s.IsAsync {
+			g.imports["github.com/conduit-lang/conduit/pkg/web/correlation"] = true
+		}
 		for _, stmt := range s.Statements {
 			g.collectStmtImports(stmt)
 		}
@@ -173,6 +303,12 @@ func (g *Generator) collectExprImports(expr ast.ExprNode) {
 		if e.Namespace == "String" && e.Function == "slugify" {
 			g.imports["github.com/conduit-lang/conduit/pkg/runtime"] = true
 		}
+		if e.Namespace == "Mail" && e.Function == "send" {
+			g.imports["github.com/conduit-lang/conduit/pkg/web/mail"] = true
+		}
+		if e.Namespace == "Notify" && e.Function == "send" {
+			g.imports["github.com/conduit-lang/conduit/pkg/web/notify"] = true
+		}
 		// Collect from arguments
 		for _, arg := range e.Arguments {
 			g.collectExprImports(arg)
@@ -187,6 +323,10 @@ func (g *Generator) collectExprImports(expr ast.ExprNode) {
 	case *ast.LogicalExpr:
 		g.collectExprImports(e.Left)
 		g.collectExprImports(e.Right)
+	case *ast.HashLiteralExpr:
+		for _, pair := range e.Pairs {
+			g.collectExprImports(pair.Value)
+		}
 	}
 }
 
@@ -209,6 +349,9 @@ func (g *Generator) GenerateResource(resource *ast.ResourceNode) (string, error)
 	if len(resource.Name) == 0 {
 		return "", fmt.Errorf("codegen: resource name cannot be empty (should be caught by type checker)")
 	}
+	if err := checkDefaultScope(resource); err != nil {
+		return "", err
+	}
 
 	// Add package declaration
 	g.writeLine("package models")
@@ -233,6 +376,18 @@ func (g *Generator) GenerateResource(resource *ast.ResourceNode) (string, error)
 	g.generateTableName(resource)
 	g.writeLine("")
 
+	// Generate the JSON:API id of a composite key (@primary(a, b))
+	if hasCompositeKey(resource) {
+		g.generateKeyMethods(resource)
+		g.writeLine("")
+	}
+
+	// Generate SearchDocument method (@search_index)
+	if resource.SearchIndex != nil {
+		g.generateSearchDocument(resource)
+		g.writeLine("")
+	}
+
 	// Generate Validate method
 	g.generateValidate(resource)
 	g.writeLine("")
@@ -241,6 +396,12 @@ func (g *Generator) GenerateResource(resource *ast.ResourceNode) (string, error)
 	g.generateCreate(resource)
 	g.writeLine("")
 
+	// Generate bulk create function (POST /resources/batch)
+	if !resource.ReadOnly() {
+		g.generateCreateBatch(resource)
+		g.writeLine("")
+	}
+
 	g.generateFindByID(resource)
 	g.writeLine("")
 
@@ -258,6 +419,44 @@ func (g *Generator) GenerateResource(resource *ast.ResourceNode) (string, error)
 
 	g.generateCount(resource)
 
+	// Generate Upsert method (@upsert)
+	if resource.Upsert != nil {
+		g.writeLine("")
+		g.generateUpsert(resource)
+	}
+
+	// Generate Archive and Restore methods (@archivable)
+	if archivedField(resource) != nil {
+		g.writeLine("")
+		g.generateArchive(resource)
+	}
+
+	// Generate Move method (@orderable)
+	if positionField(resource) != nil {
+		g.writeLine("")
+		g.generateMove(resource)
+	}
+
+	// Generate children and ancestors queries (@tree)
+	if treeParentField(resource) != nil {
+		g.writeLine("")
+		g.generateTree(resource)
+	}
+
+	// Generate Attach and Detach methods (has_many_through)
+	if !resource.ReadOnly() {
+		for _, rel := range throughRelationships(resource) {
+			g.writeLine("")
+			g.generateAttach(resource, rel)
+		}
+	}
+
+	// Generate lookups of the records other resources include (?include=)
+	for _, field := range g.includeLookups(resource) {
+		g.writeLine("")
+		g.generateIncludeFinder(resource, field)
+	}
+
 	return g.buf.String(), nil
 }
 
@@ -293,6 +492,7 @@ func (g *Generator) writeLine(format string, args ...interface{}) {
 func (g *Generator) collectImports(resource *ast.ResourceNode) {
 	needsTime := false
 	needsUUID := false
+	needsGeo := false
 
 	for _, field := range resource.Fields {
 		switch field.Type.Name {
@@ -300,6 +500,8 @@ func (g *Generator) collectImports(resource *ast.ResourceNode) {
 			needsTime = true
 		case "uuid":
 			needsUUID = true
+		case "point", "polygon":
+			needsGeo = true
 		}
 	}
 
@@ -314,6 +516,34 @@ func (g *Generator) collectImports(resource *ast.ResourceNode) {
 	if needsUUID {
 		g.imports["github.com/google/uuid"] = true
 	}
+	if needsGeo {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/geo"] = true
+	}
+	if resource.SearchIndex != nil {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/search"] = true
+	}
+	if resource.Shard != nil {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/shard"] = true
+	}
+	if generatesIDs(resource) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/ids"] = true
+	}
+	// MarshalID and UnmarshalID join and split composite keys
+	if hasCompositeKey(resource) {
+		g.imports["net/url"] = true
+		g.imports["strings"] = true
+		for _, field := range keyFields(resource) {
+			if field.Type.Name == "int" {
+				g.imports["strconv"] = true
+			}
+		}
+	}
+	// Included records are looked up with a list of placeholders
+	if len(g.includeLookups(resource)) > 0 {
+		g.imports["strings"] = true
+	}
+	// Create and Update roll back instead of committing in dry runs
+	g.imports["github.com/conduit-lang/conduit/pkg/web/dryrun"] = true
 
 	// Always need fmt for error handling
 	g.imports["fmt"] = true
@@ -371,7 +601,7 @@ func (g *Generator) toGoType(field *ast.FieldNode) string {
 
 	var goType string
 	switch typeName {
-	case "string", "text", "markdown":
+	case "string", "text", "markdown", "enum":
 		goType = "string"
 	case "int":
 		goType = "int64"
@@ -381,10 +611,16 @@ func (g *Generator) toGoType(field *ast.FieldNode) string {
 		goType = "bool"
 	case "uuid":
 		goType = "uuid.UUID"
+	case "ulid":
+		goType = "string"
 	case "timestamp":
 		goType = "time.Time"
 	case "json":
 		goType = "[]byte"
+	case "point":
+		goType = "geo.Point"
+	case "polygon":
+		goType = "geo.Polygon"
 	default:
 		// For resource types (relationships)
 		goType = typeName
@@ -440,6 +676,15 @@ func (g *Generator) toDBColumnName(name string) string {
 	return strings.ToLower(name)
 }
 
+// fieldColumnName returns the database column for a field, honoring a
+// @column("name") override
+func (g *Generator) fieldColumnName(field *ast.FieldNode) string {
+	if column := field.ColumnOverride(); column != "" {
+		return column
+	}
+	return g.toDBColumnName(field.Name)
+}
+
 // toTableName converts a resource name to a database table name (pluralized, snake_case)
 func (g *Generator) toTableName(name string) string {
 	// Simple pluralization: just add 's'
@@ -447,6 +692,44 @@ func (g *Generator) toTableName(name string) string {
 	return strings.ToLower(name) + "s"
 }
 
+// sqlTable returns the table of a resource as generated SQL refers to it:
+// the table named by @external_table, or its own table qualified with its
+// @schema, e.g. billing.invoices
+func (g *Generator) sqlTable(resource *ast.ResourceNode) string {
+	if resource.External != nil {
+		return resource.External.Table
+	}
+	if resource.Schema != nil {
+		return resource.Schema.Name + "." + g.toTableName(resource.Name)
+	}
+	return g.toTableName(resource.Name)
+}
+
+// TableName returns the database table name generated for a resource.
+// Tooling that emits SQL outside of code generation (refactors, migrations)
+// uses it to stay in sync with the generated schema.
+func TableName(resourceName string) string {
+	return (&Generator{}).toTableName(resourceName)
+}
+
+// QualifiedTableName returns the table of a resource as generated SQL refers
+// to it: TableName, qualified with the resource's @schema when it has one, or
+// the table named by @external_table
+func QualifiedTableName(resource *ast.ResourceNode) string {
+	return (&Generator{}).sqlTable(resource)
+}
+
+// ColumnName returns the database column name generated for a field
+func ColumnName(fieldName string) string {
+	return (&Generator{}).toDBColumnName(fieldName)
+}
+
+// FieldColumnName returns the database column generated for a field,
+// including any @column override
+func FieldColumnName(field *ast.FieldNode) string {
+	return (&Generator{}).fieldColumnName(field)
+}
+
 // toJSONAPIType converts a resource name to a JSON:API type (pluralized, snake_case)
 // Examples: User -> "users", BlogPost -> "blog_posts"
 func (g *Generator) toJSONAPIType(name string) string {
@@ -467,11 +750,11 @@ func (g *Generator) toJSONAPIType(name string) string {
 
 // generateStructTags generates struct tags for a field
 func (g *Generator) generateStructTags(field *ast.FieldNode, resourceName string) string {
-	dbTag := g.toDBColumnName(field.Name)
-	jsonTag := field.Name
+	dbTag := g.fieldColumnName(field)
+	jsonTag := g.jsonName(field.Name)
 
-	// For nullable fields, add omitempty to JSON
-	if field.Nullable {
+	// For nullable fields, add omitempty to JSON unless nulls are written
+	if field.Nullable && !g.serialization.IncludeNulls {
 		jsonTag += ",omitempty"
 	}
 
@@ -479,11 +762,11 @@ func (g *Generator) generateStructTags(field *ast.FieldNode, resourceName string
 	var jsonapiTag string
 	if field.Type.Kind == ast.TypeResource || field.Type.Kind == ast.TypeArray {
 		// This is a relationship field
-		relationName := g.toDBColumnName(field.Name)
+		relationName := g.jsonapiName(field.Name)
 		jsonapiTag = fmt.Sprintf("relation,%s", relationName)
 	} else {
 		// This is a regular attribute field
-		attrName := g.toDBColumnName(field.Name)
+		attrName := g.jsonapiName(field.Name)
 		jsonapiTag = fmt.Sprintf("attr,%s", attrName)
 	}
 
@@ -230,6 +230,120 @@ func TestGenerateMigrations_SimpleTable(t *testing.T) {
 	}
 }
 
+func TestGenerate_ColumnOverride(t *testing.T) {
+	resources := []*ast.ResourceNode{
+		{
+			Name: "Post",
+			Fields: []*ast.FieldNode{
+				{
+					Name:     "title",
+					Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+					Nullable: false,
+				},
+				{
+					Name:     "body",
+					Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "text"},
+					Nullable: false,
+					Constraints: []*ast.ConstraintNode{
+						{Name: "unique"},
+						{Name: "column", Arguments: []ast.ExprNode{&ast.LiteralExpr{Value: "legacy_body"}}},
+					},
+				},
+			},
+		},
+	}
+
+	gen := NewGenerator()
+	sql, err := gen.GenerateMigrations(resources)
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+	if !strings.Contains(sql, "legacy_body TEXT") {
+		t.Errorf("Migration should use the @column name, got:\n%s", sql)
+	}
+	if !strings.Contains(sql, "ON posts(legacy_body)") {
+		t.Errorf("Index should use the @column name, got:\n%s", sql)
+	}
+
+	code, err := gen.GenerateResource(resources[0])
+	if err != nil {
+		t.Fatalf("GenerateResource failed: %v", err)
+	}
+	if !strings.Contains(code, `db:"legacy_body" json:"body"`) {
+		t.Error("Struct tag should map the body attribute to the legacy_body column")
+	}
+	if !strings.Contains(code, "legacy_body = $") {
+		t.Error("UPDATE should set the legacy_body column")
+	}
+
+	handlers, err := gen.GenerateHandlers(resources, "example.com/app")
+	if err != nil {
+		t.Fatalf("GenerateHandlers failed: %v", err)
+	}
+	if !strings.Contains(handlers, `"body": "legacy_body",`) {
+		t.Error("Field map should map body to legacy_body")
+	}
+
+	if got := FieldColumnName(resources[0].Fields[0]); got != "title" {
+		t.Errorf("FieldColumnName(title) = %q, want title", got)
+	}
+}
+
+func TestGenerate_DualWrite(t *testing.T) {
+	resources := []*ast.ResourceNode{
+		{
+			Name: "Post",
+			Fields: []*ast.FieldNode{
+				{
+					Name:     "headline",
+					Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+					Nullable: false,
+					Constraints: []*ast.ConstraintNode{
+						{Name: "dual_write", Arguments: []ast.ExprNode{
+							&ast.LiteralExpr{Value: "title"},
+							&ast.LiteralExpr{Value: "2026-12-01"},
+						}},
+					},
+				},
+				{
+					Name:     "body",
+					Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "text"},
+					Nullable: false,
+				},
+			},
+		},
+	}
+
+	gen := NewGenerator()
+	sql, err := gen.GenerateMigrations(resources)
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+	if !strings.Contains(sql, "headline VARCHAR(255) NOT NULL,\n  title VARCHAR(255),\n  body TEXT NOT NULL") {
+		t.Errorf("Migration should keep the nullable legacy column, got:\n%s", sql)
+	}
+
+	code, err := gen.GenerateResource(resources[0])
+	if err != nil {
+		t.Fatalf("GenerateResource failed: %v", err)
+	}
+
+	expected := []string{
+		// INSERT writes both columns from the same parameter
+		"INSERT INTO posts (headline, title, body) VALUES ($1, $1, $2) RETURNING id",
+		"QueryRowContext(ctx, query, p.Headline, p.Body)",
+		// UPDATE and PATCH set both columns and keep the id placeholder in sequence
+		"UPDATE posts SET headline = $1, title = $1, body = $2 WHERE id = $3",
+		// Reads only use the new column
+		"SELECT id, headline, body FROM posts",
+	}
+	for _, want := range expected {
+		if !strings.Contains(code, want) {
+			t.Errorf("Generated code should contain %q", want)
+		}
+	}
+}
+
 func TestToGoType(t *testing.T) {
 	gen := NewGenerator()
 
@@ -5,11 +5,19 @@ import (
 	"strings"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/pkg/web/query"
 )
 
 // GenerateHandlers generates HTTP handlers for all resources
 func (g *Generator) GenerateHandlers(resources []*ast.ResourceNode, moduleName string) (string, error) {
+	for _, resource := range resources {
+		if err := checkDefaultScope(resource); err != nil {
+			return "", err
+		}
+	}
+
 	g.reset()
+	g.resources = resources
 
 	// Package declaration
 	g.writeLine("package handlers")
@@ -18,21 +26,79 @@ func (g *Generator) GenerateHandlers(resources []*ast.ResourceNode, moduleName s
 	// Imports
 	g.imports["database/sql"] = true
 	g.imports["encoding/json"] = true
-	g.imports["errors"] = true
 	g.imports["fmt"] = true
-	g.imports["io"] = true
 	g.imports["net/http"] = true
-	g.imports["strconv"] = true
 	g.imports["github.com/go-chi/chi/v5"] = true
 	g.imports["github.com/DataDog/jsonapi"] = true
 	g.imports[moduleName+"/models"] = true // Import models package
 	g.imports["github.com/conduit-lang/conduit/pkg/web/response"] = true // Import response package for JSON:API support
 	g.imports["github.com/conduit-lang/conduit/pkg/web/query"] = true    // Import query package for Phase 3 support
+	g.imports["github.com/conduit-lang/conduit/pkg/web/instrument"] = true // Tag queries with their resource and operation
+	g.imports["github.com/conduit-lang/conduit/pkg/web/explain"] = true    // ?explain=true on lists
+
+	if hasCacheControl(resources) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/cache"] = true
+	}
+	if hasChanges(resources) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/changes"] = true
+	}
+	if hasConflict(resources) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/conflict"] = true
+	}
+	if hasSearchIndex(resources) {
+		g.imports["errors"] = true
+		g.imports["github.com/conduit-lang/conduit/pkg/web/search"] = true
+	}
+	if hasWebhook(resources) {
+		g.imports["errors"] = true
+		g.imports["io"] = true
+		g.imports["github.com/conduit-lang/conduit/pkg/web/webhook"] = true
+	}
+	if len(signedRequestSecrets(resources)) > 0 {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/signing"] = true
+	}
+	if hasProfiles(resources) || hasAdminOnly(resources) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/profile"] = true
+	}
+	if hasRoutes(resources) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/methods"] = true
+	}
+	if hasShard(resources) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/shard"] = true
+	}
+	if hasEnums(resources) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/enums"] = true
+	}
+	if hasDefaultScope(resources) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/scope"] = true
+	}
+	if g.hasIncludes(resources) {
+		g.imports["context"] = true
+		g.imports["errors"] = true
+	}
 
-	// Pre-scan resources for additional imports (like uuid for ID types)
+	// Pre-scan resources for additional imports (uuid or strconv to parse IDs)
 	for _, resource := range resources {
-		if g.getIDType(resource) == "uuid" {
-			g.imports["github.com/google/uuid"] = true
+		// Request bodies are only read by the write handlers read-only resources lack
+		if !resource.ReadOnly() {
+			g.imports["errors"] = true
+			g.imports["io"] = true
+			g.imports["github.com/conduit-lang/conduit/pkg/web/bind"] = true
+			g.imports["github.com/conduit-lang/conduit/pkg/web/dryrun"] = true
+		}
+		// Change feeds without a creation timestamp classify with a zero time
+		if resource.Changes != nil && creationField(resource) == nil {
+			g.imports["time"] = true
+		}
+		for _, field := range keyFields(resource) {
+			switch field.Type.Name {
+			case "uuid":
+				g.imports["github.com/google/uuid"] = true
+			case "ulid":
+				g.imports["github.com/conduit-lang/conduit/pkg/web/ids"] = true
+			case "int":
+				g.imports["strconv"] = true
+			}
 		}
 	}
 
@@ -43,6 +109,12 @@ func (g *Generator) GenerateHandlers(resources []*ast.ResourceNode, moduleName s
 	g.generateErrorHelpers()
 	g.writeLine("")
 
+	// Enum fields served on enums.Path
+	if hasEnums(resources) {
+		g.generateEnumCatalog(resources)
+		g.writeLine("")
+	}
+
 	// Generate handlers for each resource
 	for _, resource := range resources {
 		if err := g.generateResourceHandlers(resource); err != nil {
@@ -54,15 +126,10 @@ func (g *Generator) GenerateHandlers(resources []*ast.ResourceNode, moduleName s
 	return g.buf.String(), nil
 }
 
-// get IDType returns the type of the resource's ID field
+// getIDType returns the type of the resource's primary key field: the
+// declared key, or int for the generated id
 func (g *Generator) getIDType(resource *ast.ResourceNode) string {
-	for _, field := range resource.Fields {
-		if field.Name == "id" {
-			return field.Type.Name
-		}
-	}
-	// Default to int64 if no explicit ID field
-	return "int"
+	return keyField(resource).Type.Name
 }
 
 // generateErrorHelpers generates the error response type and helper function
@@ -84,12 +151,26 @@ func (g *Generator) generateErrorHelpers() {
 	g.writeLine("}")
 }
 
-// generateIDParsingCode generates code to parse ID from URL based on type
+// generateIDParsingCode generates code to parse ID from URL based on type.
+// A composite key is parsed into one variable per field, named by keyVars.
 func (g *Generator) generateIDParsingCode(resource *ast.ResourceNode) {
+	if hasCompositeKey(resource) {
+		g.generateKeyParsingCode(resource)
+		return
+	}
+
 	idType := g.getIDType(resource)
+	param := resource.KeyParams()[0]
 
 	g.writeLine("// Parse ID from URL")
-	g.writeLine("idStr := chi.URLParam(r, \"id\")")
+	switch idType {
+	case "string", "text", "markdown":
+		// Natural string keys are used as they appear in the URL
+		g.writeLine("id := chi.URLParam(r, %q)", param)
+		g.writeLine("")
+		return
+	}
+	g.writeLine("idStr := chi.URLParam(r, %q)", param)
 
 	switch idType {
 	case "uuid":
@@ -102,6 +183,15 @@ func (g *Generator) generateIDParsingCode(resource *ast.ResourceNode) {
 		g.writeLine("return")
 		g.indent--
 		g.writeLine("}")
+	case "ulid":
+		g.writeLine("id := idStr")
+		g.writeLine("err := ids.ValidateULID(id)")
+		g.writeLine("if err != nil {")
+		g.indent++
+		g.writeLine("respondWithError(w, \"Invalid ID\", http.StatusBadRequest)")
+		g.writeLine("return")
+		g.indent--
+		g.writeLine("}")
 	default: // int, int64, etc.
 		g.writeLine("id, err := strconv.ParseInt(idStr, 10, 64)")
 		g.writeLine("if err != nil {")
@@ -114,10 +204,58 @@ func (g *Generator) generateIDParsingCode(resource *ast.ResourceNode) {
 	g.writeLine("")
 }
 
+// generateKeyParsingCode parses each field of a composite key from its URL
+// parameter, e.g. regionKey from {region}
+func (g *Generator) generateKeyParsingCode(resource *ast.ResourceNode) {
+	vars := g.keyVars(resource)
+	params := resource.KeyParams()
+
+	g.writeLine("// Parse key from URL")
+	for i, field := range keyFields(resource) {
+		switch field.Type.Name {
+		case "uuid":
+			g.imports["github.com/google/uuid"] = true
+			g.writeLine("%s, err := uuid.Parse(chi.URLParam(r, %q))", vars[i], params[i])
+			g.writeLine("if err != nil {")
+		case "int":
+			g.writeLine("%s, err := strconv.ParseInt(chi.URLParam(r, %q), 10, 64)", vars[i], params[i])
+			g.writeLine("if err != nil {")
+		case "ulid":
+			g.writeLine("%s := chi.URLParam(r, %q)", vars[i], params[i])
+			g.writeLine("if err := ids.ValidateULID(%s); err != nil {", vars[i])
+		default:
+			g.writeLine("%s := chi.URLParam(r, %q)", vars[i], params[i])
+			continue
+		}
+		g.indent++
+		g.writeLine("respondWithError(w, \"Invalid %s\", http.StatusBadRequest)", params[i])
+		g.writeLine("return")
+		g.indent--
+		g.writeLine("}")
+	}
+	g.writeLine("")
+}
+
 // generateResourceHandlers generates all CRUD handlers for a resource
 func (g *Generator) generateResourceHandlers(resource *ast.ResourceNode) error {
 	resourceLower := strings.ToLower(resource.Name)
 
+	// Field map shared by the handlers
+	g.generateFieldMap(resource)
+	g.writeLine("")
+
+	// Fields shown to each role (@profile)
+	if len(resource.Profiles) > 0 {
+		g.generateProfileSet(resource)
+		g.writeLine("")
+	}
+
+	// Display labels of enum fields (@labels)
+	if hasLabels(resource) {
+		g.generateLabelSet(resource)
+		g.writeLine("")
+	}
+
 	// List handler
 	g.generateListHandler(resource)
 	g.writeLine("")
@@ -126,33 +264,135 @@ func (g *Generator) generateResourceHandlers(resource *ast.ResourceNode) error {
 	g.generateGetHandler(resource)
 	g.writeLine("")
 
-	// Create handler
-	g.generateCreateHandler(resource)
-	g.writeLine("")
+	// Side-loading of related records (?include=)
+	if g.inIncludeGraph(resource) {
+		g.generateIncludeFunc(resource)
+		g.writeLine("")
+	}
 
-	// Update handler
-	g.generateUpdateHandler(resource)
-	g.writeLine("")
+	// Write handlers; @materialized views and @external_table resources are read-only
+	if !resource.ReadOnly() {
+		// Create handler
+		g.generateCreateHandler(resource)
+		g.writeLine("")
 
-	// Patch handler
-	g.generatePatchHandler(resource)
-	g.writeLine("")
+		// Bulk create handler
+		g.generateCreateBatchHandler(resource)
+		g.writeLine("")
 
-	// Delete handler
-	g.generateDeleteHandler(resource)
-	g.writeLine("")
+		// Update handler
+		g.generateUpdateHandler(resource)
+		g.writeLine("")
+
+		// Patch handler
+		g.generatePatchHandler(resource)
+		g.writeLine("")
+
+		// Delete handler
+		g.generateDeleteHandler(resource)
+		g.writeLine("")
+	}
+
+	// Change feed handler (@changes)
+	if resource.Changes != nil {
+		g.generateChangesHandler(resource)
+		g.writeLine("")
+	}
+
+	// Search handler (@search_index)
+	if resource.SearchIndex != nil {
+		g.generateSearchHandler(resource)
+		g.writeLine("")
+	}
+
+	// Webhook receiver (@webhook)
+	if resource.Webhook != nil {
+		g.generateWebhookHandler(resource)
+		g.writeLine("")
+	}
+
+	// Upsert handler (@upsert)
+	if resource.Upsert != nil {
+		g.generateUpsertHandler(resource)
+		g.writeLine("")
+	}
+
+	// Archive and restore handlers (@archivable)
+	if archivedField(resource) != nil && !resource.ReadOnly() {
+		g.generateArchiveHandler(resource, "archive")
+		g.writeLine("")
+		g.generateArchiveHandler(resource, "restore")
+		g.writeLine("")
+	}
+
+	// Move handler (@orderable)
+	if positionField(resource) != nil && !resource.ReadOnly() {
+		g.generateMoveHandler(resource)
+		g.writeLine("")
+	}
+
+	// Children and ancestors handlers (@tree)
+	if treeParentField(resource) != nil {
+		g.generateTreeHandler(resource, "children")
+		g.writeLine("")
+		g.generateTreeHandler(resource, "ancestors")
+		g.writeLine("")
+	}
+
+	// Attach and detach handlers (has_many_through)
+	if !resource.ReadOnly() {
+		for _, rel := range throughRelationships(resource) {
+			g.generateAttachHandler(resource, rel, ast.HookEventAttach)
+			g.writeLine("")
+			g.generateAttachHandler(resource, rel, ast.HookEventDetach)
+			g.writeLine("")
+		}
+	}
 
 	// Router registration helper
 	g.writeLine("// Register%sRoutes registers all routes for %s", resource.Name, resourceLower)
 	g.writeLine("func Register%sRoutes(r chi.Router, db *sql.DB) {", resource.Name)
 	g.indent++
+	// A group keeps signature checks off other resources' routes
+	secretRef := resource.SignedRequestSecret()
+	if secretRef != "" {
+		g.writeLine("r.Group(func(r chi.Router) {")
+		g.indent++
+		g.writeLine("r.Use(signing.Middleware(%q))", secretRef)
+	}
 	tableName := g.toTableName(resource.Name)
-	g.writeLine("r.Get(\"/%s\", List%sHandler(db))", tableName, resource.Name)
-	g.writeLine("r.Post(\"/%s\", Create%sHandler(db))", tableName, resource.Name)
-	g.writeLine("r.Get(\"/%s/{id}\", Get%sHandler(db))", tableName, resource.Name)
-	g.writeLine("r.Put(\"/%s/{id}\", Update%sHandler(db))", tableName, resource.Name)
-	g.writeLine("r.Patch(\"/%s/{id}\", Patch%sHandler(db))", tableName, resource.Name)
-	g.writeLine("r.Delete(\"/%s/{id}\", Delete%sHandler(db))", tableName, resource.Name)
+	member := g.memberPath(resource)
+	if resource.Changes != nil {
+		g.registerRoute("r", "Get", "/"+tableName+"/changes", g.routeHandler(resource, "Changes"+resource.Name+"Handler"))
+	}
+	if resource.SearchIndex != nil {
+		g.registerRoute("r", "Get", "/"+tableName+"/search", g.routeHandler(resource, "Search"+resource.Name+"Handler"))
+	}
+	if resource.Webhook != nil {
+		g.registerRoute("r", "Post", WebhookPath(resource.Webhook.Provider), "Webhook"+resource.Name+"Handler(db)")
+	}
+	if treeParentField(resource) != nil {
+		g.registerRoute("r", "Get", member+"/children", g.routeHandler(resource, "List"+resource.Name+"ChildrenHandler"))
+		g.registerRoute("r", "Get", member+"/ancestors", g.routeHandler(resource, "List"+resource.Name+"AncestorsHandler"))
+	}
+	if resource.ReadOnly() {
+		g.generateReadOnlyRoutes(resource)
+	} else if resource.CacheControl != nil {
+		g.generateCachedRoutes(resource)
+	} else {
+		g.generateOperationRoutes(resource, "", "")
+	}
+	if !resource.ReadOnly() {
+		for _, rel := range throughRelationships(resource) {
+			g.registerRoute("r", "Post", g.attachPath(resource, rel), g.routeHandler(resource, "Attach"+resource.Name+g.toGoFieldName(rel.Name)+"Handler"))
+			g.registerRoute("r", "Delete", g.attachPath(resource, rel), g.routeHandler(resource, "Detach"+resource.Name+g.toGoFieldName(rel.Name)+"Handler"))
+		}
+	}
+	if secretRef != "" {
+		g.indent--
+		g.writeLine("})")
+	}
+	g.generateOptionsRoutes()
 	g.indent--
 	g.writeLine("}")
 
@@ -171,91 +411,96 @@ func (g *Generator) toSnakeCase(name string) string {
 	return strings.ToLower(result.String())
 }
 
-// generateValidFieldsList generates code for a slice of valid field names
-func (g *Generator) generateValidFieldsList(resource *ast.ResourceNode) {
-	g.writeLine("validFields := []string{")
-	g.indent++
-	for i, field := range resource.Fields {
-		// Convert field name to snake_case for database column names
-		columnName := g.toSnakeCase(field.Name)
-		if i < len(resource.Fields)-1 {
-			g.writeLine("\"%s\",", columnName)
-		} else {
-			g.writeLine("\"%s\",", columnName)
-		}
-	}
-	g.indent--
-	g.writeLine("}")
+// fieldMapName returns the name of the generated field map variable for a resource
+func (g *Generator) fieldMapName(resource *ast.ResourceNode) string {
+	return g.resourceVarName(resource, "Fields")
 }
 
-// generateListHandler generates the LIST handler (GET /resources)
-func (g *Generator) generateListHandler(resource *ast.ResourceNode) {
-	resourceLower := strings.ToLower(resource.Name)
-	tableName := g.toTableName(resource.Name)
-
-	g.writeLine("// List%sHandler handles GET /%s - list all %s with pagination",
-		resource.Name, tableName, resourceLower+"s")
-	g.writeLine("func List%sHandler(db *sql.DB) http.HandlerFunc {", resource.Name)
-	g.indent++
-	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
-	g.indent++
-
-	g.writeLine("ctx := r.Context()")
-	g.writeLine("")
+// resourceVarName returns an unexported package-level variable name for a resource
+func (g *Generator) resourceVarName(resource *ast.ResourceNode, suffix string) string {
+	return strings.ToLower(resource.Name[:1]) + resource.Name[1:] + suffix
+}
 
-	// Parse query parameters for pagination
-	g.writeLine("// Parse pagination parameters")
-	g.writeLine("limit := 50 // default")
-	g.writeLine("offset := 0")
-	g.writeLine("")
-	g.writeLine("if limitStr := r.URL.Query().Get(\"limit\"); limitStr != \"\" {")
-	g.indent++
-	g.writeLine("if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {")
+// generateFieldMap generates the package-level map from JSON attribute names
+// to database columns used for filtering, sorting and sparse fieldsets
+func (g *Generator) generateFieldMap(resource *ast.ResourceNode) {
+	g.writeLine("// %s maps %s attribute names to database columns", g.fieldMapName(resource), resource.Name)
+	g.writeLine("var %s = query.NewFieldMap(map[string]string{", g.fieldMapName(resource))
 	g.indent++
-	g.writeLine("limit = l")
-	g.indent--
-	g.writeLine("}")
-	g.indent--
-	g.writeLine("}")
-	g.writeLine("")
-	g.writeLine("if offsetStr := r.URL.Query().Get(\"offset\"); offsetStr != \"\" {")
-	g.indent++
-	g.writeLine("if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {")
-	g.indent++
-	g.writeLine("offset = o")
-	g.indent--
-	g.writeLine("}")
+	for _, field := range resource.Fields {
+		g.writeLine("\"%s\": \"%s\",", g.jsonName(field.Name), g.fieldColumnName(field))
+	}
 	g.indent--
-	g.writeLine("}")
+	if g.serialization.Casing != query.Declared {
+		g.writeLine("}).WithCasing(%q)", g.serialization.Casing)
+	} else {
+		g.writeLine("})")
+	}
 	g.writeLine("")
 
-	// Parse JSON:API Phase 3 query parameters
-	g.writeLine("// Parse JSON:API Phase 3 query parameters")
-	g.writeLine("includes := query.ParseInclude(r)")
-	g.writeLine("fields := query.ParseFields(r)")
-	g.writeLine("filters := query.ParseFilter(r)")
-	g.writeLine("sorts := query.ParseSort(r)")
+	g.writeLine("// %s lists the %s fields accepted by ?filter[...]", g.resourceVarName(resource, "Filterable"), resource.Name)
+	g.writeLine("var %s = %s", g.resourceVarName(resource, "Filterable"), g.stringSliceLiteral(g.jsonNames(resource.FilterableFields())))
 	g.writeLine("")
+	g.writeLine("// %s lists the %s fields accepted by ?sort=", g.resourceVarName(resource, "Sortable"), resource.Name)
+	g.writeLine("var %s = %s", g.resourceVarName(resource, "Sortable"), g.stringSliceLiteral(g.jsonNames(resource.SortableFields())))
 
-	// TODO comment for includes support
-	g.writeLine("// TODO: Phase 3 - Load relationships if includes is not empty")
-	g.writeLine("// This requires implementing relationship loading in models package")
-	g.writeLine("_ = includes // Silence unused variable warning")
-	g.writeLine("")
+	if operators := resource.FilterOperators(); operators != nil {
+		g.writeLine("")
+		g.writeLine("// %s lists the operators each %s field accepts in ?filter[...][op]=", g.resourceVarName(resource, "Operators"), resource.Name)
+		g.writeLine("var %s = map[string][]string{", g.resourceVarName(resource, "Operators"))
+		g.indent++
+		for _, field := range resource.Fields {
+			if names, ok := operators[field.Name]; ok {
+				g.writeLine("%q: %s,", g.jsonName(field.Name), g.stringSliceLiteral(names))
+			}
+		}
+		g.indent--
+		g.writeLine("}")
+	}
 
-	// Generate valid fields list
-	g.writeLine("// Valid fields for filtering and sorting")
-	g.generateValidFieldsList(resource)
-	g.writeLine("")
+	if spatial := resource.SpatialFields(); len(spatial) > 0 {
+		g.writeLine("")
+		g.writeLine("// %s lists the %s fields accepted by ?filter[...][near]=lat,lng,radius", g.resourceVarName(resource, "Spatial"), resource.Name)
+		g.writeLine("var %s = %s", g.resourceVarName(resource, "Spatial"), g.stringSliceLiteral(g.jsonNames(spatial)))
+	}
 
-	// Build base query with filtering and sorting
-	g.writeLine("// Build base query")
-	g.writeLine("baseQuery := \"SELECT * FROM %s\"", tableName)
-	g.writeLine("")
+	if resource.Partition != nil {
+		g.writeLine("")
+		g.writeLine("// %s lists the %s fields accepted by ?filter[...][gte|gt|lte|lt]; bounding", g.resourceVarName(resource, "Ranged"), resource.Name)
+		g.writeLine("// the partition key lets PostgreSQL skip partitions outside the range")
+		g.writeLine("var %s = %s", g.resourceVarName(resource, "Ranged"), g.stringSliceLiteral([]string{g.jsonName(resource.Partition.Field)}))
+	}
+}
 
-	// Apply filtering
-	g.writeLine("// Apply filtering")
-	g.writeLine("whereClause, filterArgs, err := query.BuildFilterClause(filters, \"%s\", validFields)", tableName)
+// stringSliceLiteral formats values as a Go []string literal
+func (g *Generator) stringSliceLiteral(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+// generateValidIncludesList generates the relationship names accepted by
+// ?include=, those the list handler can side-load
+func (g *Generator) generateValidIncludesList(resource *ast.ResourceNode) {
+	rels := g.includable(resource)
+	if len(rels) == 0 {
+		g.writeLine("validIncludes := []string{}")
+		return
+	}
+
+	g.writeLine("validIncludes := []string{")
+	g.indent++
+	for _, rel := range rels {
+		g.writeLine("\"%s\",", rel.Name)
+	}
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateListBadRequest generates the 400 response for invalid list query parameters
+func (g *Generator) generateListBadRequest() {
 	g.writeLine("if err != nil {")
 	g.indent++
 	g.writeLine("if response.IsJSONAPI(r) {")
@@ -270,47 +515,201 @@ func (g *Generator) generateListHandler(resource *ast.ResourceNode) {
 	g.writeLine("return")
 	g.indent--
 	g.writeLine("}")
-	g.writeLine("if whereClause != \"\" {")
-	g.indent++
-	g.writeLine("baseQuery += \" \" + whereClause")
-	g.indent--
-	g.writeLine("}")
-	g.writeLine("")
+}
 
-	// Apply sorting
-	g.writeLine("// Apply sorting")
-	g.writeLine("orderByClause, err := query.BuildSortClause(sorts, \"%s\", validFields)", tableName)
+// generateScopeError generates the check of the error scope.Unscoped
+// returned: 403 when the caller is not an admin and 400 for invalid values
+func (g *Generator) generateScopeError() {
 	g.writeLine("if err != nil {")
 	g.indent++
 	g.writeLine("if response.IsJSONAPI(r) {")
 	g.indent++
-	g.writeLine("response.RenderJSONAPIError(w, http.StatusBadRequest, err)")
+	g.writeLine("response.RenderJSONAPIError(w, scope.Status(err), err)")
 	g.indent--
 	g.writeLine("} else {")
 	g.indent++
-	g.writeLine("respondWithError(w, err.Error(), http.StatusBadRequest)")
+	g.writeLine("respondWithError(w, err.Error(), scope.Status(err))")
 	g.indent--
 	g.writeLine("}")
 	g.writeLine("return")
 	g.indent--
 	g.writeLine("}")
-	g.writeLine("if orderByClause != \"\" {")
+}
+
+// generateListHandler generates the LIST handler (GET /resources)
+func (g *Generator) generateListHandler(resource *ast.ResourceNode) {
+	resourceLower := strings.ToLower(resource.Name)
+	tableName := g.toTableName(resource.Name)
+
+	g.writeLine("// List%sHandler handles GET /%s - list all %s with pagination",
+		resource.Name, tableName, resourceLower+"s")
+	g.writeLine("func List%sHandler(db *sql.DB) http.HandlerFunc {", resource.Name)
+	g.indent++
+	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
+	g.indent++
+
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, \"list\")", resource.Name)
+	g.writeLine("")
+	g.generateVisibleFields(resource)
+
+	// Parse query parameters for pagination
+	g.writeLine("// Parse pagination parameters (page[limit]/page[offset], or legacy limit/offset)")
+	g.writeLine("pagination, err := query.ParsePage(r, query.DefaultPageConfig())")
+	g.generateListBadRequest()
+	g.writeLine("limit, offset := pagination.Limit, pagination.Offset")
+	g.writeLine("")
+
+	// Parse JSON:API Phase 3 query parameters
+	g.writeLine("// Parse JSON:API Phase 3 query parameters")
+	g.writeLine("includes := query.ParseInclude(r)")
+	g.writeLine("fields := query.ParseFields(r)")
+	g.writeLine("filters := query.ParseFilter(r)")
+	if softDeleteField(resource) != nil {
+		g.writeLine("includeDeleted, err := query.ParseIncludeDeleted(r)")
+		g.generateListBadRequest()
+	}
+	if archivedField(resource) != nil {
+		g.writeLine("archived, err := query.ParseArchived(filters)")
+		g.generateListBadRequest()
+	}
+	if scoped(resource) {
+		g.writeLine("unscoped, err := scope.Unscoped(r)")
+		g.generateScopeError()
+	}
+	if len(resource.SpatialFields()) > 0 {
+		g.writeLine("near := query.ParseNear(r)")
+	}
+	if resource.Partition != nil {
+		g.writeLine("ranges := query.ParseRange(r)")
+	}
+	if resource.FilterOperators() != nil {
+		g.writeLine("operators := query.ParseOperators(r)")
+	}
+	g.writeLine("sorts := query.ParseSort(r)")
+	g.writeLine("")
+
+	// Sparse fieldsets may use camelCase or snake_case names
+	jsonapiType := g.toJSONAPIType(resource.Name)
+	g.writeLine("// Resolve sparse fieldset names to attribute names")
+	g.writeLine("if requested, ok := fields[\"%s\"]; ok {", jsonapiType)
 	g.indent++
-	g.writeLine("baseQuery += \" \" + orderByClause")
+	g.writeLine("attributes, err := %s.Attributes(requested)", g.fieldMapName(resource))
+	g.generateListBadRequest()
+	g.writeLine("fields[\"%s\"] = attributes", jsonapiType)
 	g.indent--
 	g.writeLine("}")
 	g.writeLine("")
 
-	// Apply pagination
-	g.writeLine("// Apply pagination")
-	g.writeLine("paramIndex := len(filterArgs) + 1")
-	g.writeLine("baseQuery += fmt.Sprintf(\" LIMIT $%d OFFSET $%d\", paramIndex, paramIndex+1)")
+	// Generate relationship list
+	g.writeLine("// Valid relationships for include")
+	g.generateValidIncludesList(resource)
+	g.writeLine("")
+
+	// Compose filtering, sorting, includes and pagination into one query,
+	// restricted to the schema's filterable and sortable fields.
+	// Sparse fieldsets are applied to the response instead of the SELECT list
+	// because generated models scan complete rows.
+	g.writeLine("// Build the list query from filters, sorting, includes and pagination")
+	g.writeLine("qb := query.NewMappedBuilder(\"%s\", %s).", g.sqlTable(resource), g.fieldMapName(resource))
+	g.indent++
+	g.writeLine("Filterable(%s).", g.queryableFields(resource, "Filterable"))
+	g.writeLine("Sortable(%s).", g.queryableFields(resource, "Sortable"))
+	if field := softDeleteField(resource); field != nil {
+		g.writeLine("Deleted(%q, includeDeleted).", g.fieldColumnName(field))
+	}
+	if field := archivedField(resource); field != nil {
+		g.writeLine("Archived(%q, archived).", g.fieldColumnName(field))
+	}
+	if scoped(resource) {
+		g.writeLine("Scope(%q, unscoped).", g.defaultScope(resource, g.sqlTable(resource)+"."))
+	}
+	g.writeLine("Filter(filters).")
+	if len(resource.SpatialFields()) > 0 {
+		g.writeLine("Spatial(%s).", g.queryableFields(resource, "Spatial"))
+		g.writeLine("Near(near).")
+	}
+	if resource.Partition != nil {
+		g.writeLine("Ranged(%s).", g.queryableFields(resource, "Ranged"))
+		g.writeLine("Range(ranges).")
+	}
+	if resource.FilterOperators() != nil {
+		g.writeLine("Operators(%s).", g.resourceVarName(resource, "Operators"))
+		g.writeLine("Compare(operators).")
+	}
+	g.writeLine("Sort(sorts).")
+	if positionField(resource) != nil {
+		var columns []string
+		for _, column := range g.listOrder(resource) {
+			columns = append(columns, fmt.Sprintf("%q", column))
+		}
+		g.writeLine("OrderBy(%s).", strings.Join(columns, ", "))
+	}
+	g.writeLine("Include(includes, validIncludes).")
+	g.writeLine("Paginate(pagination)")
+	g.indent--
+	g.writeLine("")
+	g.writeLine("listQuery, args, err := qb.Build()")
+	g.generateListBadRequest()
 	g.writeLine("")
 
+	// Get total count for pagination according to the resource's @count strategy.
+	// Counting happens before the query so the count can fail cleanly before
+	// any rows are streamed.
+	countStrategy := resource.CountStrategy
+	if countStrategy == "" {
+		countStrategy = ast.CountExact
+	}
+
+	// Conditional GET on the collection's modification time. The row count,
+	// which catches deletes, is only read where the resource already counts.
+	if field := modificationField(resource); field != nil {
+		g.writeLine("// Conditional GET: 304 Not Modified when nothing in the collection changed")
+		g.writeLine("version, err := query.LatestVersion(ctx, db, %q, %q, %t)", g.sqlTable(resource), g.fieldColumnName(field), countStrategy == ast.CountExact)
+		g.writeLine("if err != nil {")
+		g.indent++
+		g.writeLine("if response.IsJSONAPI(r) {")
+		g.indent++
+		g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to check %s for changes: %%v\", err))", resourceLower+"s")
+		g.indent--
+		g.writeLine("} else {")
+		g.indent++
+		g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to check %s for changes: %%v\", err), http.StatusInternalServerError)", resourceLower+"s")
+		g.indent--
+		g.writeLine("}")
+		g.writeLine("return")
+		g.indent--
+		g.writeLine("}")
+		if len(g.includable(resource)) > 0 {
+			// Included records change without changing the collection
+			g.writeLine("if len(includes) == 0 && response.NotModified(w, r, version.LastModified, version.ETag()) {")
+		} else {
+			g.writeLine("if response.NotModified(w, r, version.LastModified, version.ETag()) {")
+		}
+		g.indent++
+		g.writeLine("return")
+		g.indent--
+		g.writeLine("}")
+		g.writeLine("")
+	}
+
+	switch countStrategy {
+	case ast.CountExact:
+		g.writeLine("// Get total count for pagination (with filters applied)")
+		g.writeLine("total, _, err := qb.Count(ctx, db, query.CountExact)")
+	case ast.CountEstimated:
+		g.writeLine("// Get estimated total count for pagination from planner statistics")
+		g.writeLine("total, _, err := qb.Count(ctx, db, query.CountEstimated)")
+	}
+
+	if countStrategy != ast.CountNone {
+		g.generateListCountError(resourceLower)
+	}
+
+	g.generateListExplain(resourceLower)
+
 	// Execute query
 	g.writeLine("// Execute query")
-	g.writeLine("args := append(filterArgs, limit, offset)")
-	g.writeLine("rows, err := db.QueryContext(ctx, baseQuery, args...)")
+	g.writeLine("rows, err := db.QueryContext(ctx, listQuery, args...)")
 	g.writeLine("if err != nil {")
 	g.indent++
 	g.writeLine("if response.IsJSONAPI(r) {")
@@ -328,9 +727,30 @@ func (g *Generator) generateListHandler(resource *ast.ResourceNode) {
 	g.writeLine("defer rows.Close()")
 	g.writeLine("")
 
-	// Scan results
-	g.writeLine("// Scan results")
-	g.writeLine("results := []*models.%s{}", resource.Name)
+	// Stream results: each row is encoded as soon as it is scanned, so memory
+	// use does not grow with the page size
+	g.writeLine("// Stream results one row at a time (JSON:API or legacy JSON)")
+	g.generateListStream(resource, "fields")
+	if !g.serialization.Envelope {
+		g.writeLine("if plan != nil {")
+		g.indent++
+		g.writeLine("stream.Wrap()")
+		g.indent--
+		g.writeLine("}")
+	}
+	sideLoad := len(g.includable(resource)) > 0
+	if sideLoad {
+		// Records are held back until their relationships are loaded, with
+		// one query per relationship for the whole page
+		g.writeLine("// Compound documents (?include=) hold the page back to load its relationships")
+		g.writeLine("var included *response.Included")
+		g.writeLine("var items []*models.%s", resource.Name)
+		g.writeLine("if len(includes) > 0 && response.IsJSONAPI(r) {")
+		g.indent++
+		g.writeLine("included = response.NewIncluded(fields)")
+		g.indent--
+		g.writeLine("}")
+	}
 	g.writeLine("for rows.Next() {")
 	g.indent++
 	g.writeLine("item := &models.%s{}", resource.Name)
@@ -339,159 +759,191 @@ func (g *Generator) generateListHandler(resource *ast.ResourceNode) {
 	scanFields := g.generateScanFields(resource)
 	g.writeLine("if err := rows.Scan(%s); err != nil {", scanFields)
 	g.indent++
-	g.writeLine("if response.IsJSONAPI(r) {")
-	g.indent++
-	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to scan %s: %%v\", err))", resourceLower)
-	g.indent--
-	g.writeLine("} else {")
-	g.indent++
-	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to scan %s: %%v\", err), http.StatusInternalServerError)", resourceLower)
-	g.indent--
-	g.writeLine("}")
+	g.writeLine("stream.Fail(fmt.Errorf(\"Failed to scan %s: %%v\", err))", resourceLower)
 	g.writeLine("return")
 	g.indent--
 	g.writeLine("}")
-	g.writeLine("results = append(results, item)")
+	if sideLoad {
+		g.writeLine("if included != nil {")
+		g.indent++
+		g.writeLine("items = append(items, item)")
+		g.writeLine("continue")
+		g.indent--
+		g.writeLine("}")
+	}
+	g.generateStreamWrite(resourceLower)
 	g.indent--
 	g.writeLine("}")
 	g.writeLine("")
 	g.writeLine("if err := rows.Err(); err != nil {")
 	g.indent++
-	g.writeLine("if response.IsJSONAPI(r) {")
-	g.indent++
-	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Error iterating %s: %%v\", err))", resourceLower+"s")
-	g.indent--
-	g.writeLine("} else {")
-	g.indent++
-	g.writeLine("respondWithError(w, fmt.Sprintf(\"Error iterating %s: %%v\", err), http.StatusInternalServerError)", resourceLower+"s")
-	g.indent--
-	g.writeLine("}")
+	g.writeLine("stream.Fail(fmt.Errorf(\"Error iterating %s: %%v\", err))", resourceLower+"s")
 	g.writeLine("return")
 	g.indent--
 	g.writeLine("}")
 	g.writeLine("")
 
-	// Get total count for pagination (use filtered count)
-	g.writeLine("// Get total count for pagination (with filters applied)")
-	g.writeLine("countQuery := \"SELECT COUNT(*) FROM %s\"", tableName)
-	g.writeLine("if whereClause != \"\" {")
-	g.indent++
-	g.writeLine("countQuery += \" \" + whereClause")
-	g.indent--
-	g.writeLine("}")
-	g.writeLine("")
-	g.writeLine("var total int")
-	g.writeLine("err = db.QueryRowContext(ctx, countQuery, filterArgs...).Scan(&total)")
-	g.writeLine("if err != nil {")
-	g.indent++
-	g.writeLine("if response.IsJSONAPI(r) {")
-	g.indent++
-	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to count %s: %%v\", err))", resourceLower+"s")
-	g.indent--
-	g.writeLine("} else {")
-	g.indent++
-	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to count %s: %%v\", err), http.StatusInternalServerError)", resourceLower+"s")
-	g.indent--
-	g.writeLine("}")
-	g.writeLine("return")
-	g.indent--
-	g.writeLine("}")
-	g.writeLine("")
+	if sideLoad {
+		g.writeLine("if included != nil {")
+		g.indent++
+		g.writeLine("err := %s(ctx, db, r, items, includes, included)", includeFuncName(resource))
+		g.generateIncludeError(resourceLower)
+		g.writeLine("stream.Include(included)")
+		g.writeLine("for _, item := range items {")
+		g.indent++
+		g.generateStreamWrite(resourceLower)
+		g.indent--
+		g.writeLine("}")
+		g.indent--
+		g.writeLine("}")
+		g.writeLine("")
+	}
 
-	// Content negotiation
-	g.writeLine("// Content negotiation: JSON:API or legacy JSON")
-	g.writeLine("if response.IsJSONAPI(r) {")
-	g.indent++
-	g.writeLine("// JSON:API format")
+	// Pagination metadata (used by JSON:API responses only)
+	g.writeLine("// Pagination metadata and links for JSON:API responses")
 	g.writeLine("page := (offset / limit) + 1")
 	g.writeLine("meta := map[string]interface{}{")
 	g.indent++
 	g.writeLine("\"page\": page,")
 	g.writeLine("\"per_page\": limit,")
-	g.writeLine("\"total\": total,")
+	if countStrategy != ast.CountNone {
+		g.writeLine("\"total\": total,")
+	}
+	g.writeLine("\"count\": \"%s\",", countStrategy)
 	g.indent--
 	g.writeLine("}")
-	g.writeLine("links := response.BuildPaginationLinks(r.URL.Path, page, limit, total)")
-	g.writeLine("")
-
-	// Marshal with options
-	g.writeLine("// Marshal with pagination metadata")
-	g.writeLine("opts := []jsonapi.MarshalOption{")
+	if countStrategy == ast.CountNone {
+		// Without a total, a full page is the only signal that more records exist
+		g.writeLine("links := response.BuildPaginationLinksWithoutTotal(r.URL.Path, page, limit, stream.Count() == limit)")
+	} else {
+		g.writeLine("links := response.BuildPaginationLinks(r.URL.Path, page, limit, total)")
+	}
+	g.writeLine("if plan != nil {")
 	g.indent++
-	g.writeLine("jsonapi.MarshalMeta(meta),")
-	g.writeLine("jsonapi.MarshalLinks(links),")
+	g.writeLine("meta[\"explain\"] = plan")
 	g.indent--
 	g.writeLine("}")
-	g.writeLine("")
-	g.writeLine("data, err := jsonapi.Marshal(results, opts...)")
-	g.writeLine("if err != nil {")
-	g.indent++
-	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to marshal response: %%v\", err))")
-	g.writeLine("return")
+	g.writeLine("stream.Close(meta, links)")
+
 	g.indent--
 	g.writeLine("}")
-	g.writeLine("")
+	g.indent--
+	g.writeLine("}")
+}
 
-	// Apply sparse fieldsets
-	g.writeLine("// Apply sparse fieldsets if requested")
-	g.writeLine("if len(fields) > 0 {")
+// generateStreamWrite generates the write of item to the list stream
+func (g *Generator) generateStreamWrite(resourceLower string) {
+	g.writeLine("if err := stream.Write(item); err != nil {")
 	g.indent++
-	g.writeLine("data, err = response.ApplySparseFieldsets(data, fields)")
-	g.writeLine("if err != nil {")
-	g.indent++
-	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to apply sparse fieldsets: %%v\", err))")
+	g.writeLine("stream.Fail(fmt.Errorf(\"Failed to encode %s: %%v\", err))", resourceLower)
 	g.writeLine("return")
 	g.indent--
 	g.writeLine("}")
-	g.indent--
-	g.writeLine("}")
-	g.writeLine("")
+}
 
-	// Write response
-	g.writeLine("w.Header().Set(\"Content-Type\", response.JSONAPIMediaType)")
-	g.writeLine("w.WriteHeader(http.StatusOK)")
-	g.writeLine("w.Write(data)")
+// modificationField returns the field that records when a record last
+// changed: the @auto_update field, or a timestamp named updated_at. Returns nil
+// when the resource has neither, in which case lists are not conditional.
+func modificationField(resource *ast.ResourceNode) *ast.FieldNode {
+	for _, field := range resource.Fields {
+		if hasConstraint(field, "auto_update") {
+			return field
+		}
+	}
+	for _, field := range resource.Fields {
+		if field.Name == "updated_at" && field.Type != nil && field.Type.Kind == ast.TypePrimitive && field.Type.Name == "timestamp" {
+			return field
+		}
+	}
+	return nil
+}
 
-	g.indent--
-	g.writeLine("} else {")
+// generateListCountError generates the error response for a failed list count query
+func (g *Generator) generateListCountError(resourceLower string) {
+	g.writeLine("if err != nil {")
 	g.indent++
-	g.writeLine("// Legacy JSON format")
-	g.writeLine("w.Header().Set(\"Content-Type\", \"application/json\")")
-	g.writeLine("if err := json.NewEncoder(w).Encode(results); err != nil {")
+	g.writeLine("if response.IsJSONAPI(r) {")
 	g.indent++
-	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to encode response: %%v\", err), http.StatusInternalServerError)")
-	g.writeLine("return")
-	g.indent--
-	g.writeLine("}")
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to count %s: %%v\", err))", resourceLower+"s")
 	g.indent--
-	g.writeLine("}")
-
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to count %s: %%v\", err), http.StatusInternalServerError)", resourceLower+"s")
 	g.indent--
 	g.writeLine("}")
+	g.writeLine("return")
 	g.indent--
 	g.writeLine("}")
+	g.writeLine("")
 }
 
 // generateGetHandler generates the GET handler (GET /resources/:id)
 func (g *Generator) generateGetHandler(resource *ast.ResourceNode) {
 	resourceLower := strings.ToLower(resource.Name)
-	tableName := g.toTableName(resource.Name)
 
-	g.writeLine("// Get%sHandler handles GET /%s/{id} - get a single %s",
-		resource.Name, tableName, resourceLower)
+	g.writeLine("// Get%sHandler handles GET %s - get a single %s",
+		resource.Name, g.memberPath(resource), resourceLower)
 	g.writeLine("func Get%sHandler(db *sql.DB) http.HandlerFunc {", resource.Name)
 	g.indent++
 	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
 	g.indent++
 
-	g.writeLine("ctx := r.Context()")
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, \"get\")", resource.Name)
 	g.writeLine("")
+	g.generateVisibleFields(resource)
 
 	// Parse ID from URL
 	g.generateIDParsingCode(resource)
 
-	// Call FindByID function
-	g.writeLine("result, err := models.Find%sByID(ctx, db, id)", resource.Name)
+	// Call FindByID function, or FindByIDWithDeleted when a soft-deleted record
+	// is asked for with ?include_deleted=true, and FindByIDUnscoped when an
+	// admin asks for a record outside the default scope with ?unscoped=true
+	softDelete, defaultScope := softDeleteField(resource) != nil, scoped(resource)
+	if softDelete {
+		g.writeLine("includeDeleted, err := query.ParseIncludeDeleted(r)")
+		g.writeLine("if err != nil {")
+		g.indent++
+		g.writeLine("respondWithError(w, err.Error(), http.StatusBadRequest)")
+		g.writeLine("return")
+		g.indent--
+		g.writeLine("}")
+	}
+	if defaultScope {
+		g.writeLine("unscoped, err := scope.Unscoped(r)")
+		g.writeLine("if err != nil {")
+		g.indent++
+		g.writeLine("respondWithError(w, err.Error(), scope.Status(err))")
+		g.writeLine("return")
+		g.indent--
+		g.writeLine("}")
+	}
+	if softDelete || defaultScope {
+		g.writeLine("find := models.Find%sByID", resource.Name)
+		if defaultScope {
+			g.writeLine("if unscoped {")
+			g.indent++
+			g.writeLine("find = models.Find%sByIDUnscoped", resource.Name)
+			g.indent--
+			g.writeLine("}")
+		}
+		if softDelete {
+			g.writeLine("if includeDeleted {")
+			g.indent++
+			g.writeLine("find = models.Find%sByIDWithDeleted", resource.Name)
+			g.indent--
+			g.writeLine("}")
+		}
+		if softDelete && defaultScope {
+			g.writeLine("if unscoped && includeDeleted {")
+			g.indent++
+			g.writeLine("find = models.Find%sByIDUnscopedWithDeleted", resource.Name)
+			g.indent--
+			g.writeLine("}")
+		}
+		g.writeLine("result, err := find(ctx, db, %s)", g.keyArgs(resource))
+	} else {
+		g.writeLine("result, err := models.Find%sByID(ctx, db, %s)", resource.Name, g.keyArgs(resource))
+	}
 	g.writeLine("if err != nil {")
 	g.indent++
 	g.writeLine("if err == sql.ErrNoRows {")
@@ -506,12 +958,17 @@ func (g *Generator) generateGetHandler(resource *ast.ResourceNode) {
 	g.writeLine("}")
 	g.writeLine("")
 
+	g.generateETagHeader(resource, "result")
+
 	// Content negotiation
 	g.writeLine("// Content negotiation: JSON:API or legacy JSON")
 	g.writeLine("if response.IsJSONAPI(r) {")
 	g.indent++
+	if len(g.includable(resource)) > 0 {
+		g.generateGetIncluded(resource)
+	}
 	g.writeLine("// JSON:API format")
-	g.writeLine("if err := response.RenderJSONAPI(w, http.StatusOK, result); err != nil {")
+	g.writeLine("if err := %s; err != nil {", renderJSONAPI(resource, "http.StatusOK", "result"))
 	g.indent++
 	g.writeLine("respondWithError(w, \"Failed to encode response\", http.StatusInternalServerError)")
 	g.writeLine("return")
@@ -522,7 +979,7 @@ func (g *Generator) generateGetHandler(resource *ast.ResourceNode) {
 	g.indent++
 	g.writeLine("// Legacy JSON format")
 	g.writeLine("w.Header().Set(\"Content-Type\", \"application/json\")")
-	g.writeLine("if err := json.NewEncoder(w).Encode(result); err != nil {")
+	g.writeLine("if err := json.NewEncoder(w).Encode(%s); err != nil {", g.encodedRecord(resource, "result"))
 	g.indent++
 	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to encode response: %v\", err), http.StatusInternalServerError)")
 	g.writeLine("return")
@@ -550,8 +1007,10 @@ func (g *Generator) generateCreateHandler(resource *ast.ResourceNode) {
 	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
 	g.indent++
 
-	g.writeLine("ctx := r.Context()")
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, \"create\")", resource.Name)
 	g.writeLine("")
+	g.generateDryRunRequest("create")
+	g.generateVisibleFields(resource)
 
 	// Branch on content negotiation
 	g.writeLine("// Check if JSON:API format is requested")
@@ -606,12 +1065,16 @@ func (g *Generator) generateCreateHandler(resource *ast.ResourceNode) {
 	g.writeLine("}")
 	g.writeLine("")
 
-	g.writeLine("// Set Location header")
-	g.writeLine("w.Header().Set(\"Location\", fmt.Sprintf(\"/api/%s/%%s\", %s.ID))", tableName, receiverName)
+	g.writeLine("// Set Location header; dry runs created nothing to point to")
+	g.writeLine("if !dryrun.Enabled(ctx) {")
+	g.indent++
+	g.writeLine("w.Header().Set(\"Location\", fmt.Sprintf(\"/api/%s/%%s\", %s))", tableName, g.recordID(resource, receiverName))
+	g.indent--
+	g.writeLine("}")
 	g.writeLine("")
 
 	g.writeLine("// Render JSON:API response")
-	g.writeLine("if err := response.RenderJSONAPI(w, http.StatusCreated, &%s); err != nil {", receiverName)
+	g.writeLine("if err := %s; err != nil {", renderJSONAPI(resource, "dryrun.Status(ctx, http.StatusCreated)", "&"+receiverName))
 	g.indent++
 	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to encode response: %%v\", err))")
 	g.writeLine("return")
@@ -623,11 +1086,11 @@ func (g *Generator) generateCreateHandler(resource *ast.ResourceNode) {
 	g.indent++
 
 	// Legacy JSON path
-	g.writeLine("// Legacy JSON format")
+	g.writeLine("// Legacy JSON format; the body may also be form-encoded, multipart or msgpack")
 	g.writeLine("var %s models.%s", receiverName, resource.Name)
-	g.writeLine("if err := json.NewDecoder(r.Body).Decode(&%s); err != nil {", receiverName)
+	g.writeLine("if err := bind.Decode(r, &%s); err != nil {", receiverName)
 	g.indent++
-	g.writeLine("respondWithError(w, fmt.Sprintf(\"Invalid request body: %%v\", err), http.StatusBadRequest)")
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Invalid request body: %v\", err), bind.StatusCode(err))")
 	g.writeLine("return")
 	g.indent--
 	g.writeLine("}")
@@ -643,8 +1106,8 @@ func (g *Generator) generateCreateHandler(resource *ast.ResourceNode) {
 	g.writeLine("")
 
 	g.writeLine("w.Header().Set(\"Content-Type\", \"application/json\")")
-	g.writeLine("w.WriteHeader(http.StatusCreated)")
-	g.writeLine("if err := json.NewEncoder(w).Encode(%s); err != nil {", receiverName)
+	g.writeLine("w.WriteHeader(dryrun.Status(ctx, http.StatusCreated))")
+	g.writeLine("if err := json.NewEncoder(w).Encode(%s); err != nil {", g.encodedRecord(resource, receiverName))
 	g.indent++
 	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to encode response: %%v\", err), http.StatusInternalServerError)")
 	g.writeLine("return")
@@ -663,23 +1126,29 @@ func (g *Generator) generateCreateHandler(resource *ast.ResourceNode) {
 // generateUpdateHandler generates the UPDATE handler (PUT /resources/:id)
 func (g *Generator) generateUpdateHandler(resource *ast.ResourceNode) {
 	resourceLower := strings.ToLower(resource.Name)
-	tableName := g.toTableName(resource.Name)
 	receiverName := strings.ToLower(resource.Name[0:1])
 	idType := g.getIDType(resource)
 
-	g.writeLine("// Update%sHandler handles PUT /%s/{id} - update an existing %s",
-		resource.Name, tableName, resourceLower)
+	g.writeLine("// Update%sHandler handles PUT %s - update an existing %s",
+		resource.Name, g.memberPath(resource), resourceLower)
 	g.writeLine("func Update%sHandler(db *sql.DB) http.HandlerFunc {", resource.Name)
 	g.indent++
 	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
 	g.indent++
 
-	g.writeLine("ctx := r.Context()")
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, \"update\")", resource.Name)
 	g.writeLine("")
+	g.generateDryRunRequest("update")
+	g.generateVisibleFields(resource)
 
 	// Parse ID from URL
 	g.generateIDParsingCode(resource)
 
+	if checksConflicts(resource) {
+		g.generateLoadCurrent(resource)
+		g.generateConflictCheck(resource, "current")
+	}
+
 	// Branch on content negotiation
 	g.writeLine("// Check if JSON:API format is requested")
 	g.writeLine("if response.IsJSONAPI(r) {")
@@ -726,11 +1195,22 @@ func (g *Generator) generateUpdateHandler(resource *ast.ResourceNode) {
 
 	// Validate ID matches URL  (allow zero UUID in create requests)
 	g.writeLine("// Validate ID matches URL")
-	if idType == "uuid" {
-		g.writeLine("if %s.ID != uuid.Nil && %s.ID != id {", receiverName, receiverName)
-	} else {
+	key := g.keyValue(resource, receiverName)
+	if hasCompositeKey(resource) {
+		// UnmarshalID sets every key field from the id, which JSON:API
+		// updates must send
+		var mismatches []string
+		for i, value := range g.keyValues(resource, receiverName) {
+			mismatches = append(mismatches, fmt.Sprintf("%s != %s", value, g.keyVars(resource)[i]))
+		}
+		g.writeLine("if %s {", strings.Join(mismatches, " || "))
+	} else if idType == "uuid" {
+		g.writeLine("if %s != uuid.Nil && %s != id {", key, key)
+	} else if idType == "int" {
 		// Ensure type compatibility for integer IDs by converting both to int64
-		g.writeLine("if int64(%s.ID) != id {", receiverName)
+		g.writeLine("if int64(%s) != id {", key)
+	} else {
+		g.writeLine("if %s != \"\" && %s != id {", key, key)
 	}
 	g.indent++
 	g.writeLine("response.RenderJSONAPIError(w, http.StatusConflict, fmt.Errorf(\"ID in request body doesn't match URL\"))")
@@ -748,8 +1228,9 @@ func (g *Generator) generateUpdateHandler(resource *ast.ResourceNode) {
 	g.writeLine("}")
 	g.writeLine("")
 
+	g.generateETagHeader(resource, receiverName)
 	g.writeLine("// Render JSON:API response")
-	g.writeLine("if err := response.RenderJSONAPI(w, http.StatusOK, &%s); err != nil {", receiverName)
+	g.writeLine("if err := %s; err != nil {", renderJSONAPI(resource, "http.StatusOK", "&"+receiverName))
 	g.indent++
 	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to encode response: %%v\", err))")
 	g.writeLine("return")
@@ -761,18 +1242,20 @@ func (g *Generator) generateUpdateHandler(resource *ast.ResourceNode) {
 	g.indent++
 
 	// Legacy JSON path
-	g.writeLine("// Legacy JSON format")
+	g.writeLine("// Legacy JSON format; the body may also be form-encoded, multipart or msgpack")
 	g.writeLine("var %s models.%s", receiverName, resource.Name)
-	g.writeLine("if err := json.NewDecoder(r.Body).Decode(&%s); err != nil {", receiverName)
+	g.writeLine("if err := bind.Decode(r, &%s); err != nil {", receiverName)
 	g.indent++
-	g.writeLine("respondWithError(w, fmt.Sprintf(\"Invalid request body: %%v\", err), http.StatusBadRequest)")
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Invalid request body: %v\", err), bind.StatusCode(err))")
 	g.writeLine("return")
 	g.indent--
 	g.writeLine("}")
 	g.writeLine("")
 
 	g.writeLine("// Set ID from URL")
-	g.writeLine("%s.ID = id", receiverName)
+	for i, value := range g.keyValues(resource, receiverName) {
+		g.writeLine("%s = %s", value, g.keyVars(resource)[i])
+	}
 	g.writeLine("")
 
 	g.writeLine("// Update %s (includes validation and hooks)", resourceLower)
@@ -784,8 +1267,9 @@ func (g *Generator) generateUpdateHandler(resource *ast.ResourceNode) {
 	g.writeLine("}")
 	g.writeLine("")
 
+	g.generateETagHeader(resource, receiverName)
 	g.writeLine("w.Header().Set(\"Content-Type\", \"application/json\")")
-	g.writeLine("if err := json.NewEncoder(w).Encode(%s); err != nil {", receiverName)
+	g.writeLine("if err := json.NewEncoder(w).Encode(%s); err != nil {", g.encodedRecord(resource, receiverName))
 	g.indent++
 	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to encode response: %%v\", err), http.StatusInternalServerError)")
 	g.writeLine("return")
@@ -804,24 +1288,25 @@ func (g *Generator) generateUpdateHandler(resource *ast.ResourceNode) {
 // generatePatchHandler generates the PATCH handler (PATCH /resources/:id) for partial updates
 func (g *Generator) generatePatchHandler(resource *ast.ResourceNode) {
 	resourceLower := strings.ToLower(resource.Name)
-	tableName := g.toTableName(resource.Name)
 
-	g.writeLine("// Patch%sHandler handles PATCH /%s/{id} - partially update an existing %s",
-		resource.Name, tableName, resourceLower)
+	g.writeLine("// Patch%sHandler handles PATCH %s - partially update an existing %s",
+		resource.Name, g.memberPath(resource), resourceLower)
 	g.writeLine("func Patch%sHandler(db *sql.DB) http.HandlerFunc {", resource.Name)
 	g.indent++
 	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
 	g.indent++
 
-	g.writeLine("ctx := r.Context()")
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, \"patch\")", resource.Name)
 	g.writeLine("")
+	g.generateDryRunRequest("patch")
+	g.generateVisibleFields(resource)
 
 	// Parse ID from URL
 	g.generateIDParsingCode(resource)
 
 	// Fetch existing resource
 	g.writeLine("// Fetch existing %s", resourceLower)
-	g.writeLine("existing, err := models.Find%sByID(ctx, db, id)", resource.Name)
+	g.writeLine("existing, err := models.Find%sByID(ctx, db, %s)", resource.Name, g.keyArgs(resource))
 	g.writeLine("if err != nil {")
 	g.indent++
 	g.writeLine("if err == sql.ErrNoRows {")
@@ -852,6 +1337,10 @@ func (g *Generator) generatePatchHandler(resource *ast.ResourceNode) {
 	g.writeLine("}")
 	g.writeLine("")
 
+	if checksConflicts(resource) {
+		g.generateConflictCheck(resource, "existing")
+	}
+
 	// Branch on content negotiation
 	g.writeLine("// Check if JSON:API format is requested")
 	g.writeLine("if response.IsJSONAPI(r) {")
@@ -895,8 +1384,9 @@ func (g *Generator) generatePatchHandler(resource *ast.ResourceNode) {
 	g.writeLine("}")
 	g.writeLine("")
 
+	g.generateETagHeader(resource, "existing")
 	g.writeLine("// Render JSON:API response")
-	g.writeLine("if err := response.RenderJSONAPI(w, http.StatusOK, existing); err != nil {")
+	g.writeLine("if err := %s; err != nil {", renderJSONAPI(resource, "http.StatusOK", "existing"))
 	g.indent++
 	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to encode response: %%v\", err))")
 	g.writeLine("return")
@@ -908,11 +1398,11 @@ func (g *Generator) generatePatchHandler(resource *ast.ResourceNode) {
 	g.indent++
 
 	// Legacy JSON path
-	g.writeLine("// Legacy JSON format")
-	g.writeLine("body, err := io.ReadAll(r.Body)")
+	g.writeLine("// Legacy JSON merge patch; the body may also be form-encoded, multipart or msgpack")
+	g.writeLine("body, err := bind.JSON(r, existing)")
 	g.writeLine("if err != nil {")
 	g.indent++
-	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to read request body: %%v\", err), http.StatusBadRequest)")
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Invalid request body: %v\", err), bind.StatusCode(err))")
 	g.writeLine("return")
 	g.indent--
 	g.writeLine("}")
@@ -927,8 +1417,9 @@ func (g *Generator) generatePatchHandler(resource *ast.ResourceNode) {
 	g.writeLine("}")
 	g.writeLine("")
 
+	g.generateETagHeader(resource, "existing")
 	g.writeLine("w.Header().Set(\"Content-Type\", \"application/json\")")
-	g.writeLine("if err := json.NewEncoder(w).Encode(existing); err != nil {")
+	g.writeLine("if err := json.NewEncoder(w).Encode(%s); err != nil {", g.encodedRecord(resource, "existing"))
 	g.indent++
 	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to encode response: %%v\", err), http.StatusInternalServerError)")
 	g.writeLine("return")
@@ -947,17 +1438,16 @@ func (g *Generator) generatePatchHandler(resource *ast.ResourceNode) {
 // generateDeleteHandler generates the DELETE handler (DELETE /resources/:id)
 func (g *Generator) generateDeleteHandler(resource *ast.ResourceNode) {
 	resourceLower := strings.ToLower(resource.Name)
-	tableName := g.toTableName(resource.Name)
 	receiverName := strings.ToLower(resource.Name[0:1])
 
-	g.writeLine("// Delete%sHandler handles DELETE /%s/{id} - delete a %s",
-		resource.Name, tableName, resourceLower)
+	g.writeLine("// Delete%sHandler handles DELETE %s - delete a %s",
+		resource.Name, g.memberPath(resource), resourceLower)
 	g.writeLine("func Delete%sHandler(db *sql.DB) http.HandlerFunc {", resource.Name)
 	g.indent++
 	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
 	g.indent++
 
-	g.writeLine("ctx := r.Context()")
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, \"delete\")", resource.Name)
 	g.writeLine("")
 
 	// Parse ID from URL
@@ -965,7 +1455,7 @@ func (g *Generator) generateDeleteHandler(resource *ast.ResourceNode) {
 
 	// Fetch existing resource
 	g.writeLine("// Fetch existing %s", resourceLower)
-	g.writeLine("%s, err := models.Find%sByID(ctx, db, id)", receiverName, resource.Name)
+	g.writeLine("%s, err := models.Find%sByID(ctx, db, %s)", receiverName, resource.Name, g.keyArgs(resource))
 	g.writeLine("if err != nil {")
 	g.indent++
 	g.writeLine("if err == sql.ErrNoRows {")
@@ -1028,16 +1518,8 @@ func (g *Generator) generateDeleteHandler(resource *ast.ResourceNode) {
 func (g *Generator) generateScanFields(resource *ast.ResourceNode) string {
 	var scanFields []string
 
-	// Always include ID field first if not explicitly defined
-	hasID := false
-	for _, field := range resource.Fields {
-		if field.Name == "id" {
-			hasID = true
-			break
-		}
-	}
-
-	if !hasID {
+	// Always include ID field first if no primary key is declared
+	if hasImplicitID(resource) {
 		scanFields = append(scanFields, "&item.ID")
 	}
 
@@ -72,9 +72,71 @@ func TestGenerateListHandler(t *testing.T) {
 		t.Error("Generated code should call FindAllPost")
 	}
 
-	// Verify JSON response
-	if !strings.Contains(code, "json.NewEncoder(w).Encode(results)") {
-		t.Error("Generated code should encode JSON response")
+	// Verify rows are streamed to the response
+	if !strings.Contains(code, "stream.Write(item)") {
+		t.Error("Generated code should stream each row to the response")
+	}
+
+	// Verify queries are attributed to the resource and operation
+	if !strings.Contains(code, `ctx := instrument.WithOperation(r.Context(), "Post", "list")`) {
+		t.Error("Generated code should tag list queries with Post.list")
+	}
+}
+
+func TestGenerateListHandler_ConditionalGet(t *testing.T) {
+	timestamp := &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"}
+
+	tests := []struct {
+		name     string
+		resource *ast.ResourceNode
+		want     string
+	}{
+		{
+			name: "auto_update field",
+			resource: &ast.ResourceNode{
+				Name: "Post",
+				Fields: []*ast.FieldNode{
+					{Name: "modified", Type: timestamp, Constraints: []*ast.ConstraintNode{{Name: "auto_update"}}},
+				},
+			},
+			want: `version, err := query.LatestVersion(ctx, db, "posts", "modified", true)`,
+		},
+		{
+			name: "updated_at without a count",
+			resource: &ast.ResourceNode{
+				Name:          "Event",
+				CountStrategy: ast.CountNone,
+				Fields:        []*ast.FieldNode{{Name: "updated_at", Type: timestamp}},
+			},
+			want: `version, err := query.LatestVersion(ctx, db, "events", "updated_at", false)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator()
+			gen.reset()
+			gen.generateListHandler(tt.resource)
+			code := gen.buf.String()
+
+			for _, exp := range []string{tt.want, "if response.NotModified(w, r, version.LastModified, version.ETag()) {"} {
+				if !strings.Contains(code, exp) {
+					t.Errorf("Generated code missing %q", exp)
+				}
+			}
+			// The check runs before the count and the list query
+			if strings.Index(code, "query.LatestVersion") > strings.Index(code, "db.QueryContext(ctx, listQuery") {
+				t.Error("Conditional GET should be checked before the list query")
+			}
+		})
+	}
+
+	// Without a modification timestamp there is nothing to compare
+	gen := NewGenerator()
+	gen.reset()
+	gen.generateListHandler(&ast.ResourceNode{Name: "Tag", Fields: []*ast.FieldNode{{Name: "label", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}}}})
+	if strings.Contains(gen.buf.String(), "NotModified") {
+		t.Error("Resources without a modification timestamp should not use conditional GET")
 	}
 }
 
@@ -146,7 +208,7 @@ func TestGenerateCreateHandler(t *testing.T) {
 		t.Error("Generated code should declare Article variable")
 	}
 
-	if !strings.Contains(code, "json.NewDecoder(r.Body).Decode(&a)") {
+	if !strings.Contains(code, "bind.Decode(r, &a)") {
 		t.Error("Generated code should decode request body")
 	}
 
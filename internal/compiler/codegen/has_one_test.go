@@ -0,0 +1,47 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const hasOneSource = `resource User {
+  email: string!
+
+  profile: Profile? {
+    kind: has_one
+  }
+}
+
+resource Profile {
+  bio: text!
+  user_id: int!
+}`
+
+func TestGenerateMigrations_HasOne(t *testing.T) {
+	sql, err := NewGenerator().GenerateMigrations(parseResources(t, hasOneSource))
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+
+	// The foreign key lives on the related table, one record per owner
+	if !strings.Contains(sql, "CREATE UNIQUE INDEX idx_profiles_user_id ON profiles(user_id);") {
+		t.Errorf("Migration should make the has_one foreign key unique:\n%s", sql)
+	}
+	if strings.Contains(sql, "ON users(") {
+		t.Errorf("The owning table should not change:\n%s", sql)
+	}
+
+	// A foreign key declared @unique is indexed once
+	resources := parseResources(t, hasOneSource)
+	resources[1].Fields[1].Constraints = []*ast.ConstraintNode{{Name: "unique"}}
+	sql, err = NewGenerator().GenerateMigrations(resources)
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+	if count := strings.Count(sql, "idx_profiles_user_id"); count != 1 {
+		t.Errorf("Expected one index on user_id, got %d:\n%s", count, sql)
+	}
+}
@@ -1,7 +1,9 @@
 package codegen
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
 )
@@ -29,19 +31,63 @@ func (g *Generator) generateHooks(resource *ast.ResourceNode) string {
 func (g *Generator) generateHook(resource *ast.ResourceNode, hook *ast.HookNode) string {
 	receiverName := strings.ToLower(resource.Name[0:1])
 
-	// Generate method name: BeforeCreate, AfterUpdate, etc.
-	methodName := strings.Title(hook.Timing) + strings.Title(hook.Event)
+	// Generate method name: BeforeCreate, AfterUpdate, BeforeAttachTags, etc.
+	methodName := g.hookMethodName(hook)
 
-	// Build method signature
+	// Build method signature; attach and detach hooks also get the linked record
 	g.reset()
-	g.writeLine("// %s is called %s %s", methodName, hook.Timing, hook.Event)
-	g.writeLine("func (%s *%s) %s(ctx context.Context, db *sql.DB) error {",
-		receiverName, resource.Name, methodName)
+	g.batchHook = hook.Batch
+	defer func() { g.batchHook = false }()
+	if hook.Batch {
+		// Batch hooks have no receiver; they see all the records of the create
+		methodName = g.batchHookName(resource, hook)
+		g.writeLine("// %s is called %s creating %s, once with all the records", methodName, hook.Timing, g.toTableName(resource.Name))
+		g.writeLine("func %s(ctx context.Context, db *sql.DB, %s []*%s) error {",
+			methodName, ast.BatchHookVariable, resource.Name)
+	} else if rel := resource.FindRelationship(hook.Relationship); rel != nil {
+		change := "attached to"
+		if hook.Event == ast.HookEventDetach {
+			change = "detached from"
+		}
+		g.writeLine("// %s is called %s a %s is %s the %s", methodName, hook.Timing, rel.Type, change, resource.Name)
+		g.writeLine("func (%s *%s) %s(ctx context.Context, db *sql.DB, %s *%s) error {",
+			receiverName, resource.Name, methodName, rel.HookVariable(), rel.Type)
+	} else {
+		g.writeLine("// %s is called %s %s", methodName, hook.Timing, hook.Event)
+		g.writeLine("func (%s *%s) %s(ctx context.Context, db *sql.DB) error {",
+			receiverName, resource.Name, methodName)
+	}
 	g.indent++
 
-	// Generate transaction wrapper if needed
+	name := methodName
+	if !hook.Batch {
+		name = resource.Name + "." + methodName
+	}
+
+	// Error tracking runs outermost, so it sees the error the operation fails with
+	if g.errorTracking.Enabled {
+		source := g.sourceLocation(resource, hook.Loc.Line)
+		g.trackedHook = [2]string{name, source}
+		defer func() { g.trackedHook = [2]string{} }()
+		g.writeLine("return errortrack.Hook(ctx, %q, %q, func(ctx context.Context) error {", name, source)
+		g.indent++
+	}
+
+	// @timeout cancels the context of the body, retries included
+	if hook.Timeout > 0 {
+		g.writeLine("return hooks.WithTimeout(ctx, %q, %s, func(ctx context.Context) error {", name, durationLiteral(hook.Timeout))
+		g.indent++
+	}
+
+	// @on_error runs the body under its failure policy
+	if hook.OnError != nil {
+		g.writeLine("return hooks.Run(ctx, %q, %s, func() error {", name, hookPolicyLiteral(hook.OnError))
+		g.indent++
+	}
+
+	// Generate transaction wrapper if needed; it is rolled back when ctx is canceled
 	if hook.IsTransaction {
-		g.writeLine("tx, err := db.Begin()")
+		g.writeLine("tx, err := db.BeginTx(ctx, nil)")
 		g.writeLine("if err != nil {")
 		g.indent++
 		g.writeLine("return fmt.Errorf(\"failed to begin transaction: %w\", err)")
@@ -77,26 +123,77 @@ func (g *Generator) generateHook(resource *ast.ResourceNode, hook *ast.HookNode)
 
 	g.writeLine("")
 	g.writeLine("return nil")
+	if hook.OnError != nil {
+		g.indent--
+		g.writeLine("})")
+	}
+	if hook.Timeout > 0 {
+		g.indent--
+		g.writeLine("})")
+	}
+	if g.errorTracking.Enabled {
+		g.indent--
+		g.writeLine("})")
+	}
 	g.indent--
 	g.writeLine("}")
 
 	return g.buf.String()
 }
 
+// durationLiteral returns the Go expression of a duration, e.g. 2*time.Second
+func durationLiteral(d time.Duration) string {
+	if d%time.Second == 0 {
+		return fmt.Sprintf("%d*time.Second", d/time.Second)
+	}
+	return fmt.Sprintf("%d*time.Millisecond", d/time.Millisecond)
+}
+
+// hookPolicyLiteral returns the hooks.Policy literal of an @on_error policy
+func hookPolicyLiteral(onError *ast.OnErrorNode) string {
+	var fields []string
+	if onError.Retry > 0 {
+		fields = append(fields, fmt.Sprintf("Retries: %d", onError.Retry), "Backoff: hooks."+strings.Title(onError.Backoff))
+	}
+	if onError.Policy == ast.HookPolicyWarn {
+		fields = append(fields, "OnFailure: hooks.Warn")
+	}
+	return "hooks.Policy{" + strings.Join(fields, ", ") + "}"
+}
+
+// hookMethodName returns the name of the method generated for a hook, e.g.
+// BeforeCreate, or AfterAttachTags for @after attach(tags)
+func (g *Generator) hookMethodName(hook *ast.HookNode) string {
+	name := strings.Title(hook.Timing) + strings.Title(hook.Event)
+	if hook.Relationship != "" {
+		name += g.toGoFieldName(hook.Relationship)
+	}
+	return name
+}
+
 // generateAsyncBlock generates code for an @async block
 func (g *Generator) generateAsyncBlock(resource *ast.ResourceNode, block *ast.BlockStmt) {
 	receiverName := strings.ToLower(resource.Name[0:1])
 
 	// If we're in a transaction context, commit before spawning goroutine
 	// This is a simplification - in production, we'd check parent context
+	// The goroutine outlives the request, so it gets a context that is not
+	// canceled with it but still carries its request ID
+	g.imports["context"] = true
+	g.imports["github.com/conduit-lang/conduit/pkg/web/correlation"] = true
 	g.writeLine("// Async block - runs in background after response")
-	g.writeLine("go func() {")
+	g.writeLine("go func(ctx context.Context) {")
 	g.indent++
+	if g.errorTracking.Enabled {
+		g.writeLine("defer errortrack.Recover(ctx, %q, %q)", g.trackedHook[0], g.trackedHook[1])
+	}
 
-	// Copy receiver for use in goroutine
-	g.writeLine("// Copy resource for async access")
-	g.writeLine("asyncResource := *%s", receiverName)
-	g.writeLine("")
+	// Copy receiver for use in goroutine; batch hooks have none
+	if !g.batchHook {
+		g.writeLine("// Copy resource for async access")
+		g.writeLine("asyncResource := *%s", receiverName)
+		g.writeLine("")
+	}
 
 	// Generate async block statements
 	for _, stmt := range block.Statements {
@@ -104,7 +201,7 @@ func (g *Generator) generateAsyncBlock(resource *ast.ResourceNode, block *ast.Bl
 	}
 
 	g.indent--
-	g.writeLine("}()")
+	g.writeLine("}(correlation.Detach(ctx))")
 }
 
 // generateStatement generates Go code for a statement
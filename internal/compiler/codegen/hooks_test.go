@@ -1,8 +1,10 @@
 package codegen
 
 import (
+	"go/format"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
 )
@@ -89,7 +91,7 @@ func TestGenerateHooks_AfterCreateWithTransaction(t *testing.T) {
 	}
 
 	// Verify transaction wrapper
-	if !strings.Contains(hooksCode, "tx, err := db.Begin()") {
+	if !strings.Contains(hooksCode, "tx, err := db.BeginTx(ctx, nil)") {
 		t.Error("Generated code should begin transaction")
 	}
 
@@ -133,10 +135,18 @@ func TestGenerateHooks_WithAsyncBlock(t *testing.T) {
 	hooksCode := gen.generateHooks(resource)
 
 	// Verify async block with goroutine
-	if !strings.Contains(hooksCode, "go func()") {
+	if !strings.Contains(hooksCode, "go func(ctx context.Context) {") {
 		t.Error("Generated code should contain goroutine for async block")
 	}
 
+	// The goroutine outlives the request but keeps its request ID
+	if !strings.Contains(hooksCode, "}(correlation.Detach(ctx))") {
+		t.Error("Async block should run with a detached context carrying the request ID")
+	}
+	if !gen.imports["github.com/conduit-lang/conduit/pkg/web/correlation"] {
+		t.Error("Async block should import the correlation package")
+	}
+
 	// Verify async resource copy
 	if !strings.Contains(hooksCode, "asyncResource := *o") {
 		t.Error("Generated code should copy resource for async access")
@@ -221,3 +231,105 @@ func TestGenerateStatement_LetStatement(t *testing.T) {
 		t.Errorf("Generated code should contain let statement, got: %s", code)
 	}
 }
+
+func TestGenerateHooks_OnError(t *testing.T) {
+	title := &ast.AssignmentStmt{
+		Target: &ast.FieldAccessExpr{Object: &ast.SelfExpr{}, Field: "title"},
+		Value:  &ast.FieldAccessExpr{Object: &ast.SelfExpr{}, Field: "title"},
+	}
+	resource := parseResource(t, postSource)
+	resource.Hooks = []*ast.HookNode{
+		{Timing: "before", Event: "create", Body: []ast.StmtNode{title},
+			OnError: &ast.OnErrorNode{Retry: 3, Backoff: ast.BackoffExponential, Policy: ast.HookPolicyAbort}},
+		{Timing: "after", Event: "update", IsTransaction: true, Body: []ast.StmtNode{title},
+			OnError: &ast.OnErrorNode{Retry: 2, Backoff: ast.BackoffConstant, Policy: ast.HookPolicyWarn}},
+		{Timing: "after", Event: "create", Batch: true,
+			OnError: &ast.OnErrorNode{Policy: ast.HookPolicyWarn}},
+		{Timing: "after", Event: "delete", Body: []ast.StmtNode{title}},
+	}
+
+	code, err := NewGenerator().GenerateResourceWithHooks(resource)
+	if err != nil {
+		t.Fatalf("GenerateResourceWithHooks failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+	if !strings.Contains(code, `"github.com/conduit-lang/conduit/pkg/web/hooks"`) {
+		t.Error("Missing hooks import")
+	}
+
+	tests := []struct {
+		signature string
+		want      string
+	}{
+		{"func (p *Post) BeforeCreate(ctx context.Context, db *sql.DB) error {",
+			`return hooks.Run(ctx, "Post.BeforeCreate", hooks.Policy{Retries: 3, Backoff: hooks.Exponential}, func() error {`},
+		{"func (p *Post) AfterUpdate(ctx context.Context, db *sql.DB) error {",
+			`return hooks.Run(ctx, "Post.AfterUpdate", hooks.Policy{Retries: 2, Backoff: hooks.Constant, OnFailure: hooks.Warn}, func() error {`},
+		{"func AfterCreatePostBatch(ctx context.Context, db *sql.DB, records []*Post) error {",
+			`return hooks.Run(ctx, "AfterCreatePostBatch", hooks.Policy{OnFailure: hooks.Warn}, func() error {`},
+	}
+	for _, tt := range tests {
+		body := functionBody(t, code, tt.signature)
+		if !strings.Contains(body, tt.want) {
+			t.Errorf("Hook missing %q:\n%s", tt.want, body)
+		}
+	}
+
+	// Each attempt runs in its own transaction
+	update := functionBody(t, code, "func (p *Post) AfterUpdate(ctx context.Context, db *sql.DB) error {")
+	if strings.Index(update, "hooks.Run") > strings.Index(update, "tx, err := db.BeginTx(ctx, nil)") {
+		t.Errorf("The transaction should begin inside the retried function:\n%s", update)
+	}
+
+	if strings.Contains(functionBody(t, code, "func (p *Post) AfterDelete(ctx context.Context, db *sql.DB) error {"), "hooks.Run") {
+		t.Error("Hooks without @on_error should run as is")
+	}
+}
+
+func TestGenerateHooks_Timeout(t *testing.T) {
+	title := &ast.AssignmentStmt{
+		Target: &ast.FieldAccessExpr{Object: &ast.SelfExpr{}, Field: "title"},
+		Value:  &ast.FieldAccessExpr{Object: &ast.SelfExpr{}, Field: "title"},
+	}
+	resource := parseResource(t, postSource)
+	resource.Hooks = []*ast.HookNode{
+		{Timing: "before", Event: "create", IsTransaction: true, Body: []ast.StmtNode{title},
+			Timeout: 2 * time.Second,
+			OnError: &ast.OnErrorNode{Retry: 3, Backoff: ast.BackoffExponential, Policy: ast.HookPolicyAbort}},
+		{Timing: "after", Event: "create", Batch: true, Timeout: 500 * time.Millisecond},
+		{Timing: "after", Event: "delete", Body: []ast.StmtNode{title}},
+	}
+
+	code, err := NewGenerator().GenerateResourceWithHooks(resource)
+	if err != nil {
+		t.Fatalf("GenerateResourceWithHooks failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+	for _, want := range []string{`"github.com/conduit-lang/conduit/pkg/web/hooks"`, `"time"`} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Missing import %s", want)
+		}
+	}
+
+	create := functionBody(t, code, "func (p *Post) BeforeCreate(ctx context.Context, db *sql.DB) error {")
+	if !strings.Contains(create, `return hooks.WithTimeout(ctx, "Post.BeforeCreate", 2*time.Second, func(ctx context.Context) error {`) {
+		t.Errorf("BeforeCreate should run under its timeout:\n%s", create)
+	}
+	// The timeout covers every attempt, and the transaction uses the hook's context
+	if strings.Index(create, "hooks.WithTimeout") > strings.Index(create, "hooks.Run") {
+		t.Errorf("The timeout should wrap the retries:\n%s", create)
+	}
+
+	batch := functionBody(t, code, "func AfterCreatePostBatch(ctx context.Context, db *sql.DB, records []*Post) error {")
+	if !strings.Contains(batch, `return hooks.WithTimeout(ctx, "AfterCreatePostBatch", 500*time.Millisecond, func(ctx context.Context) error {`) {
+		t.Errorf("AfterCreatePostBatch should run under its timeout:\n%s", batch)
+	}
+
+	if strings.Contains(functionBody(t, code, "func (p *Post) AfterDelete(ctx context.Context, db *sql.DB) error {"), "hooks.WithTimeout") {
+		t.Error("Hooks without @timeout should run as is")
+	}
+}
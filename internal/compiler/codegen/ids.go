@@ -0,0 +1,68 @@
+package codegen
+
+import (
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// generatesIDs reports whether the models of a resource use the ids package:
+// to generate IDs with an @id strategy, or @auto ulid fields
+func generatesIDs(resource *ast.ResourceNode) bool {
+	if resource.IDStrategy != nil {
+		return true
+	}
+	for _, field := range resource.Fields {
+		if field.Type.Name == "ulid" && hasConstraint(field, "auto") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSnowflakeIDs reports whether any resource generates snowflake IDs, whose
+// node the server sets at startup
+func hasSnowflakeIDs(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if resource.IDStrategy != nil && resource.IDStrategy.Strategy == ast.IDStrategySnowflake {
+			return true
+		}
+	}
+	return false
+}
+
+// generateStrategyID generates the part of Create() that gives a new record
+// of a resource with an @id strategy its ID. An ID the client generated with
+// the same strategy is kept, so clients can refer to records before creating
+// them; any other ID fails validation.
+func (g *Generator) generateStrategyID(resource *ast.ResourceNode, receiverName string) {
+	var zero, generate, validate string
+	switch resource.IDStrategy.Strategy {
+	case ast.IDStrategyULID:
+		zero, generate, validate = `""`, "ids.NewULID()", "ids.ValidateULID"
+	case ast.IDStrategyUUIDv7:
+		zero, generate, validate = "uuid.Nil", "ids.NewUUIDv7()", "ids.ValidateUUIDv7"
+	case ast.IDStrategySnowflake:
+		zero, generate, validate = "0", "ids.NewSnowflake()", "ids.ValidateSnowflake"
+	}
+
+	g.writeLine("// Keep an ID the client generated (@id(strategy: %s))", resource.IDStrategy.Strategy)
+	g.writeLine("if %s.ID == %s {", receiverName, zero)
+	g.indent++
+	g.writeLine("%s.ID = %s", receiverName, generate)
+	g.indent--
+	g.writeLine("} else if err := %s(%s.ID); err != nil {", validate, receiverName)
+	g.indent++
+	g.writeLine("return fmt.Errorf(\"validation failed: %w\", err)")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateSnowflakeNode sets the node written into snowflake IDs
+func (g *Generator) generateSnowflakeNode() {
+	g.writeLine("// Give this server its own snowflake node (CONDUIT_NODE_ID) so IDs stay unique across servers")
+	g.writeLine("if err := ids.SetNodeFromEnv(); err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(%q, err)", "Failed to configure ID generation: %v")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
@@ -0,0 +1,178 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// idStrategySource declares an Order whose IDs follow strategy
+func idStrategySource(strategy string) string {
+	return fmt.Sprintf(`resource Order {
+  @id(strategy: %s)
+
+  id: %s! @primary @auto
+  total: int!
+}`, strategy, (&ast.IDStrategyNode{Strategy: strategy}).FieldType())
+}
+
+func TestGenerateResource_IDStrategy(t *testing.T) {
+	tests := []struct {
+		strategy string
+		idType   string
+		want     []string
+	}{
+		{ast.IDStrategyULID, "string", []string{
+			`if o.ID == "" {`,
+			"o.ID = ids.NewULID()",
+			"} else if err := ids.ValidateULID(o.ID); err != nil {",
+		}},
+		{ast.IDStrategyUUIDv7, "uuid.UUID", []string{
+			"if o.ID == uuid.Nil {",
+			"o.ID = ids.NewUUIDv7()",
+			"} else if err := ids.ValidateUUIDv7(o.ID); err != nil {",
+		}},
+		{ast.IDStrategySnowflake, "int64", []string{
+			"if o.ID == 0 {",
+			"o.ID = ids.NewSnowflake()",
+			"} else if err := ids.ValidateSnowflake(o.ID); err != nil {",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			code := generateModel(t, parseResource(t, idStrategySource(tt.strategy)))
+			if !strings.Contains(code, `"github.com/conduit-lang/conduit/pkg/web/ids"`) {
+				t.Error("Missing ids import")
+			}
+			if !strings.Contains(strings.Join(strings.Fields(code), " "), "ID "+tt.idType+" `jsonapi:\"primary,orders,omitempty\"") {
+				t.Errorf("ID should be a %s", tt.idType)
+			}
+
+			// The generated ID is inserted rather than read back
+			create := functionBody(t, code, "func (o *Order) Create(ctx context.Context, db *sql.DB) error {")
+			for _, want := range append(tt.want, `return fmt.Errorf("validation failed: %w", err)`, "INSERT INTO orders (id, total) VALUES ($1, $2)") {
+				if !strings.Contains(create, want) {
+					t.Errorf("Create missing %q:\n%s", want, create)
+				}
+			}
+			if strings.Contains(create, "RETURNING id") {
+				t.Errorf("Create should not read back a generated ID:\n%s", create)
+			}
+		})
+	}
+}
+
+func TestGenerateMigrations_IDStrategy(t *testing.T) {
+	sql, err := NewGenerator().GenerateMigrations([]*ast.ResourceNode{
+		parseResource(t, idStrategySource(ast.IDStrategyULID)),
+	})
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+	if !strings.Contains(sql, "id CHAR(26) NOT NULL PRIMARY KEY") {
+		t.Errorf("Migration should store ULIDs as CHAR(26):\n%s", sql)
+	}
+}
+
+func TestGenerateHandlers_ULIDIDs(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, idStrategySource(ast.IDStrategyULID)))
+
+	get := functionBody(t, code, "func GetOrderHandler(db *sql.DB) http.HandlerFunc {")
+	for _, want := range []string{"id := idStr", "err := ids.ValidateULID(id)", `respondWithError(w, "Invalid ID", http.StatusBadRequest)`} {
+		if !strings.Contains(get, want) {
+			t.Errorf("Get handler missing %q:\n%s", want, get)
+		}
+	}
+	if strings.Contains(code, "strconv.ParseInt(idStr") {
+		t.Error("ULID IDs should not be parsed as integers")
+	}
+
+	update := functionBody(t, code, "func UpdateOrderHandler(db *sql.DB) http.HandlerFunc {")
+	if !strings.Contains(update, `if o.ID != "" && o.ID != id {`) {
+		t.Errorf("Update handler should compare ULIDs:\n%s", update)
+	}
+}
+
+func TestGenerateMain_SnowflakeIDs(t *testing.T) {
+	code, err := NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, idStrategySource(ast.IDStrategySnowflake))}, "example.com/shop", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	assertContains(t, code,
+		`"github.com/conduit-lang/conduit/pkg/web/ids"`,
+		"if err := ids.SetNodeFromEnv(); err != nil {",
+	)
+
+	code, err = NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, idStrategySource(ast.IDStrategyULID))}, "example.com/shop", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "ids.") {
+		t.Error("Main should only set the node when a resource generates snowflake IDs")
+	}
+}
+
+func TestGeneratedULIDs_Run(t *testing.T) {
+	t.Parallel()
+	runGenerated(t, idStrategySource(ast.IDStrategyULID), map[string]string{"models/ids_test.go": `package models_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/conduit-lang/conduit/pkg/web/ids"
+
+	"example.com/app/models"
+)
+
+func TestCreateGeneratesULID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO orders (id, total) VALUES ($1, $2)")).
+		WithArgs(sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	o := &models.Order{Total: 3}
+	if err := o.Create(context.Background(), db); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := ids.ValidateULID(o.ID); err != nil {
+		t.Errorf("Create generated %q: %v", o.ID, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateRejectsInvalidID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// The ID is checked before any statement runs
+	o := &models.Order{ID: "not-a-ulid", Total: 3}
+	err = o.Create(context.Background(), db)
+	if err == nil || !strings.Contains(err.Error(), "invalid ULID") || errors.Unwrap(err) == nil {
+		t.Errorf("Create = %v, want a wrapped invalid ULID error", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+`})
+}
@@ -0,0 +1,394 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// includeBatchSize is how many keys the queries loading included records bind
+// at most; PostgreSQL accepts 65535 parameters per statement
+const includeBatchSize = 1000
+
+// includable returns the relationships of a resource that ?include= can
+// side-load: belongs_to relationships whose foreign key is a field of the
+// resource, and has_many and has_one relationships whose foreign key is a
+// field of the related resource. The related resource must serve GET to every
+// caller and live in the same database, so including it cannot reveal records
+// its own routes would refuse.
+func (g *Generator) includable(resource *ast.ResourceNode) []*ast.RelationshipNode {
+	var rels []*ast.RelationshipNode
+	for _, rel := range resource.Relationships {
+		if g.includeColumn(resource, rel) != nil {
+			rels = append(rels, rel)
+		}
+	}
+	return rels
+}
+
+// includeColumn returns the field the records of a relationship are looked up
+// by: the key of the related resource for belongs_to, or its foreign key for
+// has_many and has_one. Returns nil when the relationship cannot be included.
+func (g *Generator) includeColumn(resource *ast.ResourceNode, rel *ast.RelationshipNode) *ast.FieldNode {
+	target := g.findResource(rel.Type)
+	if target == nil || hasCompositeKey(target) || hasCompositeKey(resource) || !resource.Colocated(target) {
+		return nil
+	}
+	if !servesOperation(target, ast.OperationGet) || adminOnly(target, ast.OperationGet) {
+		return nil
+	}
+
+	switch rel.Kind {
+	case ast.RelationshipBelongsTo:
+		foreignKey := resource.FindField(rel.ForeignKeyColumn())
+		if foreignKey == nil || g.baseGoType(foreignKey) != g.getIDGoType(target) {
+			return nil
+		}
+		return keyField(target)
+	case ast.RelationshipHasMany, ast.RelationshipHasOne:
+		foreignKey := target.FindField(rel.TargetForeignKey(resource.Name))
+		if foreignKey == nil || g.baseGoType(foreignKey) != g.getIDGoType(resource) {
+			return nil
+		}
+		return foreignKey
+	}
+	return nil
+}
+
+// includeLookups returns the fields records of a resource are looked up by
+// when other resources include them, in the order they are first needed
+func (g *Generator) includeLookups(resource *ast.ResourceNode) []*ast.FieldNode {
+	var fields []*ast.FieldNode
+	seen := make(map[string]bool)
+	for _, owner := range g.resources {
+		for _, rel := range owner.Relationships {
+			if rel.Type != resource.Name {
+				continue
+			}
+			field := g.includeColumn(owner, rel)
+			if field == nil || seen[field.Name] {
+				continue
+			}
+			seen[field.Name] = true
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// inIncludeGraph reports whether a resource has relationships to include or
+// is included by another resource, and so has an include function
+func (g *Generator) inIncludeGraph(resource *ast.ResourceNode) bool {
+	return len(g.includable(resource)) > 0 || len(g.includeLookups(resource)) > 0
+}
+
+// hasIncludes reports whether any resource has relationships to include
+func (g *Generator) hasIncludes(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if len(g.includable(resource)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// baseGoType returns the Go type of a field without the pointer of nullable
+// fields
+func (g *Generator) baseGoType(field *ast.FieldNode) string {
+	return strings.TrimPrefix(g.toGoType(field), "*")
+}
+
+// includeFinderName returns the name of the function looking up records of a
+// resource by one of the fields in includeLookups, e.g. FindCommentsByPostIDs
+func (g *Generator) includeFinderName(resource *ast.ResourceNode, field *ast.FieldNode) string {
+	return fmt.Sprintf("Find%ssBy%ss", resource.Name, g.toGoFieldName(field.Name))
+}
+
+// generateIncludeFinder generates the function loading the records of a
+// resource whose field is one of a list of values, which side-loads them for
+// ?include=. Records lists leave out are left out here too, and long lists
+// are queried in batches.
+func (g *Generator) generateIncludeFinder(resource *ast.ResourceNode, field *ast.FieldNode) {
+	receiverName := strings.ToLower(resource.Name[0:1])
+	columns, scanTargets := g.buildSelectQuery(resource)
+	column := g.fieldColumnName(field)
+	plural := strings.ToLower(resource.Name) + "s"
+	name := g.includeFinderName(resource, field)
+
+	conditions := ""
+	if extra := g.listConditions(resource, ""); len(extra) > 0 {
+		conditions = " AND " + strings.Join(extra, " AND ")
+	}
+
+	g.writeLine("// %s retrieves the %s whose %s is one of values, to include", name, plural, column)
+	g.writeLine("// them with related records")
+	g.writeLine("func %s(ctx context.Context, db *sql.DB, values []%s) ([]*%s, error) {", name, g.baseGoType(field), resource.Name)
+	g.indent++
+	g.writeLine("var results []*%s", resource.Name)
+	g.writeLine("for start := 0; start < len(values); start += %d {", includeBatchSize)
+	g.indent++
+	g.writeLine("batch := values[start:min(start+%d, len(values))]", includeBatchSize)
+	g.writeLine("placeholders := make([]string, len(batch))")
+	g.writeLine("args := make([]interface{}, len(batch))")
+	g.writeLine("for i, value := range batch {")
+	g.indent++
+	g.writeLine("placeholders[i] = fmt.Sprintf(\"$%d\", i+1)")
+	g.writeLine("args[i] = value")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("query := `SELECT %s FROM %s WHERE %s IN (` + strings.Join(placeholders, \", \") + `)%s ORDER BY %s`",
+		strings.Join(columns, ", "), g.sqlTable(resource), column, conditions, strings.Join(g.listOrder(resource), ", "))
+	g.writeLine("")
+
+	g.writeLine("rows, err := db.QueryContext(ctx, query, args...)")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("return nil, fmt.Errorf(\"failed to query %s: %%w\", err)", plural)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("for rows.Next() {")
+	g.indent++
+	g.writeLine("%s := &%s{}", receiverName, resource.Name)
+	g.writeLine("if err := rows.Scan(%s); err != nil {", strings.Join(scanTargets, ", "))
+	g.indent++
+	g.writeLine("rows.Close()")
+	g.writeLine("return nil, fmt.Errorf(\"failed to scan %s: %%w\", err)", strings.ToLower(resource.Name))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("results = append(results, %s)", receiverName)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("err = rows.Err()")
+	g.writeLine("rows.Close()")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("return nil, fmt.Errorf(\"error iterating %s: %%w\", err)", plural)
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return results, nil")
+	g.indent--
+	g.writeLine("}")
+}
+
+// includeFuncName returns the name of the handler function including the
+// relationships of a resource, e.g. includePost
+func includeFuncName(resource *ast.ResourceNode) string {
+	return "include" + resource.Name
+}
+
+// generateIncludeFunc generates the function that side-loads the
+// relationships ?include= names for a page of records: one query per
+// relationship, whatever the number of records, followed by the paths that
+// continue from the related records. Paths through relationships that cannot
+// be included are reported with query.ErrInvalidInclude.
+func (g *Generator) generateIncludeFunc(resource *ast.ResourceNode) {
+	name := includeFuncName(resource)
+	jsonapiType := g.toJSONAPIType(resource.Name)
+
+	g.writeLine("// %s adds the %s related to records through the include paths to", name, jsonapiType)
+	g.writeLine("// included, with the records related to those in turn")
+	g.writeLine("func %s(ctx context.Context, db *sql.DB, r *http.Request, records []*models.%s, paths []string, included *response.Included) error {",
+		name, resource.Name)
+	g.indent++
+
+	rels := g.includable(resource)
+	if len(rels) == 0 {
+		g.writeLine("if len(paths) > 0 {")
+		g.indent++
+		g.writeLine("return fmt.Errorf(\"%%w: %%s, %s have no relationships to include\", query.ErrInvalidInclude, paths[0])", jsonapiType)
+		g.indent--
+		g.writeLine("}")
+		g.writeLine("return nil")
+		g.indent--
+		g.writeLine("}")
+		return
+	}
+
+	g.writeLine("for _, group := range query.GroupIncludes(paths) {")
+	g.indent++
+	g.writeLine("switch group.Relationship {")
+	for _, rel := range rels {
+		g.writeLine("case %q:", rel.Name)
+		g.indent++
+		g.generateIncludeRelationship(resource, rel)
+		g.indent--
+	}
+	g.writeLine("default:")
+	g.indent++
+	g.writeLine("return fmt.Errorf(\"%%w: %%s cannot be included from %s\", query.ErrInvalidInclude, group.Relationship)", jsonapiType)
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return nil")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateIncludeRelationship generates the case of an include function that
+// loads one relationship for every record and links each to its related
+// records
+func (g *Generator) generateIncludeRelationship(resource *ast.ResourceNode, rel *ast.RelationshipNode) {
+	target := g.findResource(rel.Type)
+	lookup := g.includeColumn(resource, rel)
+
+	// The value each record is matched by: its foreign key for belongs_to,
+	// its key for has_many and has_one
+	var ownField, relatedField *ast.FieldNode
+	if rel.Kind == ast.RelationshipBelongsTo {
+		ownField, relatedField = resource.FindField(rel.ForeignKeyColumn()), keyField(target)
+	} else {
+		ownField, relatedField = keyField(resource), lookup
+	}
+	keyType := g.baseGoType(lookup)
+
+	if len(target.Profiles) > 0 {
+		g.writeLine("included.Mask(%q, %s.Fields(r))", g.toJSONAPIType(target.Name), g.resourceVarName(target, "Profiles"))
+	}
+
+	g.writeLine("seen := make(map[%s]bool, len(records))", keyType)
+	g.writeLine("keys := make([]%s, 0, len(records))", keyType)
+	g.writeLine("for _, record := range records {")
+	g.indent++
+	g.withFieldValue("record", ownField, func(value string) {
+		g.writeLine("if !seen[%s] {", value)
+		g.indent++
+		g.writeLine("seen[%s] = true", value)
+		g.writeLine("keys = append(keys, %s)", value)
+		g.indent--
+		g.writeLine("}")
+	})
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("related, err := models.%s(ctx, db, keys)", g.includeFinderName(target, lookup))
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("return fmt.Errorf(\"failed to include %s: %%w\", err)", rel.Name)
+	g.indent--
+	g.writeLine("}")
+
+	if rel.Kind == ast.RelationshipHasMany {
+		g.writeLine("byKey := make(map[%s][]interface{}, len(records))", keyType)
+		g.writeLine("for _, item := range related {")
+		g.indent++
+		g.withFieldValue("item", relatedField, func(value string) {
+			g.writeLine("byKey[%s] = append(byKey[%s], item)", value, value)
+		})
+		g.indent--
+		g.writeLine("}")
+		g.writeLine("for _, record := range records {")
+		g.indent++
+		g.writeLine("if err := included.ToMany(record, %q, byKey[%s]); err != nil {", rel.Name, "record."+g.toGoFieldName(ownField.Name))
+		g.indent++
+		g.writeLine("return err")
+		g.indent--
+		g.writeLine("}")
+		g.indent--
+		g.writeLine("}")
+	} else {
+		g.writeLine("byKey := make(map[%s]*models.%s, len(related))", keyType, target.Name)
+		g.writeLine("for _, item := range related {")
+		g.indent++
+		g.withFieldValue("item", relatedField, func(value string) {
+			g.writeLine("if _, ok := byKey[%s]; !ok {", value)
+			g.indent++
+			g.writeLine("byKey[%s] = item", value)
+			g.indent--
+			g.writeLine("}")
+		})
+		g.indent--
+		g.writeLine("}")
+		g.writeLine("for _, record := range records {")
+		g.indent++
+		g.writeLine("var item interface{}")
+		g.withFieldValue("record", ownField, func(value string) {
+			g.writeLine("if found, ok := byKey[%s]; ok {", value)
+			g.indent++
+			g.writeLine("item = found")
+			g.indent--
+			g.writeLine("}")
+		})
+		g.writeLine("if err := included.ToOne(record, %q, item); err != nil {", rel.Name)
+		g.indent++
+		g.writeLine("return err")
+		g.indent--
+		g.writeLine("}")
+		g.indent--
+		g.writeLine("}")
+	}
+
+	g.writeLine("if err := %s(ctx, db, r, related, group.Paths, included); err != nil {", includeFuncName(target))
+	g.indent++
+	g.writeLine("return err")
+	g.indent--
+	g.writeLine("}")
+}
+
+// withFieldValue generates body with the value of a record's field, guarded
+// by a nil check for nullable fields
+func (g *Generator) withFieldValue(record string, field *ast.FieldNode, body func(value string)) {
+	value := record + "." + g.toGoFieldName(field.Name)
+	if !field.Nullable {
+		body(value)
+		return
+	}
+	g.writeLine("if %s != nil {", value)
+	g.indent++
+	body("*" + value)
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateIncludeError generates the response to an error loading included
+// records: 400 for include paths that cannot be followed, 500 otherwise
+func (g *Generator) generateIncludeError(resourceLower string) {
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("status := http.StatusInternalServerError")
+	g.writeLine("if errors.Is(err, query.ErrInvalidInclude) {")
+	g.indent++
+	g.writeLine("status = http.StatusBadRequest")
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("err = fmt.Errorf(\"Failed to include %s relationships: %%v\", err)", resourceLower)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("response.RenderJSONAPIError(w, status, err)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateGetIncluded generates the compound document of a GET handler asked
+// for relationships with ?include=
+func (g *Generator) generateGetIncluded(resource *ast.ResourceNode) {
+	visible := "nil"
+	if len(resource.Profiles) > 0 {
+		visible = "visible"
+	}
+
+	g.writeLine("// Compound document with the relationships named by ?include=")
+	g.writeLine("if includes := query.ParseInclude(r); len(includes) > 0 {")
+	g.indent++
+	g.writeLine("included := response.NewIncluded(query.ParseFields(r))")
+	g.writeLine("err := query.CheckIncludeDepth(includes, query.MaxIncludeDepth)")
+	g.writeLine("if err == nil {")
+	g.indent++
+	g.writeLine("err = %s(ctx, db, r, []*models.%s{result}, includes, included)", includeFuncName(resource), resource.Name)
+	g.indent--
+	g.writeLine("}")
+	g.generateIncludeError(strings.ToLower(resource.Name))
+	g.writeLine("if err := response.RenderJSONAPIIncluded(w, http.StatusOK, result, %s, included); err != nil {", visible)
+	g.indent++
+	g.writeLine("respondWithError(w, \"Failed to encode response\", http.StatusInternalServerError)")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
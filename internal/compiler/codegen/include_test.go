@@ -0,0 +1,218 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const includeSource = `resource User {
+  id: uuid! @primary @auto
+  name: string!
+
+  posts: array<Post!>! {
+    foreign_key: "author_id"
+  }
+}
+
+resource Post {
+  id: uuid! @primary @auto
+  title: string!
+  author_id: uuid!
+
+  author: User! {
+    foreign_key: "author_id"
+  }
+
+  comments: array<Comment!>! {}
+
+  tags: array<Tag!>! {
+    through: "post_tags"
+  }
+}
+
+resource Comment {
+  id: uuid! @primary @auto
+  post_id: uuid!
+  editor_id: uuid?
+
+  post: Post! {}
+
+  editor: User? {
+    foreign_key: "editor_id"
+  }
+}
+
+resource Tag {
+  id: uuid! @primary @auto
+  name: string!
+}`
+
+func TestIncludable(t *testing.T) {
+	resources := parseResources(t, includeSource)
+	gen := NewGenerator()
+	gen.resources = resources
+
+	var names []string
+	for _, rel := range gen.includable(resources[1]) {
+		names = append(names, rel.Name)
+	}
+	// has_many_through relationships are not side-loaded
+	if got := strings.Join(names, ","); got != "author,comments" {
+		t.Errorf("includable(Post) = %s, want author,comments", got)
+	}
+
+	// A related resource without a GET route for every caller is not included
+	resources[0].Operations = []string{ast.OperationList}
+	if rels := gen.includable(resources[1]); len(rels) != 1 || rels[0].Name != "comments" {
+		t.Errorf("includable(Post) should leave out the author when users serve no GET, got %d relationships", len(rels))
+	}
+	resources[0].Operations = nil
+
+	// Without the foreign key field there is nothing to look the author up by
+	resources[1].Fields = resources[1].Fields[:2]
+	if rels := gen.includable(resources[1]); len(rels) != 1 || rels[0].Name != "comments" {
+		t.Errorf("includable(Post) should leave out a belongs_to without its foreign key, got %d relationships", len(rels))
+	}
+}
+
+func TestGenerateResource_IncludeFinders(t *testing.T) {
+	resources := parseResources(t, includeSource)
+	gen := NewGenerator()
+	gen.resources = resources
+
+	code, err := gen.GenerateResource(resources[2])
+	if err != nil {
+		t.Fatalf("GenerateResource failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+	if strings.Contains(code, "FindCommentsByIDs") {
+		t.Error("Comments are never included by their key")
+	}
+
+	finder := functionBody(t, code, "func FindCommentsByPostIDs(ctx context.Context, db *sql.DB, values []uuid.UUID) ([]*Comment, error) {")
+	assertContains(t, finder,
+		"for start := 0; start < len(values); start += 1000 {",
+		"batch := values[start:min(start+1000, len(values))]",
+		`placeholders[i] = fmt.Sprintf("$%d", i+1)`,
+		"FROM comments WHERE post_id IN (` + strings.Join(placeholders, \", \") + `) ORDER BY id",
+		"rows.Close()",
+	)
+
+	// Users are included both as authors and editors, by their key, and have
+	// their posts included by author
+	code, err = gen.GenerateResource(resources[0])
+	if err != nil {
+		t.Fatalf("GenerateResource failed: %v", err)
+	}
+	if count := strings.Count(code, "func FindUsersByIDs("); count != 1 {
+		t.Errorf("Expected one FindUsersByIDs, got %d", count)
+	}
+	if !strings.Contains(code, `"strings"`) {
+		t.Error("Include finders need the strings import")
+	}
+
+	code, err = gen.GenerateResource(resources[3])
+	if err != nil {
+		t.Fatalf("GenerateResource failed: %v", err)
+	}
+	if strings.Contains(code, "FindTagsBy") {
+		t.Error("Tags are not included, so they need no finder")
+	}
+}
+
+func TestGenerateHandlers_Include(t *testing.T) {
+	code := generateHandlers(t, parseResources(t, includeSource)...)
+
+	include := functionBody(t, code, "func includePost(ctx context.Context, db *sql.DB, r *http.Request, records []*models.Post, paths []string, included *response.Included) error {")
+	assertContains(t, include,
+		"for _, group := range query.GroupIncludes(paths) {",
+		`case "author":`,
+		"related, err := models.FindUsersByIDs(ctx, db, keys)",
+		"if err := included.ToOne(record, \"author\", item); err != nil {",
+		"if err := includeUser(ctx, db, r, related, group.Paths, included); err != nil {",
+		`case "comments":`,
+		"related, err := models.FindCommentsByPostIDs(ctx, db, keys)",
+		"byKey[item.PostID] = append(byKey[item.PostID], item)",
+		"if err := included.ToMany(record, \"comments\", byKey[record.ID]); err != nil {",
+		"if err := includeComment(ctx, db, r, related, group.Paths, included); err != nil {",
+		`return fmt.Errorf("%w: %s cannot be included from posts", query.ErrInvalidInclude, group.Relationship)`,
+	)
+	if strings.Contains(include, `case "tags":`) {
+		t.Errorf("includePost should not include has_many_through relationships:\n%s", include)
+	}
+
+	// Nullable foreign keys are only followed when set
+	include = functionBody(t, code, "func includeComment(ctx context.Context, db *sql.DB, r *http.Request, records []*models.Comment, paths []string, included *response.Included) error {")
+	assertContains(t, include,
+		"if record.EditorID != nil {",
+		"if found, ok := byKey[*record.EditorID]; ok {",
+	)
+
+	if strings.Contains(code, "func includeTag(") {
+		t.Error("Tags are not included, so they need no include function")
+	}
+
+	list := functionBody(t, code, "func ListPostHandler(db *sql.DB) http.HandlerFunc {")
+	assertContains(t, list,
+		"validIncludes := []string{\n\t\t\t\"author\",\n\t\t\t\"comments\",\n\t\t}",
+		"if len(includes) > 0 && response.IsJSONAPI(r) {",
+		"included = response.NewIncluded(fields)",
+		"items = append(items, item)",
+		"err := includePost(ctx, db, r, items, includes, included)",
+		"if errors.Is(err, query.ErrInvalidInclude) {",
+		"stream.Include(included)",
+	)
+
+	get := functionBody(t, code, "func GetPostHandler(db *sql.DB) http.HandlerFunc {")
+	assertContains(t, get,
+		"if includes := query.ParseInclude(r); len(includes) > 0 {",
+		"err := query.CheckIncludeDepth(includes, query.MaxIncludeDepth)",
+		"err = includePost(ctx, db, r, []*models.Post{result}, includes, included)",
+		"response.RenderJSONAPIIncluded(w, http.StatusOK, result, nil, included)",
+	)
+
+	// Resources with nothing to include stream as before
+	list = functionBody(t, code, "func ListTagHandler(db *sql.DB) http.HandlerFunc {")
+	if strings.Contains(list, "NewIncluded") || !strings.Contains(list, "validIncludes := []string{}") {
+		t.Errorf("ListTagHandler should not side-load:\n%s", list)
+	}
+}
+
+func TestGenerateHandlers_IncludeWithoutRelationships(t *testing.T) {
+	resources := parseResources(t, includeSource)
+	resources[0].Relationships = nil
+
+	code := generateHandlers(t, resources...)
+
+	// Users have nothing to include, so paths continuing from them are rejected
+	include := functionBody(t, code, "func includeUser(ctx context.Context, db *sql.DB, r *http.Request, records []*models.User, paths []string, included *response.Included) error {")
+	if !strings.Contains(include, `return fmt.Errorf("%w: %s, users have no relationships to include", query.ErrInvalidInclude, paths[0])`) {
+		t.Errorf("includeUser should reject every path:\n%s", include)
+	}
+	list := functionBody(t, code, "func ListUserHandler(db *sql.DB) http.HandlerFunc {")
+	if strings.Contains(list, "NewIncluded") {
+		t.Errorf("ListUserHandler should not side-load:\n%s", list)
+	}
+}
+
+func TestGenerateHandlers_IncludeProfiles(t *testing.T) {
+	resources := parseResources(t, includeSource)
+	resources[0].Profiles = []*ast.ProfileNode{{Name: "public", Fields: []string{"id"}}}
+
+	code := generateHandlers(t, resources...)
+
+	// Included users show the fields the caller may see, as their own routes do
+	include := functionBody(t, code, "func includePost(ctx context.Context, db *sql.DB, r *http.Request, records []*models.Post, paths []string, included *response.Included) error {")
+	if !strings.Contains(include, `included.Mask("users", userProfiles.Fields(r))`) {
+		t.Errorf("includePost should mask included users:\n%s", include)
+	}
+	get := functionBody(t, code, "func GetUserHandler(db *sql.DB) http.HandlerFunc {")
+	if !strings.Contains(get, "response.RenderJSONAPIIncluded(w, http.StatusOK, result, visible, included)") {
+		t.Errorf("GetUserHandler should mask the user:\n%s", get)
+	}
+}
@@ -62,12 +62,7 @@ func TestGeneratedCode_Compiles(t *testing.T) {
 	}
 
 	// Create go.mod file
-	goModContent := `module test-conduit
-
-go 1.23
-
-require github.com/google/uuid v1.6.0
-`
+	goModContent := generatedGoMod(t, "test-conduit")
 	goModPath := filepath.Join(tmpDir, "go.mod")
 	if err := os.WriteFile(goModPath, []byte(goModContent), 0644); err != nil {
 		t.Fatalf("Failed to write go.mod: %v", err)
@@ -83,6 +78,26 @@ require github.com/google/uuid v1.6.0
 	}
 }
 
+// generatedGoMod returns the go.mod of a module compiling generated code,
+// which imports packages of this repository
+func generatedGoMod(t *testing.T, module string) string {
+	t.Helper()
+	root, err := filepath.Abs(filepath.Join("..", "..", ".."))
+	if err != nil {
+		t.Fatalf("Failed to find the repository root: %v", err)
+	}
+	return "module " + module + `
+
+go 1.23
+
+require (
+	github.com/conduit-lang/conduit v0.0.0
+	github.com/google/uuid v1.6.0
+)
+
+replace github.com/conduit-lang/conduit => ` + root + "\n"
+}
+
 func TestGeneratedCode_GoFmt(t *testing.T) {
 	resource := &ast.ResourceNode{
 		Name: "User",
@@ -378,13 +393,13 @@ func TestAcceptanceCriteria_HookGeneration(t *testing.T) {
 	}
 
 	// AC3.3: @transaction wraps hook body in Begin/Commit/Rollback
-	if !strings.Contains(code, "tx, err := db.Begin()") ||
+	if !strings.Contains(code, "tx, err := db.BeginTx(ctx, nil)") ||
 		!strings.Contains(code, "defer tx.Rollback()") {
 		t.Error("AC3.3 FAIL: @transaction should wrap code in transaction")
 	}
 
 	// AC3.4: @async spawns goroutine
-	if !strings.Contains(code, "go func() {") {
+	if !strings.Contains(code, "go func(ctx context.Context) {") {
 		t.Error("AC3.4 FAIL: @async should spawn goroutine")
 	}
 
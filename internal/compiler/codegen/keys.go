@@ -0,0 +1,208 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// implicitIDField is the id field generated for resources that declare no
+// primary key: a BIGSERIAL column holding an int64
+var implicitIDField = &ast.FieldNode{
+	Name: "id",
+	Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int"},
+}
+
+// keyFields returns the primary key fields of a resource: its declared key,
+// or the generated id field when it declares none
+func keyFields(resource *ast.ResourceNode) []*ast.FieldNode {
+	if fields := resource.KeyFields(); len(fields) > 0 {
+		return fields
+	}
+	return []*ast.FieldNode{implicitIDField}
+}
+
+// keyField returns the primary key field of a resource with a single-field
+// key. Features the type checker rejects on composite keys use it.
+func keyField(resource *ast.ResourceNode) *ast.FieldNode {
+	return keyFields(resource)[0]
+}
+
+// hasImplicitID reports whether a resource is keyed by a generated id column
+// it does not declare
+func hasImplicitID(resource *ast.ResourceNode) bool {
+	return len(resource.KeyFields()) == 0
+}
+
+// hasCompositeKey reports whether a resource declares @primary(a, b)
+func hasCompositeKey(resource *ast.ResourceNode) bool {
+	return len(keyFields(resource)) > 1
+}
+
+// isKeyField reports whether a field is part of a resource's primary key
+func isKeyField(resource *ast.ResourceNode, field *ast.FieldNode) bool {
+	for _, key := range keyFields(resource) {
+		if key == field {
+			return true
+		}
+	}
+	return false
+}
+
+// keyColumn returns the primary key column of a resource with a single-field
+// key
+func (g *Generator) keyColumn(resource *ast.ResourceNode) string {
+	return g.fieldColumnName(keyField(resource))
+}
+
+// keyColumns returns the primary key columns of a resource
+func (g *Generator) keyColumns(resource *ast.ResourceNode) []string {
+	var columns []string
+	for _, field := range keyFields(resource) {
+		columns = append(columns, g.fieldColumnName(field))
+	}
+	return columns
+}
+
+// keyCondition returns the WHERE condition matching a record by its primary
+// key with placeholders numbered from first, e.g. "id = $1" or
+// "region = $2 AND code = $3"
+func (g *Generator) keyCondition(resource *ast.ResourceNode, first int) string {
+	var conditions []string
+	for i, column := range g.keyColumns(resource) {
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", column, first+i))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// keyValues returns the expressions reading the primary key of the record in
+// receiver, e.g. p.ID
+func (g *Generator) keyValues(resource *ast.ResourceNode, receiver string) []string {
+	var values []string
+	for _, field := range keyFields(resource) {
+		values = append(values, fmt.Sprintf("%s.%s", receiver, g.toGoFieldName(field.Name)))
+	}
+	return values
+}
+
+// keyValue returns the expression reading the primary key of the record in
+// receiver for a resource with a single-field key
+func (g *Generator) keyValue(resource *ast.ResourceNode, receiver string) string {
+	return g.keyValues(resource, receiver)[0]
+}
+
+// keyVars returns the variables handlers parse a record's primary key into:
+// id for a single-field key, and one variable per field of a composite key,
+// such as regionKey and codeKey
+func (g *Generator) keyVars(resource *ast.ResourceNode) []string {
+	fields := keyFields(resource)
+	if len(fields) == 1 {
+		return []string{"id"}
+	}
+	var vars []string
+	for _, field := range fields {
+		name := g.toGoFieldName(field.Name)
+		vars = append(vars, strings.ToLower(name[:1])+name[1:]+"Key")
+	}
+	return vars
+}
+
+// keyArgs returns the primary key arguments of a FindByID call in a handler
+func (g *Generator) keyArgs(resource *ast.ResourceNode) string {
+	return strings.Join(g.keyVars(resource), ", ")
+}
+
+// keyParams returns the primary key parameters of FindByID, e.g. "id int64"
+// or "regionKey string, codeKey string"
+func (g *Generator) keyParams(resource *ast.ResourceNode) string {
+	vars := g.keyVars(resource)
+	var params []string
+	for i, field := range keyFields(resource) {
+		params = append(params, fmt.Sprintf("%s %s", vars[i], g.toGoType(field)))
+	}
+	return strings.Join(params, ", ")
+}
+
+// memberPath returns the route of a record of a resource, e.g. /posts/{id}
+// or /memberships/{region}/{code}
+func (g *Generator) memberPath(resource *ast.ResourceNode) string {
+	return "/" + g.toTableName(resource.Name) + "/" + resource.MemberPath()
+}
+
+// generateKeyMethods generates MarshalID and UnmarshalID for a resource with
+// a composite key, which JSON:API uses as the id of its records: the key
+// values path-escaped and joined by "/", the way they appear in its routes
+func (g *Generator) generateKeyMethods(resource *ast.ResourceNode) {
+	receiverName := strings.ToLower(resource.Name[0:1])
+	fields := keyFields(resource)
+
+	var parts []string
+	for _, field := range fields {
+		value := fmt.Sprintf("%s.%s", receiverName, g.toGoFieldName(field.Name))
+		switch field.Type.Name {
+		case "int":
+			value = fmt.Sprintf("strconv.FormatInt(%s, 10)", value)
+		case "uuid":
+			value += ".String()"
+		}
+		parts = append(parts, fmt.Sprintf("url.PathEscape(%s)", value))
+	}
+
+	g.writeLine("// MarshalID returns the JSON:API id of a %s: its primary key values joined by \"/\"", resource.Name)
+	g.writeLine("func (%s *%s) MarshalID() string {", receiverName, resource.Name)
+	g.indent++
+	g.writeLine("return strings.Join([]string{%s}, \"/\")", strings.Join(parts, ", "))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("// UnmarshalID sets the primary key of a %s from its JSON:API id", resource.Name)
+	g.writeLine("func (%s *%s) UnmarshalID(id string) error {", receiverName, resource.Name)
+	g.indent++
+	g.writeLine("parts := strings.Split(id, \"/\")")
+	g.writeLine("if len(parts) != %d {", len(fields))
+	g.indent++
+	g.writeLine("return fmt.Errorf(\"invalid %s id %%q\", id)", strings.ToLower(resource.Name))
+	g.indent--
+	g.writeLine("}")
+	vars := g.keyVars(resource)
+	for i, field := range fields {
+		fieldName := g.toGoFieldName(field.Name)
+		g.writeLine("%s, err := url.PathUnescape(parts[%d])", vars[i], i)
+		g.writeLine("if err != nil {")
+		g.indent++
+		g.writeLine("return fmt.Errorf(\"invalid %s id %%q: %%w\", id, err)", strings.ToLower(resource.Name))
+		g.indent--
+		g.writeLine("}")
+		switch field.Type.Name {
+		case "int":
+			g.writeLine("if %s.%s, err = strconv.ParseInt(%s, 10, 64); err != nil {", receiverName, fieldName, vars[i])
+			g.indent++
+			g.writeLine("return fmt.Errorf(\"invalid %s id %%q: %%w\", id, err)", strings.ToLower(resource.Name))
+			g.indent--
+			g.writeLine("}")
+		case "uuid":
+			g.writeLine("if %s.%s, err = uuid.Parse(%s); err != nil {", receiverName, fieldName, vars[i])
+			g.indent++
+			g.writeLine("return fmt.Errorf(\"invalid %s id %%q: %%w\", id, err)", strings.ToLower(resource.Name))
+			g.indent--
+			g.writeLine("}")
+		default:
+			g.writeLine("%s.%s = %s", receiverName, fieldName, vars[i])
+		}
+	}
+	g.writeLine("return nil")
+	g.indent--
+	g.writeLine("}")
+}
+
+// recordID returns the expression identifying the record in receiver in
+// Location headers and change feeds: its key, or MarshalID for a composite
+// key
+func (g *Generator) recordID(resource *ast.ResourceNode, receiver string) string {
+	if hasCompositeKey(resource) {
+		return receiver + ".MarshalID()"
+	}
+	return g.keyValue(resource, receiver)
+}
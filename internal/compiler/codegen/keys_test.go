@@ -0,0 +1,85 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const countrySource = `resource Country {
+  code: string! @primary
+  name: string!
+}`
+
+const membershipSource = `resource Membership {
+  @primary(region, code)
+
+  region: string!
+  code: int!
+  role: string!
+}`
+
+func TestGenerateResource_NaturalKey(t *testing.T) {
+	code := generateModel(t, parseResource(t, countrySource))
+
+	if !strings.Contains(code, "Code string `jsonapi:\"primary,countrys\" db:\"code\" json:\"code\"`") {
+		t.Errorf("Code should be the JSON:API primary:\n%s", code)
+	}
+	if strings.Contains(code, "ID int64") {
+		t.Errorf("Country should not get a generated id:\n%s", code)
+	}
+
+	find := functionBody(t, code, "func FindCountryByID(ctx context.Context, db *sql.DB, id string) (*Country, error) {")
+	if !strings.Contains(find, "WHERE code = $1") {
+		t.Errorf("FindCountryByID should match the code:\n%s", find)
+	}
+
+	create := functionBody(t, code, "func (c *Country) Create(ctx context.Context, db *sql.DB) error {")
+	if !strings.Contains(create, "INSERT INTO countrys (code, name) VALUES ($1, $2)") {
+		t.Errorf("Create should insert the code:\n%s", create)
+	}
+}
+
+func TestGenerateResource_CompositeKey(t *testing.T) {
+	code := generateModel(t, parseResource(t, membershipSource))
+
+	assertContains(t, code,
+		"func (m *Membership) MarshalID() string {",
+		`return strings.Join([]string{url.PathEscape(m.Region), url.PathEscape(strconv.FormatInt(m.Code, 10))}, "/")`,
+		"func (m *Membership) UnmarshalID(id string) error {",
+		"if m.Code, err = strconv.ParseInt(codeKey, 10, 64); err != nil {",
+		"func FindMembershipByID(ctx context.Context, db *sql.DB, regionKey string, codeKey int64) (*Membership, error) {",
+		"WHERE region = $1 AND code = $2",
+	)
+
+	update := functionBody(t, code, "func (m *Membership) Update(ctx context.Context, db *sql.DB) error {")
+	if !strings.Contains(update, "SET role = $1") || !strings.Contains(update, "WHERE region = $2 AND code = $3") {
+		t.Errorf("Update should only set non-key fields:\n%s", update)
+	}
+}
+
+func TestGenerateHandlers_Keys(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, countrySource), parseResource(t, membershipSource))
+
+	assertContains(t, code,
+		`r.Get("/countrys/{code}", GetCountryHandler(db))`,
+		`id := chi.URLParam(r, "code")`,
+		`r.Get("/memberships/{region}/{code}", GetMembershipHandler(db))`,
+		`regionKey := chi.URLParam(r, "region")`,
+		"models.FindMembershipByID(ctx, db, regionKey, codeKey)",
+	)
+}
+
+func TestGenerateMigrations_CompositeKey(t *testing.T) {
+	sql, err := NewGenerator().GenerateMigrations([]*ast.ResourceNode{parseResource(t, membershipSource)})
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+	if !strings.Contains(sql, "PRIMARY KEY (region, code)") {
+		t.Errorf("Migration missing composite primary key:\n%s", sql)
+	}
+	if strings.Contains(sql, "BIGSERIAL") {
+		t.Errorf("Migration should not add a generated id:\n%s", sql)
+	}
+}
@@ -0,0 +1,26 @@
+package codegen
+
+// LoggingOptions sets the request log generated for conduit.yaml's logging
+// section. CONDUIT_LOG_FORMAT overrides the format at runtime.
+type LoggingOptions struct {
+	// Format of request records, json or text; text when empty
+	Format string
+}
+
+// SetLogging configures the request log generated by GenerateProgram
+func (g *Generator) SetLogging(opts LoggingOptions) {
+	g.logging = opts
+}
+
+// generateRequestLog logs one structured record per request, after the
+// request ID and client address are known and outside Recoverer so panics are
+// logged with their 500
+func (g *Generator) generateRequestLog() {
+	format := "requestlog.FormatText"
+	if g.logging.Format == "json" {
+		format = "requestlog.FormatJSON"
+	}
+	g.writeLine("// Log each request as a structured record, in the format from conduit.yaml unless")
+	g.writeLine("// CONDUIT_LOG_FORMAT is set")
+	g.writeLine("r.Use(requestlog.Middleware(requestlog.New(os.Stdout, requestlog.FormatFromEnv(%s))))", format)
+}
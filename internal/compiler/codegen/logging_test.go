@@ -0,0 +1,53 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+func TestGenerateMain_RequestLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{"default", "", "requestlog.FormatFromEnv(requestlog.FormatText)"},
+		{"json", "json", "requestlog.FormatFromEnv(requestlog.FormatJSON)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator()
+			g.SetLogging(LoggingOptions{Format: tt.format})
+			code, err := g.GenerateMain([]*ast.ResourceNode{parseResource(t, authSource)}, "example.com/app", "")
+			if err != nil {
+				t.Fatalf("GenerateMain failed: %v", err)
+			}
+			if _, err := format.Source([]byte(code)); err != nil {
+				t.Fatalf("Generated main does not parse: %v\n%s", err, code)
+			}
+
+			if !strings.Contains(code, `"github.com/conduit-lang/conduit/pkg/web/requestlog"`) {
+				t.Error("Generated main should import requestlog")
+			}
+			if !strings.Contains(code, tt.want) {
+				t.Errorf("Generated main missing %q", tt.want)
+			}
+			if strings.Contains(code, "middleware.Logger") {
+				t.Error("The structured request log replaces middleware.Logger")
+			}
+
+			// The request ID and client address are set before the record is
+			// written, and panics recovered inside it
+			requestID := strings.Index(code, "r.Use(middleware.RequestID)")
+			realIP := strings.Index(code, "r.Use(middleware.RealIP)")
+			requestLog := strings.Index(code, "r.Use(requestlog.Middleware(")
+			recoverer := strings.Index(code, "r.Use(middleware.Recoverer)")
+			if !(requestID < requestLog && realIP < requestLog && requestLog < recoverer) {
+				t.Errorf("Middleware order: RequestID %d, RealIP %d, requestlog %d, Recoverer %d", requestID, realIP, requestLog, recoverer)
+			}
+		})
+	}
+}
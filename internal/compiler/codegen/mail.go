@@ -0,0 +1,74 @@
+package codegen
+
+import (
+	"sort"
+)
+
+// MailTemplatesFile is the generated file embedding the project's mail templates
+const MailTemplatesFile = "mailtemplates/templates.go"
+
+// MailOptions controls the mail provider configured by the generated main
+type MailOptions struct {
+	// Enabled configures Mail.send at startup; without it sends are logged and dropped
+	Enabled bool
+	// Provider is smtp, ses or sendgrid
+	Provider string
+	// From is the sender address
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SESRegion    string
+	// Templates are the mail template files, keyed by file name, embedded in the build
+	Templates map[string]string
+}
+
+// SetMail configures the mail provider generated by GenerateMain
+func (g *Generator) SetMail(opts MailOptions) {
+	g.mail = opts
+}
+
+// GenerateMailTemplates generates the package embedding the mail templates
+// passed to mail.Configure
+func (g *Generator) GenerateMailTemplates(templates map[string]string) string {
+	g.reset()
+
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g.writeLine("// Package mailtemplates embeds the mail templates sent with Mail.send")
+	g.writeLine("package mailtemplates")
+	g.writeLine("")
+	g.writeLine("// Files maps template file names to their contents")
+	g.writeLine("var Files = map[string]string{")
+	g.indent++
+	for _, name := range names {
+		g.writeLine("%q: %q,", name, templates[name])
+	}
+	g.indent--
+	g.writeLine("}")
+
+	return g.buf.String()
+}
+
+// generateMailConfig configures the mail provider from the build's conduit.yaml
+// settings; secrets are read from the environment by mail.Configure
+func (g *Generator) generateMailConfig() {
+	g.writeLine("// Deliver Mail.send through %s", g.mail.Provider)
+	g.writeLine("if err := mail.Configure(mail.Config{")
+	g.indent++
+	g.writeLine("Provider: %q,", g.mail.Provider)
+	g.writeLine("From:     %q,", g.mail.From)
+	g.writeLine("SMTP:     mail.SMTPConfig{Host: %q, Port: %d, Username: %q},", g.mail.SMTPHost, g.mail.SMTPPort, g.mail.SMTPUsername)
+	g.writeLine("SES:      mail.SESConfig{Region: %q},", g.mail.SESRegion)
+	g.indent--
+	g.writeLine("}, mailtemplates.Files); err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(%q, err)", "Failed to configure mail: %v")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
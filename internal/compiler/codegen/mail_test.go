@@ -0,0 +1,113 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const mailSource = `resource User {
+  id: uuid! @primary @auto
+  name: string!
+  email: email!
+
+  @after create {
+    @async {
+      Mail.send("welcome", self.email, {name: self.name})
+    }
+  }
+}`
+
+func TestGenerateResourceWithHooks_MailSend(t *testing.T) {
+	code, err := NewGenerator().GenerateResourceWithHooks(parseResource(t, mailSource))
+	if err != nil {
+		t.Fatalf("GenerateResourceWithHooks failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+
+	assertContains(t, code,
+		`"github.com/conduit-lang/conduit/pkg/web/mail"`,
+		`mail.SendContext(ctx, "welcome", u.Email, map[string]interface{}{"name": u.Name})`,
+		`"github.com/conduit-lang/conduit/pkg/web/correlation"`,
+		`}(correlation.Detach(ctx))`,
+	)
+}
+
+func TestGenerateStdlibCall_MailSendWithoutVars(t *testing.T) {
+	g := NewGenerator()
+	code := g.generateExpr(&ast.CallExpr{
+		Namespace: "Mail",
+		Function:  "send",
+		Arguments: []ast.ExprNode{&ast.LiteralExpr{Value: "digest"}, &ast.LiteralExpr{Value: "ops@example.com"}},
+	})
+	if want := `mail.SendContext(ctx, "digest", "ops@example.com", nil)`; code != want {
+		t.Errorf("Expected %s, got %s", want, code)
+	}
+	if !g.imports["github.com/conduit-lang/conduit/pkg/web/mail"] {
+		t.Error("Mail.send should import the mail package")
+	}
+}
+
+func TestGenerateProgram_Mail(t *testing.T) {
+	g := NewGenerator()
+	g.SetMail(MailOptions{
+		Enabled:      true,
+		Provider:     "smtp",
+		From:         "Blog <noreply@example.com>",
+		SMTPHost:     "smtp.example.com",
+		SMTPPort:     587,
+		SMTPUsername: "noreply",
+		Templates: map[string]string{
+			"welcome.txt":  "{{define \"subject\"}}Welcome{{end}}Hi {{.name}}, `quoted`",
+			"welcome.html": "<p>Hi {{.name}}</p>",
+		},
+	})
+	files, err := g.GenerateProgram(&ast.Program{Resources: []*ast.ResourceNode{parseResource(t, mailSource)}}, "example.com/blog", "", "")
+	if err != nil {
+		t.Fatalf("GenerateProgram failed: %v", err)
+	}
+
+	templates, ok := files[MailTemplatesFile]
+	if !ok {
+		t.Fatalf("Missing %s", MailTemplatesFile)
+	}
+	if _, err := format.Source([]byte(templates)); err != nil {
+		t.Fatalf("Generated templates do not parse: %v\n%s", err, templates)
+	}
+	assertContains(t, templates,
+		"package mailtemplates",
+		`"welcome.html": "<p>Hi {{.name}}</p>",`,
+		`"welcome.txt": "{{define \"subject\"}}Welcome{{end}}Hi {{.name}}, `+"`quoted`"+`",`,
+	)
+
+	main := files["main.go"]
+	if _, err := format.Source([]byte(main)); err != nil {
+		t.Fatalf("Generated main does not parse: %v\n%s", err, main)
+	}
+	assertContains(t, main,
+		`"example.com/blog/mailtemplates"`,
+		`"github.com/conduit-lang/conduit/pkg/web/mail"`,
+		"if err := mail.Configure(mail.Config{",
+		`Provider: "smtp",`,
+		`From:     "Blog <noreply@example.com>",`,
+		`SMTP:     mail.SMTPConfig{Host: "smtp.example.com", Port: 587, Username: "noreply"},`,
+		"}, mailtemplates.Files); err != nil {",
+	)
+}
+
+func TestGenerateProgram_MailDisabled(t *testing.T) {
+	files, err := NewGenerator().GenerateProgram(&ast.Program{Resources: []*ast.ResourceNode{parseResource(t, mailSource)}}, "example.com/blog", "", "")
+	if err != nil {
+		t.Fatalf("GenerateProgram failed: %v", err)
+	}
+	if _, ok := files[MailTemplatesFile]; ok {
+		t.Errorf("%s should not be generated without a mail provider", MailTemplatesFile)
+	}
+	if strings.Contains(files["main.go"], "mail.") {
+		t.Error("Main should not configure mail without a mail provider")
+	}
+}
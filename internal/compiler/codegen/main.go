@@ -1,6 +1,10 @@
 package codegen
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
 )
 
@@ -21,7 +25,91 @@ func (g *Generator) GenerateMain(resources []*ast.ResourceNode, moduleName strin
 	g.imports["github.com/go-chi/chi/v5"] = true
 	g.imports["github.com/go-chi/chi/v5/middleware"] = true
 	g.imports["_ github.com/jackc/pgx/v5/stdlib"] = true // PostgreSQL driver
+	g.imports["github.com/conduit-lang/conduit/pkg/web/buildinfo"] = true
+	g.imports["github.com/conduit-lang/conduit/pkg/web/capture"] = true
+	g.imports["github.com/conduit-lang/conduit/pkg/web/instrument"] = true
+	g.imports["github.com/conduit-lang/conduit/pkg/web/metrics"] = true
+	g.imports["github.com/conduit-lang/conduit/pkg/web/readonly"] = true
+	g.imports["github.com/conduit-lang/conduit/pkg/web/requestlog"] = true
 	g.imports[moduleName+"/handlers"] = true              // Import handlers package
+	if !g.lambdaTarget() {
+		g.imports["context"] = true
+		g.imports["github.com/conduit-lang/conduit/pkg/web/server"] = true
+	}
+	if g.serverlessTarget() {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/serverless"] = true
+	}
+	if g.preflight.Enabled {
+		g.imports["context"] = true
+		g.imports["github.com/conduit-lang/conduit/pkg/web/preflight"] = true
+	}
+	if g.admin {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/admin"] = true
+		g.imports[moduleName+"/introspection"] = true
+	}
+	if hasCacheControl(resources) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/cache"] = true
+	}
+	if hasSearchIndex(resources) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/search"] = true
+	}
+	if hasSnowflakeIDs(resources) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/ids"] = true
+	}
+	if hasPartition(resources) {
+		g.imports["context"] = true
+		g.imports["time"] = true
+		g.imports["github.com/conduit-lang/conduit/pkg/web/partition"] = true
+	}
+	if hasMaterialized(resources) {
+		g.imports["context"] = true
+		g.imports["github.com/conduit-lang/conduit/pkg/web/matview"] = true
+	}
+	if hasShard(resources) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/shard"] = true
+	}
+	if hasEnums(resources) {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/enums"] = true
+	}
+	if g.introspection.Enabled {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/introspect"] = true
+		g.imports[moduleName+"/introspection"] = true
+	}
+	if g.playground.Enabled {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/playground"] = true
+		g.imports[moduleName+"/introspection"] = true
+	}
+	if g.mail.Enabled {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/mail"] = true
+		g.imports[moduleName+"/mailtemplates"] = true
+	}
+	if g.auth.Enabled {
+		g.imports["context"] = true
+		g.imports["github.com/conduit-lang/conduit/pkg/web/oauth"] = true
+	}
+	if g.notify.Enabled {
+		g.imports["context"] = true
+		g.imports["github.com/conduit-lang/conduit/pkg/web/notify"] = true
+	}
+	if g.quota.Enabled {
+		g.imports["context"] = true
+		g.imports["github.com/conduit-lang/conduit/pkg/web/quota"] = true
+	}
+	if len(signedRequestSecrets(resources)) > 0 {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/signing"] = true
+	}
+	if len(g.serverTimeouts()) > 0 && !g.lambdaTarget() {
+		g.imports["time"] = true
+	}
+	if g.errorTracking.Enabled {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/errortrack"] = true
+	}
+	if g.usesProfiling() {
+		g.imports["github.com/conduit-lang/conduit/pkg/web/profiling"] = true
+	}
+	if g.profiling.Interval > 0 {
+		g.imports["time"] = true
+	}
 
 	g.writeImports()
 	g.writeLine("")
@@ -37,6 +125,16 @@ func (g *Generator) generateMainFunction(resources []*ast.ResourceNode, apiPrefi
 	g.writeLine("func main() {")
 	g.indent++
 
+	g.generateBuildInfo()
+
+	if g.errorTracking.Enabled {
+		g.generateErrorTrackingConfig(resources)
+	}
+
+	if g.profilingConfigured() {
+		g.generateProfilingConfig()
+	}
+
 	// Database connection
 	g.writeLine("// Initialize database connection")
 	g.writeLine("db, err := initDB()")
@@ -48,6 +146,66 @@ func (g *Generator) generateMainFunction(resources []*ast.ResourceNode, apiPrefi
 	g.writeLine("defer db.Close()")
 	g.writeLine("")
 
+	if g.preflight.Enabled {
+		g.writeLine("// Verify the database schema before serving requests (disable with CONDUIT_PREFLIGHT=off)")
+		g.writeLine("if preflight.Enabled() {")
+		g.indent++
+		g.writeLine("if err := preflight.Check(context.Background(), db, preflightSchema); err != nil {")
+		g.indent++
+		g.writeLine("log.Fatal(err)")
+		g.indent--
+		g.writeLine("}")
+		g.indent--
+		g.writeLine("}")
+		g.writeLine("")
+	}
+
+	if hasShard(resources) {
+		g.generateShardProvider()
+	}
+
+	if hasCacheControl(resources) {
+		g.generateCachePurger()
+	}
+
+	if hasSearchIndex(resources) {
+		g.generateSearchBackend()
+	}
+
+	if hasSnowflakeIDs(resources) {
+		g.generateSnowflakeNode()
+	}
+
+	if g.mail.Enabled {
+		g.generateMailConfig()
+	}
+
+	if g.notify.Enabled {
+		g.generateNotifyConfig()
+	}
+
+	if g.auth.Enabled {
+		g.generateAuthConfig()
+	}
+
+	if g.quota.Enabled {
+		g.generateQuotaConfig()
+	}
+
+	if len(signedRequestSecrets(resources)) > 0 {
+		g.generateSigningConfig(resources)
+	}
+
+	if hasPartition(resources) {
+		g.generatePartitionMaintenance(resources)
+	}
+
+	if hasMaterialized(resources) {
+		g.generateViewRefresh(resources)
+	}
+
+	g.generateReadOnlySwitch()
+
 	// Initialize router
 	g.writeLine("// Initialize router")
 	g.writeLine("r := chi.NewRouter()")
@@ -55,10 +213,37 @@ func (g *Generator) generateMainFunction(resources []*ast.ResourceNode, apiPrefi
 
 	// Add middleware
 	g.writeLine("// Add middleware")
-	g.writeLine("r.Use(middleware.Logger)")
-	g.writeLine("r.Use(middleware.Recoverer)")
 	g.writeLine("r.Use(middleware.RequestID)")
 	g.writeLine("r.Use(middleware.RealIP)")
+	g.generateRequestLog()
+	g.writeLine("r.Use(middleware.Recoverer)")
+	if g.errorTracking.Enabled {
+		g.writeLine("// Report panics, with the operation and hook they came from, before Recoverer answers 500")
+		g.writeLine("r.Use(errortrack.Middleware)")
+	}
+	g.writeLine("// Record request counts and latency by route for /metrics")
+	g.writeLine("r.Use(metrics.Middleware(metrics.Default))")
+	g.writeLine("// Compress JSON and JSON:API responses with gzip or deflate when the client accepts it")
+	g.writeLine("r.Use(middleware.Compress(5, \"application/json\", \"application/vnd.api+json\"))")
+	g.writeLine("// Answer writes with 503 while in read-only maintenance mode")
+	g.writeLine("r.Use(maintenance.Middleware)")
+	g.writeLine("")
+
+	// Development traffic capture for `conduit replay`
+	g.writeLine("// Record sanitized request/response pairs for 'conduit replay' when CONDUIT_CAPTURE is set")
+	g.writeLine("if path := os.Getenv(capture.EnvVar); path != \"\" {")
+	g.indent++
+	g.writeLine("recorder, closer, err := capture.OpenFile(path)")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(\"Failed to open capture file: %v\", err)")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("defer closer.Close()")
+	g.writeLine("log.Printf(\"Capturing requests to %s\", path)")
+	g.writeLine("r.Use(recorder.Middleware)")
+	g.indent--
+	g.writeLine("}")
 	g.writeLine("")
 
 	// Health check endpoint (always outside prefix)
@@ -71,26 +256,276 @@ func (g *Generator) generateMainFunction(resources []*ast.ResourceNode, apiPrefi
 	g.writeLine("})")
 	g.writeLine("")
 
+	// Build information (outside prefix, like the health check)
+	g.writeLine("// Compiler version, source hash, git commit and schema version of this build")
+	g.writeLine("r.Get(buildinfo.Path, buildinfo.Handler(build))")
+	g.writeLine("")
+
+	// Connection pool statistics (outside prefix, like the health check)
+	g.writeLine("// Connection pool statistics (in use, idle, wait duration)")
+	g.writeLine("r.Get(\"/debug/db/stats\", instrument.StatsHandler(db))")
+	g.writeLine("")
+
+	// Maintenance mode switch (outside prefix, like the pool statistics)
+	g.writeLine("// Read-only maintenance mode: GET reads it, PUT switches it with CONDUIT_READ_ONLY_TOKEN")
+	g.writeLine("r.Get(readonly.Path, maintenance.Handler())")
+	g.writeLine("r.Put(readonly.Path, maintenance.Handler())")
+	g.writeLine("")
+
+	if g.profiling.Pprof {
+		g.generateProfilerMount()
+	}
+
+	// Shard topology (outside prefix, like the pool statistics)
+	if hasShard(resources) {
+		g.writeLine("// Shards of the shard map with their pinned keys and pool statistics")
+		g.writeLine("r.Get(shard.TopologyPath, shard.TopologyHandler())")
+		g.writeLine("")
+	}
+
+	// Prometheus metrics (outside prefix); @slo alerting rules query these
+	g.writeLine("// Prometheus metrics (request counts and latency by route)")
+	g.writeLine("r.Get(\"/metrics\", metrics.Handler(metrics.Default))")
+	g.writeLine("")
+
+	if g.admin {
+		g.generateAdminMount(apiPrefix)
+	}
+
+	if g.introspection.Enabled {
+		g.generateIntrospectionMount()
+	}
+
+	if g.playground.Enabled {
+		g.generatePlaygroundMount(resources, apiPrefix)
+	}
+
+	// Login routes are outside the API prefix: browsers are sent to them
+	if g.auth.Enabled {
+		g.writeLine("// Social login routes (outside API prefix)")
+		g.writeLine("handlers.RegisterAuthRoutes(r, db, login)")
+		g.writeLine("")
+	}
+
+	// Usage reports are outside the API prefix and not metered
+	if g.quota.Enabled {
+		g.writeLine("// Usage and remaining quota of the requesting client (outside API prefix)")
+		g.writeLine("r.Get(%q, meter.UsageHandler())", UsagePath)
+		g.writeLine("")
+	}
+
 	// Register routes for each resource
 	// Wrap in r.Route(prefix, ...) if prefix is configured
 	if apiPrefix != "" {
 		g.writeLine("// Register resource routes with API prefix: %s", apiPrefix)
 		g.writeLine("r.Route(\"%s\", func(r chi.Router) {", apiPrefix)
 		g.indent++
-		for _, resource := range resources {
-			g.writeLine("handlers.Register%sRoutes(r, db)", resource.Name)
+		if g.quota.Enabled {
+			g.writeLine("r.Use(meter.Middleware)")
 		}
+		g.generateResourceRoutes(resources)
+		g.indent--
+		g.writeLine("})")
+	} else if g.quota.Enabled {
+		// A group keeps the meter off the routes registered above
+		g.writeLine("// Register resource routes, metered per client")
+		g.writeLine("r.Group(func(r chi.Router) {")
+		g.indent++
+		g.writeLine("r.Use(meter.Middleware)")
+		g.generateResourceRoutes(resources)
 		g.indent--
 		g.writeLine("})")
 	} else {
 		g.writeLine("// Register resource routes")
-		for _, resource := range resources {
-			g.writeLine("handlers.Register%sRoutes(r, db)", resource.Name)
+		g.generateResourceRoutes(resources)
+	}
+	g.writeLine("")
+
+	if g.lambdaTarget() {
+		g.generateStartLambda(apiPrefix)
+	} else {
+		g.generateStartServer(apiPrefix)
+	}
+
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	// Generate initDB helper function
+	g.generateInitDBFunction()
+
+	if g.preflight.Enabled {
+		g.writeLine("")
+		g.generatePreflightSchema(resources)
+	}
+}
+
+// generateResourceRoutes registers the routes of each resource and, next to
+// them, the enum fields of all resources on enums.Path
+func (g *Generator) generateResourceRoutes(resources []*ast.ResourceNode) {
+	for _, resource := range resources {
+		g.writeLine("handlers.Register%sRoutes(r, db)", resource.Name)
+	}
+	if hasEnums(resources) {
+		g.writeLine("r.Get(enums.Path, enums.Handler(handlers.Enums))")
+	}
+}
+
+// generateAdminMount mounts the admin UI (outside the API prefix). Forms are
+// rendered from the embedded introspection metadata and submit to the API.
+func (g *Generator) generateAdminMount(apiPrefix string) {
+	g.writeLine("// Admin UI for browsing and editing records (disable with CONDUIT_ADMIN=off)")
+	g.writeLine("if admin.Enabled() {")
+	g.indent++
+	g.writeLine("adminUI, err := admin.Handler(admin.Options{Path: \"/admin\", Metadata: introspection.Metadata, APIPrefix: %q})", apiPrefix)
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(\"Failed to initialize admin UI: %v\", err)")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("r.Mount(\"/admin\", adminUI)")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
+
+// IntrospectionPath is where the metadata routes are mounted (outside the API
+// prefix)
+const IntrospectionPath = "/introspection"
+
+// generateIntrospectionMount mounts the metadata routes (outside the API
+// prefix), serving the embedded introspection metadata a page at a time.
+func (g *Generator) generateIntrospectionMount() {
+	g.writeLine("// Introspection metadata, paginated and filtered (disable with CONDUIT_INTROSPECTION=off)")
+	g.writeLine("if introspect.Enabled() {")
+	g.indent++
+	g.writeLine("metadataAPI, err := introspect.Handler(%s)", g.introspectOptions())
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(\"Failed to initialize introspection routes: %v\", err)")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("r.Mount(%q, metadataAPI)", IntrospectionPath)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
+
+// introspectOptions returns the introspect.Options literal of the metadata
+// routes. The bearer token is read from the environment, never embedded.
+func (g *Generator) introspectOptions() string {
+	parts := []string{fmt.Sprintf("Path: %q", IntrospectionPath), "Metadata: introspection.Metadata"}
+	switch g.introspection.Auth {
+	case "bearer":
+		parts = append(parts, "Auth: introspect.AuthBearer", "Token: os.Getenv(introspect.TokenEnvVar)")
+	case "mtls":
+		parts = append(parts, "Auth: introspect.AuthMTLS")
+	}
+	if len(g.introspection.Exposure) > 0 {
+		sections := make([]string, 0, len(g.introspection.Exposure))
+		for section := range g.introspection.Exposure {
+			sections = append(sections, section)
+		}
+		sort.Strings(sections)
+		entries := make([]string, 0, len(sections))
+		for _, section := range sections {
+			entries = append(entries, fmt.Sprintf("%q: %q", section, g.introspection.Exposure[section]))
 		}
+		parts = append(parts, fmt.Sprintf("Exposure: map[string]string{%s}", strings.Join(entries, ", ")))
 	}
+	return "introspect.Options{" + strings.Join(parts, ", ") + "}"
+}
+
+// introspectionMTLS reports whether client certificates authenticate the
+// metadata routes, which needs the application served over TLS
+func (g *Generator) introspectionMTLS() bool {
+	return g.introspection.Enabled && g.introspection.Auth == "mtls"
+}
+
+// generateTLSConfig serves the application over TLS, verifying the client
+// certificates presented for the metadata routes. Clients without one still
+// reach the API.
+func (g *Generator) generateTLSConfig() {
+	g.writeLine("// Served over TLS so client certificates can authenticate the introspection routes")
+	g.writeLine("tlsConfig, err := introspect.ClientCertTLSConfig(os.Getenv(introspect.ClientCAEnvVar))")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(\"Failed to configure TLS: %v\", err)")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("srv.TLSConfig = tlsConfig")
+	g.writeLine("")
+}
+
+// generatePlaygroundMount mounts the API playground (outside the API prefix).
+// It is served unless the environment is production, or always when the build
+// allows production.
+func (g *Generator) generatePlaygroundMount(resources []*ast.ResourceNode, apiPrefix string) {
+	path := playgroundPath(resources, apiPrefix)
+	g.writeLine("// API playground for the OpenAPI specification (toggle with CONDUIT_PLAYGROUND=on|off)")
+	g.writeLine("if playground.Enabled(%t) {", g.playground.AllowProduction)
+	g.indent++
+	g.writeLine("r.Mount(%q, playground.Handler(playground.Options{Path: %q, Spec: introspection.OpenAPI}))", path, path)
+	g.indent--
+	g.writeLine("}")
 	g.writeLine("")
+}
+
+// playgroundPath returns where the API playground is mounted: /docs, unless a
+// resource is served from /docs because no API prefix is configured
+func playgroundPath(resources []*ast.ResourceNode, apiPrefix string) string {
+	if apiPrefix == "" {
+		for _, resource := range resources {
+			if TableName(resource.Name) == "docs" {
+				return "/_docs"
+			}
+		}
+	}
+	return "/docs"
+}
+
+// generatePreflightSchema generates the schema checked by preflight.Check:
+// each resource table with the columns its model reads and writes
+func (g *Generator) generatePreflightSchema(resources []*ast.ResourceNode) {
+	g.writeLine("// preflightSchema is the database schema this build expects")
+	g.writeLine("var preflightSchema = preflight.Schema{")
+	g.indent++
+	g.writeLine("MigrationVersion: %d,", g.preflight.MigrationVersion)
+	g.writeLine("Tables: []preflight.Table{")
+	g.indent++
+	for _, resource := range resources {
+		g.writeLine("{Name: %q, Columns: %s},", g.sqlTable(resource), g.stringSliceLiteral(g.resourceColumns(resource)))
+	}
+	g.indent--
+	g.writeLine("},")
+	g.indent--
+	g.writeLine("}")
+}
+
+// resourceColumns returns every column the generated model reads or writes
+func (g *Generator) resourceColumns(resource *ast.ResourceNode) []string {
+	columns := []string{}
+	for _, field := range resource.Fields {
+		columns = append(columns, g.fieldColumnName(field))
+		if legacy, _ := field.DualWrite(); legacy != "" {
+			columns = append(columns, legacy)
+		}
+	}
+	// Tables without a declared primary key get the default id
+	if hasImplicitID(resource) {
+		columns = append([]string{"id"}, columns...)
+	}
+	return columns
+}
+
+// ResourceColumns returns every column the generated model for a resource
+// reads or writes, including the implicit id and @dual_write legacy columns
+func ResourceColumns(resource *ast.ResourceNode) []string {
+	return (&Generator{}).resourceColumns(resource)
+}
 
-	// Start server
+// generateStartServer listens on PORT, 8080 by default
+func (g *Generator) generateStartServer(apiPrefix string) {
 	g.writeLine("// Start server")
 	g.writeLine("port := os.Getenv(\"PORT\")")
 	g.writeLine("if port == \"\" {")
@@ -108,18 +543,7 @@ func (g *Generator) generateMainFunction(resources []*ast.ResourceNode, apiPrefi
 	}
 	g.writeLine("")
 
-	g.writeLine("if err := http.ListenAndServe(addr, r); err != nil {")
-	g.indent++
-	g.writeLine("log.Fatalf(\"Server failed: %v\", err)")
-	g.indent--
-	g.writeLine("}")
-
-	g.indent--
-	g.writeLine("}")
-	g.writeLine("")
-
-	// Generate initDB helper function
-	g.generateInitDBFunction()
+	g.generateServe()
 }
 
 // generateInitDBFunction generates the database initialization function
@@ -140,8 +564,8 @@ func (g *Generator) generateInitDBFunction() {
 	g.writeLine("")
 
 	// Open database connection
-	g.writeLine("// Open database connection")
-	g.writeLine("db, err := sql.Open(\"pgx\", dbURL)")
+	g.writeLine("// Open database connection; queries slower than DB_SLOW_QUERY_THRESHOLD (default 200ms) are logged")
+	g.writeLine("db, err := instrument.Open(\"pgx\", dbURL, instrument.ConfigFromEnv())")
 	g.writeLine("if err != nil {")
 	g.indent++
 	g.writeLine("return nil, fmt.Errorf(\"failed to open database: %w\", err)")
@@ -149,6 +573,13 @@ func (g *Generator) generateInitDBFunction() {
 	g.writeLine("}")
 	g.writeLine("")
 
+	if g.serverlessTarget() {
+		g.generateServerlessPool()
+		g.indent--
+		g.writeLine("}")
+		return
+	}
+
 	// Test connection
 	g.writeLine("// Test connection")
 	g.writeLine("if err := db.Ping(); err != nil {")
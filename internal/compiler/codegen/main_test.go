@@ -88,8 +88,8 @@ func TestGenerateMain_MainFunction(t *testing.T) {
 	}
 
 	// Verify middleware
-	if !strings.Contains(code, "r.Use(middleware.Logger)") {
-		t.Error("Generated code should use Logger middleware")
+	if !strings.Contains(code, "r.Use(requestlog.Middleware(") {
+		t.Error("Generated code should use the structured request log")
 	}
 
 	if !strings.Contains(code, "r.Use(middleware.Recoverer)") {
@@ -100,6 +100,10 @@ func TestGenerateMain_MainFunction(t *testing.T) {
 		t.Error("Generated code should use RequestID middleware")
 	}
 
+	if !strings.Contains(code, `r.Use(middleware.Compress(5, "application/json", "application/vnd.api+json"))`) {
+		t.Error("Generated code should compress JSON and JSON:API responses")
+	}
+
 	// Verify resource route registration
 	if !strings.Contains(code, "handlers.RegisterPostRoutes(r, db)") {
 		t.Error("Generated code should register Post routes")
@@ -114,8 +118,29 @@ func TestGenerateMain_MainFunction(t *testing.T) {
 		t.Error("Generated code should have health check endpoint")
 	}
 
+	// Verify connection pool statistics endpoint
+	if !strings.Contains(code, `r.Get("/debug/db/stats", instrument.StatsHandler(db))`) {
+		t.Error("Generated code should expose connection pool statistics")
+	}
+
+	// Verify opt-in traffic capture
+	if !strings.Contains(code, "if path := os.Getenv(capture.EnvVar); path != \"\" {") ||
+		!strings.Contains(code, "r.Use(recorder.Middleware)") {
+		t.Error("Generated code should capture traffic when CONDUIT_CAPTURE is set")
+	}
+
+	// Verify route metrics
+	if !strings.Contains(code, "r.Use(metrics.Middleware(metrics.Default))") {
+		t.Error("Generated code should record route metrics")
+	}
+
+	if !strings.Contains(code, `r.Get("/metrics", metrics.Handler(metrics.Default))`) {
+		t.Error("Generated code should expose Prometheus metrics")
+	}
+
 	// Verify server start
-	if !strings.Contains(code, "http.ListenAndServe(addr, r)") {
+	if !strings.Contains(code, "srv := server.New(addr, r, serverConfig)") ||
+		!strings.Contains(code, "server.Run(ctx, srv, serverConfig, srv.ListenAndServe)") {
 		t.Error("Generated code should start HTTP server")
 	}
 }
@@ -152,8 +177,8 @@ func TestGenerateInitDBFunction(t *testing.T) {
 	}
 
 	// Verify database operations
-	if !strings.Contains(code, `sql.Open("pgx", dbURL)`) {
-		t.Error("Generated code should open PostgreSQL connection")
+	if !strings.Contains(code, `instrument.Open("pgx", dbURL, instrument.ConfigFromEnv())`) {
+		t.Error("Generated code should open an instrumented PostgreSQL connection")
 	}
 
 	if !strings.Contains(code, "db.Ping()") {
@@ -201,3 +226,257 @@ func TestGenerateMain_PortConfiguration(t *testing.T) {
 		t.Error("Generated code should format address with port")
 	}
 }
+
+func TestGenerateMain_Preflight(t *testing.T) {
+	resources := []*ast.ResourceNode{
+		{
+			Name: "Post",
+			Fields: []*ast.FieldNode{
+				{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}, Nullable: false},
+				{
+					Name:     "authorId",
+					Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"},
+					Nullable: false,
+					Constraints: []*ast.ConstraintNode{
+						{Name: "column", Arguments: []ast.ExprNode{&ast.LiteralExpr{Value: "author_ref"}}},
+					},
+				},
+			},
+		},
+	}
+
+	// Disabled by default
+	gen := NewGenerator()
+	code, err := gen.GenerateMain(resources, "example.com/testapp", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "preflight") {
+		t.Error("Generated code should not run a preflight unless enabled")
+	}
+
+	gen = NewGenerator()
+	gen.SetPreflight(PreflightOptions{Enabled: true, MigrationVersion: 20240102150405})
+	code, err = gen.GenerateMain(resources, "example.com/testapp", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+
+	expected := []string{
+		`"github.com/conduit-lang/conduit/pkg/web/preflight"`,
+		"if preflight.Enabled() {",
+		"if err := preflight.Check(context.Background(), db, preflightSchema); err != nil {",
+		"var preflightSchema = preflight.Schema{",
+		"MigrationVersion: 20240102150405,",
+		`{Name: "posts", Columns: []string{"id", "title", "author_ref"}},`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(code, want) {
+			t.Errorf("Generated code should contain %q", want)
+		}
+	}
+
+	// The check must run after the database is opened and before serving
+	if strings.Index(code, "preflight.Check(") < strings.Index(code, "db, err := initDB()") ||
+		strings.Index(code, "preflight.Check(") > strings.Index(code, "server.Run(") {
+		t.Error("Preflight should run between initDB and server.Run")
+	}
+}
+
+func TestGenerateMain_Admin(t *testing.T) {
+	resources := []*ast.ResourceNode{
+		{
+			Name: "Post",
+			Fields: []*ast.FieldNode{
+				{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}, Nullable: false},
+			},
+		},
+	}
+
+	// Disabled by default
+	gen := NewGenerator()
+	code, err := gen.GenerateMain(resources, "example.com/testapp", "/api")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "admin") {
+		t.Error("Generated code should not mount the admin UI unless enabled")
+	}
+
+	gen = NewGenerator()
+	gen.SetAdmin(true)
+	code, err = gen.GenerateMain(resources, "example.com/testapp", "/api")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+
+	expected := []string{
+		`"github.com/conduit-lang/conduit/pkg/web/admin"`,
+		`"example.com/testapp/introspection"`,
+		"if admin.Enabled() {",
+		`adminUI, err := admin.Handler(admin.Options{Path: "/admin", Metadata: introspection.Metadata, APIPrefix: "/api"})`,
+		`r.Mount("/admin", adminUI)`,
+	}
+	for _, exp := range expected {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated code missing %q\n%s", exp, code)
+		}
+	}
+
+	// Mounted outside the API prefix
+	if strings.Index(code, `r.Mount("/admin", adminUI)`) > strings.Index(code, `r.Route("/api"`) {
+		t.Error("Admin UI should be mounted before (outside) the API prefix route")
+	}
+}
+
+func TestGenerateMain_Introspection(t *testing.T) {
+	resources := []*ast.ResourceNode{
+		{
+			Name: "Post",
+			Fields: []*ast.FieldNode{
+				{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}, Nullable: false},
+			},
+		},
+	}
+
+	// Disabled by default
+	gen := NewGenerator()
+	code, err := gen.GenerateMain(resources, "example.com/testapp", "/api")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "introspect") {
+		t.Error("Generated code should not mount the metadata routes unless enabled")
+	}
+
+	gen = NewGenerator()
+	gen.SetIntrospection(IntrospectionOptions{Enabled: true})
+	code, err = gen.GenerateMain(resources, "example.com/testapp", "/api")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+
+	expected := []string{
+		`"github.com/conduit-lang/conduit/pkg/web/introspect"`,
+		`"example.com/testapp/introspection"`,
+		"if introspect.Enabled() {",
+		`metadataAPI, err := introspect.Handler(introspect.Options{Path: "/introspection", Metadata: introspection.Metadata})`,
+		`r.Mount("/introspection", metadataAPI)`,
+	}
+	for _, exp := range expected {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated code missing %q\n%s", exp, code)
+		}
+	}
+
+	// Mounted outside the API prefix
+	if strings.Index(code, `r.Mount("/introspection", metadataAPI)`) > strings.Index(code, `r.Route("/api"`) {
+		t.Error("Metadata routes should be mounted before (outside) the API prefix route")
+	}
+}
+
+func TestGenerateMain_IntrospectionAccess(t *testing.T) {
+	resources := []*ast.ResourceNode{{Name: "Post"}}
+
+	gen := NewGenerator()
+	gen.SetIntrospection(IntrospectionOptions{
+		Enabled:  true,
+		Auth:     "bearer",
+		Exposure: map[string]string{"routes": "public", "hooks": "hidden"},
+	})
+	code, err := gen.GenerateMain(resources, "example.com/testapp", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	expected := `metadataAPI, err := introspect.Handler(introspect.Options{Path: "/introspection", Metadata: introspection.Metadata, ` +
+		`Auth: introspect.AuthBearer, Token: os.Getenv(introspect.TokenEnvVar), Exposure: map[string]string{"hooks": "hidden", "routes": "public"}})`
+	if !strings.Contains(code, expected) {
+		t.Errorf("Generated code missing %q\n%s", expected, code)
+	}
+	if !strings.Contains(code, "srv.ListenAndServe)") || strings.Contains(code, "ListenAndServeTLS") {
+		t.Error("Bearer auth should keep serving plain HTTP")
+	}
+
+	gen = NewGenerator()
+	gen.SetIntrospection(IntrospectionOptions{Enabled: true, Auth: "mtls"})
+	code, err = gen.GenerateMain(resources, "example.com/testapp", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	for _, exp := range []string{
+		"Auth: introspect.AuthMTLS}",
+		"tlsConfig, err := introspect.ClientCertTLSConfig(os.Getenv(introspect.ClientCAEnvVar))",
+		"srv.TLSConfig = tlsConfig",
+		"server.Run(ctx, srv, serverConfig, func() error { return srv.ListenAndServeTLS(os.Getenv(introspect.CertEnvVar), os.Getenv(introspect.KeyEnvVar)) })",
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated code missing %q\n%s", exp, code)
+		}
+	}
+	if strings.Contains(code, "srv.ListenAndServe)") {
+		t.Error("mtls auth should serve over TLS only")
+	}
+}
+
+func TestGenerateMain_Playground(t *testing.T) {
+	resources := []*ast.ResourceNode{
+		{
+			Name: "Post",
+			Fields: []*ast.FieldNode{
+				{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}, Nullable: false},
+			},
+		},
+	}
+
+	gen := NewGenerator()
+	code, err := gen.GenerateMain(resources, "example.com/testapp", "/api")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "playground") {
+		t.Error("Generated code should not mount the playground unless enabled")
+	}
+
+	gen = NewGenerator()
+	gen.SetPlayground(PlaygroundOptions{Enabled: true})
+	code, err = gen.GenerateMain(resources, "example.com/testapp", "/api")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+
+	expected := []string{
+		`"github.com/conduit-lang/conduit/pkg/web/playground"`,
+		`"example.com/testapp/introspection"`,
+		"if playground.Enabled(false) {",
+		`r.Mount("/docs", playground.Handler(playground.Options{Path: "/docs", Spec: introspection.OpenAPI}))`,
+	}
+	for _, exp := range expected {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated code missing %q\n%s", exp, code)
+		}
+	}
+
+	// Without a prefix, a Doc resource owns /docs
+	resources = append(resources, &ast.ResourceNode{Name: "Doc"})
+	gen = NewGenerator()
+	gen.SetPlayground(PlaygroundOptions{Enabled: true, AllowProduction: true})
+	code, err = gen.GenerateMain(resources, "example.com/testapp", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	for _, exp := range []string{
+		"if playground.Enabled(true) {",
+		`r.Mount("/_docs", playground.Handler(playground.Options{Path: "/_docs", Spec: introspection.OpenAPI}))`,
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated code missing %q\n%s", exp, code)
+		}
+	}
+}
+
+func TestGenerateOpenAPIAccessor(t *testing.T) {
+	code := NewGenerator().GenerateOpenAPIAccessor("{\"description\": \"uses `code`\"}")
+	if !strings.Contains(code, "const OpenAPI = `{\"description\": \"uses ` + \"`\" + `code` + \"`\" + `\"}`") {
+		t.Errorf("OpenAPI constant not escaped correctly:\n%s", code)
+	}
+}
@@ -0,0 +1,119 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// hasMaterialized reports whether any resource declares @materialized
+func hasMaterialized(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if resource.Materialized != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshSchedules maps @materialized schedules to pkg/web/matview constants
+var refreshSchedules = map[string]string{
+	ast.RefreshHourly: "matview.Hourly",
+	ast.RefreshDaily:  "matview.Daily",
+	ast.RefreshWeekly: "matview.Weekly",
+}
+
+// sourceTablePattern matches the table after FROM or JOIN, optionally
+// schema-qualified or quoted
+var sourceTablePattern = regexp.MustCompile(`(?i)\b(?:from|join)\s+(?:"?[a-z_][a-z0-9_]*"?\.)?"?([a-z_][a-z0-9_]*)"?`)
+
+// MaterializedSources returns the names of the resources a @materialized
+// query reads: those whose tables follow FROM or JOIN. Tables that belong to
+// no resource are ignored.
+func MaterializedSources(query string, resources []*ast.ResourceNode) []string {
+	byTable := make(map[string]string, len(resources))
+	for _, resource := range resources {
+		byTable[TableName(resource.Name)] = resource.Name
+	}
+
+	var sources []string
+	seen := make(map[string]bool)
+	for _, match := range sourceTablePattern.FindAllStringSubmatch(query, -1) {
+		name, ok := byTable[strings.ToLower(match[1])]
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		sources = append(sources, name)
+	}
+	return sources
+}
+
+// generateCreateView generates the CREATE MATERIALIZED VIEW statement for a
+// @materialized resource, with the unique index on id that concurrent
+// refreshes require
+func (g *Generator) generateCreateView(resource *ast.ResourceNode) string {
+	viewName := g.sqlTable(resource)
+
+	var sql strings.Builder
+	sql.WriteString(fmt.Sprintf("CREATE MATERIALIZED VIEW %s AS\n%s;\n", viewName, resource.Materialized.Query))
+	for _, field := range resource.Fields {
+		if field.Name == "id" && !hasConstraint(field, "unique") {
+			sql.WriteString(fmt.Sprintf("CREATE UNIQUE INDEX idx_%s_id ON %s(%s);\n", g.toTableName(resource.Name), viewName, g.fieldColumnName(field)))
+		}
+	}
+	return sql.String()
+}
+
+// generateReadOnlyRoutes registers the list and show routes of a
+// @materialized or @external_table resource, with caching headers when it
+// declares @cache_control
+func (g *Generator) generateReadOnlyRoutes(resource *ast.ResourceNode) {
+	tableName := g.toTableName(resource.Name)
+	member := g.memberPath(resource)
+	kind := "@materialized view"
+	if resource.External != nil {
+		kind = "@external_table " + resource.External.Table
+	}
+
+	var read []string
+	if resource.CacheControl != nil {
+		// Records with a natural key are keyed by its route parameters
+		keyParams := ""
+		if resource.HasNaturalKey() {
+			for _, param := range resource.KeyParams() {
+				keyParams += fmt.Sprintf(", %q", param)
+			}
+		}
+		g.writeLine("// Read-only %s; Cache-Control and Surrogate-Key headers from @cache_control", kind)
+		g.writeLine("cacheable := cache.Cacheable(%s, %q%s)", cachePolicyLiteral(resource.CacheControl), tableName, keyParams)
+		read = append(read, "cacheable")
+	} else {
+		g.writeLine("// Read-only %s", kind)
+	}
+
+	if servesOperation(resource, ast.OperationList) {
+		g.registerRoute(g.router(resource, ast.OperationList, read...), "Get", "/"+tableName, "List"+resource.Name+"Handler(db)")
+	}
+	if servesOperation(resource, ast.OperationGet) {
+		g.registerRoute(g.router(resource, ast.OperationGet, read...), "Get", member, "Get"+resource.Name+"Handler(db)")
+	}
+}
+
+// generateViewRefresh refreshes the materialized views of @materialized
+// resources on their schedules while the application runs
+func (g *Generator) generateViewRefresh(resources []*ast.ResourceNode) {
+	var views []string
+	for _, resource := range resources {
+		if resource.Materialized == nil {
+			continue
+		}
+		views = append(views, "{Name: \""+g.sqlTable(resource)+"\", Refresh: "+refreshSchedules[resource.Materialized.Refresh]+"}")
+	}
+
+	g.writeLine("// Refresh @materialized views on their schedules")
+	g.writeLine("matview.Maintain(context.Background(), db, []matview.View{%s})", strings.Join(views, ", "))
+	g.writeLine("")
+}
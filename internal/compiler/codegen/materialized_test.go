@@ -0,0 +1,144 @@
+package codegen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const postStatsQuery = `SELECT p.id, p.title, count(c.id) AS comment_count
+FROM posts p
+LEFT JOIN comments c ON c.post_id = p.id
+GROUP BY p.id, p.title`
+
+const materializedSource = `resource PostStat {
+  @materialized(refresh: hourly, query: "SELECT p.id, p.title, count(c.id) AS comment_count\nFROM posts p\nLEFT JOIN comments c ON c.post_id = p.id\nGROUP BY p.id, p.title")
+
+  id: uuid!
+  title: string!
+  comment_count: int!
+}`
+
+func TestGenerateMigrations_Materialized(t *testing.T) {
+	view := parseResource(t, materializedSource)
+	post := &ast.ResourceNode{
+		Name: "Post",
+		Fields: []*ast.FieldNode{
+			{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}, Nullable: false},
+		},
+	}
+
+	// The view is declared first but created after the tables it reads
+	sql, err := NewGenerator().GenerateMigrations([]*ast.ResourceNode{view, post})
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+
+	create := "CREATE MATERIALIZED VIEW poststats AS\n" + postStatsQuery + ";\n"
+	if !strings.Contains(sql, create) {
+		t.Errorf("Migration missing view definition:\n%s", sql)
+	}
+	if !strings.Contains(sql, "CREATE UNIQUE INDEX idx_poststats_id ON poststats(id);") {
+		t.Errorf("Migration missing unique index for concurrent refresh:\n%s", sql)
+	}
+	if strings.Contains(sql, "CREATE TABLE poststats") {
+		t.Error("Migration should not create a table for a @materialized resource")
+	}
+	if strings.Index(sql, "CREATE TABLE posts") > strings.Index(sql, create) {
+		t.Errorf("Tables should be created before views:\n%s", sql)
+	}
+}
+
+func TestGenerateHandlers_Materialized(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, materializedSource))
+
+	for _, exp := range []string{
+		"func ListPostStatHandler(",
+		"func GetPostStatHandler(",
+		`r.Get("/poststats", ListPostStatHandler(db))`,
+		`r.Get("/poststats/{id}", GetPostStatHandler(db))`,
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated handlers missing %q", exp)
+		}
+	}
+	for _, unexpected := range []string{
+		"CreatePostStatHandler",
+		"UpdatePostStatHandler",
+		"PatchPostStatHandler",
+		"DeletePostStatHandler",
+		`"io"`,
+	} {
+		if strings.Contains(code, unexpected) {
+			t.Errorf("Generated handlers for a read-only view should not contain %q", unexpected)
+		}
+	}
+
+	// @cache_control still applies to the read routes
+	resource := parseResource(t, materializedSource)
+	resource.CacheControl = &ast.CacheControlNode{MaxAge: 60}
+	code = generateHandlers(t, resource)
+	if !strings.Contains(code, `r.With(cacheable).Get("/poststats", ListPostStatHandler(db))`) || strings.Contains(code, "PurgeOnWrite") {
+		t.Errorf("Cached view should register read routes without purging:\n%s", code)
+	}
+}
+
+func TestGenerateMain_Materialized(t *testing.T) {
+	code, err := NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, materializedSource)}, "example.com/blog", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	for _, exp := range []string{
+		`"github.com/conduit-lang/conduit/pkg/web/matview"`,
+		`matview.Maintain(context.Background(), db, []matview.View{{Name: "poststats", Refresh: matview.Hourly}})`,
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated main missing %q", exp)
+		}
+	}
+
+	code, err = NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, postSource)}, "example.com/blog", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "matview.") {
+		t.Error("Generated main should not refresh views without @materialized resources")
+	}
+}
+
+func TestMaterializedSources(t *testing.T) {
+	resources := []*ast.ResourceNode{
+		{Name: "Post"},
+		{Name: "Comment"},
+		parseResource(t, materializedSource),
+	}
+
+	got := MaterializedSources(postStatsQuery, resources)
+	if want := []string{"Post", "Comment"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MaterializedSources() = %v, want %v", got, want)
+	}
+
+	// Quoted and schema-qualified tables resolve; unknown tables are ignored
+	got = MaterializedSources(`select * from public."comments" join audit_log using (id) join comments c2 on true`, resources)
+	if want := []string{"Comment"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MaterializedSources() = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateMetadata_MaterializedSources(t *testing.T) {
+	prog := &ast.Program{Resources: []*ast.ResourceNode{
+		{Name: "Post"},
+		{Name: "Comment"},
+		parseResource(t, materializedSource),
+	}}
+
+	metadataJSON, err := NewGenerator().GenerateMetadata(prog)
+	if err != nil {
+		t.Fatalf("GenerateMetadata() error = %v", err)
+	}
+	if !strings.Contains(metadataJSON, `"sources": [`) || !strings.Contains(metadataJSON, `"Comment"`) {
+		t.Errorf("Metadata should list the view's sources:\n%s", metadataJSON)
+	}
+}
@@ -20,6 +20,48 @@ func (g *Generator) GenerateMetadata(prog *ast.Program) (string, error) {
 		return "", fmt.Errorf("metadata extraction failed: %w", err)
 	}
 
+	// Table names are a codegen concern, so the resources a view reads are
+	// resolved here
+	for i := range meta.Resources {
+		if view := meta.Resources[i].Materialized; view != nil {
+			view.Sources = MaterializedSources(view.Query, prog.Resources)
+		}
+	}
+
+	// So are the statements behind each route. A model that cannot be
+	// generated fails the build anyway, so its routes are left without.
+	queries := make(map[string]map[string][]string)
+	for _, resource := range prog.Resources {
+		if resourceQueries, err := RouteQueries(resource); err == nil {
+			queries[resource.Name] = resourceQueries
+		}
+	}
+	for i, route := range meta.Routes {
+		operation := route.Operation
+		if operation == "get" {
+			operation = "show"
+		}
+		meta.Routes[i].Queries = queries[route.Resource][operation]
+	}
+
+	// Login providers come from conduit.yaml rather than the source
+	if g.auth.Enabled {
+		auth, routes := g.authMetadata()
+		meta.Auth = auth
+		meta.Routes = append(meta.Routes, routes...)
+	}
+	if g.quota.Enabled {
+		meta.Routes = append(meta.Routes, g.quotaMetadata())
+	}
+
+	if err := metadata.Redact(meta, g.redaction); err != nil {
+		return "", fmt.Errorf("metadata redaction failed: %w", err)
+	}
+
+	// The generated main reports which metadata it was built with
+	g.build.SourceHash = meta.SourceHash
+	g.build.SchemaVersion = meta.Version
+
 	jsonStr, err := meta.ToJSON()
 	if err != nil {
 		return "", fmt.Errorf("metadata JSON generation failed: %w", err)
@@ -28,6 +70,19 @@ func (g *Generator) GenerateMetadata(prog *ast.Program) (string, error) {
 	return jsonStr, nil
 }
 
+// GenerateOpenAPIAccessor generates Go code that embeds the OpenAPI
+// specification in the introspection package
+func (g *Generator) GenerateOpenAPIAccessor(spec string) string {
+	g.reset()
+
+	g.writeLine("package introspection")
+	g.writeLine("")
+	g.writeLine("// OpenAPI contains the OpenAPI 3.0 specification of the application's API as JSON")
+	g.writeLine("const OpenAPI = `%s`", escapeBackticks(spec))
+
+	return g.buf.String()
+}
+
 // GenerateMetadataAccessor generates Go code that embeds and exposes metadata
 func (g *Generator) GenerateMetadataAccessor(metadataJSON string) (string, error) {
 	g.reset()
@@ -230,8 +285,23 @@ func (g *Generator) EmbedMetadata(metadataJSON string) (string, error) {
 	g.indent--
 	g.writeLine("}")
 	g.writeLine("")
-	g.writeLine("// Register with runtime")
-	g.writeLine("if err := metadata.RegisterMetadata(decompressed); err != nil {")
+	g.writeLine("// Register with runtime. CONDUIT_METADATA_LAZY leaves patterns and hook")
+	g.writeLine("// source code to be decompressed again when first queried.")
+	g.writeLine("if metadata.LazyFromEnv() {")
+	g.indent++
+	g.writeLine("err = metadata.RegisterMetadataWithOptions(decompressed, metadata.RegisterOptions{")
+	g.indent++
+	g.writeLine("Lazy:   true,")
+	g.writeLine("Reload: func() ([]byte, error) { return decompressMetadata(embeddedMetadata) },")
+	g.indent--
+	g.writeLine("})")
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("err = metadata.RegisterMetadata(decompressed)")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if err != nil {")
 	g.indent++
 	g.writeLine("panic(fmt.Sprintf(\"Failed to register metadata: %v\", err))")
 	g.indent--
@@ -5,12 +5,14 @@ import (
 	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"go/format"
 	"io"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/metadata"
 )
 
 func TestGenerator_GenerateMetadata(t *testing.T) {
@@ -509,6 +511,28 @@ func TestEmbedMetadata_WithAllFeatures(t *testing.T) {
 	}
 }
 
+func TestEmbedMetadata_LazyRegistration(t *testing.T) {
+	code, err := NewGenerator().EmbedMetadata(`{"version": "1.0.0", "resources": []}`)
+	if err != nil {
+		t.Fatalf("EmbedMetadata() error = %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+
+	// The embedded copy is decompressed again rather than kept in memory
+	for _, want := range []string{
+		"if metadata.LazyFromEnv() {",
+		"err = metadata.RegisterMetadataWithOptions(decompressed, metadata.RegisterOptions{",
+		"Reload: func() ([]byte, error) { return decompressMetadata(embeddedMetadata) },",
+		"err = metadata.RegisterMetadata(decompressed)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Generated code missing %q", want)
+		}
+	}
+}
+
 func TestCompressMetadata(t *testing.T) {
 	original := []byte("Hello, World! This is test data for compression.")
 
@@ -641,3 +665,78 @@ func BenchmarkEmbedMetadata_Large(b *testing.B) {
 		}
 	}
 }
+
+func TestGenerator_GenerateMetadata_StripSource(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Post",
+				Hooks: []*ast.HookNode{
+					{
+						Timing: "before",
+						Event:  "create",
+						Body:   []ast.StmtNode{&ast.ExprStmt{Expr: &ast.LiteralExpr{Value: "slugify"}}},
+					},
+				},
+			},
+		},
+	}
+
+	gen := NewGenerator()
+	full, err := gen.GenerateMetadata(prog)
+	if err != nil {
+		t.Fatalf("GenerateMetadata() error = %v", err)
+	}
+	if !strings.Contains(full, `"source_code"`) || strings.Contains(full, `"sha256:`) {
+		t.Errorf("expected hook source without stripping:\n%s", full)
+	}
+
+	gen = NewGenerator()
+	gen.SetStripSource(true)
+	stripped, err := gen.GenerateMetadata(prog)
+	if err != nil {
+		t.Fatalf("GenerateMetadata() error = %v", err)
+	}
+	if strings.Contains(stripped, `"source_code"`) || !strings.Contains(stripped, `"source_hash": "sha256:`) {
+		t.Errorf("expected a hash in place of hook source:\n%s", stripped)
+	}
+}
+
+func TestGenerator_GenerateMetadata_Redaction(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name:          "Invoice",
+				Documentation: "Discounts apply after the tenth order",
+				Hooks: []*ast.HookNode{
+					{
+						Timing: "before",
+						Event:  "create",
+						Body:   []ast.StmtNode{&ast.ExprStmt{Expr: &ast.LiteralExpr{Value: "discount"}}},
+					},
+				},
+			},
+		},
+	}
+
+	gen := NewGenerator()
+	gen.SetRedaction(metadata.RedactionPolicy{Source: metadata.RedactOmitted, Documentation: metadata.RedactOmitted})
+	metadataJSON, err := gen.GenerateMetadata(prog)
+	if err != nil {
+		t.Fatalf("GenerateMetadata() error = %v", err)
+	}
+	for _, key := range []string{`"source_code"`, `"documentation"`, `"sha256:`} {
+		if strings.Contains(metadataJSON, key) {
+			t.Errorf("expected no %s in redacted metadata:\n%s", key, metadataJSON)
+		}
+	}
+	if !strings.Contains(metadataJSON, `"timing": "before"`) {
+		t.Errorf("expected the hook kept:\n%s", metadataJSON)
+	}
+
+	gen = NewGenerator()
+	gen.SetRedaction(metadata.RedactionPolicy{Source: "hidden"})
+	if _, err := gen.GenerateMetadata(prog); err == nil {
+		t.Error("expected an error for an unknown redaction mode")
+	}
+}
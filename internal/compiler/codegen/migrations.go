@@ -2,6 +2,7 @@ package codegen
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
@@ -10,11 +11,29 @@ import (
 // GenerateMigrations generates SQL migration file for all resources
 func (g *Generator) GenerateMigrations(resources []*ast.ResourceNode) (string, error) {
 	var sql strings.Builder
+	resources = ast.WithSoftDeletes(ast.WithPositions(ast.WithCounterCaches(ast.WithTimestamps(resources, false))))
 
 	sql.WriteString("-- Initial migration for Conduit resources\n")
 	sql.WriteString("-- Generated automatically - do not edit\n\n")
 
+	// Point and polygon fields are PostGIS geography columns
+	if hasSpatialFields(resources) {
+		sql.WriteString("CREATE EXTENSION IF NOT EXISTS postgis;\n\n")
+	}
+
+	// Tables declared @schema are created in their schema
+	if schemas := resourceSchemas(resources); len(schemas) > 0 {
+		for _, schema := range schemas {
+			sql.WriteString(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;\n", schema))
+		}
+		sql.WriteString("\n")
+	}
+
 	for _, resource := range resources {
+		// External tables exist already and are never migrated
+		if resource.Materialized != nil || resource.External != nil {
+			continue
+		}
 		tableDDL, err := g.generateCreateTable(resource)
 		if err != nil {
 			return "", fmt.Errorf("failed to generate table for %s: %w", resource.Name, err)
@@ -23,13 +42,25 @@ func (g *Generator) GenerateMigrations(resources []*ast.ResourceNode) (string, e
 		sql.WriteString("\n")
 
 		// Generate indexes
-		indexDDL := g.generateIndexes(resource)
+		indexDDL := g.generateIndexes(resource, resource.HasOneColumns(resources))
 		if indexDDL != "" {
 			sql.WriteString(indexDDL)
 			sql.WriteString("\n")
 		}
 	}
 
+	// Materialized views are created after the tables they read
+	for _, resource := range resources {
+		if resource.Materialized == nil {
+			continue
+		}
+		sql.WriteString(g.generateCreateView(resource))
+		if indexDDL := g.generateIndexes(resource, nil); indexDDL != "" {
+			sql.WriteString(indexDDL)
+		}
+		sql.WriteString("\n")
+	}
+
 	return sql.String(), nil
 }
 
@@ -37,21 +68,27 @@ func (g *Generator) GenerateMigrations(resources []*ast.ResourceNode) (string, e
 func (g *Generator) generateCreateTable(resource *ast.ResourceNode) (string, error) {
 	var sql strings.Builder
 
-	tableName := g.toTableName(resource.Name)
+	tableName := g.sqlTable(resource)
 	sql.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", tableName))
 
-	// Check if resource has an ID field
-	hasID := false
-	for _, field := range resource.Fields {
-		if field.Name == "id" {
-			hasID = true
-			break
-		}
-	}
+	// Resources that declare no primary key get an id column
+	hasID := !hasImplicitID(resource)
+
+	// A partitioned table's primary key must include the partition key, and a
+	// composite key spans several columns, so both are declared after the
+	// columns instead of inline
+	partitioned := resource.Partition != nil
+	composite := resource.PrimaryKey != nil
+	var primaryKey []string
 
 	// Add default ID only if not explicitly defined
 	if !hasID {
-		sql.WriteString("  id BIGSERIAL PRIMARY KEY")
+		if partitioned {
+			sql.WriteString("  id BIGSERIAL")
+			primaryKey = append(primaryKey, "id")
+		} else {
+			sql.WriteString("  id BIGSERIAL PRIMARY KEY")
+		}
 	}
 
 	// Generate all explicitly defined fields
@@ -61,21 +98,65 @@ func (g *Generator) generateCreateTable(resource *ast.ResourceNode) (string, err
 			sql.WriteString(",\n")
 		}
 
-		columnDef, err := g.generateColumn(field)
+		columnDef, err := g.generateColumn(field, !partitioned && !composite)
 		if err != nil {
 			return "", err
 		}
 		sql.WriteString("  " + columnDef)
+		if partitioned && !composite && isPrimaryKeyField(field) {
+			primaryKey = append(primaryKey, g.fieldColumnName(field))
+		}
+
+		// The legacy column of a @dual_write field is kept, without constraints,
+		// until the transition is cleaned up
+		if legacy, _ := field.DualWrite(); legacy != "" {
+			sqlType, err := g.toSQLType(field)
+			if err != nil {
+				return "", err
+			}
+			sql.WriteString(fmt.Sprintf(",\n  %s %s", legacy, sqlType))
+		}
+	}
+
+	if composite {
+		primaryKey = g.keyColumns(resource)
+	}
+	if !partitioned {
+		if composite {
+			sql.WriteString(fmt.Sprintf(",\n  PRIMARY KEY (%s)", strings.Join(primaryKey, ", ")))
+		}
+		sql.WriteString("\n);\n")
+		return sql.String(), nil
 	}
 
-	sql.WriteString("\n);\n")
+	// Range partitions are created at runtime ahead of time; the default
+	// partition catches rows outside them
+	partitionColumn := resource.Partition.Field
+	for _, field := range resource.Fields {
+		if field.Name == resource.Partition.Field {
+			partitionColumn = g.fieldColumnName(field)
+			break
+		}
+	}
+	// A composite key may include the partition key already
+	inKey := false
+	for _, column := range primaryKey {
+		inKey = inKey || column == partitionColumn
+	}
+	if !inKey {
+		primaryKey = append(primaryKey, partitionColumn)
+	}
+	sql.WriteString(fmt.Sprintf(",\n  PRIMARY KEY (%s)", strings.Join(primaryKey, ", ")))
+	sql.WriteString(fmt.Sprintf("\n) PARTITION BY RANGE (%s);\n", partitionColumn))
+	sql.WriteString(fmt.Sprintf("CREATE TABLE %s_default PARTITION OF %s DEFAULT;\n", tableName, tableName))
 
 	return sql.String(), nil
 }
 
-// generateColumn generates a column definition for a field
-func (g *Generator) generateColumn(field *ast.FieldNode) (string, error) {
-	columnName := g.toDBColumnName(field.Name)
+// generateColumn generates a column definition for a field. inlinePrimaryKey
+// is false for partitioned tables, whose primary key is a table constraint.
+func (g *Generator) generateColumn(field *ast.FieldNode, inlinePrimaryKey bool) (string, error) {
+	columnName := g.fieldColumnName(field)
 	sqlType, err := g.toSQLType(field)
 	if err != nil {
 		return "", err
@@ -85,7 +166,7 @@ func (g *Generator) generateColumn(field *ast.FieldNode) (string, error) {
 	parts = append(parts, columnName, sqlType)
 
 	// Add constraints
-	constraints := g.generateSQLConstraints(field)
+	constraints := g.generateSQLConstraints(field, inlinePrimaryKey)
 	if constraints != "" {
 		parts = append(parts, constraints)
 	}
@@ -93,6 +174,11 @@ func (g *Generator) generateColumn(field *ast.FieldNode) (string, error) {
 	return strings.Join(parts, " "), nil
 }
 
+// SQLType returns the PostgreSQL column type generated for a field
+func SQLType(field *ast.FieldNode) (string, error) {
+	return (&Generator{}).toSQLType(field)
+}
+
 // toSQLType converts a Conduit type to a PostgreSQL type
 func (g *Generator) toSQLType(field *ast.FieldNode) (string, error) {
 	var sqlType string
@@ -127,12 +213,18 @@ func (g *Generator) toSQLType(field *ast.FieldNode) (string, error) {
 	case "uuid":
 		sqlType = "UUID"
 
+	case "ulid":
+		sqlType = "CHAR(26)"
+
 	case "timestamp":
 		sqlType = "TIMESTAMP WITH TIME ZONE"
 
 	case "json":
 		sqlType = "JSONB"
 
+	case "point", "polygon":
+		sqlType = fmt.Sprintf("GEOGRAPHY(%s, 4326)", field.Geometry())
+
 	default:
 		// For resource types (foreign keys)
 		if field.Type.Kind == ast.TypeResource {
@@ -146,12 +238,9 @@ func (g *Generator) toSQLType(field *ast.FieldNode) (string, error) {
 }
 
 // generateSQLConstraints generates SQL constraints for a field
-func (g *Generator) generateSQLConstraints(field *ast.FieldNode) string {
+func (g *Generator) generateSQLConstraints(field *ast.FieldNode, inlinePrimaryKey bool) string {
 	var constraints []string
 
-	// Automatically make 'id' fields PRIMARY KEY
-	isPrimaryKey := field.Name == "id"
-
 	// NOT NULL for required fields
 	if !field.Nullable {
 		constraints = append(constraints, "NOT NULL")
@@ -160,9 +249,6 @@ func (g *Generator) generateSQLConstraints(field *ast.FieldNode) string {
 	// Process field constraints
 	for _, constraint := range field.Constraints {
 		switch constraint.Name {
-		case "primary":
-			isPrimaryKey = true
-
 		case "unique":
 			constraints = append(constraints, "UNIQUE")
 
@@ -182,7 +268,7 @@ func (g *Generator) generateSQLConstraints(field *ast.FieldNode) string {
 				if len(constraint.Arguments) > 0 {
 					minVal := extractLiteralValue(constraint.Arguments[0])
 					constraints = append(constraints,
-						fmt.Sprintf("CHECK (length(%s) >= %v)", g.toDBColumnName(field.Name), minVal))
+						fmt.Sprintf("CHECK (length(%s) >= %v)", g.fieldColumnName(field), minVal))
 				}
 			}
 
@@ -192,14 +278,14 @@ func (g *Generator) generateSQLConstraints(field *ast.FieldNode) string {
 				if len(constraint.Arguments) > 0 {
 					maxVal := extractLiteralValue(constraint.Arguments[0])
 					constraints = append(constraints,
-						fmt.Sprintf("CHECK (%s <= %v)", g.toDBColumnName(field.Name), maxVal))
+						fmt.Sprintf("CHECK (%s <= %v)", g.fieldColumnName(field), maxVal))
 				}
 			}
 		}
 	}
 
 	// Add PRIMARY KEY constraint if this field is a primary key
-	if isPrimaryKey {
+	if inlinePrimaryKey && isPrimaryKeyField(field) {
 		constraints = append(constraints, "PRIMARY KEY")
 	}
 
@@ -214,6 +300,12 @@ func (g *Generator) generateSQLConstraints(field *ast.FieldNode) string {
 	return strings.Join(constraints, " ")
 }
 
+// isPrimaryKeyField reports whether a field is the primary key: id fields are
+// automatically, other fields with @primary
+func isPrimaryKeyField(field *ast.FieldNode) bool {
+	return field.Name == "id" || hasConstraint(field, "primary")
+}
+
 // formatDefaultValue formats a default value for SQL
 func (g *Generator) formatDefaultValue(expr ast.ExprNode) string {
 	if lit, ok := expr.(*ast.LiteralExpr); ok {
@@ -232,28 +324,97 @@ func (g *Generator) formatDefaultValue(expr ast.ExprNode) string {
 	return ""
 }
 
-// generateIndexes generates index statements for a resource
-func (g *Generator) generateIndexes(resource *ast.ResourceNode) string {
+// generateIndexes generates index statements for a resource. Index names
+// use the unqualified table name, since an index lives in its table's schema.
+// The foreign keys of has_one relationships get unique indexes, since each
+// owner has at most one record. @index fields and index blocks follow; an
+// index already created under the same name, such as @index on a @unique
+// field, is not created twice.
+func (g *Generator) generateIndexes(resource *ast.ResourceNode, hasOne []string) string {
 	var sql strings.Builder
-	tableName := g.toTableName(resource.Name)
+	tableName := g.sqlTable(resource)
+	indexPrefix := "idx_" + g.toTableName(resource.Name)
+	created := make(map[string]bool)
 
 	for _, field := range resource.Fields {
 		// Create index for unique constraints
-		if hasConstraint(field, "unique") {
-			columnName := g.toDBColumnName(field.Name)
-			indexName := fmt.Sprintf("idx_%s_%s", tableName, columnName)
+		if hasConstraint(field, "unique") || slices.Contains(hasOne, field.Name) {
+			columnName := g.fieldColumnName(field)
+			indexName := fmt.Sprintf("%s_%s", indexPrefix, columnName)
+			created[indexName] = true
 			sql.WriteString(fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s(%s);\n",
 				indexName, tableName, columnName))
 		}
 
 		// Create index for foreign keys
 		if field.Type.Kind == ast.TypeResource {
-			columnName := g.toDBColumnName(field.Name)
-			indexName := fmt.Sprintf("idx_%s_%s", tableName, columnName)
+			columnName := g.fieldColumnName(field)
+			indexName := fmt.Sprintf("%s_%s", indexPrefix, columnName)
+			created[indexName] = true
 			sql.WriteString(fmt.Sprintf("CREATE INDEX %s ON %s(%s);\n",
 				indexName, tableName, columnName))
 		}
+
+		// Create a spatial index for near filters on geography columns
+		if field.Geometry() != "" {
+			columnName := g.fieldColumnName(field)
+			indexName := fmt.Sprintf("%s_%s", indexPrefix, columnName)
+			created[indexName] = true
+			sql.WriteString(fmt.Sprintf("CREATE INDEX %s ON %s USING GIST(%s);\n",
+				indexName, tableName, columnName))
+		}
+	}
+
+	// Create the indexes declared with @index and index blocks
+	for _, index := range resource.AllIndexes() {
+		columns := resource.IndexColumns(index)
+		indexName := IndexName(resource, index)
+		if created[indexName] {
+			continue
+		}
+		created[indexName] = true
+
+		create := "CREATE INDEX"
+		if index.Unique {
+			create = "CREATE UNIQUE INDEX"
+		}
+		sql.WriteString(fmt.Sprintf("%s %s ON %s(%s);\n",
+			create, indexName, tableName, strings.Join(columns, ", ")))
 	}
 
 	return sql.String()
 }
+
+// IndexName returns the database name of an index of resource: its name
+// option, or idx_<table>_<columns> like the other generated indexes
+func IndexName(resource *ast.ResourceNode, index *ast.IndexNode) string {
+	if index.Name != "" {
+		return index.Name
+	}
+	return fmt.Sprintf("idx_%s_%s", TableName(resource.Name), strings.Join(resource.IndexColumns(index), "_"))
+}
+
+// resourceSchemas returns the distinct @schema names of resources in
+// declaration order
+func resourceSchemas(resources []*ast.ResourceNode) []string {
+	var schemas []string
+	seen := make(map[string]bool)
+	for _, resource := range resources {
+		if resource.Schema == nil || seen[resource.Schema.Name] {
+			continue
+		}
+		seen[resource.Schema.Name] = true
+		schemas = append(schemas, resource.Schema.Name)
+	}
+	return schemas
+}
+
+// hasSpatialFields reports whether any resource has a point or polygon field
+func hasSpatialFields(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if len(resource.SpatialFields()) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const indexSource = `resource Post {
+  slug: string! @unique @index
+  status: string! @index
+  author_id: uuid!
+  published_at: timestamp!
+
+  author: User! {
+    foreign_key: "author_id"
+  }
+
+  index [author, published_at]
+  index [status, published_at] { unique: true, name: "posts_status_published" }
+}`
+
+func TestGenerateMigrations_Indexes(t *testing.T) {
+	sql, err := NewGenerator().GenerateMigrations([]*ast.ResourceNode{parseResource(t, indexSource)})
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+
+	assertContains(t, sql,
+		"CREATE INDEX idx_posts_status ON posts(status);",
+		// Relationships stand for their foreign keys
+		"CREATE INDEX idx_posts_author_id_published_at ON posts(author_id, published_at);",
+		"CREATE UNIQUE INDEX posts_status_published ON posts(status, published_at);",
+	)
+
+	// The unique index of a @unique field serves @index too
+	if count := strings.Count(sql, "idx_posts_slug"); count != 1 {
+		t.Errorf("Expected one index on slug, got %d:\n%s", count, sql)
+	}
+	if !strings.Contains(sql, "CREATE UNIQUE INDEX idx_posts_slug ON posts(slug);") {
+		t.Errorf("slug should keep its unique index:\n%s", sql)
+	}
+
+	// Indexes are created after their table
+	if strings.Index(sql, "CREATE TABLE posts") > strings.Index(sql, "idx_posts_status") {
+		t.Errorf("Indexes should follow CREATE TABLE:\n%s", sql)
+	}
+}
+
+func TestIndexName(t *testing.T) {
+	resource := parseResource(t, indexSource)
+	if got := IndexName(resource, resource.Indexes[0]); got != "idx_posts_author_id_published_at" {
+		t.Errorf("IndexName() = %q, want idx_posts_author_id_published_at", got)
+	}
+	if got := IndexName(resource, resource.Indexes[1]); got != "posts_status_published" {
+		t.Errorf("IndexName() = %q, want the declared name", got)
+	}
+}
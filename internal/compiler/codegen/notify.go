@@ -0,0 +1,105 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NotifyOptions controls the notification channels configured by the generated main
+type NotifyOptions struct {
+	// Enabled starts the delivery workers; without it Notify.send calls are logged and dropped
+	Enabled bool
+	// Queue is the job queue notifications are delivered from; the runtime default when empty
+	Queue string
+	// Workers is the number of delivery workers; the runtime default when zero
+	Workers int
+	// Channels are the configured channels by name
+	Channels map[string]NotifyChannel
+}
+
+// NotifyChannel configures one notification channel
+type NotifyChannel struct {
+	Provider  string // twilio, fcm or apns
+	RateLimit int    // Messages per minute; 0 is unlimited
+	From      string
+	ProjectID string
+	Topic     string
+	TeamID    string
+	KeyID     string
+	Sandbox   bool
+}
+
+// SetNotify configures the notification channels generated by GenerateMain
+func (g *Generator) SetNotify(opts NotifyOptions) {
+	g.notify = opts
+}
+
+// generateNotifyConfig configures the notification channels from the build's
+// conduit.yaml settings and starts the workers delivering queued notifications;
+// secrets are read from the environment by notify.Configure
+func (g *Generator) generateNotifyConfig() {
+	names := make([]string, 0, len(g.notify.Channels))
+	for name := range g.notify.Channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g.writeLine("// Deliver Notify.send from the %q job queue", notifyQueue(g.notify.Queue))
+	g.writeLine("notifier, err := notify.Configure(context.Background(), db, notify.Config{")
+	g.indent++
+	if g.notify.Queue != "" {
+		g.writeLine("Queue: %q,", g.notify.Queue)
+	}
+	if g.notify.Workers > 0 {
+		g.writeLine("Workers: %d,", g.notify.Workers)
+	}
+	g.writeLine("Channels: map[string]notify.ChannelConfig{")
+	g.indent++
+	for _, name := range names {
+		g.writeLine("%q: {%s},", name, notifyChannelFields(g.notify.Channels[name]))
+	}
+	g.indent--
+	g.writeLine("},")
+	g.indent--
+	g.writeLine("})")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(%q, err)", "Failed to configure notifications: %v")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("notifier.Start(context.Background())")
+	g.writeLine("defer notifier.Stop()")
+	g.writeLine("")
+}
+
+// notifyQueue returns the job queue notifications are delivered from
+func notifyQueue(queue string) string {
+	if queue == "" {
+		return "notify"
+	}
+	return queue
+}
+
+// notifyChannelFields returns the set fields of a notify.ChannelConfig literal
+func notifyChannelFields(ch NotifyChannel) string {
+	fields := []string{fmt.Sprintf("Provider: %q", ch.Provider)}
+	if ch.RateLimit > 0 {
+		fields = append(fields, fmt.Sprintf("RateLimit: %d", ch.RateLimit))
+	}
+	for _, field := range []struct{ name, value string }{
+		{"From", ch.From},
+		{"ProjectID", ch.ProjectID},
+		{"Topic", ch.Topic},
+		{"TeamID", ch.TeamID},
+		{"KeyID", ch.KeyID},
+	} {
+		if field.value != "" {
+			fields = append(fields, fmt.Sprintf("%s: %q", field.name, field.value))
+		}
+	}
+	if ch.Sandbox {
+		fields = append(fields, "Sandbox: true")
+	}
+	return strings.Join(fields, ", ")
+}
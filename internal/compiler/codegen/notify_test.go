@@ -0,0 +1,82 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const notifySource = `resource Order {
+  id: uuid! @primary @auto
+  phone: phone!
+
+  @after update {
+    Notify.send("sms", self.phone, {body: "Your order shipped"})
+  }
+}`
+
+func TestGenerateResourceWithHooks_NotifySend(t *testing.T) {
+	code, err := NewGenerator().GenerateResourceWithHooks(parseResource(t, notifySource))
+	if err != nil {
+		t.Fatalf("GenerateResourceWithHooks failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+
+	assertContains(t, code,
+		`"github.com/conduit-lang/conduit/pkg/web/notify"`,
+		`notify.SendContext(ctx, "sms", o.Phone, map[string]interface{}{"body": "Your order shipped"})`,
+	)
+}
+
+func TestGenerateMain_Notify(t *testing.T) {
+	g := NewGenerator()
+	g.SetNotify(NotifyOptions{
+		Enabled: true,
+		Workers: 4,
+		Channels: map[string]NotifyChannel{
+			"sms":  {Provider: "twilio", From: "+15550001234", RateLimit: 60},
+			"ios":  {Provider: "apns", Topic: "com.example.app", TeamID: "TEAM", KeyID: "KEY", Sandbox: true},
+			"push": {Provider: "fcm"},
+		},
+	})
+	code, err := g.GenerateMain([]*ast.ResourceNode{parseResource(t, notifySource)}, "example.com/shop", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated main does not parse: %v\n%s", err, code)
+	}
+
+	assertContains(t, code,
+		`"github.com/conduit-lang/conduit/pkg/web/notify"`,
+		"notifier, err := notify.Configure(context.Background(), db, notify.Config{",
+		"Workers: 4,",
+		`"ios": {Provider: "apns", Topic: "com.example.app", TeamID: "TEAM", KeyID: "KEY", Sandbox: true},`,
+		`"push": {Provider: "fcm"},`,
+		`"sms": {Provider: "twilio", RateLimit: 60, From: "+15550001234"},`,
+		"notifier.Start(context.Background())",
+		"defer notifier.Stop()",
+	)
+	if strings.Contains(code, "Queue:") {
+		t.Error("Main should leave the default queue to the runtime")
+	}
+
+	// Workers start before the server accepts requests
+	if strings.Index(code, "notifier.Start") > strings.Index(code, "server.Run(") {
+		t.Error("Notification workers should start before the server")
+	}
+}
+
+func TestGenerateMain_NotifyDisabled(t *testing.T) {
+	code, err := NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, notifySource)}, "example.com/shop", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "notify.") {
+		t.Error("Main should not configure notifications without channels")
+	}
+}
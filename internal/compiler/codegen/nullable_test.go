@@ -143,12 +143,7 @@ func indexOf(s, substr string) int {
 	}
 
 	// Create go.mod file
-	goModContent := `module test-nullable
-
-go 1.23
-
-require github.com/google/uuid v1.6.0
-`
+	goModContent := generatedGoMod(t, "test-nullable")
 	goModPath := filepath.Join(tmpDir, "go.mod")
 	if err := os.WriteFile(goModPath, []byte(goModContent), 0644); err != nil {
 		t.Fatalf("Failed to write go.mod: %v", err)
@@ -257,12 +252,7 @@ func TestNullableFieldValidation(t *testing.T) {
 	}
 
 	// Create go.mod file
-	goModContent := `module test-validation
-
-go 1.23
-
-require github.com/google/uuid v1.6.0
-`
+	goModContent := generatedGoMod(t, "test-validation")
 	goModPath := filepath.Join(tmpDir, "go.mod")
 	if err := os.WriteFile(goModPath, []byte(goModContent), 0644); err != nil {
 		t.Fatalf("Failed to write go.mod: %v", err)
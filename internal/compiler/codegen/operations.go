@@ -0,0 +1,157 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// adminRole is the role operations refined with admin_only require
+const adminRole = "admin"
+
+// servesOperation reports whether the routes of resource include op's: a
+// standard operation @operations allows, a write route beside one such as
+// create_batch or patch, or a route another annotation adds (upsert, archive,
+// restore, move). @materialized views and @external_table resources only
+// serve list and get.
+func servesOperation(resource *ast.ResourceNode, op string) bool {
+	if resource.ReadOnly() && op != ast.OperationList && op != ast.OperationGet {
+		return false
+	}
+	switch op {
+	case "create_batch":
+		return resource.AllowsOperation(ast.OperationCreate)
+	case "patch":
+		return resource.AllowsOperation(ast.OperationUpdate)
+	case "upsert":
+		return resource.Upsert != nil
+	case "archive", "restore":
+		return archivedField(resource) != nil
+	case "move":
+		return positionField(resource) != nil
+	}
+	return resource.AllowsOperation(op)
+}
+
+// adminOnly reports whether op's route of resource requires the admin role
+func adminOnly(resource *ast.ResourceNode, op string) bool {
+	return servesOperation(resource, op) && resource.HasOperationModifier(op, ast.AdminOnly)
+}
+
+// hasAdminOnly reports whether any route requires the admin role
+func hasAdminOnly(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		for _, op := range []string{"list", "get", "create", "create_batch", "update", "patch", "delete", "upsert", "archive", "restore", "move"} {
+			if adminOnly(resource, op) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasRoutes reports whether any resource registers a route, which is then
+// answered on OPTIONS
+func hasRoutes(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if resource.Changes != nil || resource.SearchIndex != nil || resource.Webhook != nil || treeParentField(resource) != nil {
+			return true
+		}
+		if !resource.ReadOnly() && len(throughRelationships(resource)) > 0 {
+			return true
+		}
+		for _, op := range ast.StandardOperations {
+			if servesOperation(resource, op) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// router returns the router op's route is registered on: r, or r.With the
+// given middleware followed by the admin role check of admin_only operations
+func (g *Generator) router(resource *ast.ResourceNode, op string, middleware ...string) string {
+	if adminOnly(resource, op) {
+		middleware = append(middleware, "profile.Require(\""+adminRole+"\")")
+	}
+	if len(middleware) == 0 {
+		return "r"
+	}
+	return "r.With(" + strings.Join(middleware, ", ") + ")"
+}
+
+// generateOperationRoutes registers the list, get and write routes of the
+// operations resource serves. read and write name middleware wrapping the
+// read and write routes, or are empty.
+func (g *Generator) generateOperationRoutes(resource *ast.ResourceNode, read, write string) {
+	tableName := g.toTableName(resource.Name)
+	member := g.memberPath(resource)
+
+	routes := []struct {
+		op, method, path, constructor string
+	}{
+		{"list", "Get", "/" + tableName, "List" + resource.Name + "Handler"},
+		{"create", "Post", "/" + tableName, "Create" + resource.Name + "Handler"},
+		{"create_batch", "Post", "/" + tableName + "/batch", "Create" + resource.Name + "BatchHandler"},
+		{"upsert", "Put", UpsertPath(tableName), "Upsert" + resource.Name + "Handler"},
+		{"get", "Get", member, "Get" + resource.Name + "Handler"},
+		{"update", "Put", member, "Update" + resource.Name + "Handler"},
+		{"patch", "Patch", member, "Patch" + resource.Name + "Handler"},
+		{"delete", "Delete", member, "Delete" + resource.Name + "Handler"},
+		{"archive", "Post", member + "/archive", "Archive" + resource.Name + "Handler"},
+		{"restore", "Post", member + "/restore", "Restore" + resource.Name + "Handler"},
+		{"move", "Post", member + "/move", "Move" + resource.Name + "Handler"},
+	}
+
+	for _, route := range routes {
+		if !servesOperation(resource, route.op) {
+			continue
+		}
+		middleware := write
+		if route.method == "Get" {
+			middleware = read
+		}
+		var with []string
+		if middleware != "" {
+			with = append(with, middleware)
+		}
+		g.registerRoute(g.router(resource, route.op, with...), route.method, route.path, g.routeHandler(resource, route.constructor))
+	}
+}
+
+// registeredRoute is the method and path of a route written by registerRoute
+type registeredRoute struct {
+	method, path string
+}
+
+// registerRoute writes the registration of a route on router, followed for
+// GET routes by a HEAD route with the same middleware and handler, and
+// remembers the route for generateOptionsRoutes
+func (g *Generator) registerRoute(router, method, path, handler string) {
+	g.writeLine("%s.%s(%q, %s)", router, method, path, handler)
+	if method == "Get" {
+		g.writeLine("%s.Head(%q, methods.Head(%s))", router, path, handler)
+	}
+	g.routes = append(g.routes, registeredRoute{strings.ToUpper(method), path})
+}
+
+// generateOptionsRoutes answers OPTIONS on each path registered since the
+// last call, with an Allow header listing the methods of its routes. OPTIONS
+// is registered without middleware, so CORS preflights and capability checks
+// need no credentials.
+func (g *Generator) generateOptionsRoutes() {
+	var paths []string
+	methods := make(map[string][]string)
+	for _, route := range g.routes {
+		if _, ok := methods[route.path]; !ok {
+			paths = append(paths, route.path)
+		}
+		methods[route.path] = append(methods[route.path], fmt.Sprintf("%q", route.method))
+	}
+	for _, path := range paths {
+		g.writeLine("r.Options(%q, methods.Options(%s))", path, strings.Join(methods[path], ", "))
+	}
+	g.routes = nil
+}
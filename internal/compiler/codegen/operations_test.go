@@ -0,0 +1,109 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+func TestGenerateHandlers_Operations(t *testing.T) {
+	post := parseResource(t, softDeleteSource)
+	post.SoftDelete = nil
+	post.Operations = []string{"list", "show", "create", "update"}
+	post.OperationMods = map[string][]string{"create": {ast.AdminOnly}}
+
+	code, err := NewGenerator().GenerateHandlers([]*ast.ResourceNode{post}, "example.com/blog")
+	if err != nil {
+		t.Fatalf("GenerateHandlers failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+	if !strings.Contains(code, `"github.com/conduit-lang/conduit/pkg/web/profile"`) {
+		t.Error("Handlers should import the profile package for admin_only")
+	}
+
+	register := functionBody(t, code, "func RegisterPostRoutes(r chi.Router, db *sql.DB) {")
+	for _, want := range []string{
+		`r.Get("/posts", ListPostHandler(db))`,
+		`r.With(profile.Require("admin")).Post("/posts", CreatePostHandler(db))`,
+		`r.With(profile.Require("admin")).Post("/posts/batch", CreatePostBatchHandler(db))`,
+		`r.Get("/posts/{id}", GetPostHandler(db))`,
+		`r.Put("/posts/{id}", UpdatePostHandler(db))`,
+		`r.Patch("/posts/{id}", PatchPostHandler(db))`,
+	} {
+		if !strings.Contains(register, want) {
+			t.Errorf("Routes missing %q:\n%s", want, register)
+		}
+	}
+	if strings.Contains(register, "r.Delete(") {
+		t.Errorf("Routes should leave out delete:\n%s", register)
+	}
+
+	// all except [...] drops the excluded routes only
+	tag := parseResource(t, searchSource)
+	tag.Excluded = []string{"update", "delete"}
+	code, err = NewGenerator().GenerateHandlers([]*ast.ResourceNode{tag}, "example.com/blog")
+	if err != nil {
+		t.Fatalf("GenerateHandlers failed: %v", err)
+	}
+	if strings.Contains(code, "pkg/web/profile") {
+		t.Error("Handlers without admin_only should not import the profile package")
+	}
+	register = functionBody(t, code, "func Register"+tag.Name+"Routes(r chi.Router, db *sql.DB) {")
+	if !strings.Contains(register, "r.Post(") || !strings.Contains(register, "r.Get(") {
+		t.Errorf("Routes should keep list, get and create:\n%s", register)
+	}
+	for _, excluded := range []string{"r.Put(", "r.Patch(", "r.Delete("} {
+		if strings.Contains(register, excluded) {
+			t.Errorf("Routes should leave out %s:\n%s", excluded, register)
+		}
+	}
+}
+
+func TestGenerateHandlers_HeadAndOptions(t *testing.T) {
+	post := parseResource(t, softDeleteSource)
+	post.SoftDelete = nil
+	post.Operations = []string{"list", "show", "create", "delete"}
+	post.OperationMods = map[string][]string{"show": {ast.AdminOnly}}
+
+	code, err := NewGenerator().GenerateHandlers([]*ast.ResourceNode{post}, "example.com/blog")
+	if err != nil {
+		t.Fatalf("GenerateHandlers failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+	if !strings.Contains(code, `"github.com/conduit-lang/conduit/pkg/web/methods"`) {
+		t.Error("Handlers should import the methods package")
+	}
+
+	register := functionBody(t, code, "func RegisterPostRoutes(r chi.Router, db *sql.DB) {")
+	for _, want := range []string{
+		// HEAD shares the GET route's middleware and handler
+		`r.Head("/posts", methods.Head(ListPostHandler(db)))`,
+		`r.With(profile.Require("admin")).Head("/posts/{id}", methods.Head(GetPostHandler(db)))`,
+		// OPTIONS lists the methods registered on each path
+		`r.Options("/posts", methods.Options("GET", "POST"))`,
+		`r.Options("/posts/batch", methods.Options("POST"))`,
+		`r.Options("/posts/{id}", methods.Options("GET", "DELETE"))`,
+	} {
+		if !strings.Contains(register, want) {
+			t.Errorf("Routes missing %q:\n%s", want, register)
+		}
+	}
+
+	// A resource without routes does not import the methods package
+	post.Operations = nil
+	post.OperationMods = nil
+	post.Excluded = ast.StandardOperations
+	code, err = NewGenerator().GenerateHandlers([]*ast.ResourceNode{post}, "example.com/blog")
+	if err != nil {
+		t.Fatalf("GenerateHandlers failed: %v", err)
+	}
+	if strings.Contains(code, "pkg/web/methods") || strings.Contains(code, "r.Options(") {
+		t.Error("Handlers without routes should not answer OPTIONS")
+	}
+}
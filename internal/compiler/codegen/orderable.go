@@ -0,0 +1,396 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// PositionGap is the distance @orderable leaves between the positions of
+// neighbouring records, so a record can usually be moved between two others
+// by rewriting its own position alone
+const PositionGap = 1024
+
+// positionField returns the position field of an @orderable resource, or nil
+// for resources whose records are unordered
+func positionField(resource *ast.ResourceNode) *ast.FieldNode {
+	if resource.Orderable == nil {
+		return nil
+	}
+	return resource.FindField(ast.PositionField)
+}
+
+// orderScopeField returns the field an @orderable resource orders its records
+// within, or nil when the resource keeps a single order
+func orderScopeField(resource *ast.ResourceNode) *ast.FieldNode {
+	if positionField(resource) == nil || resource.Orderable.Scope == "" {
+		return nil
+	}
+	return resource.FindField(resource.Orderable.Scope)
+}
+
+// listOrder returns the columns lists are ordered by: the scope and position
+// of an @orderable resource, then the primary key that breaks ties
+func (g *Generator) listOrder(resource *ast.ResourceNode) []string {
+	var columns []string
+	if position := positionField(resource); position != nil {
+		if scope := orderScopeField(resource); scope != nil {
+			columns = append(columns, g.fieldColumnName(scope))
+		}
+		columns = append(columns, g.fieldColumnName(position))
+	}
+	return append(columns, g.keyColumns(resource)...)
+}
+
+// orderScope returns the SQL condition restricting a statement to the
+// receiver's order, using placeholder $n, and the argument it binds. Both are
+// empty for resources with a single order.
+func (g *Generator) orderScope(resource *ast.ResourceNode, receiverName string, n int) (string, string) {
+	scope := orderScopeField(resource)
+	if scope == nil {
+		return "", ""
+	}
+	return fmt.Sprintf("%s = $%d", g.fieldColumnName(scope), n),
+		fmt.Sprintf("%s.%s", receiverName, g.toGoFieldName(scope.Name))
+}
+
+// generatePlacement generates the part of Create() that places a new record
+// of an @orderable resource last in its order
+func (g *Generator) generatePlacement(resource *ast.ResourceNode, receiverName string) {
+	position := positionField(resource)
+	if position == nil {
+		return
+	}
+
+	query := fmt.Sprintf("SELECT COALESCE(MAX(%s), 0) + %d FROM %s",
+		g.fieldColumnName(position), PositionGap, g.sqlTable(resource))
+	args := ""
+	if condition, arg := g.orderScope(resource, receiverName, 1); condition != "" {
+		query += " WHERE " + condition
+		args = ", " + arg
+	}
+
+	g.writeLine("// Place the new %s last in its order (@orderable)", strings.ToLower(resource.Name))
+	g.writeLine("placement := `%s`", query)
+	g.writeLine("if err := tx.QueryRowContext(ctx, placement%s).Scan(&%s.%s); err != nil {",
+		args, receiverName, g.toGoFieldName(position.Name))
+	g.indent++
+	g.writeLine("return fmt.Errorf(\"failed to place %s: %%w\", err)", strings.ToLower(resource.Name))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
+
+// moveAnchorType returns the Go type of the ID a record is moved next to
+func (g *Generator) moveAnchorType(resource *ast.ResourceNode) string {
+	return g.getIDGoType(resource)
+}
+
+// generateMove generates the Move() method of an @orderable resource and the
+// movePosition() helper it places the record with. Only the position, and the
+// @auto_update timestamp when there is one, are written; lifecycle hooks do
+// not run.
+func (g *Generator) generateMove(resource *ast.ResourceNode) {
+	receiverName := strings.ToLower(resource.Name[0:1])
+	resourceLower := strings.ToLower(resource.Name)
+	position := positionField(resource)
+	modified := modificationField(resource)
+	tableName := g.sqlTable(resource)
+	column := g.fieldColumnName(position)
+	anchorType := g.moveAnchorType(resource)
+
+	g.writeLine("// Move places the %s directly before or after the %s anchor in the same", resource.Name, resource.Name)
+	g.writeLine("// order. Positions are spaced %d apart, so a move normally rewrites only the", PositionGap)
+	g.writeLine("// moved %s; when its new neighbours leave no room between them the order is", resourceLower)
+	g.writeLine("// renumbered first. The error wraps sql.ErrNoRows when anchor is not in the order.")
+	g.writeLine("func (%s *%s) Move(ctx context.Context, db *sql.DB, anchor %s, after bool) error {",
+		receiverName, resource.Name, anchorType)
+	g.indent++
+	g.writeLine("tx, err := db.BeginTx(ctx, nil)")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine(`return fmt.Errorf("failed to begin transaction: %w", err)`)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("defer tx.Rollback()")
+	g.writeLine("")
+
+	key := g.keyColumn(resource)
+	renumber := fmt.Sprintf("UPDATE %s SET %s = ordered.n * %d FROM (SELECT %s, ROW_NUMBER() OVER (ORDER BY %s, %s) AS n FROM %s",
+		tableName, column, PositionGap, key, column, key, tableName)
+	renumberArgs := ""
+	if condition, arg := g.orderScope(resource, receiverName, 1); condition != "" {
+		renumber += " WHERE " + condition
+		renumberArgs = ", " + arg
+	}
+	renumber += fmt.Sprintf(") AS ordered WHERE %s.%s = ordered.%s", tableName, key, key)
+
+	g.writeLine("position, ok, err := %s.movePosition(ctx, tx, anchor, after)", receiverName)
+	g.writeLine("if err == nil && !ok {")
+	g.indent++
+	g.writeLine("// No room between the neighbours: space the whole order out again")
+	g.writeLine("renumber := `%s`", renumber)
+	g.writeLine("if _, err := tx.ExecContext(ctx, renumber%s); err != nil {", renumberArgs)
+	g.indent++
+	g.writeLine("return fmt.Errorf(\"failed to renumber %s: %%w\", err)", resourceLower+"s")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("position, _, err = %s.movePosition(ctx, tx, anchor, after)", receiverName)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("return err")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	args := g.keyValue(resource, receiverName) + ", position"
+	modifiedSet := ""
+	if modified != nil {
+		g.writeLine("now := time.Now()")
+		modifiedSet = ", " + g.fieldColumnName(modified) + " = $3"
+		args += ", now"
+	}
+	g.writeLine("query := `UPDATE %s SET %s = $2%s WHERE %s = $1`", tableName, column, modifiedSet, key)
+	g.writeLine("if _, err := tx.ExecContext(ctx, query, %s); err != nil {", args)
+	g.indent++
+	g.writeLine("return fmt.Errorf(\"failed to move %s: %%w\", err)", resourceLower)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if err := tx.Commit(); err != nil {")
+	g.indent++
+	g.writeLine(`return fmt.Errorf("failed to commit transaction: %w", err)`)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+	g.writeLine("%s.%s = position", receiverName, g.toGoFieldName(position.Name))
+	if modified != nil {
+		g.writeLine("%s.%s = now", receiverName, g.toGoFieldName(modified.Name))
+	}
+	g.writeLine("return nil")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.generateMovePosition(resource)
+}
+
+// generateMovePosition generates movePosition(), which finds the position
+// halfway between the anchor and its neighbour on the side the record moves
+// to, or a gap past the anchor when it has no neighbour there
+func (g *Generator) generateMovePosition(resource *ast.ResourceNode) {
+	receiverName := strings.ToLower(resource.Name[0:1])
+	resourceLower := strings.ToLower(resource.Name)
+	tableName := g.sqlTable(resource)
+	column := g.fieldColumnName(positionField(resource))
+	key := g.keyColumn(resource)
+
+	anchorQuery := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1", column, tableName, key)
+	anchorArgs := "anchor"
+	if condition, arg := g.orderScope(resource, receiverName, 2); condition != "" {
+		anchorQuery += " AND " + condition
+		anchorArgs += ", " + arg
+	}
+	anchorQuery += " FOR UPDATE"
+
+	// The neighbour is the next record in (position, key) order, leaving out
+	// the record being moved
+	var conditions []string
+	neighbourArgs := ""
+	n := 1
+	if condition, arg := g.orderScope(resource, receiverName, n); condition != "" {
+		conditions = append(conditions, condition)
+		neighbourArgs = arg + ", "
+		n++
+	}
+	conditions = append(conditions, fmt.Sprintf("%s <> $%d", key, n))
+	neighbourArgs += g.keyValue(resource, receiverName) + ", anchorPosition, anchor"
+	neighbour := func(op, direction string) string {
+		where := append(append([]string(nil), conditions...), fmt.Sprintf("(%s, %s) %s ($%d, $%d)", column, key, op, n+1, n+2))
+		return fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s%s, %s%s LIMIT 1",
+			column, tableName, strings.Join(where, " AND "), column, direction, key, direction)
+	}
+
+	g.writeLine("// movePosition returns the position halfway between anchor and its neighbour")
+	g.writeLine("// on the side the %s moves to, and false when they leave no room between them", resource.Name)
+	g.writeLine("func (%s *%s) movePosition(ctx context.Context, tx *sql.Tx, anchor %s, after bool) (int64, bool, error) {",
+		receiverName, resource.Name, g.moveAnchorType(resource))
+	g.indent++
+	g.writeLine("var anchorPosition int64")
+	g.writeLine("query := `%s`", anchorQuery)
+	g.writeLine("if err := tx.QueryRowContext(ctx, query, %s).Scan(&anchorPosition); err != nil {", anchorArgs)
+	g.indent++
+	g.writeLine("return 0, false, fmt.Errorf(\"failed to find %s to move next to: %%w\", err)", resourceLower)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+	g.writeLine("step := int64(-%d)", PositionGap)
+	g.writeLine("query = `%s`", neighbour("<", " DESC"))
+	g.writeLine("if after {")
+	g.indent++
+	g.writeLine("step = %d", PositionGap)
+	g.writeLine("query = `%s`", neighbour(">", ""))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("var neighbour int64")
+	g.writeLine("err := tx.QueryRowContext(ctx, query, %s).Scan(&neighbour)", neighbourArgs)
+	g.writeLine("if err == sql.ErrNoRows {")
+	g.indent++
+	g.writeLine("return anchorPosition + step, true, nil")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("return 0, false, fmt.Errorf(\"failed to find neighbouring %s: %%w\", err)", resourceLower)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if gap := neighbour - anchorPosition; gap > -2 && gap < 2 {")
+	g.indent++
+	g.writeLine("return 0, false, nil")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return anchorPosition + (neighbour-anchorPosition)/2, true, nil")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateMoveHandler generates the handler of an @orderable resource's move
+// route (POST /resources/{id}/move), which places the record before or after
+// another of the same order named by {"before": id} or {"after": id} and
+// responds with the record as it is afterwards
+func (g *Generator) generateMoveHandler(resource *ast.ResourceNode) {
+	resourceLower := strings.ToLower(resource.Name)
+	receiverName := strings.ToLower(resource.Name[0:1])
+	anchorType := g.moveAnchorType(resource)
+
+	g.writeLine("// Move%sHandler handles POST %s/move - move a %s before or after another",
+		resource.Name, g.memberPath(resource), resourceLower)
+	g.writeLine("func Move%sHandler(db *sql.DB) http.HandlerFunc {", resource.Name)
+	g.indent++
+	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
+	g.indent++
+
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, \"move\")", resource.Name)
+	g.writeLine("")
+	g.generateVisibleFields(resource)
+
+	g.generateIDParsingCode(resource)
+
+	g.writeLine("// Read where to move the %s: {\"before\": id} or {\"after\": id}", resourceLower)
+	g.writeLine("var placement struct {")
+	g.indent++
+	g.writeLine("Before *%s `json:\"before\"`", anchorType)
+	g.writeLine("After  *%s `json:\"after\"`", anchorType)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("r.Body = http.MaxBytesReader(w, r.Body, 1<<20)")
+	g.writeLine("if err := json.NewDecoder(r.Body).Decode(&placement); err != nil || (placement.Before == nil) == (placement.After == nil) {")
+	g.indent++
+	g.generateMoveError("http.StatusBadRequest", fmt.Sprintf("Request body must name one %s to move before or after", resourceLower))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("anchor, after := placement.Before, false")
+	g.writeLine("if placement.After != nil {")
+	g.indent++
+	g.writeLine("anchor, after = placement.After, true")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if *anchor == id {")
+	g.indent++
+	g.generateMoveError("http.StatusUnprocessableEntity", fmt.Sprintf("Cannot move a %s next to itself", resourceLower))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("// Fetch existing %s", resourceLower)
+	g.writeLine("%s, err := models.Find%sByID(ctx, db, id)", receiverName, resource.Name)
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("if err == sql.ErrNoRows {")
+	g.indent++
+	g.generateMoveError("http.StatusNotFound", "Not found")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to find %s: %%v\", err))", resourceLower)
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to find %s: %%v\", err), http.StatusInternalServerError)", resourceLower)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	order := "order"
+	if scope := orderScopeField(resource); scope != nil {
+		order = "same " + strings.TrimSuffix(scope.Name, "_id")
+	}
+	g.writeLine("if err := %s.Move(ctx, db, *anchor, after); err != nil {", receiverName)
+	g.indent++
+	g.writeLine("if errors.Is(err, sql.ErrNoRows) {")
+	g.indent++
+	g.generateMoveError("http.StatusUnprocessableEntity", fmt.Sprintf("Can only move a %s next to another %s in the %s", resourceLower, resourceLower, order))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to move %s: %%v\", err))", resourceLower)
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to move %s: %%v\", err), http.StatusInternalServerError)", resourceLower)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("// Content negotiation: JSON:API or legacy JSON")
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("if err := %s; err != nil {", renderJSONAPI(resource, "http.StatusOK", receiverName))
+	g.indent++
+	g.writeLine("respondWithError(w, \"Failed to encode response\", http.StatusInternalServerError)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("w.Header().Set(\"Content-Type\", \"application/json\")")
+	g.writeLine("if err := json.NewEncoder(w).Encode(%s); err != nil {", g.encodedRecord(resource, receiverName))
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to encode response: %v\", err), http.StatusInternalServerError)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateMoveError generates the response to a move request that fails with
+// status and a fixed message, in the format the client asked for
+func (g *Generator) generateMoveError(status, message string) {
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, %s, fmt.Errorf(%q))", status, message)
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, %q, %s)", message, status)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("return")
+}
@@ -0,0 +1,152 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const orderableSource = `resource Task {
+  @orderable(scope: list_id)
+
+  id: uuid! @primary @auto
+  title: string!
+  list_id: uuid!
+  updated_at: timestamp! @auto_update
+}`
+
+func TestWithPositions(t *testing.T) {
+	resources := []*ast.ResourceNode{parseResource(t, orderableSource), parseResource(t, archiveSource)}
+	expanded := ast.WithPositions(resources)
+
+	position := expanded[0].FindField(ast.PositionField)
+	if position == nil {
+		t.Fatalf("Expected position on Task, got fields: %v", expanded[0].Fields)
+	}
+	if position.Nullable || position.Type.Name != "int" {
+		t.Errorf("Unexpected position field: %+v", position)
+	}
+	if resources[0].FindField(ast.PositionField) != nil {
+		t.Error("WithPositions should not modify the given resources")
+	}
+	if expanded[1] != resources[1] {
+		t.Error("Resources without @orderable should be returned as is")
+	}
+
+	// A declared position is kept
+	declared := ast.WithPositions(expanded)
+	if declared[0] != expanded[0] {
+		t.Error("Resources that declare position should be returned as is")
+	}
+}
+
+func TestGenerateMigrations_Orderable(t *testing.T) {
+	sql, err := NewGenerator().GenerateMigrations([]*ast.ResourceNode{parseResource(t, orderableSource)})
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+	if !strings.Contains(sql, "position BIGINT NOT NULL DEFAULT 0") {
+		t.Errorf("Migration missing position column:\n%s", sql)
+	}
+}
+
+func TestGenerateResource_Orderable(t *testing.T) {
+	resource := ast.WithPositions([]*ast.ResourceNode{parseResource(t, orderableSource)})[0]
+	code := generateModel(t, resource)
+
+	create := functionBody(t, code, "func (t *Task) Create(ctx context.Context, db *sql.DB) error {")
+	assertContains(t, create,
+		"SELECT COALESCE(MAX(position), 0) + 1024 FROM tasks WHERE list_id = $1",
+		"tx.QueryRowContext(ctx, placement, t.ListID).Scan(&t.Position)",
+	)
+
+	// Only Create() and Move() set positions; a new scope goes last
+	update := functionBody(t, code, "func (t *Task) Update(ctx context.Context, db *sql.DB) error {")
+	if !strings.Contains(update, "UPDATE tasks SET title = $1, list_id = $2, position = CASE WHEN list_id = $2 THEN position "+
+		"ELSE (SELECT COALESCE(MAX(position), 0) + 1024 FROM tasks WHERE list_id = $2) END, updated_at = $3 WHERE id = $4") {
+		t.Errorf("Update should keep the position within the list:\n%s", update)
+	}
+	patch := functionBody(t, code, "func (t *Task) Patch(ctx context.Context, db *sql.DB, partialJSON []byte) error {")
+	if !strings.Contains(patch, `"position": true,`) || strings.Contains(patch, "position = $") {
+		t.Errorf("Patch should treat position as read-only:\n%s", patch)
+	}
+
+	if !strings.Contains(code, "FROM tasks ORDER BY list_id, position, id LIMIT $1 OFFSET $2") {
+		t.Error("FindAllTask should follow the order")
+	}
+
+	move := functionBody(t, code, "func (t *Task) Move(ctx context.Context, db *sql.DB, anchor uuid.UUID, after bool) error {")
+	assertContains(t, move,
+		"position, ok, err := t.movePosition(ctx, tx, anchor, after)",
+		"UPDATE tasks SET position = ordered.n * 1024 FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY position, id) AS n "+
+			"FROM tasks WHERE list_id = $1) AS ordered WHERE tasks.id = ordered.id",
+		"tx.ExecContext(ctx, renumber, t.ListID)",
+		"UPDATE tasks SET position = $2, updated_at = $3 WHERE id = $1",
+		"t.Position = position",
+		"t.UpdatedAt = now",
+	)
+
+	movePosition := functionBody(t, code, "func (t *Task) movePosition(ctx context.Context, tx *sql.Tx, anchor uuid.UUID, after bool) (int64, bool, error) {")
+	assertContains(t, movePosition,
+		"SELECT position FROM tasks WHERE id = $1 AND list_id = $2 FOR UPDATE",
+		"SELECT position FROM tasks WHERE list_id = $1 AND id <> $2 AND (position, id) < ($3, $4) ORDER BY position DESC, id DESC LIMIT 1",
+		"SELECT position FROM tasks WHERE list_id = $1 AND id <> $2 AND (position, id) > ($3, $4) ORDER BY position, id LIMIT 1",
+		"tx.QueryRowContext(ctx, query, t.ListID, t.ID, anchorPosition, anchor).Scan(&neighbour)",
+		"return anchorPosition + (neighbour-anchorPosition)/2, true, nil",
+	)
+}
+
+func TestGenerateResource_OrderableUnscoped(t *testing.T) {
+	resource := parseResource(t, orderableSource)
+	resource.Orderable.Scope = ""
+	resource = ast.WithPositions([]*ast.ResourceNode{resource})[0]
+
+	code := generateModel(t, resource)
+
+	assertContains(t, code,
+		"placement := `SELECT COALESCE(MAX(position), 0) + 1024 FROM tasks`",
+		"tx.QueryRowContext(ctx, placement).Scan(&t.Position)",
+		"FROM tasks) AS ordered WHERE tasks.id = ordered.id",
+		"SELECT position FROM tasks WHERE id = $1 FOR UPDATE",
+		"SELECT position FROM tasks WHERE id <> $1 AND (position, id) > ($2, $3) ORDER BY position, id LIMIT 1",
+		"FROM tasks ORDER BY position, id LIMIT $1 OFFSET $2",
+	)
+}
+
+func TestGenerateHandlers_Orderable(t *testing.T) {
+	resource := ast.WithPositions([]*ast.ResourceNode{parseResource(t, orderableSource)})[0]
+	code := generateHandlers(t, resource)
+
+	if !strings.Contains(code, `r.Post("/tasks/{id}/move", MoveTaskHandler(db))`) {
+		t.Error("Missing move route registration")
+	}
+
+	list := functionBody(t, code, "func ListTaskHandler(db *sql.DB) http.HandlerFunc {")
+	if !strings.Contains(list, `OrderBy("list_id", "position", "id").`) {
+		t.Errorf("List handler should follow the order:\n%s", list)
+	}
+
+	handler := functionBody(t, code, "func MoveTaskHandler(db *sql.DB) http.HandlerFunc {")
+	assertContains(t, handler,
+		`instrument.WithOperation(r.Context(), "Task", "move")`,
+		"Before *uuid.UUID `json:\"before\"`",
+		"After  *uuid.UUID `json:\"after\"`",
+		"(placement.Before == nil) == (placement.After == nil)",
+		"t, err := models.FindTaskByID(ctx, db, id)",
+		"if err := t.Move(ctx, db, *anchor, after); err != nil {",
+		"if errors.Is(err, sql.ErrNoRows) {",
+		"Can only move a task next to another task in the same list",
+		"response.RenderJSONAPI(w, http.StatusOK, t)",
+	)
+}
+
+func TestGenerateHandlers_NotOrderable(t *testing.T) {
+	resource := parseResource(t, orderableSource)
+	resource.Orderable = nil
+
+	code := generateHandlers(t, resource)
+	if strings.Contains(code, "OrderBy(") || strings.Contains(code, "/move") {
+		t.Error("Resources without @orderable should not be ordered or moved")
+	}
+}
@@ -0,0 +1,41 @@
+package codegen
+
+import (
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// hasPartition reports whether any resource declares @partition
+func hasPartition(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if resource.Partition != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// partitionIntervals maps @partition intervals to pkg/web/partition constants
+var partitionIntervals = map[string]string{
+	ast.PartitionDay:   "partition.Day",
+	ast.PartitionWeek:  "partition.Week",
+	ast.PartitionMonth: "partition.Month",
+	ast.PartitionYear:  "partition.Year",
+}
+
+// generatePartitionMaintenance creates the range partitions of @partition
+// resources at startup and keeps creating them ahead of time while running
+func (g *Generator) generatePartitionMaintenance(resources []*ast.ResourceNode) {
+	var tables []string
+	for _, resource := range resources {
+		if resource.Partition == nil {
+			continue
+		}
+		tables = append(tables, "{Name: \""+g.sqlTable(resource)+"\", Interval: "+partitionIntervals[resource.Partition.Interval]+"}")
+	}
+
+	g.writeLine("// Create the range partitions of @partition tables ahead of time")
+	g.writeLine("partition.Maintain(context.Background(), db, []partition.Table{%s}, time.Hour)", strings.Join(tables, ", "))
+	g.writeLine("")
+}
@@ -0,0 +1,92 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const partitionSource = `resource Event {
+  @partition(by: created_at, interval: month)
+
+  kind: string!
+  created_at: timestamp! @auto
+}`
+
+func TestGenerateMigrations_Partition(t *testing.T) {
+	sql, err := NewGenerator().GenerateMigrations([]*ast.ResourceNode{parseResource(t, partitionSource)})
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+
+	expected := []string{
+		"CREATE TABLE events (\n  id BIGSERIAL,\n",
+		"  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,\n  PRIMARY KEY (id, created_at)\n) PARTITION BY RANGE (created_at);",
+		"CREATE TABLE events_default PARTITION OF events DEFAULT;",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(sql, exp) {
+			t.Errorf("Migration missing %q:\n%s", exp, sql)
+		}
+	}
+
+	// An explicit primary key is moved into the composite key too
+	resource := parseResource(t, partitionSource)
+	resource.Fields = append([]*ast.FieldNode{
+		{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}, Constraints: []*ast.ConstraintNode{{Name: "primary"}, {Name: "auto"}}},
+	}, resource.Fields...)
+	sql, err = NewGenerator().GenerateMigrations([]*ast.ResourceNode{resource})
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+	if !strings.Contains(sql, "id UUID NOT NULL DEFAULT gen_random_uuid(),") || !strings.Contains(sql, "PRIMARY KEY (id, created_at)") {
+		t.Errorf("Migration should declare a composite primary key:\n%s", sql)
+	}
+}
+
+func TestGenerateHandlers_Partition(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, partitionSource))
+
+	expected := []string{
+		`var eventRanged = []string{"created_at"}`,
+		"ranges := query.ParseRange(r)",
+		"Ranged(eventRanged).",
+		"Range(ranges).",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated handlers missing %q", exp)
+		}
+	}
+
+	// Regular resources don't parse range filters
+	code = generateHandlers(t, parseResource(t, postSource))
+	if strings.Contains(code, "ParseRange") {
+		t.Error("resources without @partition should not parse range filters")
+	}
+}
+
+func TestGenerateMain_Partition(t *testing.T) {
+	code, err := NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, partitionSource)}, "example.com/events", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	for _, exp := range []string{
+		`"github.com/conduit-lang/conduit/pkg/web/partition"`,
+		`"time"`,
+		`partition.Maintain(context.Background(), db, []partition.Table{{Name: "events", Interval: partition.Month}}, time.Hour)`,
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated main missing %q", exp)
+		}
+	}
+
+	code, err = NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, postSource)}, "example.com/blog", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "partition.") {
+		t.Error("Generated main should not maintain partitions without @partition resources")
+	}
+}
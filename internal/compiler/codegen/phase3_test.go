@@ -1,6 +1,7 @@
 package codegen
 
 import (
+	"go/format"
 	"strings"
 	"testing"
 
@@ -50,63 +51,69 @@ func TestGenerateListHandler_Phase3Features(t *testing.T) {
 		t.Error("Generated code should parse sort parameter")
 	}
 
-	// Phase 3: Check validFields generation
-	if !strings.Contains(code, "validFields := []string{") {
-		t.Error("Generated code should define validFields slice")
+	// Phase 3: Check field map generation
+	if !strings.Contains(code, "var postFields = query.NewFieldMap(map[string]string{") {
+		t.Error("Generated code should define the postFields field map")
 	}
 
-	// Phase 3: Check field names are in snake_case
-	expectedFields := []string{"id", "title", "content", "author_id", "published"}
-	for _, field := range expectedFields {
-		pattern := "\"" + field + "\","
+	// Phase 3: Check attributes map to the migrated column names
+	expectedFields := map[string]string{
+		"id":        "id",
+		"title":     "title",
+		"content":   "content",
+		"authorId":  "authorid",
+		"published": "published",
+	}
+	for field, column := range expectedFields {
+		pattern := "\"" + field + "\": \"" + column + "\","
 		if !strings.Contains(code, pattern) {
-			t.Errorf("Generated code should contain field %s in validFields", field)
+			t.Errorf("Generated code should map field %s to column %s in postFields", field, column)
 		}
 	}
 
-	// Phase 3: Check BuildFilterClause
-	if !strings.Contains(code, "query.BuildFilterClause(filters,") {
-		t.Error("Generated code should call BuildFilterClause")
+	// Phase 3: Check sparse fieldsets are resolved through the field map
+	if !strings.Contains(code, "attributes, err := postFields.Attributes(requested)") {
+		t.Error("Generated code should resolve sparse fieldset names")
 	}
 
-	// Phase 3: Check BuildSortClause
-	if !strings.Contains(code, "query.BuildSortClause(sorts,") {
-		t.Error("Generated code should call BuildSortClause")
+	// Phase 3: Check valid include list
+	if !strings.Contains(code, "validIncludes := []string{}") {
+		t.Error("Generated code should define validIncludes slice")
 	}
 
-	// Phase 3: Check custom query building
-	if !strings.Contains(code, "baseQuery := \"SELECT * FROM posts\"") {
-		t.Error("Generated code should build custom SQL query")
+	// Phase 3: Check unified query builder
+	if !strings.Contains(code, "qb := query.NewMappedBuilder(\"posts\", postFields).") {
+		t.Error("Generated code should create a query builder")
 	}
 
-	// Phase 3: Check filter WHERE clause application
-	if !strings.Contains(code, "if whereClause != \"\" {") {
-		t.Error("Generated code should conditionally apply WHERE clause")
+	for _, call := range []string{"Filter(filters).", "Sort(sorts).", "Include(includes, validIncludes).", "Paginate(pagination)"} {
+		if !strings.Contains(code, call) {
+			t.Errorf("Generated code should compose %s into the query builder", call)
+		}
 	}
 
-	// Phase 3: Check sort ORDER BY clause application
-	if !strings.Contains(code, "if orderByClause != \"\" {") {
-		t.Error("Generated code should conditionally apply ORDER BY clause")
+	if !strings.Contains(code, "listQuery, args, err := qb.Build()") {
+		t.Error("Generated code should build the list query")
 	}
 
-	// Phase 3: Check parameter indexing for pagination
-	if !strings.Contains(code, "paramIndex := len(filterArgs) + 1") {
-		t.Error("Generated code should calculate parameter index for pagination")
+	// Phase 3: Check pagination parsing
+	if !strings.Contains(code, "pagination, err := query.ParsePage(r, query.DefaultPageConfig())") {
+		t.Error("Generated code should parse pagination parameters")
 	}
 
-	// Phase 3: Check ApplySparseFieldsets
-	if !strings.Contains(code, "response.ApplySparseFieldsets(data, fields)") {
-		t.Error("Generated code should apply sparse fieldsets")
+	// Phase 3: Check sparse fieldsets are applied while streaming
+	if !strings.Contains(code, "stream := response.NewListStream(w, r, fields)") {
+		t.Error("Generated code should stream results with sparse fieldsets")
 	}
 
-	// Phase 3: Check sparse fieldsets conditional
-	if !strings.Contains(code, "if len(fields) > 0 {") {
-		t.Error("Generated code should conditionally apply sparse fieldsets")
+	// Phase 3: Check pagination metadata is written when the stream closes
+	if !strings.Contains(code, "stream.Close(meta, links)") {
+		t.Error("Generated code should close the stream with meta and links")
 	}
 
-	// Phase 3: Check includes TODO comment
-	if !strings.Contains(code, "// TODO: Phase 3 - Load relationships if includes is not empty") {
-		t.Error("Generated code should have TODO comment for includes")
+	// Phase 3: Without relationships nothing is side-loaded
+	if strings.Contains(code, "response.NewIncluded") {
+		t.Error("Generated code should not load relationships for a resource without any")
 	}
 
 	// Phase 3: Check ScanRow usage
@@ -115,8 +122,8 @@ func TestGenerateListHandler_Phase3Features(t *testing.T) {
 	}
 
 	// Phase 3: Check filtered count query
-	if !strings.Contains(code, "countQuery := \"SELECT COUNT(*) FROM posts\"") {
-		t.Error("Generated code should build count query with filters")
+	if !strings.Contains(code, "total, _, err := qb.Count(ctx, db, query.CountExact)") {
+		t.Error("Generated code should count with filters applied")
 	}
 
 	// Verify error handling for invalid filter fields
@@ -124,3 +131,148 @@ func TestGenerateListHandler_Phase3Features(t *testing.T) {
 		t.Error("Generated code should handle filter validation errors")
 	}
 }
+
+func TestGenerateListHandler_CountStrategy(t *testing.T) {
+	tests := []struct {
+		strategy string
+		want     []string
+		notWant  []string
+	}{
+		{
+			strategy: "",
+			want:     []string{"qb.Count(ctx, db, query.CountExact)", "\"count\": \"exact\"", "BuildPaginationLinks(r.URL.Path, page, limit, total)"},
+		},
+		{
+			strategy: ast.CountEstimated,
+			want:     []string{"qb.Count(ctx, db, query.CountEstimated)", "\"count\": \"estimated\"", "\"total\": total"},
+			notWant:  []string{"query.CountExact"},
+		},
+		{
+			strategy: ast.CountNone,
+			want:     []string{"\"count\": \"none\"", "BuildPaginationLinksWithoutTotal(r.URL.Path, page, limit, stream.Count() == limit)"},
+			notWant:  []string{"qb.Count(", "\"total\": total"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			resource := &ast.ResourceNode{
+				Name:          "Event",
+				CountStrategy: tt.strategy,
+				Fields: []*ast.FieldNode{
+					{Name: "name", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}, Nullable: false},
+				},
+			}
+
+			gen := NewGenerator()
+			code, err := gen.GenerateHandlers([]*ast.ResourceNode{resource}, "example.com/testapp")
+			if err != nil {
+				t.Fatalf("GenerateHandlers failed: %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(code, want) {
+					t.Errorf("Generated code should contain %q", want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(code, notWant) {
+					t.Errorf("Generated code should not contain %q", notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateListHandler_QueryAllowLists(t *testing.T) {
+	stringType := &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}
+	resources := []*ast.ResourceNode{
+		{
+			Name: "Post",
+			Fields: []*ast.FieldNode{
+				{Name: "title", Type: stringType, Constraints: []*ast.ConstraintNode{{Name: "sortable"}}},
+				{Name: "status", Type: stringType, Constraints: []*ast.ConstraintNode{{Name: "filterable"}, {Name: "sortable"}}},
+				{Name: "body", Type: stringType},
+			},
+		},
+		{
+			Name: "Tag",
+			Fields: []*ast.FieldNode{
+				{Name: "label", Type: stringType},
+				{Name: "color", Type: stringType},
+			},
+		},
+	}
+
+	gen := NewGenerator()
+	code, err := gen.GenerateHandlers(resources, "example.com/testapp")
+	if err != nil {
+		t.Fatalf("GenerateHandlers failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`var postFilterable = []string{"status"}`,
+		`var postSortable = []string{"title", "status"}`,
+		// Resources without annotations allow every field
+		`var tagFilterable = []string{"label", "color"}`,
+		`var tagSortable = []string{"label", "color"}`,
+		"Filterable(postFilterable).",
+		"Sortable(postSortable).",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Generated code should contain %q", want)
+		}
+	}
+}
+
+func TestGenerateListHandler_FilterOperators(t *testing.T) {
+	stringType := &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}
+	intType := &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int"}
+	filterable := func(operators ...string) []*ast.ConstraintNode {
+		constraint := &ast.ConstraintNode{Name: "filterable"}
+		for _, operator := range operators {
+			constraint.Arguments = append(constraint.Arguments, &ast.IdentifierExpr{Name: operator})
+		}
+		return []*ast.ConstraintNode{constraint}
+	}
+	post := &ast.ResourceNode{
+		Name: "Post",
+		Fields: []*ast.FieldNode{
+			{Name: "title", Type: stringType, Constraints: filterable("like")},
+			{Name: "status", Type: stringType, Constraints: filterable()},
+			{Name: "view_count", Type: intType, Constraints: filterable("gte", "lte", "in")},
+		},
+	}
+
+	code, err := NewGenerator().GenerateHandlers([]*ast.ResourceNode{post}, "example.com/testapp")
+	if err != nil {
+		t.Fatalf("GenerateHandlers failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+
+	for _, want := range []string{
+		"var postOperators = map[string][]string{",
+		`"title": []string{"like"},`,
+		`"view_count": []string{"gte", "lte", "in"},`,
+		"operators := query.ParseOperators(r)",
+		"Operators(postOperators).",
+		"Compare(operators).",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Generated code should contain %q", want)
+		}
+	}
+
+	// Resources without operators only filter by equality
+	post.Fields[0].Constraints = filterable()
+	post.Fields[2].Constraints = filterable()
+	code, err = NewGenerator().GenerateHandlers([]*ast.ResourceNode{post}, "example.com/testapp")
+	if err != nil {
+		t.Fatalf("GenerateHandlers failed: %v", err)
+	}
+	if strings.Contains(code, "ParseOperators") || strings.Contains(code, "postOperators") {
+		t.Error("Resources without filter operators should not parse them")
+	}
+}
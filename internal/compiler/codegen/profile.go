@@ -0,0 +1,77 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// hasProfiles reports whether any resource declares @profile
+func hasProfiles(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if len(resource.Profiles) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// generateProfileSet generates the package-level profile.Set of a @profile
+// resource; * becomes a nil field list, which shows every field
+func (g *Generator) generateProfileSet(resource *ast.ResourceNode) {
+	g.writeLine("// %s maps %s serialization profiles to the fields they show", g.resourceVarName(resource, "Profiles"), resource.Name)
+	g.writeLine("var %s = profile.Set{", g.resourceVarName(resource, "Profiles"))
+	g.indent++
+	for _, p := range resource.Profiles {
+		if p.All {
+			g.writeLine("%q: nil,", p.Name)
+		} else {
+			g.writeLine("%q: %s,", p.Name, g.stringSliceLiteral(g.jsonNames(p.Fields)))
+		}
+	}
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateVisibleFields declares visible, the fields the caller's roles may
+// see, in a handler of a @profile resource. Responses then differ by
+// credentials, so shared caches are told to keep them apart.
+func (g *Generator) generateVisibleFields(resource *ast.ResourceNode) {
+	if len(resource.Profiles) == 0 {
+		return
+	}
+	g.writeLine("// Render only the fields the caller's roles may see (@profile)")
+	g.writeLine("visible := %s.Fields(r)", g.resourceVarName(resource, "Profiles"))
+	g.writeLine("w.Header().Add(\"Vary\", \"Authorization, Cookie\")")
+	g.writeLine("")
+}
+
+// queryableFields returns the expression for the fields a list query may
+// filter or sort by: the resource variable with the given suffix, restricted
+// to the visible fields of @profile resources so hidden values cannot be
+// probed with filters
+func (g *Generator) queryableFields(resource *ast.ResourceNode, suffix string) string {
+	name := g.resourceVarName(resource, suffix)
+	if len(resource.Profiles) == 0 {
+		return name
+	}
+	return fmt.Sprintf("profile.Restrict(%s, visible)", name)
+}
+
+// maskedRecord returns the expression a handler encodes as legacy JSON for
+// record: the record itself, or the record masked to the visible fields
+func maskedRecord(resource *ast.ResourceNode, record string) string {
+	if len(resource.Profiles) == 0 {
+		return record
+	}
+	return fmt.Sprintf("response.Masked(%s, visible)", record)
+}
+
+// renderJSONAPI returns the call rendering record as a JSON:API document,
+// keeping only the visible attributes of @profile resources
+func renderJSONAPI(resource *ast.ResourceNode, status, record string) string {
+	if len(resource.Profiles) == 0 {
+		return fmt.Sprintf("response.RenderJSONAPI(w, %s, %s)", status, record)
+	}
+	return fmt.Sprintf("response.RenderJSONAPIMasked(w, %s, %s, visible)", status, record)
+}
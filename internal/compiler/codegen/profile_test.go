@@ -0,0 +1,75 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+const profileSource = `resource Post {
+  @search_index(title, body)
+  @profile(public: [id, title], admin: *)
+
+  id: uuid! @primary @auto
+  title: string!
+  body: text!
+}`
+
+func TestGenerateHandlers_Profiles(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, profileSource))
+
+	assertContains(t, code,
+		`"github.com/conduit-lang/conduit/pkg/web/profile"`,
+		"var postProfiles = profile.Set{",
+		`"public": []string{"id", "title"},`,
+		`"admin": nil,`,
+	)
+
+	// Every handler rendering records masks them in both formats
+	for _, fn := range []struct {
+		name string
+		want []string
+	}{
+		{"ListPostHandler", []string{
+			"stream.Mask(visible)",
+			"Filterable(profile.Restrict(postFilterable, visible)).",
+			"Sortable(profile.Restrict(postSortable, visible)).",
+		}},
+		{"GetPostHandler", []string{
+			"response.RenderJSONAPIMasked(w, http.StatusOK, result, visible)",
+			"json.NewEncoder(w).Encode(response.Masked(result, visible))",
+		}},
+		{"CreatePostHandler", []string{
+			"response.RenderJSONAPIMasked(w, dryrun.Status(ctx, http.StatusCreated), &p, visible)",
+			"json.NewEncoder(w).Encode(response.Masked(p, visible))",
+		}},
+		{"UpdatePostHandler", []string{
+			"response.RenderJSONAPIMasked(w, http.StatusOK, &p, visible)",
+			"json.NewEncoder(w).Encode(response.Masked(p, visible))",
+		}},
+		{"PatchPostHandler", []string{
+			"response.RenderJSONAPIMasked(w, http.StatusOK, existing, visible)",
+			"json.NewEncoder(w).Encode(response.Masked(existing, visible))",
+		}},
+		{"SearchPostHandler", []string{"stream.Mask(visible)"}},
+	} {
+		body := functionBody(t, code, "func "+fn.name+"(")
+		want := append([]string{"visible := postProfiles.Fields(r)", `w.Header().Add("Vary", "Authorization, Cookie")`}, fn.want...)
+		for _, line := range want {
+			if !strings.Contains(body, line) {
+				t.Errorf("%s missing %q", fn.name, line)
+			}
+		}
+		if strings.Contains(body, "response.RenderJSONAPI(w") {
+			t.Errorf("%s renders JSON:API without masking", fn.name)
+		}
+	}
+}
+
+func TestGenerateHandlers_NoProfiles(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, searchSource))
+	for _, unwanted := range []string{"profile.", "visible", "Masked"} {
+		if strings.Contains(code, unwanted) {
+			t.Errorf("Resource without @profile should not reference %q", unwanted)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProfilingOptions controls the Go profiler and the profiling agent of the
+// generated main, from conduit.yaml's profiling section. Agent credentials
+// are read from the environment at startup.
+type ProfilingOptions struct {
+	// Pprof serves net/http/pprof on /debug/pprof to local callers and to
+	// callers with CONDUIT_PPROF_TOKEN
+	Pprof bool
+	// BlockRate and MutexFraction turn on the block and mutex profiles
+	BlockRate     int
+	MutexFraction int
+	// Agent sends continuous profiles: pyroscope, or none when empty
+	Agent string
+	// ServerAddress of the agent's server; PYROSCOPE_SERVER_ADDRESS
+	// overrides it
+	ServerAddress string
+	// Application names the profiles
+	Application string
+	// Interval between profiles; the agent's default when zero
+	Interval time.Duration
+	// Tags label every profile
+	Tags map[string]string
+}
+
+// SetProfiling configures the profiling generated by GenerateProgram
+func (g *Generator) SetProfiling(opts ProfilingOptions) {
+	g.profiling = opts
+}
+
+// profilingConfigured reports whether main calls profiling.Configure: an
+// agent runs or a profile rate is set
+func (g *Generator) profilingConfigured() bool {
+	p := g.profiling
+	return p.Agent != "" || p.BlockRate > 0 || p.MutexFraction > 0
+}
+
+// usesProfiling reports whether main imports pkg/web/profiling
+func (g *Generator) usesProfiling() bool {
+	return g.profiling.Pprof || g.profilingConfigured()
+}
+
+// generateProfilingConfig sets the profile rates and starts the agent before
+// anything is served, so the first requests are profiled too
+func (g *Generator) generateProfilingConfig() {
+	p := g.profiling
+	var fields [][2]string
+	if p.Agent != "" {
+		fields = append(fields,
+			[2]string{"Agent", "profiling.Agent" + strings.Title(p.Agent)},
+			[2]string{"Application", fmt.Sprintf("%q", p.Application)})
+	}
+	if p.ServerAddress != "" {
+		fields = append(fields, [2]string{"ServerAddress", fmt.Sprintf("%q", p.ServerAddress)})
+	}
+	if p.Interval > 0 {
+		fields = append(fields, [2]string{"Interval", durationLiteral(p.Interval)})
+	}
+	if len(p.Tags) > 0 {
+		fields = append(fields, [2]string{"Tags", tagsLiteral(p.Tags)})
+	}
+	if p.BlockRate > 0 {
+		fields = append(fields, [2]string{"BlockRate", fmt.Sprintf("%d", p.BlockRate)})
+	}
+	if p.MutexFraction > 0 {
+		fields = append(fields, [2]string{"MutexFraction", fmt.Sprintf("%d", p.MutexFraction)})
+	}
+	width := 0
+	for _, field := range fields {
+		width = max(width, len(field[0]))
+	}
+
+	if p.Agent != "" {
+		g.writeLine("// Send continuous profiles to the Pyroscope server at PYROSCOPE_SERVER_ADDRESS, when it is set")
+	} else {
+		g.writeLine("// Record blocking and mutex contention for the profiler")
+	}
+	g.writeLine("if err := profiling.Configure(profiling.Config{")
+	g.indent++
+	for _, field := range fields {
+		g.writeLine("%-*s %s,", width+1, field[0]+":", field[1])
+	}
+	g.indent--
+	g.writeLine("}); err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(%q, err)", "Failed to configure profiling: %v")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
+
+// tagsLiteral returns the map[string]string literal of profile tags, sorted
+// so builds are reproducible
+func tagsLiteral(tags map[string]string) string {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	entries := make([]string, len(names))
+	for i, name := range names {
+		entries[i] = fmt.Sprintf("%q: %q", name, tags[name])
+	}
+	return "map[string]string{" + strings.Join(entries, ", ") + "}"
+}
+
+// generateProfilerMount serves net/http/pprof outside the API prefix, like
+// the pool statistics
+func (g *Generator) generateProfilerMount() {
+	g.writeLine("// Go profiler for local callers and CONDUIT_PPROF_TOKEN (disable with CONDUIT_PPROF=off)")
+	g.writeLine("if profiling.Enabled() {")
+	g.indent++
+	g.writeLine("r.Mount(profiling.Path, profiling.Handler(os.Getenv(profiling.TokenEnvVar)))")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
@@ -0,0 +1,103 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+func TestGenerateMain_Profiling(t *testing.T) {
+	resources := []*ast.ResourceNode{parseResource(t, authSource)}
+
+	// Disabled by default
+	code, err := NewGenerator().GenerateMain(resources, "example.com/app", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "profiling") {
+		t.Error("Generated main should not profile unless configured")
+	}
+
+	g := NewGenerator()
+	g.SetProfiling(ProfilingOptions{
+		Pprof:         true,
+		MutexFraction: 5,
+		Agent:         "pyroscope",
+		ServerAddress: "http://pyroscope:4040",
+		Application:   "blog",
+		Interval:      15 * time.Second,
+		Tags:          map[string]string{"region": "eu", "env": "prod"},
+	})
+	code, err = g.GenerateMain(resources, "example.com/app", "/api")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated main does not parse: %v\n%s", err, code)
+	}
+
+	for _, want := range []string{
+		`"github.com/conduit-lang/conduit/pkg/web/profiling"`,
+		"Agent:         profiling.AgentPyroscope,",
+		`Application:   "blog",`,
+		`ServerAddress: "http://pyroscope:4040",`,
+		"Interval:      15*time.Second,",
+		`Tags:          map[string]string{"env": "prod", "region": "eu"},`,
+		"MutexFraction: 5,",
+		`log.Fatalf("Failed to configure profiling: %v", err)`,
+		"r.Mount(profiling.Path, profiling.Handler(os.Getenv(profiling.TokenEnvVar)))",
+		"profiling.Stop(profiling.StopTimeout)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Generated main missing %q", want)
+		}
+	}
+	if strings.Contains(code, "BlockRate") {
+		t.Error("Unset rates should be left out")
+	}
+
+	// The agent runs before anything is served, and the profiler is
+	// mounted outside the API prefix
+	configure := strings.Index(code, "profiling.Configure(")
+	router := strings.Index(code, "r := chi.NewRouter()")
+	mount := strings.Index(code, "r.Mount(profiling.Path")
+	prefix := strings.Index(code, `r.Route("/api"`)
+	if !(configure < router && mount < prefix) {
+		t.Errorf("Configure %d, router %d, mount %d, API prefix %d", configure, router, mount, prefix)
+	}
+
+	// Profiles are sent before the process exits, whether or not the server failed
+	if strings.Count(code, "profiling.Stop(profiling.StopTimeout)") != 2 {
+		t.Error("Generated main should stop the agent on both exits")
+	}
+}
+
+func TestGenerateMain_PprofOnly(t *testing.T) {
+	g := NewGenerator()
+	g.SetProfiling(ProfilingOptions{Pprof: true})
+	code, err := g.GenerateMain([]*ast.ResourceNode{parseResource(t, authSource)}, "example.com/app", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if !strings.Contains(code, "if profiling.Enabled() {") {
+		t.Error("Generated main should serve the profiler")
+	}
+	// Nothing to configure and no agent to stop
+	if strings.Contains(code, "profiling.Configure(") || strings.Contains(code, "profiling.Stop(") {
+		t.Error("Generated main should only mount the profiler")
+	}
+
+	// Profile rates alone are configured without an agent
+	g = NewGenerator()
+	g.SetProfiling(ProfilingOptions{BlockRate: 1})
+	code, err = g.GenerateMain([]*ast.ResourceNode{parseResource(t, authSource)}, "example.com/app", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if !strings.Contains(code, "BlockRate: 1,") || strings.Contains(code, "Agent:") || strings.Contains(code, "profiling.Enabled()") {
+		t.Errorf("Generated main should only set the block rate:\n%s", code)
+	}
+}
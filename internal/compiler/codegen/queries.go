@@ -0,0 +1,79 @@
+package codegen
+
+import (
+	"fmt"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// routeFunctions lists the model functions behind each route operation, %s
+// standing for the resource name. The statements a route executes are the
+// SQL literals of these functions.
+var routeFunctions = map[string][]string{
+	"list":         {"FindAll%s", "Count%s"},
+	"show":         {"Find%sByID"},
+	"create":       {"Create", "create"},
+	"create_batch": {"Create%sBatch", "Create", "create"},
+	"update":       {"Update"},
+	"delete":       {"Delete"},
+	"upsert":       {"Upsert"},
+	"archive":      {"Archive"},
+	"restore":      {"Restore"},
+	"move":         {"Move", "movePosition"},
+	"children":     {"Find%sChildren"},
+	"ancestors":    {"Find%sAncestors"},
+}
+
+// RouteQueries returns the SQL templates each route operation of a resource
+// may execute, in the order they appear in its model. They are the statements
+// as generated: list routes add the request's filters and sorts to the WHERE
+// and ORDER BY clauses of the list query. Operations without SQL of their
+// own, like webhook, are left out.
+func RouteQueries(resource *ast.ResourceNode) (map[string][]string, error) {
+	code, err := NewGenerator().GenerateResource(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, 0)
+	if err != nil {
+		return nil, fmt.Errorf("codegen: parsing %s model: %w", resource.Name, err)
+	}
+
+	// SQL literals by function name; the generated models write every
+	// statement as a raw string
+	literals := make(map[string][]string)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*goast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		goast.Inspect(fn.Body, func(n goast.Node) bool {
+			if lit, ok := n.(*goast.BasicLit); ok && lit.Kind == token.STRING && strings.HasPrefix(lit.Value, "`") {
+				literals[fn.Name.Name] = append(literals[fn.Name.Name], strings.Trim(lit.Value, "`"))
+			}
+			return true
+		})
+	}
+
+	queries := make(map[string][]string)
+	for operation, functions := range routeFunctions {
+		seen := make(map[string]bool)
+		for _, function := range functions {
+			if strings.Contains(function, "%s") {
+				function = fmt.Sprintf(function, resource.Name)
+			}
+			for _, query := range literals[function] {
+				if !seen[query] {
+					seen[query] = true
+					queries[operation] = append(queries[operation], query)
+				}
+			}
+		}
+	}
+	return queries, nil
+}
@@ -0,0 +1,76 @@
+package codegen
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+func TestRouteQueries(t *testing.T) {
+	queries, err := RouteQueries(ast.WithPositions([]*ast.ResourceNode{parseResource(t, orderableSource)})[0])
+	if err != nil {
+		t.Fatalf("RouteQueries failed: %v", err)
+	}
+
+	wantList := []string{
+		"SELECT id, title, list_id, updated_at, position FROM tasks ORDER BY list_id, position, id LIMIT $1 OFFSET $2",
+		"SELECT COUNT(*) FROM tasks",
+	}
+	if !reflect.DeepEqual(queries["list"], wantList) {
+		t.Errorf("list queries = %q, want %q", queries["list"], wantList)
+	}
+
+	// Create appends the record to its list before inserting it
+	wantCreate := []string{
+		"SELECT COALESCE(MAX(position), 0) + 1024 FROM tasks WHERE list_id = $1",
+		"INSERT INTO tasks (id, title, list_id, updated_at, position) VALUES ($1, $2, $3, $4, $5)",
+	}
+	if !reflect.DeepEqual(queries["create"], wantCreate) {
+		t.Errorf("create queries = %q, want %q", queries["create"], wantCreate)
+	}
+	if !reflect.DeepEqual(queries["create_batch"], wantCreate) {
+		t.Errorf("create_batch queries = %q, want %q", queries["create_batch"], wantCreate)
+	}
+
+	// Move includes the queries of the helper finding the new position
+	move := strings.Join(queries["move"], "\n")
+	assertContains(t, move,
+		"UPDATE tasks SET position = $2, updated_at = $3 WHERE id = $1",
+		"SELECT position FROM tasks WHERE id = $1 AND list_id = $2 FOR UPDATE",
+	)
+
+	if _, ok := queries["archive"]; ok {
+		t.Error("Resources without @archivable should have no archive queries")
+	}
+}
+
+func TestGenerateMetadata_RouteQueries(t *testing.T) {
+	prog := &ast.Program{Resources: []*ast.ResourceNode{parseResource(t, postSource)}}
+
+	metadataJSON, err := NewGenerator().GenerateMetadata(prog)
+	if err != nil {
+		t.Fatalf("GenerateMetadata() error = %v", err)
+	}
+
+	var meta struct {
+		Routes []struct {
+			Operation string   `json:"operation"`
+			Queries   []string `json:"queries"`
+		} `json:"routes"`
+	}
+	if err := json.Unmarshal([]byte(metadataJSON), &meta); err != nil {
+		t.Fatalf("Invalid metadata JSON: %v", err)
+	}
+	for _, route := range meta.Routes {
+		if len(route.Queries) == 0 {
+			t.Errorf("The %s route should list its queries", route.Operation)
+		}
+		// Show routes are "get" in the metadata
+		if route.Operation == "get" && len(route.Queries) > 0 && !strings.HasPrefix(route.Queries[0], "SELECT") {
+			t.Errorf("get queries = %q, want the show query", route.Queries)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/metadata"
+)
+
+// UsagePath is where clients read their usage (outside the API prefix)
+const UsagePath = "/usage"
+
+// QuotaOptions controls the per-client quotas generated for conduit.yaml's
+// quota section
+type QuotaOptions struct {
+	// Enabled meters resource routes and serves UsagePath
+	Enabled bool
+	// Client is how clients are identified: api_key or user
+	Client string
+	// Header carries the API key; X-API-Key when empty
+	Header string
+	// Limits applies to clients without an entry in Clients
+	Limits QuotaLimits
+	// Clients overrides Limits by client name or user ID
+	Clients map[string]QuotaLimits
+}
+
+// QuotaLimits caps a client's requests; zero is unlimited
+type QuotaLimits struct {
+	Monthly   int64
+	RateLimit int
+}
+
+// SetQuota configures the quotas generated by GenerateProgram
+func (g *Generator) SetQuota(opts QuotaOptions) {
+	g.quota = opts
+}
+
+// generateQuotaConfig configures the request meter from the build's
+// conduit.yaml settings; API keys are read from the environment by
+// quota.Configure
+func (g *Generator) generateQuotaConfig() {
+	names := make([]string, 0, len(g.quota.Clients))
+	for name := range g.quota.Clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g.writeLine("// Meter requests per %s and enforce quotas (flushed to usage_daily and usage_monthly)", strings.ReplaceAll(g.quota.Client, "_", " "))
+	g.writeLine("meter, err := quota.Configure(context.Background(), db, quota.Config{")
+	g.indent++
+	g.writeLine("Client: %q,", g.quota.Client)
+	if g.quota.Header != "" {
+		g.writeLine("Header: %q,", g.quota.Header)
+	}
+	if limits := quotaLimitsFields(g.quota.Limits); limits != "" {
+		g.writeLine("Limits: quota.Limits{%s},", limits)
+	}
+	if len(names) > 0 {
+		g.writeLine("Clients: map[string]quota.Limits{")
+		g.indent++
+		for _, name := range names {
+			g.writeLine("%q: {%s},", name, quotaLimitsFields(g.quota.Clients[name]))
+		}
+		g.indent--
+		g.writeLine("},")
+	}
+	g.indent--
+	g.writeLine("})")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(%q, err)", "Failed to configure quotas: %v")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("meter.Start(context.Background())")
+	g.writeLine("defer meter.Stop()")
+	g.writeLine("")
+}
+
+// quotaLimitsFields returns the set fields of a quota.Limits literal
+func quotaLimitsFields(limits QuotaLimits) string {
+	var fields []string
+	if limits.Monthly > 0 {
+		fields = append(fields, fmt.Sprintf("Monthly: %d", limits.Monthly))
+	}
+	if limits.RateLimit > 0 {
+		fields = append(fields, fmt.Sprintf("RateLimit: %d", limits.RateLimit))
+	}
+	return strings.Join(fields, ", ")
+}
+
+// quotaMetadata describes the usage route
+func (g *Generator) quotaMetadata() metadata.RouteMetadata {
+	return metadata.RouteMetadata{
+		Method:      "GET",
+		Path:        UsagePath,
+		Handler:     "meter.UsageHandler",
+		Operation:   "usage",
+		Description: "Report the client's requests and remaining quota",
+	}
+}
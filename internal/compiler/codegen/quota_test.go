@@ -0,0 +1,114 @@
+package codegen
+
+import (
+	"encoding/json"
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/metadata"
+)
+
+func quotaTestGenerator() *Generator {
+	g := NewGenerator()
+	g.SetQuota(QuotaOptions{
+		Enabled: true,
+		Client:  "api_key",
+		Header:  "X-Client-Key",
+		Limits:  QuotaLimits{Monthly: 10000, RateLimit: 60},
+		Clients: map[string]QuotaLimits{
+			"beta": {RateLimit: 600},
+			"acme": {Monthly: 1000000, RateLimit: 600},
+		},
+	})
+	return g
+}
+
+func TestGenerateMain_Quota(t *testing.T) {
+	code, err := quotaTestGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, authSource)}, "example.com/app", "/api")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated main does not parse: %v\n%s", err, code)
+	}
+
+	for _, want := range []string{
+		`"github.com/conduit-lang/conduit/pkg/web/quota"`,
+		"meter, err := quota.Configure(context.Background(), db, quota.Config{",
+		`Client: "api_key",`,
+		`Header: "X-Client-Key",`,
+		"Limits: quota.Limits{Monthly: 10000, RateLimit: 60},",
+		`"acme": {Monthly: 1000000, RateLimit: 600},`,
+		`"beta": {RateLimit: 600},`,
+		"meter.Start(context.Background())",
+		"defer meter.Stop()",
+		`r.Get("/usage", meter.UsageHandler())`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Main missing %q", want)
+		}
+	}
+
+	// Only resource routes are metered
+	prefix := strings.Index(code, `r.Route("/api", func(r chi.Router) {`)
+	use := strings.Index(code, "r.Use(meter.Middleware)")
+	if prefix < 0 || use < prefix || use > strings.Index(code, "handlers.RegisterUserRoutes") {
+		t.Error("Meter should wrap the resource routes inside the API prefix")
+	}
+	if strings.Index(code, "meter.UsageHandler") > prefix {
+		t.Error("Usage route should be outside the API prefix")
+	}
+}
+
+func TestGenerateMain_QuotaWithoutPrefix(t *testing.T) {
+	g := NewGenerator()
+	g.SetQuota(QuotaOptions{Enabled: true, Client: "user"})
+	code, err := g.GenerateMain([]*ast.ResourceNode{parseResource(t, authSource)}, "example.com/app", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated main does not parse: %v\n%s", err, code)
+	}
+
+	group := strings.Index(code, "r.Group(func(r chi.Router) {")
+	if group < 0 || strings.Index(code, "r.Use(meter.Middleware)") < group {
+		t.Error("Meter should wrap the resource routes in a group")
+	}
+	if strings.Contains(code, "Limits:") || strings.Contains(code, "Header:") {
+		t.Error("Main should leave unset limits and the default header to the runtime")
+	}
+}
+
+func TestGenerateMain_QuotaDisabled(t *testing.T) {
+	code, err := NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, authSource)}, "example.com/app", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "quota.") || strings.Contains(code, "/usage") {
+		t.Error("Main should not meter requests without a quota client")
+	}
+}
+
+func TestGenerateMetadata_Quota(t *testing.T) {
+	metadataJSON, err := quotaTestGenerator().GenerateMetadata(&ast.Program{Resources: []*ast.ResourceNode{parseResource(t, authSource)}})
+	if err != nil {
+		t.Fatalf("GenerateMetadata() error = %v", err)
+	}
+
+	var meta metadata.Metadata
+	if err := json.Unmarshal([]byte(metadataJSON), &meta); err != nil {
+		t.Fatalf("Metadata does not parse: %v", err)
+	}
+	for _, route := range meta.Routes {
+		if route.Path == UsagePath {
+			if route.Method != "GET" || route.Operation != "usage" {
+				t.Errorf("Usage route = %+v", route)
+			}
+			return
+		}
+	}
+	t.Error("Usage route missing from metadata")
+}
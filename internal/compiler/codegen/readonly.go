@@ -0,0 +1,20 @@
+package codegen
+
+// generateReadOnlySwitch creates the read-only maintenance mode switch from
+// CONDUIT_READ_ONLY, so operators can stop writes during migrations and
+// failovers while reads continue
+func (g *Generator) generateReadOnlySwitch() {
+	g.writeLine("// Read-only maintenance mode, set by CONDUIT_READ_ONLY and switched on readonly.Path")
+	g.writeLine("maintenance, err := readonly.FromEnv()")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("log.Fatal(err)")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if state := maintenance.State(); state.ReadOnly {")
+	g.indent++
+	g.writeLine("log.Printf(%q, state.Message)", "Starting in read-only mode: %s")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
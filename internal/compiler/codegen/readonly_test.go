@@ -0,0 +1,32 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+func TestGenerateMain_ReadOnly(t *testing.T) {
+	code, err := NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, searchSource)}, "example.com/blog", "/api/v1")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+
+	for _, exp := range []string{
+		`"github.com/conduit-lang/conduit/pkg/web/readonly"`,
+		"maintenance, err := readonly.FromEnv()",
+		"r.Use(maintenance.Middleware)",
+		"r.Get(readonly.Path, maintenance.Handler())",
+		"r.Put(readonly.Path, maintenance.Handler())",
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated main missing %q", exp)
+		}
+	}
+
+	// The switch stays outside the API prefix, like the health check
+	if strings.Index(code, "r.Put(readonly.Path") > strings.Index(code, `r.Route("/api/v1"`) {
+		t.Error("the read-only switch should be mounted outside the API prefix")
+	}
+}
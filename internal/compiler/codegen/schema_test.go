@@ -0,0 +1,95 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const invoiceSource = `resource Invoice {
+  @schema("billing")
+
+  id: uuid! @primary @auto
+  number: string! @unique
+}`
+
+func TestGenerateMigrations_Schema(t *testing.T) {
+	resources := parseResources(t, invoiceSource+`
+
+resource Payment {
+  @schema("billing")
+
+  id: uuid! @primary @auto
+  number: string! @unique
+}
+
+resource Post {
+  id: uuid! @primary @auto
+  number: string! @unique
+}`)
+	sql, err := NewGenerator().GenerateMigrations(resources)
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+
+	expected := []string{
+		"CREATE SCHEMA IF NOT EXISTS billing;\n\nCREATE TABLE billing.invoices (",
+		"CREATE TABLE billing.payments (",
+		"CREATE TABLE posts (",
+		"CREATE UNIQUE INDEX idx_invoices_number ON billing.invoices(number);",
+		"CREATE UNIQUE INDEX idx_posts_number ON posts(number);",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(sql, exp) {
+			t.Errorf("Migration missing %q:\n%s", exp, sql)
+		}
+	}
+	if n := strings.Count(sql, "CREATE SCHEMA"); n != 1 {
+		t.Errorf("Migration creates the billing schema %d times, want once:\n%s", n, sql)
+	}
+
+	sql, err = NewGenerator().GenerateMigrations([]*ast.ResourceNode{parseResource(t, postSource)})
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+	if strings.Contains(sql, "CREATE SCHEMA") {
+		t.Errorf("Migration should not create schemas without @schema resources:\n%s", sql)
+	}
+}
+
+func TestGenerateResource_Schema(t *testing.T) {
+	code := generateModel(t, parseResource(t, invoiceSource))
+	assertContains(t, code,
+		`return "billing.invoices"`,
+		"INSERT INTO billing.invoices (",
+		"FROM billing.invoices WHERE id = $1",
+		"UPDATE billing.invoices SET",
+		"DELETE FROM billing.invoices WHERE id = $1",
+	)
+}
+
+func TestGenerateHandlers_Schema(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, invoiceSource))
+
+	// Queries name the qualified table; routes keep the plain collection name
+	assertContains(t, code,
+		`query.NewMappedBuilder("billing.invoices", `,
+		`r.Get("/invoices", ListInvoiceHandler(db))`,
+	)
+	if strings.Contains(code, `"/billing.invoices`) {
+		t.Error("Routes should not include the schema")
+	}
+}
+
+func TestGenerateMain_Schema(t *testing.T) {
+	gen := NewGenerator()
+	gen.SetPreflight(PreflightOptions{Enabled: true})
+	code, err := gen.GenerateMain([]*ast.ResourceNode{parseResource(t, invoiceSource)}, "example.com/billing", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if !strings.Contains(code, `{Name: "billing.invoices", Columns: `) {
+		t.Errorf("Preflight should check the qualified table:\n%s", code)
+	}
+}
@@ -0,0 +1,200 @@
+package codegen
+
+import (
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// hasSearchIndex reports whether any resource declares @search_index
+func hasSearchIndex(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if resource.SearchIndex != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchIndexName returns the search backend index a @search_index resource
+// is stored in: its table name
+func SearchIndexName(resourceName string) string {
+	return TableName(resourceName)
+}
+
+// generateSearchDocument generates the SearchDocument method that copies the
+// @search_index fields of a record into the document sent to the backend
+func (g *Generator) generateSearchDocument(resource *ast.ResourceNode) {
+	receiverName := strings.ToLower(resource.Name[0:1])
+
+	g.writeLine("// SearchDocument returns the fields of the %s indexed for search (@search_index)", resource.Name)
+	g.writeLine("func (%s *%s) SearchDocument() search.Document {", receiverName, resource.Name)
+	g.indent++
+	g.writeLine("return search.Document{")
+	g.indent++
+	for _, name := range resource.SearchIndex.Fields {
+		g.writeLine("%q: %s.%s,", name, receiverName, g.toGoFieldName(name))
+	}
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateSearchIndexing keeps the search index in step with a committed
+// write: the record is re-indexed after create and update, and removed after
+// delete. It is called after the commit so searches never return records a
+// rolled back transaction wrote.
+func (g *Generator) generateSearchIndexing(resource *ast.ResourceNode, receiverName string, remove bool) {
+	if resource.SearchIndex == nil {
+		return
+	}
+
+	g.writeLine("// Keep the search index in step (@search_index); failures are logged")
+	if remove {
+		g.writeLine("search.Delete(%q, fmt.Sprint(%s))", SearchIndexName(resource.Name), g.keyValue(resource, receiverName))
+	} else {
+		g.writeLine("search.Index(%q, fmt.Sprint(%s), %s.SearchDocument())", SearchIndexName(resource.Name), g.keyValue(resource, receiverName), receiverName)
+	}
+	g.writeLine("")
+}
+
+// generateSearchHandler generates the search handler for a @search_index
+// resource (GET /resources/search). The backend returns matching IDs, and the
+// records are loaded from the database in the backend's relevance order.
+func (g *Generator) generateSearchHandler(resource *ast.ResourceNode) {
+	resourceLower := strings.ToLower(resource.Name)
+	tableName := g.toTableName(resource.Name)
+
+	g.writeLine("// Search%sHandler handles GET /%s/search - %s matching a full-text query",
+		resource.Name, tableName, resourceLower+"s")
+	g.writeLine("func Search%sHandler(db *sql.DB) http.HandlerFunc {", resource.Name)
+	g.indent++
+	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
+	g.indent++
+
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, \"search\")", resource.Name)
+	g.writeLine("")
+	g.generateVisibleFields(resource)
+
+	g.writeLine("// Parse q, limit and offset")
+	g.writeLine("q, err := search.ParseRequest(r)")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeSearchError("http.StatusBadRequest", "err")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	fields := make([]string, len(resource.SearchIndex.Fields))
+	for i, name := range resource.SearchIndex.Fields {
+		fields[i] = "\"" + name + "\""
+	}
+	g.writeLine("q.Fields = []string{%s}", strings.Join(fields, ", "))
+	g.writeLine("")
+
+	g.writeLine("// Find matching IDs in relevance order")
+	g.writeLine("result, err := search.Search(ctx, %q, q)", SearchIndexName(resource.Name))
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("status := http.StatusBadGateway")
+	g.writeLine("if errors.Is(err, search.ErrDisabled) {")
+	g.indent++
+	g.writeLine("status = http.StatusServiceUnavailable")
+	g.indent--
+	g.writeLine("}")
+	g.writeSearchError("status", "fmt.Errorf(\"Failed to search "+resourceLower+"s: %v\", err)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("// Load the matching records; IDs the database no longer has are skipped")
+	g.writeLine("found := make(map[string]*models.%s, len(result.IDs))", resource.Name)
+	g.writeLine("if len(result.IDs) > 0 {")
+	g.indent++
+	g.writeLine("rows, err := db.QueryContext(ctx, `SELECT * FROM %s WHERE %s::text = ANY($1)%s`, result.IDs)",
+		g.sqlTable(resource), g.keyColumn(resource), g.liveCondition(resource))
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeSearchError("http.StatusInternalServerError", "fmt.Errorf(\"Failed to query "+resourceLower+"s: %v\", err)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("defer rows.Close()")
+	g.writeLine("for rows.Next() {")
+	g.indent++
+	g.writeLine("item := &models.%s{}", resource.Name)
+	g.writeLine("if err := rows.Scan(%s); err != nil {", g.generateScanFields(resource))
+	g.indent++
+	g.writeSearchError("http.StatusInternalServerError", "fmt.Errorf(\"Failed to scan "+resourceLower+": %v\", err)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("found[fmt.Sprint(%s)] = item", g.keyValue(resource, "item"))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if err := rows.Err(); err != nil {")
+	g.indent++
+	g.writeSearchError("http.StatusInternalServerError", "fmt.Errorf(\"Error iterating "+resourceLower+"s: %v\", err)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.generateListStream(resource, "nil")
+	g.writeLine("for _, id := range result.IDs {")
+	g.indent++
+	g.writeLine("if item, ok := found[id]; ok {")
+	g.indent++
+	g.writeLine("if err := stream.Write(item); err != nil {")
+	g.indent++
+	g.writeLine("stream.Fail(fmt.Errorf(\"Failed to encode %s: %%v\", err))", resourceLower)
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("stream.Close(map[string]interface{}{")
+	g.indent++
+	g.writeLine("\"query\": q.Text,")
+	g.writeLine("\"total\": result.Total,")
+	g.writeLine("\"limit\": q.Limit,")
+	g.writeLine("\"offset\": q.Offset,")
+	g.indent--
+	g.writeLine("}, nil)")
+
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+}
+
+// writeSearchError writes an error response in the negotiated format
+func (g *Generator) writeSearchError(status, err string) {
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, %s, %s)", status, err)
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+	g.writeLine("respondWithError(w, %s.Error(), %s)", err, status)
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateSearchBackend configures the search backend from the environment
+func (g *Generator) generateSearchBackend() {
+	g.writeLine("// Index @search_index resources into Elasticsearch or Meilisearch (CONDUIT_SEARCH_URL)")
+	g.writeLine("searchBackend, err := search.BackendFromEnv()")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(%q, err)", "Failed to configure search: %v")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("search.SetBackend(searchBackend)")
+	g.writeLine("")
+}
@@ -0,0 +1,104 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const searchSource = `resource Post {
+  @search_index(title, body)
+
+  id: uuid! @primary @auto
+  title: string!
+  body: text!
+}`
+
+func TestGenerateResource_SearchIndex(t *testing.T) {
+	code := generateModel(t, parseResource(t, searchSource))
+
+	if !strings.Contains(code, `"github.com/conduit-lang/conduit/pkg/web/search"`) {
+		t.Error("Missing search import")
+	}
+	document := functionBody(t, code, "func (p *Post) SearchDocument() search.Document {")
+	for _, want := range []string{`"title": p.Title,`, `"body": p.Body,`} {
+		if !strings.Contains(document, want) {
+			t.Errorf("SearchDocument missing %q:\n%s", want, document)
+		}
+	}
+
+	// Records are indexed only once the write has committed
+	for _, fn := range []struct {
+		name string
+		want string
+	}{
+		{"Create", `search.Index("posts", fmt.Sprint(p.ID), p.SearchDocument())`},
+		{"Update", `search.Index("posts", fmt.Sprint(p.ID), p.SearchDocument())`},
+		{"Patch", `search.Index("posts", fmt.Sprint(p.ID), p.SearchDocument())`},
+		{"Delete", `search.Delete("posts", fmt.Sprint(p.ID))`},
+	} {
+		body := functionBody(t, code, "func (p *Post) "+fn.name+"(")
+		i := strings.Index(body, fn.want)
+		if i < 0 {
+			t.Errorf("%s missing %q:\n%s", fn.name, fn.want, body)
+			continue
+		}
+		if commit := strings.Index(body, "tx.Commit()"); commit < 0 || commit > i {
+			t.Errorf("%s should index after the commit:\n%s", fn.name, body)
+		}
+	}
+}
+
+func TestGenerateResource_NoSearchIndex(t *testing.T) {
+	resource := parseResource(t, searchSource)
+	resource.SearchIndex = nil
+
+	code := generateModel(t, resource)
+	if strings.Contains(code, "search.") {
+		t.Errorf("Resource without @search_index should not reference search:\n%s", code)
+	}
+}
+
+func TestGenerateHandlers_SearchIndex(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, searchSource))
+
+	assertContains(t, code,
+		`r.Get("/posts/search", SearchPostHandler(db))`,
+		"func SearchPostHandler(db *sql.DB) http.HandlerFunc {",
+		"q, err := search.ParseRequest(r)",
+		`q.Fields = []string{"title", "body"}`,
+		`result, err := search.Search(ctx, "posts", q)`,
+		"errors.Is(err, search.ErrDisabled)",
+		"SELECT * FROM posts WHERE id::text = ANY($1)",
+		"for _, id := range result.IDs {",
+		`"total": result.Total,`,
+	)
+
+	// The search route is registered before /posts/{id} so chi matches it first
+	if strings.Index(code, `"/posts/search"`) > strings.Index(code, `"/posts/{id}"`) {
+		t.Error("Search route should be registered before the item routes")
+	}
+}
+
+func TestGenerateMain_SearchIndex(t *testing.T) {
+	code, err := NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, searchSource)}, "example.com/blog", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	assertContains(t, code,
+		`"github.com/conduit-lang/conduit/pkg/web/search"`,
+		"searchBackend, err := search.BackendFromEnv()",
+		"search.SetBackend(searchBackend)",
+	)
+
+	resource := parseResource(t, searchSource)
+	resource.SearchIndex = nil
+	code, err = NewGenerator().GenerateMain([]*ast.ResourceNode{resource}, "example.com/blog", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "search.") {
+		t.Error("Main should not configure search without @search_index resources")
+	}
+}
@@ -0,0 +1,82 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/pkg/web/query"
+)
+
+// SerializationOptions sets the JSON conventions of the generated API, from
+// serialization in conduit.yaml
+type SerializationOptions struct {
+	// Casing of JSON keys, query parameters and profile fields; field names
+	// as declared when empty
+	Casing query.Casing
+	// Envelope wraps plain JSON records and lists in {"data": ...}
+	Envelope bool
+	// IncludeNulls writes null fields instead of omitting them
+	IncludeNulls bool
+}
+
+// SetSerialization configures the JSON conventions of generated models and
+// handlers
+func (g *Generator) SetSerialization(opts SerializationOptions) {
+	g.serialization = opts
+}
+
+// jsonName returns the JSON key of a field
+func (g *Generator) jsonName(name string) string {
+	return g.serialization.Casing.Apply(name)
+}
+
+// jsonNames returns the JSON keys of fields
+func (g *Generator) jsonNames(names []string) []string {
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = g.jsonName(name)
+	}
+	return keys
+}
+
+// jsonapiName returns the JSON:API attribute or relationship name of a field:
+// its JSON key when a casing is set, and its snake_case form otherwise
+func (g *Generator) jsonapiName(name string) string {
+	if g.serialization.Casing == query.Declared {
+		return g.toDBColumnName(name)
+	}
+	return g.jsonName(name)
+}
+
+// encodedRecord returns the expression a handler encodes as legacy JSON for
+// record: the record masked to the visible fields of @profile resources, with
+// enum labels on ?include_labels=true, in an envelope when
+// serialization.envelope is set
+func (g *Generator) encodedRecord(resource *ast.ResourceNode, record string) string {
+	return g.encodedBody(g.labeledRecord(resource, maskedRecord(resource, record)))
+}
+
+// encodedBody returns body in an envelope when serialization.envelope is set
+func (g *Generator) encodedBody(body string) string {
+	if g.serialization.Envelope {
+		return fmt.Sprintf("response.Enveloped(%s)", body)
+	}
+	return body
+}
+
+// generateListStream declares stream, the response.ListStream of a list
+// handler, masked to the visible fields of @profile resources, with enum
+// labels on ?include_labels=true and wrapped in an envelope when
+// serialization.envelope is set
+func (g *Generator) generateListStream(resource *ast.ResourceNode, fieldsets string) {
+	g.writeLine("stream := response.NewListStream(w, r, %s)", fieldsets)
+	if len(resource.Profiles) > 0 {
+		g.writeLine("stream.Mask(visible)")
+	}
+	if hasLabels(resource) {
+		g.writeLine("stream.Label(%s.For(r))", g.resourceVarName(resource, "Labels"))
+	}
+	if g.serialization.Envelope {
+		g.writeLine("stream.Wrap()")
+	}
+}
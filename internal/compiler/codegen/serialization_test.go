@@ -0,0 +1,75 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/pkg/web/query"
+)
+
+const serializationSource = `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+  author_name: string? @filterable
+  created_at: timestamp! @auto
+  updated_at: timestamp! @auto_update
+}`
+
+func serializationTestGenerator() *Generator {
+	g := NewGenerator()
+	g.SetSerialization(SerializationOptions{Casing: query.CamelCase, Envelope: true, IncludeNulls: true})
+	return g
+}
+
+func TestGenerateResource_Serialization(t *testing.T) {
+	code, err := serializationTestGenerator().GenerateResource(parseResource(t, serializationSource))
+	if err != nil {
+		t.Fatalf("GenerateResource failed: %v", err)
+	}
+
+	assertContains(t, code,
+		`json:"authorName"`,
+		`jsonapi:"attr,createdAt"`,
+	)
+	if strings.Contains(code, `json:"authorName,omitempty"`) {
+		t.Error("Nullable fields should be written as null when serialization.nulls is include")
+	}
+
+	patch := functionBody(t, code, "func (p *Post) Patch(")
+	for _, want := range []string{`"createdAt": true`, `"authorName": true`} {
+		if !strings.Contains(patch, want) {
+			t.Errorf("Patch should accept camelCase keys, missing %s", want)
+		}
+	}
+}
+
+func TestGenerateResource_SerializationDefaults(t *testing.T) {
+	code := generateModel(t, parseResource(t, serializationSource))
+	if !strings.Contains(code, `json:"author_name,omitempty"`) {
+		t.Error("Without serialization settings nullable fields should keep their declared name and omitempty")
+	}
+}
+
+func TestGenerateHandlers_Serialization(t *testing.T) {
+	code, err := serializationTestGenerator().GenerateHandlers([]*ast.ResourceNode{parseResource(t, serializationSource)}, "example.com/blog")
+	if err != nil {
+		t.Fatalf("GenerateHandlers failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, code)
+	}
+
+	assertContains(t, code,
+		`"authorName": "author_name"`,
+		`}).WithCasing("camelCase")`,
+		`var postFilterable = []string{"authorName"}`,
+		`Encode(response.Enveloped(`,
+	)
+
+	list := functionBody(t, code, "func ListPostHandler(")
+	if !strings.Contains(list, "\t\tstream.Wrap()\n") {
+		t.Error("List handler should always wrap the stream in an envelope")
+	}
+}
@@ -0,0 +1,91 @@
+package codegen
+
+import (
+	"time"
+
+	"github.com/conduit-lang/conduit/pkg/web/serverless"
+)
+
+// ServerOptions sets the HTTP server timeouts generated for conduit.yaml's
+// server section. Zero keeps the pkg/web/server default; SERVER_*_TIMEOUT
+// environment variables override either at runtime.
+type ServerOptions struct {
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// SetServer configures the server timeouts generated by GenerateProgram
+func (g *Generator) SetServer(opts ServerOptions) {
+	g.server = opts
+}
+
+// serverTimeouts returns the configured timeouts by server.Config field,
+// leaving out those kept at their defaults
+func (g *Generator) serverTimeouts() [][2]string {
+	var timeouts [][2]string
+	for _, timeout := range []struct {
+		field string
+		value time.Duration
+	}{
+		{"ReadTimeout", g.server.ReadTimeout},
+		{"WriteTimeout", g.server.WriteTimeout},
+		{"IdleTimeout", g.server.IdleTimeout},
+		{"ShutdownTimeout", g.server.ShutdownTimeout},
+	} {
+		if timeout.value > 0 {
+			timeouts = append(timeouts, [2]string{timeout.field, durationLiteral(timeout.value)})
+		}
+	}
+	return timeouts
+}
+
+// generateServe serves r on addr until SIGINT or SIGTERM, then drains
+// in-flight requests and closes the database pool before exiting
+func (g *Generator) generateServe() {
+	g.writeLine("// Timeouts from conduit.yaml, overridden by SERVER_READ_TIMEOUT, SERVER_WRITE_TIMEOUT,")
+	g.writeLine("// SERVER_IDLE_TIMEOUT and SERVER_SHUTDOWN_TIMEOUT")
+	g.writeLine("serverConfig := server.DefaultConfig()")
+	for _, timeout := range g.serverTimeouts() {
+		g.writeLine("serverConfig.%s = %s", timeout[0], timeout[1])
+	}
+	if g.target == serverless.TargetCloudRun && g.server.ShutdownTimeout == 0 {
+		g.writeLine("// Cloud Run stops the instance 10s after SIGTERM")
+		g.writeLine("serverConfig.ShutdownTimeout = serverless.CloudRunShutdownTimeout")
+	}
+	g.writeLine("serverConfig = server.ConfigFromEnv(serverConfig)")
+	g.writeLine("srv := server.New(addr, r, serverConfig)")
+	g.writeLine("")
+
+	serve := "srv.ListenAndServe"
+	if g.introspectionMTLS() {
+		g.generateTLSConfig()
+		serve = "func() error { return srv.ListenAndServeTLS(os.Getenv(introspect.CertEnvVar), os.Getenv(introspect.KeyEnvVar)) }"
+	}
+
+	g.writeLine("// Shut down gracefully on SIGINT or SIGTERM, draining in-flight requests")
+	g.writeLine("ctx, stop := server.SignalContext(context.Background())")
+	g.writeLine("defer stop()")
+	g.writeLine("if err := server.Run(ctx, srv, serverConfig, %s); err != nil {", serve)
+	g.indent++
+	g.writeLine("db.Close()")
+	if g.errorTracking.Enabled {
+		g.writeLine("errortrack.Flush(errortrack.FlushTimeout)")
+	}
+	if g.profiling.Agent != "" {
+		g.writeLine("profiling.Stop(profiling.StopTimeout)")
+	}
+	g.writeLine("log.Fatalf(\"Server failed: %v\", err)")
+	g.indent--
+	g.writeLine("}")
+	if g.errorTracking.Enabled {
+		g.writeLine("// Send the events captured while draining")
+		g.writeLine("errortrack.Flush(errortrack.FlushTimeout)")
+	}
+	if g.profiling.Agent != "" {
+		g.writeLine("// Send the profile of the last interval")
+		g.writeLine("profiling.Stop(profiling.StopTimeout)")
+	}
+	g.writeLine("log.Println(\"Server stopped\")")
+}
@@ -0,0 +1,74 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+func TestGenerateMain_GracefulShutdown(t *testing.T) {
+	code, err := NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, authSource)}, "example.com/app", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated main does not parse: %v\n%s", err, code)
+	}
+
+	for _, want := range []string{
+		`"github.com/conduit-lang/conduit/pkg/web/server"`,
+		"serverConfig := server.DefaultConfig()",
+		"serverConfig = server.ConfigFromEnv(serverConfig)",
+		"ctx, stop := server.SignalContext(context.Background())",
+		"if err := server.Run(ctx, srv, serverConfig, srv.ListenAndServe); err != nil {",
+		`log.Println("Server stopped")`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Generated main missing %q\n%s", want, code)
+		}
+	}
+	if strings.Contains(code, "http.ListenAndServe") {
+		t.Error("Generated main should not serve without timeouts")
+	}
+	if strings.Contains(code, `"time"`) {
+		t.Error("Default timeouts should not need the time package")
+	}
+
+	// The pool is closed on the way out, whether or not serving failed
+	failed := code[strings.Index(code, "server.Run("):]
+	if !strings.Contains(failed, "db.Close()\n\t\tlog.Fatalf(\"Server failed: %v\", err)") {
+		t.Errorf("Generated main should close the database before exiting on failure\n%s", failed)
+	}
+}
+
+func TestGenerateMain_ServerTimeouts(t *testing.T) {
+	g := NewGenerator()
+	g.SetServer(ServerOptions{ReadTimeout: 10 * time.Second, IdleTimeout: 2 * time.Minute, ShutdownTimeout: 1500 * time.Millisecond})
+	code, err := g.GenerateMain([]*ast.ResourceNode{parseResource(t, authSource)}, "example.com/app", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated main does not parse: %v\n%s", err, code)
+	}
+
+	for _, want := range []string{
+		`"time"`,
+		"serverConfig.ReadTimeout = 10*time.Second",
+		"serverConfig.IdleTimeout = 120*time.Second",
+		"serverConfig.ShutdownTimeout = 1500*time.Millisecond",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Generated main missing %q", want)
+		}
+	}
+	if strings.Contains(code, "serverConfig.WriteTimeout") {
+		t.Error("Unset timeouts should keep the runtime default")
+	}
+	if strings.Index(code, "serverConfig.ShutdownTimeout") > strings.Index(code, "server.ConfigFromEnv") {
+		t.Error("The environment should override conduit.yaml")
+	}
+}
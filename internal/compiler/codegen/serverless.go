@@ -0,0 +1,60 @@
+package codegen
+
+import "github.com/conduit-lang/conduit/pkg/web/serverless"
+
+// SetTarget sets where the generated application runs, from conduit.yaml's
+// build.target: server (the default when empty), lambda or cloudrun
+func (g *Generator) SetTarget(target string) {
+	g.target = target
+}
+
+// serverlessTarget reports whether the application runs on a serverless
+// platform, which connects to the database lazily with a small pool
+func (g *Generator) serverlessTarget() bool {
+	return g.target == serverless.TargetLambda || g.target == serverless.TargetCloudRun
+}
+
+// lambdaTarget reports whether main serves Lambda invocations instead of
+// listening on PORT
+func (g *Generator) lambdaTarget() bool {
+	return g.target == serverless.TargetLambda
+}
+
+// generateStartLambda serves r as a Lambda custom runtime. Lambda freezes the
+// instance between invocations, so buffered error reports are sent after each
+// response rather than on shutdown.
+func (g *Generator) generateStartLambda(apiPrefix string) {
+	g.writeLine("// Start the Lambda runtime, serving API Gateway and function URL events")
+	if apiPrefix != "" {
+		g.writeLine("log.Println(\"Lambda runtime starting with API prefix: %s\")", apiPrefix)
+	} else {
+		g.writeLine("log.Println(\"Lambda runtime starting\")")
+	}
+	options := "serverless.LambdaOptions{}"
+	if g.errorTracking.Enabled {
+		options = "serverless.LambdaOptions{AfterInvoke: func() { errortrack.Flush(errortrack.FlushTimeout) }}"
+	}
+	g.writeLine("if err := serverless.StartLambda(r, %s); err != nil {", options)
+	g.indent++
+	g.writeLine("db.Close()")
+	g.writeLine("log.Fatalf(\"Lambda runtime failed: %v\", err)")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateServerlessPool sizes the pool of initDB for the target. The pool
+// connects on the first query, so cold starts do not wait for the database.
+func (g *Generator) generateServerlessPool() {
+	constant := "serverless.TargetLambda"
+	if g.target == serverless.TargetCloudRun {
+		constant = "serverless.TargetCloudRun"
+	}
+	g.writeLine("// Size the pool for %s; DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS,", g.target)
+	g.writeLine("// DB_CONN_MAX_IDLE_TIME and DB_CONN_MAX_LIFETIME override it. The first")
+	g.writeLine("// query connects, so cold starts do not wait for the database.")
+	g.writeLine("serverless.PoolFromEnv(serverless.DefaultPool(%s)).Apply(db)", constant)
+	g.writeLine("")
+
+	g.writeLine("log.Println(\"Database pool ready; connecting on first query\")")
+	g.writeLine("return db, nil")
+}
@@ -0,0 +1,76 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+func TestGenerateMain_LambdaTarget(t *testing.T) {
+	g := NewGenerator()
+	g.SetTarget("lambda")
+	g.SetErrorTracking(ErrorTrackingOptions{Enabled: true})
+	code, err := g.GenerateMain([]*ast.ResourceNode{parseResource(t, authSource)}, "example.com/app", "/api")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated main does not parse: %v\n%s", err, code)
+	}
+
+	for _, want := range []string{
+		`"github.com/conduit-lang/conduit/pkg/web/serverless"`,
+		`log.Println("Lambda runtime starting with API prefix: /api")`,
+		"serverless.StartLambda(r, serverless.LambdaOptions{AfterInvoke: func() { errortrack.Flush(errortrack.FlushTimeout) }})",
+		"serverless.PoolFromEnv(serverless.DefaultPool(serverless.TargetLambda)).Apply(db)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Generated main missing %q\n%s", want, code)
+		}
+	}
+	// Lambda invokes the runtime; nothing listens on PORT
+	for _, unwanted := range []string{`"github.com/conduit-lang/conduit/pkg/web/server"`, `"context"`, `os.Getenv("PORT")`, "db.Ping()", "db.SetMaxOpenConns(25)"} {
+		if strings.Contains(code, unwanted) {
+			t.Errorf("Lambda main should not contain %q", unwanted)
+		}
+	}
+}
+
+func TestGenerateMain_CloudRunTarget(t *testing.T) {
+	g := NewGenerator()
+	g.SetTarget("cloudrun")
+	code, err := g.GenerateMain([]*ast.ResourceNode{parseResource(t, authSource)}, "example.com/app", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated main does not parse: %v\n%s", err, code)
+	}
+
+	for _, want := range []string{
+		`os.Getenv("PORT")`,
+		"serverConfig.ShutdownTimeout = serverless.CloudRunShutdownTimeout",
+		"if err := server.Run(ctx, srv, serverConfig, srv.ListenAndServe); err != nil {",
+		"serverless.PoolFromEnv(serverless.DefaultPool(serverless.TargetCloudRun)).Apply(db)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Generated main missing %q\n%s", want, code)
+		}
+	}
+	if strings.Contains(code, "db.Ping()") {
+		t.Error("Cloud Run main should connect on the first query")
+	}
+
+	// A configured shutdown timeout is kept
+	g.SetServer(ServerOptions{ShutdownTimeout: 5 * time.Second})
+	code, err = g.GenerateMain([]*ast.ResourceNode{parseResource(t, authSource)}, "example.com/app", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "serverless.CloudRunShutdownTimeout") {
+		t.Error("Cloud Run main should keep server.shutdown_timeout")
+	}
+}
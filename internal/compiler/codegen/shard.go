@@ -0,0 +1,68 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// hasShard reports whether any resource declares @shard
+func hasShard(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if resource.Shard != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// routeHandler returns the handler registered for a route, given the name of
+// its constructor such as ListPostHandler. Routes of @shard resources build
+// the handler for the connection of each request's shard.
+func (g *Generator) routeHandler(resource *ast.ResourceNode, constructor string) string {
+	if resource.Shard == nil {
+		return constructor + "(db)"
+	}
+	return fmt.Sprintf("shard.Route(db, %q, %s)", resource.Shard.Field, constructor)
+}
+
+// generateShardCheck refuses to write a record of a @shard resource whose
+// shard key differs from the one its request was routed by, which would store
+// it on a shard that does not hold it. zero precedes err in the return
+// statement of methods with more than one result.
+func (g *Generator) generateShardCheck(resource *ast.ResourceNode, receiverName, zero string) {
+	if resource.Shard == nil {
+		return
+	}
+
+	g.writeLine("// Keep the record on the shard its request was routed to (@shard)")
+	g.writeLine("if err := shard.CheckKey(ctx, fmt.Sprint(%s.%s)); err != nil {", receiverName, g.toGoFieldName(resource.Shard.Field))
+	g.indent++
+	g.writeLine("return %serr", zero)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
+
+// generateShardProvider opens the shard map named by CONDUIT_SHARD_MAP, so
+// routes of @shard resources query the database holding each request's key
+func (g *Generator) generateShardProvider() {
+	g.writeLine("// Route @shard resources to the databases of the shard map (CONDUIT_SHARD_MAP)")
+	g.writeLine("shards, err := shard.ProviderFromEnv(func(url string) (*sql.DB, error) {")
+	g.indent++
+	g.writeLine("return instrument.Open(\"pgx\", url, instrument.ConfigFromEnv())")
+	g.indent--
+	g.writeLine("})")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("log.Fatalf(%q, err)", "Failed to open shard map: %v")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if shards != nil {")
+	g.indent++
+	g.writeLine("defer shards.Close()")
+	g.writeLine("shard.SetProvider(shards)")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
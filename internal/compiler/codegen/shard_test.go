@@ -0,0 +1,80 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const shardSource = `resource Invoice {
+  @shard(by: tenant_id)
+
+  tenant_id: string!
+  total: int!
+}`
+
+func TestGenerateResource_Shard(t *testing.T) {
+	resource := parseResource(t, shardSource)
+	resource.Upsert = &ast.UpsertNode{Fields: []string{"total"}}
+	code := generateModel(t, resource)
+
+	if !strings.Contains(code, `"github.com/conduit-lang/conduit/pkg/web/shard"`) {
+		t.Error("Generated model should import the shard package")
+	}
+	// Create, Update, Patch and Upsert all check the shard key
+	check := "if err := shard.CheckKey(ctx, fmt.Sprint(i.TenantID)); err != nil {\n\t\treturn err\n\t}"
+	if got := strings.Count(code, check); got != 3 {
+		t.Errorf("Generated model checks the shard key %d times, want 3:\n%s", got, code)
+	}
+	if !strings.Contains(code, "if err := shard.CheckKey(ctx, fmt.Sprint(i.TenantID)); err != nil {\n\t\treturn false, err\n\t}") {
+		t.Error("Upsert should check the shard key")
+	}
+
+	code = generateModel(t, parseResource(t, searchSource))
+	if strings.Contains(code, "shard.") {
+		t.Error("resources without @shard should not check shard keys")
+	}
+}
+
+func TestGenerateHandlers_Shard(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, shardSource), parseResource(t, searchSource))
+
+	for _, exp := range []string{
+		`"github.com/conduit-lang/conduit/pkg/web/shard"`,
+		`r.Get("/invoices", shard.Route(db, "tenant_id", ListInvoiceHandler))`,
+		`r.Post("/invoices/batch", shard.Route(db, "tenant_id", CreateInvoiceBatchHandler))`,
+		`r.Delete("/invoices/{id}", shard.Route(db, "tenant_id", DeleteInvoiceHandler))`,
+		`r.Get("/posts", ListPostHandler(db))`,
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated handlers missing %q", exp)
+		}
+	}
+}
+
+func TestGenerateMain_Shard(t *testing.T) {
+	code, err := NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, shardSource)}, "example.com/billing", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	for _, exp := range []string{
+		`"github.com/conduit-lang/conduit/pkg/web/shard"`,
+		"shards, err := shard.ProviderFromEnv(func(url string) (*sql.DB, error) {",
+		`return instrument.Open("pgx", url, instrument.ConfigFromEnv())`,
+		"shard.SetProvider(shards)",
+		"r.Get(shard.TopologyPath, shard.TopologyHandler())",
+	} {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated main missing %q", exp)
+		}
+	}
+
+	code, err = NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, postSource)}, "example.com/blog", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(code, "shard.") {
+		t.Error("Generated main should not open a shard map without @shard resources")
+	}
+}
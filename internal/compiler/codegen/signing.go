@@ -0,0 +1,41 @@
+package codegen
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// signedRequestSecrets returns the environment variables holding the secrets
+// of signed_request middleware, sorted and without repeats
+func signedRequestSecrets(resources []*ast.ResourceNode) []string {
+	seen := make(map[string]bool)
+	var refs []string
+	for _, resource := range resources {
+		if ref := resource.SignedRequestSecret(); ref != "" && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// generateSigningConfig checks the signed request secrets at startup and opens
+// the store replayed requests are detected with
+func (g *Generator) generateSigningConfig(resources []*ast.ResourceNode) {
+	refs := signedRequestSecrets(resources)
+	quoted := make([]string, len(refs))
+	for i, ref := range refs {
+		quoted[i] = `"` + ref + `"`
+	}
+
+	g.writeLine("// Verify signed requests (replays are detected across instances when CONDUIT_REDIS_ADDR is set)")
+	g.writeLine("if err := signing.Configure(%s); err != nil {", strings.Join(quoted, ", "))
+	g.indent++
+	g.writeLine("log.Fatalf(%q, err)", "Failed to configure signed requests: %v")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
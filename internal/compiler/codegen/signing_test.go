@@ -0,0 +1,83 @@
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const signedSource = `resource PartnerEvent {
+  @middleware [auth, signed_request(PARTNER_SECRET)]
+
+  id: uuid! @primary @auto
+  kind: string!
+}`
+
+func TestGenerateHandlers_SignedRequest(t *testing.T) {
+	code := generateHandlers(t, parseResources(t, signedSource+`
+
+resource Note {
+  id: uuid! @primary @auto
+  kind: string!
+}`)...)
+
+	if !strings.Contains(code, `"github.com/conduit-lang/conduit/pkg/web/signing"`) {
+		t.Error("Missing signing import")
+	}
+
+	register := functionBody(t, code, "func RegisterPartnerEventRoutes(r chi.Router, db *sql.DB) {")
+	assertContains(t, register,
+		"r.Group(func(r chi.Router) {",
+		`r.Use(signing.Middleware("PARTNER_SECRET"))`,
+		`r.Post("/partnerevents", CreatePartnerEventHandler(db))`,
+	)
+	if strings.Index(register, "signing.Middleware") > strings.Index(register, "r.Get(") {
+		t.Error("Signatures should be checked on every route of the resource")
+	}
+
+	if strings.Contains(functionBody(t, code, "func RegisterNoteRoutes(r chi.Router, db *sql.DB) {"), "signing.") {
+		t.Error("Resources without signed_request should not check signatures")
+	}
+}
+
+func TestGenerateMain_SignedRequest(t *testing.T) {
+	resources := parseResources(t, signedSource+`
+
+resource PartnerRefund {
+  @middleware [auth, signed_request(PARTNER_SECRET)]
+
+  id: uuid! @primary @auto
+  kind: string!
+}
+
+resource BankEvent {
+  @middleware [auth, signed_request(BANK_SECRET)]
+
+  id: uuid! @primary @auto
+  kind: string!
+}`)
+	code, err := NewGenerator().GenerateMain(resources, "example.com/shop", "/api")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("Generated main does not parse: %v\n%s", err, code)
+	}
+
+	if !strings.Contains(code, `if err := signing.Configure("BANK_SECRET", "PARTNER_SECRET"); err != nil {`) {
+		t.Errorf("Main should check each secret once at startup:\n%s", code)
+	}
+	if strings.Index(code, "signing.Configure") > strings.Index(code, "handlers.RegisterPartnerEventRoutes") {
+		t.Error("Signed requests should be configured before routes are registered")
+	}
+
+	plain, err := NewGenerator().GenerateMain([]*ast.ResourceNode{parseResource(t, webhookSource)}, "example.com/shop", "")
+	if err != nil {
+		t.Fatalf("GenerateMain failed: %v", err)
+	}
+	if strings.Contains(plain, "signing.") {
+		t.Error("Main should not configure signed requests without signed_request middleware")
+	}
+}
@@ -0,0 +1,164 @@
+package codegen
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/pkg/web/metrics"
+)
+
+// SLORulesFile is the generated Prometheus rules file for @slo annotations
+const SLORulesFile = "monitoring/slo-rules.yml"
+
+// burnRateThreshold is the error budget burn rate that pages: at 14.4x a
+// 30-day budget is exhausted in about two days
+const burnRateThreshold = 14.4
+
+// GenerateSLORules generates Prometheus recording and alerting rules for every
+// resource with an @slo annotation. The rules select the resource's routes in
+// the route-labeled metrics served at /metrics. It returns "" when no resource
+// declares an SLO.
+func (g *Generator) GenerateSLORules(resources []*ast.ResourceNode, apiPrefix string) string {
+	hasSLO := false
+	for _, resource := range resources {
+		if resource.SLO != nil {
+			hasSLO = true
+		}
+	}
+	if !hasSLO {
+		return ""
+	}
+
+	w := &rulesWriter{}
+	w.line("# Prometheus rules generated from @slo annotations - DO NOT EDIT")
+	w.line("# Load with rule_files in prometheus.yml; metrics are served at /metrics")
+	w.line("groups:")
+	w.indent++
+	for _, resource := range resources {
+		if resource.SLO != nil {
+			g.generateSLOGroup(w, resource, apiPrefix)
+		}
+	}
+	w.indent--
+
+	return w.buf.String()
+}
+
+// rulesWriter writes YAML, which must be indented with spaces rather than the
+// tabs writeLine uses for Go code
+type rulesWriter struct {
+	buf    strings.Builder
+	indent int
+}
+
+func (w *rulesWriter) line(format string, args ...interface{}) {
+	w.buf.WriteString(strings.Repeat("  ", w.indent))
+	fmt.Fprintf(&w.buf, format, args...)
+	w.buf.WriteString("\n")
+}
+
+// generateSLOGroup generates the rule group for one resource
+func (g *Generator) generateSLOGroup(w *rulesWriter, resource *ast.ResourceNode, apiPrefix string) {
+	slo := resource.SLO
+	routes := g.sloRouteMatcher(resource, apiPrefix)
+
+	w.line("- name: conduit_slo_%s", g.toSnakeCase(resource.Name))
+	w.indent++
+	w.line("rules:")
+	w.indent++
+
+	for _, objective := range slo.Latency {
+		record := fmt.Sprintf("conduit:request_latency_seconds:p%d_5m", objective.Percentile)
+		writeRecordingRule(w, resource, record, []string{
+			fmt.Sprintf("histogram_quantile(%s, sum by (le) (rate(%s_bucket{route=~\"%s\"}[5m])))",
+				formatRuleNumber(float64(objective.Percentile)/100), metrics.RequestDuration, routes),
+		})
+		writeAlertingRule(w, resource,
+			fmt.Sprintf("%sLatencyP%dSLO", resource.Name, objective.Percentile),
+			fmt.Sprintf("%s{resource=\"%s\"} > %s", record, resource.Name, formatRuleNumber(objective.Threshold.Seconds())),
+			"10m", "warning",
+			fmt.Sprintf("%s p%d latency is above its %s SLO", resource.Name, objective.Percentile, objective.Threshold))
+	}
+
+	if slo.Availability > 0 {
+		for _, window := range []string{"5m", "1h"} {
+			writeRecordingRule(w, resource, "conduit:request_error_ratio:rate"+window, []string{
+				fmt.Sprintf("sum(rate(%s{route=~\"%s\",code=~\"5..\"}[%s]))", metrics.RequestsTotal, routes, window),
+				"/",
+				fmt.Sprintf("sum(rate(%s{route=~\"%s\"}[%s]))", metrics.RequestsTotal, routes, window),
+			})
+		}
+
+		threshold := formatRuleNumber(burnRateThreshold * (100 - slo.Availability) / 100)
+		writeAlertingRule(w, resource,
+			fmt.Sprintf("%sErrorBudgetBurn", resource.Name),
+			fmt.Sprintf("conduit:request_error_ratio:rate1h{resource=\"%s\"} > %s and conduit:request_error_ratio:rate5m{resource=\"%s\"} > %s",
+				resource.Name, threshold, resource.Name, threshold),
+			"2m", "critical",
+			fmt.Sprintf("%s is burning its %s%% availability error budget %sx faster than allowed",
+				resource.Name, formatRuleNumber(slo.Availability), formatRuleNumber(burnRateThreshold)))
+	}
+
+	w.indent--
+	w.indent--
+}
+
+// writeRecordingRule writes a recording rule labeled with the resource name
+func writeRecordingRule(w *rulesWriter, resource *ast.ResourceNode, record string, expr []string) {
+	w.line("- record: %s", record)
+	w.indent++
+	w.line("expr: |")
+	w.indent++
+	for _, line := range expr {
+		w.line("%s", line)
+	}
+	w.indent--
+	w.line("labels:")
+	w.indent++
+	w.line("resource: %s", resource.Name)
+	w.indent--
+	w.indent--
+}
+
+// writeAlertingRule writes an alerting rule labeled with severity and
+// resource, and with the owning team when the resource has one so
+// Alertmanager can route the alert to it
+func writeAlertingRule(w *rulesWriter, resource *ast.ResourceNode, name, expr, duration, severity, summary string) {
+	w.line("- alert: %s", name)
+	w.indent++
+	w.line("expr: %s", expr)
+	w.line("for: %s", duration)
+	w.line("labels:")
+	w.indent++
+	w.line("severity: %s", severity)
+	w.line("resource: %s", resource.Name)
+	if resource.Owner != nil {
+		w.line("owner: %s", strconv.Quote(resource.Owner.Team))
+	}
+	w.indent--
+	w.line("annotations:")
+	w.indent++
+	w.line("summary: %s", strconv.Quote(summary))
+	w.indent--
+	w.indent--
+}
+
+// sloRouteMatcher returns a PromQL regex matching the resource's route
+// patterns, escaped for use inside a double-quoted PromQL string
+func (g *Generator) sloRouteMatcher(resource *ast.ResourceNode, apiPrefix string) string {
+	patterns := []string{
+		regexp.QuoteMeta(apiPrefix + "/" + g.toTableName(resource.Name)),
+		regexp.QuoteMeta(apiPrefix + g.memberPath(resource)),
+	}
+	return strings.ReplaceAll(strings.Join(patterns, "|"), `\`, `\\`)
+}
+
+// formatRuleNumber formats a float for a rules file without binary rounding
+// noise, e.g. 0.0144 rather than 0.014400000000000081
+func formatRuleNumber(value float64) string {
+	return strconv.FormatFloat(math.Round(value*1e9)/1e9, 'f', -1, 64)
+}
@@ -0,0 +1,117 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"gopkg.in/yaml.v3"
+)
+
+const sloSource = `resource BlogPost {
+  @slo(latency_p99: 300ms, availability: 99.9)
+}
+
+resource Comment {
+}`
+
+func TestGenerateSLORules(t *testing.T) {
+	rules := NewGenerator().GenerateSLORules(parseResources(t, sloSource), "/api/v1")
+
+	var parsed struct {
+		Groups []struct {
+			Name  string `yaml:"name"`
+			Rules []struct {
+				Record string            `yaml:"record"`
+				Alert  string            `yaml:"alert"`
+				Expr   string            `yaml:"expr"`
+				For    string            `yaml:"for"`
+				Labels map[string]string `yaml:"labels"`
+			} `yaml:"rules"`
+		} `yaml:"groups"`
+	}
+	if err := yaml.Unmarshal([]byte(rules), &parsed); err != nil {
+		t.Fatalf("generated rules are not valid YAML: %v\n%s", err, rules)
+	}
+
+	if len(parsed.Groups) != 1 || parsed.Groups[0].Name != "conduit_slo_blog_post" {
+		t.Fatalf("expected one group for BlogPost, got %+v", parsed.Groups)
+	}
+
+	var names []string
+	for _, rule := range parsed.Groups[0].Rules {
+		names = append(names, rule.Record+rule.Alert)
+		if rule.Labels["resource"] != "BlogPost" {
+			t.Errorf("rule %s%s should be labeled with the resource", rule.Record, rule.Alert)
+		}
+	}
+	want := []string{
+		"conduit:request_latency_seconds:p99_5m",
+		"BlogPostLatencyP99SLO",
+		"conduit:request_error_ratio:rate5m",
+		"conduit:request_error_ratio:rate1h",
+		"BlogPostErrorBudgetBurn",
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("rules = %v, want %v", names, want)
+	}
+
+	for _, snippet := range []string{
+		`histogram_quantile(0.99, sum by (le) (rate(conduit_http_request_duration_seconds_bucket{route=~"/api/v1/blogposts|/api/v1/blogposts/\\{id\\}"}[5m])))`,
+		`conduit:request_latency_seconds:p99_5m{resource="BlogPost"} > 0.3`,
+		`sum(rate(conduit_http_requests_total{route=~"/api/v1/blogposts|/api/v1/blogposts/\\{id\\}",code=~"5.."}[1h]))`,
+		`conduit:request_error_ratio:rate1h{resource="BlogPost"} > 0.0144 and conduit:request_error_ratio:rate5m{resource="BlogPost"} > 0.0144`,
+		`summary: "BlogPost p99 latency is above its 300ms SLO"`,
+	} {
+		if !strings.Contains(rules, snippet) {
+			t.Errorf("rules missing %q:\n%s", snippet, rules)
+		}
+	}
+}
+
+func TestGenerateSLORules_OwnerLabel(t *testing.T) {
+	resources := parseResources(t, sloSource)
+	resources[0].Owner = &ast.OwnerNode{Team: "content-team"}
+	rules := NewGenerator().GenerateSLORules(resources, "")
+
+	var parsed struct {
+		Groups []struct {
+			Rules []struct {
+				Record string            `yaml:"record"`
+				Alert  string            `yaml:"alert"`
+				Labels map[string]string `yaml:"labels"`
+			} `yaml:"rules"`
+		} `yaml:"groups"`
+	}
+	if err := yaml.Unmarshal([]byte(rules), &parsed); err != nil {
+		t.Fatalf("generated rules are not valid YAML: %v\n%s", err, rules)
+	}
+
+	for _, rule := range parsed.Groups[0].Rules {
+		want := ""
+		if rule.Alert != "" {
+			want = "content-team"
+		}
+		if rule.Labels["owner"] != want {
+			t.Errorf("rule %s%s owner label = %q, want %q", rule.Record, rule.Alert, rule.Labels["owner"], want)
+		}
+	}
+}
+
+func TestGenerateSLORules_None(t *testing.T) {
+	resources := []*ast.ResourceNode{{Name: "Comment"}}
+	if rules := NewGenerator().GenerateSLORules(resources, ""); rules != "" {
+		t.Errorf("expected no rules without @slo, got:\n%s", rules)
+	}
+}
+
+func TestGenerateProgram_SLORulesFile(t *testing.T) {
+	prog := &ast.Program{Resources: parseResources(t, sloSource)}
+	files, err := NewGenerator().GenerateProgram(prog, "example.com/app", "/tmp/conduit", "")
+	if err != nil {
+		t.Fatalf("GenerateProgram failed: %v", err)
+	}
+	if _, ok := files[SLORulesFile]; !ok {
+		t.Errorf("expected %s to be generated", SLORulesFile)
+	}
+}
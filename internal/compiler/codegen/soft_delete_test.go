@@ -0,0 +1,152 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const softDeleteSource = `resource Post {
+  @soft_delete
+
+  id: uuid! @primary @auto
+  title: string!
+}`
+
+func TestWithSoftDeletes(t *testing.T) {
+	resources := []*ast.ResourceNode{parseResource(t, softDeleteSource), parseResource(t, archiveSource)}
+	expanded := ast.WithSoftDeletes(resources)
+
+	deleted := expanded[0].FindField(ast.SoftDeleteField)
+	if deleted == nil {
+		t.Fatalf("Expected deleted_at on Post, got fields: %v", expanded[0].Fields)
+	}
+	if !deleted.Nullable || deleted.Type.Name != "timestamp" {
+		t.Errorf("Unexpected deleted_at field: %+v", deleted)
+	}
+	if resources[0].FindField(ast.SoftDeleteField) != nil {
+		t.Error("WithSoftDeletes should not modify the given resources")
+	}
+	if expanded[1] != resources[1] {
+		t.Error("Resources without @soft_delete should be returned as is")
+	}
+
+	// A declared deleted_at is kept
+	declared := ast.WithSoftDeletes(expanded)
+	if declared[0] != expanded[0] {
+		t.Error("Resources that declare deleted_at should be returned as is")
+	}
+}
+
+func TestGenerateMigrations_SoftDelete(t *testing.T) {
+	sql, err := NewGenerator().GenerateMigrations([]*ast.ResourceNode{parseResource(t, softDeleteSource)})
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+	if !strings.Contains(sql, "deleted_at TIMESTAMP WITH TIME ZONE\n") {
+		t.Errorf("Migration missing deleted_at column:\n%s", sql)
+	}
+}
+
+func TestGenerateResource_SoftDeleteAnnotation(t *testing.T) {
+	resource := ast.WithSoftDeletes([]*ast.ResourceNode{parseResource(t, softDeleteSource)})[0]
+	code := generateModel(t, resource)
+
+	// Without an @auto_update field only deleted_at is set
+	del := functionBody(t, code, "func (p *Post) Delete(ctx context.Context, db *sql.DB) error {")
+	assertContains(t, del,
+		"UPDATE posts SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL",
+		"p.DeletedAt = &now",
+	)
+	if strings.Contains(code, "DELETE FROM posts") {
+		t.Error("@soft_delete resources should not delete rows")
+	}
+
+	if !strings.Contains(functionBody(t, code, "func FindPostByID("), "WHERE id = $1 AND deleted_at IS NULL`") {
+		t.Error("FindPostByID should skip deleted records")
+	}
+	if strings.Contains(functionBody(t, code, "func FindPostByIDWithDeleted("), "deleted_at IS NULL") {
+		t.Error("FindPostByIDWithDeleted should find deleted records")
+	}
+	if !strings.Contains(code, "FROM posts WHERE deleted_at IS NULL ORDER BY id") {
+		t.Error("FindAllPost should leave deleted records out")
+	}
+
+	// Only deletes set deleted_at
+	if !strings.Contains(functionBody(t, code, "func (p *Post) Update("), "UPDATE posts SET title = $1 WHERE id = $2 AND deleted_at IS NULL") {
+		t.Error("Update should not set deleted_at")
+	}
+	readOnly := functionBody(t, code, "func (p *Post) Patch(")
+	readOnly = readOnly[strings.Index(readOnly, "readOnlyFields"):strings.Index(readOnly, "validFields")]
+	if !strings.Contains(readOnly, `"deleted_at": true,`) {
+		t.Error("Patch should reject deleted_at as read-only")
+	}
+}
+
+func TestGenerateHandlers_SoftDelete(t *testing.T) {
+	resource := ast.WithSoftDeletes([]*ast.ResourceNode{parseResource(t, softDeleteSource)})[0]
+	code := generateHandlers(t, resource)
+
+	list := functionBody(t, code, "func ListPostHandler(db *sql.DB) http.HandlerFunc {")
+	assertContains(t, list,
+		"includeDeleted, err := query.ParseIncludeDeleted(r)",
+		`Deleted("deleted_at", includeDeleted).`,
+	)
+
+	get := functionBody(t, code, "func GetPostHandler(db *sql.DB) http.HandlerFunc {")
+	assertContains(t, get,
+		"includeDeleted, err := query.ParseIncludeDeleted(r)",
+		"find = models.FindPostByIDWithDeleted",
+		"result, err := find(ctx, db, id)",
+	)
+
+	// Resources with hard deletes take no include_deleted
+	code = generateHandlers(t, parseResource(t, searchSource))
+	if strings.Contains(code, "ParseIncludeDeleted") || strings.Contains(code, "WithDeleted") {
+		t.Error("Resources without soft deletes should not accept include_deleted")
+	}
+}
+
+func TestGeneratedSoftDelete_Runs(t *testing.T) {
+	t.Parallel()
+	runGenerated(t, softDeleteSource, map[string]string{"models/soft_delete_test.go": `package models_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"example.com/app/models"
+)
+
+func TestDeleteKeepsRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Any DELETE statement fails the expectations
+	p := &models.Post{ID: uuid.New(), Title: "Hello"}
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE posts SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := p.Delete(context.Background(), db); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if p.DeletedAt == nil {
+		t.Error("Delete should set DeletedAt")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+`})
+}
@@ -0,0 +1,106 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+const spatialSource = `resource Store {
+  id: uuid! @primary @auto
+  name: string!
+  location: point!
+  area: polygon?
+}`
+
+func TestGenerateResource_Spatial(t *testing.T) {
+	code, err := NewGenerator().GenerateResourceWithHooks(parseResource(t, spatialSource))
+	if err != nil {
+		t.Fatalf("GenerateResource failed: %v", err)
+	}
+
+	expected := []string{
+		`"github.com/conduit-lang/conduit/pkg/web/geo"`,
+		"Location geo.Point ",
+		"*geo.Polygon `jsonapi:\"attr,area\"",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated model missing %q", exp)
+		}
+	}
+}
+
+func TestGenerateResource_ImportsDoNotLeak(t *testing.T) {
+	g := NewGenerator()
+	if _, err := g.GenerateResourceWithHooks(parseResource(t, spatialSource)); err != nil {
+		t.Fatalf("GenerateResource failed: %v", err)
+	}
+
+	code, err := g.GenerateResourceWithHooks(&ast.ResourceNode{
+		Name: "Tag",
+		Fields: []*ast.FieldNode{
+			{Name: "name", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("GenerateResource failed: %v", err)
+	}
+	for _, imp := range []string{`"github.com/conduit-lang/conduit/pkg/web/geo"`, `"github.com/google/uuid"`} {
+		if strings.Contains(code, imp) {
+			t.Errorf("Tag model imports %s from the previous resource", imp)
+		}
+	}
+}
+
+func TestGenerateMigrations_Spatial(t *testing.T) {
+	sql, err := NewGenerator().GenerateMigrations([]*ast.ResourceNode{parseResource(t, spatialSource)})
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+
+	expected := []string{
+		"CREATE EXTENSION IF NOT EXISTS postgis;",
+		"location GEOGRAPHY(Point, 4326) NOT NULL",
+		"area GEOGRAPHY(Polygon, 4326)",
+		"CREATE INDEX idx_stores_location ON stores USING GIST(location);",
+		"CREATE INDEX idx_stores_area ON stores USING GIST(area);",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(sql, exp) {
+			t.Errorf("Migration missing %q:\n%s", exp, sql)
+		}
+	}
+
+	// Schemas without spatial fields don't require PostGIS
+	sql, err = NewGenerator().GenerateMigrations([]*ast.ResourceNode{parseResource(t, postSource)})
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+	if strings.Contains(sql, "postgis") {
+		t.Error("Migration without spatial fields should not create the postgis extension")
+	}
+}
+
+func TestGenerateHandlers_Spatial(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, spatialSource))
+
+	expected := []string{
+		`var storeSpatial = []string{"location", "area"}`,
+		"near := query.ParseNear(r)",
+		"Spatial(storeSpatial).",
+		"Near(near).",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(code, exp) {
+			t.Errorf("Generated handlers missing %q", exp)
+		}
+	}
+
+	// Resources without spatial fields don't parse near filters
+	code = generateHandlers(t, parseResource(t, postSource))
+	if strings.Contains(code, "ParseNear") {
+		t.Error("resources without spatial fields should not parse near filters")
+	}
+}
@@ -17,15 +17,6 @@ func (g *Generator) generateStruct(resource *ast.ResourceNode) error {
 	g.writeLine("type %s struct {", resource.Name)
 	g.indent++
 
-	// Check if resource has an ID field
-	hasID := false
-	for _, field := range resource.Fields {
-		if field.Name == "id" {
-			hasID = true
-			break
-		}
-	}
-
 	// Collect all field information for alignment
 	type fieldInfo struct {
 		name string
@@ -37,8 +28,8 @@ func (g *Generator) generateStruct(resource *ast.ResourceNode) error {
 	// Generate JSON:API type for this resource
 	jsonapiType := g.toJSONAPIType(resource.Name)
 
-	// Add ID field if not explicitly defined
-	if !hasID {
+	// Add ID field if no primary key is declared
+	if hasImplicitID(resource) {
 		fields = append(fields, fieldInfo{
 			name: "ID",
 			typ:  "int64",
@@ -50,10 +41,15 @@ func (g *Generator) generateStruct(resource *ast.ResourceNode) error {
 	for _, field := range resource.Fields {
 		var tags string
 		var typ string
-		if field.Name == "id" {
+		if field.Name == "id" && !resource.HasNaturalKey() {
 			// ID fields always get primary tag with omitempty for optional creation
 			tags = fmt.Sprintf("`jsonapi:\"primary,%s,omitempty\" db:\"id\" json:\"id,omitempty\"`", jsonapiType)
 			typ = g.toGoType(field)
+		} else if field == keyField(resource) {
+			// A natural key is the JSON:API id; clients choose it, so it is
+			// always sent. Composite keys are joined by MarshalID.
+			tags = fmt.Sprintf("`jsonapi:\"primary,%s\" db:%q json:%q`", jsonapiType, g.fieldColumnName(field), g.jsonName(field.Name))
+			typ = g.toGoType(field)
 		} else {
 			tags = g.generateStructTags(field, resource.Name)
 			typ = g.toGoType(field)
@@ -97,7 +93,7 @@ func (g *Generator) generateStruct(resource *ast.ResourceNode) error {
 
 // generateTableName generates the TableName() method
 func (g *Generator) generateTableName(resource *ast.ResourceNode) {
-	tableName := g.toTableName(resource.Name)
+	tableName := g.sqlTable(resource)
 
 	g.writeLine("// TableName returns the database table name for %s", resource.Name)
 	g.writeLine("func (%s *%s) TableName() string {",
@@ -150,7 +146,13 @@ func (g *Generator) generateFieldValidation(resource *ast.ResourceNode, field *a
 	case "string", "text", "markdown":
 		g.writeLine("if len(%s.%s) == 0 {", receiverName, fieldName)
 		g.indent++
-		g.writeLine("return fmt.Errorf(\"%s is required\")", field.Name)
+		g.writeLine("return fmt.Errorf(\"%s is required\")", g.jsonName(field.Name))
+		g.indent--
+		g.writeLine("}")
+	case "polygon":
+		g.writeLine("if len(%s.%s) == 0 {", receiverName, fieldName)
+		g.indent++
+		g.writeLine("return fmt.Errorf(\"%s is required\")", g.jsonName(field.Name))
 		g.indent--
 		g.writeLine("}")
 	}
@@ -173,7 +175,7 @@ func (g *Generator) generateConstraintValidation(resource *ast.ResourceNode, fie
 			g.indent++
 			errorMsg := constraint.Error
 			if errorMsg == "" {
-				errorMsg = fmt.Sprintf("%s must be at least %v characters", field.Name, extractLiteralValue(constraint.Arguments[0]))
+				errorMsg = fmt.Sprintf("%s must be at least %v characters", g.jsonName(field.Name), extractLiteralValue(constraint.Arguments[0]))
 			}
 			g.writeLine("return fmt.Errorf(%q)", errorMsg)
 			g.indent--
@@ -191,7 +193,7 @@ func (g *Generator) generateConstraintValidation(resource *ast.ResourceNode, fie
 			g.indent++
 			errorMsg := constraint.Error
 			if errorMsg == "" {
-				errorMsg = fmt.Sprintf("%s must be at most %v characters", field.Name, extractLiteralValue(constraint.Arguments[0]))
+				errorMsg = fmt.Sprintf("%s must be at most %v characters", g.jsonName(field.Name), extractLiteralValue(constraint.Arguments[0]))
 			}
 			g.writeLine("return fmt.Errorf(%q)", errorMsg)
 			g.indent--
@@ -0,0 +1,117 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// timestampsSource declares a Post that gets timestamps, a Tag that opts out
+// of them, a Comment that declares created_at and a view that never gets them
+const timestampsSource = `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+}
+
+resource Tag {
+  @timestamps(false)
+
+  id: uuid! @primary @auto
+  title: string!
+}
+
+resource Comment {
+  id: uuid! @primary @auto
+  title: string!
+  created_at: timestamp! @auto
+}
+
+resource Stat {
+  @materialized(refresh: hourly, query: "SELECT id, title FROM posts")
+
+  id: uuid! @primary @auto
+  title: string!
+}`
+
+func fieldNames(resource *ast.ResourceNode) []string {
+	names := make([]string, len(resource.Fields))
+	for i, field := range resource.Fields {
+		names[i] = field.Name
+	}
+	return names
+}
+
+func TestWithTimestamps(t *testing.T) {
+	resources := parseResources(t, timestampsSource)
+	plain, optedOut, declared, view := resources[0], resources[1], resources[2], resources[3]
+	expanded := ast.WithTimestamps(resources, true)
+
+	want := "id title created_at updated_at"
+	if got := strings.Join(fieldNames(expanded[0]), " "); got != want {
+		t.Errorf("Post fields = %s, want %s", got, want)
+	}
+	createdAt, updatedAt := expanded[0].FindField("created_at"), expanded[0].FindField("updated_at")
+	if createdAt.Nullable || createdAt.Type.Name != "timestamp" || createdAt.Constraints[0].Name != "auto" {
+		t.Errorf("Unexpected created_at field: %+v", createdAt)
+	}
+	if updatedAt.Nullable || updatedAt.Type.Name != "timestamp" || updatedAt.Constraints[0].Name != "auto_update" {
+		t.Errorf("Unexpected updated_at field: %+v", updatedAt)
+	}
+	if len(plain.Fields) != 2 {
+		t.Error("WithTimestamps should not modify the given resources")
+	}
+
+	if expanded[1] != optedOut {
+		t.Error("Resources with @timestamps(false) should be returned as is")
+	}
+	if got := strings.Join(fieldNames(expanded[2]), " "); got != want {
+		t.Errorf("Comment fields = %s, want %s", got, want)
+	}
+	if expanded[2].FindField("created_at") != declared.Fields[2] {
+		t.Error("A declared created_at should be kept")
+	}
+	if expanded[3] != view {
+		t.Error("Materialized resources should be returned as is")
+	}
+
+	// Without the project setting only @timestamps resources get them
+	plain.Timestamps = &ast.TimestampsNode{Enabled: true}
+	expanded = ast.WithTimestamps(resources, false)
+	if got := strings.Join(fieldNames(expanded[0]), " "); got != want {
+		t.Errorf("Post fields = %s, want %s", got, want)
+	}
+	if expanded[2] != declared {
+		t.Error("Resources without @timestamps should be returned as is")
+	}
+}
+
+func TestGenerateMigrations_Timestamps(t *testing.T) {
+	resource := parseResource(t, strings.Replace(postSource, "{\n", "{\n  @timestamps\n\n", 1))
+
+	sql, err := NewGenerator().GenerateMigrations([]*ast.ResourceNode{resource})
+	if err != nil {
+		t.Fatalf("GenerateMigrations failed: %v", err)
+	}
+	for _, want := range []string{"created_at TIMESTAMP WITH TIME ZONE NOT NULL", "updated_at TIMESTAMP WITH TIME ZONE NOT NULL"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("Migration missing %q:\n%s", want, sql)
+		}
+	}
+}
+
+func TestGenerateResource_Timestamps(t *testing.T) {
+	resource := ast.WithTimestamps([]*ast.ResourceNode{parseResource(t, postSource)}, true)[0]
+
+	code := generateModel(t, resource)
+	create := functionBody(t, code, "func (p *Post) Create(ctx context.Context, db *sql.DB) error {")
+	for _, want := range []string{"p.CreatedAt = time.Now()", "p.UpdatedAt = time.Now()"} {
+		if !strings.Contains(create, want) {
+			t.Errorf("Create missing %q:\n%s", want, create)
+		}
+	}
+	update := functionBody(t, code, "func (p *Post) Update(ctx context.Context, db *sql.DB) error {")
+	if !strings.Contains(update, "p.UpdatedAt = time.Now()") {
+		t.Errorf("Update should set updated_at:\n%s", update)
+	}
+}
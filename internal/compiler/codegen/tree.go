@@ -0,0 +1,193 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// treeParentField returns the field of a @tree resource that holds the ID of
+// each record's parent, or nil for resources that are not trees
+func treeParentField(resource *ast.ResourceNode) *ast.FieldNode {
+	rel := resource.TreeParent()
+	if rel == nil {
+		return nil
+	}
+	return resource.FindField(rel.ForeignKeyColumn())
+}
+
+// generateTree generates the Find<Resource>Children() and
+// Find<Resource>Ancestors() functions of a @tree resource. Both walk the tree
+// with a recursive CTE that stops after depth levels, so a cycle in the data
+// cannot make a query run away. Children leave out the records lists leave
+// out, and with them everything below; ancestors only leave out soft-deleted
+// records, so the path to the root stays whole.
+func (g *Generator) generateTree(resource *ast.ResourceNode) {
+	tableName := g.sqlTable(resource)
+	parent := g.fieldColumnName(treeParentField(resource))
+	plural := strings.ToLower(resource.Name) + "s"
+	key := g.keyColumn(resource)
+
+	columns, _ := g.buildSelectQuery(resource)
+	qualified := make([]string, len(columns))
+	for i, column := range columns {
+		qualified[i] = "t." + column
+	}
+	selectList := strings.Join(qualified, ", ")
+
+	childConditions := g.listConditions(resource, "t.")
+	var ancestorConditions []string
+	if field := softDeleteField(resource); field != nil {
+		ancestorConditions = append(ancestorConditions, "t."+g.fieldColumnName(field)+" IS NULL")
+	}
+
+	children := fmt.Sprintf("WITH RECURSIVE tree AS (SELECT %s, 1 AS tree_depth FROM %s t WHERE %s "+
+		"UNION ALL SELECT %s, tree.tree_depth + 1 FROM %s t JOIN tree ON t.%s = tree.%s WHERE %s) "+
+		"SELECT %s FROM tree ORDER BY tree_depth, %s",
+		selectList, tableName, strings.Join(append([]string{"t." + parent + " = $1"}, childConditions...), " AND "),
+		selectList, tableName, parent, key, strings.Join(append([]string{"tree.tree_depth < $2"}, childConditions...), " AND "),
+		strings.Join(columns, ", "), strings.Join(g.listOrder(resource), ", "))
+	g.generateTreeQuery(resource, "Children",
+		fmt.Sprintf("retrieves the %s below a %s, down to depth levels, level by level", plural, strings.ToLower(resource.Name)),
+		children)
+	g.writeLine("")
+
+	ancestors := fmt.Sprintf("WITH RECURSIVE tree AS (SELECT %s, 1 AS tree_depth FROM %s t WHERE %s "+
+		"UNION ALL SELECT %s, tree.tree_depth + 1 FROM %s t JOIN tree ON t.%s = tree.%s WHERE %s) "+
+		"SELECT %s FROM tree ORDER BY tree_depth",
+		selectList, tableName, strings.Join(append([]string{fmt.Sprintf("t.%s = (SELECT %s FROM %s WHERE %s = $1)", key, parent, tableName, key)}, ancestorConditions...), " AND "),
+		selectList, tableName, key, parent, strings.Join(append([]string{"tree.tree_depth < $2"}, ancestorConditions...), " AND "),
+		strings.Join(columns, ", "))
+	g.generateTreeQuery(resource, "Ancestors",
+		fmt.Sprintf("retrieves the %s above a %s, up to depth levels, parent first", plural, strings.ToLower(resource.Name)),
+		ancestors)
+}
+
+// generateTreeQuery generates a function that runs a tree query binding the
+// ID of the record it starts from and the depth it stops at
+func (g *Generator) generateTreeQuery(resource *ast.ResourceNode, direction, doc, query string) {
+	receiverName := strings.ToLower(resource.Name[0:1])
+	_, scanTargets := g.buildSelectQuery(resource)
+
+	g.writeLine("// Find%s%s %s", resource.Name, direction, doc)
+	g.writeLine("func Find%s%s(ctx context.Context, db *sql.DB, id %s, depth int) ([]*%s, error) {",
+		resource.Name, direction, g.getIDGoType(resource), resource.Name)
+	g.indent++
+	g.writeLine("query := `%s`", query)
+	g.writeLine("")
+
+	g.writeLine("rows, err := db.QueryContext(ctx, query, id, depth)")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("return nil, fmt.Errorf(\"failed to query %s %s: %%w\", err)", strings.ToLower(resource.Name), strings.ToLower(direction))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("defer rows.Close()")
+	g.writeLine("")
+
+	g.writeLine("var results []*%s", resource.Name)
+	g.writeLine("for rows.Next() {")
+	g.indent++
+	g.writeLine("%s := &%s{}", receiverName, resource.Name)
+	g.writeLine("if err := rows.Scan(%s); err != nil {", strings.Join(scanTargets, ", "))
+	g.indent++
+	g.writeLine("return nil, fmt.Errorf(\"failed to scan %s: %%w\", err)", strings.ToLower(resource.Name))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("results = append(results, %s)", receiverName)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("if err := rows.Err(); err != nil {")
+	g.indent++
+	g.writeLine("return nil, fmt.Errorf(\"error iterating %s %s: %%w\", err)", strings.ToLower(resource.Name), strings.ToLower(direction))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("return results, nil")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateTreeHandler generates the handler of a @tree resource's children
+// or ancestors route (GET /resources/{id}/children or /ancestors). ?depth
+// picks how many levels to follow: one level of children and every ancestor
+// by default, and never more than the depth limit.
+func (g *Generator) generateTreeHandler(resource *ast.ResourceNode, operation string) {
+	resourceLower := strings.ToLower(resource.Name)
+	direction := strings.ToUpper(operation[:1]) + operation[1:]
+	limit := resource.Tree.DepthLimit()
+
+	defaultDepth, summary := 1, "below"
+	if operation == "ancestors" {
+		defaultDepth, summary = limit, "above"
+	}
+
+	g.writeLine("// List%s%sHandler handles GET %s/%s - the %s %s a %s",
+		resource.Name, direction, g.memberPath(resource), operation, resourceLower+"s", summary, resourceLower)
+	g.writeLine("func List%s%sHandler(db *sql.DB) http.HandlerFunc {", resource.Name, direction)
+	g.indent++
+	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
+	g.indent++
+
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, %q)", resource.Name, operation)
+	g.writeLine("")
+	g.generateVisibleFields(resource)
+
+	g.generateIDParsingCode(resource)
+
+	g.writeLine("// Parse depth: the number of levels to follow, at most %d (@tree)", limit)
+	g.writeLine("depth, err := query.ParseDepth(r, %d, %d)", defaultDepth, limit)
+	g.generateListBadRequest()
+	g.writeLine("")
+
+	g.writeLine("// The %s the tree is walked from must exist", resourceLower)
+	g.writeLine("if _, err := models.Find%sByID(ctx, db, id); err != nil {", resource.Name)
+	g.indent++
+	g.writeLine("if err == sql.ErrNoRows {")
+	g.indent++
+	g.writeSearchError("http.StatusNotFound", "fmt.Errorf(\"Not found\")")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeSearchError("http.StatusInternalServerError", "fmt.Errorf(\"Failed to find "+resourceLower+": %v\", err)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("items, err := models.Find%s%s(ctx, db, id, depth)", resource.Name, direction)
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeSearchError("http.StatusInternalServerError", "fmt.Errorf(\"Failed to query "+resourceLower+" "+operation+": %v\", err)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.generateListStream(resource, "nil")
+	g.writeLine("for _, item := range items {")
+	g.indent++
+	g.writeLine("if err := stream.Write(item); err != nil {")
+	g.indent++
+	g.writeLine("stream.Fail(fmt.Errorf(\"Failed to encode %s: %%v\", err))", resourceLower)
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("stream.Close(map[string]interface{}{")
+	g.indent++
+	g.writeLine("\"depth\": depth,")
+	g.writeLine("\"total\": len(items),")
+	g.indent--
+	g.writeLine("}, nil)")
+
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+}
@@ -0,0 +1,93 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+const treeSource = `resource Folder {
+  @archivable
+  @tree(max_depth: 8)
+
+  id: uuid! @primary @auto
+  name: string!
+  parent_id: uuid?
+  archived_at: timestamp?
+
+  parent: Folder? {
+    foreign_key: "parent_id"
+  }
+}`
+
+func TestGenerateResource_Tree(t *testing.T) {
+	code := generateModel(t, parseResource(t, treeSource))
+
+	columns := "t.id, t.name, t.parent_id, t.archived_at"
+
+	children := functionBody(t, code, "func FindFolderChildren(ctx context.Context, db *sql.DB, id uuid.UUID, depth int) ([]*Folder, error) {")
+	assertContains(t, children,
+		"WITH RECURSIVE tree AS (SELECT "+columns+", 1 AS tree_depth FROM folders t "+
+			"WHERE t.parent_id = $1 AND t.archived_at IS NULL "+
+			"UNION ALL SELECT "+columns+", tree.tree_depth + 1 FROM folders t JOIN tree ON t.parent_id = tree.id "+
+			"WHERE tree.tree_depth < $2 AND t.archived_at IS NULL) "+
+			"SELECT id, name, parent_id, archived_at FROM tree ORDER BY tree_depth, id",
+		"db.QueryContext(ctx, query, id, depth)",
+		"rows.Scan(&f.ID, &f.Name, &f.ParentID, &f.ArchivedAt)",
+	)
+
+	// Archived ancestors are still part of the path
+	ancestors := functionBody(t, code, "func FindFolderAncestors(ctx context.Context, db *sql.DB, id uuid.UUID, depth int) ([]*Folder, error) {")
+	if !strings.Contains(ancestors, "WITH RECURSIVE tree AS (SELECT "+columns+", 1 AS tree_depth FROM folders t "+
+		"WHERE t.id = (SELECT parent_id FROM folders WHERE id = $1) "+
+		"UNION ALL SELECT "+columns+", tree.tree_depth + 1 FROM folders t JOIN tree ON t.id = tree.parent_id "+
+		"WHERE tree.tree_depth < $2) "+
+		"SELECT id, name, parent_id, archived_at FROM tree ORDER BY tree_depth`") {
+		t.Errorf("FindFolderAncestors should walk up to the root:\n%s", ancestors)
+	}
+}
+
+func TestGenerateHandlers_Tree(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, treeSource))
+
+	assertContains(t, code,
+		`r.Get("/folders/{id}/children", ListFolderChildrenHandler(db))`,
+		`r.Get("/folders/{id}/ancestors", ListFolderAncestorsHandler(db))`,
+	)
+
+	tests := []struct {
+		handler string
+		wants   []string
+	}{
+		{"ListFolderChildrenHandler", []string{
+			`instrument.WithOperation(r.Context(), "Folder", "children")`,
+			"depth, err := query.ParseDepth(r, 1, 8)",
+			"if _, err := models.FindFolderByID(ctx, db, id); err != nil {",
+			"items, err := models.FindFolderChildren(ctx, db, id, depth)",
+			"stream.Write(item)",
+			`"depth": depth,`,
+		}},
+		{"ListFolderAncestorsHandler", []string{
+			`instrument.WithOperation(r.Context(), "Folder", "ancestors")`,
+			"depth, err := query.ParseDepth(r, 8, 8)",
+			"items, err := models.FindFolderAncestors(ctx, db, id, depth)",
+		}},
+	}
+	for _, tt := range tests {
+		handler := functionBody(t, code, "func "+tt.handler+"(db *sql.DB) http.HandlerFunc {")
+		for _, want := range tt.wants {
+			if !strings.Contains(handler, want) {
+				t.Errorf("%s missing %q:\n%s", tt.handler, want, handler)
+			}
+		}
+	}
+}
+
+func TestGenerateHandlers_NotTree(t *testing.T) {
+	resource := parseResource(t, treeSource)
+	resource.Tree = nil
+
+	code := generateHandlers(t, resource)
+	if strings.Contains(code, "/children") || strings.Contains(code, "ParseDepth") {
+		t.Error("Resources without @tree should not serve children or ancestors")
+	}
+}
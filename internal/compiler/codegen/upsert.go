@@ -0,0 +1,331 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// UpsertPath returns the route of a resource's @upsert operation
+func UpsertPath(tableName string) string {
+	return "/" + tableName + ":upsert"
+}
+
+// upsertColumn returns the column of the @upsert conflict target
+func (g *Generator) upsertColumn(resource *ast.ResourceNode) string {
+	if field := resource.FindField(resource.Upsert.Fields[0]); field != nil {
+		return g.fieldColumnName(field)
+	}
+	return resource.Upsert.Fields[0]
+}
+
+// buildUpsertSet builds the SET clauses applied to the conflicting row: every
+// inserted column except the ID, the conflict target and @auto values such
+// as creation timestamps, which keep what the row was created with
+func (g *Generator) buildUpsertSet(resource *ast.ResourceNode, target string) []string {
+	var setClauses []string
+	for _, field := range resource.Fields {
+		if isKeyField(resource, field) || field.IsCounterCache() || hasConstraint(field, "auto") {
+			continue
+		}
+		columnName := g.fieldColumnName(field)
+		if columnName == target {
+			continue
+		}
+		setClauses = append(setClauses, fmt.Sprintf("%s = EXCLUDED.%s", columnName, columnName))
+		if legacy, _ := field.DualWrite(); legacy != "" {
+			setClauses = append(setClauses, fmt.Sprintf("%s = EXCLUDED.%s", legacy, legacy))
+		}
+	}
+
+	// DO NOTHING would return no row, so rewrite the target when there is
+	// nothing else to update
+	if len(setClauses) == 0 {
+		setClauses = append(setClauses, fmt.Sprintf("%s = EXCLUDED.%s", target, target))
+	}
+	return setClauses
+}
+
+// generateUpsert generates the Upsert() method for a @upsert resource. The
+// record is written with a single INSERT ... ON CONFLICT, so concurrent
+// upserts of the same key cannot both insert. Whether a row was inserted is
+// only known once it is written, so the create and update before hooks do not
+// run; BeforeSave does, and the after hooks of whichever write happened.
+func (g *Generator) generateUpsert(resource *ast.ResourceNode) {
+	receiverName := strings.ToLower(resource.Name[0:1])
+	resourceLower := strings.ToLower(resource.Name)
+	target := g.upsertColumn(resource)
+
+	g.writeLine("// Upsert inserts the %s, or updates the %s with the same %s, and",
+		resource.Name, resourceLower, strings.Join(resource.Upsert.Fields, ", "))
+	g.writeLine("// reports whether it was inserted")
+	g.writeLine("func (%s *%s) Upsert(ctx context.Context, db *sql.DB) (bool, error) {",
+		receiverName, resource.Name)
+	g.indent++
+
+	g.writeLine("// Generate @auto fields (UUIDs, timestamps); a conflicting row keeps its own")
+	g.generateAutoFields(resource, "create")
+	g.generateAutoFields(resource, "update")
+	if hasAutoFields(resource) || hasAutoUpdateFields(resource) {
+		g.writeLine("")
+	}
+
+	if hasHook(resource, "before", "save") {
+		g.writeLine("// Call BeforeSave hook")
+		g.writeLine("if err := %s.BeforeSave(ctx, db); err != nil {", receiverName)
+		g.indent++
+		g.writeLine(`return false, fmt.Errorf("before save hook failed: %w", err)`)
+		g.indent--
+		g.writeLine("}")
+		g.writeLine("")
+	}
+
+	g.writeLine("// Validate after hooks have run")
+	g.writeLine("if err := %s.Validate(); err != nil {", receiverName)
+	g.indent++
+	g.writeLine(`return false, fmt.Errorf("validation failed: %w", err)`)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+	g.generateShardCheck(resource, receiverName, "false, ")
+
+	g.writeLine("// Begin transaction")
+	g.writeLine("tx, err := db.BeginTx(ctx, nil)")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine(`return false, fmt.Errorf("failed to begin transaction: %w", err)`)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("defer tx.Rollback()")
+	g.writeLine("")
+
+	// The conflicting row's ID and @auto values are read back; xmax is zero
+	// only for a row this statement inserted
+	columns, placeholders, values := g.buildInsertQuery(resource)
+	returning := g.keyColumns(resource)
+	var targets []string
+	for _, value := range g.keyValues(resource, receiverName) {
+		targets = append(targets, "&"+value)
+	}
+	for _, field := range resource.Fields {
+		if !isKeyField(resource, field) && hasConstraint(field, "auto") {
+			returning = append(returning, g.fieldColumnName(field))
+			targets = append(targets, fmt.Sprintf("&%s.%s", receiverName, g.toGoFieldName(field.Name)))
+		}
+	}
+	returning = append(returning, "(xmax = 0)")
+	targets = append(targets, "&inserted")
+
+	g.writeLine("query := `INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s RETURNING %s`",
+		g.sqlTable(resource), strings.Join(columns, ", "), strings.Join(placeholders, ", "),
+		target, strings.Join(g.buildUpsertSet(resource, target), ", "), strings.Join(returning, ", "))
+	g.writeLine("")
+
+	g.writeLine("// Execute INSERT ... ON CONFLICT")
+	g.writeLine("var inserted bool")
+	g.writeLine("err = tx.QueryRowContext(ctx, query, %s).Scan(%s)",
+		strings.Join(values, ", "), strings.Join(targets, ", "))
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("return false, fmt.Errorf(\"failed to upsert %s: %%w\", err)", resourceLower)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	afterCreate := hasHook(resource, "after", "create")
+	afterUpdate := hasHook(resource, "after", "update")
+	if afterCreate || afterUpdate {
+		g.writeLine("// Call the after hook of the write that happened")
+		if afterCreate {
+			g.writeLine("if inserted {")
+			g.indent++
+			g.writeLine("if err := %s.AfterCreate(ctx, tx); err != nil {", receiverName)
+			g.indent++
+			g.writeLine(`return false, fmt.Errorf("after create hook failed: %w", err)`)
+			g.indent--
+			g.writeLine("}")
+			g.indent--
+			if afterUpdate {
+				g.writeLine("} else {")
+			} else {
+				g.writeLine("}")
+			}
+		} else {
+			g.writeLine("if !inserted {")
+		}
+		if afterUpdate {
+			g.indent++
+			g.writeLine("if err := %s.AfterUpdate(ctx, tx); err != nil {", receiverName)
+			g.indent++
+			g.writeLine(`return false, fmt.Errorf("after update hook failed: %w", err)`)
+			g.indent--
+			g.writeLine("}")
+			g.indent--
+			g.writeLine("}")
+		}
+		g.writeLine("")
+	}
+
+	if hasHook(resource, "after", "save") {
+		g.writeLine("// Call AfterSave hook")
+		g.writeLine("if err := %s.AfterSave(ctx, tx); err != nil {", receiverName)
+		g.indent++
+		g.writeLine(`return false, fmt.Errorf("after save hook failed: %w", err)`)
+		g.indent--
+		g.writeLine("}")
+		g.writeLine("")
+	}
+
+	g.writeLine("// Commit transaction")
+	g.writeLine("if err := tx.Commit(); err != nil {")
+	g.indent++
+	g.writeLine(`return false, fmt.Errorf("failed to commit transaction: %w", err)`)
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.generateSearchIndexing(resource, receiverName, false)
+
+	g.writeLine("return inserted, nil")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateUpsertHandler generates the handler for a @upsert resource
+// (PUT /resources:upsert). It answers 201 Created with a Location when the
+// record was inserted and 200 OK when an existing record was updated.
+func (g *Generator) generateUpsertHandler(resource *ast.ResourceNode) {
+	resourceLower := strings.ToLower(resource.Name)
+	tableName := g.toTableName(resource.Name)
+	receiverName := strings.ToLower(resource.Name[0:1])
+
+	g.writeLine("// Upsert%sHandler handles PUT %s - create a %s or update the one with the same %s",
+		resource.Name, UpsertPath(tableName), resourceLower, strings.Join(resource.Upsert.Fields, ", "))
+	g.writeLine("func Upsert%sHandler(db *sql.DB) http.HandlerFunc {", resource.Name)
+	g.indent++
+	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
+	g.indent++
+
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, \"upsert\")", resource.Name)
+	g.writeLine("")
+	g.generateVisibleFields(resource)
+
+	g.writeLine("// Check if JSON:API format is requested")
+	g.writeLine("if response.IsJSONAPI(r) {")
+	g.indent++
+
+	g.writeLine("// Validate Content-Type")
+	g.writeLine("if !response.ValidateJSONAPIContentType(w, r) {")
+	g.indent++
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("// Limit request body size to prevent DoS attacks (10MB default)")
+	g.writeLine("r.Body = http.MaxBytesReader(w, r.Body, 10<<20)")
+	g.writeLine("")
+	g.writeLine("// Read request body")
+	g.writeLine("body, err := io.ReadAll(r.Body)")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("var maxBytesError *http.MaxBytesError")
+	g.writeLine("if errors.As(err, &maxBytesError) {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusRequestEntityTooLarge, fmt.Errorf(\"Request body too large (max 10MB)\"))")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusBadRequest, fmt.Errorf(\"Failed to read request body: %v\", err))")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("// Unmarshal JSON:API request")
+	g.writeLine("var %s models.%s", receiverName, resource.Name)
+	g.writeLine("if err := jsonapi.Unmarshal(body, &%s); err != nil {", receiverName)
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusBadRequest, fmt.Errorf(\"Invalid JSON:API request: %v\", err))")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("// Upsert %s (includes validation and hooks)", resourceLower)
+	g.writeLine("inserted, err := %s.Upsert(ctx, db)", receiverName)
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusUnprocessableEntity, err)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.generateUpsertStatus(resource, tableName, receiverName)
+
+	g.writeLine("// Render JSON:API response")
+	g.writeLine("if err := %s; err != nil {", renderJSONAPI(resource, "status", "&"+receiverName))
+	g.indent++
+	g.writeLine("response.RenderJSONAPIError(w, http.StatusInternalServerError, fmt.Errorf(\"Failed to encode response: %v\", err))")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+
+	g.indent--
+	g.writeLine("} else {")
+	g.indent++
+
+	g.writeLine("// Legacy JSON format; the body may also be form-encoded, multipart or msgpack")
+	g.writeLine("var %s models.%s", receiverName, resource.Name)
+	g.writeLine("if err := bind.Decode(r, &%s); err != nil {", receiverName)
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Invalid request body: %v\", err), bind.StatusCode(err))")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("// Upsert %s (includes validation and hooks)", resourceLower)
+	g.writeLine("inserted, err := %s.Upsert(ctx, db)", receiverName)
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to upsert %s: %v\", err), http.StatusUnprocessableEntity)", resourceLower)
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.generateUpsertStatus(resource, tableName, receiverName)
+
+	g.writeLine("w.Header().Set(\"Content-Type\", \"application/json\")")
+	g.writeLine("w.WriteHeader(status)")
+	g.writeLine("if err := json.NewEncoder(w).Encode(%s); err != nil {", g.encodedRecord(resource, receiverName))
+	g.indent++
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to encode response: %v\", err), http.StatusInternalServerError)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+
+	g.indent--
+	g.writeLine("}")
+
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+}
+
+// generateUpsertStatus declares status, 201 with a Location header for an
+// inserted record and 200 for an updated one
+func (g *Generator) generateUpsertStatus(resource *ast.ResourceNode, tableName, receiverName string) {
+	g.writeLine("status := http.StatusOK")
+	g.writeLine("if inserted {")
+	g.indent++
+	g.writeLine("status = http.StatusCreated")
+	g.writeLine("w.Header().Set(\"Location\", fmt.Sprintf(\"/api/%s/%%v\", %s))", tableName, g.recordID(resource, receiverName))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+}
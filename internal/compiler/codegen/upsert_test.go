@@ -0,0 +1,130 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+const upsertSource = `resource User {
+  @upsert(on: [email])
+
+  id: uuid! @primary @auto
+  email: string! @unique
+  name: string!
+  created_at: timestamp! @auto
+  updated_at: timestamp! @auto_update
+
+  @after create {
+  }
+
+  @after update {
+  }
+}`
+
+func TestGenerateResource_Upsert(t *testing.T) {
+	code := generateModel(t, parseResource(t, upsertSource))
+
+	upsert := functionBody(t, code, "func (u *User) Upsert(ctx context.Context, db *sql.DB) (bool, error) {")
+	assertContains(t, upsert,
+		"u.ID = uuid.New()",
+		"u.UpdatedAt = time.Now()",
+		"INSERT INTO users (id, email, name, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) "+
+			"ON CONFLICT (email) DO UPDATE SET name = EXCLUDED.name, updated_at = EXCLUDED.updated_at "+
+			"RETURNING id, created_at, (xmax = 0)",
+		"Scan(&u.ID, &u.CreatedAt, &inserted)",
+		"if inserted {",
+		"u.AfterCreate(ctx, tx)",
+		"u.AfterUpdate(ctx, tx)",
+		"return inserted, nil",
+	)
+}
+
+func TestGenerateResource_UpsertTargetOnly(t *testing.T) {
+	code := generateModel(t, parseResource(t, `resource Tag {
+  @upsert(on: [slug])
+
+  slug: string! @unique
+}`))
+
+	// DO NOTHING would not return the existing row
+	assertContains(t, code, "ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug RETURNING id, (xmax = 0)")
+}
+
+func TestGenerateHandlers_Upsert(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, upsertSource))
+
+	assertContains(t, code, `r.Put("/users:upsert", UpsertUserHandler(db))`)
+	handler := functionBody(t, code, "func UpsertUserHandler(db *sql.DB) http.HandlerFunc {")
+	assertContains(t, handler,
+		`instrument.WithOperation(r.Context(), "User", "upsert")`,
+		"inserted, err := u.Upsert(ctx, db)",
+		"status = http.StatusCreated",
+		`w.Header().Set("Location", fmt.Sprintf("/api/users/%v", u.ID))`,
+		"response.RenderJSONAPI(w, status, &u)",
+		"w.WriteHeader(status)",
+		`fmt.Errorf("Invalid JSON:API request: %v", err)`,
+		`fmt.Sprintf("Failed to encode response: %v", err)`,
+	)
+	if strings.Contains(handler, "%%") {
+		t.Errorf("Upsert handler prints a literal %%:\n%s", handler)
+	}
+}
+
+func TestGenerateHandlers_NoUpsert(t *testing.T) {
+	resource := parseResource(t, upsertSource)
+	resource.Upsert = nil
+
+	if code := generateHandlers(t, resource); strings.Contains(code, "Upsert") {
+		t.Error("Resources without @upsert should not get an upsert route")
+	}
+}
+
+func TestGeneratedUpsert_Runs(t *testing.T) {
+	t.Parallel()
+	// After hooks are left out: generated hooks take a *sql.DB, not the
+	// transaction Upsert passes them
+	source := upsertSource[:strings.Index(upsertSource, "\n\n  @after")] + "\n}"
+	runGenerated(t, source, map[string]string{"models/upsert_test.go": `package models_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"example.com/app/models"
+)
+
+func TestUpsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	existing := uuid.New()
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (id, email, name, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (email) DO UPDATE SET")).
+		WithArgs(sqlmock.AnyArg(), "ann@example.com", "Ann", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "inserted"}).AddRow(existing, created, false))
+	mock.ExpectCommit()
+
+	u := &models.User{Email: "ann@example.com", Name: "Ann"}
+	inserted, err := u.Upsert(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	// The conflicting row keeps its ID and creation time
+	if inserted || u.ID != existing || !u.CreatedAt.Equal(created) {
+		t.Errorf("Upsert = %v with %v created %v, want the existing row", inserted, u.ID, u.CreatedAt)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+`})
+}
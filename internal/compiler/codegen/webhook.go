@@ -0,0 +1,120 @@
+package codegen
+
+import (
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// hasWebhook reports whether any resource declares @webhook
+func hasWebhook(resources []*ast.ResourceNode) bool {
+	for _, resource := range resources {
+		if resource.Webhook != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookPath returns the route a @webhook provider delivers events to
+func WebhookPath(provider string) string {
+	return "/webhooks/" + provider
+}
+
+// generateWebhookHandler generates the handler for a @webhook resource
+// (POST /webhooks/<provider>). The delivery is verified against the
+// provider's signing secret, then recorded through Create so the resource's
+// create hooks process it. Providers redeliver events until they are
+// acknowledged, so an event already recorded under its event_id is
+// acknowledged without running the hooks again; a concurrent redelivery
+// loses the race on the unique event_id and its transaction rolls back.
+func (g *Generator) generateWebhookHandler(resource *ast.ResourceNode) {
+	receiverName := strings.ToLower(resource.Name[0:1])
+	path := WebhookPath(resource.Webhook.Provider)
+
+	eventColumn := ast.WebhookEventIDField
+	if field := resource.FindField(ast.WebhookEventIDField); field != nil {
+		eventColumn = g.fieldColumnName(field)
+	}
+	seenQuery := "SELECT EXISTS (SELECT 1 FROM " + g.sqlTable(resource) + " WHERE " + eventColumn + " = $1)"
+
+	g.writeLine("// Webhook%sHandler handles POST %s - %s events recorded once per event ID",
+		resource.Name, path, resource.Webhook.Provider)
+	g.writeLine("func Webhook%sHandler(db *sql.DB) http.HandlerFunc {", resource.Name)
+	g.indent++
+	g.writeLine("return func(w http.ResponseWriter, r *http.Request) {")
+	g.indent++
+
+	g.writeLine("ctx := instrument.WithOperation(r.Context(), %q, \"webhook\")", resource.Name)
+	g.writeLine("")
+
+	g.writeLine("// The signature covers the exact bytes sent, so read the body unparsed")
+	g.writeLine("body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, webhook.MaxBodySize))")
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("respondWithError(w, \"Failed to read request body\", http.StatusBadRequest)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("event, err := webhook.Verify(%q, r.Header, body)", resource.Webhook.Provider)
+	g.writeLine("if err != nil {")
+	g.indent++
+	g.writeLine("status := http.StatusBadRequest")
+	g.writeLine("if errors.Is(err, webhook.ErrNotConfigured) {")
+	g.indent++
+	g.writeLine("status = http.StatusInternalServerError")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("respondWithError(w, err.Error(), status)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("// Redeliveries of a recorded event are acknowledged without processing")
+	g.writeLine("seenQuery := `%s`", seenQuery)
+	g.writeLine("var seen bool")
+	g.writeLine("if err := db.QueryRowContext(ctx, seenQuery, event.ID).Scan(&seen); err != nil {")
+	g.indent++
+	g.writeLine("respondWithError(w, \"Failed to look up event\", http.StatusInternalServerError)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("if !seen {")
+	g.indent++
+	g.writeLine("%s := models.%s{", receiverName, resource.Name)
+	g.indent++
+	g.writeLine("%s: event.ID,", g.toGoFieldName(ast.WebhookEventIDField))
+	g.writeLine("%s: event.Type,", g.toGoFieldName(ast.WebhookEventTypeField))
+	g.writeLine("%s: body,", g.toGoFieldName(ast.WebhookPayloadField))
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+	g.writeLine("// Record and process the event (includes validation and hooks)")
+	g.writeLine("if err := %s.Create(ctx, db); err != nil {", receiverName)
+	g.indent++
+	g.writeLine("// A concurrent delivery of the same event may have recorded it first")
+	g.writeLine("if db.QueryRowContext(ctx, seenQuery, event.ID).Scan(&seen) != nil || !seen {")
+	g.indent++
+	g.writeLine("// A failure tells the provider to redeliver the event later")
+	g.writeLine("respondWithError(w, fmt.Sprintf(\"Failed to process event: %v\", err), http.StatusInternalServerError)")
+	g.writeLine("return")
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+	g.writeLine("")
+
+	g.writeLine("w.Header().Set(\"Content-Type\", \"application/json\")")
+	g.writeLine("w.WriteHeader(http.StatusOK)")
+	g.writeLine("json.NewEncoder(w).Encode(map[string]bool{\"received\": true})")
+
+	g.indent--
+	g.writeLine("}")
+	g.indent--
+	g.writeLine("}")
+}
@@ -0,0 +1,59 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+const webhookSource = `resource StripeEvent {
+  @webhook(stripe)
+
+  id: uuid! @primary @auto
+  event_id: string! @unique
+  event_type: string!
+  payload: json!
+}`
+
+func TestGenerateHandlers_Webhook(t *testing.T) {
+	code := generateHandlers(t, parseResource(t, webhookSource))
+
+	if !strings.Contains(code, `"github.com/conduit-lang/conduit/pkg/web/webhook"`) {
+		t.Error("Missing webhook import")
+	}
+	if !strings.Contains(code, `r.Post("/webhooks/stripe", WebhookStripeEventHandler(db))`) {
+		t.Error("Missing webhook route registration")
+	}
+
+	handler := functionBody(t, code, "func WebhookStripeEventHandler(db *sql.DB) http.HandlerFunc {")
+	assertContains(t, handler,
+		"io.ReadAll(http.MaxBytesReader(w, r.Body, webhook.MaxBodySize))",
+		`event, err := webhook.Verify("stripe", r.Header, body)`,
+		"errors.Is(err, webhook.ErrNotConfigured)",
+		"SELECT EXISTS (SELECT 1 FROM stripeevents WHERE event_id = $1)",
+		"EventID: event.ID,",
+		"EventType: event.Type,",
+		"Payload: body,",
+		"s.Create(ctx, db)",
+		`fmt.Sprintf("Failed to process event: %v", err)`,
+		`map[string]bool{"received": true}`,
+	)
+
+	// The event is verified before anything is read from or written to the database
+	if strings.Index(handler, "webhook.Verify") > strings.Index(handler, "db.QueryRowContext") {
+		t.Error("Webhook handler should verify the signature before querying")
+	}
+	// Only events not yet recorded reach Create and its hooks
+	if strings.Index(handler, "if !seen {") > strings.Index(handler, "s.Create(ctx, db)") {
+		t.Error("Webhook handler should skip recorded events before Create")
+	}
+}
+
+func TestGenerateHandlers_NoWebhook(t *testing.T) {
+	resource := parseResource(t, webhookSource)
+	resource.Webhook = nil
+
+	code := generateHandlers(t, resource)
+	if strings.Contains(code, "webhook") {
+		t.Error("Handlers without @webhook should not reference webhook")
+	}
+}
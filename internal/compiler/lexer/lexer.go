@@ -641,7 +641,7 @@ func IsKeyword(s string) bool {
 
 // IsType checks if a token type represents a primitive type
 func IsType(t TokenType) bool {
-	return t >= TOKEN_STRING && t <= TOKEN_JSON
+	return t >= TOKEN_STRING && t <= TOKEN_POLYGON
 }
 
 // IsPrimitiveType checks if a string is a primitive type name
@@ -652,7 +652,7 @@ func IsPrimitiveType(s string) bool {
 		"timestamp": true, "date": true, "time": true,
 		"uuid": true, "ulid": true,
 		"email": true, "url": true, "phone": true,
-		"json":  true,
+		"json": true, "point": true, "polygon": true,
 		"array": true, "hash": true, "enum": true,
 	}
 	return primitiveTypes[s]
@@ -42,26 +42,58 @@ const (
 	TOKEN_HAS   // has
 
 	// Keywords - Annotations
-	TOKEN_HAS_MANY    // @has_many
-	TOKEN_NESTED      // @nested
-	TOKEN_MIDDLEWARE  // @middleware
-	TOKEN_FUNCTION    // @function
-	TOKEN_VALIDATE    // @validate
-	TOKEN_CONSTRAINT  // @constraint
-	TOKEN_INVARIANT   // @invariant
-	TOKEN_COMPUTED    // @computed
-	TOKEN_SCOPE       // @scope
-	TOKEN_OPERATIONS  // @operations
-	TOKEN_PRIMARY     // @primary
-	TOKEN_AUTO        // @auto
-	TOKEN_AUTO_UPDATE // @auto_update
-	TOKEN_UNIQUE      // @unique
-	TOKEN_REQUIRED    // @required (deprecated but recognized)
-	TOKEN_DEFAULT     // @default
-	TOKEN_MIN         // @min
-	TOKEN_MAX         // @max
-	TOKEN_PATTERN     // @pattern
-	TOKEN_STRICT      // @strict
+	TOKEN_HAS_MANY      // @has_many
+	TOKEN_NESTED        // @nested
+	TOKEN_MIDDLEWARE    // @middleware
+	TOKEN_FUNCTION      // @function
+	TOKEN_VALIDATE      // @validate
+	TOKEN_CONSTRAINT    // @constraint
+	TOKEN_INVARIANT     // @invariant
+	TOKEN_COMPUTED      // @computed
+	TOKEN_SCOPE         // @scope
+	TOKEN_OPERATIONS    // @operations
+	TOKEN_PRIMARY       // @primary
+	TOKEN_AUTO          // @auto
+	TOKEN_AUTO_UPDATE   // @auto_update
+	TOKEN_UNIQUE        // @unique
+	TOKEN_REQUIRED      // @required (deprecated but recognized)
+	TOKEN_DEFAULT       // @default
+	TOKEN_MIN           // @min
+	TOKEN_MAX           // @max
+	TOKEN_PATTERN       // @pattern
+	TOKEN_STRICT        // @strict
+	TOKEN_ALIAS         // @alias
+	TOKEN_COUNT         // @count
+	TOKEN_COLUMN        // @column
+	TOKEN_FILTERABLE    // @filterable
+	TOKEN_SORTABLE      // @sortable
+	TOKEN_DUAL_WRITE    // @dual_write
+	TOKEN_SLO           // @slo
+	TOKEN_CACHE_CONTROL // @cache_control
+	TOKEN_CHANGES       // @changes
+	TOKEN_CONFLICT      // @conflict
+	TOKEN_PARTITION     // @partition
+	TOKEN_MATERIALIZED  // @materialized
+	TOKEN_COUNTER_CACHE // @counter_cache
+	TOKEN_SEARCH_INDEX  // @search_index
+	TOKEN_WEBHOOK       // @webhook
+	TOKEN_PROFILE       // @profile
+	TOKEN_UPSERT        // @upsert
+	TOKEN_ARCHIVABLE    // @archivable
+	TOKEN_ORDERABLE     // @orderable
+	TOKEN_TREE          // @tree
+	TOKEN_ID            // @id
+	TOKEN_TIMESTAMPS    // @timestamps
+	TOKEN_SCHEMA        // @schema
+	TOKEN_EXTERNAL      // @external_table
+	TOKEN_OWNER         // @owner
+	TOKEN_STABILITY     // @stability
+	TOKEN_META          // @meta
+	TOKEN_SHARD         // @shard
+	TOKEN_LABELS        // @labels
+	TOKEN_SOFT_DELETE   // @soft_delete
+	TOKEN_DEFAULT_SCOPE // @default_scope
+	TOKEN_INDEX         // @index
 
 	// Keywords - Control flow
 	TOKEN_IF        // if
@@ -104,6 +136,8 @@ const (
 	TOKEN_URL       // url
 	TOKEN_PHONE     // phone
 	TOKEN_JSON      // json
+	TOKEN_POINT     // point
+	TOKEN_POLYGON   // polygon
 
 	// Literals
 	TOKEN_IDENTIFIER     // user_name, slugify, etc.
@@ -196,6 +230,38 @@ var TokenTypeNames = map[TokenType]string{
 	TOKEN_MAX:                 "MAX",
 	TOKEN_PATTERN:             "PATTERN",
 	TOKEN_STRICT:              "STRICT",
+	TOKEN_ALIAS:               "ALIAS",
+	TOKEN_COUNT:               "COUNT",
+	TOKEN_COLUMN:              "COLUMN",
+	TOKEN_FILTERABLE:          "FILTERABLE",
+	TOKEN_SORTABLE:            "SORTABLE",
+	TOKEN_DUAL_WRITE:          "DUAL_WRITE",
+	TOKEN_SLO:                 "SLO",
+	TOKEN_CACHE_CONTROL:       "CACHE_CONTROL",
+	TOKEN_CHANGES:             "CHANGES",
+	TOKEN_CONFLICT:            "CONFLICT",
+	TOKEN_PARTITION:           "PARTITION",
+	TOKEN_MATERIALIZED:        "MATERIALIZED",
+	TOKEN_COUNTER_CACHE:       "COUNTER_CACHE",
+	TOKEN_SEARCH_INDEX:        "SEARCH_INDEX",
+	TOKEN_WEBHOOK:             "WEBHOOK",
+	TOKEN_PROFILE:             "PROFILE",
+	TOKEN_UPSERT:              "UPSERT",
+	TOKEN_ARCHIVABLE:          "ARCHIVABLE",
+	TOKEN_ORDERABLE:           "ORDERABLE",
+	TOKEN_TREE:                "TREE",
+	TOKEN_ID:                  "ID",
+	TOKEN_TIMESTAMPS:          "TIMESTAMPS",
+	TOKEN_SCHEMA:              "SCHEMA",
+	TOKEN_EXTERNAL:            "EXTERNAL_TABLE",
+	TOKEN_OWNER:               "OWNER",
+	TOKEN_STABILITY:           "STABILITY",
+	TOKEN_META:                "META",
+	TOKEN_SHARD:               "SHARD",
+	TOKEN_LABELS:              "LABELS",
+	TOKEN_SOFT_DELETE:         "SOFT_DELETE",
+	TOKEN_DEFAULT_SCOPE:       "DEFAULT_SCOPE",
+	TOKEN_INDEX:               "INDEX",
 	TOKEN_IF:                  "IF",
 	TOKEN_ELSIF:               "ELSIF",
 	TOKEN_ELSE:                "ELSE",
@@ -232,6 +298,8 @@ var TokenTypeNames = map[TokenType]string{
 	TOKEN_URL:                 "URL",
 	TOKEN_PHONE:               "PHONE",
 	TOKEN_JSON:                "JSON",
+	TOKEN_POINT:               "POINT",
+	TOKEN_POLYGON:             "POLYGON",
 	TOKEN_IDENTIFIER:          "IDENTIFIER",
 	TOKEN_INT_LITERAL:         "INT_LITERAL",
 	TOKEN_FLOAT_LITERAL:       "FLOAT_LITERAL",
@@ -364,6 +432,8 @@ var Keywords = map[string]TokenType{
 	"url":       TOKEN_URL,
 	"phone":     TOKEN_PHONE,
 	"json":      TOKEN_JSON,
+	"point":     TOKEN_POINT,
+	"polygon":   TOKEN_POLYGON,
 
 	// Boolean literals
 	"true":  TOKEN_TRUE,
@@ -394,16 +464,48 @@ var AnnotationKeywords = map[string]TokenType{
 	"operations": TOKEN_OPERATIONS,
 
 	// Field annotations
-	"primary":     TOKEN_PRIMARY,
-	"auto":        TOKEN_AUTO,
-	"auto_update": TOKEN_AUTO_UPDATE,
-	"unique":      TOKEN_UNIQUE,
-	"required":    TOKEN_REQUIRED,
-	"default":     TOKEN_DEFAULT,
-	"min":         TOKEN_MIN,
-	"max":         TOKEN_MAX,
-	"pattern":     TOKEN_PATTERN,
-	"strict":      TOKEN_STRICT,
+	"primary":        TOKEN_PRIMARY,
+	"auto":           TOKEN_AUTO,
+	"auto_update":    TOKEN_AUTO_UPDATE,
+	"unique":         TOKEN_UNIQUE,
+	"required":       TOKEN_REQUIRED,
+	"default":        TOKEN_DEFAULT,
+	"min":            TOKEN_MIN,
+	"max":            TOKEN_MAX,
+	"pattern":        TOKEN_PATTERN,
+	"strict":         TOKEN_STRICT,
+	"alias":          TOKEN_ALIAS,
+	"count":          TOKEN_COUNT,
+	"column":         TOKEN_COLUMN,
+	"filterable":     TOKEN_FILTERABLE,
+	"sortable":       TOKEN_SORTABLE,
+	"dual_write":     TOKEN_DUAL_WRITE,
+	"slo":            TOKEN_SLO,
+	"cache_control":  TOKEN_CACHE_CONTROL,
+	"changes":        TOKEN_CHANGES,
+	"conflict":       TOKEN_CONFLICT,
+	"partition":      TOKEN_PARTITION,
+	"materialized":   TOKEN_MATERIALIZED,
+	"counter_cache":  TOKEN_COUNTER_CACHE,
+	"search_index":   TOKEN_SEARCH_INDEX,
+	"webhook":        TOKEN_WEBHOOK,
+	"profile":        TOKEN_PROFILE,
+	"upsert":         TOKEN_UPSERT,
+	"archivable":     TOKEN_ARCHIVABLE,
+	"orderable":      TOKEN_ORDERABLE,
+	"tree":           TOKEN_TREE,
+	"id":             TOKEN_ID,
+	"timestamps":     TOKEN_TIMESTAMPS,
+	"schema":         TOKEN_SCHEMA,
+	"external_table": TOKEN_EXTERNAL,
+	"owner":          TOKEN_OWNER,
+	"stability":      TOKEN_STABILITY,
+	"meta":           TOKEN_META,
+	"shard":          TOKEN_SHARD,
+	"labels":         TOKEN_LABELS,
+	"soft_delete":    TOKEN_SOFT_DELETE,
+	"default_scope":  TOKEN_DEFAULT_SCOPE,
+	"index":          TOKEN_INDEX,
 }
 
 // LexError represents an error encountered during lexical analysis
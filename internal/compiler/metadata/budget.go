@@ -0,0 +1,78 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultResourceBudget is the compressed size, in bytes, the metadata of a
+// single resource is expected to stay under
+const DefaultResourceBudget = 2048
+
+// ResourceSize is the size of one resource's metadata
+type ResourceSize struct {
+	Resource     string
+	Uncompressed int // JSON bytes
+	Compressed   int // Gzip-compressed JSON bytes
+	HookSource   int // Bytes of hook source code in the uncompressed JSON
+}
+
+// ResourceSizes measures the metadata of each resource on its own, in the
+// order of the resources. Each resource is compressed separately, so the
+// sizes are comparable across builds however many resources there are.
+func ResourceSizes(metadata *Metadata) ([]ResourceSize, error) {
+	if metadata == nil {
+		return nil, fmt.Errorf("metadata cannot be nil")
+	}
+
+	sizes := make([]ResourceSize, 0, len(metadata.Resources))
+	for _, resource := range metadata.Resources {
+		data, err := json.Marshal(resource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize resource %s: %w", resource.Name, err)
+		}
+		compressed, err := Compress(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress resource %s: %w", resource.Name, err)
+		}
+
+		size := ResourceSize{
+			Resource:     resource.Name,
+			Uncompressed: len(data),
+			Compressed:   len(compressed),
+		}
+		for _, hook := range resource.Hooks {
+			size.HookSource += len(hook.SourceCode)
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
+// OverBudget returns the resources whose compressed metadata exceeds budget
+// bytes. A budget of zero or less uses DefaultResourceBudget.
+func OverBudget(metadata *Metadata, budget int) ([]ResourceSize, error) {
+	if budget <= 0 {
+		budget = DefaultResourceBudget
+	}
+
+	sizes, err := ResourceSizes(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var over []ResourceSize
+	for _, size := range sizes {
+		if size.Compressed > budget {
+			over = append(over, size)
+		}
+	}
+	return over, nil
+}
+
+// StripSource replaces the source code of every hook with its SHA-256 hash,
+// so builds can tell hooks apart without embedding their bodies.
+func StripSource(metadata *Metadata) {
+	// A hashed source policy is always valid
+	_ = Redact(metadata, RedactionPolicy{Source: RedactHashed})
+}
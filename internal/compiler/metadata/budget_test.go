@@ -0,0 +1,106 @@
+package metadata
+
+import (
+	"strings"
+	"testing"
+)
+
+func budgetTestMetadata(hookSource string) *Metadata {
+	return &Metadata{
+		Version: "1.0.0",
+		Resources: []ResourceMetadata{
+			{
+				Name:   "Post",
+				Fields: []FieldMetadata{{Name: "title", Type: "string!"}},
+				Hooks: []HookMetadata{
+					{Timing: "before", Event: "create", SourceCode: hookSource},
+					{Timing: "after", Event: "create"},
+				},
+			},
+			{
+				Name:   "User",
+				Fields: []FieldMetadata{{Name: "email", Type: "email!"}},
+			},
+		},
+	}
+}
+
+// incompressible returns source that gzip cannot shrink much
+func incompressible(n int) string {
+	var b strings.Builder
+	state := uint32(1)
+	for b.Len() < n {
+		state = state*1664525 + 1013904223
+		b.WriteByte(byte('!' + state>>24%90))
+	}
+	return b.String()
+}
+
+func TestResourceSizes(t *testing.T) {
+	sizes, err := ResourceSizes(budgetTestMetadata("self.slug = String.slugify(self.title)"))
+	if err != nil {
+		t.Fatalf("ResourceSizes() error = %v", err)
+	}
+	if len(sizes) != 2 || sizes[0].Resource != "Post" || sizes[1].Resource != "User" {
+		t.Fatalf("sizes = %+v", sizes)
+	}
+	if sizes[0].HookSource != len("self.slug = String.slugify(self.title)") || sizes[1].HookSource != 0 {
+		t.Errorf("hook source = %d, %d", sizes[0].HookSource, sizes[1].HookSource)
+	}
+	for _, size := range sizes {
+		if size.Uncompressed == 0 || size.Compressed == 0 {
+			t.Errorf("%s: empty size %+v", size.Resource, size)
+		}
+	}
+
+	if _, err := ResourceSizes(nil); err == nil {
+		t.Error("expected an error for nil metadata")
+	}
+}
+
+func TestOverBudget(t *testing.T) {
+	meta := budgetTestMetadata(incompressible(4 * DefaultResourceBudget))
+
+	over, err := OverBudget(meta, 0)
+	if err != nil {
+		t.Fatalf("OverBudget() error = %v", err)
+	}
+	if len(over) != 1 || over[0].Resource != "Post" || over[0].Compressed <= DefaultResourceBudget {
+		t.Errorf("over = %+v", over)
+	}
+
+	over, err = OverBudget(meta, 1<<20)
+	if err != nil || len(over) != 0 {
+		t.Errorf("with a large budget: over = %+v, err = %v", over, err)
+	}
+
+	StripSource(meta)
+	over, err = OverBudget(meta, 0)
+	if err != nil || len(over) != 0 {
+		t.Errorf("after StripSource: over = %+v, err = %v", over, err)
+	}
+}
+
+func TestStripSource(t *testing.T) {
+	meta := budgetTestMetadata("self.slug = String.slugify(self.title)")
+	StripSource(meta)
+
+	hooks := meta.Resources[0].Hooks
+	if hooks[0].SourceCode != "" {
+		t.Errorf("source code = %q", hooks[0].SourceCode)
+	}
+	if !strings.HasPrefix(hooks[0].SourceHash, "sha256:") || len(hooks[0].SourceHash) != len("sha256:")+64 {
+		t.Errorf("source hash = %q", hooks[0].SourceHash)
+	}
+	if hooks[1].SourceHash != "" {
+		t.Errorf("a hook without a body got hash %q", hooks[1].SourceHash)
+	}
+
+	other := budgetTestMetadata("self.slug = String.slugify(self.title)")
+	StripSource(other)
+	if other.Resources[0].Hooks[0].SourceHash != hooks[0].SourceHash {
+		t.Error("the same body hashed differently")
+	}
+
+	StripSource(nil)
+}
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/pkg/web/cache"
+	"github.com/conduit-lang/conduit/pkg/web/methods"
 )
 
 // Extractor extracts introspection metadata from an AST
@@ -70,7 +73,11 @@ func (e *Extractor) Extract(prog *ast.Program) (*Metadata, error) {
 	// Extract patterns from all resources
 	meta.Patterns = e.extractPatterns(prog.Resources)
 
+	// Group co-located @shard resources
+	meta.Shards = extractShards(prog.Resources)
+
 	// Add generated routes
+	allowMethods(e.routes)
 	meta.Routes = e.routes
 
 	// Compute source hash for change detection
@@ -155,6 +162,13 @@ func (e *Extractor) computeSourceHash(prog *ast.Program) string {
 			}
 		}
 
+		// Hash index blocks
+		for _, index := range resource.Indexes {
+			h.Write([]byte(strings.Join(index.Fields, ",")))
+			h.Write([]byte(fmt.Sprintf("%t", index.Unique)))
+			h.Write([]byte(index.Name))
+		}
+
 		// Hash scopes
 		for _, scope := range resource.Scopes {
 			h.Write([]byte(scope.Name))
@@ -184,6 +198,12 @@ func (e *Extractor) computeSourceHash(prog *ast.Program) string {
 		// Hash operations
 		for _, op := range resource.Operations {
 			h.Write([]byte(op))
+			for _, mod := range resource.OperationMods[op] {
+				h.Write([]byte(mod))
+			}
+		}
+		for _, op := range resource.Excluded {
+			h.Write([]byte("except " + op))
 		}
 
 		// Hash middleware
@@ -211,17 +231,50 @@ func (e *Extractor) extractResource(resource *ast.ResourceNode) (ResourceMetadat
 		Computed:      make([]ComputedMetadata, 0, len(resource.Computed)),
 		Operations:    resource.Operations,
 		Middleware:    resource.Middleware,
+		Aliases:       resource.Aliases,
+		CountStrategy: resource.CountStrategy,
+		SLO:           extractSLO(resource.SLO),
+		CacheControl:  extractCacheControl(resource),
+		Changes:       extractChanges(resource),
+		Conflict:      extractConflict(resource),
+		Partition:     extractPartition(resource.Partition),
+		Materialized:  extractMaterialized(resource.Materialized),
+		CounterCaches: extractCounterCaches(resource),
+		SearchIndex:   extractSearchIndex(resource),
+		Indexes:       extractIndexes(resource),
+		Profiles:      extractProfiles(resource),
+		Archivable:    resource.Archivable != nil,
+		SoftDelete:    resource.SoftDelete != nil,
+		DefaultScope:  e.extractDefaultScope(resource.DefaultScope),
+		Orderable:     extractOrderable(resource.Orderable),
+		Tree:          extractTree(resource),
+		Schema:        extractSchema(resource.Schema),
+		External:      extractExternal(resource.External),
+		Owner:         extractOwner(resource.Owner),
+		Stability:     extractStability(resource.Stability),
+		Custom:        extractCustom(resource.Meta),
 	}
 
 	// Extract fields
+	filterable := stringSet(resource.FilterableFields())
+	sortable := stringSet(resource.SortableFields())
 	for _, field := range resource.Fields {
 		fieldMeta := e.extractField(field)
+		fieldMeta.Filterable = filterable[field.Name]
+		fieldMeta.Sortable = sortable[field.Name]
+		if field.Name == "id" && resource.IDStrategy != nil {
+			fieldMeta.IDStrategy = resource.IDStrategy.Strategy
+		}
 		resMeta.Fields = append(resMeta.Fields, fieldMeta)
 	}
 
 	// Extract relationships
 	for _, rel := range resource.Relationships {
 		relMeta := e.extractRelationship(rel)
+		// A has_one foreign key is a column of the related resource
+		if rel.Kind == ast.RelationshipHasOne {
+			relMeta.ForeignKey = rel.TargetForeignKey(resource.Name)
+		}
 		resMeta.Relationships = append(resMeta.Relationships, relMeta)
 	}
 
@@ -265,10 +318,35 @@ func (e *Extractor) extractField(field *ast.FieldNode) FieldMetadata {
 		Type:        e.formatType(field.Type),
 		Nullable:    field.Nullable,
 		Constraints: make([]string, 0),
+		Custom:      extractCustom(field.Meta),
+		Labels:      extractLabels(field.Labels),
 	}
 
 	// Extract constraints
 	for _, constraint := range field.Constraints {
+		if constraint.Name == "alias" {
+			for _, arg := range constraint.Arguments {
+				if lit, ok := arg.(*ast.LiteralExpr); ok {
+					if alias, ok := lit.Value.(string); ok {
+						fieldMeta.Aliases = append(fieldMeta.Aliases, alias)
+					}
+				}
+			}
+			continue
+		}
+		if constraint.Name == "column" {
+			fieldMeta.Column = field.ColumnOverride()
+			continue
+		}
+		if constraint.Name == "dual_write" {
+			column, until := field.DualWrite()
+			fieldMeta.DualWrite = &DualWriteMetadata{Column: column, Until: until}
+			continue
+		}
+		if constraint.Name == "filterable" || constraint.Name == "sortable" {
+			continue // Reported as Filterable/Sortable by extractResource
+		}
+
 		constraintStr := e.formatConstraint(constraint)
 		fieldMeta.Constraints = append(fieldMeta.Constraints, constraintStr)
 	}
@@ -278,6 +356,10 @@ func (e *Extractor) extractField(field *ast.FieldNode) FieldMetadata {
 		fieldMeta.Default = e.formatExpression(field.Default)
 	}
 
+	if geometry := field.Geometry(); geometry != "" {
+		fieldMeta.Geometry = &GeometryMetadata{Type: geometry, Format: "geojson", SRID: 4326}
+	}
+
 	return fieldMeta
 }
 
@@ -298,12 +380,23 @@ func (e *Extractor) extractRelationship(rel *ast.RelationshipNode) RelationshipM
 func (e *Extractor) extractHook(hook *ast.HookNode) HookMetadata {
 	// Format hook body as source code
 	sourceCode := e.formatHookBody(hook.Body)
+	retry, backoff := hook.Retries()
+	timeout := ""
+	if hook.Timeout > 0 {
+		timeout = hook.Timeout.String()
+	}
 
 	return HookMetadata{
 		Timing:         hook.Timing,
 		Event:          hook.Event,
+		Relationship:   hook.Relationship,
+		Batch:          hook.Batch,
 		HasTransaction: hook.IsTransaction,
 		HasAsync:       hook.IsAsync,
+		Policy:         hook.ErrorPolicy(),
+		Retry:          retry,
+		Backoff:        backoff,
+		Timeout:        timeout,
 		SourceCode:     sourceCode,
 		Line:           hook.Loc.Line,
 		Middleware:     hook.Middleware,
@@ -663,6 +756,13 @@ func (e *Extractor) formatLiteral(value interface{}) string {
 	}
 }
 
+// PatternID returns the identifier of the pattern with the given template:
+// a hash of the template, so the same pattern keeps its ID across builds
+func PatternID(template string) string {
+	sum := sha256.Sum256([]byte(template))
+	return "pattern-" + hex.EncodeToString(sum[:8])
+}
+
 // extractPatterns identifies common patterns across resources
 func (e *Extractor) extractPatterns(resources []*ast.ResourceNode) []PatternMetadata {
 	patterns := make(map[string]*PatternMetadata)
@@ -746,6 +846,7 @@ func (e *Extractor) extractPatterns(resources []*ast.ResourceNode) []PatternMeta
 	// Convert map to sorted slice
 	result := make([]PatternMetadata, 0, len(patterns))
 	for _, p := range patterns {
+		p.ID = PatternID(p.Template)
 		result = append(result, *p)
 	}
 
@@ -766,16 +867,37 @@ func (e *Extractor) extractPatterns(resources []*ast.ResourceNode) []PatternMeta
 //   - update: PUT    /resources/:id
 //   - delete: DELETE /resources/:id
 //
+// Resources with a natural key are addressed by it instead of :id, such as
+// /countries/:code or /memberships/:region/:code.
+//
 // Operation Filtering:
 //   - If resource.Operations is empty, all 5 standard operations are generated
-//   - If resource.Operations is set, only specified operations are generated
+//   - If resource.Operations is set, only specified operations are generated;
+//     show is accepted for get
+//   - @operations all except [...] generates all but the excluded operations
 //   - Unknown operation names are silently ignored (they don't match any standard route)
 //
 // Middleware:
 //   - Resource-level middleware (resource.Middleware) is applied to all routes
+//   - Operation modifiers such as create(admin_only) are appended to the
+//     middleware of that operation's route
 //   - Per-operation middleware (@on <op>: [mw]) is not yet supported
 //     TODO(CON-56): Implement per-operation middleware extraction when AST supports it
-//     This requires adding OperationMiddleware map[string][]string to ResourceNode
+//
+// Webhook Routes:
+//   - @webhook(provider) generates: POST /webhooks/provider
+//
+// Upsert Routes:
+//   - @upsert(on: [field]) generates: PUT /resources:upsert
+//
+// Archive Routes:
+//   - @archivable generates: POST /resources/:id/archive and POST /resources/:id/restore
+//
+// Move Routes:
+//   - @orderable generates: POST /resources/:id/move
+//
+// Tree Routes:
+//   - @tree generates: GET /resources/:id/children and GET /resources/:id/ancestors
 //
 // Nested Routes:
 //   - Has-many relationships generate: GET /parents/:id/children
@@ -789,6 +911,7 @@ func (e *Extractor) extractPatterns(resources []*ast.ResourceNode) []PatternMeta
 //   - See toPlural() for full pluralization logic and limitations
 func (e *Extractor) generateRoutes(resource *ast.ResourceNode) {
 	resourcePath := e.toPlural(strings.ToLower(resource.Name))
+	memberPath := "/" + resourcePath + "/" + resource.MemberPattern()
 
 	// Map of operation to route configuration
 	type routeConfig struct {
@@ -809,7 +932,7 @@ func (e *Extractor) generateRoutes(resource *ast.ResourceNode) {
 		},
 		"get": {
 			method:      "GET",
-			path:        "/" + resourcePath + "/:id",
+			path:        memberPath,
 			handler:     resource.Name + ".get",
 			operation:   "get",
 			description: fmt.Sprintf("Get a single %s by ID", resource.Name),
@@ -823,37 +946,27 @@ func (e *Extractor) generateRoutes(resource *ast.ResourceNode) {
 		},
 		"update": {
 			method:      "PUT",
-			path:        "/" + resourcePath + "/:id",
+			path:        memberPath,
 			handler:     resource.Name + ".update",
 			operation:   "update",
 			description: fmt.Sprintf("Update an existing %s", resource.Name),
 		},
 		"delete": {
 			method:      "DELETE",
-			path:        "/" + resourcePath + "/:id",
+			path:        memberPath,
 			handler:     resource.Name + ".delete",
 			operation:   "delete",
 			description: fmt.Sprintf("Delete a %s", resource.Name),
 		},
 	}
 
-	// Determine which operations to generate routes for
-	allowedOps := make(map[string]bool)
-	if len(resource.Operations) > 0 {
-		// If @operations is specified, only generate routes for those operations
-		for _, op := range resource.Operations {
-			allowedOps[op] = true
-		}
-	} else {
-		// If no @operations restriction, generate all standard routes
-		for op := range standardRoutes {
-			allowedOps[op] = true
-		}
-	}
-
-	// Generate standard REST routes
+	// Generate standard REST routes for the operations @operations allows
 	for opName, config := range standardRoutes {
-		if !allowedOps[opName] {
+		if !resource.AllowsOperation(opName) {
+			continue
+		}
+		// @materialized views and @external_table resources are read-only
+		if resource.ReadOnly() && opName != "list" && opName != "get" {
 			continue
 		}
 
@@ -863,12 +976,81 @@ func (e *Extractor) generateRoutes(resource *ast.ResourceNode) {
 			Handler:     config.handler,
 			Resource:    resource.Name,
 			Operation:   config.operation,
-			Middleware:  resource.Middleware,
+			Middleware:  resource.OperationMiddleware(opName),
 			Description: config.description,
 		}
 		e.routes = append(e.routes, route)
 	}
 
+	// Generate the webhook receiver route (@webhook). Deliveries are
+	// authenticated by their signature, so resource middleware is not applied.
+	if resource.Webhook != nil {
+		e.routes = append(e.routes, RouteMetadata{
+			Method:      "POST",
+			Path:        "/webhooks/" + resource.Webhook.Provider,
+			Handler:     resource.Name + ".webhook",
+			Resource:    resource.Name,
+			Operation:   "webhook",
+			Description: fmt.Sprintf("Receive signed %s events", resource.Webhook.Provider),
+		})
+	}
+
+	// Generate the upsert route (@upsert), which writes like create and update
+	if resource.Upsert != nil && !resource.ReadOnly() {
+		e.routes = append(e.routes, RouteMetadata{
+			Method:      "PUT",
+			Path:        "/" + resourcePath + ":upsert",
+			Handler:     resource.Name + ".upsert",
+			Resource:    resource.Name,
+			Operation:   "upsert",
+			Middleware:  resource.OperationMiddleware("upsert"),
+			Description: fmt.Sprintf("Create a %s or update the one with the same %s", resource.Name, strings.Join(resource.Upsert.Fields, ", ")),
+		})
+	}
+
+	// Generate the archive and restore routes (@archivable)
+	if resource.Archivable != nil && !resource.ReadOnly() {
+		for _, action := range []string{"archive", "restore"} {
+			e.routes = append(e.routes, RouteMetadata{
+				Method:      "POST",
+				Path:        memberPath + "/" + action,
+				Handler:     resource.Name + "." + action,
+				Resource:    resource.Name,
+				Operation:   action,
+				Middleware:  resource.OperationMiddleware(action),
+				Description: fmt.Sprintf("%s a %s", strings.ToUpper(action[:1])+action[1:], resource.Name),
+			})
+		}
+	}
+
+	// Generate the move route (@orderable)
+	if resource.Orderable != nil && !resource.ReadOnly() {
+		e.routes = append(e.routes, RouteMetadata{
+			Method:      "POST",
+			Path:        memberPath + "/move",
+			Handler:     resource.Name + ".move",
+			Resource:    resource.Name,
+			Operation:   "move",
+			Middleware:  resource.OperationMiddleware("move"),
+			Description: fmt.Sprintf("Move a %s before or after another", resource.Name),
+		})
+	}
+
+	// Generate the children and ancestors routes (@tree), which only read
+	if resource.TreeParent() != nil {
+		for _, direction := range []string{"children", "ancestors"} {
+			e.routes = append(e.routes, RouteMetadata{
+				Method:      "GET",
+				Path:        memberPath + "/" + direction,
+				Handler:     resource.Name + "." + direction,
+				Resource:    resource.Name,
+				Operation:   direction,
+				Middleware:  resource.Middleware,
+				Description: fmt.Sprintf("List the %s of a %s", direction, resource.Name),
+			})
+		}
+	}
+
 	// Generate nested resource routes for has_many relationships
 	for _, rel := range resource.Relationships {
 		if rel.Kind == ast.RelationshipHasMany {
@@ -877,6 +1059,18 @@ func (e *Extractor) generateRoutes(resource *ast.ResourceNode) {
 	}
 }
 
+// allowMethods sets the Allow list of each route from the methods of every
+// route on its path, as generated applications answer OPTIONS
+func allowMethods(routes []RouteMetadata) {
+	byPath := make(map[string][]string)
+	for _, route := range routes {
+		byPath[route.Path] = append(byPath[route.Path], route.Method)
+	}
+	for i := range routes {
+		routes[i].Allow = methods.Allow(byPath[routes[i].Path])
+	}
+}
+
 // generateNestedRoutes generates nested routes for has_many relationships.
 // Example: GET /posts/:post_id/comments
 func (e *Extractor) generateNestedRoutes(parent *ast.ResourceNode, rel *ast.RelationshipNode) {
@@ -886,7 +1080,7 @@ func (e *Extractor) generateNestedRoutes(parent *ast.ResourceNode, rel *ast.Rela
 	// Nested list route: GET /parents/:parent_id/children
 	route := RouteMetadata{
 		Method:      "GET",
-		Path:        fmt.Sprintf("/%s/:id/%s", parentPath, childPath),
+		Path:        fmt.Sprintf("/%s/%s/%s", parentPath, parent.MemberPattern(), childPath),
 		Handler:     fmt.Sprintf("%s.%s.list", parent.Name, rel.Name),
 		Resource:    parent.Name,
 		Operation:   fmt.Sprintf("list_%s", rel.Name),
@@ -956,3 +1150,273 @@ func (e *Extractor) toPlural(singular string) string {
 func isVowel(c byte) bool {
 	return c == 'a' || c == 'e' || c == 'i' || c == 'o' || c == 'u'
 }
+
+// stringSet returns a membership set of values
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// extractSLO converts @slo objectives to metadata
+func extractSLO(slo *ast.SLONode) *SLOMetadata {
+	if slo == nil {
+		return nil
+	}
+	meta := &SLOMetadata{Availability: slo.Availability}
+	for _, objective := range slo.Latency {
+		meta.Latency = append(meta.Latency, LatencyObjectiveMetadata{
+			Percentile:  objective.Percentile,
+			ThresholdMs: float64(objective.Threshold) / float64(time.Millisecond),
+		})
+	}
+	return meta
+}
+
+// extractChanges describes the change feed of a @changes resource
+func extractChanges(resource *ast.ResourceNode) *ChangesMetadata {
+	if resource.Changes == nil {
+		return nil
+	}
+	meta := &ChangesMetadata{
+		// Same table name formula as extractCacheControl
+		Path:         "/" + strings.ToLower(resource.Name) + "s/changes",
+		DeletedField: ast.SoftDeleteField,
+	}
+	for _, field := range resource.Fields {
+		for _, constraint := range field.Constraints {
+			if constraint.Name == "auto_update" {
+				meta.CursorField = field.Name
+				return meta
+			}
+		}
+	}
+	return meta
+}
+
+// extractShards converts the @shard resources to one group per shard key
+func extractShards(resources []*ast.ResourceNode) []ShardMetadata {
+	var shards []ShardMetadata
+	for _, group := range ast.ShardGroups(resources) {
+		shards = append(shards, ShardMetadata{Key: group.Field, Resources: group.Resources})
+	}
+	return shards
+}
+
+// extractPartition converts @partition to metadata
+func extractPartition(partition *ast.PartitionNode) *PartitionMetadata {
+	if partition == nil {
+		return nil
+	}
+	return &PartitionMetadata{
+		Field:    partition.Field,
+		Interval: partition.Interval,
+	}
+}
+
+// extractMaterialized converts @materialized to metadata
+func extractMaterialized(materialized *ast.MaterializedNode) *MaterializedMetadata {
+	if materialized == nil {
+		return nil
+	}
+	return &MaterializedMetadata{
+		Refresh: materialized.Refresh,
+		Query:   materialized.Query,
+	}
+}
+
+// extractCounterCaches converts @counter_cache annotations to metadata
+func extractCounterCaches(resource *ast.ResourceNode) []CounterCacheMetadata {
+	var counters []CounterCacheMetadata
+	for _, counter := range resource.CounterCaches {
+		meta := CounterCacheMetadata{
+			Column:       counter.Column,
+			Resource:     counter.Resource,
+			Relationship: counter.Relationship,
+		}
+		if rel := resource.CounterCacheRelationship(counter); rel != nil {
+			meta.ForeignKey = rel.ForeignKeyColumn()
+		}
+		counters = append(counters, meta)
+	}
+	return counters
+}
+
+// extractSearchIndex converts @search_index to metadata
+func extractSearchIndex(resource *ast.ResourceNode) *SearchIndexMetadata {
+	if resource.SearchIndex == nil {
+		return nil
+	}
+	// Same table name formula as extractCacheControl
+	table := strings.ToLower(resource.Name) + "s"
+	return &SearchIndexMetadata{
+		Index:  table,
+		Path:   "/" + table + "/search",
+		Fields: resource.SearchIndex.Fields,
+	}
+}
+
+// extractIndexes converts @index fields and index blocks to metadata, named as
+// the generated migrations name them
+func extractIndexes(resource *ast.ResourceNode) []IndexMetadata {
+	var indexes []IndexMetadata
+	for _, index := range resource.AllIndexes() {
+		columns := resource.IndexColumns(index)
+		name := index.Name
+		if name == "" {
+			// Same table name formula as extractCacheControl
+			name = "idx_" + strings.ToLower(resource.Name) + "s_" + strings.Join(columns, "_")
+		}
+		indexes = append(indexes, IndexMetadata{
+			Name:    name,
+			Fields:  index.Fields,
+			Columns: columns,
+			Unique:  index.Unique,
+		})
+	}
+	return indexes
+}
+
+// extractProfiles converts @profile to metadata, listing every field for *
+func extractProfiles(resource *ast.ResourceNode) []ProfileMetadata {
+	var profiles []ProfileMetadata
+	for _, profile := range resource.Profiles {
+		meta := ProfileMetadata{Name: profile.Name, Fields: profile.Fields, All: profile.All}
+		if profile.All {
+			meta.Fields = make([]string, 0, len(resource.Fields))
+			for _, field := range resource.Fields {
+				meta.Fields = append(meta.Fields, field.Name)
+			}
+		}
+		profiles = append(profiles, meta)
+	}
+	return profiles
+}
+
+// extractOrderable converts @orderable to metadata
+func extractOrderable(orderable *ast.OrderableNode) *OrderableMetadata {
+	if orderable == nil {
+		return nil
+	}
+	return &OrderableMetadata{Scope: orderable.Scope, Position: ast.PositionField}
+}
+
+// extractSchema returns the name given by @schema; empty for the default
+// schema
+func extractSchema(schema *ast.SchemaNode) string {
+	if schema == nil {
+		return ""
+	}
+	return schema.Name
+}
+
+// extractDefaultScope returns the condition of a @default_scope as written;
+// empty for resources without one
+func (e *Extractor) extractDefaultScope(scope *ast.DefaultScopeNode) string {
+	if scope == nil {
+		return ""
+	}
+	return e.formatExpression(scope.Condition)
+}
+
+// extractOwner returns the team owning the resource; empty when it is unowned
+func extractOwner(owner *ast.OwnerNode) string {
+	if owner == nil {
+		return ""
+	}
+	return owner.Team
+}
+
+// extractStability returns the level declared with @stability; empty when the
+// resource declares none
+func extractStability(stability *ast.StabilityNode) string {
+	if stability == nil {
+		return ""
+	}
+	return stability.Level
+}
+
+// extractCustom returns a copy of the pairs declared with @meta; nil when
+// there are none
+func extractCustom(meta *ast.MetaNode) map[string]string {
+	if meta == nil || len(meta.Values) == 0 {
+		return nil
+	}
+	custom := make(map[string]string, len(meta.Values))
+	for key, value := range meta.Values {
+		custom[key] = value
+	}
+	return custom
+}
+
+// extractLabels copies the display labels of @labels, nil without any
+func extractLabels(labels *ast.LabelsNode) map[string]string {
+	if labels == nil || len(labels.Values) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(labels.Values))
+	for value, label := range labels.Values {
+		values[value] = label
+	}
+	return values
+}
+
+// extractExternal returns the table named by @external_table; empty for a
+// table the application migrates
+func extractExternal(external *ast.ExternalTableNode) string {
+	if external == nil {
+		return ""
+	}
+	return external.Table
+}
+
+// extractTree converts @tree to metadata
+func extractTree(resource *ast.ResourceNode) *TreeMetadata {
+	rel := resource.TreeParent()
+	if rel == nil {
+		return nil
+	}
+	return &TreeMetadata{Parent: rel.Name, ForeignKey: rel.ForeignKeyColumn(), MaxDepth: resource.Tree.DepthLimit()}
+}
+
+// extractConflict converts a @conflict policy to metadata
+func extractConflict(resource *ast.ResourceNode) *ConflictMetadata {
+	if resource.Conflict == nil {
+		return nil
+	}
+	meta := &ConflictMetadata{
+		Strategy:    resource.Conflict.Strategy,
+		MergeFields: resource.Conflict.MergeFields,
+	}
+	for _, field := range resource.Fields {
+		for _, constraint := range field.Constraints {
+			if constraint.Name == "auto_update" && !field.Nullable {
+				meta.VersionField = field.Name
+				return meta
+			}
+		}
+	}
+	return meta
+}
+
+// extractCacheControl converts a @cache_control policy to metadata
+func extractCacheControl(resource *ast.ResourceNode) *CacheControlMetadata {
+	cc := resource.CacheControl
+	if cc == nil {
+		return nil
+	}
+	// Keys use the generated table name (codegen.TableName), which codegen
+	// cannot share with this package without an import cycle
+	collection := strings.ToLower(resource.Name) + "s"
+	policy := cache.Policy{MaxAge: cc.MaxAge, SharedMaxAge: cc.SharedMaxAge, StaleWhileRevalidate: cc.StaleWhileRevalidate, Public: cc.Public}
+	return &CacheControlMetadata{
+		MaxAge:               cc.MaxAge,
+		SharedMaxAge:         cc.SharedMaxAge,
+		StaleWhileRevalidate: cc.StaleWhileRevalidate,
+		Public:               cc.Public,
+		Header:               policy.Header(),
+		SurrogateKeys:        []string{collection, cache.RecordKey(collection, resource.MemberPath())},
+	}
+}
@@ -2,8 +2,10 @@ package metadata
 
 import (
 	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
 )
@@ -168,6 +170,11 @@ func TestExtractor_Extract_WithHooks(t *testing.T) {
 						IsTransaction: false,
 						IsAsync:       true,
 					},
+					{
+						Timing:       "after",
+						Event:        "attach",
+						Relationship: "tags",
+					},
 				},
 			},
 		},
@@ -181,8 +188,8 @@ func TestExtractor_Extract_WithHooks(t *testing.T) {
 	}
 
 	resource := meta.Resources[0]
-	if len(resource.Hooks) != 2 {
-		t.Fatalf("Hooks count = %v, want 2", len(resource.Hooks))
+	if len(resource.Hooks) != 3 {
+		t.Fatalf("Hooks count = %v, want 3", len(resource.Hooks))
 	}
 
 	// Check first hook
@@ -217,6 +224,15 @@ func TestExtractor_Extract_WithHooks(t *testing.T) {
 	if !hook2.HasAsync {
 		t.Error("Hook2 should be async")
 	}
+	if hook2.Relationship != "" {
+		t.Errorf("Hook2 relationship = %v, want none", hook2.Relationship)
+	}
+
+	// Attach and detach hooks name their relationship
+	hook3 := resource.Hooks[2]
+	if hook3.Event != "attach" || hook3.Relationship != "tags" {
+		t.Errorf("Hook3 = %s(%s), want attach(tags)", hook3.Event, hook3.Relationship)
+	}
 }
 
 func TestExtractor_Extract_Patterns(t *testing.T) {
@@ -311,6 +327,16 @@ func TestExtractor_Extract_Patterns(t *testing.T) {
 			t.Errorf("unique_field occurrences = %v, want 2", p.Occurrences)
 		}
 	}
+
+	// IDs come from the template, so they survive other patterns changing
+	for _, p := range meta.Patterns {
+		if p.ID != PatternID(p.Template) {
+			t.Errorf("%s ID = %q, want %q", p.Name, p.ID, PatternID(p.Template))
+		}
+	}
+	if PatternID("field: type! @unique") != patterns["unique_field"].ID || PatternID("@async { ... }") == patterns["unique_field"].ID {
+		t.Error("expected pattern IDs to depend on the template only")
+	}
 }
 
 func TestExtractor_Extract_Routes(t *testing.T) {
@@ -538,6 +564,34 @@ func TestExtractor_RelationshipKinds(t *testing.T) {
 	}
 }
 
+func TestExtractor_HasOneForeignKey(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "BlogAuthor",
+				Relationships: []*ast.RelationshipNode{
+					{Name: "profile", Type: "Profile", Kind: ast.RelationshipHasOne, Nullable: true},
+					{Name: "avatar", Type: "Image", Kind: ast.RelationshipHasOne, ForeignKey: "owner_id"},
+				},
+			},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	// The foreign key is resolved to the related resource's column
+	rels := meta.Resources[0].Relationships
+	if rels[0].Kind != "has_one" || rels[0].ForeignKey != "blog_author_id" {
+		t.Errorf("profile = %+v, want has_one on blog_author_id", rels[0])
+	}
+	if rels[1].ForeignKey != "owner_id" {
+		t.Errorf("avatar ForeignKey = %v, want owner_id", rels[1].ForeignKey)
+	}
+}
+
 func TestExtractor_StructTypes(t *testing.T) {
 	prog := &ast.Program{
 		Resources: []*ast.ResourceNode{
@@ -750,6 +804,196 @@ func TestExtractor_GenerateRoutes_WithOperationsRestriction(t *testing.T) {
 	}
 }
 
+func TestExtractor_GenerateRoutes_OperationRefinements(t *testing.T) {
+	title := []*ast.FieldNode{{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}}}
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name:          "Post",
+				Fields:        title,
+				Operations:    []string{"list", "show", "create", "update"},
+				OperationMods: map[string][]string{"create": {ast.AdminOnly}},
+				Middleware:    []string{"auth"},
+			},
+			{Name: "Tag", Fields: title, Excluded: []string{"delete"}},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	middleware := make(map[string]string)
+	for _, route := range meta.Routes {
+		middleware[route.Resource+"."+route.Operation] = strings.Join(route.Middleware, ",")
+	}
+
+	want := map[string]string{
+		"Post.list":   "auth",
+		"Post.get":    "auth",
+		"Post.create": "auth,admin_only",
+		"Post.update": "auth",
+		"Tag.list":    "",
+		"Tag.get":     "",
+		"Tag.create":  "",
+		"Tag.update":  "",
+	}
+	if !reflect.DeepEqual(middleware, want) {
+		t.Errorf("Routes = %v, want %v", middleware, want)
+	}
+}
+
+func TestExtractor_CountStrategy(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{Name: "Event", CountStrategy: ast.CountNone},
+			{Name: "Post"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	if got := meta.Resources[0].CountStrategy; got != "none" {
+		t.Errorf("Event CountStrategy = %q, want %q", got, "none")
+	}
+	if got := meta.Resources[1].CountStrategy; got != "" {
+		t.Errorf("Post CountStrategy = %q, want empty", got)
+	}
+}
+
+func TestExtractor_ColumnOverride(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Post",
+				Fields: []*ast.FieldNode{
+					{
+						Name:     "title",
+						Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string", Nullable: false},
+						Nullable: false,
+					},
+					{
+						Name:     "body",
+						Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "text", Nullable: false},
+						Nullable: false,
+						Constraints: []*ast.ConstraintNode{
+							{Name: "column", Arguments: []ast.ExprNode{&ast.LiteralExpr{Value: "legacy_body"}}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	fields := meta.Resources[0].Fields
+	if fields[0].Column != "" {
+		t.Errorf("title Column = %q, want empty", fields[0].Column)
+	}
+	if fields[1].Column != "legacy_body" {
+		t.Errorf("body Column = %q, want %q", fields[1].Column, "legacy_body")
+	}
+	if len(fields[1].Constraints) != 0 {
+		t.Errorf("@column should not be listed as a constraint, got %v", fields[1].Constraints)
+	}
+}
+
+func TestExtractor_DualWrite(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Post",
+				Fields: []*ast.FieldNode{
+					{
+						Name:     "headline",
+						Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string", Nullable: false},
+						Nullable: false,
+						Constraints: []*ast.ConstraintNode{
+							{Name: "dual_write", Arguments: []ast.ExprNode{
+								&ast.LiteralExpr{Value: "title"},
+								&ast.LiteralExpr{Value: "2026-12-01"},
+							}},
+						},
+					},
+					{
+						Name:     "body",
+						Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "text", Nullable: false},
+						Nullable: false,
+					},
+				},
+			},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	fields := meta.Resources[0].Fields
+	want := &DualWriteMetadata{Column: "title", Until: "2026-12-01"}
+	if fields[0].DualWrite == nil || *fields[0].DualWrite != *want {
+		t.Errorf("headline DualWrite = %+v, want %+v", fields[0].DualWrite, want)
+	}
+	if len(fields[0].Constraints) != 0 {
+		t.Errorf("@dual_write should not be listed as a constraint, got %v", fields[0].Constraints)
+	}
+	if fields[1].DualWrite != nil {
+		t.Errorf("body DualWrite = %+v, want nil", fields[1].DualWrite)
+	}
+}
+
+func TestExtractor_QueryAllowLists(t *testing.T) {
+	stringType := &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string", Nullable: false}
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Post",
+				Fields: []*ast.FieldNode{
+					{Name: "title", Type: stringType, Constraints: []*ast.ConstraintNode{{Name: "sortable"}}},
+					{Name: "status", Type: stringType, Constraints: []*ast.ConstraintNode{{Name: "filterable"}}},
+				},
+			},
+			{
+				Name: "Tag",
+				Fields: []*ast.FieldNode{
+					{Name: "label", Type: stringType},
+				},
+			},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	post := meta.Resources[0].Fields
+	if post[0].Filterable || !post[0].Sortable {
+		t.Errorf("title = filterable %v, sortable %v; want false, true", post[0].Filterable, post[0].Sortable)
+	}
+	if !post[1].Filterable || post[1].Sortable {
+		t.Errorf("status = filterable %v, sortable %v; want true, false", post[1].Filterable, post[1].Sortable)
+	}
+	if len(post[0].Constraints) != 0 || len(post[1].Constraints) != 0 {
+		t.Errorf("allow-list annotations should not be listed as constraints, got %v %v", post[0].Constraints, post[1].Constraints)
+	}
+
+	// Without annotations every field is allowed
+	tag := meta.Resources[1].Fields
+	if !tag[0].Filterable || !tag[0].Sortable {
+		t.Errorf("label = filterable %v, sortable %v; want true, true", tag[0].Filterable, tag[0].Sortable)
+	}
+}
+
 func TestExtractor_GenerateRoutes_NestedResources(t *testing.T) {
 	prog := &ast.Program{
 		Resources: []*ast.ResourceNode{
@@ -878,54 +1122,886 @@ func TestExtractor_GenerateRoutes_NoMiddleware(t *testing.T) {
 	}
 }
 
-func TestExtractor_GenerateRoutes_MultipleResources(t *testing.T) {
+func TestExtractor_GenerateRoutes_Webhook(t *testing.T) {
 	prog := &ast.Program{
 		Resources: []*ast.ResourceNode{
 			{
-				Name: "User",
-				Fields: []*ast.FieldNode{
-					{
-						Name:     "username",
-						Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string", Nullable: false},
-						Nullable: false,
-					},
-				},
+				Name:       "StripeEvent",
+				Operations: []string{"list", "get"},
+				Middleware: []string{"auth"},
+				Webhook:    &ast.WebhookNode{Provider: "stripe"},
 			},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	var webhook *RouteMetadata
+	for i, route := range meta.Routes {
+		if route.Operation == "webhook" {
+			webhook = &meta.Routes[i]
+		}
+	}
+	want := &RouteMetadata{
+		Method:      "POST",
+		Path:        "/webhooks/stripe",
+		Handler:     "StripeEvent.webhook",
+		Resource:    "StripeEvent",
+		Operation:   "webhook",
+		Description: "Receive signed stripe events",
+		Allow:       []string{"POST", "OPTIONS"},
+	}
+	if !reflect.DeepEqual(webhook, want) {
+		t.Errorf("webhook route = %+v, want %+v", webhook, want)
+	}
+	if len(meta.Routes) != 3 {
+		t.Errorf("Expected list, get and webhook routes, got %d", len(meta.Routes))
+	}
+}
+
+func TestExtractor_GenerateRoutes_Upsert(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
 			{
-				Name: "Post",
-				Fields: []*ast.FieldNode{
-					{
-						Name:     "title",
-						Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string", Nullable: false},
-						Nullable: false,
-					},
-				},
+				Name:       "User",
+				Middleware: []string{"auth"},
+				Upsert:     &ast.UpsertNode{Fields: []string{"email"}},
 			},
 		},
 	}
 
-	extractor := NewExtractor("1.0.0")
-	meta, err := extractor.Extract(prog)
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	var upsert *RouteMetadata
+	for i, route := range meta.Routes {
+		if route.Operation == "upsert" {
+			upsert = &meta.Routes[i]
+		}
+	}
+	want := &RouteMetadata{
+		Method:      "PUT",
+		Path:        "/users:upsert",
+		Handler:     "User.upsert",
+		Resource:    "User",
+		Operation:   "upsert",
+		Middleware:  []string{"auth"},
+		Description: "Create a User or update the one with the same email",
+		Allow:       []string{"PUT", "OPTIONS"},
+	}
+	if !reflect.DeepEqual(upsert, want) {
+		t.Errorf("upsert route = %+v, want %+v", upsert, want)
+	}
+}
+
+func TestExtractor_SoftDelete(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{Name: "Post", SoftDelete: &ast.SoftDeleteNode{}},
+			{Name: "Comment"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if !meta.Resources[0].SoftDelete {
+		t.Error("Expected Post to be flagged soft_delete")
+	}
+	if meta.Resources[1].SoftDelete {
+		t.Error("Expected Comment not to be flagged soft_delete")
+	}
+}
+
+func TestExtractor_DefaultScope(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{Name: "Post", DefaultScope: &ast.DefaultScopeNode{Condition: &ast.BinaryExpr{
+				Left:     &ast.FieldAccessExpr{Object: &ast.SelfExpr{}, Field: "deleted_at"},
+				Operator: "==",
+				Right:    &ast.LiteralExpr{Value: nil},
+			}}},
+			{Name: "Comment"},
+		},
+	}
 
+	meta, err := NewExtractor("1.0.0").Extract(prog)
 	if err != nil {
 		t.Fatalf("Extract() error = %v", err)
 	}
+	if got := meta.Resources[0].DefaultScope; got != "self.deleted_at == null" {
+		t.Errorf("DefaultScope = %q, want %q", got, "self.deleted_at == null")
+	}
+	if got := meta.Resources[1].DefaultScope; got != "" {
+		t.Errorf("Expected Comment to have no default scope, got %q", got)
+	}
+}
 
-	// Should generate 5 routes per resource = 10 total
-	if len(meta.Routes) != 10 {
-		t.Fatalf("Routes count = %v, want 10", len(meta.Routes))
+func TestExtractor_GenerateRoutes_Archivable(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name:       "Post",
+				Operations: []string{"list", "get"},
+				Archivable: &ast.ArchivableNode{},
+			},
+		},
 	}
 
-	// Count routes by resource
-	resourceCounts := make(map[string]int)
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if !meta.Resources[0].Archivable {
+		t.Error("Expected the resource to be flagged archivable")
+	}
+
+	var actions []RouteMetadata
 	for _, route := range meta.Routes {
-		resourceCounts[route.Resource]++
+		if route.Operation == "archive" || route.Operation == "restore" {
+			actions = append(actions, route)
+		}
+	}
+	want := []RouteMetadata{
+		{Method: "POST", Path: "/posts/:id/archive", Handler: "Post.archive", Resource: "Post", Operation: "archive", Description: "Archive a Post", Allow: []string{"POST", "OPTIONS"}},
+		{Method: "POST", Path: "/posts/:id/restore", Handler: "Post.restore", Resource: "Post", Operation: "restore", Description: "Restore a Post", Allow: []string{"POST", "OPTIONS"}},
 	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("archive routes = %+v, want %+v", actions, want)
+	}
+}
 
-	if resourceCounts["User"] != 5 {
-		t.Errorf("User routes count = %v, want 5", resourceCounts["User"])
+func TestExtractor_GenerateRoutes_Orderable(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name:       "Task",
+				Operations: []string{"list", "get"},
+				Middleware: []string{"auth"},
+				Orderable:  &ast.OrderableNode{Scope: "list_id"},
+			},
+		},
 	}
-	if resourceCounts["Post"] != 5 {
-		t.Errorf("Post routes count = %v, want 5", resourceCounts["Post"])
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if got, want := meta.Resources[0].Orderable, (&OrderableMetadata{Scope: "list_id", Position: "position"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Orderable = %+v, want %+v", got, want)
+	}
+
+	var move *RouteMetadata
+	for i, route := range meta.Routes {
+		if route.Operation == "move" {
+			move = &meta.Routes[i]
+		}
+	}
+	want := &RouteMetadata{
+		Method:      "POST",
+		Path:        "/tasks/:id/move",
+		Handler:     "Task.move",
+		Resource:    "Task",
+		Operation:   "move",
+		Middleware:  []string{"auth"},
+		Description: "Move a Task before or after another",
+		Allow:       []string{"POST", "OPTIONS"},
+	}
+	if !reflect.DeepEqual(move, want) {
+		t.Errorf("move route = %+v, want %+v", move, want)
+	}
+}
+
+func TestExtractor_GenerateRoutes_Tree(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name:       "Category",
+				Middleware: []string{"auth"},
+				Relationships: []*ast.RelationshipNode{
+					{Name: "parent", Type: "Category", Kind: ast.RelationshipBelongsTo, ForeignKey: "parent_id", Nullable: true},
+				},
+				Tree: &ast.TreeNode{},
+			},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if got, want := meta.Resources[0].Tree, (&TreeMetadata{Parent: "parent", ForeignKey: "parent_id", MaxDepth: 32}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tree = %+v, want %+v", got, want)
+	}
+
+	var paths []string
+	for _, route := range meta.Routes {
+		if route.Operation == "children" || route.Operation == "ancestors" {
+			paths = append(paths, route.Method+" "+route.Path+" "+route.Handler)
+		}
+	}
+	want := []string{"GET /categories/:id/children Category.children", "GET /categories/:id/ancestors Category.ancestors"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("tree routes = %v, want %v", paths, want)
+	}
+}
+
+func TestExtractor_IDStrategy(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Order",
+				Fields: []*ast.FieldNode{
+					{
+						Name:        "id",
+						Type:        &ast.TypeNode{Kind: ast.TypePrimitive, Name: "ulid", Nullable: false},
+						Nullable:    false,
+						Constraints: []*ast.ConstraintNode{{Name: "primary"}, {Name: "auto"}},
+					},
+					{
+						Name:     "total",
+						Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int", Nullable: false},
+						Nullable: false,
+					},
+				},
+				IDStrategy: &ast.IDStrategyNode{Strategy: ast.IDStrategyULID},
+			},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	fields := meta.Resources[0].Fields
+	if fields[0].IDStrategy != "ulid" {
+		t.Errorf("id IDStrategy = %q, want %q", fields[0].IDStrategy, "ulid")
+	}
+	if fields[1].IDStrategy != "" {
+		t.Errorf("total IDStrategy = %q, want none", fields[1].IDStrategy)
+	}
+}
+
+func TestExtractor_GenerateRoutes_MultipleResources(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "User",
+				Fields: []*ast.FieldNode{
+					{
+						Name:     "username",
+						Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string", Nullable: false},
+						Nullable: false,
+					},
+				},
+			},
+			{
+				Name: "Post",
+				Fields: []*ast.FieldNode{
+					{
+						Name:     "title",
+						Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string", Nullable: false},
+						Nullable: false,
+					},
+				},
+			},
+		},
+	}
+
+	extractor := NewExtractor("1.0.0")
+	meta, err := extractor.Extract(prog)
+
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	// Should generate 5 routes per resource = 10 total
+	if len(meta.Routes) != 10 {
+		t.Fatalf("Routes count = %v, want 10", len(meta.Routes))
+	}
+
+	// Count routes by resource
+	resourceCounts := make(map[string]int)
+	for _, route := range meta.Routes {
+		resourceCounts[route.Resource]++
+	}
+
+	if resourceCounts["User"] != 5 {
+		t.Errorf("User routes count = %v, want 5", resourceCounts["User"])
+	}
+	if resourceCounts["Post"] != 5 {
+		t.Errorf("Post routes count = %v, want 5", resourceCounts["Post"])
+	}
+}
+
+func TestExtractor_SLO(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Post",
+				SLO: &ast.SLONode{
+					Latency:      []ast.LatencyObjective{{Percentile: 99, Threshold: 300 * time.Millisecond}},
+					Availability: 99.9,
+				},
+			},
+			{Name: "Comment"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	slo := meta.Resources[0].SLO
+	if slo == nil {
+		t.Fatal("expected SLO metadata for Post")
+	}
+	if slo.Availability != 99.9 {
+		t.Errorf("Availability = %v, want 99.9", slo.Availability)
+	}
+	if len(slo.Latency) != 1 || slo.Latency[0] != (LatencyObjectiveMetadata{Percentile: 99, ThresholdMs: 300}) {
+		t.Errorf("Latency = %+v", slo.Latency)
+	}
+	if meta.Resources[1].SLO != nil {
+		t.Errorf("Comment should have no SLO, got %+v", meta.Resources[1].SLO)
+	}
+}
+
+func TestExtractor_CacheControl(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{Name: "Post", CacheControl: &ast.CacheControlNode{MaxAge: 60, Public: true}},
+			{Name: "Comment"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	cc := meta.Resources[0].CacheControl
+	if cc == nil {
+		t.Fatal("expected cache control metadata for Post")
+	}
+	if cc.Header != "public, max-age=60" {
+		t.Errorf("Header = %q, want %q", cc.Header, "public, max-age=60")
+	}
+	if strings.Join(cc.SurrogateKeys, " ") != "posts posts/{id}" {
+		t.Errorf("SurrogateKeys = %v", cc.SurrogateKeys)
+	}
+	if meta.Resources[1].CacheControl != nil {
+		t.Errorf("Comment should not be cacheable, got %+v", meta.Resources[1].CacheControl)
+	}
+}
+
+func TestExtractor_Changes(t *testing.T) {
+	timestamp := &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"}
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Post",
+				Fields: []*ast.FieldNode{
+					{Name: "modified_at", Type: timestamp, Constraints: []*ast.ConstraintNode{{Name: "auto_update"}}},
+					{Name: "deleted_at", Type: timestamp, Nullable: true},
+				},
+				Changes: &ast.ChangesNode{},
+			},
+			{Name: "Comment"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	changes := meta.Resources[0].Changes
+	if changes == nil {
+		t.Fatal("expected change feed metadata for Post")
+	}
+	want := ChangesMetadata{Path: "/posts/changes", CursorField: "modified_at", DeletedField: "deleted_at"}
+	if *changes != want {
+		t.Errorf("Changes = %+v, want %+v", *changes, want)
+	}
+	if meta.Resources[1].Changes != nil {
+		t.Errorf("Comment should not have a change feed, got %+v", meta.Resources[1].Changes)
+	}
+}
+
+func TestExtractor_Conflict(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Post",
+				Fields: []*ast.FieldNode{
+					{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+					{Name: "updated_at", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"}, Constraints: []*ast.ConstraintNode{{Name: "auto_update"}}},
+				},
+				Conflict: &ast.ConflictNode{Strategy: ast.ConflictMerge, MergeFields: []string{"title"}},
+			},
+			{Name: "Comment"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	conflict := meta.Resources[0].Conflict
+	if conflict == nil {
+		t.Fatal("expected conflict metadata for Post")
+	}
+	want := &ConflictMetadata{Strategy: "merge", MergeFields: []string{"title"}, VersionField: "updated_at"}
+	if !reflect.DeepEqual(conflict, want) {
+		t.Errorf("Conflict = %+v, want %+v", conflict, want)
+	}
+	if meta.Resources[1].Conflict != nil {
+		t.Errorf("Comment should not have a conflict policy, got %+v", meta.Resources[1].Conflict)
+	}
+}
+
+func TestExtractor_Shard(t *testing.T) {
+	shard := &ast.ShardNode{Field: "tenant_id"}
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{Name: "Account", Shard: shard},
+			{Name: "Invoice", Shard: shard},
+			{Name: "Event", Shard: &ast.ShardNode{Field: "region"}},
+			{Name: "Plan"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := []ShardMetadata{
+		{Key: "tenant_id", Resources: []string{"Account", "Invoice"}},
+		{Key: "region", Resources: []string{"Event"}},
+	}
+	if !reflect.DeepEqual(meta.Shards, want) {
+		t.Errorf("Shards = %+v, want %+v", meta.Shards, want)
+	}
+}
+
+func TestExtractor_Partition(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Event",
+				Fields: []*ast.FieldNode{
+					{Name: "created_at", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"}, Constraints: []*ast.ConstraintNode{{Name: "auto"}}},
+				},
+				Partition: &ast.PartitionNode{Field: "created_at", Interval: ast.PartitionWeek},
+			},
+			{Name: "Comment"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := &PartitionMetadata{Field: "created_at", Interval: "week"}
+	if !reflect.DeepEqual(meta.Resources[0].Partition, want) {
+		t.Errorf("Partition = %+v, want %+v", meta.Resources[0].Partition, want)
+	}
+	if meta.Resources[1].Partition != nil {
+		t.Errorf("Comment should not be partitioned, got %+v", meta.Resources[1].Partition)
+	}
+}
+
+func TestExtractor_Schema(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{Name: "Invoice", Schema: &ast.SchemaNode{Name: "billing"}},
+			{Name: "Comment"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	if meta.Resources[0].Schema != "billing" {
+		t.Errorf("Schema = %q, want %q", meta.Resources[0].Schema, "billing")
+	}
+	if meta.Resources[1].Schema != "" {
+		t.Errorf("Comment should be in the default schema, got %q", meta.Resources[1].Schema)
+	}
+}
+
+func TestExtractor_Owner(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{Name: "Invoice", Owner: &ast.OwnerNode{Team: "payments-team"}},
+			{Name: "Comment"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	if meta.Resources[0].Owner != "payments-team" {
+		t.Errorf("Owner = %q, want %q", meta.Resources[0].Owner, "payments-team")
+	}
+	if meta.Resources[1].Owner != "" {
+		t.Errorf("Comment should be unowned, got %q", meta.Resources[1].Owner)
+	}
+}
+
+func TestExtractor_Stability(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{Name: "Invoice", Stability: &ast.StabilityNode{Level: ast.StabilityExperimental}},
+			{Name: "Comment"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	if meta.Resources[0].Stability != "experimental" {
+		t.Errorf("Stability = %q, want %q", meta.Resources[0].Stability, "experimental")
+	}
+	if meta.Resources[1].Stability != "" {
+		t.Errorf("Comment should declare no stability, got %q", meta.Resources[1].Stability)
+	}
+}
+
+func TestExtractor_Custom(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Customer",
+				Meta: &ast.MetaNode{Values: map[string]string{"cost_center": "cc-42"}},
+				Fields: []*ast.FieldNode{
+					{Name: "email", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}, Meta: &ast.MetaNode{Values: map[string]string{"classification": "pii"}}},
+					{Name: "name", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+				},
+			},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	customer := meta.Resources[0]
+	if customer.Custom["cost_center"] != "cc-42" {
+		t.Errorf("resource Custom = %v, want cost_center cc-42", customer.Custom)
+	}
+	if customer.Fields[0].Custom["classification"] != "pii" {
+		t.Errorf("email Custom = %v, want classification pii", customer.Fields[0].Custom)
+	}
+	if customer.Fields[1].Custom != nil {
+		t.Errorf("name should have no custom metadata, got %v", customer.Fields[1].Custom)
+	}
+}
+
+func TestExtractor_Labels(t *testing.T) {
+	enumType := &ast.TypeNode{Kind: ast.TypeEnum, Name: "enum", EnumValues: []string{"draft", "published"}}
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Post",
+				Fields: []*ast.FieldNode{
+					{Name: "status", Type: enumType, Labels: &ast.LabelsNode{Values: map[string]string{"draft": "Draft"}}},
+					{Name: "kind", Type: enumType},
+				},
+			},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	post := meta.Resources[0]
+	if post.Fields[0].Labels["draft"] != "Draft" || len(post.Fields[0].Labels) != 1 {
+		t.Errorf("status Labels = %v, want draft Draft", post.Fields[0].Labels)
+	}
+	if post.Fields[1].Labels != nil {
+		t.Errorf("kind should have no labels, got %v", post.Fields[1].Labels)
+	}
+}
+
+func TestExtractor_External(t *testing.T) {
+	idField := &ast.FieldNode{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int"}, Constraints: []*ast.ConstraintNode{{Name: "primary"}}}
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{Name: "LegacyUser", Fields: []*ast.FieldNode{idField}, External: &ast.ExternalTableNode{Table: "legacy.users"}},
+			{Name: "Comment"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	if meta.Resources[0].External != "legacy.users" {
+		t.Errorf("External = %q, want %q", meta.Resources[0].External, "legacy.users")
+	}
+	if meta.Resources[1].External != "" {
+		t.Errorf("Comment should not be external, got %q", meta.Resources[1].External)
+	}
+
+	// Only the read routes are generated
+	var methods []string
+	for _, route := range meta.Routes {
+		if route.Resource == "LegacyUser" {
+			methods = append(methods, route.Method)
+		}
+	}
+	if len(methods) != 2 || methods[0] != "GET" || methods[1] != "GET" {
+		t.Errorf("Expected list and show routes only, got %v", methods)
+	}
+}
+
+func TestExtractor_Materialized(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "PostStat",
+				Fields: []*ast.FieldNode{
+					{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}},
+				},
+				Materialized: &ast.MaterializedNode{Query: "SELECT id FROM posts", Refresh: ast.RefreshWeekly},
+			},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := &MaterializedMetadata{Refresh: "weekly", Query: "SELECT id FROM posts"}
+	if !reflect.DeepEqual(meta.Resources[0].Materialized, want) {
+		t.Errorf("Materialized = %+v, want %+v", meta.Resources[0].Materialized, want)
+	}
+
+	// Views only serve reads
+	for _, route := range meta.Routes {
+		if route.Method != "GET" {
+			t.Errorf("Unexpected %s %s route for a materialized view", route.Method, route.Path)
+		}
+	}
+	if len(meta.Routes) != 2 {
+		t.Errorf("Routes count = %d, want 2", len(meta.Routes))
+	}
+}
+
+func TestExtractor_CounterCache(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Comment",
+				Fields: []*ast.FieldNode{
+					{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}},
+				},
+				Relationships: []*ast.RelationshipNode{
+					{Name: "post", Type: "Post", Kind: ast.RelationshipBelongsTo},
+				},
+				CounterCaches: []*ast.CounterCacheNode{
+					{Column: "comments_count", Resource: "Post", Relationship: "comments"},
+				},
+			},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := []CounterCacheMetadata{{
+		Column:       "comments_count",
+		Resource:     "Post",
+		Relationship: "comments",
+		ForeignKey:   "post_id",
+	}}
+	if !reflect.DeepEqual(meta.Resources[0].CounterCaches, want) {
+		t.Errorf("CounterCaches = %+v, want %+v", meta.Resources[0].CounterCaches, want)
+	}
+}
+
+func TestExtractor_SearchIndex(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Post",
+				Fields: []*ast.FieldNode{
+					{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+					{Name: "body", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "text"}},
+				},
+				SearchIndex: &ast.SearchIndexNode{Fields: []string{"title", "body"}},
+			},
+			{Name: "Tag"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := &SearchIndexMetadata{Index: "posts", Path: "/posts/search", Fields: []string{"title", "body"}}
+	if !reflect.DeepEqual(meta.Resources[0].SearchIndex, want) {
+		t.Errorf("SearchIndex = %+v, want %+v", meta.Resources[0].SearchIndex, want)
+	}
+	if meta.Resources[1].SearchIndex != nil {
+		t.Errorf("SearchIndex = %+v, want nil without @search_index", meta.Resources[1].SearchIndex)
+	}
+}
+
+func TestExtractor_Indexes(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Post",
+				Fields: []*ast.FieldNode{
+					{Name: "status", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+						Constraints: []*ast.ConstraintNode{{Name: ast.IndexConstraint}}},
+					{Name: "author_id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}},
+					{Name: "published_at", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"}},
+				},
+				Relationships: []*ast.RelationshipNode{
+					{Name: "author", Type: "User", Kind: ast.RelationshipBelongsTo, ForeignKey: "author_id"},
+				},
+				Indexes: []*ast.IndexNode{
+					{Fields: []string{"author", "published_at"}, Unique: true},
+					{Fields: []string{"status", "published_at"}, Name: "posts_by_status"},
+				},
+			},
+			{Name: "Tag"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := []IndexMetadata{
+		{Name: "idx_posts_status", Fields: []string{"status"}, Columns: []string{"status"}},
+		{Name: "idx_posts_author_id_published_at", Fields: []string{"author", "published_at"}, Columns: []string{"author_id", "published_at"}, Unique: true},
+		{Name: "posts_by_status", Fields: []string{"status", "published_at"}, Columns: []string{"status", "published_at"}},
+	}
+	if !reflect.DeepEqual(meta.Resources[0].Indexes, want) {
+		t.Errorf("Indexes = %+v, want %+v", meta.Resources[0].Indexes, want)
+	}
+	if meta.Resources[1].Indexes != nil {
+		t.Errorf("Indexes = %+v, want nil without indexes", meta.Resources[1].Indexes)
+	}
+}
+
+func TestExtractor_Profiles(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Post",
+				Fields: []*ast.FieldNode{
+					{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}},
+					{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+					{Name: "notes", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "text"}},
+				},
+				Profiles: []*ast.ProfileNode{
+					{Name: "public", Fields: []string{"id", "title"}},
+					{Name: "admin", All: true},
+				},
+			},
+			{Name: "Tag"},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := []ProfileMetadata{
+		{Name: "public", Fields: []string{"id", "title"}},
+		{Name: "admin", Fields: []string{"id", "title", "notes"}, All: true},
+	}
+	if !reflect.DeepEqual(meta.Resources[0].Profiles, want) {
+		t.Errorf("Profiles = %+v, want %+v", meta.Resources[0].Profiles, want)
+	}
+	if meta.Resources[1].Profiles != nil {
+		t.Errorf("Profiles = %+v, want nil without @profile", meta.Resources[1].Profiles)
+	}
+}
+
+func TestExtractor_Geometry(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{
+				Name: "Store",
+				Fields: []*ast.FieldNode{
+					{Name: "location", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "point"}},
+					{Name: "area", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "polygon", Nullable: true}, Nullable: true},
+					{Name: "name", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+				},
+			},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	fields := meta.Resources[0].Fields
+	wantTypes := []string{"Point", "Polygon"}
+	for i, want := range wantTypes {
+		geometry := fields[i].Geometry
+		if geometry == nil || *geometry != (GeometryMetadata{Type: want, Format: "geojson", SRID: 4326}) {
+			t.Errorf("%s Geometry = %+v, want a %s", fields[i].Name, geometry, want)
+		}
+	}
+	if fields[2].Geometry != nil {
+		t.Errorf("name Geometry = %+v, want nil", fields[2].Geometry)
+	}
+}
+
+func TestExtractor_GenerateRoutes_Allow(t *testing.T) {
+	prog := &ast.Program{
+		Resources: []*ast.ResourceNode{
+			{Name: "Post", Operations: []string{"list", "get", "create", "delete"}},
+		},
+	}
+
+	meta, err := NewExtractor("1.0.0").Extract(prog)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"/posts":     {"GET", "HEAD", "POST", "OPTIONS"},
+		"/posts/:id": {"GET", "HEAD", "DELETE", "OPTIONS"},
+	}
+	for _, route := range meta.Routes {
+		if !reflect.DeepEqual(route.Allow, want[route.Path]) {
+			t.Errorf("%s %s Allow = %v, want %v", route.Method, route.Path, route.Allow, want[route.Path])
+		}
 	}
 }
@@ -0,0 +1,100 @@
+package metadata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Redaction modes for a category of metadata
+const (
+	RedactFull    = "full"    // Included as written
+	RedactHashed  = "hashed"  // Replaced with a "sha256:" hash of the value
+	RedactOmitted = "omitted" // Left out
+)
+
+// RedactionPolicy controls which potentially sensitive parts of the metadata
+// are distributed. An empty mode is the same as RedactFull, so the zero
+// policy leaves the metadata unchanged.
+type RedactionPolicy struct {
+	Source        string // Hook bodies
+	FilePaths     string // Source file paths of resources
+	Documentation string // Resource documentation comments
+}
+
+// Validate checks that each mode of the policy is known
+func (p RedactionPolicy) Validate() error {
+	for name, mode := range map[string]string{
+		"source":        p.Source,
+		"file_paths":    p.FilePaths,
+		"documentation": p.Documentation,
+	} {
+		switch mode {
+		case "", RedactFull, RedactHashed, RedactOmitted:
+		default:
+			return fmt.Errorf("%s redaction must be %s, %s or %s, got: %s", name, RedactFull, RedactHashed, RedactOmitted, mode)
+		}
+	}
+	return nil
+}
+
+// IsFull reports whether the policy leaves the metadata unchanged
+func (p RedactionPolicy) IsFull() bool {
+	return isFull(p.Source) && isFull(p.FilePaths) && isFull(p.Documentation)
+}
+
+// Redact applies policy to metadata in place. Structural metadata such as
+// fields, routes and hook timing is always kept; hashed hook bodies move to
+// SourceHash so tools can still tell when a hook changed.
+func Redact(metadata *Metadata, policy RedactionPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if metadata == nil || policy.IsFull() {
+		return nil
+	}
+
+	for i := range metadata.Resources {
+		resource := &metadata.Resources[i]
+		resource.FilePath = redactValue(resource.FilePath, policy.FilePaths)
+		resource.Documentation = redactValue(resource.Documentation, policy.Documentation)
+
+		for j := range resource.Hooks {
+			hook := &resource.Hooks[j]
+			if hook.SourceCode == "" {
+				continue
+			}
+			switch policy.Source {
+			case RedactHashed:
+				hook.SourceHash = hashValue(hook.SourceCode)
+				hook.SourceCode = ""
+			case RedactOmitted:
+				hook.SourceCode = ""
+			}
+		}
+	}
+	return nil
+}
+
+func isFull(mode string) bool {
+	return mode == "" || mode == RedactFull
+}
+
+// redactValue returns value as mode distributes it
+func redactValue(value, mode string) string {
+	if value == "" {
+		return ""
+	}
+	switch mode {
+	case RedactHashed:
+		return hashValue(value)
+	case RedactOmitted:
+		return ""
+	}
+	return value
+}
+
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,90 @@
+package metadata
+
+import (
+	"strings"
+	"testing"
+)
+
+func redactTestMetadata() *Metadata {
+	return &Metadata{
+		Version: "1.0.0",
+		Resources: []ResourceMetadata{
+			{
+				Name:          "Invoice",
+				Documentation: "Discounts apply after the tenth order",
+				FilePath:      "app/billing/invoice.cdt",
+				Fields:        []FieldMetadata{{Name: "total", Type: "decimal!"}},
+				Hooks: []HookMetadata{
+					{Timing: "before", Event: "create", SourceCode: "self.total = Billing.discount(self)"},
+				},
+			},
+		},
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RedactionPolicy
+		check  func(t *testing.T, resource ResourceMetadata)
+	}{
+		{
+			name:   "zero policy",
+			policy: RedactionPolicy{},
+			check: func(t *testing.T, resource ResourceMetadata) {
+				if resource.Hooks[0].SourceCode == "" || resource.FilePath == "" || resource.Documentation == "" {
+					t.Errorf("expected the metadata unchanged, got %+v", resource)
+				}
+			},
+		},
+		{
+			name:   "hashed",
+			policy: RedactionPolicy{Source: RedactHashed, FilePaths: RedactHashed, Documentation: RedactHashed},
+			check: func(t *testing.T, resource ResourceMetadata) {
+				hook := resource.Hooks[0]
+				if hook.SourceCode != "" || !strings.HasPrefix(hook.SourceHash, "sha256:") {
+					t.Errorf("hook = %+v", hook)
+				}
+				if !strings.HasPrefix(resource.FilePath, "sha256:") || !strings.HasPrefix(resource.Documentation, "sha256:") {
+					t.Errorf("file path = %q, documentation = %q", resource.FilePath, resource.Documentation)
+				}
+			},
+		},
+		{
+			name:   "omitted",
+			policy: RedactionPolicy{Source: RedactOmitted, FilePaths: RedactOmitted, Documentation: RedactFull},
+			check: func(t *testing.T, resource ResourceMetadata) {
+				hook := resource.Hooks[0]
+				if hook.SourceCode != "" || hook.SourceHash != "" || resource.FilePath != "" {
+					t.Errorf("resource = %+v", resource)
+				}
+				if resource.Documentation == "" {
+					t.Error("expected documentation kept in full")
+				}
+				if hook.Timing != "before" || len(resource.Fields) != 1 {
+					t.Error("expected the structural metadata kept")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := redactTestMetadata()
+			if err := Redact(meta, tt.policy); err != nil {
+				t.Fatalf("Redact() error = %v", err)
+			}
+			tt.check(t, meta.Resources[0])
+		})
+	}
+}
+
+func TestRedact_InvalidMode(t *testing.T) {
+	err := Redact(redactTestMetadata(), RedactionPolicy{Documentation: "hidden"})
+	if err == nil || !strings.Contains(err.Error(), "documentation redaction") {
+		t.Errorf("expected an invalid mode error, got %v", err)
+	}
+	if err := Redact(nil, RedactionPolicy{Source: RedactHashed}); err != nil {
+		t.Errorf("Redact(nil) error = %v", err)
+	}
+}
@@ -11,6 +11,8 @@ type Metadata struct {
 	Resources  []ResourceMetadata `json:"resources"`
 	Patterns   []PatternMetadata  `json:"patterns"`
 	Routes     []RouteMetadata    `json:"routes"`
+	Auth       *AuthMetadata      `json:"auth,omitempty"`   // Social login; nil when no providers are configured
+	Shards     []ShardMetadata    `json:"shards,omitempty"` // Co-located resource groups from @shard
 }
 
 // ResourceMetadata describes a resource and its components
@@ -28,6 +30,126 @@ type ResourceMetadata struct {
 	Computed      []ComputedMetadata     `json:"computed,omitempty"`
 	Operations    []string               `json:"operations,omitempty"`
 	Middleware    []string               `json:"middleware,omitempty"`
+	Aliases       []string               `json:"aliases,omitempty"`        // Former names from @alias
+	CountStrategy string                 `json:"count_strategy,omitempty"` // List count strategy from @count
+	SLO           *SLOMetadata           `json:"slo,omitempty"`            // Service level objectives from @slo
+	CacheControl  *CacheControlMetadata  `json:"cache_control,omitempty"`  // HTTP caching policy from @cache_control
+	Changes       *ChangesMetadata       `json:"changes,omitempty"`        // Change feed from @changes
+	Conflict      *ConflictMetadata      `json:"conflict,omitempty"`       // Concurrent update policy from @conflict
+	Partition     *PartitionMetadata     `json:"partition,omitempty"`      // Table partitioning from @partition
+	Materialized  *MaterializedMetadata  `json:"materialized,omitempty"`   // Read-only materialized view from @materialized
+	CounterCaches []CounterCacheMetadata `json:"counter_caches,omitempty"` // Counts kept on parents from @counter_cache
+	SearchIndex   *SearchIndexMetadata   `json:"search_index,omitempty"`   // Full-text search from @search_index
+	Indexes       []IndexMetadata        `json:"indexes,omitempty"`        // Database indexes from @index and index blocks
+	Profiles      []ProfileMetadata      `json:"profiles,omitempty"`       // Fields rendered per caller role from @profile
+	Archivable    bool                   `json:"archivable,omitempty"`     // Archive and restore routes from @archivable
+	SoftDelete    bool                   `json:"soft_delete,omitempty"`    // Deletes set deleted_at instead of removing rows, from @soft_delete
+	DefaultScope  string                 `json:"default_scope,omitempty"`  // Condition list and show queries apply implicitly, from @default_scope
+	Orderable     *OrderableMetadata     `json:"orderable,omitempty"`      // Position and move route from @orderable
+	Tree          *TreeMetadata          `json:"tree,omitempty"`           // Children and ancestors routes from @tree
+	Schema        string                 `json:"schema,omitempty"`         // PostgreSQL schema holding the table from @schema
+	External      string                 `json:"external,omitempty"`       // Existing table read from @external_table; never migrated
+	Owner         string                 `json:"owner,omitempty"`          // Team owning the resource from @owner or CODEOWNERS
+	Stability     string                 `json:"stability,omitempty"`      // Lifecycle status from @stability; empty for stable
+	Custom        map[string]string      `json:"custom,omitempty"`         // Key-value pairs from @meta, verbatim
+}
+
+// TreeMetadata describes the hierarchy declared with @tree
+type TreeMetadata struct {
+	Parent     string `json:"parent"`      // belongs_to relationship to the parent record
+	ForeignKey string `json:"foreign_key"` // Field holding the parent's ID; null for roots
+	MaxDepth   int    `json:"max_depth"`   // Levels the routes follow at most
+}
+
+// OrderableMetadata describes the order kept by @orderable
+type OrderableMetadata struct {
+	Scope    string `json:"scope,omitempty"` // Field records are ordered within; empty for one order
+	Position string `json:"position"`        // Field holding each record's position
+}
+
+// ProfileMetadata describes the fields one role sees, declared with @profile
+type ProfileMetadata struct {
+	Name   string   `json:"name"`          // Role, or "public" for every caller
+	Fields []string `json:"fields"`        // Visible fields; every field when All is set
+	All    bool     `json:"all,omitempty"` // Declared with *
+}
+
+// SearchIndexMetadata describes the search backend index declared with @search_index
+type SearchIndexMetadata struct {
+	Index  string   `json:"index"`  // Backend index name, e.g. "posts"
+	Path   string   `json:"path"`   // Search route, e.g. "/posts/search"
+	Fields []string `json:"fields"` // Indexed fields, in declaration order
+}
+
+// IndexMetadata describes a database index declared with @index or an index block
+type IndexMetadata struct {
+	Name    string   `json:"name"`             // Index name, e.g. "idx_posts_author_id_published_at"
+	Fields  []string `json:"fields"`           // Fields and belongs_to relationships, in column order
+	Columns []string `json:"columns"`          // Indexed columns, in order
+	Unique  bool     `json:"unique,omitempty"` // Rejects two records with the same values
+}
+
+// CounterCacheMetadata describes a count of the resource kept on a parent with @counter_cache
+type CounterCacheMetadata struct {
+	Column       string `json:"column"`       // Counter column added to the parent
+	Resource     string `json:"resource"`     // Parent resource
+	Relationship string `json:"relationship"` // Parent's name for the counted collection
+	ForeignKey   string `json:"foreign_key"`  // Column of this resource referencing the parent
+}
+
+// MaterializedMetadata describes the materialized view declared with @materialized
+type MaterializedMetadata struct {
+	Refresh string   `json:"refresh"`           // hourly, daily or weekly
+	Query   string   `json:"query"`             // SELECT statement the view is defined by
+	Sources []string `json:"sources,omitempty"` // Resources read by the query, filled in by codegen
+}
+
+// ShardMetadata describes the resources sharded with @shard by the same key
+type ShardMetadata struct {
+	Key       string   `json:"key"`       // Field whose value selects the shard
+	Resources []string `json:"resources"` // Co-located resources sharded by Key
+}
+
+// PartitionMetadata describes the table partitioning declared with @partition
+type PartitionMetadata struct {
+	Field    string `json:"field"`    // Timestamp the table is range partitioned by
+	Interval string `json:"interval"` // day, week, month or year
+}
+
+// ConflictMetadata describes the concurrent update policy declared with @conflict
+type ConflictMetadata struct {
+	Strategy     string   `json:"strategy"`                // last_write_wins, reject or merge
+	MergeFields  []string `json:"merge_fields,omitempty"`  // Fields a stale update may still write (merge)
+	VersionField string   `json:"version_field,omitempty"` // @auto_update field sent as the ETag
+}
+
+// ChangesMetadata describes the change feed declared with @changes
+type ChangesMetadata struct {
+	Path         string `json:"path"`          // Feed route, e.g. "/posts/changes"
+	CursorField  string `json:"cursor_field"`  // @auto_update field the feed is ordered by
+	DeletedField string `json:"deleted_field"` // Timestamp set by soft deletes
+}
+
+// CacheControlMetadata describes the caching policy declared with @cache_control
+type CacheControlMetadata struct {
+	MaxAge               int      `json:"max_age"`
+	SharedMaxAge         int      `json:"s_maxage,omitempty"`
+	StaleWhileRevalidate int      `json:"stale_while_revalidate,omitempty"`
+	Public               bool     `json:"public"`
+	Header               string   `json:"header"`         // Cache-Control header sent on reads
+	SurrogateKeys        []string `json:"surrogate_keys"` // Surrogate-Key patterns for list and single-record responses
+}
+
+// SLOMetadata describes the service level objectives declared with @slo
+type SLOMetadata struct {
+	Latency      []LatencyObjectiveMetadata `json:"latency,omitempty"`
+	Availability float64                    `json:"availability,omitempty"` // Percentage, e.g. 99.9
+}
+
+// LatencyObjectiveMetadata is a latency target such as latency_p99: 300ms
+type LatencyObjectiveMetadata struct {
+	Percentile  int     `json:"percentile"`
+	ThresholdMs float64 `json:"threshold_ms"`
 }
 
 // FieldMetadata describes a field in a resource
@@ -37,6 +159,31 @@ type FieldMetadata struct {
 	Nullable    bool     `json:"nullable"`
 	Constraints []string `json:"constraints,omitempty"`
 	Default     string   `json:"default,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`     // Former names from @alias
+	Column      string   `json:"column,omitempty"`      // Column name override from @column
+	Filterable  bool     `json:"filterable,omitempty"`  // Accepted by ?filter[...]; all fields unless some are @filterable
+	Sortable    bool     `json:"sortable,omitempty"`    // Accepted by ?sort=; all fields unless some are @sortable
+	IDStrategy  string   `json:"id_strategy,omitempty"` // How new IDs are generated, from @id; set on the id field only
+
+	DualWrite *DualWriteMetadata `json:"dual_write,omitempty"` // Legacy column still written, from @dual_write
+	Geometry  *GeometryMetadata  `json:"geometry,omitempty"`   // GeoJSON encoding of point and polygon fields
+	Custom    map[string]string  `json:"custom,omitempty"`     // Key-value pairs from @meta, verbatim
+	Labels    map[string]string  `json:"labels,omitempty"`     // Display labels of enum values, from @labels
+}
+
+// GeometryMetadata describes how a point or polygon field is exchanged and
+// stored
+type GeometryMetadata struct {
+	Type   string `json:"type"`   // GeoJSON geometry type: Point or Polygon
+	Format string `json:"format"` // Always "geojson"
+	SRID   int    `json:"srid"`   // Spatial reference system, 4326 (WGS 84)
+}
+
+// DualWriteMetadata describes a renamed field that is also written to its old
+// column until the transition is cleaned up
+type DualWriteMetadata struct {
+	Column string `json:"column"`          // Legacy column
+	Until  string `json:"until,omitempty"` // Cleanup date (YYYY-MM-DD), if set
 }
 
 // RelationshipMetadata describes a relationship between resources
@@ -53,10 +200,17 @@ type RelationshipMetadata struct {
 // HookMetadata describes a lifecycle hook
 type HookMetadata struct {
 	Timing         string   `json:"timing"`          // before, after
-	Event          string   `json:"event"`           // create, update, delete, save
+	Event          string   `json:"event"`           // create, update, delete, save, attach, detach
+	Relationship   string   `json:"relationship,omitempty"` // has_many_through relationship of attach and detach hooks
+	Batch          bool     `json:"batch,omitempty"` // batch modifier: runs once with all the records created
 	HasTransaction bool     `json:"has_transaction"` // @transaction annotation
 	HasAsync       bool     `json:"has_async"`       // @async annotation
+	Policy         string   `json:"policy"`          // abort or warn, from @on_error
+	Retry          int      `json:"retry,omitempty"` // Retries of a failing hook, from @on_error
+	Backoff        string   `json:"backoff,omitempty"` // constant or exponential delay between retries
+	Timeout        string   `json:"timeout,omitempty"` // @timeout, e.g. 2s; the hook's context is canceled after it
 	SourceCode     string   `json:"source_code,omitempty"` // Hook body as source code
+	SourceHash     string   `json:"source_hash,omitempty"` // sha256 of the hook body, in place of SourceCode when stripped
 	Line           int      `json:"line,omitempty"`  // Line number in source
 	Middleware     []string `json:"middleware,omitempty"`
 }
@@ -94,6 +248,7 @@ type ComputedMetadata struct {
 
 // PatternMetadata describes a common pattern found in the codebase
 type PatternMetadata struct {
+	ID          string `json:"id"` // Derived from the template, stable across builds
 	Name        string `json:"name"`
 	Template    string `json:"template"`
 	Description string `json:"description,omitempty"`
@@ -109,6 +264,23 @@ type RouteMetadata struct {
 	Operation   string   `json:"operation"`
 	Middleware  []string `json:"middleware,omitempty"`
 	Description string   `json:"description,omitempty"`
+	Queries     []string `json:"queries,omitempty"` // SQL templates the route executes, filled in by codegen
+	Allow       []string `json:"allow,omitempty"`   // Methods served on Path, including HEAD and OPTIONS
+}
+
+// AuthMetadata describes the social login configured in conduit.yaml
+type AuthMetadata struct {
+	Resource  string                 `json:"resource"` // Resource users sign in as
+	Providers []AuthProviderMetadata `json:"providers"`
+}
+
+// AuthProviderMetadata describes a login provider and its routes
+type AuthProviderMetadata struct {
+	Name          string   `json:"name"`           // google, github
+	Protocol      string   `json:"protocol"`       // oidc or oauth2
+	AuthorizePath string   `json:"authorize_path"` // Starts sign-in
+	CallbackPath  string   `json:"callback_path"`  // The provider redirects back here
+	Scopes        []string `json:"scopes"`
 }
 
 // ToJSON converts metadata to JSON string
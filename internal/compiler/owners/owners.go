@@ -0,0 +1,175 @@
+// Package owners maps resources to the teams that own them. A resource is
+// owned by the team its @owner annotation names or, without one, by the first
+// owner CODEOWNERS gives the file declaring it.
+package owners
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// Locations searched for a CODEOWNERS file, in the order GitHub reads them
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwners is a parsed CODEOWNERS file. The last rule matching a path
+// decides its owner, as on GitHub.
+type CodeOwners struct {
+	rules []rule
+}
+
+type rule struct {
+	pattern *regexp.Regexp
+	owner   string // First owner of the rule; empty when the rule removes ownership
+}
+
+// Load reads the first CODEOWNERS file found in Locations under root. It
+// returns nil when the project has none.
+func Load(root string) (*CodeOwners, error) {
+	for _, location := range Locations {
+		data, err := os.ReadFile(filepath.Join(root, location))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", location, err)
+		}
+		codeOwners, err := Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", location, err)
+		}
+		return codeOwners, nil
+	}
+	return nil, nil
+}
+
+// Parse parses the contents of a CODEOWNERS file. Each line is a path pattern
+// followed by owners; blank lines and # comments are skipped.
+func Parse(data string) (*CodeOwners, error) {
+	codeOwners := &CodeOwners{}
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		pattern, err := compilePattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		owner := ""
+		if len(fields) > 1 {
+			owner = TeamName(fields[1])
+		}
+		codeOwners.rules = append(codeOwners.rules, rule{pattern: pattern, owner: owner})
+	}
+	return codeOwners, scanner.Err()
+}
+
+// Owner returns the owner of a path relative to the project root, or "" when
+// no rule gives it one. A nil CodeOwners owns nothing.
+func (c *CodeOwners) Owner(path string) string {
+	if c == nil {
+		return ""
+	}
+	path = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "./")
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].pattern.MatchString(path) {
+			return c.rules[i].owner
+		}
+	}
+	return ""
+}
+
+// TeamName returns the team a CODEOWNERS owner refers to: @acme/payments-team
+// and @payments-team are both payments-team. Email owners are kept as they are.
+func TeamName(owner string) string {
+	if !strings.HasPrefix(owner, "@") {
+		return owner
+	}
+	owner = strings.TrimPrefix(owner, "@")
+	if i := strings.LastIndex(owner, "/"); i >= 0 {
+		owner = owner[i+1:]
+	}
+	return owner
+}
+
+// WithOwners returns the resources with an owner from CODEOWNERS given to each
+// one that does not declare @owner. files maps each resource name to the file
+// declaring it, relative to the project root. Resources that gain an owner are copied, so the given
+// resources are left unchanged.
+func WithOwners(resources []*ast.ResourceNode, files map[string]string, codeOwners *CodeOwners) []*ast.ResourceNode {
+	result := make([]*ast.ResourceNode, len(resources))
+	copy(result, resources)
+
+	for i, resource := range resources {
+		if resource.Owner != nil {
+			continue
+		}
+		team := codeOwners.Owner(files[resource.Name])
+		if team == "" {
+			continue
+		}
+		owned := *resource
+		owned.Owner = &ast.OwnerNode{Team: team, Loc: resource.Loc}
+		result[i] = &owned
+	}
+
+	return result
+}
+
+// compilePattern converts a CODEOWNERS path pattern, which follows gitignore
+// rules, to a regular expression matching the paths it owns. A pattern with a
+// slash other than a trailing one is anchored at the project root; one
+// without matches at any depth. A pattern matching a directory owns
+// everything under it.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("^(.*/)?")
+	}
+	for i := 0; i < len(trimmed); i++ {
+		switch {
+		case strings.HasPrefix(trimmed[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case trimmed[i] == '*':
+			expr.WriteString("[^/]*")
+		case trimmed[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(trimmed[i : i+1]))
+		}
+	}
+	if dirOnly {
+		expr.WriteString("/.*$")
+	} else {
+		expr.WriteString("(/.*)?$")
+	}
+
+	compiled, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return compiled, nil
+}
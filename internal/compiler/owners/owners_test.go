@@ -0,0 +1,115 @@
+package owners
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+func TestCodeOwners_Owner(t *testing.T) {
+	codeOwners, err := Parse(`# Default owners
+*                     @acme/platform
+
+/app/billing/         @acme/payments-team @alice
+app/**/audit_*.cdt    @acme/compliance
+*.md                  docs@example.com # documentation
+/app/billing/legacy/
+`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"app/post.cdt", "platform"},
+		{"app/billing/invoice.cdt", "payments-team"},
+		{"./app/billing/nested/refund.cdt", "payments-team"},
+		{"app/resources/audit_log.cdt", "compliance"},
+		{"app/audit_log.cdt", "compliance"},
+		{"app/billing/README.md", "docs@example.com"},
+		{"app/billing/legacy/invoice.cdt", ""},
+		{"lib/billing/invoice.cdt", "platform"},
+	}
+	for _, tt := range tests {
+		if got := codeOwners.Owner(tt.path); got != tt.want {
+			t.Errorf("Owner(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	var none *CodeOwners
+	if got := none.Owner("app/post.cdt"); got != "" {
+		t.Errorf("nil CodeOwners should own nothing, got %q", got)
+	}
+}
+
+func TestTeamName(t *testing.T) {
+	for owner, want := range map[string]string{
+		"@acme/payments-team": "payments-team",
+		"@alice":              "alice",
+		"dev@example.com":     "dev@example.com",
+	} {
+		if got := TeamName(owner); got != want {
+			t.Errorf("TeamName(%q) = %q, want %q", owner, got, want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if codeOwners, err := Load(dir); err != nil || codeOwners != nil {
+		t.Fatalf("Load() without CODEOWNERS = %v, %v; want nil, nil", codeOwners, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "CODEOWNERS"), []byte("* @root-team\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".github"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".github", "CODEOWNERS"), []byte("* @github-team\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	codeOwners, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := codeOwners.Owner("app/post.cdt"); got != "github-team" {
+		t.Errorf("Owner() = %q, want .github/CODEOWNERS to take precedence", got)
+	}
+}
+
+func TestWithOwners(t *testing.T) {
+	codeOwners, err := Parse("/app/billing/ @acme/payments-team\n")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	declared := &ast.ResourceNode{Name: "Refund", Owner: &ast.OwnerNode{Team: "refunds"}}
+	invoice := &ast.ResourceNode{Name: "Invoice", Loc: ast.SourceLocation{Line: 3}}
+	post := &ast.ResourceNode{Name: "Post"}
+	files := map[string]string{
+		"Refund":  "app/billing/refund.cdt",
+		"Invoice": "app/billing/invoice.cdt",
+		"Post":    "app/post.cdt",
+	}
+
+	result := WithOwners([]*ast.ResourceNode{declared, invoice, post}, files, codeOwners)
+
+	if result[0] != declared || result[0].Owner.Team != "refunds" {
+		t.Errorf("@owner should win over CODEOWNERS, got %+v", result[0].Owner)
+	}
+	if result[1].Owner == nil || result[1].Owner.Team != "payments-team" || result[1].Owner.Loc.Line != 3 {
+		t.Errorf("Invoice owner = %+v, want payments-team at the resource", result[1].Owner)
+	}
+	if invoice.Owner != nil {
+		t.Error("WithOwners should not modify the given resources")
+	}
+	if result[2] != post || result[2].Owner != nil {
+		t.Errorf("Post should stay unowned, got %+v", result[2].Owner)
+	}
+}
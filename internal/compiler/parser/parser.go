@@ -2,6 +2,8 @@ package parser
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
 	"github.com/conduit-lang/conduit/internal/compiler/lexer"
@@ -77,6 +79,7 @@ func (p *Parser) parseResource() *ast.ResourceNode {
 		Computed:      make([]*ast.ComputedNode, 0),
 		Operations:    make([]string, 0),
 		Middleware:    make([]string, 0),
+		Aliases:       make([]string, 0),
 		Loc:           ast.TokenLocation(resourceToken),
 	}
 
@@ -85,6 +88,10 @@ func (p *Parser) parseResource() *ast.ResourceNode {
 		// Check for annotations
 		if p.isResourceAnnotationToken() {
 			p.parseResourceAnnotation(resource)
+		} else if p.isIndexBlock() {
+			if index := p.parseIndex(); index != nil {
+				resource.Indexes = append(resource.Indexes, index)
+			}
 		} else if p.isFieldNameToken() {
 			// Field or relationship
 			if field := p.parseField(); field != nil {
@@ -142,9 +149,174 @@ func (p *Parser) parseResourceAnnotation(resource *ast.ResourceNode) {
 			resource.Computed = append(resource.Computed, computed)
 		}
 	case "operations":
-		resource.Operations = p.parseOperations()
+		p.parseOperations(resource)
 	case "middleware":
 		resource.Middleware = p.parseMiddleware()
+	case "alias":
+		resource.Aliases = append(resource.Aliases, p.parseAlias()...)
+	case "count":
+		resource.CountStrategy = p.parseCountStrategy()
+	case "slo":
+		if slo := p.parseSLO(annotationToken); slo != nil {
+			resource.SLO = slo
+		}
+	case "cache_control":
+		if cacheControl := p.parseCacheControl(annotationToken); cacheControl != nil {
+			resource.CacheControl = cacheControl
+		}
+	case "changes":
+		if resource.Changes != nil {
+			p.error(annotationToken, "Duplicate @changes annotation")
+		}
+		resource.Changes = &ast.ChangesNode{
+			Loc: ast.TokenLocation(annotationToken),
+		}
+	case "conflict":
+		if resource.Conflict != nil {
+			p.error(annotationToken, "Duplicate @conflict annotation")
+		}
+		if conflict := p.parseConflict(annotationToken); conflict != nil {
+			resource.Conflict = conflict
+		}
+	case "partition":
+		if resource.Partition != nil {
+			p.error(annotationToken, "Duplicate @partition annotation")
+		}
+		if partition := p.parsePartition(annotationToken); partition != nil {
+			resource.Partition = partition
+		}
+	case "materialized":
+		if resource.Materialized != nil {
+			p.error(annotationToken, "Duplicate @materialized annotation")
+		}
+		if materialized := p.parseMaterialized(annotationToken); materialized != nil {
+			resource.Materialized = materialized
+		}
+	case "counter_cache":
+		if counterCache := p.parseCounterCache(annotationToken); counterCache != nil {
+			resource.CounterCaches = append(resource.CounterCaches, counterCache)
+		}
+	case "search_index":
+		if resource.SearchIndex != nil {
+			p.error(annotationToken, "Duplicate @search_index annotation")
+		}
+		if searchIndex := p.parseSearchIndex(annotationToken); searchIndex != nil {
+			resource.SearchIndex = searchIndex
+		}
+	case "webhook":
+		if resource.Webhook != nil {
+			p.error(annotationToken, "Duplicate @webhook annotation")
+		}
+		if webhook := p.parseWebhook(annotationToken); webhook != nil {
+			resource.Webhook = webhook
+		}
+	case "profile":
+		if len(resource.Profiles) > 0 {
+			p.error(annotationToken, "Duplicate @profile annotation")
+		}
+		if profiles := p.parseProfiles(annotationToken); profiles != nil {
+			resource.Profiles = profiles
+		}
+	case "upsert":
+		if resource.Upsert != nil {
+			p.error(annotationToken, "Duplicate @upsert annotation")
+		}
+		if upsert := p.parseUpsert(annotationToken); upsert != nil {
+			resource.Upsert = upsert
+		}
+	case "archivable":
+		if resource.Archivable != nil {
+			p.error(annotationToken, "Duplicate @archivable annotation")
+		}
+		resource.Archivable = &ast.ArchivableNode{
+			Loc: ast.TokenLocation(annotationToken),
+		}
+	case "soft_delete":
+		if resource.SoftDelete != nil {
+			p.error(annotationToken, "Duplicate @soft_delete annotation")
+		}
+		resource.SoftDelete = &ast.SoftDeleteNode{
+			Loc: ast.TokenLocation(annotationToken),
+		}
+	case "default_scope":
+		if resource.DefaultScope != nil {
+			p.error(annotationToken, "Duplicate @default_scope annotation")
+		}
+		if defaultScope := p.parseDefaultScope(annotationToken); defaultScope != nil {
+			resource.DefaultScope = defaultScope
+		}
+	case "orderable":
+		if resource.Orderable != nil {
+			p.error(annotationToken, "Duplicate @orderable annotation")
+		}
+		if orderable := p.parseOrderable(annotationToken); orderable != nil {
+			resource.Orderable = orderable
+		}
+	case "tree":
+		if resource.Tree != nil {
+			p.error(annotationToken, "Duplicate @tree annotation")
+		}
+		if tree := p.parseTree(annotationToken); tree != nil {
+			resource.Tree = tree
+		}
+	case "id":
+		if resource.IDStrategy != nil {
+			p.error(annotationToken, "Duplicate @id annotation")
+		}
+		if strategy := p.parseIDStrategy(annotationToken); strategy != nil {
+			resource.IDStrategy = strategy
+		}
+	case "primary":
+		if resource.PrimaryKey != nil {
+			p.error(annotationToken, "Duplicate @primary annotation")
+		}
+		if primaryKey := p.parsePrimaryKey(annotationToken); primaryKey != nil {
+			resource.PrimaryKey = primaryKey
+		}
+	case "timestamps":
+		if resource.Timestamps != nil {
+			p.error(annotationToken, "Duplicate @timestamps annotation")
+		}
+		if timestamps := p.parseTimestamps(annotationToken); timestamps != nil {
+			resource.Timestamps = timestamps
+		}
+	case "schema":
+		if resource.Schema != nil {
+			p.error(annotationToken, "Duplicate @schema annotation")
+		}
+		if schema := p.parseSchema(annotationToken); schema != nil {
+			resource.Schema = schema
+		}
+	case "external_table":
+		if resource.External != nil {
+			p.error(annotationToken, "Duplicate @external_table annotation")
+		}
+		if external := p.parseExternalTable(annotationToken); external != nil {
+			resource.External = external
+		}
+	case "owner":
+		if resource.Owner != nil {
+			p.error(annotationToken, "Duplicate @owner annotation")
+		}
+		if owner := p.parseOwner(annotationToken); owner != nil {
+			resource.Owner = owner
+		}
+	case "meta":
+		resource.Meta = p.parseMeta(annotationToken, resource.Meta)
+	case "stability":
+		if resource.Stability != nil {
+			p.error(annotationToken, "Duplicate @stability annotation")
+		}
+		if stability := p.parseStability(annotationToken); stability != nil {
+			resource.Stability = stability
+		}
+	case "shard":
+		if resource.Shard != nil {
+			p.error(annotationToken, "Duplicate @shard annotation")
+		}
+		if shard := p.parseShard(annotationToken); shard != nil {
+			resource.Shard = shard
+		}
 	default:
 		p.error(annotationToken, fmt.Sprintf("Unknown resource annotation: @%s", annotationName))
 	}
@@ -186,8 +358,22 @@ func (p *Parser) parseField() *ast.FieldNode {
 		Loc:         ast.TokenLocation(nameToken),
 	}
 
-	// Parse field constraints
-	for p.isFieldConstraintToken() {
+	// Parse field constraints. @alias, @primary and @meta are valid on both
+	// fields and resources, so they only bind to the field when written on the
+	// same line as the field name.
+	for (p.isFieldConstraintToken() || p.check(lexer.TOKEN_META) || p.check(lexer.TOKEN_LABELS)) && !((p.check(lexer.TOKEN_ALIAS) || p.check(lexer.TOKEN_PRIMARY) || p.check(lexer.TOKEN_META)) && p.peek().Line != nameToken.Line) {
+		if p.check(lexer.TOKEN_META) {
+			field.Meta = p.parseMeta(p.advance(), field.Meta)
+			continue
+		}
+		if p.check(lexer.TOKEN_LABELS) {
+			annotationToken := p.advance()
+			if field.Labels != nil {
+				p.error(annotationToken, "Duplicate @labels annotation")
+			}
+			field.Labels = p.parseLabels(annotationToken)
+			continue
+		}
 		if constraint := p.parseFieldConstraint(); constraint != nil {
 			field.Constraints = append(field.Constraints, constraint)
 		}
@@ -403,7 +589,12 @@ func (p *Parser) parseFieldConstraint() *ast.ConstraintNode {
 	// Parse constraint arguments
 	if p.match(lexer.TOKEN_LPAREN) {
 		for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
-			arg := p.parseExpression()
+			var arg ast.ExprNode
+			if constraintName == "filterable" {
+				arg = p.parseFilterOperator()
+			} else {
+				arg = p.parseExpression()
+			}
 			if arg != nil {
 				constraint.Arguments = append(constraint.Arguments, arg)
 			}
@@ -424,6 +615,17 @@ func (p *Parser) parseFieldConstraint() *ast.ConstraintNode {
 	return constraint
 }
 
+// parseFilterOperator parses an operator of @filterable(gte, in, null) as an
+// identifier; in and null are keywords elsewhere
+func (p *Parser) parseFilterOperator() ast.ExprNode {
+	if !p.check(lexer.TOKEN_IDENTIFIER) && !p.check(lexer.TOKEN_IN) && !p.check(lexer.TOKEN_NULL) {
+		p.error(p.peek(), "Expected filter operator")
+		return nil
+	}
+	token := p.advance()
+	return &ast.IdentifierExpr{Name: token.Lexeme, Loc: ast.TokenLocation(token)}
+}
+
 // parseHook parses a lifecycle hook
 func (p *Parser) parseHook(timingToken lexer.Token) *ast.HookNode {
 	timing := timingToken.Lexeme
@@ -433,8 +635,8 @@ func (p *Parser) parseHook(timingToken lexer.Token) *ast.HookNode {
 		timing = hookTimingAfter
 	}
 
-	// Expect event (create, update, delete, save)
-	eventToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected hook event (create, update, delete, save)")
+	// Expect event (create, update, delete, save, attach, detach)
+	eventToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected hook event (create, update, delete, save, attach, detach)")
 	if eventToken.Type == lexer.TOKEN_ERROR {
 		return nil
 	}
@@ -449,8 +651,31 @@ func (p *Parser) parseHook(timingToken lexer.Token) *ast.HookNode {
 		Loc:           ast.TokenLocation(timingToken),
 	}
 
-	// Parse optional modifiers (transaction and async are represented as tokens)
-	for p.check(lexer.TOKEN_TRANSACTION) || p.check(lexer.TOKEN_ASYNC) {
+	// Attach and detach name their relationship, e.g. @after attach(tags)
+	if hook.Event == ast.HookEventAttach || hook.Event == ast.HookEventDetach {
+		if !p.match(lexer.TOKEN_LPAREN) {
+			p.error(p.peek(), fmt.Sprintf("Expected '(' after %s", hook.Event))
+			return nil
+		}
+		relationshipToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected relationship name")
+		if relationshipToken.Type == lexer.TOKEN_ERROR {
+			return nil
+		}
+		hook.Relationship = relationshipToken.Lexeme
+		if !p.match(lexer.TOKEN_RPAREN) {
+			p.error(p.peek(), "Expected ')' after relationship name")
+		}
+	}
+
+	// batch runs the hook once with all the records of a create
+	if p.check(lexer.TOKEN_IDENTIFIER) && p.peek().Lexeme == "batch" {
+		p.advance()
+		hook.Batch = true
+	}
+
+	// Parse optional modifiers (transaction and async are represented as
+	// tokens, @on_error as @ and an identifier)
+	for p.check(lexer.TOKEN_TRANSACTION) || p.check(lexer.TOKEN_ASYNC) || p.check(lexer.TOKEN_AT) {
 		modifierToken := p.advance()
 
 		switch modifierToken.Type {
@@ -459,7 +684,22 @@ func (p *Parser) parseHook(timingToken lexer.Token) *ast.HookNode {
 		case lexer.TOKEN_ASYNC:
 			hook.IsAsync = true
 		default:
-			p.error(modifierToken, "Expected hook modifier (@transaction or @async)")
+			if p.check(lexer.TOKEN_IDENTIFIER) && p.peek().Lexeme == "on_error" {
+				if hook.OnError != nil {
+					p.error(p.peek(), "Duplicate @on_error")
+				}
+				hook.OnError = p.parseOnError(p.advance())
+				continue
+			}
+			if p.check(lexer.TOKEN_IDENTIFIER) && p.peek().Lexeme == "timeout" {
+				if hook.Timeout != 0 {
+					p.error(p.peek(), "Duplicate @timeout")
+				}
+				hook.Timeout = p.parseHookTimeout(p.advance())
+				continue
+			}
+			p.error(modifierToken, "Expected hook modifier (@transaction, @async, @on_error or @timeout)")
+			return nil
 		}
 	}
 
@@ -478,6 +718,130 @@ func (p *Parser) parseHook(timingToken lexer.Token) *ast.HookNode {
 	return hook
 }
 
+// parseOnError parses @on_error(retry: 3, backoff: constant | exponential,
+// policy: abort | warn)
+func (p *Parser) parseOnError(annotationToken lexer.Token) *ast.OnErrorNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @on_error")
+		return nil
+	}
+
+	onError := &ast.OnErrorNode{Policy: ast.HookPolicyAbort, Loc: ast.TokenLocation(annotationToken)}
+	seen := make(map[string]bool)
+
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		keyToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected error option (retry, backoff or policy)")
+		if keyToken.Type == lexer.TOKEN_ERROR {
+			return nil
+		}
+		if seen[keyToken.Lexeme] {
+			p.error(keyToken, fmt.Sprintf("Duplicate error option: %s", keyToken.Lexeme))
+		}
+		seen[keyToken.Lexeme] = true
+
+		if !p.match(lexer.TOKEN_COLON) {
+			p.error(p.peek(), fmt.Sprintf("Expected ':' after %s", keyToken.Lexeme))
+			return nil
+		}
+
+		switch keyToken.Lexeme {
+		case "retry":
+			valueToken := p.peek()
+			retries, ok := valueToken.Literal.(int64)
+			if valueToken.Type != lexer.TOKEN_INT_LITERAL || !ok || retries < 1 {
+				p.error(valueToken, "Expected a positive number of retries")
+				return nil
+			}
+			p.advance()
+			onError.Retry = int(retries)
+		case "backoff":
+			backoffToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected backoff (constant or exponential)")
+			if backoffToken.Type == lexer.TOKEN_ERROR {
+				return nil
+			}
+			switch backoffToken.Lexeme {
+			case ast.BackoffConstant, ast.BackoffExponential:
+				onError.Backoff = backoffToken.Lexeme
+			default:
+				p.error(backoffToken, fmt.Sprintf("Unknown backoff: %s (expected constant or exponential)", backoffToken.Lexeme))
+			}
+		case "policy":
+			policyToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected error policy (abort or warn)")
+			if policyToken.Type == lexer.TOKEN_ERROR {
+				return nil
+			}
+			switch policyToken.Lexeme {
+			case ast.HookPolicyAbort, ast.HookPolicyWarn:
+				onError.Policy = policyToken.Lexeme
+			default:
+				p.error(policyToken, fmt.Sprintf("Unknown error policy: %s (expected abort or warn)", policyToken.Lexeme))
+			}
+		default:
+			p.error(keyToken, fmt.Sprintf("Unknown error option: %s (expected retry, backoff or policy)", keyToken.Lexeme))
+			p.advance() // Skip the value
+		}
+
+		if !p.match(lexer.TOKEN_COMMA) {
+			break
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after error options")
+		return nil
+	}
+
+	if !seen["retry"] && !seen["policy"] {
+		p.error(annotationToken, "@on_error requires retry or policy")
+		return nil
+	}
+	if onError.Backoff != "" && onError.Retry == 0 {
+		p.error(annotationToken, "@on_error backoff requires retry")
+	}
+	if onError.Retry > 0 && onError.Backoff == "" {
+		onError.Backoff = ast.BackoffExponential
+	}
+
+	return onError
+}
+
+// parseHookTimeout parses @timeout(2s) or @timeout(500ms) on a hook
+func (p *Parser) parseHookTimeout(annotationToken lexer.Token) time.Duration {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @timeout")
+		return 0
+	}
+
+	valueToken := p.peek()
+	value, ok := p.parseSLONumber()
+	if !ok {
+		return 0
+	}
+	unitToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected timeout unit (ms or s)")
+	var timeout time.Duration
+	switch unitToken.Lexeme {
+	case "ms":
+		timeout = time.Duration(value * float64(time.Millisecond))
+	case "s":
+		timeout = time.Duration(value * float64(time.Second))
+	default:
+		if unitToken.Type != lexer.TOKEN_ERROR {
+			p.error(unitToken, fmt.Sprintf("Unknown timeout unit: %s (expected ms or s)", unitToken.Lexeme))
+		}
+		return 0
+	}
+	if timeout < time.Millisecond {
+		p.error(valueToken, "@timeout must be at least 1ms")
+		timeout = 0
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after timeout")
+		return 0
+	}
+	return timeout.Round(time.Millisecond)
+}
+
 // parseValidation parses a validation block
 func (p *Parser) parseValidation() *ast.ValidationNode {
 	nameToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected validation name")
@@ -715,8 +1079,34 @@ func (p *Parser) parseComputed() *ast.ComputedNode {
 	return computed
 }
 
-// parseOperations parses the @operations annotation
-func (p *Parser) parseOperations() []string {
+// parseOperations parses the @operations annotation: a list of operations,
+// each optionally refined with modifiers, or all except a list
+//
+//	@operations [list, get, create(admin_only)]
+//	@operations all except [delete]
+func (p *Parser) parseOperations(resource *ast.ResourceNode) {
+	if p.check(lexer.TOKEN_IDENTIFIER) && p.peek().Lexeme == "all" {
+		p.advance()
+		if !p.check(lexer.TOKEN_IDENTIFIER) || p.peek().Lexeme != "except" {
+			p.error(p.peek(), "Expected 'except' after @operations all")
+			return
+		}
+		p.advance()
+		resource.Excluded = p.parseOperationList(nil)
+		return
+	}
+
+	mods := make(map[string][]string)
+	resource.Operations = p.parseOperationList(mods)
+	if len(mods) > 0 {
+		resource.OperationMods = mods
+	}
+}
+
+// parseOperationList parses a bracketed list of operation names. When mods
+// is not nil, an operation may be followed by parenthesized modifiers, which
+// are recorded in mods.
+func (p *Parser) parseOperationList(mods map[string][]string) []string {
 	if !p.match(lexer.TOKEN_LBRACKET) {
 		p.error(p.peek(), "Expected '[' after @operations")
 		return nil
@@ -729,6 +1119,22 @@ func (p *Parser) parseOperations() []string {
 			operations = append(operations, opToken.Lexeme)
 		}
 
+		if mods != nil && p.match(lexer.TOKEN_LPAREN) {
+			for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+				modToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected operation modifier")
+				if modToken.Type != lexer.TOKEN_ERROR {
+					mods[opToken.Lexeme] = append(mods[opToken.Lexeme], modToken.Lexeme)
+				}
+				if !p.check(lexer.TOKEN_RPAREN) && !p.match(lexer.TOKEN_COMMA) {
+					p.error(p.peek(), "Expected ',' or ')' after operation modifier")
+					break
+				}
+			}
+			if !p.match(lexer.TOKEN_RPAREN) {
+				p.error(p.peek(), "Expected ')' after operation modifiers")
+			}
+		}
+
 		if !p.check(lexer.TOKEN_RBRACKET) {
 			if !p.match(lexer.TOKEN_COMMA) {
 				p.error(p.peek(), "Expected ',' or ']' after operation")
@@ -755,7 +1161,11 @@ func (p *Parser) parseMiddleware() []string {
 	for !p.check(lexer.TOKEN_RBRACKET) && !p.isAtEnd() {
 		mwToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected middleware name")
 		if mwToken.Type != lexer.TOKEN_ERROR {
-			middleware = append(middleware, mwToken.Lexeme)
+			mw := mwToken.Lexeme
+			if p.check(lexer.TOKEN_LPAREN) {
+				mw += p.parseMiddlewareArgs()
+			}
+			middleware = append(middleware, mw)
 		}
 
 		if !p.check(lexer.TOKEN_RBRACKET) {
@@ -773,114 +1183,1346 @@ func (p *Parser) parseMiddleware() []string {
 	return middleware
 }
 
-// parseStatementList parses a list of statements
-func (p *Parser) parseStatementList() []ast.StmtNode {
-	statements := make([]ast.StmtNode, 0)
-
-	for !p.check(lexer.TOKEN_RBRACE) && !p.isAtEnd() {
-		if stmt := p.parseStatement(); stmt != nil {
-			statements = append(statements, stmt)
+// parseMiddlewareArgs parses the arguments of a middleware entry, such as
+// rate_limit(100/hour) or signed_request(PARTNER_SECRET), and returns them as
+// written, parentheses included
+func (p *Parser) parseMiddlewareArgs() string {
+	p.advance() // (
+
+	var args strings.Builder
+	args.WriteString("(")
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		token := p.advance()
+		args.WriteString(token.Lexeme)
+		if token.Type == lexer.TOKEN_COMMA {
+			args.WriteString(" ")
 		}
 	}
-
-	return statements
+	p.consume(lexer.TOKEN_RPAREN, "Expected ')' after middleware arguments")
+	args.WriteString(")")
+	return args.String()
 }
 
-// parseStatement parses a single statement
-func (p *Parser) parseStatement() ast.StmtNode {
-	// Check for control flow
-	if p.match(lexer.TOKEN_IF) {
-		return p.parseIfStatement()
+// parseAlias parses the argument list of a resource-level @alias annotation
+func (p *Parser) parseAlias() []string {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @alias")
+		return nil
 	}
 
-	if p.match(lexer.TOKEN_RETURN) {
-		return p.parseReturnStatement()
+	aliases := make([]string, 0)
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		aliasToken := p.consume(lexer.TOKEN_STRING_LITERAL, "Expected string literal in @alias")
+		if aliasToken.Type == lexer.TOKEN_ERROR {
+			break
+		}
+
+		if str, ok := aliasToken.Literal.(string); ok {
+			aliases = append(aliases, str)
+		}
+
+		if !p.check(lexer.TOKEN_RPAREN) {
+			if !p.match(lexer.TOKEN_COMMA) {
+				p.error(p.peek(), "Expected ',' or ')' after alias")
+				break
+			}
+		}
 	}
 
-	if p.match(lexer.TOKEN_LET) {
-		return p.parseLetStatement()
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after aliases")
 	}
 
-	if p.match(lexer.TOKEN_MATCH) {
-		return p.parseMatchStatement()
+	return aliases
+}
+
+// parseCountStrategy parses the @count annotation, e.g. @count(estimated)
+func (p *Parser) parseCountStrategy() string {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @count")
+		return ""
 	}
 
-	// Check for async block
-	if p.check(lexer.TOKEN_ASYNC) {
-		return p.parseAsyncBlock()
+	strategyToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected count strategy (exact, estimated or none)")
+	strategy := ""
+	if strategyToken.Type != lexer.TOKEN_ERROR {
+		switch strategyToken.Lexeme {
+		case ast.CountExact, ast.CountEstimated, ast.CountNone:
+			strategy = strategyToken.Lexeme
+		default:
+			p.error(strategyToken, fmt.Sprintf("Unknown count strategy: %s (expected exact, estimated or none)", strategyToken.Lexeme))
+		}
 	}
 
-	// Try to parse as expression statement or assignment
-	expr := p.parseExpression()
-	if expr == nil {
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after count strategy")
+	}
+
+	return strategy
+}
+
+// sloPercentiles are the latency percentiles accepted by @slo (latency_p50 ... latency_p99)
+var sloPercentiles = map[string]int{
+	"latency_p50": 50,
+	"latency_p75": 75,
+	"latency_p90": 90,
+	"latency_p95": 95,
+	"latency_p99": 99,
+}
+
+// parseSLO parses @slo(latency_p99: 300ms, availability: 99.9)
+func (p *Parser) parseSLO(annotationToken lexer.Token) *ast.SLONode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @slo")
 		return nil
 	}
 
-	// Check if it's an assignment
-	if p.match(lexer.TOKEN_EQUALS) {
-		value := p.parseExpression()
-		if value == nil {
-			p.error(p.peek(), "Expected expression after '='")
+	slo := &ast.SLONode{Loc: ast.TokenLocation(annotationToken)}
+	seen := make(map[string]bool)
+
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		keyToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected SLO objective (latency_p50 ... latency_p99 or availability)")
+		if keyToken.Type == lexer.TOKEN_ERROR {
 			return nil
 		}
+		if seen[keyToken.Lexeme] {
+			p.error(keyToken, fmt.Sprintf("Duplicate SLO objective: %s", keyToken.Lexeme))
+		}
+		seen[keyToken.Lexeme] = true
 
-		return &ast.AssignmentStmt{
-			Target: expr,
-			Value:  value,
-			Loc:    expr.Location(),
+		if !p.match(lexer.TOKEN_COLON) {
+			p.error(p.peek(), fmt.Sprintf("Expected ':' after %s", keyToken.Lexeme))
+			return nil
 		}
-	}
 
-	// Expression statement
-	return &ast.ExprStmt{
-		Expr: expr,
-		Loc:  expr.Location(),
-	}
-}
+		valueToken := p.peek()
+		value, ok := p.parseSLONumber()
+		if !ok {
+			return nil
+		}
 
-// parseIfStatement parses an if/elsif/else statement
-func (p *Parser) parseIfStatement() ast.StmtNode {
-	loc := ast.TokenLocation(p.previous())
+		if percentile, isLatency := sloPercentiles[keyToken.Lexeme]; isLatency {
+			unitToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected latency unit (ms or s)")
+			var threshold time.Duration
+			switch unitToken.Lexeme {
+			case "ms":
+				threshold = time.Duration(value * float64(time.Millisecond))
+			case "s":
+				threshold = time.Duration(value * float64(time.Second))
+			default:
+				if unitToken.Type != lexer.TOKEN_ERROR {
+					p.error(unitToken, fmt.Sprintf("Unknown latency unit: %s (expected ms or s)", unitToken.Lexeme))
+				}
+				return nil
+			}
+			if threshold <= 0 {
+				p.error(valueToken, "SLO latency must be greater than zero")
+			}
+			slo.Latency = append(slo.Latency, ast.LatencyObjective{Percentile: percentile, Threshold: threshold})
+		} else if keyToken.Lexeme == "availability" {
+			if value <= 0 || value >= 100 {
+				p.error(valueToken, "SLO availability must be a percentage between 0 and 100 (exclusive)")
+			}
+			slo.Availability = value
+		} else {
+			p.error(keyToken, fmt.Sprintf("Unknown SLO objective: %s (expected latency_p50 ... latency_p99 or availability)", keyToken.Lexeme))
+			p.match(lexer.TOKEN_IDENTIFIER) // Skip a unit such as ms
+		}
 
-	condition := p.parseExpression()
-	if condition == nil {
-		p.error(p.peek(), "Expected condition after 'if'")
+		if !p.match(lexer.TOKEN_COMMA) {
+			break
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after SLO objectives")
 		return nil
 	}
 
-	if !p.match(lexer.TOKEN_LBRACE) {
-		p.error(p.peek(), "Expected '{' for if body")
+	if len(slo.Latency) == 0 && slo.Availability == 0 {
+		p.error(annotationToken, "@slo requires at least one objective")
 		return nil
 	}
 
-	thenBranch := p.parseStatementList()
+	return slo
+}
 
-	if !p.match(lexer.TOKEN_RBRACE) {
-		p.error(p.peek(), "Expected '}' after if body")
+// parseSLONumber parses an integer or float literal used as an SLO value
+func (p *Parser) parseSLONumber() (float64, bool) {
+	token := p.peek()
+	switch token.Type {
+	case lexer.TOKEN_INT_LITERAL:
+		p.advance()
+		if v, ok := token.Literal.(int64); ok {
+			return float64(v), true
+		}
+	case lexer.TOKEN_FLOAT_LITERAL:
+		p.advance()
+		if v, ok := token.Literal.(float64); ok {
+			return v, true
+		}
 	}
+	p.error(token, "Expected a number")
+	return 0, false
+}
 
-	ifStmt := &ast.IfStmt{
-		Condition:     condition,
-		ThenBranch:    thenBranch,
-		ElsIfBranches: make([]*ast.ElsIfBranch, 0),
-		Loc:           loc,
+// parseCacheControl parses @cache_control(max_age: 60, public: true).
+// Also accepts s_maxage and stale_while_revalidate, in seconds.
+func (p *Parser) parseCacheControl(annotationToken lexer.Token) *ast.CacheControlNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @cache_control")
+		return nil
 	}
 
-	// Parse elsif branches
-	for p.match(lexer.TOKEN_ELSIF) {
-		elsifLoc := ast.TokenLocation(p.previous())
-		elsifCondition := p.parseExpression()
-		if elsifCondition == nil {
-			p.error(p.peek(), "Expected condition after 'elsif'")
-			break
-		}
+	cacheControl := &ast.CacheControlNode{Loc: ast.TokenLocation(annotationToken)}
+	seen := make(map[string]bool)
 
-		if !p.match(lexer.TOKEN_LBRACE) {
-			p.error(p.peek(), "Expected '{' for elsif body")
-			break
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		keyToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected cache option (max_age, s_maxage, stale_while_revalidate or public)")
+		if keyToken.Type == lexer.TOKEN_ERROR {
+			return nil
 		}
+		if seen[keyToken.Lexeme] {
+			p.error(keyToken, fmt.Sprintf("Duplicate cache option: %s", keyToken.Lexeme))
+		}
+		seen[keyToken.Lexeme] = true
 
-		elsifBody := p.parseStatementList()
+		if !p.match(lexer.TOKEN_COLON) {
+			p.error(p.peek(), fmt.Sprintf("Expected ':' after %s", keyToken.Lexeme))
+			return nil
+		}
+
+		switch keyToken.Lexeme {
+		case "public":
+			switch {
+			case p.match(lexer.TOKEN_TRUE):
+				cacheControl.Public = true
+			case p.match(lexer.TOKEN_FALSE):
+				cacheControl.Public = false
+			default:
+				p.error(p.peek(), "Expected true or false for public")
+				return nil
+			}
+		case "max_age", "s_maxage", "stale_while_revalidate":
+			valueToken := p.peek()
+			seconds, ok := valueToken.Literal.(int64)
+			if valueToken.Type != lexer.TOKEN_INT_LITERAL || !ok || seconds < 0 {
+				p.error(valueToken, fmt.Sprintf("Expected a non-negative number of seconds for %s", keyToken.Lexeme))
+				return nil
+			}
+			p.advance()
+
+			switch keyToken.Lexeme {
+			case "max_age":
+				cacheControl.MaxAge = int(seconds)
+			case "s_maxage":
+				cacheControl.SharedMaxAge = int(seconds)
+			case "stale_while_revalidate":
+				cacheControl.StaleWhileRevalidate = int(seconds)
+			}
+		default:
+			p.error(keyToken, fmt.Sprintf("Unknown cache option: %s (expected max_age, s_maxage, stale_while_revalidate or public)", keyToken.Lexeme))
+			p.advance() // Skip the value
+		}
+
+		if !p.match(lexer.TOKEN_COMMA) {
+			break
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after cache options")
+		return nil
+	}
+
+	if !seen["max_age"] {
+		p.error(annotationToken, "@cache_control requires max_age")
+		return nil
+	}
+	if cacheControl.SharedMaxAge > 0 && !cacheControl.Public {
+		p.error(annotationToken, "@cache_control s_maxage requires public: true (shared caches do not store private responses)")
+	}
+
+	return cacheControl
+}
+
+// parseConflict parses @conflict(strategy: last_write_wins | reject | merge(field, ...))
+func (p *Parser) parseConflict(annotationToken lexer.Token) *ast.ConflictNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @conflict")
+		return nil
+	}
+
+	conflict := &ast.ConflictNode{Loc: ast.TokenLocation(annotationToken)}
+	seen := make(map[string]bool)
+
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		keyToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected conflict option (strategy)")
+		if keyToken.Type == lexer.TOKEN_ERROR {
+			return nil
+		}
+		if seen[keyToken.Lexeme] {
+			p.error(keyToken, fmt.Sprintf("Duplicate conflict option: %s", keyToken.Lexeme))
+		}
+		seen[keyToken.Lexeme] = true
+
+		if !p.match(lexer.TOKEN_COLON) {
+			p.error(p.peek(), fmt.Sprintf("Expected ':' after %s", keyToken.Lexeme))
+			return nil
+		}
+
+		switch keyToken.Lexeme {
+		case "strategy":
+			strategyToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected conflict strategy (last_write_wins, reject or merge)")
+			if strategyToken.Type == lexer.TOKEN_ERROR {
+				return nil
+			}
+
+			switch strategyToken.Lexeme {
+			case ast.ConflictLastWriteWins, ast.ConflictReject:
+				conflict.Strategy = strategyToken.Lexeme
+			case ast.ConflictMerge:
+				conflict.Strategy = ast.ConflictMerge
+				fields, ok := p.parseMergeFields()
+				if !ok {
+					return nil
+				}
+				conflict.MergeFields = fields
+			default:
+				p.error(strategyToken, fmt.Sprintf("Unknown conflict strategy: %s (expected last_write_wins, reject or merge)", strategyToken.Lexeme))
+			}
+		default:
+			p.error(keyToken, fmt.Sprintf("Unknown conflict option: %s (expected strategy)", keyToken.Lexeme))
+			p.advance() // Skip the value
+		}
+
+		if !p.match(lexer.TOKEN_COMMA) {
+			break
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after conflict options")
+		return nil
+	}
+
+	if !seen["strategy"] {
+		p.error(annotationToken, "@conflict requires strategy")
+		return nil
+	}
+
+	return conflict
+}
+
+// parseMergeFields parses the field list of merge(field, ...)
+func (p *Parser) parseMergeFields() ([]string, bool) {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after merge")
+		return nil, false
+	}
+
+	var fields []string
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		fieldToken := p.consumeFieldName()
+		if fieldToken.Type == lexer.TOKEN_ERROR {
+			return nil, false
+		}
+		fields = append(fields, fieldToken.Lexeme)
+
+		if !p.match(lexer.TOKEN_COMMA) {
+			break
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after merge fields")
+		return nil, false
+	}
+	if len(fields) == 0 {
+		p.error(p.previous(), "merge requires at least one field")
+		return nil, false
+	}
+
+	return fields, true
+}
+
+// parsePartition parses @partition(by: field, interval: day|week|month|year)
+func (p *Parser) parsePartition(annotationToken lexer.Token) *ast.PartitionNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @partition")
+		return nil
+	}
+
+	partition := &ast.PartitionNode{Loc: ast.TokenLocation(annotationToken)}
+	seen := make(map[string]bool)
+
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		// "by" is a keyword, so accept it alongside identifiers
+		var keyToken lexer.Token
+		if p.check(lexer.TOKEN_BY) {
+			keyToken = p.advance()
+		} else {
+			keyToken = p.consume(lexer.TOKEN_IDENTIFIER, "Expected partition option (by, interval)")
+			if keyToken.Type == lexer.TOKEN_ERROR {
+				return nil
+			}
+		}
+		if seen[keyToken.Lexeme] {
+			p.error(keyToken, fmt.Sprintf("Duplicate partition option: %s", keyToken.Lexeme))
+		}
+		seen[keyToken.Lexeme] = true
+
+		if !p.match(lexer.TOKEN_COLON) {
+			p.error(p.peek(), fmt.Sprintf("Expected ':' after %s", keyToken.Lexeme))
+			return nil
+		}
+
+		switch keyToken.Lexeme {
+		case "by":
+			fieldToken := p.consumeFieldName()
+			if fieldToken.Type == lexer.TOKEN_ERROR {
+				return nil
+			}
+			partition.Field = fieldToken.Lexeme
+		case "interval":
+			intervalToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected partition interval (day, week, month or year)")
+			if intervalToken.Type == lexer.TOKEN_ERROR {
+				return nil
+			}
+
+			switch intervalToken.Lexeme {
+			case ast.PartitionDay, ast.PartitionWeek, ast.PartitionMonth, ast.PartitionYear:
+				partition.Interval = intervalToken.Lexeme
+			default:
+				p.error(intervalToken, fmt.Sprintf("Unknown partition interval: %s (expected day, week, month or year)", intervalToken.Lexeme))
+			}
+		default:
+			p.error(keyToken, fmt.Sprintf("Unknown partition option: %s (expected by or interval)", keyToken.Lexeme))
+			p.advance() // Skip the value
+		}
+
+		if !p.match(lexer.TOKEN_COMMA) {
+			break
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after partition options")
+		return nil
+	}
+
+	if !seen["by"] || !seen["interval"] {
+		p.error(annotationToken, "@partition requires by and interval")
+		return nil
+	}
+
+	return partition
+}
+
+// parseMaterialized parses @materialized(refresh: hourly|daily|weekly, query: "SELECT ...")
+func (p *Parser) parseMaterialized(annotationToken lexer.Token) *ast.MaterializedNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @materialized")
+		return nil
+	}
+
+	materialized := &ast.MaterializedNode{Loc: ast.TokenLocation(annotationToken)}
+	seen := make(map[string]bool)
+
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		keyToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected materialized option (refresh, query)")
+		if keyToken.Type == lexer.TOKEN_ERROR {
+			return nil
+		}
+		if seen[keyToken.Lexeme] {
+			p.error(keyToken, fmt.Sprintf("Duplicate materialized option: %s", keyToken.Lexeme))
+		}
+		seen[keyToken.Lexeme] = true
+
+		if !p.match(lexer.TOKEN_COLON) {
+			p.error(p.peek(), fmt.Sprintf("Expected ':' after %s", keyToken.Lexeme))
+			return nil
+		}
+
+		switch keyToken.Lexeme {
+		case "refresh":
+			refreshToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected refresh schedule (hourly, daily or weekly)")
+			if refreshToken.Type == lexer.TOKEN_ERROR {
+				return nil
+			}
+
+			switch refreshToken.Lexeme {
+			case ast.RefreshHourly, ast.RefreshDaily, ast.RefreshWeekly:
+				materialized.Refresh = refreshToken.Lexeme
+			default:
+				p.error(refreshToken, fmt.Sprintf("Unknown refresh schedule: %s (expected hourly, daily or weekly)", refreshToken.Lexeme))
+			}
+		case "query":
+			queryToken := p.consume(lexer.TOKEN_STRING_LITERAL, "Expected SQL string for query")
+			if queryToken.Type == lexer.TOKEN_ERROR {
+				return nil
+			}
+			if query, ok := queryToken.Literal.(string); ok {
+				materialized.Query = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+			}
+		default:
+			p.error(keyToken, fmt.Sprintf("Unknown materialized option: %s (expected refresh or query)", keyToken.Lexeme))
+			p.advance() // Skip the value
+		}
+
+		if !p.match(lexer.TOKEN_COMMA) {
+			break
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after materialized options")
+		return nil
+	}
+
+	if !seen["refresh"] || !seen["query"] {
+		p.error(annotationToken, "@materialized requires refresh and query")
+		return nil
+	}
+
+	return materialized
+}
+
+// parseCounterCache parses @counter_cache(column on Resource.relationship)
+func (p *Parser) parseCounterCache(annotationToken lexer.Token) *ast.CounterCacheNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @counter_cache")
+		return nil
+	}
+
+	columnToken := p.consumeFieldName()
+	if columnToken.Type == lexer.TOKEN_ERROR {
+		return nil
+	}
+	if !p.match(lexer.TOKEN_ON) {
+		p.error(p.peek(), fmt.Sprintf("Expected 'on' after %s", columnToken.Lexeme))
+		return nil
+	}
+	resourceToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected resource name after 'on'")
+	if resourceToken.Type == lexer.TOKEN_ERROR {
+		return nil
+	}
+	if !p.match(lexer.TOKEN_DOT) {
+		p.error(p.peek(), fmt.Sprintf("Expected '.' after %s", resourceToken.Lexeme))
+		return nil
+	}
+	relationshipToken := p.consumeFieldName()
+	if relationshipToken.Type == lexer.TOKEN_ERROR {
+		return nil
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after counter cache")
+		return nil
+	}
+
+	return &ast.CounterCacheNode{
+		Column:       columnToken.Lexeme,
+		Resource:     resourceToken.Lexeme,
+		Relationship: relationshipToken.Lexeme,
+		Loc:          ast.TokenLocation(annotationToken),
+	}
+}
+
+// parseSearchIndex parses @search_index(field, ...)
+func (p *Parser) parseSearchIndex(annotationToken lexer.Token) *ast.SearchIndexNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @search_index")
+		return nil
+	}
+
+	searchIndex := &ast.SearchIndexNode{Loc: ast.TokenLocation(annotationToken)}
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		fieldToken := p.consumeFieldName()
+		if fieldToken.Type == lexer.TOKEN_ERROR {
+			return nil
+		}
+		searchIndex.Fields = append(searchIndex.Fields, fieldToken.Lexeme)
+
+		if !p.match(lexer.TOKEN_COMMA) {
+			break
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after search index fields")
+		return nil
+	}
+	if len(searchIndex.Fields) == 0 {
+		p.error(annotationToken, "@search_index requires at least one field")
+		return nil
+	}
+
+	return searchIndex
+}
+
+// isIndexBlock checks if the current token starts an index block. index is
+// not a keyword, so a field may still be named index.
+func (p *Parser) isIndexBlock() bool {
+	return p.check(lexer.TOKEN_IDENTIFIER) && p.peek().Lexeme == "index" &&
+		p.current+1 < len(p.tokens) && p.tokens[p.current+1].Type == lexer.TOKEN_LBRACKET
+}
+
+// parseIndex parses index [field, ...] with an optional { unique: bool,
+// name: "..." } body
+func (p *Parser) parseIndex() *ast.IndexNode {
+	indexToken := p.advance()
+	p.advance() // [
+
+	index := &ast.IndexNode{Loc: ast.TokenLocation(indexToken)}
+	for !p.check(lexer.TOKEN_RBRACKET) && !p.isAtEnd() {
+		fieldToken := p.consumeFieldName()
+		if fieldToken.Type == lexer.TOKEN_ERROR {
+			return nil
+		}
+		index.Fields = append(index.Fields, fieldToken.Lexeme)
+
+		if !p.match(lexer.TOKEN_COMMA) {
+			break
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RBRACKET) {
+		p.error(p.peek(), "Expected ']' after index fields")
+		return nil
+	}
+	if len(index.Fields) == 0 {
+		p.error(indexToken, "index requires at least one field")
+		return nil
+	}
+
+	if !p.match(lexer.TOKEN_LBRACE) {
+		return index
+	}
+
+	seen := make(map[string]bool)
+	for !p.check(lexer.TOKEN_RBRACE) && !p.isAtEnd() {
+		keyToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected index option (unique or name)")
+		if keyToken.Type == lexer.TOKEN_ERROR {
+			return nil
+		}
+		if seen[keyToken.Lexeme] {
+			p.error(keyToken, fmt.Sprintf("Duplicate index option: %s", keyToken.Lexeme))
+		}
+		seen[keyToken.Lexeme] = true
+
+		if !p.match(lexer.TOKEN_COLON) {
+			p.error(p.peek(), fmt.Sprintf("Expected ':' after %s", keyToken.Lexeme))
+			return nil
+		}
+
+		switch keyToken.Lexeme {
+		case "unique":
+			if !p.match(lexer.TOKEN_TRUE, lexer.TOKEN_FALSE) {
+				p.error(p.peek(), "Expected true or false for unique")
+				return nil
+			}
+			index.Unique = p.previous().Type == lexer.TOKEN_TRUE
+		case "name":
+			nameToken := p.consume(lexer.TOKEN_STRING_LITERAL, "Expected string literal for name")
+			if nameToken.Type == lexer.TOKEN_ERROR {
+				return nil
+			}
+			if str, ok := nameToken.Literal.(string); ok {
+				index.Name = str
+			} else {
+				index.Name = nameToken.Lexeme
+			}
+		default:
+			p.error(keyToken, fmt.Sprintf("Unknown index option: %s (expected unique or name)", keyToken.Lexeme))
+			p.advance() // Skip the value
+		}
+
+		// Options are separated by commas or newlines
+		p.match(lexer.TOKEN_COMMA)
+	}
+
+	if !p.match(lexer.TOKEN_RBRACE) {
+		p.error(p.peek(), "Expected '}' after index options")
+		return nil
+	}
+
+	return index
+}
+
+// parseWebhook parses @webhook(provider)
+func (p *Parser) parseWebhook(annotationToken lexer.Token) *ast.WebhookNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @webhook")
+		return nil
+	}
+
+	providerToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected webhook provider (stripe)")
+	if providerToken.Type == lexer.TOKEN_ERROR {
+		return nil
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after webhook provider")
+		return nil
+	}
+
+	return &ast.WebhookNode{
+		Provider: providerToken.Lexeme,
+		Loc:      ast.TokenLocation(annotationToken),
+	}
+}
+
+// parseProfiles parses @profile(name: [field, ...] | *, ...)
+func (p *Parser) parseProfiles(annotationToken lexer.Token) []*ast.ProfileNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @profile")
+		return nil
+	}
+
+	var profiles []*ast.ProfileNode
+	seen := make(map[string]bool)
+
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		// Profile names are roles, which may be spelled like type keywords
+		nameToken := p.consumeFieldName()
+		if nameToken.Type == lexer.TOKEN_ERROR {
+			return nil
+		}
+		if seen[nameToken.Lexeme] {
+			p.error(nameToken, fmt.Sprintf("Duplicate profile: %s", nameToken.Lexeme))
+		}
+		seen[nameToken.Lexeme] = true
+
+		if !p.match(lexer.TOKEN_COLON) {
+			p.error(p.peek(), fmt.Sprintf("Expected ':' after %s", nameToken.Lexeme))
+			return nil
+		}
+
+		profile := &ast.ProfileNode{Name: nameToken.Lexeme, Loc: ast.TokenLocation(nameToken)}
+		switch {
+		case p.match(lexer.TOKEN_STAR):
+			profile.All = true
+		case p.match(lexer.TOKEN_LBRACKET):
+			for !p.check(lexer.TOKEN_RBRACKET) && !p.isAtEnd() {
+				fieldToken := p.consumeFieldName()
+				if fieldToken.Type == lexer.TOKEN_ERROR {
+					return nil
+				}
+				profile.Fields = append(profile.Fields, fieldToken.Lexeme)
+
+				if !p.match(lexer.TOKEN_COMMA) {
+					break
+				}
+			}
+			if !p.match(lexer.TOKEN_RBRACKET) {
+				p.error(p.peek(), "Expected ']' after profile fields")
+				return nil
+			}
+		default:
+			p.error(p.peek(), fmt.Sprintf("Expected field list or * for profile %s", nameToken.Lexeme))
+			return nil
+		}
+		profiles = append(profiles, profile)
+
+		if !p.match(lexer.TOKEN_COMMA) {
+			break
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after profiles")
+		return nil
+	}
+	if len(profiles) == 0 {
+		p.error(annotationToken, "@profile requires at least one profile")
+		return nil
+	}
+
+	return profiles
+}
+
+// parseUpsert parses @upsert(on: [field, ...])
+func (p *Parser) parseUpsert(annotationToken lexer.Token) *ast.UpsertNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @upsert")
+		return nil
+	}
+
+	if !p.match(lexer.TOKEN_ON) {
+		p.error(p.peek(), "Expected 'on' in @upsert")
+		return nil
+	}
+	if !p.match(lexer.TOKEN_COLON) {
+		p.error(p.peek(), "Expected ':' after on")
+		return nil
+	}
+	if !p.match(lexer.TOKEN_LBRACKET) {
+		p.error(p.peek(), "Expected '[' before upsert conflict fields")
+		return nil
+	}
+
+	upsert := &ast.UpsertNode{Loc: ast.TokenLocation(annotationToken)}
+	for !p.check(lexer.TOKEN_RBRACKET) && !p.isAtEnd() {
+		fieldToken := p.consumeFieldName()
+		if fieldToken.Type == lexer.TOKEN_ERROR {
+			return nil
+		}
+		upsert.Fields = append(upsert.Fields, fieldToken.Lexeme)
+
+		if !p.match(lexer.TOKEN_COMMA) {
+			break
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RBRACKET) {
+		p.error(p.peek(), "Expected ']' after upsert conflict fields")
+		return nil
+	}
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after @upsert")
+		return nil
+	}
+	if len(upsert.Fields) == 0 {
+		p.error(annotationToken, "@upsert requires at least one conflict field")
+		return nil
+	}
+
+	return upsert
+}
+
+// parseOrderable parses @orderable or @orderable(scope: field)
+func (p *Parser) parseOrderable(annotationToken lexer.Token) *ast.OrderableNode {
+	orderable := &ast.OrderableNode{Loc: ast.TokenLocation(annotationToken)}
+	if !p.match(lexer.TOKEN_LPAREN) {
+		return orderable
+	}
+
+	keyToken := p.peek()
+	if keyToken.Type != lexer.TOKEN_IDENTIFIER || keyToken.Lexeme != "scope" {
+		p.error(keyToken, "Expected 'scope' in @orderable")
+		return nil
+	}
+	p.advance()
+	if !p.match(lexer.TOKEN_COLON) {
+		p.error(p.peek(), "Expected ':' after scope")
+		return nil
+	}
+
+	fieldToken := p.consumeFieldName()
+	if fieldToken.Type == lexer.TOKEN_ERROR {
+		return nil
+	}
+	orderable.Scope = fieldToken.Lexeme
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after @orderable")
+		return nil
+	}
+
+	return orderable
+}
+
+// parseTree parses @tree or @tree(parent: relationship, max_depth: n) with
+// the options in any order
+func (p *Parser) parseTree(annotationToken lexer.Token) *ast.TreeNode {
+	tree := &ast.TreeNode{Loc: ast.TokenLocation(annotationToken)}
+	if !p.match(lexer.TOKEN_LPAREN) {
+		return tree
+	}
+
+	seen := make(map[string]bool)
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		keyToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected tree option (parent or max_depth)")
+		if keyToken.Type == lexer.TOKEN_ERROR {
+			return nil
+		}
+		if seen[keyToken.Lexeme] {
+			p.error(keyToken, fmt.Sprintf("Duplicate tree option: %s", keyToken.Lexeme))
+		}
+		seen[keyToken.Lexeme] = true
+
+		if !p.match(lexer.TOKEN_COLON) {
+			p.error(p.peek(), fmt.Sprintf("Expected ':' after %s", keyToken.Lexeme))
+			return nil
+		}
+
+		switch keyToken.Lexeme {
+		case "parent":
+			relToken := p.consumeFieldName()
+			if relToken.Type == lexer.TOKEN_ERROR {
+				return nil
+			}
+			tree.Parent = relToken.Lexeme
+		case "max_depth":
+			valueToken := p.peek()
+			depth, ok := valueToken.Literal.(int64)
+			if valueToken.Type != lexer.TOKEN_INT_LITERAL || !ok || depth < 1 {
+				p.error(valueToken, "Expected a positive number of levels for max_depth")
+				return nil
+			}
+			p.advance()
+			tree.MaxDepth = int(depth)
+		default:
+			p.error(keyToken, fmt.Sprintf("Unknown tree option: %s (expected parent or max_depth)", keyToken.Lexeme))
+			p.advance() // Skip the value
+		}
+
+		if !p.match(lexer.TOKEN_COMMA) {
+			break
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after @tree options")
+		return nil
+	}
+
+	return tree
+}
+
+// parseIDStrategy parses @id(strategy: ulid|uuidv7|snowflake)
+func (p *Parser) parseIDStrategy(annotationToken lexer.Token) *ast.IDStrategyNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @id")
+		return nil
+	}
+
+	keyToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected id option (strategy)")
+	if keyToken.Type == lexer.TOKEN_ERROR {
+		return nil
+	}
+	if keyToken.Lexeme != "strategy" {
+		p.error(keyToken, fmt.Sprintf("Unknown id option: %s (expected strategy)", keyToken.Lexeme))
+		return nil
+	}
+	if !p.match(lexer.TOKEN_COLON) {
+		p.error(p.peek(), "Expected ':' after strategy")
+		return nil
+	}
+
+	// ulid is also a type keyword
+	if !p.isFieldNameToken() {
+		p.error(p.peek(), "Expected id strategy (ulid, uuidv7 or snowflake)")
+		return nil
+	}
+	strategyToken := p.advance()
+	node := &ast.IDStrategyNode{Strategy: strategyToken.Lexeme, Loc: ast.TokenLocation(annotationToken)}
+	switch node.Strategy {
+	case ast.IDStrategyULID, ast.IDStrategyUUIDv7, ast.IDStrategySnowflake:
+	default:
+		p.error(strategyToken, fmt.Sprintf("Unknown id strategy: %s (expected ulid, uuidv7 or snowflake)", strategyToken.Lexeme))
+		node = nil
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after @id strategy")
+		return nil
+	}
+
+	return node
+}
+
+// parsePrimaryKey parses a composite primary key, @primary(field, field, ...)
+func (p *Parser) parsePrimaryKey(annotationToken lexer.Token) *ast.PrimaryKeyNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @primary; declare a single-field key with @primary on the field")
+		return nil
+	}
+
+	primaryKey := &ast.PrimaryKeyNode{Loc: ast.TokenLocation(annotationToken)}
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		fieldToken := p.consumeFieldName()
+		if fieldToken.Type == lexer.TOKEN_ERROR {
+			return nil
+		}
+		primaryKey.Fields = append(primaryKey.Fields, fieldToken.Lexeme)
+
+		if !p.match(lexer.TOKEN_COMMA) {
+			break
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after @primary fields")
+		return nil
+	}
+	if len(primaryKey.Fields) == 0 {
+		p.error(annotationToken, "@primary requires at least one field")
+		return nil
+	}
+
+	return primaryKey
+}
+
+// parseSchema parses @schema("billing")
+func (p *Parser) parseSchema(annotationToken lexer.Token) *ast.SchemaNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @schema")
+		return nil
+	}
+
+	nameToken := p.consume(lexer.TOKEN_STRING_LITERAL, "Expected schema name string in @schema")
+	if nameToken.Type == lexer.TOKEN_ERROR {
+		return nil
+	}
+	name, _ := nameToken.Literal.(string)
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after @schema name")
+		return nil
+	}
+
+	return &ast.SchemaNode{Name: name, Loc: ast.TokenLocation(annotationToken)}
+}
+
+// parseExternalTable parses @external_table("legacy.users")
+func (p *Parser) parseExternalTable(annotationToken lexer.Token) *ast.ExternalTableNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @external_table")
+		return nil
+	}
+
+	tableToken := p.consume(lexer.TOKEN_STRING_LITERAL, "Expected table name string in @external_table")
+	if tableToken.Type == lexer.TOKEN_ERROR {
+		return nil
+	}
+	table, _ := tableToken.Literal.(string)
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after @external_table name")
+		return nil
+	}
+
+	return &ast.ExternalTableNode{Table: table, Loc: ast.TokenLocation(annotationToken)}
+}
+
+// parseOwner parses @owner("payments-team")
+func (p *Parser) parseOwner(annotationToken lexer.Token) *ast.OwnerNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @owner")
+		return nil
+	}
+
+	teamToken := p.consume(lexer.TOKEN_STRING_LITERAL, "Expected team name string in @owner")
+	if teamToken.Type == lexer.TOKEN_ERROR {
+		return nil
+	}
+	team, _ := teamToken.Literal.(string)
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after @owner team")
+		return nil
+	}
+
+	return &ast.OwnerNode{Team: team, Loc: ast.TokenLocation(annotationToken)}
+}
+
+// parseStability parses @stability(experimental), @stability(stable) or
+// @stability(deprecated)
+func (p *Parser) parseStability(annotationToken lexer.Token) *ast.StabilityNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @stability")
+		return nil
+	}
+
+	levelToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected stability (experimental, stable or deprecated)")
+	if levelToken.Type == lexer.TOKEN_ERROR {
+		return nil
+	}
+	node := &ast.StabilityNode{Level: levelToken.Lexeme, Loc: ast.TokenLocation(annotationToken)}
+	switch node.Level {
+	case ast.StabilityExperimental, ast.StabilityStable, ast.StabilityDeprecated:
+	default:
+		p.error(levelToken, fmt.Sprintf("Unknown stability: %s (expected experimental, stable or deprecated)", levelToken.Lexeme))
+		node = nil
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after @stability level")
+		return nil
+	}
+
+	return node
+}
+
+// parseShard parses @shard(by: field)
+func (p *Parser) parseShard(annotationToken lexer.Token) *ast.ShardNode {
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @shard")
+		return nil
+	}
+
+	// "by" is a keyword, so it is matched by token type
+	if !p.match(lexer.TOKEN_BY) {
+		p.error(p.peek(), "Expected shard option: by")
+		return nil
+	}
+	if !p.match(lexer.TOKEN_COLON) {
+		p.error(p.peek(), "Expected ':' after by")
+		return nil
+	}
+	fieldToken := p.consumeFieldName()
+	if fieldToken.Type == lexer.TOKEN_ERROR {
+		return nil
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after shard key")
+		return nil
+	}
+
+	return &ast.ShardNode{Field: fieldToken.Lexeme, Loc: ast.TokenLocation(annotationToken)}
+}
+
+// parseMeta parses @meta(key: "value", ...) and adds the pairs to meta, which
+// is nil for the first @meta of a resource or field
+func (p *Parser) parseMeta(annotationToken lexer.Token, meta *ast.MetaNode) *ast.MetaNode {
+	if meta == nil {
+		meta = &ast.MetaNode{Values: make(map[string]string), Loc: ast.TokenLocation(annotationToken)}
+	}
+
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @meta")
+		return meta
+	}
+
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		if !p.isFieldNameToken() {
+			p.error(p.peek(), "Expected @meta key")
+			return meta
+		}
+		keyToken := p.advance()
+
+		if !p.match(lexer.TOKEN_COLON) {
+			p.error(p.peek(), fmt.Sprintf("Expected ':' after %s", keyToken.Lexeme))
+			return meta
+		}
+
+		valueToken := p.consume(lexer.TOKEN_STRING_LITERAL, fmt.Sprintf("Expected string value for @meta key %s", keyToken.Lexeme))
+		if valueToken.Type == lexer.TOKEN_ERROR {
+			return meta
+		}
+		if _, ok := meta.Values[keyToken.Lexeme]; ok {
+			p.error(keyToken, fmt.Sprintf("Duplicate @meta key: %s", keyToken.Lexeme))
+		}
+		meta.Values[keyToken.Lexeme], _ = valueToken.Literal.(string)
+
+		if !p.check(lexer.TOKEN_RPAREN) && !p.match(lexer.TOKEN_COMMA) {
+			p.error(p.peek(), "Expected ',' or ')' after @meta value")
+			return meta
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after @meta values")
+	}
+
+	return meta
+}
+
+// parseLabels parses @labels(value: "Label", ...). Enum values that are not
+// identifiers, such as "in-review", are written as strings.
+func (p *Parser) parseLabels(annotationToken lexer.Token) *ast.LabelsNode {
+	labels := &ast.LabelsNode{Values: make(map[string]string), Loc: ast.TokenLocation(annotationToken)}
+
+	if !p.match(lexer.TOKEN_LPAREN) {
+		p.error(p.peek(), "Expected '(' after @labels")
+		return labels
+	}
+
+	for !p.check(lexer.TOKEN_RPAREN) && !p.isAtEnd() {
+		var value string
+		switch {
+		case p.check(lexer.TOKEN_STRING_LITERAL):
+			value, _ = p.advance().Literal.(string)
+		case p.isFieldNameToken():
+			value = p.advance().Lexeme
+		default:
+			p.error(p.peek(), "Expected enum value in @labels")
+			return labels
+		}
+		valueToken := p.previous()
+
+		if !p.match(lexer.TOKEN_COLON) {
+			p.error(p.peek(), fmt.Sprintf("Expected ':' after %s", value))
+			return labels
+		}
+
+		labelToken := p.consume(lexer.TOKEN_STRING_LITERAL, fmt.Sprintf("Expected string label for enum value %s", value))
+		if labelToken.Type == lexer.TOKEN_ERROR {
+			return labels
+		}
+		if _, ok := labels.Values[value]; ok {
+			p.error(valueToken, fmt.Sprintf("Duplicate label for enum value: %s", value))
+		}
+		labels.Values[value], _ = labelToken.Literal.(string)
+
+		if !p.check(lexer.TOKEN_RPAREN) && !p.match(lexer.TOKEN_COMMA) {
+			p.error(p.peek(), "Expected ',' or ')' after label")
+			return labels
+		}
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after @labels values")
+	}
+
+	return labels
+}
+
+// parseTimestamps parses @timestamps or @timestamps(false)
+func (p *Parser) parseTimestamps(annotationToken lexer.Token) *ast.TimestampsNode {
+	timestamps := &ast.TimestampsNode{Enabled: true, Loc: ast.TokenLocation(annotationToken)}
+	if !p.match(lexer.TOKEN_LPAREN) {
+		return timestamps
+	}
+
+	switch {
+	case p.match(lexer.TOKEN_TRUE):
+	case p.match(lexer.TOKEN_FALSE):
+		timestamps.Enabled = false
+	default:
+		p.error(p.peek(), "Expected true or false for @timestamps")
+		return nil
+	}
+
+	if !p.match(lexer.TOKEN_RPAREN) {
+		p.error(p.peek(), "Expected ')' after @timestamps")
+		return nil
+	}
+
+	return timestamps
+}
+
+// parseDefaultScope parses @default_scope { condition }
+func (p *Parser) parseDefaultScope(annotationToken lexer.Token) *ast.DefaultScopeNode {
+	if !p.match(lexer.TOKEN_LBRACE) {
+		p.error(p.peek(), "Expected '{' for @default_scope body")
+		return nil
+	}
+
+	defaultScope := &ast.DefaultScopeNode{
+		Condition: p.parseExpression(),
+		Loc:       ast.TokenLocation(annotationToken),
+	}
+
+	if !p.match(lexer.TOKEN_RBRACE) {
+		p.error(p.peek(), "Expected '}' after @default_scope body")
+		return nil
+	}
+
+	return defaultScope
+}
+
+// parseStatementList parses a list of statements
+func (p *Parser) parseStatementList() []ast.StmtNode {
+	statements := make([]ast.StmtNode, 0)
+
+	for !p.check(lexer.TOKEN_RBRACE) && !p.isAtEnd() {
+		if stmt := p.parseStatement(); stmt != nil {
+			statements = append(statements, stmt)
+		}
+	}
+
+	return statements
+}
+
+// parseStatement parses a single statement
+func (p *Parser) parseStatement() ast.StmtNode {
+	// Check for control flow
+	if p.match(lexer.TOKEN_IF) {
+		return p.parseIfStatement()
+	}
+
+	if p.match(lexer.TOKEN_RETURN) {
+		return p.parseReturnStatement()
+	}
+
+	if p.match(lexer.TOKEN_LET) {
+		return p.parseLetStatement()
+	}
+
+	if p.match(lexer.TOKEN_MATCH) {
+		return p.parseMatchStatement()
+	}
+
+	// Check for async block
+	if p.check(lexer.TOKEN_ASYNC) {
+		return p.parseAsyncBlock()
+	}
+
+	// Try to parse as expression statement or assignment
+	expr := p.parseExpression()
+	if expr == nil {
+		return nil
+	}
+
+	// Check if it's an assignment
+	if p.match(lexer.TOKEN_EQUALS) {
+		value := p.parseExpression()
+		if value == nil {
+			p.error(p.peek(), "Expected expression after '='")
+			return nil
+		}
+
+		return &ast.AssignmentStmt{
+			Target: expr,
+			Value:  value,
+			Loc:    expr.Location(),
+		}
+	}
+
+	// Expression statement
+	return &ast.ExprStmt{
+		Expr: expr,
+		Loc:  expr.Location(),
+	}
+}
+
+// parseIfStatement parses an if/elsif/else statement
+func (p *Parser) parseIfStatement() ast.StmtNode {
+	loc := ast.TokenLocation(p.previous())
+
+	condition := p.parseExpression()
+	if condition == nil {
+		p.error(p.peek(), "Expected condition after 'if'")
+		return nil
+	}
+
+	if !p.match(lexer.TOKEN_LBRACE) {
+		p.error(p.peek(), "Expected '{' for if body")
+		return nil
+	}
+
+	thenBranch := p.parseStatementList()
+
+	if !p.match(lexer.TOKEN_RBRACE) {
+		p.error(p.peek(), "Expected '}' after if body")
+	}
+
+	ifStmt := &ast.IfStmt{
+		Condition:     condition,
+		ThenBranch:    thenBranch,
+		ElsIfBranches: make([]*ast.ElsIfBranch, 0),
+		Loc:           loc,
+	}
+
+	// Parse elsif branches
+	for p.match(lexer.TOKEN_ELSIF) {
+		elsifLoc := ast.TokenLocation(p.previous())
+		elsifCondition := p.parseExpression()
+		if elsifCondition == nil {
+			p.error(p.peek(), "Expected condition after 'elsif'")
+			break
+		}
+
+		if !p.match(lexer.TOKEN_LBRACE) {
+			p.error(p.peek(), "Expected '{' for elsif body")
+			break
+		}
+
+		elsifBody := p.parseStatementList()
 
 		if !p.match(lexer.TOKEN_RBRACE) {
 			p.error(p.peek(), "Expected '}' after elsif body")
@@ -1053,11 +2695,22 @@ func (p *Parser) fieldToRelationship(field *ast.FieldNode) *ast.RelationshipNode
 		Nullable: field.Nullable,
 		Loc:      field.Loc,
 	}
+	// array<Tag!>! relates to Tag records
+	if field.Type.Kind == ast.TypeArray && field.Type.ElementType != nil {
+		relationship.Type = field.Type.ElementType.Name
+	}
 
 	// Parse relationship body
+	var kindToken lexer.Token
 	if p.match(lexer.TOKEN_LBRACE) {
 		for !p.check(lexer.TOKEN_RBRACE) && !p.isAtEnd() {
-			keyToken := p.consume(lexer.TOKEN_IDENTIFIER, "Expected relationship property")
+			// through is also a keyword
+			var keyToken lexer.Token
+			if p.check(lexer.TOKEN_THROUGH) {
+				keyToken = p.advance()
+			} else {
+				keyToken = p.consume(lexer.TOKEN_IDENTIFIER, "Expected relationship property")
+			}
 			if keyToken.Type == lexer.TOKEN_ERROR {
 				break
 			}
@@ -1092,6 +2745,13 @@ func (p *Parser) fieldToRelationship(field *ast.FieldNode) *ast.RelationshipNode
 					}
 					relationship.Kind = ast.RelationshipHasManyThrough
 				}
+			case "kind":
+				// has_many is also a keyword
+				if p.check(lexer.TOKEN_HAS_MANY) {
+					kindToken = p.advance()
+				} else {
+					kindToken = p.consume(lexer.TOKEN_IDENTIFIER, "Expected relationship kind")
+				}
 			default:
 				p.error(keyToken, fmt.Sprintf("Unknown relationship property: %s", keyToken.Lexeme))
 			}
@@ -1109,13 +2769,41 @@ func (p *Parser) fieldToRelationship(field *ast.FieldNode) *ast.RelationshipNode
 		relationship.Kind = ast.RelationshipHasMany
 	} else {
 		// Default to BelongsTo for scalar resource types
-		// TODO: Add support for HasOne relationships (requires 'kind' property or FK direction)
 		relationship.Kind = ast.RelationshipBelongsTo
 	}
 
+	if kindToken.Type == lexer.TOKEN_IDENTIFIER || kindToken.Type == lexer.TOKEN_HAS_MANY {
+		p.applyRelationshipKind(relationship, kindToken)
+	}
+
 	return relationship
 }
 
+// applyRelationshipKind applies a relationship's kind property, which must
+// agree with its type: has_one and belongs_to relate to a single record,
+// has_many to an array, and has_many_through needs a through table
+func (p *Parser) applyRelationshipKind(relationship *ast.RelationshipNode, kindToken lexer.Token) {
+	kind, ok := ast.RelationshipKindNames[kindToken.Lexeme]
+	if !ok {
+		p.error(kindToken, fmt.Sprintf("Unknown relationship kind: %s (expected belongs_to, has_one, has_many or has_many_through)", kindToken.Lexeme))
+		return
+	}
+
+	single := relationship.Kind == ast.RelationshipBelongsTo
+	switch {
+	case kind == ast.RelationshipHasManyThrough && relationship.Kind != ast.RelationshipHasManyThrough:
+		p.error(kindToken, fmt.Sprintf("Relationship %s is has_many_through but has no through table", relationship.Name))
+	case kind != ast.RelationshipHasManyThrough && relationship.Kind == ast.RelationshipHasManyThrough:
+		p.error(kindToken, fmt.Sprintf("Relationship %s has a through table, so its kind is has_many_through", relationship.Name))
+	case (kind == ast.RelationshipHasOne || kind == ast.RelationshipBelongsTo) && !single:
+		p.error(kindToken, fmt.Sprintf("Relationship %s is %s, so its type must be a single %s, not an array", relationship.Name, kindToken.Lexeme, relationship.Type))
+	case kind == ast.RelationshipHasMany && single:
+		p.error(kindToken, fmt.Sprintf("Relationship %s is has_many, so its type must be array<%s!>", relationship.Name, relationship.Type))
+	default:
+		relationship.Kind = kind
+	}
+}
+
 // isPrimitiveType checks if the current token is a primitive type
 func (p *Parser) isPrimitiveType() bool {
 	return p.check(lexer.TOKEN_STRING) ||
@@ -1133,7 +2821,9 @@ func (p *Parser) isPrimitiveType() bool {
 		p.check(lexer.TOKEN_EMAIL) ||
 		p.check(lexer.TOKEN_URL) ||
 		p.check(lexer.TOKEN_PHONE) ||
-		p.check(lexer.TOKEN_JSON)
+		p.check(lexer.TOKEN_JSON) ||
+		p.check(lexer.TOKEN_POINT) ||
+		p.check(lexer.TOKEN_POLYGON)
 }
 
 // isFieldConstraintToken checks if the current token is a field constraint annotation
@@ -1145,7 +2835,13 @@ func (p *Parser) isFieldConstraintToken() bool {
 		p.check(lexer.TOKEN_DEFAULT) ||
 		p.check(lexer.TOKEN_MIN) ||
 		p.check(lexer.TOKEN_MAX) ||
-		p.check(lexer.TOKEN_PATTERN)
+		p.check(lexer.TOKEN_PATTERN) ||
+		p.check(lexer.TOKEN_ALIAS) ||
+		p.check(lexer.TOKEN_COLUMN) ||
+		p.check(lexer.TOKEN_FILTERABLE) ||
+		p.check(lexer.TOKEN_SORTABLE) ||
+		p.check(lexer.TOKEN_DUAL_WRITE) ||
+		p.check(lexer.TOKEN_INDEX)
 }
 
 // isResourceAnnotationToken checks if the current token is a resource-level annotation
@@ -1157,7 +2853,34 @@ func (p *Parser) isResourceAnnotationToken() bool {
 		p.check(lexer.TOKEN_SCOPE) ||
 		p.check(lexer.TOKEN_COMPUTED) ||
 		p.check(lexer.TOKEN_OPERATIONS) ||
-		p.check(lexer.TOKEN_MIDDLEWARE)
+		p.check(lexer.TOKEN_MIDDLEWARE) ||
+		p.check(lexer.TOKEN_ALIAS) ||
+		p.check(lexer.TOKEN_COUNT) ||
+		p.check(lexer.TOKEN_SLO) ||
+		p.check(lexer.TOKEN_CACHE_CONTROL) ||
+		p.check(lexer.TOKEN_CHANGES) ||
+		p.check(lexer.TOKEN_CONFLICT) ||
+		p.check(lexer.TOKEN_PARTITION) ||
+		p.check(lexer.TOKEN_MATERIALIZED) ||
+		p.check(lexer.TOKEN_COUNTER_CACHE) ||
+		p.check(lexer.TOKEN_SEARCH_INDEX) ||
+		p.check(lexer.TOKEN_WEBHOOK) ||
+		p.check(lexer.TOKEN_PROFILE) ||
+		p.check(lexer.TOKEN_UPSERT) ||
+		p.check(lexer.TOKEN_ARCHIVABLE) ||
+		p.check(lexer.TOKEN_ORDERABLE) ||
+		p.check(lexer.TOKEN_TREE) ||
+		p.check(lexer.TOKEN_ID) ||
+		p.check(lexer.TOKEN_PRIMARY) ||
+		p.check(lexer.TOKEN_TIMESTAMPS) ||
+		p.check(lexer.TOKEN_SCHEMA) ||
+		p.check(lexer.TOKEN_EXTERNAL) ||
+		p.check(lexer.TOKEN_OWNER) ||
+		p.check(lexer.TOKEN_STABILITY) ||
+		p.check(lexer.TOKEN_META) ||
+		p.check(lexer.TOKEN_SHARD) ||
+		p.check(lexer.TOKEN_SOFT_DELETE) ||
+		p.check(lexer.TOKEN_DEFAULT_SCOPE)
 }
 
 // isFieldNameToken checks if the current token can be used as a field name
@@ -1194,6 +2917,8 @@ func (p *Parser) getTypeName(tokenType lexer.TokenType) string {
 		lexer.TOKEN_URL:       "url",
 		lexer.TOKEN_PHONE:     "phone",
 		lexer.TOKEN_JSON:      "json",
+		lexer.TOKEN_POINT:     "point",
+		lexer.TOKEN_POLYGON:   "polygon",
 	}
 
 	if name, ok := typeNames[tokenType]; ok {
@@ -1206,24 +2931,56 @@ func (p *Parser) getTypeName(tokenType lexer.TokenType) string {
 // getAnnotationName maps token types to annotation names
 func (p *Parser) getAnnotationName(tokenType lexer.TokenType) string {
 	annotationNames := map[lexer.TokenType]string{
-		lexer.TOKEN_BEFORE:      hookTimingBefore,
-		lexer.TOKEN_AFTER:       hookTimingAfter,
-		lexer.TOKEN_VALIDATE:    "validate",
-		lexer.TOKEN_CONSTRAINT:  "constraint",
-		lexer.TOKEN_SCOPE:       "scope",
-		lexer.TOKEN_COMPUTED:    "computed",
-		lexer.TOKEN_OPERATIONS:  "operations",
-		lexer.TOKEN_MIDDLEWARE:  "middleware",
-		lexer.TOKEN_PRIMARY:     "primary",
-		lexer.TOKEN_AUTO:        "auto",
-		lexer.TOKEN_AUTO_UPDATE: "auto_update",
-		lexer.TOKEN_UNIQUE:      "unique",
-		lexer.TOKEN_DEFAULT:     "default",
-		lexer.TOKEN_MIN:         "min",
-		lexer.TOKEN_MAX:         "max",
-		lexer.TOKEN_PATTERN:     "pattern",
-		lexer.TOKEN_TRANSACTION: "transaction",
-		lexer.TOKEN_ASYNC:       "async",
+		lexer.TOKEN_BEFORE:        hookTimingBefore,
+		lexer.TOKEN_AFTER:         hookTimingAfter,
+		lexer.TOKEN_VALIDATE:      "validate",
+		lexer.TOKEN_CONSTRAINT:    "constraint",
+		lexer.TOKEN_SCOPE:         "scope",
+		lexer.TOKEN_COMPUTED:      "computed",
+		lexer.TOKEN_OPERATIONS:    "operations",
+		lexer.TOKEN_MIDDLEWARE:    "middleware",
+		lexer.TOKEN_PRIMARY:       "primary",
+		lexer.TOKEN_AUTO:          "auto",
+		lexer.TOKEN_AUTO_UPDATE:   "auto_update",
+		lexer.TOKEN_UNIQUE:        "unique",
+		lexer.TOKEN_DEFAULT:       "default",
+		lexer.TOKEN_MIN:           "min",
+		lexer.TOKEN_MAX:           "max",
+		lexer.TOKEN_PATTERN:       "pattern",
+		lexer.TOKEN_TRANSACTION:   "transaction",
+		lexer.TOKEN_ASYNC:         "async",
+		lexer.TOKEN_ALIAS:         "alias",
+		lexer.TOKEN_COUNT:         "count",
+		lexer.TOKEN_COLUMN:        "column",
+		lexer.TOKEN_FILTERABLE:    "filterable",
+		lexer.TOKEN_SORTABLE:      "sortable",
+		lexer.TOKEN_DUAL_WRITE:    "dual_write",
+		lexer.TOKEN_SLO:           "slo",
+		lexer.TOKEN_CACHE_CONTROL: "cache_control",
+		lexer.TOKEN_CHANGES:       "changes",
+		lexer.TOKEN_CONFLICT:      "conflict",
+		lexer.TOKEN_PARTITION:     "partition",
+		lexer.TOKEN_MATERIALIZED:  "materialized",
+		lexer.TOKEN_COUNTER_CACHE: "counter_cache",
+		lexer.TOKEN_SEARCH_INDEX:  "search_index",
+		lexer.TOKEN_WEBHOOK:       "webhook",
+		lexer.TOKEN_PROFILE:       "profile",
+		lexer.TOKEN_UPSERT:        "upsert",
+		lexer.TOKEN_ARCHIVABLE:    "archivable",
+		lexer.TOKEN_ORDERABLE:     "orderable",
+		lexer.TOKEN_TREE:          "tree",
+		lexer.TOKEN_ID:            "id",
+		lexer.TOKEN_TIMESTAMPS:    "timestamps",
+		lexer.TOKEN_SCHEMA:        "schema",
+		lexer.TOKEN_EXTERNAL:      "external_table",
+		lexer.TOKEN_OWNER:         "owner",
+		lexer.TOKEN_STABILITY:     "stability",
+		lexer.TOKEN_META:          "meta",
+		lexer.TOKEN_SHARD:         "shard",
+		lexer.TOKEN_LABELS:        "labels",
+		lexer.TOKEN_SOFT_DELETE:   "soft_delete",
+		lexer.TOKEN_DEFAULT_SCOPE: "default_scope",
+		lexer.TOKEN_INDEX:         "index",
 	}
 
 	if name, ok := annotationNames[tokenType]; ok {
@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
 	"github.com/conduit-lang/conduit/internal/compiler/lexer"
@@ -131,6 +134,39 @@ func TestParseArrayType(t *testing.T) {
 	}
 }
 
+// TestParseSpatialTypes tests parsing point and polygon types
+func TestParseSpatialTypes(t *testing.T) {
+	source := `resource Store {
+  location: point!
+  area: polygon?
+  point: string!
+}`
+
+	program, errors := parseSource(t, source)
+
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	fields := program.Resources[0].Fields
+	tests := []struct {
+		name     string
+		typeName string
+		nullable bool
+	}{
+		{"location", "point", false},
+		{"area", "polygon", true},
+		{"point", "string", false}, // Type names remain valid field names
+	}
+	for i, tt := range tests {
+		field := fields[i]
+		if field.Name != tt.name || field.Type.Kind != ast.TypePrimitive || field.Type.Name != tt.typeName || field.Nullable != tt.nullable {
+			t.Errorf("field %d = %s: %s (nullable %v), want %s: %s (nullable %v)",
+				i, field.Name, field.Type.Name, field.Nullable, tt.name, tt.typeName, tt.nullable)
+		}
+	}
+}
+
 // TestParseHashType tests parsing hash types
 func TestParseHashType(t *testing.T) {
 	source := `resource Config {
@@ -334,6 +370,259 @@ func TestParseHookWithAsync(t *testing.T) {
 	}
 }
 
+// TestParseAttachHooks tests parsing attach and detach hooks on a
+// has_many_through relationship
+func TestParseAttachHooks(t *testing.T) {
+	source := `resource Post {
+  title: string!
+  tags: array<Tag!>! { through: "post_tags" }
+
+  @before attach(tags) {
+    self.title = self.title
+  }
+
+  @after detach(tags) @async {
+    self.title = self.title
+  }
+}`
+
+	program, errors := parseSource(t, source)
+
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	resource := program.Resources[0]
+
+	if len(resource.Relationships) != 1 {
+		t.Fatalf("Expected 1 relationship, got %d", len(resource.Relationships))
+	}
+	rel := resource.Relationships[0]
+	if rel.Kind != ast.RelationshipHasManyThrough || rel.Type != "Tag" || rel.Through != "post_tags" {
+		t.Errorf("Unexpected relationship: %+v", rel)
+	}
+
+	if len(resource.Hooks) != 2 {
+		t.Fatalf("Expected 2 hooks, got %d", len(resource.Hooks))
+	}
+	attach, detach := resource.Hooks[0], resource.Hooks[1]
+	if attach.Timing != "before" || attach.Event != ast.HookEventAttach || attach.Relationship != "tags" {
+		t.Errorf("Unexpected attach hook: %+v", attach)
+	}
+	if detach.Timing != "after" || detach.Event != ast.HookEventDetach || detach.Relationship != "tags" || !detach.IsAsync {
+		t.Errorf("Unexpected detach hook: %+v", detach)
+	}
+
+	// The relationship is required
+	_, errors = parseSource(t, `resource Post {
+  @after attach {
+    self.title = self.title
+  }
+}`)
+	if len(errors) == 0 {
+		t.Error("Expected an error for an attach hook without a relationship")
+	}
+}
+
+// TestParseHasOneRelationship tests parsing the kind property of a
+// relationship
+func TestParseHasOneRelationship(t *testing.T) {
+	source := `resource User {
+  profile: Profile? {
+    kind: has_one
+    foreign_key: "owner_id"
+    on_delete: cascade
+  }
+  posts: array<Post!>! { kind: has_many }
+  team: Team! { kind: belongs_to }
+}`
+
+	program, errors := parseSource(t, source)
+
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	rels := program.Resources[0].Relationships
+	if len(rels) != 3 {
+		t.Fatalf("Expected 3 relationships, got %d", len(rels))
+	}
+	if rels[0].Kind != ast.RelationshipHasOne || rels[0].Type != "Profile" || rels[0].ForeignKey != "owner_id" || rels[0].OnDelete != "cascade" || !rels[0].Nullable {
+		t.Errorf("Unexpected has_one relationship: %+v", rels[0])
+	}
+	if rels[1].Kind != ast.RelationshipHasMany || rels[2].Kind != ast.RelationshipBelongsTo {
+		t.Errorf("Unexpected kinds: %v, %v", rels[1].Kind, rels[2].Kind)
+	}
+
+	// The kind must agree with the relationship's type
+	for _, body := range []string{
+		"profiles: array<Profile!>! { kind: has_one }",
+		"profile: Profile! { kind: has_many }",
+		"profile: Profile! { kind: has_many_through }",
+		`tags: array<Tag!>! { through: "post_tags" kind: has_many }`,
+		"profile: Profile! { kind: has_two }",
+	} {
+		if _, errors := parseSource(t, "resource User {\n  "+body+"\n}"); len(errors) == 0 {
+			t.Errorf("Expected an error for %q", body)
+		}
+	}
+}
+
+// TestParseBatchHook tests parsing hooks with the batch modifier
+func TestParseBatchHook(t *testing.T) {
+	source := `resource Post {
+  title: string!
+
+  @after create batch @async {
+    Logger.info("posts created", records)
+  }
+}`
+
+	program, errors := parseSource(t, source)
+
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	hook := program.Resources[0].Hooks[0]
+
+	if !hook.Batch || !hook.IsAsync || hook.Event != "create" {
+		t.Errorf("Unexpected batch hook: %+v", hook)
+	}
+	if printed := ast.Print(program); !strings.Contains(printed, "@after create batch @async {") {
+		t.Errorf("Printed source lost the batch modifier:\n%s", printed)
+	}
+}
+
+// TestParseHookOnError tests parsing @on_error failure policies on hooks
+func TestParseHookOnError(t *testing.T) {
+	source := `resource Post {
+  title: string!
+
+  @before create @on_error(retry: 3) {
+    self.title = self.title
+  }
+
+  @after create @async @on_error(retry: 2, backoff: constant, policy: warn) {
+    self.title = self.title
+  }
+
+  @after update @on_error(policy: warn) {
+    self.title = self.title
+  }
+}`
+
+	program, errors := parseSource(t, source)
+
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	hooks := program.Resources[0].Hooks
+	tests := []struct {
+		retry   int
+		backoff string
+		policy  string
+	}{
+		{3, ast.BackoffExponential, ast.HookPolicyAbort},
+		{2, ast.BackoffConstant, ast.HookPolicyWarn},
+		{0, "", ast.HookPolicyWarn},
+	}
+	for i, tt := range tests {
+		onError := hooks[i].OnError
+		if onError == nil || onError.Retry != tt.retry || onError.Backoff != tt.backoff || onError.Policy != tt.policy {
+			t.Errorf("hook %d OnError = %+v, want retry %d, backoff %q, policy %q", i, onError, tt.retry, tt.backoff, tt.policy)
+		}
+	}
+	if !hooks[1].IsAsync {
+		t.Error("Expected @async alongside @on_error")
+	}
+
+	printed := ast.Print(program)
+	for _, want := range []string{
+		"@before create @on_error(retry: 3, backoff: exponential) {",
+		"@after create @async @on_error(retry: 2, backoff: constant, policy: warn) {",
+		"@after update @on_error(policy: warn) {",
+	} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("Printed source missing %q:\n%s", want, printed)
+		}
+	}
+
+	invalid := []string{
+		"@on_error()",
+		"@on_error(retry: 0)",
+		"@on_error(backoff: constant)",
+		"@on_error(policy: ignore)",
+		"@on_error(retries: 3)",
+		"@on_error(retry: 3) @on_error(policy: warn)",
+	}
+	for _, modifier := range invalid {
+		_, errors := parseSource(t, "resource Post {\n  @after create "+modifier+" {\n  }\n}")
+		if len(errors) == 0 {
+			t.Errorf("Expected an error for %s", modifier)
+		}
+	}
+}
+
+func TestParseHookTimeout(t *testing.T) {
+	source := `resource Post {
+  title: string!
+
+  @before create @timeout(2s) {
+    self.title = self.title
+  }
+
+  @after create @on_error(retry: 2) @timeout(250ms) {
+    self.title = self.title
+  }
+
+  @after update {
+    self.title = self.title
+  }
+}`
+
+	program, errors := parseSource(t, source)
+
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	hooks := program.Resources[0].Hooks
+	for i, want := range []time.Duration{2 * time.Second, 250 * time.Millisecond, 0} {
+		if hooks[i].Timeout != want {
+			t.Errorf("hook %d Timeout = %v, want %v", i, hooks[i].Timeout, want)
+		}
+	}
+	if hooks[1].OnError == nil {
+		t.Error("Expected @on_error alongside @timeout")
+	}
+
+	printed := ast.Print(program)
+	for _, want := range []string{
+		"@before create @timeout(2s) {",
+		"@after create @on_error(retry: 2, backoff: exponential) @timeout(250ms) {",
+	} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("Printed source missing %q:\n%s", want, printed)
+		}
+	}
+
+	invalid := []string{
+		"@timeout()",
+		"@timeout(2)",
+		"@timeout(2m)",
+		"@timeout(0s)",
+		"@timeout(2s) @timeout(3s)",
+	}
+	for _, modifier := range invalid {
+		_, errors := parseSource(t, "resource Post {\n  @after create "+modifier+" {\n  }\n}")
+		if len(errors) == 0 {
+			t.Errorf("Expected an error for %s", modifier)
+		}
+	}
+}
+
 // TestParseExpressions tests parsing various expressions
 func TestParseExpressions(t *testing.T) {
 	tests := []struct {
@@ -554,18 +843,12 @@ func TestParseComputed(t *testing.T) {
 	}
 }
 
-// TestParseMultipleResources tests parsing multiple resources
-func TestParseMultipleResources(t *testing.T) {
-	source := `resource User {
-  username: string!
-}
-
-resource Post {
-  title: string!
-}
+// TestParseCountStrategy tests parsing the @count resource annotation
+func TestParseCountStrategy(t *testing.T) {
+	source := `resource Event {
+  name: string!
 
-resource Comment {
-  body: text!
+  @count(estimated)
 }`
 
 	program, errors := parseSource(t, source)
@@ -574,47 +857,41 @@ resource Comment {
 		t.Fatalf("Parse errors: %v", errors)
 	}
 
-	if len(program.Resources) != 3 {
-		t.Fatalf("Expected 3 resources, got %d", len(program.Resources))
+	resource := program.Resources[0]
+	if resource.CountStrategy != ast.CountEstimated {
+		t.Errorf("Expected count strategy 'estimated', got '%s'", resource.CountStrategy)
 	}
 
-	expectedNames := []string{"User", "Post", "Comment"}
-	for i, name := range expectedNames {
-		if program.Resources[i].Name != name {
-			t.Errorf("Expected resource %d to be '%s', got '%s'", i, name, program.Resources[i].Name)
-		}
+	if len(resource.Fields) != 1 {
+		t.Errorf("Expected 1 field, got %d", len(resource.Fields))
 	}
 }
 
-// TestParseErrorRecovery tests error recovery
-func TestParseErrorRecovery(t *testing.T) {
-	source := `resource User {
-  username string!
-  email: string!
+// TestParseCountStrategyInvalid tests that unknown count strategies are rejected
+func TestParseCountStrategyInvalid(t *testing.T) {
+	source := `resource Event {
+  name: string!
+
+  @count(approximate)
 }`
 
 	program, errors := parseSource(t, source)
 
 	if len(errors) == 0 {
-		t.Fatal("Expected parse errors")
+		t.Fatal("Expected parse error for unknown count strategy")
 	}
 
-	// Should still parse the resource
-	if len(program.Resources) != 1 {
-		t.Errorf("Expected 1 resource despite errors, got %d", len(program.Resources))
+	if program.Resources[0].CountStrategy != "" {
+		t.Errorf("Expected empty count strategy, got '%s'", program.Resources[0].CountStrategy)
 	}
 }
 
-// TestParseIfStatement tests parsing if statements
-func TestParseIfStatement(t *testing.T) {
+// TestParseSLO tests parsing the @slo resource annotation
+func TestParseSLO(t *testing.T) {
 	source := `resource Post {
-  status: string!
+  title: string!
 
-  @before update {
-    if self.status == "published" {
-      self.published_at = Time.now()
-    }
-  }
+  @slo(latency_p99: 300ms, latency_p50: 0.05s, availability: 99.9)
 }`
 
 	program, errors := parseSource(t, source)
@@ -623,38 +900,60 @@ func TestParseIfStatement(t *testing.T) {
 		t.Fatalf("Parse errors: %v", errors)
 	}
 
-	hook := program.Resources[0].Hooks[0]
+	slo := program.Resources[0].SLO
+	if slo == nil {
+		t.Fatal("Expected SLO to be parsed")
+	}
 
-	if len(hook.Body) != 1 {
-		t.Fatalf("Expected 1 statement in hook body, got %d", len(hook.Body))
+	want := []ast.LatencyObjective{
+		{Percentile: 99, Threshold: 300 * time.Millisecond},
+		{Percentile: 50, Threshold: 50 * time.Millisecond},
+	}
+	if len(slo.Latency) != len(want) {
+		t.Fatalf("Expected %d latency objectives, got %d", len(want), len(slo.Latency))
+	}
+	for i := range want {
+		if slo.Latency[i] != want[i] {
+			t.Errorf("Latency[%d] = %+v, want %+v", i, slo.Latency[i], want[i])
+		}
 	}
 
-	ifStmt, ok := hook.Body[0].(*ast.IfStmt)
-	if !ok {
-		t.Fatal("Expected if statement")
+	if slo.Availability != 99.9 {
+		t.Errorf("Expected availability 99.9, got %v", slo.Availability)
 	}
+}
 
-	if ifStmt.Condition == nil {
-		t.Error("Expected if condition")
+// TestParseSLOInvalid tests that malformed @slo annotations are rejected
+func TestParseSLOInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"unknown objective", "@slo(latency_p98: 300ms)"},
+		{"unknown unit", "@slo(latency_p99: 300us)"},
+		{"missing unit", "@slo(latency_p99: 300)"},
+		{"availability out of range", "@slo(availability: 100)"},
+		{"duplicate objective", "@slo(availability: 99, availability: 99.9)"},
+		{"empty", "@slo()"},
 	}
 
-	if len(ifStmt.ThenBranch) == 0 {
-		t.Error("Expected non-empty then branch")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource Post {\n  title: string!\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
 	}
 }
 
-// TestParseIfElseStatement tests parsing if/else statements
-func TestParseIfElseStatement(t *testing.T) {
+// TestParseCacheControl tests parsing the @cache_control resource annotation
+func TestParseCacheControl(t *testing.T) {
 	source := `resource Post {
-  status: string!
+  title: string!
 
-  @before update {
-    if self.status == "published" {
-      self.published_at = Time.now()
-    } else {
-      self.published_at = null
-    }
-  }
+  @cache_control(max_age: 60, public: true, s_maxage: 300, stale_while_revalidate: 30)
 }`
 
 	program, errors := parseSource(t, source)
@@ -663,22 +962,48 @@ func TestParseIfElseStatement(t *testing.T) {
 		t.Fatalf("Parse errors: %v", errors)
 	}
 
-	ifStmt := program.Resources[0].Hooks[0].Body[0].(*ast.IfStmt)
+	cacheControl := program.Resources[0].CacheControl
+	if cacheControl == nil {
+		t.Fatal("Expected cache control to be parsed")
+	}
+	if cacheControl.MaxAge != 60 || cacheControl.SharedMaxAge != 300 || cacheControl.StaleWhileRevalidate != 30 || !cacheControl.Public {
+		t.Errorf("Unexpected cache control: %+v", cacheControl)
+	}
+}
 
-	if len(ifStmt.ElseBranch) == 0 {
-		t.Error("Expected non-empty else branch")
+// TestParseCacheControlInvalid tests that malformed @cache_control annotations are rejected
+func TestParseCacheControlInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing max_age", "@cache_control(public: true)"},
+		{"unknown option", "@cache_control(max_age: 60, immutable: true)"},
+		{"negative max_age", "@cache_control(max_age: -1)"},
+		{"non-boolean public", "@cache_control(max_age: 60, public: 1)"},
+		{"s_maxage without public", "@cache_control(max_age: 60, s_maxage: 300)"},
+		{"duplicate option", "@cache_control(max_age: 60, max_age: 30)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource Post {\n  title: string!\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
 	}
 }
 
-// TestParseLetStatement tests parsing let statements
-func TestParseLetStatement(t *testing.T) {
+// TestParseChanges tests parsing the @changes resource annotation
+func TestParseChanges(t *testing.T) {
 	source := `resource Post {
   title: string!
+  updated_at: timestamp! @auto_update
+  deleted_at: timestamp?
 
-  @before create {
-    let slug: string! = String.slugify(self.title)
-    self.slug = slug
-  }
+  @changes
 }`
 
 	program, errors := parseSource(t, source)
@@ -687,32 +1012,1322 @@ func TestParseLetStatement(t *testing.T) {
 		t.Fatalf("Parse errors: %v", errors)
 	}
 
-	hook := program.Resources[0].Hooks[0]
-
-	if len(hook.Body) < 1 {
-		t.Fatal("Expected at least 1 statement in hook body")
+	resource := program.Resources[0]
+	if resource.Changes == nil {
+		t.Fatal("Expected @changes to be parsed")
 	}
-
-	letStmt, ok := hook.Body[0].(*ast.LetStmt)
-	if !ok {
-		t.Fatal("Expected let statement")
+	if resource.Changes.Loc.Line != 6 {
+		t.Errorf("Changes.Loc.Line = %d, want 6", resource.Changes.Loc.Line)
+	}
+	if len(resource.Fields) != 3 {
+		t.Errorf("Expected 3 fields, got %d", len(resource.Fields))
 	}
+}
 
-	if letStmt.Name != "slug" {
-		t.Errorf("Expected variable name 'slug', got '%s'", letStmt.Name)
+func TestParseArchivable(t *testing.T) {
+	source := `resource Post {
+  title: string!
+  archived_at: timestamp?
+
+  @archivable
+}`
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
 	}
 
-	if letStmt.Type == nil {
-		t.Error("Expected variable type")
+	resource := program.Resources[0]
+	if resource.Archivable == nil {
+		t.Fatal("Expected @archivable to be parsed")
+	}
+	if resource.Archivable.Loc.Line != 5 {
+		t.Errorf("Archivable.Loc.Line = %d, want 5", resource.Archivable.Loc.Line)
 	}
 
-	if letStmt.Value == nil {
-		t.Error("Expected variable value")
+	_, errors = parseSource(t, "resource Post {\n  archived_at: timestamp?\n\n  @archivable\n  @archivable\n}")
+	if len(errors) == 0 {
+		t.Error("Expected an error for a duplicate @archivable")
 	}
 }
 
-// TestParseArrayLiteral tests parsing array literals
-func TestParseArrayLiteral(t *testing.T) {
+func TestParseSoftDelete(t *testing.T) {
+	source := `resource Post {
+  title: string!
+
+  @soft_delete
+}`
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	resource := program.Resources[0]
+	if resource.SoftDelete == nil {
+		t.Fatal("Expected @soft_delete to be parsed")
+	}
+	if resource.SoftDelete.Loc.Line != 4 {
+		t.Errorf("SoftDelete.Loc.Line = %d, want 4", resource.SoftDelete.Loc.Line)
+	}
+	// deleted_at is added later, by ast.WithSoftDeletes
+	if len(resource.Fields) != 1 {
+		t.Errorf("Expected 1 field, got %d", len(resource.Fields))
+	}
+
+	_, errors = parseSource(t, "resource Post {\n  title: string!\n\n  @soft_delete\n  @soft_delete\n}")
+	if len(errors) == 0 {
+		t.Error("Expected an error for a duplicate @soft_delete")
+	}
+}
+
+// TestParseDefaultScope tests parsing @default_scope and its condition
+func TestParseDefaultScope(t *testing.T) {
+	source := `resource Post {
+  title: string!
+  published: bool!
+
+  @soft_delete
+  @default_scope { self.deleted_at == nil and self.published }
+}`
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	scope := program.Resources[0].DefaultScope
+	if scope == nil {
+		t.Fatal("Expected @default_scope to be parsed")
+	}
+	if scope.Loc.Line != 6 {
+		t.Errorf("DefaultScope.Loc.Line = %d, want 6", scope.Loc.Line)
+	}
+	if _, ok := scope.Condition.(*ast.LogicalExpr); !ok {
+		t.Errorf("Expected a logical condition, got %T", scope.Condition)
+	}
+
+	for _, source := range []string{
+		"resource Post {\n  published: bool!\n\n  @default_scope { self.published }\n  @default_scope { self.published }\n}",
+		"resource Post {\n  published: bool!\n\n  @default_scope self.published\n}",
+		"resource Post {\n  published: bool!\n\n  @default_scope { self.published\n}",
+	} {
+		if _, errors := parseSource(t, source); len(errors) == 0 {
+			t.Errorf("Expected an error for %q", source)
+		}
+	}
+}
+
+// TestParseOperations tests the list, modifier and exclusion forms of @operations
+func TestParseOperations(t *testing.T) {
+	program, errors := parseSource(t, "resource Post {\n  title: string!\n\n  @operations [list, show, create(admin_only), update]\n}")
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+	post := program.Resources[0]
+	if want := []string{"list", "show", "create", "update"}; !reflect.DeepEqual(post.Operations, want) {
+		t.Errorf("Operations = %v, want %v", post.Operations, want)
+	}
+	if want := map[string][]string{"create": {"admin_only"}}; !reflect.DeepEqual(post.OperationMods, want) {
+		t.Errorf("OperationMods = %v, want %v", post.OperationMods, want)
+	}
+
+	program, errors = parseSource(t, "resource Post {\n  title: string!\n\n  @operations all except [delete, update]\n}")
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+	post = program.Resources[0]
+	if want := []string{"delete", "update"}; !reflect.DeepEqual(post.Excluded, want) || len(post.Operations) != 0 {
+		t.Errorf("Excluded = %v, Operations = %v, want %v and none", post.Excluded, post.Operations, want)
+	}
+
+	for _, source := range []string{
+		"resource Post {\n  @operations all [delete]\n}",
+		"resource Post {\n  @operations all except delete\n}",
+		"resource Post {\n  @operations [create(admin_only]\n}",
+		"resource Post {\n  @operations all except [delete(admin_only)]\n}",
+	} {
+		if _, errors := parseSource(t, source); len(errors) == 0 {
+			t.Errorf("Expected an error for %q", source)
+		}
+	}
+}
+
+func TestParseFilterOperators(t *testing.T) {
+	program, errors := parseSource(t, "resource Post {\n  views: int! @filterable(gte, lte, in)\n  deleted_at: timestamp? @filterable(null)\n}")
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+	post := program.Resources[0]
+	if want := []string{"gte", "lte", "in"}; !reflect.DeepEqual(post.Fields[0].FilterOperators(), want) {
+		t.Errorf("FilterOperators() = %v, want %v", post.Fields[0].FilterOperators(), want)
+	}
+	if want := []string{"null"}; !reflect.DeepEqual(post.Fields[1].FilterOperators(), want) {
+		t.Errorf("FilterOperators() = %v, want %v", post.Fields[1].FilterOperators(), want)
+	}
+
+	for _, source := range []string{
+		"resource Post {\n  views: int! @filterable(\"gte\")\n}",
+		"resource Post {\n  views: int! @filterable(gte lte)\n}",
+	} {
+		if _, errors := parseSource(t, source); len(errors) == 0 {
+			t.Errorf("Expected an error for %q", source)
+		}
+	}
+}
+
+// TestParseOrderable tests parsing @orderable with and without a scope
+func TestParseOrderable(t *testing.T) {
+	tests := []struct {
+		annotation string
+		scope      string
+	}{
+		{"@orderable", ""},
+		{"@orderable(scope: list_id)", "list_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.annotation, func(t *testing.T) {
+			source := "resource Task {\n  list_id: uuid!\n\n  " + tt.annotation + "\n}"
+			program, errors := parseSource(t, source)
+			if len(errors) > 0 {
+				t.Fatalf("Parse errors: %v", errors)
+			}
+
+			orderable := program.Resources[0].Orderable
+			if orderable == nil {
+				t.Fatal("Expected @orderable to be parsed")
+			}
+			if orderable.Scope != tt.scope {
+				t.Errorf("Scope = %q, want %q", orderable.Scope, tt.scope)
+			}
+			if orderable.Loc.Line != 4 {
+				t.Errorf("Loc.Line = %d, want 4", orderable.Loc.Line)
+			}
+		})
+	}
+
+	for _, source := range []string{
+		"resource Task {\n  @orderable(list_id)\n}",
+		"resource Task {\n  @orderable(scope: list_id\n}",
+		"resource Task {\n  @orderable\n  @orderable\n}",
+	} {
+		if _, errors := parseSource(t, source); len(errors) == 0 {
+			t.Errorf("Expected an error for %q", source)
+		}
+	}
+}
+
+func TestParseTree(t *testing.T) {
+	tests := []struct {
+		annotation string
+		parent     string
+		maxDepth   int
+	}{
+		{"@tree", "", 0},
+		{"@tree(parent: parent)", "parent", 0},
+		{"@tree(max_depth: 5)", "", 5},
+		{"@tree(parent: parent, max_depth: 5)", "parent", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.annotation, func(t *testing.T) {
+			source := "resource Category {\n  parent_id: uuid?\n\n  " + tt.annotation + "\n}"
+			program, errors := parseSource(t, source)
+			if len(errors) > 0 {
+				t.Fatalf("Parse errors: %v", errors)
+			}
+
+			tree := program.Resources[0].Tree
+			if tree == nil {
+				t.Fatal("Expected @tree to be parsed")
+			}
+			if tree.Parent != tt.parent || tree.MaxDepth != tt.maxDepth {
+				t.Errorf("Tree = %+v, want parent %q and max_depth %d", tree, tt.parent, tt.maxDepth)
+			}
+			if tree.Loc.Line != 4 {
+				t.Errorf("Loc.Line = %d, want 4", tree.Loc.Line)
+			}
+		})
+	}
+
+	for _, source := range []string{
+		"resource Category {\n  @tree(max_depth: 0)\n}",
+		"resource Category {\n  @tree(depth: 5)\n}",
+		"resource Category {\n  @tree(max_depth: 5, max_depth: 6)\n}",
+		"resource Category {\n  @tree(parent: parent\n}",
+		"resource Category {\n  @tree\n  @tree\n}",
+	} {
+		if _, errors := parseSource(t, source); len(errors) == 0 {
+			t.Errorf("Expected an error for %q", source)
+		}
+	}
+}
+
+func TestParseIDStrategy(t *testing.T) {
+	for _, strategy := range []string{ast.IDStrategyULID, ast.IDStrategyUUIDv7, ast.IDStrategySnowflake} {
+		t.Run(strategy, func(t *testing.T) {
+			source := "resource Order {\n  id: ulid! @primary @auto\n\n  @id(strategy: " + strategy + ")\n}"
+			program, errors := parseSource(t, source)
+			if len(errors) > 0 {
+				t.Fatalf("Parse errors: %v", errors)
+			}
+
+			id := program.Resources[0].IDStrategy
+			if id == nil {
+				t.Fatal("Expected @id to be parsed")
+			}
+			if id.Strategy != strategy {
+				t.Errorf("Strategy = %q, want %q", id.Strategy, strategy)
+			}
+			if id.Loc.Line != 4 {
+				t.Errorf("Loc.Line = %d, want 4", id.Loc.Line)
+			}
+		})
+	}
+
+	for _, source := range []string{
+		"resource Order {\n  @id\n}",
+		"resource Order {\n  @id(strategy: uuidv4)\n}",
+		"resource Order {\n  @id(kind: ulid)\n}",
+		"resource Order {\n  @id(strategy: ulid\n}",
+		"resource Order {\n  @id(strategy: ulid)\n  @id(strategy: ulid)\n}",
+	} {
+		if _, errors := parseSource(t, source); len(errors) == 0 {
+			t.Errorf("Expected an error for %q", source)
+		}
+	}
+}
+
+// TestParseTimestamps tests parsing the @timestamps resource annotation
+func TestParseTimestamps(t *testing.T) {
+	tests := []struct {
+		annotation string
+		enabled    bool
+	}{
+		{"@timestamps", true},
+		{"@timestamps(true)", true},
+		{"@timestamps(false)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.annotation, func(t *testing.T) {
+			source := "resource Post {\n  title: string!\n\n  " + tt.annotation + "\n}"
+			program, errors := parseSource(t, source)
+			if len(errors) > 0 {
+				t.Fatalf("Parse errors: %v", errors)
+			}
+
+			timestamps := program.Resources[0].Timestamps
+			if timestamps == nil {
+				t.Fatal("Expected @timestamps to be parsed")
+			}
+			if timestamps.Enabled != tt.enabled {
+				t.Errorf("Enabled = %v, want %v", timestamps.Enabled, tt.enabled)
+			}
+			if timestamps.Loc.Line != 4 {
+				t.Errorf("Loc.Line = %d, want 4", timestamps.Loc.Line)
+			}
+		})
+	}
+
+	for _, source := range []string{
+		"resource Post {\n  @timestamps(off)\n}",
+		"resource Post {\n  @timestamps(false\n}",
+		"resource Post {\n  @timestamps\n  @timestamps(false)\n}",
+	} {
+		if _, errors := parseSource(t, source); len(errors) == 0 {
+			t.Errorf("Expected an error for %q", source)
+		}
+	}
+}
+
+// TestParseConflict tests parsing the @conflict resource annotation
+func TestParseConflict(t *testing.T) {
+	tests := []struct {
+		annotation string
+		strategy   string
+		fields     []string
+	}{
+		{"@conflict(strategy: last_write_wins)", ast.ConflictLastWriteWins, nil},
+		{"@conflict(strategy: reject)", ast.ConflictReject, nil},
+		{"@conflict(strategy: merge(title, email))", ast.ConflictMerge, []string{"title", "email"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			source := "resource Post {\n  title: string!\n\n  " + tt.annotation + "\n}"
+			program, errors := parseSource(t, source)
+			if len(errors) > 0 {
+				t.Fatalf("Parse errors: %v", errors)
+			}
+
+			conflict := program.Resources[0].Conflict
+			if conflict == nil {
+				t.Fatal("Expected @conflict to be parsed")
+			}
+			if conflict.Strategy != tt.strategy {
+				t.Errorf("Strategy = %q, want %q", conflict.Strategy, tt.strategy)
+			}
+			if !reflect.DeepEqual(conflict.MergeFields, tt.fields) {
+				t.Errorf("MergeFields = %v, want %v", conflict.MergeFields, tt.fields)
+			}
+			if conflict.Loc.Line != 4 {
+				t.Errorf("Loc.Line = %d, want 4", conflict.Loc.Line)
+			}
+		})
+	}
+}
+
+func TestParseConflictInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing strategy", "@conflict()"},
+		{"unknown strategy", "@conflict(strategy: first_write_wins)"},
+		{"unknown option", "@conflict(strategy: reject, retries: 3)"},
+		{"duplicate option", "@conflict(strategy: reject, strategy: merge(title))"},
+		{"merge without fields", "@conflict(strategy: merge())"},
+		{"merge without list", "@conflict(strategy: merge)"},
+		{"duplicate annotation", "@conflict(strategy: reject)\n  @conflict(strategy: reject)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource Post {\n  title: string!\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParsePartition(t *testing.T) {
+	tests := []struct {
+		annotation string
+		field      string
+		interval   string
+	}{
+		{"@partition(by: created_at, interval: month)", "created_at", ast.PartitionMonth},
+		{"@partition(interval: day, by: created_at)", "created_at", ast.PartitionDay},
+		{"@partition(by: created_at, interval: week)", "created_at", ast.PartitionWeek},
+		{"@partition(by: created_at, interval: year)", "created_at", ast.PartitionYear},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.annotation, func(t *testing.T) {
+			source := "resource Event {\n  created_at: timestamp! @auto\n\n  " + tt.annotation + "\n}"
+			program, errors := parseSource(t, source)
+			if len(errors) > 0 {
+				t.Fatalf("Parse errors: %v", errors)
+			}
+
+			partition := program.Resources[0].Partition
+			if partition == nil {
+				t.Fatal("Expected @partition to be parsed")
+			}
+			if partition.Field != tt.field {
+				t.Errorf("Field = %q, want %q", partition.Field, tt.field)
+			}
+			if partition.Interval != tt.interval {
+				t.Errorf("Interval = %q, want %q", partition.Interval, tt.interval)
+			}
+			if partition.Loc.Line != 4 {
+				t.Errorf("Loc.Line = %d, want 4", partition.Loc.Line)
+			}
+		})
+	}
+}
+
+func TestParsePartitionInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing options", "@partition"},
+		{"missing by", "@partition(interval: month)"},
+		{"missing interval", "@partition(by: created_at)"},
+		{"unknown interval", "@partition(by: created_at, interval: hour)"},
+		{"unknown option", "@partition(by: created_at, interval: month, retain: 12)"},
+		{"duplicate option", "@partition(by: created_at, by: updated_at, interval: month)"},
+		{"duplicate annotation", "@partition(by: created_at, interval: month)\n  @partition(by: created_at, interval: day)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource Event {\n  created_at: timestamp! @auto\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParseMaterialized(t *testing.T) {
+	source := `resource PostStats {
+  id: uuid!
+  comment_count: int!
+
+  @materialized(refresh: hourly, query: "
+    SELECT posts.id, count(comments.id) AS comment_count
+    FROM posts LEFT JOIN comments ON comments.post_id = posts.id
+    GROUP BY posts.id
+  ")
+}`
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	materialized := program.Resources[0].Materialized
+	if materialized == nil {
+		t.Fatal("Expected @materialized to be parsed")
+	}
+	if materialized.Refresh != ast.RefreshHourly {
+		t.Errorf("Refresh = %q, want %q", materialized.Refresh, ast.RefreshHourly)
+	}
+	if !strings.HasPrefix(materialized.Query, "SELECT posts.id") || !strings.HasSuffix(materialized.Query, "GROUP BY posts.id") {
+		t.Errorf("Query should be the trimmed SQL, got %q", materialized.Query)
+	}
+	if materialized.Loc.Line != 5 {
+		t.Errorf("Loc.Line = %d, want 5", materialized.Loc.Line)
+	}
+}
+
+func TestParseMaterializedInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing options", "@materialized"},
+		{"missing query", "@materialized(refresh: daily)"},
+		{"missing refresh", `@materialized(query: "SELECT 1 AS id")`},
+		{"unknown schedule", `@materialized(refresh: monthly, query: "SELECT 1 AS id")`},
+		{"query is not a string", "@materialized(refresh: daily, query: posts)"},
+		{"unknown option", `@materialized(refresh: daily, query: "SELECT 1 AS id", concurrently: true)`},
+		{"duplicate annotation", `@materialized(refresh: daily, query: "SELECT 1 AS id")` + "\n  " + `@materialized(refresh: daily, query: "SELECT 1 AS id")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource PostStats {\n  id: int!\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParseCounterCache(t *testing.T) {
+	source := `resource Comment {
+  post_id: uuid!
+  post: Post! { foreign_key: "post_id" }
+
+  @counter_cache(comments_count on Post.comments)
+}`
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	counters := program.Resources[0].CounterCaches
+	if len(counters) != 1 {
+		t.Fatalf("Expected 1 counter cache, got %d", len(counters))
+	}
+	counter := counters[0]
+	if counter.Column != "comments_count" || counter.Resource != "Post" || counter.Relationship != "comments" {
+		t.Errorf("Unexpected counter cache: %+v", counter)
+	}
+	if counter.Loc.Line != 5 {
+		t.Errorf("Loc.Line = %d, want 5", counter.Loc.Line)
+	}
+}
+
+func TestParseCounterCacheInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing arguments", "@counter_cache"},
+		{"missing on", "@counter_cache(comments_count Post.comments)"},
+		{"missing relationship", "@counter_cache(comments_count on Post)"},
+		{"unclosed", "@counter_cache(comments_count on Post.comments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource Comment {\n  post_id: uuid!\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParseSearchIndex(t *testing.T) {
+	source := `resource Post {
+  title: string!
+  body: text!
+
+  @search_index(title, body)
+}`
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	searchIndex := program.Resources[0].SearchIndex
+	if searchIndex == nil {
+		t.Fatal("Expected search index")
+	}
+	if !reflect.DeepEqual(searchIndex.Fields, []string{"title", "body"}) {
+		t.Errorf("Fields = %v, want [title body]", searchIndex.Fields)
+	}
+	if searchIndex.Loc.Line != 5 {
+		t.Errorf("Loc.Line = %d, want 5", searchIndex.Loc.Line)
+	}
+}
+
+func TestParseSearchIndexInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing arguments", "@search_index"},
+		{"no fields", "@search_index()"},
+		{"unclosed", "@search_index(title"},
+		{"duplicate", "@search_index(title)\n  @search_index(title)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource Post {\n  title: string!\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParseIndexes(t *testing.T) {
+	source := `resource Post {
+  status: string! @index
+  index: int!
+  author_id: uuid!
+  published_at: timestamp?
+
+  author: User! {
+    foreign_key: "author_id"
+  }
+
+  index [author, published_at]
+  index [status, published_at] {
+    unique: true
+    name: "posts_status_published"
+  }
+  index [index] { unique: false }
+}`
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	resource := program.Resources[0]
+	if resource.FindField("index") == nil {
+		t.Error("Expected a field named index")
+	}
+
+	indexes := resource.Indexes
+	if len(indexes) != 3 {
+		t.Fatalf("Expected 3 index blocks, got %d", len(indexes))
+	}
+	if !reflect.DeepEqual(indexes[0].Fields, []string{"author", "published_at"}) || indexes[0].Unique || indexes[0].Name != "" {
+		t.Errorf("indexes[0] = %+v, want [author, published_at]", indexes[0])
+	}
+	if indexes[0].Loc.Line != 11 {
+		t.Errorf("Loc.Line = %d, want 11", indexes[0].Loc.Line)
+	}
+	if !indexes[1].Unique || indexes[1].Name != "posts_status_published" {
+		t.Errorf("indexes[1] = %+v, want unique posts_status_published", indexes[1])
+	}
+	if indexes[2].Unique || !reflect.DeepEqual(indexes[2].Fields, []string{"index"}) {
+		t.Errorf("indexes[2] = %+v, want [index]", indexes[2])
+	}
+
+	// @index fields come first, as single-field indexes
+	all := resource.AllIndexes()
+	if len(all) != 4 || !reflect.DeepEqual(all[0].Fields, []string{"status"}) {
+		t.Fatalf("AllIndexes() = %v, want status first", all)
+	}
+	if columns := resource.IndexColumns(all[1]); !reflect.DeepEqual(columns, []string{"author_id", "published_at"}) {
+		t.Errorf("IndexColumns() = %v, want [author_id published_at]", columns)
+	}
+
+	// Index blocks print back as they were declared
+	printed := ast.Print(program)
+	for _, want := range []string{
+		"status: string! @index",
+		"index [author, published_at]\n",
+		`index [status, published_at] { unique: true, name: "posts_status_published" }`,
+		"index [index]\n",
+	} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("Printed source missing %q:\n%s", want, printed)
+		}
+	}
+}
+
+func TestParseIndexesInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		block string
+	}{
+		{"no fields", "index []"},
+		{"unclosed", "index [title"},
+		{"unknown option", "index [title] { sparse: true }"},
+		{"duplicate option", "index [title] { unique: true, unique: false }"},
+		{"non-boolean unique", `index [title] { unique: "yes" }`},
+		{"non-string name", "index [title] { name: 3 }"},
+		{"unclosed options", "index [title] { unique: true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource Post {\n  title: string!\n\n  " + tt.block + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.block)
+			}
+		})
+	}
+}
+
+func TestParseProfiles(t *testing.T) {
+	source := `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+  notes: text?
+
+  @profile(public: [id, title], admin: *)
+}`
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	profiles := program.Resources[0].Profiles
+	if len(profiles) != 2 {
+		t.Fatalf("Expected 2 profiles, got %d", len(profiles))
+	}
+	if profiles[0].Name != "public" || profiles[0].All || !reflect.DeepEqual(profiles[0].Fields, []string{"id", "title"}) {
+		t.Errorf("profiles[0] = %+v, want public: [id, title]", profiles[0])
+	}
+	if profiles[1].Name != "admin" || !profiles[1].All || len(profiles[1].Fields) != 0 {
+		t.Errorf("profiles[1] = %+v, want admin: *", profiles[1])
+	}
+	if profiles[0].Loc.Line != 6 {
+		t.Errorf("Loc.Line = %d, want 6", profiles[0].Loc.Line)
+	}
+}
+
+func TestParseProfilesInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing arguments", "@profile"},
+		{"no profiles", "@profile()"},
+		{"missing fields", "@profile(public)"},
+		{"bare field", "@profile(public: title)"},
+		{"unclosed list", "@profile(public: [title)"},
+		{"duplicate profile", "@profile(public: [title], public: *)"},
+		{"duplicate annotation", "@profile(public: *)\n  @profile(admin: *)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource Post {\n  title: string!\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParseWebhook(t *testing.T) {
+	source := `resource StripeEvent {
+  event_id: string! @unique
+  event_type: string!
+  payload: json!
+
+  @webhook(stripe)
+}`
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	webhook := program.Resources[0].Webhook
+	if webhook == nil {
+		t.Fatal("Expected webhook")
+	}
+	if webhook.Provider != "stripe" {
+		t.Errorf("Provider = %q, want stripe", webhook.Provider)
+	}
+	if webhook.Loc.Line != 6 {
+		t.Errorf("Loc.Line = %d, want 6", webhook.Loc.Line)
+	}
+}
+
+func TestParseWebhookInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing arguments", "@webhook"},
+		{"no provider", "@webhook()"},
+		{"unclosed", "@webhook(stripe"},
+		{"two providers", "@webhook(stripe, paypal)"},
+		{"duplicate", "@webhook(stripe)\n  @webhook(stripe)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource StripeEvent {\n  event_id: string!\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParseUpsert(t *testing.T) {
+	source := `resource User {
+  id: uuid! @primary @auto
+  email: string! @unique
+  name: string!
+
+  @upsert(on: [email])
+}`
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	upsert := program.Resources[0].Upsert
+	if upsert == nil {
+		t.Fatal("Expected upsert")
+	}
+	if !reflect.DeepEqual(upsert.Fields, []string{"email"}) {
+		t.Errorf("Fields = %v, want [email]", upsert.Fields)
+	}
+	if upsert.Loc.Line != 6 {
+		t.Errorf("Loc.Line = %d, want 6", upsert.Loc.Line)
+	}
+}
+
+func TestParseUpsertInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing arguments", "@upsert"},
+		{"missing on", "@upsert([email])"},
+		{"bare field", "@upsert(on: email)"},
+		{"no fields", "@upsert(on: [])"},
+		{"unclosed list", "@upsert(on: [email)"},
+		{"duplicate", "@upsert(on: [email])\n  @upsert(on: [email])"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource User {\n  email: string! @unique\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParsePrimaryKey(t *testing.T) {
+	source := `resource Membership {
+  region: string!
+  code: int! @primary
+
+  @primary(region, code)
+}`
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	resource := program.Resources[0]
+	if resource.PrimaryKey == nil {
+		t.Fatal("Expected primary key")
+	}
+	if !reflect.DeepEqual(resource.PrimaryKey.Fields, []string{"region", "code"}) {
+		t.Errorf("Fields = %v, want [region code]", resource.PrimaryKey.Fields)
+	}
+	if resource.PrimaryKey.Loc.Line != 5 {
+		t.Errorf("Loc.Line = %d, want 5", resource.PrimaryKey.Loc.Line)
+	}
+	// @primary on the line after a field belongs to the resource, while
+	// @primary after a field's type on its own line still marks the field
+	if !resource.FindField("code").HasConstraint("primary") {
+		t.Error("Expected code to keep its @primary constraint")
+	}
+	if resource.FindField("region").HasConstraint("primary") {
+		t.Error("Expected region without a @primary constraint")
+	}
+}
+
+func TestParsePrimaryKeyInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing fields", "@primary"},
+		{"no fields", "@primary()"},
+		{"unclosed", "@primary(region, code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource Membership {\n  region: string!\n  code: int!\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	source := "resource Invoice {\n  total: float!\n\n  @schema(\"billing\")\n}"
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	schema := program.Resources[0].Schema
+	if schema == nil {
+		t.Fatal("Expected @schema to be parsed")
+	}
+	if schema.Name != "billing" {
+		t.Errorf("Name = %q, want %q", schema.Name, "billing")
+	}
+	if schema.Loc.Line != 4 {
+		t.Errorf("Loc.Line = %d, want 4", schema.Loc.Line)
+	}
+}
+
+func TestParseSchemaInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing name", "@schema"},
+		{"empty arguments", "@schema()"},
+		{"identifier", "@schema(billing)"},
+		{"duplicate annotation", "@schema(\"billing\")\n  @schema(\"sales\")"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource Invoice {\n  total: float!\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParseExternalTable(t *testing.T) {
+	source := "resource LegacyUser {\n  id: int! @primary\n  email: string!\n\n  @external_table(\"legacy.users\")\n}"
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	external := program.Resources[0].External
+	if external == nil {
+		t.Fatal("Expected @external_table to be parsed")
+	}
+	if external.Table != "legacy.users" {
+		t.Errorf("Table = %q, want %q", external.Table, "legacy.users")
+	}
+	if external.Loc.Line != 5 {
+		t.Errorf("Loc.Line = %d, want 5", external.Loc.Line)
+	}
+}
+
+func TestParseExternalTableInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing table", "@external_table"},
+		{"empty arguments", "@external_table()"},
+		{"identifier", "@external_table(users)"},
+		{"duplicate annotation", "@external_table(\"users\")\n  @external_table(\"accounts\")"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource LegacyUser {\n  id: int! @primary\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParseOwner(t *testing.T) {
+	source := "resource Invoice {\n  owner: string!\n\n  @owner(\"payments-team\")\n}"
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	resource := program.Resources[0]
+	if resource.Owner == nil {
+		t.Fatal("Expected @owner to be parsed")
+	}
+	if resource.Owner.Team != "payments-team" {
+		t.Errorf("Team = %q, want %q", resource.Owner.Team, "payments-team")
+	}
+	if resource.Owner.Loc.Line != 4 {
+		t.Errorf("Loc.Line = %d, want 4", resource.Owner.Loc.Line)
+	}
+	if resource.FindField("owner") == nil {
+		t.Error("Expected owner to remain usable as a field name")
+	}
+}
+
+func TestParseOwnerInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing team", "@owner"},
+		{"empty arguments", "@owner()"},
+		{"identifier", "@owner(payments)"},
+		{"duplicate annotation", "@owner(\"payments\")\n  @owner(\"billing\")"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource Invoice {\n  total: float!\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParseStability(t *testing.T) {
+	source := "resource Invoice {\n  stability: string!\n\n  @stability(experimental)\n}"
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	resource := program.Resources[0]
+	if resource.Stability == nil {
+		t.Fatal("Expected @stability to be parsed")
+	}
+	if resource.Stability.Level != ast.StabilityExperimental {
+		t.Errorf("Level = %q, want %q", resource.Stability.Level, ast.StabilityExperimental)
+	}
+	if resource.Stability.Loc.Line != 4 {
+		t.Errorf("Loc.Line = %d, want 4", resource.Stability.Loc.Line)
+	}
+	if resource.FindField("stability") == nil {
+		t.Error("Expected stability to remain usable as a field name")
+	}
+}
+
+func TestParseStabilityInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing level", "@stability"},
+		{"empty arguments", "@stability()"},
+		{"unknown level", "@stability(beta)"},
+		{"string", "@stability(\"stable\")"},
+		{"duplicate annotation", "@stability(stable)\n  @stability(deprecated)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource Invoice {\n  total: float!\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParseMeta(t *testing.T) {
+	source := `resource Customer {
+  email: string! @unique @meta(classification: "pii")
+  meta: string?
+
+  @meta(cost_center: "cc-42", classification: "internal")
+  @meta(team_channel: "#billing")
+}`
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	resource := program.Resources[0]
+	if resource.Meta == nil {
+		t.Fatal("Expected resource @meta to be parsed")
+	}
+	want := map[string]string{"cost_center": "cc-42", "classification": "internal", "team_channel": "#billing"}
+	if !reflect.DeepEqual(resource.Meta.Values, want) {
+		t.Errorf("resource Meta = %v, want %v", resource.Meta.Values, want)
+	}
+	if resource.Meta.Loc.Line != 5 {
+		t.Errorf("Loc.Line = %d, want 5", resource.Meta.Loc.Line)
+	}
+
+	email := resource.FindField("email")
+	if email == nil || email.Meta == nil {
+		t.Fatal("Expected field @meta to be parsed")
+	}
+	if !reflect.DeepEqual(email.Meta.Values, map[string]string{"classification": "pii"}) {
+		t.Errorf("email Meta = %v", email.Meta.Values)
+	}
+	if len(email.Constraints) != 1 {
+		t.Errorf("Expected @meta to leave the field constraints alone, got %d", len(email.Constraints))
+	}
+	if field := resource.FindField("meta"); field == nil || field.Meta != nil {
+		t.Error("Expected meta to remain usable as a field name")
+	}
+}
+
+func TestParseMetaInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+	}{
+		{"missing arguments", "@meta"},
+		{"missing value", "@meta(classification)"},
+		{"identifier value", "@meta(classification: pii)"},
+		{"number value", "@meta(tier: 1)"},
+		{"duplicate key", "@meta(tier: \"gold\")\n  @meta(tier: \"silver\")"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "resource Invoice {\n  total: float!\n\n  " + tt.annotation + "\n}"
+			_, errors := parseSource(t, source)
+			if len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", tt.annotation)
+			}
+		})
+	}
+}
+
+func TestParseMiddlewareArguments(t *testing.T) {
+	source := `resource PartnerEvent {
+  id: uuid! @primary @auto
+
+  @middleware [auth, signed_request(PARTNER_SECRET), rate_limit(100/hour), cors("https://a.example", "https://b.example")]
+}`
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	want := []string{"auth", "signed_request(PARTNER_SECRET)", "rate_limit(100/hour)", `cors("https://a.example", "https://b.example")`}
+	got := program.Resources[0].Middleware
+	if len(got) != len(want) {
+		t.Fatalf("Middleware = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Middleware[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if _, errors := parseSource(t, "resource PartnerEvent {\n  @middleware [signed_request(PARTNER_SECRET]\n}"); len(errors) == 0 {
+		t.Error("Expected parse error for unclosed middleware arguments")
+	}
+}
+
+// TestParseMultipleResources tests parsing multiple resources
+func TestParseMultipleResources(t *testing.T) {
+	source := `resource User {
+  username: string!
+}
+
+resource Post {
+  title: string!
+}
+
+resource Comment {
+  body: text!
+}`
+
+	program, errors := parseSource(t, source)
+
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	if len(program.Resources) != 3 {
+		t.Fatalf("Expected 3 resources, got %d", len(program.Resources))
+	}
+
+	expectedNames := []string{"User", "Post", "Comment"}
+	for i, name := range expectedNames {
+		if program.Resources[i].Name != name {
+			t.Errorf("Expected resource %d to be '%s', got '%s'", i, name, program.Resources[i].Name)
+		}
+	}
+}
+
+// TestParseErrorRecovery tests error recovery
+func TestParseErrorRecovery(t *testing.T) {
+	source := `resource User {
+  username string!
+  email: string!
+}`
+
+	program, errors := parseSource(t, source)
+
+	if len(errors) == 0 {
+		t.Fatal("Expected parse errors")
+	}
+
+	// Should still parse the resource
+	if len(program.Resources) != 1 {
+		t.Errorf("Expected 1 resource despite errors, got %d", len(program.Resources))
+	}
+}
+
+// TestParseIfStatement tests parsing if statements
+func TestParseIfStatement(t *testing.T) {
+	source := `resource Post {
+  status: string!
+
+  @before update {
+    if self.status == "published" {
+      self.published_at = Time.now()
+    }
+  }
+}`
+
+	program, errors := parseSource(t, source)
+
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	hook := program.Resources[0].Hooks[0]
+
+	if len(hook.Body) != 1 {
+		t.Fatalf("Expected 1 statement in hook body, got %d", len(hook.Body))
+	}
+
+	ifStmt, ok := hook.Body[0].(*ast.IfStmt)
+	if !ok {
+		t.Fatal("Expected if statement")
+	}
+
+	if ifStmt.Condition == nil {
+		t.Error("Expected if condition")
+	}
+
+	if len(ifStmt.ThenBranch) == 0 {
+		t.Error("Expected non-empty then branch")
+	}
+}
+
+// TestParseIfElseStatement tests parsing if/else statements
+func TestParseIfElseStatement(t *testing.T) {
+	source := `resource Post {
+  status: string!
+
+  @before update {
+    if self.status == "published" {
+      self.published_at = Time.now()
+    } else {
+      self.published_at = null
+    }
+  }
+}`
+
+	program, errors := parseSource(t, source)
+
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	ifStmt := program.Resources[0].Hooks[0].Body[0].(*ast.IfStmt)
+
+	if len(ifStmt.ElseBranch) == 0 {
+		t.Error("Expected non-empty else branch")
+	}
+}
+
+// TestParseLetStatement tests parsing let statements
+func TestParseLetStatement(t *testing.T) {
+	source := `resource Post {
+  title: string!
+
+  @before create {
+    let slug: string! = String.slugify(self.title)
+    self.slug = slug
+  }
+}`
+
+	program, errors := parseSource(t, source)
+
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	hook := program.Resources[0].Hooks[0]
+
+	if len(hook.Body) < 1 {
+		t.Fatal("Expected at least 1 statement in hook body")
+	}
+
+	letStmt, ok := hook.Body[0].(*ast.LetStmt)
+	if !ok {
+		t.Fatal("Expected let statement")
+	}
+
+	if letStmt.Name != "slug" {
+		t.Errorf("Expected variable name 'slug', got '%s'", letStmt.Name)
+	}
+
+	if letStmt.Type == nil {
+		t.Error("Expected variable type")
+	}
+
+	if letStmt.Value == nil {
+		t.Error("Expected variable value")
+	}
+}
+
+// TestParseArrayLiteral tests parsing array literals
+func TestParseArrayLiteral(t *testing.T) {
 	source := `resource Test {
   items: array<int!>!
 
@@ -799,3 +2414,75 @@ func TestParseSelfExpression(t *testing.T) {
 		t.Error("Expected self expression")
 	}
 }
+
+func TestParseShard(t *testing.T) {
+	source := "resource Invoice {\n  tenant_id: uuid!\n\n  @shard(by: tenant_id)\n}"
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	shard := program.Resources[0].Shard
+	if shard == nil {
+		t.Fatal("Expected @shard to be parsed")
+	}
+	if shard.Field != "tenant_id" {
+		t.Errorf("Field = %q, want tenant_id", shard.Field)
+	}
+	if shard.Loc.Line != 4 {
+		t.Errorf("Loc.Line = %d, want 4", shard.Loc.Line)
+	}
+
+	for name, annotation := range map[string]string{
+		"missing options":      "@shard",
+		"missing by":           "@shard(tenant_id)",
+		"unknown option":       "@shard(on: tenant_id)",
+		"extra option":         "@shard(by: tenant_id, replicas: 2)",
+		"duplicate annotation": "@shard(by: tenant_id)\n  @shard(by: tenant_id)",
+	} {
+		t.Run(name, func(t *testing.T) {
+			source := "resource Invoice {\n  tenant_id: uuid!\n\n  " + annotation + "\n}"
+			if _, errors := parseSource(t, source); len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", annotation)
+			}
+		})
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	source := "resource Post {\n  status: enum [\"draft\", \"in-review\"]! @labels(draft: \"Draft\", \"in-review\": \"In review\")\n  title: string!\n}"
+	program, errors := parseSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Parse errors: %v", errors)
+	}
+
+	resource := program.Resources[0]
+	labels := resource.FindField("status").Labels
+	if labels == nil {
+		t.Fatal("Expected @labels to be parsed")
+	}
+	if labels.Values["draft"] != "Draft" || labels.Values["in-review"] != "In review" || len(labels.Values) != 2 {
+		t.Errorf("Values = %v", labels.Values)
+	}
+	if labels.Loc.Line != 2 {
+		t.Errorf("Loc.Line = %d, want 2", labels.Loc.Line)
+	}
+	if resource.FindField("title") == nil {
+		t.Error("Expected the next field to be parsed after @labels")
+	}
+
+	for name, annotation := range map[string]string{
+		"missing values":       "@labels",
+		"missing label":        "@labels(draft)",
+		"unquoted label":       "@labels(draft: Draft)",
+		"duplicate value":      "@labels(draft: \"Draft\", draft: \"Rough\")",
+		"duplicate annotation": "@labels(draft: \"Draft\") @labels(draft: \"Draft\")",
+	} {
+		t.Run(name, func(t *testing.T) {
+			source := "resource Post {\n  status: enum [\"draft\"]! " + annotation + "\n}"
+			if _, errors := parseSource(t, source); len(errors) == 0 {
+				t.Errorf("Expected parse error for %s", annotation)
+			}
+		})
+	}
+}
@@ -96,6 +96,20 @@ var StdlibRegistry = map[string][]FunctionDef{
 			Description: "Checks if a hash contains a specific key",
 		},
 	},
+	"Mail": {
+		{
+			Name:        "send",
+			Signature:   "send(template: string!, to: string!, vars: hash?) -> void",
+			Description: "Sends a mail template to an address; only callable from @async hooks",
+		},
+	},
+	"Notify": {
+		{
+			Name:        "send",
+			Signature:   "send(channel: string!, target: string!, payload: hash!) -> void",
+			Description: "Queues an SMS or push notification on a channel configured in conduit.yaml",
+		},
+	},
 	"UUID": {
 		{
 			Name:        "generate",
@@ -6,7 +6,7 @@ import (
 
 // TestRegistryCompleteness verifies that the registry contains all expected namespaces
 func TestRegistryCompleteness(t *testing.T) {
-	expectedNamespaces := []string{"String", "Time", "Array", "Hash", "UUID"}
+	expectedNamespaces := []string{"String", "Time", "Array", "Hash", "UUID", "Mail", "Notify"}
 
 	for _, namespace := range expectedNamespaces {
 		if _, exists := StdlibRegistry[namespace]; !exists {
@@ -28,6 +28,8 @@ func TestFunctionCounts(t *testing.T) {
 		"Array":  2, // length, contains
 		"Hash":   1, // has_key
 		"UUID":   1, // generate
+		"Mail":   1, // send
+		"Notify": 1, // send
 	}
 
 	for namespace, expectedCount := range expectedCounts {
@@ -45,7 +47,7 @@ func TestFunctionCounts(t *testing.T) {
 
 // TestTotalFunctionCount verifies the total function count
 func TestTotalFunctionCount(t *testing.T) {
-	expectedTotal := 15 // 7 + 4 + 2 + 1 + 1
+	expectedTotal := 17 // 7 + 4 + 2 + 1 + 1 + 1 + 1
 	actualTotal := TotalFunctionCount()
 
 	if actualTotal != expectedTotal {
@@ -58,12 +60,12 @@ func TestGetNamespaces(t *testing.T) {
 	namespaces := GetNamespaces()
 
 	// Check count
-	if len(namespaces) != 5 {
-		t.Errorf("Expected 5 namespaces, got %d", len(namespaces))
+	if len(namespaces) != 7 {
+		t.Errorf("Expected 7 namespaces, got %d", len(namespaces))
 	}
 
 	// Verify sorted order
-	expectedOrder := []string{"Array", "Hash", "String", "Time", "UUID"}
+	expectedOrder := []string{"Array", "Hash", "Mail", "Notify", "String", "Time", "UUID"}
 	for i, expected := range expectedOrder {
 		if i >= len(namespaces) {
 			t.Errorf("Missing namespace at index %d", i)
@@ -122,6 +124,12 @@ func TestFunctionSignatures(t *testing.T) {
 		// Hash functions
 		{"Hash", "has_key", "has_key(h: hash!, key: any!) -> bool!"},
 
+		// Mail functions
+		{"Mail", "send", "send(template: string!, to: string!, vars: hash?) -> void"},
+
+		// Notify functions
+		{"Notify", "send", "send(channel: string!, target: string!, payload: hash!) -> void"},
+
 		// UUID functions
 		{"UUID", "generate", "generate() -> uuid!"},
 	}
@@ -2,6 +2,10 @@ package typechecker
 
 import (
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
 )
@@ -20,72 +24,1449 @@ type TypeChecker struct {
 	// Custom functions defined in resources
 	customFunctions map[string]*Function
 
+	// Whether the statements being checked run in the background (@async)
+	inAsync bool
+
+	// Templates Mail.send may name; nil when the template directory is unknown
+	mailTemplates map[string]bool
+
+	// Channels Notify.send may name; nil when the configured channels are unknown
+	notifyChannels map[string]bool
+
+	// Resource social login signs users in as; empty when login is not configured
+	loginResource string
+
+	// Whether every resource gets created_at and updated_at (timestamps in conduit.yaml)
+	timestamps bool
+
 	// Accumulated errors
 	errors ErrorList
 }
 
-// NewTypeChecker creates a new type checker
-func NewTypeChecker() *TypeChecker {
-	return &TypeChecker{
-		resources:       make(map[string]*ast.ResourceNode),
-		currentScope:    make(map[string]Type),
-		customFunctions: make(map[string]*Function),
-		errors:          make(ErrorList, 0),
+// NewTypeChecker creates a new type checker
+func NewTypeChecker() *TypeChecker {
+	return &TypeChecker{
+		resources:       make(map[string]*ast.ResourceNode),
+		currentScope:    make(map[string]Type),
+		customFunctions: make(map[string]*Function),
+		errors:          make(ErrorList, 0),
+	}
+}
+
+// SetMailTemplates sets the templates of the project's mail directory, so
+// Mail.send calls naming any other template are reported. Without it, template
+// names are not checked.
+func (tc *TypeChecker) SetMailTemplates(names []string) {
+	tc.mailTemplates = make(map[string]bool, len(names))
+	for _, name := range names {
+		tc.mailTemplates[name] = true
+	}
+}
+
+// SetNotifyChannels sets the notification channels configured in
+// conduit.yaml, so Notify.send calls naming any other channel are reported.
+// Without it, channel names are not checked.
+func (tc *TypeChecker) SetNotifyChannels(names []string) {
+	tc.notifyChannels = make(map[string]bool, len(names))
+	for _, name := range names {
+		tc.notifyChannels[name] = true
+	}
+}
+
+// SetLoginResource sets the resource configured as auth.resource in
+// conduit.yaml, so a resource social login cannot match or create users in is
+// reported.
+func (tc *TypeChecker) SetLoginResource(name string) {
+	tc.loginResource = name
+}
+
+// SetTimestamps sets whether conduit.yaml adds created_at and updated_at to
+// every resource, so declared timestamps that differ from the added ones are
+// reported for every resource rather than only those with @timestamps.
+func (tc *TypeChecker) SetTimestamps(enabled bool) {
+	tc.timestamps = enabled
+}
+
+// CheckProgram is the main entry point for type checking
+// It type-checks all resources in the program and returns any errors found
+func (tc *TypeChecker) CheckProgram(prog *ast.Program) ErrorList {
+	// First pass: Register all resources
+	for _, resource := range prog.Resources {
+		tc.resources[resource.Name] = resource
+	}
+
+	// Second pass: Type check each resource
+	for _, resource := range prog.Resources {
+		tc.checkResource(resource)
+	}
+
+	if tc.loginResource != "" {
+		tc.checkLoginResource()
+	}
+
+	return tc.errors
+}
+
+// checkResource type-checks a single resource
+func (tc *TypeChecker) checkResource(resource *ast.ResourceNode) {
+	tc.currentResource = resource
+
+	// Check all fields
+	for _, field := range resource.Fields {
+		tc.checkField(field)
+	}
+
+	// Check all hooks
+	for _, hook := range resource.Hooks {
+		tc.checkHook(hook)
+	}
+
+	// Check all validations
+	for _, validation := range resource.Validations {
+		tc.checkValidation(validation)
+	}
+
+	// Check all constraints
+	for _, constraint := range resource.Constraints {
+		tc.checkConstraint(constraint)
+	}
+
+	// Check computed fields
+	for _, computed := range resource.Computed {
+		tc.checkComputed(computed)
+	}
+
+	// Check relationships
+	for _, relationship := range resource.Relationships {
+		tc.checkRelationship(relationship)
+	}
+
+	// Check the fields a change feed depends on
+	if resource.Changes != nil {
+		tc.checkChanges(resource)
+	}
+
+	// Check the version field and merge fields of a conflict policy
+	if resource.Conflict != nil {
+		tc.checkConflict(resource)
+	}
+
+	// Check the partition key of a partitioned table
+	if resource.Partition != nil {
+		tc.checkPartition(resource)
+	}
+
+	// Check the query and read-only constraints of a materialized view
+	if resource.Materialized != nil {
+		tc.checkMaterialized(resource)
+	}
+
+	// Check the table and read-only constraints of an external table
+	if resource.External != nil {
+		tc.checkExternalTable(resource)
+	}
+
+	// Check the parents that counter caches are kept on
+	for _, counter := range resource.CounterCaches {
+		tc.checkCounterCache(resource, counter)
+	}
+
+	// Check the fields copied into search documents
+	if resource.SearchIndex != nil {
+		tc.checkSearchIndex(resource)
+	}
+
+	// Check the columns of database indexes
+	if len(resource.Indexes) > 0 {
+		tc.checkIndexes(resource)
+	}
+
+	// Check the provider and event fields of a webhook receiver
+	if resource.Webhook != nil {
+		tc.checkWebhook(resource)
+	}
+
+	// Check the secret reference of signed request verification
+	tc.checkSignedRequest(resource)
+
+	// Check the operation refinements and exclusions of @operations
+	if len(resource.OperationMods) > 0 || len(resource.Excluded) > 0 {
+		tc.checkOperations(resource)
+	}
+
+	// Check the fields each serialization profile shows
+	if len(resource.Profiles) > 0 {
+		tc.checkProfiles(resource)
+	}
+
+	// Check the conflict target of the upsert route
+	if resource.Upsert != nil && !resource.ReadOnly() {
+		tc.checkUpsert(resource)
+	}
+
+	// Check the field archiving records in
+	if resource.Archivable != nil {
+		tc.checkArchivable(resource)
+	}
+
+	// Check the field soft deletes are recorded in
+	if resource.SoftDelete != nil {
+		tc.checkSoftDelete(resource)
+	}
+
+	// Check the condition implicitly filtering list and show queries
+	if resource.DefaultScope != nil {
+		tc.checkDefaultScope(resource)
+	}
+
+	// Check the scope and position of ordered records
+	if resource.Orderable != nil {
+		tc.checkOrderable(resource)
+	}
+
+	// Check the parent relationship of a tree
+	if resource.Tree != nil {
+		tc.checkTree(resource)
+	}
+
+	// Check the fields identifying records
+	tc.checkPrimaryKey(resource)
+
+	// Check the PostgreSQL schema holding the table
+	if resource.Schema != nil {
+		tc.checkSchema(resource)
+	}
+
+	// Check the team named as owner
+	if resource.Owner != nil {
+		tc.checkOwner(resource)
+	}
+
+	// Check the shard key and the annotations a sharded resource cannot use
+	if resource.Shard != nil {
+		tc.checkShard(resource)
+	}
+
+	// Check the id field an ID strategy generates
+	if resource.IDStrategy != nil {
+		tc.checkIDStrategy(resource)
+	}
+
+	// Check declared timestamps match the ones that would be added
+	if resource.TimestampsEnabled(tc.timestamps) {
+		tc.checkTimestamps(resource)
+	}
+
+	// Reset current resource
+	tc.currentResource = nil
+}
+
+// checkChanges verifies that a @changes resource declares the fields its feed
+// is read from: an @auto_update timestamp that orders the feed, and a nullable
+// deleted_at timestamp that records soft deletes
+func (tc *TypeChecker) checkChanges(resource *ast.ResourceNode) {
+	hasModified := false
+	for _, field := range resource.Fields {
+		if isTimestampField(field) && !field.Nullable && hasFieldConstraint(field, "auto_update") {
+			hasModified = true
+			break
+		}
+	}
+	if !hasModified {
+		tc.errors = append(tc.errors, NewMissingAnnotationField(
+			resource.Changes.Loc,
+			"changes",
+			"a required @auto_update timestamp field to order the change feed",
+			"updated_at: timestamp! @auto_update",
+		))
+	}
+
+	var deleted *ast.FieldNode
+	for _, field := range resource.Fields {
+		if field.Name == ast.SoftDeleteField {
+			deleted = field
+			break
+		}
+	}
+	// @soft_delete adds deleted_at when it is not declared
+	if deleted == nil && resource.SoftDelete != nil {
+		return
+	}
+	if deleted == nil || !isTimestampField(deleted) || !deleted.Nullable {
+		tc.errors = append(tc.errors, NewMissingAnnotationField(
+			resource.Changes.Loc,
+			"changes",
+			"a nullable "+ast.SoftDeleteField+" timestamp field to record deletes",
+			ast.SoftDeleteField+": timestamp?",
+		))
+	}
+}
+
+// checkArchivable verifies that an @archivable resource declares the nullable
+// archived_at timestamp that records when a record was archived
+func (tc *TypeChecker) checkArchivable(resource *ast.ResourceNode) {
+	archived := resource.FindField(ast.ArchivedField)
+	if archived == nil || !isTimestampField(archived) || !archived.Nullable {
+		tc.errors = append(tc.errors, NewMissingAnnotationField(
+			resource.Archivable.Loc,
+			"archivable",
+			"a nullable "+ast.ArchivedField+" timestamp field to record archiving",
+			ast.ArchivedField+": timestamp?",
+		))
+	}
+}
+
+// checkSoftDelete verifies that a @soft_delete resource either leaves out
+// deleted_at, which is then added, or declares it as the nullable timestamp
+// deletes set
+func (tc *TypeChecker) checkSoftDelete(resource *ast.ResourceNode) {
+	deleted := resource.FindField(ast.SoftDeleteField)
+	declared := resource.FindRelationship(ast.SoftDeleteField) != nil
+	for _, computed := range resource.Computed {
+		declared = declared || computed.Name == ast.SoftDeleteField
+	}
+	if declared || (deleted != nil && (!isTimestampField(deleted) || !deleted.Nullable)) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_soft_delete",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("@soft_delete records deletes in %s, which must be a nullable timestamp field", ast.SoftDeleteField),
+			Location:   resource.SoftDelete.Loc,
+			Suggestion: fmt.Sprintf("Declare %s as timestamp?, or leave it out to have it added", ast.SoftDeleteField),
+			Examples:   []string{ast.SoftDeleteField + ": timestamp?"},
+		})
+	}
+}
+
+// checkDefaultScope verifies that the condition of a @default_scope is a bool
+// expression the generated queries can filter by in SQL
+func (tc *TypeChecker) checkDefaultScope(resource *ast.ResourceNode) {
+	scope := resource.DefaultScope
+
+	oldScope := tc.currentScope
+	tc.currentScope = map[string]Type{"self": NewResourceType(resource.Name, false)}
+	condType, err := tc.inferExpr(scope.Condition)
+	tc.currentScope = oldScope
+	if err != nil {
+		return
+	}
+
+	expectedBool := NewPrimitiveType("bool", false)
+	if !expectedBool.IsAssignableFrom(condType) {
+		tc.errors = append(tc.errors, NewTypeMismatch(
+			scope.Loc,
+			expectedBool,
+			condType,
+			"default scope condition",
+		))
+		return
+	}
+
+	if _, err := resource.DefaultScopeSQL(""); err != nil {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_default_scope",
+			Severity:   SeverityError,
+			Message:    err.Error(),
+			Location:   scope.Loc,
+			Suggestion: "Compare fields of self with literals, nil or Time.now(), joined with and, or and not",
+			Examples: []string{
+				"@default_scope { self.deleted_at == nil }",
+				"@default_scope { self.published and self.status != \"draft\" }",
+			},
+		})
+	}
+}
+
+// checkOrderable verifies that the scope of an @orderable resource is a
+// required field, so every record belongs to exactly one order, and that a
+// declared position field is an int! the generated code can maintain.
+// Upserts insert records without placing them, so @upsert is rejected.
+func (tc *TypeChecker) checkOrderable(resource *ast.ResourceNode) {
+	orderable := resource.Orderable
+
+	if orderable.Scope != "" {
+		scope := resource.FindField(orderable.Scope)
+		switch {
+		case scope == nil:
+			tc.errors = append(tc.errors, NewUndefinedField(orderable.Loc, orderable.Scope, resource.Name))
+		case scope.Nullable || scope.Name == ast.PositionField:
+			tc.errors = append(tc.errors, &TypeError{
+				Code:       ErrInvalidConstraintType,
+				Type:       "invalid_orderable",
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("@orderable scope %s must be a required field other than %s", orderable.Scope, ast.PositionField),
+				Location:   orderable.Loc,
+				Suggestion: "Scope the order by a required field such as a foreign key",
+				Examples:   []string{"list_id: uuid!", "@orderable(scope: list_id)"},
+			})
+		}
+	}
+
+	position := resource.FindField(ast.PositionField)
+	declared := resource.FindRelationship(ast.PositionField) != nil
+	for _, computed := range resource.Computed {
+		declared = declared || computed.Name == ast.PositionField
+	}
+	if declared || (position != nil && (position.Nullable || position.Type == nil ||
+		position.Type.Kind != ast.TypePrimitive || position.Type.Name != "int")) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_orderable",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("@orderable keeps positions in %s, which must be an int! field", ast.PositionField),
+			Location:   orderable.Loc,
+			Suggestion: fmt.Sprintf("Declare %s as int!, or leave it out to have it added", ast.PositionField),
+			Examples:   []string{ast.PositionField + ": int!"},
+		})
+	}
+
+	if resource.Upsert != nil {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:     ErrInvalidConstraintType,
+			Type:     "invalid_orderable",
+			Severity: SeverityError,
+			Message:  "@orderable cannot be combined with @upsert",
+			Location: resource.Upsert.Loc,
+		})
+	}
+}
+
+// checkTree verifies that a @tree resource links each record to its parent
+// through a belongs_to relationship to itself, named by parent when there is
+// more than one, whose foreign key is a declared nullable field: roots have
+// no parent.
+func (tc *TypeChecker) checkTree(resource *ast.ResourceNode) {
+	tree := resource.Tree
+
+	rel := resource.TreeParent()
+	if rel == nil {
+		message := fmt.Sprintf("@tree requires a single belongs_to relationship from %s to itself", resource.Name)
+		if tree.Parent != "" {
+			message = fmt.Sprintf("@tree parent %s is not a belongs_to relationship from %s to itself", tree.Parent, resource.Name)
+		}
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_tree",
+			Severity:   SeverityError,
+			Message:    message,
+			Location:   tree.Loc,
+			Suggestion: "Declare the parent relationship, and name it with @tree(parent: ...) when there are several",
+			Examples:   []string{fmt.Sprintf("parent: %s? { foreign_key: \"parent_id\" }", resource.Name)},
+		})
+		return
+	}
+
+	if field := resource.FindField(rel.ForeignKeyColumn()); field == nil || !field.Nullable {
+		tc.errors = append(tc.errors, NewMissingAnnotationField(
+			tree.Loc,
+			"tree",
+			fmt.Sprintf("a nullable %s field holding the ID of the parent, null for roots", rel.ForeignKeyColumn()),
+			rel.ForeignKeyColumn()+": uuid?",
+		))
+	}
+}
+
+// checkIDStrategy verifies that a resource declaring @id has a required
+// @primary @auto id field of the type its strategy generates
+func (tc *TypeChecker) checkIDStrategy(resource *ast.ResourceNode) {
+	strategy := resource.IDStrategy
+	fieldType := strategy.FieldType()
+	example := fmt.Sprintf("id: %s! @primary @auto", fieldType)
+
+	id := resource.FindField("id")
+	if id == nil {
+		tc.errors = append(tc.errors, NewMissingAnnotationField(strategy.Loc, "id",
+			fmt.Sprintf("an id field of type %s to generate", fieldType), example))
+		return
+	}
+
+	if id.Nullable || id.Type == nil || id.Type.Kind != ast.TypePrimitive || id.Type.Name != fieldType ||
+		!hasFieldConstraint(id, "primary") || !hasFieldConstraint(id, "auto") {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_id_strategy",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("@id(strategy: %s) generates %s IDs, so id must be a required %s field declared @primary @auto", strategy.Strategy, fieldType, fieldType),
+			Location:   strategy.Loc,
+			Suggestion: fmt.Sprintf("Declare id as %s! @primary @auto", fieldType),
+			Examples:   []string{example},
+		})
+	}
+}
+
+// checkPrimaryKey verifies the primary key of a resource: at most one
+// @primary field or a composite @primary(a, b) of distinct fields, each a
+// required scalar handlers can read from a route. Composite keys are rejected
+// on features that identify records by a single column.
+func (tc *TypeChecker) checkPrimaryKey(resource *ast.ResourceNode) {
+	var primary []*ast.FieldNode
+	for _, field := range resource.Fields {
+		if hasFieldConstraint(field, "primary") {
+			primary = append(primary, field)
+		}
+	}
+
+	if resource.PrimaryKey != nil {
+		key := resource.PrimaryKey
+		if len(primary) > 0 {
+			tc.errors = append(tc.errors, primaryKeyError(key.Loc,
+				fmt.Sprintf("%s declares both @primary(%s) and a @primary field %s", resource.Name, strings.Join(key.Fields, ", "), primary[0].Name),
+				"Remove @primary from the field, or the composite key", ""))
+			return
+		}
+		if len(key.Fields) < 2 {
+			example := "code: string! @primary"
+			if len(key.Fields) == 1 {
+				example = key.Fields[0] + ": string! @primary"
+			}
+			tc.errors = append(tc.errors, primaryKeyError(key.Loc,
+				"@primary(...) declares a composite key of two or more fields",
+				"Declare a single-field key with @primary on the field", example))
+			return
+		}
+		seen := map[string]bool{}
+		for _, name := range key.Fields {
+			if seen[name] {
+				tc.errors = append(tc.errors, primaryKeyError(key.Loc,
+					fmt.Sprintf("@primary(%s) names %s more than once", strings.Join(key.Fields, ", "), name),
+					"List each field of the key once", ""))
+				return
+			}
+			seen[name] = true
+			if resource.FindField(name) == nil {
+				tc.errors = append(tc.errors, NewUndefinedField(key.Loc, name, resource.Name))
+				return
+			}
+		}
+	} else if len(primary) > 1 {
+		tc.errors = append(tc.errors, primaryKeyError(primary[1].Loc,
+			fmt.Sprintf("%s declares more than one @primary field: %s and %s", resource.Name, primary[0].Name, primary[1].Name),
+			"Declare a composite key on the resource instead",
+			fmt.Sprintf("@primary(%s, %s)", primary[0].Name, primary[1].Name)))
+		return
+	}
+
+	key := resource.KeyFields()
+	if len(key) == 0 {
+		return
+	}
+	for _, field := range key {
+		if field.Nullable || field.Type == nil || field.Type.Kind != ast.TypePrimitive || !primaryKeyTypes[field.Type.Name] {
+			tc.errors = append(tc.errors, primaryKeyError(field.Loc,
+				fmt.Sprintf("primary key field %s must be a required string, text, int, uuid or ulid", field.Name),
+				"Declare the field as a required scalar", field.Name+": string! @primary"))
+		}
+	}
+
+	if !resource.HasNaturalKey() {
+		return
+	}
+	if id := resource.FindField("id"); id != nil {
+		inKey := false
+		for _, field := range key {
+			if field == id {
+				inKey = true
+			}
+		}
+		if !inKey {
+			tc.errors = append(tc.errors, primaryKeyError(id.Loc,
+				fmt.Sprintf("%s is identified by its natural key, so it cannot also declare an id field", resource.Name),
+				"Remove the id field, or rename it", ""))
+		}
+	}
+	if resource.Materialized != nil {
+		tc.errors = append(tc.errors, primaryKeyError(resource.Materialized.Loc,
+			fmt.Sprintf("@materialized resource %s is identified by its id column, so it cannot declare a natural key", resource.Name),
+			"Select an id column in the query", ""))
+	}
+	if len(key) < 2 {
+		return
+	}
+
+	var features []string
+	if resource.Tree != nil {
+		features = append(features, "@tree")
+	}
+	if resource.SearchIndex != nil {
+		features = append(features, "@search_index")
+	}
+	if resource.Orderable != nil {
+		features = append(features, "@orderable")
+	}
+	if resource.IDStrategy != nil {
+		features = append(features, "@id")
+	}
+	if resource.Changes != nil {
+		features = append(features, "@changes")
+	}
+	if resource.Name == tc.loginResource {
+		features = append(features, "auth.resource")
+	}
+	for _, rel := range resource.Relationships {
+		if rel.Kind == ast.RelationshipHasManyThrough {
+			features = append(features, "has_many_through relationship "+rel.Name)
+		}
+	}
+	for _, other := range tc.resources {
+		for _, rel := range other.Relationships {
+			if rel.Kind == ast.RelationshipHasManyThrough && rel.Type == resource.Name {
+				features = append(features, fmt.Sprintf("%s.%s through %s", other.Name, rel.Name, rel.Through))
+			}
+		}
+	}
+	for _, feature := range features {
+		tc.errors = append(tc.errors, primaryKeyError(resource.PrimaryKey.Loc,
+			fmt.Sprintf("%s has a composite key, which %s does not support", resource.Name, feature),
+			"Use a single-field key", ""))
+	}
+}
+
+// primaryKeyTypes are the field types a primary key may have
+var primaryKeyTypes = map[string]bool{
+	"string": true,
+	"text":   true,
+	"int":    true,
+	"uuid":   true,
+	"ulid":   true,
+}
+
+// primaryKeyError returns an invalid_primary_key error
+func primaryKeyError(loc ast.SourceLocation, message, suggestion, example string) *TypeError {
+	err := &TypeError{
+		Code:       ErrInvalidConstraintType,
+		Type:       "invalid_primary_key",
+		Severity:   SeverityError,
+		Message:    message,
+		Location:   loc,
+		Suggestion: suggestion,
+	}
+	if example != "" {
+		err.Examples = []string{example}
+	}
+	return err
+}
+
+// checkSchema verifies that @schema names a PostgreSQL schema generated SQL
+// can use unquoted: lowercase letters, digits and underscores, not starting
+// with a digit, and not one of the schemas PostgreSQL reserves
+func (tc *TypeChecker) checkSchema(resource *ast.ResourceNode) {
+	name := resource.Schema.Name
+	valid := name != "" && len(name) <= 63 && !(name[0] >= '0' && name[0] <= '9')
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_') {
+			valid = false
+		}
+	}
+
+	message := ""
+	switch {
+	case !valid:
+		message = fmt.Sprintf("@schema(%q) must be a lowercase identifier of letters, digits and underscores", name)
+	case strings.HasPrefix(name, "pg_") || name == "information_schema":
+		message = fmt.Sprintf("@schema(%q) is reserved by PostgreSQL", name)
+	default:
+		return
+	}
+	tc.errors = append(tc.errors, &TypeError{
+		Code:       ErrInvalidConstraintType,
+		Type:       "invalid_schema",
+		Severity:   SeverityError,
+		Message:    message,
+		Location:   resource.Schema.Loc,
+		Suggestion: "Name the schema with a lowercase identifier",
+		Examples:   []string{`@schema("billing")`},
+	})
+}
+
+// checkOwner verifies that @owner names a team with letters, digits, dashes,
+// underscores and dots only, so it can be used as an OpenAPI tag and an
+// alert label as it is
+func (tc *TypeChecker) checkOwner(resource *ast.ResourceNode) {
+	team := resource.Owner.Team
+	valid := team != ""
+	for _, r := range team {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			valid = false
+		}
+	}
+	if valid {
+		return
+	}
+	tc.errors = append(tc.errors, &TypeError{
+		Code:       ErrInvalidConstraintType,
+		Type:       "invalid_owner",
+		Severity:   SeverityError,
+		Message:    fmt.Sprintf("@owner(%q) must name a team with letters, digits, dashes, underscores and dots", team),
+		Location:   resource.Owner.Loc,
+		Suggestion: "Name the owning team without spaces or an @ prefix",
+		Examples:   []string{`@owner("payments-team")`},
+	})
+}
+
+// checkTimestamps verifies that a resource with timestamps enabled declares
+// created_at and updated_at, if at all, as the fields ast.WithTimestamps would
+// add. Sync, caching and conflict detection rely on both being maintained.
+func (tc *TypeChecker) checkTimestamps(resource *ast.ResourceNode) {
+	for _, name := range []string{ast.CreatedAtField, ast.UpdatedAtField} {
+		field := resource.FindField(name)
+		if field == nil {
+			continue
+		}
+
+		constraint := "auto"
+		if name == ast.UpdatedAtField {
+			constraint = "auto_update"
+		}
+		if field.Nullable || !isTimestampField(field) || !hasFieldConstraint(field, constraint) {
+			example := fmt.Sprintf("%s: timestamp! @%s", name, constraint)
+			tc.errors = append(tc.errors, &TypeError{
+				Code:       ErrInvalidConstraintType,
+				Type:       "invalid_timestamps",
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("Resource %s has timestamps enabled, so %s must be a required timestamp field declared @%s", resource.Name, name, constraint),
+				Location:   field.Loc,
+				Suggestion: fmt.Sprintf("Declare %s, remove it to have it added, or opt out with @timestamps(false)", example),
+				Examples:   []string{example},
+			})
+		}
+	}
+}
+
+// checkConflict verifies that a @conflict resource can detect stale updates.
+// last_write_wins needs nothing; reject and merge compare the update's
+// precondition against the @auto_update timestamp, and merge fields must exist.
+func (tc *TypeChecker) checkConflict(resource *ast.ResourceNode) {
+	conflict := resource.Conflict
+	if conflict.Strategy == ast.ConflictLastWriteWins {
+		return
+	}
+
+	hasVersion := false
+	for _, field := range resource.Fields {
+		if isTimestampField(field) && !field.Nullable && hasFieldConstraint(field, "auto_update") {
+			hasVersion = true
+			break
+		}
+	}
+	if !hasVersion {
+		tc.errors = append(tc.errors, NewMissingAnnotationField(
+			conflict.Loc,
+			"conflict",
+			"a required @auto_update timestamp field to detect stale updates",
+			"updated_at: timestamp! @auto_update",
+		))
+	}
+
+	declared := make(map[string]bool, len(resource.Fields))
+	for _, field := range resource.Fields {
+		declared[field.Name] = true
+	}
+	for _, name := range conflict.MergeFields {
+		if !declared[name] {
+			tc.errors = append(tc.errors, NewUndefinedField(conflict.Loc, name, resource.Name))
+		}
+	}
+}
+
+// checkPartition verifies that a @partition resource is partitioned by a
+// required timestamp, so every row falls into exactly one range partition.
+// PostgreSQL requires unique constraints on a partitioned table to include
+// the partition key, so @unique fields are rejected.
+func (tc *TypeChecker) checkPartition(resource *ast.ResourceNode) {
+	partition := resource.Partition
+
+	var key *ast.FieldNode
+	for _, field := range resource.Fields {
+		if field.Name == partition.Field {
+			key = field
+			break
+		}
+	}
+	if key == nil {
+		tc.errors = append(tc.errors, NewUndefinedField(partition.Loc, partition.Field, resource.Name))
+	} else if !isTimestampField(key) || key.Nullable {
+		tc.errors = append(tc.errors, NewMissingAnnotationField(
+			partition.Loc,
+			"partition",
+			"by: to name a required timestamp field",
+			"created_at: timestamp! @auto",
+		))
+	}
+
+	for _, field := range resource.Fields {
+		if !hasFieldConstraint(field, "unique") {
+			continue
+		}
+		fieldType, err := TypeFromASTNode(field.Type, field.Nullable)
+		if err != nil {
+			continue
+		}
+		tc.errors = append(tc.errors, NewInvalidConstraintType(
+			field.Loc,
+			"unique",
+			fieldType,
+			"unique constraints on a @partition table must include the partition key",
+		))
+	}
+}
+
+// checkShard verifies that a @shard resource is sharded by a required string,
+// int or uuid field, so every record and every request names one shard.
+// Partition maintenance and view refreshes only run on the default database,
+// and webhook and external table requests carry no shard key, so those
+// annotations are rejected.
+func (tc *TypeChecker) checkShard(resource *ast.ResourceNode) {
+	shard := resource.Shard
+
+	key := resource.FindField(shard.Field)
+	if key == nil {
+		tc.errors = append(tc.errors, NewUndefinedField(shard.Loc, shard.Field, resource.Name))
+	} else if key.Nullable || key.Type == nil || key.Type.Kind != ast.TypePrimitive ||
+		(key.Type.Name != "string" && key.Type.Name != "int" && key.Type.Name != "uuid") {
+		tc.errors = append(tc.errors, NewMissingAnnotationField(
+			shard.Loc,
+			"shard",
+			"by: to name a required string, int or uuid field",
+			"tenant_id: uuid!",
+		))
+	}
+
+	incompatible := func(loc ast.SourceLocation, what string) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:     ErrInvalidConstraintType,
+			Type:     "invalid_shard",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("@shard cannot be combined with %s", what),
+			Location: loc,
+		})
+	}
+	if resource.Partition != nil {
+		incompatible(resource.Partition.Loc, "@partition")
+	}
+	if resource.Materialized != nil {
+		incompatible(resource.Materialized.Loc, "@materialized")
+	}
+	if resource.Webhook != nil {
+		incompatible(resource.Webhook.Loc, "@webhook")
+	}
+	if resource.External != nil {
+		incompatible(resource.External.Loc, "@external_table")
+	}
+}
+
+// shardDescription describes where the records of a resource live, for
+// cross-shard relationship errors
+func shardDescription(resource *ast.ResourceNode) string {
+	if resource.Shard == nil {
+		return resource.Name + " is not sharded"
+	}
+	return fmt.Sprintf("%s is sharded by %s", resource.Name, resource.Shard.Field)
+}
+
+// checkMaterialized verifies that a @materialized resource is defined by a
+// single SELECT statement, exposes an id for show routes and the unique index
+// that concurrent refreshes need, and declares nothing that writes to it.
+func (tc *TypeChecker) checkMaterialized(resource *ast.ResourceNode) {
+	materialized := resource.Materialized
+
+	var keyword string
+	if words := strings.Fields(materialized.Query); len(words) > 0 {
+		keyword = strings.ToUpper(words[0])
+	}
+	if (keyword != "SELECT" && keyword != "WITH") || strings.Contains(materialized.Query, ";") {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_materialized_query",
+			Severity:   SeverityError,
+			Message:    "@materialized query must be a single SELECT statement",
+			Location:   materialized.Loc,
+			Suggestion: "Define the view with SELECT or WITH ... SELECT",
+			Examples:   []string{`query: "SELECT posts.id, count(*) AS comment_count FROM posts GROUP BY posts.id"`},
+		})
+	}
+
+	hasID := false
+	for _, field := range resource.Fields {
+		if field.Name == "id" {
+			hasID = true
+			break
+		}
+	}
+	if !hasID {
+		tc.errors = append(tc.errors, NewMissingAnnotationField(
+			materialized.Loc,
+			"materialized",
+			"an id field selected by the query",
+			"id: uuid!",
+		))
+	}
+
+	tc.checkReadOnly(resource, "@materialized")
+}
+
+// checkExternalTable verifies that an @external_table resource names a table
+// generated SQL can use unquoted, optionally schema-qualified, has a key for
+// show routes, and declares nothing that writes to it or migrates it.
+func (tc *TypeChecker) checkExternalTable(resource *ast.ResourceNode) {
+	external := resource.External
+
+	valid := external.Table != ""
+	for _, part := range strings.SplitN(external.Table, ".", 2) {
+		if part == "" || len(part) > 63 || (part[0] >= '0' && part[0] <= '9') {
+			valid = false
+		}
+		for _, r := range part {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_') {
+				valid = false
+			}
+		}
+	}
+	if !valid {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_external_table",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("@external_table(%q) must be a lowercase table name, optionally qualified with its schema", external.Table),
+			Location:   external.Loc,
+			Suggestion: "Name the table as PostgreSQL stores it unquoted",
+			Examples:   []string{`@external_table("legacy.users")`},
+		})
+	}
+
+	if len(resource.KeyFields()) == 0 {
+		tc.errors = append(tc.errors, NewMissingAnnotationField(
+			external.Loc,
+			"external_table",
+			"an id or @primary field identifying rows of the table",
+			"id: int! @primary",
+		))
+	}
+
+	conflicting := func(loc ast.SourceLocation, annotation, reason string) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:     ErrInvalidConstraintType,
+			Type:     "invalid_external_table",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("@external_table resources cannot declare %s: %s", annotation, reason),
+			Location: loc,
+		})
+	}
+	if resource.Materialized != nil {
+		conflicting(resource.Materialized.Loc, "@materialized", "the view already exists")
+	}
+	if resource.Schema != nil {
+		conflicting(resource.Schema.Loc, "@schema", "qualify the table name instead")
+	}
+	for _, index := range resource.AllIndexes() {
+		conflicting(index.Loc, "indexes", "the table is not migrated by Conduit")
+	}
+
+	// checkMaterialized has reported what writes to the resource already
+	if resource.Materialized == nil {
+		tc.checkReadOnly(resource, "@external_table")
+	}
+}
+
+// checkReadOnly reports everything a read-only resource declares that writes
+// to it; annotation names what makes it read-only
+func (tc *TypeChecker) checkReadOnly(resource *ast.ResourceNode, annotation string) {
+	readOnly := func(loc ast.SourceLocation, what string) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:     ErrInvalidConstraintType,
+			Type:     "read_only_resource",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s resources are read-only and cannot declare %s", annotation, what),
+			Location: loc,
+		})
+	}
+	for _, hook := range resource.Hooks {
+		readOnly(hook.Loc, "lifecycle hooks")
+	}
+	if resource.Changes != nil {
+		readOnly(resource.Changes.Loc, "@changes")
+	}
+	if resource.Conflict != nil {
+		readOnly(resource.Conflict.Loc, "@conflict")
+	}
+	if resource.Partition != nil {
+		readOnly(resource.Partition.Loc, "@partition")
+	}
+	for _, counter := range resource.CounterCaches {
+		readOnly(counter.Loc, "@counter_cache")
+	}
+	if resource.SearchIndex != nil {
+		readOnly(resource.SearchIndex.Loc, "@search_index")
+	}
+	if resource.Webhook != nil {
+		readOnly(resource.Webhook.Loc, "@webhook")
+	}
+	if resource.Upsert != nil {
+		readOnly(resource.Upsert.Loc, "@upsert")
+	}
+	if resource.Archivable != nil {
+		readOnly(resource.Archivable.Loc, "@archivable")
+	}
+	if resource.SoftDelete != nil {
+		readOnly(resource.SoftDelete.Loc, "@soft_delete")
+	}
+	if resource.Orderable != nil {
+		readOnly(resource.Orderable.Loc, "@orderable")
+	}
+	if resource.IDStrategy != nil {
+		readOnly(resource.IDStrategy.Loc, "@id")
+	}
+	if resource.Timestamps != nil {
+		readOnly(resource.Timestamps.Loc, "@timestamps")
+	}
+}
+
+// checkWebhook verifies that a @webhook resource names a supported provider
+// and declares the fields each event is recorded in. event_id must be
+// @unique: it is what makes a redelivered event a no-op.
+func (tc *TypeChecker) checkWebhook(resource *ast.ResourceNode) {
+	webhook := resource.Webhook
+
+	if webhook.Provider != ast.WebhookStripe {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_webhook",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("Unknown webhook provider: %s", webhook.Provider),
+			Location:   webhook.Loc,
+			Suggestion: "Use a supported provider",
+			Examples:   []string{"@webhook(stripe)"},
+		})
+	}
+
+	required := []struct {
+		name        string
+		typ         string
+		unique      bool
+		requirement string
+		example     string
+	}{
+		{ast.WebhookEventIDField, "string", true, "a required @unique event_id string field to deduplicate deliveries", "event_id: string! @unique"},
+		{ast.WebhookEventTypeField, "string", false, "a required event_type string field", "event_type: string!"},
+		{ast.WebhookPayloadField, "json", false, "a required payload json field to store the event", "payload: json!"},
+	}
+	for _, want := range required {
+		field := resource.FindField(want.name)
+		if field == nil || field.Nullable || field.Type.Kind != ast.TypePrimitive ||
+			field.Type.Name != want.typ || (want.unique && !hasFieldConstraint(field, "unique")) {
+			tc.errors = append(tc.errors, NewMissingAnnotationField(webhook.Loc, "webhook", want.requirement, want.example))
+		}
+	}
+}
+
+// checkSignedRequest verifies that a signed_request middleware names the
+// environment variable its shared secret is read from. Secrets are never
+// written into the source.
+func (tc *TypeChecker) checkSignedRequest(resource *ast.ResourceNode) {
+	for _, mw := range resource.Middleware {
+		name, args := ast.ParseMiddleware(mw)
+		if name != ast.SignedRequestMiddleware || isEnvVarName(strings.Trim(args, `"`)) {
+			continue
+		}
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_signed_request",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("signed_request needs the environment variable holding its secret, got: %s", mw),
+			Location:   resource.Loc,
+			Suggestion: "Name the environment variable the shared secret is read from",
+			Examples:   []string{"@middleware [signed_request(PARTNER_SECRET)]"},
+		})
+	}
+}
+
+// checkOperations verifies that @operations refines operations with known
+// modifiers and excludes standard operations, and that a resource does not
+// both list and exclude operations
+func (tc *TypeChecker) checkOperations(resource *ast.ResourceNode) {
+	invalid := func(message string) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_operations",
+			Severity:   SeverityError,
+			Message:    message,
+			Location:   resource.Loc,
+			Suggestion: "List the operations to serve, or exclude standard operations with all except",
+			Examples: []string{
+				"@operations [list, get, create(admin_only), update]",
+				"@operations all except [delete]",
+			},
+		})
+	}
+
+	if len(resource.Operations) > 0 && len(resource.Excluded) > 0 {
+		invalid(fmt.Sprintf("@operations of %s both lists and excludes operations", resource.Name))
+	}
+	for _, op := range resource.Excluded {
+		if !ast.IsStandardOperation(op) {
+			invalid(fmt.Sprintf("@operations all except names unknown operation %s (standard operations: %s)",
+				op, strings.Join(ast.StandardOperations, ", ")))
+		}
+	}
+	for _, op := range resource.Operations {
+		for _, mod := range resource.OperationMods[op] {
+			if !slices.Contains(ast.OperationModifiers, mod) {
+				invalid(fmt.Sprintf("unknown modifier %s on operation %s (supported: %s)",
+					mod, op, strings.Join(ast.OperationModifiers, ", ")))
+			} else if !ast.IsStandardOperation(op) {
+				invalid(fmt.Sprintf("modifier %s applies to standard operations only, not %s", mod, op))
+			}
+		}
+	}
+}
+
+// checkProfiles verifies that every field of a @profile is declared once and
+// that a public profile says what callers without a matching role see
+func (tc *TypeChecker) checkProfiles(resource *ast.ResourceNode) {
+	hasPublic := false
+	for _, profile := range resource.Profiles {
+		if profile.Name == ast.ProfilePublic {
+			hasPublic = true
+		}
+
+		seen := make(map[string]bool)
+		for _, name := range profile.Fields {
+			if seen[name] {
+				tc.errors = append(tc.errors, &TypeError{
+					Code:     ErrInvalidConstraintType,
+					Type:     "invalid_profile",
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("Profile %s lists %s more than once", profile.Name, name),
+					Location: profile.Loc,
+				})
+				continue
+			}
+			seen[name] = true
+
+			if resource.FindField(name) == nil {
+				tc.errors = append(tc.errors, NewUndefinedField(profile.Loc, name, resource.Name))
+			}
+		}
+	}
+
+	if !hasPublic {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_profile",
+			Severity:   SeverityError,
+			Message:    "@profile requires a public profile for callers without a matching role",
+			Location:   resource.Profiles[0].Loc,
+			Suggestion: "List the fields every caller may see as the public profile",
+			Examples:   []string{"@profile(public: [id, title], admin: *)"},
+		})
+	}
+}
+
+// checkUpsert verifies that the conflict target of @upsert is covered by a
+// unique constraint: ON CONFLICT only accepts columns with a unique index, and
+// fields are only ever unique on their own, so the target must be a single
+// @unique or @primary field. Upserts write without the bookkeeping of
+// @counter_cache and @conflict, and partitioned tables have no unique index
+// on the field alone, so those are rejected too.
+func (tc *TypeChecker) checkUpsert(resource *ast.ResourceNode) {
+	upsert := resource.Upsert
+
+	for _, name := range upsert.Fields {
+		if resource.FindField(name) == nil {
+			tc.errors = append(tc.errors, NewUndefinedField(upsert.Loc, name, resource.Name))
+			return
+		}
+	}
+
+	var field *ast.FieldNode
+	if len(upsert.Fields) == 1 {
+		field = resource.FindField(upsert.Fields[0])
+	}
+	if field == nil || !(hasFieldConstraint(field, "unique") || hasFieldConstraint(field, "primary")) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_upsert",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("@upsert conflict target (%s) is not covered by a unique constraint", strings.Join(upsert.Fields, ", ")),
+			Location:   upsert.Loc,
+			Suggestion: "Upsert on a single @unique or @primary field",
+			Examples:   []string{"email: string! @unique", "@upsert(on: [email])"},
+		})
+	}
+
+	incompatible := func(loc ast.SourceLocation, what string) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:     ErrInvalidConstraintType,
+			Type:     "invalid_upsert",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("@upsert cannot be combined with %s", what),
+			Location: loc,
+		})
+	}
+	if resource.Partition != nil {
+		incompatible(resource.Partition.Loc, "@partition")
+	}
+	if resource.Conflict != nil {
+		incompatible(resource.Conflict.Loc, "@conflict")
+	}
+	for _, counter := range resource.CounterCaches {
+		incompatible(counter.Loc, "@counter_cache")
+	}
+}
+
+// isEnvVarName reports whether s is an upper-case environment variable name
+func isEnvVarName(s string) bool {
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '_' {
+			return false
+		}
 	}
+	return true
 }
 
-// CheckProgram is the main entry point for type checking
-// It type-checks all resources in the program and returns any errors found
-func (tc *TypeChecker) CheckProgram(prog *ast.Program) ErrorList {
-	// First pass: Register all resources
-	for _, resource := range prog.Resources {
-		tc.resources[resource.Name] = resource
+// checkLoginResource verifies that the auth resource can be signed in as:
+// users are matched by a required @unique email, and a first sign-in creates a
+// record from the email and name alone, so every other required field must be
+// filled in by the database or a default
+func (tc *TypeChecker) checkLoginResource() {
+	resource, ok := tc.resources[tc.loginResource]
+	if !ok {
+		tc.errors = append(tc.errors, NewUndefinedResource(ast.SourceLocation{}, tc.loginResource))
+		return
 	}
 
-	// Second pass: Type check each resource
-	for _, resource := range prog.Resources {
-		tc.checkResource(resource)
+	email := resource.FindField(ast.LoginEmailField)
+	if email == nil || email.Nullable || email.Type.Kind != ast.TypePrimitive ||
+		(email.Type.Name != "string" && email.Type.Name != "text") || !hasFieldConstraint(email, "unique") {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrMissingAnnotationField,
+			Type:       "invalid_login_resource",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("auth.resource %s needs a required @unique email string field to match users by", resource.Name),
+			Location:   resource.Loc,
+			Suggestion: "Declare the field in the resource",
+			Examples:   []string{"email: string! @unique"},
+		})
+	}
+	if name := resource.FindField(ast.LoginNameField); name != nil &&
+		(name.Type.Kind != ast.TypePrimitive || (name.Type.Name != "string" && name.Type.Name != "text")) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:     ErrInvalidConstraintType,
+			Type:     "invalid_login_resource",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("auth.resource %s fills name with the provider's display name, so it must be a string field", resource.Name),
+			Location: name.Loc,
+		})
 	}
 
-	return tc.errors
+	for _, field := range resource.Fields {
+		if field.Nullable || field.Name == ast.LoginEmailField || field.Name == ast.LoginNameField {
+			continue
+		}
+		if hasFieldConstraint(field, "auto") || hasFieldConstraint(field, "auto_update") || hasFieldConstraint(field, "default") {
+			continue
+		}
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_login_resource",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("auth.resource %s cannot be created at first sign-in: %s is required", resource.Name, field.Name),
+			Location:   field.Loc,
+			Suggestion: "Make the field optional, or give it a @default",
+			Examples:   []string{field.Name + ": " + field.Type.Name + "?"},
+		})
+	}
 }
 
-// checkResource type-checks a single resource
-func (tc *TypeChecker) checkResource(resource *ast.ResourceNode) {
-	tc.currentResource = resource
+// checkSearchIndex verifies that every field of a @search_index is declared
+// once and holds values a search backend can index: scalars, enums and arrays
+func (tc *TypeChecker) checkSearchIndex(resource *ast.ResourceNode) {
+	searchIndex := resource.SearchIndex
 
-	// Check all fields
-	for _, field := range resource.Fields {
-		tc.checkField(field)
+	seen := make(map[string]bool)
+	for _, name := range searchIndex.Fields {
+		if seen[name] {
+			tc.errors = append(tc.errors, &TypeError{
+				Code:     ErrInvalidConstraintType,
+				Type:     "invalid_search_index",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("@search_index lists %s more than once", name),
+				Location: searchIndex.Loc,
+			})
+			continue
+		}
+		seen[name] = true
+
+		field := resource.FindField(name)
+		if field == nil {
+			tc.errors = append(tc.errors, NewUndefinedField(searchIndex.Loc, name, resource.Name))
+			continue
+		}
+		if field.Type.Kind == ast.TypeHash || field.Type.Kind == ast.TypeStruct {
+			tc.errors = append(tc.errors, &TypeError{
+				Code:       ErrInvalidConstraintType,
+				Type:       "invalid_search_index",
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("@search_index cannot index %s: hash and struct fields are not searchable", name),
+				Location:   searchIndex.Loc,
+				Suggestion: "Index the text fields of the resource",
+			})
+		}
 	}
+}
 
-	// Check all hooks
-	for _, hook := range resource.Hooks {
-		tc.checkHook(hook)
+// checkIndexes verifies that every index block lists declared fields or
+// belongs_to relationships, each once, and that no two indexes cover the same
+// columns in the same order
+func (tc *TypeChecker) checkIndexes(resource *ast.ResourceNode) {
+	invalid := func(loc ast.SourceLocation, message, suggestion string) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_index",
+			Severity:   SeverityError,
+			Message:    message,
+			Location:   loc,
+			Suggestion: suggestion,
+		})
 	}
 
-	// Check all validations
-	for _, validation := range resource.Validations {
-		tc.checkValidation(validation)
+	for _, index := range resource.Indexes {
+		seen := make(map[string]bool)
+		for _, name := range index.Fields {
+			if seen[name] {
+				invalid(index.Loc, fmt.Sprintf("index lists %s more than once", name), "")
+				continue
+			}
+			seen[name] = true
+
+			if field := resource.FindField(name); field != nil {
+				if field.Type.Kind == ast.TypeHash || field.Type.Kind == ast.TypeStruct {
+					invalid(index.Loc, fmt.Sprintf("index cannot include %s: hash and struct fields are not indexable", name),
+						"Index the scalar fields the resource is queried by")
+				}
+				continue
+			}
+			if rel := resource.FindRelationship(name); rel != nil {
+				if rel.Kind != ast.RelationshipBelongsTo {
+					invalid(index.Loc, fmt.Sprintf("index cannot include %s: only belongs_to relationships have a column", name),
+						"Index the relationship on the resource that holds its foreign key")
+				}
+				continue
+			}
+			tc.errors = append(tc.errors, NewUndefinedField(index.Loc, name, resource.Name))
+		}
 	}
 
-	// Check all constraints
-	for _, constraint := range resource.Constraints {
-		tc.checkConstraint(constraint)
+	covered := make(map[string]bool)
+	for _, index := range resource.AllIndexes() {
+		key := strings.Join(resource.IndexColumns(index), ",")
+		if covered[key] {
+			invalid(index.Loc, fmt.Sprintf("%s already has an index on (%s)", resource.Name, strings.Join(index.Fields, ", ")),
+				"Remove the duplicate index")
+			continue
+		}
+		covered[key] = true
 	}
+}
 
-	// Check computed fields
-	for _, computed := range resource.Computed {
-		tc.checkComputed(computed)
+// checkCounterCache verifies that a @counter_cache counts through a single
+// belongs_to relationship whose foreign key is a field of the resource, and
+// that its column can be added to the parent
+func (tc *TypeChecker) checkCounterCache(resource *ast.ResourceNode, counter *ast.CounterCacheNode) {
+	parent, exists := tc.resources[counter.Resource]
+	if !exists {
+		tc.errors = append(tc.errors, NewUndefinedResource(counter.Loc, counter.Resource))
+		return
 	}
 
-	// Check relationships
-	for _, relationship := range resource.Relationships {
-		tc.checkRelationship(relationship)
+	rel := resource.CounterCacheRelationship(counter)
+	if rel == nil {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_counter_cache",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("@counter_cache on %s requires exactly one belongs_to relationship to %s", counter.Resource, counter.Resource),
+			Location:   counter.Loc,
+			Suggestion: fmt.Sprintf("Declare a single relationship to %s with its foreign key", counter.Resource),
+			Examples:   []string{fmt.Sprintf("%s: %s! { foreign_key: %q }", strings.ToLower(counter.Resource), counter.Resource, strings.ToLower(counter.Resource)+"_id")},
+		})
+		return
+	}
+	if resource.FindField(rel.ForeignKeyColumn()) == nil {
+		tc.errors = append(tc.errors, NewMissingAnnotationField(
+			counter.Loc,
+			"counter_cache",
+			fmt.Sprintf("a %s field holding the foreign key of %s", rel.ForeignKeyColumn(), rel.Name),
+			rel.ForeignKeyColumn()+": uuid!",
+		))
 	}
 
-	// Reset current resource
-	tc.currentResource = nil
+	if parent.ReadOnly() {
+		annotation := "@materialized"
+		if parent.External != nil {
+			annotation = "@external_table"
+		}
+		tc.errors = append(tc.errors, &TypeError{
+			Code:     ErrInvalidConstraintType,
+			Type:     "invalid_counter_cache",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("@counter_cache cannot add %s to %s: %s resources are read-only", counter.Column, counter.Resource, annotation),
+			Location: counter.Loc,
+		})
+	}
+
+	// The column is added to the parent, so it must not clash with its members
+	if parent.FindField(counter.Column) != nil || parent.FindRelationship(counter.Column) != nil {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_counter_cache",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("@counter_cache column %s is already declared on %s", counter.Column, counter.Resource),
+			Location:   counter.Loc,
+			Suggestion: fmt.Sprintf("Remove %s from %s; @counter_cache adds it", counter.Column, counter.Resource),
+		})
+	}
+	for _, other := range tc.resources {
+		for _, otherCounter := range other.CounterCaches {
+			if otherCounter != counter && otherCounter.Resource == counter.Resource && otherCounter.Column == counter.Column {
+				tc.errors = append(tc.errors, &TypeError{
+					Code:     ErrInvalidConstraintType,
+					Type:     "invalid_counter_cache",
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("@counter_cache column %s on %s is also maintained by %s", counter.Column, counter.Resource, other.Name),
+					Location: counter.Loc,
+				})
+			}
+		}
+	}
+
+	// A declared collection must be the has-many side of the relationship
+	if collection := parent.FindRelationship(counter.Relationship); collection != nil &&
+		(collection.Kind != ast.RelationshipHasMany || collection.Type != resource.Name) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:     ErrInvalidConstraintType,
+			Type:     "invalid_counter_cache",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("@counter_cache counts %s.%s, which is not a has-many relationship to %s", counter.Resource, counter.Relationship, resource.Name),
+			Location: counter.Loc,
+		})
+	}
+}
+
+func isTimestampField(field *ast.FieldNode) bool {
+	return field.Type != nil && field.Type.Kind == ast.TypePrimitive && field.Type.Name == "timestamp"
+}
+
+func hasFieldConstraint(field *ast.FieldNode, name string) bool {
+	for _, constraint := range field.Constraints {
+		if constraint.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 // checkField type-checks a field definition
@@ -112,9 +1493,88 @@ func (tc *TypeChecker) checkField(field *ast.FieldNode) {
 	if field.Default != nil {
 		tc.checkDefaultValue(field)
 	}
+
+	if field.Labels != nil {
+		tc.checkLabels(field)
+	}
+}
+
+// checkLabels validates @labels: the field must be an enum and every label
+// must name one of its values
+func (tc *TypeChecker) checkLabels(field *ast.FieldNode) {
+	if field.Type == nil || field.Type.Kind != ast.TypeEnum {
+		fieldType, err := TypeFromASTNode(field.Type, field.Nullable)
+		if err != nil {
+			return // Already reported in checkField
+		}
+		tc.errors = append(tc.errors, NewInvalidConstraintType(field.Labels.Loc, "labels", fieldType, "only valid for enum fields"))
+		return
+	}
+
+	values := make(map[string]bool, len(field.Type.EnumValues))
+	for _, value := range field.Type.EnumValues {
+		values[value] = true
+	}
+	var unknown []string
+	for value := range field.Labels.Values {
+		if !values[value] {
+			unknown = append(unknown, value)
+		}
+	}
+	sort.Strings(unknown)
+	for _, value := range unknown {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "unknown_enum_value",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("@labels names %q, which is not a value of %s", value, field.Name),
+			Location:   field.Labels.Loc,
+			Suggestion: fmt.Sprintf("Label one of the values of %s: %s", field.Name, strings.Join(field.Type.EnumValues, ", ")),
+		})
+	}
 }
 
 // checkFieldConstraint validates a field-level constraint
+// checkFilterOperators validates the operators of @filterable(gte, in, ...):
+// each must be known and suit the field's type
+func (tc *TypeChecker) checkFilterOperators(constraint *ast.ConstraintNode, fieldType Type) {
+	prim, _ := fieldType.(*PrimitiveType)
+	for _, arg := range constraint.Arguments {
+		ident, ok := arg.(*ast.IdentifierExpr)
+		if !ok || !slices.Contains(ast.FilterOperatorNames, ident.Name) {
+			tc.errors = append(tc.errors, &TypeError{
+				Code:       ErrInvalidConstraintType,
+				Type:       "invalid_filter_operator",
+				Severity:   SeverityError,
+				Message:    "Unknown @filterable operator; expected one of " + strings.Join(ast.FilterOperatorNames, ", "),
+				Location:   arg.Location(),
+				Suggestion: "Name the operators the field accepts beyond equality",
+				Examples:   []string{"views: int! @filterable(gte, lte)", "status: string! @filterable(in)"},
+			})
+			continue
+		}
+
+		var reason string
+		switch ident.Name {
+		case "like":
+			if prim == nil || (prim.Name != "string" && prim.Name != "text") {
+				reason = "like only matches string and text fields"
+			}
+		case "null":
+			if !fieldType.IsNullable() {
+				reason = "null only applies to nullable fields"
+			}
+		case "gt", "gte", "lt", "lte":
+			if prim != nil && prim.Name == "bool" {
+				reason = ident.Name + " does not order bool fields"
+			}
+		}
+		if reason != "" {
+			tc.errors = append(tc.errors, NewInvalidConstraintType(arg.Location(), constraint.Name, fieldType, reason))
+		}
+	}
+}
+
 func (tc *TypeChecker) checkFieldConstraint(field *ast.FieldNode, constraint *ast.ConstraintNode) {
 	fieldType, err := TypeFromASTNode(field.Type, field.Nullable)
 	if err != nil {
@@ -180,6 +1640,114 @@ func (tc *TypeChecker) checkFieldConstraint(field *ast.FieldNode, constraint *as
 	case "unique", "primary", "auto", "auto_update":
 		// These are always valid
 
+	case ast.IndexConstraint:
+		// @index takes no arguments; index blocks name and combine indexes
+		if len(constraint.Arguments) > 0 {
+			tc.errors = append(tc.errors, NewInvalidArgumentCount(
+				constraint.Location(),
+				"@index",
+				0,
+				len(constraint.Arguments),
+			))
+		}
+
+	case "filterable", "sortable":
+		// Query parameters compare and order scalar columns only
+		switch fieldType.(type) {
+		case *PrimitiveType, *EnumType:
+		default:
+			tc.errors = append(tc.errors, NewInvalidConstraintType(
+				constraint.Location(),
+				constraint.Name,
+				fieldType,
+				"only valid for primitive and enum types",
+			))
+		}
+		if constraint.Name == "filterable" {
+			tc.checkFilterOperators(constraint, fieldType)
+		} else if len(constraint.Arguments) > 0 {
+			tc.errors = append(tc.errors, NewInvalidArgumentCount(
+				constraint.Location(),
+				"@"+constraint.Name,
+				0,
+				len(constraint.Arguments),
+			))
+		}
+
+	case "column":
+		// @column takes the database column name as a single string
+		stringType := NewPrimitiveType("string", false)
+		if len(constraint.Arguments) != 1 {
+			tc.errors = append(tc.errors, NewInvalidArgumentCount(
+				constraint.Location(),
+				"@column",
+				1,
+				len(constraint.Arguments),
+			))
+		} else if argType, err := tc.inferExpr(constraint.Arguments[0]); err == nil && !stringType.IsAssignableFrom(argType) {
+			tc.errors = append(tc.errors, NewConstraintTypeMismatch(
+				constraint.Location(),
+				"column",
+				stringType,
+				argType,
+			))
+		}
+
+	case "dual_write":
+		// @dual_write takes the legacy column and an optional YYYY-MM-DD cleanup date
+		stringType := NewPrimitiveType("string", false)
+		if len(constraint.Arguments) < 1 || len(constraint.Arguments) > 2 {
+			tc.errors = append(tc.errors, NewInvalidArgumentCount(
+				constraint.Location(),
+				"@dual_write",
+				1,
+				len(constraint.Arguments),
+			))
+			break
+		}
+		for _, arg := range constraint.Arguments {
+			if argType, err := tc.inferExpr(arg); err == nil && !stringType.IsAssignableFrom(argType) {
+				tc.errors = append(tc.errors, NewConstraintTypeMismatch(
+					constraint.Location(),
+					"dual_write",
+					stringType,
+					argType,
+				))
+			}
+		}
+
+		column, until := field.DualWrite()
+		currentColumn := field.ColumnOverride()
+		if currentColumn == "" {
+			currentColumn = strings.ToLower(field.Name)
+		}
+		switch {
+		case tc.isKeyField(field):
+			tc.errors = append(tc.errors, NewInvalidConstraintType(
+				constraint.Location(),
+				"dual_write",
+				fieldType,
+				"not valid on the primary key",
+			))
+		case column == currentColumn:
+			tc.errors = append(tc.errors, NewInvalidConstraintType(
+				constraint.Location(),
+				"dual_write",
+				fieldType,
+				fmt.Sprintf("legacy column %q must differ from the field's column", column),
+			))
+		}
+		if until != "" {
+			if _, err := time.Parse("2006-01-02", until); err != nil {
+				tc.errors = append(tc.errors, NewInvalidConstraintType(
+					constraint.Location(),
+					"dual_write",
+					fieldType,
+					fmt.Sprintf("cleanup date %q must be formatted as YYYY-MM-DD", until),
+				))
+			}
+		}
+
 	case "default":
 		// Check that default value matches field type
 		if len(constraint.Arguments) > 0 {
@@ -233,11 +1801,24 @@ func (tc *TypeChecker) checkHook(hook *ast.HookNode) {
 	oldScope := tc.currentScope
 	tc.currentScope = make(map[string]Type)
 
-	// Add 'self' to scope if we're in a resource
-	if tc.currentResource != nil {
+	// Add 'self' to scope if we're in a resource; batch hooks see all the
+	// records of the create instead
+	if hook.Batch {
+		tc.checkBatchHook(hook)
+	} else if tc.currentResource != nil {
 		tc.currentScope["self"] = NewResourceType(tc.currentResource.Name, false)
 	}
 
+	// Attach and detach hooks also see the record being linked or unlinked
+	if hook.Event == ast.HookEventAttach || hook.Event == ast.HookEventDetach {
+		if rel := tc.hookRelationship(hook); rel != nil {
+			tc.currentScope[rel.HookVariable()] = NewResourceType(rel.Type, false)
+		}
+	}
+
+	oldAsync := tc.inAsync
+	tc.inAsync = hook.IsAsync
+
 	// Type check all statements in the hook
 	for _, stmt := range hook.Body {
 		tc.checkStmt(stmt)
@@ -245,6 +1826,51 @@ func (tc *TypeChecker) checkHook(hook *ast.HookNode) {
 
 	// Restore old scope
 	tc.currentScope = oldScope
+	tc.inAsync = oldAsync
+}
+
+// hookRelationship returns the has_many_through relationship an attach or
+// detach hook names, reporting an error when the resource has none by that name
+func (tc *TypeChecker) hookRelationship(hook *ast.HookNode) *ast.RelationshipNode {
+	if tc.currentResource == nil {
+		return nil
+	}
+	rel := tc.currentResource.FindRelationship(hook.Relationship)
+	if rel != nil && rel.Kind == ast.RelationshipHasManyThrough {
+		return rel
+	}
+
+	example := fmt.Sprintf("@%s %s(tags) { ... }", hook.Timing, hook.Event)
+	tc.errors = append(tc.errors, &TypeError{
+		Code:       ErrInvalidConstraintType,
+		Type:       "invalid_hook_relationship",
+		Severity:   SeverityError,
+		Message:    fmt.Sprintf("@%s %s hook on %s must name a has_many_through relationship, got %q", hook.Timing, hook.Event, tc.currentResource.Name, hook.Relationship),
+		Location:   hook.Loc,
+		Suggestion: "Name a relationship declared with through, e.g. tags: array<Tag!>! { through: \"post_tags\" }",
+		Examples:   []string{example},
+	})
+	return nil
+}
+
+// checkBatchHook adds the records a batch hook runs with to its scope,
+// reporting an error when the hook is not on create, the only event bulk
+// endpoints run
+func (tc *TypeChecker) checkBatchHook(hook *ast.HookNode) {
+	if hook.Event != "create" {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "invalid_batch_hook",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("@%s %s hook cannot be batch; only create hooks run in batches", hook.Timing, hook.Event),
+			Location:   hook.Loc,
+			Suggestion: "Remove batch, or move the logic to a create hook",
+			Examples:   []string{fmt.Sprintf("@%s create batch { ... }", hook.Timing)},
+		})
+	}
+	if tc.currentResource != nil {
+		tc.currentScope[ast.BatchHookVariable] = NewArrayType(NewResourceType(tc.currentResource.Name, false), false)
+	}
 }
 
 // checkValidation type-checks a validation block
@@ -399,13 +2025,132 @@ func (tc *TypeChecker) checkRelationship(rel *ast.RelationshipNode) {
 		})
 	}
 
+	// A foreign key must reference a unique key, and the primary key of a
+	// partitioned table includes the partition key
+	if rel.Kind == ast.RelationshipBelongsTo && targetResource.Partition != nil {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:     ErrInvalidConstraintType,
+			Type:     "partitioned_reference",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("Relationship %s cannot reference %s: foreign keys to a @partition resource are not supported", rel.Name, rel.Type),
+			Location: rel.Location(),
+		})
+	}
+
+	// Materialized views cannot be referenced by foreign keys
+	if rel.Kind == ast.RelationshipBelongsTo && targetResource.Materialized != nil {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:     ErrInvalidConstraintType,
+			Type:     "materialized_reference",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("Relationship %s cannot reference %s: foreign keys to a @materialized resource are not supported", rel.Name, rel.Type),
+			Location: rel.Location(),
+		})
+	}
+
+	// Both sides of a relationship must be in the same database, which only
+	// co-located resources guarantee
+	if tc.currentResource != nil && !tc.currentResource.Colocated(targetResource) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidConstraintType,
+			Type:       "cross_shard_relationship",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("Relationship %s crosses shards: %s, but %s", rel.Name, shardDescription(tc.currentResource), shardDescription(targetResource)),
+			Location:   rel.Location(),
+			Suggestion: "Shard both resources by the same field, or neither",
+		})
+	}
+
+	// A foreign key holds the single primary key of its target
+	if rel.Kind == ast.RelationshipBelongsTo {
+		tc.checkForeignKey(rel, targetResource)
+	}
+
+	// A has_one relationship is held by a foreign key on its target
+	if rel.Kind == ast.RelationshipHasOne && tc.currentResource != nil {
+		tc.checkHasOneForeignKey(rel, targetResource)
+	}
+
+	// The join table needs distinct columns for both sides of the link
+	if rel.Kind == ast.RelationshipHasManyThrough && tc.currentResource != nil {
+		ownerColumn, targetColumn := rel.JoinColumns(tc.currentResource.Name)
+		if ownerColumn == targetColumn {
+			tc.errors = append(tc.errors, &TypeError{
+				Code:       ErrInvalidConstraintType,
+				Type:       "ambiguous_join_columns",
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("Relationship %s links %s records through %s, but both sides would use the column %s", rel.Name, rel.Type, rel.Through, ownerColumn),
+				Location:   rel.Location(),
+				Suggestion: fmt.Sprintf("Set foreign_key to the %s column referencing this %s", rel.Through, tc.currentResource.Name),
+				Examples:   []string{fmt.Sprintf("%s: array<%s!>! { foreign_key: \"source_id\" through: %q }", rel.Name, rel.Type, rel.Through)},
+			})
+		}
+	}
+
 	// Note: For has-many-through relationships, we're not currently validating
 	// that the through table exists as a resource, since it might be defined
 	// as a pure join table in migrations. This could be enhanced in the future
 	// to check migration files.
+}
 
-	// Suppress unused variable warning
-	_ = targetResource
+// checkForeignKey verifies that a belongs_to relationship references a
+// resource with a single-field key, and that its foreign key field has the
+// type of a natural key it references
+func (tc *TypeChecker) checkForeignKey(rel *ast.RelationshipNode, target *ast.ResourceNode) {
+	key := target.KeyFields()
+	if len(key) > 1 {
+		tc.errors = append(tc.errors, primaryKeyError(rel.Location(),
+			fmt.Sprintf("Relationship %s cannot reference %s: foreign keys to a composite key are not supported", rel.Name, rel.Type),
+			"Give the referenced resource a single-field key", ""))
+		return
+	}
+	if !target.HasNaturalKey() || tc.currentResource == nil {
+		return
+	}
+	fk := tc.currentResource.FindField(rel.ForeignKeyColumn())
+	if fk == nil || fk.Type == nil || key[0].Type == nil || fk.Type.Name == key[0].Type.Name {
+		return
+	}
+	tc.errors = append(tc.errors, primaryKeyError(fk.Loc,
+		fmt.Sprintf("%s holds the key of %s, so it must be a %s like %s.%s", fk.Name, rel.Type, key[0].Type.Name, rel.Type, key[0].Name),
+		"Declare the foreign key with the type of the key it references",
+		fmt.Sprintf("%s: %s!", fk.Name, key[0].Type.Name)))
+}
+
+// checkHasOneForeignKey verifies that the target of a has_one relationship
+// declares the foreign key referencing the owning resource
+func (tc *TypeChecker) checkHasOneForeignKey(rel *ast.RelationshipNode, target *ast.ResourceNode) {
+	column := rel.TargetForeignKey(tc.currentResource.Name)
+	if target.FindField(column) != nil {
+		return
+	}
+	keyType := "uuid"
+	if key := tc.currentResource.KeyFields(); len(key) == 1 && key[0].Type != nil {
+		keyType = key[0].Type.Name
+	}
+	tc.errors = append(tc.errors, &TypeError{
+		Code:       ErrUndefinedField,
+		Type:       "missing_has_one_foreign_key",
+		Severity:   SeverityError,
+		Message:    fmt.Sprintf("Relationship %s is has_one, but %s has no %s field referencing %s", rel.Name, rel.Type, column, tc.currentResource.Name),
+		Location:   rel.Location(),
+		Suggestion: fmt.Sprintf("Declare the foreign key on %s, or set foreign_key to the field that holds it", rel.Type),
+		Examples:   []string{fmt.Sprintf("%s: %s!", column, keyType)},
+	})
+}
+
+// isKeyField reports whether a field is part of the primary key of the
+// resource being checked
+func (tc *TypeChecker) isKeyField(field *ast.FieldNode) bool {
+	if tc.currentResource == nil {
+		return field.Name == "id"
+	}
+	for _, key := range tc.currentResource.KeyFields() {
+		if key == field {
+			return true
+		}
+	}
+	return false
 }
 
 // checkStmt type-checks a statement
@@ -429,9 +2174,12 @@ func (tc *TypeChecker) checkStmt(stmt ast.StmtNode) {
 		tc.checkIf(s)
 
 	case *ast.BlockStmt:
+		oldAsync := tc.inAsync
+		tc.inAsync = tc.inAsync || s.IsAsync
 		for _, stmt := range s.Statements {
 			tc.checkStmt(stmt)
 		}
+		tc.inAsync = oldAsync
 
 	case *ast.RescueStmt:
 		for _, stmt := range s.Try {
@@ -1,6 +1,7 @@
 package typechecker
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
@@ -589,6 +590,1658 @@ func TestConstraintTypeValidation(t *testing.T) {
 	}
 }
 
+// TestColumnConstraintValidation tests that @column requires a string column name
+func TestColumnConstraintValidation(t *testing.T) {
+	columnField := func(arg interface{}) *ast.ResourceNode {
+		return &ast.ResourceNode{
+			Name: "Test",
+			Fields: []*ast.FieldNode{
+				{
+					Name:     "title",
+					Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+					Nullable: false,
+					Constraints: []*ast.ConstraintNode{
+						{
+							Name:      "column",
+							Arguments: []ast.ExprNode{&ast.LiteralExpr{Value: arg}},
+							Loc:       ast.SourceLocation{Line: 2, Column: 18},
+						},
+					},
+					Loc: ast.SourceLocation{Line: 2, Column: 3},
+				},
+			},
+			Loc: ast.SourceLocation{Line: 1, Column: 1},
+		}
+	}
+
+	errors := NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{columnField("legacy_title")}})
+	if len(errors) != 0 {
+		t.Errorf("Expected no errors for @column(\"legacy_title\"), got: %v", errors)
+	}
+
+	errors = NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{columnField(42)}})
+	found := false
+	for _, err := range errors {
+		if err.Code == ErrConstraintTypeMismatch {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected constraint type mismatch for @column(42), got: %v", errors)
+	}
+}
+
+func TestQueryAllowListConstraintValidation(t *testing.T) {
+	resource := func(typeNode *ast.TypeNode, constraint string) *ast.ResourceNode {
+		return &ast.ResourceNode{
+			Name: "Test",
+			Fields: []*ast.FieldNode{
+				{
+					Name:     "value",
+					Type:     typeNode,
+					Nullable: false,
+					Constraints: []*ast.ConstraintNode{
+						{Name: constraint, Loc: ast.SourceLocation{Line: 2, Column: 18}},
+					},
+					Loc: ast.SourceLocation{Line: 2, Column: 3},
+				},
+			},
+			Loc: ast.SourceLocation{Line: 1, Column: 1},
+		}
+	}
+
+	stringType := &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}
+	enumType := &ast.TypeNode{Kind: ast.TypeEnum, EnumValues: []string{"draft", "published"}}
+	arrayType := &ast.TypeNode{
+		Kind:        ast.TypeArray,
+		ElementType: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+	}
+
+	for _, constraint := range []string{"filterable", "sortable"} {
+		for _, typeNode := range []*ast.TypeNode{stringType, enumType} {
+			errors := NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource(typeNode, constraint)}})
+			if len(errors) != 0 {
+				t.Errorf("Expected no errors for @%s on %v, got: %v", constraint, typeNode.Kind, errors)
+			}
+		}
+
+		errors := NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource(arrayType, constraint)}})
+		found := false
+		for _, err := range errors {
+			if err.Code == ErrInvalidConstraintType {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected invalid constraint type for @%s on an array, got: %v", constraint, errors)
+		}
+	}
+}
+
+func TestFilterOperatorValidation(t *testing.T) {
+	resource := func(typeName string, nullable bool, operators ...string) *ast.ResourceNode {
+		constraint := &ast.ConstraintNode{Name: "filterable", Loc: ast.SourceLocation{Line: 2, Column: 18}}
+		for _, operator := range operators {
+			constraint.Arguments = append(constraint.Arguments, &ast.IdentifierExpr{Name: operator, Loc: ast.SourceLocation{Line: 2, Column: 30}})
+		}
+		return &ast.ResourceNode{
+			Name: "Test",
+			Fields: []*ast.FieldNode{
+				{
+					Name:        "value",
+					Type:        &ast.TypeNode{Kind: ast.TypePrimitive, Name: typeName},
+					Nullable:    nullable,
+					Constraints: []*ast.ConstraintNode{constraint},
+					Loc:         ast.SourceLocation{Line: 2, Column: 3},
+				},
+			},
+			Loc: ast.SourceLocation{Line: 1, Column: 1},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		resource  *ast.ResourceNode
+		wantType  string
+		wantError string
+	}{
+		{"comparisons on int", resource("int", false, "ne", "gt", "gte", "lt", "lte", "in"), "", ""},
+		{"like on string", resource("string", false, "like", "in"), "", ""},
+		{"null on nullable", resource("timestamp", true, "null", "gte"), "", ""},
+		{"unknown operator", resource("int", false, "between"), "invalid_filter_operator", "Unknown @filterable operator"},
+		{"like on int", resource("int", false, "like"), "invalid_constraint_type", "like only matches string and text fields"},
+		{"null on required", resource("string", false, "null"), "invalid_constraint_type", "null only applies to nullable fields"},
+		{"gt on bool", resource("bool", false, "gt"), "invalid_constraint_type", "gt does not order bool fields"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{tt.resource}})
+			if tt.wantType == "" {
+				if len(errors) != 0 {
+					t.Errorf("Expected no errors, got: %v", errors)
+				}
+				return
+			}
+			if len(errors) != 1 || errors[0].Type != tt.wantType || !strings.Contains(errors[0].Message, tt.wantError) {
+				t.Errorf("Expected one %s error containing %q, got: %v", tt.wantType, tt.wantError, errors)
+			}
+		})
+	}
+
+	// @sortable still takes no arguments
+	sortable := resource("int", false, "gt")
+	sortable.Fields[0].Constraints[0].Name = "sortable"
+	errors := NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{sortable}})
+	if len(errors) != 1 || errors[0].Code != ErrInvalidArgumentCount {
+		t.Errorf("Expected an argument count error for @sortable(gt), got: %v", errors)
+	}
+}
+
+func TestDualWriteConstraintValidation(t *testing.T) {
+	check := func(fieldName string, args ...ast.ExprNode) []*TypeError {
+		resource := &ast.ResourceNode{
+			Name: "Post",
+			Fields: []*ast.FieldNode{
+				{
+					Name:     fieldName,
+					Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+					Nullable: false,
+					Constraints: []*ast.ConstraintNode{
+						{Name: "dual_write", Arguments: args, Loc: ast.SourceLocation{Line: 2, Column: 20}},
+					},
+					Loc: ast.SourceLocation{Line: 2, Column: 3},
+				},
+			},
+			Loc: ast.SourceLocation{Line: 1, Column: 1},
+		}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+	str := func(value string) ast.ExprNode { return &ast.LiteralExpr{Value: value} }
+
+	if errors := check("headline", str("title")); len(errors) != 0 {
+		t.Errorf("Expected no errors for @dual_write(\"title\"), got: %v", errors)
+	}
+	if errors := check("headline", str("title"), str("2026-12-01")); len(errors) != 0 {
+		t.Errorf("Expected no errors with a cleanup date, got: %v", errors)
+	}
+
+	tests := []struct {
+		name     string
+		field    string
+		args     []ast.ExprNode
+		wantCode ErrorCode
+	}{
+		{"no arguments", "headline", nil, ErrInvalidArgumentCount},
+		{"too many arguments", "headline", []ast.ExprNode{str("a"), str("2026-12-01"), str("b")}, ErrInvalidArgumentCount},
+		{"non-string column", "headline", []ast.ExprNode{&ast.LiteralExpr{Value: int64(1)}}, ErrConstraintTypeMismatch},
+		{"same column", "headline", []ast.ExprNode{str("headline")}, ErrInvalidConstraintType},
+		{"primary key", "id", []ast.ExprNode{str("legacy_id")}, ErrInvalidConstraintType},
+		{"invalid date", "headline", []ast.ExprNode{str("title"), str("next week")}, ErrInvalidConstraintType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.field, tt.args...)
+			found := false
+			for _, err := range errors {
+				if err.Code == tt.wantCode {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected %s, got: %v", tt.wantCode, errors)
+			}
+		})
+	}
+}
+
+// TestChangesValidation tests that @changes requires an @auto_update timestamp
+// and a nullable deleted_at timestamp
+func TestChangesValidation(t *testing.T) {
+	timestamp := func(name string, nullable bool, constraints ...string) *ast.FieldNode {
+		field := &ast.FieldNode{
+			Name:     name,
+			Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"},
+			Nullable: nullable,
+		}
+		for _, constraint := range constraints {
+			field.Constraints = append(field.Constraints, &ast.ConstraintNode{Name: constraint})
+		}
+		return field
+	}
+	check := func(fields ...*ast.FieldNode) []*TypeError {
+		resource := &ast.ResourceNode{
+			Name:    "Post",
+			Fields:  fields,
+			Changes: &ast.ChangesNode{Loc: ast.SourceLocation{Line: 5, Column: 3}},
+		}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+
+	if errors := check(timestamp("updated_at", false, "auto_update"), timestamp("deleted_at", true)); len(errors) != 0 {
+		t.Errorf("Expected no errors, got: %v", errors)
+	}
+
+	tests := []struct {
+		name   string
+		fields []*ast.FieldNode
+	}{
+		{"no auto_update field", []*ast.FieldNode{timestamp("updated_at", false), timestamp("deleted_at", true)}},
+		{"no deleted_at field", []*ast.FieldNode{timestamp("updated_at", false, "auto_update")}},
+		{"required deleted_at", []*ast.FieldNode{timestamp("updated_at", false, "auto_update"), timestamp("deleted_at", false)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.fields...)
+			if len(errors) != 1 || errors[0].Code != ErrMissingAnnotationField {
+				t.Fatalf("Expected one %s error, got: %v", ErrMissingAnnotationField, errors)
+			}
+			if errors[0].Location.Line != 5 {
+				t.Errorf("Expected error at the annotation, got line %d", errors[0].Location.Line)
+			}
+		})
+	}
+}
+
+func TestArchivableValidation(t *testing.T) {
+	check := func(fields ...*ast.FieldNode) []*TypeError {
+		resource := &ast.ResourceNode{
+			Name:       "Post",
+			Fields:     append([]*ast.FieldNode{{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}}}, fields...),
+			Archivable: &ast.ArchivableNode{Loc: ast.SourceLocation{Line: 4, Column: 3}},
+		}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+	archivedAt := func(typeName string, nullable bool) *ast.FieldNode {
+		return &ast.FieldNode{Name: "archived_at", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: typeName}, Nullable: nullable}
+	}
+
+	if errors := check(archivedAt("timestamp", true)); len(errors) != 0 {
+		t.Errorf("Expected no errors, got: %v", errors)
+	}
+
+	tests := []struct {
+		name   string
+		fields []*ast.FieldNode
+	}{
+		{"no archived_at field", nil},
+		{"required archived_at", []*ast.FieldNode{archivedAt("timestamp", false)}},
+		{"archived_at not a timestamp", []*ast.FieldNode{archivedAt("bool", true)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.fields...)
+			if len(errors) != 1 || errors[0].Code != ErrMissingAnnotationField {
+				t.Fatalf("Expected one %s error, got: %v", ErrMissingAnnotationField, errors)
+			}
+			if errors[0].Location.Line != 4 {
+				t.Errorf("Expected error at the annotation, got line %d", errors[0].Location.Line)
+			}
+		})
+	}
+}
+
+func TestSoftDeleteValidation(t *testing.T) {
+	check := func(fields ...*ast.FieldNode) []*TypeError {
+		resource := &ast.ResourceNode{
+			Name:       "Post",
+			Fields:     append([]*ast.FieldNode{{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}}}, fields...),
+			SoftDelete: &ast.SoftDeleteNode{Loc: ast.SourceLocation{Line: 4, Column: 3}},
+		}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+	deletedAt := func(typeName string, nullable bool) *ast.FieldNode {
+		return &ast.FieldNode{Name: "deleted_at", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: typeName}, Nullable: nullable}
+	}
+
+	// deleted_at is added when it is left out
+	if errors := check(); len(errors) != 0 {
+		t.Errorf("Expected no errors without deleted_at, got: %v", errors)
+	}
+	if errors := check(deletedAt("timestamp", true)); len(errors) != 0 {
+		t.Errorf("Expected no errors, got: %v", errors)
+	}
+
+	// ...including for @changes, whose feed reads it
+	feed := &ast.ResourceNode{
+		Name: "Post",
+		Fields: []*ast.FieldNode{{
+			Name:        "updated_at",
+			Type:        &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"},
+			Constraints: []*ast.ConstraintNode{{Name: "auto_update"}},
+		}},
+		Changes:    &ast.ChangesNode{},
+		SoftDelete: &ast.SoftDeleteNode{},
+	}
+	if errors := NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{feed}}); len(errors) != 0 {
+		t.Errorf("Expected no errors for @changes with @soft_delete, got: %v", errors)
+	}
+
+	tests := []struct {
+		name   string
+		fields []*ast.FieldNode
+	}{
+		{"required deleted_at", []*ast.FieldNode{deletedAt("timestamp", false)}},
+		{"deleted_at not a timestamp", []*ast.FieldNode{deletedAt("bool", true)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.fields...)
+			if len(errors) != 1 || errors[0].Type != "invalid_soft_delete" {
+				t.Fatalf("Expected one invalid_soft_delete error, got: %v", errors)
+			}
+			if errors[0].Location.Line != 4 {
+				t.Errorf("Expected error at the annotation, got line %d", errors[0].Location.Line)
+			}
+		})
+	}
+}
+
+// TestDefaultScopeValidation tests the condition of @default_scope
+func TestDefaultScopeValidation(t *testing.T) {
+	self := func(field string) ast.ExprNode {
+		return &ast.FieldAccessExpr{Object: &ast.SelfExpr{}, Field: field}
+	}
+	compare := func(left ast.ExprNode, operator string, right ast.ExprNode) ast.ExprNode {
+		return &ast.BinaryExpr{Left: left, Operator: operator, Right: right}
+	}
+	check := func(condition ast.ExprNode) []*TypeError {
+		resource := &ast.ResourceNode{
+			Name: "Post",
+			Fields: []*ast.FieldNode{
+				{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+				{Name: "published", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "bool"}},
+			},
+			SoftDelete:   &ast.SoftDeleteNode{},
+			DefaultScope: &ast.DefaultScopeNode{Condition: condition, Loc: ast.SourceLocation{Line: 4, Column: 3}},
+		}
+		resources := ast.WithSoftDeletes([]*ast.ResourceNode{resource})
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: resources})
+	}
+
+	valid := map[string]ast.ExprNode{
+		"deleted_at == nil": compare(self("deleted_at"), "==", &ast.LiteralExpr{Value: nil}),
+		"bool field":        self("published"),
+		"string comparison": compare(self("title"), "!=", &ast.LiteralExpr{Value: "draft"}),
+		"and": &ast.LogicalExpr{
+			Left:     self("published"),
+			Operator: "and",
+			Right:    compare(self("deleted_at"), "==", &ast.LiteralExpr{Value: nil}),
+		},
+	}
+	for name, condition := range valid {
+		if errors := check(condition); len(errors) != 0 {
+			t.Errorf("%s: expected no errors, got: %v", name, errors)
+		}
+	}
+
+	// Conditions must be bool
+	errors := check(self("title"))
+	if len(errors) != 1 || errors[0].Code != ErrTypeMismatch {
+		t.Fatalf("Expected one type mismatch for a string condition, got: %v", errors)
+	}
+
+	// ...and translatable to SQL
+	errors = check(compare(&ast.CallExpr{Namespace: "String", Function: "length", Arguments: []ast.ExprNode{self("title")}}, ">", &ast.LiteralExpr{Value: int64(0)}))
+	if len(errors) != 1 || errors[0].Type != "invalid_default_scope" {
+		t.Fatalf("Expected one invalid_default_scope error, got: %v", errors)
+	}
+	if errors[0].Location.Line != 4 {
+		t.Errorf("Expected error at the annotation, got line %d", errors[0].Location.Line)
+	}
+}
+
+// TestOperationsValidation tests the modifiers and exclusions of @operations
+func TestOperationsValidation(t *testing.T) {
+	check := func(operations, excluded []string, mods map[string][]string) []*TypeError {
+		resource := &ast.ResourceNode{
+			Name:          "Post",
+			Fields:        []*ast.FieldNode{{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}}},
+			Operations:    operations,
+			Excluded:      excluded,
+			OperationMods: mods,
+		}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+
+	if errors := check([]string{"list", "show", "create"}, nil, map[string][]string{"create": {"admin_only"}, "show": {"admin_only"}}); len(errors) != 0 {
+		t.Errorf("Expected no errors for admin_only operations, got: %v", errors)
+	}
+	if errors := check(nil, []string{"delete", "show"}, nil); len(errors) != 0 {
+		t.Errorf("Expected no errors for excluded operations, got: %v", errors)
+	}
+
+	invalid := map[string][]*TypeError{
+		"unknown modifier":        check([]string{"create"}, nil, map[string][]string{"create": {"owner_only"}}),
+		"modifier on custom op":   check([]string{"archive"}, nil, map[string][]string{"archive": {"admin_only"}}),
+		"unknown excluded op":     check(nil, []string{"destroy"}, nil),
+		"listed and excluded ops": check([]string{"list"}, []string{"delete"}, nil),
+	}
+	for name, errors := range invalid {
+		if len(errors) != 1 || errors[0].Type != "invalid_operations" {
+			t.Errorf("%s: expected one invalid_operations error, got: %v", name, errors)
+		}
+	}
+}
+
+// TestOrderableValidation tests the scope and position field of @orderable
+func TestOrderableValidation(t *testing.T) {
+	field := func(name, typeName string, nullable bool) *ast.FieldNode {
+		return &ast.FieldNode{Name: name, Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: typeName}, Nullable: nullable}
+	}
+	check := func(scope string, upsert bool, fields ...*ast.FieldNode) []*TypeError {
+		resource := &ast.ResourceNode{
+			Name:      "Task",
+			Fields:    append([]*ast.FieldNode{field("title", "string", false), field("list_id", "uuid", false), field("note", "string", true)}, fields...),
+			Orderable: &ast.OrderableNode{Scope: scope, Loc: ast.SourceLocation{Line: 4, Column: 3}},
+		}
+		if upsert {
+			resource.Fields[0].Constraints = []*ast.ConstraintNode{{Name: "unique"}}
+			resource.Upsert = &ast.UpsertNode{Fields: []string{"title"}, Loc: ast.SourceLocation{Line: 5, Column: 3}}
+		}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+
+	for _, valid := range []struct {
+		name   string
+		scope  string
+		fields []*ast.FieldNode
+	}{
+		{"unscoped", "", nil},
+		{"scoped", "list_id", nil},
+		{"declared position", "list_id", []*ast.FieldNode{field("position", "int", false)}},
+	} {
+		if errors := check(valid.scope, false, valid.fields...); len(errors) != 0 {
+			t.Errorf("%s: expected no errors, got: %v", valid.name, errors)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		scope  string
+		upsert bool
+		fields []*ast.FieldNode
+		typ    string
+	}{
+		{"unknown scope", "board_id", false, nil, "undefined_field"},
+		{"nullable scope", "note", false, nil, "invalid_orderable"},
+		{"position as scope", "position", false, []*ast.FieldNode{field("position", "int", false)}, "invalid_orderable"},
+		{"nullable position", "", false, []*ast.FieldNode{field("position", "int", true)}, "invalid_orderable"},
+		{"position not an int", "", false, []*ast.FieldNode{field("position", "float", false)}, "invalid_orderable"},
+		{"with upsert", "", true, nil, "invalid_orderable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.scope, tt.upsert, tt.fields...)
+			if len(errors) != 1 || errors[0].Type != tt.typ {
+				t.Fatalf("Expected one %s error, got: %v", tt.typ, errors)
+			}
+		})
+	}
+}
+
+func TestTreeValidation(t *testing.T) {
+	belongsTo := func(name, target, foreignKey string) *ast.RelationshipNode {
+		return &ast.RelationshipNode{Name: name, Type: target, Kind: ast.RelationshipBelongsTo, ForeignKey: foreignKey, Nullable: true}
+	}
+	check := func(tree *ast.TreeNode, parentNullable bool, relationships ...*ast.RelationshipNode) []*TypeError {
+		tree.Loc = ast.SourceLocation{Line: 5, Column: 3}
+		fields := []*ast.FieldNode{
+			{Name: "name", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+			{Name: "parent_id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}, Nullable: parentNullable},
+			{Name: "origin_id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}, Nullable: true},
+		}
+		resource := &ast.ResourceNode{Name: "Category", Fields: fields, Relationships: relationships, Tree: tree}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+	parent := belongsTo("parent", "Category", "parent_id")
+	origin := belongsTo("origin", "Category", "origin_id")
+
+	if errors := check(&ast.TreeNode{}, true, parent); len(errors) != 0 {
+		t.Errorf("single parent: expected no errors, got: %v", errors)
+	}
+	if errors := check(&ast.TreeNode{Parent: "parent"}, true, parent, origin); len(errors) != 0 {
+		t.Errorf("named parent: expected no errors, got: %v", errors)
+	}
+
+	tests := []struct {
+		name           string
+		tree           *ast.TreeNode
+		parentNullable bool
+		relationships  []*ast.RelationshipNode
+		typ            string
+	}{
+		{"no parent", &ast.TreeNode{}, true, nil, "invalid_tree"},
+		{"ambiguous parent", &ast.TreeNode{}, true, []*ast.RelationshipNode{parent, origin}, "invalid_tree"},
+		{"unknown parent", &ast.TreeNode{Parent: "owner"}, true, []*ast.RelationshipNode{parent}, "invalid_tree"},
+		{"required parent", &ast.TreeNode{}, false, []*ast.RelationshipNode{parent}, "missing_annotation_field"},
+		{"undeclared foreign key", &ast.TreeNode{}, true, []*ast.RelationshipNode{belongsTo("up", "Category", "")}, "missing_annotation_field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.tree, tt.parentNullable, tt.relationships...)
+			if len(errors) != 1 || errors[0].Type != tt.typ {
+				t.Fatalf("Expected one %s error, got: %v", tt.typ, errors)
+			}
+		})
+	}
+}
+
+func TestIDStrategyValidation(t *testing.T) {
+	id := func(typeName string, constraints ...string) *ast.FieldNode {
+		field := &ast.FieldNode{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: typeName}}
+		for _, name := range constraints {
+			field.Constraints = append(field.Constraints, &ast.ConstraintNode{Name: name})
+		}
+		return field
+	}
+	check := func(strategy string, fields ...*ast.FieldNode) []*TypeError {
+		resource := &ast.ResourceNode{
+			Name:       "Order",
+			Fields:     append(fields, &ast.FieldNode{Name: "total", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int"}}),
+			IDStrategy: &ast.IDStrategyNode{Strategy: strategy, Loc: ast.SourceLocation{Line: 5, Column: 3}},
+		}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+
+	valid := map[string]string{
+		ast.IDStrategyULID:      "ulid",
+		ast.IDStrategyUUIDv7:    "uuid",
+		ast.IDStrategySnowflake: "int",
+	}
+	for strategy, typeName := range valid {
+		if errors := check(strategy, id(typeName, "primary", "auto")); len(errors) != 0 {
+			t.Errorf("%s: expected no errors, got: %v", strategy, errors)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		fields   []*ast.FieldNode
+		typ      string
+	}{
+		{"no id", ast.IDStrategyULID, nil, "missing_annotation_field"},
+		{"wrong type", ast.IDStrategySnowflake, []*ast.FieldNode{id("uuid", "primary", "auto")}, "invalid_id_strategy"},
+		{"not auto", ast.IDStrategyUUIDv7, []*ast.FieldNode{id("uuid", "primary")}, "invalid_id_strategy"},
+		{"not primary", ast.IDStrategyULID, []*ast.FieldNode{id("ulid", "auto")}, "invalid_id_strategy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.strategy, tt.fields...)
+			if len(errors) != 1 || errors[0].Type != tt.typ {
+				t.Fatalf("Expected one %s error, got: %v", tt.typ, errors)
+			}
+		})
+	}
+}
+
+// TestPrimaryKeyValidation tests natural and composite primary keys
+func TestPrimaryKeyValidation(t *testing.T) {
+	field := func(name, typeName string, nullable bool, constraints ...string) *ast.FieldNode {
+		f := &ast.FieldNode{Name: name, Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: typeName}, Nullable: nullable}
+		for _, c := range constraints {
+			f.Constraints = append(f.Constraints, &ast.ConstraintNode{Name: c})
+		}
+		return f
+	}
+	check := func(resources ...*ast.ResourceNode) []*TypeError {
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: resources})
+	}
+	composite := func(fields ...string) *ast.PrimaryKeyNode {
+		return &ast.PrimaryKeyNode{Fields: fields}
+	}
+
+	country := &ast.ResourceNode{Name: "Country", Fields: []*ast.FieldNode{field("code", "string", false, "primary"), field("name", "string", false)}}
+	membership := &ast.ResourceNode{
+		Name:       "Membership",
+		Fields:     []*ast.FieldNode{field("region", "string", false), field("code", "int", false)},
+		PrimaryKey: composite("region", "code"),
+	}
+	if errors := check(country, membership); len(errors) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errors)
+	}
+
+	tests := []struct {
+		name      string
+		resources []*ast.ResourceNode
+	}{
+		{"two primary fields", []*ast.ResourceNode{{Name: "A", Fields: []*ast.FieldNode{field("a", "string", false, "primary"), field("b", "string", false, "primary")}}}},
+		{"composite and primary field", []*ast.ResourceNode{{Name: "A", Fields: []*ast.FieldNode{field("a", "string", false, "primary"), field("b", "string", false)}, PrimaryKey: composite("a", "b")}}},
+		{"single field composite", []*ast.ResourceNode{{Name: "A", Fields: []*ast.FieldNode{field("a", "string", false)}, PrimaryKey: composite("a")}}},
+		{"duplicate field", []*ast.ResourceNode{{Name: "A", Fields: []*ast.FieldNode{field("a", "string", false)}, PrimaryKey: composite("a", "a")}}},
+		{"nullable key", []*ast.ResourceNode{{Name: "A", Fields: []*ast.FieldNode{field("a", "string", true, "primary")}}}},
+		{"float key", []*ast.ResourceNode{{Name: "A", Fields: []*ast.FieldNode{field("a", "float", false, "primary")}}}},
+		{"id beside natural key", []*ast.ResourceNode{{Name: "A", Fields: []*ast.FieldNode{field("id", "uuid", false), field("a", "string", false, "primary")}}}},
+		{"composite with orderable", []*ast.ResourceNode{{
+			Name:       "A",
+			Fields:     []*ast.FieldNode{field("a", "string", false), field("b", "string", false), field("position", "int", false)},
+			PrimaryKey: composite("a", "b"),
+			Orderable:  &ast.OrderableNode{},
+		}}},
+		{"composite with changes", []*ast.ResourceNode{{
+			Name: "A",
+			Fields: []*ast.FieldNode{field("a", "string", false), field("b", "string", false),
+				field("updated_at", "timestamp", false, "auto_update"), field("deleted_at", "timestamp", true)},
+			PrimaryKey: composite("a", "b"),
+			Changes:    &ast.ChangesNode{},
+		}}},
+		{"reference to composite key", []*ast.ResourceNode{membership, {
+			Name:          "Invite",
+			Fields:        []*ast.FieldNode{field("membership_id", "string", false)},
+			Relationships: []*ast.RelationshipNode{{Name: "membership", Type: "Membership", Kind: ast.RelationshipBelongsTo}},
+		}}},
+		{"foreign key type", []*ast.ResourceNode{country, {
+			Name:          "City",
+			Fields:        []*ast.FieldNode{field("country_id", "uuid", false)},
+			Relationships: []*ast.RelationshipNode{{Name: "country", Type: "Country", Kind: ast.RelationshipBelongsTo}},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.resources...)
+			if len(errors) != 1 || errors[0].Type != "invalid_primary_key" {
+				t.Fatalf("Expected one invalid_primary_key error, got: %v", errors)
+			}
+		})
+	}
+
+	errors := check(&ast.ResourceNode{Name: "A", Fields: []*ast.FieldNode{field("a", "string", false)}, PrimaryKey: composite("a", "b")})
+	if len(errors) != 1 || errors[0].Type != "undefined_field" {
+		t.Errorf("Expected one undefined_field error, got: %v", errors)
+	}
+}
+
+func TestHasOneValidation(t *testing.T) {
+	field := func(name, typeName string, constraints ...string) *ast.FieldNode {
+		f := &ast.FieldNode{Name: name, Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: typeName}}
+		for _, c := range constraints {
+			f.Constraints = append(f.Constraints, &ast.ConstraintNode{Name: c})
+		}
+		return f
+	}
+	check := func(rel *ast.RelationshipNode, profileFields ...*ast.FieldNode) []*TypeError {
+		user := &ast.ResourceNode{Name: "User", Fields: []*ast.FieldNode{field("id", "uuid", "primary")}, Relationships: []*ast.RelationshipNode{rel}}
+		profile := &ast.ResourceNode{Name: "Profile", Fields: append([]*ast.FieldNode{field("id", "uuid", "primary")}, profileFields...)}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{user, profile}})
+	}
+
+	profile := &ast.RelationshipNode{Name: "profile", Type: "Profile", Kind: ast.RelationshipHasOne, Nullable: true}
+	if errors := check(profile, field("user_id", "uuid")); len(errors) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errors)
+	}
+
+	// The foreign key is a field of the related resource
+	errors := check(profile, field("owner_id", "uuid"))
+	if len(errors) != 1 || errors[0].Type != "missing_has_one_foreign_key" || !strings.Contains(errors[0].Message, "no user_id field") {
+		t.Fatalf("Expected one missing_has_one_foreign_key error, got: %v", errors)
+	}
+	if len(errors[0].Examples) != 1 || errors[0].Examples[0] != "user_id: uuid!" {
+		t.Errorf("Unexpected examples: %v", errors[0].Examples)
+	}
+
+	owned := &ast.RelationshipNode{Name: "profile", Type: "Profile", Kind: ast.RelationshipHasOne, ForeignKey: "owner_id"}
+	if errors := check(owned, field("owner_id", "uuid")); len(errors) != 0 {
+		t.Errorf("Expected no errors with a foreign_key, got: %v", errors)
+	}
+}
+
+func TestSchemaValidation(t *testing.T) {
+	check := func(name string) []*TypeError {
+		resource := &ast.ResourceNode{
+			Name:   "Invoice",
+			Fields: []*ast.FieldNode{{Name: "total", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "float"}}},
+			Schema: &ast.SchemaNode{Name: name, Loc: ast.SourceLocation{Line: 4, Column: 3}},
+		}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+
+	for _, name := range []string{"billing", "sales_2024", "_archive"} {
+		if errors := check(name); len(errors) != 0 {
+			t.Errorf("@schema(%q): expected no errors, got: %v", name, errors)
+		}
+	}
+
+	for _, name := range []string{"", "Billing", "billing-eu", "2024_sales", "billing.eu", strings.Repeat("a", 64), "pg_billing", "information_schema"} {
+		t.Run(name, func(t *testing.T) {
+			errors := check(name)
+			if len(errors) != 1 || errors[0].Type != "invalid_schema" || errors[0].Location.Line != 4 {
+				t.Errorf("Expected one invalid_schema error, got: %v", errors)
+			}
+		})
+	}
+}
+
+func TestOwnerValidation(t *testing.T) {
+	check := func(team string) []*TypeError {
+		resource := &ast.ResourceNode{
+			Name:   "Invoice",
+			Fields: []*ast.FieldNode{{Name: "total", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "float"}}},
+			Owner:  &ast.OwnerNode{Team: team, Loc: ast.SourceLocation{Line: 4, Column: 3}},
+		}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+
+	for _, team := range []string{"payments-team", "Billing", "sre_oncall", "team.eu"} {
+		if errors := check(team); len(errors) != 0 {
+			t.Errorf("@owner(%q): expected no errors, got: %v", team, errors)
+		}
+	}
+
+	for _, team := range []string{"", "payments team", "@payments", "acme/payments", "payments:eu"} {
+		t.Run(team, func(t *testing.T) {
+			errors := check(team)
+			if len(errors) != 1 || errors[0].Type != "invalid_owner" || errors[0].Location.Line != 4 {
+				t.Errorf("Expected one invalid_owner error, got: %v", errors)
+			}
+		})
+	}
+}
+
+func TestExternalTableValidation(t *testing.T) {
+	idField := &ast.FieldNode{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int"}, Constraints: []*ast.ConstraintNode{{Name: "primary"}}}
+	emailField := &ast.FieldNode{Name: "email", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}}
+	external := func(table string, fields ...*ast.FieldNode) *ast.ResourceNode {
+		return &ast.ResourceNode{
+			Name:     "LegacyUser",
+			Fields:   fields,
+			External: &ast.ExternalTableNode{Table: table, Loc: ast.SourceLocation{Line: 5, Column: 3}},
+		}
+	}
+	check := func(resources ...*ast.ResourceNode) []*TypeError {
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: resources})
+	}
+
+	for _, table := range []string{"users", "legacy.users", "crm_v2.contact_view"} {
+		if errors := check(external(table, idField, emailField)); len(errors) != 0 {
+			t.Errorf("@external_table(%q): expected no errors, got: %v", table, errors)
+		}
+	}
+
+	withSchema := external("users", idField)
+	withSchema.Schema = &ast.SchemaNode{Name: "legacy", Loc: ast.SourceLocation{Line: 6}}
+	withUpsert := external("users", idField)
+	withUpsert.Upsert = &ast.UpsertNode{Loc: ast.SourceLocation{Line: 7}}
+	withTimestamps := external("users", idField)
+	withTimestamps.Timestamps = &ast.TimestampsNode{Loc: ast.SourceLocation{Line: 8}}
+
+	tests := []struct {
+		name     string
+		resource *ast.ResourceNode
+		wantType string
+		wantLine int
+	}{
+		{"empty", external("", idField), "invalid_external_table", 5},
+		{"uppercase", external("Users", idField), "invalid_external_table", 5},
+		{"quoted", external(`"users"`, idField), "invalid_external_table", 5},
+		{"three parts", external("db.legacy.users", idField), "invalid_external_table", 5},
+		{"empty schema", external(".users", idField), "invalid_external_table", 5},
+		{"leading digit", external("legacy.2024_users", idField), "invalid_external_table", 5},
+		{"too long", external(strings.Repeat("a", 64), idField), "invalid_external_table", 5},
+		{"missing key", external("users", emailField), "missing_annotation_field", 5},
+		{"schema", withSchema, "invalid_external_table", 6},
+		{"upsert", withUpsert, "read_only_resource", 7},
+		{"timestamps", withTimestamps, "read_only_resource", 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.resource)
+			if len(errors) != 1 || errors[0].Type != tt.wantType {
+				t.Fatalf("Expected one %s error, got: %v", tt.wantType, errors)
+			}
+			if errors[0].Location.Line != tt.wantLine {
+				t.Errorf("Expected error on line %d, got line %d", tt.wantLine, errors[0].Location.Line)
+			}
+		})
+	}
+
+	// Other resources may belong to an external one
+	account := &ast.ResourceNode{
+		Name:          "Account",
+		Fields:        []*ast.FieldNode{idField},
+		Relationships: []*ast.RelationshipNode{{Name: "owner", Type: "LegacyUser", Kind: ast.RelationshipBelongsTo, Loc: ast.SourceLocation{Line: 3}}},
+	}
+	if errors := check(external("legacy.users", idField), account); len(errors) != 0 {
+		t.Errorf("Expected no errors for a belongs_to an external resource, got: %v", errors)
+	}
+}
+
+// TestTimestampsValidation tests the declared timestamps of resources with
+// timestamps enabled
+func TestTimestampsValidation(t *testing.T) {
+	timestamp := func(name string, nullable bool, constraints ...string) *ast.FieldNode {
+		field := &ast.FieldNode{Name: name, Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"}, Nullable: nullable}
+		for _, c := range constraints {
+			field.Constraints = append(field.Constraints, &ast.ConstraintNode{Name: c})
+		}
+		return field
+	}
+	check := func(all bool, timestamps *ast.TimestampsNode, fields ...*ast.FieldNode) []*TypeError {
+		resource := &ast.ResourceNode{
+			Name:       "Post",
+			Fields:     append([]*ast.FieldNode{{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}}}, fields...),
+			Timestamps: timestamps,
+		}
+		tc := NewTypeChecker()
+		tc.SetTimestamps(all)
+		return tc.CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+	enabled := &ast.TimestampsNode{Enabled: true}
+
+	if errors := check(true, nil, timestamp("created_at", false, "auto"), timestamp("updated_at", false, "auto_update")); len(errors) != 0 {
+		t.Errorf("Expected no errors for declared timestamps, got: %v", errors)
+	}
+	if errors := check(true, nil); len(errors) != 0 {
+		t.Errorf("Expected no errors without declared timestamps, got: %v", errors)
+	}
+	// Timestamps that are off are not checked
+	if errors := check(false, nil, timestamp("created_at", true)); len(errors) != 0 {
+		t.Errorf("Expected no errors with timestamps off, got: %v", errors)
+	}
+	if errors := check(true, &ast.TimestampsNode{Enabled: false}, timestamp("created_at", true)); len(errors) != 0 {
+		t.Errorf("Expected no errors with @timestamps(false), got: %v", errors)
+	}
+
+	tests := []struct {
+		name       string
+		all        bool
+		timestamps *ast.TimestampsNode
+		field      *ast.FieldNode
+	}{
+		{"nullable", true, nil, timestamp("created_at", true, "auto")},
+		{"not auto", true, nil, timestamp("created_at", false)},
+		{"auto instead of auto_update", false, enabled, timestamp("updated_at", false, "auto")},
+		{"not a timestamp", false, enabled, &ast.FieldNode{Name: "updated_at", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+			Constraints: []*ast.ConstraintNode{{Name: "auto_update"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.all, tt.timestamps, tt.field)
+			if len(errors) != 1 || errors[0].Type != "invalid_timestamps" {
+				t.Fatalf("Expected one invalid_timestamps error, got: %v", errors)
+			}
+		})
+	}
+}
+
+// TestAttachHookValidation tests the relationships attach and detach hooks
+// name and the record their bodies see
+func TestAttachHookValidation(t *testing.T) {
+	stringField := func(name string) *ast.FieldNode {
+		return &ast.FieldNode{Name: name, Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}}
+	}
+	check := func(hook *ast.HookNode, rels ...*ast.RelationshipNode) []*TypeError {
+		post := &ast.ResourceNode{
+			Name:          "Post",
+			Fields:        []*ast.FieldNode{stringField("title")},
+			Relationships: rels,
+			Hooks:         []*ast.HookNode{hook},
+		}
+		tag := &ast.ResourceNode{Name: "Tag", Fields: []*ast.FieldNode{
+			stringField("name"),
+			{Name: "uses", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int"}},
+		}}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{post, tag}})
+	}
+	hook := func(event, relationship, field string) *ast.HookNode {
+		return &ast.HookNode{
+			Timing:       "after",
+			Event:        event,
+			Relationship: relationship,
+			Body: []ast.StmtNode{&ast.AssignmentStmt{
+				Target: &ast.FieldAccessExpr{Object: &ast.SelfExpr{}, Field: "title"},
+				Value:  &ast.FieldAccessExpr{Object: &ast.IdentifierExpr{Name: "tag"}, Field: field},
+			}},
+		}
+	}
+	tags := &ast.RelationshipNode{Name: "tags", Type: "Tag", Kind: ast.RelationshipHasManyThrough, Through: "post_tags"}
+
+	for _, event := range []string{ast.HookEventAttach, ast.HookEventDetach} {
+		if errors := check(hook(event, "tags", "name"), tags); len(errors) != 0 {
+			t.Errorf("Expected no errors for an %s hook, got: %v", event, errors)
+		}
+	}
+
+	// The record is typed as a Tag
+	if errors := check(hook(ast.HookEventAttach, "tags", "uses"), tags); len(errors) != 1 || errors[0].Type != "type_mismatch" {
+		t.Errorf("Expected one type_mismatch error for an int assigned to a string, got: %v", errors)
+	}
+
+	tests := []struct {
+		name         string
+		relationship string
+		rels         []*ast.RelationshipNode
+	}{
+		{"undeclared", "labels", []*ast.RelationshipNode{tags}},
+		{"not through", "tags", []*ast.RelationshipNode{{Name: "tags", Type: "Tag", Kind: ast.RelationshipHasMany}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(hook(ast.HookEventAttach, tt.relationship, "name"), tt.rels...)
+			found := false
+			for _, err := range errors {
+				found = found || err.Type == "invalid_hook_relationship"
+			}
+			if !found {
+				t.Fatalf("Expected an invalid_hook_relationship error, got: %v", errors)
+			}
+		})
+	}
+
+	// Self-referential links need a foreign_key to tell the columns apart
+	related := &ast.RelationshipNode{Name: "related", Type: "Post", Kind: ast.RelationshipHasManyThrough, Through: "related_posts"}
+	errors := check(hook(ast.HookEventAttach, "tags", "name"), tags, related)
+	if len(errors) != 1 || errors[0].Type != "ambiguous_join_columns" {
+		t.Fatalf("Expected one ambiguous_join_columns error, got: %v", errors)
+	}
+	related.ForeignKey = "source_id"
+	if errors := check(hook(ast.HookEventAttach, "tags", "name"), tags, related); len(errors) != 0 {
+		t.Errorf("Expected no errors with a foreign_key, got: %v", errors)
+	}
+}
+
+// TestBatchHookValidation tests the events batch hooks can run on and the
+// records their bodies see
+func TestBatchHookValidation(t *testing.T) {
+	check := func(event string, typ *ast.TypeNode) []*TypeError {
+		post := &ast.ResourceNode{
+			Name:   "Post",
+			Fields: []*ast.FieldNode{{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}}},
+			Hooks: []*ast.HookNode{{
+				Timing: "before",
+				Event:  event,
+				Batch:  true,
+				Body:   []ast.StmtNode{&ast.LetStmt{Name: "created", Type: typ, Value: &ast.IdentifierExpr{Name: "records"}}},
+			}},
+		}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{post}})
+	}
+
+	if errors := check("create", nil); len(errors) != 0 {
+		t.Errorf("Expected no errors for a batch create hook, got: %v", errors)
+	}
+
+	// The records are typed as an array of Posts
+	if errors := check("create", &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int"}); len(errors) != 1 || errors[0].Type != "type_mismatch" {
+		t.Errorf("Expected one type_mismatch error for an array assigned to an int, got: %v", errors)
+	}
+
+	for _, event := range []string{"update", "delete", "save"} {
+		errors := check(event, nil)
+		if len(errors) != 1 || errors[0].Type != "invalid_batch_hook" {
+			t.Errorf("Expected one invalid_batch_hook error for a batch %s hook, got: %v", event, errors)
+		}
+	}
+}
+
+// TestConflictValidation tests the fields required by @conflict strategies
+func TestConflictValidation(t *testing.T) {
+	check := func(conflict *ast.ConflictNode, withVersion bool) []*TypeError {
+		fields := []*ast.FieldNode{
+			{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+		}
+		if withVersion {
+			fields = append(fields, &ast.FieldNode{
+				Name:        "updated_at",
+				Type:        &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"},
+				Constraints: []*ast.ConstraintNode{{Name: "auto_update"}},
+			})
+		}
+		conflict.Loc = ast.SourceLocation{Line: 5, Column: 3}
+		resource := &ast.ResourceNode{Name: "Post", Fields: fields, Conflict: conflict}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+
+	valid := []*ast.ConflictNode{
+		{Strategy: ast.ConflictReject},
+		{Strategy: ast.ConflictMerge, MergeFields: []string{"title"}},
+	}
+	for _, conflict := range valid {
+		if errors := check(conflict, true); len(errors) != 0 {
+			t.Errorf("%s: expected no errors, got: %v", conflict.Strategy, errors)
+		}
+	}
+	if errors := check(&ast.ConflictNode{Strategy: ast.ConflictLastWriteWins}, false); len(errors) != 0 {
+		t.Errorf("last_write_wins should not need a version field, got: %v", errors)
+	}
+
+	tests := []struct {
+		name        string
+		conflict    *ast.ConflictNode
+		withVersion bool
+		wantCode    ErrorCode
+	}{
+		{"reject without version", &ast.ConflictNode{Strategy: ast.ConflictReject}, false, ErrMissingAnnotationField},
+		{"merge without version", &ast.ConflictNode{Strategy: ast.ConflictMerge, MergeFields: []string{"title"}}, false, ErrMissingAnnotationField},
+		{"unknown merge field", &ast.ConflictNode{Strategy: ast.ConflictMerge, MergeFields: []string{"summary"}}, true, ErrUndefinedField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.conflict, tt.withVersion)
+			if len(errors) != 1 || errors[0].Code != tt.wantCode {
+				t.Fatalf("Expected one %s error, got: %v", tt.wantCode, errors)
+			}
+			if errors[0].Location.Line != 5 {
+				t.Errorf("Expected error at the annotation, got line %d", errors[0].Location.Line)
+			}
+		})
+	}
+}
+
+// TestPartitionValidation tests the partition key required by @partition
+func TestPartitionValidation(t *testing.T) {
+	timestamp := &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"}
+	check := func(field string, extra ...*ast.FieldNode) []*TypeError {
+		fields := append([]*ast.FieldNode{
+			{Name: "created_at", Type: timestamp, Constraints: []*ast.ConstraintNode{{Name: "auto"}}},
+			{Name: "seen_at", Type: timestamp, Nullable: true},
+			{Name: "name", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+		}, extra...)
+		resource := &ast.ResourceNode{
+			Name:      "Event",
+			Fields:    fields,
+			Partition: &ast.PartitionNode{Field: field, Interval: ast.PartitionMonth, Loc: ast.SourceLocation{Line: 5, Column: 3}},
+		}
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+
+	if errors := check("created_at"); len(errors) != 0 {
+		t.Errorf("Expected no errors, got: %v", errors)
+	}
+
+	// Foreign keys cannot reference a partitioned table
+	event := &ast.ResourceNode{
+		Name:      "Event",
+		Partition: &ast.PartitionNode{Field: "created_at", Interval: ast.PartitionDay},
+		Fields:    []*ast.FieldNode{{Name: "created_at", Type: timestamp}},
+	}
+	alert := &ast.ResourceNode{
+		Name:          "Alert",
+		Relationships: []*ast.RelationshipNode{{Name: "event", Type: "Event", Kind: ast.RelationshipBelongsTo, Loc: ast.SourceLocation{Line: 7}}},
+	}
+	errors := NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{event, alert}})
+	if len(errors) != 1 || errors[0].Type != "partitioned_reference" || errors[0].Location.Line != 7 {
+		t.Errorf("Expected one partitioned_reference error on the relationship, got: %v", errors)
+	}
+
+	tests := []struct {
+		name     string
+		field    string
+		extra    []*ast.FieldNode
+		wantCode ErrorCode
+		wantLine int
+	}{
+		{"unknown field", "occurred_at", nil, ErrUndefinedField, 5},
+		{"nullable timestamp", "seen_at", nil, ErrMissingAnnotationField, 5},
+		{"not a timestamp", "name", nil, ErrMissingAnnotationField, 5},
+		{"unique field", "created_at", []*ast.FieldNode{{
+			Name:        "slug",
+			Type:        &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+			Constraints: []*ast.ConstraintNode{{Name: "unique"}},
+			Loc:         ast.SourceLocation{Line: 3, Column: 3},
+		}}, ErrInvalidConstraintType, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.field, tt.extra...)
+			if len(errors) != 1 || errors[0].Code != tt.wantCode {
+				t.Fatalf("Expected one %s error, got: %v", tt.wantCode, errors)
+			}
+			if errors[0].Location.Line != tt.wantLine {
+				t.Errorf("Expected error on line %d, got line %d", tt.wantLine, errors[0].Location.Line)
+			}
+		})
+	}
+}
+
+// TestMaterializedValidation tests the query and read-only rules of @materialized
+func TestMaterializedValidation(t *testing.T) {
+	idField := &ast.FieldNode{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}}
+	countField := &ast.FieldNode{Name: "comment_count", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int"}}
+	view := func(query string, fields ...*ast.FieldNode) *ast.ResourceNode {
+		return &ast.ResourceNode{
+			Name:   "PostStats",
+			Fields: fields,
+			Materialized: &ast.MaterializedNode{
+				Query:   query,
+				Refresh: ast.RefreshHourly,
+				Loc:     ast.SourceLocation{Line: 5, Column: 3},
+			},
+		}
+	}
+	check := func(resources ...*ast.ResourceNode) []*TypeError {
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: resources})
+	}
+
+	valid := []string{
+		"SELECT posts.id, count(*) AS comment_count FROM posts GROUP BY posts.id",
+		"with recent AS (SELECT * FROM posts) SELECT id, 0 AS comment_count FROM recent",
+	}
+	for _, query := range valid {
+		if errors := check(view(query, idField, countField)); len(errors) != 0 {
+			t.Errorf("%q: expected no errors, got: %v", query, errors)
+		}
+	}
+
+	withHook := view("SELECT id, 0 AS comment_count FROM posts", idField, countField)
+	withHook.Hooks = []*ast.HookNode{{Timing: "after", Event: "create", Loc: ast.SourceLocation{Line: 8}}}
+	withConflict := view("SELECT id, 0 AS comment_count FROM posts", idField, countField)
+	withConflict.Conflict = &ast.ConflictNode{Strategy: ast.ConflictLastWriteWins, Loc: ast.SourceLocation{Line: 6}}
+
+	tests := []struct {
+		name     string
+		resource *ast.ResourceNode
+		wantType string
+		wantLine int
+	}{
+		{"not a select", view("DELETE FROM posts", idField), "invalid_materialized_query", 5},
+		{"several statements", view("SELECT 1 AS id; DROP TABLE posts", idField), "invalid_materialized_query", 5},
+		{"missing id", view("SELECT count(*) AS comment_count FROM posts", countField), "missing_annotation_field", 5},
+		{"hook", withHook, "read_only_resource", 8},
+		{"conflict policy", withConflict, "read_only_resource", 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.resource)
+			if len(errors) != 1 || errors[0].Type != tt.wantType {
+				t.Fatalf("Expected one %s error, got: %v", tt.wantType, errors)
+			}
+			if errors[0].Location.Line != tt.wantLine {
+				t.Errorf("Expected error on line %d, got line %d", tt.wantLine, errors[0].Location.Line)
+			}
+		})
+	}
+
+	// Foreign keys cannot reference a materialized view
+	report := &ast.ResourceNode{
+		Name:          "Report",
+		Relationships: []*ast.RelationshipNode{{Name: "stats", Type: "PostStats", Kind: ast.RelationshipBelongsTo, Loc: ast.SourceLocation{Line: 3}}},
+	}
+	errors := check(view("SELECT id FROM posts", idField), report)
+	if len(errors) != 1 || errors[0].Type != "materialized_reference" {
+		t.Errorf("Expected one materialized_reference error, got: %v", errors)
+	}
+}
+
+func TestCounterCacheValidation(t *testing.T) {
+	post := func(fields ...*ast.FieldNode) *ast.ResourceNode {
+		return &ast.ResourceNode{Name: "Post", Fields: fields}
+	}
+	comment := func(rels ...*ast.RelationshipNode) *ast.ResourceNode {
+		return &ast.ResourceNode{
+			Name:          "Comment",
+			Fields:        []*ast.FieldNode{{Name: "post_id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}}},
+			Relationships: rels,
+			CounterCaches: []*ast.CounterCacheNode{{
+				Column:       "comments_count",
+				Resource:     "Post",
+				Relationship: "comments",
+				Loc:          ast.SourceLocation{Line: 7, Column: 3},
+			}},
+		}
+	}
+	belongsTo := func(name, foreignKey string) *ast.RelationshipNode {
+		return &ast.RelationshipNode{Name: name, Type: "Post", Kind: ast.RelationshipBelongsTo, ForeignKey: foreignKey}
+	}
+	check := func(resources ...*ast.ResourceNode) []*TypeError {
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: resources})
+	}
+
+	if errors := check(post(), comment(belongsTo("post", "post_id"))); len(errors) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errors)
+	}
+	// The foreign key defaults to the relationship name with _id
+	if errors := check(post(), comment(belongsTo("post", ""))); len(errors) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errors)
+	}
+
+	materializedPost := post()
+	materializedPost.Materialized = &ast.MaterializedNode{Query: "SELECT id FROM posts", Refresh: ast.RefreshDaily}
+	materializedPost.Fields = []*ast.FieldNode{{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}}}
+	wrongCollection := post()
+	wrongCollection.Relationships = []*ast.RelationshipNode{{Name: "comments", Type: "Tag", Kind: ast.RelationshipHasMany}}
+
+	tests := []struct {
+		name      string
+		resources []*ast.ResourceNode
+		wantType  string
+	}{
+		{"undefined parent", []*ast.ResourceNode{comment()}, "undefined_resource"},
+		{"no relationship", []*ast.ResourceNode{post(), comment()}, "invalid_counter_cache"},
+		{"ambiguous relationship", []*ast.ResourceNode{post(), comment(belongsTo("post", "post_id"), belongsTo("reply_to", "post_id"))}, "invalid_counter_cache"},
+		{"missing foreign key field", []*ast.ResourceNode{post(), comment(belongsTo("article", ""))}, "missing_annotation_field"},
+		{"column already declared", []*ast.ResourceNode{post(&ast.FieldNode{Name: "comments_count", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int"}}), comment(belongsTo("post", "post_id"))}, "invalid_counter_cache"},
+		{"collection of another resource", []*ast.ResourceNode{wrongCollection, {Name: "Tag"}, comment(belongsTo("post", "post_id"))}, "invalid_counter_cache"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.resources...)
+			if len(errors) != 1 || errors[0].Type != tt.wantType {
+				t.Fatalf("Expected one %s error, got: %v", tt.wantType, errors)
+			}
+			if errors[0].Location.Line != 7 {
+				t.Errorf("Expected error on line 7, got line %d", errors[0].Location.Line)
+			}
+		})
+	}
+
+	// Read-only views can't be counted or counted on
+	errors := check(materializedPost, comment(belongsTo("post", "post_id")))
+	found := false
+	for _, err := range errors {
+		found = found || err.Type == "invalid_counter_cache"
+	}
+	if !found {
+		t.Errorf("Expected an invalid_counter_cache error for a materialized parent, got: %v", errors)
+	}
+}
+
+func TestSearchIndexValidation(t *testing.T) {
+	post := func(fields ...string) *ast.ResourceNode {
+		return &ast.ResourceNode{
+			Name: "Post",
+			Fields: []*ast.FieldNode{
+				{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+				{Name: "tags", Type: &ast.TypeNode{Kind: ast.TypeArray, ElementType: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}}},
+				{Name: "settings", Type: &ast.TypeNode{Kind: ast.TypeHash, KeyType: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}, ValueType: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}}},
+			},
+			SearchIndex: &ast.SearchIndexNode{Fields: fields, Loc: ast.SourceLocation{Line: 9, Column: 3}},
+		}
+	}
+	check := func(resource *ast.ResourceNode) []*TypeError {
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+
+	if errors := check(post("title", "tags")); len(errors) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errors)
+	}
+
+	view := post("title")
+	view.Fields = append(view.Fields, &ast.FieldNode{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}})
+	view.Materialized = &ast.MaterializedNode{Query: "SELECT id, title FROM posts", Refresh: ast.RefreshDaily}
+
+	tests := []struct {
+		name     string
+		resource *ast.ResourceNode
+		wantType string
+	}{
+		{"undefined field", post("summary"), "undefined_field"},
+		{"hash field", post("settings"), "invalid_search_index"},
+		{"listed twice", post("title", "title"), "invalid_search_index"},
+		{"materialized view", view, "read_only_resource"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.resource)
+			if len(errors) != 1 || errors[0].Type != tt.wantType {
+				t.Fatalf("Expected one %s error, got: %v", tt.wantType, errors)
+			}
+			if errors[0].Location.Line != 9 {
+				t.Errorf("Expected error on line 9, got line %d", errors[0].Location.Line)
+			}
+		})
+	}
+}
+
+func TestIndexValidation(t *testing.T) {
+	user := &ast.ResourceNode{
+		Name:   "User",
+		Fields: []*ast.FieldNode{{Name: "name", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}}},
+	}
+	post := func(indexes ...*ast.IndexNode) *ast.ResourceNode {
+		return &ast.ResourceNode{
+			Name: "Post",
+			Fields: []*ast.FieldNode{
+				{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+					Constraints: []*ast.ConstraintNode{{Name: ast.IndexConstraint}}},
+				{Name: "author_id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}},
+				{Name: "published_at", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"}},
+				{Name: "settings", Type: &ast.TypeNode{Kind: ast.TypeHash, KeyType: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}, ValueType: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}}},
+			},
+			Relationships: []*ast.RelationshipNode{
+				{Name: "author", Type: "User", Kind: ast.RelationshipBelongsTo, ForeignKey: "author_id", OnDelete: "restrict"},
+			},
+			Indexes: indexes,
+		}
+	}
+	index := func(unique bool, fields ...string) *ast.IndexNode {
+		return &ast.IndexNode{Fields: fields, Unique: unique, Loc: ast.SourceLocation{Line: 9, Column: 3}}
+	}
+	check := func(resource *ast.ResourceNode) []*TypeError {
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{user, resource}})
+	}
+
+	if errors := check(post(index(false, "author", "published_at"), index(true, "title", "published_at"))); len(errors) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errors)
+	}
+
+	withArgs := post()
+	withArgs.Fields[0].Constraints[0].Arguments = []ast.ExprNode{&ast.LiteralExpr{Value: "title_idx"}}
+
+	external := post(index(false, "published_at"))
+	external.Fields[0].Constraints = nil
+	external.Fields = append(external.Fields, &ast.FieldNode{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int"},
+		Constraints: []*ast.ConstraintNode{{Name: "primary"}}})
+	external.External = &ast.ExternalTableNode{Table: "legacy_posts"}
+
+	tests := []struct {
+		name     string
+		resource *ast.ResourceNode
+		wantType string
+	}{
+		{"undefined field", post(index(false, "summary")), "undefined_field"},
+		{"hash field", post(index(false, "settings")), "invalid_index"},
+		{"listed twice", post(index(false, "published_at", "published_at")), "invalid_index"},
+		{"same columns as @index", post(index(false, "title")), "invalid_index"},
+		{"same columns via relationship", post(index(false, "author_id"), index(true, "author")), "invalid_index"},
+		{"@index arguments", withArgs, "invalid_argument_count"},
+		{"external table", external, "invalid_external_table"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.resource)
+			if len(errors) != 1 || errors[0].Type != tt.wantType {
+				t.Fatalf("Expected one %s error, got: %v", tt.wantType, errors)
+			}
+		})
+	}
+}
+
+func TestWebhookValidation(t *testing.T) {
+	stripeEvent := func(provider string) *ast.ResourceNode {
+		return &ast.ResourceNode{
+			Name: "StripeEvent",
+			Fields: []*ast.FieldNode{
+				{Name: "event_id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+					Constraints: []*ast.ConstraintNode{{Name: "unique"}}},
+				{Name: "event_type", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+				{Name: "payload", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "json"}},
+			},
+			Webhook: &ast.WebhookNode{Provider: provider, Loc: ast.SourceLocation{Line: 9, Column: 3}},
+		}
+	}
+	check := func(resource *ast.ResourceNode) []*TypeError {
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+
+	if errors := check(stripeEvent("stripe")); len(errors) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errors)
+	}
+
+	notUnique := stripeEvent("stripe")
+	notUnique.Fields[0].Constraints = nil
+
+	nullablePayload := stripeEvent("stripe")
+	nullablePayload.Fields[2].Nullable = true
+
+	textPayload := stripeEvent("stripe")
+	textPayload.Fields[2].Type = &ast.TypeNode{Kind: ast.TypePrimitive, Name: "text"}
+
+	missingType := stripeEvent("stripe")
+	missingType.Fields = missingType.Fields[:1:1]
+	missingType.Fields = append(missingType.Fields, stripeEvent("stripe").Fields[2])
+
+	view := stripeEvent("stripe")
+	view.Fields = append(view.Fields, &ast.FieldNode{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}})
+	view.Materialized = &ast.MaterializedNode{Query: "SELECT * FROM events", Refresh: ast.RefreshDaily}
+
+	tests := []struct {
+		name     string
+		resource *ast.ResourceNode
+		wantType string
+	}{
+		{"unknown provider", stripeEvent("paypal"), "invalid_webhook"},
+		{"event_id not unique", notUnique, "missing_annotation_field"},
+		{"nullable payload", nullablePayload, "missing_annotation_field"},
+		{"text payload", textPayload, "missing_annotation_field"},
+		{"missing event_type", missingType, "missing_annotation_field"},
+		{"materialized view", view, "read_only_resource"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.resource)
+			if len(errors) != 1 || errors[0].Type != tt.wantType {
+				t.Fatalf("Expected one %s error, got: %v", tt.wantType, errors)
+			}
+			if errors[0].Location.Line != 9 {
+				t.Errorf("Expected error on line 9, got line %d", errors[0].Location.Line)
+			}
+		})
+	}
+}
+
+func TestSignedRequestValidation(t *testing.T) {
+	tests := []struct {
+		middleware string
+		wantError  bool
+	}{
+		{"signed_request(PARTNER_SECRET)", false},
+		{`signed_request("PARTNER_SECRET")`, false},
+		{"signed_request", true},
+		{"signed_request()", true},
+		{"signed_request(partner_secret)", true},
+		{"signed_request(PARTNER_SECRET, OTHER_SECRET)", true},
+		{`signed_request("whsec_123")`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.middleware, func(t *testing.T) {
+			resource := &ast.ResourceNode{
+				Name: "PartnerEvent",
+				Fields: []*ast.FieldNode{
+					{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"},
+						Constraints: []*ast.ConstraintNode{{Name: "primary"}, {Name: "auto"}}},
+				},
+				Middleware: []string{"auth", tt.middleware},
+				Loc:        ast.SourceLocation{Line: 4, Column: 1},
+			}
+			errors := NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+			if !tt.wantError {
+				if len(errors) != 0 {
+					t.Fatalf("Expected no errors, got: %v", errors)
+				}
+				return
+			}
+			if len(errors) != 1 || errors[0].Type != "invalid_signed_request" {
+				t.Fatalf("Expected one invalid_signed_request error, got: %v", errors)
+			}
+		})
+	}
+}
+
+func TestProfileValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		profiles  []*ast.ProfileNode
+		wantType  string
+		wantError bool
+	}{
+		{
+			name: "public and admin",
+			profiles: []*ast.ProfileNode{
+				{Name: "public", Fields: []string{"id", "title"}},
+				{Name: "admin", All: true},
+			},
+		},
+		{
+			name:     "missing public",
+			profiles: []*ast.ProfileNode{{Name: "admin", All: true}},
+			wantType: "invalid_profile", wantError: true,
+		},
+		{
+			name:     "unknown field",
+			profiles: []*ast.ProfileNode{{Name: "public", Fields: []string{"id", "secret"}}},
+			wantType: "undefined_field", wantError: true,
+		},
+		{
+			name:     "repeated field",
+			profiles: []*ast.ProfileNode{{Name: "public", Fields: []string{"title", "title"}}},
+			wantType: "invalid_profile", wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := &ast.ResourceNode{
+				Name: "Post",
+				Fields: []*ast.FieldNode{
+					{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"},
+						Constraints: []*ast.ConstraintNode{{Name: "primary"}, {Name: "auto"}}},
+					{Name: "title", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+				},
+				Profiles: tt.profiles,
+				Loc:      ast.SourceLocation{Line: 1, Column: 1},
+			}
+			errors := NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+			if !tt.wantError {
+				if len(errors) != 0 {
+					t.Fatalf("Expected no errors, got: %v", errors)
+				}
+				return
+			}
+			if len(errors) != 1 || errors[0].Type != tt.wantType {
+				t.Fatalf("Expected one %s error, got: %v", tt.wantType, errors)
+			}
+		})
+	}
+}
+
+func TestUpsertValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    []string
+		conflict  *ast.ConflictNode
+		wantType  string
+		wantError bool
+	}{
+		{name: "unique field", fields: []string{"email"}},
+		{name: "primary key", fields: []string{"id"}},
+		{name: "not unique", fields: []string{"name"}, wantType: "invalid_upsert", wantError: true},
+		{name: "several fields", fields: []string{"email", "name"}, wantType: "invalid_upsert", wantError: true},
+		{name: "unknown field", fields: []string{"phone"}, wantType: "undefined_field", wantError: true},
+		{
+			name:     "with conflict policy",
+			fields:   []string{"email"},
+			conflict: &ast.ConflictNode{Strategy: ast.ConflictLastWriteWins},
+			wantType: "invalid_upsert", wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := &ast.ResourceNode{
+				Name: "User",
+				Fields: []*ast.FieldNode{
+					{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"},
+						Constraints: []*ast.ConstraintNode{{Name: "primary"}, {Name: "auto"}}},
+					{Name: "email", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+						Constraints: []*ast.ConstraintNode{{Name: "unique"}}},
+					{Name: "name", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+				},
+				Conflict: tt.conflict,
+				Upsert:   &ast.UpsertNode{Fields: tt.fields},
+				Loc:      ast.SourceLocation{Line: 1, Column: 1},
+			}
+			errors := NewTypeChecker().CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+			if !tt.wantError {
+				if len(errors) != 0 {
+					t.Fatalf("Expected no errors, got: %v", errors)
+				}
+				return
+			}
+			if len(errors) != 1 || errors[0].Type != tt.wantType {
+				t.Fatalf("Expected one %s error, got: %v", tt.wantType, errors)
+			}
+		})
+	}
+}
+
+func TestLoginResourceValidation(t *testing.T) {
+	user := func() *ast.ResourceNode {
+		return &ast.ResourceNode{
+			Name: "User",
+			Loc:  ast.SourceLocation{Line: 1, Column: 1},
+			Fields: []*ast.FieldNode{
+				{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"},
+					Constraints: []*ast.ConstraintNode{{Name: "primary"}, {Name: "auto"}}},
+				{Name: "email", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+					Constraints: []*ast.ConstraintNode{{Name: "unique"}}},
+				{Name: "name", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+				{Name: "role", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+					Constraints: []*ast.ConstraintNode{{Name: "default", Arguments: []ast.ExprNode{&ast.LiteralExpr{Value: "member"}}}}},
+				{Name: "bio", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "text"}, Nullable: true},
+				{Name: "created_at", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"},
+					Constraints: []*ast.ConstraintNode{{Name: "auto"}}},
+			},
+		}
+	}
+	check := func(name string, resource *ast.ResourceNode) []*TypeError {
+		tc := NewTypeChecker()
+		tc.SetLoginResource(name)
+		return tc.CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{resource}})
+	}
+
+	if errors := check("User", user()); len(errors) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errors)
+	}
+
+	notUnique := user()
+	notUnique.Fields[1].Constraints = nil
+
+	nullableEmail := user()
+	nullableEmail.Fields[1].Nullable = true
+
+	intName := user()
+	intName.Fields[2].Type = &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int"}
+
+	requiredField := user()
+	requiredField.Fields = append(requiredField.Fields, &ast.FieldNode{
+		Name: "password_hash", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}})
+
+	tests := []struct {
+		name     string
+		resource string
+		user     *ast.ResourceNode
+		wantType string
+		contains string
+	}{
+		{"undefined resource", "Account", user(), "undefined_resource", "Account"},
+		{"email not unique", "User", notUnique, "invalid_login_resource", "@unique email"},
+		{"nullable email", "User", nullableEmail, "invalid_login_resource", "@unique email"},
+		{"name not a string", "User", intName, "invalid_login_resource", "must be a string"},
+		{"required field", "User", requiredField, "invalid_login_resource", "password_hash is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.resource, tt.user)
+			if len(errors) != 1 || errors[0].Type != tt.wantType {
+				t.Fatalf("Expected one %s error, got: %v", tt.wantType, errors)
+			}
+			if !strings.Contains(errors[0].Message, tt.contains) {
+				t.Errorf("Expected message containing %q, got %q", tt.contains, errors[0].Message)
+			}
+		})
+	}
+}
+
 // TestStdlibFunctionCall tests standard library function type checking
 func TestStdlibFunctionCall(t *testing.T) {
 	tc := NewTypeChecker()
@@ -725,6 +2378,157 @@ func TestInvalidFunctionCall(t *testing.T) {
 	}
 }
 
+func TestMailSend(t *testing.T) {
+	self := func(field string) ast.ExprNode {
+		return &ast.FieldAccessExpr{Object: &ast.SelfExpr{}, Field: field}
+	}
+	send := func(args ...ast.ExprNode) *ast.CallExpr {
+		return &ast.CallExpr{Namespace: "Mail", Function: "send", Arguments: args, Loc: ast.SourceLocation{Line: 7, Column: 5}}
+	}
+	welcome := &ast.LiteralExpr{Value: "welcome"}
+	vars := &ast.HashLiteralExpr{Pairs: []ast.HashPair{
+		{Key: &ast.IdentifierExpr{Name: "name"}, Value: self("name")},
+		{Key: &ast.LiteralExpr{Value: "age"}, Value: self("age")},
+	}}
+	check := func(hook *ast.HookNode, templates ...string) []*TypeError {
+		user := &ast.ResourceNode{
+			Name: "User",
+			Fields: []*ast.FieldNode{
+				{Name: "name", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+				{Name: "age", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int"}},
+				{Name: "email", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "email"}},
+				{Name: "backup_email", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "email", Nullable: true}, Nullable: true},
+			},
+			Hooks: []*ast.HookNode{hook},
+		}
+		tc := NewTypeChecker()
+		tc.SetMailTemplates(append([]string{"welcome"}, templates...))
+		return tc.CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{user}})
+	}
+	asyncBlock := func(call *ast.CallExpr) *ast.HookNode {
+		return &ast.HookNode{Timing: "after", Event: "create", Body: []ast.StmtNode{
+			&ast.BlockStmt{IsAsync: true, Statements: []ast.StmtNode{&ast.ExprStmt{Expr: call}}},
+		}}
+	}
+
+	valid := []struct {
+		name string
+		hook *ast.HookNode
+	}{
+		{"async block", asyncBlock(send(welcome, self("email"), vars))},
+		{"without vars", asyncBlock(send(welcome, self("email")))},
+		{"async hook", &ast.HookNode{Timing: "after", Event: "update", IsAsync: true, Body: []ast.StmtNode{
+			&ast.ExprStmt{Expr: send(welcome, &ast.LiteralExpr{Value: "ops@example.com"})},
+		}}},
+	}
+	for _, tt := range valid {
+		t.Run(tt.name, func(t *testing.T) {
+			if errors := check(tt.hook); len(errors) != 0 {
+				t.Errorf("Expected no errors, got: %v", errors)
+			}
+		})
+	}
+
+	invalid := []struct {
+		name     string
+		hook     *ast.HookNode
+		wantType string
+	}{
+		{"not async", &ast.HookNode{Timing: "after", Event: "create", Body: []ast.StmtNode{
+			&ast.ExprStmt{Expr: send(welcome, self("email"))},
+		}}, "mail_outside_async"},
+		{"unknown template", asyncBlock(send(&ast.LiteralExpr{Value: "goodbye"}, self("email"))), "undefined_mail_template"},
+		{"computed template", asyncBlock(send(self("name"), self("email"))), "invalid_mail_template"},
+		{"nullable recipient", asyncBlock(send(welcome, self("backup_email"))), "invalid_argument_type"},
+		{"non-string recipient", asyncBlock(send(welcome, self("age"))), "invalid_argument_type"},
+		{"vars not a hash", asyncBlock(send(welcome, self("email"), self("name"))), "invalid_argument_type"},
+		{"missing recipient", asyncBlock(send(welcome)), "invalid_argument_count"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.hook)
+			if len(errors) != 1 || errors[0].Type != tt.wantType {
+				t.Fatalf("Expected one %s error, got: %v", tt.wantType, errors)
+			}
+			if errors[0].Location.Line != 7 {
+				t.Errorf("Expected error on line 7, got line %d", errors[0].Location.Line)
+			}
+		})
+	}
+
+	// Without a template directory, names are not checked
+	tc := NewTypeChecker()
+	user := &ast.ResourceNode{
+		Name:   "User",
+		Fields: []*ast.FieldNode{{Name: "email", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "email"}}},
+		Hooks:  []*ast.HookNode{asyncBlock(send(&ast.LiteralExpr{Value: "anything"}, self("email")))},
+	}
+	if errors := tc.CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{user}}); len(errors) != 0 {
+		t.Errorf("Expected no errors without SetMailTemplates, got: %v", errors)
+	}
+}
+
+func TestNotifySend(t *testing.T) {
+	self := func(field string) ast.ExprNode {
+		return &ast.FieldAccessExpr{Object: &ast.SelfExpr{}, Field: field}
+	}
+	send := func(args ...ast.ExprNode) *ast.CallExpr {
+		return &ast.CallExpr{Namespace: "Notify", Function: "send", Arguments: args, Loc: ast.SourceLocation{Line: 9, Column: 3}}
+	}
+	sms := &ast.LiteralExpr{Value: "sms"}
+	payload := &ast.HashLiteralExpr{Pairs: []ast.HashPair{
+		{Key: &ast.IdentifierExpr{Name: "body"}, Value: &ast.LiteralExpr{Value: "Your order shipped"}},
+		{Key: &ast.LiteralExpr{Value: "order_id"}, Value: self("id")},
+	}}
+	check := func(call *ast.CallExpr) []*TypeError {
+		order := &ast.ResourceNode{
+			Name: "Order",
+			Fields: []*ast.FieldNode{
+				{Name: "id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}},
+				{Name: "phone", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "phone"}},
+				{Name: "device_token", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string", Nullable: true}, Nullable: true},
+				{Name: "total", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "int"}},
+			},
+			// Notifications are queued, so they need not be @async
+			Hooks: []*ast.HookNode{{Timing: "after", Event: "update", Body: []ast.StmtNode{&ast.ExprStmt{Expr: call}}}},
+		}
+		tc := NewTypeChecker()
+		tc.SetNotifyChannels([]string{"sms", "push"})
+		return tc.CheckProgram(&ast.Program{Resources: []*ast.ResourceNode{order}})
+	}
+
+	if errors := check(send(sms, self("phone"), payload)); len(errors) != 0 {
+		t.Errorf("Expected no errors, got: %v", errors)
+	}
+
+	invalid := []struct {
+		name     string
+		call     *ast.CallExpr
+		wantType string
+	}{
+		{"unknown channel", send(&ast.LiteralExpr{Value: "fax"}, self("phone"), payload), "undefined_notify_channel"},
+		{"computed channel", send(self("phone"), self("phone"), payload), "invalid_notify_channel"},
+		{"nullable target", send(&ast.LiteralExpr{Value: "push"}, self("device_token"), payload), "invalid_argument_type"},
+		{"non-string target", send(sms, self("total"), payload), "invalid_argument_type"},
+		{"payload not a hash", send(sms, self("phone"), self("phone")), "invalid_argument_type"},
+		{"computed payload key", send(sms, self("phone"), &ast.HashLiteralExpr{Pairs: []ast.HashPair{
+			{Key: self("phone"), Value: &ast.LiteralExpr{Value: "x"}},
+		}}), "invalid_notify_payload"},
+		{"missing payload", send(sms, self("phone")), "invalid_argument_count"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.call)
+			if len(errors) != 1 || errors[0].Type != tt.wantType {
+				t.Fatalf("Expected one %s error, got: %v", tt.wantType, errors)
+			}
+			if errors[0].Location.Line != 9 {
+				t.Errorf("Expected error on line 9, got line %d", errors[0].Location.Line)
+			}
+		})
+	}
+}
+
 // TestTypeString tests the String() method for types
 func TestTypeString(t *testing.T) {
 	tests := []struct {
@@ -768,3 +2572,96 @@ func TestTypeString(t *testing.T) {
 		})
 	}
 }
+
+func TestShardValidation(t *testing.T) {
+	uuidType := &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}
+	sharded := func(name, field string, rels ...*ast.RelationshipNode) *ast.ResourceNode {
+		resource := &ast.ResourceNode{
+			Name: name,
+			Fields: []*ast.FieldNode{
+				{Name: "tenant_id", Type: uuidType},
+				{Name: "region", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}},
+				{Name: "archived_at", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"}},
+				{Name: "parent_id", Type: uuidType, Nullable: true},
+			},
+			Relationships: rels,
+		}
+		if field != "" {
+			resource.Shard = &ast.ShardNode{Field: field, Loc: ast.SourceLocation{Line: 3}}
+		}
+		return resource
+	}
+	belongsTo := func(target string) *ast.RelationshipNode {
+		return &ast.RelationshipNode{Name: strings.ToLower(target), Type: target, Kind: ast.RelationshipBelongsTo, Loc: ast.SourceLocation{Line: 7}}
+	}
+	check := func(resources ...*ast.ResourceNode) []*TypeError {
+		return NewTypeChecker().CheckProgram(&ast.Program{Resources: resources})
+	}
+
+	// Resources sharded by the same field may reference each other
+	if errors := check(sharded("Account", "tenant_id"), sharded("Invoice", "tenant_id", belongsTo("Account"))); len(errors) != 0 {
+		t.Errorf("Expected no errors for co-located resources, got: %v", errors)
+	}
+
+	tests := []struct {
+		name      string
+		resources []*ast.ResourceNode
+		wantType  string
+		wantLine  int
+	}{
+		{"undefined key", []*ast.ResourceNode{sharded("Invoice", "org_id")}, "undefined_field", 3},
+		{"optional key", []*ast.ResourceNode{sharded("Invoice", "parent_id")}, "missing_annotation_field", 3},
+		{"timestamp key", []*ast.ResourceNode{sharded("Invoice", "archived_at")}, "missing_annotation_field", 3},
+		{"sharded to unsharded", []*ast.ResourceNode{sharded("Account", ""), sharded("Invoice", "tenant_id", belongsTo("Account"))}, "cross_shard_relationship", 7},
+		{"unsharded to sharded", []*ast.ResourceNode{sharded("Account", "tenant_id"), sharded("Invoice", "", belongsTo("Account"))}, "cross_shard_relationship", 7},
+		{"different keys", []*ast.ResourceNode{sharded("Account", "region"), sharded("Invoice", "tenant_id", belongsTo("Account"))}, "cross_shard_relationship", 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := check(tt.resources...)
+			if len(errors) != 1 || errors[0].Type != tt.wantType || errors[0].Location.Line != tt.wantLine {
+				t.Errorf("Expected one %s error on line %d, got: %v", tt.wantType, tt.wantLine, errors)
+			}
+		})
+	}
+
+	partitioned := sharded("Event", "tenant_id")
+	partitioned.Partition = &ast.PartitionNode{Field: "archived_at", Interval: ast.PartitionMonth, Loc: ast.SourceLocation{Line: 4}}
+	partitioned.Fields[2].Constraints = []*ast.ConstraintNode{{Name: "auto"}}
+	errors := check(partitioned)
+	if len(errors) != 1 || errors[0].Type != "invalid_shard" || !strings.Contains(errors[0].Message, "@partition") {
+		t.Errorf("Expected @shard to reject @partition, got: %v", errors)
+	}
+}
+
+func TestLabelsValidation(t *testing.T) {
+	labeled := func(fieldType *ast.TypeNode, labels map[string]string) *ast.Program {
+		return &ast.Program{Resources: []*ast.ResourceNode{{
+			Name: "Post",
+			Fields: []*ast.FieldNode{{
+				Name:   "status",
+				Type:   fieldType,
+				Labels: &ast.LabelsNode{Values: labels, Loc: ast.SourceLocation{Line: 2}},
+			}},
+		}}}
+	}
+	enumType := &ast.TypeNode{Kind: ast.TypeEnum, Name: "enum", EnumValues: []string{"draft", "published"}}
+
+	// Labels may leave values out; those are shown as themselves
+	if errors := NewTypeChecker().CheckProgram(labeled(enumType, map[string]string{"draft": "Draft"})); len(errors) != 0 {
+		t.Errorf("Expected no errors, got: %v", errors)
+	}
+
+	errors := NewTypeChecker().CheckProgram(labeled(enumType, map[string]string{"draft": "Draft", "archived": "Archived"}))
+	if len(errors) != 1 || errors[0].Type != "unknown_enum_value" || errors[0].Location.Line != 2 {
+		t.Fatalf("Expected an unknown_enum_value error on line 2, got: %v", errors)
+	}
+	if !strings.Contains(errors[0].Suggestion, "draft, published") {
+		t.Errorf("Suggestion = %q, want the enum values", errors[0].Suggestion)
+	}
+
+	errors = NewTypeChecker().CheckProgram(labeled(&ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"}, map[string]string{"draft": "Draft"}))
+	if len(errors) != 1 || errors[0].Type != "invalid_constraint_type" {
+		t.Errorf("Expected an invalid_constraint_type error for @labels on a string, got: %v", errors)
+	}
+}
@@ -32,11 +32,15 @@ const (
 	ErrInvalidArgumentCount ErrorCode = "TYP301"
 	// ErrInvalidArgumentType indicates wrong type of argument in a function call.
 	ErrInvalidArgumentType ErrorCode = "TYP302"
+	// ErrInvalidCallContext indicates a function was called where it is not allowed.
+	ErrInvalidCallContext ErrorCode = "TYP303"
 
 	// ErrInvalidConstraintType indicates a constraint was applied to an incompatible type.
 	ErrInvalidConstraintType ErrorCode = "TYP400"
 	// ErrConstraintTypeMismatch indicates a constraint argument has the wrong type.
 	ErrConstraintTypeMismatch ErrorCode = "TYP401"
+	// ErrMissingAnnotationField indicates a resource annotation requires a field the resource does not declare.
+	ErrMissingAnnotationField ErrorCode = "TYP402"
 
 	// ErrInvalidBinaryOp indicates an invalid binary operation between types.
 	ErrInvalidBinaryOp ErrorCode = "TYP500"
@@ -313,6 +317,19 @@ func NewConstraintTypeMismatch(loc ast.SourceLocation, constraint string, expect
 	}
 }
 
+// NewMissingAnnotationField creates a TYP402 error
+func NewMissingAnnotationField(loc ast.SourceLocation, annotation, requirement, example string) *TypeError {
+	return &TypeError{
+		Code:       ErrMissingAnnotationField,
+		Type:       "missing_annotation_field",
+		Severity:   SeverityError,
+		Message:    fmt.Sprintf("@%s requires %s", annotation, requirement),
+		Location:   loc,
+		Suggestion: "Declare the field in the resource",
+		Examples:   []string{example},
+	}
+}
+
 // NewInvalidBinaryOp creates a TYP500 error
 func NewInvalidBinaryOp(loc ast.SourceLocation, op string, left, right Type) *TypeError {
 	return &TypeError{
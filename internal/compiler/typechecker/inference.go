@@ -195,6 +195,14 @@ func (tc *TypeChecker) inferCall(call *ast.CallExpr) (Type, error) {
 			tc.errors = append(tc.errors, NewUndefinedFunction(call.Location(), call.Namespace, call.Function))
 			return NewPrimitiveType("unknown", false), nil
 		}
+		switch fn.FullName() {
+		case "Mail.send":
+			tc.checkMailSend(call, fn)
+			return fn.ReturnType, nil
+		case "Notify.send":
+			tc.checkNotifySend(call, fn)
+			return fn.ReturnType, nil
+		}
 
 		// Type check arguments
 		if len(call.Arguments) != len(fn.Parameters) {
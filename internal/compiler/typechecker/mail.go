@@ -0,0 +1,118 @@
+package typechecker
+
+import (
+	"fmt"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// checkMailSend type-checks Mail.send(template, to, vars). The template must
+// be a string literal naming a known template so it can be checked at compile
+// time, the recipient a required string, and vars a hash. Sending waits on the
+// mail provider, so it is only allowed in @async hooks and blocks.
+func (tc *TypeChecker) checkMailSend(call *ast.CallExpr, fn *Function) {
+	loc := call.Location()
+
+	if !tc.inAsync {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidCallContext,
+			Type:       "mail_outside_async",
+			Severity:   SeverityError,
+			Message:    "Mail.send can only be called from @async hooks and blocks",
+			Location:   loc,
+			Suggestion: "Wrap the call in @async { ... } so sending mail does not delay the response",
+		})
+	}
+
+	if len(call.Arguments) < 2 || len(call.Arguments) > 3 {
+		tc.errors = append(tc.errors, NewInvalidArgumentCount(loc, fn.FullName(), len(fn.Parameters), len(call.Arguments)))
+		return
+	}
+
+	// Template: a literal naming a file of the template directory
+	if lit, ok := call.Arguments[0].(*ast.LiteralExpr); !ok || !isStringLiteral(lit) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidArgumentType,
+			Type:       "invalid_mail_template",
+			Severity:   SeverityError,
+			Message:    "Mail.send: the template must be a string literal",
+			Location:   loc,
+			Suggestion: `Name the template directly, e.g. Mail.send("welcome", self.email, {name: self.name})`,
+		})
+	} else if name := lit.Value.(string); tc.mailTemplates != nil && !tc.mailTemplates[name] {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidArgumentType,
+			Type:       "undefined_mail_template",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("Mail.send: template %q does not exist", name),
+			Location:   loc,
+			Suggestion: fmt.Sprintf("Add %s.txt or %s.html to the mail template directory (mail.templates in conduit.yaml)", name, name),
+		})
+	}
+
+	// Recipient: a required string
+	tc.checkTargetArgument(call, fn, 1, "email")
+
+	if len(call.Arguments) < 3 {
+		return
+	}
+
+	// Vars: template variables by name
+	tc.checkHashArgument(call, fn, 2, "invalid_mail_vars", "template variable names")
+}
+
+// checkTargetArgument checks that argument index of call is a required
+// string, or a required value of the string-based type named typeName
+func (tc *TypeChecker) checkTargetArgument(call *ast.CallExpr, fn *Function, index int, typeName string) {
+	targetType, err := tc.inferExpr(call.Arguments[index])
+	if err != nil {
+		return
+	}
+	expected := fn.Parameters[index].Type
+	prim, ok := targetType.(*PrimitiveType)
+	if !ok || prim.Nullable || prim.Name != typeName && !expected.IsAssignableFrom(prim) {
+		tc.errors = append(tc.errors, NewInvalidArgumentType(call.Location(), fn.FullName(), index, expected, targetType))
+	}
+}
+
+// checkHashArgument checks that argument index of call is a hash literal
+// with named keys, whose values may have different types, or a required hash
+func (tc *TypeChecker) checkHashArgument(call *ast.CallExpr, fn *Function, index int, errType, keys string) {
+	if hash, ok := call.Arguments[index].(*ast.HashLiteralExpr); ok {
+		for _, pair := range hash.Pairs {
+			if !isHashKeyName(pair.Key) {
+				tc.errors = append(tc.errors, &TypeError{
+					Code:     ErrInvalidArgumentType,
+					Type:     errType,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("%s: %s must be identifiers or string literals", fn.FullName(), keys),
+					Location: call.Location(),
+				})
+			}
+			_, _ = tc.inferExpr(pair.Value)
+		}
+		return
+	}
+	if argType, err := tc.inferExpr(call.Arguments[index]); err == nil {
+		if _, ok := argType.(*HashType); !ok || argType.IsNullable() {
+			tc.errors = append(tc.errors, NewInvalidArgumentType(call.Location(), fn.FullName(), index, fn.Parameters[index].Type, argType))
+		}
+	}
+}
+
+// isStringLiteral reports whether lit is a string literal
+func isStringLiteral(lit *ast.LiteralExpr) bool {
+	_, ok := lit.Value.(string)
+	return ok
+}
+
+// isHashKeyName reports whether a hash literal key is a bare name or a string
+func isHashKeyName(key ast.ExprNode) bool {
+	switch k := key.(type) {
+	case *ast.IdentifierExpr:
+		return true
+	case *ast.LiteralExpr:
+		return isStringLiteral(k)
+	}
+	return false
+}
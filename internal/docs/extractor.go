@@ -5,11 +5,15 @@ import (
 	"strings"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/codegen"
+	"github.com/conduit-lang/conduit/pkg/web/bind"
+	"github.com/conduit-lang/conduit/pkg/web/cache"
 )
 
 // Extractor extracts documentation from AST nodes
 type Extractor struct {
-	exampleGen *ExampleGenerator
+	exampleGen    *ExampleGenerator
+	serialization codegen.SerializationOptions
 }
 
 // NewExtractor creates a new documentation extractor
@@ -19,6 +23,17 @@ func NewExtractor() *Extractor {
 	}
 }
 
+// SetSerialization documents field names, nulls and response envelopes with
+// the JSON conventions of the generated API
+func (e *Extractor) SetSerialization(opts codegen.SerializationOptions) {
+	e.serialization = opts
+}
+
+// jsonName returns the JSON key of a field
+func (e *Extractor) jsonName(name string) string {
+	return e.serialization.Casing.Apply(name)
+}
+
 // Extract extracts documentation from a parsed program
 func (e *Extractor) Extract(program *ast.Program, projectName, projectVersion, projectDescription string) *Documentation {
 	doc := &Documentation{
@@ -50,6 +65,12 @@ func (e *Extractor) extractResource(resource *ast.ResourceNode) *ResourceDoc {
 		Validations:   make([]*ValidationDoc, 0, len(resource.Validations)),
 		Constraints:   make([]*ConstraintDoc, 0, len(resource.Constraints)),
 	}
+	if resource.Owner != nil {
+		doc.Owner = resource.Owner.Team
+	}
+	if resource.Stability != nil {
+		doc.Stability = resource.Stability.Level
+	}
 
 	// Extract fields
 	for _, field := range resource.Fields {
@@ -81,6 +102,15 @@ func (e *Extractor) extractResource(resource *ast.ResourceNode) *ResourceDoc {
 		doc.Constraints = append(doc.Constraints, constraintDoc)
 	}
 
+	// Extract indexes
+	for _, index := range resource.AllIndexes() {
+		doc.Indexes = append(doc.Indexes, &IndexDoc{
+			Name:   codegen.IndexName(resource, index),
+			Fields: index.Fields,
+			Unique: index.Unique,
+		})
+	}
+
 	return doc
 }
 
@@ -104,7 +134,7 @@ func (e *Extractor) extractField(field *ast.FieldNode) *FieldDoc {
 	}
 
 	return &FieldDoc{
-		Name:        field.Name,
+		Name:        e.jsonName(field.Name),
 		Type:        typeStr,
 		Description: "", // No inline field documentation in current spec
 		Required:    !field.Nullable,
@@ -173,11 +203,25 @@ func (e *Extractor) extractConstraint(constraint *ast.ConstraintNode) *Constrain
 	}
 }
 
+// cacheHeaders documents the caching headers sent on reads of a resource
+// with @cache_control. Returns nil for resources that are not cacheable.
+func cacheHeaders(resource *ast.ResourceNode, surrogateKey string) map[string]string {
+	cc := resource.CacheControl
+	if cc == nil {
+		return nil
+	}
+	policy := cache.Policy{MaxAge: cc.MaxAge, SharedMaxAge: cc.SharedMaxAge, StaleWhileRevalidate: cc.StaleWhileRevalidate, Public: cc.Public}
+	return map[string]string{
+		"Cache-Control":          policy.Header(),
+		cache.SurrogateKeyHeader: surrogateKey,
+	}
+}
+
 // generateEndpoints generates REST API endpoints for a resource
 func (e *Extractor) generateEndpoints(resource *ast.ResourceNode) []*EndpointDoc {
 	endpoints := make([]*EndpointDoc, 0)
-	resourceName := strings.ToLower(resource.Name)
-	resourcePath := "/" + pluralize(resourceName)
+	resourcePath := "/" + codegen.TableName(resource.Name)
+	memberPath := resourcePath + "/" + resource.MemberPattern()
 
 	// List endpoint - GET /resources
 	endpoints = append(endpoints, &EndpointDoc{
@@ -186,16 +230,17 @@ func (e *Extractor) generateEndpoints(resource *ast.ResourceNode) []*EndpointDoc
 		Summary:     fmt.Sprintf("List all %s", pluralize(resource.Name)),
 		Description: fmt.Sprintf("Retrieve a paginated list of %s", pluralize(resource.Name)),
 		Parameters: []*ParameterDoc{
-			{Name: "page", In: "query", Type: "integer", Required: false, Description: "Page number", Example: 1},
 			{Name: "limit", In: "query", Type: "integer", Required: false, Description: "Items per page", Example: 20},
+			{Name: "offset", In: "query", Type: "integer", Required: false, Description: "Items to skip", Example: 0},
 		},
 		Responses: map[int]*ResponseDoc{
 			200: {
 				StatusCode:  200,
 				Description: "Success",
 				ContentType: "application/json",
-				Schema:      e.createArraySchema(resource),
-				Example:     e.createArrayExample(resource),
+				Schema:      e.listSchema(e.createArraySchema(resource)),
+				Example:     e.listExample(e.createArrayExample(resource)),
+				Headers:     cacheHeaders(resource, resourcePath[1:]),
 			},
 		},
 		Middleware: resource.Middleware,
@@ -204,19 +249,18 @@ func (e *Extractor) generateEndpoints(resource *ast.ResourceNode) []*EndpointDoc
 	// Get endpoint - GET /resources/:id
 	endpoints = append(endpoints, &EndpointDoc{
 		Method:      "GET",
-		Path:        resourcePath + "/:id",
+		Path:        memberPath,
 		Summary:     fmt.Sprintf("Get a %s by ID", resource.Name),
 		Description: fmt.Sprintf("Retrieve a single %s by its unique identifier", resource.Name),
-		Parameters: []*ParameterDoc{
-			{Name: "id", In: "path", Type: "string", Required: true, Description: "Resource ID", Example: "uuid"},
-		},
+		Parameters:  e.keyParameters(resource),
 		Responses: map[int]*ResponseDoc{
 			200: {
 				StatusCode:  200,
 				Description: "Success",
 				ContentType: "application/json",
-				Schema:      e.createObjectSchema(resource),
-				Example:     e.createObjectExample(resource),
+				Schema:      e.recordSchema(e.createObjectSchema(resource)),
+				Example:     e.recordExample(e.createObjectExample(resource)),
+				Headers:     cacheHeaders(resource, cache.RecordKey(resourcePath[1:], resource.MemberPath())),
 			},
 			404: {
 				StatusCode:  404,
@@ -227,6 +271,11 @@ func (e *Extractor) generateEndpoints(resource *ast.ResourceNode) []*EndpointDoc
 		Middleware: resource.Middleware,
 	})
 
+	// @materialized views and @external_table resources are read-only
+	if resource.ReadOnly() {
+		return endpoints
+	}
+
 	// Create endpoint - POST /resources
 	endpoints = append(endpoints, &EndpointDoc{
 		Method:      "POST",
@@ -237,6 +286,7 @@ func (e *Extractor) generateEndpoints(resource *ast.ResourceNode) []*EndpointDoc
 			Description: fmt.Sprintf("%s data", resource.Name),
 			Required:    true,
 			ContentType: "application/json",
+			Accepts:     bind.MediaTypes()[1:],
 			Schema:      e.createObjectSchema(resource),
 			Example:     e.createObjectExample(resource),
 		},
@@ -245,8 +295,8 @@ func (e *Extractor) generateEndpoints(resource *ast.ResourceNode) []*EndpointDoc
 				StatusCode:  201,
 				Description: "Created",
 				ContentType: "application/json",
-				Schema:      e.createObjectSchema(resource),
-				Example:     e.createObjectExample(resource),
+				Schema:      e.recordSchema(e.createObjectSchema(resource)),
+				Example:     e.recordExample(e.createObjectExample(resource)),
 			},
 			400: {
 				StatusCode:  400,
@@ -260,16 +310,15 @@ func (e *Extractor) generateEndpoints(resource *ast.ResourceNode) []*EndpointDoc
 	// Update endpoint - PUT /resources/:id
 	endpoints = append(endpoints, &EndpointDoc{
 		Method:      "PUT",
-		Path:        resourcePath + "/:id",
+		Path:        memberPath,
 		Summary:     fmt.Sprintf("Update a %s", resource.Name),
 		Description: fmt.Sprintf("Update an existing %s with the provided data", resource.Name),
-		Parameters: []*ParameterDoc{
-			{Name: "id", In: "path", Type: "string", Required: true, Description: "Resource ID", Example: "uuid"},
-		},
+		Parameters:  e.keyParameters(resource),
 		RequestBody: &RequestBodyDoc{
 			Description: fmt.Sprintf("Updated %s data", resource.Name),
 			Required:    true,
 			ContentType: "application/json",
+			Accepts:     bind.MediaTypes()[1:],
 			Schema:      e.createObjectSchema(resource),
 			Example:     e.createObjectExample(resource),
 		},
@@ -278,8 +327,8 @@ func (e *Extractor) generateEndpoints(resource *ast.ResourceNode) []*EndpointDoc
 				StatusCode:  200,
 				Description: "Success",
 				ContentType: "application/json",
-				Schema:      e.createObjectSchema(resource),
-				Example:     e.createObjectExample(resource),
+				Schema:      e.recordSchema(e.createObjectSchema(resource)),
+				Example:     e.recordExample(e.createObjectExample(resource)),
 			},
 			404: {
 				StatusCode:  404,
@@ -293,12 +342,10 @@ func (e *Extractor) generateEndpoints(resource *ast.ResourceNode) []*EndpointDoc
 	// Delete endpoint - DELETE /resources/:id
 	endpoints = append(endpoints, &EndpointDoc{
 		Method:      "DELETE",
-		Path:        resourcePath + "/:id",
+		Path:        memberPath,
 		Summary:     fmt.Sprintf("Delete a %s", resource.Name),
 		Description: fmt.Sprintf("Delete a %s by its unique identifier", resource.Name),
-		Parameters: []*ParameterDoc{
-			{Name: "id", In: "path", Type: "string", Required: true, Description: "Resource ID", Example: "uuid"},
-		},
+		Parameters:  e.keyParameters(resource),
 		Responses: map[int]*ResponseDoc{
 			204: {
 				StatusCode:  204,
@@ -317,6 +364,27 @@ func (e *Extractor) generateEndpoints(resource *ast.ResourceNode) []*EndpointDoc
 }
 
 // createObjectSchema creates a JSON schema for a resource
+// keyParameters returns the path parameters identifying a record: its id, or
+// the fields of its natural key
+func (e *Extractor) keyParameters(resource *ast.ResourceNode) []*ParameterDoc {
+	if !resource.HasNaturalKey() {
+		return []*ParameterDoc{
+			{Name: "id", In: "path", Type: "string", Required: true, Description: "Resource ID", Example: "uuid"},
+		}
+	}
+	var params []*ParameterDoc
+	for _, field := range resource.KeyFields() {
+		params = append(params, &ParameterDoc{
+			Name:        field.Name,
+			In:          "path",
+			Type:        e.schemaTypeForFieldType(field.Type),
+			Required:    true,
+			Description: fmt.Sprintf("%s %s", resource.Name, field.Name),
+		})
+	}
+	return params
+}
+
 func (e *Extractor) createObjectSchema(resource *ast.ResourceNode) *SchemaDoc {
 	schema := &SchemaDoc{
 		Type:       "object",
@@ -328,15 +396,17 @@ func (e *Extractor) createObjectSchema(resource *ast.ResourceNode) *SchemaDoc {
 		propType := e.schemaTypeForFieldType(field.Type)
 		format := e.schemaFormatForFieldType(field.Type)
 
-		schema.Properties[field.Name] = &PropertyDoc{
+		name := e.jsonName(field.Name)
+		schema.Properties[name] = &PropertyDoc{
 			Type:        propType,
-			Description: fmt.Sprintf("%s field", field.Name),
+			Description: fmt.Sprintf("%s field", name),
 			Format:      format,
 			Example:     e.exampleGen.GenerateForType(field.Type),
+			Nullable:    field.Nullable && e.serialization.IncludeNulls,
 		}
 
 		if !field.Nullable {
-			schema.Required = append(schema.Required, field.Name)
+			schema.Required = append(schema.Required, name)
 		}
 	}
 
@@ -356,7 +426,7 @@ func (e *Extractor) createObjectExample(resource *ast.ResourceNode) map[string]i
 	example := make(map[string]interface{})
 
 	for _, field := range resource.Fields {
-		example[field.Name] = e.exampleGen.GenerateForType(field.Type)
+		example[e.jsonName(field.Name)] = e.exampleGen.GenerateForType(field.Type)
 	}
 
 	return example
@@ -369,6 +439,56 @@ func (e *Extractor) createArrayExample(resource *ast.ResourceNode) []map[string]
 	}
 }
 
+// recordSchema returns the schema of a response with one record, in a
+// {"data": ...} envelope when serialization.envelope is set
+func (e *Extractor) recordSchema(record *SchemaDoc) *SchemaDoc {
+	if !e.serialization.Envelope {
+		return record
+	}
+	return &SchemaDoc{
+		Type: "object",
+		Properties: map[string]*PropertyDoc{
+			"data": {Type: record.Type, Schema: record},
+		},
+		Required: []string{"data"},
+	}
+}
+
+// recordExample returns the example of a response with one record
+func (e *Extractor) recordExample(record map[string]interface{}) interface{} {
+	if !e.serialization.Envelope {
+		return record
+	}
+	return map[string]interface{}{"data": record}
+}
+
+// listSchema returns the schema of a list response: an array, or with
+// serialization.envelope the records in data and pagination in meta
+func (e *Extractor) listSchema(records *SchemaDoc) *SchemaDoc {
+	if !e.serialization.Envelope {
+		return records
+	}
+	return &SchemaDoc{
+		Type: "object",
+		Properties: map[string]*PropertyDoc{
+			"data": {Type: records.Type, Schema: records},
+			"meta": {Type: "object", Description: "Pagination: page, per_page and total"},
+		},
+		Required: []string{"data"},
+	}
+}
+
+// listExample returns the example of a list response
+func (e *Extractor) listExample(records []map[string]interface{}) interface{} {
+	if !e.serialization.Envelope {
+		return records
+	}
+	return map[string]interface{}{
+		"data": records,
+		"meta": map[string]interface{}{"page": 1, "per_page": 20, "total": len(records)},
+	}
+}
+
 // Helper functions
 
 func (e *Extractor) formatType(typeNode *ast.TypeNode) string {
@@ -445,6 +565,8 @@ func (e *Extractor) schemaTypeForFieldType(typeNode *ast.TypeNode) string {
 			return "number"
 		case "bool", "boolean":
 			return "boolean"
+		case "point", "polygon":
+			return "object"
 		default:
 			return "string"
 		}
@@ -469,6 +591,8 @@ func (e *Extractor) schemaFormatForFieldType(typeNode *ast.TypeNode) string {
 		return "email"
 	case "url":
 		return "uri"
+	case "point", "polygon":
+		return "geojson"
 	default:
 		return ""
 	}
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/codegen"
+	"github.com/conduit-lang/conduit/pkg/web/query"
 )
 
 func TestExtractor_Extract(t *testing.T) {
@@ -302,6 +304,40 @@ func TestExtractor_GenerateEndpoints(t *testing.T) {
 	}
 }
 
+func TestExtractor_GenerateEndpoints_CacheControl(t *testing.T) {
+	resource := &ast.ResourceNode{
+		Name:         "Post",
+		CacheControl: &ast.CacheControlNode{MaxAge: 60, Public: true},
+	}
+
+	endpoints := NewExtractor().generateEndpoints(resource)
+
+	list := endpoints[0].Responses[200].Headers
+	if list["Cache-Control"] != "public, max-age=60" || list["Surrogate-Key"] != "posts" {
+		t.Errorf("Unexpected list headers: %v", list)
+	}
+	get := endpoints[1].Responses[200].Headers
+	if get["Surrogate-Key"] != "posts/{id}" {
+		t.Errorf("Unexpected get headers: %v", get)
+	}
+	if endpoints[2].Responses[201].Headers != nil {
+		t.Error("Writes should not document caching headers")
+	}
+}
+
+func TestExtractor_GenerateEndpoints_Materialized(t *testing.T) {
+	resource := &ast.ResourceNode{
+		Name:         "PostStat",
+		Materialized: &ast.MaterializedNode{Query: "SELECT id FROM posts", Refresh: ast.RefreshHourly},
+	}
+
+	endpoints := NewExtractor().generateEndpoints(resource)
+
+	if len(endpoints) != 2 || endpoints[0].Method != "GET" || endpoints[1].Method != "GET" {
+		t.Errorf("Materialized views should only document list and get endpoints, got %d", len(endpoints))
+	}
+}
+
 func TestExtractor_CreateSchema(t *testing.T) {
 	extractor := NewExtractor()
 
@@ -366,3 +402,46 @@ func TestExtractor_CreateSchema(t *testing.T) {
 		t.Error("Expected age to be optional")
 	}
 }
+
+func TestExtractor_Serialization(t *testing.T) {
+	extractor := NewExtractor()
+	extractor.SetSerialization(codegen.SerializationOptions{Casing: query.CamelCase, Envelope: true, IncludeNulls: true})
+
+	resource := &ast.ResourceNode{
+		Name: "Post",
+		Fields: []*ast.FieldNode{
+			{
+				Name: "id",
+				Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"},
+			},
+			{
+				Name:     "author_name",
+				Type:     &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string", Nullable: true},
+				Nullable: true,
+			},
+		},
+	}
+
+	schema := extractor.createObjectSchema(resource)
+	prop, ok := schema.Properties["authorName"]
+	if !ok {
+		t.Fatalf("Expected camelCase property authorName, got %v", schema.Properties)
+	}
+	if !prop.Nullable {
+		t.Error("Nullable fields should be documented as nullable when nulls are included")
+	}
+
+	endpoints := extractor.generateEndpoints(resource)
+
+	list := endpoints[0].Responses[200].Schema
+	if list.Type != "object" || list.Properties["data"] == nil || list.Properties["meta"] == nil {
+		t.Errorf("Expected list response in a data/meta envelope, got %+v", list)
+	}
+	get := endpoints[1].Responses[200].Schema
+	if data := get.Properties["data"]; data == nil || data.Schema == nil || data.Schema.Properties["authorName"] == nil {
+		t.Errorf("Expected record in a data envelope, got %+v", get)
+	}
+	if example, ok := endpoints[1].Responses[200].Example.(map[string]interface{}); !ok || example["data"] == nil {
+		t.Errorf("Expected enveloped example, got %v", endpoints[1].Responses[200].Example)
+	}
+}
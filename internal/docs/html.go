@@ -218,7 +218,7 @@ const indexTemplate = `{{define "index"}}
     <div class="resource-grid">
         {{range .Resources}}
         <div class="resource-card">
-            <h3><a href="{{lower .Name}}.html">{{.Name}}</a></h3>
+            <h3><a href="{{lower .Name}}.html">{{.Name}}</a>{{if .Stability}} <span class="stability stability-{{.Stability}}">{{.Stability}}</span>{{end}}</h3>
             <p>{{.Documentation}}</p>
             <div class="resource-stats">
                 <span>{{len .Fields}} fields</span>
@@ -262,7 +262,7 @@ const resourceTemplate = `{{define "resource"}}
         </nav>
         <main class="content">
 <div class="page-header">
-    <h1>{{.Resource.Name}}</h1>
+    <h1>{{.Resource.Name}}{{if .Resource.Stability}} <span class="stability stability-{{.Resource.Stability}}">{{.Resource.Stability}}</span>{{end}}</h1>
     {{if .Resource.Documentation}}
     <p class="description">{{.Resource.Documentation}}</p>
     {{end}}
@@ -310,6 +310,31 @@ const resourceTemplate = `{{define "resource"}}
 </div>
 {{end}}
 
+{{if .Resource.Indexes}}
+<div class="section">
+    <h2>Indexes</h2>
+    <p>Filters and sorts on the leading fields of an index are served by it.</p>
+    <table class="fields-table">
+        <thead>
+            <tr>
+                <th>Name</th>
+                <th>Fields</th>
+                <th>Unique</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{range .Resource.Indexes}}
+            <tr>
+                <td><code>{{.Name}}</code></td>
+                <td>{{range $i, $field := .Fields}}{{if $i}}, {{end}}<code>{{$field}}</code>{{end}}</td>
+                <td>{{if .Unique}}Yes{{else}}No{{end}}</td>
+            </tr>
+            {{end}}
+        </tbody>
+    </table>
+</div>
+{{end}}
+
 <div class="section">
     <h2>Endpoints</h2>
     {{range .Resource.Endpoints}}
@@ -613,6 +638,20 @@ body {
 .method-delete { background: #e74c3c; color: white; }
 .method-patch { background: #9b59b6; color: white; }
 
+.stability {
+    display: inline-block;
+    padding: 2px 8px;
+    border-radius: 4px;
+    font-size: 12px;
+    font-weight: 600;
+    text-transform: uppercase;
+    vertical-align: middle;
+}
+
+.stability-experimental { background: #f39c12; color: white; }
+.stability-stable { background: #27ae60; color: white; }
+.stability-deprecated { background: #e74c3c; color: white; }
+
 .path {
     font-family: 'Courier New', monospace;
     color: #34495e;
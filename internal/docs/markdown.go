@@ -100,6 +100,10 @@ func (g *MarkdownGenerator) generateResourceDoc(resource *ResourceDoc, outputDir
 		buf.WriteString(fmt.Sprintf("> %s\n\n", resource.Documentation))
 	}
 
+	if notice := stabilityNotice(resource.Stability); notice != "" {
+		buf.WriteString(fmt.Sprintf("**Stability:** %s\n\n", notice))
+	}
+
 	// Table of contents
 	buf.WriteString("## Table of Contents\n\n")
 	buf.WriteString("- [Fields](#fields)\n")
@@ -116,6 +120,9 @@ func (g *MarkdownGenerator) generateResourceDoc(resource *ResourceDoc, outputDir
 	if len(resource.Constraints) > 0 {
 		buf.WriteString("- [Constraints](#constraints)\n")
 	}
+	if len(resource.Indexes) > 0 {
+		buf.WriteString("- [Indexes](#indexes)\n")
+	}
 	buf.WriteString("\n")
 
 	// Fields
@@ -226,6 +233,23 @@ func (g *MarkdownGenerator) generateResourceDoc(resource *ResourceDoc, outputDir
 		}
 	}
 
+	// Indexes
+	if len(resource.Indexes) > 0 {
+		buf.WriteString("## Indexes\n\n")
+		buf.WriteString("Filters and sorts on the leading fields of an index are served by it.\n\n")
+		buf.WriteString("| Name | Fields | Unique |\n")
+		buf.WriteString("|------|--------|--------|\n")
+		for _, index := range resource.Indexes {
+			unique := "No"
+			if index.Unique {
+				unique = "Yes"
+			}
+			buf.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n",
+				index.Name, strings.Join(index.Fields, ", "), unique))
+		}
+		buf.WriteString("\n")
+	}
+
 	// Write to file
 	outputPath := filepath.Join(outputDir, strings.ToLower(resource.Name)+".md")
 	return os.WriteFile(outputPath, []byte(buf.String()), 0644)
@@ -297,3 +321,18 @@ func (g *MarkdownGenerator) createResourceExample(resource *ResourceDoc) map[str
 	}
 	return example
 }
+
+// stabilityNotice describes a @stability level for readers; empty when the
+// resource declares none
+func stabilityNotice(stability string) string {
+	switch stability {
+	case "experimental":
+		return "Experimental - may change or be removed without notice"
+	case "deprecated":
+		return "Deprecated - kept for existing clients and scheduled for removal"
+	case "stable":
+		return "Stable - changes stay backwards compatible"
+	default:
+		return ""
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
 )
 
 func TestMarkdownGenerator_Generate(t *testing.T) {
@@ -284,3 +286,60 @@ func TestMarkdownGenerator_GenerateResourceDoc(t *testing.T) {
 		t.Error("Should contain relationship kind")
 	}
 }
+
+func TestMarkdownGenerator_Stability(t *testing.T) {
+	outputDir := t.TempDir()
+	generator := NewMarkdownGenerator(&Config{OutputDir: outputDir})
+
+	err := generator.generateResourceDoc(&ResourceDoc{Name: "Invoice", Stability: "experimental"}, outputDir)
+	if err != nil {
+		t.Fatalf("generateResourceDoc failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "invoice.md"))
+	if err != nil {
+		t.Fatalf("Failed to read invoice.md: %v", err)
+	}
+	if !strings.Contains(string(content), "**Stability:** Experimental") {
+		t.Errorf("Expected an experimental notice, got:\n%s", content)
+	}
+
+	if err := generator.generateResourceDoc(&ResourceDoc{Name: "Comment"}, outputDir); err != nil {
+		t.Fatalf("generateResourceDoc failed: %v", err)
+	}
+	content, _ = os.ReadFile(filepath.Join(outputDir, "comment.md"))
+	if strings.Contains(string(content), "Stability") {
+		t.Errorf("Resources without @stability should have no notice, got:\n%s", content)
+	}
+}
+
+func TestMarkdownGenerator_Indexes(t *testing.T) {
+	outputDir := t.TempDir()
+	generator := NewMarkdownGenerator(&Config{OutputDir: outputDir})
+
+	resource := NewExtractor().extractResource(&ast.ResourceNode{
+		Name: "Post",
+		Fields: []*ast.FieldNode{
+			{Name: "status", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "string"},
+				Constraints: []*ast.ConstraintNode{{Name: ast.IndexConstraint}}},
+			{Name: "published_at", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"}},
+		},
+		Indexes: []*ast.IndexNode{{Fields: []string{"status", "published_at"}, Unique: true}},
+	})
+	if err := generator.generateResourceDoc(resource, outputDir); err != nil {
+		t.Fatalf("generateResourceDoc failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "post.md"))
+	if err != nil {
+		t.Fatalf("Failed to read post.md: %v", err)
+	}
+
+	for _, want := range []string{
+		"- [Indexes](#indexes)",
+		"| `idx_posts_status` | status | No |",
+		"| `idx_posts_status_published_at` | status, published_at | Yes |",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %q, got:\n%s", want, content)
+		}
+	}
+}
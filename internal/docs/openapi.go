@@ -5,8 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 )
 
+// pathParamPattern matches ":name" path parameters, which OpenAPI spells "{name}"
+var pathParamPattern = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)`)
+
 // OpenAPIGenerator generates OpenAPI 3.0 specifications
 type OpenAPIGenerator struct {
 	config *Config
@@ -21,8 +26,6 @@ func NewOpenAPIGenerator(config *Config) *OpenAPIGenerator {
 
 // Generate generates an OpenAPI 3.0 specification
 func (g *OpenAPIGenerator) Generate(doc *Documentation) error {
-	spec := g.createSpec(doc)
-
 	// Validate the output directory BEFORE making it absolute
 	if containsPathTraversal(g.config.OutputDir) {
 		return fmt.Errorf("invalid output directory: path traversal detected")
@@ -41,10 +44,9 @@ func (g *OpenAPIGenerator) Generate(doc *Documentation) error {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Marshal to JSON
-	data, err := json.MarshalIndent(spec, "", "  ")
+	data, err := g.Spec(doc)
 	if err != nil {
-		return fmt.Errorf("failed to marshal OpenAPI spec: %w", err)
+		return err
 	}
 
 	// Write to file
@@ -55,6 +57,16 @@ func (g *OpenAPIGenerator) Generate(doc *Documentation) error {
 	return nil
 }
 
+// Spec returns the OpenAPI 3.0 specification as indented JSON. Generated
+// applications embed it to serve the API playground.
+func (g *OpenAPIGenerator) Spec(doc *Documentation) ([]byte, error) {
+	data, err := json.MarshalIndent(g.createSpec(doc), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal OpenAPI spec: %w", err)
+	}
+	return data, nil
+}
+
 // createSpec creates the complete OpenAPI specification
 func (g *OpenAPIGenerator) createSpec(doc *Documentation) map[string]interface{} {
 	spec := map[string]interface{}{
@@ -65,8 +77,15 @@ func (g *OpenAPIGenerator) createSpec(doc *Documentation) map[string]interface{}
 			"description": doc.ProjectInfo.Description,
 		},
 		"servers":    g.createServers(),
+		"tags":       g.createTags(doc.Resources),
 		"paths":      g.createPaths(doc.Resources),
 		"components": g.createComponents(doc.Resources),
+		// Bearer tokens are accepted but not required, so public routes can
+		// still be called without authorizing
+		"security": []map[string][]string{
+			{"bearerAuth": {}},
+			{},
+		},
 	}
 
 	return spec
@@ -103,20 +122,50 @@ func (g *OpenAPIGenerator) createServers() []map[string]interface{} {
 	return servers
 }
 
+// createTags creates the tags section, one tag per resource. The team owning
+// a resource is given as the x-owner extension of its tag, so API catalogs
+// can route questions about its operations, and its @stability as
+// x-stability.
+func (g *OpenAPIGenerator) createTags(resources []*ResourceDoc) []map[string]interface{} {
+	tags := make([]map[string]interface{}, 0, len(resources))
+
+	for _, resource := range resources {
+		tag := map[string]interface{}{
+			"name": resource.Name,
+		}
+		if resource.Documentation != "" {
+			tag["description"] = resource.Documentation
+		}
+		if resource.Owner != "" {
+			tag["x-owner"] = resource.Owner
+		}
+		if resource.Stability != "" {
+			tag["x-stability"] = resource.Stability
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
 // createPaths creates the paths section
 func (g *OpenAPIGenerator) createPaths(resources []*ResourceDoc) map[string]interface{} {
 	paths := make(map[string]interface{})
 
 	for _, resource := range resources {
 		for _, endpoint := range resource.Endpoints {
-			pathItem, ok := paths[endpoint.Path].(map[string]interface{})
+			path := openAPIPath(endpoint.Path)
+			pathItem, ok := paths[path].(map[string]interface{})
 			if !ok {
 				pathItem = make(map[string]interface{})
-				paths[endpoint.Path] = pathItem
+				paths[path] = pathItem
 			}
 
 			operation := g.createOperation(endpoint, resource.Name)
-			pathItem[endpoint.Method] = operation
+			if resource.Stability == "deprecated" {
+				operation["deprecated"] = true
+			}
+			pathItem[strings.ToLower(endpoint.Method)] = operation
 		}
 	}
 
@@ -189,6 +238,14 @@ func (g *OpenAPIGenerator) createRequestBody(body *RequestBodyDoc) map[string]in
 		mediaType["example"] = body.Example
 	}
 
+	// Other formats share the schema; the example stays with the JSON body
+	content := requestBody["content"].(map[string]interface{})
+	for _, contentType := range body.Accepts {
+		content[contentType] = map[string]interface{}{
+			"schema": g.createSchemaObject(body.Schema),
+		}
+	}
+
 	return requestBody
 }
 
@@ -216,6 +273,17 @@ func (g *OpenAPIGenerator) createResponses(responses map[int]*ResponseDoc) map[s
 			}
 		}
 
+		if len(response.Headers) > 0 {
+			headers := make(map[string]interface{}, len(response.Headers))
+			for name, example := range response.Headers {
+				headers[name] = map[string]interface{}{
+					"schema":  map[string]interface{}{"type": "string"},
+					"example": example,
+				}
+			}
+			responseObj["headers"] = headers
+		}
+
 		responsesObj[statusKey] = responseObj
 	}
 
@@ -258,6 +326,13 @@ func (g *OpenAPIGenerator) createPropertyObject(prop *PropertyDoc) map[string]in
 	propObj := map[string]interface{}{
 		"type": prop.Type,
 	}
+	if prop.Schema != nil {
+		propObj = g.createSchemaObject(prop.Schema)
+	}
+
+	if prop.Nullable {
+		propObj["nullable"] = true
+	}
 
 	if prop.Description != "" {
 		propObj["description"] = prop.Description
@@ -297,6 +372,11 @@ func (g *OpenAPIGenerator) createComponents(resources []*ResourceDoc) map[string
 				properties[field.Name].(map[string]interface{})["example"] = field.Example
 			}
 
+			// Optional fields are written as null rather than omitted
+			if !field.Required && g.config != nil && g.config.Serialization.IncludeNulls {
+				properties[field.Name].(map[string]interface{})["nullable"] = true
+			}
+
 			if field.Required {
 				required = append(required, field.Name)
 			}
@@ -320,9 +400,20 @@ func (g *OpenAPIGenerator) createComponents(resources []*ResourceDoc) map[string
 
 	return map[string]interface{}{
 		"schemas": schemas,
+		"securitySchemes": map[string]interface{}{
+			"bearerAuth": map[string]interface{}{
+				"type":   "http",
+				"scheme": "bearer",
+			},
+		},
 	}
 }
 
+// openAPIPath converts a route such as "/posts/:id" to "/posts/{id}"
+func openAPIPath(path string) string {
+	return pathParamPattern.ReplaceAllString(path, "{$1}")
+}
+
 // mapTypeToOpenAPI maps Conduit types to OpenAPI types
 func (g *OpenAPIGenerator) mapTypeToOpenAPI(conduitType string) string {
 	if len(conduitType) == 0 {
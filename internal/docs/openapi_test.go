@@ -184,3 +184,188 @@ func TestOpenAPIGenerator_MapTypeToOpenAPI(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenAPIGenerator_Spec(t *testing.T) {
+	generator := NewOpenAPIGenerator(&Config{ServerURLs: []ServerURL{{URL: "/api", Description: "This server"}}})
+
+	doc := &Documentation{
+		ProjectInfo: &ProjectInfo{Name: "Blog", Version: "1.0.0"},
+		Resources: []*ResourceDoc{
+			{
+				Name: "Post",
+				Endpoints: []*EndpointDoc{
+					{Method: "GET", Path: "/posts/:id", Parameters: []*ParameterDoc{{Name: "id", In: "path", Type: "string", Required: true}}},
+					{Method: "DELETE", Path: "/posts/:id"},
+				},
+			},
+		},
+	}
+
+	data, err := generator.Spec(doc)
+	if err != nil {
+		t.Fatalf("Spec failed: %v", err)
+	}
+
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("Spec is not valid JSON: %v", err)
+	}
+
+	paths := spec["paths"].(map[string]interface{})
+	item, ok := paths["/posts/{id}"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected templated path /posts/{id}, got %v", paths)
+	}
+	for _, method := range []string{"get", "delete"} {
+		if _, ok := item[method]; !ok {
+			t.Errorf("expected lowercase %q operation, got %v", method, item)
+		}
+	}
+
+	components := spec["components"].(map[string]interface{})
+	schemes := components["securitySchemes"].(map[string]interface{})
+	if bearer, ok := schemes["bearerAuth"].(map[string]interface{}); !ok || bearer["scheme"] != "bearer" {
+		t.Errorf("expected bearerAuth security scheme, got %v", schemes)
+	}
+
+	servers := spec["servers"].([]interface{})
+	if url := servers[0].(map[string]interface{})["url"]; url != "/api" {
+		t.Errorf("server url = %v, want /api", url)
+	}
+}
+
+func TestOpenAPIGenerator_OwnerTags(t *testing.T) {
+	generator := NewOpenAPIGenerator(&Config{})
+	tags := generator.createTags([]*ResourceDoc{
+		{Name: "Invoice", Documentation: "Customer invoices", Owner: "payments-team"},
+		{Name: "Comment"},
+	})
+
+	if len(tags) != 2 {
+		t.Fatalf("tags = %v, want one per resource", tags)
+	}
+	if tags[0]["name"] != "Invoice" || tags[0]["description"] != "Customer invoices" || tags[0]["x-owner"] != "payments-team" {
+		t.Errorf("Invoice tag = %v, want its description and owner", tags[0])
+	}
+	if _, ok := tags[1]["x-owner"]; ok {
+		t.Errorf("Comment tag = %v, want no owner", tags[1])
+	}
+}
+
+func TestOpenAPIGenerator_Stability(t *testing.T) {
+	generator := NewOpenAPIGenerator(&Config{})
+	resources := []*ResourceDoc{
+		{Name: "Invoice", Stability: "deprecated", Endpoints: []*EndpointDoc{{Method: "GET", Path: "/invoices"}}},
+		{Name: "Comment", Endpoints: []*EndpointDoc{{Method: "GET", Path: "/comments"}}},
+	}
+
+	tags := generator.createTags(resources)
+	if tags[0]["x-stability"] != "deprecated" {
+		t.Errorf("Invoice tag = %v, want x-stability deprecated", tags[0])
+	}
+	if _, ok := tags[1]["x-stability"]; ok {
+		t.Errorf("Comment tag = %v, want no stability", tags[1])
+	}
+
+	paths := generator.createPaths(resources)
+	invoices := paths["/invoices"].(map[string]interface{})["get"].(map[string]interface{})
+	if invoices["deprecated"] != true {
+		t.Errorf("operations of deprecated resources should be deprecated, got %v", invoices)
+	}
+	comments := paths["/comments"].(map[string]interface{})["get"].(map[string]interface{})
+	if _, ok := comments["deprecated"]; ok {
+		t.Errorf("Comment operation = %v, want it not deprecated", comments)
+	}
+}
+
+func TestOpenAPIGenerator_RequestBodyContentTypes(t *testing.T) {
+	generator := NewOpenAPIGenerator(&Config{})
+	body := generator.createRequestBody(&RequestBodyDoc{
+		Required:    true,
+		ContentType: "application/json",
+		Accepts:     []string{"application/x-www-form-urlencoded", "application/msgpack"},
+		Schema:      &SchemaDoc{Type: "object", Properties: map[string]*PropertyDoc{"title": {Type: "string"}}},
+		Example:     map[string]interface{}{"title": "Hello"},
+	})
+
+	content := body["content"].(map[string]interface{})
+	if len(content) != 3 {
+		t.Fatalf("content = %v, want JSON and the accepted types", content)
+	}
+	form, ok := content["application/x-www-form-urlencoded"].(map[string]interface{})
+	if !ok || form["schema"] == nil {
+		t.Errorf("form content = %v, want the shared schema", content["application/x-www-form-urlencoded"])
+	}
+	if _, ok := form["example"]; ok {
+		t.Error("the JSON example should not be offered for other formats")
+	}
+	if json := content["application/json"].(map[string]interface{}); json["example"] == nil {
+		t.Error("JSON content lost its example")
+	}
+}
+
+func TestOpenAPIGenerator_SpecSerialization(t *testing.T) {
+	generator := NewOpenAPIGenerator(&Config{})
+
+	record := &SchemaDoc{
+		Type: "object",
+		Properties: map[string]*PropertyDoc{
+			"authorName": {Type: "string", Nullable: true},
+		},
+	}
+	doc := &Documentation{
+		ProjectInfo: &ProjectInfo{Name: "Blog", Version: "1.0.0"},
+		Resources: []*ResourceDoc{
+			{
+				Name: "Post",
+				Endpoints: []*EndpointDoc{
+					{
+						Method: "GET",
+						Path:   "/posts/:id",
+						Responses: map[int]*ResponseDoc{
+							200: {
+								StatusCode:  200,
+								ContentType: "application/json",
+								Schema: &SchemaDoc{
+									Type:       "object",
+									Properties: map[string]*PropertyDoc{"data": {Type: "object", Schema: record}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	data, err := generator.Spec(doc)
+	if err != nil {
+		t.Fatalf("Spec failed: %v", err)
+	}
+
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]struct {
+				Content map[string]struct {
+					Schema struct {
+						Properties map[string]struct {
+							Properties map[string]map[string]interface{} `json:"properties"`
+						} `json:"properties"`
+					} `json:"schema"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("Spec is not valid JSON: %v", err)
+	}
+
+	schema := spec.Paths["/posts/{id}"]["get"].Responses["200"].Content["application/json"].Schema
+	authorName := schema.Properties["data"].Properties["authorName"]
+	if authorName == nil {
+		t.Fatalf("Expected the record schema nested under data, got %s", data)
+	}
+	if authorName["nullable"] != true {
+		t.Errorf("Expected authorName to be nullable, got %v", authorName)
+	}
+}
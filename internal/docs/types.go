@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/codegen"
 )
 
 // Generator orchestrates documentation generation across multiple formats
@@ -36,6 +37,10 @@ type Config struct {
 
 	// ServerURLs are additional server URLs for the API
 	ServerURLs []ServerURL
+
+	// Serialization is the JSON conventions of the generated API, from
+	// serialization in conduit.yaml, so documented schemas match responses
+	Serialization codegen.SerializationOptions
 }
 
 // Format represents a documentation output format
@@ -99,6 +104,15 @@ type ResourceDoc struct {
 
 	// Constraints contains constraint rules
 	Constraints []*ConstraintDoc
+
+	// Indexes contains the database indexes from @index and index blocks
+	Indexes []*IndexDoc
+
+	// Owner is the team owning the resource, from @owner or CODEOWNERS
+	Owner string
+
+	// Stability is the lifecycle status from @stability; empty for stable
+	Stability string
 }
 
 // FieldDoc represents documentation for a resource field
@@ -202,6 +216,9 @@ type RequestBodyDoc struct {
 	// ContentType is the media type (application/json)
 	ContentType string
 
+	// Accepts lists further media types the body may be sent as, with the same schema
+	Accepts []string
+
 	// Schema describes the structure
 	Schema *SchemaDoc
 
@@ -225,6 +242,9 @@ type ResponseDoc struct {
 
 	// Example provides an example response
 	Example interface{}
+
+	// Headers maps response header names to example values
+	Headers map[string]string
 }
 
 // SchemaDoc describes a JSON schema
@@ -258,6 +278,12 @@ type PropertyDoc struct {
 
 	// Enum lists allowed values
 	Enum []interface{}
+
+	// Nullable indicates the property may be null
+	Nullable bool
+
+	// Schema describes the value of an object or array property
+	Schema *SchemaDoc
 }
 
 // HookDoc represents documentation for a lifecycle hook
@@ -305,6 +331,18 @@ type ConstraintDoc struct {
 	On []string
 }
 
+// IndexDoc represents documentation for a database index
+type IndexDoc struct {
+	// Name is the index name in the database
+	Name string
+
+	// Fields lists the indexed fields and belongs_to relationships in order
+	Fields []string
+
+	// Unique indicates that no two records share the indexed values
+	Unique bool
+}
+
 // NewGenerator creates a new documentation generator
 func NewGenerator(config *Config) (*Generator, error) {
 	// Validate project name
@@ -325,9 +363,12 @@ func NewGenerator(config *Config) (*Generator, error) {
 		return nil, fmt.Errorf("project description too long (max 500 characters)")
 	}
 
+	extractor := NewExtractor()
+	extractor.SetSerialization(config.Serialization)
+
 	return &Generator{
 		config:    config,
-		extractor: NewExtractor(),
+		extractor: extractor,
 	}, nil
 }
 
@@ -226,10 +226,17 @@ func (f *Formatter) formatRelationship(rel *parser.RelationshipNode, maxLen int)
 	}
 
 	// Write relationship metadata if present
-	if rel.ForeignKey != "" || rel.OnDelete != "" || rel.OnUpdate != "" {
+	if rel.ForeignKey != "" || rel.OnDelete != "" || rel.OnUpdate != "" || rel.Kind != "" {
 		f.buf.WriteString(" {\n")
 		f.indent++
 
+		if rel.Kind != "" {
+			f.writeIndent()
+			f.buf.WriteString("kind: ")
+			f.buf.WriteString(rel.Kind)
+			f.buf.WriteString("\n")
+		}
+
 		if rel.ForeignKey != "" {
 			f.writeIndent()
 			f.buf.WriteString("foreign_key: \"")
@@ -0,0 +1,237 @@
+// Package mcp implements a Model Context Protocol server exposing the
+// introspection registry to AI agents and IDEs. Agents call its tools, such
+// as list_resources and query_dependencies, to learn the structure of a
+// Conduit application instead of reading its source.
+//
+// The server speaks JSON-RPC 2.0 over the stdio transport: one message per
+// line on stdin, one response per line on stdout. Logs go to stderr, since
+// anything else on stdout would corrupt the protocol.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+// ProtocolVersion is the latest MCP revision the server implements
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions are the revisions the server can negotiate, newest first
+var supportedVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// maxMessageSize bounds a single JSON-RPC message read from stdin
+const maxMessageSize = 4 * 1024 * 1024
+
+// JSON-RPC 2.0 error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// instructions tell the agent what the server is for
+const instructions = `Tools of this server describe a Conduit application from the metadata of its last build: its resources with their fields, relationships, hooks and validations, its HTTP routes, the dependencies between resources, and the patterns its code follows. Prefer them to reading .cdt sources when you need the application's structure. Run 'conduit build' to refresh the metadata; the server picks up the new build without restarting.`
+
+// request is an incoming JSON-RPC request or notification (without ID)
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is an outgoing JSON-RPC response
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Server answers MCP requests from the global metadata registry
+type Server struct {
+	registry *metadata.RegistryAPI
+	version  string
+	tools    []tool
+	logger   *log.Logger
+}
+
+// NewServer creates a server reporting version as its own, backed by registry
+func NewServer(registry *metadata.RegistryAPI, version string) *Server {
+	s := &Server{
+		registry: registry,
+		version:  version,
+		logger:   log.New(os.Stderr, "[MCP] ", log.LstdFlags),
+	}
+	s.tools = s.registryTools()
+	return s
+}
+
+// SetLogger replaces the stderr logger, e.g. with one discarding output
+func (s *Server) SetLogger(logger *log.Logger) {
+	s.logger = logger
+}
+
+// Serve reads requests from in and writes responses to out until in is
+// exhausted or ctx is done. Requests are answered in order.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+
+	lines := make(chan []byte)
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		scanErr <- scanner.Err()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-lines:
+			if !ok {
+				select {
+				case err := <-scanErr:
+					if err != nil {
+						return fmt.Errorf("failed to read request: %w", err)
+					}
+				default:
+				}
+				return nil
+			}
+			if len(line) == 0 {
+				continue
+			}
+			if reply := s.Handle(ctx, line); reply != nil {
+				if _, err := out.Write(append(reply, '\n')); err != nil {
+					return fmt.Errorf("failed to write response: %w", err)
+				}
+			}
+		}
+	}
+}
+
+// Handle answers a single JSON-RPC message. It returns nil for
+// notifications, which get no response.
+func (s *Server) Handle(ctx context.Context, msg []byte) []byte {
+	var req request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return s.encode(response{ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: "parse error: " + err.Error()}})
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if req.ID == nil {
+			return nil
+		}
+		return s.encode(response{ID: req.ID, Error: &rpcError{Code: codeInvalidRequest, Message: "invalid request: expected a JSON-RPC 2.0 request"}})
+	}
+
+	result, rpcErr := s.dispatch(ctx, req)
+	if req.ID == nil {
+		// Notifications such as notifications/initialized are not answered
+		return nil
+	}
+	return s.encode(response{ID: req.ID, Result: result, Error: rpcErr})
+}
+
+// dispatch runs the method of req
+func (s *Server) dispatch(ctx context.Context, req request) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+			ClientInfo      struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"clientInfo"`
+		}
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		s.logger.Printf("Client %s %s connected (protocol %s)", params.ClientInfo.Name, params.ClientInfo.Version, params.ProtocolVersion)
+		return map[string]interface{}{
+			"protocolVersion": negotiateVersion(params.ProtocolVersion),
+			"capabilities": map[string]interface{}{
+				"tools": map[string]interface{}{"listChanged": false},
+			},
+			"serverInfo":   map[string]string{"name": "conduit", "version": s.version},
+			"instructions": instructions,
+		}, nil
+
+	case "ping":
+		return map[string]interface{}{}, nil
+
+	case "tools/list":
+		return map[string]interface{}{"tools": s.tools}, nil
+
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.callTool(ctx, params.Name, params.Arguments)
+
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// negotiateVersion returns requested when the server supports it, else the
+// latest revision, which the client may then reject
+func negotiateVersion(requested string) string {
+	for _, version := range supportedVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return ProtocolVersion
+}
+
+// unmarshalParams decodes the params of a request; absent params leave v unchanged
+func unmarshalParams(params json.RawMessage, v interface{}) *rpcError {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	return nil
+}
+
+// encode marshals a response; results are built from plain values, so
+// marshaling cannot fail for valid responses
+func (s *Server) encode(resp response) []byte {
+	resp.JSONRPC = "2.0"
+	data, err := json.Marshal(resp)
+	if err != nil {
+		s.logger.Printf("Failed to encode response: %v", err)
+		data, _ = json.Marshal(response{JSONRPC: "2.0", ID: resp.ID, Error: &rpcError{Code: codeInternalError, Message: "failed to encode response"}})
+	}
+	return data
+}
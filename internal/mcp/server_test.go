@@ -0,0 +1,224 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+const testMetadata = `{
+  "version": "1.0.0",
+  "resources": [
+    {"name": "User", "file_path": "app/user.cdt", "fields": [{"name": "email", "type": "string!"}]},
+    {"name": "Post", "documentation": "A blog post", "file_path": "app/post.cdt",
+     "fields": [{"name": "title", "type": "string!"}],
+     "relationships": [{"name": "author", "type": "belongs_to", "target_resource": "User", "foreign_key": "author_id"}]}
+  ],
+  "routes": [
+    {"method": "GET", "path": "/posts", "handler": "ListPostHandler", "resource": "Post", "operation": "list"},
+    {"method": "POST", "path": "/posts", "handler": "CreatePostHandler", "resource": "Post", "operation": "create"},
+    {"method": "GET", "path": "/users", "handler": "ListUserHandler", "resource": "User", "operation": "list"}
+  ],
+  "patterns": [
+    {"id": "pattern-1", "name": "authenticated_handler", "category": "authentication", "template": "@on <operation>: [auth]", "frequency": 2, "confidence": 0.5},
+    {"id": "pattern-2", "name": "cached_handler", "category": "caching", "template": "@on list: [cache(300)]", "frequency": 4, "confidence": 0.7}
+  ]
+}`
+
+// session runs a server over pipes and exchanges messages with it
+type session struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	out    *bufio.Scanner
+	nextID int
+}
+
+func newSession(t *testing.T) *session {
+	t.Helper()
+	if err := metadata.RegisterMetadata([]byte(testMetadata)); err != nil {
+		t.Fatalf("RegisterMetadata() error = %v", err)
+	}
+	t.Cleanup(metadata.Reset)
+
+	server := NewServer(metadata.GetRegistry(), "test")
+	server.SetLogger(log.New(io.Discard, "", 0))
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(context.Background(), inR, outW)
+		outW.Close()
+	}()
+	t.Cleanup(func() {
+		inW.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	})
+	return &session{t: t, in: inW, out: bufio.NewScanner(outR)}
+}
+
+// call sends a request and decodes the result of its response into result
+func (s *session) call(method string, params interface{}, result interface{}) *rpcError {
+	s.t.Helper()
+	s.nextID++
+	msg, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": s.nextID, "method": method, "params": params})
+	if _, err := s.in.Write(append(msg, '\n')); err != nil {
+		s.t.Fatalf("write %s: %v", method, err)
+	}
+	if !s.out.Scan() {
+		s.t.Fatalf("no response to %s", method)
+	}
+
+	var resp struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.Unmarshal(s.out.Bytes(), &resp); err != nil {
+		s.t.Fatalf("invalid response to %s: %s", method, s.out.Text())
+	}
+	if resp.ID != s.nextID {
+		s.t.Fatalf("response id = %d, want %d", resp.ID, s.nextID)
+	}
+	if resp.Error == nil && result != nil {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			s.t.Fatalf("invalid result of %s: %s", method, resp.Result)
+		}
+	}
+	return resp.Error
+}
+
+// callTool calls a tool and decodes the JSON text of its result into v
+func (s *session) callTool(name string, args map[string]interface{}, v interface{}) toolResult {
+	s.t.Helper()
+	var result toolResult
+	if err := s.call("tools/call", map[string]interface{}{"name": name, "arguments": args}, &result); err != nil {
+		s.t.Fatalf("tools/call %s error = %+v", name, err)
+	}
+	if !result.IsError && v != nil {
+		if err := json.Unmarshal([]byte(result.Content[0].Text), v); err != nil {
+			s.t.Fatalf("%s returned %s: %v", name, result.Content[0].Text, err)
+		}
+	}
+	return result
+}
+
+func TestServer_Initialize(t *testing.T) {
+	s := newSession(t)
+
+	var result struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		Capabilities    struct {
+			Tools map[string]interface{} `json:"tools"`
+		} `json:"capabilities"`
+		ServerInfo struct {
+			Name string `json:"name"`
+		} `json:"serverInfo"`
+	}
+	if err := s.call("initialize", map[string]interface{}{"protocolVersion": "2025-03-26", "clientInfo": map[string]string{"name": "test"}}, &result); err != nil {
+		t.Fatalf("initialize error = %+v", err)
+	}
+	if result.ProtocolVersion != "2025-03-26" || result.Capabilities.Tools == nil || result.ServerInfo.Name != "conduit" {
+		t.Errorf("initialize result = %+v", result)
+	}
+
+	// Notifications get no response: the next line answers the ping
+	s.in.Write([]byte(`{"jsonrpc": "2.0", "method": "notifications/initialized"}` + "\n"))
+	if err := s.call("ping", nil, nil); err != nil {
+		t.Errorf("ping error = %+v", err)
+	}
+
+	var tools struct {
+		Tools []struct {
+			Name        string                 `json:"name"`
+			InputSchema map[string]interface{} `json:"inputSchema"`
+		} `json:"tools"`
+	}
+	s.call("tools/list", nil, &tools)
+	var names []string
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+		if tool.InputSchema["type"] != "object" {
+			t.Errorf("%s input schema = %v", tool.Name, tool.InputSchema)
+		}
+	}
+	if got := strings.Join(names, ","); got != "list_resources,get_resource,list_routes,query_dependencies,search_patterns" {
+		t.Errorf("tools = %s", got)
+	}
+
+	if err := s.call("resources/list", nil, nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("unknown method error = %+v, want method not found", err)
+	}
+	if err := s.call("tools/call", map[string]interface{}{"name": "drop_tables"}, nil); err == nil || err.Code != codeInvalidParams {
+		t.Errorf("unknown tool error = %+v, want invalid params", err)
+	}
+}
+
+func TestServer_Tools(t *testing.T) {
+	s := newSession(t)
+
+	var resources []resourceSummary
+	s.callTool("list_resources", nil, &resources)
+	if len(resources) != 2 || resources[1].Name != "Post" || resources[1].Relationships[0] != "author: belongs_to User" {
+		t.Errorf("list_resources = %+v", resources)
+	}
+
+	var post metadata.ResourceMetadata
+	s.callTool("get_resource", map[string]interface{}{"name": "Post"}, &post)
+	if post.Documentation != "A blog post" || len(post.Fields) != 1 {
+		t.Errorf("get_resource = %+v", post)
+	}
+	result := s.callTool("get_resource", map[string]interface{}{"name": "Comment"}, nil)
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "Post, User") {
+		t.Errorf("get_resource of an unknown resource = %+v", result)
+	}
+
+	var routes []metadata.RouteMetadata
+	s.callTool("list_routes", map[string]interface{}{"method": "get", "resource": "Post"}, &routes)
+	if len(routes) != 1 || routes[0].Handler != "ListPostHandler" {
+		t.Errorf("list_routes = %+v", routes)
+	}
+
+	var graph metadata.DependencyGraph
+	s.callTool("query_dependencies", map[string]interface{}{"resource": "User", "reverse": true}, &graph)
+	if _, ok := graph.Nodes["Post"]; !ok {
+		t.Errorf("reverse dependencies of User = %+v, want Post", graph.Nodes)
+	}
+
+	var patterns []metadata.PatternMetadata
+	s.callTool("search_patterns", map[string]interface{}{}, &patterns)
+	if len(patterns) != 2 || patterns[0].Name != "cached_handler" {
+		t.Errorf("search_patterns = %+v, want most frequent first", patterns)
+	}
+	s.callTool("search_patterns", map[string]interface{}{"query": "AUTH"}, &patterns)
+	if len(patterns) != 1 || patterns[0].Name != "authenticated_handler" {
+		t.Errorf("search_patterns auth = %+v", patterns)
+	}
+
+	result = s.callTool("list_routes", map[string]interface{}{"verb": "GET"}, nil)
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "verb") {
+		t.Errorf("unknown argument = %+v", result)
+	}
+}
+
+func TestServer_Handle_InvalidMessages(t *testing.T) {
+	server := NewServer(metadata.GetRegistry(), "test")
+
+	var resp response
+	json.Unmarshal(server.Handle(context.Background(), []byte(`{not json`)), &resp)
+	if resp.Error == nil || resp.Error.Code != codeParseError {
+		t.Errorf("parse error response = %+v", resp)
+	}
+	json.Unmarshal(server.Handle(context.Background(), []byte(`{"id": 1, "method": "ping"}`)), &resp)
+	if resp.Error == nil || resp.Error.Code != codeInvalidRequest {
+		t.Errorf("request without jsonrpc = %+v", resp)
+	}
+}
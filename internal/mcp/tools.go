@@ -0,0 +1,266 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+// tool is a tool advertised by tools/list
+type tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+
+	// call runs the tool with its decoded arguments
+	call func(ctx context.Context, args json.RawMessage) (interface{}, error)
+}
+
+// textContent is a content block of a tool result
+type textContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// toolResult is the result of tools/call. Failures of the tool itself, such
+// as an unknown resource, are results with IsError set so the agent can read
+// them, not JSON-RPC errors.
+type toolResult struct {
+	Content []textContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
+
+// resourceSummary is an entry of list_resources
+type resourceSummary struct {
+	Name          string   `json:"name"`
+	Documentation string   `json:"documentation,omitempty"`
+	FilePath      string   `json:"file_path,omitempty"`
+	Fields        int      `json:"fields"`
+	Relationships []string `json:"relationships,omitempty"` // "name: type Resource"
+	Hooks         int      `json:"hooks,omitempty"`
+}
+
+// schema returns the JSON Schema of an object with the given properties
+func schema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	s := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+// registryTools returns the tools backed by the server's registry
+func (s *Server) registryTools() []tool {
+	return []tool{
+		{
+			Name:        "list_resources",
+			Description: "List the resources of the application with their documentation, field counts and relationships.",
+			InputSchema: schema(map[string]interface{}{}),
+			call:        s.listResources,
+		},
+		{
+			Name:        "get_resource",
+			Description: "Get the full metadata of one resource: fields with types and constraints, relationships, hooks, validations, constraints, middleware and scopes.",
+			InputSchema: schema(map[string]interface{}{
+				"name": stringProperty("Resource name, e.g. Post"),
+			}, "name"),
+			call: s.getResource,
+		},
+		{
+			Name:        "list_routes",
+			Description: "List the HTTP routes of the application, optionally filtered by method or resource.",
+			InputSchema: schema(map[string]interface{}{
+				"method":   stringProperty("HTTP method, e.g. GET"),
+				"resource": stringProperty("Resource name, e.g. Post"),
+			}),
+			call: s.listRoutes,
+		},
+		{
+			Name:        "query_dependencies",
+			Description: "Get the dependency graph of a resource: what it depends on, or with reverse what depends on it.",
+			InputSchema: schema(map[string]interface{}{
+				"resource": stringProperty("Resource name, e.g. Post"),
+				"depth":    map[string]interface{}{"type": "integer", "minimum": 0, "description": "Maximum traversal depth; 0 is unlimited"},
+				"reverse":  map[string]interface{}{"type": "boolean", "description": "Find the resources that depend on this one"},
+				"types": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Only follow these edge types, e.g. belongs_to, has_many, uses",
+				},
+			}, "resource"),
+			call: s.queryDependencies,
+		},
+		{
+			Name:        "search_patterns",
+			Description: "Search the patterns the application's code follows, such as middleware combinations and hooks, most frequent first. Follow them when adding code.",
+			InputSchema: schema(map[string]interface{}{
+				"query":    stringProperty("Text to find in the pattern's name, description or template; empty matches all"),
+				"category": stringProperty("Pattern category, e.g. authentication, caching, hook"),
+			}),
+			call: s.searchPatterns,
+		},
+	}
+}
+
+// callTool runs the named tool and wraps its outcome as a tool result
+func (s *Server) callTool(ctx context.Context, name string, args json.RawMessage) (interface{}, *rpcError) {
+	for _, t := range s.tools {
+		if t.Name != name {
+			continue
+		}
+		if len(args) == 0 || string(args) == "null" {
+			args = json.RawMessage("{}")
+		}
+		if s.registry.GetSchema() == nil {
+			return errorResult("no metadata is loaded; run 'conduit build' first"), nil
+		}
+
+		value, err := t.call(ctx, args)
+		if err != nil {
+			return errorResult(err.Error()), nil
+		}
+		text, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to encode result: %v", err)), nil
+		}
+		return toolResult{Content: []textContent{{Type: "text", Text: string(text)}}}, nil
+	}
+	return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + name}
+}
+
+func errorResult(message string) toolResult {
+	return toolResult{Content: []textContent{{Type: "text", Text: message}}, IsError: true}
+}
+
+// decodeArgs decodes tool arguments, rejecting unknown ones so typos surface
+func decodeArgs(args json.RawMessage, v interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(string(args)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) listResources(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	resources := s.registry.Resources()
+	summaries := make([]resourceSummary, 0, len(resources))
+	for _, res := range resources {
+		summary := resourceSummary{
+			Name:          res.Name,
+			Documentation: res.Documentation,
+			FilePath:      res.FilePath,
+			Fields:        len(res.Fields),
+			Hooks:         len(res.Hooks),
+		}
+		for _, rel := range res.Relationships {
+			summary.Relationships = append(summary.Relationships, strings.TrimSpace(fmt.Sprintf("%s: %s %s", rel.Name, rel.Type, rel.TargetResource)))
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func (s *Server) getResource(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Name string `json:"name"`
+	}
+	if err := decodeArgs(args, &params); err != nil {
+		return nil, err
+	}
+	if params.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	resource, err := s.registry.Resource(params.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%v; resources are: %s", err, strings.Join(s.resourceNames(), ", "))
+	}
+	return resource, nil
+}
+
+func (s *Server) listRoutes(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Method   string `json:"method"`
+		Resource string `json:"resource"`
+	}
+	if err := decodeArgs(args, &params); err != nil {
+		return nil, err
+	}
+	routes := s.registry.Routes(metadata.RouteFilter{Method: strings.ToUpper(params.Method), Resource: params.Resource})
+	if routes == nil {
+		routes = []metadata.RouteMetadata{}
+	}
+	return routes, nil
+}
+
+func (s *Server) queryDependencies(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Resource string   `json:"resource"`
+		Depth    int      `json:"depth"`
+		Reverse  bool     `json:"reverse"`
+		Types    []string `json:"types"`
+	}
+	if err := decodeArgs(args, &params); err != nil {
+		return nil, err
+	}
+	if params.Resource == "" {
+		return nil, fmt.Errorf("resource is required")
+	}
+	if params.Depth < 0 {
+		return nil, fmt.Errorf("depth must not be negative")
+	}
+	graph, err := s.registry.Dependencies(params.Resource, metadata.DependencyOptions{
+		Depth:   params.Depth,
+		Reverse: params.Reverse,
+		Types:   params.Types,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%v; resources are: %s", err, strings.Join(s.resourceNames(), ", "))
+	}
+	return graph, nil
+}
+
+func (s *Server) searchPatterns(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Query    string `json:"query"`
+		Category string `json:"category"`
+	}
+	if err := decodeArgs(args, &params); err != nil {
+		return nil, err
+	}
+
+	query := strings.ToLower(params.Query)
+	matches := []metadata.PatternMetadata{}
+	for _, pattern := range s.registry.Patterns(params.Category) {
+		text := strings.ToLower(pattern.Name + "\n" + pattern.Description + "\n" + pattern.Template)
+		if query == "" || strings.Contains(text, query) {
+			matches = append(matches, pattern)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Frequency > matches[j].Frequency
+	})
+	return matches, nil
+}
+
+// resourceNames returns the registered resource names, sorted, to suggest
+// alternatives for a name that was not found
+func (s *Server) resourceNames() []string {
+	var names []string
+	for _, res := range s.registry.Resources() {
+		names = append(names, res.Name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -41,7 +41,7 @@ func (g *ConstraintGenerator) GenerateCheckConstraints(resource *schema.Resource
 				if checkSQL != "" {
 					constraints = append(constraints,
 						fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;",
-							QuoteIdentifier(tableName), QuoteIdentifier(checkName), checkSQL))
+							QuoteTable(resource.Schema, tableName), QuoteIdentifier(checkName), checkSQL))
 				}
 
 			case schema.ConstraintMax:
@@ -50,7 +50,7 @@ func (g *ConstraintGenerator) GenerateCheckConstraints(resource *schema.Resource
 				if checkSQL != "" {
 					constraints = append(constraints,
 						fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;",
-							QuoteIdentifier(tableName), QuoteIdentifier(checkName), checkSQL))
+							QuoteTable(resource.Schema, tableName), QuoteIdentifier(checkName), checkSQL))
 				}
 
 			case schema.ConstraintPattern:
@@ -59,7 +59,7 @@ func (g *ConstraintGenerator) GenerateCheckConstraints(resource *schema.Resource
 				if checkSQL != "" {
 					constraints = append(constraints,
 						fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;",
-							QuoteIdentifier(tableName), QuoteIdentifier(checkName), checkSQL))
+							QuoteTable(resource.Schema, tableName), QuoteIdentifier(checkName), checkSQL))
 				}
 			}
 		}
@@ -71,7 +71,7 @@ func (g *ConstraintGenerator) GenerateCheckConstraints(resource *schema.Resource
 				checkName := fmt.Sprintf("%s_%s_valid", tableName, columnName)
 				constraints = append(constraints,
 					fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;",
-						QuoteIdentifier(tableName), QuoteIdentifier(checkName), checkSQL))
+						QuoteTable(resource.Schema, tableName), QuoteIdentifier(checkName), checkSQL))
 			}
 		}
 	}
@@ -181,10 +181,10 @@ func (g *ConstraintGenerator) GenerateForeignKeyConstraints(resource *schema.Res
 
 		fkSQL := fmt.Sprintf(
 			"ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
-			QuoteIdentifier(tableName),
+			QuoteTable(resource.Schema, tableName),
 			QuoteIdentifier(constraintName),
 			QuoteIdentifier(foreignKeyColumn),
-			QuoteIdentifier(targetTable),
+			QuoteTable(targetResource.Schema, targetTable),
 			QuoteIdentifier(targetColumn),
 		)
 
@@ -247,7 +247,7 @@ func (g *ConstraintGenerator) GenerateUniqueConstraints(resource *schema.Resourc
 			constraintName := fmt.Sprintf("%s_%s_unique", tableName, columnName)
 			constraints = append(constraints,
 				fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (%s);",
-					QuoteIdentifier(tableName), QuoteIdentifier(constraintName), QuoteIdentifier(columnName)))
+					QuoteTable(resource.Schema, tableName), QuoteIdentifier(constraintName), QuoteIdentifier(columnName)))
 		}
 	}
 
@@ -303,7 +303,7 @@ func (g *ConstraintGenerator) GenerateDropConstraints(resource *schema.ResourceS
 			}
 			if constraintName != "" {
 				dropStatements = append(dropStatements,
-					fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", QuoteIdentifier(tableName), QuoteIdentifier(constraintName)))
+					fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", QuoteTable(resource.Schema, tableName), QuoteIdentifier(constraintName)))
 			}
 		}
 
@@ -311,7 +311,7 @@ func (g *ConstraintGenerator) GenerateDropConstraints(resource *schema.ResourceS
 		if field.Type.IsValidated() {
 			constraintName := fmt.Sprintf("%s_%s_valid", tableName, columnName)
 			dropStatements = append(dropStatements,
-				fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", QuoteIdentifier(tableName), QuoteIdentifier(constraintName)))
+				fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", QuoteTable(resource.Schema, tableName), QuoteIdentifier(constraintName)))
 		}
 	}
 
@@ -328,7 +328,7 @@ func (g *ConstraintGenerator) GenerateDropConstraints(resource *schema.ResourceS
 			columnName := toSnakeCase(fieldName)
 			constraintName := fmt.Sprintf("%s_%s_unique", tableName, columnName)
 			dropStatements = append(dropStatements,
-				fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", QuoteIdentifier(tableName), QuoteIdentifier(constraintName)))
+				fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", QuoteTable(resource.Schema, tableName), QuoteIdentifier(constraintName)))
 		}
 	}
 
@@ -347,7 +347,7 @@ func (g *ConstraintGenerator) GenerateDropConstraints(resource *schema.ResourceS
 
 		constraintName := fmt.Sprintf("%s_%s_fkey", tableName, foreignKeyColumn)
 		dropStatements = append(dropStatements,
-			fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", QuoteIdentifier(tableName), QuoteIdentifier(constraintName)))
+			fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", QuoteTable(resource.Schema, tableName), QuoteIdentifier(constraintName)))
 	}
 
 	// Sort for deterministic output
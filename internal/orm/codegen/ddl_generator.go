@@ -35,20 +35,63 @@ func (g *DDLGenerator) GenerateCreateTable(resource *schema.ResourceSchema) (str
 		tableName = toSnakeCase(resource.Name)
 	}
 
-	b.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n", QuoteIdentifier(tableName)))
+	if resource.External != "" {
+		return "", fmt.Errorf("resource %s reads external table %s, which is not migrated", resource.Name, resource.External)
+	}
+
+	if resource.Materialized != nil {
+		return g.generateCreateView(resource, tableName), nil
+	}
+
+	// A table in a @schema creates its schema first, so each table's DDL
+	// stands on its own in a migration
+	if resource.Schema != "" {
+		b.WriteString(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;\n", QuoteIdentifier(resource.Schema)))
+	}
+	b.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n", QuoteTable(resource.Schema, tableName)))
 
 	// Collect and sort fields for optimal column ordering
 	// Fixed-length types first, then variable-length types
 	fields := g.orderFields(resource)
 
+	// A partitioned table's primary key must include the partition key, so it
+	// is declared as a table constraint instead of inline, as is a composite
+	// key
+	partitioned := resource.Partition != nil
+	constraint := partitioned || len(resource.PrimaryKey) > 0
+	var primaryKey []string
+	for _, name := range resource.PrimaryKey {
+		primaryKey = append(primaryKey, QuoteIdentifier(toSnakeCase(name)))
+	}
+
 	// Generate column definitions
-	columnDefs := make([]string, 0, len(fields))
+	columnDefs := make([]string, 0, len(fields)+1)
 	for _, field := range fields {
-		columnDef, err := g.generateColumnDefinition(resource.Name, field)
+		columnDef, err := g.generateColumnDefinition(resource.Name, field, !constraint)
 		if err != nil {
 			return "", fmt.Errorf("field %s: %w", field.Name, err)
 		}
 		columnDefs = append(columnDefs, columnDef)
+		if constraint && len(resource.PrimaryKey) == 0 && hasAnnotation(field, "primary") {
+			primaryKey = append(primaryKey, QuoteIdentifier(toSnakeCase(field.Name)))
+		}
+	}
+
+	var partitionColumn string
+	if partitioned {
+		partitionColumn = QuoteIdentifier(toSnakeCase(resource.Partition.Field))
+		inKey := false
+		for _, column := range primaryKey {
+			if column == partitionColumn {
+				inKey = true
+			}
+		}
+		if len(primaryKey) > 0 && !inKey {
+			primaryKey = append(primaryKey, partitionColumn)
+		}
+	}
+	if len(primaryKey) > 0 {
+		columnDefs = append(columnDefs, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKey, ", ")))
 	}
 
 	// Write column definitions
@@ -61,13 +104,40 @@ func (g *DDLGenerator) GenerateCreateTable(resource *schema.ResourceSchema) (str
 		b.WriteString("\n")
 	}
 
-	b.WriteString(");")
+	if !partitioned {
+		b.WriteString(");")
+		return b.String(), nil
+	}
+
+	// Range partitions are created by the application ahead of time; the
+	// default partition catches rows outside them
+	b.WriteString(fmt.Sprintf(") PARTITION BY RANGE (%s);\n", partitionColumn))
+	b.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s DEFAULT;",
+		QuoteTable(resource.Schema, tableName+"_default"), QuoteTable(resource.Schema, tableName)))
 
 	return b.String(), nil
 }
 
-// generateColumnDefinition generates a column definition for a field
-func (g *DDLGenerator) generateColumnDefinition(resourceName string, field *schema.Field) (string, error) {
+// generateCreateView generates the CREATE MATERIALIZED VIEW statement for a
+// @materialized resource. Concurrent refreshes need a unique index, which is
+// declared on id.
+func (g *DDLGenerator) generateCreateView(resource *schema.ResourceSchema, viewName string) string {
+	var b strings.Builder
+	if resource.Schema != "" {
+		b.WriteString(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;\n", QuoteIdentifier(resource.Schema)))
+	}
+	b.WriteString(fmt.Sprintf("CREATE MATERIALIZED VIEW IF NOT EXISTS %s AS\n%s;", QuoteTable(resource.Schema, viewName), resource.Materialized.Query))
+	if _, ok := resource.Fields["id"]; ok {
+		b.WriteString(fmt.Sprintf("\nCREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s);",
+			QuoteIdentifier("idx_"+viewName+"_id"), QuoteTable(resource.Schema, viewName), QuoteIdentifier("id")))
+	}
+	return b.String()
+}
+
+// generateColumnDefinition generates a column definition for a field.
+// inlinePrimaryKey is false for partitioned tables, whose primary key is a
+// table constraint.
+func (g *DDLGenerator) generateColumnDefinition(resourceName string, field *schema.Field, inlinePrimaryKey bool) (string, error) {
 	var parts []string
 
 	// Column name (quoted to prevent SQL injection)
@@ -128,11 +198,8 @@ func (g *DDLGenerator) generateColumnDefinition(resourceName string, field *sche
 	}
 
 	// Primary key constraint (inline)
-	for _, annotation := range field.Annotations {
-		if annotation.Name == "primary" {
-			parts = append(parts, "PRIMARY KEY")
-			break
-		}
+	if inlinePrimaryKey && hasAnnotation(field, "primary") {
+		parts = append(parts, "PRIMARY KEY")
 	}
 
 	return strings.Join(parts, " "), nil
@@ -272,14 +339,18 @@ func (g *DDLGenerator) GenerateSchema(resource *schema.ResourceSchema) (string,
 	return b.String(), nil
 }
 
-// GenerateDropTable generates a DROP TABLE statement
+// GenerateDropTable generates a DROP TABLE statement, or DROP MATERIALIZED
+// VIEW for a @materialized resource
 func (g *DDLGenerator) GenerateDropTable(resource *schema.ResourceSchema) string {
 	tableName := resource.TableName
 	if tableName == "" {
 		tableName = toSnakeCase(resource.Name)
 	}
 
-	return fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE;", QuoteIdentifier(tableName))
+	if resource.Materialized != nil {
+		return fmt.Sprintf("DROP MATERIALIZED VIEW IF EXISTS %s CASCADE;", QuoteTable(resource.Schema, tableName))
+	}
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE;", QuoteTable(resource.Schema, tableName))
 }
 
 // GenerateDropEnumTypes generates DROP TYPE statements for all enum fields
@@ -298,3 +369,13 @@ func (g *DDLGenerator) GenerateDropEnumTypes(resource *schema.ResourceSchema) []
 
 	return dropStatements
 }
+
+// hasAnnotation reports whether a field carries the named annotation
+func hasAnnotation(field *schema.Field, name string) bool {
+	for _, annotation := range field.Annotations {
+		if annotation.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -49,6 +49,129 @@ func TestDDLGenerator_GenerateCreateTable_Simple(t *testing.T) {
 	}
 }
 
+func TestDDLGenerator_GenerateCreateTable_Partitioned(t *testing.T) {
+	gen := NewDDLGenerator()
+
+	resource := schema.NewResourceSchema("Event")
+	resource.Fields["id"] = &schema.Field{
+		Name:        "id",
+		Type:        &schema.TypeSpec{BaseType: schema.TypeUUID},
+		Annotations: []schema.Annotation{{Name: "primary"}, {Name: "auto"}},
+	}
+	resource.Fields["created_at"] = &schema.Field{
+		Name:        "created_at",
+		Type:        &schema.TypeSpec{BaseType: schema.TypeTimestamp},
+		Annotations: []schema.Annotation{{Name: "auto"}},
+	}
+	resource.Partition = &schema.Partition{Field: "created_at", Interval: "month"}
+
+	result, err := gen.GenerateCreateTable(resource)
+	if err != nil {
+		t.Fatalf("GenerateCreateTable() error = %v", err)
+	}
+
+	expected := []string{
+		`"id" UUID NOT NULL DEFAULT gen_random_uuid(),`,
+		`  PRIMARY KEY ("id", "created_at")` + "\n" + `) PARTITION BY RANGE ("created_at");`,
+		`CREATE TABLE IF NOT EXISTS "event_default" PARTITION OF "event" DEFAULT;`,
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("GenerateCreateTable() missing %q\nGot:\n%s", exp, result)
+		}
+	}
+	if strings.Contains(result, "gen_random_uuid() PRIMARY KEY") {
+		t.Errorf("partitioned table should not declare an inline primary key:\n%s", result)
+	}
+}
+
+func TestDDLGenerator_GenerateCreateTable_CompositeKey(t *testing.T) {
+	gen := NewDDLGenerator()
+
+	resource := schema.NewResourceSchema("Membership")
+	resource.Fields["region"] = &schema.Field{
+		Name: "region",
+		Type: &schema.TypeSpec{BaseType: schema.TypeString},
+	}
+	resource.Fields["code"] = &schema.Field{
+		Name: "code",
+		Type: &schema.TypeSpec{BaseType: schema.TypeInt},
+	}
+	resource.PrimaryKey = []string{"region", "code"}
+
+	result, err := gen.GenerateCreateTable(resource)
+	if err != nil {
+		t.Fatalf("GenerateCreateTable() error = %v", err)
+	}
+	if !strings.Contains(result, `  PRIMARY KEY ("region", "code")`+"\n);") {
+		t.Errorf("GenerateCreateTable() missing composite primary key\nGot:\n%s", result)
+	}
+}
+
+func TestDDLGenerator_GenerateCreateTable_Schema(t *testing.T) {
+	gen := NewDDLGenerator()
+
+	resource := schema.NewResourceSchema("Invoice")
+	resource.Schema = "billing"
+	resource.Fields["id"] = &schema.Field{
+		Name:        "id",
+		Type:        &schema.TypeSpec{BaseType: schema.TypeUUID},
+		Annotations: []schema.Annotation{{Name: "primary"}, {Name: "auto"}},
+	}
+
+	result, err := gen.GenerateCreateTable(resource)
+	if err != nil {
+		t.Fatalf("GenerateCreateTable() error = %v", err)
+	}
+	want := `CREATE SCHEMA IF NOT EXISTS "billing";` + "\n" + `CREATE TABLE IF NOT EXISTS "billing"."invoice" (`
+	if !strings.HasPrefix(result, want) {
+		t.Errorf("GenerateCreateTable() should create the schema and qualify the table\nGot:\n%s", result)
+	}
+
+	if got := gen.GenerateDropTable(resource); got != `DROP TABLE IF EXISTS "billing"."invoice" CASCADE;` {
+		t.Errorf("GenerateDropTable() = %q", got)
+	}
+}
+
+func TestDDLGenerator_GenerateCreateTable_Materialized(t *testing.T) {
+	gen := NewDDLGenerator()
+
+	resource := schema.NewResourceSchema("PostStats")
+	resource.Fields["id"] = &schema.Field{
+		Name: "id",
+		Type: &schema.TypeSpec{BaseType: schema.TypeUUID},
+	}
+	resource.Materialized = &schema.Materialized{
+		Query:   "SELECT p.id, count(*) AS comment_count FROM post p JOIN comment c ON c.post_id = p.id GROUP BY p.id",
+		Refresh: "hourly",
+	}
+
+	result, err := gen.GenerateCreateTable(resource)
+	if err != nil {
+		t.Fatalf("GenerateCreateTable() error = %v", err)
+	}
+
+	expected := `CREATE MATERIALIZED VIEW IF NOT EXISTS "post_stats" AS
+SELECT p.id, count(*) AS comment_count FROM post p JOIN comment c ON c.post_id = p.id GROUP BY p.id;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_post_stats_id" ON "post_stats" ("id");`
+	if result != expected {
+		t.Errorf("GenerateCreateTable() =\n%s\nwant:\n%s", result, expected)
+	}
+
+	if got := gen.GenerateDropTable(resource); got != `DROP MATERIALIZED VIEW IF EXISTS "post_stats" CASCADE;` {
+		t.Errorf("GenerateDropTable() = %s", got)
+	}
+}
+
+func TestDDLGenerator_GenerateCreateTable_External(t *testing.T) {
+	resource := schema.NewResourceSchema("LegacyUser")
+	resource.External = "legacy.users"
+
+	if _, err := NewDDLGenerator().GenerateCreateTable(resource); err == nil {
+		t.Error("GenerateCreateTable() should refuse an external table")
+	}
+}
+
 func TestDDLGenerator_GenerateCreateTable_AllTypes(t *testing.T) {
 	gen := NewDDLGenerator()
 
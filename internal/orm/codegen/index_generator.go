@@ -42,12 +42,19 @@ func (g *IndexGenerator) GenerateIndexes(resource *schema.ResourceSchema) []stri
 			}
 		}
 
-		// Generate index for @index fields
-		if hasIndex {
+		// Spatial fields always get a GiST index, which serves near filters
+		// and stands in for any @index
+		if field.Type != nil && field.Type.IsSpatial() {
+			indexName := fmt.Sprintf("idx_%s_%s", tableName, columnName)
+			indexes = append(indexes,
+				fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING GIST (%s);",
+					QuoteIdentifier(indexName), QuoteTable(resource.Schema, tableName), QuoteIdentifier(columnName)))
+		} else if hasIndex {
+			// Generate index for @index fields
 			indexName := fmt.Sprintf("idx_%s_%s", tableName, columnName)
 			indexes = append(indexes,
 				fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s);",
-					QuoteIdentifier(indexName), QuoteIdentifier(tableName), QuoteIdentifier(columnName)))
+					QuoteIdentifier(indexName), QuoteTable(resource.Schema, tableName), QuoteIdentifier(columnName)))
 		}
 
 		// Generate unique index for @unique fields
@@ -55,16 +62,47 @@ func (g *IndexGenerator) GenerateIndexes(resource *schema.ResourceSchema) []stri
 			indexName := fmt.Sprintf("idx_%s_%s_unique", tableName, columnName)
 			indexes = append(indexes,
 				fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s);",
-					QuoteIdentifier(indexName), QuoteIdentifier(tableName), QuoteIdentifier(columnName)))
+					QuoteIdentifier(indexName), QuoteTable(resource.Schema, tableName), QuoteIdentifier(columnName)))
 		}
 	}
 
+	// Indexes declared with index blocks
+	for _, index := range resource.Indexes {
+		indexes = append(indexes, g.GenerateIndex(resource, index))
+	}
+
 	// Sort for deterministic output
 	sort.Strings(indexes)
 
 	return indexes
 }
 
+// GenerateIndex generates the CREATE INDEX statement of an index block of
+// resource
+func (g *IndexGenerator) GenerateIndex(resource *schema.ResourceSchema, index *schema.Index) string {
+	tableName := resource.TableName
+	if tableName == "" {
+		tableName = toSnakeCase(resource.Name)
+	}
+	quotedColumns := make([]string, len(index.Columns))
+	for i, col := range index.Columns {
+		quotedColumns[i] = QuoteIdentifier(col)
+	}
+
+	create := "CREATE INDEX"
+	if index.Unique {
+		create = "CREATE UNIQUE INDEX"
+	}
+	return fmt.Sprintf("%s IF NOT EXISTS %s ON %s (%s);",
+		create, QuoteIdentifier(index.Name), QuoteTable(resource.Schema, tableName), strings.Join(quotedColumns, ", "))
+}
+
+// GenerateDropIndex generates the DROP INDEX statement of an index block of
+// resource
+func (g *IndexGenerator) GenerateDropIndex(resource *schema.ResourceSchema, index *schema.Index) string {
+	return fmt.Sprintf("DROP INDEX IF EXISTS %s;", QuoteTable(resource.Schema, index.Name))
+}
+
 // GenerateForeignKeyIndexes generates indexes on foreign key columns
 func (g *IndexGenerator) GenerateForeignKeyIndexes(resource *schema.ResourceSchema) []string {
 	var indexes []string
@@ -103,7 +141,7 @@ func (g *IndexGenerator) GenerateForeignKeyIndexes(resource *schema.ResourceSche
 			indexName := fmt.Sprintf("idx_%s_%s", tableName, foreignKeyColumn)
 			indexes = append(indexes,
 				fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s);",
-					QuoteIdentifier(indexName), QuoteIdentifier(tableName), QuoteIdentifier(foreignKeyColumn)))
+					QuoteIdentifier(indexName), QuoteTable(resource.Schema, tableName), QuoteIdentifier(foreignKeyColumn)))
 		}
 	}
 
@@ -177,6 +215,11 @@ func (g *IndexGenerator) GenerateDropIndexes(resource *schema.ResourceSchema) []
 		}
 	}
 
+	// Drop the indexes of index blocks
+	for _, index := range resource.Indexes {
+		dropStatements = append(dropStatements, g.GenerateDropIndex(resource, index))
+	}
+
 	// Drop foreign key indexes
 	for _, rel := range resource.Relationships {
 		if rel.Type != schema.RelationshipBelongsTo {
@@ -29,6 +29,28 @@ func TestIndexGenerator_GenerateIndexes_Basic(t *testing.T) {
 	}
 }
 
+func TestIndexGenerator_GenerateIndexes_Blocks(t *testing.T) {
+	gen := NewIndexGenerator()
+
+	resource := schema.NewResourceSchema("Post")
+	resource.Schema = "blog"
+	resource.Indexes = []*schema.Index{
+		{Name: "idx_post_author_id_published_at", Columns: []string{"author_id", "published_at"}, Unique: true},
+	}
+
+	result := gen.GenerateIndexes(resource)
+	expected := `CREATE UNIQUE INDEX IF NOT EXISTS "idx_post_author_id_published_at" ON "blog"."post" ("author_id", "published_at");`
+	if len(result) != 1 || result[0] != expected {
+		t.Errorf("GenerateIndexes() = %q, want [%q]", result, expected)
+	}
+
+	drops := gen.GenerateDropIndexes(resource)
+	expected = `DROP INDEX IF EXISTS "blog"."idx_post_author_id_published_at";`
+	if len(drops) != 1 || drops[0] != expected {
+		t.Errorf("GenerateDropIndexes() = %q, want [%q]", drops, expected)
+	}
+}
+
 func TestIndexGenerator_GenerateIndexes_Unique(t *testing.T) {
 	gen := NewIndexGenerator()
 
@@ -51,6 +73,31 @@ func TestIndexGenerator_GenerateIndexes_Unique(t *testing.T) {
 	}
 }
 
+func TestIndexGenerator_GenerateIndexes_Spatial(t *testing.T) {
+	gen := NewIndexGenerator()
+
+	resource := schema.NewResourceSchema("Store")
+	resource.Fields["location"] = &schema.Field{
+		Name:        "location",
+		Type:        &schema.TypeSpec{BaseType: schema.TypePoint, Nullable: false},
+		Annotations: []schema.Annotation{{Name: "index"}},
+	}
+	resource.Fields["area"] = &schema.Field{
+		Name: "area",
+		Type: &schema.TypeSpec{BaseType: schema.TypePolygon, Nullable: true},
+	}
+
+	result := gen.GenerateIndexes(resource)
+
+	expected := []string{
+		`CREATE INDEX IF NOT EXISTS "idx_store_area" ON "store" USING GIST ("area");`,
+		`CREATE INDEX IF NOT EXISTS "idx_store_location" ON "store" USING GIST ("location");`,
+	}
+	if strings.Join(result, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("GenerateIndexes() = %q, want %q", result, expected)
+	}
+}
+
 func TestIndexGenerator_GenerateIndexes_Multiple(t *testing.T) {
 	gen := NewIndexGenerator()
 
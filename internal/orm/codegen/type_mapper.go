@@ -109,6 +109,12 @@ func (tm *TypeMapper) mapPrimitiveType(typeSpec *schema.TypeSpec) (string, error
 	case schema.TypeJSONB:
 		return "JSONB", nil
 
+	case schema.TypePoint:
+		return "GEOGRAPHY(Point, 4326)", nil
+
+	case schema.TypePolygon:
+		return "GEOGRAPHY(Polygon, 4326)", nil
+
 	default:
 		return "", fmt.Errorf("unsupported type: %s", typeSpec.BaseType)
 	}
@@ -291,3 +297,12 @@ func QuoteIdentifier(identifier string) string {
 	escaped := strings.ReplaceAll(identifier, `"`, `""`)
 	return fmt.Sprintf(`"%s"`, escaped)
 }
+
+// QuoteTable quotes a table name, qualified with its schema when it has one,
+// e.g. "billing"."invoice"
+func QuoteTable(schemaName, tableName string) string {
+	if schemaName == "" {
+		return QuoteIdentifier(tableName)
+	}
+	return QuoteIdentifier(schemaName) + "." + QuoteIdentifier(tableName)
+}
@@ -37,6 +37,8 @@ func TestTypeMapper_MapPrimitiveTypes(t *testing.T) {
 		{"phone", schema.TypePhone, nil, nil, nil, "VARCHAR(255)"},
 		{"json", schema.TypeJSON, nil, nil, nil, "JSON"},
 		{"jsonb", schema.TypeJSONB, nil, nil, nil, "JSONB"},
+		{"point", schema.TypePoint, nil, nil, nil, "GEOGRAPHY(Point, 4326)"},
+		{"polygon", schema.TypePolygon, nil, nil, nil, "GEOGRAPHY(Polygon, 4326)"},
 	}
 
 	for _, tt := range tests {
@@ -2,6 +2,7 @@ package migrate
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
@@ -70,12 +71,37 @@ type Differ struct {
 	newSchemas map[string]*schema.ResourceSchema
 }
 
-// NewDiffer creates a new schema differ
+// NewDiffer creates a new schema differ. Resources reading an
+// @external_table in either schema are left out: their tables are not the
+// application's to create, alter or drop.
 func NewDiffer(oldSchemas, newSchemas map[string]*schema.ResourceSchema) *Differ {
+	external := make(map[string]bool)
+	for _, schemas := range []map[string]*schema.ResourceSchema{oldSchemas, newSchemas} {
+		for name, resource := range schemas {
+			if resource.External != "" {
+				external[name] = true
+			}
+		}
+	}
 	return &Differ{
-		oldSchemas: oldSchemas,
-		newSchemas: newSchemas,
+		oldSchemas: withoutResources(oldSchemas, external),
+		newSchemas: withoutResources(newSchemas, external),
+	}
+}
+
+// withoutResources returns schemas without the named resources, or schemas
+// itself when it has none of them
+func withoutResources(schemas map[string]*schema.ResourceSchema, names map[string]bool) map[string]*schema.ResourceSchema {
+	if len(names) == 0 {
+		return schemas
+	}
+	kept := make(map[string]*schema.ResourceSchema, len(schemas))
+	for name, resource := range schemas {
+		if !names[name] {
+			kept[name] = resource
+		}
 	}
+	return kept
 }
 
 // ComputeDiff computes all changes between old and new schemas
@@ -86,15 +112,24 @@ func (d *Differ) ComputeDiff() []SchemaChange {
 	oldNames := getSortedResourceNames(d.oldSchemas)
 	newNames := getSortedResourceNames(d.newSchemas)
 
-	// Detect added resources
+	// Detect added resources. Materialized views are created after the
+	// tables they may read, and the foreign keys of new tables after all
+	// tables they may reference.
+	var addedViews, addedRelationships []SchemaChange
 	for _, name := range setDifference(newNames, oldNames) {
-		changes = append(changes, SchemaChange{
+		change := SchemaChange{
 			Type:     ChangeAddResource,
 			Resource: name,
 			NewValue: d.newSchemas[name],
 			Breaking: false,
 			DataLoss: false,
-		})
+		}
+		if d.newSchemas[name].Materialized != nil {
+			addedViews = append(addedViews, change)
+			continue
+		}
+		changes = append(changes, change)
+		addedRelationships = append(addedRelationships, d.newRelationships(name, d.newSchemas[name])...)
 	}
 
 	// Detect dropped resources
@@ -104,7 +139,7 @@ func (d *Differ) ComputeDiff() []SchemaChange {
 			Resource: name,
 			OldValue: d.oldSchemas[name],
 			Breaking: true,
-			DataLoss: true,
+			DataLoss: d.oldSchemas[name].Materialized == nil,
 		})
 	}
 
@@ -113,13 +148,102 @@ func (d *Differ) ComputeDiff() []SchemaChange {
 		oldRes := d.oldSchemas[name]
 		newRes := d.newSchemas[name]
 
+		// A materialized view can't be altered; it is dropped and recreated
+		// from its query, which loses nothing
+		if oldRes.Materialized != nil || newRes.Materialized != nil {
+			if !d.viewsEqual(oldRes, newRes) {
+				changes = append(changes, SchemaChange{
+					Type:     ChangeDropResource,
+					Resource: name,
+					OldValue: oldRes,
+					Breaking: true,
+					DataLoss: oldRes.Materialized == nil,
+				})
+				addedViews = append(addedViews, SchemaChange{
+					Type:     ChangeAddResource,
+					Resource: name,
+					NewValue: newRes,
+				})
+			}
+			continue
+		}
+
+		// Indexes are dropped before the columns they cover change, and
+		// created after, so the down migration restores columns first too
+		droppedIndexes, addedIndexes := d.diffIndexes(name, oldRes, newRes)
+		changes = append(changes, droppedIndexes...)
 		changes = append(changes, d.diffFields(name, oldRes, newRes)...)
 		changes = append(changes, d.diffRelationships(name, oldRes, newRes)...)
+		changes = append(changes, addedIndexes...)
 	}
 
+	changes = append(changes, addedRelationships...)
+	return append(changes, addedViews...)
+}
+
+// newRelationships returns the relationships of an added resource that have
+// foreign keys as added relationships, so the keys are created with the
+// table. A has_one whose target declares the inverse belongs_to is skipped,
+// since both would create the same constraint.
+func (d *Differ) newRelationships(resourceName string, res *schema.ResourceSchema) []SchemaChange {
+	var changes []SchemaChange
+	for _, relName := range getSortedRelationshipNames(res.Relationships) {
+		rel := res.Relationships[relName]
+		if !hasForeignKey(rel) {
+			continue
+		}
+		if rel.Type == schema.RelationshipHasOne && d.hasInverseBelongsTo(resourceName, rel) {
+			continue
+		}
+		changes = append(changes, SchemaChange{
+			Type:     ChangeAddRelationship,
+			Resource: resourceName,
+			Relation: relName,
+			NewValue: rel,
+		})
+	}
 	return changes
 }
 
+// hasInverseBelongsTo reports whether the target of owner's has_one declares
+// a belongs_to back to owner on the same column
+func (d *Differ) hasInverseBelongsTo(owner string, hasOne *schema.Relationship) bool {
+	target := d.newSchemas[hasOne.TargetResource]
+	if target == nil {
+		return false
+	}
+	column := hasOne.ForeignKey
+	if column == "" {
+		column = toSnakeCase(owner) + "_id"
+	}
+	for _, rel := range target.Relationships {
+		if rel.Type != schema.RelationshipBelongsTo || rel.TargetResource != owner {
+			continue
+		}
+		foreignKey := rel.ForeignKey
+		if foreignKey == "" {
+			foreignKey = toSnakeCase(owner) + "_id"
+		}
+		if foreignKey == column {
+			return true
+		}
+	}
+	return false
+}
+
+// viewsEqual reports whether a resource that is a materialized view in either
+// schema needs no migration: it is a view in both, with the same query and
+// fields
+func (d *Differ) viewsEqual(oldRes, newRes *schema.ResourceSchema) bool {
+	if oldRes.Materialized == nil || newRes.Materialized == nil {
+		return false
+	}
+	if oldRes.Materialized.Query != newRes.Materialized.Query {
+		return false
+	}
+	return len(d.diffFields(newRes.Name, oldRes, newRes)) == 0
+}
+
 // diffFields compares fields between old and new resource
 func (d *Differ) diffFields(resourceName string, oldRes, newRes *schema.ResourceSchema) []SchemaChange {
 	var changes []SchemaChange
@@ -232,6 +356,47 @@ func (d *Differ) diffRelationships(resourceName string, oldRes, newRes *schema.R
 	return changes
 }
 
+// diffIndexes compares the index blocks of old and new resource by name. An
+// index whose columns or uniqueness changed is dropped and created again.
+func (d *Differ) diffIndexes(resourceName string, oldRes, newRes *schema.ResourceSchema) (dropped, added []SchemaChange) {
+	oldIndexes := make(map[string]*schema.Index, len(oldRes.Indexes))
+	for _, index := range oldRes.Indexes {
+		oldIndexes[index.Name] = index
+	}
+	newIndexes := make(map[string]*schema.Index, len(newRes.Indexes))
+	for _, index := range newRes.Indexes {
+		newIndexes[index.Name] = index
+	}
+
+	for _, index := range oldRes.Indexes {
+		if newIndex, exists := newIndexes[index.Name]; !exists || !indexesEqual(index, newIndex) {
+			dropped = append(dropped, SchemaChange{
+				Type:     ChangeDropIndex,
+				Resource: resourceName,
+				OldValue: index,
+			})
+		}
+	}
+	for _, index := range newRes.Indexes {
+		if oldIndex, exists := oldIndexes[index.Name]; !exists || !indexesEqual(oldIndex, index) {
+			added = append(added, SchemaChange{
+				Type:     ChangeAddIndex,
+				Resource: resourceName,
+				NewValue: index,
+				// A new unique index fails on existing duplicates
+				Breaking: index.Unique,
+			})
+		}
+	}
+	return dropped, added
+}
+
+// indexesEqual checks if two indexes cover the same columns in the same order
+// with the same uniqueness
+func indexesEqual(old, new *schema.Index) bool {
+	return old.Unique == new.Unique && slices.Equal(old.Columns, new.Columns)
+}
+
 // fieldsEqual checks if two fields are equal
 func (d *Differ) fieldsEqual(old, new *schema.Field) bool {
 	if old.Name != new.Name {
@@ -622,6 +787,10 @@ func GenerateMigrationName(changes []SchemaChange) string {
 		case ChangeModifyField:
 			modified = append(modified, fmt.Sprintf("%s.%s", change.Resource, change.Field))
 			fields++
+		case ChangeAddIndex:
+			added = append(added, change.NewValue.(*schema.Index).Name)
+		case ChangeDropIndex:
+			dropped = append(dropped, change.OldValue.(*schema.Index).Name)
 		}
 	}
 
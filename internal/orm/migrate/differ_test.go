@@ -1,6 +1,7 @@
 package migrate
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/conduit-lang/conduit/internal/orm/schema"
@@ -40,6 +41,53 @@ func TestDiffer_ComputeDiff_AddResource(t *testing.T) {
 	}
 }
 
+func TestDiffer_ComputeDiff_AddResourceWithRelationships(t *testing.T) {
+	oldSchemas := map[string]*schema.ResourceSchema{}
+	newSchemas := map[string]*schema.ResourceSchema{
+		"Post": {
+			Name:   "Post",
+			Fields: map[string]*schema.Field{},
+			Relationships: map[string]*schema.Relationship{
+				"author": {Type: schema.RelationshipBelongsTo, TargetResource: "User", ForeignKey: "user_id"},
+				// No foreign key
+				"tags": {Type: schema.RelationshipHasManyThrough, TargetResource: "Tag", ThroughResource: "post_tags"},
+			},
+		},
+		"Profile": {
+			Name:   "Profile",
+			Fields: map[string]*schema.Field{},
+			Relationships: map[string]*schema.Relationship{
+				"user": {Type: schema.RelationshipBelongsTo, TargetResource: "User"},
+			},
+		},
+		"User": {
+			Name:   "User",
+			Fields: map[string]*schema.Field{},
+			Relationships: map[string]*schema.Relationship{
+				// Same constraint as Profile.user
+				"profile": {Type: schema.RelationshipHasOne, TargetResource: "Profile"},
+			},
+		},
+	}
+
+	changes := NewDiffer(oldSchemas, newSchemas).ComputeDiff()
+
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.Type.String()+" "+change.Resource+" "+change.Relation)
+	}
+	want := []string{
+		"add_resource Post ",
+		"add_resource Profile ",
+		"add_resource User ",
+		"add_relationship Post author",
+		"add_relationship Profile user",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("changes =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
 func TestDiffer_ComputeDiff_DropResource(t *testing.T) {
 	oldSchemas := map[string]*schema.ResourceSchema{
 		"User": {
@@ -364,6 +412,149 @@ func TestDiffer_ComputeDiff_NoChanges(t *testing.T) {
 	}
 }
 
+func TestDiffer_ComputeDiff_Materialized(t *testing.T) {
+	view := func(query string) *schema.ResourceSchema {
+		return &schema.ResourceSchema{
+			Name: "PostStats",
+			Fields: map[string]*schema.Field{
+				"id": {Name: "id", Type: &schema.TypeSpec{BaseType: schema.TypeUUID}},
+			},
+			Materialized: &schema.Materialized{Query: query, Refresh: "hourly"},
+		}
+	}
+	post := &schema.ResourceSchema{Name: "Post", Fields: map[string]*schema.Field{}}
+
+	// New views are added after the tables they read
+	changes := NewDiffer(map[string]*schema.ResourceSchema{}, map[string]*schema.ResourceSchema{
+		"PostStats": view("SELECT id FROM post"),
+		"Post":      post,
+	}).ComputeDiff()
+	if len(changes) != 2 || changes[0].Resource != "Post" || changes[1].Resource != "PostStats" {
+		t.Fatalf("Expected Post then PostStats to be added, got %+v", changes)
+	}
+
+	// An unchanged view needs no migration
+	oldSchemas := map[string]*schema.ResourceSchema{"PostStats": view("SELECT id FROM post")}
+	if changes := NewDiffer(oldSchemas, oldSchemas).ComputeDiff(); len(changes) != 0 {
+		t.Errorf("Expected no changes, got %d", len(changes))
+	}
+
+	// A changed query drops and recreates the view without data loss
+	changes = NewDiffer(oldSchemas, map[string]*schema.ResourceSchema{
+		"PostStats": view("SELECT id FROM post WHERE published"),
+	}).ComputeDiff()
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %d", len(changes))
+	}
+	if changes[0].Type != ChangeDropResource || changes[1].Type != ChangeAddResource {
+		t.Errorf("Expected drop then add, got %v then %v", changes[0].Type, changes[1].Type)
+	}
+	if changes[0].DataLoss {
+		t.Error("Recreating a materialized view should not cause data loss")
+	}
+
+	migration, err := NewGenerator().GenerateMigration(oldSchemas, map[string]*schema.ResourceSchema{
+		"PostStats": view("SELECT id FROM post WHERE published"),
+	})
+	if err != nil {
+		t.Fatalf("GenerateMigration() error = %v", err)
+	}
+	if !strings.Contains(migration.Up, `DROP MATERIALIZED VIEW IF EXISTS "poststatss" CASCADE;`) ||
+		!strings.Contains(migration.Up, "SELECT id FROM post WHERE published;") {
+		t.Errorf("Up migration should recreate the view:\n%s", migration.Up)
+	}
+	if !strings.Contains(migration.Down, "SELECT id FROM post;") {
+		t.Errorf("Down migration should restore the old view:\n%s", migration.Down)
+	}
+}
+
+func TestDiffer_ComputeDiff_External(t *testing.T) {
+	legacyUser := func(external string, fields ...string) *schema.ResourceSchema {
+		resource := &schema.ResourceSchema{Name: "LegacyUser", Fields: map[string]*schema.Field{}, External: external}
+		for _, name := range fields {
+			resource.Fields[name] = &schema.Field{Name: name, Type: &schema.TypeSpec{BaseType: schema.TypeString}}
+		}
+		return resource
+	}
+	post := &schema.ResourceSchema{Name: "Post", Fields: map[string]*schema.Field{}}
+
+	tests := []struct {
+		name       string
+		oldSchemas map[string]*schema.ResourceSchema
+		newSchemas map[string]*schema.ResourceSchema
+	}{
+		{"added", map[string]*schema.ResourceSchema{}, map[string]*schema.ResourceSchema{"LegacyUser": legacyUser("legacy.users")}},
+		{"field added", map[string]*schema.ResourceSchema{"LegacyUser": legacyUser("legacy.users")}, map[string]*schema.ResourceSchema{"LegacyUser": legacyUser("legacy.users", "email")}},
+		{"removed", map[string]*schema.ResourceSchema{"LegacyUser": legacyUser("legacy.users")}, map[string]*schema.ResourceSchema{}},
+		{"owned table made external", map[string]*schema.ResourceSchema{"LegacyUser": legacyUser("")}, map[string]*schema.ResourceSchema{"LegacyUser": legacyUser("legacy.users")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.oldSchemas["Post"] = post
+			tt.newSchemas["Post"] = post
+			if changes := NewDiffer(tt.oldSchemas, tt.newSchemas).ComputeDiff(); len(changes) != 0 {
+				t.Errorf("Expected external tables to be skipped, got %+v", changes)
+			}
+		})
+	}
+}
+
+func TestDiffer_ComputeDiff_Indexes(t *testing.T) {
+	postWith := func(indexes ...*schema.Index) map[string]*schema.ResourceSchema {
+		return map[string]*schema.ResourceSchema{
+			"Post": {
+				Name: "Post",
+				Fields: map[string]*schema.Field{
+					"status": {Name: "status", Type: &schema.TypeSpec{BaseType: schema.TypeString}},
+				},
+				Relationships: map[string]*schema.Relationship{},
+				Indexes:       indexes,
+			},
+		}
+	}
+	byAuthor := &schema.Index{Name: "idx_post_author_id_published_at", Columns: []string{"author_id", "published_at"}}
+	byStatus := &schema.Index{Name: "idx_post_status_published_at", Columns: []string{"status", "published_at"}}
+	byStatusUnique := &schema.Index{Name: "idx_post_status_published_at", Columns: []string{"status", "published_at"}, Unique: true}
+
+	tests := []struct {
+		name     string
+		old, new map[string]*schema.ResourceSchema
+		want     []string
+		breaking bool
+	}{
+		{"unchanged", postWith(byAuthor), postWith(byAuthor), nil, false},
+		{"added", postWith(byAuthor), postWith(byAuthor, byStatus), []string{"add_index idx_post_status_published_at"}, false},
+		{"dropped", postWith(byAuthor, byStatus), postWith(byStatus), []string{"drop_index idx_post_author_id_published_at"}, false},
+		{"made unique", postWith(byStatus), postWith(byStatusUnique),
+			[]string{"drop_index idx_post_status_published_at", "add_index idx_post_status_published_at"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := NewDiffer(tt.old, tt.new).ComputeDiff()
+
+			var got []string
+			breaking := false
+			for _, change := range changes {
+				var index *schema.Index
+				if change.Type == ChangeAddIndex {
+					index = change.NewValue.(*schema.Index)
+				} else {
+					index = change.OldValue.(*schema.Index)
+				}
+				got = append(got, change.Type.String()+" "+index.Name)
+				breaking = breaking || change.Breaking
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("changes =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if breaking != tt.breaking {
+				t.Errorf("breaking = %v, want %v", breaking, tt.breaking)
+			}
+		})
+	}
+}
+
 func TestGenerateMigrationName(t *testing.T) {
 	tests := []struct {
 		name     string
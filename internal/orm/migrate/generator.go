@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	compiler "github.com/conduit-lang/conduit/internal/compiler/codegen"
 	"github.com/conduit-lang/conduit/internal/orm/codegen"
 	"github.com/conduit-lang/conduit/internal/orm/schema"
 )
@@ -71,6 +72,11 @@ func (g *Generator) generateUpSQL(changes []SchemaChange, newSchemas map[string]
 	sql.WriteString("-- Auto-generated migration\n")
 	sql.WriteString(fmt.Sprintf("-- Generated at: %s\n\n", time.Now().Format(time.RFC3339)))
 
+	// Point and polygon columns need PostGIS
+	if addsSpatialColumns(changes, newSchemas) {
+		sql.WriteString("CREATE EXTENSION IF NOT EXISTS postgis;\n\n")
+	}
+
 	// Process changes in safe order
 	for _, change := range changes {
 		switch change.Type {
@@ -87,15 +93,15 @@ func (g *Generator) generateUpSQL(changes []SchemaChange, newSchemas map[string]
 			sql.WriteString("\n")
 
 		case ChangeAddField:
-			sql.WriteString(g.generateAddField(change))
+			sql.WriteString(g.generateAddField(change, newSchemas))
 			sql.WriteString("\n")
 
 		case ChangeDropField:
-			sql.WriteString(g.generateDropField(change))
+			sql.WriteString(g.generateDropField(change, newSchemas))
 			sql.WriteString("\n")
 
 		case ChangeModifyField:
-			modSQL, err := g.generateModifyField(change)
+			modSQL, err := g.generateModifyField(change, newSchemas)
 			if err != nil {
 				return "", err
 			}
@@ -111,7 +117,23 @@ func (g *Generator) generateUpSQL(changes []SchemaChange, newSchemas map[string]
 			sql.WriteString("\n")
 
 		case ChangeDropRelationship:
-			sql.WriteString(g.generateDropRelationship(change))
+			sql.WriteString(g.generateDropRelationship(change, newSchemas))
+			sql.WriteString("\n")
+
+		case ChangeModifyRelationship:
+			relSQL, err := g.generateModifyRelationship(change, newSchemas)
+			if err != nil {
+				return "", err
+			}
+			sql.WriteString(relSQL)
+			sql.WriteString("\n")
+
+		case ChangeAddIndex:
+			sql.WriteString(g.generateAddIndex(change.Resource, change.NewValue.(*schema.Index), newSchemas))
+			sql.WriteString("\n")
+
+		case ChangeDropIndex:
+			sql.WriteString(g.generateDropIndex(change.Resource, change.OldValue.(*schema.Index), newSchemas))
 			sql.WriteString("\n")
 		}
 	}
@@ -147,19 +169,19 @@ func (g *Generator) generateDownSQL(changes []SchemaChange, oldSchemas map[strin
 
 		case ChangeAddField:
 			// Reverse: drop the field
-			sql.WriteString(g.generateDropField(change))
+			sql.WriteString(g.generateDropField(change, oldSchemas))
 			sql.WriteString("\n")
 
 		case ChangeDropField:
 			// Reverse: add the field back
-			sql.WriteString(g.generateAddField(change))
+			sql.WriteString(g.generateAddField(change, oldSchemas))
 			sql.WriteString("\n")
 
 		case ChangeModifyField:
 			// Reverse: restore old field definition
 			reverseChange := change
 			reverseChange.OldValue, reverseChange.NewValue = change.NewValue, change.OldValue
-			modSQL, err := g.generateModifyField(reverseChange)
+			modSQL, err := g.generateModifyField(reverseChange, oldSchemas)
 			if err != nil {
 				return "", err
 			}
@@ -168,7 +190,7 @@ func (g *Generator) generateDownSQL(changes []SchemaChange, oldSchemas map[strin
 
 		case ChangeAddRelationship:
 			// Reverse: drop the foreign key
-			sql.WriteString(g.generateDropRelationship(change))
+			sql.WriteString(g.generateDropRelationship(change, oldSchemas))
 			sql.WriteString("\n")
 
 		case ChangeDropRelationship:
@@ -179,6 +201,27 @@ func (g *Generator) generateDownSQL(changes []SchemaChange, oldSchemas map[strin
 			}
 			sql.WriteString(relSQL)
 			sql.WriteString("\n")
+
+		case ChangeModifyRelationship:
+			// Reverse: restore the old foreign key
+			reverseChange := change
+			reverseChange.OldValue, reverseChange.NewValue = change.NewValue, change.OldValue
+			relSQL, err := g.generateModifyRelationship(reverseChange, oldSchemas)
+			if err != nil {
+				return "", err
+			}
+			sql.WriteString(relSQL)
+			sql.WriteString("\n")
+
+		case ChangeAddIndex:
+			// Reverse: drop the index
+			sql.WriteString(g.generateDropIndex(change.Resource, change.NewValue.(*schema.Index), oldSchemas))
+			sql.WriteString("\n")
+
+		case ChangeDropIndex:
+			// Reverse: create the index again
+			sql.WriteString(g.generateAddIndex(change.Resource, change.OldValue.(*schema.Index), oldSchemas))
+			sql.WriteString("\n")
 		}
 	}
 
@@ -197,6 +240,7 @@ func (g *Generator) generateAddResource(change SchemaChange, schemas map[string]
 			return "", fmt.Errorf("no schema found for resource: %s", change.Resource)
 		}
 	}
+	resourceSchema = withResourceTable(change.Resource, resourceSchema)
 
 	createSQL, err := g.ddlGen.GenerateCreateTable(resourceSchema)
 	if err != nil {
@@ -221,15 +265,34 @@ func (g *Generator) generateAddResource(change SchemaChange, schemas map[string]
 	return sql.String(), nil
 }
 
-// generateDropResource generates SQL to drop a resource table
+// generateDropResource generates SQL to drop a resource table, or the
+// materialized view behind a @materialized resource
 func (g *Generator) generateDropResource(change SchemaChange) string {
-	tableName := toSnakeCase(change.Resource)
+	var schemaName string
+	for _, value := range []interface{}{change.OldValue, change.NewValue} {
+		if resourceSchema, ok := value.(*schema.ResourceSchema); ok && resourceSchema != nil {
+			schemaName = resourceSchema.Schema
+			break
+		}
+	}
+	tableName := codegen.QuoteTable(schemaName, resourceTable(change.Resource))
+	if isMaterialized(change.OldValue) || isMaterialized(change.NewValue) {
+		return fmt.Sprintf("-- Drop resource: %s\nDROP MATERIALIZED VIEW IF EXISTS %s CASCADE;\n",
+			change.Resource, tableName)
+	}
 	return fmt.Sprintf("-- Drop resource: %s\nDROP TABLE IF EXISTS %s CASCADE;\n",
-		change.Resource, codegen.QuoteIdentifier(tableName))
+		change.Resource, tableName)
+}
+
+// isMaterialized reports whether a change value is the schema of a
+// @materialized resource
+func isMaterialized(value interface{}) bool {
+	resourceSchema, ok := value.(*schema.ResourceSchema)
+	return ok && resourceSchema != nil && resourceSchema.Materialized != nil
 }
 
 // generateAddField generates SQL to add a field
-func (g *Generator) generateAddField(change SchemaChange) string {
+func (g *Generator) generateAddField(change SchemaChange, schemas map[string]*schema.ResourceSchema) string {
 	var field *schema.Field
 	if change.NewValue != nil {
 		field = change.NewValue.(*schema.Field)
@@ -240,7 +303,7 @@ func (g *Generator) generateAddField(change SchemaChange) string {
 		return fmt.Sprintf("-- Unable to generate ADD COLUMN for %s.%s (missing field data)\n",
 			change.Resource, change.Field)
 	}
-	tableName := toSnakeCase(change.Resource)
+	tableName := resourceTable(change.Resource)
 	columnName := toSnakeCase(field.Name)
 
 	// Map type
@@ -256,29 +319,73 @@ func (g *Generator) generateAddField(change SchemaChange) string {
 		parts = append(parts, "DEFAULT "+defaultVal)
 	}
 
-	return fmt.Sprintf("-- Add field: %s.%s\nALTER TABLE %s ADD COLUMN %s %s;\n",
+	sql := fmt.Sprintf("-- Add field: %s.%s\nALTER TABLE %s ADD COLUMN %s %s;\n",
 		change.Resource, field.Name,
-		codegen.QuoteIdentifier(tableName),
+		qualifiedTable(change.Resource, schemas),
 		codegen.QuoteIdentifier(columnName),
 		strings.Join(parts, " "))
+
+	// Spatial columns are indexed for near filters, and @index columns for
+	// lookups
+	if field.Type != nil && field.Type.IsSpatial() {
+		sql += fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING GIST (%s);\n",
+			codegen.QuoteIdentifier(fmt.Sprintf("idx_%s_%s", tableName, columnName)),
+			qualifiedTable(change.Resource, schemas),
+			codegen.QuoteIdentifier(columnName))
+	} else if g.hasConstraint(field, schema.ConstraintIndex) {
+		sql += fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s);\n",
+			codegen.QuoteIdentifier(fmt.Sprintf("idx_%s_%s", tableName, columnName)),
+			qualifiedTable(change.Resource, schemas),
+			codegen.QuoteIdentifier(columnName))
+	}
+
+	return sql
+}
+
+// addsSpatialColumns reports whether the changes add a point or polygon
+// column, either with a new resource or as a new field
+func addsSpatialColumns(changes []SchemaChange, newSchemas map[string]*schema.ResourceSchema) bool {
+	for _, change := range changes {
+		switch change.Type {
+		case ChangeAddResource:
+			resourceSchema := newSchemas[change.Resource]
+			if resourceSchema == nil {
+				resourceSchema, _ = change.NewValue.(*schema.ResourceSchema)
+			}
+			if resourceSchema == nil {
+				continue
+			}
+			for _, field := range resourceSchema.Fields {
+				if field.Type != nil && field.Type.IsSpatial() {
+					return true
+				}
+			}
+
+		case ChangeAddField, ChangeModifyField:
+			if field, ok := change.NewValue.(*schema.Field); ok && field.Type != nil && field.Type.IsSpatial() {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // generateDropField generates SQL to drop a field
-func (g *Generator) generateDropField(change SchemaChange) string {
-	tableName := toSnakeCase(change.Resource)
+func (g *Generator) generateDropField(change SchemaChange, schemas map[string]*schema.ResourceSchema) string {
 	columnName := toSnakeCase(change.Field)
 
 	return fmt.Sprintf("-- Drop field: %s.%s\nALTER TABLE %s DROP COLUMN IF EXISTS %s CASCADE;\n",
 		change.Resource, change.Field,
-		codegen.QuoteIdentifier(tableName),
+		qualifiedTable(change.Resource, schemas),
 		codegen.QuoteIdentifier(columnName))
 }
 
 // generateModifyField generates SQL to modify a field
-func (g *Generator) generateModifyField(change SchemaChange) (string, error) {
+func (g *Generator) generateModifyField(change SchemaChange, schemas map[string]*schema.ResourceSchema) (string, error) {
 	oldField := change.OldValue.(*schema.Field)
 	newField := change.NewValue.(*schema.Field)
-	tableName := toSnakeCase(change.Resource)
+	tableName := resourceTable(change.Resource)
+	table := qualifiedTable(change.Resource, schemas)
 	columnName := toSnakeCase(change.Field)
 
 	var sql strings.Builder
@@ -292,7 +399,7 @@ func (g *Generator) generateModifyField(change SchemaChange) (string, error) {
 		}
 
 		sql.WriteString(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;\n",
-			codegen.QuoteIdentifier(tableName),
+			table,
 			codegen.QuoteIdentifier(columnName),
 			mappedType))
 	}
@@ -301,11 +408,11 @@ func (g *Generator) generateModifyField(change SchemaChange) (string, error) {
 	if oldField.Type.Nullable != newField.Type.Nullable {
 		if newField.Type.Nullable {
 			sql.WriteString(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;\n",
-				codegen.QuoteIdentifier(tableName),
+				table,
 				codegen.QuoteIdentifier(columnName)))
 		} else {
 			sql.WriteString(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;\n",
-				codegen.QuoteIdentifier(tableName),
+				table,
 				codegen.QuoteIdentifier(columnName)))
 		}
 	}
@@ -318,13 +425,13 @@ func (g *Generator) generateModifyField(change SchemaChange) (string, error) {
 			defaultVal, _ := g.typeMapper.MapDefault(newField.Type)
 			if defaultVal != "" {
 				sql.WriteString(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;\n",
-					codegen.QuoteIdentifier(tableName),
+					table,
 					codegen.QuoteIdentifier(columnName),
 					defaultVal))
 			}
 		} else if oldDefault != nil {
 			sql.WriteString(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;\n",
-				codegen.QuoteIdentifier(tableName),
+				table,
 				codegen.QuoteIdentifier(columnName)))
 		}
 	}
@@ -337,17 +444,33 @@ func (g *Generator) generateModifyField(change SchemaChange) (string, error) {
 		if newUnique {
 			// Add unique constraint
 			sql.WriteString(fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (%s);\n",
-				codegen.QuoteIdentifier(tableName),
+				table,
 				codegen.QuoteIdentifier(constraintName),
 				codegen.QuoteIdentifier(columnName)))
 		} else {
 			// Drop unique constraint
 			sql.WriteString(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;\n",
-				codegen.QuoteIdentifier(tableName),
+				table,
 				codegen.QuoteIdentifier(constraintName)))
 		}
 	}
 
+	// Handle @index changes; spatial columns keep their GiST index
+	oldIndex := g.hasConstraint(oldField, schema.ConstraintIndex)
+	newIndex := g.hasConstraint(newField, schema.ConstraintIndex)
+	if oldIndex != newIndex && !newField.Type.IsSpatial() {
+		indexName := fmt.Sprintf("idx_%s_%s", tableName, columnName)
+		if newIndex {
+			sql.WriteString(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s);\n",
+				codegen.QuoteIdentifier(indexName),
+				table,
+				codegen.QuoteIdentifier(columnName)))
+		} else {
+			sql.WriteString(fmt.Sprintf("DROP INDEX IF EXISTS %s;\n",
+				codegen.QuoteTable(indexedResource(change.Resource, schemas).Schema, indexName)))
+		}
+	}
+
 	// Handle CHECK constraint changes (for min/max/pattern)
 	oldCheckConstraints := g.getCheckConstraints(oldField)
 	newCheckConstraints := g.getCheckConstraints(newField)
@@ -357,14 +480,14 @@ func (g *Generator) generateModifyField(change SchemaChange) (string, error) {
 		// Drop old check constraint if exists
 		if oldCheckConstraints != "" {
 			sql.WriteString(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;\n",
-				codegen.QuoteIdentifier(tableName),
+				table,
 				codegen.QuoteIdentifier(constraintName)))
 		}
 
 		// Add new check constraint if needed
 		if newCheckConstraints != "" {
 			sql.WriteString(fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s);\n",
-				codegen.QuoteIdentifier(tableName),
+				table,
 				codegen.QuoteIdentifier(constraintName),
 				newCheckConstraints))
 		}
@@ -432,35 +555,77 @@ func (g *Generator) generateAddRelationship(change SchemaChange, schemas map[str
 		rel = change.OldValue.(*schema.Relationship)
 	}
 
+	if rel.Type == schema.RelationshipHasOne {
+		return g.generateAddHasOne(change, rel, schemas), nil
+	}
+
 	// Only generate FK for belongs_to relationships
 	if rel.Type != schema.RelationshipBelongsTo {
 		return "", nil
 	}
 
-	tableName := toSnakeCase(change.Resource)
+	// An external table may be a view, which foreign keys cannot reference
+	if target := schemas[rel.TargetResource]; target != nil && target.External != "" {
+		return "", nil
+	}
+
+	tableName := resourceTable(change.Resource)
 	foreignKey := rel.ForeignKey
 	if foreignKey == "" {
 		foreignKey = toSnakeCase(rel.TargetResource) + "_id"
 	}
 
-	targetTable := toSnakeCase(rel.TargetResource)
 	constraintName := fmt.Sprintf("fk_%s_%s", tableName, foreignKey)
 
 	onDelete := mapCascadeAction(rel.OnDelete)
 	onUpdate := mapCascadeAction(rel.OnUpdate)
 
+	// Both tables are qualified, since the target may be in another schema
 	return fmt.Sprintf("-- Add relationship: %s.%s -> %s\nALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(id) ON DELETE %s ON UPDATE %s;\n",
 		change.Resource, change.Relation, rel.TargetResource,
-		codegen.QuoteIdentifier(tableName),
+		qualifiedTable(change.Resource, schemas),
 		codegen.QuoteIdentifier(constraintName),
 		codegen.QuoteIdentifier(foreignKey),
-		codegen.QuoteIdentifier(targetTable),
+		qualifiedTable(rel.TargetResource, schemas),
 		onDelete,
 		onUpdate), nil
 }
 
+// hasOneForeignKey returns the column of a has_one relationship's target
+// referencing the owning resource, and the names of its foreign key and
+// unique constraints
+func hasOneForeignKey(owner string, rel *schema.Relationship) (column, fkName, uniqueName string) {
+	column = rel.ForeignKey
+	if column == "" {
+		column = toSnakeCase(owner) + "_id"
+	}
+	tableName := resourceTable(rel.TargetResource)
+	return column, fmt.Sprintf("fk_%s_%s", tableName, column), fmt.Sprintf("uq_%s_%s", tableName, column)
+}
+
+// generateAddHasOne generates SQL to add a has_one relationship: a foreign
+// key on the target table referencing the owner, unique so each owner has at
+// most one target record
+func (g *Generator) generateAddHasOne(change SchemaChange, rel *schema.Relationship, schemas map[string]*schema.ResourceSchema) string {
+	if target := schemas[rel.TargetResource]; target != nil && target.External != "" {
+		return ""
+	}
+
+	column, fkName, uniqueName := hasOneForeignKey(change.Resource, rel)
+	targetTable := qualifiedTable(rel.TargetResource, schemas)
+	return fmt.Sprintf("-- Add relationship: %s.%s -> %s (has_one)\n"+
+		"ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (%s);\n"+
+		"ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(id) ON DELETE %s ON UPDATE %s;\n",
+		change.Resource, change.Relation, rel.TargetResource,
+		targetTable, codegen.QuoteIdentifier(uniqueName), codegen.QuoteIdentifier(column),
+		targetTable, codegen.QuoteIdentifier(fkName), codegen.QuoteIdentifier(column),
+		qualifiedTable(change.Resource, schemas),
+		mapCascadeAction(rel.OnDelete),
+		mapCascadeAction(rel.OnUpdate))
+}
+
 // generateDropRelationship generates SQL to drop a foreign key
-func (g *Generator) generateDropRelationship(change SchemaChange) string {
+func (g *Generator) generateDropRelationship(change SchemaChange, schemas map[string]*schema.ResourceSchema) string {
 	var rel *schema.Relationship
 	if change.OldValue != nil {
 		rel = change.OldValue.(*schema.Relationship)
@@ -471,7 +636,16 @@ func (g *Generator) generateDropRelationship(change SchemaChange) string {
 			change.Resource, change.Relation)
 	}
 
-	tableName := toSnakeCase(change.Resource)
+	if rel.Type == schema.RelationshipHasOne {
+		_, fkName, uniqueName := hasOneForeignKey(change.Resource, rel)
+		targetTable := qualifiedTable(rel.TargetResource, schemas)
+		return fmt.Sprintf("-- Drop relationship: %s.%s\nALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;\nALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;\n",
+			change.Resource, change.Relation,
+			targetTable, codegen.QuoteIdentifier(fkName),
+			targetTable, codegen.QuoteIdentifier(uniqueName))
+	}
+
+	tableName := resourceTable(change.Resource)
 	foreignKey := rel.ForeignKey
 	if foreignKey == "" {
 		foreignKey = toSnakeCase(rel.TargetResource) + "_id"
@@ -481,12 +655,92 @@ func (g *Generator) generateDropRelationship(change SchemaChange) string {
 
 	return fmt.Sprintf("-- Drop relationship: %s.%s\nALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;\n",
 		change.Resource, change.Relation,
-		codegen.QuoteIdentifier(tableName),
+		qualifiedTable(change.Resource, schemas),
 		codegen.QuoteIdentifier(constraintName))
 }
 
+// generateModifyRelationship generates SQL to replace the foreign key of a
+// changed relationship, e.g. one whose on_delete changed: Postgres cannot alter
+// a constraint's actions, so the old constraint is dropped and the new one added
+func (g *Generator) generateModifyRelationship(change SchemaChange, schemas map[string]*schema.ResourceSchema) (string, error) {
+	oldRel, _ := change.OldValue.(*schema.Relationship)
+	newRel, _ := change.NewValue.(*schema.Relationship)
+	if oldRel == nil || newRel == nil {
+		return fmt.Sprintf("-- Unable to generate ALTER CONSTRAINT for %s.%s (missing relationship data)\n",
+			change.Resource, change.Relation), nil
+	}
+
+	var sql strings.Builder
+	if hasForeignKey(oldRel) {
+		drop := change
+		drop.NewValue = nil
+		sql.WriteString(g.generateDropRelationship(drop, schemas))
+	}
+	if hasForeignKey(newRel) {
+		add := change
+		add.OldValue = nil
+		addSQL, err := g.generateAddRelationship(add, schemas)
+		if err != nil {
+			return "", err
+		}
+		sql.WriteString(addSQL)
+	}
+	return sql.String(), nil
+}
+
+// generateAddIndex generates SQL to create an index block's index
+func (g *Generator) generateAddIndex(resourceName string, index *schema.Index, schemas map[string]*schema.ResourceSchema) string {
+	return fmt.Sprintf("-- Add index: %s\n%s\n", index.Name,
+		codegen.NewIndexGenerator().GenerateIndex(indexedResource(resourceName, schemas), index))
+}
+
+// generateDropIndex generates SQL to drop an index block's index
+func (g *Generator) generateDropIndex(resourceName string, index *schema.Index, schemas map[string]*schema.ResourceSchema) string {
+	return fmt.Sprintf("-- Drop index: %s\n%s\n", index.Name,
+		codegen.NewIndexGenerator().GenerateDropIndex(indexedResource(resourceName, schemas), index))
+}
+
+// indexedResource returns the schema of the resource an index belongs to, or
+// a bare schema in the default PostgreSQL schema when schemas lacks it
+func indexedResource(resourceName string, schemas map[string]*schema.ResourceSchema) *schema.ResourceSchema {
+	if resourceSchema := schemas[resourceName]; resourceSchema != nil {
+		return withResourceTable(resourceName, resourceSchema)
+	}
+	return withResourceTable(resourceName, schema.NewResourceSchema(resourceName))
+}
+
+// hasForeignKey reports whether a relationship is backed by a foreign key
+// constraint
+func hasForeignKey(rel *schema.Relationship) bool {
+	return rel.Type == schema.RelationshipBelongsTo || rel.Type == schema.RelationshipHasOne
+}
+
 // Helper functions
 
+// qualifiedTable returns the quoted table of a resource, qualified with the
+// @schema it has in schemas
+func qualifiedTable(resourceName string, schemas map[string]*schema.ResourceSchema) string {
+	var schemaName string
+	if resourceSchema := schemas[resourceName]; resourceSchema != nil {
+		schemaName = resourceSchema.Schema
+	}
+	return codegen.QuoteTable(schemaName, resourceTable(resourceName))
+}
+
+// resourceTable returns the table of a resource, named as the generated
+// models and handlers query it
+func resourceTable(resourceName string) string {
+	return compiler.TableName(resourceName)
+}
+
+// withResourceTable returns a copy of a resource's schema whose TableName is
+// resourceTable, for the DDL and index generators
+func withResourceTable(resourceName string, resourceSchema *schema.ResourceSchema) *schema.ResourceSchema {
+	named := *resourceSchema
+	named.TableName = resourceTable(resourceName)
+	return &named
+}
+
 func mapCascadeAction(action schema.CascadeAction) string {
 	switch action {
 	case schema.CascadeRestrict:
@@ -143,6 +143,46 @@ func TestGenerator_GenerateMigration_AddField(t *testing.T) {
 	}
 }
 
+func TestGenerator_GenerateMigration_AddSpatialField(t *testing.T) {
+	gen := NewGenerator()
+
+	store := func(fields map[string]*schema.Field) map[string]*schema.ResourceSchema {
+		fields["id"] = &schema.Field{Name: "id", Type: &schema.TypeSpec{BaseType: schema.TypeUUID}}
+		return map[string]*schema.ResourceSchema{
+			"Store": {Name: "Store", TableName: "stores", Fields: fields, Relationships: map[string]*schema.Relationship{}},
+		}
+	}
+	oldSchemas := store(map[string]*schema.Field{})
+	newSchemas := store(map[string]*schema.Field{
+		"location": {Name: "location", Type: &schema.TypeSpec{BaseType: schema.TypePoint, Nullable: true}},
+	})
+
+	migration, err := gen.GenerateMigration(oldSchemas, newSchemas)
+	if err != nil {
+		t.Fatalf("GenerateMigration() failed: %v", err)
+	}
+
+	expected := []string{
+		"CREATE EXTENSION IF NOT EXISTS postgis;",
+		`ADD COLUMN "location" GEOGRAPHY(Point, 4326) NULL`,
+		`CREATE INDEX IF NOT EXISTS "idx_stores_location" ON "stores" USING GIST ("location");`,
+	}
+	for _, exp := range expected {
+		if !strings.Contains(migration.Up, exp) {
+			t.Errorf("Up SQL missing %q:\n%s", exp, migration.Up)
+		}
+	}
+
+	// Migrations without spatial columns don't require PostGIS
+	migration, err = gen.GenerateMigration(map[string]*schema.ResourceSchema{}, oldSchemas)
+	if err != nil {
+		t.Fatalf("GenerateMigration() failed: %v", err)
+	}
+	if strings.Contains(migration.Up, "postgis") {
+		t.Error("Up SQL without spatial columns should not create the postgis extension")
+	}
+}
+
 func TestGenerator_GenerateMigration_DropField(t *testing.T) {
 	gen := NewGenerator()
 
@@ -297,6 +337,277 @@ func TestGenerator_GenerateAddRelationship(t *testing.T) {
 	}
 }
 
+func TestGenerator_GenerateModifyRelationship(t *testing.T) {
+	gen := NewGenerator()
+
+	postWith := func(onDelete schema.CascadeAction) map[string]*schema.ResourceSchema {
+		return map[string]*schema.ResourceSchema{
+			"Post": {
+				Name:      "Post",
+				TableName: "posts",
+				Fields:    map[string]*schema.Field{},
+				Relationships: map[string]*schema.Relationship{
+					"author": {
+						Type:           schema.RelationshipBelongsTo,
+						FieldName:      "author",
+						TargetResource: "User",
+						ForeignKey:     "author_id",
+						OnDelete:       onDelete,
+						OnUpdate:       schema.CascadeCascade,
+					},
+				},
+			},
+		}
+	}
+
+	migration, err := gen.GenerateMigration(postWith(schema.CascadeRestrict), postWith(schema.CascadeCascade))
+	if err != nil {
+		t.Fatalf("GenerateMigration() failed: %v", err)
+	}
+	if migration == nil {
+		t.Fatal("GenerateMigration() returned nil for a changed on_delete")
+	}
+
+	// The constraint is replaced, since Postgres cannot alter its actions
+	drop := `ALTER TABLE "posts" DROP CONSTRAINT IF EXISTS "fk_posts_author_id";`
+	add := `ALTER TABLE "posts" ADD CONSTRAINT "fk_posts_author_id" FOREIGN KEY ("author_id") REFERENCES "users"(id) ON DELETE CASCADE ON UPDATE CASCADE;`
+	if !strings.Contains(migration.Up, drop) || !strings.Contains(migration.Up, add) {
+		t.Errorf("Up SQL should replace the foreign key, got:\n%s", migration.Up)
+	}
+	if strings.Index(migration.Up, drop) > strings.Index(migration.Up, add) {
+		t.Errorf("Up SQL should drop the old constraint before adding the new one, got:\n%s", migration.Up)
+	}
+
+	if !strings.Contains(migration.Down, "ON DELETE RESTRICT") {
+		t.Errorf("Down SQL should restore ON DELETE RESTRICT, got:\n%s", migration.Down)
+	}
+	if strings.Contains(migration.Down, "ON DELETE CASCADE") {
+		t.Errorf("Down SQL should not add ON DELETE CASCADE, got:\n%s", migration.Down)
+	}
+}
+
+func TestGenerator_GenerateAddRelationship_CrossSchema(t *testing.T) {
+	gen := NewGenerator()
+
+	invoice := &schema.ResourceSchema{
+		Name:          "Invoice",
+		Schema:        "billing",
+		Fields:        map[string]*schema.Field{},
+		Relationships: map[string]*schema.Relationship{},
+	}
+	user := &schema.ResourceSchema{
+		Name:          "User",
+		Schema:        "identity",
+		Fields:        map[string]*schema.Field{},
+		Relationships: map[string]*schema.Relationship{},
+	}
+	withCustomer := *invoice
+	withCustomer.Fields = map[string]*schema.Field{
+		"customer_id": {Name: "customer_id", Type: &schema.TypeSpec{BaseType: schema.TypeUUID}},
+	}
+	withCustomer.Relationships = map[string]*schema.Relationship{
+		"customer": {
+			Type:           schema.RelationshipBelongsTo,
+			FieldName:      "customer",
+			TargetResource: "User",
+			ForeignKey:     "customer_id",
+			OnDelete:       schema.CascadeRestrict,
+			OnUpdate:       schema.CascadeCascade,
+		},
+	}
+
+	migration, err := gen.GenerateMigration(
+		map[string]*schema.ResourceSchema{"Invoice": invoice, "User": user},
+		map[string]*schema.ResourceSchema{"Invoice": &withCustomer, "User": user},
+	)
+	if err != nil {
+		t.Fatalf("GenerateMigration() failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`ALTER TABLE "billing"."invoices" ADD COLUMN "customer_id" UUID`,
+		`ALTER TABLE "billing"."invoices" ADD CONSTRAINT "fk_invoices_customer_id" FOREIGN KEY ("customer_id") REFERENCES "identity"."users"(id)`,
+	} {
+		if !strings.Contains(migration.Up, want) {
+			t.Errorf("Up SQL missing %q:\n%s", want, migration.Up)
+		}
+	}
+	for _, want := range []string{
+		`ALTER TABLE "billing"."invoices" DROP CONSTRAINT IF EXISTS "fk_invoices_customer_id";`,
+		`ALTER TABLE "billing"."invoices" DROP COLUMN IF EXISTS "customer_id" CASCADE;`,
+	} {
+		if !strings.Contains(migration.Down, want) {
+			t.Errorf("Down SQL missing %q:\n%s", want, migration.Down)
+		}
+	}
+}
+
+func TestGenerator_GenerateAddRelationship_HasOne(t *testing.T) {
+	gen := NewGenerator()
+
+	profile := &schema.ResourceSchema{
+		Name: "Profile",
+		Fields: map[string]*schema.Field{
+			"user_id": {Name: "user_id", Type: &schema.TypeSpec{BaseType: schema.TypeUUID}},
+		},
+		Relationships: map[string]*schema.Relationship{},
+	}
+	user := &schema.ResourceSchema{
+		Name:          "User",
+		Fields:        map[string]*schema.Field{},
+		Relationships: map[string]*schema.Relationship{},
+	}
+	withProfile := *user
+	withProfile.Relationships = map[string]*schema.Relationship{
+		"profile": {
+			Type:           schema.RelationshipHasOne,
+			FieldName:      "profile",
+			TargetResource: "Profile",
+			OnDelete:       schema.CascadeCascade,
+			OnUpdate:       schema.CascadeCascade,
+		},
+	}
+
+	migration, err := gen.GenerateMigration(
+		map[string]*schema.ResourceSchema{"User": user, "Profile": profile},
+		map[string]*schema.ResourceSchema{"User": &withProfile, "Profile": profile},
+	)
+	if err != nil {
+		t.Fatalf("GenerateMigration() failed: %v", err)
+	}
+
+	// The foreign key and its uniqueness are on the target table
+	for _, want := range []string{
+		`ALTER TABLE "profiles" ADD CONSTRAINT "uq_profiles_user_id" UNIQUE ("user_id");`,
+		`ALTER TABLE "profiles" ADD CONSTRAINT "fk_profiles_user_id" FOREIGN KEY ("user_id") REFERENCES "users"(id) ON DELETE CASCADE ON UPDATE CASCADE;`,
+	} {
+		if !strings.Contains(migration.Up, want) {
+			t.Errorf("Up SQL missing %q:\n%s", want, migration.Up)
+		}
+	}
+	if strings.Contains(migration.Up, `ALTER TABLE "users"`) {
+		t.Errorf("The owning table should not change:\n%s", migration.Up)
+	}
+	for _, want := range []string{
+		`ALTER TABLE "profiles" DROP CONSTRAINT IF EXISTS "fk_profiles_user_id";`,
+		`ALTER TABLE "profiles" DROP CONSTRAINT IF EXISTS "uq_profiles_user_id";`,
+	} {
+		if !strings.Contains(migration.Down, want) {
+			t.Errorf("Down SQL missing %q:\n%s", want, migration.Down)
+		}
+	}
+}
+
+func TestGenerator_GenerateAddRelationship_External(t *testing.T) {
+	user := &schema.ResourceSchema{
+		Name:          "LegacyUser",
+		External:      "legacy.users",
+		Fields:        map[string]*schema.Field{},
+		Relationships: map[string]*schema.Relationship{},
+	}
+	account := &schema.ResourceSchema{
+		Name:          "Account",
+		Fields:        map[string]*schema.Field{},
+		Relationships: map[string]*schema.Relationship{},
+	}
+	withOwner := *account
+	withOwner.Fields = map[string]*schema.Field{
+		"owner_id": {Name: "owner_id", Type: &schema.TypeSpec{BaseType: schema.TypeInt}},
+	}
+	withOwner.Relationships = map[string]*schema.Relationship{
+		"owner": {
+			Type:           schema.RelationshipBelongsTo,
+			FieldName:      "owner",
+			TargetResource: "LegacyUser",
+			ForeignKey:     "owner_id",
+			OnDelete:       schema.CascadeRestrict,
+		},
+	}
+
+	migration, err := NewGenerator().GenerateMigration(
+		map[string]*schema.ResourceSchema{"Account": account, "LegacyUser": user},
+		map[string]*schema.ResourceSchema{"Account": &withOwner, "LegacyUser": user},
+	)
+	if err != nil {
+		t.Fatalf("GenerateMigration() failed: %v", err)
+	}
+	if !strings.Contains(migration.Up, `ALTER TABLE "accounts" ADD COLUMN "owner_id"`) {
+		t.Errorf("Up SQL should add the column:\n%s", migration.Up)
+	}
+	if strings.Contains(migration.Up, "FOREIGN KEY") || strings.Contains(migration.Up, "legacy") {
+		t.Errorf("Up SQL should not reference the external table:\n%s", migration.Up)
+	}
+}
+
+func TestGenerator_GenerateIndexes(t *testing.T) {
+	gen := NewGenerator()
+
+	postWith := func(status []schema.Annotation, indexes ...*schema.Index) map[string]*schema.ResourceSchema {
+		var constraints []schema.Constraint
+		for _, annotation := range status {
+			if annotation.Name == "index" {
+				constraints = append(constraints, schema.Constraint{Type: schema.ConstraintIndex})
+			}
+		}
+		return map[string]*schema.ResourceSchema{
+			"Post": {
+				Name:      "Post",
+				TableName: "post",
+				Schema:    "blog",
+				Fields: map[string]*schema.Field{
+					"status": {Name: "status", Type: &schema.TypeSpec{BaseType: schema.TypeString},
+						Constraints: constraints, Annotations: status},
+				},
+				Relationships: map[string]*schema.Relationship{},
+				Indexes:       indexes,
+			},
+		}
+	}
+	byStatus := &schema.Index{Name: "idx_post_status_author_id", Columns: []string{"status", "author_id"}, Unique: true}
+	indexed := []schema.Annotation{{Name: "index"}}
+
+	// A new table is created with its indexes
+	migration, err := gen.GenerateMigration(map[string]*schema.ResourceSchema{}, postWith(indexed, byStatus))
+	if err != nil {
+		t.Fatalf("GenerateMigration() failed: %v", err)
+	}
+	for _, want := range []string{
+		`CREATE INDEX IF NOT EXISTS "idx_posts_status" ON "blog"."posts" ("status");`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS "idx_post_status_author_id" ON "blog"."posts" ("status", "author_id");`,
+	} {
+		if !strings.Contains(migration.Up, want) {
+			t.Errorf("Up SQL missing %s, got:\n%s", want, migration.Up)
+		}
+	}
+
+	// Index blocks are created and dropped as they are declared and removed
+	migration, err = gen.GenerateMigration(postWith(nil), postWith(nil, byStatus))
+	if err != nil {
+		t.Fatalf("GenerateMigration() failed: %v", err)
+	}
+	if !strings.Contains(migration.Up, `CREATE UNIQUE INDEX IF NOT EXISTS "idx_post_status_author_id" ON "blog"."posts" ("status", "author_id");`) {
+		t.Errorf("Up SQL should create the index, got:\n%s", migration.Up)
+	}
+	if !strings.Contains(migration.Down, `DROP INDEX IF EXISTS "blog"."idx_post_status_author_id";`) {
+		t.Errorf("Down SQL should drop the index, got:\n%s", migration.Down)
+	}
+	if !migration.Breaking {
+		t.Error("A new unique index should be reported as breaking")
+	}
+
+	// So are @index fields
+	migration, err = gen.GenerateMigration(postWith(nil), postWith(indexed))
+	if err != nil {
+		t.Fatalf("GenerateMigration() failed: %v", err)
+	}
+	if !strings.Contains(migration.Up, `CREATE INDEX IF NOT EXISTS "idx_posts_status" ON "blog"."posts" ("status");`) {
+		t.Errorf("Up SQL should index the field, got:\n%s", migration.Up)
+	}
+	if !strings.Contains(migration.Down, `DROP INDEX IF EXISTS "blog"."idx_posts_status";`) {
+		t.Errorf("Down SQL should drop the field's index, got:\n%s", migration.Down)
+	}
+}
+
 func TestGenerator_SQLComments(t *testing.T) {
 	gen := NewGenerator()
 
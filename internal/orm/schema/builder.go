@@ -88,6 +88,41 @@ func (b *Builder) Build(node *ast.ResourceNode) (*ResourceSchema, error) {
 		schema.Computed[computed.Name] = computed
 	}
 
+	if node.Partition != nil {
+		schema.Partition = &Partition{
+			Field:    node.Partition.Field,
+			Interval: node.Partition.Interval,
+		}
+	}
+
+	if node.Materialized != nil {
+		schema.Materialized = &Materialized{
+			Query:   node.Materialized.Query,
+			Refresh: node.Materialized.Refresh,
+		}
+	}
+
+	if node.PrimaryKey != nil {
+		schema.PrimaryKey = append([]string(nil), node.PrimaryKey.Fields...)
+	}
+
+	for _, index := range node.Indexes {
+		columns := node.IndexColumns(index)
+		name := index.Name
+		if name == "" {
+			name = fmt.Sprintf("idx_%s_%s", schema.TableName, strings.Join(columns, "_"))
+		}
+		schema.Indexes = append(schema.Indexes, &Index{Name: name, Columns: columns, Unique: index.Unique})
+	}
+
+	if node.Schema != nil {
+		schema.Schema = node.Schema.Name
+	}
+
+	if node.External != nil {
+		schema.External = node.External.Table
+	}
+
 	if len(b.errors) > 0 {
 		var errMsgs []string
 		for _, err := range b.errors {
@@ -106,6 +141,11 @@ func (b *Builder) buildField(node *ast.FieldNode) (*Field, error) {
 	if err != nil {
 		return nil, fmt.Errorf("field %s: %w", node.Name, err)
 	}
+	if node.Default != nil {
+		if typeSpec.Default, err = b.extractValue(node.Default); err != nil {
+			return nil, fmt.Errorf("field %s default: %w", node.Name, err)
+		}
+	}
 
 	field := &Field{
 		Name:        node.Name,
@@ -117,6 +157,11 @@ func (b *Builder) buildField(node *ast.FieldNode) (*Field, error) {
 
 	// Build constraints
 	for _, constraintNode := range node.Constraints {
+		// Counter columns are plain integers in the schema; the counted
+		// resource's generated code keeps them in step
+		if constraintNode.Name == ast.CounterCacheConstraint {
+			continue
+		}
 		constraint, err := b.buildConstraint(constraintNode)
 		if err != nil {
 			return nil, fmt.Errorf("field %s constraint: %w", node.Name, err)
@@ -358,16 +403,26 @@ func (b *Builder) buildHook(node *ast.HookNode) (*Hook, error) {
 		hookType = AfterDelete
 	case "after_save":
 		hookType = AfterSave
+	case "before_attach":
+		hookType = BeforeAttach
+	case "after_attach":
+		hookType = AfterAttach
+	case "before_detach":
+		hookType = BeforeDetach
+	case "after_detach":
+		hookType = AfterDetach
 	default:
 		return nil, fmt.Errorf("unknown hook type: %s", hookKey)
 	}
 
 	hook := &Hook{
-		Type:        hookType,
-		Transaction: node.IsTransaction,
-		Async:       node.IsAsync,
-		Body:        node.Body,
-		Location:    node.Loc,
+		Type:         hookType,
+		Relationship: node.Relationship,
+		Batch:        node.Batch,
+		Transaction:  node.IsTransaction,
+		Async:        node.IsAsync,
+		Body:         node.Body,
+		Location:     node.Loc,
 	}
 
 	return hook, nil
@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
@@ -670,6 +671,25 @@ func TestBuildHook(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "before_create batch",
+			hookNode: &ast.HookNode{
+				Timing: "before",
+				Event:  "create",
+				Batch:  true,
+				Body:   []ast.StmtNode{},
+				Loc:    ast.SourceLocation{Line: 11, Column: 1},
+			},
+			wantErr: false,
+			validate: func(t *testing.T, h *Hook) {
+				if h.Type != BeforeCreate {
+					t.Errorf("expected BeforeCreate, got %v", h.Type)
+				}
+				if !h.Batch {
+					t.Error("expected Batch to be true")
+				}
+			},
+		},
 		{
 			name: "after_save with async",
 			hookNode: &ast.HookNode{
@@ -690,6 +710,41 @@ func TestBuildHook(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "before_attach with relationship",
+			hookNode: &ast.HookNode{
+				Timing:       "before",
+				Event:        "attach",
+				Relationship: "tags",
+				Body:         []ast.StmtNode{},
+				Loc:          ast.SourceLocation{Line: 13, Column: 1},
+			},
+			wantErr: false,
+			validate: func(t *testing.T, h *Hook) {
+				if h.Type != BeforeAttach || h.Type.String() != "before_attach" {
+					t.Errorf("expected BeforeAttach, got %v", h.Type)
+				}
+				if h.Relationship != "tags" {
+					t.Errorf("expected relationship tags, got %q", h.Relationship)
+				}
+			},
+		},
+		{
+			name: "after_detach with relationship",
+			hookNode: &ast.HookNode{
+				Timing:       "after",
+				Event:        "detach",
+				Relationship: "tags",
+				Body:         []ast.StmtNode{},
+				Loc:          ast.SourceLocation{Line: 14, Column: 1},
+			},
+			wantErr: false,
+			validate: func(t *testing.T, h *Hook) {
+				if h.Type != AfterDetach || h.Type.String() != "after_detach" {
+					t.Errorf("expected AfterDetach, got %v", h.Type)
+				}
+			},
+		},
 		{
 			name: "invalid hook type",
 			hookNode: &ast.HookNode{
@@ -970,6 +1025,86 @@ func TestBuild(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "partitioned resource",
+			resourceNode: &ast.ResourceNode{
+				Name: "Event",
+				Fields: []*ast.FieldNode{
+					{
+						Name: "created_at",
+						Type: &ast.TypeNode{
+							Kind: ast.TypePrimitive,
+							Name: "timestamp",
+						},
+						Constraints: []*ast.ConstraintNode{},
+						Loc:         ast.SourceLocation{Line: 1, Column: 1},
+					},
+				},
+				Partition: &ast.PartitionNode{Field: "created_at", Interval: ast.PartitionMonth},
+				Loc:       ast.SourceLocation{Line: 1, Column: 1},
+			},
+			wantErr: false,
+			validate: func(t *testing.T, rs *ResourceSchema) {
+				if rs.Partition == nil {
+					t.Fatal("expected partition to be set")
+				}
+				if rs.Partition.Field != "created_at" || rs.Partition.Interval != "month" {
+					t.Errorf("expected partition by created_at per month, got %+v", rs.Partition)
+				}
+			},
+		},
+		{
+			name: "counter cache column",
+			resourceNode: ast.WithCounterCaches([]*ast.ResourceNode{
+				{Name: "Post", Loc: ast.SourceLocation{Line: 1, Column: 1}},
+				{
+					Name:          "Comment",
+					Relationships: []*ast.RelationshipNode{{Name: "post", Type: "Post", Kind: ast.RelationshipBelongsTo}},
+					CounterCaches: []*ast.CounterCacheNode{{Column: "comments_count", Resource: "Post", Relationship: "comments"}},
+				},
+			})[0],
+			wantErr: false,
+			validate: func(t *testing.T, rs *ResourceSchema) {
+				field, ok := rs.Fields["comments_count"]
+				if !ok {
+					t.Fatal("expected comments_count field")
+				}
+				if field.Type.BaseType != TypeInt || field.Type.Nullable || field.Type.Default != int64(0) {
+					t.Errorf("expected int! defaulting to 0, got %+v", field.Type)
+				}
+				if len(field.Constraints) != 0 {
+					t.Errorf("expected no constraints, got %+v", field.Constraints)
+				}
+			},
+		},
+		{
+			name: "index blocks",
+			resourceNode: &ast.ResourceNode{
+				Name: "Post",
+				Fields: []*ast.FieldNode{
+					{Name: "author_id", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "uuid"}},
+					{Name: "published_at", Type: &ast.TypeNode{Kind: ast.TypePrimitive, Name: "timestamp"}},
+				},
+				Relationships: []*ast.RelationshipNode{
+					{Name: "author", Type: "User", Kind: ast.RelationshipBelongsTo, ForeignKey: "author_id"},
+				},
+				Indexes: []*ast.IndexNode{
+					{Fields: []string{"author", "published_at"}},
+					{Fields: []string{"published_at"}, Unique: true, Name: "posts_published"},
+				},
+				Loc: ast.SourceLocation{Line: 1, Column: 1},
+			},
+			wantErr: false,
+			validate: func(t *testing.T, rs *ResourceSchema) {
+				want := []*Index{
+					{Name: "idx_post_author_id_published_at", Columns: []string{"author_id", "published_at"}},
+					{Name: "posts_published", Columns: []string{"published_at"}, Unique: true},
+				}
+				if !reflect.DeepEqual(rs.Indexes, want) {
+					t.Errorf("expected indexes %+v, got %+v", want, rs.Indexes)
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -47,6 +47,10 @@ const (
 
 	// Enum
 	TypeEnum
+
+	// Spatial types, stored as PostGIS geography
+	TypePoint
+	TypePolygon
 )
 
 // String returns the string representation of the primitive type
@@ -90,6 +94,10 @@ func (p PrimitiveType) String() string {
 		return "jsonb"
 	case TypeEnum:
 		return "enum"
+	case TypePoint:
+		return "point"
+	case TypePolygon:
+		return "polygon"
 	default:
 		return "unknown"
 	}
@@ -136,6 +144,10 @@ func ParsePrimitiveType(s string) (PrimitiveType, error) {
 		return TypeJSONB, nil
 	case "enum":
 		return TypeEnum, nil
+	case "point":
+		return TypePoint, nil
+	case "polygon":
+		return TypePolygon, nil
 	default:
 		return 0, fmt.Errorf("unknown primitive type: %s", s)
 	}
@@ -209,6 +221,12 @@ func (t *TypeSpec) IsText() bool {
 		t.BaseType == TypeMarkdown
 }
 
+// IsSpatial returns true if the type is stored as a PostGIS geography
+func (t *TypeSpec) IsSpatial() bool {
+	return t.BaseType == TypePoint ||
+		t.BaseType == TypePolygon
+}
+
 // IsValidated returns true if the type has built-in validation
 func (t *TypeSpec) IsValidated() bool {
 	return t.BaseType == TypeEmail ||
@@ -389,6 +407,10 @@ const (
 	AfterUpdate
 	AfterDelete
 	AfterSave
+	BeforeAttach
+	AfterAttach
+	BeforeDetach
+	AfterDetach
 )
 
 // String returns the string representation of the hook type
@@ -410,6 +432,14 @@ func (h HookType) String() string {
 		return "after_delete"
 	case AfterSave:
 		return "after_save"
+	case BeforeAttach:
+		return "before_attach"
+	case AfterAttach:
+		return "after_attach"
+	case BeforeDetach:
+		return "before_detach"
+	case AfterDetach:
+		return "after_detach"
 	default:
 		return "unknown"
 	}
@@ -417,11 +447,13 @@ func (h HookType) String() string {
 
 // Hook represents a lifecycle hook
 type Hook struct {
-	Type        HookType
-	Transaction bool // @transaction annotation
-	Async       bool // @async block present
-	Body        []ast.StmtNode
-	Location    ast.SourceLocation
+	Type         HookType
+	Relationship string // has_many_through relationship of attach and detach hooks
+	Batch        bool   // batch modifier: runs once with all the records created
+	Transaction  bool   // @transaction annotation
+	Async        bool   // @async block present
+	Body         []ast.StmtNode
+	Location     ast.SourceLocation
 }
 
 // Validator represents a procedural validation block
@@ -476,6 +508,28 @@ type ComputedField struct {
 	Location ast.SourceLocation
 }
 
+// Partition describes a table range partitioned on a timestamp field, with
+// one partition per interval (day, week, month or year)
+type Partition struct {
+	Field    string
+	Interval string
+}
+
+// Materialized describes a read-only resource backed by a materialized view
+// over Query, refreshed hourly, daily or weekly
+type Materialized struct {
+	Query   string
+	Refresh string
+}
+
+// Index is a database index declared with an index block. Indexes of single
+// @index fields are annotations of their fields.
+type Index struct {
+	Name    string   // Declared name, or idx_<table>_<columns>
+	Columns []string // Columns in index order; belongs_to relationships are their foreign keys
+	Unique  bool
+}
+
 // ResourceSchema represents the complete schema for a resource
 type ResourceSchema struct {
 	Name          string
@@ -502,6 +556,27 @@ type ResourceSchema struct {
 	// Middleware
 	Middleware map[string][]string // operation -> middleware list
 
+	// Range partitioning from @partition; nil for a regular table
+	Partition *Partition
+
+	// Materialized view from @materialized; nil for a regular table
+	Materialized *Materialized
+
+	// Fields of a composite key from @primary(a, b); nil when the key is a
+	// single @primary field
+	PrimaryKey []string
+
+	// Indexes declared with index blocks, in declaration order
+	Indexes []*Index
+
+	// PostgreSQL schema holding the table from @schema; empty for the
+	// default schema
+	Schema string
+
+	// Existing table or view read from @external_table, which migrations
+	// never touch; empty for a table the application owns
+	External string
+
 	// Metadata
 	TableName string
 	Location  ast.SourceLocation
@@ -19,6 +19,8 @@ func TestPrimitiveTypeString(t *testing.T) {
 		{"TypeEmail", TypeEmail, "email"},
 		{"TypeURL", TypeURL, "url"},
 		{"TypeTimestamp", TypeTimestamp, "timestamp"},
+		{"TypePoint", TypePoint, "point"},
+		{"TypePolygon", TypePolygon, "polygon"},
 	}
 
 	for _, tt := range tests {
@@ -41,6 +43,8 @@ func TestParsePrimitiveType(t *testing.T) {
 		{"valid string", "string", TypeString, false},
 		{"valid int", "int", TypeInt, false},
 		{"valid uuid", "uuid", TypeUUID, false},
+		{"valid point", "point", TypePoint, false},
+		{"valid polygon", "polygon", TypePolygon, false},
 		{"invalid type", "unknown", 0, true},
 	}
 
@@ -0,0 +1,389 @@
+package build
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+const (
+	// minDuplicateGroupSize is the smallest set of fields worth extracting
+	minDuplicateGroupSize = 3
+
+	// minResourceSimilarity is the Jaccard similarity of two resources' field
+	// sets above which they are reported as structurally similar
+	minResourceSimilarity = 0.8
+)
+
+// boilerplateFields are present on nearly every resource and are ignored when
+// comparing schemas
+var boilerplateFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// fieldSignature identifies a field by name and type for duplicate detection
+type fieldSignature struct {
+	name string
+	typ  string
+}
+
+func (s fieldSignature) String() string {
+	return s.name + ": " + s.typ
+}
+
+// resourceFields holds the comparable fields of a single resource
+type resourceFields struct {
+	resource *ast.ResourceNode
+	fields   map[fieldSignature]*ast.FieldNode
+}
+
+// discoverDuplicationPatterns finds copy-pasted schema: groups of identical
+// fields repeated across resources, and resources whose fields are nearly the
+// same. Both are reported in the "duplication" category with a suggested
+// shared struct or mixin as the template.
+func (e *MetadataExtractor) discoverDuplicationPatterns(resources []*ast.ResourceNode) []metadata.PatternMetadata {
+	candidates := make([]resourceFields, 0, len(resources))
+	for _, res := range resources {
+		fields := make(map[fieldSignature]*ast.FieldNode)
+		for _, field := range res.Fields {
+			if boilerplateFields[field.Name] || isPrimaryKey(field) {
+				continue
+			}
+			fields[fieldSignature{name: field.Name, typ: formatFieldType(field)}] = field
+		}
+		if len(fields) >= minDuplicateGroupSize {
+			candidates = append(candidates, resourceFields{resource: res, fields: fields})
+		}
+	}
+
+	patterns := make([]metadata.PatternMetadata, 0)
+	clusters := e.similarResourceClusters(candidates)
+	for _, cluster := range clusters {
+		patterns = append(patterns, e.similarResourcesPattern(cluster))
+	}
+
+	// Member sets already reported as similar resources
+	covered := make(map[string]bool)
+	for _, cluster := range clusters {
+		covered[resourceNames(cluster)] = true
+	}
+
+	for _, group := range repeatedFieldGroups(candidates) {
+		if covered[resourceNames(group.members)] {
+			continue
+		}
+		patterns = append(patterns, e.fieldGroupPattern(group, len(resources)))
+	}
+
+	sort.Slice(patterns, func(i, j int) bool {
+		return patterns[i].ID < patterns[j].ID
+	})
+
+	return patterns
+}
+
+// fieldGroup is a set of identical fields shared by several resources
+type fieldGroup struct {
+	fields  []fieldSignature
+	members []resourceFields
+}
+
+// repeatedFieldGroups returns the maximal field sets shared by two or more
+// resources. A group is dropped when a larger group covers the same resources.
+func repeatedFieldGroups(candidates []resourceFields) []fieldGroup {
+	groups := make(map[string][]fieldSignature)
+	for i := 0; i < len(candidates); i++ {
+		for j := i + 1; j < len(candidates); j++ {
+			shared := intersectFields(candidates[i].fields, candidates[j].fields)
+			if len(shared) >= minDuplicateGroupSize {
+				groups[signatureKey(shared)] = shared
+			}
+		}
+	}
+
+	result := make([]fieldGroup, 0, len(groups))
+	for _, fields := range groups {
+		group := fieldGroup{fields: fields}
+		for _, candidate := range candidates {
+			if containsAll(candidate.fields, fields) {
+				group.members = append(group.members, candidate)
+			}
+		}
+		result = append(result, group)
+	}
+
+	maximal := make([]fieldGroup, 0, len(result))
+	for _, group := range result {
+		dominated := false
+		for _, other := range result {
+			if len(other.fields) > len(group.fields) &&
+				resourceNames(other.members) == resourceNames(group.members) &&
+				containsAllSignatures(other.fields, group.fields) {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			maximal = append(maximal, group)
+		}
+	}
+
+	return maximal
+}
+
+// similarResourceClusters groups resources whose field sets are at least
+// minResourceSimilarity alike, joining pairs transitively
+func (e *MetadataExtractor) similarResourceClusters(candidates []resourceFields) [][]resourceFields {
+	parent := make([]int, len(candidates))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := 0; i < len(candidates); i++ {
+		for j := i + 1; j < len(candidates); j++ {
+			if jaccard(candidates[i].fields, candidates[j].fields) >= minResourceSimilarity {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	byRoot := make(map[int][]resourceFields)
+	roots := make([]int, 0)
+	for i, candidate := range candidates {
+		root := find(i)
+		if _, ok := byRoot[root]; !ok {
+			roots = append(roots, root)
+		}
+		byRoot[root] = append(byRoot[root], candidate)
+	}
+
+	clusters := make([][]resourceFields, 0)
+	for _, root := range roots {
+		if len(byRoot[root]) > 1 {
+			clusters = append(clusters, byRoot[root])
+		}
+	}
+	return clusters
+}
+
+// similarResourcesPattern describes a cluster of structurally similar resources
+func (e *MetadataExtractor) similarResourcesPattern(cluster []resourceFields) metadata.PatternMetadata {
+	shared := cluster[0].fields
+	union := make(map[fieldSignature]*ast.FieldNode)
+	for _, member := range cluster {
+		shared = intersectFieldMap(shared, member.fields)
+		for sig, field := range member.fields {
+			union[sig] = field
+		}
+	}
+	signatures := sortedSignatures(shared)
+
+	names := make([]string, len(cluster))
+	examples := make([]metadata.PatternExample, len(cluster))
+	for i, member := range cluster {
+		names[i] = member.resource.Name
+		examples[i] = metadata.PatternExample{
+			Resource:   member.resource.Name,
+			FilePath:   e.resourceFiles[member.resource.Name],
+			ModifiedAt: e.resourceModified[member.resource.Name],
+			LineNumber: member.resource.Loc.Line,
+			Code:       formatFieldLines(signatures),
+		}
+	}
+
+	similarity := float64(len(shared)) / float64(len(union))
+
+	return metadata.PatternMetadata{
+		ID:       "duplication_resources_" + strings.ToLower(strings.Join(names, "_")),
+		Name:     fmt.Sprintf("Similar resources (%s)", strings.Join(names, ", ")),
+		Category: "duplication",
+		Description: fmt.Sprintf("Resources %s share %d of %d fields (%.0f%%); consider extracting the shared fields into a mixin",
+			strings.Join(names, ", "), len(shared), len(union), similarity*100),
+		Template:   formatFieldLines(signatures),
+		Examples:   examples,
+		Frequency:  len(cluster),
+		Confidence: similarity,
+	}
+}
+
+// fieldGroupPattern describes a field group repeated across resources
+func (e *MetadataExtractor) fieldGroupPattern(group fieldGroup, totalResources int) metadata.PatternMetadata {
+	names := make([]string, len(group.fields))
+	structFields := make([]string, len(group.fields))
+	for i, sig := range group.fields {
+		names[i] = sig.name
+		structFields[i] = sig.String()
+	}
+
+	examples := make([]metadata.PatternExample, len(group.members))
+	memberNames := make([]string, len(group.members))
+	for i, member := range group.members {
+		memberNames[i] = member.resource.Name
+
+		line := 0
+		for _, sig := range group.fields {
+			if loc := member.fields[sig].Loc.Line; line == 0 || loc < line {
+				line = loc
+			}
+		}
+
+		examples[i] = metadata.PatternExample{
+			Resource:   member.resource.Name,
+			FilePath:   e.resourceFiles[member.resource.Name],
+			ModifiedAt: e.resourceModified[member.resource.Name],
+			LineNumber: line,
+			Code:       formatFieldLines(group.fields),
+		}
+	}
+
+	return metadata.PatternMetadata{
+		ID:       "duplication_fields_" + strings.Join(names, "_"),
+		Name:     fmt.Sprintf("Repeated field group (%s)", strings.Join(names, ", ")),
+		Category: "duplication",
+		Description: fmt.Sprintf("Fields %s are repeated in %d resources (%s); consider extracting them into a struct type",
+			strings.Join(names, ", "), len(group.members), strings.Join(memberNames, ", ")),
+		Template:   "<name>: { " + strings.Join(structFields, ", ") + " }!",
+		Examples:   examples,
+		Frequency:  len(group.members),
+		Confidence: e.calculateConfidence(len(group.members), totalResources),
+	}
+}
+
+// Helper functions
+
+func isPrimaryKey(field *ast.FieldNode) bool {
+	for _, c := range field.Constraints {
+		if c.Name == "primary" {
+			return true
+		}
+	}
+	return false
+}
+
+// formatFieldType renders a field type including element, key and enum detail
+// so that structurally different fields never compare equal
+func formatFieldType(field *ast.FieldNode) string {
+	return formatTypeNode(field.Type, field.Nullable)
+}
+
+func formatTypeNode(t *ast.TypeNode, nullable bool) string {
+	if t == nil {
+		return "unknown"
+	}
+
+	base := t.Name
+	switch t.Kind {
+	case ast.TypeArray:
+		base = "array<" + formatTypeNode(t.ElementType, t.ElementType != nil && t.ElementType.Nullable) + ">"
+	case ast.TypeHash:
+		base = "hash<" + formatTypeNode(t.KeyType, false) + ", " +
+			formatTypeNode(t.ValueType, t.ValueType != nil && t.ValueType.Nullable) + ">"
+	case ast.TypeEnum:
+		quoted := make([]string, len(t.EnumValues))
+		for i, v := range t.EnumValues {
+			quoted[i] = fmt.Sprintf("%q", v)
+		}
+		base = "enum[" + strings.Join(quoted, ", ") + "]"
+	}
+
+	if nullable {
+		return base + "?"
+	}
+	return base + "!"
+}
+
+func intersectFields(a, b map[fieldSignature]*ast.FieldNode) []fieldSignature {
+	return sortedSignatures(intersectFieldMap(a, b))
+}
+
+func intersectFieldMap(a, b map[fieldSignature]*ast.FieldNode) map[fieldSignature]*ast.FieldNode {
+	shared := make(map[fieldSignature]*ast.FieldNode)
+	for sig, field := range a {
+		if _, ok := b[sig]; ok {
+			shared[sig] = field
+		}
+	}
+	return shared
+}
+
+func containsAll(fields map[fieldSignature]*ast.FieldNode, sigs []fieldSignature) bool {
+	for _, sig := range sigs {
+		if _, ok := fields[sig]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAllSignatures(set, subset []fieldSignature) bool {
+	lookup := make(map[fieldSignature]bool, len(set))
+	for _, sig := range set {
+		lookup[sig] = true
+	}
+	for _, sig := range subset {
+		if !lookup[sig] {
+			return false
+		}
+	}
+	return true
+}
+
+func jaccard(a, b map[fieldSignature]*ast.FieldNode) float64 {
+	shared := len(intersectFieldMap(a, b))
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+func sortedSignatures(fields map[fieldSignature]*ast.FieldNode) []fieldSignature {
+	sigs := make([]fieldSignature, 0, len(fields))
+	for sig := range fields {
+		sigs = append(sigs, sig)
+	}
+	sort.Slice(sigs, func(i, j int) bool {
+		if sigs[i].name != sigs[j].name {
+			return sigs[i].name < sigs[j].name
+		}
+		return sigs[i].typ < sigs[j].typ
+	})
+	return sigs
+}
+
+func signatureKey(sigs []fieldSignature) string {
+	parts := make([]string, len(sigs))
+	for i, sig := range sigs {
+		parts[i] = sig.String()
+	}
+	return strings.Join(parts, "|")
+}
+
+func resourceNames(members []resourceFields) string {
+	names := make([]string, len(members))
+	for i, member := range members {
+		names[i] = member.resource.Name
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func formatFieldLines(sigs []fieldSignature) string {
+	lines := make([]string, len(sigs))
+	for i, sig := range sigs {
+		lines[i] = sig.String()
+	}
+	return strings.Join(lines, "\n")
+}
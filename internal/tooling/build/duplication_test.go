@@ -0,0 +1,159 @@
+package build
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+	"github.com/conduit-lang/conduit/internal/compiler/parser"
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+func parseResources(t *testing.T, source string) []*ast.ResourceNode {
+	t.Helper()
+
+	tokens, lexErrors := lexer.New(source).ScanTokens()
+	if len(lexErrors) > 0 {
+		t.Fatalf("lexer errors: %v", lexErrors)
+	}
+
+	program, parseErrors := parser.New(tokens).Parse()
+	if len(parseErrors) > 0 {
+		t.Fatalf("parse errors: %v", parseErrors)
+	}
+
+	return program.Resources
+}
+
+func duplicationPatterns(patterns []metadata.PatternMetadata) []metadata.PatternMetadata {
+	result := make([]metadata.PatternMetadata, 0)
+	for _, p := range patterns {
+		if p.Category == "duplication" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func TestDiscoverDuplicationPatterns_RepeatedFieldGroup(t *testing.T) {
+	resources := parseResources(t, `resource Customer {
+  id: uuid! @primary @auto
+  name: string!
+  street: string!
+  city: string!
+  zip: string!
+}
+
+resource Warehouse {
+  id: uuid! @primary @auto
+  code: string!
+  capacity: int!
+  street: string!
+  city: string!
+  zip: string!
+}
+
+resource Supplier {
+  id: uuid! @primary @auto
+  rating: int!
+  contact_email: string!
+  street: string!
+  city: string!
+  zip: string!
+}
+
+resource Tag {
+  id: uuid! @primary @auto
+  label: string!
+}
+`)
+
+	extractor := NewMetadataExtractor()
+	patterns := duplicationPatterns(extractor.extractPatterns(resources))
+
+	if len(patterns) != 1 {
+		t.Fatalf("expected 1 duplication pattern, got %d: %+v", len(patterns), patterns)
+	}
+
+	p := patterns[0]
+	if p.ID != "duplication_fields_city_street_zip" {
+		t.Errorf("unexpected pattern ID %s", p.ID)
+	}
+	if p.Frequency != 3 {
+		t.Errorf("expected frequency 3, got %d", p.Frequency)
+	}
+	if p.Template != "<name>: { city: string!, street: string!, zip: string! }!" {
+		t.Errorf("unexpected template %q", p.Template)
+	}
+	if !strings.Contains(p.Description, "struct type") {
+		t.Errorf("description should suggest a struct type: %s", p.Description)
+	}
+	for _, example := range p.Examples {
+		if example.LineNumber == 0 {
+			t.Errorf("example for %s has no line number", example.Resource)
+		}
+	}
+}
+
+func TestDiscoverDuplicationPatterns_SimilarResources(t *testing.T) {
+	resources := parseResources(t, `resource BillingAddress {
+  id: uuid! @primary @auto
+  street: string!
+  city: string!
+  zip: string!
+  country: string!
+  created_at: timestamp!
+}
+
+resource ShippingAddress {
+  id: uuid! @primary @auto
+  street: string!
+  city: string!
+  zip: string!
+  country: string!
+  instructions: string?
+}
+`)
+
+	extractor := NewMetadataExtractor()
+	patterns := duplicationPatterns(extractor.extractPatterns(resources))
+
+	// The shared field group covers the same resources and is not repeated
+	if len(patterns) != 1 {
+		t.Fatalf("expected 1 duplication pattern, got %d: %+v", len(patterns), patterns)
+	}
+
+	p := patterns[0]
+	if p.ID != "duplication_resources_billingaddress_shippingaddress" {
+		t.Errorf("unexpected pattern ID %s", p.ID)
+	}
+	if p.Confidence != 0.8 {
+		t.Errorf("expected confidence 0.8, got %f", p.Confidence)
+	}
+	if !strings.Contains(p.Description, "mixin") {
+		t.Errorf("description should suggest a mixin: %s", p.Description)
+	}
+}
+
+func TestDiscoverDuplicationPatterns_DifferentTypesAreNotDuplicates(t *testing.T) {
+	resources := parseResources(t, `resource A {
+  id: uuid! @primary @auto
+  street: string!
+  city: string!
+  zip: string!
+}
+
+resource B {
+  id: uuid! @primary @auto
+  street: string!
+  city: string!
+  zip: int!
+}
+`)
+
+	extractor := NewMetadataExtractor()
+	if patterns := duplicationPatterns(extractor.extractPatterns(resources)); len(patterns) != 0 {
+		t.Errorf("expected no duplication patterns, got %+v", patterns)
+	}
+}
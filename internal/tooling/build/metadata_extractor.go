@@ -1,14 +1,24 @@
 package build
 
 import (
+	"bufio"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/codegen"
+	"github.com/conduit-lang/conduit/internal/compiler/owners"
+	"github.com/conduit-lang/conduit/pkg/web/cache"
+	"github.com/conduit-lang/conduit/pkg/web/geo"
+	"github.com/conduit-lang/conduit/pkg/web/methods"
 	"github.com/conduit-lang/conduit/runtime/metadata"
 )
 
@@ -16,26 +26,53 @@ import (
 type MetadataExtractor struct {
 	// Track file paths for each resource
 	resourceFiles map[string]string
+	// When each resource's file was last committed, for pattern recency
+	resourceModified map[string]*time.Time
+	// Owners of resources without @owner, by file
+	codeOwners *owners.CodeOwners
 }
 
 // NewMetadataExtractor creates a new metadata extractor.
 func NewMetadataExtractor() *MetadataExtractor {
 	return &MetadataExtractor{
-		resourceFiles: make(map[string]string),
+		resourceFiles:    make(map[string]string),
+		resourceModified: make(map[string]*time.Time),
 	}
 }
 
+// SetCodeOwners sets the CODEOWNERS rules giving resources without @owner the
+// owner of the file declaring them.
+func (e *MetadataExtractor) SetCodeOwners(codeOwners *owners.CodeOwners) {
+	e.codeOwners = codeOwners
+}
+
 // Extract generates metadata from compiled files.
 func (e *MetadataExtractor) Extract(compiled []*CompiledFile) (*metadata.Metadata, error) {
 	// Collect all resources
 	var allResources []*ast.ResourceNode
+	files := make(map[string]string)
+	paths := make([]string, len(compiled))
+	for i, cf := range compiled {
+		paths[i] = cf.Path
+	}
+	modified := lastCommitted(paths)
 	for _, cf := range compiled {
 		for _, res := range cf.Program.Resources {
 			e.resourceFiles[res.Name] = cf.Path
+			e.resourceModified[res.Name] = modified[cf.Path]
+			files[res.Name] = projectPath(cf.Path)
 			allResources = append(allResources, res)
 		}
 	}
 
+	// Resources without @owner are owned by their file's CODEOWNERS owner
+	allResources = owners.WithOwners(allResources, files, e.codeOwners)
+
+	// Parents carry the columns maintained by @counter_cache, @orderable
+	// resources their position, @soft_delete resources their deleted_at and
+	// @timestamps resources their timestamps
+	allResources = ast.WithSoftDeletes(ast.WithPositions(ast.WithCounterCaches(ast.WithTimestamps(allResources, false))))
+
 	// Sort resources by name for consistent output
 	sort.Slice(allResources, func(i, j int) bool {
 		return allResources[i].Name < allResources[j].Name
@@ -46,6 +83,7 @@ func (e *MetadataExtractor) Extract(compiled []*CompiledFile) (*metadata.Metadat
 	routes := e.extractRoutes(allResources)
 	patterns := e.extractPatterns(allResources)
 	dependencyGraph := e.extractDependencyGraph(allResources)
+	shards := e.extractShards(allResources)
 
 	// Compute source hash for cache invalidation
 	sourceHash := e.computeSourceHash(compiled)
@@ -58,6 +96,7 @@ func (e *MetadataExtractor) Extract(compiled []*CompiledFile) (*metadata.Metadat
 		Routes:       routes,
 		Patterns:     patterns,
 		Dependencies: dependencyGraph,
+		Shards:       shards,
 	}
 
 	return meta, nil
@@ -69,18 +108,41 @@ func (e *MetadataExtractor) extractResources(resources []*ast.ResourceNode) []me
 
 	for _, res := range resources {
 		resMeta := metadata.ResourceMetadata{
-			Name:          res.Name,
-			Documentation: res.Documentation,
-			FilePath:      e.resourceFiles[res.Name],
-			Fields:        e.extractFields(res.Fields),
-			Relationships: e.extractRelationships(res.Relationships),
-			Hooks:         e.extractHooks(res.Hooks),
-			Validations:   e.extractValidations(res.Validations),
-			Constraints:   e.extractConstraints(res.Constraints),
-			Middleware:    e.extractMiddleware(res),
-			Scopes:        e.extractScopes(res.Scopes),
+			Name:           res.Name,
+			Documentation:  res.Documentation,
+			FilePath:       e.resourceFiles[res.Name],
+			Fields:         e.extractFields(res),
+			Relationships:  e.extractRelationships(res),
+			Hooks:          e.extractHooks(res.Hooks),
+			Validations:    e.extractValidations(res.Validations),
+			Constraints:    e.extractConstraints(res.Constraints),
+			Middleware:     e.extractMiddleware(res),
+			Scopes:         e.extractScopes(res.Scopes),
 			ComputedFields: e.extractComputedFields(res.Computed),
+			Indexes:        e.extractIndexes(res),
+			Owner:          e.extractOwner(res),
 		}
+		resMeta.SetAnnotations(metadata.ResourceAnnotations{
+			CountStrategy: e.extractCountStrategy(res),
+			SLO:           e.extractSLO(res.SLO),
+			CacheControl:  e.extractCacheControl(res),
+			Changes:       e.extractChanges(res),
+			Conflict:      e.extractConflict(res),
+			Partition:     e.extractPartition(res),
+			Materialized:  e.extractMaterialized(res, resources),
+			CounterCaches: e.extractCounterCaches(res),
+			SearchIndex:   e.extractSearchIndex(res),
+			Profiles:      e.extractProfiles(res),
+			Archivable:    res.Archivable != nil,
+			SoftDelete:    res.SoftDelete != nil,
+			DefaultScope:  e.extractDefaultScope(res),
+			Orderable:     e.extractOrderable(res),
+			Tree:          e.extractTree(res),
+			Schema:        e.extractSchema(res),
+			External:      e.extractExternal(res),
+			Stability:     e.extractStability(res),
+			Custom:        e.extractCustom(res.Meta),
+		})
 
 		result = append(result, resMeta)
 	}
@@ -88,16 +150,23 @@ func (e *MetadataExtractor) extractResources(resources []*ast.ResourceNode) []me
 	return result
 }
 
-// extractFields extracts field metadata from AST field nodes.
-func (e *MetadataExtractor) extractFields(fields []*ast.FieldNode) []metadata.FieldMetadata {
-	result := make([]metadata.FieldMetadata, 0, len(fields))
+// extractFields extracts field metadata from a resource's AST field nodes.
+func (e *MetadataExtractor) extractFields(res *ast.ResourceNode) []metadata.FieldMetadata {
+	result := make([]metadata.FieldMetadata, 0, len(res.Fields))
+	filterable := e.stringSet(res.FilterableFields())
+	sortable := e.stringSet(res.SortableFields())
 
-	for _, field := range fields {
+	for _, field := range res.Fields {
 		fieldMeta := metadata.FieldMetadata{
-			Name:     field.Name,
-			Type:     e.formatType(field.Type),
-			Nullable: field.Nullable,
-			Required: !field.Nullable && field.Default == nil,
+			Name:       field.Name,
+			Type:       e.formatType(field.Type),
+			Nullable:   field.Nullable,
+			Required:   !field.Nullable && field.Default == nil,
+			Column:     codegen.FieldColumnName(field),
+			Filterable: filterable[field.Name],
+			Sortable:   sortable[field.Name],
+			Custom:     e.extractCustom(field.Meta),
+			Labels:     e.extractLabels(field.Labels),
 		}
 
 		// Extract default value
@@ -105,6 +174,21 @@ func (e *MetadataExtractor) extractFields(fields []*ast.FieldNode) []metadata.Fi
 			fieldMeta.DefaultValue = e.formatExpr(field.Default)
 		}
 
+		// Record an in-progress @dual_write transition
+		if column, until := field.DualWrite(); column != "" {
+			fieldMeta.DualWrite = &metadata.DualWriteMetadata{Column: column, Until: until}
+		}
+
+		// Tell clients how to pre-generate IDs (@id)
+		if field.Name == "id" && res.IDStrategy != nil {
+			fieldMeta.IDStrategy = res.IDStrategy.Strategy
+		}
+
+		// Describe the GeoJSON encoding of point and polygon fields
+		if geometry := field.Geometry(); geometry != "" {
+			fieldMeta.Geometry = &metadata.GeometryMetadata{Type: geometry, Format: "geojson", SRID: geo.SRID}
+		}
+
 		// Extract constraints
 		if len(field.Constraints) > 0 {
 			constraints := make([]string, 0, len(field.Constraints))
@@ -120,11 +204,13 @@ func (e *MetadataExtractor) extractFields(fields []*ast.FieldNode) []metadata.Fi
 	return result
 }
 
-// extractRelationships extracts relationship metadata from AST relationship nodes.
-func (e *MetadataExtractor) extractRelationships(relationships []*ast.RelationshipNode) []metadata.RelationshipMetadata {
-	result := make([]metadata.RelationshipMetadata, 0, len(relationships))
+// extractRelationships extracts relationship metadata from a resource's AST
+// relationship nodes. The foreign key of a has_one relationship is the
+// related resource's column referencing it.
+func (e *MetadataExtractor) extractRelationships(res *ast.ResourceNode) []metadata.RelationshipMetadata {
+	result := make([]metadata.RelationshipMetadata, 0, len(res.Relationships))
 
-	for _, rel := range relationships {
+	for _, rel := range res.Relationships {
 		relMeta := metadata.RelationshipMetadata{
 			Name:           rel.Name,
 			Type:           e.formatRelationshipKind(rel.Kind),
@@ -133,6 +219,9 @@ func (e *MetadataExtractor) extractRelationships(relationships []*ast.Relationsh
 			ThroughTable:   rel.Through,
 			OnDelete:       rel.OnDelete,
 		}
+		if rel.Kind == ast.RelationshipHasOne {
+			relMeta.ForeignKey = rel.TargetForeignKey(res.Name)
+		}
 
 		result = append(result, relMeta)
 	}
@@ -148,10 +237,17 @@ func (e *MetadataExtractor) extractHooks(hooks []*ast.HookNode) []metadata.HookM
 		hookType := hook.Timing + "_" + hook.Event
 
 		hookMeta := metadata.HookMetadata{
-			Type:        hookType,
-			Transaction: hook.IsTransaction,
-			Async:       hook.IsAsync,
-			LineNumber:  hook.Loc.Line,
+			Type:         hookType,
+			Relationship: hook.Relationship,
+			Batch:        hook.Batch,
+			Transaction:  hook.IsTransaction,
+			Async:        hook.IsAsync,
+			Policy:       hook.ErrorPolicy(),
+			LineNumber:   hook.Loc.Line,
+		}
+		hookMeta.Retry, hookMeta.Backoff = hook.Retries()
+		if hook.Timeout > 0 {
+			hookMeta.Timeout = hook.Timeout.String()
 		}
 
 		// Include source code for verbose introspection
@@ -233,6 +329,375 @@ func (e *MetadataExtractor) extractMiddleware(res *ast.ResourceNode) map[string]
 	return middleware
 }
 
+// extractCacheControl converts a @cache_control policy to metadata.
+// Returns nil when the resource's responses are not cacheable.
+func (e *MetadataExtractor) extractCacheControl(res *ast.ResourceNode) *metadata.CacheControlMetadata {
+	cc := res.CacheControl
+	if cc == nil {
+		return nil
+	}
+	collection := codegen.TableName(res.Name)
+	policy := cache.Policy{MaxAge: cc.MaxAge, SharedMaxAge: cc.SharedMaxAge, StaleWhileRevalidate: cc.StaleWhileRevalidate, Public: cc.Public}
+	return &metadata.CacheControlMetadata{
+		MaxAge:               cc.MaxAge,
+		SharedMaxAge:         cc.SharedMaxAge,
+		StaleWhileRevalidate: cc.StaleWhileRevalidate,
+		Public:               cc.Public,
+		Header:               policy.Header(),
+		SurrogateKeys:        []string{collection, cache.RecordKey(collection, res.MemberPath())},
+	}
+}
+
+// extractChanges describes the change feed of a @changes resource.
+// Returns nil when the resource does not serve one.
+func (e *MetadataExtractor) extractChanges(res *ast.ResourceNode) *metadata.ChangesMetadata {
+	if res.Changes == nil {
+		return nil
+	}
+	meta := &metadata.ChangesMetadata{
+		Path:         "/" + codegen.TableName(res.Name) + "/changes",
+		DeletedField: ast.SoftDeleteField,
+	}
+	for _, field := range res.Fields {
+		for _, constraint := range field.Constraints {
+			if constraint.Name == "auto_update" {
+				meta.CursorField = field.Name
+				return meta
+			}
+		}
+	}
+	return meta
+}
+
+// extractShards groups @shard resources by shard key, so the shard topology
+// shows which resources are co-located. Returns nil when nothing is sharded.
+func (e *MetadataExtractor) extractShards(resources []*ast.ResourceNode) []metadata.ShardMetadata {
+	var shards []metadata.ShardMetadata
+	for _, group := range ast.ShardGroups(resources) {
+		shards = append(shards, metadata.ShardMetadata{Key: group.Field, Resources: group.Resources})
+	}
+	return shards
+}
+
+// extractPartition converts @partition to metadata.
+// Returns nil for tables that are not partitioned.
+func (e *MetadataExtractor) extractPartition(res *ast.ResourceNode) *metadata.PartitionMetadata {
+	if res.Partition == nil {
+		return nil
+	}
+	return &metadata.PartitionMetadata{
+		Field:    res.Partition.Field,
+		Interval: res.Partition.Interval,
+	}
+}
+
+// extractMaterialized converts @materialized to metadata, with the resources
+// the view's query reads. Returns nil for regular resources.
+func (e *MetadataExtractor) extractMaterialized(res *ast.ResourceNode, resources []*ast.ResourceNode) *metadata.MaterializedMetadata {
+	if res.Materialized == nil {
+		return nil
+	}
+	return &metadata.MaterializedMetadata{
+		Refresh: res.Materialized.Refresh,
+		Query:   res.Materialized.Query,
+		Sources: codegen.MaterializedSources(res.Materialized.Query, resources),
+	}
+}
+
+// extractCounterCaches converts @counter_cache annotations to metadata.
+func (e *MetadataExtractor) extractCounterCaches(res *ast.ResourceNode) []metadata.CounterCacheMetadata {
+	var counters []metadata.CounterCacheMetadata
+	for _, counter := range res.CounterCaches {
+		meta := metadata.CounterCacheMetadata{
+			Column:       counter.Column,
+			Resource:     counter.Resource,
+			Relationship: counter.Relationship,
+		}
+		if rel := res.CounterCacheRelationship(counter); rel != nil {
+			meta.ForeignKey = rel.ForeignKeyColumn()
+		}
+		counters = append(counters, meta)
+	}
+	return counters
+}
+
+// extractSearchIndex converts @search_index to metadata.
+// Returns nil for resources that are not searchable.
+func (e *MetadataExtractor) extractSearchIndex(res *ast.ResourceNode) *metadata.SearchIndexMetadata {
+	if res.SearchIndex == nil {
+		return nil
+	}
+	return &metadata.SearchIndexMetadata{
+		Index:  codegen.SearchIndexName(res.Name),
+		Path:   "/" + codegen.TableName(res.Name) + "/search",
+		Fields: res.SearchIndex.Fields,
+	}
+}
+
+// extractIndexes converts @index fields and index blocks to metadata.
+// Returns nil for resources without declared indexes.
+func (e *MetadataExtractor) extractIndexes(res *ast.ResourceNode) []metadata.IndexMetadata {
+	var indexes []metadata.IndexMetadata
+	for _, index := range res.AllIndexes() {
+		indexes = append(indexes, metadata.IndexMetadata{
+			Name:    codegen.IndexName(res, index),
+			Fields:  index.Fields,
+			Columns: res.IndexColumns(index),
+			Unique:  index.Unique,
+		})
+	}
+	return indexes
+}
+
+// extractOrderable converts @orderable to metadata.
+// Returns nil for resources whose records are unordered.
+func (e *MetadataExtractor) extractOrderable(res *ast.ResourceNode) *metadata.OrderableMetadata {
+	if res.Orderable == nil {
+		return nil
+	}
+	return &metadata.OrderableMetadata{Scope: res.Orderable.Scope, Position: ast.PositionField}
+}
+
+// extractDefaultScope returns the condition of a @default_scope as written;
+// empty for resources without one.
+func (e *MetadataExtractor) extractDefaultScope(res *ast.ResourceNode) string {
+	if res.DefaultScope == nil {
+		return ""
+	}
+	return ast.Print(res.DefaultScope.Condition)
+}
+
+// extractSchema returns the name given by @schema; empty for the default
+// schema.
+func (e *MetadataExtractor) extractSchema(res *ast.ResourceNode) string {
+	if res.Schema == nil {
+		return ""
+	}
+	return res.Schema.Name
+}
+
+// extractExternal returns the table named by @external_table; empty for a
+// table the application migrates
+func (e *MetadataExtractor) extractExternal(res *ast.ResourceNode) string {
+	if res.External == nil {
+		return ""
+	}
+	return res.External.Table
+}
+
+// extractOwner returns the team owning the resource; empty when it is
+// unowned
+func (e *MetadataExtractor) extractOwner(res *ast.ResourceNode) string {
+	if res.Owner == nil {
+		return ""
+	}
+	return res.Owner.Team
+}
+
+// extractStability returns the level declared with @stability; empty when
+// the resource declares none
+func (e *MetadataExtractor) extractStability(res *ast.ResourceNode) string {
+	if res.Stability == nil {
+		return ""
+	}
+	return res.Stability.Level
+}
+
+// extractCustom returns a copy of the pairs declared with @meta on a resource
+// or field; nil when there are none
+func (e *MetadataExtractor) extractCustom(meta *ast.MetaNode) map[string]string {
+	if meta == nil || len(meta.Values) == 0 {
+		return nil
+	}
+	custom := make(map[string]string, len(meta.Values))
+	for key, value := range meta.Values {
+		custom[key] = value
+	}
+	return custom
+}
+
+// extractLabels copies the display labels of @labels, nil without any
+func (e *MetadataExtractor) extractLabels(labels *ast.LabelsNode) map[string]string {
+	if labels == nil || len(labels.Values) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(labels.Values))
+	for value, label := range labels.Values {
+		values[value] = label
+	}
+	return values
+}
+
+// lastCommitted returns when each file last changed in git history. Files
+// outside a repository and files never committed have no entry. Commit times,
+// unlike file modification times, are the same in every checkout, so metadata
+// built from the same commit is the same.
+//
+// One git log walks the history of all the files, newest first, and stops
+// once every file has been seen, so a build starts one process however many
+// files it compiles.
+func lastCommitted(paths []string) map[string]*time.Time {
+	committed := make(map[string]*time.Time, len(paths))
+	if len(paths) == 0 {
+		return committed
+	}
+
+	// Paths are given relative to the directory holding all the files, and
+	// --relative makes git name them the same way
+	absolute := make([]string, len(paths))
+	for i, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return committed
+		}
+		absolute[i] = abs
+	}
+	dir := filepath.Dir(absolute[0])
+	for _, abs := range absolute[1:] {
+		for !strings.HasPrefix(abs, dir+string(filepath.Separator)) && filepath.Dir(dir) != dir {
+			dir = filepath.Dir(dir)
+		}
+	}
+	pending := make(map[string]string, len(paths))
+	specs := make([]string, 0, len(paths))
+	for i, abs := range absolute {
+		rel, err := filepath.Rel(dir, abs)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if _, ok := pending[rel]; !ok {
+			specs = append(specs, rel)
+		}
+		pending[rel] = paths[i]
+	}
+
+	args := append([]string{"-C", dir, "-c", "core.quotePath=false", "log", "--format=%x00%ct", "--name-only", "--relative", "--"}, specs...)
+	cmd := exec.Command("git", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return committed
+	}
+	if err := cmd.Start(); err != nil {
+		return committed
+	}
+
+	// Each commit is its time after a NUL, then the files it changed
+	var current *time.Time
+	scanner := bufio.NewScanner(stdout)
+	for len(pending) > 0 && scanner.Scan() {
+		line := scanner.Text()
+		if seconds, ok := strings.CutPrefix(line, "\x00"); ok {
+			current = nil
+			if unix, err := strconv.ParseInt(seconds, 10, 64); err == nil {
+				t := time.Unix(unix, 0).UTC()
+				current = &t
+			}
+			continue
+		}
+		if path, ok := pending[line]; ok && current != nil {
+			committed[path] = current
+			delete(pending, line)
+		}
+	}
+
+	// The rest of the history is not needed once every file was seen
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+	return committed
+}
+
+// projectPath returns a source path relative to the working directory, the
+// project root CODEOWNERS patterns are anchored at
+func projectPath(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(cwd, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// extractTree converts @tree to metadata.
+// Returns nil for resources whose records do not form a tree.
+func (e *MetadataExtractor) extractTree(res *ast.ResourceNode) *metadata.TreeMetadata {
+	rel := res.TreeParent()
+	if rel == nil {
+		return nil
+	}
+	return &metadata.TreeMetadata{
+		Parent:     rel.Name,
+		ForeignKey: rel.ForeignKeyColumn(),
+		MaxDepth:   res.Tree.DepthLimit(),
+	}
+}
+
+// extractProfiles converts @profile to metadata, listing every field for *.
+// Returns nil when every caller sees every field.
+func (e *MetadataExtractor) extractProfiles(res *ast.ResourceNode) []metadata.ProfileMetadata {
+	var profiles []metadata.ProfileMetadata
+	for _, profile := range res.Profiles {
+		meta := metadata.ProfileMetadata{Name: profile.Name, Fields: profile.Fields, All: profile.All}
+		if profile.All {
+			meta.Fields = make([]string, 0, len(res.Fields))
+			for _, field := range res.Fields {
+				meta.Fields = append(meta.Fields, field.Name)
+			}
+		}
+		profiles = append(profiles, meta)
+	}
+	return profiles
+}
+
+// extractConflict converts a @conflict policy to metadata.
+// Returns nil when the resource declares none.
+func (e *MetadataExtractor) extractConflict(res *ast.ResourceNode) *metadata.ConflictMetadata {
+	if res.Conflict == nil {
+		return nil
+	}
+	meta := &metadata.ConflictMetadata{
+		Strategy:    res.Conflict.Strategy,
+		MergeFields: res.Conflict.MergeFields,
+	}
+	for _, field := range res.Fields {
+		if field.HasConstraint("auto_update") && !field.Nullable {
+			meta.VersionField = field.Name
+			return meta
+		}
+	}
+	return meta
+}
+
+// extractSLO converts @slo objectives to metadata.
+// Returns nil when the resource declares no SLO.
+func (e *MetadataExtractor) extractSLO(slo *ast.SLONode) *metadata.SLOMetadata {
+	if slo == nil {
+		return nil
+	}
+	meta := &metadata.SLOMetadata{Availability: slo.Availability}
+	for _, objective := range slo.Latency {
+		meta.Latency = append(meta.Latency, metadata.LatencyObjectiveMetadata{
+			Percentile:  objective.Percentile,
+			ThresholdMs: float64(objective.Threshold) / float64(time.Millisecond),
+		})
+	}
+	return meta
+}
+
+// extractCountStrategy returns the list count strategy for a resource.
+// Resources without a @count annotation use an exact count.
+func (e *MetadataExtractor) extractCountStrategy(res *ast.ResourceNode) string {
+	if res.CountStrategy == "" {
+		return ast.CountExact
+	}
+	return res.CountStrategy
+}
+
 // extractScopes extracts scope metadata from AST scope nodes.
 func (e *MetadataExtractor) extractScopes(scopes []*ast.ScopeNode) []metadata.ScopeMetadata {
 	result := make([]metadata.ScopeMetadata, 0, len(scopes))
@@ -284,25 +749,19 @@ func (e *MetadataExtractor) extractRoutes(resources []*ast.ResourceNode) []metad
 	for _, res := range resources {
 		resourceName := res.Name
 		resourcePath := e.toSnakeCase(resourceName)
+		memberPath := "/" + resourcePath + "/" + res.MemberPattern()
+		first := len(routes)
 
 		// Determine which operations are allowed
-		allowedOps := map[string]bool{
-			"list":   true,
-			"show":   true,
-			"create": true,
-			"update": true,
-			"delete": true,
-		}
-
-		// If Operations is specified, restrict to those
-		if len(res.Operations) > 0 {
-			// Reset to false and only enable specified operations
-			for op := range allowedOps {
-				allowedOps[op] = false
-			}
-			for _, op := range res.Operations {
-				allowedOps[op] = true
-			}
+		// @materialized views and @external_table resources are read-only
+		allowedOps := make(map[string]bool)
+		for _, op := range []string{"list", "show", "create", "update", "delete"} {
+			allowedOps[op] = res.AllowsOperation(op)
+		}
+		if res.ReadOnly() {
+			allowedOps["create"] = false
+			allowedOps["update"] = false
+			allowedOps["delete"] = false
 		}
 
 		// LIST: GET /resources
@@ -322,7 +781,7 @@ func (e *MetadataExtractor) extractRoutes(resources []*ast.ResourceNode) []metad
 		if allowedOps["show"] {
 			routes = append(routes, metadata.RouteMetadata{
 				Method:       "GET",
-				Path:         "/" + resourcePath + "/:id",
+				Path:         memberPath,
 				Handler:      "Show" + resourceName,
 				Resource:     resourceName,
 				Operation:    "show",
@@ -343,13 +802,25 @@ func (e *MetadataExtractor) extractRoutes(resources []*ast.ResourceNode) []metad
 				RequestBody:  resourceName + "Input",
 				ResponseBody: resourceName,
 			})
+
+			// CREATE BATCH: POST /resources/batch, running batch create hooks once
+			routes = append(routes, metadata.RouteMetadata{
+				Method:       "POST",
+				Path:         "/" + resourcePath + "/batch",
+				Handler:      "Create" + resourceName + "Batch",
+				Resource:     resourceName,
+				Operation:    "create_batch",
+				Middleware:   e.getOperationMiddleware(res, "create"),
+				RequestBody:  "[]" + resourceName + "Input",
+				ResponseBody: "[]" + resourceName,
+			})
 		}
 
 		// UPDATE: PUT /resources/:id
 		if allowedOps["update"] {
 			routes = append(routes, metadata.RouteMetadata{
 				Method:       "PUT",
-				Path:         "/" + resourcePath + "/:id",
+				Path:         memberPath,
 				Handler:      "Update" + resourceName,
 				Resource:     resourceName,
 				Operation:    "update",
@@ -363,13 +834,106 @@ func (e *MetadataExtractor) extractRoutes(resources []*ast.ResourceNode) []metad
 		if allowedOps["delete"] {
 			routes = append(routes, metadata.RouteMetadata{
 				Method:       "DELETE",
-				Path:         "/" + resourcePath + "/:id",
+				Path:         memberPath,
 				Handler:      "Delete" + resourceName,
 				Resource:     resourceName,
 				Operation:    "delete",
 				Middleware:   e.getOperationMiddleware(res, "delete"),
 			})
 		}
+
+		// WEBHOOK: POST /webhooks/<provider>, authenticated by its signature
+		if res.Webhook != nil {
+			routes = append(routes, metadata.RouteMetadata{
+				Method:      "POST",
+				Path:        codegen.WebhookPath(res.Webhook.Provider),
+				Handler:     "Webhook" + resourceName,
+				Resource:    resourceName,
+				Operation:   "webhook",
+			})
+		}
+
+		// UPSERT: PUT /resources:upsert, keyed by a unique field
+		if res.Upsert != nil && !res.ReadOnly() {
+			routes = append(routes, metadata.RouteMetadata{
+				Method:       "PUT",
+				Path:         codegen.UpsertPath(resourcePath),
+				Handler:      "Upsert" + resourceName,
+				Resource:     resourceName,
+				Operation:    "upsert",
+				Middleware:   e.getOperationMiddleware(res, "upsert"),
+				RequestBody:  resourceName + "Input",
+				ResponseBody: resourceName,
+			})
+		}
+
+		// ARCHIVE / RESTORE: POST /resources/:id/archive and /restore
+		if res.Archivable != nil && !res.ReadOnly() {
+			routes = append(routes, metadata.RouteMetadata{
+				Method:       "POST",
+				Path:         memberPath + "/archive",
+				Handler:      "Archive" + resourceName,
+				Resource:     resourceName,
+				Operation:    "archive",
+				Middleware:   e.getOperationMiddleware(res, "archive"),
+				ResponseBody: resourceName,
+			})
+			routes = append(routes, metadata.RouteMetadata{
+				Method:       "POST",
+				Path:         memberPath + "/restore",
+				Handler:      "Restore" + resourceName,
+				Resource:     resourceName,
+				Operation:    "restore",
+				Middleware:   e.getOperationMiddleware(res, "restore"),
+				ResponseBody: resourceName,
+			})
+		}
+
+		// MOVE: POST /resources/:id/move
+		if res.Orderable != nil && !res.ReadOnly() {
+			routes = append(routes, metadata.RouteMetadata{
+				Method:       "POST",
+				Path:         memberPath + "/move",
+				Handler:      "Move" + resourceName,
+				Resource:     resourceName,
+				Operation:    "move",
+				Middleware:   e.getOperationMiddleware(res, "move"),
+				ResponseBody: resourceName,
+			})
+		}
+
+		// CHILDREN / ANCESTORS: GET /resources/:id/children and /ancestors
+		if res.TreeParent() != nil {
+			for _, direction := range []string{"children", "ancestors"} {
+				routes = append(routes, metadata.RouteMetadata{
+					Method:       "GET",
+					Path:         memberPath + "/" + direction,
+					Handler:      "List" + resourceName + strings.ToUpper(direction[:1]) + direction[1:],
+					Resource:     resourceName,
+					Operation:    direction,
+					Middleware:   e.getOperationMiddleware(res, direction),
+					ResponseBody: "[]" + resourceName,
+				})
+			}
+		}
+
+		// The SQL each route executes. A model that cannot be generated
+		// fails the build anyway, so its routes are just left without.
+		if queries, err := codegen.RouteQueries(res); err == nil {
+			for i := first; i < len(routes); i++ {
+				routes[i].Queries = queries[routes[i].Operation]
+			}
+		}
+	}
+
+	// The methods served on each path, as generated applications answer
+	// OPTIONS
+	byPath := make(map[string][]string)
+	for _, route := range routes {
+		byPath[route.Path] = append(byPath[route.Path], route.Method)
+	}
+	for i := range routes {
+		routes[i].Allow = methods.Allow(byPath[routes[i].Path])
 	}
 
 	return routes
@@ -377,9 +941,9 @@ func (e *MetadataExtractor) extractRoutes(resources []*ast.ResourceNode) []metad
 
 // getOperationMiddleware returns middleware for a specific operation.
 func (e *MetadataExtractor) getOperationMiddleware(res *ast.ResourceNode, operation string) []string {
-	// For now, return resource-level middleware
+	// Resource-level middleware, followed by modifiers such as admin_only
 	// TODO: Add support for operation-specific middleware
-	return res.Middleware
+	return res.OperationMiddleware(operation)
 }
 
 // extractPatterns discovers common patterns in the codebase.
@@ -390,10 +954,12 @@ func (e *MetadataExtractor) extractPatterns(resources []*ast.ResourceNode) []met
 	hookPatterns := e.discoverHookPatterns(resources)
 	validationPatterns := e.discoverValidationPatterns(resources)
 	middlewarePatterns := e.discoverMiddlewarePatterns(resources)
+	duplicationPatterns := e.discoverDuplicationPatterns(resources)
 
 	patterns = append(patterns, hookPatterns...)
 	patterns = append(patterns, validationPatterns...)
 	patterns = append(patterns, middlewarePatterns...)
+	patterns = append(patterns, duplicationPatterns...)
 
 	return patterns
 }
@@ -418,6 +984,7 @@ func (e *MetadataExtractor) discoverHookPatterns(resources []*ast.ResourceNode)
 			example := metadata.PatternExample{
 				Resource:   res.Name,
 				FilePath:   e.resourceFiles[res.Name],
+				ModifiedAt: e.resourceModified[res.Name],
 				LineNumber: hook.Loc.Line,
 				Code:       e.formatHookBody(hook.Body),
 			}
@@ -460,6 +1027,7 @@ func (e *MetadataExtractor) discoverMiddlewarePatterns(resources []*ast.Resource
 			example := metadata.PatternExample{
 				Resource:   res.Name,
 				FilePath:   e.resourceFiles[res.Name],
+				ModifiedAt: e.resourceModified[res.Name],
 				LineNumber: res.Loc.Line,
 			}
 
@@ -520,6 +1088,18 @@ func (e *MetadataExtractor) extractDependencyGraph(resources []*ast.ResourceNode
 
 			graph.Edges = append(graph.Edges, edge)
 		}
+
+		// A @materialized view depends on the resources its query reads
+		if res.Materialized != nil {
+			for _, source := range codegen.MaterializedSources(res.Materialized.Query, resources) {
+				graph.Edges = append(graph.Edges, metadata.DependencyEdge{
+					From:         fromID,
+					To:           "resource:" + source,
+					Relationship: "reads",
+					Weight:       1,
+				})
+			}
+		}
 	}
 
 	return graph
@@ -645,6 +1225,14 @@ func (e *MetadataExtractor) calculateConfidence(frequency, totalResources int) f
 	return 0.4
 }
 
+func (e *MetadataExtractor) stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
 func (e *MetadataExtractor) toSnakeCase(s string) string {
 	var result strings.Builder
 	for i, r := range s {
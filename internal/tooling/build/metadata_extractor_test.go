@@ -0,0 +1,819 @@
+package build
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/owners"
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+func TestMetadataExtractor_Materialized(t *testing.T) {
+	resources := parseResources(t, `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+}
+
+resource Comment {
+  id: uuid! @primary @auto
+  body: text!
+  post: Post!
+}
+
+resource PostStat {
+  id: uuid!
+  comment_count: int!
+
+  @materialized(refresh: daily, query: "
+    SELECT posts.id, count(comments.id) AS comment_count
+    FROM posts LEFT JOIN comments ON comments.post_id = posts.id
+    GROUP BY posts.id
+  ")
+}
+`)
+
+	extractor := NewMetadataExtractor()
+
+	meta := extractor.extractResources(resources)[2].Annotations().Materialized
+	if meta == nil {
+		t.Fatal("PostStat should have materialized metadata")
+	}
+	if meta.Refresh != "daily" {
+		t.Errorf("Refresh = %q, want daily", meta.Refresh)
+	}
+	if want := []string{"Post", "Comment"}; !reflect.DeepEqual(meta.Sources, want) {
+		t.Errorf("Sources = %v, want %v", meta.Sources, want)
+	}
+
+	// Only list and show routes are served
+	var operations []string
+	for _, route := range extractor.extractRoutes(resources) {
+		if route.Resource == "PostStat" {
+			operations = append(operations, route.Operation)
+		}
+	}
+	if want := []string{"list", "show"}; !reflect.DeepEqual(operations, want) {
+		t.Errorf("PostStat operations = %v, want %v", operations, want)
+	}
+
+	// The view depends on the resources its query reads
+	graph := extractor.extractDependencyGraph(resources)
+	var reads []metadata.DependencyEdge
+	for _, edge := range graph.Edges {
+		if edge.Relationship == "reads" {
+			reads = append(reads, edge)
+		}
+	}
+	want := []metadata.DependencyEdge{
+		{From: "resource:PostStat", To: "resource:Post", Relationship: "reads", Weight: 1},
+		{From: "resource:PostStat", To: "resource:Comment", Relationship: "reads", Weight: 1},
+	}
+	if !reflect.DeepEqual(reads, want) {
+		t.Errorf("reads edges = %+v, want %+v", reads, want)
+	}
+}
+
+func TestMetadataExtractor_WebhookRoute(t *testing.T) {
+	resources := parseResources(t, `resource StripeEvent {
+  id: uuid! @primary @auto
+  event_id: string! @unique
+  event_type: string!
+  payload: json!
+
+  @operations [list, show]
+  @webhook(stripe)
+}
+`)
+
+	routes := NewMetadataExtractor().extractRoutes(resources)
+	var webhook *metadata.RouteMetadata
+	for i, route := range routes {
+		if route.Operation == "webhook" {
+			webhook = &routes[i]
+		}
+	}
+	want := &metadata.RouteMetadata{
+		Method:    "POST",
+		Path:      "/webhooks/stripe",
+		Handler:   "WebhookStripeEvent",
+		Resource:  "StripeEvent",
+		Operation: "webhook",
+		Allow:     []string{"POST", "OPTIONS"},
+	}
+	if !reflect.DeepEqual(webhook, want) {
+		t.Errorf("webhook route = %+v, want %+v", webhook, want)
+	}
+}
+
+func TestMetadataExtractor_UpsertRoute(t *testing.T) {
+	resources := parseResources(t, `resource User {
+  id: uuid! @primary @auto
+  email: string! @unique
+  name: string!
+
+  @middleware [auth]
+  @upsert(on: [email])
+}
+`)
+
+	routes := NewMetadataExtractor().extractRoutes(resources)
+	var upsert *metadata.RouteMetadata
+	for i, route := range routes {
+		if route.Operation == "upsert" {
+			upsert = &routes[i]
+		}
+	}
+	want := &metadata.RouteMetadata{
+		Method:       "PUT",
+		Path:         "/user:upsert",
+		Handler:      "UpsertUser",
+		Resource:     "User",
+		Operation:    "upsert",
+		Middleware:   []string{"auth"},
+		RequestBody:  "UserInput",
+		ResponseBody: "User",
+		Queries: []string{
+			"INSERT INTO users (id, email, name) VALUES ($1, $2, $3) ON CONFLICT (email) DO UPDATE SET name = EXCLUDED.name RETURNING id, (xmax = 0)",
+		},
+		Allow: []string{"PUT", "OPTIONS"},
+	}
+	if !reflect.DeepEqual(upsert, want) {
+		t.Errorf("upsert route = %+v, want %+v", upsert, want)
+	}
+}
+
+func TestMetadataExtractor_Archivable(t *testing.T) {
+	resources := parseResources(t, `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+  archived_at: timestamp?
+
+  @archivable
+}
+`)
+
+	extractor := NewMetadataExtractor()
+	if meta := extractor.extractResources(resources); !meta[0].Annotations().Archivable {
+		t.Error("Expected the resource to be flagged archivable")
+	}
+
+	var operations []string
+	for _, route := range extractor.extractRoutes(resources) {
+		if route.Method == "POST" && strings.HasPrefix(route.Path, "/post/:id/") {
+			operations = append(operations, route.Operation+" "+route.Path)
+		}
+	}
+	want := []string{"archive /post/:id/archive", "restore /post/:id/restore"}
+	if !reflect.DeepEqual(operations, want) {
+		t.Errorf("archive routes = %v, want %v", operations, want)
+	}
+}
+
+func TestMetadataExtractor_Orderable(t *testing.T) {
+	resources := parseResources(t, `resource Task {
+  id: uuid! @primary @auto
+  title: string!
+  list_id: uuid!
+
+  @orderable(scope: list_id)
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/task.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	resource := meta.Resources[0]
+	if want := (&metadata.OrderableMetadata{Scope: "list_id", Position: "position"}); !reflect.DeepEqual(resource.Annotations().Orderable, want) {
+		t.Errorf("Orderable = %+v, want %+v", resource.Annotations().Orderable, want)
+	}
+	var position bool
+	for _, field := range resource.Fields {
+		position = position || field.Name == "position"
+	}
+	if !position {
+		t.Errorf("Expected the added position field, got %+v", resource.Fields)
+	}
+
+	var operations []string
+	for _, route := range meta.Routes {
+		if route.Method == "POST" && strings.HasPrefix(route.Path, "/task/:id/") {
+			operations = append(operations, route.Operation+" "+route.Path)
+		}
+	}
+	if want := []string{"move /task/:id/move"}; !reflect.DeepEqual(operations, want) {
+		t.Errorf("move routes = %v, want %v", operations, want)
+	}
+}
+
+func TestMetadataExtractor_SoftDelete(t *testing.T) {
+	resources := parseResources(t, `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+
+  @soft_delete
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/post.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	resource := meta.Resources[0]
+	if !resource.Annotations().SoftDelete {
+		t.Error("Expected the resource to be flagged soft_delete")
+	}
+	var deleted *metadata.FieldMetadata
+	for i := range resource.Fields {
+		if resource.Fields[i].Name == "deleted_at" {
+			deleted = &resource.Fields[i]
+		}
+	}
+	if deleted == nil || deleted.Type != "timestamp?" {
+		t.Errorf("Expected the added deleted_at timestamp? field, got %+v", resource.Fields)
+	}
+}
+
+func TestMetadataExtractor_DefaultScope(t *testing.T) {
+	resources := parseResources(t, `resource Post {
+  id: uuid! @primary @auto
+  published: bool!
+
+  @soft_delete
+  @default_scope { self.deleted_at == nil and self.published }
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/post.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := "self.deleted_at == null and self.published"
+	if got := meta.Resources[0].Annotations().DefaultScope; got != want {
+		t.Errorf("DefaultScope = %q, want %q", got, want)
+	}
+}
+
+func TestMetadataExtractor_Tree(t *testing.T) {
+	resources := parseResources(t, `resource Category {
+  id: uuid! @primary @auto
+  name: string!
+  parent_id: uuid?
+  parent: Category? {
+    foreign_key: "parent_id"
+  }
+
+  @tree(max_depth: 5)
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/category.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	if want := (&metadata.TreeMetadata{Parent: "parent", ForeignKey: "parent_id", MaxDepth: 5}); !reflect.DeepEqual(meta.Resources[0].Annotations().Tree, want) {
+		t.Errorf("Tree = %+v, want %+v", meta.Resources[0].Annotations().Tree, want)
+	}
+
+	var routes []string
+	for _, route := range meta.Routes {
+		if route.Operation == "children" || route.Operation == "ancestors" {
+			routes = append(routes, route.Method+" "+route.Path+" "+route.Handler)
+		}
+	}
+	want := []string{"GET /category/:id/children ListCategoryChildren", "GET /category/:id/ancestors ListCategoryAncestors"}
+	if !reflect.DeepEqual(routes, want) {
+		t.Errorf("tree routes = %v, want %v", routes, want)
+	}
+}
+
+func TestMetadataExtractor_IDStrategy(t *testing.T) {
+	resources := parseResources(t, `resource Order {
+  id: int! @primary @auto
+  total: int!
+
+  @id(strategy: snowflake)
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/order.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	strategies := map[string]string{}
+	for _, field := range meta.Resources[0].Fields {
+		strategies[field.Name] = field.IDStrategy
+	}
+	if want := map[string]string{"id": "snowflake", "total": ""}; !reflect.DeepEqual(strategies, want) {
+		t.Errorf("IDStrategy = %v, want %v", strategies, want)
+	}
+}
+
+func TestMetadataExtractor_Timestamps(t *testing.T) {
+	resources := parseResources(t, `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+
+  @timestamps
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/post.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	var names []string
+	for _, field := range meta.Resources[0].Fields {
+		names = append(names, field.Name)
+	}
+	if want := []string{"id", "title", "created_at", "updated_at"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("fields = %v, want %v", names, want)
+	}
+}
+
+func TestMetadataExtractor_Schema(t *testing.T) {
+	resources := parseResources(t, `resource Invoice {
+  id: uuid! @primary @auto
+  total: float!
+
+  @schema("billing")
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/invoice.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if got := meta.Resources[0].Annotations().Schema; got != "billing" {
+		t.Errorf("Schema = %q, want %q", got, "billing")
+	}
+}
+
+func TestMetadataExtractor_Shard(t *testing.T) {
+	resources := parseResources(t, `resource Account {
+  id: uuid! @primary @auto
+  tenant_id: string!
+
+  @shard(by: tenant_id)
+}
+
+resource Invoice {
+  id: uuid! @primary @auto
+  tenant_id: string!
+
+  @shard(by: tenant_id)
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/billing.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	shard := meta.ShardOf("Invoice")
+	if shard == nil || shard.Key != "tenant_id" || strings.Join(shard.Resources, ",") != "Account,Invoice" {
+		t.Errorf("Invoice shard = %+v, want tenant_id with Account and Invoice", shard)
+	}
+}
+
+func TestMetadataExtractor_Owner(t *testing.T) {
+	invoices := parseResources(t, `resource Invoice {
+  id: uuid! @primary @auto
+  total: float!
+
+  @owner("billing")
+}
+`)
+	payments := parseResources(t, `resource Payment {
+  id: uuid! @primary @auto
+}
+`)
+	comments := parseResources(t, `resource Comment {
+  id: uuid! @primary @auto
+}
+`)
+
+	codeOwners, err := owners.Parse("/app/payments/ @acme/payments-team\n")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	extractor := NewMetadataExtractor()
+	extractor.SetCodeOwners(codeOwners)
+	meta, err := extractor.Extract([]*CompiledFile{
+		{Path: "app/payments/invoice.cdt", Program: &ast.Program{Resources: invoices}},
+		{Path: "app/payments/payment.cdt", Program: &ast.Program{Resources: payments}},
+		{Path: "app/comment.cdt", Program: &ast.Program{Resources: comments}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := map[string]string{"Comment": "", "Invoice": "billing", "Payment": "payments-team"}
+	for _, res := range meta.Resources {
+		if res.Owner != want[res.Name] {
+			t.Errorf("%s owner = %q, want %q", res.Name, res.Owner, want[res.Name])
+		}
+	}
+}
+
+func TestMetadataExtractor_Stability(t *testing.T) {
+	resources := parseResources(t, `resource Invoice {
+  id: uuid! @primary @auto
+
+  @stability(deprecated)
+}
+
+resource Comment {
+  id: uuid! @primary @auto
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{{Path: "app/invoice.cdt", Program: &ast.Program{Resources: resources}}})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := map[string]string{"Comment": "", "Invoice": "deprecated"}
+	for _, res := range meta.Resources {
+		if res.Annotations().Stability != want[res.Name] {
+			t.Errorf("%s stability = %q, want %q", res.Name, res.Annotations().Stability, want[res.Name])
+		}
+	}
+}
+
+func TestMetadataExtractor_Custom(t *testing.T) {
+	resources := parseResources(t, `resource Customer {
+  id: uuid! @primary @auto
+  email: string! @meta(classification: "pii", retention: "7y")
+
+  @meta(cost_center: "cc-42")
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{{Path: "app/customer.cdt", Program: &ast.Program{Resources: resources}}})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	customer := meta.Resources[0]
+	if !reflect.DeepEqual(customer.Annotations().Custom, map[string]string{"cost_center": "cc-42"}) {
+		t.Errorf("resource Custom = %v", customer.Annotations().Custom)
+	}
+	for _, field := range customer.Fields {
+		switch field.Name {
+		case "email":
+			if !reflect.DeepEqual(field.Custom, map[string]string{"classification": "pii", "retention": "7y"}) {
+				t.Errorf("email Custom = %v", field.Custom)
+			}
+		case "id":
+			if field.Custom != nil {
+				t.Errorf("id should have no custom metadata, got %v", field.Custom)
+			}
+		}
+	}
+}
+
+func TestMetadataExtractor_Labels(t *testing.T) {
+	resources := parseResources(t, `resource Post {
+  id: uuid! @primary @auto
+  status: enum ["draft", "in-review", "published"]! @labels(draft: "Draft", "in-review": "In review")
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{{Path: "app/post.cdt", Program: &ast.Program{Resources: resources}}})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	for _, field := range meta.Resources[0].Fields {
+		want := map[string]string(nil)
+		if field.Name == "status" {
+			want = map[string]string{"draft": "Draft", "in-review": "In review"}
+		}
+		if !reflect.DeepEqual(field.Labels, want) {
+			t.Errorf("%s Labels = %v, want %v", field.Name, field.Labels, want)
+		}
+	}
+}
+
+func TestMetadataExtractor_External(t *testing.T) {
+	resources := parseResources(t, `resource LegacyUser {
+  id: int! @primary
+  email: string!
+
+  @external_table("legacy.users")
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/legacy_user.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if got := meta.Resources[0].Annotations().External; got != "legacy.users" {
+		t.Errorf("External = %q, want %q", got, "legacy.users")
+	}
+	for _, route := range meta.Routes {
+		if route.Method != "GET" {
+			t.Errorf("External resources should only have read routes, got %s %s", route.Method, route.Path)
+		}
+	}
+	if len(meta.Routes) != 2 {
+		t.Errorf("Expected list and show routes, got %d", len(meta.Routes))
+	}
+}
+
+func TestMetadataExtractor_AttachHooks(t *testing.T) {
+	resources := parseResources(t, `resource Tag {
+  id: uuid! @primary @auto
+  name: string!
+}
+
+resource Post {
+  id: uuid! @primary @auto
+  title: string!
+  tags: array<Tag!>! { through: "post_tags" }
+
+  @after attach(tags) @async {
+    self.title = tag.name
+  }
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/post.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	var hooks []metadata.HookMetadata
+	for _, res := range meta.Resources {
+		if res.Name == "Post" {
+			hooks = res.Hooks
+		}
+	}
+	if len(hooks) != 1 {
+		t.Fatalf("hooks = %v, want 1", hooks)
+	}
+	if hooks[0].Type != "after_attach" || hooks[0].Relationship != "tags" || !hooks[0].Async {
+		t.Errorf("hook = %+v, want an async after_attach hook on tags", hooks[0])
+	}
+}
+
+func TestMetadataExtractor_BatchHooks(t *testing.T) {
+	resources := parseResources(t, `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+
+  @before create batch {
+    Logger.info("creating posts", records)
+  }
+
+  @after create {
+    Logger.info("created post", self.title)
+  }
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/post.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	hooks := meta.Resources[0].Hooks
+	if len(hooks) != 2 {
+		t.Fatalf("hooks = %v, want 2", hooks)
+	}
+	if hooks[0].Type != "before_create" || !hooks[0].Batch {
+		t.Errorf("hook = %+v, want a batch before_create hook", hooks[0])
+	}
+	if hooks[1].Batch {
+		t.Errorf("hook = %+v, want a per-record after_create hook", hooks[1])
+	}
+}
+
+func TestMetadataExtractor_HookErrorPolicies(t *testing.T) {
+	resources := parseResources(t, `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+
+  @before create {
+    self.title = self.title
+  }
+
+  @after create @on_error(retry: 3, policy: warn) {
+    self.title = self.title
+  }
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/post.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	hooks := meta.Resources[0].Hooks
+	if len(hooks) != 2 {
+		t.Fatalf("hooks = %v, want 2", hooks)
+	}
+	if hooks[0].Policy != "abort" || hooks[0].Retry != 0 || hooks[0].Backoff != "" {
+		t.Errorf("hook = %+v, want abort without retries", hooks[0])
+	}
+	if hooks[1].Policy != "warn" || hooks[1].Retry != 3 || hooks[1].Backoff != "exponential" {
+		t.Errorf("hook = %+v, want warn after 3 exponential retries", hooks[1])
+	}
+}
+
+func TestMetadataExtractor_HookTimeouts(t *testing.T) {
+	resources := parseResources(t, `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+
+  @before create {
+    self.title = self.title
+  }
+
+  @after create @timeout(2s) {
+    self.title = self.title
+  }
+
+  @after update @timeout(1500ms) {
+    self.title = self.title
+  }
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/post.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	hooks := meta.Resources[0].Hooks
+	if len(hooks) != 3 {
+		t.Fatalf("hooks = %v, want 3", hooks)
+	}
+	for i, want := range []string{"", "2s", "1.5s"} {
+		if hooks[i].Timeout != want {
+			t.Errorf("hooks[%d].Timeout = %q, want %q", i, hooks[i].Timeout, want)
+		}
+	}
+}
+
+func TestMetadataExtractor_Indexes(t *testing.T) {
+	resources := parseResources(t, `resource Post {
+  id: uuid! @primary @auto
+  status: string! @index
+  author_id: uuid!
+  published_at: timestamp?
+
+  author: User! {
+    foreign_key: "author_id"
+  }
+
+  index [author, published_at] { unique: true }
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/post.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := []metadata.IndexMetadata{
+		{Name: "idx_posts_status", Fields: []string{"status"}, Columns: []string{"status"}},
+		{Name: "idx_posts_author_id_published_at", Fields: []string{"author", "published_at"}, Columns: []string{"author_id", "published_at"}, Unique: true},
+	}
+	if got := meta.Resources[0].Indexes; !reflect.DeepEqual(got, want) {
+		t.Errorf("Indexes = %+v, want %+v", got, want)
+	}
+}
+
+func TestMetadataExtractor_RouteQueries(t *testing.T) {
+	resources := parseResources(t, `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+}
+`)
+
+	meta, err := NewMetadataExtractor().Extract([]*CompiledFile{
+		{Path: "app/post.cdt", Program: &ast.Program{Resources: resources}},
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"list": {
+			"SELECT id, title FROM posts ORDER BY id LIMIT $1 OFFSET $2",
+			"SELECT COUNT(*) FROM posts",
+		},
+		"show":         {"SELECT id, title FROM posts WHERE id = $1"},
+		"create":       {"INSERT INTO posts (id, title) VALUES ($1, $2)"},
+		"create_batch": {"INSERT INTO posts (id, title) VALUES ($1, $2)"},
+		"update":       {"UPDATE posts SET title = $1 WHERE id = $2"},
+		"delete":       {"DELETE FROM posts WHERE id = $1"},
+	}
+	if len(meta.Routes) != len(want) {
+		t.Fatalf("routes = %v, want %d", meta.Routes, len(want))
+	}
+	for _, route := range meta.Routes {
+		if !reflect.DeepEqual(route.Queries, want[route.Operation]) {
+			t.Errorf("%s queries = %q, want %q", route.Operation, route.Queries, want[route.Operation])
+		}
+	}
+}
+
+func TestLastCommitted(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	post := filepath.Join(dir, "post.cdt")
+	user := filepath.Join(dir, "models", "user.cdt")
+	draft := filepath.Join(dir, "draft.cdt")
+	if err := os.MkdirAll(filepath.Dir(user), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{post, user, draft} {
+		if err := os.WriteFile(path, []byte("resource Post {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths := []string{post, user, draft}
+	if got := lastCommitted(paths); len(got) != 0 {
+		t.Errorf("lastCommitted() = %v outside a repository, want no times", got)
+	}
+
+	git := func(at time.Time, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+at.Format(time.RFC3339), "GIT_COMMITTER_DATE="+at.Format(time.RFC3339))
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", args[0], err, output)
+		}
+	}
+	commit := []string{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "Change resources"}
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	edited := created.Add(48 * time.Hour)
+	git(created, "init", "-q")
+	git(created, "add", "post.cdt", "models/user.cdt")
+	git(created, commit...)
+	if err := os.WriteFile(user, []byte("resource User {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(edited, "add", "models/user.cdt")
+	git(edited, commit...)
+
+	// Touching a file changes its modification time but not its history
+	if err := os.Chtimes(post, time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	got := lastCommitted(paths)
+	if got[post] == nil || !got[post].Equal(created) {
+		t.Errorf("post.cdt committed at %v, want %v", got[post], created)
+	}
+	if got[user] == nil || !got[user].Equal(edited) {
+		t.Errorf("models/user.cdt committed at %v, want %v", got[user], edited)
+	}
+	if _, ok := got[draft]; ok {
+		t.Errorf("draft.cdt was never committed, got %v", got[draft])
+	}
+}
@@ -23,23 +23,33 @@ func NewSchemaExtractor() *SchemaExtractor {
 func (e *SchemaExtractor) ExtractSchemas(compiled []*CompiledFile) (map[string]*schema.ResourceSchema, error) {
 	schemas := make(map[string]*schema.ResourceSchema)
 
+	// Counter caches add columns to resources declared in other files, so
+	// they are applied across all files before building
+	var resources []*ast.ResourceNode
+	var paths []string
 	for _, cf := range compiled {
 		for _, resource := range cf.Program.Resources {
-			resourceSchema, err := e.builder.Build(resource)
-			if err != nil {
-				return nil, fmt.Errorf("failed to build schema for resource %s in %s: %w",
-					resource.Name, cf.Path, err)
-			}
+			resources = append(resources, resource)
+			paths = append(paths, cf.Path)
+		}
+	}
 
-			// Store schema by resource name
-			if existing, exists := schemas[resource.Name]; exists {
-				return nil, fmt.Errorf("duplicate resource name %s (defined in %s and %s)",
-					resource.Name, existing.FilePath, cf.Path)
-			}
+	for i, resource := range ast.WithSoftDeletes(ast.WithPositions(ast.WithCounterCaches(ast.WithTimestamps(resources, false)))) {
+		path := paths[i]
+		resourceSchema, err := e.builder.Build(resource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build schema for resource %s in %s: %w",
+				resource.Name, path, err)
+		}
 
-			resourceSchema.FilePath = cf.Path
-			schemas[resource.Name] = resourceSchema
+		// Store schema by resource name
+		if existing, exists := schemas[resource.Name]; exists {
+			return nil, fmt.Errorf("duplicate resource name %s (defined in %s and %s)",
+				resource.Name, existing.FilePath, path)
 		}
+
+		resourceSchema.FilePath = path
+		schemas[resource.Name] = resourceSchema
 	}
 
 	return schemas, nil
@@ -49,7 +59,7 @@ func (e *SchemaExtractor) ExtractSchemas(compiled []*CompiledFile) (map[string]*
 func (e *SchemaExtractor) ExtractSchemasFromProgram(program *ast.Program, filePath string) (map[string]*schema.ResourceSchema, error) {
 	schemas := make(map[string]*schema.ResourceSchema)
 
-	for _, resource := range program.Resources {
+	for _, resource := range ast.WithSoftDeletes(ast.WithPositions(ast.WithCounterCaches(ast.WithTimestamps(program.Resources, false)))) {
 		resourceSchema, err := e.builder.Build(resource)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build schema for resource %s: %w", resource.Name, err)
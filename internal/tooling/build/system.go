@@ -15,6 +15,7 @@ import (
 	"github.com/conduit-lang/conduit/internal/compiler/ast"
 	"github.com/conduit-lang/conduit/internal/compiler/codegen"
 	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+	"github.com/conduit-lang/conduit/internal/compiler/owners"
 	"github.com/conduit-lang/conduit/internal/compiler/parser"
 	"github.com/conduit-lang/conduit/internal/compiler/typechecker"
 	"github.com/conduit-lang/conduit/internal/utils"
@@ -825,6 +826,11 @@ func (s *System) generateMetadata(compiled []*CompiledFile) (string, error) {
 
 	// Extract metadata from compiled AST
 	extractor := NewMetadataExtractor()
+	codeOwners, err := owners.Load(".")
+	if err != nil {
+		return "", err
+	}
+	extractor.SetCodeOwners(codeOwners)
 	meta, err := extractor.Extract(compiled)
 	if err != nil {
 		return "", fmt.Errorf("failed to extract metadata: %w", err)
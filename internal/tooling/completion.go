@@ -150,6 +150,8 @@ func (a *API) getTypeCompletions() []CompletionItem {
 		{"url", "URL"},
 		{"phone", "Phone number"},
 		{"json", "JSON data"},
+		{"point", "Geographic point (GeoJSON)"},
+		{"polygon", "Geographic area (GeoJSON)"},
 		{"array", "Array collection"},
 		{"hash", "Key-value map"},
 		{"enum", "Enumeration"},
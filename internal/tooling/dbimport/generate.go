@@ -0,0 +1,479 @@
+package dbimport
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/conduit-lang/conduit/internal/compiler/codegen"
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+)
+
+// Options controls how tables become resources
+type Options struct {
+	// External serves every table read-only with @external_table, so
+	// Conduit never migrates it
+	External bool
+
+	// Command names the import command in the header of each file;
+	// conduit import db when empty
+	Command string
+}
+
+// File is a generated .cdt file holding one resource
+type File struct {
+	Name     string // File name, e.g. blog_post.cdt
+	Resource string // Resource declared in the file
+	Table    string // Table the resource was generated from, e.g. public.blog_posts
+	Content  string
+}
+
+// Generate returns one draft resource per table, in the order of tables.
+// Whatever cannot be expressed in .cdt, such as CHECK constraints or
+// unsupported column types, is left as a TODO comment to review by hand.
+func Generate(tables []*Table, opts Options) []*File {
+	names := resourceNames(tables)
+
+	var files []*File
+	for _, table := range tables {
+		g := &generator{table: table, names: names, opts: opts}
+		name := names[table.Schema+"."+table.Name]
+		files = append(files, &File{
+			Name:     snakeCase(name) + ".cdt",
+			Resource: name,
+			Table:    table.Schema + "." + table.Name,
+			Content:  g.generate(name),
+		})
+	}
+	return files
+}
+
+// resourceNames names the resource of each table, keyed by schema.table:
+// its Resource, or the table name singularized in PascalCase. When tables
+// in several schemas share a name, those outside public are prefixed with
+// their schema.
+func resourceNames(tables []*Table) map[string]string {
+	resourceName := func(table *Table) string {
+		if table.Resource != "" {
+			return table.Resource
+		}
+		return ResourceName(table.Name)
+	}
+
+	count := make(map[string]int)
+	for _, table := range tables {
+		count[resourceName(table)]++
+	}
+
+	names := make(map[string]string)
+	for _, table := range tables {
+		name := resourceName(table)
+		if count[name] > 1 && table.Schema != "public" {
+			name = ResourceName(table.Schema) + name
+		}
+		names[table.Schema+"."+table.Name] = name
+	}
+	return names
+}
+
+// ResourceName returns the resource name for a table: its name singularized
+// and in PascalCase, e.g. BlogPost for blog_posts
+func ResourceName(table string) string {
+	words := strings.FieldsFunc(table, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "Table"
+	}
+	words[len(words)-1] = singularize(words[len(words)-1])
+
+	var b strings.Builder
+	for _, word := range words {
+		b.WriteString(strings.ToUpper(word[:1]) + strings.ToLower(word[1:]))
+	}
+	name := b.String()
+	if unicode.IsDigit(rune(name[0])) {
+		name = "T" + name
+	}
+	return name
+}
+
+// singularize undoes the common English plural endings
+func singularize(word string) string {
+	lower := strings.ToLower(word)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(word) > 3:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"),
+		strings.HasSuffix(lower, "zes"), strings.HasSuffix(lower, "ches"),
+		strings.HasSuffix(lower, "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(lower, "s") && !strings.HasSuffix(lower, "ss") &&
+		!strings.HasSuffix(lower, "us") && len(word) > 1:
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// snakeCase converts a resource name to snake_case, e.g. blog_post
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// fieldName returns a valid field name for a column: snake_case letters,
+// digits and underscores, not starting with a digit and not a keyword other
+// than a type name. camelCase columns such as authorId become author_id.
+func fieldName(column string) string {
+	var b strings.Builder
+	var prev rune
+	for _, r := range column {
+		switch {
+		case unicode.IsUpper(r):
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+		prev = r
+	}
+	name := b.String()
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "f_" + name
+	}
+	if tokenType, ok := lexer.Keywords[name]; ok && !lexer.IsType(tokenType) {
+		name += "_field"
+	}
+	return name
+}
+
+// generator renders the resource of one table
+type generator struct {
+	table *Table
+	names map[string]string // Resource names keyed by schema.table
+	opts  Options
+	out   strings.Builder
+}
+
+func (g *generator) line(format string, args ...any) {
+	fmt.Fprintf(&g.out, format+"\n", args...)
+}
+
+func (g *generator) generate(name string) string {
+	table := g.table
+	qualified := table.Name
+	if table.Schema != "public" {
+		qualified = table.Schema + "." + table.Name
+	}
+
+	command := g.opts.Command
+	if command == "" {
+		command = "conduit import db"
+	}
+	g.line("// Generated by: %s", command)
+	g.line("// Source: table %s.%s", table.Schema, table.Name)
+	g.line("// Review this draft before building: types, validations and relationships")
+	g.line("// are inferred from the schema.")
+	if !g.opts.External && codegen.TableName(name) != table.Name {
+		g.line("//")
+		g.line("// TODO: Conduit stores %s in the table %q. Rename the table, or serve it", name, codegen.TableName(name))
+		g.line("// read-only with @external_table(%q).", qualified)
+	}
+	g.line("")
+	g.line("resource %s {", name)
+
+	relationships := g.relationships()
+	compositeKey := len(table.PrimaryKey) > 1
+	for _, column := range table.Columns {
+		if rel, ok := relationships[column.Name]; ok {
+			g.out.WriteString(rel)
+			continue
+		}
+		g.field(column, compositeKey)
+	}
+
+	var todos []string
+	if len(table.PrimaryKey) == 0 {
+		if g.opts.External {
+			todos = append(todos, "the table has no primary key; mark the field identifying its rows @primary")
+		} else {
+			todos = append(todos, "the table has no primary key; Conduit adds an id column")
+		}
+	}
+	for _, unique := range table.Unique {
+		if len(unique) > 1 {
+			todos = append(todos, fmt.Sprintf("UNIQUE (%s)", strings.Join(unique, ", ")))
+		}
+	}
+	for _, fk := range table.ForeignKeys {
+		if len(fk.Columns) > 1 {
+			todos = append(todos, fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s.%s(%s)",
+				strings.Join(fk.Columns, ", "), fk.RefSchema, fk.RefTable, strings.Join(fk.RefColumns, ", ")))
+		}
+	}
+	todos = append(todos, table.Checks...)
+	todos = append(todos, table.Notes...)
+	if len(todos) > 0 {
+		g.line("")
+		g.line("  // TODO: translate by hand:")
+		for _, todo := range todos {
+			g.line("  //   %s", todo)
+		}
+	}
+
+	var annotations []string
+	if compositeKey {
+		var fields []string
+		for _, column := range table.PrimaryKey {
+			fields = append(fields, fieldName(column))
+		}
+		annotations = append(annotations, fmt.Sprintf("@primary(%s)", strings.Join(fields, ", ")))
+	}
+	if g.opts.External {
+		annotations = append(annotations, fmt.Sprintf("@external_table(%q)", qualified))
+	} else if table.Schema != "public" {
+		annotations = append(annotations, fmt.Sprintf("@schema(%q)", table.Schema))
+	}
+	if len(annotations) > 0 {
+		g.line("")
+		for _, annotation := range annotations {
+			g.line("  %s", annotation)
+		}
+	}
+
+	g.line("}")
+	return g.out.String()
+}
+
+// relationships renders the belongs_to relationship standing in for each
+// single-column foreign key to an imported table, keyed by its column. A
+// column of the primary key stays a field, since the key must name it.
+func (g *generator) relationships() map[string]string {
+	table := g.table
+	rendered := make(map[string]string)
+	for _, fk := range table.ForeignKeys {
+		if len(fk.Columns) != 1 {
+			continue
+		}
+		column := table.Column(fk.Columns[0])
+		if column == nil || contains(table.PrimaryKey, column.Name) {
+			continue
+		}
+		target, ok := g.names[fk.RefSchema+"."+fk.RefTable]
+		if !ok {
+			continue
+		}
+
+		name := fieldName(strings.TrimSuffix(fieldName(column.Name), "_id"))
+		if name == fieldName(column.Name) || table.Column(name) != nil {
+			name = fieldName(column.Name)
+		}
+
+		nullability := "!"
+		if column.Nullable {
+			nullability = "?"
+		}
+
+		var body []string
+		if name+"_id" != column.Name {
+			body = append(body, fmt.Sprintf("foreign_key: %q", column.Name))
+		}
+		var note string
+		switch fk.OnDelete {
+		case "CASCADE":
+			body = append(body, "on_delete: cascade")
+		case "SET NULL":
+			body = append(body, "on_delete: nullify")
+		case "RESTRICT":
+			body = append(body, "on_delete: restrict")
+		case "SET DEFAULT":
+			note = " // TODO: ON DELETE SET DEFAULT in the database"
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "  %s: %s%s", name, target, nullability)
+		if len(body) > 0 {
+			b.WriteString(" {\n")
+			for _, line := range body {
+				fmt.Fprintf(&b, "    %s\n", line)
+			}
+			b.WriteString("  }")
+		}
+		b.WriteString(note + "\n")
+		rendered[column.Name] = b.String()
+	}
+	return rendered
+}
+
+// field renders the field of a column
+func (g *generator) field(column *Column, compositeKey bool) {
+	table := g.table
+	name := fieldName(column.Name)
+	fieldType, notes := columnType(column)
+
+	nullability := "!"
+	if column.Nullable {
+		nullability = "?"
+	}
+
+	var annotations []string
+	primary := !compositeKey && len(table.PrimaryKey) == 1 && table.PrimaryKey[0] == column.Name
+	if primary {
+		annotations = append(annotations, "@primary")
+	}
+	if fieldType == "string" || fieldType == "text" {
+		if column.MinLength > 0 {
+			annotations = append(annotations, fmt.Sprintf("@min(%d)", column.MinLength))
+		}
+		if column.MaxLength > 0 {
+			annotations = append(annotations, fmt.Sprintf("@max(%d)", column.MaxLength))
+		}
+		if column.Pattern != "" {
+			annotations = append(annotations, fmt.Sprintf("@pattern(%q)", column.Pattern))
+		}
+	}
+	if !primary {
+		for _, unique := range table.Unique {
+			if len(unique) == 1 && unique[0] == column.Name {
+				annotations = append(annotations, "@unique")
+				break
+			}
+		}
+	}
+
+	switch {
+	case primary && isGenerated(column):
+		annotations = append(annotations, "@auto")
+	case name == "created_at" && fieldType == "timestamp":
+		annotations = append(annotations, "@auto")
+	case name == "updated_at" && fieldType == "timestamp":
+		annotations = append(annotations, "@auto_update")
+	case column.Default != "":
+		if value, ok := defaultValue(column.Default, fieldType); ok {
+			annotations = append(annotations, fmt.Sprintf("@default(%s)", value))
+		} else {
+			notes = append(notes, "DEFAULT "+column.Default)
+		}
+	}
+
+	if name != column.Name {
+		annotations = append(annotations, fmt.Sprintf("@column(%q)", column.Name))
+	}
+
+	// A foreign key without a relationship: part of the primary key, or to
+	// a table that was not imported
+	for _, fk := range table.ForeignKeys {
+		if len(fk.Columns) == 1 && fk.Columns[0] == column.Name {
+			notes = append(notes, fmt.Sprintf("references %s.%s(%s)", fk.RefSchema, fk.RefTable, strings.Join(fk.RefColumns, ", ")))
+		}
+	}
+	notes = append(notes, column.Notes...)
+
+	line := fmt.Sprintf("  %s: %s%s", name, fieldType, nullability)
+	if len(annotations) > 0 {
+		line += " " + strings.Join(annotations, " ")
+	}
+	if len(notes) > 0 {
+		line += " // TODO: " + strings.Join(notes, "; ")
+	}
+	g.line("%s", line)
+}
+
+// columnType returns the Conduit type of a column, with notes on what the
+// type does not capture
+func columnType(column *Column) (string, []string) {
+	switch column.DataType {
+	case "character varying", "character":
+		if column.MaxLength == 0 {
+			return "text", nil
+		}
+		return "string", nil
+	case "text":
+		return "text", nil
+	case "smallint", "integer", "bigint":
+		return "int", nil
+	case "real", "double precision":
+		return "float", nil
+	case "numeric":
+		return "float", []string{"numeric in the database"}
+	case "boolean":
+		return "bool", nil
+	case "uuid":
+		return "uuid", nil
+	case "timestamp with time zone", "timestamp without time zone":
+		return "timestamp", nil
+	case "date":
+		return "timestamp", []string{"date in the database"}
+	case "json", "jsonb":
+		return "json", nil
+	case "USER-DEFINED":
+		if len(column.EnumValues) > 0 {
+			values := make([]string, len(column.EnumValues))
+			for i, value := range column.EnumValues {
+				values[i] = fmt.Sprintf("%q", value)
+			}
+			return fmt.Sprintf("enum [%s]", strings.Join(values, ", ")), nil
+		}
+		return "text", []string{column.UDTName + " in the database"}
+	}
+	return "text", []string{column.DataType + " in the database"}
+}
+
+// isGenerated reports whether the database generates a key column's values
+func isGenerated(column *Column) bool {
+	return column.Identity ||
+		strings.HasPrefix(column.Default, "nextval(") ||
+		strings.HasPrefix(column.Default, "gen_random_uuid()") ||
+		strings.HasPrefix(column.Default, "uuid_generate_v4()")
+}
+
+var (
+	stringDefault  = regexp.MustCompile(`^'((?:[^']|'')*)'(?:::[a-z_ ]+)?$`)
+	numericDefault = regexp.MustCompile(`^\(?(-?[0-9]+(?:\.[0-9]+)?)\)?(?:::[a-z_ ]+)?$`)
+)
+
+// defaultValue translates a column default to a @default argument when it
+// is a literal
+func defaultValue(expr, fieldType string) (string, bool) {
+	switch {
+	case expr == "true" || expr == "false":
+		return expr, fieldType == "bool"
+	case stringDefault.MatchString(expr):
+		// The type checker does not accept strings as enum defaults yet
+		if fieldType == "json" || strings.HasPrefix(fieldType, "enum") {
+			return "", false
+		}
+		value := stringDefault.FindStringSubmatch(expr)[1]
+		return fmt.Sprintf("%q", strings.ReplaceAll(value, "''", "'")), true
+	case numericDefault.MatchString(expr):
+		value := numericDefault.FindStringSubmatch(expr)[1]
+		if fieldType == "float" && !strings.Contains(value, ".") {
+			value += ".0"
+		}
+		return value, fieldType == "int" || fieldType == "float"
+	}
+	return "", false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
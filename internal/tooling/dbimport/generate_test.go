@@ -0,0 +1,197 @@
+package dbimport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+	"github.com/conduit-lang/conduit/internal/compiler/parser"
+	"github.com/conduit-lang/conduit/internal/compiler/typechecker"
+)
+
+func testTables() []*Table {
+	return []*Table{
+		{
+			Schema: "public",
+			Name:   "users",
+			Columns: []*Column{
+				{Name: "id", DataType: "bigint", Identity: true},
+				{Name: "email", DataType: "character varying", MaxLength: 255},
+				{Name: "Display Name", DataType: "text", Nullable: true},
+				{Name: "role", DataType: "USER-DEFINED", UDTName: "user_role", EnumValues: []string{"member", "admin"}, Default: "'member'::user_role"},
+				{Name: "created_at", DataType: "timestamp with time zone", Default: "now()"},
+				{Name: "updated_at", DataType: "timestamp with time zone", Default: "now()"},
+			},
+			PrimaryKey: []string{"id"},
+			Unique:     [][]string{{"email"}},
+		},
+		{
+			Schema: "public",
+			Name:   "blog_posts",
+			Columns: []*Column{
+				{Name: "id", DataType: "uuid", Default: "gen_random_uuid()"},
+				{Name: "author_id", DataType: "bigint"},
+				{Name: "editor", DataType: "bigint", Nullable: true},
+				{Name: "title", DataType: "character varying", MaxLength: 200},
+				{Name: "views", DataType: "integer", Default: "0"},
+				{Name: "published", DataType: "boolean", Default: "false"},
+				{Name: "price", DataType: "numeric", Nullable: true},
+				{Name: "when", DataType: "timestamp without time zone", Nullable: true, Default: "CURRENT_TIMESTAMP"},
+			},
+			PrimaryKey: []string{"id"},
+			ForeignKeys: []ForeignKey{
+				{Columns: []string{"author_id"}, RefSchema: "public", RefTable: "users", RefColumns: []string{"id"}, OnDelete: "CASCADE"},
+				{Columns: []string{"editor"}, RefSchema: "public", RefTable: "users", RefColumns: []string{"id"}, OnDelete: "SET NULL"},
+			},
+			Checks: []string{"CHECK ((views >= 0))"},
+		},
+		{
+			Schema: "public",
+			Name:   "post_tags",
+			Columns: []*Column{
+				{Name: "post_id", DataType: "uuid"},
+				{Name: "tag", DataType: "character varying", MaxLength: 50},
+			},
+			PrimaryKey: []string{"post_id", "tag"},
+			ForeignKeys: []ForeignKey{
+				{Columns: []string{"post_id"}, RefSchema: "public", RefTable: "blog_posts", RefColumns: []string{"id"}, OnDelete: "CASCADE"},
+			},
+		},
+		{
+			Schema: "billing",
+			Name:   "users",
+			Columns: []*Column{
+				{Name: "id", DataType: "uuid"},
+				{Name: "balance", DataType: "double precision", Default: "0"},
+			},
+			PrimaryKey: []string{"id"},
+		},
+	}
+}
+
+// checkFiles parses and type checks the generated files together
+func checkFiles(t *testing.T, files []*File) {
+	t.Helper()
+	program := &ast.Program{}
+	for _, file := range files {
+		tokens, lexErrors := lexer.New(file.Content).ScanTokens()
+		if len(lexErrors) > 0 {
+			t.Fatalf("%s: %v\n%s", file.Name, lexErrors, file.Content)
+		}
+		parsed, parseErrors := parser.New(tokens).Parse()
+		if len(parseErrors) > 0 {
+			t.Fatalf("%s: %v\n%s", file.Name, parseErrors, file.Content)
+		}
+		program.Resources = append(program.Resources, parsed.Resources...)
+	}
+	for _, err := range typechecker.NewTypeChecker().CheckProgram(program) {
+		if err.Severity == typechecker.SeverityError {
+			t.Errorf("type error: %v", err)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	files := Generate(testTables(), Options{})
+	if len(files) != 4 {
+		t.Fatalf("Expected 4 files, got %d", len(files))
+	}
+	checkFiles(t, files)
+
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name+"="+file.Resource)
+	}
+	if got := strings.Join(names, " "); got != "user.cdt=User blog_post.cdt=BlogPost post_tag.cdt=PostTag billing_user.cdt=BillingUser" {
+		t.Errorf("unexpected files %s", got)
+	}
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"user.cdt", []string{
+			"// Source: table public.users",
+			"resource User {\n",
+			"  id: int! @primary @auto\n",
+			"  email: string! @max(255) @unique\n",
+			`  display_name: text? @column("Display Name")` + "\n",
+			`  role: enum ["member", "admin"]! // TODO: DEFAULT 'member'::user_role` + "\n",
+			"  created_at: timestamp! @auto\n",
+			"  updated_at: timestamp! @auto_update\n",
+		}},
+		{"blog_post.cdt", []string{
+			`// TODO: Conduit stores BlogPost in the table "blogposts". Rename the table, or serve it`,
+			"  id: uuid! @primary @auto\n",
+			"  author: User! {\n    on_delete: cascade\n  }\n",
+			"  editor: User? {\n    foreign_key: \"editor\"\n    on_delete: nullify\n  }\n",
+			"  title: string! @max(200)\n",
+			"  views: int! @default(0)\n",
+			"  published: bool! @default(false)\n",
+			"  price: float? // TODO: numeric in the database\n",
+			`  when_field: timestamp? @column("when") // TODO: DEFAULT CURRENT_TIMESTAMP` + "\n",
+			"  // TODO: translate by hand:\n  //   CHECK ((views >= 0))\n",
+		}},
+		{"post_tag.cdt", []string{
+			"  post_id: uuid! // TODO: references public.blog_posts(id)\n",
+			"  tag: string! @max(50)\n",
+			"\n  @primary(post_id, tag)\n}\n",
+		}},
+		{"billing_user.cdt", []string{
+			"  balance: float! @default(0.0)\n",
+			"\n  @schema(\"billing\")\n}\n",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var content string
+			for _, file := range files {
+				if file.Name == tt.file {
+					content = file.Content
+				}
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content, want) {
+					t.Errorf("missing %q in:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerate_External(t *testing.T) {
+	files := Generate(testTables(), Options{External: true})
+	checkFiles(t, files)
+
+	for _, file := range files {
+		if strings.Contains(file.Content, "TODO: Conduit stores") || strings.Contains(file.Content, "@schema") {
+			t.Errorf("%s: external resources keep their table:\n%s", file.Name, file.Content)
+		}
+	}
+	if !strings.Contains(files[1].Content, "\n  @external_table(\"blog_posts\")\n}\n") {
+		t.Errorf("expected @external_table for blog_posts:\n%s", files[1].Content)
+	}
+	if !strings.Contains(files[3].Content, "\n  @external_table(\"billing.users\")\n}\n") {
+		t.Errorf("expected a qualified @external_table for billing.users:\n%s", files[3].Content)
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	tests := map[string]string{
+		"users":        "User",
+		"blog_posts":   "BlogPost",
+		"categories":   "Category",
+		"addresses":    "Address",
+		"boxes":        "Box",
+		"status":       "Status",
+		"order_items":  "OrderItem",
+		"CustomerData": "Customerdata",
+		"2fa_codes":    "T2faCode",
+	}
+	for table, want := range tests {
+		if got := ResourceName(table); got != want {
+			t.Errorf("ResourceName(%q) = %q, want %q", table, got, want)
+		}
+	}
+}
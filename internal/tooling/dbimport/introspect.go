@@ -0,0 +1,225 @@
+// Package dbimport reverse-engineers existing schemas into draft .cdt
+// resources, so a project can adopt Conduit on top of the tables it already
+// has: a live PostgreSQL database, a Rails db/schema.rb with its models, or a
+// Prisma schema.
+package dbimport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Table is a table read from the database
+type Table struct {
+	Schema      string
+	Name        string
+	Resource    string // Resource name to generate; derived from Name when empty
+	Columns     []*Column
+	PrimaryKey  []string     // Columns of the primary key, in key order
+	Unique      [][]string   // Columns of each unique constraint
+	ForeignKeys []ForeignKey // Foreign keys declared on the table
+	Checks      []string     // CHECK constraints as PostgreSQL prints them
+	Notes       []string     // What the import could not translate, such as has_many associations
+}
+
+// Column is a column of a table
+type Column struct {
+	Name       string
+	DataType   string   // information_schema data_type, e.g. character varying
+	UDTName    string   // Underlying type name, e.g. varchar or an enum's name
+	MaxLength  int      // Length of a varchar or char column; 0 when unbounded
+	Nullable   bool     // Column accepts NULL
+	Default    string   // Default expression; empty when there is none
+	Identity   bool     // GENERATED AS IDENTITY column
+	EnumValues []string // Labels of an enum type, in order
+
+	// Validations declared by an application framework rather than the
+	// database
+	MinLength int      // Shortest value allowed; 0 when unchecked
+	Pattern   string   // Regular expression values must match
+	Notes     []string // What the import could not translate
+}
+
+// ForeignKey is a foreign key constraint
+type ForeignKey struct {
+	Columns    []string
+	RefSchema  string
+	RefTable   string
+	RefColumns []string
+	OnDelete   string // NO ACTION, RESTRICT, CASCADE, SET NULL or SET DEFAULT
+}
+
+// Column returns the named column of the table, or nil
+func (t *Table) Column(name string) *Column {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column
+		}
+	}
+	return nil
+}
+
+// Querier runs queries; *pgx.Conn and *pgxpool.Pool satisfy it
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// ignoredTables are tables Conduit manages itself
+var ignoredTables = map[string]bool{
+	"schema_migrations": true,
+}
+
+// Introspect reads the tables of the given schemas, with their columns and
+// constraints, in schema and table name order
+func Introspect(ctx context.Context, db Querier, schemas []string) ([]*Table, error) {
+	var tables []*Table
+	byName := make(map[string]*Table)
+
+	rows, err := db.Query(ctx, `SELECT table_schema, table_name FROM information_schema.tables
+WHERE table_type = 'BASE TABLE' AND table_schema = ANY($1)
+ORDER BY table_schema, table_name`, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	for rows.Next() {
+		table := &Table{}
+		if err := rows.Scan(&table.Schema, &table.Name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to list tables: %w", err)
+		}
+		if ignoredTables[table.Name] {
+			continue
+		}
+		tables = append(tables, table)
+		byName[table.Schema+"."+table.Name] = table
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	enums, err := loadEnums(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadColumns(ctx, db, schemas, byName, enums); err != nil {
+		return nil, err
+	}
+	if err := loadConstraints(ctx, db, schemas, byName); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// loadEnums returns the labels of every enum type, keyed by type name
+func loadEnums(ctx context.Context, db Querier) (map[string][]string, error) {
+	rows, err := db.Query(ctx, `SELECT t.typname, e.enumlabel FROM pg_type t
+JOIN pg_enum e ON e.enumtypid = t.oid
+ORDER BY t.typname, e.enumsortorder`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read enum types: %w", err)
+	}
+	defer rows.Close()
+
+	enums := make(map[string][]string)
+	for rows.Next() {
+		var name, label string
+		if err := rows.Scan(&name, &label); err != nil {
+			return nil, fmt.Errorf("failed to read enum types: %w", err)
+		}
+		enums[name] = append(enums[name], label)
+	}
+	return enums, rows.Err()
+}
+
+// loadColumns adds the columns of each table in tables, in column order
+func loadColumns(ctx context.Context, db Querier, schemas []string, tables map[string]*Table, enums map[string][]string) error {
+	rows, err := db.Query(ctx, `SELECT table_schema, table_name, column_name, data_type, udt_name,
+  COALESCE(character_maximum_length, 0), is_nullable = 'YES', COALESCE(column_default, ''), is_identity = 'YES'
+FROM information_schema.columns
+WHERE table_schema = ANY($1)
+ORDER BY table_schema, table_name, ordinal_position`, schemas)
+	if err != nil {
+		return fmt.Errorf("failed to read columns: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schema, table string
+		column := &Column{}
+		if err := rows.Scan(&schema, &table, &column.Name, &column.DataType, &column.UDTName,
+			&column.MaxLength, &column.Nullable, &column.Default, &column.Identity); err != nil {
+			return fmt.Errorf("failed to read columns: %w", err)
+		}
+		if column.DataType == "USER-DEFINED" {
+			column.EnumValues = enums[column.UDTName]
+		}
+		if t := tables[schema+"."+table]; t != nil {
+			t.Columns = append(t.Columns, column)
+		}
+	}
+	return rows.Err()
+}
+
+// onDeleteActions maps pg_constraint.confdeltype to the SQL action
+var onDeleteActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+// loadConstraints adds the primary key, unique, foreign key and check
+// constraints of each table in tables
+func loadConstraints(ctx context.Context, db Querier, schemas []string, tables map[string]*Table) error {
+	rows, err := db.Query(ctx, `SELECT n.nspname, c.relname, con.contype::text,
+  ARRAY(SELECT a.attname::text FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+    JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum ORDER BY k.ord),
+  COALESCE(fn.nspname::text, ''), COALESCE(fc.relname::text, ''),
+  ARRAY(SELECT a.attname::text FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, ord)
+    JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum ORDER BY k.ord),
+  con.confdeltype::text, pg_get_constraintdef(con.oid)
+FROM pg_constraint con
+JOIN pg_class c ON c.oid = con.conrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_class fc ON fc.oid = con.confrelid
+LEFT JOIN pg_namespace fn ON fn.oid = fc.relnamespace
+WHERE n.nspname = ANY($1) AND con.contype IN ('p', 'u', 'f', 'c')
+ORDER BY n.nspname, c.relname, con.conname`, schemas)
+	if err != nil {
+		return fmt.Errorf("failed to read constraints: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schema, table, kind, refSchema, refTable, onDelete, definition string
+		var columns, refColumns []string
+		if err := rows.Scan(&schema, &table, &kind, &columns, &refSchema, &refTable, &refColumns, &onDelete, &definition); err != nil {
+			return fmt.Errorf("failed to read constraints: %w", err)
+		}
+		t := tables[schema+"."+table]
+		if t == nil {
+			continue
+		}
+		switch kind {
+		case "p":
+			t.PrimaryKey = columns
+		case "u":
+			t.Unique = append(t.Unique, columns)
+		case "f":
+			t.ForeignKeys = append(t.ForeignKeys, ForeignKey{
+				Columns:    columns,
+				RefSchema:  refSchema,
+				RefTable:   refTable,
+				RefColumns: refColumns,
+				OnDelete:   onDeleteActions[onDelete],
+			})
+		case "c":
+			t.Checks = append(t.Checks, definition)
+		}
+	}
+	return rows.Err()
+}
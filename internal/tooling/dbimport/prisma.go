@@ -0,0 +1,379 @@
+package dbimport
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// prismaTypes maps Prisma scalar types to the data types PostgreSQL
+// reports for their default columns
+var prismaTypes = map[string]string{
+	"String":   "text",
+	"Int":      "integer",
+	"BigInt":   "bigint",
+	"Float":    "double precision",
+	"Decimal":  "numeric",
+	"Boolean":  "boolean",
+	"DateTime": "timestamp without time zone",
+	"Json":     "jsonb",
+	"Bytes":    "bytea",
+}
+
+// prismaOnDelete maps the onDelete argument of @relation to the SQL action
+var prismaOnDelete = map[string]string{
+	"Cascade":    "CASCADE",
+	"SetNull":    "SET NULL",
+	"Restrict":   "RESTRICT",
+	"NoAction":   "NO ACTION",
+	"SetDefault": "SET DEFAULT",
+}
+
+var (
+	prismaBlock     = regexp.MustCompile(`^(model|enum)\s+(\w+)\s*\{$`)
+	prismaField     = regexp.MustCompile(`^(\w+)\s+(\w+)(\[\])?(\?)?\s*(.*)$`)
+	prismaAttribute = regexp.MustCompile(`@@?[\w.]+`)
+	prismaMaxLength = regexp.MustCompile(`^@db\.(?:VarChar|Char)\((\d+)\)$`)
+)
+
+// prismaFieldDecl is a field of a Prisma model before its type is resolved
+type prismaFieldDecl struct {
+	name       string
+	typeName   string
+	list       bool
+	optional   bool
+	attributes []prismaAttr
+}
+
+// prismaAttr is one @attribute or @@attribute with its raw arguments
+type prismaAttr struct {
+	name string
+	args string
+}
+
+// ParsePrisma reads the models of a Prisma schema. Models are returned in
+// the order the schema declares them, each stored in its @@map table.
+// List relations become notes, since the other side of the relation holds
+// the foreign key.
+func ParsePrisma(source string) ([]*Table, error) {
+	type model struct {
+		name       string
+		fields     []prismaFieldDecl
+		attributes []prismaAttr
+	}
+
+	var models []*model
+	enums := make(map[string][]string)
+	var currentModel *model
+	var currentEnum string
+	inBlock := false
+
+	for i, raw := range strings.Split(source, "\n") {
+		line := strings.TrimSpace(stripPrismaComment(raw))
+		if line == "" {
+			continue
+		}
+
+		if !inBlock {
+			m := prismaBlock.FindStringSubmatch(line)
+			if m == nil {
+				// datasource, generator and other blocks are skipped
+				if strings.HasSuffix(line, "{") {
+					inBlock = true
+				}
+				continue
+			}
+			inBlock = true
+			if m[1] == "model" {
+				currentModel = &model{name: m[2]}
+				models = append(models, currentModel)
+			} else {
+				currentEnum = m[2]
+				enums[currentEnum] = nil
+			}
+			continue
+		}
+
+		if line == "}" {
+			inBlock, currentModel, currentEnum = false, nil, ""
+			continue
+		}
+
+		switch {
+		case currentEnum != "":
+			if !strings.HasPrefix(line, "@@") {
+				enums[currentEnum] = append(enums[currentEnum], strings.Fields(line)[0])
+			}
+		case currentModel != nil:
+			if strings.HasPrefix(line, "@@") {
+				currentModel.attributes = append(currentModel.attributes, prismaAttributes(line)...)
+				continue
+			}
+			m := prismaField.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("schema.prisma:%d: unexpected line in model %s: %s", i+1, currentModel.name, line)
+			}
+			currentModel.fields = append(currentModel.fields, prismaFieldDecl{
+				name:       m[1],
+				typeName:   m[2],
+				list:       m[3] != "",
+				optional:   m[4] != "",
+				attributes: prismaAttributes(m[5]),
+			})
+		}
+	}
+	if inBlock {
+		return nil, fmt.Errorf("schema.prisma: block is not closed")
+	}
+
+	// Models are referenced by name in relations, before the table a later
+	// model maps to is known
+	tableNames := make(map[string][2]string)
+	for _, model := range models {
+		schema, name := "public", model.name
+		for _, attr := range model.attributes {
+			switch attr.name {
+			case "@@map":
+				name = prismaString(attr.args)
+			case "@@schema":
+				schema = prismaString(attr.args)
+			}
+		}
+		tableNames[model.name] = [2]string{schema, name}
+	}
+
+	var tables []*Table
+	for _, model := range models {
+		names := tableNames[model.name]
+		table := &Table{Schema: names[0], Name: names[1], Resource: model.name}
+
+		// Columns are named by @map; attributes refer to fields
+		columnNames := make(map[string]string)
+		for _, field := range model.fields {
+			columnNames[field.name] = field.name
+			for _, attr := range field.attributes {
+				if attr.name == "@map" {
+					columnNames[field.name] = prismaString(attr.args)
+				}
+			}
+		}
+		columns := func(list string) []string {
+			var names []string
+			for _, field := range prismaList(list) {
+				if column, ok := columnNames[field]; ok {
+					names = append(names, column)
+				} else {
+					names = append(names, field)
+				}
+			}
+			return names
+		}
+
+		for _, field := range model.fields {
+			if _, ok := tableNames[field.typeName]; ok {
+				if field.list {
+					table.Notes = append(table.Notes, fmt.Sprintf("has many %s as %s", field.typeName, field.name))
+					continue
+				}
+				for _, attr := range field.attributes {
+					if attr.name != "@relation" {
+						continue
+					}
+					args := prismaArgs(attr.args)
+					if args["fields"] == "" {
+						continue
+					}
+					target := tableNames[field.typeName]
+					table.ForeignKeys = append(table.ForeignKeys, ForeignKey{
+						Columns:    columns(args["fields"]),
+						RefSchema:  target[0],
+						RefTable:   target[1],
+						RefColumns: prismaList(args["references"]),
+						OnDelete:   prismaOnDelete[args["onDelete"]],
+					})
+				}
+				continue
+			}
+
+			column := &Column{
+				Name:     columnNames[field.name],
+				DataType: field.typeName,
+				Nullable: field.optional,
+			}
+			if dataType, ok := prismaTypes[field.typeName]; ok {
+				column.DataType = dataType
+			} else if values, ok := enums[field.typeName]; ok {
+				column.DataType = "USER-DEFINED"
+				column.UDTName = field.typeName
+				column.EnumValues = values
+			}
+			if field.list {
+				column.DataType = "ARRAY"
+			}
+
+			for _, attr := range field.attributes {
+				switch {
+				case attr.name == "@id":
+					table.PrimaryKey = []string{column.Name}
+				case attr.name == "@unique":
+					table.Unique = append(table.Unique, []string{column.Name})
+				case attr.name == "@updatedAt":
+					// Only a field named updated_at becomes @auto_update
+					if fieldName(column.Name) != "updated_at" {
+						column.Notes = append(column.Notes, "@updatedAt in Prisma")
+					}
+				case attr.name == "@default":
+					applyPrismaDefault(column, attr.args)
+				case attr.name == "@db.Uuid":
+					column.DataType = "uuid"
+				case attr.name == "@db.Timestamptz":
+					column.DataType = "timestamp with time zone"
+				case attr.name == "@db.Date":
+					column.DataType = "date"
+				case prismaMaxLength.MatchString(attr.name + "(" + attr.args + ")"):
+					column.DataType = "character varying"
+					column.MaxLength, _ = strconv.Atoi(attr.args)
+				}
+			}
+			table.Columns = append(table.Columns, column)
+		}
+
+		for _, attr := range model.attributes {
+			switch attr.name {
+			case "@@id":
+				table.PrimaryKey = columns(prismaArgs(attr.args)["fields"])
+			case "@@unique":
+				table.Unique = append(table.Unique, columns(prismaArgs(attr.args)["fields"]))
+			}
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// applyPrismaDefault sets the default of a column from the argument of its
+// @default attribute, as the SQL default Prisma migrations create
+func applyPrismaDefault(column *Column, value string) {
+	switch value {
+	case "autoincrement()":
+		column.Identity = true
+	case "uuid()":
+		column.DataType = "uuid"
+		column.Default = "gen_random_uuid()"
+	case "now()":
+		column.Default = "CURRENT_TIMESTAMP"
+	case "cuid()":
+		column.Notes = append(column.Notes, "@default(cuid()) in Prisma")
+	default:
+		switch {
+		case strings.HasPrefix(value, "dbgenerated("):
+			column.Default = prismaString(strings.TrimSuffix(strings.TrimPrefix(value, "dbgenerated("), ")"))
+		case strings.HasPrefix(value, `"`):
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			column.Default = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+		case column.DataType == "USER-DEFINED":
+			column.Default = "'" + value + "'::" + column.UDTName
+		default:
+			column.Default = value
+		}
+	}
+}
+
+// prismaAttributes splits the attributes after a field type, or a block
+// attribute line, into their names and arguments
+func prismaAttributes(s string) []prismaAttr {
+	var attrs []prismaAttr
+	locs := prismaAttribute.FindAllStringIndex(s, -1)
+	for _, loc := range locs {
+		if loc[0] > 0 && s[loc[0]-1] != ' ' && s[loc[0]-1] != '\t' {
+			// An @ inside the arguments of another attribute
+			continue
+		}
+		attr := prismaAttr{name: s[loc[0]:loc[1]]}
+		if loc[1] < len(s) && s[loc[1]] == '(' {
+			attr.args = balancedArgs(s[loc[1]:])
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs
+}
+
+// balancedArgs returns what is inside the parentheses s starts with
+func balancedArgs(s string) string {
+	depth := 0
+	var quote bool
+	for i, r := range s {
+		switch {
+		case r == '"' && (i == 0 || s[i-1] != '\\'):
+			quote = !quote
+		case quote:
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+			if depth == 0 {
+				return s[1:i]
+			}
+		}
+	}
+	return strings.TrimPrefix(s, "(")
+}
+
+// prismaArgs returns the named arguments of an attribute, such as fields
+// and references of @relation. A leading unnamed argument is keyed fields,
+// as in @@id([a, b]).
+func prismaArgs(s string) map[string]string {
+	args := make(map[string]string)
+	for i, part := range splitTopLevel(s) {
+		key, value, ok := strings.Cut(part, ":")
+		if !ok || strings.HasPrefix(part, `"`) {
+			if i == 0 {
+				args["fields"] = part
+			}
+			continue
+		}
+		args[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return args
+}
+
+// prismaList returns the names in a list argument such as [authorId]
+func prismaList(s string) []string {
+	var names []string
+	for _, name := range strings.Split(strings.Trim(strings.TrimSpace(s), "[]"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			// Sort orders and lengths, as in id(sort: Desc), are dropped
+			if i := strings.Index(name, "("); i >= 0 {
+				name = name[:i]
+			}
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// prismaString returns the value of a quoted string argument
+func prismaString(s string) string {
+	s = strings.TrimSpace(s)
+	if value, err := strconv.Unquote(s); err == nil {
+		return value
+	}
+	return s
+}
+
+// stripPrismaComment removes a // comment outside string literals
+func stripPrismaComment(line string) string {
+	quote := false
+	for i := 0; i+1 < len(line); i++ {
+		switch {
+		case line[i] == '"' && (i == 0 || line[i-1] != '\\'):
+			quote = !quote
+		case !quote && line[i] == '/' && line[i+1] == '/':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,150 @@
+package dbimport
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPrismaSchema = `datasource db {
+  provider = "postgresql"
+  url      = env("DATABASE_URL")
+}
+
+generator client {
+  provider = "prisma-client-js"
+}
+
+enum Role {
+  MEMBER
+  ADMIN
+}
+
+model User {
+  id        Int        @id @default(autoincrement())
+  email     String     @unique @db.VarChar(255)
+  name      String?
+  role      Role       @default(MEMBER)
+  posts     BlogPost[]
+  createdAt DateTime   @default(now()) @map("created_at")
+  updatedAt DateTime   @updatedAt @map("updated_at")
+
+  @@map("users")
+}
+
+// Posts belong to their author
+model BlogPost {
+  id        String   @id @default(uuid()) @db.Uuid
+  title     String   @db.VarChar(200)
+  views     Int      @default(0)
+  author    User     @relation(fields: [authorId], references: [id], onDelete: Cascade)
+  authorId  Int
+  tags      PostTag[]
+
+  @@unique([authorId, title])
+  @@map("blog_posts")
+}
+
+model PostTag {
+  post   BlogPost @relation(fields: [postId], references: [id])
+  postId String   @db.Uuid @map("post_id")
+  tag    String   @db.VarChar(50)
+
+  @@id([postId, tag])
+  @@map("post_tags")
+}
+`
+
+func TestParsePrisma(t *testing.T) {
+	tables, err := ParsePrisma(testPrismaSchema)
+	if err != nil {
+		t.Fatalf("ParsePrisma: %v", err)
+	}
+	if len(tables) != 3 {
+		t.Fatalf("Expected 3 tables, got %d", len(tables))
+	}
+
+	users := tables[0]
+	if users.Name != "users" || users.Resource != "User" {
+		t.Errorf("unexpected users table %s %s", users.Name, users.Resource)
+	}
+	if id := users.Column("id"); !id.Identity || id.DataType != "integer" {
+		t.Errorf("expected an identity id, got %+v", id)
+	}
+	if role := users.Column("role"); role.Default != "'MEMBER'::Role" || !reflect.DeepEqual(role.EnumValues, []string{"MEMBER", "ADMIN"}) {
+		t.Errorf("unexpected role column %+v", role)
+	}
+	if users.Column("created_at") == nil || users.Column("posts") != nil {
+		t.Errorf("expected mapped columns without the posts relation")
+	}
+	if !reflect.DeepEqual(users.Notes, []string{"has many BlogPost as posts"}) {
+		t.Errorf("unexpected notes %v", users.Notes)
+	}
+
+	posts := tables[1]
+	want := []ForeignKey{{Columns: []string{"authorId"}, RefSchema: "public", RefTable: "users", RefColumns: []string{"id"}, OnDelete: "CASCADE"}}
+	if !reflect.DeepEqual(posts.ForeignKeys, want) {
+		t.Errorf("unexpected foreign keys %+v", posts.ForeignKeys)
+	}
+	if !reflect.DeepEqual(posts.Unique, [][]string{{"authorId", "title"}}) {
+		t.Errorf("unexpected unique constraints %v", posts.Unique)
+	}
+
+	tags := tables[2]
+	if !reflect.DeepEqual(tags.PrimaryKey, []string{"post_id", "tag"}) {
+		t.Errorf("expected the mapped composite key, got %v", tags.PrimaryKey)
+	}
+}
+
+func TestParsePrisma_Generate(t *testing.T) {
+	tables, err := ParsePrisma(testPrismaSchema)
+	if err != nil {
+		t.Fatalf("ParsePrisma: %v", err)
+	}
+	files := Generate(tables, Options{Command: "conduit import prisma"})
+	checkFiles(t, files)
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"user.cdt", []string{
+			"  id: int! @primary @auto\n",
+			"  email: string! @max(255) @unique\n",
+			"  created_at: timestamp! @auto\n",
+			"  updated_at: timestamp! @auto_update\n",
+			"  //   has many BlogPost as posts\n",
+		}},
+		{"blog_post.cdt", []string{
+			"  id: uuid! @primary @auto\n",
+			"  author: User! {\n    foreign_key: \"authorId\"\n    on_delete: cascade\n  }\n",
+			"  //   UNIQUE (authorId, title)\n",
+		}},
+		{"post_tag.cdt", []string{
+			"  post_id: uuid! // TODO: references public.blog_posts(id)\n",
+			"\n  @primary(post_id, tag)\n}\n",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var content string
+			for _, file := range files {
+				if file.Name == tt.file {
+					content = file.Content
+				}
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content, want) {
+					t.Errorf("missing %q in:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePrisma_Unclosed(t *testing.T) {
+	_, err := ParsePrisma("model User {\n  id Int @id\n")
+	if err == nil || !strings.Contains(err.Error(), "not closed") {
+		t.Errorf("expected an unclosed block error, got %v", err)
+	}
+}
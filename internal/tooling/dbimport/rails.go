@@ -0,0 +1,644 @@
+package dbimport
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// railsTypes maps the column types of a Rails schema to the data types
+// PostgreSQL reports for them
+var railsTypes = map[string]string{
+	"string":      "character varying",
+	"text":        "text",
+	"integer":     "integer",
+	"bigint":      "bigint",
+	"float":       "double precision",
+	"decimal":     "numeric",
+	"boolean":     "boolean",
+	"date":        "date",
+	"datetime":    "timestamp without time zone",
+	"timestamp":   "timestamp without time zone",
+	"timestamptz": "timestamp with time zone",
+	"time":        "time without time zone",
+	"json":        "json",
+	"jsonb":       "jsonb",
+	"uuid":        "uuid",
+	"binary":      "bytea",
+}
+
+var (
+	railsCreateEnum  = regexp.MustCompile(`^create_enum\s+"([^"]+)",\s*\[(.*)\]`)
+	railsCreateTable = regexp.MustCompile(`^create_table\s+"([^"]+)"(.*?)\s+do\s*\|\w+\|$`)
+	railsColumn      = regexp.MustCompile(`^t\.(\w+)\s*(.*)$`)
+	railsStatement   = regexp.MustCompile(`^(add_foreign_key|add_index|add_check_constraint)\s+(.*)$`)
+)
+
+// ParseRailsSchema reads the tables of a Rails db/schema.rb. Tables are
+// returned in the order the schema creates them.
+func ParseRailsSchema(source string) ([]*Table, error) {
+	var tables []*Table
+	enums := make(map[string][]string)
+	byName := make(map[string]*Table)
+	var current *Table
+
+	for i, raw := range strings.Split(source, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if current != nil {
+			if line == "end" {
+				current = nil
+				continue
+			}
+			m := railsColumn.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("schema.rb:%d: unexpected line in create_table: %s", i+1, line)
+			}
+			args, opts := rubyArgs(m[2])
+			if err := addRailsColumn(current, m[1], args, opts, enums); err != nil {
+				return nil, fmt.Errorf("schema.rb:%d: %w", i+1, err)
+			}
+			continue
+		}
+
+		if m := railsCreateEnum.FindStringSubmatch(line); m != nil {
+			values, _ := rubyArgs(m[2])
+			for _, value := range values {
+				enums[m[1]] = append(enums[m[1]], rubyString(value))
+			}
+			continue
+		}
+
+		if m := railsCreateTable.FindStringSubmatch(line); m != nil {
+			_, opts := rubyArgs(strings.TrimPrefix(m[2], ","))
+			current = newRailsTable(m[1], opts)
+			tables = append(tables, current)
+			byName[current.Name] = current
+			continue
+		}
+
+		if m := railsStatement.FindStringSubmatch(line); m != nil {
+			args, opts := rubyArgs(m[2])
+			if len(args) == 0 {
+				continue
+			}
+			table := byName[rubyString(args[0])]
+			if table == nil {
+				continue
+			}
+			switch m[1] {
+			case "add_foreign_key":
+				addRailsForeignKey(table, args, opts)
+			case "add_index":
+				if len(args) > 1 && opts["unique"] == "true" {
+					table.Unique = append(table.Unique, rubyStrings(args[1]))
+				}
+			case "add_check_constraint":
+				if len(args) > 1 {
+					table.Checks = append(table.Checks, fmt.Sprintf("CHECK (%s)", rubyString(args[1])))
+				}
+			}
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("schema.rb: create_table %q is not closed with end", current.Name)
+	}
+	return tables, nil
+}
+
+// newRailsTable starts a table from the options of create_table: the id
+// column Rails adds unless id: false, and its primary key
+func newRailsTable(name string, opts map[string]string) *Table {
+	table := &Table{Schema: "public", Name: name}
+	if schema, rest, ok := strings.Cut(name, "."); ok {
+		table.Schema, table.Name = schema, rest
+	}
+
+	if primaryKey, ok := opts["primary_key"]; ok && strings.HasPrefix(primaryKey, "[") {
+		table.PrimaryKey = rubyStrings(primaryKey)
+		return table
+	}
+
+	idType := opts["id"]
+	if idType == "false" {
+		return table
+	}
+	column := &Column{Name: "id", DataType: "bigint", Identity: true}
+	if primaryKey, ok := opts["primary_key"]; ok {
+		column.Name = rubyString(primaryKey)
+	}
+	switch strings.TrimPrefix(idType, ":") {
+	case "", "bigint", "primary_key":
+	case "uuid":
+		column.DataType, column.Identity, column.Default = "uuid", false, "gen_random_uuid()"
+	case "integer", "serial":
+		column.DataType = "integer"
+	case "string":
+		column.DataType, column.Identity = "character varying", false
+	default:
+		column.DataType, column.Identity = strings.TrimPrefix(idType, ":"), false
+	}
+	if defaultValue, ok := opts["default"]; ok {
+		column.Default = railsDefault(defaultValue)
+	}
+	table.Columns = append(table.Columns, column)
+	table.PrimaryKey = []string{column.Name}
+	return table
+}
+
+// addRailsColumn adds what a t.<type> line of create_table declares
+func addRailsColumn(table *Table, kind string, args []string, opts map[string]string, enums map[string][]string) error {
+	switch kind {
+	case "index":
+		if len(args) > 0 && opts["unique"] == "true" {
+			table.Unique = append(table.Unique, rubyStrings(args[0]))
+		}
+		return nil
+	case "check_constraint":
+		if len(args) > 0 {
+			table.Checks = append(table.Checks, fmt.Sprintf("CHECK (%s)", rubyString(args[0])))
+		}
+		return nil
+	case "timestamps":
+		for _, name := range []string{"created_at", "updated_at"} {
+			table.Columns = append(table.Columns, &Column{Name: name, DataType: "timestamp without time zone", Nullable: opts["null"] != "false"})
+		}
+		return nil
+	case "references", "belongs_to":
+		for _, arg := range args {
+			name := rubyString(arg)
+			column := &Column{Name: name + "_id", DataType: "bigint", Nullable: opts["null"] != "false"}
+			if opts["type"] == ":uuid" {
+				column.DataType = "uuid"
+			}
+			table.Columns = append(table.Columns, column)
+			if opts["foreign_key"] == "true" {
+				table.ForeignKeys = append(table.ForeignKeys, ForeignKey{
+					Columns:    []string{column.Name},
+					RefSchema:  table.Schema,
+					RefTable:   pluralize(name),
+					RefColumns: []string{"id"},
+				})
+			}
+			if opts["polymorphic"] == "true" {
+				table.Columns = append(table.Columns, &Column{Name: name + "_type", DataType: "character varying", Nullable: column.Nullable})
+				table.Notes = append(table.Notes, fmt.Sprintf("polymorphic association %s", name))
+			}
+		}
+		return nil
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("t.%s without a column name", kind)
+	}
+	for _, arg := range args {
+		column := &Column{
+			Name:     rubyString(arg),
+			DataType: kind,
+			Nullable: opts["null"] != "false",
+		}
+		if dataType, ok := railsTypes[kind]; ok {
+			column.DataType = dataType
+		}
+		switch {
+		case kind == "integer" && opts["limit"] == "8":
+			column.DataType = "bigint"
+		case kind == "integer" && opts["limit"] == "2":
+			column.DataType = "smallint"
+		case kind == "string" && opts["limit"] != "":
+			column.MaxLength, _ = strconv.Atoi(opts["limit"])
+		case kind == "enum":
+			column.DataType = "USER-DEFINED"
+			column.UDTName = rubyString(opts["enum_type"])
+			column.EnumValues = enums[column.UDTName]
+		}
+		if opts["array"] == "true" {
+			column.DataType = "ARRAY"
+		}
+		if defaultValue, ok := opts["default"]; ok {
+			column.Default = railsDefault(defaultValue)
+		}
+		table.Columns = append(table.Columns, column)
+	}
+	return nil
+}
+
+// railsOnDelete maps the on_delete option of add_foreign_key to the SQL
+// action
+var railsOnDelete = map[string]string{
+	":cascade":  "CASCADE",
+	":nullify":  "SET NULL",
+	":restrict": "RESTRICT",
+}
+
+// addRailsForeignKey adds the foreign key of an add_foreign_key line
+func addRailsForeignKey(table *Table, args []string, opts map[string]string) {
+	if len(args) < 2 {
+		return
+	}
+	refTable := rubyString(args[1])
+	column := singularize(refTable) + "_id"
+	if value, ok := opts["column"]; ok {
+		column = rubyString(value)
+	}
+	refColumn := "id"
+	if value, ok := opts["primary_key"]; ok {
+		refColumn = rubyString(value)
+	}
+	refSchema := "public"
+	if schema, rest, ok := strings.Cut(refTable, "."); ok {
+		refSchema, refTable = schema, rest
+	}
+	table.ForeignKeys = append(table.ForeignKeys, ForeignKey{
+		Columns:    []string{column},
+		RefSchema:  refSchema,
+		RefTable:   refTable,
+		RefColumns: []string{refColumn},
+		OnDelete:   railsOnDelete[opts["on_delete"]],
+	})
+}
+
+// railsDefault converts the default option of a column to the SQL default
+// expression PostgreSQL would report
+func railsDefault(value string) string {
+	if strings.HasPrefix(value, "->") {
+		// default: -> { "now()" }
+		inner := strings.TrimSpace(strings.TrimPrefix(value, "->"))
+		inner = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(inner, "{"), "}"))
+		return rubyString(inner)
+	}
+	if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'") {
+		return "'" + strings.ReplaceAll(rubyString(value), "'", "''") + "'"
+	}
+	return value
+}
+
+var (
+	railsClass     = regexp.MustCompile(`^class\s+([A-Z]\w*(?:::[A-Z]\w*)*)\s*<\s*(\S+)`)
+	railsTableName = regexp.MustCompile(`^self\.table_name\s*=\s*(\S+)`)
+	railsMacro     = regexp.MustCompile(`^(belongs_to|has_many|has_one|has_and_belongs_to_many|validates|validates_presence_of|validates_uniqueness_of|validates_length_of|validate)\s+(.*)$`)
+)
+
+// railsModel is what an ActiveRecord model declares
+type railsModel struct {
+	class  string
+	table  string
+	macros [][2]string // Macro name and its arguments, in order
+}
+
+// ApplyRailsModels adds the validations and associations of Rails models,
+// given as the source of app/models files, to the tables they are stored
+// in. belongs_to associations without a foreign key in the schema gain one;
+// has_many associations and custom validations are left as notes.
+func ApplyRailsModels(tables []*Table, sources []string) {
+	var models []*railsModel
+	for _, source := range sources {
+		models = append(models, parseRailsModels(source)...)
+	}
+
+	byName := make(map[string]*Table)
+	for _, table := range tables {
+		byName[table.Name] = table
+	}
+	classTables := make(map[string]string)
+	for _, model := range models {
+		classTables[model.class] = model.table
+	}
+
+	for _, model := range models {
+		table := byName[model.table]
+		if table == nil {
+			continue
+		}
+		if !strings.Contains(model.class, "::") {
+			table.Resource = model.class
+		}
+		for _, macro := range model.macros {
+			applyRailsMacro(table, macro[0], macro[1], classTables)
+		}
+	}
+}
+
+// parseRailsModels reads the models declared in one Ruby source file
+func parseRailsModels(source string) []*railsModel {
+	var models []*railsModel
+	var current *railsModel
+	for _, raw := range strings.Split(source, "\n") {
+		line := strings.TrimSpace(raw)
+		if m := railsClass.FindStringSubmatch(line); m != nil {
+			class := m[1]
+			if i := strings.LastIndex(class, "::"); i >= 0 {
+				class = class[i+2:]
+			}
+			current = &railsModel{class: m[1], table: pluralize(snakeCase(class))}
+			models = append(models, current)
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if line == "self.abstract_class = true" {
+			current.table = ""
+			continue
+		}
+		if m := railsTableName.FindStringSubmatch(line); m != nil {
+			current.table = rubyString(m[1])
+			continue
+		}
+		if m := railsMacro.FindStringSubmatch(line); m != nil {
+			current.macros = append(current.macros, [2]string{m[1], m[2]})
+		}
+	}
+	return models
+}
+
+// applyRailsMacro applies one association or validation to table
+func applyRailsMacro(table *Table, macro, arguments string, classTables map[string]string) {
+	args, opts := rubyArgs(arguments)
+	if len(args) == 0 {
+		return
+	}
+	name := rubyString(args[0])
+
+	switch macro {
+	case "belongs_to":
+		if opts["polymorphic"] == "true" {
+			return
+		}
+		column := name + "_id"
+		if value, ok := opts["foreign_key"]; ok {
+			column = rubyString(value)
+		}
+		if table.Column(column) == nil {
+			return
+		}
+		for _, fk := range table.ForeignKeys {
+			if len(fk.Columns) == 1 && fk.Columns[0] == column {
+				return
+			}
+		}
+		class := camelize(name)
+		if value, ok := opts["class_name"]; ok {
+			class = rubyString(value)
+		}
+		refTable := classTables[class]
+		if refTable == "" {
+			refTable = pluralize(snakeCase(class))
+		}
+		table.ForeignKeys = append(table.ForeignKeys, ForeignKey{
+			Columns:    []string{column},
+			RefSchema:  table.Schema,
+			RefTable:   refTable,
+			RefColumns: []string{"id"},
+		})
+
+	case "has_many", "has_one", "has_and_belongs_to_many":
+		table.Notes = append(table.Notes, fmt.Sprintf("%s :%s", macro, name))
+
+	case "validate":
+		table.Notes = append(table.Notes, "validate "+arguments)
+
+	case "validates_presence_of":
+		for _, arg := range args {
+			applyRailsValidation(table, rubyString(arg), "presence", "true")
+		}
+
+	case "validates_uniqueness_of":
+		for _, arg := range args {
+			applyRailsValidation(table, rubyString(arg), "uniqueness", opts["scope"])
+		}
+
+	case "validates_length_of":
+		for _, arg := range args {
+			applyRailsValidation(table, rubyString(arg), "length", "{"+arguments[strings.Index(arguments, ",")+1:]+"}")
+		}
+
+	case "validates":
+		for _, arg := range args {
+			for _, key := range sortedKeys(opts) {
+				applyRailsValidation(table, rubyString(arg), key, opts[key])
+			}
+		}
+	}
+}
+
+// applyRailsValidation applies one validation of an attribute: presence,
+// length, uniqueness and format translate to the field; others become notes
+func applyRailsValidation(table *Table, attribute, kind, value string) {
+	column := table.Column(attribute)
+	if column == nil {
+		column = table.Column(attribute + "_id")
+	}
+	if column == nil {
+		return
+	}
+
+	switch kind {
+	case "if", "unless", "on", "allow_nil", "allow_blank", "message":
+		return
+	case "presence":
+		if value == "true" {
+			column.Nullable = false
+			return
+		}
+	case "uniqueness":
+		if value == "true" || value == "" {
+			table.Unique = append(table.Unique, []string{column.Name})
+			return
+		}
+		_, opts := rubyArgs(strings.TrimSuffix(strings.TrimPrefix(value, "{"), "}"))
+		if scope, ok := opts["scope"]; ok {
+			table.Unique = append(table.Unique, append([]string{column.Name}, rubyStrings(scope)...))
+			return
+		}
+		if !strings.HasPrefix(value, "{") {
+			// validates_uniqueness_of :name, scope: :account_id
+			table.Unique = append(table.Unique, append([]string{column.Name}, rubyStrings(value)...))
+			return
+		}
+	case "length":
+		_, opts := rubyArgs(strings.TrimSuffix(strings.TrimPrefix(value, "{"), "}"))
+		if within, ok := opts["in"]; ok {
+			opts["within"] = within
+		}
+		if low, high, ok := strings.Cut(opts["within"], ".."); ok {
+			opts["minimum"], opts["maximum"] = low, strings.TrimPrefix(high, ".")
+		}
+		if maximum, err := strconv.Atoi(opts["maximum"]); err == nil {
+			column.MaxLength = maximum
+		}
+		if minimum, err := strconv.Atoi(opts["minimum"]); err == nil {
+			column.MinLength = minimum
+		}
+		if opts["maximum"] != "" || opts["minimum"] != "" {
+			return
+		}
+	case "format":
+		_, opts := rubyArgs(strings.TrimSuffix(strings.TrimPrefix(value, "{"), "}"))
+		if pattern, ok := rubyRegexp(opts["with"]); ok {
+			column.Pattern = pattern
+			return
+		}
+	}
+	column.Notes = append(column.Notes, fmt.Sprintf("validates %s: %s", kind, value))
+}
+
+// rubyRegexp converts a Ruby regexp literal such as /\A[a-z]+\z/ to an
+// equivalent Go regular expression
+func rubyRegexp(literal string) (string, bool) {
+	if !strings.HasPrefix(literal, "/") {
+		return "", false
+	}
+	end := strings.LastIndex(literal, "/")
+	if end == 0 {
+		return "", false
+	}
+	pattern := literal[1:end]
+	if flags := literal[end+1:]; strings.Contains(flags, "i") {
+		pattern = "(?i)" + pattern
+	}
+	pattern = strings.ReplaceAll(pattern, `\A`, "^")
+	pattern = strings.ReplaceAll(pattern, `\z`, "$")
+	pattern = strings.ReplaceAll(pattern, `\Z`, "$")
+	return pattern, true
+}
+
+// rubyArgs splits the arguments of a Ruby method call into positional
+// arguments and keyword options, keeping nested hashes, arrays and lambdas
+// whole
+func rubyArgs(s string) ([]string, map[string]string) {
+	var args []string
+	opts := make(map[string]string)
+	for _, part := range splitTopLevel(s) {
+		if key, value, ok := strings.Cut(part, ":"); ok && isRubyIdent(key) && strings.HasPrefix(value, " ") {
+			opts[key] = strings.TrimSpace(value)
+			continue
+		}
+		if key, value, ok := strings.Cut(part, "=>"); ok && !strings.HasPrefix(part, "[") {
+			// "key" => value, as older schemas write options
+			opts[strings.Trim(strings.TrimSpace(key), `:"'`)] = strings.TrimSpace(value)
+			continue
+		}
+		args = append(args, part)
+	}
+	return args, opts
+}
+
+// splitTopLevel splits s at commas outside strings, regexps, brackets and
+// braces
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth := 0
+	var quote rune
+	start := 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote && (i == 0 || s[i-1] != '\\') {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '/' && strings.TrimSpace(s[start:i]) == "" || r == '/' && strings.HasSuffix(strings.TrimSpace(s[start:i]), ":"):
+			quote = r
+		case r == '[' || r == '{' || r == '(':
+			depth++
+		case r == ']' || r == '}' || r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" {
+		parts = append(parts, rest)
+	}
+	return parts
+}
+
+func isRubyIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// rubyString returns the value of a Ruby string or symbol literal, or s
+// itself when it is neither
+func rubyString(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, ":") {
+		return strings.Trim(s[1:], `"`)
+	}
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		if s[0] == '"' {
+			if value, err := strconv.Unquote(s); err == nil {
+				return value
+			}
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// rubyStrings returns the values of a Ruby array of strings or symbols, or
+// of a single one
+func rubyStrings(s string) []string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") {
+		return []string{rubyString(s)}
+	}
+	var values []string
+	for _, part := range splitTopLevel(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")) {
+		values = append(values, rubyString(part))
+	}
+	return values
+}
+
+// camelize converts a snake_case association name to its class name, e.g.
+// BlogPost for blog_post
+func camelize(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		if word != "" {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// pluralize returns the table Rails stores a model in, e.g. categories for
+// category
+func pluralize(name string) string {
+	switch {
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsAny(name[len(name)-2:len(name)-1], "aeiou"):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	}
+	return name + "s"
+}
+
+// sortedKeys returns the keys of m in sorted order, so notes come out the
+// same on every run
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
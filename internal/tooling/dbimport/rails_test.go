@@ -0,0 +1,189 @@
+package dbimport
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testRailsSchema = `# This file is auto-generated from the current state of the database.
+
+ActiveRecord::Schema[7.1].define(version: 2024_05_01_120000) do
+  enable_extension "plpgsql"
+
+  create_enum "user_role", ["member", "admin"]
+
+  create_table "users", force: :cascade do |t|
+    t.string "email", null: false
+    t.string "name", limit: 100
+    t.enum "role", default: "member", null: false, enum_type: "user_role"
+    t.timestamps null: false
+    t.index ["email"], name: "index_users_on_email", unique: true
+  end
+
+  create_table "blog_posts", id: :uuid, default: -> { "gen_random_uuid()" }, force: :cascade do |t|
+    t.bigint "author_id", null: false
+    t.string "title", null: false
+    t.string "slug", null: false
+    t.text "body"
+    t.integer "views", default: 0, null: false
+    t.boolean "published", default: false
+    t.decimal "price", precision: 10, scale: 2
+    t.datetime "published_at", default: -> { "CURRENT_TIMESTAMP" }
+    t.check_constraint "views >= 0", name: "views_positive"
+  end
+
+  create_table "post_tags", id: false, force: :cascade do |t|
+    t.references "blog_post", type: :uuid, null: false
+    t.string "tag", null: false
+  end
+
+  add_foreign_key "blog_posts", "users", column: "author_id", on_delete: :cascade
+end
+`
+
+var testRailsModels = []string{`class ApplicationRecord < ActiveRecord::Base
+  primary_abstract_class
+end
+`, `class User < ApplicationRecord
+  has_many :posts, class_name: "BlogPost", foreign_key: :author_id
+  validates :name, presence: true, length: { minimum: 2, maximum: 50 }
+  validates :email, format: { with: /\A[^@\s]+@[^@\s]+\z/i }, uniqueness: true
+  validate :email_domain_allowed
+end
+`, `class BlogPost < ApplicationRecord
+  belongs_to :author, class_name: "User"
+  validates :title, length: { in: 5..200 }
+  validates_uniqueness_of :slug, scope: :author_id
+  validates :views, numericality: { greater_than_or_equal_to: 0 }
+end
+
+class PostTag < ApplicationRecord
+  self.table_name = "post_tags"
+  belongs_to :blog_post
+end
+`}
+
+func TestParseRailsSchema(t *testing.T) {
+	tables, err := ParseRailsSchema(testRailsSchema)
+	if err != nil {
+		t.Fatalf("ParseRailsSchema: %v", err)
+	}
+	if len(tables) != 3 {
+		t.Fatalf("Expected 3 tables, got %d", len(tables))
+	}
+
+	users := tables[0]
+	if users.Name != "users" || !reflect.DeepEqual(users.PrimaryKey, []string{"id"}) {
+		t.Errorf("unexpected users table %s %v", users.Name, users.PrimaryKey)
+	}
+	if id := users.Column("id"); id == nil || !id.Identity || id.DataType != "bigint" {
+		t.Errorf("expected an identity bigint id, got %+v", id)
+	}
+	if name := users.Column("name"); name.MaxLength != 100 || !name.Nullable {
+		t.Errorf("unexpected name column %+v", name)
+	}
+	if role := users.Column("role"); role.DataType != "USER-DEFINED" || !reflect.DeepEqual(role.EnumValues, []string{"member", "admin"}) || role.Default != "'member'" {
+		t.Errorf("unexpected role column %+v", role)
+	}
+	if created := users.Column("created_at"); created == nil || created.Nullable {
+		t.Errorf("expected a required created_at, got %+v", created)
+	}
+	if !reflect.DeepEqual(users.Unique, [][]string{{"email"}}) {
+		t.Errorf("unexpected unique indexes %v", users.Unique)
+	}
+
+	posts := tables[1]
+	if id := posts.Column("id"); id.DataType != "uuid" || id.Default != "gen_random_uuid()" {
+		t.Errorf("expected a uuid id, got %+v", id)
+	}
+	if publishedAt := posts.Column("published_at"); publishedAt.Default != "CURRENT_TIMESTAMP" {
+		t.Errorf("expected the lambda default, got %q", publishedAt.Default)
+	}
+	if !reflect.DeepEqual(posts.Checks, []string{"CHECK (views >= 0)"}) {
+		t.Errorf("unexpected checks %v", posts.Checks)
+	}
+	want := []ForeignKey{{Columns: []string{"author_id"}, RefSchema: "public", RefTable: "users", RefColumns: []string{"id"}, OnDelete: "CASCADE"}}
+	if !reflect.DeepEqual(posts.ForeignKeys, want) {
+		t.Errorf("unexpected foreign keys %+v", posts.ForeignKeys)
+	}
+
+	tags := tables[2]
+	if len(tags.PrimaryKey) != 0 || tags.Column("id") != nil {
+		t.Errorf("id: false should not add an id column")
+	}
+	if postID := tags.Column("blog_post_id"); postID == nil || postID.DataType != "uuid" || postID.Nullable {
+		t.Errorf("unexpected reference column %+v", postID)
+	}
+}
+
+func TestParseRailsSchema_Unclosed(t *testing.T) {
+	_, err := ParseRailsSchema("create_table \"users\", force: :cascade do |t|\n  t.string \"email\"\n")
+	if err == nil || !strings.Contains(err.Error(), "not closed") {
+		t.Errorf("expected an unclosed table error, got %v", err)
+	}
+}
+
+func TestApplyRailsModels(t *testing.T) {
+	tables, err := ParseRailsSchema(testRailsSchema)
+	if err != nil {
+		t.Fatalf("ParseRailsSchema: %v", err)
+	}
+	ApplyRailsModels(tables, testRailsModels)
+
+	files := Generate(tables, Options{Command: "conduit import rails"})
+	checkFiles(t, files)
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"user.cdt", []string{
+			"// Generated by: conduit import rails\n",
+			`  email: text! @pattern("(?i)^[^@\\s]+@[^@\\s]+$") @unique` + "\n",
+			"  name: string! @min(2) @max(50)\n",
+			"  //   has_many :posts\n",
+			"  //   validate :email_domain_allowed\n",
+		}},
+		{"blog_post.cdt", []string{
+			"  author: User! {\n    on_delete: cascade\n  }\n",
+			"  title: string! @min(5) @max(200)\n",
+			"  views: int! @default(0) // TODO: validates numericality: { greater_than_or_equal_to: 0 }\n",
+			"  //   UNIQUE (slug, author_id)\n",
+		}},
+		{"post_tag.cdt", []string{
+			"  blog_post: BlogPost!\n",
+			"  // TODO: translate by hand:\n  //   the table has no primary key",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var content string
+			for _, file := range files {
+				if file.Name == tt.file {
+					content = file.Content
+				}
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content, want) {
+					t.Errorf("missing %q in:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
+
+func TestRubyArgs(t *testing.T) {
+	args, opts := rubyArgs(`:email, format: { with: /\A[a,b]+\z/ }, presence: true, "scope" => [:a, :b]`)
+	if !reflect.DeepEqual(args, []string{":email"}) {
+		t.Errorf("unexpected args %v", args)
+	}
+	want := map[string]string{
+		"format":   `{ with: /\A[a,b]+\z/ }`,
+		"presence": "true",
+		"scope":    "[:a, :b]",
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("unexpected options %v", opts)
+	}
+}
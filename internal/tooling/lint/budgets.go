@@ -0,0 +1,139 @@
+package lint
+
+import (
+	"sort"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+// Rule identifiers reported by CheckBudgets
+const (
+	RuleBudgetFields          = "budget-fields"
+	RuleBudgetDependencyDepth = "budget-dependency-depth"
+	RuleBudgetHooks           = "budget-hooks"
+)
+
+// Budgets are complexity limits declared by a team. A zero value disables the
+// corresponding check.
+type Budgets struct {
+	MaxFieldsPerResource int // Fields declared directly on a resource
+	MaxDependencyDepth   int // Longest dependency chain starting at a resource
+	MaxHooksPerEvent     int // Hooks sharing the same timing and event (e.g., before create)
+}
+
+// CheckBudgets reports every resource that exceeds a budget. Violations are
+// errors so that `conduit lint` fails.
+//
+// Dependency depth is measured with metadata.GraphDepth over the same graph
+// the runtime registry builds: relationships, middleware and namespaced
+// function calls in hooks.
+func CheckBudgets(files []File, budgets Budgets) []Issue {
+	program, paths := combine(files)
+	issues := make([]Issue, 0)
+
+	var graph *metadata.DependencyGraph
+	if budgets.MaxDependencyDepth > 0 {
+		graph = metadata.BuildDependencyGraph(dependencyMetadata(program, paths))
+	}
+
+	for _, resource := range program.Resources {
+		path := paths[resource.Name]
+
+		if limit := budgets.MaxFieldsPerResource; limit > 0 && len(resource.Fields) > limit {
+			issues = append(issues, newIssue(RuleBudgetFields, SeverityError, path, resource.Name, resource.Loc,
+				"resource %s has %d fields, exceeding the budget of %d", resource.Name, len(resource.Fields), limit))
+		}
+
+		if limit := budgets.MaxDependencyDepth; limit > 0 {
+			if depth := metadata.GraphDepth(graph, resource.Name); depth > limit {
+				issues = append(issues, newIssue(RuleBudgetDependencyDepth, SeverityError, path, resource.Name, resource.Loc,
+					"resource %s has dependency depth %d, exceeding the budget of %d", resource.Name, depth, limit))
+			}
+		}
+
+		if limit := budgets.MaxHooksPerEvent; limit > 0 {
+			issues = append(issues, checkHookBudget(resource, path, limit)...)
+		}
+	}
+
+	sortIssues(issues)
+	return issues
+}
+
+// checkHookBudget reports events with more hooks than allowed, located at the
+// first hook past the budget
+func checkHookBudget(resource *ast.ResourceNode, path string, limit int) []Issue {
+	byEvent := make(map[string][]*ast.HookNode)
+	events := make([]string, 0)
+	for _, hook := range resource.Hooks {
+		event := hook.Timing + " " + hook.Event
+		if _, ok := byEvent[event]; !ok {
+			events = append(events, event)
+		}
+		byEvent[event] = append(byEvent[event], hook)
+	}
+	sort.Strings(events)
+
+	issues := make([]Issue, 0)
+	for _, event := range events {
+		hooks := byEvent[event]
+		if len(hooks) > limit {
+			issues = append(issues, newIssue(RuleBudgetHooks, SeverityError, path, resource.Name, hooks[limit].Loc,
+				"resource %s has %d @%s hooks, exceeding the budget of %d", resource.Name, len(hooks), event, limit))
+		}
+	}
+	return issues
+}
+
+// dependencyMetadata converts the program into the subset of registry metadata
+// that metadata.BuildDependencyGraph reads
+func dependencyMetadata(program *ast.Program, paths map[string]string) *metadata.Metadata {
+	meta := &metadata.Metadata{
+		Resources: make([]metadata.ResourceMetadata, 0, len(program.Resources)),
+	}
+
+	for _, resource := range program.Resources {
+		resMeta := metadata.ResourceMetadata{
+			Name:     resource.Name,
+			FilePath: paths[resource.Name],
+		}
+
+		for _, rel := range resource.Relationships {
+			resMeta.Relationships = append(resMeta.Relationships, metadata.RelationshipMetadata{
+				Name:           rel.Name,
+				Type:           relationshipType(rel.Kind),
+				TargetResource: rel.Type,
+			})
+		}
+
+		if len(resource.Middleware) > 0 {
+			resMeta.Middleware = map[string][]string{"all": resource.Middleware}
+		}
+
+		for _, hook := range resource.Hooks {
+			resMeta.Hooks = append(resMeta.Hooks, metadata.HookMetadata{
+				Type:       hook.Timing + "_" + hook.Event,
+				SourceCode: ast.Print(hook),
+				LineNumber: hook.Loc.Line,
+			})
+		}
+
+		meta.Resources = append(meta.Resources, resMeta)
+	}
+
+	return meta
+}
+
+func relationshipType(kind ast.RelationshipKind) string {
+	switch kind {
+	case ast.RelationshipHasMany:
+		return "has_many"
+	case ast.RelationshipHasManyThrough:
+		return "has_many_through"
+	case ast.RelationshipHasOne:
+		return "has_one"
+	default:
+		return "belongs_to"
+	}
+}
@@ -0,0 +1,109 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/conduit-lang/conduit/runtime/metadata"
+)
+
+const budgetSource = `resource Comment {
+  id: uuid! @primary @auto
+  body: string!
+  post_id: uuid!
+
+  post: Post! {
+    foreign_key: "post_id"
+  }
+
+  @before create {
+    self.body = String.trim(self.body)
+  }
+
+  @before create {
+    self.body = String.downcase(self.body)
+  }
+
+  @after create {
+    self.body = self.body
+  }
+}
+
+resource Post {
+  id: uuid! @primary @auto
+  title: string!
+  author_id: uuid!
+
+  author: User! {
+    foreign_key: "author_id"
+  }
+}
+
+resource User {
+  id: uuid! @primary @auto
+  name: string!
+}
+`
+
+func TestCheckBudgets(t *testing.T) {
+	files := []File{parseFile(t, "app/blog.cdt", budgetSource)}
+
+	issues := CheckBudgets(files, Budgets{
+		MaxFieldsPerResource: 2,
+		MaxDependencyDepth:   1,
+		MaxHooksPerEvent:     1,
+	})
+
+	tests := []struct {
+		rule    string
+		message string
+		line    int
+	}{
+		{RuleBudgetFields, "resource Comment has 3 fields, exceeding the budget of 2", 1},
+		{RuleBudgetFields, "resource Post has 3 fields, exceeding the budget of 2", 23},
+		{RuleBudgetDependencyDepth, "resource Comment has dependency depth 2, exceeding the budget of 1", 1},
+		{RuleBudgetHooks, "resource Comment has 2 @before create hooks, exceeding the budget of 1", 14},
+	}
+
+	for _, tt := range tests {
+		issue := findIssue(issues, tt.rule, tt.message)
+		if issue == nil {
+			t.Errorf("expected issue %q, got %v", tt.message, issues)
+			continue
+		}
+		if issue.Line != tt.line {
+			t.Errorf("%s: expected line %d, got %d", tt.message, tt.line, issue.Line)
+		}
+		if issue.Severity != SeverityError {
+			t.Errorf("%s: budget violations must be errors", tt.message)
+		}
+	}
+
+	if len(issues) != len(tests) {
+		t.Errorf("expected %d issues, got %d: %v", len(tests), len(issues), issues)
+	}
+	if !HasErrors(issues) {
+		t.Error("HasErrors should report budget violations")
+	}
+}
+
+func TestCheckBudgets_Disabled(t *testing.T) {
+	files := []File{parseFile(t, "app/blog.cdt", budgetSource)}
+
+	if issues := CheckBudgets(files, Budgets{}); len(issues) != 0 {
+		t.Errorf("zero budgets should disable all checks, got %v", issues)
+	}
+}
+
+func TestCheckBudgets_DepthMatchesGraphDepth(t *testing.T) {
+	files := []File{parseFile(t, "app/blog.cdt", budgetSource)}
+	program, paths := combine(files)
+	graph := metadata.BuildDependencyGraph(dependencyMetadata(program, paths))
+
+	// Comment -> Post -> User plus Comment -> String.trim/String.downcase
+	if depth := metadata.GraphDepth(graph, "Comment"); depth != 2 {
+		t.Errorf("expected Comment depth 2, got %d", depth)
+	}
+	if depth := metadata.GraphDepth(graph, "User"); depth != 0 {
+		t.Errorf("expected User depth 0, got %d", depth)
+	}
+}
@@ -0,0 +1,104 @@
+// Package lint implements static checks over Conduit programs that go beyond
+// what the type checker enforces: unused definitions, complexity budgets and
+// other maintainability problems.
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// Severity indicates how serious a lint issue is
+type Severity string
+
+const (
+	// SeverityWarning marks issues that should be fixed but do not fail a build
+	SeverityWarning Severity = "warning"
+	// SeverityError marks issues that fail `conduit lint`
+	SeverityError Severity = "error"
+)
+
+// File is a parsed .cdt source file
+type File struct {
+	Path    string
+	Program *ast.Program
+}
+
+// Issue is a single lint finding
+type Issue struct {
+	Rule     string   `json:"rule"`               // Rule identifier (e.g., "unused-scope")
+	Severity Severity `json:"severity"`           // Issue severity
+	Message  string   `json:"message"`            // Human-readable description
+	File     string   `json:"file"`               // Source file path
+	Line     int      `json:"line,omitempty"`     // Line number (1-indexed, 0 if unknown)
+	Column   int      `json:"column,omitempty"`   // Column number (1-indexed, 0 if unknown)
+	Resource string   `json:"resource,omitempty"` // Resource the issue belongs to
+}
+
+// String formats the issue as file:line:column: message
+func (i Issue) String() string {
+	location := i.File
+	if i.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", i.File, i.Line, i.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", location, i.Severity, i.Message, i.Rule)
+}
+
+// newIssue creates an issue located at the given node
+func newIssue(rule string, severity Severity, file string, resource string, loc ast.SourceLocation, format string, args ...interface{}) Issue {
+	return Issue{
+		Rule:     rule,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		File:     file,
+		Line:     loc.Line,
+		Column:   loc.Column,
+		Resource: resource,
+	}
+}
+
+// sortIssues orders issues by file, line, column and rule for stable output
+func sortIssues(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Rule < b.Rule
+	})
+}
+
+// HasErrors reports whether any issue has error severity
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// combine merges all files into a single program and records which file
+// declares each resource
+func combine(files []File) (*ast.Program, map[string]string) {
+	program := &ast.Program{}
+	paths := make(map[string]string)
+	for _, file := range files {
+		if file.Program == nil {
+			continue
+		}
+		for _, resource := range file.Program.Resources {
+			program.Resources = append(program.Resources, resource)
+			paths[resource.Name] = file.Path
+		}
+	}
+	return program, paths
+}
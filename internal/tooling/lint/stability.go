@@ -0,0 +1,38 @@
+package lint
+
+import (
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// RuleStabilityDependency is reported by CheckStability
+const RuleStabilityDependency = "stability-dependency"
+
+// CheckStability reports stable resources with relationships to experimental
+// resources. Resources without @stability are stable. Such relationships put
+// an experimental resource in a stable API, through includes and nested
+// writes, so violations are errors.
+func CheckStability(files []File) []Issue {
+	program, paths := combine(files)
+	issues := make([]Issue, 0)
+
+	levels := make(map[string]string, len(program.Resources))
+	for _, resource := range program.Resources {
+		levels[resource.Name] = resource.StabilityLevel()
+	}
+
+	for _, resource := range program.Resources {
+		if levels[resource.Name] != ast.StabilityStable {
+			continue
+		}
+		for _, rel := range resource.Relationships {
+			if levels[rel.Type] != ast.StabilityExperimental {
+				continue
+			}
+			issues = append(issues, newIssue(RuleStabilityDependency, SeverityError, paths[resource.Name], resource.Name, rel.Loc,
+				"stable resource %s depends on experimental resource %s through %s", resource.Name, rel.Type, rel.Name))
+		}
+	}
+
+	sortIssues(issues)
+	return issues
+}
@@ -0,0 +1,79 @@
+package lint
+
+import "testing"
+
+const stabilitySource = `resource Invoice {
+  id: uuid! @primary @auto
+  draft_id: uuid!
+
+  draft: Draft! {
+    foreign_key: "draft_id"
+  }
+}
+
+resource Payment {
+  id: uuid! @primary @auto
+  draft_id: uuid!
+
+  draft: Draft! {
+    foreign_key: "draft_id"
+  }
+
+  @stability(stable)
+}
+
+resource Draft {
+  id: uuid! @primary @auto
+  legacy_id: uuid!
+
+  legacy: Legacy! {
+    foreign_key: "legacy_id"
+  }
+
+  @stability(experimental)
+}
+
+resource Legacy {
+  id: uuid! @primary @auto
+  draft_id: uuid!
+
+  draft: Draft! {
+    foreign_key: "draft_id"
+  }
+
+  @stability(deprecated)
+}
+`
+
+func TestCheckStability(t *testing.T) {
+	files := []File{parseFile(t, "app/billing.cdt", stabilitySource)}
+
+	issues := CheckStability(files)
+
+	tests := []struct {
+		message string
+		line    int
+	}{
+		{"stable resource Invoice depends on experimental resource Draft through draft", 5},
+		{"stable resource Payment depends on experimental resource Draft through draft", 14},
+	}
+
+	for _, tt := range tests {
+		issue := findIssue(issues, RuleStabilityDependency, tt.message)
+		if issue == nil {
+			t.Errorf("expected issue %q, got %v", tt.message, issues)
+			continue
+		}
+		if issue.Line != tt.line {
+			t.Errorf("%s: expected line %d, got %d", tt.message, tt.line, issue.Line)
+		}
+		if issue.Severity != SeverityError {
+			t.Errorf("%s: stability violations must be errors", tt.message)
+		}
+	}
+
+	// Experimental and deprecated resources may depend on anything
+	if len(issues) != len(tests) {
+		t.Errorf("expected %d issues, got %d: %v", len(tests), len(issues), issues)
+	}
+}
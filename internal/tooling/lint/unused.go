@@ -0,0 +1,253 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/metadata"
+)
+
+// Rule identifiers reported by FindUnused
+const (
+	RuleUnusedScope      = "unused-scope"
+	RuleUnusedMiddleware = "unused-middleware"
+	RuleUnusedField      = "unused-field"
+	RuleResourceNoRoutes = "resource-without-routes"
+)
+
+// UnusedOptions configures FindUnused
+type UnusedOptions struct {
+	// DeclaredMiddleware lists the middleware the project makes available,
+	// typically from the `middleware` list in conduit.yml
+	DeclaredMiddleware []string
+	// ConfigPath is reported as the location of unused declared middleware
+	ConfigPath string
+}
+
+// exposingOperations are the operations whose routes read or write every
+// field of a resource
+var exposingOperations = map[string]bool{
+	"list":   true,
+	"get":    true,
+	"create": true,
+	"update": true,
+}
+
+// FindUnused reports definitions that nothing refers to:
+//   - scopes never called as Resource.scope(...)
+//   - declared middleware not applied to any resource or hook
+//   - fields that are never read by hooks, validations, constraints, computed
+//     fields or scopes and are not exposed by any operation
+//   - resources that generate no routes
+func FindUnused(files []File, opts UnusedOptions) []Issue {
+	program, paths := combine(files)
+	issues := make([]Issue, 0)
+
+	routes := resourceRoutes(program)
+	reads := collectFieldReads(program)
+	calls := collectCalls(program)
+
+	for _, resource := range program.Resources {
+		path := paths[resource.Name]
+
+		for _, scope := range resource.Scopes {
+			if !calls[resource.Name+"."+scope.Name] {
+				issues = append(issues, newIssue(RuleUnusedScope, SeverityWarning, path, resource.Name, scope.Loc,
+					"scope %s.%s is never used", resource.Name, scope.Name))
+			}
+		}
+
+		if len(routes[resource.Name]) == 0 {
+			issues = append(issues, newIssue(RuleResourceNoRoutes, SeverityWarning, path, resource.Name, resource.Loc,
+				"resource %s has no routes; @operations %v matches no standard operation",
+				resource.Name, resource.Operations))
+		}
+
+		exposed := false
+		for op := range routes[resource.Name] {
+			if exposingOperations[op] {
+				exposed = true
+				break
+			}
+		}
+		if exposed {
+			continue
+		}
+
+		for _, field := range resource.Fields {
+			if reads[resource.Name][field.Name] || isStructuralField(resource, field) {
+				continue
+			}
+			issues = append(issues, newIssue(RuleUnusedField, SeverityWarning, path, resource.Name, field.Loc,
+				"field %s.%s is never read and is not exposed by any operation", resource.Name, field.Name))
+		}
+	}
+
+	if len(opts.DeclaredMiddleware) > 0 {
+		used := collectMiddleware(program)
+		for _, mw := range opts.DeclaredMiddleware {
+			if !used[middlewareName(mw)] {
+				issues = append(issues, Issue{
+					Rule:     RuleUnusedMiddleware,
+					Severity: SeverityWarning,
+					Message:  "middleware " + mw + " is not applied to any resource",
+					File:     opts.ConfigPath,
+				})
+			}
+		}
+	}
+
+	sortIssues(issues)
+	return issues
+}
+
+// resourceRoutes returns the set of route operations generated per resource,
+// using the same route generation as the metadata extractor. Nested list
+// routes also count as exposing the child resource.
+func resourceRoutes(program *ast.Program) map[string]map[string]bool {
+	routes := make(map[string]map[string]bool)
+	add := func(resource, op string) {
+		if routes[resource] == nil {
+			routes[resource] = make(map[string]bool)
+		}
+		routes[resource][op] = true
+	}
+
+	// Extraction errors only affect individual resources; routes for the rest
+	// are still generated
+	meta, _ := metadata.NewExtractor("lint").Extract(program)
+	for _, route := range meta.Routes {
+		add(route.Resource, route.Operation)
+
+		if rel := strings.TrimPrefix(route.Operation, "list_"); rel != route.Operation {
+			if parent := program.FindResource(route.Resource); parent != nil {
+				if r := parent.FindRelationship(rel); r != nil {
+					add(r.Type, "list")
+				}
+			}
+		}
+	}
+	return routes
+}
+
+// isStructuralField reports whether a field is used by the schema itself:
+// primary keys and foreign keys of the resource's relationships
+func isStructuralField(resource *ast.ResourceNode, field *ast.FieldNode) bool {
+	for _, constraint := range field.Constraints {
+		if constraint.Name == "primary" {
+			return true
+		}
+	}
+	for _, rel := range resource.Relationships {
+		if rel.ForeignKey == field.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// collectFieldReads returns, per resource, the fields read anywhere in the
+// program: self.<field> in the owning resource and self.<rel>.<field> in
+// resources whose relationships point at it. Assignment targets are writes and
+// do not count as reads.
+func collectFieldReads(program *ast.Program) map[string]map[string]bool {
+	reads := make(map[string]map[string]bool)
+	mark := func(resource, field string) {
+		if reads[resource] == nil {
+			reads[resource] = make(map[string]bool)
+		}
+		reads[resource][field] = true
+	}
+
+	for _, resource := range program.Resources {
+		relationships := make(map[string]string)
+		for _, rel := range resource.Relationships {
+			relationships[rel.Name] = rel.Type
+		}
+
+		// Assignment targets of the form self.<field> are writes
+		writes := make(map[ast.Node]bool)
+		ast.Inspect(resource, func(n ast.Node) bool {
+			if assign, ok := n.(*ast.AssignmentStmt); ok {
+				if access, ok := assign.Target.(*ast.FieldAccessExpr); ok {
+					if _, isSelf := access.Object.(*ast.SelfExpr); isSelf {
+						writes[access] = true
+					}
+				}
+			}
+			return true
+		})
+
+		ast.Inspect(resource, func(n ast.Node) bool {
+			if writes[n] {
+				return false
+			}
+
+			object, field, ok := fieldAccess(n)
+			if !ok {
+				return true
+			}
+
+			if _, isSelf := object.(*ast.SelfExpr); isSelf {
+				mark(resource.Name, field)
+				return true
+			}
+
+			if inner, rel, ok := fieldAccess(object); ok {
+				if _, isSelf := inner.(*ast.SelfExpr); isSelf && relationships[rel] != "" {
+					mark(relationships[rel], field)
+				}
+			}
+			return true
+		})
+	}
+
+	return reads
+}
+
+// fieldAccess unpacks field and safe-navigation accesses
+func fieldAccess(n ast.Node) (ast.ExprNode, string, bool) {
+	switch access := n.(type) {
+	case *ast.FieldAccessExpr:
+		return access.Object, access.Field, true
+	case *ast.SafeNavigationExpr:
+		return access.Object, access.Field, true
+	}
+	return nil, "", false
+}
+
+// collectCalls returns every namespaced call in the program as "Namespace.function"
+func collectCalls(program *ast.Program) map[string]bool {
+	calls := make(map[string]bool)
+	ast.Inspect(program, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && call.Namespace != "" {
+			calls[call.Namespace+"."+call.Function] = true
+		}
+		return true
+	})
+	return calls
+}
+
+// collectMiddleware returns the names of middleware applied to resources and hooks
+func collectMiddleware(program *ast.Program) map[string]bool {
+	used := make(map[string]bool)
+	for _, resource := range program.Resources {
+		for _, mw := range resource.Middleware {
+			used[middlewareName(mw)] = true
+		}
+		for _, hook := range resource.Hooks {
+			for _, mw := range hook.Middleware {
+				used[middlewareName(mw)] = true
+			}
+		}
+	}
+	return used
+}
+
+// middlewareName strips arguments such as rate_limit(100/hour)
+func middlewareName(mw string) string {
+	if idx := strings.Index(mw, "("); idx >= 0 {
+		return strings.TrimSpace(mw[:idx])
+	}
+	return strings.TrimSpace(mw)
+}
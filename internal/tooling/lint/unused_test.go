@@ -0,0 +1,183 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+	"github.com/conduit-lang/conduit/internal/compiler/parser"
+)
+
+func parseFile(t *testing.T, path, source string) File {
+	t.Helper()
+
+	tokens, lexErrors := lexer.New(source).ScanTokens()
+	if len(lexErrors) > 0 {
+		t.Fatalf("lexer errors: %v", lexErrors)
+	}
+
+	program, parseErrors := parser.New(tokens).Parse()
+	if len(parseErrors) > 0 {
+		t.Fatalf("parse errors: %v", parseErrors)
+	}
+
+	return File{Path: path, Program: program}
+}
+
+func findIssue(issues []Issue, rule, message string) *Issue {
+	for i := range issues {
+		if issues[i].Rule == rule && issues[i].Message == message {
+			return &issues[i]
+		}
+	}
+	return nil
+}
+
+const unusedSource = `resource Post {
+  id: uuid! @primary @auto
+  title: string!
+  status: string!
+  author_id: uuid!
+
+  author: User! {
+    foreign_key: "author_id"
+  }
+
+  @middleware [auth]
+
+  @scope published {
+    self.status == "published"
+  }
+
+  @scope drafts {
+    self.status == "draft"
+  }
+
+  @after create {
+    let recent: array<Post!>! = Post.published()
+  }
+}
+
+resource AuditEntry {
+  @operations [delete]
+
+  id: uuid! @primary @auto
+  action: string!
+  payload: string!
+  author_id: uuid!
+
+  author: User! {
+    foreign_key: "author_id"
+  }
+
+  @before delete {
+    self.payload = self.action
+  }
+}
+
+resource Job {
+  @operations [archive]
+
+  id: uuid! @primary @auto
+}
+
+resource User {
+  id: uuid! @primary @auto
+  name: string!
+}
+`
+
+func TestFindUnused(t *testing.T) {
+	files := []File{parseFile(t, "app/blog.cdt", unusedSource)}
+
+	issues := FindUnused(files, UnusedOptions{
+		DeclaredMiddleware: []string{"auth", "rate_limit(100/hour)"},
+		ConfigPath:         "conduit.yml",
+	})
+
+	tests := []struct {
+		rule    string
+		message string
+		line    int
+	}{
+		{RuleUnusedScope, "scope Post.drafts is never used", 17},
+		{RuleUnusedField, "field AuditEntry.payload is never read and is not exposed by any operation", 31},
+		{RuleResourceNoRoutes, "resource Job has no routes; @operations [archive] matches no standard operation", 43},
+		{RuleUnusedMiddleware, "middleware rate_limit(100/hour) is not applied to any resource", 0},
+	}
+
+	for _, tt := range tests {
+		issue := findIssue(issues, tt.rule, tt.message)
+		if issue == nil {
+			t.Errorf("expected issue %q, got %v", tt.message, issues)
+			continue
+		}
+		if issue.Line != tt.line {
+			t.Errorf("%s: expected line %d, got %d", tt.message, tt.line, issue.Line)
+		}
+	}
+
+	// Used scopes, read fields, foreign keys, exposed fields and applied
+	// middleware are not reported
+	for _, message := range []string{
+		"scope Post.published is never used",
+		"field AuditEntry.action is never read and is not exposed by any operation",
+		"field AuditEntry.author_id is never read and is not exposed by any operation",
+		"field Post.title is never read and is not exposed by any operation",
+		"middleware auth is not applied to any resource",
+	} {
+		for _, issue := range issues {
+			if issue.Message == message {
+				t.Errorf("unexpected issue %s", issue)
+			}
+		}
+	}
+
+	if len(issues) != len(tests) {
+		t.Errorf("expected %d issues, got %d: %v", len(tests), len(issues), issues)
+	}
+}
+
+func TestFindUnused_RelationshipReads(t *testing.T) {
+	source := `resource Comment {
+  @operations [delete]
+
+  id: uuid! @primary @auto
+  post_id: uuid!
+
+  post: Post! {
+    foreign_key: "post_id"
+  }
+
+  @before delete {
+    let title: string! = self.post.headline
+  }
+}
+
+resource Post {
+  @operations [delete]
+
+  id: uuid! @primary @auto
+  headline: string!
+  body: string!
+}
+`
+	issues := FindUnused([]File{parseFile(t, "app/comment.cdt", source)}, UnusedOptions{})
+
+	if findIssue(issues, RuleUnusedField, "field Post.headline is never read and is not exposed by any operation") != nil {
+		t.Error("field read through a relationship should not be reported")
+	}
+	if findIssue(issues, RuleUnusedField, "field Post.body is never read and is not exposed by any operation") == nil {
+		t.Errorf("expected Post.body to be reported, got %v", issues)
+	}
+}
+
+func TestIssueString(t *testing.T) {
+	issue := newIssue(RuleUnusedScope, SeverityWarning, "app/post.cdt", "Post", ast.SourceLocation{Line: 3, Column: 5},
+		"scope %s.%s is never used", "Post", "drafts")
+
+	want := "app/post.cdt:3:5: warning: scope Post.drafts is never used [unused-scope]"
+	if got := issue.String(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,252 @@
+// Package loadtest generates load testing scenarios from resource definitions,
+// so performance testing starts from the schema instead of hand-written scripts.
+package loadtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/codegen"
+)
+
+// Options configure a generated scenario
+type Options struct {
+	BaseURL   string // Default target, overridable with BASE_URL at run time
+	APIPrefix string // server.api_prefix the routes are mounted under
+	Mix       Mix    // Relative weight of list, show and create requests
+	VUs       int    // Concurrent virtual users
+	Duration  string // Test duration, e.g. "1m"
+}
+
+// GenerateK6 writes a k6 script that exercises the list, show and create
+// routes of every resource. Setup creates one record per resource, parents
+// first, so show requests and foreign keys reference existing records.
+func GenerateK6(resources []*ast.ResourceNode, opts Options) (string, error) {
+	if len(resources) == 0 {
+		return "", fmt.Errorf("no resources to load test")
+	}
+	if opts.Mix == nil {
+		opts.Mix = DefaultMix
+	}
+	if opts.Mix.Total() == 0 {
+		return "", fmt.Errorf("traffic mix has no positive weights")
+	}
+	if opts.VUs <= 0 {
+		opts.VUs = 10
+	}
+	if opts.Duration == "" {
+		opts.Duration = "1m"
+	}
+
+	ordered := setupOrder(resources)
+
+	var b strings.Builder
+	w := func(format string, args ...interface{}) {
+		fmt.Fprintf(&b, format, args...)
+		b.WriteString("\n")
+	}
+
+	w("// Code generated by conduit loadtest generate. Edit freely; regenerate after schema changes.")
+	w("//")
+	w("// Run with k6, optionally overriding the target:")
+	w("//   k6 run -e BASE_URL=https://staging.example.com <script>")
+	w("import http from 'k6/http';")
+	w("import { check } from 'k6';")
+	w("")
+	w("const BASE_URL = __ENV.BASE_URL || %s;", strconv.Quote(opts.BaseURL))
+	w("const HEADERS = { 'Content-Type': 'application/json' };")
+	w("")
+	w("export const options = {")
+	w("  vus: %d,", opts.VUs)
+	w("  duration: %s,", strconv.Quote(opts.Duration))
+	w("  thresholds: {")
+	w("    http_req_failed: ['rate<0.01'],")
+	w("  },")
+	w("};")
+	w("")
+	w("// Relative weight of each operation; adjust to match production traffic")
+	w("const MIX = {")
+	for _, op := range Operations {
+		w("  %s: %d,", op, opts.Mix[op])
+	}
+	w("};")
+	w("")
+	writeHelpers(w)
+
+	w("// Create bodies derived from field types and constraints")
+	w("const payloads = {")
+	for _, resource := range ordered {
+		w("  %s: (ids) => ({", resource.Name)
+		for _, field := range createPayload(resource, foreignKeys(resource, resources)) {
+			w("    %s: %s,", field.Name, field.Expr)
+		}
+		w("  }),")
+	}
+	w("};")
+	w("")
+
+	w("const routes = {")
+	for _, resource := range ordered {
+		collection := opts.APIPrefix + "/" + codegen.TableName(resource.Name)
+		key := make([]string, len(resource.KeyParams()))
+		for i, param := range resource.KeyParams() {
+			key[i] = strconv.Quote(param)
+		}
+		w("  %s: { collection: %s, member: %s, key: [%s] },", resource.Name, strconv.Quote(collection),
+			strconv.Quote(collection+"/"+resource.MemberPath()), strings.Join(key, ", "))
+	}
+	w("};")
+	w("")
+
+	w("function create(resource, ids) {")
+	w("  const route = routes[resource];")
+	w("  const res = http.post(`${BASE_URL}${route.collection}`, JSON.stringify(payloads[resource](ids)), {")
+	w("    headers: HEADERS,")
+	w("    tags: { name: `POST ${route.collection}` },")
+	w("  });")
+	w("  check(res, { [`create ${resource} is 201`]: (r) => r.status === 201 });")
+	w("  if (res.status !== 201) {")
+	w("    return undefined;")
+	w("  }")
+	w("  // The key of the record as it appears in its route")
+	w("  return route.key.map((field) => encodeURIComponent(res.json(field))).join('/');")
+	w("}")
+	w("")
+	w("const operations = {")
+	w("  list: (resource) => {")
+	w("    const route = routes[resource];")
+	w("    const res = http.get(`${BASE_URL}${route.collection}`, { tags: { name: `GET ${route.collection}` } });")
+	w("    check(res, { [`list ${resource} is 200`]: (r) => r.status === 200 });")
+	w("  },")
+	w("  show: (resource, ids) => {")
+	w("    if (!ids[resource]) {")
+	w("      return;")
+	w("    }")
+	w("    const route = routes[resource];")
+	w("    const res = http.get(`${BASE_URL}${route.collection}/${ids[resource]}`, { tags: { name: `GET ${route.member}` } });")
+	w("    check(res, { [`show ${resource} is 200`]: (r) => r.status === 200 });")
+	w("  },")
+	w("  create: (resource, ids) => create(resource, ids),")
+	w("};")
+	w("")
+
+	w("// Create one record per resource, parents before children")
+	w("export function setup() {")
+	w("  const ids = {};")
+	for _, resource := range ordered {
+		w("  ids.%s = create('%s', ids);", resource.Name, resource.Name)
+	}
+	w("  return ids;")
+	w("}")
+	w("")
+	w("export default function (ids) {")
+	w("  const operation = weighted(MIX);")
+	w("  const resource = pick(Object.keys(routes));")
+	w("  operations[operation](resource, ids);")
+	w("}")
+
+	return b.String(), nil
+}
+
+func writeHelpers(w func(string, ...interface{})) {
+	w("function pick(values) {")
+	w("  return values[Math.floor(Math.random() * values.length)];")
+	w("}")
+	w("")
+	w("function weighted(weights) {")
+	w("  const total = Object.values(weights).reduce((sum, weight) => sum + weight, 0);")
+	w("  let n = Math.random() * total;")
+	w("  for (const [key, weight] of Object.entries(weights)) {")
+	w("    n -= weight;")
+	w("    if (n < 0) {")
+	w("      return key;")
+	w("    }")
+	w("  }")
+	w("  return Object.keys(weights)[0];")
+	w("}")
+	w("")
+	w("function between(min, max) {")
+	w("  return min + Math.floor(Math.random() * (max - min + 1));")
+	w("}")
+	w("")
+	w("function unique() {")
+	w("  return `${__VU}-${__ITER}-${Date.now().toString(36)}`;")
+	w("}")
+	w("")
+	w("// sized pads or truncates a string to satisfy @min/@max length constraints")
+	w("function sized(value, min, max) {")
+	w("  while (value.length < min) {")
+	w("    value += ' lorem ipsum';")
+	w("  }")
+	w("  return max > 0 ? value.slice(0, max) : value;")
+	w("}")
+	w("")
+	w("function uuid() {")
+	w("  return 'xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx'.replace(/[xy]/g, (c) => {")
+	w("    const r = Math.floor(Math.random() * 16);")
+	w("    return (c === 'x' ? r : (r & 0x3) | 0x8).toString(16);")
+	w("  });")
+	w("}")
+	w("")
+}
+
+// foreignKeys maps each belongs-to foreign key of the resource to the
+// resource it references, when that resource is part of the scenario
+func foreignKeys(resource *ast.ResourceNode, resources []*ast.ResourceNode) map[string]string {
+	known := make(map[string]bool, len(resources))
+	for _, r := range resources {
+		known[r.Name] = true
+	}
+
+	keys := make(map[string]string)
+	for _, rel := range resource.Relationships {
+		if rel.Kind != ast.RelationshipBelongsTo || !known[rel.Type] {
+			continue
+		}
+		key := rel.ForeignKey
+		if key == "" {
+			key = rel.Name + "_id"
+		}
+		keys[key] = rel.Type
+	}
+	return keys
+}
+
+// setupOrder sorts resources so every resource follows the resources it
+// belongs to. Declaration order is kept otherwise, and cycles fall back to it.
+func setupOrder(resources []*ast.ResourceNode) []*ast.ResourceNode {
+	ordered := make([]*ast.ResourceNode, 0, len(resources))
+	placed := make(map[string]bool, len(resources))
+
+	for len(ordered) < len(resources) {
+		progress := false
+		for _, resource := range resources {
+			if placed[resource.Name] {
+				continue
+			}
+			ready := true
+			for _, parent := range foreignKeys(resource, resources) {
+				if parent != resource.Name && !placed[parent] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				ordered = append(ordered, resource)
+				placed[resource.Name] = true
+				progress = true
+			}
+		}
+		if !progress {
+			for _, resource := range resources {
+				if !placed[resource.Name] {
+					ordered = append(ordered, resource)
+					placed[resource.Name] = true
+				}
+			}
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,140 @@
+package loadtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+	"github.com/conduit-lang/conduit/internal/compiler/parser"
+)
+
+func parseResources(t *testing.T, source string) []*ast.ResourceNode {
+	t.Helper()
+	l := lexer.New(source)
+	tokens, lexErrors := l.ScanTokens()
+	if len(lexErrors) > 0 {
+		t.Fatalf("lexer errors: %v", lexErrors)
+	}
+	p := parser.New(tokens)
+	program, parseErrors := p.Parse()
+	if len(parseErrors) > 0 {
+		t.Fatalf("parser errors: %v", parseErrors)
+	}
+	return program.Resources
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix("list=80, show=20")
+	if err != nil {
+		t.Fatalf("ParseMix() error = %v", err)
+	}
+	if mix["list"] != 80 || mix["show"] != 20 || mix["create"] != 0 {
+		t.Errorf("mix = %v", mix)
+	}
+	if got := mix.String(); got != "list=80,show=20,create=0" {
+		t.Errorf("String() = %q", got)
+	}
+
+	for _, invalid := range []string{"list", "delete=10", "list=-1", "list=abc", "list=0,show=0"} {
+		if _, err := ParseMix(invalid); err == nil {
+			t.Errorf("ParseMix(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestSampleValue(t *testing.T) {
+	resources := parseResources(t, `resource Product {
+  id: uuid! @primary @auto
+  name: string! @min(5) @max(40)
+  sku: string! @unique
+  contact_email: string!
+  quantity: int! @min(1) @max(10)
+  price: float! @min(0)
+  status: enum ["draft", "active"]!
+  featured: bool!
+  created_at: timestamp! @auto
+}`)
+
+	fields := createPayload(resources[0], nil)
+	got := make(map[string]string)
+	for _, field := range fields {
+		got[field.Name] = field.Expr
+	}
+
+	if _, ok := got["id"]; ok {
+		t.Error("@primary @auto field should be omitted")
+	}
+	if _, ok := got["created_at"]; ok {
+		t.Error("@auto field should be omitted")
+	}
+
+	want := map[string]string{
+		"name":          "sized(`Sample name`, 5, 40)",
+		"sku":           "`${unique()} sku`",
+		"contact_email": "`user-${unique()}@example.com`",
+		"quantity":      "between(1, 10)",
+		"price":         "Math.round((0 + Math.random() * 1000) * 100) / 100",
+		"status":        `pick(["draft", "active"])`,
+		"featured":      "Math.random() < 0.5",
+	}
+	for name, expr := range want {
+		if got[name] != expr {
+			t.Errorf("%s = %q, want %q", name, got[name], expr)
+		}
+	}
+}
+
+func TestGenerateK6(t *testing.T) {
+	resources := parseResources(t, `resource Comment {
+  id: uuid! @primary @auto
+  body: text!
+  post_id: uuid!
+
+  post: Post! {
+    foreign_key: "post_id"
+  }
+}
+
+resource Post {
+  id: uuid! @primary @auto
+  title: string!
+}`)
+
+	script, err := GenerateK6(resources, Options{
+		BaseURL:   "http://localhost:4000",
+		APIPrefix: "/api",
+		Mix:       Mix{"list": 70, "show": 20, "create": 10},
+		VUs:       25,
+		Duration:  "2m",
+	})
+	if err != nil {
+		t.Fatalf("GenerateK6() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`const BASE_URL = __ENV.BASE_URL || "http://localhost:4000";`,
+		"vus: 25,",
+		`duration: "2m",`,
+		"  list: 70,\n  show: 20,\n  create: 10,",
+		`Post: { collection: "/api/posts", member: "/api/posts/{id}", key: ["id"] },`,
+		"post_id: ids.Post,",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q", want)
+		}
+	}
+
+	// Parents are created before the resources that belong to them
+	postSetup := strings.Index(script, "ids.Post = create('Post', ids);")
+	commentSetup := strings.Index(script, "ids.Comment = create('Comment', ids);")
+	if postSetup < 0 || commentSetup < 0 || postSetup > commentSetup {
+		t.Errorf("setup should create Post before Comment:\n%s", script)
+	}
+}
+
+func TestGenerateK6_NoResources(t *testing.T) {
+	if _, err := GenerateK6(nil, Options{}); err == nil {
+		t.Error("expected an error without resources")
+	}
+}
@@ -0,0 +1,75 @@
+package loadtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Operations covered by generated scenarios, in the order they are written
+var Operations = []string{"list", "show", "create"}
+
+// Mix is the relative weight of each operation in the generated traffic.
+// Weights are relative to each other and need not add up to 100.
+type Mix map[string]int
+
+// DefaultMix is a read-heavy mix typical of JSON APIs
+var DefaultMix = Mix{"list": 60, "show": 30, "create": 10}
+
+// ParseMix parses a mix such as "list=60,show=30,create=10". Operations that
+// are omitted get a weight of zero.
+func ParseMix(s string) (Mix, error) {
+	mix := Mix{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		op, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix entry %q (expected operation=weight)", part)
+		}
+		op = strings.TrimSpace(op)
+		if !isOperation(op) {
+			return nil, fmt.Errorf("unknown operation %q in mix (expected %s)", op, strings.Join(Operations, ", "))
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %q for %s (expected a non-negative integer)", value, op)
+		}
+		mix[op] = weight
+	}
+
+	if mix.Total() == 0 {
+		return nil, fmt.Errorf("mix %q has no traffic (at least one weight must be positive)", s)
+	}
+	return mix, nil
+}
+
+// Total returns the sum of all weights
+func (m Mix) Total() int {
+	total := 0
+	for _, op := range Operations {
+		total += m[op]
+	}
+	return total
+}
+
+// String formats the mix in the form accepted by ParseMix
+func (m Mix) String() string {
+	parts := make([]string, 0, len(Operations))
+	for _, op := range Operations {
+		parts = append(parts, fmt.Sprintf("%s=%d", op, m[op]))
+	}
+	return strings.Join(parts, ",")
+}
+
+func isOperation(op string) bool {
+	for _, candidate := range Operations {
+		if candidate == op {
+			return true
+		}
+	}
+	return false
+}
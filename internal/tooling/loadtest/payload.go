@@ -0,0 +1,183 @@
+package loadtest
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+)
+
+// payloadField is one attribute of a generated create body. Expr is a
+// JavaScript expression evaluated per request.
+type payloadField struct {
+	Name string
+	Expr string
+}
+
+// createPayload returns the attributes sent when creating a resource.
+// Generated fields (@primary, @auto, @auto_update) are omitted and foreign
+// keys reference records created during setup.
+func createPayload(resource *ast.ResourceNode, foreignKeys map[string]string) []payloadField {
+	fields := make([]payloadField, 0, len(resource.Fields))
+	for _, field := range resource.Fields {
+		if field.Type == nil || field.Type.Kind == ast.TypeResource || isGenerated(field) {
+			continue
+		}
+		if target, ok := foreignKeys[field.Name]; ok {
+			fields = append(fields, payloadField{Name: field.Name, Expr: "ids." + target})
+			continue
+		}
+		fields = append(fields, payloadField{Name: field.Name, Expr: sampleValue(field)})
+	}
+	return fields
+}
+
+// isGenerated reports whether the server assigns the field's value
+func isGenerated(field *ast.FieldNode) bool {
+	for _, constraint := range field.Constraints {
+		switch constraint.Name {
+		case "primary":
+			// Natural keys such as a country code are supplied by the client
+			if field.Name == "id" {
+				return true
+			}
+		case "auto", "auto_update":
+			return true
+		}
+	}
+	return false
+}
+
+// sampleValue returns a JavaScript expression producing a realistic value
+// for the field that satisfies its @min, @max and @unique constraints
+func sampleValue(field *ast.FieldNode) string {
+	if field.Type.Kind == ast.TypeEnum && len(field.Type.EnumValues) > 0 {
+		quoted := make([]string, len(field.Type.EnumValues))
+		for i, value := range field.Type.EnumValues {
+			quoted[i] = strconv.Quote(value)
+		}
+		return fmt.Sprintf("pick([%s])", strings.Join(quoted, ", "))
+	}
+	if field.Type.Kind == ast.TypeArray {
+		return "[]"
+	}
+	if field.Type.Kind == ast.TypeHash || field.Type.Kind == ast.TypeStruct {
+		return "{}"
+	}
+
+	min, hasMin := constraintNumber(field, "min")
+	max, hasMax := constraintNumber(field, "max")
+
+	switch field.Type.Name {
+	case "string", "text", "markdown":
+		return sampleString(field, int(min), int(max), hasMax)
+	case "int":
+		return sampleNumber(min, hasMin, max, hasMax, 1, 1000, true)
+	case "float":
+		return sampleNumber(min, hasMin, max, hasMax, 1, 1000, false)
+	case "bool":
+		return "Math.random() < 0.5"
+	case "uuid":
+		return "uuid()"
+	case "timestamp":
+		return "new Date().toISOString()"
+	case "json":
+		return "{}"
+	case "point":
+		return "{type: 'Point', coordinates: [Math.random() * 360 - 180, Math.random() * 180 - 90]}"
+	case "polygon":
+		return "{type: 'Polygon', coordinates: [[[0, 0], [1, 0], [1, 1], [0, 0]]]}"
+	default:
+		return "null"
+	}
+}
+
+// sampleString picks text based on the field name and sizes it to the
+// field's length constraints. Unique values lead with a per-request suffix so
+// truncation keeps them distinct.
+func sampleString(field *ast.FieldNode, min, max int, hasMax bool) string {
+	name := strings.ToLower(field.Name)
+	unique := hasConstraint(field, "unique")
+
+	var value string
+	switch {
+	case strings.Contains(name, "email"):
+		return "`user-${unique()}@example.com`"
+	case strings.Contains(name, "url") || strings.Contains(name, "website"):
+		value = "https://example.com/${unique()}"
+	case strings.Contains(name, "slug"):
+		value = strings.ReplaceAll(name, "_", "-") + "-${unique()}"
+	case field.Type.Name == "text" || field.Type.Name == "markdown" ||
+		strings.Contains(name, "body") || strings.Contains(name, "description") || strings.Contains(name, "content"):
+		value = "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore."
+	case unique:
+		value = "${unique()} " + strings.ReplaceAll(name, "_", " ")
+	default:
+		value = "Sample " + strings.ReplaceAll(name, "_", " ")
+	}
+
+	expr := "`" + value + "`"
+	if min > 0 || hasMax {
+		return fmt.Sprintf("sized(%s, %d, %d)", expr, min, max)
+	}
+	return expr
+}
+
+// sampleNumber returns a random number within the constraint bounds,
+// defaulting to [lo, hi] for unbounded sides
+func sampleNumber(min float64, hasMin bool, max float64, hasMax bool, lo, hi float64, integer bool) string {
+	if !hasMin {
+		min = lo
+		if hasMax && max < min {
+			min = max - (hi - lo)
+		}
+	}
+	if !hasMax {
+		max = hi
+		if max < min {
+			max = min + (hi - lo)
+		}
+	}
+
+	if integer {
+		return fmt.Sprintf("between(%s, %s)", formatNumber(math.Ceil(min)), formatNumber(math.Floor(max)))
+	}
+	return fmt.Sprintf("Math.round((%s + Math.random() * %s) * 100) / 100", formatNumber(min), formatNumber(max-min))
+}
+
+// constraintNumber returns the numeric argument of a constraint such as @min(3)
+func constraintNumber(field *ast.FieldNode, name string) (float64, bool) {
+	for _, constraint := range field.Constraints {
+		if constraint.Name != name || len(constraint.Arguments) == 0 {
+			continue
+		}
+		lit, ok := constraint.Arguments[0].(*ast.LiteralExpr)
+		if !ok {
+			continue
+		}
+		switch v := lit.Value.(type) {
+		case int:
+			return float64(v), true
+		case int64:
+			return float64(v), true
+		case float64:
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+func hasConstraint(field *ast.FieldNode, name string) bool {
+	for _, constraint := range field.Constraints {
+		if constraint.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
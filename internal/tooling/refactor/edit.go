@@ -0,0 +1,97 @@
+package refactor
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+)
+
+// edit replaces the source text of a token, or inserts text after it
+type edit struct {
+	tok    lexer.Token
+	text   string
+	insert bool // Insert text after the token instead of replacing it
+}
+
+func replaceToken(tok lexer.Token, text string) edit {
+	return edit{tok: tok, text: text}
+}
+
+func insertAfter(tok lexer.Token, text string) edit {
+	return edit{tok: tok, text: text, insert: true}
+}
+
+// applyEdits applies edits to source. Edits whose token cannot be located are
+// dropped; the caller's reparse check catches any resulting mismatch.
+func applyEdits(source string, edits []edit) string {
+	lineStarts := []int{0}
+	for i := 0; i < len(source); i++ {
+		if source[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	type span struct {
+		start, end int
+		text       string
+	}
+
+	spans := make([]span, 0, len(edits))
+	for _, e := range edits {
+		offset, ok := locate(source, lineStarts, e.tok)
+		if !ok {
+			continue
+		}
+		if e.insert {
+			end := offset + len(e.tok.Lexeme)
+			spans = append(spans, span{start: end, end: end, text: e.text})
+		} else {
+			spans = append(spans, span{start: offset, end: offset + len(e.tok.Lexeme), text: e.text})
+		}
+	}
+
+	// Apply back to front so earlier offsets stay valid
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].start > spans[j].start
+	})
+
+	var b strings.Builder
+	b.Grow(len(source))
+	result := source
+	for _, s := range spans {
+		b.Reset()
+		b.WriteString(result[:s.start])
+		b.WriteString(s.text)
+		b.WriteString(result[s.end:])
+		result = b.String()
+	}
+	return result
+}
+
+// locate returns the byte offset of the token in source. The lexer reports
+// columns that drift by one after the first line, so both candidates are
+// tried before falling back to the first occurrence on the line.
+func locate(source string, lineStarts []int, tok lexer.Token) (int, bool) {
+	if tok.Line < 1 || tok.Line > len(lineStarts) || tok.Lexeme == "" {
+		return 0, false
+	}
+
+	start := lineStarts[tok.Line-1]
+	end := len(source)
+	if tok.Line < len(lineStarts) {
+		end = lineStarts[tok.Line] - 1
+	}
+	line := source[start:end]
+
+	for _, col := range []int{tok.Column - 1, tok.Column} {
+		if col >= 0 && strings.HasPrefix(line[min(col, len(line)):], tok.Lexeme) {
+			return start + col, true
+		}
+	}
+
+	if idx := strings.Index(line, tok.Lexeme); idx >= 0 {
+		return start + idx, true
+	}
+	return 0, false
+}
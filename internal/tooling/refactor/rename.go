@@ -0,0 +1,596 @@
+// Package refactor implements source-preserving codemods over Conduit (.cdt)
+// files. Rewrites are applied at the token level so comments and formatting
+// survive, and every result is cross-checked against the equivalent AST
+// rewrite before any file is touched.
+package refactor
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/compiler/ast"
+	"github.com/conduit-lang/conduit/internal/compiler/codegen"
+	"github.com/conduit-lang/conduit/internal/compiler/lexer"
+	"github.com/conduit-lang/conduit/internal/compiler/parser"
+)
+
+var (
+	resourceNamePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	fieldNamePattern    = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+)
+
+// SourceFile is a parsed .cdt file
+type SourceFile struct {
+	Path    string
+	Source  string
+	Program *ast.Program
+	tokens  []lexer.Token
+}
+
+// ParseFile lexes and parses a single .cdt source
+func ParseFile(path, source string) (*SourceFile, error) {
+	tokens, lexErrors := lexer.New(source).ScanTokens()
+	if len(lexErrors) > 0 {
+		return nil, fmt.Errorf("%s: %s", path, lexErrors[0].Error())
+	}
+
+	program, parseErrors := parser.New(tokens).Parse()
+	if len(parseErrors) > 0 {
+		return nil, fmt.Errorf("%s: %s", path, parseErrors[0].Error())
+	}
+
+	return &SourceFile{
+		Path:    path,
+		Source:  source,
+		Program: program,
+		tokens:  tokens,
+	}, nil
+}
+
+// LoadFiles reads and parses the given .cdt files
+func LoadFiles(paths []string) ([]*SourceFile, error) {
+	files := make([]*SourceFile, 0, len(paths))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		file, err := ParseFile(path, string(content))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Target identifies the element being refactored: a resource ("Post") or a
+// field on a resource ("Post.title").
+type Target struct {
+	Resource string
+	Field    string
+}
+
+// ParseTarget parses a "Resource" or "Resource.field" reference
+func ParseTarget(s string) (Target, error) {
+	parts := strings.Split(s, ".")
+	switch len(parts) {
+	case 1:
+		if !resourceNamePattern.MatchString(parts[0]) {
+			return Target{}, fmt.Errorf("invalid resource name %q: must be PascalCase", parts[0])
+		}
+		return Target{Resource: parts[0]}, nil
+	case 2:
+		if !resourceNamePattern.MatchString(parts[0]) {
+			return Target{}, fmt.Errorf("invalid resource name %q: must be PascalCase", parts[0])
+		}
+		if !fieldNamePattern.MatchString(parts[1]) {
+			return Target{}, fmt.Errorf("invalid field name %q: must be snake_case", parts[1])
+		}
+		return Target{Resource: parts[0], Field: parts[1]}, nil
+	}
+	return Target{}, fmt.Errorf("invalid target %q: expected Resource or Resource.field", s)
+}
+
+// IsField reports whether the target is a field rather than a resource
+func (t Target) IsField() bool {
+	return t.Field != ""
+}
+
+// String returns the target in "Resource" or "Resource.field" form
+func (t Target) String() string {
+	if t.IsField() {
+		return t.Resource + "." + t.Field
+	}
+	return t.Resource
+}
+
+// Migration is the SQL needed to keep the database in step with a refactor
+type Migration struct {
+	Name string
+	Up   string
+	Down string
+}
+
+// IsEmpty reports whether the refactor needs no database change
+func (m Migration) IsEmpty() bool {
+	return m.Up == ""
+}
+
+// Result describes the outcome of a rename
+type Result struct {
+	Changed    map[string]string // File path -> rewritten source, for changed files only
+	References int               // References rewritten, excluding the declaration itself
+	Migration  Migration
+}
+
+// ChangedPaths returns the paths of changed files in sorted order
+func (r *Result) ChangedPaths() []string {
+	paths := make([]string, 0, len(r.Changed))
+	for path := range r.Changed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// RenameOptions adjusts how a rename is carried out in the database
+type RenameOptions struct {
+	// DualWrite renames a field without renaming its column in place: the new
+	// column is added and backfilled, and the field is annotated with
+	// @dual_write so generated code keeps writing the old column too. Instances
+	// still running the previous build keep working during a blue/green deploy.
+	DualWrite bool
+	// Until is the optional cleanup date (YYYY-MM-DD) recorded with @dual_write
+	Until string
+}
+
+// Rename renames a resource or field across all files, cascading to every
+// reference (relationships, hooks, constraints, scopes), and records the old
+// name with @alias so generated metadata keeps it for API backward
+// compatibility. The input files are not modified.
+func Rename(files []*SourceFile, target Target, newName string) (*Result, error) {
+	return RenameWithOptions(files, target, newName, RenameOptions{})
+}
+
+// RenameWithOptions is Rename with control over the database migration
+func RenameWithOptions(files []*SourceFile, target Target, newName string, opts RenameOptions) (*Result, error) {
+	if opts.DualWrite && !target.IsField() {
+		return nil, fmt.Errorf("dual-write only applies to field renames")
+	}
+	if opts.Until != "" {
+		if _, err := time.Parse("2006-01-02", opts.Until); err != nil {
+			return nil, fmt.Errorf("invalid cleanup date %q: must be YYYY-MM-DD", opts.Until)
+		}
+	}
+	if target.IsField() && !fieldNamePattern.MatchString(newName) {
+		return nil, fmt.Errorf("invalid field name %q: must be snake_case", newName)
+	}
+	if !target.IsField() && !resourceNamePattern.MatchString(newName) {
+		return nil, fmt.Errorf("invalid resource name %q: must be PascalCase", newName)
+	}
+
+	// Relationship names that point at the target resource, per resource,
+	// captured before the AST rewrite mutates anything
+	relTargets := make(map[string]map[string]bool)
+	expected := &ast.Program{}
+	for _, file := range files {
+		for _, resource := range file.Program.Resources {
+			for _, rel := range resource.Relationships {
+				if rel.Type == target.Resource {
+					if relTargets[resource.Name] == nil {
+						relTargets[resource.Name] = make(map[string]bool)
+					}
+					relTargets[resource.Name][rel.Name] = true
+				}
+			}
+		}
+	}
+
+	// Apply the rename to a private copy of the AST; this validates the rename
+	// and gives the expected shape of the rewritten program
+	for _, file := range files {
+		reparsed, err := ParseFile(file.Path, file.Source)
+		if err != nil {
+			return nil, err
+		}
+		expected.Resources = append(expected.Resources, reparsed.Program.Resources...)
+	}
+
+	var (
+		references  int
+		err         error
+		migration   Migration
+		annotations string // Appended to the renamed declaration
+	)
+	if target.IsField() {
+		annotations = fmt.Sprintf(" @alias(%s)", strconv.Quote(target.Field))
+
+		// A field stored under a @column name keeps its column when renamed
+		var columnOverride string
+		var field *ast.FieldNode
+		if resource := expected.FindResource(target.Resource); resource != nil {
+			if field = resource.FindField(target.Field); field != nil {
+				columnOverride = field.ColumnOverride()
+			}
+			// The columns of an external table are not the application's to rename
+			if resource.External != nil && field != nil && columnOverride == "" {
+				return nil, fmt.Errorf("%s reads @external_table %s, which is never migrated; add @column(%q) to %s before renaming it",
+					target.Resource, resource.External.Table, codegen.ColumnName(target.Field), target.Field)
+			}
+		}
+
+		// Resolve the column type before the AST rewrite renames the field
+		var sqlType string
+		if opts.DualWrite && columnOverride == "" && field != nil {
+			if sqlType, err = codegen.SQLType(field); err != nil {
+				return nil, err
+			}
+		}
+
+		references, err = ast.RenameField(expected, target.Resource, target.Field, newName)
+		table := codegen.TableName(target.Resource)
+		qualified := schemaPrefix(expected, target.Resource) + table
+		oldColumn, newColumn := codegen.ColumnName(target.Field), codegen.ColumnName(newName)
+		switch {
+		case columnOverride != "":
+			// No database change
+		case opts.DualWrite:
+			dualWrite := []string{strconv.Quote(oldColumn)}
+			if opts.Until != "" {
+				dualWrite = append(dualWrite, strconv.Quote(opts.Until))
+			}
+			annotations += fmt.Sprintf(" @dual_write(%s)", strings.Join(dualWrite, ", "))
+			migration = Migration{
+				Name: fmt.Sprintf("dual_write_%s_%s_to_%s", table, target.Field, newName),
+				Up: fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;\nUPDATE %s SET %s = %s;\n",
+					qualified, newColumn, sqlType, qualified, newColumn, oldColumn),
+				Down: fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;\n", qualified, newColumn),
+			}
+		default:
+			migration = Migration{
+				Name: fmt.Sprintf("rename_%s_%s_to_%s", table, target.Field, newName),
+				Up:   fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;\n", qualified, oldColumn, newColumn),
+				Down: fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;\n", qualified, newColumn, oldColumn),
+			}
+		}
+	} else {
+		prefix := schemaPrefix(expected, target.Resource)
+		resource := expected.FindResource(target.Resource)
+		references, err = ast.RenameResource(expected, target.Resource, newName)
+		oldTable, newTable := codegen.TableName(target.Resource), codegen.TableName(newName)
+		// An external table keeps its name; only the resource is renamed
+		if resource == nil || resource.External == nil {
+			migration = Migration{
+				Name: fmt.Sprintf("rename_%s_to_%s", oldTable, newTable),
+				Up:   fmt.Sprintf("ALTER TABLE %s RENAME TO %s;\n", prefix+oldTable, newTable),
+				Down: fmt.Sprintf("ALTER TABLE %s RENAME TO %s;\n", prefix+newTable, oldTable),
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Changed:    make(map[string]string),
+		References: references,
+		Migration:  migration,
+	}
+
+	rewritten := &ast.Program{}
+	for _, file := range files {
+		var edits []edit
+		if target.IsField() {
+			edits = fieldRenameEdits(file, target, newName, relTargets, annotations)
+		} else {
+			edits = resourceRenameEdits(file, target.Resource, newName)
+		}
+
+		source := file.Source
+		if len(edits) > 0 {
+			source = applyEdits(file.Source, edits)
+			result.Changed[file.Path] = source
+		}
+
+		parsed, err := ParseFile(file.Path, source)
+		if err != nil {
+			return nil, fmt.Errorf("refactor produced invalid source: %w", err)
+		}
+		rewritten.Resources = append(rewritten.Resources, parsed.Program.Resources...)
+	}
+
+	// Safety check: the source rewrite must match the AST rewrite exactly
+	// once the newly recorded annotations are set aside
+	stripAlias(rewritten, target, newName)
+	if ast.Print(rewritten) != ast.Print(expected) {
+		return nil, fmt.Errorf("could not safely rewrite all references to %s; no files were changed", target)
+	}
+
+	return result, nil
+}
+
+// stripAlias removes the alias (and any @dual_write) recorded by Rename from a
+// reparsed program
+func stripAlias(program *ast.Program, target Target, newName string) {
+	if !target.IsField() {
+		if resource := program.FindResource(newName); resource != nil && len(resource.Aliases) > 0 {
+			if last := len(resource.Aliases) - 1; resource.Aliases[last] == target.Resource {
+				resource.Aliases = resource.Aliases[:last]
+			}
+		}
+		return
+	}
+
+	resource := program.FindResource(target.Resource)
+	if resource == nil {
+		return
+	}
+	field := resource.FindField(newName)
+	if field == nil || len(field.Constraints) == 0 {
+		return
+	}
+	if last := field.Constraints[len(field.Constraints)-1]; last.Name == "dual_write" {
+		field.Constraints = field.Constraints[:len(field.Constraints)-1]
+		if len(field.Constraints) == 0 {
+			return
+		}
+	}
+	last := field.Constraints[len(field.Constraints)-1]
+	if last.Name == "alias" && len(last.Arguments) == 1 {
+		if lit, ok := last.Arguments[0].(*ast.LiteralExpr); ok && lit.Value == target.Field {
+			field.Constraints = field.Constraints[:len(field.Constraints)-1]
+		}
+	}
+}
+
+// schemaPrefix returns "billing." for a resource declared @schema("billing")
+// and "" otherwise, qualifying its table in rename migrations
+func schemaPrefix(program *ast.Program, resourceName string) string {
+	if resource := program.FindResource(resourceName); resource != nil && resource.Schema != nil {
+		return resource.Schema.Name + "."
+	}
+	return ""
+}
+
+// resourceRenameEdits computes the edits that rename a resource in one file
+func resourceRenameEdits(file *SourceFile, oldName, newName string) []edit {
+	var edits []edit
+	tokens := file.tokens
+
+	for i, tok := range tokens {
+		if tok.Type != lexer.TOKEN_IDENTIFIER || tok.Lexeme != oldName {
+			continue
+		}
+
+		prev, next := tokenAt(tokens, i-1), tokenAt(tokens, i+1)
+
+		// Field names, hash keys and member accesses share the spelling but
+		// are not references to the resource
+		if next.Type == lexer.TOKEN_COLON || prev.Type == lexer.TOKEN_DOT || prev.Type == lexer.TOKEN_SAFE_NAV {
+			continue
+		}
+
+		edits = append(edits, replaceToken(tok, newName))
+
+		// Record the old name right after the opening brace of the declaration
+		if prev.Type == lexer.TOKEN_RESOURCE && next.Type == lexer.TOKEN_LBRACE {
+			indent := lineIndent(file.Source, tokenAt(tokens, i+2).Line, "  ")
+			edits = append(edits, insertAfter(next, fmt.Sprintf("\n%s@alias(%s)", indent, strconv.Quote(oldName))))
+		}
+	}
+
+	return edits
+}
+
+// fieldRenameEdits computes the edits that rename a field in one file.
+// annotations are appended to the end of the declaration line.
+func fieldRenameEdits(file *SourceFile, target Target, newName string, relTargets map[string]map[string]bool, annotations string) []edit {
+	var edits []edit
+	tokens := file.tokens
+
+	depth := 0
+	current := ""
+	listEnd := -1
+	for i, tok := range tokens {
+		if i <= listEnd {
+			continue
+		}
+
+		switch tok.Type {
+		case lexer.TOKEN_LBRACE:
+			if depth == 0 && tokenAt(tokens, i-2).Type == lexer.TOKEN_RESOURCE {
+				current = tokenAt(tokens, i-1).Lexeme
+			}
+			depth++
+			continue
+		case lexer.TOKEN_RBRACE:
+			depth--
+			if depth == 0 {
+				current = ""
+			}
+			continue
+		}
+
+		if current == "" {
+			continue
+		}
+
+		// Field names listed by an index block or annotation of the owning resource
+		if current == target.Resource && depth == 1 {
+			if refs, end, ok := fieldListReferences(tokens, i, target.Field); ok {
+				for _, ref := range refs {
+					edits = append(edits, replaceToken(ref, newName))
+				}
+				listEnd = end
+				continue
+			}
+		}
+
+		// foreign_key: "<field>" on the owning resource's relationships
+		if tok.Type == lexer.TOKEN_STRING_LITERAL {
+			if current == target.Resource && tok.Literal == target.Field &&
+				tokenAt(tokens, i-1).Type == lexer.TOKEN_COLON && tokenAt(tokens, i-2).Lexeme == "foreign_key" {
+				edits = append(edits, replaceToken(tok, strconv.Quote(newName)))
+			}
+			continue
+		}
+
+		if tok.Lexeme != target.Field {
+			continue
+		}
+
+		prev := tokenAt(tokens, i-1)
+		isAccess := prev.Type == lexer.TOKEN_DOT || prev.Type == lexer.TOKEN_SAFE_NAV
+
+		switch {
+		case current == target.Resource && depth == 1 && !isAccess && tokenAt(tokens, i+1).Type == lexer.TOKEN_COLON:
+			// The declaration itself, followed by the annotations at the end of its line
+			edits = append(edits, replaceToken(tok, newName))
+			last := i
+			for tokenAt(tokens, last+1).Line == tok.Line && tokenAt(tokens, last+1).Type != lexer.TOKEN_EOF {
+				last++
+			}
+			edits = append(edits, insertAfter(tokens[last], annotations))
+
+		case current == target.Resource && isAccess && tokenAt(tokens, i-2).Type == lexer.TOKEN_SELF:
+			// self.<field>
+			edits = append(edits, replaceToken(tok, newName))
+
+		case isAccess && relTargets[current] != nil && tokenAt(tokens, i-4).Type == lexer.TOKEN_SELF &&
+			isAccessToken(tokenAt(tokens, i-3)) && relTargets[current][tokenAt(tokens, i-2).Lexeme]:
+			// self.<relationship>.<field>
+			edits = append(edits, replaceToken(tok, newName))
+		}
+	}
+
+	return edits
+}
+
+// fieldListAnnotations are the resource annotations whose arguments name
+// fields of the resource
+var fieldListAnnotations = map[string]bool{
+	"upsert":       true,
+	"orderable":    true,
+	"search_index": true,
+	"partition":    true,
+	"shard":        true,
+	"profile":      true,
+	"conflict":     true,
+	"primary":      true,
+}
+
+// fieldListReferences reports whether tokens[start] begins a list naming
+// fields of the resource: an index block, such as index [email, title], or a
+// resource annotation such as @upsert(on: [email]). It returns the tokens
+// naming field, and the index of the token that ends the list.
+func fieldListReferences(tokens []lexer.Token, start int, field string) ([]lexer.Token, int, bool) {
+	var list string
+	var open int
+	if tokens[start].Lexeme == "index" && tokenAt(tokens, start+1).Type == lexer.TOKEN_LBRACKET {
+		list, open = "index", start+1
+	} else if name, next := annotationAt(tokens, start); fieldListAnnotations[name] && tokenAt(tokens, next).Type == lexer.TOKEN_LPAREN {
+		list, open = name, next
+	} else {
+		return nil, 0, false
+	}
+
+	var refs []lexer.Token
+	parens, brackets := 0, 0
+	option := ""
+	for i := open; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.Type {
+		case lexer.TOKEN_LPAREN:
+			parens++
+		case lexer.TOKEN_RPAREN:
+			parens--
+		case lexer.TOKEN_LBRACKET:
+			brackets++
+		case lexer.TOKEN_RBRACKET:
+			brackets--
+		case lexer.TOKEN_EOF:
+			return refs, i, true
+		default:
+			if tokenAt(tokens, i+1).Type == lexer.TOKEN_COLON {
+				option = tok.Lexeme
+			} else if tok.Lexeme == field && namesField(list, option, parens, brackets) {
+				refs = append(refs, tok)
+			}
+			continue
+		}
+		if parens == 0 && brackets == 0 {
+			return refs, i, true
+		}
+	}
+	return refs, len(tokens) - 1, true
+}
+
+// annotationAt returns the name of the annotation starting at tokens[i] and
+// the index of the token after it, or "" when there is none. Known
+// annotations are a single token such as @upsert; others are @ followed by
+// an identifier.
+func annotationAt(tokens []lexer.Token, i int) (string, int) {
+	tok := tokens[i]
+	if tok.Type == lexer.TOKEN_AT {
+		if next := tokenAt(tokens, i+1); next.Type == lexer.TOKEN_IDENTIFIER {
+			return next.Lexeme, i + 2
+		}
+		return "", i + 1
+	}
+	if len(tok.Lexeme) > 1 && strings.HasPrefix(tok.Lexeme, "@") {
+		return tok.Lexeme[1:], i + 1
+	}
+	return "", i + 1
+}
+
+// namesField reports whether a name in the arguments of a field list is a
+// field of the resource, given the option it follows and how deeply it is
+// nested in parentheses and brackets. In @upsert(on: [email]), email is.
+func namesField(list, option string, parens, brackets int) bool {
+	switch list {
+	case "index", "upsert", "profile":
+		return brackets == 1
+	case "search_index", "primary":
+		return parens == 1
+	case "orderable":
+		return option == "scope"
+	case "partition", "shard":
+		return option == "by"
+	case "conflict":
+		return parens == 2
+	}
+	return false
+}
+
+func isAccessToken(tok lexer.Token) bool {
+	return tok.Type == lexer.TOKEN_DOT || tok.Type == lexer.TOKEN_SAFE_NAV
+}
+
+// tokenAt returns the token at index i, or an EOF token when out of range
+func tokenAt(tokens []lexer.Token, i int) lexer.Token {
+	if i < 0 || i >= len(tokens) {
+		return lexer.Token{Type: lexer.TOKEN_EOF}
+	}
+	return tokens[i]
+}
+
+// lineIndent returns the leading whitespace of the given 1-indexed line
+func lineIndent(source string, line int, fallback string) string {
+	lines := strings.Split(source, "\n")
+	if line < 1 || line > len(lines) {
+		return fallback
+	}
+	text := lines[line-1]
+	indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+	if indent == "" {
+		return fallback
+	}
+	return indent
+}
@@ -0,0 +1,526 @@
+package refactor
+
+import (
+	"strings"
+	"testing"
+)
+
+const userSource = `// Users of the blog
+resource User {
+  id: uuid! @primary @auto
+  name: string! // display name
+}
+`
+
+const postSource = `resource Post {
+  id: uuid! @primary @auto
+  title: string! @min(5) @max(200) // shown in listings
+  slug: string! @unique
+  author_id: uuid!
+
+  author: User! {
+    foreign_key: "author_id"
+    on_delete: restrict
+  }
+
+  @before create {
+    self.slug = String.slugify(self.title)
+    if self.author.name == "" {
+      self.title = self.title
+    }
+  }
+}
+
+resource Comment {
+  id: uuid! @primary @auto
+  post: Post! {
+    foreign_key: "post_id"
+  }
+}
+`
+
+func loadTestFiles(t *testing.T) []*SourceFile {
+	t.Helper()
+
+	var files []*SourceFile
+	for path, source := range map[string]string{"app/user.cdt": userSource, "app/post.cdt": postSource} {
+		file, err := ParseFile(path, source)
+		if err != nil {
+			t.Fatalf("ParseFile(%s) failed: %v", path, err)
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Target
+		wantErr bool
+	}{
+		{"Post", Target{Resource: "Post"}, false},
+		{"Post.title", Target{Resource: "Post", Field: "title"}, false},
+		{"post", Target{}, true},
+		{"Post.Title", Target{}, true},
+		{"Post.title.extra", Target{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTarget(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTarget(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTarget(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRename_Resource(t *testing.T) {
+	files := loadTestFiles(t)
+
+	result, err := Rename(files, Target{Resource: "Post"}, "BlogPost")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	if len(result.Changed) != 1 {
+		t.Fatalf("expected 1 changed file, got %v", result.ChangedPaths())
+	}
+
+	source := result.Changed["app/post.cdt"]
+	for _, want := range []string{
+		"resource BlogPost {\n  @alias(\"Post\")\n  id:",
+		"post: BlogPost! {",
+		"// shown in listings",
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("rewritten source missing %q:\n%s", want, source)
+		}
+	}
+
+	if result.Migration.Name != "rename_posts_to_blogposts" {
+		t.Errorf("unexpected migration name %s", result.Migration.Name)
+	}
+	if result.Migration.Up != "ALTER TABLE posts RENAME TO blogposts;\n" {
+		t.Errorf("unexpected up migration %q", result.Migration.Up)
+	}
+	if result.Migration.Down != "ALTER TABLE blogposts RENAME TO posts;\n" {
+		t.Errorf("unexpected down migration %q", result.Migration.Down)
+	}
+
+	// The rewritten source must parse and carry the alias
+	file, err := ParseFile("app/post.cdt", source)
+	if err != nil {
+		t.Fatalf("rewritten source does not parse: %v", err)
+	}
+	aliases := file.Program.FindResource("BlogPost").Aliases
+	if len(aliases) != 1 || aliases[0] != "Post" {
+		t.Errorf("expected alias Post, got %v", aliases)
+	}
+}
+
+func TestRename_Field(t *testing.T) {
+	files := loadTestFiles(t)
+
+	result, err := Rename(files, Target{Resource: "Post", Field: "title"}, "headline")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	if result.References != 3 {
+		t.Errorf("expected 3 references, got %d", result.References)
+	}
+
+	source := result.Changed["app/post.cdt"]
+	if strings.Contains(source, "self.title") {
+		t.Errorf("old field reference remains:\n%s", source)
+	}
+	if !strings.Contains(source, `headline: string! @min(5) @max(200) @alias("title") // shown in listings`) {
+		t.Errorf("declaration not rewritten:\n%s", source)
+	}
+
+	if result.Migration.Up != "ALTER TABLE posts RENAME COLUMN title TO headline;\n" {
+		t.Errorf("unexpected up migration %q", result.Migration.Up)
+	}
+}
+
+func TestRename_Schema(t *testing.T) {
+	file, err := ParseFile("app/invoice.cdt", `resource Invoice {
+  id: uuid! @primary @auto
+  total: float!
+
+  @schema("billing")
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	result, err := Rename([]*SourceFile{file}, Target{Resource: "Invoice", Field: "total"}, "amount")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if result.Migration.Up != "ALTER TABLE billing.invoices RENAME COLUMN total TO amount;\n" {
+		t.Errorf("unexpected up migration %q", result.Migration.Up)
+	}
+
+	result, err = Rename([]*SourceFile{file}, Target{Resource: "Invoice"}, "Bill")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if result.Migration.Name != "rename_invoices_to_bills" {
+		t.Errorf("unexpected migration name %s", result.Migration.Name)
+	}
+	if result.Migration.Up != "ALTER TABLE billing.invoices RENAME TO bills;\n" {
+		t.Errorf("unexpected up migration %q", result.Migration.Up)
+	}
+	if result.Migration.Down != "ALTER TABLE billing.bills RENAME TO invoices;\n" {
+		t.Errorf("unexpected down migration %q", result.Migration.Down)
+	}
+}
+
+func TestRename_External(t *testing.T) {
+	file, err := ParseFile("app/legacy_user.cdt", `resource LegacyUser {
+  id: int! @primary
+  email: string!
+  name: string! @column("full_name")
+
+  @external_table("legacy.users")
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	// Renaming would need a migration of a table the app does not own
+	_, err = Rename([]*SourceFile{file}, Target{Resource: "LegacyUser", Field: "email"}, "address")
+	if err == nil || !strings.Contains(err.Error(), `add @column("email")`) {
+		t.Fatalf("expected an error suggesting @column, got %v", err)
+	}
+
+	result, err := Rename([]*SourceFile{file}, Target{Resource: "LegacyUser", Field: "name"}, "display_name")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if result.Migration.Up != "" {
+		t.Errorf("expected no migration, got %q", result.Migration.Up)
+	}
+
+	result, err = Rename([]*SourceFile{file}, Target{Resource: "LegacyUser"}, "Member")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if result.Migration.Up != "" || result.Migration.Down != "" {
+		t.Errorf("expected no migration for an external table, got %+v", result.Migration)
+	}
+	if source := result.Changed["app/legacy_user.cdt"]; !strings.Contains(source, `@external_table("legacy.users")`) {
+		t.Errorf("rename should keep the external table:\n%s", source)
+	}
+}
+
+func TestRename_FieldWithColumnOverride(t *testing.T) {
+	source := `resource Post {
+  id: uuid! @primary @auto
+  title: string! @column("post_title")
+}
+`
+	file, err := ParseFile("app/post.cdt", source)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	result, err := Rename([]*SourceFile{file}, Target{Resource: "Post", Field: "title"}, "headline")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	if !strings.Contains(result.Changed["app/post.cdt"], `headline: string! @column("post_title") @alias("title")`) {
+		t.Errorf("declaration not rewritten:\n%s", result.Changed["app/post.cdt"])
+	}
+	if !result.Migration.IsEmpty() {
+		t.Errorf("expected no migration for a field with @column, got %q", result.Migration.Up)
+	}
+}
+
+func TestRename_FieldDualWrite(t *testing.T) {
+	files := loadTestFiles(t)
+
+	result, err := RenameWithOptions(files, Target{Resource: "Post", Field: "title"}, "headline",
+		RenameOptions{DualWrite: true, Until: "2026-12-01"})
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	source := result.Changed["app/post.cdt"]
+	if !strings.Contains(source, `headline: string! @min(5) @max(200) @alias("title") @dual_write("title", "2026-12-01") // shown in listings`) {
+		t.Errorf("declaration not rewritten:\n%s", source)
+	}
+
+	wantUp := "ALTER TABLE posts ADD COLUMN headline VARCHAR(200);\nUPDATE posts SET headline = title;\n"
+	if result.Migration.Up != wantUp {
+		t.Errorf("unexpected up migration %q, want %q", result.Migration.Up, wantUp)
+	}
+	if result.Migration.Down != "ALTER TABLE posts DROP COLUMN headline;\n" {
+		t.Errorf("unexpected down migration %q", result.Migration.Down)
+	}
+
+	// The rewritten source declares the transition for code generation
+	parsed, err := ParseFile("app/post.cdt", source)
+	if err != nil {
+		t.Fatalf("rewritten source does not parse: %v", err)
+	}
+	column, until := parsed.Program.FindResource("Post").FindField("headline").DualWrite()
+	if column != "title" || until != "2026-12-01" {
+		t.Errorf("DualWrite() = (%q, %q), want (title, 2026-12-01)", column, until)
+	}
+}
+
+func TestRename_DualWriteErrors(t *testing.T) {
+	files := loadTestFiles(t)
+
+	if _, err := RenameWithOptions(files, Target{Resource: "Post"}, "Article", RenameOptions{DualWrite: true}); err == nil {
+		t.Error("expected error for dual-write on a resource rename")
+	}
+	if _, err := RenameWithOptions(files, Target{Resource: "Post", Field: "title"}, "headline",
+		RenameOptions{DualWrite: true, Until: "next month"}); err == nil {
+		t.Error("expected error for an invalid cleanup date")
+	}
+}
+
+func TestRename_FieldAcrossRelationship(t *testing.T) {
+	files := loadTestFiles(t)
+
+	result, err := Rename(files, Target{Resource: "User", Field: "name"}, "display_name")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	if len(result.Changed) != 2 {
+		t.Fatalf("expected 2 changed files, got %v", result.ChangedPaths())
+	}
+	if !strings.Contains(result.Changed["app/post.cdt"], "self.author.display_name") {
+		t.Errorf("relationship access not rewritten:\n%s", result.Changed["app/post.cdt"])
+	}
+	if !strings.Contains(result.Changed["app/user.cdt"], `display_name: string! @alias("name") // display name`) {
+		t.Errorf("declaration not rewritten:\n%s", result.Changed["app/user.cdt"])
+	}
+}
+
+func TestRename_ForeignKey(t *testing.T) {
+	files := loadTestFiles(t)
+
+	result, err := Rename(files, Target{Resource: "Post", Field: "author_id"}, "writer_id")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if !strings.Contains(result.Changed["app/post.cdt"], `foreign_key: "writer_id"`) {
+		t.Errorf("foreign key not rewritten:\n%s", result.Changed["app/post.cdt"])
+	}
+}
+
+func TestRename_FieldInIndex(t *testing.T) {
+	file, err := ParseFile("app/post.cdt", `resource Post {
+  id: uuid! @primary @auto
+  email: string!
+  title: string!
+  index: int!
+
+  index [email, title] { unique: true }
+  index [title]
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	result, err := Rename([]*SourceFile{file}, Target{Resource: "Post", Field: "title"}, "headline")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if result.References != 2 {
+		t.Errorf("expected 2 references, got %d", result.References)
+	}
+
+	source := result.Changed["app/post.cdt"]
+	for _, want := range []string{
+		"index [email, headline] { unique: true }",
+		"index [headline]",
+		"index: int!",
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("rewritten source missing %q:\n%s", want, source)
+		}
+	}
+
+	parsed, err := ParseFile("app/post.cdt", source)
+	if err != nil {
+		t.Fatalf("rewritten source does not parse: %v", err)
+	}
+	if fields := parsed.Program.FindResource("Post").Indexes[0].Fields; strings.Join(fields, ",") != "email,headline" {
+		t.Errorf("index fields = %v, want [email headline]", fields)
+	}
+}
+
+func TestRename_FieldInAnnotations(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		field      string
+		want       string
+		references int
+	}{
+		{
+			name:       "upsert",
+			source:     "@upsert(on: [email, tenant_id])",
+			field:      "email",
+			want:       "@upsert(on: [address, tenant_id])",
+			references: 1,
+		},
+		{
+			name:       "orderable scope",
+			source:     "@orderable(scope: list_id)",
+			field:      "list_id",
+			want:       "@orderable(scope: address)",
+			references: 1,
+		},
+		{
+			name:       "search index",
+			source:     "@search_index(title, body)",
+			field:      "title",
+			want:       "@search_index(address, body)",
+			references: 1,
+		},
+		{
+			name:       "partition",
+			source:     "@partition(by: created_at, interval: month)",
+			field:      "created_at",
+			want:       "@partition(by: address, interval: month)",
+			references: 1,
+		},
+		{
+			name:       "shard",
+			source:     "@shard(by: tenant_id)",
+			field:      "tenant_id",
+			want:       "@shard(by: address)",
+			references: 1,
+		},
+		{
+			name:       "profiles",
+			source:     "@profile(public: [id, title], editor: [title, body], admin: *)",
+			field:      "title",
+			want:       "@profile(public: [id, address], editor: [address, body], admin: *)",
+			references: 2,
+		},
+		{
+			name:       "conflict merge",
+			source:     "@conflict(strategy: merge(title, body))",
+			field:      "body",
+			want:       "@conflict(strategy: merge(title, address))",
+			references: 1,
+		},
+		{
+			name:       "composite primary key",
+			source:     "@primary(tenant_id, list_id)",
+			field:      "list_id",
+			want:       "@primary(tenant_id, address)",
+			references: 1,
+		},
+		{
+			name:       "default scope",
+			source:     `@default_scope { self.title != "" }`,
+			field:      "title",
+			want:       `@default_scope { self.address != "" }`,
+			references: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ParseFile("app/subscriber.cdt", `resource Subscriber {
+  id: uuid! @primary @auto
+  email: string! @unique
+  title: string!
+  body: text!
+  list_id: uuid!
+  tenant_id: uuid!
+  created_at: timestamp!
+
+  `+tt.source+`
+}
+`)
+			if err != nil {
+				t.Fatalf("ParseFile failed: %v", err)
+			}
+
+			result, err := Rename([]*SourceFile{file}, Target{Resource: "Subscriber", Field: tt.field}, "address")
+			if err != nil {
+				t.Fatalf("Rename failed: %v", err)
+			}
+			if result.References != tt.references {
+				t.Errorf("expected %d references, got %d", tt.references, result.References)
+			}
+			if source := result.Changed["app/subscriber.cdt"]; !strings.Contains(source, tt.want) {
+				t.Errorf("rewritten source missing %q:\n%s", tt.want, source)
+			}
+		})
+	}
+}
+
+func TestRename_OrderablePosition(t *testing.T) {
+	file, err := ParseFile("app/card.cdt", `resource Card {
+  id: uuid! @primary @auto
+  position: int! @default(0)
+  scope: uuid!
+
+  @orderable(scope: scope)
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	// Generated code finds the order by the field's name
+	_, err = Rename([]*SourceFile{file}, Target{Resource: "Card", Field: "position"}, "place")
+	if err == nil || !strings.Contains(err.Error(), "@orderable") {
+		t.Errorf("Rename(Card.position) error = %v, want an @orderable error", err)
+	}
+
+	// A scope field named like the option is still renamed
+	result, err := Rename([]*SourceFile{file}, Target{Resource: "Card", Field: "scope"}, "board_id")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if source := result.Changed["app/card.cdt"]; !strings.Contains(source, "@orderable(scope: board_id)") {
+		t.Errorf("orderable scope not rewritten:\n%s", source)
+	}
+}
+
+func TestRename_Errors(t *testing.T) {
+	files := loadTestFiles(t)
+
+	tests := []struct {
+		target  Target
+		newName string
+	}{
+		{Target{Resource: "Post"}, "blog_post"},
+		{Target{Resource: "Post"}, "User"},
+		{Target{Resource: "Missing"}, "Other"},
+		{Target{Resource: "Post", Field: "title"}, "Headline"},
+		{Target{Resource: "Post", Field: "title"}, "slug"},
+		{Target{Resource: "Post", Field: "missing"}, "other"},
+	}
+
+	for _, tt := range tests {
+		if _, err := Rename(files, tt.target, tt.newName); err == nil {
+			t.Errorf("Rename(%s, %s) should fail", tt.target, tt.newName)
+		}
+	}
+
+	// Inputs are never modified
+	if files[0].Program.FindResource("Post") == nil && files[1].Program.FindResource("Post") == nil {
+		t.Error("Rename mutated the input programs")
+	}
+}
@@ -23,6 +23,15 @@ type Cache interface {
 	Exists(ctx context.Context, key string) (bool, error)
 }
 
+// Adder is implemented by caches that can store a value only when its key is
+// absent, as one operation for every client of the cache, so that concurrent
+// callers cannot both see a key missing and both store it.
+type Adder interface {
+	// SetNX stores a value with a TTL unless the key exists, and reports
+	// whether it was stored
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+}
+
 // CacheConfig holds common configuration for cache backends
 type CacheConfig struct {
 	// DefaultTTL is the default time-to-live for cached items
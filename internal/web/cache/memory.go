@@ -11,6 +11,9 @@ type MemoryCache struct {
 	data   sync.Map
 	config CacheConfig
 	cancel context.CancelFunc
+
+	// addMu makes the check and store of SetNX one operation
+	addMu sync.Mutex
 }
 
 // cacheItem represents an item stored in the cache
@@ -92,6 +95,22 @@ func (m *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl tim
 	return nil
 }
 
+// SetNX stores a value with a TTL unless the key exists, and reports whether
+// it was stored
+func (m *MemoryCache) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	m.addMu.Lock()
+	defer m.addMu.Unlock()
+
+	exists, err := m.Exists(ctx, key)
+	if err != nil || exists {
+		return false, err
+	}
+	if err := m.Set(ctx, key, value, ttl); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // Delete removes a value from the cache
 func (m *MemoryCache) Delete(ctx context.Context, key string) error {
 	select {
@@ -2,6 +2,8 @@ package cache
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -117,6 +119,52 @@ func TestMemoryCache_Exists(t *testing.T) {
 	assert.True(t, exists)
 }
 
+func TestMemoryCache_SetNX(t *testing.T) {
+	cache := NewMemoryCache()
+	defer cache.Close()
+	ctx := context.Background()
+
+	added, err := cache.SetNX(ctx, "test-key", []byte("first"), 1*time.Minute)
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = cache.SetNX(ctx, "test-key", []byte("second"), 1*time.Minute)
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	retrieved, err := cache.Get(ctx, "test-key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), retrieved)
+
+	// An expired key counts as absent
+	require.NoError(t, cache.Set(ctx, "expired", []byte("old"), 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+	added, err = cache.SetNX(ctx, "expired", []byte("new"), 1*time.Minute)
+	require.NoError(t, err)
+	assert.True(t, added)
+}
+
+func TestMemoryCache_SetNXConcurrent(t *testing.T) {
+	cache := NewMemoryCache()
+	defer cache.Close()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var added atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := cache.SetNX(ctx, "test-key", []byte("value"), 1*time.Minute); err == nil && ok {
+				added.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), added.Load())
+}
+
 func TestMemoryCache_TTLExpiration(t *testing.T) {
 	cache := NewMemoryCache()
 	ctx := context.Background()
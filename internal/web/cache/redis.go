@@ -98,6 +98,20 @@ func (r *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time
 	return r.client.Set(ctx, fullKey, value, ttl).Err()
 }
 
+// SetNX stores a value with a TTL unless the key exists, and reports whether
+// it was stored. It is a single SET NX, so it is atomic across every client
+// of the server.
+func (r *RedisCache) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	fullKey := r.config.Prefix + key
+
+	// Use default TTL if none provided
+	if ttl == 0 {
+		ttl = r.config.DefaultTTL
+	}
+
+	return r.client.SetNX(ctx, fullKey, value, ttl).Result()
+}
+
 // Delete removes a value from the cache
 func (r *RedisCache) Delete(ctx context.Context, key string) error {
 	fullKey := r.config.Prefix + key
@@ -162,6 +162,33 @@ func TestRedisCache_Exists(t *testing.T) {
 	assert.True(t, exists)
 }
 
+func TestRedisCache_SetNX(t *testing.T) {
+	cache, mr := setupTestRedis(t)
+	defer mr.Close()
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	added, err := cache.SetNX(ctx, "test-key", []byte("first"), 1*time.Minute)
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = cache.SetNX(ctx, "test-key", []byte("second"), 1*time.Minute)
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	retrieved, err := cache.Get(ctx, "test-key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), retrieved)
+	assert.Equal(t, 1*time.Minute, mr.TTL("conduit:test-key"))
+
+	// Once the key expires it can be added again
+	mr.FastForward(2 * time.Minute)
+	added, err = cache.SetNX(ctx, "test-key", []byte("third"), 1*time.Minute)
+	require.NoError(t, err)
+	assert.True(t, added)
+}
+
 func TestRedisCache_TTLExpiration(t *testing.T) {
 	cache, mr := setupTestRedis(t)
 	defer mr.Close()
@@ -50,7 +50,8 @@ func setupTestDB(t *testing.T) (*sql.DB, func()) {
 			started_at TIMESTAMP WITH TIME ZONE,
 			completed_at TIMESTAMP WITH TIME ZONE,
 			locked_by VARCHAR(255),
-			locked_at TIMESTAMP WITH TIME ZONE
+			locked_at TIMESTAMP WITH TIME ZONE,
+			correlation_id VARCHAR(255)
 		)
 	`)
 	require.NoError(t, err)
@@ -68,6 +68,9 @@ type Job struct {
 	LockedBy *string `db:"locked_by" json:"locked_by,omitempty"`
 	// LockedAt is when the job was locked for processing
 	LockedAt *time.Time `db:"locked_at" json:"locked_at,omitempty"`
+	// CorrelationID is the ID of the request that enqueued the job, empty
+	// when no request did
+	CorrelationID string `db:"correlation_id" json:"correlation_id,omitempty"`
 }
 
 // NewJob creates a new job with default values
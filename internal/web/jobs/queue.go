@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
 )
 
@@ -20,8 +21,50 @@ func NewQueue(db *sql.DB) *Queue {
 	return &Queue{db: db}
 }
 
-// Enqueue adds a new job to the queue
+// CreateTable creates the jobs table and its dequeue index if they do not
+// exist, for applications that don't run the migrations in migrations/
+func (q *Queue) CreateTable(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS jobs (
+			id UUID PRIMARY KEY,
+			queue VARCHAR(255) NOT NULL,
+			type VARCHAR(255) NOT NULL,
+			payload JSONB NOT NULL,
+			status VARCHAR(50) NOT NULL,
+			priority INTEGER NOT NULL DEFAULT 50,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			max_attempts INTEGER NOT NULL DEFAULT 3,
+			error TEXT,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			started_at TIMESTAMP WITH TIME ZONE,
+			completed_at TIMESTAMP WITH TIME ZONE,
+			locked_by VARCHAR(255),
+			locked_at TIMESTAMP WITH TIME ZONE,
+			correlation_id VARCHAR(255)
+		)`,
+		// Tables created before jobs recorded the request that enqueued them
+		`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(255)`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_dequeue ON jobs (queue, status, run_at, priority DESC, created_at ASC)
+		WHERE status = 'pending'`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := q.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create jobs table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Enqueue adds a new job to the queue. A job without a CorrelationID takes
+// the request ID ctx carries, if any.
 func (q *Queue) Enqueue(ctx context.Context, job *Job) error {
+	if job.CorrelationID == "" {
+		job.CorrelationID = middleware.GetReqID(ctx)
+	}
+
 	payloadJSON, err := json.Marshal(job.Payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal payload: %w", err)
@@ -30,13 +73,14 @@ func (q *Queue) Enqueue(ctx context.Context, job *Job) error {
 	query := `
 		INSERT INTO jobs (
 			id, queue, type, payload, status, priority,
-			attempts, max_attempts, created_at, run_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			attempts, max_attempts, created_at, run_at, correlation_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
 	_, err = q.db.ExecContext(ctx, query,
 		job.ID, job.Queue, job.Type, payloadJSON, job.Status, job.Priority,
 		job.Attempts, job.MaxAttempts, job.CreatedAt, job.RunAt,
+		sql.NullString{String: job.CorrelationID, Valid: job.CorrelationID != ""},
 	)
 
 	if err != nil {
@@ -74,12 +118,13 @@ func (q *Queue) Dequeue(ctx context.Context, workerID string, queueName string)
 			LIMIT 1
 		)
 		RETURNING id, queue, type, payload, status, priority, attempts, max_attempts,
-		          error, created_at, run_at, started_at, completed_at, locked_by, locked_at
+		          error, created_at, run_at, started_at, completed_at, locked_by, locked_at, correlation_id
 	`
 
 	now := time.Now()
 	var job Job
 	var payloadJSON []byte
+	var correlationID sql.NullString
 
 	err := q.db.QueryRowContext(ctx, query,
 		JobStatusRunning, workerID, now, now, // SET values
@@ -87,7 +132,7 @@ func (q *Queue) Dequeue(ctx context.Context, workerID string, queueName string)
 	).Scan(
 		&job.ID, &job.Queue, &job.Type, &payloadJSON, &job.Status, &job.Priority,
 		&job.Attempts, &job.MaxAttempts, &job.Error, &job.CreatedAt, &job.RunAt,
-		&job.StartedAt, &job.CompletedAt, &job.LockedBy, &job.LockedAt,
+		&job.StartedAt, &job.CompletedAt, &job.LockedBy, &job.LockedAt, &correlationID,
 	)
 
 	if err == sql.ErrNoRows {
@@ -97,6 +142,8 @@ func (q *Queue) Dequeue(ctx context.Context, workerID string, queueName string)
 		return nil, fmt.Errorf("failed to dequeue job: %w", err)
 	}
 
+	job.CorrelationID = correlationID.String
+
 	// Unmarshal payload
 	if err := json.Unmarshal(payloadJSON, &job.Payload); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
@@ -216,18 +263,19 @@ func (q *Queue) Cancel(ctx context.Context, jobID uuid.UUID) error {
 func (q *Queue) GetJob(ctx context.Context, jobID uuid.UUID) (*Job, error) {
 	query := `
 		SELECT id, queue, type, payload, status, priority, attempts, max_attempts,
-		       error, created_at, run_at, started_at, completed_at, locked_by, locked_at
+		       error, created_at, run_at, started_at, completed_at, locked_by, locked_at, correlation_id
 		FROM jobs
 		WHERE id = $1
 	`
 
 	var job Job
 	var payloadJSON []byte
+	var correlationID sql.NullString
 
 	err := q.db.QueryRowContext(ctx, query, jobID).Scan(
 		&job.ID, &job.Queue, &job.Type, &payloadJSON, &job.Status, &job.Priority,
 		&job.Attempts, &job.MaxAttempts, &job.Error, &job.CreatedAt, &job.RunAt,
-		&job.StartedAt, &job.CompletedAt, &job.LockedBy, &job.LockedAt,
+		&job.StartedAt, &job.CompletedAt, &job.LockedBy, &job.LockedAt, &correlationID,
 	)
 
 	if err == sql.ErrNoRows {
@@ -237,6 +285,8 @@ func (q *Queue) GetJob(ctx context.Context, jobID uuid.UUID) (*Job, error) {
 		return nil, fmt.Errorf("failed to get job: %w", err)
 	}
 
+	job.CorrelationID = correlationID.String
+
 	// Unmarshal payload
 	if err := json.Unmarshal(payloadJSON, &job.Payload); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
@@ -249,7 +299,7 @@ func (q *Queue) GetJob(ctx context.Context, jobID uuid.UUID) (*Job, error) {
 func (q *Queue) ListJobs(ctx context.Context, queueName string, status JobStatus, limit int) ([]*Job, error) {
 	query := `
 		SELECT id, queue, type, payload, status, priority, attempts, max_attempts,
-		       error, created_at, run_at, started_at, completed_at, locked_by, locked_at
+		       error, created_at, run_at, started_at, completed_at, locked_by, locked_at, correlation_id
 		FROM jobs
 		WHERE ($1 = '' OR queue = $1)
 		  AND ($2 = '' OR status = $2)
@@ -267,16 +317,19 @@ func (q *Queue) ListJobs(ctx context.Context, queueName string, status JobStatus
 	for rows.Next() {
 		var job Job
 		var payloadJSON []byte
+		var correlationID sql.NullString
 
 		err := rows.Scan(
 			&job.ID, &job.Queue, &job.Type, &payloadJSON, &job.Status, &job.Priority,
 			&job.Attempts, &job.MaxAttempts, &job.Error, &job.CreatedAt, &job.RunAt,
-			&job.StartedAt, &job.CompletedAt, &job.LockedBy, &job.LockedAt,
+			&job.StartedAt, &job.CompletedAt, &job.LockedBy, &job.LockedAt, &correlationID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan job: %w", err)
 		}
 
+		job.CorrelationID = correlationID.String
+
 		// Unmarshal payload
 		if err := json.Unmarshal(payloadJSON, &job.Payload); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -28,6 +29,19 @@ func TestNewQueue(t *testing.T) {
 	assert.NotNil(t, queue.db)
 }
 
+func TestCreateTable(t *testing.T) {
+	db, mock, queue := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS jobs`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS correlation_id`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE INDEX IF NOT EXISTS idx_jobs_dequeue`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := queue.CreateTable(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestEnqueue(t *testing.T) {
 	db, mock, queue := setupMockDB(t)
 	defer db.Close()
@@ -38,12 +52,33 @@ func TestEnqueue(t *testing.T) {
 	mock.ExpectExec(`INSERT INTO jobs`).
 		WithArgs(
 			job.ID, job.Queue, job.Type, sqlmock.AnyArg(), job.Status, job.Priority,
-			job.Attempts, job.MaxAttempts, job.CreatedAt, job.RunAt,
+			job.Attempts, job.MaxAttempts, job.CreatedAt, job.RunAt, nil,
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := queue.Enqueue(ctx, job)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueueCorrelationID(t *testing.T) {
+	db, mock, queue := setupMockDB(t)
+	defer db.Close()
+
+	// A job enqueued while serving a request records the request's ID
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-1")
+	job := NewJob("default", "test.job", map[string]interface{}{"key": "value"})
+
+	mock.ExpectExec(`INSERT INTO jobs`).
+		WithArgs(
+			job.ID, job.Queue, job.Type, sqlmock.AnyArg(), job.Status, job.Priority,
+			job.Attempts, job.MaxAttempts, job.CreatedAt, job.RunAt, "req-1",
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err := queue.Enqueue(ctx, job)
 	assert.NoError(t, err)
+	assert.Equal(t, "req-1", job.CorrelationID)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -57,7 +92,7 @@ func TestEnqueueWithPriority(t *testing.T) {
 	mock.ExpectExec(`INSERT INTO jobs`).
 		WithArgs(
 			job.ID, job.Queue, job.Type, sqlmock.AnyArg(), job.Status, PriorityUrgent,
-			job.Attempts, job.MaxAttempts, job.CreatedAt, job.RunAt,
+			job.Attempts, job.MaxAttempts, job.CreatedAt, job.RunAt, nil,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -78,7 +113,7 @@ func TestSchedule(t *testing.T) {
 	mock.ExpectExec(`INSERT INTO jobs`).
 		WithArgs(
 			job.ID, job.Queue, job.Type, sqlmock.AnyArg(), job.Status, job.Priority,
-			job.Attempts, job.MaxAttempts, job.CreatedAt, runAt,
+			job.Attempts, job.MaxAttempts, job.CreatedAt, runAt, nil,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -99,10 +134,10 @@ func TestDequeue(t *testing.T) {
 
 	rows := sqlmock.NewRows([]string{
 		"id", "queue", "type", "payload", "status", "priority", "attempts", "max_attempts",
-		"error", "created_at", "run_at", "started_at", "completed_at", "locked_by", "locked_at",
+		"error", "created_at", "run_at", "started_at", "completed_at", "locked_by", "locked_at", "correlation_id",
 	}).AddRow(
 		jobID, "default", "test.job", []byte(`{"key":"value"}`), JobStatusRunning, PriorityNormal,
-		1, 3, nil, time.Now(), time.Now(), time.Now(), nil, workerID, time.Now(),
+		1, 3, nil, time.Now(), time.Now(), time.Now(), nil, workerID, time.Now(), "req-1",
 	)
 
 	mock.ExpectQuery(`UPDATE jobs`).
@@ -118,6 +153,7 @@ func TestDequeue(t *testing.T) {
 	assert.Equal(t, jobID, job.ID)
 	assert.Equal(t, "test.job", job.Type)
 	assert.Equal(t, "value", job.Payload["key"])
+	assert.Equal(t, "req-1", job.CorrelationID)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -237,10 +273,10 @@ func TestGetJob(t *testing.T) {
 
 	rows := sqlmock.NewRows([]string{
 		"id", "queue", "type", "payload", "status", "priority", "attempts", "max_attempts",
-		"error", "created_at", "run_at", "started_at", "completed_at", "locked_by", "locked_at",
+		"error", "created_at", "run_at", "started_at", "completed_at", "locked_by", "locked_at", "correlation_id",
 	}).AddRow(
 		jobID, "default", "test.job", []byte(`{"key":"value"}`), JobStatusPending, PriorityNormal,
-		0, 3, nil, time.Now(), time.Now(), nil, nil, nil, nil,
+		0, 3, nil, time.Now(), time.Now(), nil, nil, nil, nil, nil,
 	)
 
 	mock.ExpectQuery(`SELECT (.+) FROM jobs WHERE id`).
@@ -264,10 +300,10 @@ func TestListJobs(t *testing.T) {
 
 	rows := sqlmock.NewRows([]string{
 		"id", "queue", "type", "payload", "status", "priority", "attempts", "max_attempts",
-		"error", "created_at", "run_at", "started_at", "completed_at", "locked_by", "locked_at",
+		"error", "created_at", "run_at", "started_at", "completed_at", "locked_by", "locked_at", "correlation_id",
 	}).
-		AddRow(uuid.New(), "default", "test.job1", []byte(`{}`), JobStatusPending, PriorityNormal, 0, 3, nil, time.Now(), time.Now(), nil, nil, nil, nil).
-		AddRow(uuid.New(), "default", "test.job2", []byte(`{}`), JobStatusPending, PriorityHigh, 0, 3, nil, time.Now(), time.Now(), nil, nil, nil, nil)
+		AddRow(uuid.New(), "default", "test.job1", []byte(`{}`), JobStatusPending, PriorityNormal, 0, 3, nil, time.Now(), time.Now(), nil, nil, nil, nil, nil).
+		AddRow(uuid.New(), "default", "test.job2", []byte(`{}`), JobStatusPending, PriorityHigh, 0, 3, nil, time.Now(), time.Now(), nil, nil, nil, nil, nil)
 
 	mock.ExpectQuery(`SELECT (.+) FROM jobs`).
 		WithArgs(queueName, "", 10).
@@ -394,10 +430,10 @@ func TestDequeueMarshalError(t *testing.T) {
 	// Return invalid JSON
 	rows := sqlmock.NewRows([]string{
 		"id", "queue", "type", "payload", "status", "priority", "attempts", "max_attempts",
-		"error", "created_at", "run_at", "started_at", "completed_at", "locked_by", "locked_at",
+		"error", "created_at", "run_at", "started_at", "completed_at", "locked_by", "locked_at", "correlation_id",
 	}).AddRow(
 		jobID, "default", "test.job", []byte(`{invalid json`), JobStatusRunning, PriorityNormal,
-		1, 3, nil, time.Now(), time.Now(), time.Now(), nil, workerID, time.Now(),
+		1, 3, nil, time.Now(), time.Now(), time.Now(), nil, workerID, time.Now(), nil,
 	)
 
 	mock.ExpectQuery(`UPDATE jobs`).
@@ -489,10 +525,10 @@ func TestGetJobMarshalError(t *testing.T) {
 	// Return invalid JSON
 	rows := sqlmock.NewRows([]string{
 		"id", "queue", "type", "payload", "status", "priority", "attempts", "max_attempts",
-		"error", "created_at", "run_at", "started_at", "completed_at", "locked_by", "locked_at",
+		"error", "created_at", "run_at", "started_at", "completed_at", "locked_by", "locked_at", "correlation_id",
 	}).AddRow(
 		jobID, "default", "test.job", []byte(`{invalid`), JobStatusPending, PriorityNormal,
-		0, 3, nil, time.Now(), time.Now(), nil, nil, nil, nil,
+		0, 3, nil, time.Now(), time.Now(), nil, nil, nil, nil, nil,
 	)
 
 	mock.ExpectQuery(`SELECT (.+) FROM jobs WHERE id`).
@@ -515,10 +551,10 @@ func TestListJobsMarshalError(t *testing.T) {
 	// Return invalid JSON
 	rows := sqlmock.NewRows([]string{
 		"id", "queue", "type", "payload", "status", "priority", "attempts", "max_attempts",
-		"error", "created_at", "run_at", "started_at", "completed_at", "locked_by", "locked_at",
+		"error", "created_at", "run_at", "started_at", "completed_at", "locked_by", "locked_at", "correlation_id",
 	}).AddRow(
 		uuid.New(), "default", "test.job", []byte(`{bad json}`), JobStatusPending, PriorityNormal,
-		0, 3, nil, time.Now(), time.Now(), nil, nil, nil, nil,
+		0, 3, nil, time.Now(), time.Now(), nil, nil, nil, nil, nil,
 	)
 
 	mock.ExpectQuery(`SELECT (.+) FROM jobs`).
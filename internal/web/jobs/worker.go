@@ -6,6 +6,8 @@ import (
 	"log"
 	"sync"
 	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 // Handler is a function that processes a job's payload
@@ -120,8 +122,8 @@ func (w *Worker) run(ctx context.Context, metrics *Metrics) {
 // processJob handles a single job execution
 func (w *Worker) processJob(ctx context.Context, job *Job, metrics *Metrics) {
 	startTime := time.Now()
-	log.Printf("Worker %s processing job %s (type: %s, attempt: %d/%d)",
-		w.ID, job.ID, job.Type, job.Attempts, job.MaxAttempts)
+	log.Printf("Worker %s processing job %s (type: %s, attempt: %d/%d)%s",
+		w.ID, job.ID, job.Type, job.Attempts, job.MaxAttempts, correlation(job))
 
 	// Get handler for this job type
 	handler, err := w.handlers.Get(job.Type)
@@ -133,8 +135,13 @@ func (w *Worker) processJob(ctx context.Context, job *Job, metrics *Metrics) {
 		return
 	}
 
-	// Execute the handler
-	err = handler(ctx, job.Payload)
+	// Execute the handler with the ID of the request that enqueued the job,
+	// so its logs and outbound requests carry it
+	handlerCtx := ctx
+	if job.CorrelationID != "" {
+		handlerCtx = context.WithValue(ctx, middleware.RequestIDKey, job.CorrelationID)
+	}
+	err = handler(handlerCtx, job.Payload)
 
 	duration := time.Since(startTime)
 
@@ -156,8 +163,8 @@ func (w *Worker) processJob(ctx context.Context, job *Job, metrics *Metrics) {
 // handleJobError processes a job failure and determines retry logic
 func (w *Worker) handleJobError(ctx context.Context, job *Job, err error, metrics *Metrics, duration time.Duration) {
 	errMsg := err.Error()
-	log.Printf("Worker %s: job %s failed: %v (attempt %d/%d)",
-		w.ID, job.ID, err, job.Attempts, job.MaxAttempts)
+	log.Printf("Worker %s: job %s failed: %v (attempt %d/%d)%s",
+		w.ID, job.ID, err, job.Attempts, job.MaxAttempts, correlation(job))
 
 	// Check if job should be retried
 	if job.IsRetryable() {
@@ -185,6 +192,15 @@ func (w *Worker) handleJobError(ctx context.Context, job *Job, err error, metric
 	metrics.RecordFailure(job.Type, duration)
 }
 
+// correlation returns the request_id=<id> suffix of a job's log lines, or ""
+// when no request enqueued it
+func correlation(job *Job) string {
+	if job.CorrelationID == "" {
+		return ""
+	}
+	return " request_id=" + job.CorrelationID
+}
+
 // HandlerRegistry manages job type handlers
 type HandlerRegistry struct {
 	handlers map[string]Handler
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
@@ -115,6 +116,37 @@ func TestWorkerProcessJobSuccess(t *testing.T) {
 	assert.Equal(t, int64(0), stats.Failed)
 }
 
+func TestWorkerProcessJobCorrelationID(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+
+	worker := &Worker{
+		ID:        "worker-1",
+		queue:     NewQueue(db),
+		handlers:  NewHandlerRegistry(),
+		queueName: "default",
+	}
+
+	// The handler runs with the ID of the request that enqueued the job
+	var requestID string
+	worker.handlers.Register("test.job", func(ctx context.Context, payload map[string]interface{}) error {
+		requestID = middleware.GetReqID(ctx)
+		return nil
+	})
+
+	job := NewJob("default", "test.job", map[string]interface{}{})
+	job.CorrelationID = "req-1"
+
+	mock.ExpectExec(`UPDATE jobs`).
+		WithArgs(JobStatusCompleted, sqlmock.AnyArg(), job.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	worker.processJob(context.Background(), job, NewMetrics())
+
+	assert.Equal(t, "req-1", requestID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestWorkerProcessJobFailure(t *testing.T) {
 	db, mock, _ := setupMockDB(t)
 	defer db.Close()
@@ -0,0 +1,15 @@
+-- Migration: Record the request that enqueued each job
+-- Description: correlation_id holds the request ID of the request whose work queued the job,
+-- so a request's background side effects can be found by its X-Request-Id
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(255);
+
+-- Index for finding the jobs a request caused
+CREATE INDEX IF NOT EXISTS idx_jobs_correlation_id ON jobs (correlation_id)
+WHERE correlation_id IS NOT NULL;
+
+COMMENT ON COLUMN jobs.correlation_id IS 'Request ID of the request that enqueued the job';
+
+-- Rollback migration
+-- DROP INDEX IF EXISTS idx_jobs_correlation_id;
+-- ALTER TABLE jobs DROP COLUMN IF EXISTS correlation_id;
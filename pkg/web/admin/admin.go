@@ -0,0 +1,230 @@
+// Package admin serves a browser UI for exploring and editing the records of a
+// generated application. The UI is a static single-page app embedded in the
+// binary; forms are rendered from the application's introspection metadata and
+// every change goes through the application's own REST API, so validations and
+// hooks run exactly as they do for any other client.
+//
+// The admin UI performs no authentication of its own. Only enable it where the
+// API itself is protected, or in development.
+//
+// Example:
+//
+//	if admin.Enabled() {
+//		ui, err := admin.Handler(admin.Options{Path: "/admin", Metadata: introspection.Metadata})
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		r.Mount("/admin", ui)
+//	}
+package admin
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// EnvVar disables the admin UI when set to "false", "0" or "off", so a build
+// with the UI can still be deployed where it must not be exposed.
+const EnvVar = "CONDUIT_ADMIN"
+
+//go:embed static
+var staticFiles embed.FS
+
+// Options configure the admin handler.
+type Options struct {
+	// Path is where the handler is mounted, e.g. "/admin"
+	Path string
+	// Metadata is the introspection metadata JSON embedded in the application
+	Metadata string
+	// APIPrefix is server.api_prefix, prepended to every resource route
+	APIPrefix string
+}
+
+// Schema describes the resources the UI can manage.
+type Schema struct {
+	Resources []Resource `json:"resources"`
+}
+
+// Resource is a resource and the collection route it is served from.
+type Resource struct {
+	Name   string  `json:"name"`
+	Path   string  `json:"path"`
+	Fields []Field `json:"fields"`
+}
+
+// Field carries what the UI needs to render and validate a form input.
+type Field struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`                // Base type such as "string", "int" or "enum"
+	Required  bool     `json:"required"`            // Non-nullable without a default
+	Generated bool     `json:"generated,omitempty"` // Assigned by the server (@primary, @auto, @auto_update)
+	Enum      []string `json:"enum,omitempty"`      // Allowed values of an enum
+	Min       *float64 `json:"min,omitempty"`       // Minimum length or value from @min
+	Max       *float64 `json:"max,omitempty"`       // Maximum length or value from @max
+	Pattern   string   `json:"pattern,omitempty"`   // Regular expression from @pattern
+	Default   string   `json:"default,omitempty"`   // Default value expression
+}
+
+// Enabled reports whether the admin UI should be served, based on
+// CONDUIT_ADMIN. It is enabled unless explicitly turned off.
+func Enabled() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(EnvVar))) {
+	case "false", "0", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+// Handler returns the admin UI handler. It serves the SPA at opts.Path and the
+// schema it renders forms from at opts.Path + "/schema.json".
+func Handler(opts Options) (http.Handler, error) {
+	schema, err := ParseSchema(opts.Metadata, opts.APIPrefix)
+	if err != nil {
+		return nil, err
+	}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("admin: failed to encode schema: %w", err)
+	}
+
+	static, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		return nil, err
+	}
+	files := http.FileServer(http.FS(static))
+	base := strings.TrimSuffix(opts.Path, "/")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		rest := strings.TrimPrefix(r.URL.Path, base)
+		switch rest {
+		case "":
+			// Relative asset URLs resolve against the trailing slash
+			http.Redirect(w, r, base+"/", http.StatusMovedPermanently)
+		case "/schema.json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Write(schemaJSON)
+		default:
+			req := r.Clone(r.Context())
+			req.URL.Path = path.Clean("/" + strings.TrimPrefix(rest, "/"))
+			files.ServeHTTP(w, req)
+		}
+	}), nil
+}
+
+// metadataDocument is the subset of the introspection metadata the UI uses
+type metadataDocument struct {
+	Resources []struct {
+		Name   string `json:"name"`
+		Fields []struct {
+			Name        string   `json:"name"`
+			Type        string   `json:"type"`
+			Nullable    bool     `json:"nullable"`
+			Constraints []string `json:"constraints"`
+			Default     string   `json:"default"`
+		} `json:"fields"`
+	} `json:"resources"`
+	Routes []struct {
+		Path      string `json:"path"`
+		Resource  string `json:"resource"`
+		Operation string `json:"operation"`
+	} `json:"routes"`
+}
+
+// ParseSchema converts introspection metadata into the schema served to the
+// UI. Resources without a list route are omitted because they cannot be
+// browsed.
+func ParseSchema(metadataJSON, apiPrefix string) (*Schema, error) {
+	var doc metadataDocument
+	if err := json.Unmarshal([]byte(metadataJSON), &doc); err != nil {
+		return nil, fmt.Errorf("admin: invalid metadata: %w", err)
+	}
+
+	collections := make(map[string]string)
+	for _, route := range doc.Routes {
+		if route.Operation == "list" {
+			collections[route.Resource] = route.Path
+		}
+	}
+
+	schema := &Schema{Resources: make([]Resource, 0, len(doc.Resources))}
+	for _, res := range doc.Resources {
+		collection, ok := collections[res.Name]
+		if !ok {
+			continue
+		}
+
+		resource := Resource{
+			Name:   res.Name,
+			Path:   apiPrefix + collection,
+			Fields: make([]Field, 0, len(res.Fields)),
+		}
+		for _, f := range res.Fields {
+			field := Field{
+				Name:     f.Name,
+				Default:  f.Default,
+				Required: !f.Nullable && f.Default == "",
+			}
+			field.Type, field.Enum = parseType(f.Type)
+
+			for _, constraint := range f.Constraints {
+				name, arg := splitConstraint(constraint)
+				switch name {
+				case "primary", "auto", "auto_update":
+					field.Generated = true
+					field.Required = false
+				case "min":
+					field.Min = parseNumber(arg)
+				case "max":
+					field.Max = parseNumber(arg)
+				case "pattern":
+					field.Pattern = strings.Trim(arg, `"`)
+				}
+			}
+			resource.Fields = append(resource.Fields, field)
+		}
+		schema.Resources = append(schema.Resources, resource)
+	}
+	return schema, nil
+}
+
+// parseType strips the nullability marker from a metadata type and splits out
+// enum values, so "enum[draft|published]!" becomes "enum", [draft published]
+func parseType(t string) (string, []string) {
+	t = strings.TrimRight(t, "!?")
+	if strings.HasPrefix(t, "enum[") && strings.HasSuffix(t, "]") {
+		return "enum", strings.Split(t[len("enum["):len(t)-1], "|")
+	}
+	return t, nil
+}
+
+// splitConstraint splits a formatted constraint such as "min(5)" into its name
+// and argument text
+func splitConstraint(constraint string) (string, string) {
+	name, arg, ok := strings.Cut(strings.TrimPrefix(constraint, "@"), "(")
+	if !ok {
+		return name, ""
+	}
+	return name, strings.TrimSuffix(arg, ")")
+}
+
+func parseNumber(s string) *float64 {
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testMetadata = `{
+  "version": "1.0.0",
+  "resources": [
+    {
+      "name": "Post",
+      "fields": [
+        {"name": "id", "type": "uuid!", "nullable": false, "constraints": ["primary", "auto"]},
+        {"name": "title", "type": "string!", "nullable": false, "constraints": ["min(5)", "max(200)"]},
+        {"name": "status", "type": "enum[draft|published]!", "nullable": false, "default": "\"draft\""},
+        {"name": "slug", "type": "string?", "nullable": true, "constraints": ["pattern(\"^[a-z-]+$\")"]}
+      ]
+    },
+    {
+      "name": "AuditLog",
+      "fields": [{"name": "id", "type": "uuid!", "nullable": false}]
+    }
+  ],
+  "routes": [
+    {"method": "GET", "path": "/posts", "resource": "Post", "operation": "list"},
+    {"method": "GET", "path": "/posts/:id", "resource": "Post", "operation": "get"}
+  ]
+}`
+
+func TestParseSchema(t *testing.T) {
+	schema, err := ParseSchema(testMetadata, "/api")
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+
+	// AuditLog has no list route
+	if len(schema.Resources) != 1 {
+		t.Fatalf("expected 1 resource, got %d", len(schema.Resources))
+	}
+	post := schema.Resources[0]
+	if post.Name != "Post" || post.Path != "/api/posts" {
+		t.Errorf("resource = %s %s", post.Name, post.Path)
+	}
+
+	fields := make(map[string]Field)
+	for _, field := range post.Fields {
+		fields[field.Name] = field
+	}
+
+	if id := fields["id"]; !id.Generated || id.Required || id.Type != "uuid" {
+		t.Errorf("id = %+v", id)
+	}
+	if title := fields["title"]; !title.Required || title.Min == nil || *title.Min != 5 || title.Max == nil || *title.Max != 200 {
+		t.Errorf("title = %+v", title)
+	}
+	if status := fields["status"]; status.Type != "enum" || strings.Join(status.Enum, ",") != "draft,published" || status.Required {
+		t.Errorf("status = %+v", status)
+	}
+	if slug := fields["slug"]; slug.Required || slug.Pattern != "^[a-z-]+$" {
+		t.Errorf("slug = %+v", slug)
+	}
+}
+
+func TestParseSchema_InvalidMetadata(t *testing.T) {
+	if _, err := ParseSchema("not json", ""); err == nil {
+		t.Error("expected an error for invalid metadata")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	handler, err := Handler(Options{Path: "/admin", Metadata: testMetadata})
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	tests := []struct {
+		method   string
+		path     string
+		status   int
+		contains string
+	}{
+		{http.MethodGet, "/admin", http.StatusMovedPermanently, ""},
+		{http.MethodGet, "/admin/", http.StatusOK, "<title>Conduit Admin</title>"},
+		{http.MethodGet, "/admin/app.js", http.StatusOK, "schema.json"},
+		{http.MethodGet, "/admin/schema.json", http.StatusOK, `"path":"/posts"`},
+		{http.MethodGet, "/admin/missing.js", http.StatusNotFound, ""},
+		{http.MethodPost, "/admin/schema.json", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.contains != "" && !strings.Contains(rec.Body.String(), tt.contains) {
+				t.Errorf("body does not contain %q:\n%s", tt.contains, rec.Body.String())
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/schema.json", nil))
+	var schema Schema
+	if err := json.Unmarshal(rec.Body.Bytes(), &schema); err != nil {
+		t.Fatalf("schema.json is not valid JSON: %v", err)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	for value, want := range map[string]bool{"": true, "on": true, "off": false, "0": false, "FALSE": false} {
+		t.Setenv(EnvVar, value)
+		if got := Enabled(); got != want {
+			t.Errorf("Enabled() with %s=%q = %v, want %v", EnvVar, value, got, want)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Conduit admin UI. Renders list and form views from schema.json and performs
+// CRUD through the application's own API.
+(function () {
+  'use strict';
+
+  const PAGE_SIZE = 25;
+  const content = document.getElementById('content');
+  const notice = document.getElementById('notice');
+  let schema = { resources: [] };
+  let flash = null; // Notice shown after the next navigation
+
+  function el(tag, attrs, children) {
+    const node = document.createElement(tag);
+    Object.entries(attrs || {}).forEach(([key, value]) => {
+      if (key === 'text') {
+        node.textContent = value;
+      } else if (key.startsWith('on')) {
+        node.addEventListener(key.slice(2), value);
+      } else if (value !== undefined && value !== null && value !== false) {
+        node.setAttribute(key, value === true ? '' : value);
+      }
+    });
+    (children || []).forEach((child) => node.appendChild(child));
+    return node;
+  }
+
+  function showNotice(message, kind) {
+    notice.textContent = message;
+    notice.className = kind;
+    notice.hidden = !message;
+  }
+
+  async function api(method, path, body) {
+    const res = await fetch(path, {
+      method: method,
+      headers: body ? { 'Content-Type': 'application/json', Accept: 'application/json' } : { Accept: 'application/json' },
+      body: body ? JSON.stringify(body) : undefined,
+    });
+    const text = await res.text();
+    let data = null;
+    try {
+      data = text ? JSON.parse(text) : null;
+    } catch (e) {
+      data = text;
+    }
+    if (!res.ok) {
+      const message = data && (data.message || data.error) ? data.message || data.error : res.status + ' ' + res.statusText;
+      throw new Error(message);
+    }
+    return data;
+  }
+
+  function findResource(name) {
+    return schema.resources.find((resource) => resource.name === name);
+  }
+
+  function formatValue(value) {
+    if (value === null || value === undefined) {
+      return '';
+    }
+    return typeof value === 'object' ? JSON.stringify(value) : String(value);
+  }
+
+  function renderSidebar(active) {
+    const list = document.getElementById('resources');
+    list.replaceChildren(...schema.resources.map((resource) =>
+      el('li', {}, [el('a', { href: '#/' + resource.name, class: resource.name === active ? 'active' : null, text: resource.name })])
+    ));
+  }
+
+  async function renderList(resource, page) {
+    const offset = (page - 1) * PAGE_SIZE;
+    const records = await api('GET', resource.path + '?limit=' + PAGE_SIZE + '&offset=' + offset);
+    const columns = resource.fields.map((field) => field.name);
+
+    const rows = (records || []).map((record) =>
+      el('tr', { class: 'row', onclick: () => { location.hash = '#/' + resource.name + '/' + record.id; } },
+        columns.map((column) => el('td', { text: formatValue(record[column]), title: formatValue(record[column]) })))
+    );
+
+    content.replaceChildren(
+      el('h2', { text: resource.name }),
+      el('div', { class: 'toolbar' }, [
+        el('a', { class: 'button primary', href: '#/' + resource.name + '/new', text: 'New ' + resource.name }),
+        el('span', { class: 'spacer' }),
+        el('button', { disabled: page <= 1, text: 'Previous', onclick: () => { location.hash = '#/' + resource.name + '?page=' + (page - 1); } }),
+        el('span', { class: 'muted', text: 'Page ' + page }),
+        el('button', { disabled: rows.length < PAGE_SIZE, text: 'Next', onclick: () => { location.hash = '#/' + resource.name + '?page=' + (page + 1); } }),
+      ]),
+      rows.length
+        ? el('table', {}, [
+          el('thead', {}, [el('tr', {}, columns.map((column) => el('th', { text: column })))]),
+          el('tbody', {}, rows),
+        ])
+        : el('p', { class: 'muted', text: 'No records.' })
+    );
+  }
+
+  function toLocalDateTime(value) {
+    const date = new Date(value);
+    if (isNaN(date)) {
+      return '';
+    }
+    const pad = (n) => String(n).padStart(2, '0');
+    return date.getFullYear() + '-' + pad(date.getMonth() + 1) + '-' + pad(date.getDate()) +
+      'T' + pad(date.getHours()) + ':' + pad(date.getMinutes());
+  }
+
+  function hint(field) {
+    const parts = [field.type];
+    if (field.required) parts.push('required');
+    if (field.min !== undefined) parts.push('min ' + field.min);
+    if (field.max !== undefined) parts.push('max ' + field.max);
+    if (field.default) parts.push('default ' + field.default);
+    return parts.join(', ');
+  }
+
+  // input builds a form control whose native validation mirrors the field's
+  // constraints; the server remains the source of truth
+  function input(field, value) {
+    const common = { name: field.name, required: field.required && field.type !== 'bool' };
+    switch (field.type) {
+      case 'enum':
+        return el('select', common, [el('option', { value: '', text: '' })].concat(field.enum.map((option) =>
+          el('option', { value: option, text: option, selected: option === value }))));
+      case 'bool':
+        return el('input', Object.assign({}, common, { type: 'checkbox', checked: value === true }));
+      case 'int':
+      case 'float':
+        return el('input', Object.assign({}, common, {
+          type: 'number', step: field.type === 'int' ? '1' : 'any', min: field.min, max: field.max, value: formatValue(value),
+        }));
+      case 'timestamp':
+        return el('input', Object.assign({}, common, { type: 'datetime-local', value: value ? toLocalDateTime(value) : '' }));
+      case 'text':
+      case 'markdown':
+        return el('textarea', Object.assign({}, common, { minlength: field.min, maxlength: field.max }), [document.createTextNode(formatValue(value))]);
+      case 'json':
+        return el('textarea', common, [document.createTextNode(value === undefined ? '' : JSON.stringify(value, null, 2))]);
+      default:
+        return el('input', Object.assign({}, common, {
+          type: field.name.includes('email') ? 'email' : 'text',
+          minlength: field.min, maxlength: field.max, pattern: field.pattern, value: formatValue(value),
+        }));
+    }
+  }
+
+  // readForm converts form controls back into typed JSON values, leaving out
+  // empty optional fields so defaults apply
+  function readForm(form, fields) {
+    const body = {};
+    fields.forEach((field) => {
+      const control = form.elements[field.name];
+      if (field.type === 'bool') {
+        body[field.name] = control.checked;
+        return;
+      }
+      const raw = control.value;
+      if (raw === '') {
+        if (!field.required && field.default === undefined) body[field.name] = null;
+        return;
+      }
+      switch (field.type) {
+        case 'int':
+          body[field.name] = parseInt(raw, 10);
+          break;
+        case 'float':
+          body[field.name] = parseFloat(raw);
+          break;
+        case 'timestamp':
+          body[field.name] = new Date(raw).toISOString();
+          break;
+        case 'json':
+          body[field.name] = JSON.parse(raw);
+          break;
+        default:
+          body[field.name] = raw;
+      }
+    });
+    return body;
+  }
+
+  async function renderForm(resource, id) {
+    const record = id ? await api('GET', resource.path + '/' + encodeURIComponent(id)) : {};
+    const editable = resource.fields.filter((field) => !field.generated);
+
+    const form = el('form', {}, editable.map((field) =>
+      el('label', {}, [
+        document.createTextNode(field.name),
+        el('span', { class: 'hint', text: '(' + hint(field) + ')' }),
+        input(field, record[field.name]),
+      ])
+    ));
+
+    const generated = resource.fields.filter((field) => field.generated && record[field.name] !== undefined);
+    if (generated.length) {
+      form.prepend(el('p', { class: 'muted', text: generated.map((field) => field.name + ': ' + formatValue(record[field.name])).join(' · ') }));
+    }
+
+    const actions = el('div', { class: 'toolbar' }, [
+      el('button', { type: 'submit', class: 'primary', text: id ? 'Save' : 'Create' }),
+      el('a', { class: 'button', href: '#/' + resource.name, text: 'Cancel' }),
+      el('span', { class: 'spacer' }),
+    ]);
+    if (id) {
+      actions.appendChild(el('button', {
+        type: 'button', class: 'danger', text: 'Delete', onclick: async () => {
+          if (!confirm('Delete this ' + resource.name + '?')) return;
+          try {
+            await api('DELETE', resource.path + '/' + encodeURIComponent(id));
+            flash = resource.name + ' deleted';
+            location.hash = '#/' + resource.name;
+          } catch (err) {
+            showNotice(err.message, 'error');
+          }
+        },
+      }));
+    }
+    form.appendChild(actions);
+
+    form.addEventListener('submit', async (event) => {
+      event.preventDefault();
+      try {
+        const body = readForm(form, editable);
+        if (id) {
+          await api('PATCH', resource.path + '/' + encodeURIComponent(id), body);
+          showNotice(resource.name + ' saved', 'success');
+        } else {
+          const created = await api('POST', resource.path, body);
+          flash = resource.name + ' created';
+          location.hash = '#/' + resource.name + '/' + created.id;
+        }
+      } catch (err) {
+        showNotice(err.message, 'error');
+      }
+    });
+
+    content.replaceChildren(el('h2', { text: (id ? 'Edit ' : 'New ') + resource.name }), form);
+  }
+
+  async function route() {
+    const [path, queryString] = location.hash.replace(/^#\/?/, '').split('?');
+    const [name, id] = path.split('/');
+    const resource = findResource(name) || schema.resources[0];
+    renderSidebar(resource && resource.name);
+
+    if (!resource) {
+      content.replaceChildren(el('p', { class: 'muted', text: 'No resources to manage.' }));
+      return;
+    }
+
+    try {
+      if (id === 'new') {
+        await renderForm(resource, null);
+      } else if (id) {
+        await renderForm(resource, decodeURIComponent(id));
+      } else {
+        const page = parseInt(new URLSearchParams(queryString).get('page'), 10) || 1;
+        await renderList(resource, page);
+      }
+    } catch (err) {
+      showNotice(err.message, 'error');
+    }
+  }
+
+  window.addEventListener('hashchange', () => {
+    showNotice(flash || '', 'success');
+    flash = null;
+    route();
+  });
+
+  api('GET', 'schema.json').then((data) => {
+    schema = data;
+    route();
+  }).catch((err) => showNotice('Failed to load schema: ' + err.message, 'error'));
+})();
@@ -0,0 +1,322 @@
+// Package bind decodes the request bodies of generated create, update and
+// patch handlers. The format is negotiated by Content-Type:
+//
+//	application/json                   (also when Content-Type is missing)
+//	application/x-www-form-urlencoded
+//	multipart/form-data                (file parts fill string and json fields)
+//	application/msgpack                (also application/x-msgpack)
+//
+// Every format is converted to the JSON document it stands for and decoded
+// with encoding/json, so models see the same values, and run the same
+// validations, whatever the client sent. Form values are strings; they are
+// typed by the model field they are decoded into.
+package bind
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types accepted for request bodies
+const (
+	MediaTypeJSON      = "application/json"
+	MediaTypeForm      = "application/x-www-form-urlencoded"
+	MediaTypeMultipart = "multipart/form-data"
+	MediaTypeMsgpack   = "application/msgpack"
+)
+
+// MaxBodySize is the largest request body read (10MB, like JSON:API bodies)
+const MaxBodySize = 10 << 20
+
+// ErrUnsupportedMediaType is returned for bodies in a format bind does not read.
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// MediaTypes lists the accepted media types, JSON first.
+func MediaTypes() []string {
+	return []string{MediaTypeJSON, MediaTypeForm, MediaTypeMultipart, MediaTypeMsgpack}
+}
+
+// Decode decodes r's body into dst, a pointer to a model.
+func Decode(r *http.Request, dst any) error {
+	data, err := JSON(r, dst)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// JSON returns r's body as the JSON object it stands for. model types form
+// values and may be a model or a pointer to one; keys missing from the body
+// are missing from the object, which keeps it usable as a merge patch.
+func JSON(r *http.Request, model any) ([]byte, error) {
+	mediaType := MediaTypeJSON
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+		}
+		mediaType = parsed
+	}
+
+	// A nil writer still limits the read and reports *http.MaxBytesError
+	r.Body = http.MaxBytesReader(nil, r.Body, MaxBodySize)
+	fields := jsonFields(reflect.TypeOf(model))
+
+	switch {
+	case mediaType == MediaTypeJSON || strings.HasSuffix(mediaType, "+json"):
+		return io.ReadAll(r.Body)
+
+	case mediaType == MediaTypeForm:
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		return fromValues(r.PostForm, nil, fields)
+
+	case mediaType == MediaTypeMultipart:
+		if err := r.ParseMultipartForm(MaxBodySize); err != nil {
+			return nil, err
+		}
+		defer r.MultipartForm.RemoveAll()
+		files, err := readFiles(r)
+		if err != nil {
+			return nil, err
+		}
+		return fromValues(r.MultipartForm.Value, files, fields)
+
+	case mediaType == MediaTypeMsgpack || mediaType == "application/x-msgpack" || mediaType == "application/vnd.msgpack":
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeMsgpack(body)
+		if err != nil {
+			return nil, err
+		}
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("msgpack body must be a map")
+		}
+		return fromMsgpack(object, fields)
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+	}
+}
+
+// StatusCode returns the HTTP status a Decode or JSON error is answered with:
+// 415 for unsupported formats, 413 for bodies over MaxBodySize and 400 for
+// malformed ones.
+func StatusCode(err error) int {
+	var maxBytesError *http.MaxBytesError
+	switch {
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.As(err, &maxBytesError):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// readFiles reads the first file of each multipart file field
+func readFiles(r *http.Request) (map[string][]byte, error) {
+	files := make(map[string][]byte, len(r.MultipartForm.File))
+	for key, headers := range r.MultipartForm.File {
+		if len(headers) == 0 {
+			continue
+		}
+		file, err := headers[0].Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[key] = content
+	}
+	return files, nil
+}
+
+var (
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	byteSlice       = reflect.TypeOf([]byte(nil))
+)
+
+// jsonFields maps the JSON names of a struct's fields to their types
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			for embedded, typ := range jsonFields(field.Type) {
+				fields[embedded] = typ
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// fromValues converts form values and file contents to a JSON object, typing
+// each by the field it is decoded into. Keys without a field are dropped.
+func fromValues(values map[string][]string, files map[string][]byte, fields map[string]reflect.Type) ([]byte, error) {
+	object := make(map[string]any, len(values)+len(files))
+	for key, vals := range values {
+		typ, ok := fields[key]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		value, err := formValue(typ, vals)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		object[key] = value
+	}
+	for key, content := range files {
+		typ, ok := fields[key]
+		if !ok {
+			continue
+		}
+		value, err := formValue(typ, []string{string(content)})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		object[key] = value
+	}
+	return json.Marshal(object)
+}
+
+// formValue types the string values of one form field for a field of type t
+func formValue(t reflect.Type, vals []string) (any, error) {
+	if t.Kind() == reflect.Pointer {
+		// An empty value clears a nullable field
+		if vals[0] == "" {
+			return nil, nil
+		}
+		t = t.Elem()
+	}
+	value := vals[0]
+
+	// uuid.UUID and time.Time parse text; GeoJSON types parse JSON
+	ptr := reflect.PointerTo(t)
+	if ptr.Implements(textUnmarshaler) {
+		return value, nil
+	}
+	if ptr.Implements(jsonUnmarshaler) {
+		return rawJSON(value)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Bool:
+		if value == "on" { // HTML checkboxes
+			return true, nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean %q", value)
+		}
+		return b, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", value)
+		}
+		return n, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", value)
+		}
+		return n, nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", value)
+		}
+		return f, nil
+	case reflect.Slice:
+		// json fields hold the document as bytes
+		if t == byteSlice {
+			return []byte(value), nil
+		}
+		items := make([]any, 0, len(vals))
+		for _, val := range vals {
+			item, err := formValue(t.Elem(), []string{val})
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return rawJSON(value)
+	}
+}
+
+// rawJSON passes a form value through as JSON
+func rawJSON(value string) (json.RawMessage, error) {
+	if !json.Valid([]byte(value)) {
+		return nil, fmt.Errorf("invalid JSON value")
+	}
+	return json.RawMessage(value), nil
+}
+
+// fromMsgpack converts a decoded msgpack map to a JSON object. msgpack
+// strings and documents sent for json fields become the field's bytes.
+func fromMsgpack(object map[string]any, fields map[string]reflect.Type) ([]byte, error) {
+	for key, value := range object {
+		typ, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		if typ != byteSlice {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			object[key] = []byte(v)
+		case map[string]any, []any:
+			doc, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			object[key] = doc
+		}
+	}
+	return json.Marshal(object)
+}
@@ -0,0 +1,270 @@
+package bind
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conduit-lang/conduit/pkg/web/geo"
+)
+
+// post mirrors a generated model
+type post struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Subtitle    *string    `json:"subtitle"`
+	Views       int64      `json:"views"`
+	Rating      float64    `json:"rating"`
+	Published   bool       `json:"published"`
+	PublishedAt time.Time  `json:"published_at"`
+	Metadata    []byte     `json:"metadata"`
+	Location    *geo.Point `json:"location"`
+	Score       *int64     `json:"score"`
+	secret      string
+}
+
+var postID = uuid.MustParse("6f1c7a4e-3b8a-4a5e-9d7e-2b1f0c9a8e11")
+
+func request(contentType string, body []byte) *http.Request {
+	req := httptest.NewRequest("POST", "/posts", bytes.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req
+}
+
+func checkPost(t *testing.T, p post) {
+	t.Helper()
+	if p.ID != postID || p.Title != "Hello" || p.Views != 42 || p.Rating != 4.5 || !p.Published {
+		t.Errorf("post = %+v", p)
+	}
+	if !p.PublishedAt.Equal(time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("published_at = %v", p.PublishedAt)
+	}
+	if string(p.Metadata) != `{"tags":["go"]}` {
+		t.Errorf("metadata = %s", p.Metadata)
+	}
+}
+
+func TestDecode_JSON(t *testing.T) {
+	body := `{"id":"` + postID.String() + `","title":"Hello","views":42,"rating":4.5,"published":true,` +
+		`"published_at":"2026-10-16T12:00:00Z","metadata":"eyJ0YWdzIjpbImdvIl19"}`
+
+	for _, contentType := range []string{"", "application/json", "application/json; charset=utf-8", "application/merge-patch+json"} {
+		var p post
+		if err := Decode(request(contentType, []byte(body)), &p); err != nil {
+			t.Fatalf("Decode(%q) error = %v", contentType, err)
+		}
+		checkPost(t, p)
+	}
+}
+
+func TestDecode_Form(t *testing.T) {
+	form := url.Values{
+		"id":           {postID.String()},
+		"title":        {"Hello"},
+		"subtitle":     {""},
+		"views":        {"42"},
+		"rating":       {"4.5"},
+		"published":    {"on"},
+		"published_at": {"2026-10-16T12:00:00Z"},
+		"metadata":     {`{"tags":["go"]}`},
+		"location":     {`{"type":"Point","coordinates":[-73.98,40.75]}`},
+		"secret":       {"ignored"},
+		"unknown":      {"ignored"},
+	}
+
+	var p post
+	if err := Decode(request(MediaTypeForm, []byte(form.Encode())), &p); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	checkPost(t, p)
+	if p.Subtitle != nil || p.Score != nil {
+		t.Errorf("empty and missing nullable fields should be null, got %v and %v", p.Subtitle, p.Score)
+	}
+	if p.Location == nil || p.Location.Lng != -73.98 || p.Location.Lat != 40.75 {
+		t.Errorf("location = %+v", p.Location)
+	}
+	if p.secret != "" {
+		t.Error("unexported fields must not be bound")
+	}
+}
+
+func TestDecode_FormErrors(t *testing.T) {
+	tests := []struct {
+		form string
+		want string
+	}{
+		{"views=many", "views: invalid integer"},
+		{"published=maybe", "published: invalid boolean"},
+		{"rating=high", "rating: invalid number"},
+		{"location=here", "location: invalid JSON"},
+	}
+
+	for _, tt := range tests {
+		var p post
+		err := Decode(request(MediaTypeForm, []byte(tt.form)), &p)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Decode(%s) error = %v, want %q", tt.form, err, tt.want)
+		}
+		if StatusCode(err) != http.StatusBadRequest {
+			t.Errorf("StatusCode(%v) = %d, want 400", err, StatusCode(err))
+		}
+	}
+}
+
+func TestDecode_Multipart(t *testing.T) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, value := range map[string]string{
+		"id":           postID.String(),
+		"title":        "Hello",
+		"views":        "42",
+		"rating":       "4.5",
+		"published":    "true",
+		"published_at": "2026-10-16T12:00:00Z",
+	} {
+		writer.WriteField(key, value)
+	}
+	file, _ := writer.CreateFormFile("metadata", "metadata.json")
+	file.Write([]byte(`{"tags":["go"]}`))
+	writer.Close()
+
+	var p post
+	if err := Decode(request(writer.FormDataContentType(), body.Bytes()), &p); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	checkPost(t, p)
+}
+
+func TestDecode_Msgpack(t *testing.T) {
+	ts := make([]byte, 4)
+	binary.BigEndian.PutUint32(ts, uint32(time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC).Unix()))
+
+	body := msgMap(
+		msgStr("id"), msgStr(postID.String()),
+		msgStr("title"), msgStr("Hello"),
+		msgStr("subtitle"), []byte{0xc0},
+		msgStr("views"), []byte{0xcc, 42},
+		msgStr("rating"), msgFloat(4.5),
+		msgStr("published"), []byte{0xc3},
+		msgStr("published_at"), append([]byte{0xd6, 0xff}, ts...),
+		msgStr("metadata"), msgMap(msgStr("tags"), []byte{0x91, 0xa2, 'g', 'o'}),
+		msgStr("score"), []byte{0xd1, 0xff, 0x38}, // -200
+	)
+
+	for _, contentType := range []string{MediaTypeMsgpack, "application/x-msgpack"} {
+		var p post
+		if err := Decode(request(contentType, body), &p); err != nil {
+			t.Fatalf("Decode(%s) error = %v", contentType, err)
+		}
+		checkPost(t, p)
+		if p.Subtitle != nil || p.Score == nil || *p.Score != -200 {
+			t.Errorf("subtitle = %v, score = %v", p.Subtitle, p.Score)
+		}
+	}
+}
+
+func TestDecode_MsgpackInvalid(t *testing.T) {
+	tests := map[string][]byte{
+		"truncated":     {0x82, 0xa1, 'a'},
+		"not a map":     {0x92, 0x01, 0x02},
+		"trailing data": append(msgMap(), 0x01),
+		"integer key":   {0x81, 0x01, 0x02},
+		"huge length":   {0xdf, 0xff, 0xff, 0xff, 0xff},
+		"unknown ext":   {0xd4, 0x05, 0x00},
+		"reserved byte": {0xc1},
+	}
+
+	for name, body := range tests {
+		var p post
+		if err := Decode(request(MediaTypeMsgpack, body), &p); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	deep := bytes.Repeat([]byte{0x91}, maxMsgpackDepth+2)
+	if _, err := decodeMsgpack(append(deep, 0xc0)); err == nil {
+		t.Error("expected an error for deeply nested arrays")
+	}
+}
+
+func TestDecodeMsgpack_Numbers(t *testing.T) {
+	tests := []struct {
+		body []byte
+		want any
+	}{
+		{[]byte{0x7f}, int64(127)},
+		{[]byte{0xe0}, int64(-32)},
+		{[]byte{0xd0, 0x80}, int64(-128)},
+		{[]byte{0xd2, 0xff, 0xff, 0xff, 0xfe}, int64(-2)},
+		{[]byte{0xcd, 0x01, 0x00}, int64(256)},
+		{[]byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, uint64(math.MaxUint64)},
+		{[]byte{0xca, 0x3f, 0xc0, 0x00, 0x00}, float64(1.5)},
+	}
+
+	for _, tt := range tests {
+		got, err := decodeMsgpack(tt.body)
+		if err != nil || got != tt.want {
+			t.Errorf("decodeMsgpack(% x) = %v (%T), %v; want %v", tt.body, got, got, err, tt.want)
+		}
+	}
+}
+
+func TestJSON_KeepsMissingKeys(t *testing.T) {
+	data, err := JSON(request(MediaTypeForm, []byte("title=Renamed")), &post{})
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	if string(data) != `{"title":"Renamed"}` {
+		t.Errorf("JSON() = %s, want only the sent keys for a merge patch", data)
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	var p post
+	err := Decode(request("text/csv", []byte("title\nHello")), &p)
+	if StatusCode(err) != http.StatusUnsupportedMediaType {
+		t.Errorf("StatusCode(%v) = %d, want 415", err, StatusCode(err))
+	}
+
+	large := append([]byte(`{"title":"`), bytes.Repeat([]byte("a"), MaxBodySize)...)
+	err = Decode(request(MediaTypeJSON, large), &p)
+	if StatusCode(err) != http.StatusRequestEntityTooLarge {
+		t.Errorf("StatusCode(%v) = %d, want 413", err, StatusCode(err))
+	}
+}
+
+// msgStr encodes a msgpack string of up to 255 bytes
+func msgStr(s string) []byte {
+	if len(s) < 32 {
+		return append([]byte{0xa0 | byte(len(s))}, s...)
+	}
+	return append([]byte{0xd9, byte(len(s))}, s...)
+}
+
+// msgFloat encodes a msgpack float64
+func msgFloat(f float64) []byte {
+	b := make([]byte, 9)
+	b[0] = 0xcb
+	binary.BigEndian.PutUint64(b[1:], math.Float64bits(f))
+	return b
+}
+
+// msgMap encodes a msgpack map of up to 15 key/value pairs
+func msgMap(pairs ...[]byte) []byte {
+	body := []byte{0x80 | byte(len(pairs)/2)}
+	for _, part := range pairs {
+		body = append(body, part...)
+	}
+	return body
+}
@@ -0,0 +1,247 @@
+package bind
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// maxMsgpackDepth bounds the nesting of maps and arrays in a msgpack body
+const maxMsgpackDepth = 64
+
+// msgpackTimestamp is the extension type of msgpack timestamps
+const msgpackTimestamp = -1
+
+var errMsgpackTruncated = errors.New("invalid msgpack: unexpected end of body")
+
+// decodeMsgpack decodes one msgpack value into the types encoding/json
+// produces (maps with string keys, []any, string, bool, numbers and nil);
+// binaries become []byte and timestamps time.Time.
+func decodeMsgpack(data []byte) (any, error) {
+	d := &msgpackDecoder{data: data}
+	value, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("invalid msgpack: %d bytes after the value", len(d.data)-d.pos)
+	}
+	return value, nil
+}
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) value(depth int) (any, error) {
+	if depth > maxMsgpackDepth {
+		return nil, fmt.Errorf("invalid msgpack: nested more than %d levels", maxMsgpackDepth)
+	}
+	b, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return d.mapOf(int(b&0x0f), depth)
+	case b&0xf0 == 0x90:
+		return d.arrayOf(int(b&0x0f), depth)
+	case b&0xe0 == 0xa0:
+		return d.str(int(b & 0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(b - 0xc4)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := d.bytes(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), raw...), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.length(b - 0xc7)
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(n)
+	case 0xca:
+		raw, err := d.bytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), nil
+	case 0xcb:
+		raw, err := d.bytes(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		raw, err := d.bytes(1 << (b - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		n := bigEndian(raw)
+		if n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		raw, err := d.bytes(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend from the encoded width
+		shift := 64 - 8*size
+		return int64(bigEndian(raw)<<shift) >> shift, nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (b - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(b - 0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd:
+		n, err := d.length(b - 0xdc + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.length(b - 0xde + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(n, depth)
+	default:
+		return nil, fmt.Errorf("invalid msgpack: unknown type byte 0x%02x", b)
+	}
+}
+
+func (d *msgpackDecoder) mapOf(n, depth int) (any, error) {
+	// Each entry takes at least two bytes; this bounds allocations
+	if n > (len(d.data)-d.pos)/2 {
+		return nil, errMsgpackTruncated
+	}
+	object := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		key, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid msgpack: map keys must be strings")
+		}
+		if object[name], err = d.value(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	return object, nil
+}
+
+func (d *msgpackDecoder) arrayOf(n, depth int) (any, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errMsgpackTruncated
+	}
+	items := make([]any, n)
+	for i := range items {
+		item, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+func (d *msgpackDecoder) str(n int) (any, error) {
+	raw, err := d.bytes(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+// ext decodes an extension of n data bytes; only timestamps are supported
+func (d *msgpackDecoder) ext(n int) (any, error) {
+	typ, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := d.bytes(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(typ) != msgpackTimestamp {
+		return nil, fmt.Errorf("invalid msgpack: unsupported extension type %d", int8(typ))
+	}
+
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(raw)), 0).UTC(), nil
+	case 8:
+		v := binary.BigEndian.Uint64(raw)
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34)).UTC(), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(raw[:4])
+		sec := int64(binary.BigEndian.Uint64(raw[4:]))
+		return time.Unix(sec, int64(nsec)).UTC(), nil
+	default:
+		return nil, fmt.Errorf("invalid msgpack: timestamp of %d bytes", n)
+	}
+}
+
+// length reads a big-endian length of 1, 2 or 4 bytes (width 0, 1 or 2)
+func (d *msgpackDecoder) length(width byte) (int, error) {
+	raw, err := d.bytes(1 << width)
+	if err != nil {
+		return 0, err
+	}
+	return int(bigEndian(raw)), nil
+}
+
+func (d *msgpackDecoder) byte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, errMsgpackTruncated
+	}
+	b := d.data[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *msgpackDecoder) bytes(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, errMsgpackTruncated
+	}
+	raw := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return raw, nil
+}
+
+func bigEndian(raw []byte) uint64 {
+	var n uint64
+	for _, b := range raw {
+		n = n<<8 | uint64(b)
+	}
+	return n
+}
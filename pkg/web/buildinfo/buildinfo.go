@@ -0,0 +1,82 @@
+// Package buildinfo reports which build a generated application is running,
+// so operators can verify that an instance was compiled from the schema
+// they expect. The generated main logs the build at startup:
+//
+//	Build compiler_version=0.4.0 source_hash=3f2a... git_commit=9c1e... schema_version=1.0.0 go_version=go1.23.4
+//
+// and serves it as JSON on Path:
+//
+//	curl localhost:8080/version
+//
+// SourceHash is the source_hash of the application's introspection metadata,
+// so a running instance can be matched with build/introspection/metadata.json.
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Path serves the build information
+const Path = "/version"
+
+// Info describes the build of an application
+type Info struct {
+	CompilerVersion string    `json:"compiler_version"` // Version of the conduit compiler that generated the code
+	SourceHash      string    `json:"source_hash"`      // source_hash of the introspection metadata
+	GitCommit       string    `json:"git_commit,omitempty"`
+	SchemaVersion   string    `json:"schema_version"` // Version of the introspection metadata schema
+	GoVersion       string    `json:"go_version"`
+	StartedAt       time.Time `json:"started_at"`
+}
+
+// New completes info with the Go version the application was built with and
+// the current time as its start
+func New(info Info) Info {
+	info.GoVersion = runtime.Version()
+	info.StartedAt = time.Now().UTC()
+	return info
+}
+
+// String formats the build as logfmt key=value pairs for the startup log
+func (i Info) String() string {
+	pairs := []struct{ key, value string }{
+		{"compiler_version", i.CompilerVersion},
+		{"source_hash", i.SourceHash},
+		{"git_commit", i.GitCommit},
+		{"schema_version", i.SchemaVersion},
+		{"go_version", i.GoVersion},
+	}
+
+	var b strings.Builder
+	for _, pair := range pairs {
+		if pair.value == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(pair.key)
+		b.WriteByte('=')
+		if strings.ContainsAny(pair.value, " \"=") {
+			b.WriteString(strconv.Quote(pair.value))
+		} else {
+			b.WriteString(pair.value)
+		}
+	}
+	return b.String()
+}
+
+// Handler serves info as JSON
+func Handler(info Info) http.HandlerFunc {
+	body, _ := json.Marshal(info)
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(body)
+	}
+}
@@ -0,0 +1,46 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+func TestInfo_String(t *testing.T) {
+	info := Info{
+		CompilerVersion: "0.4.0",
+		SourceHash:      "3f2a",
+		SchemaVersion:   "1.0.0",
+		GoVersion:       "go1.23.4",
+	}
+	want := "compiler_version=0.4.0 source_hash=3f2a schema_version=1.0.0 go_version=go1.23.4"
+	if got := info.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	info.GitCommit = "9c1e dirty"
+	want = `compiler_version=0.4.0 source_hash=3f2a git_commit="9c1e dirty" schema_version=1.0.0 go_version=go1.23.4`
+	if got := info.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	info := New(Info{CompilerVersion: "0.4.0", SourceHash: "3f2a", GitCommit: "9c1e", SchemaVersion: "1.0.0"})
+
+	rec := httptest.NewRecorder()
+	Handler(info).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GET %s = %d %v", Path, rec.Code, rec.Header())
+	}
+
+	var got Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid body %s: %v", rec.Body.String(), err)
+	}
+	if got.SourceHash != "3f2a" || got.GitCommit != "9c1e" || got.GoVersion != runtime.Version() || got.StartedAt.IsZero() {
+		t.Errorf("GET %s = %+v", Path, got)
+	}
+}
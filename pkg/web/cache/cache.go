@@ -0,0 +1,242 @@
+// Package cache adds HTTP caching headers to the read routes of generated
+// resources and purges CDN caches when records change.
+//
+// Cacheable responses carry a Cache-Control header from the resource's
+// @cache_control policy and a Surrogate-Key header naming what they contain:
+// the collection key (e.g. "posts") on list responses and the record key
+// (e.g. "posts/42") on single-record responses. Writes purge the collection
+// key and, for updates and deletes, the record key through the configured
+// Purger.
+//
+// Example:
+//
+//	policy := cache.Policy{MaxAge: 60, Public: true}
+//	r.With(cache.Cacheable(policy, "posts")).Get("/posts/{id}", GetPostHandler(db))
+//	r.With(cache.PurgeOnWrite("posts")).Put("/posts/{id}", UpdatePostHandler(db))
+package cache
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/conduit-lang/conduit/pkg/web/correlation"
+)
+
+const (
+	// SurrogateKeyHeader lists the cache keys of a response, space separated
+	SurrogateKeyHeader = "Surrogate-Key"
+	// PurgeTimeout bounds each purge request
+	PurgeTimeout = 10 * time.Second
+)
+
+// Policy is the caching policy declared with @cache_control. Durations are
+// in seconds.
+type Policy struct {
+	MaxAge               int
+	SharedMaxAge         int
+	StaleWhileRevalidate int
+	Public               bool
+}
+
+// Header returns the Cache-Control header value for the policy, e.g.
+// "public, max-age=60"
+func (p Policy) Header() string {
+	parts := []string{"private"}
+	if p.Public {
+		parts[0] = "public"
+	}
+	parts = append(parts, "max-age="+strconv.Itoa(p.MaxAge))
+	if p.SharedMaxAge > 0 {
+		parts = append(parts, "s-maxage="+strconv.Itoa(p.SharedMaxAge))
+	}
+	if p.StaleWhileRevalidate > 0 {
+		parts = append(parts, "stale-while-revalidate="+strconv.Itoa(p.StaleWhileRevalidate))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RecordKey returns the surrogate key of a single record
+func RecordKey(collection, id string) string {
+	return collection + "/" + id
+}
+
+// routeID returns the record a route addresses: its "id" parameter, or the
+// given parameters joined by "/". It returns "" on collection routes.
+func routeID(r *http.Request, params []string) string {
+	if len(params) == 0 {
+		return chi.URLParam(r, "id")
+	}
+	values := make([]string, len(params))
+	for i, param := range params {
+		if values[i] = chi.URLParam(r, param); values[i] == "" {
+			return ""
+		}
+	}
+	return strings.Join(values, "/")
+}
+
+// Cacheable sets the policy's Cache-Control header and the surrogate keys on
+// successful GET and HEAD responses. Error responses are marked no-store so a
+// transient failure is never cached. The record key is taken from the "id"
+// route parameter, or from the params naming the primary key of a resource
+// with a natural key, so the middleware must be attached to the route with
+// With rather than to the router with Use.
+func Cacheable(policy Policy, collection string, params ...string) func(http.Handler) http.Handler {
+	header := policy.Header()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := collection
+			if id := routeID(r, params); id != "" {
+				key = RecordKey(collection, id)
+			}
+			next.ServeHTTP(&headerWriter{ResponseWriter: w, cacheControl: header, surrogateKey: key}, r)
+		})
+	}
+}
+
+// PurgeOnWrite purges the collection key, and the record key when the route
+// has an "id" parameter or the params given, after a successful write. Purges
+// run in the background so the response is not delayed by the CDN.
+func PurgeOnWrite(collection string, params ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			if rec.status() >= 300 {
+				return
+			}
+
+			keys := []string{collection}
+			if id := routeID(r, params); id != "" {
+				keys = append(keys, RecordKey(collection, id))
+			}
+			go purge(correlation.Detach(r.Context()), keys)
+		})
+	}
+}
+
+// Purger removes cached responses by surrogate key, e.g. through a CDN API.
+type Purger interface {
+	Purge(ctx context.Context, keys []string) error
+}
+
+// PurgerFunc adapts a function to the Purger interface.
+type PurgerFunc func(ctx context.Context, keys []string) error
+
+// Purge calls f(ctx, keys).
+func (f PurgerFunc) Purge(ctx context.Context, keys []string) error {
+	return f(ctx, keys)
+}
+
+var (
+	purgerMu sync.RWMutex
+	purger   Purger
+)
+
+// SetPurger sets the Purger used by PurgeOnWrite. A nil Purger disables
+// purging, which is the default.
+func SetPurger(p Purger) {
+	purgerMu.Lock()
+	defer purgerMu.Unlock()
+	purger = p
+}
+
+// purge runs in the background with a context carrying only the request ID
+// of the write, so purge requests carry it in their X-Request-Id header
+func purge(ctx context.Context, keys []string) {
+	purgerMu.RLock()
+	p := purger
+	purgerMu.RUnlock()
+	if p == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, PurgeTimeout)
+	defer cancel()
+	if err := p.Purge(ctx, keys); err != nil {
+		correlation.Logf(ctx, "cache: failed to purge %s: %v", strings.Join(keys, " "), err)
+	}
+}
+
+// headerWriter adds the caching headers once the status code is known
+type headerWriter struct {
+	http.ResponseWriter
+	cacheControl string
+	surrogateKey string
+	wroteHeader  bool
+}
+
+func (w *headerWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		// A 304 revalidates the cached copy, so it carries the same headers
+		if (code >= 200 && code < 300) || code == http.StatusNotModified {
+			w.Header().Set("Cache-Control", w.cacheControl)
+			w.Header().Set(SurrogateKeyHeader, w.surrogateKey)
+		} else {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *headerWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush keeps streamed list responses streaming
+func (w *headerWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// statusRecorder records the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusRecorder) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
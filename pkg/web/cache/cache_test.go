@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/conduit-lang/conduit/pkg/web/correlation"
+)
+
+func TestPolicyHeader(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		want   string
+	}{
+		{Policy{MaxAge: 60, Public: true}, "public, max-age=60"},
+		{Policy{MaxAge: 30}, "private, max-age=30"},
+		{Policy{MaxAge: 60, SharedMaxAge: 600, StaleWhileRevalidate: 30, Public: true}, "public, max-age=60, s-maxage=600, stale-while-revalidate=30"},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Header(); got != tt.want {
+			t.Errorf("Header() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	cacheable := Cacheable(Policy{MaxAge: 60, Public: true}, "posts")
+	r := chi.NewRouter()
+	r.With(cacheable).Get("/posts", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	})
+	r.With(cacheable).Get("/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch chi.URLParam(r, "id") {
+		case "missing":
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		case "unchanged":
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("{}"))
+	})
+
+	tests := []struct {
+		path         string
+		cacheControl string
+		surrogateKey string
+	}{
+		{"/posts", "public, max-age=60", "posts"},
+		{"/posts/42", "public, max-age=60", "posts/42"},
+		{"/posts/missing", "no-store", ""},
+		{"/posts/unchanged", "public, max-age=60", "posts/unchanged"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if got := rec.Header().Get("Cache-Control"); got != tt.cacheControl {
+			t.Errorf("GET %s: Cache-Control = %q, want %q", tt.path, got, tt.cacheControl)
+		}
+		if got := rec.Header().Get(SurrogateKeyHeader); got != tt.surrogateKey {
+			t.Errorf("GET %s: Surrogate-Key = %q, want %q", tt.path, got, tt.surrogateKey)
+		}
+	}
+}
+
+func TestCacheable_NaturalKey(t *testing.T) {
+	r := chi.NewRouter()
+	r.With(Cacheable(Policy{MaxAge: 60}, "memberships", "region", "code")).Get("/memberships/{region}/{code}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/memberships/eu/7", nil))
+	if got := rec.Header().Get(SurrogateKeyHeader); got != "memberships/eu/7" {
+		t.Errorf("Surrogate-Key = %q, want %q", got, "memberships/eu/7")
+	}
+}
+
+func TestPurgeOnWrite(t *testing.T) {
+	purged := make(chan []string, 1)
+	SetPurger(PurgerFunc(func(ctx context.Context, keys []string) error {
+		purged <- keys
+		return nil
+	}))
+	defer SetPurger(nil)
+
+	r := chi.NewRouter()
+	r.With(PurgeOnWrite("posts")).Put("/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "id") == "invalid" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		w.Write([]byte("{}"))
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/posts/invalid", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/posts/42", nil))
+
+	select {
+	case keys := <-purged:
+		if len(keys) != 2 || keys[0] != "posts" || keys[1] != "posts/42" {
+			t.Errorf("purged keys = %v, want [posts posts/42]", keys)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a purge after a successful write")
+	}
+
+	select {
+	case keys := <-purged:
+		t.Errorf("failed write should not purge, got %v", keys)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestHTTPPurger(t *testing.T) {
+	var gotKeys, gotToken, gotRequestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKeys = r.Header.Get(SurrogateKeyHeader)
+		gotToken = r.Header.Get("Fastly-Key")
+		gotRequestID = r.Header.Get(correlation.Header)
+	}))
+	defer server.Close()
+
+	t.Setenv(PurgeURLEnvVar, server.URL)
+	t.Setenv(PurgeHeaderEnvVar, "Fastly-Key: secret")
+	purger, err := PurgerFromEnv()
+	if err != nil {
+		t.Fatalf("PurgerFromEnv() error = %v", err)
+	}
+
+	if err := purger.Purge(correlation.WithID(context.Background(), "req-1"), []string{"posts", "posts/42"}); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if gotKeys != "posts posts/42" {
+		t.Errorf("Surrogate-Key = %q", gotKeys)
+	}
+	if gotToken != "secret" {
+		t.Errorf("Fastly-Key = %q", gotToken)
+	}
+	if gotRequestID != "req-1" {
+		t.Errorf("X-Request-Id = %q, want the ID of the request that caused the purge", gotRequestID)
+	}
+
+	t.Setenv(PurgeURLEnvVar, "")
+	if purger, err := PurgerFromEnv(); purger != nil || err != nil {
+		t.Errorf("PurgerFromEnv() without a URL = %v, %v", purger, err)
+	}
+}
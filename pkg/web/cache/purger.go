@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/conduit-lang/conduit/pkg/web/correlation"
+)
+
+const (
+	// PurgeURLEnvVar is the endpoint PurgerFromEnv sends purge requests to,
+	// e.g. https://api.fastly.com/service/<service id>/purge
+	PurgeURLEnvVar = "CONDUIT_CACHE_PURGE_URL"
+	// PurgeHeaderEnvVar is an optional "Name: value" header added to purge
+	// requests, e.g. "Fastly-Key: <token>"
+	PurgeHeaderEnvVar = "CONDUIT_CACHE_PURGE_HEADER"
+)
+
+// HTTPPurger purges by POSTing to URL with the keys in a Surrogate-Key
+// header, the request format of Fastly's purge-by-key API. Purges caused by a
+// request carry its ID in an X-Request-Id header.
+type HTTPPurger struct {
+	URL    string
+	Header http.Header
+	Client *http.Client // http.DefaultClient when nil
+}
+
+// Purge sends one purge request for all keys.
+func (p *HTTPPurger) Purge(ctx context.Context, keys []string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, nil)
+	if err != nil {
+		return err
+	}
+	for name, values := range p.Header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	req.Header.Set(SurrogateKeyHeader, strings.Join(keys, " "))
+	correlation.SetHeader(req)
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("purge request returned %s", resp.Status)
+	}
+	return nil
+}
+
+// PurgerFromEnv returns an HTTPPurger configured from CONDUIT_CACHE_PURGE_URL
+// and CONDUIT_CACHE_PURGE_HEADER, or nil when no purge URL is set.
+func PurgerFromEnv() (Purger, error) {
+	url := strings.TrimSpace(os.Getenv(PurgeURLEnvVar))
+	if url == "" {
+		return nil, nil
+	}
+
+	purger := &HTTPPurger{URL: url, Header: make(http.Header)}
+	if header := strings.TrimSpace(os.Getenv(PurgeHeaderEnvVar)); header != "" {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("%s must be formatted as \"Name: value\"", PurgeHeaderEnvVar)
+		}
+		purger.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return purger, nil
+}
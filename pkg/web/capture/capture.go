@@ -0,0 +1,249 @@
+// Package capture records sanitized request/response pairs to an NDJSON file
+// so they can be re-sent against a local build with `conduit replay`.
+//
+// Capture is meant for development and staging. Generated applications enable
+// it only when CONDUIT_CAPTURE names an output file:
+//
+//	CONDUIT_CAPTURE=capture.ndjson ./build/app
+//
+// Sensitive headers (Authorization, Cookie, API keys) and JSON body fields
+// whose names look like secrets (password, token, secret, ...) are replaced
+// with Redacted before anything is written.
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// EnvVar names the capture file; capture is disabled when it is unset
+const EnvVar = "CONDUIT_CAPTURE"
+
+// Redacted replaces sanitized header values and JSON fields
+const Redacted = "[REDACTED]"
+
+// MaxBodySize is the largest request or response body recorded in full;
+// longer bodies are truncated and marked as such
+const MaxBodySize = 64 * 1024
+
+// sensitiveHeaders are never written to a capture
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"X-Auth-Token":        true,
+	"X-Csrf-Token":        true,
+}
+
+// sensitiveFields are substrings of JSON field names whose values are redacted
+var sensitiveFields = []string{"password", "passwd", "secret", "token", "api_key", "apikey", "authorization", "credit_card", "ssn"}
+
+// Entry is one recorded request and its response
+type Entry struct {
+	Time              time.Time         `json:"time"`
+	Method            string            `json:"method"`
+	Path              string            `json:"path"`
+	Query             string            `json:"query,omitempty"`
+	Headers           map[string]string `json:"headers,omitempty"`
+	Body              string            `json:"body,omitempty"`
+	BodyTruncated     bool              `json:"body_truncated,omitempty"`
+	Status            int               `json:"status"`
+	ResponseBody      string            `json:"response_body,omitempty"`
+	ResponseTruncated bool              `json:"response_truncated,omitempty"`
+	DurationMs        float64           `json:"duration_ms"`
+}
+
+// Recorder appends entries to an NDJSON writer. It is safe for concurrent use.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewRecorder creates a recorder writing one JSON entry per line to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// OpenFile creates a recorder that appends to the file at path
+func OpenFile(path string) (*Recorder, io.Closer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open capture file: %w", err)
+	}
+	return NewRecorder(f), f, nil
+}
+
+// Record writes an entry
+func (rec *Recorder) Record(entry Entry) error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.enc.Encode(entry)
+}
+
+// Middleware records every request passing through it. Request bodies are
+// buffered so handlers still read them in full.
+func (rec *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		var body []byte
+		if r.Body != nil {
+			body, _ = io.ReadAll(r.Body)
+			r.Body.Close()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		var response bytes.Buffer
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(&limitedWriter{buf: &response, limit: MaxBodySize + 1})
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		entry := Entry{
+			Time:       start.UTC(),
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Query:      r.URL.RawQuery,
+			Headers:    SanitizeHeaders(r.Header),
+			Status:     status,
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		entry.Body, entry.BodyTruncated = sanitizeBody(body)
+		entry.ResponseBody, entry.ResponseTruncated = sanitizeBody(response.Bytes())
+
+		// Capture is best effort and must never fail the request
+		_ = rec.Record(entry)
+	})
+}
+
+// SanitizeHeaders flattens headers and redacts sensitive ones
+func SanitizeHeaders(header http.Header) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(header))
+	for name, values := range header {
+		canonical := http.CanonicalHeaderKey(name)
+		if sensitiveHeaders[canonical] {
+			out[canonical] = Redacted
+			continue
+		}
+		out[canonical] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+// sanitizeBody redacts sensitive JSON fields and truncates long bodies
+func sanitizeBody(body []byte) (string, bool) {
+	if len(body) == 0 {
+		return "", false
+	}
+	if len(body) > MaxBodySize {
+		return string(body[:MaxBodySize]), true
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return string(body), false
+	}
+	sanitized, err := json.Marshal(redactJSON(value))
+	if err != nil {
+		return string(body), false
+	}
+	return string(sanitized), false
+}
+
+// redactJSON replaces the values of sensitive object keys at any depth
+func redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if isSensitiveField(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = redactJSON(child)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactJSON(child)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isSensitiveField(name string) bool {
+	lower := strings.ToLower(name)
+	for _, field := range sensitiveFields {
+		if strings.Contains(lower, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRedacted reports whether a captured value was sanitized
+func IsRedacted(value string) bool {
+	return value == Redacted
+}
+
+// Read parses an NDJSON capture. Blank lines are skipped.
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*MaxBodySize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: invalid capture entry: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// limitedWriter keeps at most limit bytes and discards the rest
+type limitedWriter struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if remaining := w.limit - w.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			w.buf.Write(p[:remaining])
+		} else {
+			w.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
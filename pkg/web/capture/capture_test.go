@@ -0,0 +1,87 @@
+package capture
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware_RecordsSanitizedEntries(t *testing.T) {
+	var out bytes.Buffer
+	rec := NewRecorder(&out)
+
+	handler := rec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "hunter2") {
+			t.Error("handler should receive the original request body")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data":{"id":"1","attributes":{"email":"a@example.com","api_token":"abc"}}}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users?include=posts",
+		strings.NewReader(`{"data":{"attributes":{"email":"a@example.com","password":"hunter2"}}}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries, err := Read(&out)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Method != http.MethodPost || entry.Path != "/users" || entry.Query != "include=posts" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if entry.Status != http.StatusCreated {
+		t.Errorf("Status = %d, want 201", entry.Status)
+	}
+	if entry.Headers["Authorization"] != Redacted {
+		t.Errorf("Authorization = %q, want redacted", entry.Headers["Authorization"])
+	}
+	if entry.Headers["Content-Type"] != "application/json" {
+		t.Errorf("Content-Type = %q", entry.Headers["Content-Type"])
+	}
+	if strings.Contains(entry.Body, "hunter2") || !strings.Contains(entry.Body, `"password":"[REDACTED]"`) {
+		t.Errorf("request body not sanitized: %s", entry.Body)
+	}
+	if !strings.Contains(entry.Body, "a@example.com") {
+		t.Errorf("non-sensitive fields should be kept: %s", entry.Body)
+	}
+	if strings.Contains(entry.ResponseBody, `"abc"`) || !strings.Contains(entry.ResponseBody, `"api_token":"[REDACTED]"`) {
+		t.Errorf("response body not sanitized: %s", entry.ResponseBody)
+	}
+}
+
+func TestSanitizeBody(t *testing.T) {
+	body, truncated := sanitizeBody([]byte("plain text"))
+	if body != "plain text" || truncated {
+		t.Errorf("sanitizeBody(plain) = %q, %v", body, truncated)
+	}
+
+	long := bytes.Repeat([]byte("a"), MaxBodySize+10)
+	body, truncated = sanitizeBody(long)
+	if len(body) != MaxBodySize || !truncated {
+		t.Errorf("long body: len = %d, truncated = %v", len(body), truncated)
+	}
+
+	body, _ = sanitizeBody([]byte(`[{"client_secret":"x","name":"n"}]`))
+	if body != `[{"client_secret":"[REDACTED]","name":"n"}]` {
+		t.Errorf("nested body = %s", body)
+	}
+}
+
+func TestRead_InvalidLine(t *testing.T) {
+	_, err := Read(strings.NewReader("{\"method\":\"GET\"}\n\nnot json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Read() error = %v, want line 3 error", err)
+	}
+}
@@ -0,0 +1,156 @@
+package changes
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{Time: time.Date(2026, 3, 1, 12, 30, 0, 123456000, time.UTC), ID: "42"}
+
+	parsed, err := ParseCursor(cursor.String())
+	if err != nil {
+		t.Fatalf("ParseCursor() error = %v", err)
+	}
+	if !parsed.Time.Equal(cursor.Time) || parsed.ID != cursor.ID {
+		t.Errorf("ParseCursor() = %+v, want %+v", parsed, cursor)
+	}
+
+	for _, invalid := range []string{"not base64!", "bm8tbmV3bGluZQ", "eWVzdGVyZGF5CjQy"} {
+		if _, err := ParseCursor(invalid); err == nil {
+			t.Errorf("ParseCursor(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	cursor := Cursor{Time: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), ID: "7"}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    Request
+		wantErr string
+	}{
+		{"since", "since=2026-03-01T00:00:00Z", Request{Cursor: Cursor{Time: cursor.Time}, Limit: DefaultLimit}, ""},
+		{"cursor with limit", "cursor=" + cursor.String() + "&limit=10", Request{Cursor: cursor, Limit: 10}, ""},
+		{"limit clamped", "since=2026-03-01T00:00:00Z&limit=5000", Request{Cursor: Cursor{Time: cursor.Time}, Limit: MaxLimit}, ""},
+		{"missing", "", Request{}, "since or cursor is required"},
+		{"both", "since=2026-03-01T00:00:00Z&cursor=" + cursor.String(), Request{}, "cannot be combined"},
+		{"invalid since", "since=yesterday", Request{}, "invalid since"},
+		{"invalid cursor", "cursor=abc", Request{}, "invalid cursor"},
+		{"invalid limit", "since=2026-03-01T00:00:00Z&limit=0", Request{}, "invalid limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRequest(httptest.NewRequest("GET", "/posts/changes?"+tt.query, nil))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseRequest() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRequest() error = %v", err)
+			}
+			if !got.Cursor.Time.Equal(tt.want.Cursor.Time) || got.Cursor.ID != tt.want.Cursor.ID || got.Limit != tt.want.Limit {
+				t.Errorf("ParseRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	sql, args := Query("posts", "updated_at", Request{Cursor: Cursor{Time: since}, Limit: 10})
+	if want := "SELECT * FROM posts WHERE updated_at >= $1 ORDER BY updated_at, id LIMIT $2"; sql != want {
+		t.Errorf("since query = %q, want %q", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{since, 11}) {
+		t.Errorf("since args = %v", args)
+	}
+
+	sql, args = Query("posts", "updated_at", Request{Cursor: Cursor{Time: since, ID: "42"}, Limit: 10})
+	if want := "SELECT * FROM posts WHERE (updated_at, id) > ($1, $2) ORDER BY updated_at, id LIMIT $3"; sql != want {
+		t.Errorf("cursor query = %q, want %q", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{since, "42", 11}) {
+		t.Errorf("cursor args = %v", args)
+	}
+
+	sql, _ = QueryByKey("countrys", "updated_at", "code", Request{Cursor: Cursor{Time: since, ID: "fr"}, Limit: 10})
+	if want := "SELECT * FROM countrys WHERE (updated_at, code) > ($1, $2) ORDER BY updated_at, code LIMIT $3"; sql != want {
+		t.Errorf("key query = %q, want %q", sql, want)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		deleted   bool
+		createdAt time.Time
+		want      Operation
+	}{
+		{"deleted", true, since.Add(time.Hour), Deleted},
+		{"created after since", false, since.Add(time.Hour), Created},
+		{"created at since", false, since, Created},
+		{"created before since", false, since.Add(-time.Hour), Updated},
+		{"no creation time", false, time.Time{}, Updated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.deleted, tt.createdAt, since); got != tt.want {
+				t.Errorf("Classify() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeed(t *testing.T) {
+	start := Cursor{Time: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}
+	feed := NewFeed(Request{Cursor: start, Limit: 2})
+
+	if feed.Cursor != start.String() || len(feed.Changes) != 0 {
+		t.Fatalf("empty feed = %+v", feed)
+	}
+
+	changedAt := start.Time.Add(time.Minute)
+	if !feed.Add(Created, 1, changedAt, map[string]string{"title": "Hello"}) {
+		t.Fatal("Add() rejected the first change")
+	}
+	if !feed.Add(Deleted, 2, changedAt, map[string]string{"title": "Gone"}) {
+		t.Fatal("Add() rejected the second change")
+	}
+	if feed.Add(Updated, 3, changedAt, nil) {
+		t.Fatal("Add() accepted a change beyond the limit")
+	}
+
+	if !feed.HasMore || len(feed.Changes) != 2 {
+		t.Errorf("feed = %+v, want 2 changes and more", feed)
+	}
+	if feed.Changes[1].Data != nil {
+		t.Errorf("tombstone data = %v, want nil", feed.Changes[1].Data)
+	}
+	if want := (Cursor{Time: changedAt, ID: "2"}).String(); feed.Cursor != want {
+		t.Errorf("cursor = %q, want %q", feed.Cursor, want)
+	}
+
+	body, err := json.Marshal(feed)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	for _, want := range []string{`"operation":"created"`, `"data":{"title":"Hello"}`, `{"operation":"deleted","id":2,"changed_at":"2026-03-01T00:01:00Z"}`, `"has_more":true`} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("JSON %s missing %s", body, want)
+		}
+	}
+}
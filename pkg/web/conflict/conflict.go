@@ -0,0 +1,194 @@
+// Package conflict resolves concurrent updates to resources declared with
+// @conflict. Reads return the record's version as a strong ETag derived from
+// its @auto_update timestamp; an update states the version it was based on in
+// If-Match (or If-Unmodified-Since), and the resource's strategy decides what
+// happens when another write got there first:
+//
+//   - last_write_wins: the update is applied regardless (the default)
+//   - reject: the update fails with 412 Precondition Failed, and updates
+//     without a precondition fail with 428 Precondition Required
+//   - merge(fields): the update is applied when it only writes the listed
+//     fields, and fails with 412 otherwise
+//
+// Example:
+//
+//	policy := conflict.Policy{Strategy: conflict.Merge, MergeFields: []string{"title"}}
+//	if cerr := conflict.Check(r, policy, current.UpdatedAt); cerr != nil {
+//		http.Error(w, cerr.Message, cerr.Status)
+//		return
+//	}
+//	...
+//	w.Header().Set("ETag", conflict.ETag(post.UpdatedAt))
+package conflict
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Strategy is how a resource resolves an update based on a stale version.
+type Strategy string
+
+// Strategies accepted by @conflict
+const (
+	LastWriteWins Strategy = "last_write_wins"
+	Reject        Strategy = "reject"
+	Merge         Strategy = "merge"
+)
+
+// maxInspectedBody is the largest body Check reads to find the written fields.
+// Larger bodies are left to the handler's own size limit.
+const maxInspectedBody = 10 << 20
+
+// Policy is the conflict policy of a resource.
+type Policy struct {
+	Strategy Strategy
+	// MergeFields are the fields a stale update may still write (Merge only)
+	MergeFields []string
+}
+
+// Error is a rejected update. Status is 412 Precondition Failed for a stale
+// update or 428 Precondition Required for an update without a precondition.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ETag returns the strong entity tag of a record version. Versions have
+// microsecond precision, matching PostgreSQL timestamps, so the tag of a
+// record read back from the database equals the tag returned by the write.
+func ETag(version time.Time) string {
+	return `"` + strconv.FormatInt(version.Round(time.Microsecond).UnixMicro(), 10) + `"`
+}
+
+// Check applies policy to an update of a record whose current version is
+// version. It returns nil when the update may proceed. The request body is
+// read for the Merge strategy and restored so handlers can decode it.
+func Check(r *http.Request, policy Policy, version time.Time) *Error {
+	if policy.Strategy == LastWriteWins {
+		return nil
+	}
+
+	stale, conditional := isStale(r, version)
+	if !conditional {
+		if policy.Strategy == Reject {
+			return &Error{
+				Status:  http.StatusPreconditionRequired,
+				Message: "Precondition required: send If-Match with the ETag of the record being updated",
+			}
+		}
+		return nil
+	}
+	if !stale {
+		return nil
+	}
+
+	// A stale merge proceeds only when every written field can be merged;
+	// bodies that cannot be inspected are treated as conflicting
+	if policy.Strategy == Merge {
+		if fields, err := writtenFields(r); err == nil {
+			conflicting := outside(fields, policy.MergeFields)
+			if len(conflicting) == 0 {
+				return nil
+			}
+			return &Error{
+				Status:  http.StatusPreconditionFailed,
+				Message: fmt.Sprintf("Record was modified since it was read; conflicting fields: %s", strings.Join(conflicting, ", ")),
+			}
+		}
+	}
+
+	return &Error{
+		Status:  http.StatusPreconditionFailed,
+		Message: "Record was modified since it was read",
+	}
+}
+
+// isStale evaluates the request's precondition against version. conditional
+// is false when the request has neither If-Match nor If-Unmodified-Since. As
+// in RFC 9110, If-Unmodified-Since is ignored when If-Match is present.
+func isStale(r *http.Request, version time.Time) (stale, conditional bool) {
+	if match := r.Header.Get("If-Match"); match != "" {
+		etag := ETag(version)
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			// If-Match uses the strong comparison, so weak tags never match
+			if candidate == "*" || candidate == etag {
+				return false, true
+			}
+		}
+		return true, true
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Unmodified-Since"))
+	if err != nil {
+		return false, false
+	}
+	// HTTP dates have one-second resolution
+	return version.UTC().Truncate(time.Second).After(since), true
+}
+
+// writtenFields returns the fields set by the request body: the top-level
+// keys of a JSON object, or the attributes of a JSON:API document.
+func writtenFields(r *http.Request) ([]string, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxInspectedBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxInspectedBody {
+		return nil, fmt.Errorf("request body exceeds %d bytes", maxInspectedBody)
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil, err
+	}
+	if data, ok := object["data"]; ok {
+		var document struct {
+			Attributes map[string]json.RawMessage `json:"attributes"`
+		}
+		if err := json.Unmarshal(data, &document); err == nil && document.Attributes != nil {
+			object = document.Attributes
+		}
+	}
+
+	fields := make([]string, 0, len(object))
+	for field := range object {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// outside returns the fields that are not in allowed, ignoring "id"
+func outside(fields, allowed []string) []string {
+	permitted := map[string]bool{"id": true}
+	for _, field := range allowed {
+		permitted[field] = true
+	}
+	var result []string
+	for _, field := range fields {
+		if !permitted[field] {
+			result = append(result, field)
+		}
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+package conflict
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestETag(t *testing.T) {
+	version := time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.UTC)
+
+	if got, want := ETag(version), `"1772366400123457"`; got != want {
+		t.Errorf("ETag() = %s, want %s", got, want)
+	}
+	// A version read back from the database with microsecond precision keeps its tag
+	if ETag(version) != ETag(version.Round(time.Microsecond)) {
+		t.Error("ETag() should ignore sub-microsecond precision")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	version := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	current := ETag(version)
+	stale := ETag(version.Add(-time.Minute))
+
+	reject := Policy{Strategy: Reject}
+	merge := Policy{Strategy: Merge, MergeFields: []string{"title", "tags"}}
+
+	tests := []struct {
+		name       string
+		policy     Policy
+		headers    map[string]string
+		body       string
+		wantStatus int
+	}{
+		{"last write wins ignores stale versions", Policy{Strategy: LastWriteWins}, map[string]string{"If-Match": stale}, `{"body":"x"}`, 0},
+		{"reject without precondition", reject, nil, `{"title":"x"}`, http.StatusPreconditionRequired},
+		{"reject current version", reject, map[string]string{"If-Match": current}, `{"title":"x"}`, 0},
+		{"reject one of several tags", reject, map[string]string{"If-Match": stale + ", " + current}, `{"title":"x"}`, 0},
+		{"reject wildcard", reject, map[string]string{"If-Match": "*"}, `{"title":"x"}`, 0},
+		{"reject stale version", reject, map[string]string{"If-Match": stale}, `{"title":"x"}`, http.StatusPreconditionFailed},
+		{"reject weak tag", reject, map[string]string{"If-Match": "W/" + current}, `{"title":"x"}`, http.StatusPreconditionFailed},
+		{"reject unmodified since", reject, map[string]string{"If-Unmodified-Since": version.Format(http.TimeFormat)}, `{"title":"x"}`, 0},
+		{"reject modified since", reject, map[string]string{"If-Unmodified-Since": version.Add(-time.Hour).Format(http.TimeFormat)}, `{"title":"x"}`, http.StatusPreconditionFailed},
+		{"merge without precondition", merge, nil, `{"body":"x"}`, 0},
+		{"merge stale mergeable fields", merge, map[string]string{"If-Match": stale}, `{"id":1,"title":"x","tags":[]}`, 0},
+		{"merge stale conflicting field", merge, map[string]string{"If-Match": stale}, `{"title":"x","body":"y"}`, http.StatusPreconditionFailed},
+		{"merge stale JSON:API attributes", merge, map[string]string{"If-Match": stale}, `{"data":{"type":"posts","id":"1","attributes":{"title":"x"}}}`, 0},
+		{"merge stale malformed body", merge, map[string]string{"If-Match": stale}, `{"title":`, http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/posts/1", strings.NewReader(tt.body))
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+
+			cerr := Check(r, tt.policy, version)
+			status := 0
+			if cerr != nil {
+				status = cerr.Status
+			}
+			if status != tt.wantStatus {
+				t.Fatalf("Check() status = %d, want %d (%v)", status, tt.wantStatus, cerr)
+			}
+
+			// The body remains readable by the handler
+			body, err := io.ReadAll(r.Body)
+			if err != nil || string(body) != tt.body {
+				t.Errorf("body after Check() = %q, %v; want %q", body, err, tt.body)
+			}
+		})
+	}
+}
+
+func TestCheckConflictingFields(t *testing.T) {
+	version := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := httptest.NewRequest(http.MethodPatch, "/posts/1", strings.NewReader(`{"title":"x","status":"draft","body":"y"}`))
+	r.Header.Set("If-Match", ETag(version.Add(-time.Second)))
+
+	cerr := Check(r, Policy{Strategy: Merge, MergeFields: []string{"title"}}, version)
+	if cerr == nil {
+		t.Fatal("Check() = nil, want a conflict")
+	}
+	if !strings.HasSuffix(cerr.Error(), "conflicting fields: body, status") {
+		t.Errorf("Check() error = %q", cerr.Error())
+	}
+}
@@ -0,0 +1,66 @@
+// Package correlation carries the ID of the request that caused work into
+// the work it causes, so a request's side effects can be traced end to end:
+// hooks, @async blocks, queued jobs, outbound HTTP requests and log lines all
+// report the same ID.
+//
+// The ID is the one middleware.RequestID assigns, which is the client's
+// X-Request-Id header when it sent one. Generated handlers pass the request's
+// context to models and hooks, so synchronous hooks see it directly. Work
+// that outlives the request takes a Detach-ed context instead, which keeps
+// the ID without being canceled when the response is written:
+//
+//	go func(ctx context.Context) {
+//		mail.SendContext(ctx, "welcome", user.Email, nil)
+//	}(correlation.Detach(ctx))
+//
+// Jobs enqueued with such a context record the ID in their correlation_id
+// column, and workers run their handlers with it.
+package correlation
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Header carries the ID on outbound requests, as it does on inbound ones
+const Header = "X-Request-Id"
+
+// ID returns the request ID carried by ctx, or "" when it carries none
+func ID(ctx context.Context) string {
+	return middleware.GetReqID(ctx)
+}
+
+// WithID returns a copy of ctx carrying id, as middleware.RequestID would.
+// An empty id leaves ctx unchanged.
+func WithID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, middleware.RequestIDKey, id)
+}
+
+// Detach returns a background context carrying the request ID of ctx, for
+// work that must not be canceled with the request
+func Detach(ctx context.Context) context.Context {
+	return WithID(context.Background(), ID(ctx))
+}
+
+// SetHeader sets the X-Request-Id header of an outbound request to the ID
+// its context carries, unless the header is already set
+func SetHeader(req *http.Request) {
+	if id := ID(req.Context()); id != "" && req.Header.Get(Header) == "" {
+		req.Header.Set(Header, id)
+	}
+}
+
+// Logf logs like log.Printf, followed by request_id=<id> when ctx carries one
+func Logf(ctx context.Context, format string, args ...interface{}) {
+	if id := ID(ctx); id != "" {
+		format += " request_id=%s"
+		args = append(args, id)
+	}
+	log.Printf(format, args...)
+}
@@ -0,0 +1,79 @@
+package correlation
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestDetach(t *testing.T) {
+	var ctx context.Context
+	handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/posts", nil)
+	req.Header.Set(Header, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := ID(ctx); got != "req-1" {
+		t.Fatalf("ID() = %q, want req-1", got)
+	}
+
+	requestCtx, cancel := context.WithCancel(ctx)
+	detached := Detach(requestCtx)
+	cancel()
+	if detached.Err() != nil {
+		t.Error("Detach() should not be canceled with the request")
+	}
+	if got := ID(detached); got != "req-1" {
+		t.Errorf("ID(Detach()) = %q, want req-1", got)
+	}
+
+	if got := WithID(context.Background(), ""); ID(got) != "" {
+		t.Errorf("WithID(\"\") should carry no ID, got %q", ID(got))
+	}
+}
+
+func TestSetHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "https://cdn.example.com/purge", nil)
+	SetHeader(req)
+	if got := req.Header.Get(Header); got != "" {
+		t.Errorf("SetHeader() without an ID set %q", got)
+	}
+
+	req = req.WithContext(WithID(req.Context(), "req-1"))
+	SetHeader(req)
+	if got := req.Header.Get(Header); got != "req-1" {
+		t.Errorf("SetHeader() = %q, want req-1", got)
+	}
+
+	req.Header.Set(Header, "upstream")
+	SetHeader(req)
+	if got := req.Header.Get(Header); got != "upstream" {
+		t.Errorf("SetHeader() should keep an existing header, got %q", got)
+	}
+}
+
+func TestLogf(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	Logf(context.Background(), "hooks: %s failed", "Post.AfterCreate")
+	Logf(WithID(context.Background(), "req-1"), "hooks: %s failed", "Post.AfterCreate")
+
+	want := "hooks: Post.AfterCreate failed\nhooks: Post.AfterCreate failed request_id=req-1\n"
+	if got := out.String(); got != want {
+		t.Errorf("Logf() wrote %q, want %q", got, want)
+	}
+}
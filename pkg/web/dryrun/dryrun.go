@@ -0,0 +1,62 @@
+// Package dryrun lets clients try a create or update without saving it, for
+// example to pre-validate a form. A request with ?dry_run=true runs the
+// operation's before hooks, validations and write inside a transaction that is
+// then rolled back, so database constraints are checked too, and responds with
+// the record as it would have been saved. After hooks do not run.
+//
+// Example:
+//
+//	// In a handler
+//	if dryrun.Requested(r) {
+//		ctx = dryrun.With(ctx)
+//		w.Header().Set(dryrun.Header, "true")
+//	}
+//	...
+//	w.WriteHeader(dryrun.Status(ctx, http.StatusCreated))
+//
+//	// In a model, once the write succeeded
+//	if dryrun.Enabled(ctx) {
+//		return nil // the deferred tx.Rollback undoes the write
+//	}
+package dryrun
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// Param is the query parameter requesting a dry run
+const Param = "dry_run"
+
+// Header marks the responses of dry runs
+const Header = "Conduit-Dry-Run"
+
+type dryRunKey struct{}
+
+// Requested reports whether the request asks for a dry run, e.g. with
+// ?dry_run=true or ?dry_run=1
+func Requested(r *http.Request) bool {
+	enabled, err := strconv.ParseBool(r.URL.Query().Get(Param))
+	return err == nil && enabled
+}
+
+// With returns a context whose writes are rolled back instead of committed.
+func With(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// Enabled reports whether the context belongs to a dry run.
+func Enabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(dryRunKey{}).(bool)
+	return enabled
+}
+
+// Status returns the status of a successful response: 200 OK in place of 201
+// Created during a dry run, since nothing was created, and status otherwise.
+func Status(ctx context.Context, status int) int {
+	if status == http.StatusCreated && Enabled(ctx) {
+		return http.StatusOK
+	}
+	return status
+}
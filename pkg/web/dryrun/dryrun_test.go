@@ -0,0 +1,49 @@
+package dryrun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequested(t *testing.T) {
+	tests := []struct {
+		target string
+		want   bool
+	}{
+		{"/posts", false},
+		{"/posts?dry_run=true", true},
+		{"/posts?dry_run=1", true},
+		{"/posts?dry_run=false", false},
+		{"/posts?dry_run=yes", false},
+		{"/posts?dry_run=", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, tt.target, nil)
+		if got := Requested(r); got != tt.want {
+			t.Errorf("Requested(%s) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestWith(t *testing.T) {
+	ctx := context.Background()
+	if Enabled(ctx) {
+		t.Error("Enabled() = true without With")
+	}
+	if got := Status(ctx, http.StatusCreated); got != http.StatusCreated {
+		t.Errorf("Status() = %d, want %d", got, http.StatusCreated)
+	}
+
+	ctx = With(ctx)
+	if !Enabled(ctx) {
+		t.Error("Enabled() = false after With")
+	}
+	if got := Status(ctx, http.StatusCreated); got != http.StatusOK {
+		t.Errorf("Status() = %d in a dry run, want %d", got, http.StatusOK)
+	}
+	if got := Status(ctx, http.StatusOK); got != http.StatusOK {
+		t.Errorf("Status() = %d in a dry run, want %d", got, http.StatusOK)
+	}
+}
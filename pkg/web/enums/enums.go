@@ -0,0 +1,90 @@
+// Package enums serves the enum fields of generated applications with the
+// display labels declared by @labels, so UIs don't hard-code how enum values
+// are presented:
+//
+//	status: enum ["draft", "published"]! @labels(draft: "Draft", published: "Published")
+//
+// Path, under the API prefix, lists every enum field of every resource:
+//
+//	curl localhost:8080/enums
+//	{"Post": {"status": {"values": ["draft", "published"], "labels": {"draft": "Draft", "published": "Published"}, "label_key": "status_label"}}}
+//
+// Records carry labels on request: with ?include_labels=true, JSON responses
+// add the label of each labeled field under its label_key, next to the value.
+package enums
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/conduit-lang/conduit/pkg/web/response"
+)
+
+// Path serves the Catalog of an application
+const Path = "/enums"
+
+// IncludeParam is the query parameter that adds labels to records
+const IncludeParam = "include_labels"
+
+// Enum is an enum field: its values in declaration order and the display
+// labels of those that have one
+type Enum struct {
+	Values   []string          `json:"values"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	LabelKey string            `json:"label_key,omitempty"` // Attribute holding the label with ?include_labels=true
+}
+
+// Label returns the display label of value, or value itself without one
+func (e Enum) Label(value string) string {
+	if label, ok := e.Labels[value]; ok {
+		return label
+	}
+	return value
+}
+
+// Catalog maps resource names to their enum fields, by JSON attribute name
+type Catalog map[string]map[string]Enum
+
+// Labels returns the labels of the resource's enum fields that have a
+// LabelKey, ordered by field
+func (c Catalog) Labels(resource string) Labels {
+	var labels Labels
+	for field, enum := range c[resource] {
+		if enum.LabelKey != "" {
+			labels = append(labels, response.EnumLabels{Field: field, Key: enum.LabelKey, Labels: enum.Labels})
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Field < labels[j].Field
+	})
+	return labels
+}
+
+// Labels are the labeled enum fields of a resource
+type Labels []response.EnumLabels
+
+// For returns l when r asks for labels with ?include_labels=true, nil
+// otherwise; response.Labeled leaves records unchanged for nil labels
+func (l Labels) For(r *http.Request) Labels {
+	if len(l) == 0 || !Requested(r) {
+		return nil
+	}
+	return l
+}
+
+// Requested reports whether r asks for labels with ?include_labels=true
+func Requested(r *http.Request) bool {
+	include, _ := strconv.ParseBool(r.URL.Query().Get(IncludeParam))
+	return include
+}
+
+// Handler serves the catalog as JSON
+func Handler(c Catalog) http.HandlerFunc {
+	body, _ := json.Marshal(c)
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
@@ -0,0 +1,59 @@
+package enums
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var catalog = Catalog{
+	"Post": {
+		"status": {Values: []string{"draft", "published"}, Labels: map[string]string{"draft": "Draft"}, LabelKey: "status_label"},
+		"kind":   {Values: []string{"note", "article"}},
+	},
+}
+
+func TestEnum_Label(t *testing.T) {
+	status := catalog["Post"]["status"]
+	if got := status.Label("draft"); got != "Draft" {
+		t.Errorf("Label(draft) = %q, want Draft", got)
+	}
+	if got := status.Label("published"); got != "published" {
+		t.Errorf("Label(published) = %q, want the value itself", got)
+	}
+}
+
+func TestCatalog_Labels(t *testing.T) {
+	labels := catalog.Labels("Post")
+	if len(labels) != 1 || labels[0].Field != "status" || labels[0].Key != "status_label" {
+		t.Errorf("Labels(Post) = %+v, want status only", labels)
+	}
+	if labels := catalog.Labels("Comment"); labels != nil {
+		t.Errorf("Labels(Comment) = %+v, want nil", labels)
+	}
+
+	if got := labels.For(httptest.NewRequest(http.MethodGet, "/posts", nil)); got != nil {
+		t.Errorf("For() without %s = %+v, want nil", IncludeParam, got)
+	}
+	if got := labels.For(httptest.NewRequest(http.MethodGet, "/posts?include_labels=true", nil)); len(got) != 1 {
+		t.Errorf("For() with %s = %+v, want the labels", IncludeParam, got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(catalog).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GET %s = %d %v", Path, rec.Code, rec.Header())
+	}
+	for _, want := range []string{
+		`"status":{"values":["draft","published"],"labels":{"draft":"Draft"},"label_key":"status_label"}`,
+		`"kind":{"values":["note","article"]}`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("GET %s = %s, missing %s", Path, rec.Body.String(), want)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+// Package errortrack reports the panics and hook errors of a generated
+// application to an error tracker, with the resource, operation, hook and
+// .cdt source location they happened in. It does nothing until a Tracker is
+// set; generated applications set one at startup when conduit.yaml enables
+// error tracking:
+//
+//	error_tracking:
+//	  enabled: true
+//	  environment: production
+//
+// The built-in Client speaks the protocol of Sentry and of services
+// compatible with it, such as GlitchTip, and reads its DSN from SENTRY_DSN.
+// Other trackers plug in by implementing Tracker.
+//
+// Example:
+//
+//	client, err := errortrack.NewClient(errortrack.Options{DSN: os.Getenv(errortrack.DSNEnvVar)})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	errortrack.Set(client)
+//	defer errortrack.Flush(errortrack.FlushTimeout)
+//
+//	r.Use(middleware.Recoverer)
+//	r.Use(errortrack.Middleware)
+package errortrack
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/conduit-lang/conduit/pkg/web/correlation"
+	"github.com/conduit-lang/conduit/pkg/web/instrument"
+)
+
+// Level is the severity of an event
+type Level string
+
+// Levels of captured events
+const (
+	LevelFatal   Level = "fatal"   // Panics
+	LevelError   Level = "error"   // Hook errors that failed their operation
+	LevelWarning Level = "warning" // Hook errors a warn policy let the operation survive
+)
+
+// FlushTimeout bounds how long generated applications wait at shutdown for
+// captured events to be sent
+const FlushTimeout = 2 * time.Second
+
+// Event is an error reported to a tracker
+type Event struct {
+	ID    string // 32 lowercase hex digits
+	Time  time.Time
+	Level Level
+	Err   error  // The error, or the panic value as an error
+	Stack []byte // Stack of the goroutine that panicked; nil for errors
+
+	Resource  string // e.g. Post; empty outside resource handlers
+	Operation string // e.g. create
+	Hook      string // e.g. Post.AfterCreate; empty outside hooks
+	Source    string // .cdt location of the hook or resource, e.g. app/post.cdt:20
+	RequestID string
+	Method    string
+	Path      string
+}
+
+// Tracker receives captured events. Implementations must be safe for
+// concurrent use and should not block, since events are captured while
+// requests are served.
+type Tracker interface {
+	Capture(event *Event)
+	// Flush waits up to timeout for captured events to be delivered and
+	// reports whether they all were
+	Flush(timeout time.Duration) bool
+}
+
+var (
+	mu      sync.RWMutex
+	tracker Tracker
+	sources map[string]string
+)
+
+// Set installs the tracker events are captured with; nil disables tracking
+func Set(t Tracker) {
+	mu.Lock()
+	defer mu.Unlock()
+	tracker = t
+}
+
+// SetSources sets the .cdt locations of resources, e.g. "Post" to
+// "app/post.cdt:6", reported with panics in their handlers
+func SetSources(locations map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	sources = locations
+}
+
+// Enabled reports whether a tracker is set
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return tracker != nil
+}
+
+// Flush waits up to timeout for the tracker's events to be delivered. It
+// returns true at once without a tracker.
+func Flush(timeout time.Duration) bool {
+	mu.RLock()
+	t := tracker
+	mu.RUnlock()
+	if t == nil {
+		return true
+	}
+	return t.Flush(timeout)
+}
+
+// Capture reports event, filling in what ctx knows of it: the request ID, the
+// operation being served and the hook running. It does nothing without a
+// tracker.
+func Capture(ctx context.Context, event *Event) {
+	mu.RLock()
+	t, locations := tracker, sources
+	mu.RUnlock()
+	if t == nil {
+		return
+	}
+
+	if event.ID == "" {
+		event.ID = newID()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if event.Level == "" {
+		event.Level = LevelError
+	}
+	if event.RequestID == "" {
+		event.RequestID = correlation.ID(ctx)
+	}
+	if event.Resource == "" {
+		event.Resource, event.Operation, _ = instrument.OperationFromContext(ctx)
+	}
+	if hook, ok := ctx.Value(hookKey{}).(hookInfo); ok && event.Hook == "" {
+		event.Hook, event.Source = hook.name, hook.source
+	}
+	if event.Source == "" && event.Resource != "" {
+		event.Source = locations[event.Resource]
+	}
+	t.Capture(event)
+}
+
+// CaptureError reports err at level
+func CaptureError(ctx context.Context, level Level, err error) {
+	Capture(ctx, &Event{Level: level, Err: err})
+}
+
+type hookKey struct{}
+
+type hookInfo struct {
+	name   string
+	source string
+}
+
+// Hook runs a generated hook and reports the error it returns, with the
+// hook's name and .cdt source location. hook is passed a context naming the
+// hook, so errors its @on_error policy only logs are reported with it too. A
+// panic is noted for the request's Middleware to report and re-raised; outside
+// a request, such as in a batch job, it is reported here.
+func Hook(ctx context.Context, name, source string, hook func(ctx context.Context) error) error {
+	ctx = context.WithValue(ctx, hookKey{}, hookInfo{name: name, source: source})
+	defer func() {
+		if rec := recover(); rec != nil {
+			if state, ok := ctx.Value(requestKey{}).(*requestState); ok {
+				state.noteHook(name, source)
+			} else {
+				Capture(ctx, panicEvent(rec, debug.Stack()))
+			}
+			panic(rec)
+		}
+	}()
+
+	err := hook(ctx)
+	if err != nil {
+		Capture(ctx, &Event{Level: LevelError, Err: err})
+	}
+	return err
+}
+
+// Recover reports a panic in a goroutine started by a hook, such as an @async
+// block, with the hook's name and source location, and re-raises it once the
+// event is flushed: nothing recovers panics outside the request's goroutine, so
+// the process is about to exit. It must be deferred directly.
+func Recover(ctx context.Context, name, source string) {
+	rec := recover()
+	if rec == nil {
+		return
+	}
+	ctx = context.WithValue(ctx, hookKey{}, hookInfo{name: name, source: source})
+	Capture(ctx, panicEvent(rec, debug.Stack()))
+	Flush(FlushTimeout)
+	panic(rec)
+}
+
+type requestKey struct{}
+
+// requestState is the hook a request's panic came from, noted by Hook since
+// the hook's context is gone by the time Middleware recovers
+type requestState struct {
+	mu     sync.Mutex
+	hook   string
+	source string
+}
+
+// noteHook records the innermost hook that panicked
+func (s *requestState) noteHook(name, source string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hook == "" {
+		s.hook, s.source = name, source
+	}
+}
+
+func (s *requestState) get() (string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hook, s.source
+}
+
+// Middleware reports panics in the handlers it wraps, with the request's
+// operation and the hook that panicked, then re-raises them. It belongs inside
+// middleware.Recoverer, which answers the panic with a 500, and inside
+// requestlog.Middleware when both are used, so both see the same operation.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := &requestState{}
+		ctx := context.WithValue(r.Context(), requestKey{}, state)
+		recorder, ok := instrument.RecorderFromContext(ctx)
+		if !ok {
+			ctx, recorder = instrument.WithRecorder(ctx)
+		}
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec != http.ErrAbortHandler {
+				event := panicEvent(rec, debug.Stack())
+				event.Method = r.Method
+				event.Path = r.URL.Path
+				event.Resource, event.Operation, _ = recorder.Operation()
+				event.Hook, event.Source = state.get()
+				Capture(ctx, event)
+			}
+			panic(rec)
+		}()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// panicEvent returns the event of a recovered panic
+func panicEvent(rec interface{}, stack []byte) *Event {
+	err, ok := rec.(error)
+	if !ok {
+		err = fmt.Errorf("%v", rec)
+	}
+	return &Event{Level: LevelFatal, Err: err, Stack: stack}
+}
+
+// errorType names the innermost error err wraps, e.g. *pq.Error, which
+// trackers group events by
+func errorType(err error) string {
+	for {
+		inner := errors.Unwrap(err)
+		if inner == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = inner
+	}
+}
+
+// newID returns a random event ID
+func newID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,219 @@
+package errortrack
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/conduit-lang/conduit/pkg/web/instrument"
+)
+
+// fakeTracker records captured events
+type fakeTracker struct {
+	mu     sync.Mutex
+	events []*Event
+}
+
+func (f *fakeTracker) Capture(event *Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, event)
+}
+
+func (f *fakeTracker) Flush(time.Duration) bool { return true }
+
+func (f *fakeTracker) captured(t *testing.T, want int) []*Event {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.events) != want {
+		t.Fatalf("captured %d events, want %d", len(f.events), want)
+	}
+	return f.events
+}
+
+// track installs a fake tracker for the duration of the test
+func track(t *testing.T) *fakeTracker {
+	t.Helper()
+	tracker := &fakeTracker{}
+	Set(tracker)
+	SetSources(map[string]string{"Post": "app/post.cdt:1"})
+	t.Cleanup(func() {
+		Set(nil)
+		SetSources(nil)
+	})
+	return tracker
+}
+
+func TestCaptureDisabled(t *testing.T) {
+	if Enabled() {
+		t.Fatal("Enabled() should be false without a tracker")
+	}
+	// Nothing to capture with, and nothing to flush
+	CaptureError(context.Background(), LevelError, errors.New("boom"))
+	if !Flush(time.Millisecond) {
+		t.Error("Flush() should succeed without a tracker")
+	}
+}
+
+func TestCaptureFillsContext(t *testing.T) {
+	tracker := track(t)
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-1")
+	ctx = instrument.WithOperation(ctx, "Post", "create")
+	CaptureError(ctx, LevelError, errors.New("boom"))
+
+	event := tracker.captured(t, 1)[0]
+	if len(event.ID) != 32 || event.Time.IsZero() {
+		t.Errorf("ID = %q, Time = %v", event.ID, event.Time)
+	}
+	if event.RequestID != "req-1" || event.Resource != "Post" || event.Operation != "create" {
+		t.Errorf("RequestID, Resource, Operation = %q, %q, %q", event.RequestID, event.Resource, event.Operation)
+	}
+	if event.Source != "app/post.cdt:1" {
+		t.Errorf("Source = %q, want the resource's location", event.Source)
+	}
+}
+
+func TestHook(t *testing.T) {
+	tracker := track(t)
+	ctx := instrument.WithOperation(context.Background(), "Post", "create")
+
+	if err := Hook(ctx, "Post.AfterCreate", "app/post.cdt:20", func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("Hook() error = %v", err)
+	}
+	tracker.captured(t, 0)
+
+	hookErr := errors.New("notify failed")
+	if err := Hook(ctx, "Post.AfterCreate", "app/post.cdt:20", func(context.Context) error { return hookErr }); err != hookErr {
+		t.Fatalf("Hook() error = %v, want the hook's error", err)
+	}
+	event := tracker.captured(t, 1)[0]
+	if event.Level != LevelError || event.Err != hookErr {
+		t.Errorf("Level, Err = %q, %v", event.Level, event.Err)
+	}
+	if event.Hook != "Post.AfterCreate" || event.Source != "app/post.cdt:20" || event.Operation != "create" {
+		t.Errorf("Hook, Source, Operation = %q, %q, %q", event.Hook, event.Source, event.Operation)
+	}
+}
+
+func TestHookContextNamesHook(t *testing.T) {
+	tracker := track(t)
+
+	// Errors a warn policy swallows are captured with the hook's context
+	Hook(context.Background(), "Post.AfterCreate", "app/post.cdt:20", func(ctx context.Context) error {
+		CaptureError(ctx, LevelWarning, errors.New("swallowed"))
+		return nil
+	})
+
+	event := tracker.captured(t, 1)[0]
+	if event.Level != LevelWarning || event.Hook != "Post.AfterCreate" || event.Source != "app/post.cdt:20" {
+		t.Errorf("Level, Hook, Source = %q, %q, %q", event.Level, event.Hook, event.Source)
+	}
+}
+
+func TestHookPanicOutsideRequest(t *testing.T) {
+	tracker := track(t)
+
+	func() {
+		defer func() {
+			if rec := recover(); rec != "nil record" {
+				t.Errorf("recovered %v, want the hook's panic", rec)
+			}
+		}()
+		Hook(context.Background(), "Post.AfterCreate", "app/post.cdt:20", func(context.Context) error {
+			panic("nil record")
+		})
+	}()
+
+	event := tracker.captured(t, 1)[0]
+	if event.Level != LevelFatal || event.Err.Error() != "nil record" || len(event.Stack) == 0 {
+		t.Errorf("Level, Err, stack = %q, %v, %d bytes", event.Level, event.Err, len(event.Stack))
+	}
+}
+
+func TestRecover(t *testing.T) {
+	tracker := track(t)
+
+	done := make(chan interface{})
+	go func() {
+		defer func() { done <- recover() }()
+		defer Recover(context.Background(), "Post.AfterCreate", "app/post.cdt:24")
+		panic("async failed")
+	}()
+	if rec := <-done; rec != "async failed" {
+		t.Errorf("recovered %v, want the panic re-raised", rec)
+	}
+
+	event := tracker.captured(t, 1)[0]
+	if event.Level != LevelFatal || event.Hook != "Post.AfterCreate" || event.Source != "app/post.cdt:24" {
+		t.Errorf("Level, Hook, Source = %q, %q, %q", event.Level, event.Hook, event.Source)
+	}
+
+	// Without a panic there is nothing to report
+	func() {
+		defer Recover(context.Background(), "Post.AfterCreate", "app/post.cdt:24")
+	}()
+	tracker.captured(t, 1)
+}
+
+func TestMiddleware(t *testing.T) {
+	tracker := track(t)
+
+	handler := middleware.RequestID(middleware.Recoverer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := instrument.WithOperation(r.Context(), "Post", "create")
+		Hook(ctx, "Post.BeforeCreate", "app/post.cdt:12", func(context.Context) error {
+			panic(errors.New("index out of range"))
+		})
+	}))))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/posts?token=secret", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500 from Recoverer", rec.Code)
+	}
+
+	// The panic is captured once, by the middleware, with the hook it came from
+	event := tracker.captured(t, 1)[0]
+	if event.Level != LevelFatal || event.Err.Error() != "index out of range" {
+		t.Errorf("Level, Err = %q, %v", event.Level, event.Err)
+	}
+	if event.Resource != "Post" || event.Operation != "create" {
+		t.Errorf("Resource, Operation = %q, %q", event.Resource, event.Operation)
+	}
+	if event.Hook != "Post.BeforeCreate" || event.Source != "app/post.cdt:12" {
+		t.Errorf("Hook, Source = %q, %q", event.Hook, event.Source)
+	}
+	if event.Method != http.MethodPost || event.Path != "/posts" || event.RequestID == "" {
+		t.Errorf("Method, Path, RequestID = %q, %q, %q", event.Method, event.Path, event.RequestID)
+	}
+	if !strings.Contains(string(event.Stack), "errortrack_test") {
+		t.Error("Stack should include the frames that panicked")
+	}
+}
+
+func TestMiddlewareAbortHandler(t *testing.T) {
+	tracker := track(t)
+
+	handler := Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	func() {
+		defer func() {
+			if rec := recover(); rec != http.ErrAbortHandler {
+				t.Errorf("recovered %v, want http.ErrAbortHandler", rec)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	// Aborted responses are not errors
+	tracker.captured(t, 0)
+}
@@ -0,0 +1,272 @@
+package errortrack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DSNEnvVar holds the DSN of the project events are sent to
+	DSNEnvVar = "SENTRY_DSN"
+	// EnvironmentEnvVar overrides the configured environment
+	EnvironmentEnvVar = "SENTRY_ENVIRONMENT"
+
+	// QueueSize is how many events a Client buffers while sending; events
+	// captured while the buffer is full are dropped
+	QueueSize = 100
+
+	// SendTimeout bounds each request to the tracker when Options has no
+	// HTTPClient
+	SendTimeout = 10 * time.Second
+
+	clientName = "conduit/1.0"
+)
+
+// Config is the error_tracking section of conduit.yaml, without the DSN
+type Config struct {
+	Environment string
+	Release     string
+}
+
+// Configure sets a Client sending to the DSN in SENTRY_DSN as the tracker.
+// Without the variable tracking stays disabled, so a build with error tracking
+// enabled still runs where no tracker is set up, such as on a laptop.
+func Configure(cfg Config) error {
+	dsn := os.Getenv(DSNEnvVar)
+	if dsn == "" {
+		log.Printf("errortrack: %s is not set, error tracking disabled", DSNEnvVar)
+		return nil
+	}
+	environment := cfg.Environment
+	if value := os.Getenv(EnvironmentEnvVar); value != "" {
+		environment = value
+	}
+
+	client, err := NewClient(Options{DSN: dsn, Environment: environment, Release: cfg.Release})
+	if err != nil {
+		return err
+	}
+	Set(client)
+	return nil
+}
+
+// Options configures a Client
+type Options struct {
+	// DSN of the project, e.g. https://<key>@o1.ingest.sentry.io/<project>
+	DSN         string
+	Environment string // e.g. production
+	Release     string // Version of the application, e.g. its git commit
+	ServerName  string // Host name when empty
+	HTTPClient  *http.Client
+}
+
+// Client sends events to a Sentry-compatible tracker in the background
+type Client struct {
+	endpoint string
+	auth     string
+	opts     Options
+	queue    chan []byte
+	pending  atomic.Int64
+}
+
+// NewClient returns a Client sending to the project of opts.DSN
+func NewClient(opts Options) (*Client, error) {
+	endpoint, auth, err := parseDSN(opts.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ServerName == "" {
+		opts.ServerName, _ = os.Hostname()
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: SendTimeout}
+	}
+
+	c := &Client{
+		endpoint: endpoint,
+		auth:     auth,
+		opts:     opts,
+		queue:    make(chan []byte, QueueSize),
+	}
+	go c.run()
+	return c, nil
+}
+
+// parseDSN returns the store endpoint and auth header of a DSN. Errors never
+// include the DSN, which holds the project's key.
+func parseDSN(dsn string) (endpoint, auth string, err error) {
+	if dsn == "" {
+		return "", "", fmt.Errorf("%s is required for error tracking", DSNEnvVar)
+	}
+	u, err := url.Parse(dsn)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", "", errors.New("error tracking DSN must be an http or https URL")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return "", "", errors.New("error tracking DSN has no key")
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	if i < 0 || path[i+1:] == "" {
+		return "", "", errors.New("error tracking DSN has no project")
+	}
+	prefix, project := path[:i], path[i+1:]
+
+	auth = fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", clientName, u.User.Username())
+	if secret, ok := u.User.Password(); ok && secret != "" {
+		auth += ", sentry_secret=" + secret
+	}
+	return fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project), auth, nil
+}
+
+// Capture queues event to be sent
+func (c *Client) Capture(event *Event) {
+	body, err := json.Marshal(c.payload(event))
+	if err != nil {
+		log.Printf("errortrack: failed to encode event %s: %v", event.ID, err)
+		return
+	}
+
+	c.pending.Add(1)
+	select {
+	case c.queue <- body:
+	default:
+		c.pending.Add(-1)
+		log.Printf("errortrack: queue full, dropping event %s", event.ID)
+	}
+}
+
+// Flush waits up to timeout for queued events to be sent
+func (c *Client) Flush(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for c.pending.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
+func (c *Client) run() {
+	for body := range c.queue {
+		c.send(body)
+		c.pending.Add(-1)
+	}
+}
+
+// send posts an event; failures are logged, since there is nowhere else to
+// report them
+func (c *Client) send(body []byte) {
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("errortrack: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", c.auth)
+
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		log.Printf("errortrack: failed to send event: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		log.Printf("errortrack: tracker responded %s", resp.Status)
+	}
+}
+
+// payload is an event in the tracker's JSON format
+type payload struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       Level             `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	Transaction string            `json:"transaction,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Exception   exceptions        `json:"exception"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]string `json:"extra,omitempty"`
+	Request     *request          `json:"request,omitempty"`
+}
+
+type exceptions struct {
+	Values []exception `json:"values"`
+}
+
+type exception struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+func (c *Client) payload(event *Event) payload {
+	p := payload{
+		EventID:     event.ID,
+		Timestamp:   event.Time.UTC().Format(time.RFC3339Nano),
+		Level:       event.Level,
+		Platform:    "go",
+		Logger:      "conduit",
+		Environment: c.opts.Environment,
+		Release:     c.opts.Release,
+		ServerName:  c.opts.ServerName,
+		Tags:        map[string]string{},
+		Extra:       map[string]string{},
+	}
+
+	exc := exception{Type: "error"}
+	if event.Err != nil {
+		exc = exception{Type: errorType(event.Err), Value: event.Err.Error()}
+	}
+	if event.Stack != nil {
+		exc.Type = "panic"
+		p.Extra["stack"] = string(event.Stack)
+	}
+	p.Exception.Values = []exception{exc}
+
+	// Events group by where they happened: the hook, or else the operation
+	switch {
+	case event.Hook != "":
+		p.Transaction = event.Hook
+	case event.Resource != "":
+		p.Transaction = event.Resource + "." + event.Operation
+	}
+
+	for key, value := range map[string]string{
+		"resource":   event.Resource,
+		"operation":  event.Operation,
+		"hook":       event.Hook,
+		"request_id": event.RequestID,
+	} {
+		if value != "" {
+			p.Tags[key] = value
+		}
+	}
+	if event.Source != "" {
+		p.Extra["source"] = event.Source
+	}
+	if event.Method != "" {
+		p.Request = &request{Method: event.Method, URL: event.Path}
+	}
+	return p
+}
@@ -0,0 +1,97 @@
+// Package explain shows how list requests translate to SQL. In development, a
+// list request with ?explain=true gets the generated query, its arguments and
+// the database's EXPLAIN plan in meta alongside the results, which helps check
+// filters, sorts and pagination and spot missing indexes.
+//
+// Plans reveal the schema and query arguments, so explaining is disabled when
+// the process looks like a production deployment unless CONDUIT_EXPLAIN
+// enables it.
+//
+// Example:
+//
+//	if explain.Requested(r) {
+//		plan, err := explain.Query(ctx, db, listQuery, args...)
+//		...
+//		meta["explain"] = plan
+//	}
+package explain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Param is the query parameter requesting a plan
+const Param = "explain"
+
+// EnvVar overrides the environment check: "false", "0" or "off" disables
+// explaining and "true", "1" or "on" enables it even in production.
+const EnvVar = "CONDUIT_EXPLAIN"
+
+// environmentVars are checked for "production", matching the playground
+var environmentVars = []string{"CONDUIT_ENV", "ENV", "ENVIRONMENT", "APP_ENV", "GO_ENV"}
+
+// Plan is a query and how the database runs it.
+type Plan struct {
+	SQL  string        `json:"sql"`
+	Args []interface{} `json:"args"`
+	// Plan holds the lines of the EXPLAIN output
+	Plan []string `json:"plan"`
+}
+
+// Enabled reports whether requests may ask for plans. CONDUIT_EXPLAIN wins
+// when set; otherwise explaining is disabled in production.
+func Enabled() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(EnvVar))) {
+	case "false", "0", "off":
+		return false
+	case "true", "1", "on":
+		return true
+	}
+	for _, name := range environmentVars {
+		switch strings.ToLower(strings.TrimSpace(os.Getenv(name))) {
+		case "production", "prod", "prd":
+			return false
+		}
+	}
+	return true
+}
+
+// Requested reports whether the request asks for a plan with ?explain=true
+// and plans are enabled. Production requests asking for one get the results
+// alone.
+func Requested(r *http.Request) bool {
+	requested, err := strconv.ParseBool(r.URL.Query().Get(Param))
+	return err == nil && requested && Enabled()
+}
+
+// Query explains query without running it. The plan shows the planner's
+// estimates, not actual row counts or timings.
+func Query(ctx context.Context, db *sql.DB, query string, args ...interface{}) (*Plan, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN "+query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+	defer rows.Close()
+
+	plan := &Plan{SQL: query, Args: args, Plan: []string{}}
+	if plan.Args == nil {
+		plan.Args = []interface{}{}
+	}
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("failed to read query plan: %w", err)
+		}
+		plan.Plan = append(plan.Plan, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read query plan: %w", err)
+	}
+	return plan, nil
+}
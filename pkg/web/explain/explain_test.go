@@ -0,0 +1,83 @@
+package explain
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		env      string
+		want     bool
+	}{
+		{"development", "", "", true},
+		{"production", "", "production", false},
+		{"disabled", "off", "", false},
+		{"enabled in production", "on", "production", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvVar, tt.override)
+			t.Setenv("CONDUIT_ENV", tt.env)
+			if got := Enabled(); got != tt.want {
+				t.Errorf("Enabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequested(t *testing.T) {
+	t.Setenv(EnvVar, "")
+	t.Setenv("CONDUIT_ENV", "")
+
+	for target, want := range map[string]bool{
+		"/posts":               false,
+		"/posts?explain=true":  true,
+		"/posts?explain=1":     true,
+		"/posts?explain=false": false,
+	} {
+		if got := Requested(httptest.NewRequest(http.MethodGet, target, nil)); got != want {
+			t.Errorf("Requested(%s) = %v, want %v", target, got, want)
+		}
+	}
+
+	t.Setenv("CONDUIT_ENV", "production")
+	if Requested(httptest.NewRequest(http.MethodGet, "/posts?explain=true", nil)) {
+		t.Error("Requested() = true in production")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	query := "SELECT id, title FROM posts WHERE title = $1 LIMIT 20"
+	mock.ExpectQuery(regexp.QuoteMeta("EXPLAIN " + query)).WithArgs("Hello").
+		WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).
+			AddRow("Limit  (cost=0.00..25.88 rows=6 width=48)").
+			AddRow("  ->  Seq Scan on posts  (cost=0.00..25.88 rows=6 width=48)"))
+
+	plan, err := Query(context.Background(), db, query, "Hello")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if plan.SQL != query || len(plan.Args) != 1 || plan.Args[0] != "Hello" {
+		t.Errorf("plan = %+v, want the query and its arguments", plan)
+	}
+	if len(plan.Plan) != 2 || plan.Plan[1] != "  ->  Seq Scan on posts  (cost=0.00..25.88 rows=6 width=48)" {
+		t.Errorf("plan.Plan = %q", plan.Plan)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
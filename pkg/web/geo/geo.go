@@ -0,0 +1,337 @@
+// Package geo provides the Go values of Conduit's point and polygon field
+// types. Fields are stored in PostGIS geography columns with SRID 4326 (WGS 84
+// longitude/latitude) and exchanged with clients as GeoJSON geometries:
+//
+//	{"type": "Point", "coordinates": [-122.4194, 37.7749]}
+//	{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}
+//
+// Values implement sql.Scanner for the (E)WKB that PostgreSQL drivers return
+// for geography columns, and driver.Valuer as EWKT, so generated models can
+// read and write them like any other column.
+package geo
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SRID is the spatial reference system of every geography column (WGS 84)
+const SRID = 4326
+
+// WKB geometry type codes
+const (
+	wkbPoint   = 1
+	wkbPolygon = 3
+)
+
+// EWKB flags set on the geometry type code
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// Point is a WGS 84 position.
+type Point struct {
+	Lng float64 // Longitude in degrees, -180 to 180
+	Lat float64 // Latitude in degrees, -90 to 90
+}
+
+// Validate reports whether the coordinates are within range.
+func (p Point) Validate() error {
+	if math.IsNaN(p.Lng) || p.Lng < -180 || p.Lng > 180 {
+		return fmt.Errorf("longitude %v out of range [-180, 180]", p.Lng)
+	}
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("latitude %v out of range [-90, 90]", p.Lat)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the point as a GeoJSON Point.
+func (p Point) MarshalJSON() ([]byte, error) {
+	return json.Marshal(geometry{Type: "Point", Coordinates: p.position()})
+}
+
+// UnmarshalJSON decodes a GeoJSON Point.
+func (p *Point) UnmarshalJSON(data []byte) error {
+	var g struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	}
+	if err := json.Unmarshal(data, &g); err != nil {
+		return fmt.Errorf("invalid GeoJSON point: %w", err)
+	}
+	if g.Type != "Point" {
+		return fmt.Errorf("invalid GeoJSON point: type is %q, want \"Point\"", g.Type)
+	}
+	point, err := fromPosition(g.Coordinates)
+	if err != nil {
+		return fmt.Errorf("invalid GeoJSON point: %w", err)
+	}
+	*p = point
+	return nil
+}
+
+// Value encodes the point as EWKT for a geography column.
+func (p Point) Value() (driver.Value, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf("SRID=%d;POINT(%s)", SRID, p.wkt()), nil
+}
+
+// Scan decodes a point from (E)WKB, raw or hex encoded.
+func (p *Point) Scan(src interface{}) error {
+	r, err := newReader(src, wkbPoint)
+	if err != nil {
+		return err
+	}
+	point, err := r.point()
+	if err != nil {
+		return err
+	}
+	*p = point
+	return nil
+}
+
+func (p Point) position() []float64 {
+	return []float64{p.Lng, p.Lat}
+}
+
+func (p Point) wkt() string {
+	return strconv.FormatFloat(p.Lng, 'f', -1, 64) + " " + strconv.FormatFloat(p.Lat, 'f', -1, 64)
+}
+
+func fromPosition(position []float64) (Point, error) {
+	if len(position) < 2 {
+		return Point{}, errors.New("position needs longitude and latitude")
+	}
+	point := Point{Lng: position[0], Lat: position[1]}
+	return point, point.Validate()
+}
+
+// Polygon is an area bounded by linear rings: the exterior ring first, then
+// any holes. Each ring is closed, repeating its first point at the end.
+type Polygon [][]Point
+
+// Validate reports whether the polygon has an exterior ring and every ring is
+// closed with at least four points.
+func (p Polygon) Validate() error {
+	if len(p) == 0 {
+		return errors.New("polygon needs an exterior ring")
+	}
+	for i, ring := range p {
+		if len(ring) < 4 {
+			return fmt.Errorf("ring %d has %d points, want at least 4", i, len(ring))
+		}
+		if ring[0] != ring[len(ring)-1] {
+			return fmt.Errorf("ring %d is not closed", i)
+		}
+		for _, point := range ring {
+			if err := point.Validate(); err != nil {
+				return fmt.Errorf("ring %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the polygon as a GeoJSON Polygon.
+func (p Polygon) MarshalJSON() ([]byte, error) {
+	rings := make([][][]float64, len(p))
+	for i, ring := range p {
+		rings[i] = make([][]float64, len(ring))
+		for j, point := range ring {
+			rings[i][j] = point.position()
+		}
+	}
+	return json.Marshal(geometry{Type: "Polygon", Coordinates: rings})
+}
+
+// UnmarshalJSON decodes a GeoJSON Polygon.
+func (p *Polygon) UnmarshalJSON(data []byte) error {
+	var g struct {
+		Type        string        `json:"type"`
+		Coordinates [][][]float64 `json:"coordinates"`
+	}
+	if err := json.Unmarshal(data, &g); err != nil {
+		return fmt.Errorf("invalid GeoJSON polygon: %w", err)
+	}
+	if g.Type != "Polygon" {
+		return fmt.Errorf("invalid GeoJSON polygon: type is %q, want \"Polygon\"", g.Type)
+	}
+
+	polygon := make(Polygon, len(g.Coordinates))
+	for i, ring := range g.Coordinates {
+		polygon[i] = make([]Point, len(ring))
+		for j, position := range ring {
+			point, err := fromPosition(position)
+			if err != nil {
+				return fmt.Errorf("invalid GeoJSON polygon: %w", err)
+			}
+			polygon[i][j] = point
+		}
+	}
+	if err := polygon.Validate(); err != nil {
+		return fmt.Errorf("invalid GeoJSON polygon: %w", err)
+	}
+	*p = polygon
+	return nil
+}
+
+// Value encodes the polygon as EWKT for a geography column.
+func (p Polygon) Value() (driver.Value, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	rings := make([]string, len(p))
+	for i, ring := range p {
+		points := make([]string, len(ring))
+		for j, point := range ring {
+			points[j] = point.wkt()
+		}
+		rings[i] = "(" + strings.Join(points, ", ") + ")"
+	}
+	return fmt.Sprintf("SRID=%d;POLYGON(%s)", SRID, strings.Join(rings, ", ")), nil
+}
+
+// Scan decodes a polygon from (E)WKB, raw or hex encoded.
+func (p *Polygon) Scan(src interface{}) error {
+	r, err := newReader(src, wkbPolygon)
+	if err != nil {
+		return err
+	}
+
+	numRings, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	polygon := make(Polygon, 0, numRings)
+	for i := uint32(0); i < numRings; i++ {
+		numPoints, err := r.uint32()
+		if err != nil {
+			return err
+		}
+		ring := make([]Point, 0, numPoints)
+		for j := uint32(0); j < numPoints; j++ {
+			point, err := r.point()
+			if err != nil {
+				return err
+			}
+			ring = append(ring, point)
+		}
+		polygon = append(polygon, ring)
+	}
+	*p = polygon
+	return nil
+}
+
+// geometry is the GeoJSON encoding of a geometry
+type geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// reader decodes the body of a WKB or EWKB geometry
+type reader struct {
+	buf        *bytes.Reader
+	order      binary.ByteOrder
+	dimensions int
+}
+
+// newReader reads the header of a geometry of the expected type. Drivers
+// return geography columns as hex-encoded EWKB text or as raw bytes.
+func newReader(src interface{}, wantType uint32) (*reader, error) {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return nil, fmt.Errorf("cannot scan %T into a geometry", src)
+	}
+
+	if isHex(data) {
+		decoded := make([]byte, hex.DecodedLen(len(data)))
+		if _, err := hex.Decode(decoded, data); err != nil {
+			return nil, fmt.Errorf("invalid hex geometry: %w", err)
+		}
+		data = decoded
+	}
+	if len(data) < 5 {
+		return nil, errors.New("geometry too short")
+	}
+
+	r := &reader{buf: bytes.NewReader(data[1:]), order: binary.LittleEndian, dimensions: 2}
+	if data[0] == 0 {
+		r.order = binary.BigEndian
+	}
+
+	typeCode, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if typeCode&ewkbZ != 0 {
+		r.dimensions++
+	}
+	if typeCode&ewkbM != 0 {
+		r.dimensions++
+	}
+	if typeCode&ewkbSRID != 0 {
+		if _, err := r.uint32(); err != nil {
+			return nil, err
+		}
+	}
+
+	// ISO WKB encodes Z and M as thousands: 1001 is PointZ, 3002 PolygonM
+	baseType := typeCode & 0x0FFFFFFF
+	switch baseType / 1000 {
+	case 1, 2:
+		r.dimensions++
+	case 3:
+		r.dimensions += 2
+	}
+	if baseType%1000 != wantType {
+		return nil, fmt.Errorf("geometry type %d, want %d", baseType%1000, wantType)
+	}
+	return r, nil
+}
+
+func (r *reader) uint32() (uint32, error) {
+	var v uint32
+	if err := binary.Read(r.buf, r.order, &v); err != nil {
+		return 0, fmt.Errorf("truncated geometry: %w", err)
+	}
+	return v, nil
+}
+
+// point reads one position, dropping Z and M values
+func (r *reader) point() (Point, error) {
+	coords := make([]float64, r.dimensions)
+	if err := binary.Read(r.buf, r.order, coords); err != nil {
+		return Point{}, fmt.Errorf("truncated geometry: %w", err)
+	}
+	return Point{Lng: coords[0], Lat: coords[1]}, nil
+}
+
+func isHex(data []byte) bool {
+	if len(data) == 0 || len(data)%2 != 0 {
+		return false
+	}
+	for _, c := range data {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+package geo
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPointJSON(t *testing.T) {
+	point := Point{Lng: -122.4194, Lat: 37.7749}
+
+	data, err := json.Marshal(point)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `{"type":"Point","coordinates":[-122.4194,37.7749]}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var decoded Point
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded != point {
+		t.Errorf("Unmarshal() = %+v, want %+v", decoded, point)
+	}
+}
+
+func TestPointJSONInvalid(t *testing.T) {
+	inputs := []string{
+		`{"type":"Polygon","coordinates":[1,2]}`,
+		`{"type":"Point","coordinates":[1]}`,
+		`{"type":"Point","coordinates":[181,0]}`,
+		`{"type":"Point","coordinates":[0,-91]}`,
+		`"37.7,-122.4"`,
+	}
+	for _, input := range inputs {
+		var point Point
+		if err := json.Unmarshal([]byte(input), &point); err == nil {
+			t.Errorf("Unmarshal(%s) should fail", input)
+		}
+	}
+}
+
+func TestPolygonJSON(t *testing.T) {
+	input := `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}`
+
+	var polygon Polygon
+	if err := json.Unmarshal([]byte(input), &polygon); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}
+	if !reflect.DeepEqual(polygon, want) {
+		t.Errorf("Unmarshal() = %v, want %v", polygon, want)
+	}
+
+	data, err := json.Marshal(polygon)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(data) != input {
+		t.Errorf("Marshal() = %s, want %s", data, input)
+	}
+
+	// Rings must be closed and have at least four points
+	for _, invalid := range []string{
+		`{"type":"Polygon","coordinates":[]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}`,
+	} {
+		if err := json.Unmarshal([]byte(invalid), &polygon); err == nil {
+			t.Errorf("Unmarshal(%s) should fail", invalid)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	value, err := Point{Lng: -122.4194, Lat: 37.7749}.Value()
+	if err != nil || value != "SRID=4326;POINT(-122.4194 37.7749)" {
+		t.Errorf("Point.Value() = %v, %v", value, err)
+	}
+
+	value, err = Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}.Value()
+	if err != nil || value != "SRID=4326;POLYGON((0 0, 1 0, 1 1, 0 0))" {
+		t.Errorf("Polygon.Value() = %v, %v", value, err)
+	}
+
+	if _, err := (Point{Lng: 200}).Value(); err == nil {
+		t.Error("Point.Value() should reject out of range coordinates")
+	}
+}
+
+// ewkb encodes a geometry header followed by values, as PostGIS does
+func ewkb(order binary.AppendByteOrder, typeCode uint32, srid bool, values ...interface{}) []byte {
+	buf := []byte{1}
+	if order == binary.AppendByteOrder(binary.BigEndian) {
+		buf[0] = 0
+	}
+	if srid {
+		typeCode |= ewkbSRID
+	}
+	buf = order.AppendUint32(buf, typeCode)
+	if srid {
+		buf = order.AppendUint32(buf, SRID)
+	}
+	for _, value := range values {
+		switch v := value.(type) {
+		case uint32:
+			buf = order.AppendUint32(buf, v)
+		case float64:
+			buf = order.AppendUint64(buf, math.Float64bits(v))
+		}
+	}
+	return buf
+}
+
+func TestPointScan(t *testing.T) {
+	want := Point{Lng: -122.4194, Lat: 37.7749}
+	tests := []struct {
+		name string
+		src  interface{}
+	}{
+		{"hex EWKB", hex.EncodeToString(ewkb(binary.LittleEndian, wkbPoint, true, want.Lng, want.Lat))},
+		{"hex EWKB bytes", []byte(hex.EncodeToString(ewkb(binary.LittleEndian, wkbPoint, true, want.Lng, want.Lat)))},
+		{"raw big endian WKB", ewkb(binary.BigEndian, wkbPoint, false, want.Lng, want.Lat)},
+		{"EWKB with Z", ewkb(binary.LittleEndian, wkbPoint|ewkbZ, true, want.Lng, want.Lat, 12.5)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var point Point
+			if err := point.Scan(tt.src); err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if point != want {
+				t.Errorf("Scan() = %+v, want %+v", point, want)
+			}
+		})
+	}
+}
+
+func TestPolygonScan(t *testing.T) {
+	src := ewkb(binary.LittleEndian, wkbPolygon, true,
+		uint32(1), uint32(4),
+		0.0, 0.0, 1.0, 0.0, 1.0, 1.0, 0.0, 0.0)
+
+	var polygon Polygon
+	if err := polygon.Scan(hex.EncodeToString(src)); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	want := Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}
+	if !reflect.DeepEqual(polygon, want) {
+		t.Errorf("Scan() = %v, want %v", polygon, want)
+	}
+}
+
+func TestScanInvalid(t *testing.T) {
+	var point Point
+	if err := point.Scan(ewkb(binary.LittleEndian, wkbPolygon, true, uint32(0))); err == nil {
+		t.Error("Point.Scan() should reject a polygon")
+	}
+	if err := point.Scan(ewkb(binary.LittleEndian, wkbPoint, true, 1.0)); err == nil {
+		t.Error("Point.Scan() should reject a truncated point")
+	}
+	if err := point.Scan(42); err == nil {
+		t.Error("Point.Scan() should reject non-binary values")
+	}
+}
@@ -0,0 +1,118 @@
+// Package hooks enforces the timeouts and failure policies of lifecycle hooks.
+//
+// A hook declared with @timeout runs under a context canceled once the timeout
+// passes, so the database calls it makes with the context stop. It is derived
+// from the request's context, so a canceled request cancels its hooks too.
+//
+// A hook declared with @on_error is retried up to the policy's number of
+// retries, waiting between attempts; if it still fails, the policy decides
+// what happens to the operation that ran it:
+//
+//   - abort: the error fails the operation (the default, e.g. for validation
+//     in before hooks)
+//   - warn: the error is logged and the operation goes on (e.g. for
+//     notifications sent by after hooks)
+//
+// Example:
+//
+//	return hooks.WithTimeout(ctx, "Post.AfterCreate", 2*time.Second, func(ctx context.Context) error {
+//		policy := hooks.Policy{Retries: 3, Backoff: hooks.Exponential, OnFailure: hooks.Warn}
+//		return hooks.Run(ctx, "Post.AfterCreate", policy, func() error {
+//			...
+//		})
+//	})
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/conduit-lang/conduit/pkg/web/correlation"
+	"github.com/conduit-lang/conduit/pkg/web/errortrack"
+)
+
+// Backoff is how long a hook waits between attempts.
+type Backoff string
+
+// Backoffs accepted by @on_error
+const (
+	Constant    Backoff = "constant"    // BaseDelay before every retry
+	Exponential Backoff = "exponential" // BaseDelay doubling with every retry
+)
+
+// Failure is what happens to an operation whose hook failed every attempt.
+type Failure string
+
+// Policies accepted by @on_error
+const (
+	Abort Failure = "abort"
+	Warn  Failure = "warn"
+)
+
+// BaseDelay is the wait before a hook's first retry.
+var BaseDelay = 100 * time.Millisecond
+
+// Policy is the failure policy of a hook.
+type Policy struct {
+	// Retries is the number of attempts after the first failure
+	Retries int
+	Backoff Backoff
+	// OnFailure is Abort when empty
+	OnFailure Failure
+}
+
+// Delay returns the wait before the given retry, counting from 1.
+func (p Policy) Delay(retry int) time.Duration {
+	if p.Backoff == Exponential {
+		return BaseDelay << (retry - 1)
+	}
+	return BaseDelay
+}
+
+// Run runs hook under the policy. It returns nil when an attempt succeeds or
+// the policy is Warn, and the last error otherwise. Retries stop early when
+// ctx is done. name identifies the hook in logs.
+func Run(ctx context.Context, name string, policy Policy, hook func() error) error {
+	err := hook()
+	for retry := 1; err != nil && retry <= policy.Retries; retry++ {
+		timer := time.NewTimer(policy.Delay(retry))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return failed(ctx, name, policy, err)
+		case <-timer.C:
+		}
+		err = hook()
+	}
+	if err != nil {
+		return failed(ctx, name, policy, err)
+	}
+	return nil
+}
+
+// failed applies the policy to the error of a hook's last attempt. Warnings
+// name the request that ran the hook, and go to the error tracker when one is
+// set, since nothing else reports them.
+func failed(ctx context.Context, name string, policy Policy, err error) error {
+	if policy.OnFailure == Warn {
+		correlation.Logf(ctx, "hooks: %s failed, continuing: %v", name, err)
+		errortrack.CaptureError(ctx, errortrack.LevelWarning, fmt.Errorf("%s failed: %w", name, err))
+		return nil
+	}
+	return err
+}
+
+// WithTimeout runs hook with a context canceled after timeout. When the hook
+// fails once the timeout passed, the error says which hook timed out.
+func WithTimeout(ctx context.Context, name string, timeout time.Duration, hook func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := hook(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s timed out after %s: %w", name, timeout, err)
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduit-lang/conduit/pkg/web/errortrack"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	exponential := Policy{Retries: 3, Backoff: Exponential}
+	constant := Policy{Retries: 3, Backoff: Constant}
+
+	for retry, want := range map[int]time.Duration{1: BaseDelay, 2: 2 * BaseDelay, 3: 4 * BaseDelay} {
+		if got := exponential.Delay(retry); got != want {
+			t.Errorf("exponential Delay(%d) = %v, want %v", retry, got, want)
+		}
+		if got := constant.Delay(retry); got != BaseDelay {
+			t.Errorf("constant Delay(%d) = %v, want %v", retry, got, BaseDelay)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	defer func(delay time.Duration) { BaseDelay = delay }(BaseDelay)
+	BaseDelay = time.Millisecond
+
+	errHook := errors.New("hook failed")
+	// failing returns a hook failing its first n attempts, and the attempt counter
+	failing := func(n int) (func() error, *int) {
+		attempts := 0
+		return func() error {
+			attempts++
+			if attempts <= n {
+				return errHook
+			}
+			return nil
+		}, &attempts
+	}
+
+	tests := []struct {
+		name         string
+		policy       Policy
+		failures     int
+		wantErr      error
+		wantAttempts int
+	}{
+		{"succeeds", Policy{}, 0, nil, 1},
+		{"aborts by default", Policy{}, 1, errHook, 1},
+		{"retries until success", Policy{Retries: 3, Backoff: Exponential}, 2, nil, 3},
+		{"aborts after the retries", Policy{Retries: 2, Backoff: Constant}, 5, errHook, 3},
+		{"warns", Policy{OnFailure: Warn}, 1, nil, 1},
+		{"warns after the retries", Policy{Retries: 1, Backoff: Constant, OnFailure: Warn}, 5, nil, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook, attempts := failing(tt.failures)
+			if err := Run(context.Background(), "Post.after_create", tt.policy, hook); err != tt.wantErr {
+				t.Errorf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+			if *attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", *attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRunStopsRetryingWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	err := Run(ctx, "Post.after_create", Policy{Retries: 3, Backoff: Constant}, func() error {
+		attempts++
+		return errors.New("hook failed")
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("Run() = %v after %d attempts, want the error after 1", err, attempts)
+	}
+}
+
+// warnings records the events captured by errortrack
+type warnings struct{ events []*errortrack.Event }
+
+func (w *warnings) Capture(event *errortrack.Event) { w.events = append(w.events, event) }
+func (w *warnings) Flush(time.Duration) bool        { return true }
+
+func TestRunReportsWarnings(t *testing.T) {
+	tracker := &warnings{}
+	errortrack.Set(tracker)
+	defer errortrack.Set(nil)
+
+	errHook := errors.New("smtp unavailable")
+	err := Run(context.Background(), "Post.after_create", Policy{OnFailure: Warn}, func() error { return errHook })
+	if err != nil {
+		t.Fatalf("Run() error = %v, want the warning swallowed", err)
+	}
+	if len(tracker.events) != 1 {
+		t.Fatalf("captured %d events, want 1", len(tracker.events))
+	}
+	if event := tracker.events[0]; event.Level != errortrack.LevelWarning || !errors.Is(event.Err, errHook) {
+		t.Errorf("Level, Err = %q, %v", event.Level, event.Err)
+	}
+
+	// Failures the operation returns are reported by the generated hook
+	Run(context.Background(), "Post.after_create", Policy{}, func() error { return errHook })
+	if len(tracker.events) != 1 {
+		t.Errorf("captured %d events, want failures left to the caller", len(tracker.events))
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	// A hook waiting on its context is canceled
+	err := WithTimeout(context.Background(), "Post.BeforeCreate", time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WithTimeout() error = %v, want a deadline exceeded error", err)
+	}
+	if want := "Post.BeforeCreate timed out after 1ms"; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("WithTimeout() error = %q, want it to start with %q", err, want)
+	}
+
+	// Errors before the timeout are returned as is
+	errHook := errors.New("hook failed")
+	err = WithTimeout(context.Background(), "Post.BeforeCreate", time.Minute, func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("The hook's context should have a deadline")
+		}
+		return errHook
+	})
+	if err != errHook {
+		t.Errorf("WithTimeout() error = %v, want %v", err, errHook)
+	}
+
+	// Canceling the request cancels the hook
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = WithTimeout(ctx, "Post.BeforeCreate", time.Minute, func(ctx context.Context) error {
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("WithTimeout() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,202 @@
+// Package ids generates the IDs of resources declared with @id. Every
+// strategy yields IDs that sort by creation time, so new rows are appended to
+// the end of the primary key index, and clients can generate IDs themselves
+// before a record is created, as offline-first apps and idempotent retries do:
+//
+//	ulid       26-character Crockford base32 strings: 48-bit Unix milliseconds, 80 random bits
+//	uuidv7     RFC 9562 version 7 UUIDs: 48-bit Unix milliseconds, 74 random bits
+//	snowflake  positive 64-bit integers: 41-bit milliseconds since Epoch, 10-bit node, 12-bit sequence
+//
+// Snowflake IDs are unique only when every process generating them has its own
+// node, set with CONDUIT_NODE_ID.
+//
+// Example:
+//
+//	if p.ID == "" {
+//		p.ID = ids.NewULID()
+//	} else if err := ids.ValidateULID(p.ID); err != nil {
+//		return err
+//	}
+package ids
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Crockford's base32 alphabet, which leaves out I, L, O and U
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDLength is the length of a ULID string
+const ULIDLength = 26
+
+var ulidState struct {
+	sync.Mutex
+	ms      uint64
+	entropy [10]byte
+}
+
+// NewULID returns a new ULID. ULIDs generated within the same millisecond by
+// the process increase monotonically.
+func NewULID() string {
+	ulidState.Lock()
+	defer ulidState.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms > ulidState.ms || !increment(ulidState.entropy[:]) {
+		ulidState.ms = max(ms, ulidState.ms)
+		if _, err := rand.Read(ulidState.entropy[:]); err != nil {
+			panic(fmt.Sprintf("ids: reading random bytes: %v", err))
+		}
+	}
+
+	var id [16]byte
+	for i := 0; i < 6; i++ {
+		id[i] = byte(ulidState.ms >> (40 - 8*i))
+	}
+	copy(id[6:], ulidState.entropy[:])
+	return encodeULID(id)
+}
+
+// increment adds one to the big-endian number in b, reporting false when it
+// overflows
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeULID encodes 128 bits as 26 base32 characters, 5 bits each with two
+// leading zero bits
+func encodeULID(id [16]byte) string {
+	var out [ULIDLength]byte
+	for i := range out {
+		var v byte
+		for j := 0; j < 5; j++ {
+			// Bit position counted from the least significant bit
+			pos := (ULIDLength-1-i)*5 + j
+			if pos < 128 && id[15-pos/8]&(1<<(pos%8)) != 0 {
+				v |= 1 << j
+			}
+		}
+		out[i] = crockford[v]
+	}
+	return string(out[:])
+}
+
+// ValidateULID returns an error unless s is a ULID: 26 Crockford base32
+// characters, case-insensitive, whose first character is at most 7
+func ValidateULID(s string) error {
+	if len(s) != ULIDLength {
+		return fmt.Errorf("invalid ULID %q: must be %d characters", s, ULIDLength)
+	}
+	if s[0] > '7' {
+		return fmt.Errorf("invalid ULID %q: timestamp out of range", s)
+	}
+	for _, c := range strings.ToUpper(s) {
+		if !strings.ContainsRune(crockford, c) {
+			return fmt.Errorf("invalid ULID %q: %q is not a base32 character", s, c)
+		}
+	}
+	return nil
+}
+
+// NewUUIDv7 returns a new version 7 UUID
+func NewUUIDv7() uuid.UUID {
+	return uuid.Must(uuid.NewV7())
+}
+
+// ValidateUUIDv7 returns an error unless id is a version 7 UUID
+func ValidateUUIDv7(id uuid.UUID) error {
+	if id.Version() != 7 {
+		return fmt.Errorf("invalid ID %s: must be a version 7 UUID", id)
+	}
+	return nil
+}
+
+// Epoch is the time snowflake IDs count milliseconds from
+var Epoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// NodeEnvVar is the environment variable holding the node of the process,
+// from 0 to MaxNode
+const NodeEnvVar = "CONDUIT_NODE_ID"
+
+// MaxNode is the highest snowflake node
+const MaxNode = 1<<10 - 1
+
+const maxSequence = 1<<12 - 1
+
+var snowflakeState struct {
+	sync.Mutex
+	node     int64
+	ms       int64
+	sequence int64
+}
+
+// SetNode sets the node written into the snowflake IDs of the process
+func SetNode(node int64) error {
+	if node < 0 || node > MaxNode {
+		return fmt.Errorf("snowflake node must be between 0 and %d, got %d", MaxNode, node)
+	}
+	snowflakeState.Lock()
+	snowflakeState.node = node
+	snowflakeState.Unlock()
+	return nil
+}
+
+// SetNodeFromEnv sets the snowflake node from CONDUIT_NODE_ID. The node
+// stays 0 when the variable is not set.
+func SetNodeFromEnv() error {
+	value := strings.TrimSpace(os.Getenv(NodeEnvVar))
+	if value == "" {
+		return nil
+	}
+	node, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s must be a number, got %q", NodeEnvVar, value)
+	}
+	return SetNode(node)
+}
+
+// NewSnowflake returns a new snowflake ID. At most 4096 IDs are generated
+// per millisecond; further calls wait for the next one.
+func NewSnowflake() int64 {
+	snowflakeState.Lock()
+	defer snowflakeState.Unlock()
+
+	// A clock that moves back keeps counting from the last millisecond used
+	ms := max(time.Since(Epoch).Milliseconds(), snowflakeState.ms)
+	if ms == snowflakeState.ms {
+		snowflakeState.sequence = (snowflakeState.sequence + 1) & maxSequence
+		if snowflakeState.sequence == 0 {
+			for ms <= snowflakeState.ms {
+				time.Sleep(100 * time.Microsecond)
+				ms = time.Since(Epoch).Milliseconds()
+			}
+		}
+	} else {
+		snowflakeState.sequence = 0
+	}
+	snowflakeState.ms = ms
+
+	return ms<<22 | snowflakeState.node<<12 | snowflakeState.sequence
+}
+
+// ValidateSnowflake returns an error unless id can be a snowflake ID
+func ValidateSnowflake(id int64) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid ID %d: must be a positive snowflake ID", id)
+	}
+	return nil
+}
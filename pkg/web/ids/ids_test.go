@@ -0,0 +1,118 @@
+package ids
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewULID(t *testing.T) {
+	generated := make([]string, 1000)
+	for i := range generated {
+		generated[i] = NewULID()
+		if err := ValidateULID(generated[i]); err != nil {
+			t.Fatalf("NewULID() = %q: %v", generated[i], err)
+		}
+	}
+	if !sort.StringsAreSorted(generated) {
+		t.Error("ULIDs should sort in the order they were generated")
+	}
+	seen := make(map[string]bool)
+	for _, id := range generated {
+		if seen[id] {
+			t.Fatalf("Duplicate ULID %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestEncodeULID(t *testing.T) {
+	var max [16]byte
+	for i := range max {
+		max[i] = 0xff
+	}
+	tests := []struct {
+		id   [16]byte
+		want string
+	}{
+		{[16]byte{}, "00000000000000000000000000"},
+		{max, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ"},
+		{[16]byte{15: 1}, "00000000000000000000000001"},
+		{[16]byte{0: 0x01, 1: 0x7f}, "01FW0000000000000000000000"},
+	}
+	for _, tt := range tests {
+		if got := encodeULID(tt.id); got != tt.want {
+			t.Errorf("encodeULID(%x) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestValidateULID(t *testing.T) {
+	for _, valid := range []string{"01ARZ3NDEKTSV4RRFFQ69G5FAV", "01arz3ndektsv4rrffq69g5fav"} {
+		if err := ValidateULID(valid); err != nil {
+			t.Errorf("ValidateULID(%q) error = %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"", "01ARZ3NDEKTSV4RRFFQ69G5FA", "01ARZ3NDEKTSV4RRFFQ69G5FAU", "81ARZ3NDEKTSV4RRFFQ69G5FAV"} {
+		if err := ValidateULID(invalid); err == nil {
+			t.Errorf("ValidateULID(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestNewUUIDv7(t *testing.T) {
+	id := NewUUIDv7()
+	if err := ValidateUUIDv7(id); err != nil {
+		t.Errorf("NewUUIDv7() = %s: %v", id, err)
+	}
+	if err := ValidateUUIDv7(uuid.New()); err == nil {
+		t.Error("ValidateUUIDv7() should reject version 4 UUIDs")
+	}
+}
+
+func TestNewSnowflake(t *testing.T) {
+	if err := SetNode(5); err != nil {
+		t.Fatalf("SetNode() error = %v", err)
+	}
+	defer SetNode(0)
+
+	var last int64
+	for i := 0; i < 10000; i++ {
+		id := NewSnowflake()
+		if id <= last {
+			t.Fatalf("NewSnowflake() = %d after %d, want increasing IDs", id, last)
+		}
+		if node := id >> 12 & MaxNode; node != 5 {
+			t.Fatalf("NewSnowflake() node = %d, want 5", node)
+		}
+		last = id
+	}
+	if err := ValidateSnowflake(last); err != nil {
+		t.Errorf("ValidateSnowflake(%d) error = %v", last, err)
+	}
+	if err := ValidateSnowflake(0); err == nil {
+		t.Error("ValidateSnowflake(0) should fail")
+	}
+}
+
+func TestSetNodeFromEnv(t *testing.T) {
+	defer SetNode(0)
+
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"", false},
+		{"1023", false},
+		{"1024", true},
+		{"-1", true},
+		{"web-1", true},
+	}
+	for _, tt := range tests {
+		t.Setenv(NodeEnvVar, tt.value)
+		if err := SetNodeFromEnv(); (err != nil) != tt.wantErr {
+			t.Errorf("SetNodeFromEnv() with %q error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
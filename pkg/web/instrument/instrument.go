@@ -0,0 +1,201 @@
+// Package instrument adds database instrumentation to generated applications:
+// connection pool statistics and slow-query logging tagged with the resource and
+// operation that issued the query.
+//
+// Instrumentation is applied at the driver level, so the returned *sql.DB can be
+// passed to generated handlers and models unchanged.
+//
+// Example:
+//
+//	db, err := instrument.Open("pgx", dbURL, instrument.ConfigFromEnv())
+//	...
+//	r.Get("/debug/db/stats", instrument.StatsHandler(db))
+//
+//	// In a handler
+//	ctx := instrument.WithOperation(r.Context(), "Post", "list")
+//	rows, err := db.QueryContext(ctx, listQuery, args...)
+package instrument
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultSlowQueryThreshold is the slow-query threshold used when none is configured.
+const DefaultSlowQueryThreshold = 200 * time.Millisecond
+
+// SlowQueryThresholdEnv is the environment variable read by ConfigFromEnv.
+// It accepts a Go duration such as "250ms" or "1s"; "0" disables slow-query logging.
+const SlowQueryThresholdEnv = "DB_SLOW_QUERY_THRESHOLD"
+
+// SlowQuery describes a query that took longer than the configured threshold.
+// Query arguments are never recorded.
+type SlowQuery struct {
+	Resource  string
+	Operation string
+	Query     string
+	Duration  time.Duration
+}
+
+// Config holds database instrumentation configuration
+type Config struct {
+	// SlowQueryThreshold is the duration above which a query is reported.
+	// Zero or negative disables slow-query logging.
+	SlowQueryThreshold time.Duration
+	// Logger receives slow queries. Defaults to the standard logger.
+	Logger func(SlowQuery)
+}
+
+// DefaultConfig returns the default instrumentation configuration
+func DefaultConfig() Config {
+	return Config{
+		SlowQueryThreshold: DefaultSlowQueryThreshold,
+		Logger:             defaultLogger,
+	}
+}
+
+// ConfigFromEnv returns DefaultConfig with the threshold taken from
+// DB_SLOW_QUERY_THRESHOLD when it is set to a valid duration.
+func ConfigFromEnv() Config {
+	config := DefaultConfig()
+	if value := os.Getenv(SlowQueryThresholdEnv); value != "" {
+		threshold, err := time.ParseDuration(value)
+		if err != nil {
+			log.Printf("Ignoring invalid %s %q: %v", SlowQueryThresholdEnv, value, err)
+			return config
+		}
+		config.SlowQueryThreshold = threshold
+	}
+	return config
+}
+
+// Open opens a database like sql.Open, with every query timed against the
+// configured slow-query threshold.
+func Open(driverName, dataSourceName string, config Config) (*sql.DB, error) {
+	// sql.Open does not connect, it only resolves the registered driver
+	probe, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	drv := probe.Driver()
+	probe.Close()
+
+	var base driver.Connector
+	if dc, ok := drv.(driver.DriverContext); ok {
+		base, err = dc.OpenConnector(dataSourceName)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		base = dsnConnector{dsn: dataSourceName, driver: drv}
+	}
+
+	if config.Logger == nil {
+		config.Logger = defaultLogger
+	}
+
+	return sql.OpenDB(&connector{base: base, config: config}), nil
+}
+
+type operationKey struct{}
+
+type operation struct {
+	resource string
+	name     string
+}
+
+// WithOperation returns a context that attributes queries to a resource and
+// operation (for example "Post" and "list") in slow-query reports. A Recorder
+// in ctx captures the operation too.
+func WithOperation(ctx context.Context, resource, name string) context.Context {
+	op := operation{resource: resource, name: name}
+	if recorder, ok := ctx.Value(recorderKey{}).(*Recorder); ok {
+		recorder.record(op)
+	}
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+type recorderKey struct{}
+
+// Recorder captures the operation a handler passes to WithOperation, so that
+// middleware wrapping the handler, such as a request logger, can report it.
+// It is safe for concurrent use.
+type Recorder struct {
+	mu       sync.Mutex
+	op       operation
+	recorded bool
+}
+
+// WithRecorder returns a context whose handler's operation is captured by the
+// returned Recorder
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	recorder := &Recorder{}
+	return context.WithValue(ctx, recorderKey{}, recorder), recorder
+}
+
+// RecorderFromContext returns the Recorder of the nearest WithRecorder, so
+// middleware inside the one that installed it can read the same operation.
+// The boolean is false when the context carries no Recorder.
+func RecorderFromContext(ctx context.Context) (*Recorder, bool) {
+	recorder, ok := ctx.Value(recorderKey{}).(*Recorder)
+	return recorder, ok
+}
+
+// Operation returns the resource and operation last recorded. The boolean is
+// false when the handler recorded none.
+func (r *Recorder) Operation() (string, string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.op.resource, r.op.name, r.recorded
+}
+
+func (r *Recorder) record(op operation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.op = op
+	r.recorded = true
+}
+
+// OperationFromContext returns the resource and operation recorded by WithOperation.
+// The boolean is false when the context carries no operation.
+func OperationFromContext(ctx context.Context) (string, string, bool) {
+	op, ok := ctx.Value(operationKey{}).(operation)
+	return op.resource, op.name, ok
+}
+
+// observe reports the query if it ran longer than the threshold
+func (c Config) observe(ctx context.Context, query string, start time.Time) {
+	if c.SlowQueryThreshold <= 0 {
+		return
+	}
+	duration := time.Since(start)
+	if duration < c.SlowQueryThreshold {
+		return
+	}
+
+	resource, name, ok := OperationFromContext(ctx)
+	if !ok {
+		resource, name = "unknown", "unknown"
+	}
+	c.Logger(SlowQuery{
+		Resource:  resource,
+		Operation: name,
+		Query:     query,
+		Duration:  duration,
+	})
+}
+
+func defaultLogger(q SlowQuery) {
+	log.Printf("[SLOW QUERY] %s", q)
+}
+
+// String formats the slow query for logging
+func (q SlowQuery) String() string {
+	return fmt.Sprintf("%s.%s took %v: %s", q.Resource, q.Operation, q.Duration, q.Query)
+}
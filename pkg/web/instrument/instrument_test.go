@@ -0,0 +1,254 @@
+package instrument
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// openMock returns an instrumented database backed by sqlmock and the slow
+// queries it reports.
+func openMock(t *testing.T, threshold time.Duration) (*sql.DB, sqlmock.Sqlmock, func() []SlowQuery) {
+	t.Helper()
+
+	dsn := "instrument_" + t.Name()
+	mockDB, mock, err := sqlmock.NewWithDSN(dsn)
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { mockDB.Close() })
+
+	var mu sync.Mutex
+	var reported []SlowQuery
+	db, err := Open("sqlmock", dsn, Config{
+		SlowQueryThreshold: threshold,
+		Logger: func(q SlowQuery) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, q)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db, mock, func() []SlowQuery {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]SlowQuery(nil), reported...)
+	}
+}
+
+func TestOpen_ReportsSlowQueries(t *testing.T) {
+	db, mock, reported := openMock(t, 10*time.Millisecond)
+
+	mock.ExpectQuery("SELECT \\* FROM posts").
+		WillDelayFor(20 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM posts").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := WithOperation(context.Background(), "Post", "list")
+	rows, err := db.QueryContext(ctx, "SELECT * FROM posts")
+	if err != nil {
+		t.Fatalf("QueryContext() error = %v", err)
+	}
+	rows.Close()
+
+	if _, err := db.ExecContext(ctx, "DELETE FROM posts WHERE id = $1", 1); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+
+	got := reported()
+	if len(got) != 1 {
+		t.Fatalf("reported %d slow queries, want 1: %v", len(got), got)
+	}
+	if got[0].Resource != "Post" || got[0].Operation != "list" {
+		t.Errorf("slow query = %s.%s, want Post.list", got[0].Resource, got[0].Operation)
+	}
+	if got[0].Query != "SELECT * FROM posts" {
+		t.Errorf("slow query text = %q", got[0].Query)
+	}
+	if got[0].Duration < 10*time.Millisecond {
+		t.Errorf("slow query duration = %v, want at least 10ms", got[0].Duration)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestOpen_UnlabeledAndTransactionQueries(t *testing.T) {
+	db, mock, reported := openMock(t, time.Nanosecond)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE posts").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	if _, err := tx.ExecContext(context.Background(), "UPDATE posts SET title = $1", "x"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	got := reported()
+	if len(got) != 1 {
+		t.Fatalf("reported %d slow queries, want 1: %v", len(got), got)
+	}
+	if got[0].Resource != "unknown" || got[0].Operation != "unknown" {
+		t.Errorf("slow query = %s.%s, want unknown.unknown", got[0].Resource, got[0].Operation)
+	}
+}
+
+func TestOpen_PreparedStatements(t *testing.T) {
+	db, mock, reported := openMock(t, time.Nanosecond)
+
+	mock.ExpectPrepare("SELECT title FROM posts").
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"title"}).AddRow("Hello"))
+
+	stmt, err := db.Prepare("SELECT title FROM posts WHERE id = $1")
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	defer stmt.Close()
+
+	var title string
+	ctx := WithOperation(context.Background(), "Post", "get")
+	if err := stmt.QueryRowContext(ctx, 1).Scan(&title); err != nil {
+		t.Fatalf("QueryRowContext() error = %v", err)
+	}
+
+	got := reported()
+	if len(got) != 1 || got[0].Operation != "get" {
+		t.Errorf("reported = %v, want one Post.get query", got)
+	}
+}
+
+func TestOpen_DisabledThreshold(t *testing.T) {
+	db, mock, reported := openMock(t, 0)
+
+	mock.ExpectExec("DELETE FROM posts").
+		WillDelayFor(5 * time.Millisecond).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := db.Exec("DELETE FROM posts"); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if got := reported(); len(got) != 0 {
+		t.Errorf("reported = %v, want none when the threshold is zero", got)
+	}
+}
+
+func TestOpen_UnknownDriver(t *testing.T) {
+	if _, err := Open("no-such-driver", "", DefaultConfig()); err == nil {
+		t.Error("Open() expected error for an unregistered driver")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(SlowQueryThresholdEnv, "")
+	if got := ConfigFromEnv().SlowQueryThreshold; got != DefaultSlowQueryThreshold {
+		t.Errorf("default threshold = %v, want %v", got, DefaultSlowQueryThreshold)
+	}
+
+	t.Setenv(SlowQueryThresholdEnv, "750ms")
+	if got := ConfigFromEnv().SlowQueryThreshold; got != 750*time.Millisecond {
+		t.Errorf("threshold = %v, want 750ms", got)
+	}
+
+	t.Setenv(SlowQueryThresholdEnv, "soon")
+	if got := ConfigFromEnv().SlowQueryThreshold; got != DefaultSlowQueryThreshold {
+		t.Errorf("invalid threshold = %v, want default %v", got, DefaultSlowQueryThreshold)
+	}
+}
+
+func TestSlowQueryString(t *testing.T) {
+	q := SlowQuery{Resource: "Post", Operation: "list", Query: "SELECT 1", Duration: 250 * time.Millisecond}
+	if got, want := q.String(), "Post.list took 250ms: SELECT 1"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	ctx, recorder := WithRecorder(context.Background())
+	if _, _, ok := recorder.Operation(); ok {
+		t.Error("Operation() should report none before WithOperation")
+	}
+
+	ctx = WithOperation(ctx, "Post", "list")
+	if resource, name, ok := recorder.Operation(); !ok || resource != "Post" || name != "list" {
+		t.Errorf("Operation() = %q, %q, %v, want Post, list, true", resource, name, ok)
+	}
+	if resource, name, _ := OperationFromContext(ctx); resource != "Post" || name != "list" {
+		t.Errorf("OperationFromContext() = %q, %q", resource, name)
+	}
+	if found, ok := RecorderFromContext(ctx); !ok || found != recorder {
+		t.Error("RecorderFromContext() should return the installed recorder")
+	}
+	if _, ok := RecorderFromContext(context.Background()); ok {
+		t.Error("RecorderFromContext() should report none without WithRecorder")
+	}
+
+	// Contexts without a recorder are unaffected
+	WithOperation(context.Background(), "Tag", "get")
+	if _, name, _ := recorder.Operation(); name != "list" {
+		t.Errorf("Operation() = %q after an unrelated WithOperation", name)
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	db, mock, _ := openMock(t, DefaultSlowQueryThreshold)
+	db.SetMaxOpenConns(7)
+
+	mock.ExpectPing()
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	StatsHandler(db)(rec, httptest.NewRequest(http.MethodGet, "/debug/db/stats", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var stats PoolStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("failed to decode stats: %v", err)
+	}
+	if stats.MaxOpenConnections != 7 {
+		t.Errorf("max_open_connections = %d, want 7", stats.MaxOpenConnections)
+	}
+	if stats.OpenConnections != 1 || stats.Idle != 1 || stats.InUse != 0 {
+		t.Errorf("stats = %+v, want one idle connection", stats)
+	}
+}
+
+func TestNewPoolStats(t *testing.T) {
+	stats := NewPoolStats(sql.DBStats{
+		InUse:        2,
+		Idle:         3,
+		WaitCount:    4,
+		WaitDuration: 1500 * time.Microsecond,
+	})
+	if stats.InUse != 2 || stats.Idle != 3 || stats.WaitCount != 4 {
+		t.Errorf("NewPoolStats() = %+v", stats)
+	}
+	if stats.WaitDurationMs != 1.5 {
+		t.Errorf("wait_duration_ms = %v, want 1.5", stats.WaitDurationMs)
+	}
+}
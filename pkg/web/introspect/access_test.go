@@ -0,0 +1,193 @@
+package introspect
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const hookMetadata = `{
+  "version": "1.0.0",
+  "resources": [
+    {"name": "Invoice", "hooks": [{"timing": "before", "event": "create", "source_code": "self.total = discount(self)"}]}
+  ],
+  "routes": [{"method": "GET", "path": "/invoices", "resource": "Invoice"}]
+}`
+
+func serveWith(t *testing.T, opts Options, req *http.Request) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	opts.Path = "/introspection"
+	opts.Metadata = hookMetadata
+	handler, err := Handler(opts)
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q", rec.Body.String())
+	}
+	return rec, body
+}
+
+func bearerRequest(target, token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestHandler_BearerAuth(t *testing.T) {
+	opts := Options{
+		Auth:     AuthBearer,
+		Token:    "s3cret",
+		Exposure: map[string]string{"routes": Public, "patterns": Hidden},
+	}
+
+	// Routes are public, resources are internal by default
+	if rec, _ := serveWith(t, opts, bearerRequest("/introspection/routes", "")); rec.Code != http.StatusOK {
+		t.Errorf("public routes: status = %d", rec.Code)
+	}
+	rec, _ := serveWith(t, opts, bearerRequest("/introspection/resources", ""))
+	if rec.Code != http.StatusUnauthorized || !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Bearer") {
+		t.Errorf("without a token: status = %d, WWW-Authenticate = %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+	if rec, _ := serveWith(t, opts, bearerRequest("/introspection/resources", "wrong")); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d", rec.Code)
+	}
+	if rec, _ := serveWith(t, opts, bearerRequest("/introspection/resources/Invoice", "s3cret")); rec.Code != http.StatusOK {
+		t.Errorf("with the token: status = %d", rec.Code)
+	}
+
+	// Hidden sections are not found, even with the token
+	if rec, _ := serveWith(t, opts, bearerRequest("/introspection/patterns", "s3cret")); rec.Code != http.StatusNotFound {
+		t.Errorf("hidden patterns: status = %d", rec.Code)
+	}
+
+	// The summary lists only what the caller may read
+	_, body := serveWith(t, opts, bearerRequest("/introspection", ""))
+	collections := body["collections"].(map[string]interface{})
+	if len(collections) != 1 || collections["routes"] == nil {
+		t.Errorf("anonymous summary = %v", collections)
+	}
+	_, body = serveWith(t, opts, bearerRequest("/introspection", "s3cret"))
+	if collections := body["collections"].(map[string]interface{}); len(collections) != 2 {
+		t.Errorf("authenticated summary = %v", collections)
+	}
+}
+
+func TestHandler_HooksExposure(t *testing.T) {
+	opts := Options{
+		Auth:     AuthBearer,
+		Token:    "s3cret",
+		Exposure: map[string]string{"resources": Public, HooksSection: Internal},
+	}
+
+	_, body := serveWith(t, opts, bearerRequest("/introspection/resources/Invoice", ""))
+	if data := body["data"].(map[string]interface{}); data["hooks"] != nil || data["name"] != "Invoice" {
+		t.Errorf("anonymous resource = %v", data)
+	}
+	rec, _ := serveWith(t, opts, bearerRequest("/introspection/resources?filter[hooks.event]=create", ""))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("anonymous hook filter: status = %d", rec.Code)
+	}
+
+	_, body = serveWith(t, opts, bearerRequest("/introspection/resources?filter[hooks.event]=create", "s3cret"))
+	data := body["data"].([]interface{})
+	if len(data) != 1 || data[0].(map[string]interface{})["hooks"] == nil {
+		t.Errorf("authenticated resources = %v", data)
+	}
+}
+
+func TestHandler_MTLSAuth(t *testing.T) {
+	opts := Options{Auth: AuthMTLS}
+
+	if rec, _ := serveWith(t, opts, httptest.NewRequest(http.MethodGet, "/introspection/routes", nil)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without a certificate: status = %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/introspection/routes", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	if rec, _ := serveWith(t, opts, req); rec.Code != http.StatusOK {
+		t.Errorf("with a verified certificate: status = %d", rec.Code)
+	}
+}
+
+func TestHandler_AccessErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"bearer without token", Options{Auth: AuthBearer}, TokenEnvVar},
+		{"unknown auth", Options{Auth: "basic"}, "auth must be"},
+		{"unknown section", Options{Auth: AuthMTLS, Exposure: map[string]string{"scopes": Public}}, `unknown section "scopes"`},
+		{"unknown level", Options{Exposure: map[string]string{"routes": "private"}}, "routes must be"},
+		{"internal without auth", Options{Exposure: map[string]string{HooksSection: Internal}}, "no auth is configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Metadata = hookMetadata
+			_, err := Handler(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Handler() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientCertTLSConfig(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "introspection clients"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := ClientCertTLSConfig(caFile)
+	if err != nil {
+		t.Fatalf("ClientCertTLSConfig() error = %v", err)
+	}
+	if cfg.ClientAuth != tls.VerifyClientCertIfGiven || cfg.ClientCAs == nil {
+		t.Errorf("config = %+v", cfg)
+	}
+
+	if _, err := ClientCertTLSConfig(""); err == nil || !strings.Contains(err.Error(), ClientCAEnvVar) {
+		t.Errorf("expected an error naming %s, got %v", ClientCAEnvVar, err)
+	}
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(empty, []byte("not a certificate"), 0o600)
+	if _, err := ClientCertTLSConfig(empty); err == nil {
+		t.Error("expected an error for a file without certificates")
+	}
+}
@@ -0,0 +1,522 @@
+// Package introspect serves a generated application's introspection metadata
+// over HTTP. Clients with narrow questions, such as the routes of one
+// resource or the resources with a given field, page through a collection
+// and filter it on the server instead of downloading the whole registry.
+//
+//	GET /introspection                  version, source hash and collection totals
+//	GET /introspection/resources        resources, paginated
+//	GET /introspection/resources/User   one resource
+//	GET /introspection/routes?filter[resource]=User&fields[routes]=method,path
+//	GET /introspection/resources?filter[fields.name]=email&page[limit]=10
+//
+// Collections take the pagination, sparse fieldset and filter parameters of
+// the generated API: page[limit] and page[offset], fields[<collection>] and
+// filter[<field>]. A filter names a field of the items, or a path into them
+// separated by dots; it matches when any value at that path equals the
+// filter value.
+//
+// Responses carry an ETag derived from the metadata's source hash and a
+// Last-Modified time, so polling clients can send If-None-Match or
+// If-Modified-Since and get 304 Not Modified until the application is
+// rebuilt with different metadata.
+//
+// Access is controlled per section: the resources, routes and patterns
+// collections and the hooks of each resource. A public section is served to
+// every caller, an internal one only to callers authenticated with a bearer
+// token or a verified TLS client certificate, and a hidden one to nobody.
+//
+// Example:
+//
+//	if introspect.Enabled() {
+//		meta, err := introspect.Handler(introspect.Options{Path: "/introspection", Metadata: introspection.Metadata})
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		r.Mount("/introspection", meta)
+//	}
+package introspect
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/conduit-lang/conduit/pkg/web/query"
+	"github.com/conduit-lang/conduit/pkg/web/response"
+)
+
+// EnvVar disables the metadata routes when set to "false", "0" or "off".
+const EnvVar = "CONDUIT_INTROSPECTION"
+
+// Environment variables read by the generated application
+const (
+	// TokenEnvVar holds the bearer token when Auth is AuthBearer
+	TokenEnvVar = "CONDUIT_INTROSPECTION_TOKEN"
+	// ClientCAEnvVar names the PEM file of CAs that sign client certificates
+	// when Auth is AuthMTLS
+	ClientCAEnvVar = "CONDUIT_INTROSPECTION_CLIENT_CA"
+	// CertEnvVar and KeyEnvVar name the server certificate and key the
+	// application serves TLS with when Auth is AuthMTLS
+	CertEnvVar = "CONDUIT_TLS_CERT"
+	KeyEnvVar  = "CONDUIT_TLS_KEY"
+)
+
+// Auth modes of the metadata routes
+const (
+	AuthNone   = "none"   // No caller is authenticated; every section must be public
+	AuthBearer = "bearer" // "Authorization: Bearer <token>" authenticates
+	AuthMTLS   = "mtls"   // A verified TLS client certificate authenticates
+)
+
+// Exposure levels of a section
+const (
+	Public   = "public"   // Served to every caller
+	Internal = "internal" // Served to authenticated callers
+	Hidden   = "hidden"   // Never served
+)
+
+// HooksSection is the hooks of each resource, which can be exposed less
+// widely than the resources themselves
+const HooksSection = "hooks"
+
+const (
+	// DefaultPageLimit is the page size when the request does not specify one
+	DefaultPageLimit = 50
+
+	// MaxPageLimit is the largest page size a client may request
+	MaxPageLimit = 500
+)
+
+// Collections are the paginated parts of the metadata, in the order the
+// summary lists them
+var Collections = []string{"resources", "routes", "patterns"}
+
+// itemKeys names the field identifying a single item of a collection.
+// Routes have no single name and are only listed.
+var itemKeys = map[string]string{
+	"resources": "name",
+	"patterns":  "name",
+}
+
+// Options configure the metadata handler.
+type Options struct {
+	// Path is where the handler is mounted, e.g. "/introspection"
+	Path string
+	// Metadata is the introspection metadata JSON embedded in the application
+	Metadata string
+	// Auth is how callers authenticate: AuthNone (the default), AuthBearer or AuthMTLS
+	Auth string
+	// Token is the bearer token when Auth is AuthBearer
+	Token string
+	// Exposure sets the level of each of Collections and HooksSection. A
+	// section left out is internal when Auth authenticates callers and
+	// public otherwise.
+	Exposure map[string]string
+}
+
+// Enabled reports whether the metadata routes should be served. They are on
+// unless CONDUIT_INTROSPECTION turns them off.
+func Enabled() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(EnvVar))) {
+	case "false", "0", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+// ClientCertTLSConfig returns a TLS configuration that verifies client
+// certificates against the CAs in caFile when a client presents one. Callers
+// without a certificate still connect, so only the internal metadata
+// sections need one.
+func ClientCertTLSConfig(caFile string) (*tls.Config, error) {
+	if caFile == "" {
+		return nil, fmt.Errorf("introspect: mtls auth needs %s", ClientCAEnvVar)
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("introspect: failed to read client CAs: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("introspect: no certificates in %s", caFile)
+	}
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// server holds the metadata decoded once at startup
+type server struct {
+	base     string
+	auth     string
+	token    []byte
+	exposure map[string]string
+	version  string
+	hash     string
+	tag      string    // Entity tag of the metadata, unquoted
+	modified time.Time // When the metadata was generated, or the handler created
+	items    map[string][]map[string]interface{}
+	fields   map[string]map[string]bool // Top-level fields present in each collection
+}
+
+// Handler returns the metadata handler. It fails when opts.Metadata is not
+// valid metadata JSON or the access settings are inconsistent, such as
+// bearer auth without a token.
+func Handler(opts Options) (http.Handler, error) {
+	s := &server{
+		base:     strings.TrimSuffix(opts.Path, "/"),
+		auth:     opts.Auth,
+		token:    []byte(opts.Token),
+		exposure: make(map[string]string),
+		items:    make(map[string][]map[string]interface{}),
+		fields:   make(map[string]map[string]bool),
+	}
+	if err := s.configureAccess(opts); err != nil {
+		return nil, err
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(opts.Metadata), &doc); err != nil {
+		return nil, fmt.Errorf("introspect: invalid metadata: %w", err)
+	}
+
+	for _, name := range Collections {
+		var items []map[string]interface{}
+		if raw, ok := doc[name]; ok && string(raw) != "null" {
+			decoder := json.NewDecoder(bytes.NewReader(raw))
+			decoder.UseNumber()
+			if err := decoder.Decode(&items); err != nil {
+				return nil, fmt.Errorf("introspect: invalid %s: %w", name, err)
+			}
+		}
+		s.items[name] = items
+
+		fields := make(map[string]bool)
+		for _, item := range items {
+			for field := range item {
+				fields[field] = true
+			}
+		}
+		s.fields[name] = fields
+	}
+
+	if raw, ok := doc["version"]; ok {
+		_ = json.Unmarshal(raw, &s.version)
+	}
+	if raw, ok := doc["source_hash"]; ok {
+		_ = json.Unmarshal(raw, &s.hash)
+	}
+
+	// The source hash identifies the metadata; without one, hash it
+	s.tag = s.hash
+	if s.tag == "" {
+		s.tag = fmt.Sprintf("%x", sha256.Sum256([]byte(opts.Metadata)))
+	}
+	if raw, ok := doc["generated"]; !ok || json.Unmarshal(raw, &s.modified) != nil || s.modified.IsZero() {
+		s.modified = time.Now()
+	}
+
+	return s, nil
+}
+
+// configureAccess validates the auth mode and fills in the exposure of
+// every section
+func (s *server) configureAccess(opts Options) error {
+	switch s.auth {
+	case "":
+		s.auth = AuthNone
+	case AuthNone, AuthMTLS:
+	case AuthBearer:
+		if opts.Token == "" {
+			return fmt.Errorf("introspect: bearer auth needs a token; set %s", TokenEnvVar)
+		}
+	default:
+		return fmt.Errorf("introspect: auth must be %s, %s or %s, got: %s", AuthNone, AuthBearer, AuthMTLS, s.auth)
+	}
+
+	fallback := Internal
+	if s.auth == AuthNone {
+		fallback = Public
+	}
+	sections := append(append([]string{}, Collections...), HooksSection)
+	for _, section := range sections {
+		s.exposure[section] = fallback
+	}
+	for section, level := range opts.Exposure {
+		if _, ok := s.exposure[section]; !ok {
+			return fmt.Errorf("introspect: unknown section %q; sections are %s", section, strings.Join(sections, ", "))
+		}
+		switch level {
+		case Public, Hidden:
+		case Internal:
+			if s.auth == AuthNone {
+				return fmt.Errorf("introspect: section %s is internal but no auth is configured", section)
+			}
+		default:
+			return fmt.Errorf("introspect: section %s must be %s, %s or %s, got: %s", section, Public, Internal, Hidden, level)
+		}
+		s.exposure[section] = level
+	}
+	return nil
+}
+
+// authenticated reports whether r carries the configured credentials
+func (s *server) authenticated(r *http.Request) bool {
+	switch s.auth {
+	case AuthBearer:
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		return ok && subtle.ConstantTimeCompare([]byte(token), s.token) == 1
+	case AuthMTLS:
+		return r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+	}
+	return false
+}
+
+// visible reports whether section is served to a caller
+func (s *server) visible(section string, authenticated bool) bool {
+	switch s.exposure[section] {
+	case Public:
+		return true
+	case Internal:
+		return authenticated
+	}
+	return false
+}
+
+// notModified sets the validators of the metadata and answers a conditional
+// request with 304 when the client's copy is current. Authenticated callers
+// may see more sections, so their responses are tagged apart.
+func (s *server) notModified(w http.ResponseWriter, r *http.Request, authenticated bool) bool {
+	etag := s.tag
+	if authenticated {
+		etag += "-internal"
+	}
+	if s.auth == AuthBearer {
+		w.Header().Add("Vary", "Authorization")
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	return response.NotModified(w, r, s.modified, `"`+etag+`"`)
+}
+
+// summary lists the version, source hash and the collections the caller may read
+func (s *server) summary(authenticated bool) ([]byte, error) {
+	collections := make(map[string]interface{})
+	for _, name := range Collections {
+		if !s.visible(name, authenticated) {
+			continue
+		}
+		collections[name] = map[string]interface{}{
+			"total": len(s.items[name]),
+			"href":  s.base + "/" + name,
+		}
+	}
+	return json.Marshal(map[string]interface{}{
+		"version":     s.version,
+		"source_hash": s.hash,
+		"collections": collections,
+	})
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		response.RenderMethodNotAllowed(w, []string{http.MethodGet, http.MethodHead})
+		return
+	}
+	authenticated := s.authenticated(r)
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, s.base), "/")
+	if path == "" {
+		if s.notModified(w, r, authenticated) {
+			return
+		}
+		body, err := s.summary(authenticated)
+		if err != nil {
+			response.RenderInternalError(w, err)
+			return
+		}
+		writeJSON(w, body)
+		return
+	}
+
+	collection, key, single := strings.Cut(path, "/")
+	items, ok := s.items[collection]
+	if !ok || s.exposure[collection] == Hidden {
+		response.RenderNotFound(w, fmt.Sprintf("no metadata collection %q", collection))
+		return
+	}
+	if !s.visible(collection, authenticated) {
+		if s.auth == AuthBearer {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="introspection"`)
+		}
+		response.RenderUnauthorized(w, fmt.Sprintf("%s metadata requires authentication", collection))
+		return
+	}
+	if s.notModified(w, r, authenticated) {
+		return
+	}
+	// Hooks the caller may not see are dropped from each resource
+	hideHooks := collection == "resources" && !s.visible(HooksSection, authenticated)
+	fields := query.ParseFields(r)[collection]
+
+	if single {
+		s.serveItem(w, collection, key, fields, hideHooks)
+		return
+	}
+
+	filters := query.ParseFilter(r)
+	for _, field := range sortedKeys(filters) {
+		top := strings.SplitN(field, ".", 2)[0]
+		if !s.fields[collection][top] || (hideHooks && top == HooksSection) {
+			response.RenderBadRequest(w, fmt.Sprintf("unknown filter field %q for %s", field, collection))
+			return
+		}
+	}
+
+	page, err := query.ParsePage(r, query.PageConfig{DefaultLimit: DefaultPageLimit, MaxLimit: MaxPageLimit})
+	if err != nil {
+		response.RenderBadRequest(w, err.Error())
+		return
+	}
+
+	var matched []map[string]interface{}
+	for _, item := range items {
+		if matchesFilters(item, filters) {
+			matched = append(matched, item)
+		}
+	}
+
+	data := make([]map[string]interface{}, 0, page.Limit)
+	if page.Offset < len(matched) {
+		end := page.Offset + page.Limit
+		if end > len(matched) {
+			end = len(matched)
+		}
+		for _, item := range matched[page.Offset:end] {
+			data = append(data, render(item, fields, hideHooks))
+		}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"data":  data,
+		"meta":  page.Meta(len(matched)),
+		"links": response.BuildPaginationLinks(r.URL.RequestURI(), page.Number(), page.Limit, len(matched)),
+	})
+	if err != nil {
+		response.RenderInternalError(w, err)
+		return
+	}
+	writeJSON(w, body)
+}
+
+// serveItem serves the item of a collection whose identifying field is key
+func (s *server) serveItem(w http.ResponseWriter, collection, key string, fields []string, hideHooks bool) {
+	itemKey, ok := itemKeys[collection]
+	if !ok {
+		response.RenderNotFound(w, fmt.Sprintf("%s are only listed; filter them instead", collection))
+		return
+	}
+	for _, item := range s.items[collection] {
+		if name, _ := item[itemKey].(string); name == key {
+			body, err := json.Marshal(map[string]interface{}{"data": render(item, fields, hideHooks)})
+			if err != nil {
+				response.RenderInternalError(w, err)
+				return
+			}
+			writeJSON(w, body)
+			return
+		}
+	}
+	response.RenderNotFound(w, fmt.Sprintf("no %s named %q", strings.TrimSuffix(collection, "s"), key))
+}
+
+// render returns item as served: with only the requested fields, and
+// without its hooks when the caller may not see them
+func render(item map[string]interface{}, fields []string, hideHooks bool) map[string]interface{} {
+	selected := selectFields(item, fields)
+	if _, ok := selected[HooksSection]; !ok || !hideHooks {
+		return selected
+	}
+	visible := make(map[string]interface{}, len(selected))
+	for field, value := range selected {
+		if field != HooksSection {
+			visible[field] = value
+		}
+	}
+	return visible
+}
+
+// matchesFilters reports whether item matches every filter
+func matchesFilters(item map[string]interface{}, filters map[string]string) bool {
+	for field, want := range filters {
+		if !matchesPath(item, strings.Split(field, "."), want) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesPath reports whether any value at path under value equals want.
+// Arrays match when any element does, so filter[fields.name]=email finds
+// resources with an email field.
+func matchesPath(value interface{}, path []string, want string) bool {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, element := range v {
+			if matchesPath(element, path, want) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return false
+		}
+		return matchesPath(v[path[0]], path[1:], want)
+	}
+	if len(path) > 0 || value == nil {
+		return false
+	}
+	return fmt.Sprint(value) == want
+}
+
+// selectFields returns item with only the requested top-level fields, or
+// item itself when no fields were requested. Unknown fields are ignored, as
+// with sparse fieldsets in the API.
+func selectFields(item map[string]interface{}, fields []string) map[string]interface{} {
+	if fields == nil {
+		return item
+	}
+	selected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := item[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected
+}
+
+func writeJSON(w http.ResponseWriter, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,273 @@
+package introspect
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testMetadata = `{
+  "version": "1.0.0",
+  "source_hash": "abc123",
+  "resources": [
+    {
+      "name": "Post",
+      "fields": [
+        {"name": "id", "type": "uuid!", "nullable": false},
+        {"name": "title", "type": "string!", "nullable": false}
+      ],
+      "operations": ["list", "get", "create"]
+    },
+    {
+      "name": "User",
+      "fields": [
+        {"name": "id", "type": "uuid!", "nullable": false},
+        {"name": "email", "type": "string!", "nullable": false}
+      ],
+      "line": 12
+    },
+    {
+      "name": "AuditLog",
+      "fields": [{"name": "id", "type": "uuid!", "nullable": false}]
+    }
+  ],
+  "patterns": null,
+  "routes": [
+    {"method": "GET", "path": "/posts", "resource": "Post", "operation": "list"},
+    {"method": "POST", "path": "/posts", "resource": "Post", "operation": "create"},
+    {"method": "GET", "path": "/users", "resource": "User", "operation": "list"}
+  ]
+}`
+
+func serve(t *testing.T, target string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	handler, err := Handler(Options{Path: "/introspection", Metadata: testMetadata})
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s: invalid JSON %q", target, rec.Body.String())
+	}
+	return rec, body
+}
+
+func names(body map[string]interface{}, key string) string {
+	var values []string
+	for _, item := range body["data"].([]interface{}) {
+		values = append(values, item.(map[string]interface{})[key].(string))
+	}
+	return strings.Join(values, ",")
+}
+
+func TestHandler_Summary(t *testing.T) {
+	rec, body := serve(t, "/introspection")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if body["version"] != "1.0.0" || body["source_hash"] != "abc123" {
+		t.Errorf("summary = %v", body)
+	}
+
+	collections := body["collections"].(map[string]interface{})
+	resources := collections["resources"].(map[string]interface{})
+	if resources["total"] != float64(3) || resources["href"] != "/introspection/resources" {
+		t.Errorf("resources = %v", resources)
+	}
+	if patterns := collections["patterns"].(map[string]interface{}); patterns["total"] != float64(0) {
+		t.Errorf("patterns = %v", patterns)
+	}
+}
+
+func TestHandler_Pagination(t *testing.T) {
+	_, body := serve(t, "/introspection/resources?page[limit]=2")
+	if got := names(body, "name"); got != "Post,User" {
+		t.Errorf("first page = %s", got)
+	}
+	meta := body["meta"].(map[string]interface{})
+	if meta["total"] != float64(3) || meta["total_pages"] != float64(2) {
+		t.Errorf("meta = %v", meta)
+	}
+	links := body["links"].(map[string]interface{})
+	if next, _ := links["next"].(string); !strings.Contains(next, "page%5Boffset%5D=2") {
+		t.Errorf("next link = %v", links["next"])
+	}
+
+	_, body = serve(t, "/introspection/resources?page[limit]=2&page[offset]=2")
+	if got := names(body, "name"); got != "AuditLog" {
+		t.Errorf("second page = %s", got)
+	}
+
+	_, body = serve(t, "/introspection/resources?page[offset]=10")
+	if data := body["data"].([]interface{}); len(data) != 0 {
+		t.Errorf("expected an empty page past the end, got %v", data)
+	}
+
+	rec, _ := serve(t, "/introspection/resources?page[limit]=zero")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid limit status = %d", rec.Code)
+	}
+}
+
+func TestHandler_Filter(t *testing.T) {
+	tests := []struct {
+		target string
+		key    string
+		want   string
+	}{
+		{"/introspection/routes?filter[resource]=Post", "method", "GET,POST"},
+		{"/introspection/routes?filter[resource]=Post&filter[method]=GET", "path", "/posts"},
+		{"/introspection/resources?filter[fields.name]=email", "name", "User"},
+		{"/introspection/resources?filter[operations]=create", "name", "Post"},
+		{"/introspection/resources?filter[line]=12", "name", "User"},
+		{"/introspection/resources?filter[name]=Comment", "name", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			_, body := serve(t, tt.target)
+			if got := names(body, tt.key); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	rec, body := serve(t, "/introspection/routes?filter[color]=red")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(body["message"].(string), `"color"`) {
+		t.Errorf("unknown filter: status = %d, body = %v", rec.Code, body)
+	}
+}
+
+func TestHandler_Fields(t *testing.T) {
+	_, body := serve(t, "/introspection/routes?fields[routes]=method,path,missing")
+	for _, item := range body["data"].([]interface{}) {
+		route := item.(map[string]interface{})
+		if len(route) != 2 || route["method"] == nil || route["path"] == nil {
+			t.Errorf("route = %v", route)
+		}
+	}
+
+	_, body = serve(t, "/introspection/resources/User?fields[resources]=name")
+	if data := body["data"].(map[string]interface{}); len(data) != 1 || data["name"] != "User" {
+		t.Errorf("resource = %v", data)
+	}
+}
+
+func TestHandler_Item(t *testing.T) {
+	rec, body := serve(t, "/introspection/resources/Post")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if fields := body["data"].(map[string]interface{})["fields"].([]interface{}); len(fields) != 2 {
+		t.Errorf("fields = %v", fields)
+	}
+
+	for _, target := range []string{"/introspection/resources/Comment", "/introspection/routes/posts", "/introspection/hooks"} {
+		if rec, _ := serve(t, target); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", target, rec.Code)
+		}
+	}
+}
+
+func TestHandler_MethodNotAllowed(t *testing.T) {
+	handler, err := Handler(Options{Path: "/introspection", Metadata: testMetadata})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/introspection/resources", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d", rec.Code)
+	}
+}
+
+func TestHandler_InvalidMetadata(t *testing.T) {
+	if _, err := Handler(Options{Path: "/introspection", Metadata: "not json"}); err == nil {
+		t.Error("expected an error for invalid metadata")
+	}
+	if _, err := Handler(Options{Path: "/introspection", Metadata: `{"resources": {}}`}); err == nil {
+		t.Error("expected an error for resources that are not a list")
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	for value, want := range map[string]bool{"": true, "on": true, "off": false, "0": false, "FALSE": false} {
+		t.Setenv(EnvVar, value)
+		if got := Enabled(); got != want {
+			t.Errorf("Enabled() with %q = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestHandler_Conditional(t *testing.T) {
+	handler, err := Handler(Options{Path: "/introspection", Metadata: testMetadata})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/introspection/routes", nil)
+	etag, modified := rec.Header().Get("ETag"), rec.Header().Get("Last-Modified")
+	if etag != `"abc123"` || modified == "" || rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, ETag = %q, Last-Modified = %q", rec.Code, etag, modified)
+	}
+
+	for _, header := range []http.Header{
+		{"If-None-Match": {etag}},
+		{"If-None-Match": {`W/"old", ` + etag}},
+		{"If-Modified-Since": {modified}},
+	} {
+		if rec := get("/introspection/routes", header); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("%v: status = %d", header, rec.Code)
+		}
+	}
+	if rec := get("/introspection", http.Header{"If-None-Match": {etag}}); rec.Code != http.StatusNotModified {
+		t.Errorf("summary: status = %d", rec.Code)
+	}
+	if rec := get("/introspection/routes", http.Header{"If-None-Match": {`"old"`}}); rec.Code != http.StatusOK {
+		t.Errorf("stale tag: status = %d", rec.Code)
+	}
+}
+
+func TestHandler_ConditionalAuthenticated(t *testing.T) {
+	opts := Options{Path: "/introspection", Metadata: testMetadata, Auth: AuthBearer, Token: "s3cret", Exposure: map[string]string{"routes": Public}}
+	handler, err := Handler(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anonymous := httptest.NewRecorder()
+	handler.ServeHTTP(anonymous, httptest.NewRequest(http.MethodGet, "/introspection", nil))
+	req := httptest.NewRequest(http.MethodGet, "/introspection", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	authenticated := httptest.NewRecorder()
+	handler.ServeHTTP(authenticated, req)
+
+	if anonymous.Header().Get("ETag") == authenticated.Header().Get("ETag") {
+		t.Error("expected anonymous and authenticated views to be tagged apart")
+	}
+	if authenticated.Header().Get("Vary") != "Authorization" {
+		t.Errorf("Vary = %q", authenticated.Header().Get("Vary"))
+	}
+
+	// Unauthorized responses are never 304
+	req = httptest.NewRequest(http.MethodGet, "/introspection/resources", nil)
+	req.Header.Set("If-None-Match", "*")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d", rec.Code)
+	}
+}
@@ -0,0 +1,206 @@
+// Package mail sends the email generated hooks request with
+// Mail.send(template, to, vars). Messages are rendered from the templates of
+// the project's mail directory and delivered through SMTP, Amazon SES or
+// SendGrid, as configured under mail in conduit.yaml:
+//
+//	mail:
+//	  provider: smtp
+//	  from: "Blog <noreply@example.com>"
+//	  smtp:
+//	    host: smtp.example.com
+//	    port: 587
+//	    username: noreply@example.com
+//
+// Secrets are read from the environment when the application starts:
+// CONDUIT_MAIL_SMTP_PASSWORD for SMTP, SENDGRID_API_KEY for SendGrid, and the
+// standard AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN for
+// SES.
+//
+// Mail.send may only be called from @async hooks and blocks, so delivery never
+// delays a response. Failures are logged rather than returned.
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conduit-lang/conduit/pkg/web/correlation"
+)
+
+const (
+	// SMTPPasswordEnvVar holds the password of the SMTP account
+	SMTPPasswordEnvVar = "CONDUIT_MAIL_SMTP_PASSWORD"
+	// SendGridAPIKeyEnvVar holds the SendGrid API key
+	SendGridAPIKeyEnvVar = "SENDGRID_API_KEY"
+
+	// SendTimeout bounds how long Send waits for the provider to accept a message
+	SendTimeout = 30 * time.Second
+)
+
+// Provider names accepted in conduit.yaml
+const (
+	ProviderSMTP     = "smtp"
+	ProviderSES      = "ses"
+	ProviderSendGrid = "sendgrid"
+)
+
+// ErrNotConfigured is returned when mail is sent without a configured Mailer.
+var ErrNotConfigured = errors.New("mail is not configured")
+
+// Message is a rendered email.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string // Plain text body; empty when the template has none
+	HTML    string // HTML body; empty when the template has none
+}
+
+// Provider delivers messages.
+type Provider interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config is the mail section of conduit.yaml, without secrets.
+type Config struct {
+	Provider string // smtp, ses or sendgrid
+	From     string // Sender address, e.g. "Blog <noreply@example.com>"
+	SMTP     SMTPConfig
+	SES      SESConfig
+}
+
+// SMTPConfig configures the SMTP provider.
+type SMTPConfig struct {
+	Host     string
+	Port     int // 587 when zero
+	Username string
+}
+
+// SESConfig configures the Amazon SES provider.
+type SESConfig struct {
+	Region string // AWS_REGION when empty
+}
+
+// ProviderFromConfig returns the provider selected by cfg, with its secrets
+// read from the environment.
+func ProviderFromConfig(cfg Config) (Provider, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case ProviderSMTP:
+		if cfg.SMTP.Host == "" {
+			return nil, errors.New("mail.smtp.host is required for the smtp provider")
+		}
+		return &SMTP{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: os.Getenv(SMTPPasswordEnvVar),
+		}, nil
+	case ProviderSES:
+		region := cfg.SES.Region
+		if region == "" {
+			region = os.Getenv("AWS_REGION")
+		}
+		if region == "" {
+			return nil, errors.New("mail.ses.region or AWS_REGION is required for the ses provider")
+		}
+		return &SES{
+			Region:          region,
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	case ProviderSendGrid:
+		apiKey := os.Getenv(SendGridAPIKeyEnvVar)
+		if apiKey == "" {
+			return nil, fmt.Errorf("%s is required for the sendgrid provider", SendGridAPIKeyEnvVar)
+		}
+		return &SendGrid{APIKey: apiKey}, nil
+	default:
+		return nil, fmt.Errorf("mail.provider must be %s, %s or %s, got %q", ProviderSMTP, ProviderSES, ProviderSendGrid, cfg.Provider)
+	}
+}
+
+// Mailer renders templates and sends them from one address.
+type Mailer struct {
+	Provider  Provider
+	From      string
+	Templates *Templates
+}
+
+// Send renders the named template with vars and delivers it to to.
+func (m *Mailer) Send(ctx context.Context, template, to string, vars map[string]interface{}) error {
+	msg, err := m.Templates.Render(template, vars)
+	if err != nil {
+		return err
+	}
+	msg.From = m.From
+	msg.To = to
+	return m.Provider.Send(ctx, msg)
+}
+
+var (
+	mailerMu sync.RWMutex
+	mailer   *Mailer
+)
+
+// SetMailer sets the Mailer used by Send. A nil Mailer disables mail, which is
+// the default.
+func SetMailer(m *Mailer) {
+	mailerMu.Lock()
+	defer mailerMu.Unlock()
+	mailer = m
+}
+
+func current() *Mailer {
+	mailerMu.RLock()
+	defer mailerMu.RUnlock()
+	return mailer
+}
+
+// Configure parses the mail templates, given as file name to contents, and
+// sets the Mailer for cfg. Generated applications call it at startup with
+// the templates embedded at build time.
+func Configure(cfg Config, files map[string]string) error {
+	if cfg.From == "" {
+		return errors.New("mail.from is required")
+	}
+	templates, err := ParseTemplates(files)
+	if err != nil {
+		return err
+	}
+	provider, err := ProviderFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	SetMailer(&Mailer{Provider: provider, From: cfg.From, Templates: templates})
+	return nil
+}
+
+// Send renders and delivers a template, the runtime of Mail.send. It logs
+// failures instead of returning them because it runs in the background after
+// the write that triggered it.
+func Send(template, to string, vars map[string]interface{}) {
+	SendContext(context.Background(), template, to, vars)
+}
+
+// SendContext is Send for a hook serving a request: failures are logged with
+// the request ID ctx carries. The message is sent even when ctx is canceled.
+func SendContext(ctx context.Context, template, to string, vars map[string]interface{}) {
+	ctx = correlation.Detach(ctx)
+	m := current()
+	if m == nil {
+		correlation.Logf(ctx, "mail: %v, not sending %s to %s", ErrNotConfigured, template, to)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, SendTimeout)
+	defer cancel()
+	if err := m.Send(ctx, template, to, vars); err != nil {
+		correlation.Logf(ctx, "mail: failed to send %s to %s: %v", template, to, err)
+	}
+}
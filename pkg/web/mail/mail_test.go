@@ -0,0 +1,293 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var welcomeFiles = map[string]string{
+	"welcome.txt":  `{{define "subject"}}Welcome, {{.name}}{{end}}Hi {{.name}}, thanks for joining.`,
+	"welcome.html": `<p>Hi {{.name}}, thanks for joining.</p>`,
+	"reset.txt":    `{{define "subject"}}Reset your password{{end}}Use {{.link}}`,
+	"README.md":    `not a template`,
+}
+
+func TestParseTemplates(t *testing.T) {
+	templates, err := ParseTemplates(welcomeFiles)
+	if err != nil {
+		t.Fatalf("ParseTemplates() error = %v", err)
+	}
+	if want := []string{"reset", "welcome"}; !reflect.DeepEqual(templates.Names(), want) {
+		t.Errorf("Names() = %v, want %v", templates.Names(), want)
+	}
+
+	msg, err := templates.Render("welcome", map[string]interface{}{"name": "<Ada>"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := Message{
+		Subject: "Welcome, <Ada>",
+		Text:    "Hi <Ada>, thanks for joining.",
+		HTML:    "<p>Hi &lt;Ada&gt;, thanks for joining.</p>",
+	}
+	if msg != want {
+		t.Errorf("Render() = %+v, want %+v", msg, want)
+	}
+
+	if _, err := templates.Render("missing", nil); err == nil {
+		t.Error("Render() should fail for an unknown template")
+	}
+}
+
+func TestParseTemplatesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{"no subject", map[string]string{"welcome.txt": "Hi"}, "does not define a subject"},
+		{"syntax", map[string]string{"welcome.html": "{{.name"}, "welcome.html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTemplates(tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseTemplates() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadTemplateDir(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "welcome.txt"), []byte("hi"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.md"), []byte("ignored"), 0644)
+	os.Mkdir(filepath.Join(dir, "partials.html"), 0755)
+
+	files, err := ReadTemplateDir(dir)
+	if err != nil {
+		t.Fatalf("ReadTemplateDir() error = %v", err)
+	}
+	if want := map[string]string{"welcome.txt": "hi"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ReadTemplateDir() = %v, want %v", files, want)
+	}
+
+	files, err = ReadTemplateDir(filepath.Join(dir, "missing"))
+	if err != nil || len(files) != 0 {
+		t.Errorf("ReadTemplateDir() = %v, %v, want no templates for a missing directory", files, err)
+	}
+}
+
+func TestMIME(t *testing.T) {
+	msg := Message{From: "Blog <noreply@example.com>", To: "ada@example.com", Subject: "Grüße", Text: "plain"}
+	data, err := msg.MIME()
+	if err != nil {
+		t.Fatalf("MIME() error = %v", err)
+	}
+	for _, want := range []string{"From: Blog <noreply@example.com>\r\n", "Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=\r\n", "Content-Type: text/plain; charset=utf-8\r\n\r\nplain"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("MIME() missing %q:\n%s", want, data)
+		}
+	}
+
+	msg.HTML = "<p>html</p>"
+	data, err = msg.MIME()
+	if err != nil {
+		t.Fatalf("MIME() error = %v", err)
+	}
+	text, html := strings.Index(string(data), "plain"), strings.Index(string(data), "<p>html</p>")
+	if !strings.Contains(string(data), "multipart/alternative; boundary=") || text < 0 || html < text {
+		t.Errorf("MIME() should hold the text then the HTML alternative:\n%s", data)
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	var gotAddr, gotFrom string
+	var gotTo []string
+	s := &SMTP{Host: "smtp.example.com", Username: "user", Password: "secret",
+		sendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			gotAddr, gotFrom, gotTo = addr, from, to
+			return nil
+		}}
+
+	err := s.Send(context.Background(), Message{From: "Blog <noreply@example.com>", To: "Ada <ada@example.com>", Subject: "Hi", Text: "Hello"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if gotAddr != "smtp.example.com:587" || gotFrom != "noreply@example.com" || !reflect.DeepEqual(gotTo, []string{"ada@example.com"}) {
+		t.Errorf("SendMail(%q, %q, %v), want the default port and bare addresses", gotAddr, gotFrom, gotTo)
+	}
+
+	if err := s.Send(context.Background(), Message{From: "noreply@example.com", To: "not an address"}); err == nil {
+		t.Error("Send() should reject an invalid recipient")
+	}
+}
+
+// TestSignV4 checks the signer against the get-vanilla case of the AWS
+// Signature Version 4 test suite
+func TestSignV4(t *testing.T) {
+	req := httptest.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	req.Header.Del("Content-Type")
+	signV4(req, nil, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC), "us-east-1", "service",
+		"AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "")
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %s, want %s", got, want)
+	}
+}
+
+// recordingServer records the last request body and authorization header
+func recordingServer(t *testing.T, status int) (*httptest.Server, *map[string]interface{}, *http.Header) {
+	t.Helper()
+	var body map[string]interface{}
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &body, &header
+}
+
+func TestSES(t *testing.T) {
+	server, body, header := recordingServer(t, http.StatusOK)
+	ses := &SES{Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token", Endpoint: server.URL}
+
+	err := ses.Send(context.Background(), Message{From: "noreply@example.com", To: "ada@example.com", Subject: "Hi", HTML: "<p>Hello</p>"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	auth := header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-1/ses/aws4_request") ||
+		!strings.Contains(auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token") {
+		t.Errorf("Unexpected Authorization: %s", auth)
+	}
+	if header.Get("X-Amz-Security-Token") != "token" {
+		t.Error("Missing session token")
+	}
+	simple := (*body)["Content"].(map[string]interface{})["Simple"].(map[string]interface{})
+	if _, ok := simple["Body"].(map[string]interface{})["Html"]; !ok {
+		t.Errorf("Unexpected content: %v", simple)
+	}
+}
+
+func TestSendGrid(t *testing.T) {
+	server, body, header := recordingServer(t, http.StatusAccepted)
+	sg := &SendGrid{APIKey: "key", URL: server.URL}
+
+	err := sg.Send(context.Background(), Message{From: "Blog <noreply@example.com>", To: "ada@example.com", Subject: "Hi", Text: "Hello", HTML: "<p>Hello</p>"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if header.Get("Authorization") != "Bearer key" {
+		t.Errorf("Authorization = %q", header.Get("Authorization"))
+	}
+	if from := (*body)["from"]; !reflect.DeepEqual(from, map[string]interface{}{"email": "noreply@example.com", "name": "Blog"}) {
+		t.Errorf("from = %v", from)
+	}
+	content := (*body)["content"].([]interface{})
+	if len(content) != 2 || content[0].(map[string]interface{})["type"] != "text/plain" {
+		t.Errorf("content = %v, want text/plain first", content)
+	}
+
+	server, _, _ = recordingServer(t, http.StatusUnauthorized)
+	err = (&SendGrid{URL: server.URL}).Send(context.Background(), Message{From: "noreply@example.com", To: "ada@example.com"})
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Send() error = %v, want a 401 StatusError", err)
+	}
+}
+
+func TestProviderFromConfig(t *testing.T) {
+	t.Setenv(SMTPPasswordEnvVar, "secret")
+	provider, err := ProviderFromConfig(Config{Provider: "smtp", SMTP: SMTPConfig{Host: "smtp.example.com", Port: 2525, Username: "user"}})
+	if err != nil {
+		t.Fatalf("ProviderFromConfig() error = %v", err)
+	}
+	if want := (&SMTP{Host: "smtp.example.com", Port: 2525, Username: "user", Password: "secret"}); !reflect.DeepEqual(provider, want) {
+		t.Errorf("ProviderFromConfig() = %+v, want %+v", provider, want)
+	}
+
+	t.Setenv("AWS_REGION", "us-west-2")
+	if provider, err := ProviderFromConfig(Config{Provider: "ses"}); err != nil || provider.(*SES).Region != "us-west-2" {
+		t.Errorf("ProviderFromConfig() = %+v, %v, want the AWS_REGION", provider, err)
+	}
+
+	t.Setenv(SendGridAPIKeyEnvVar, "")
+	for _, cfg := range []Config{{Provider: "smtp"}, {Provider: "sendgrid"}, {Provider: "postmark"}} {
+		if _, err := ProviderFromConfig(cfg); err == nil {
+			t.Errorf("ProviderFromConfig(%+v) should fail", cfg)
+		}
+	}
+}
+
+type fakeProvider struct {
+	sent []Message
+	err  error
+}
+
+func (f *fakeProvider) Send(ctx context.Context, msg Message) error {
+	f.sent = append(f.sent, msg)
+	return f.err
+}
+
+func TestSend(t *testing.T) {
+	t.Cleanup(func() { SetMailer(nil) })
+
+	// Not configured: nothing is sent
+	Send("welcome", "ada@example.com", nil)
+
+	templates, err := ParseTemplates(welcomeFiles)
+	if err != nil {
+		t.Fatalf("ParseTemplates() error = %v", err)
+	}
+	fake := &fakeProvider{}
+	SetMailer(&Mailer{Provider: fake, From: "noreply@example.com", Templates: templates})
+
+	Send("welcome", "ada@example.com", map[string]interface{}{"name": "Ada"})
+	if len(fake.sent) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(fake.sent))
+	}
+	if msg := fake.sent[0]; msg.From != "noreply@example.com" || msg.To != "ada@example.com" || msg.Subject != "Welcome, Ada" {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+
+	// Unknown templates and provider failures are logged, not returned
+	Send("missing", "ada@example.com", nil)
+	fake.err = errors.New("unavailable")
+	Send("reset", "ada@example.com", nil)
+	if len(fake.sent) != 2 {
+		t.Errorf("Expected 2 messages, got %d", len(fake.sent))
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() { SetMailer(nil) })
+	t.Setenv(SendGridAPIKeyEnvVar, "key")
+
+	if err := Configure(Config{Provider: "sendgrid"}, welcomeFiles); err == nil {
+		t.Error("Configure() should require a from address")
+	}
+	if err := Configure(Config{Provider: "sendgrid", From: "noreply@example.com"}, welcomeFiles); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	if m := current(); m == nil || m.From != "noreply@example.com" {
+		t.Errorf("Configure() did not set the mailer: %+v", m)
+	}
+}
@@ -0,0 +1,315 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	netmail "net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// SMTP delivers messages through an SMTP server, using STARTTLS when the
+// server offers it and PLAIN authentication when a username is set.
+type SMTP struct {
+	Host     string
+	Port     int // 587 when zero
+	Username string
+	Password string
+
+	// sendMail is smtp.SendMail, replaced in tests
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// Send delivers the message.
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	from, err := netmail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address %q: %w", msg.From, err)
+	}
+	to, err := netmail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+	body, err := msg.MIME()
+	if err != nil {
+		return err
+	}
+
+	port := s.Port
+	if port == 0 {
+		port = 587
+	}
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	sendMail := s.sendMail
+	if sendMail == nil {
+		sendMail = smtp.SendMail
+	}
+
+	// net/smtp does not take a context; run it so the caller's deadline holds
+	done := make(chan error, 1)
+	go func() {
+		done <- sendMail(net.JoinHostPort(s.Host, strconv.Itoa(port)), auth, from.Address, []string{to.Address}, body)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// MIME encodes the message as an RFC 5322 email, multipart/alternative when it
+// has both a text and an HTML body.
+func (m Message) MIME() ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	header("From", m.From)
+	header("To", m.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+
+	if m.Text == "" || m.HTML == "" {
+		contentType, body := "text/plain; charset=utf-8", m.Text
+		if m.HTML != "" {
+			contentType, body = "text/html; charset=utf-8", m.HTML
+		}
+		header("Content-Type", contentType)
+		buf.WriteString("\r\n")
+		buf.WriteString(body)
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SES delivers messages through the Amazon SES v2 API, signing requests with
+// AWS Signature Version 4.
+type SES struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string       // For temporary credentials; optional
+	Endpoint        string       // https://email.<region>.amazonaws.com when empty
+	Client          *http.Client // http.DefaultClient when nil
+
+	now func() time.Time // time.Now, replaced in tests
+}
+
+// Send delivers the message.
+func (s *SES) Send(ctx context.Context, msg Message) error {
+	content := func(data string) map[string]string {
+		return map[string]string{"Data": data, "Charset": "UTF-8"}
+	}
+	body := map[string]interface{}{}
+	if msg.Text != "" {
+		body["Text"] = content(msg.Text)
+	}
+	if msg.HTML != "" {
+		body["Html"] = content(msg.HTML)
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"FromEmailAddress": msg.From,
+		"Destination":      map[string][]string{"ToAddresses": {msg.To}},
+		"Content": map[string]interface{}{
+			"Simple": map[string]interface{}{"Subject": content(msg.Subject), "Body": body},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://email." + s.Region + ".amazonaws.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/v2/email/outbound-emails", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	signV4(req, payload, now(), s.Region, "ses", s.AccessKeyID, s.SecretAccessKey, s.SessionToken)
+	return do(s.Client, req)
+}
+
+// signV4 adds AWS Signature Version 4 headers to req. The host, x-amz-date
+// and, when present, content-type and x-amz-security-token headers are signed.
+func signV4(req *http.Request, payload []byte, t time.Time, region, service, accessKeyID, secretAccessKey, sessionToken string) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	// Canonical headers are lowercase and sorted by name
+	headers := [][2]string{}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers = append(headers, [2]string{"content-type", contentType})
+	}
+	headers = append(headers, [2]string{"host", req.URL.Host}, [2]string{"x-amz-date", amzDate})
+	if sessionToken != "" {
+		headers = append(headers, [2]string{"x-amz-security-token", sessionToken})
+	}
+	var canonicalHeaders, signedHeaders string
+	for i, header := range headers {
+		canonicalHeaders += header[0] + ":" + header[1] + "\n"
+		if i > 0 {
+			signedHeaders += ";"
+		}
+		signedHeaders += header[0]
+	}
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := req.Method + "\n" + path + "\n" + req.URL.RawQuery + "\n" +
+		canonicalHeaders + "\n" + signedHeaders + "\n" + hexSHA256(payload)
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, signedHeaders, signature))
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// SendGrid delivers messages through the SendGrid v3 Mail Send API.
+type SendGrid struct {
+	APIKey string
+	URL    string       // https://api.sendgrid.com when empty
+	Client *http.Client // http.DefaultClient when nil
+}
+
+// Send delivers the message.
+func (s *SendGrid) Send(ctx context.Context, msg Message) error {
+	from, err := netmail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address %q: %w", msg.From, err)
+	}
+
+	// SendGrid requires text/plain before text/html
+	var content []map[string]string
+	if msg.Text != "" {
+		content = append(content, map[string]string{"type": "text/plain", "value": msg.Text})
+	}
+	if msg.HTML != "" {
+		content = append(content, map[string]string{"type": "text/html", "value": msg.HTML})
+	}
+	sender := map[string]string{"email": from.Address}
+	if from.Name != "" {
+		sender["name"] = from.Name
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"personalizations": []map[string]interface{}{
+			{"to": []map[string]string{{"email": msg.To}}},
+		},
+		"from":    sender,
+		"subject": msg.Subject,
+		"content": content,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := s.URL
+	if url == "" {
+		url = "https://api.sendgrid.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url+"/v3/mail/send", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	return do(s.Client, req)
+}
+
+// StatusError is returned when a provider API responds with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("mail provider returned %s", e.Status)
+	}
+	return fmt.Sprintf("mail provider returned %s: %s", e.Status, e.Body)
+}
+
+// do sends req and fails on a non-2xx response
+func do(client *http.Client, req *http.Request) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(bytes.TrimSpace(detail))}
+	}
+	return nil
+}
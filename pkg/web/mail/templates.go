@@ -0,0 +1,171 @@
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template file extensions. A template named welcome is welcome.txt,
+// welcome.html or both; either file defines its subject with
+// {{define "subject"}}...{{end}}.
+const (
+	TextExtension = ".txt"
+	HTMLExtension = ".html"
+)
+
+// DefaultTemplateDir is the project directory templates are read from when
+// conduit.yaml does not set mail.templates
+const DefaultTemplateDir = "app/mail"
+
+// Templates are the parsed mail templates of a project.
+type Templates struct {
+	subject map[string]*texttemplate.Template
+	text    map[string]*texttemplate.Template
+	html    map[string]*htmltemplate.Template
+}
+
+// ReadTemplateDir reads the template files of dir, keyed by file name. A
+// missing directory has no templates.
+func ReadTemplateDir(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || templateName(entry.Name()) == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[entry.Name()] = string(data)
+	}
+	return files, nil
+}
+
+// ParseTemplates parses template files keyed by file name. Files without a
+// .txt or .html extension are ignored; every template must define a subject.
+func ParseTemplates(files map[string]string) (*Templates, error) {
+	t := &Templates{
+		subject: make(map[string]*texttemplate.Template),
+		text:    make(map[string]*texttemplate.Template),
+		html:    make(map[string]*htmltemplate.Template),
+	}
+
+	// Parse in a stable order so errors are reported deterministically
+	fileNames := make([]string, 0, len(files))
+	for fileName := range files {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	for _, fileName := range fileNames {
+		name := templateName(fileName)
+		if name == "" {
+			continue
+		}
+		content := files[fileName]
+
+		if strings.HasSuffix(fileName, HTMLExtension) {
+			tmpl, err := htmltemplate.New(fileName).Option("missingkey=zero").Parse(content)
+			if err != nil {
+				return nil, fmt.Errorf("mail template %s: %w", fileName, err)
+			}
+			t.html[name] = tmpl
+		} else {
+			tmpl, err := texttemplate.New(fileName).Option("missingkey=zero").Parse(content)
+			if err != nil {
+				return nil, fmt.Errorf("mail template %s: %w", fileName, err)
+			}
+			t.text[name] = tmpl
+		}
+
+		// Subjects are plain text even when defined in the HTML file
+		subject, err := texttemplate.New(fileName).Option("missingkey=zero").Parse(content)
+		if err != nil {
+			return nil, fmt.Errorf("mail template %s: %w", fileName, err)
+		}
+		if subject.Lookup("subject") != nil {
+			t.subject[name] = subject
+		}
+	}
+
+	for _, name := range t.Names() {
+		if t.subject[name] == nil {
+			return nil, fmt.Errorf("mail template %s does not define a subject: add {{define \"subject\"}}...{{end}}", name)
+		}
+	}
+	return t, nil
+}
+
+// Names returns the names of the templates in sorted order.
+func (t *Templates) Names() []string {
+	seen := make(map[string]bool)
+	for name := range t.text {
+		seen[name] = true
+	}
+	for name := range t.html {
+		seen[name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render renders the subject and bodies of the named template with vars.
+func (t *Templates) Render(name string, vars map[string]interface{}) (Message, error) {
+	subject, ok := t.subject[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown mail template %q", name)
+	}
+
+	var msg Message
+	var buf bytes.Buffer
+	if err := subject.ExecuteTemplate(&buf, "subject", vars); err != nil {
+		return Message{}, fmt.Errorf("mail template %s: %w", name, err)
+	}
+	msg.Subject = strings.TrimSpace(buf.String())
+
+	if tmpl, ok := t.text[name]; ok {
+		buf.Reset()
+		if err := tmpl.Execute(&buf, vars); err != nil {
+			return Message{}, fmt.Errorf("mail template %s: %w", name, err)
+		}
+		msg.Text = strings.TrimSpace(buf.String())
+	}
+	if tmpl, ok := t.html[name]; ok {
+		buf.Reset()
+		if err := tmpl.Execute(&buf, vars); err != nil {
+			return Message{}, fmt.Errorf("mail template %s: %w", name, err)
+		}
+		msg.HTML = strings.TrimSpace(buf.String())
+	}
+	return msg, nil
+}
+
+// templateName returns the template a file belongs to, or "" for files that
+// are not templates
+func templateName(fileName string) string {
+	for _, ext := range []string{TextExtension, HTMLExtension} {
+		if name, ok := strings.CutSuffix(fileName, ext); ok && name != "" {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+// Package matview refreshes the materialized views behind resources declared
+// with @materialized. Migrations create each view, populated, with a unique
+// index on id; the application refreshes it concurrently on its schedule, so
+// list and show requests keep reading the previous contents during a refresh.
+//
+// Example:
+//
+//	matview.Maintain(ctx, db, []matview.View{
+//		{Name: "post_stats", Refresh: matview.Hourly},
+//	})
+//
+// Every instance of the application refreshes on its own schedule. A
+// transaction-scoped advisory lock keeps instances from refreshing the same
+// view at the same time; a refresh that finds the lock taken is skipped.
+package matview
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Schedule is how often a view is refreshed.
+type Schedule string
+
+// Schedules accepted by @materialized
+const (
+	Hourly Schedule = "hourly"
+	Daily  Schedule = "daily"
+	Weekly Schedule = "weekly"
+)
+
+// Interval returns the time between refreshes.
+func (s Schedule) Interval() time.Duration {
+	switch s {
+	case Daily:
+		return 24 * time.Hour
+	case Weekly:
+		return 7 * 24 * time.Hour
+	default:
+		return time.Hour
+	}
+}
+
+// View is a materialized view and its refresh schedule.
+type View struct {
+	Name    string
+	Refresh Schedule
+}
+
+// Refresh recomputes the view without blocking readers. It reports false,
+// without refreshing, when another instance holds the view's refresh lock.
+func Refresh(ctx context.Context, db *sql.DB, view string) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to refresh %s: %w", view, err)
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock(hashtext($1))", "matview:"+view).Scan(&locked); err != nil {
+		return false, fmt.Errorf("failed to lock %s: %w", view, err)
+	}
+	if !locked {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+view); err != nil {
+		return false, fmt.Errorf("failed to refresh %s: %w", view, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to refresh %s: %w", view, err)
+	}
+	return true, nil
+}
+
+// Maintain refreshes every view on its schedule until ctx is done. Views are
+// populated when they are created, so the first refresh happens one interval
+// after startup. Failures are logged rather than returned and retried on the
+// next interval.
+func Maintain(ctx context.Context, db *sql.DB, views []View) {
+	for _, view := range views {
+		go func(view View) {
+			ticker := time.NewTicker(view.Refresh.Interval())
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if _, err := Refresh(ctx, db, view.Name); err != nil {
+						log.Printf("materialized view refresh: %v", err)
+					}
+				}
+			}
+		}(view)
+	}
+}
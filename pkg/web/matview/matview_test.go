@@ -0,0 +1,94 @@
+package matview
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var lockQuery = regexp.QuoteMeta("SELECT pg_try_advisory_xact_lock(hashtext($1))")
+
+func TestScheduleInterval(t *testing.T) {
+	tests := map[Schedule]time.Duration{
+		Hourly: time.Hour,
+		Daily:  24 * time.Hour,
+		Weekly: 7 * 24 * time.Hour,
+	}
+	for schedule, want := range tests {
+		if got := schedule.Interval(); got != want {
+			t.Errorf("%s.Interval() = %v, want %v", schedule, got, want)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockQuery).WithArgs("matview:post_stats").
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectExec(regexp.QuoteMeta("REFRESH MATERIALIZED VIEW CONCURRENTLY post_stats")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	refreshed, err := Refresh(context.Background(), db, "post_stats")
+	if err != nil || !refreshed {
+		t.Fatalf("Refresh() = %v, %v; want true, nil", refreshed, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRefresh_Locked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Another instance is refreshing the view
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockQuery).WithArgs("matview:post_stats").
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	mock.ExpectRollback()
+
+	refreshed, err := Refresh(context.Background(), db, "post_stats")
+	if err != nil || refreshed {
+		t.Fatalf("Refresh() = %v, %v; want false, nil", refreshed, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRefresh_Error(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockQuery).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectExec("REFRESH MATERIALIZED VIEW CONCURRENTLY post_stats").
+		WillReturnError(errors.New(`cannot refresh materialized view "post_stats" concurrently`))
+	mock.ExpectRollback()
+
+	_, err = Refresh(context.Background(), db, "post_stats")
+	if err == nil || !strings.Contains(err.Error(), "failed to refresh post_stats") {
+		t.Errorf("Refresh() error = %v, want the view named", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,123 @@
+// Package methods answers HEAD and OPTIONS requests on the routes of generated
+// applications. Every path with a GET route also serves HEAD, with the headers
+// of the GET response and no body, and every path serves OPTIONS with an
+// Allow header listing the methods registered on it:
+//
+//	r.Get("/posts", ListPostHandler(db))
+//	r.Head("/posts", methods.Head(ListPostHandler(db)))
+//	r.Post("/posts", CreatePostHandler(db))
+//	r.Options("/posts", methods.Options("GET", "POST"))
+//
+//	curl -i -X OPTIONS localhost:8080/posts
+//	HTTP/1.1 204 No Content
+//	Allow: GET, HEAD, POST, OPTIONS
+package methods
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// order is the order of methods in Allow headers
+var order = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// Allow returns the methods a path serves given the methods of its routes:
+// those methods, HEAD when GET is among them, and OPTIONS, without duplicates
+// and in a fixed order.
+//
+// Example:
+//
+//	Allow([]string{"POST", "GET"}) // ["GET", "HEAD", "POST", "OPTIONS"]
+func Allow(methods []string) []string {
+	set := map[string]bool{http.MethodOptions: true}
+	for _, method := range methods {
+		set[strings.ToUpper(method)] = true
+	}
+	if set[http.MethodGet] {
+		set[http.MethodHead] = true
+	}
+
+	allowed := make([]string, 0, len(set))
+	for _, method := range order {
+		if set[method] {
+			allowed = append(allowed, method)
+			delete(set, method)
+		}
+	}
+	// Methods outside the fixed order follow it, sorted
+	var others []string
+	for method := range set {
+		others = append(others, method)
+	}
+	slices.Sort(others)
+	return append(allowed, others...)
+}
+
+// Options returns a handler answering OPTIONS with 204 No Content and an
+// Allow header for a path whose routes use the given methods.
+func Options(methods ...string) http.HandlerFunc {
+	allow := strings.Join(Allow(methods), ", ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Head serves HEAD requests with the GET handler next: the response keeps
+// its status and headers, including a Content-Length counted from the body
+// next writes, while the body itself is discarded.
+func Head(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hw := &headWriter{ResponseWriter: w}
+		next.ServeHTTP(hw, r)
+		hw.finish()
+	}
+}
+
+// headWriter discards the body of a response and holds back its status until
+// the handler returns, so the body length is known
+type headWriter struct {
+	http.ResponseWriter
+	status int
+	length int
+}
+
+func (w *headWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.length += len(p)
+	return len(p), nil
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (w *headWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *headWriter) finish() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	header := w.ResponseWriter.Header()
+	if w.length > 0 && header.Get("Content-Length") == "" && header.Get("Transfer-Encoding") == "" {
+		header.Set("Content-Length", strconv.Itoa(w.length))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
@@ -0,0 +1,79 @@
+package methods
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAllow(t *testing.T) {
+	tests := []struct {
+		methods []string
+		want    []string
+	}{
+		{nil, []string{"OPTIONS"}},
+		{[]string{"POST", "GET"}, []string{"GET", "HEAD", "POST", "OPTIONS"}},
+		{[]string{"delete", "PATCH", "PUT", "GET", "GET"}, []string{"GET", "HEAD", "PUT", "PATCH", "DELETE", "OPTIONS"}},
+		{[]string{"PROPFIND", "POST"}, []string{"POST", "OPTIONS", "PROPFIND"}},
+	}
+	for _, tt := range tests {
+		if got := Allow(tt.methods); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Allow(%v) = %v, want %v", tt.methods, got, tt.want)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	w := httptest.NewRecorder()
+	Options("GET", "POST").ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/posts", nil))
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", w.Code)
+	}
+	if got := w.Header().Get("Allow"); got != "GET, HEAD, POST, OPTIONS" {
+		t.Errorf("Allow = %q", got)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("body = %q, want none", w.Body.String())
+	}
+}
+
+func TestHead(t *testing.T) {
+	get := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":[]}`))
+		w.Write([]byte("\n"))
+	})
+
+	w := httptest.NewRecorder()
+	Head(get).ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/posts", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("body = %q, want none", w.Body.String())
+	}
+	for header, want := range map[string]string{
+		"Content-Type":   "application/vnd.api+json",
+		"ETag":           `"v1"`,
+		"Content-Length": "12",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	// Statuses without a body pass through
+	notModified := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	})
+	w = httptest.NewRecorder()
+	Head(notModified).ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/posts/1", nil))
+	if w.Code != http.StatusNotModified || w.Header().Get("Content-Length") != "" {
+		t.Errorf("status = %d, Content-Length = %q, want 304 without length", w.Code, w.Header().Get("Content-Length"))
+	}
+}
@@ -0,0 +1,200 @@
+// Package metrics records per-route HTTP metrics for generated applications and
+// serves them in the Prometheus text exposition format, without depending on a
+// Prometheus client library.
+//
+// Requests are labeled with the chi route pattern (e.g. "/posts/{id}") rather
+// than the raw path, so label cardinality stays bounded. The metric names are
+// stable: SLO alerting rules generated from @slo annotations query them.
+//
+// Example:
+//
+//	r.Use(metrics.Middleware(metrics.Default))
+//	r.Get("/metrics", metrics.Handler(metrics.Default))
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	// RequestsTotal counts requests by method, route and status code
+	RequestsTotal = "conduit_http_requests_total"
+	// RequestDuration is a histogram of request latency in seconds by method and route
+	RequestDuration = "conduit_http_request_duration_seconds"
+)
+
+// UnmatchedRoute labels requests that did not match any route
+const UnmatchedRoute = "unmatched"
+
+// DefaultBuckets are the latency histogram buckets in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Default is the registry used by generated applications
+var Default = NewRegistry(DefaultBuckets)
+
+type requestKey struct {
+	method string
+	route  string
+	code   int
+}
+
+type routeKey struct {
+	method string
+	route  string
+}
+
+type histogram struct {
+	counts []uint64 // Cumulative count per bucket
+	sum    float64
+	count  uint64
+}
+
+// Registry holds request counters and latency histograms. It is safe for
+// concurrent use.
+type Registry struct {
+	mu        sync.Mutex
+	buckets   []float64
+	requests  map[requestKey]uint64
+	durations map[routeKey]*histogram
+}
+
+// NewRegistry creates a registry with the given histogram buckets in seconds
+func NewRegistry(buckets []float64) *Registry {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &Registry{
+		buckets:   sorted,
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[routeKey]*histogram),
+	}
+}
+
+// Observe records a finished request
+func (reg *Registry) Observe(method, route string, code int, duration time.Duration) {
+	seconds := duration.Seconds()
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.requests[requestKey{method, route, code}]++
+
+	key := routeKey{method, route}
+	h, ok := reg.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(reg.buckets))}
+		reg.durations[key] = h
+	}
+	for i, upper := range reg.buckets {
+		if seconds <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// Middleware records the method, route pattern, status code and latency of
+// every request. It must be installed on a chi router.
+func Middleware(reg *Registry) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			route := UnmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			code := ww.Status()
+			if code == 0 {
+				code = http.StatusOK
+			}
+			reg.Observe(r.Method, route, code, time.Since(start))
+		})
+	}
+}
+
+// Handler serves the registry in the Prometheus text exposition format
+func Handler(reg *Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		reg.WriteText(w)
+	}
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format,
+// sorted by labels so the output is deterministic
+func (reg *Registry) WriteText(w io.Writer) error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	var b strings.Builder
+
+	requestKeys := make([]requestKey, 0, len(reg.requests))
+	for key := range reg.requests {
+		requestKeys = append(requestKeys, key)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		a, c := requestKeys[i], requestKeys[j]
+		if a.route != c.route {
+			return a.route < c.route
+		}
+		if a.method != c.method {
+			return a.method < c.method
+		}
+		return a.code < c.code
+	})
+
+	fmt.Fprintf(&b, "# HELP %s Total HTTP requests by route and status code.\n", RequestsTotal)
+	fmt.Fprintf(&b, "# TYPE %s counter\n", RequestsTotal)
+	for _, key := range requestKeys {
+		fmt.Fprintf(&b, "%s{method=%s,route=%s,code=\"%d\"} %d\n",
+			RequestsTotal, quote(key.method), quote(key.route), key.code, reg.requests[key])
+	}
+
+	routeKeys := make([]routeKey, 0, len(reg.durations))
+	for key := range reg.durations {
+		routeKeys = append(routeKeys, key)
+	}
+	sort.Slice(routeKeys, func(i, j int) bool {
+		if routeKeys[i].route != routeKeys[j].route {
+			return routeKeys[i].route < routeKeys[j].route
+		}
+		return routeKeys[i].method < routeKeys[j].method
+	})
+
+	fmt.Fprintf(&b, "# HELP %s HTTP request latency in seconds by route.\n", RequestDuration)
+	fmt.Fprintf(&b, "# TYPE %s histogram\n", RequestDuration)
+	for _, key := range routeKeys {
+		h := reg.durations[key]
+		labels := fmt.Sprintf("method=%s,route=%s", quote(key.method), quote(key.route))
+		for i, upper := range reg.buckets {
+			fmt.Fprintf(&b, "%s_bucket{%s,le=\"%s\"} %d\n",
+				RequestDuration, labels, strconv.FormatFloat(upper, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(&b, "%s_bucket{%s,le=\"+Inf\"} %d\n", RequestDuration, labels, h.count)
+		fmt.Fprintf(&b, "%s_sum{%s} %s\n", RequestDuration, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "%s_count{%s} %d\n", RequestDuration, labels, h.count)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quote formats a label value, escaping backslashes, quotes and newlines
+func quote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMiddleware_RecordsRoutePatterns(t *testing.T) {
+	reg := NewRegistry([]float64{0.1, 1})
+
+	r := chi.NewRouter()
+	r.Use(Middleware(reg))
+	r.Get("/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "id") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	for _, path := range []string{"/posts/1", "/posts/2", "/posts/missing", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var out strings.Builder
+	if err := reg.WriteText(&out); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	text := out.String()
+
+	for _, want := range []string{
+		`conduit_http_requests_total{method="GET",route="/posts/{id}",code="200"} 2`,
+		`conduit_http_requests_total{method="GET",route="/posts/{id}",code="404"} 1`,
+		`conduit_http_requests_total{method="GET",route="unmatched",code="404"} 1`,
+		`conduit_http_request_duration_seconds_bucket{method="GET",route="/posts/{id}",le="+Inf"} 3`,
+		`conduit_http_request_duration_seconds_count{method="GET",route="/posts/{id}"} 3`,
+		"# TYPE conduit_http_request_duration_seconds histogram",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics output missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "/posts/1") {
+		t.Error("raw paths must not be used as labels")
+	}
+}
+
+func TestRegistry_HistogramBuckets(t *testing.T) {
+	reg := NewRegistry([]float64{1, 0.1})
+	reg.Observe("GET", "/posts", 200, 50*time.Millisecond)
+	reg.Observe("GET", "/posts", 200, 500*time.Millisecond)
+	reg.Observe("GET", "/posts", 500, 2*time.Second)
+
+	var out strings.Builder
+	reg.WriteText(&out)
+	text := out.String()
+
+	for _, want := range []string{
+		`conduit_http_request_duration_seconds_bucket{method="GET",route="/posts",le="0.1"} 1`,
+		`conduit_http_request_duration_seconds_bucket{method="GET",route="/posts",le="1"} 2`,
+		`conduit_http_request_duration_seconds_bucket{method="GET",route="/posts",le="+Inf"} 3`,
+		`conduit_http_request_duration_seconds_sum{method="GET",route="/posts"} 2.55`,
+		`conduit_http_requests_total{method="GET",route="/posts",code="500"} 1`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics output missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	reg := NewRegistry(DefaultBuckets)
+	reg.Observe("POST", `/weird"route`, 201, time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	Handler(reg)(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), `route="/weird\"route"`) {
+		t.Errorf("label values should be escaped:\n%s", rec.Body.String())
+	}
+}
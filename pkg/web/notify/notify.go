@@ -0,0 +1,337 @@
+// Package notify delivers the SMS and push notifications generated hooks
+// request with Notify.send(channel, target, payload). Each call enqueues a job
+// on the PostgreSQL job queue; workers deliver it through the channel's
+// adapter, so failed deliveries are retried and every delivery's status is the
+// status of its job. Channels are configured under notify in conduit.yaml:
+//
+//	notify:
+//	  channels:
+//	    sms:
+//	      provider: twilio
+//	      from: "+15550001234"
+//	      rate_limit: 60       # messages per minute; 0 is unlimited
+//	    push:
+//	      provider: fcm
+//	      project_id: my-app
+//	    ios:
+//	      provider: apns
+//	      topic: com.example.app
+//	      team_id: ABCDE12345
+//	      key_id: FGHIJ67890
+//
+// Secrets are read from the environment when the application starts:
+// TWILIO_ACCOUNT_SID and TWILIO_AUTH_TOKEN for Twilio, a service account file
+// named by GOOGLE_APPLICATION_CREDENTIALS for FCM, and the .p8 signing key in
+// CONDUIT_APNS_KEY for APNs.
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conduit-lang/conduit/internal/web/jobs"
+	"github.com/conduit-lang/conduit/internal/web/ratelimit"
+	"github.com/conduit-lang/conduit/pkg/web/correlation"
+)
+
+const (
+	// TwilioAccountSIDEnvVar holds the Twilio account SID
+	TwilioAccountSIDEnvVar = "TWILIO_ACCOUNT_SID"
+	// TwilioAuthTokenEnvVar holds the Twilio auth token
+	TwilioAuthTokenEnvVar = "TWILIO_AUTH_TOKEN"
+	// FCMCredentialsEnvVar names the Google service account JSON file used for FCM
+	FCMCredentialsEnvVar = "GOOGLE_APPLICATION_CREDENTIALS"
+	// APNsKeyEnvVar holds the PEM-encoded .p8 key that signs APNs requests
+	APNsKeyEnvVar = "CONDUIT_APNS_KEY"
+
+	// JobType is the job type of queued notifications
+	JobType = "notify.send"
+	// DefaultQueue is the job queue notifications are delivered from
+	DefaultQueue = "notify"
+	// DefaultWorkers is the number of delivery workers
+	DefaultWorkers = 2
+	// SendTimeout bounds how long one delivery attempt waits for the provider
+	SendTimeout = 30 * time.Second
+)
+
+// Provider names accepted in conduit.yaml
+const (
+	ProviderTwilio = "twilio"
+	ProviderFCM    = "fcm"
+	ProviderAPNs   = "apns"
+)
+
+// ErrNotConfigured is returned when a notification is sent without a configured Notifier.
+var ErrNotConfigured = errors.New("notifications are not configured")
+
+// Payload is the content of a notification. SMS channels send "body"; push
+// channels send "title" and "body" as the alert and every other key as data.
+type Payload map[string]interface{}
+
+// Channel delivers notifications to a target: a phone number for SMS, a
+// device token for push.
+type Channel interface {
+	Send(ctx context.Context, target string, payload Payload) error
+}
+
+// Config is the notify section of conduit.yaml, without secrets.
+type Config struct {
+	Queue    string // DefaultQueue when empty
+	Workers  int    // DefaultWorkers when zero
+	Channels map[string]ChannelConfig
+}
+
+// ChannelConfig configures one named channel.
+type ChannelConfig struct {
+	Provider  string // twilio, fcm or apns
+	RateLimit int    // Messages per minute; 0 is unlimited
+
+	From      string // twilio: sender number or messaging service SID (MG...)
+	ProjectID string // fcm: Firebase project; the service account's project when empty
+	Topic     string // apns: app bundle ID
+	TeamID    string // apns: Apple developer team ID
+	KeyID     string // apns: ID of the signing key
+	Sandbox   bool   // apns: use the development environment
+}
+
+// ChannelFromConfig returns the adapter selected by cfg, with its secrets read
+// from the environment.
+func ChannelFromConfig(name string, cfg ChannelConfig) (Channel, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case ProviderTwilio:
+		return NewTwilioFromEnv(cfg.From)
+	case ProviderFCM:
+		return NewFCMFromEnv(cfg.ProjectID)
+	case ProviderAPNs:
+		return NewAPNsFromEnv(cfg.Topic, cfg.TeamID, cfg.KeyID, cfg.Sandbox)
+	default:
+		return nil, fmt.Errorf("notify.channels.%s.provider must be %s, %s or %s, got %q",
+			name, ProviderTwilio, ProviderFCM, ProviderAPNs, cfg.Provider)
+	}
+}
+
+// channel is a configured channel and its rate limiter
+type channel struct {
+	Channel
+	limiter *ratelimit.TokenBucket // nil when unlimited
+	limit   int
+}
+
+// wait blocks until the channel's rate limit allows another message
+func (c *channel) wait(ctx context.Context, name string) error {
+	if c.limiter == nil {
+		return nil
+	}
+	for {
+		info, err := c.limiter.Allow(ctx, name)
+		if err != nil {
+			return err
+		}
+		if info.Allowed {
+			return nil
+		}
+		// One token is refilled every minute / limit
+		select {
+		case <-time.After(time.Minute / time.Duration(c.limit)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Notifier queues notifications and delivers them with a worker pool.
+type Notifier struct {
+	queue     *jobs.Queue
+	queueName string
+	pool      *jobs.WorkerPool
+	channels  map[string]*channel
+}
+
+// New returns a Notifier delivering through channels, keyed by name, from the
+// job queue in db. Channels are limited to limits[name] messages per minute.
+func New(db *sql.DB, queueName string, workers int, channels map[string]Channel, limits map[string]int) *Notifier {
+	if queueName == "" {
+		queueName = DefaultQueue
+	}
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	n := &Notifier{
+		queue:     jobs.NewQueue(db),
+		queueName: queueName,
+		channels:  make(map[string]*channel, len(channels)),
+	}
+	for name, adapter := range channels {
+		c := &channel{Channel: adapter, limit: limits[name]}
+		if c.limit > 0 {
+			c.limiter = ratelimit.NewTokenBucketWithConfig(ratelimit.TokenBucketConfig{
+				Capacity:   c.limit,
+				RefillRate: time.Minute,
+			})
+		}
+		n.channels[name] = c
+	}
+
+	n.pool = jobs.NewWorkerPool(n.queue, queueName, workers)
+	n.pool.RegisterHandler(JobType, n.deliver)
+	return n
+}
+
+// Channels returns the names of the configured channels in sorted order.
+func (n *Notifier) Channels() []string {
+	names := make([]string, 0, len(n.channels))
+	for name := range n.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Enqueue queues a notification and returns its job, whose status is the
+// delivery status.
+func (n *Notifier) Enqueue(ctx context.Context, channel, target string, payload Payload) (*jobs.Job, error) {
+	if _, ok := n.channels[channel]; !ok {
+		return nil, fmt.Errorf("unknown notification channel %q", channel)
+	}
+	if target == "" {
+		return nil, fmt.Errorf("notification on %s has no target", channel)
+	}
+
+	job := jobs.NewJob(n.queueName, JobType, map[string]interface{}{
+		"channel": channel,
+		"target":  target,
+		"payload": map[string]interface{}(payload),
+	})
+	if err := n.queue.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Status returns the delivery status of a queued notification: pending until
+// a worker delivers it, completed once the provider accepts it, and failed
+// after the last retry.
+func (n *Notifier) Status(ctx context.Context, id uuid.UUID) (jobs.JobStatus, error) {
+	job, err := n.queue.GetJob(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return job.Status, nil
+}
+
+// deliver is the job handler that sends a queued notification
+func (n *Notifier) deliver(ctx context.Context, job map[string]interface{}) error {
+	name, _ := job["channel"].(string)
+	target, _ := job["target"].(string)
+	payload, _ := job["payload"].(map[string]interface{})
+
+	c, ok := n.channels[name]
+	if !ok {
+		return fmt.Errorf("unknown notification channel %q", name)
+	}
+	if err := c.wait(ctx, name); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, SendTimeout)
+	defer cancel()
+	return c.Send(ctx, target, Payload(payload))
+}
+
+// Start starts the delivery workers.
+func (n *Notifier) Start(ctx context.Context) {
+	n.pool.Start(ctx)
+}
+
+// Stop waits for in-flight deliveries and stops the workers.
+func (n *Notifier) Stop() {
+	n.pool.Stop()
+	for _, c := range n.channels {
+		if c.limiter != nil {
+			c.limiter.Close()
+		}
+	}
+}
+
+var (
+	notifierMu sync.RWMutex
+	notifier   *Notifier
+)
+
+// SetNotifier sets the Notifier used by Send. A nil Notifier disables
+// notifications, which is the default.
+func SetNotifier(n *Notifier) {
+	notifierMu.Lock()
+	defer notifierMu.Unlock()
+	notifier = n
+}
+
+func current() *Notifier {
+	notifierMu.RLock()
+	defer notifierMu.RUnlock()
+	return notifier
+}
+
+// Configure creates the channels of cfg, creates the jobs table if needed and
+// sets the Notifier used by Send. Generated applications call it at startup and
+// then start the returned Notifier's workers.
+func Configure(ctx context.Context, db *sql.DB, cfg Config) (*Notifier, error) {
+	if len(cfg.Channels) == 0 {
+		return nil, errors.New("notify.channels must configure at least one channel")
+	}
+
+	channels := make(map[string]Channel, len(cfg.Channels))
+	limits := make(map[string]int, len(cfg.Channels))
+	for name, channelCfg := range cfg.Channels {
+		adapter, err := ChannelFromConfig(name, channelCfg)
+		if err != nil {
+			return nil, err
+		}
+		channels[name] = adapter
+		limits[name] = channelCfg.RateLimit
+	}
+
+	n := New(db, cfg.Queue, cfg.Workers, channels, limits)
+	if err := n.queue.CreateTable(ctx); err != nil {
+		return nil, err
+	}
+	SetNotifier(n)
+	return n, nil
+}
+
+// Send queues a notification, the runtime of Notify.send. It logs failures
+// instead of returning them so a notification never fails the write that
+// triggered it.
+func Send(channel, target string, payload map[string]interface{}) {
+	SendContext(context.Background(), channel, target, payload)
+}
+
+// SendContext is Send for a hook serving a request: the queued job records
+// the request ID ctx carries, and the delivery runs with it. The notification
+// is queued even when ctx is canceled.
+func SendContext(ctx context.Context, channel, target string, payload map[string]interface{}) {
+	ctx = correlation.Detach(ctx)
+	n := current()
+	if n == nil {
+		correlation.Logf(ctx, "notify: %v, not sending on %s to %s", ErrNotConfigured, channel, target)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, SendTimeout)
+	defer cancel()
+	job, err := n.Enqueue(ctx, channel, target, payload)
+	if err != nil {
+		correlation.Logf(ctx, "notify: failed to queue notification on %s to %s: %v", channel, target, err)
+		return
+	}
+	correlation.Logf(ctx, "notify: queued notification %s on %s", job.ID, channel)
+}
@@ -0,0 +1,390 @@
+package notify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/conduit-lang/conduit/internal/web/jobs"
+	"github.com/conduit-lang/conduit/pkg/web/correlation"
+)
+
+func TestTwilio(t *testing.T) {
+	var form url.Values
+	var path, user, pass string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		user, pass, _ = r.BasicAuth()
+		r.ParseForm()
+		form = r.PostForm
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	twilio := &Twilio{AccountSID: "AC123", AuthToken: "secret", From: "+15550001234", URL: srv.URL}
+	if err := twilio.Send(context.Background(), "+15559876543", Payload{"body": "Your code is 1234"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if path != "/2010-04-01/Accounts/AC123/Messages.json" {
+		t.Errorf("path = %s", path)
+	}
+	if user != "AC123" || pass != "secret" {
+		t.Errorf("basic auth = %s:%s", user, pass)
+	}
+	want := url.Values{"To": {"+15559876543"}, "From": {"+15550001234"}, "Body": {"Your code is 1234"}}
+	if !reflect.DeepEqual(form, want) {
+		t.Errorf("form = %v, want %v", form, want)
+	}
+
+	// Messaging service SIDs are sent as MessagingServiceSid
+	twilio.From = "MG999"
+	if err := twilio.Send(context.Background(), "+15559876543", Payload{"body": "Hi"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if form.Get("MessagingServiceSid") != "MG999" || form.Get("From") != "" {
+		t.Errorf("form = %v, want MessagingServiceSid", form)
+	}
+
+	if err := twilio.Send(context.Background(), "+15559876543", Payload{"title": "no body"}); err == nil {
+		t.Error("Send() should fail without a body")
+	}
+}
+
+func TestFCM(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	tokenRequests := 0
+	var message map[string]interface{}
+	var authorization string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/token":
+			tokenRequests++
+			r.ParseForm()
+			claims := jwt.MapClaims{}
+			if _, err := jwt.ParseWithClaims(r.PostForm.Get("assertion"), claims, func(*jwt.Token) (interface{}, error) {
+				return &key.PublicKey, nil
+			}, jwt.WithAudience(srv.URL+"/token")); err != nil {
+				t.Errorf("invalid assertion: %v", err)
+			}
+			if claims["iss"] != "push@my-app.iam.gserviceaccount.com" {
+				t.Errorf("iss = %v", claims["iss"])
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "ya29.token", "expires_in": 3600})
+		case "/v1/projects/my-app/messages:send":
+			authorization = r.Header.Get("Authorization")
+			json.NewDecoder(r.Body).Decode(&message)
+			w.Write([]byte(`{"name": "projects/my-app/messages/1"}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	credentials, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "my-app",
+		"client_email": "push@my-app.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		"token_uri":    srv.URL + "/token",
+	})
+	fcm, err := NewFCM(credentials, "")
+	if err != nil {
+		t.Fatalf("NewFCM() error = %v", err)
+	}
+	fcm.URL = srv.URL
+
+	payload := Payload{"title": "New comment", "body": "Ada replied", "post_id": 42}
+	for i := 0; i < 2; i++ {
+		if err := fcm.Send(context.Background(), "device-token", payload); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("token requests = %d, want the access token reused", tokenRequests)
+	}
+	if authorization != "Bearer ya29.token" {
+		t.Errorf("Authorization = %s", authorization)
+	}
+	want := map[string]interface{}{"message": map[string]interface{}{
+		"token":        "device-token",
+		"notification": map[string]interface{}{"title": "New comment", "body": "Ada replied"},
+		"data":         map[string]interface{}{"post_id": "42"},
+	}}
+	if !reflect.DeepEqual(message, want) {
+		t.Errorf("message = %v, want %v", message, want)
+	}
+
+	// The token is refreshed once it is about to expire
+	fcm.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if err := fcm.Send(context.Background(), "device-token", payload); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if tokenRequests != 2 {
+		t.Errorf("token requests = %d, want a refreshed token", tokenRequests)
+	}
+}
+
+func TestAPNs(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var req *http.Request
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path == "/3/device/bad-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"reason":"BadDeviceToken"}`))
+		}
+	}))
+	defer srv.Close()
+
+	apns := &APNs{Topic: "com.example.app", TeamID: "TEAM123456", KeyID: "KEY1234567", Key: key, URL: srv.URL}
+	if err := apns.Send(context.Background(), "device-token", Payload{"title": "Hi", "body": "Welcome", "screen": "home"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if req.URL.Path != "/3/device/device-token" {
+		t.Errorf("path = %s", req.URL.Path)
+	}
+	if req.Header.Get("apns-topic") != "com.example.app" || req.Header.Get("apns-push-type") != "alert" {
+		t.Errorf("headers = %v", req.Header)
+	}
+	token, err := jwt.Parse(strings.TrimPrefix(req.Header.Get("Authorization"), "bearer "), func(*jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"}), jwt.WithIssuer("TEAM123456"))
+	if err != nil {
+		t.Fatalf("invalid provider token: %v", err)
+	}
+	if token.Header["kid"] != "KEY1234567" {
+		t.Errorf("kid = %v", token.Header["kid"])
+	}
+	want := map[string]interface{}{
+		"aps":    map[string]interface{}{"alert": map[string]interface{}{"title": "Hi", "body": "Welcome"}},
+		"screen": "home",
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("body = %v, want %v", body, want)
+	}
+
+	// Payloads without an alert are background pushes
+	if err := apns.Send(context.Background(), "device-token", Payload{"sync": true}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if req.Header.Get("apns-push-type") != "background" || req.Header.Get("apns-priority") != "5" {
+		t.Errorf("headers = %v, want a background push", req.Header)
+	}
+
+	err = apns.Send(context.Background(), "bad-token", Payload{"body": "Hi"})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest || !strings.Contains(err.Error(), "BadDeviceToken") {
+		t.Errorf("Send() error = %v, want BadDeviceToken status error", err)
+	}
+}
+
+func TestChannelFromConfig(t *testing.T) {
+	t.Setenv(TwilioAccountSIDEnvVar, "AC123")
+	t.Setenv(TwilioAuthTokenEnvVar, "secret")
+	t.Setenv(FCMCredentialsEnvVar, "")
+	t.Setenv(APNsKeyEnvVar, "")
+
+	channel, err := ChannelFromConfig("sms", ChannelConfig{Provider: "twilio", From: "+15550001234"})
+	if err != nil {
+		t.Fatalf("ChannelFromConfig() error = %v", err)
+	}
+	if twilio, ok := channel.(*Twilio); !ok || twilio.AccountSID != "AC123" || twilio.From != "+15550001234" {
+		t.Errorf("ChannelFromConfig() = %#v", channel)
+	}
+
+	tests := []struct {
+		cfg     ChannelConfig
+		wantErr string
+	}{
+		{ChannelConfig{Provider: "pigeon"}, "notify.channels.test.provider must be twilio, fcm or apns"},
+		{ChannelConfig{Provider: "twilio"}, "from is required"},
+		{ChannelConfig{Provider: "fcm"}, FCMCredentialsEnvVar + " is required"},
+		{ChannelConfig{Provider: "apns", Topic: "com.example.app"}, "team_id and key_id are required"},
+		{ChannelConfig{Provider: "apns", Topic: "com.example.app", TeamID: "T", KeyID: "K"}, APNsKeyEnvVar + " is required"},
+	}
+	for _, tt := range tests {
+		if _, err := ChannelFromConfig("test", tt.cfg); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ChannelFromConfig(%+v) error = %v, want %q", tt.cfg, err, tt.wantErr)
+		}
+	}
+}
+
+// recordingChannel records the notifications it is asked to send
+type recordingChannel struct {
+	mu    sync.Mutex
+	sent  []string
+	err   error
+	calls int
+}
+
+func (c *recordingChannel) Send(ctx context.Context, target string, payload Payload) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.err != nil {
+		return c.err
+	}
+	c.sent = append(c.sent, target+": "+payload.String("body"))
+	return nil
+}
+
+func TestNotifierEnqueue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	n := New(db, "", 0, map[string]Channel{"sms": &recordingChannel{}}, nil)
+	mock.ExpectExec(`INSERT INTO jobs`).
+		WithArgs(sqlmock.AnyArg(), DefaultQueue, JobType, sqlmock.AnyArg(), jobs.JobStatusPending,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	job, err := n.Enqueue(context.Background(), "sms", "+15559876543", Payload{"body": "Hi"})
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	want := map[string]interface{}{"channel": "sms", "target": "+15559876543", "payload": map[string]interface{}{"body": "Hi"}}
+	if !reflect.DeepEqual(job.Payload, want) {
+		t.Errorf("job payload = %v, want %v", job.Payload, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := n.Enqueue(context.Background(), "fax", "+15559876543", Payload{}); err == nil {
+		t.Error("Enqueue() should fail for an unknown channel")
+	}
+	if _, err := n.Enqueue(context.Background(), "sms", "", Payload{}); err == nil {
+		t.Error("Enqueue() should fail without a target")
+	}
+}
+
+func TestSendContext(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	SetNotifier(New(db, "", 0, map[string]Channel{"sms": &recordingChannel{}}, nil))
+	defer SetNotifier(nil)
+
+	// The job records the request that sent it, even once the request is over
+	mock.ExpectExec(`INSERT INTO jobs`).
+		WithArgs(sqlmock.AnyArg(), DefaultQueue, JobType, sqlmock.AnyArg(), jobs.JobStatusPending,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "req-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	ctx, cancel := context.WithCancel(correlation.WithID(context.Background(), "req-1"))
+	cancel()
+	SendContext(ctx, "sms", "+15559876543", map[string]interface{}{"body": "Hi"})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNotifierDeliver(t *testing.T) {
+	sms := &recordingChannel{}
+	n := New(nil, "", 0, map[string]Channel{"sms": sms}, nil)
+
+	// Payloads arrive as decoded job JSON
+	var job map[string]interface{}
+	json.Unmarshal([]byte(`{"channel": "sms", "target": "+15559876543", "payload": {"body": "Hi"}}`), &job)
+	if err := n.deliver(context.Background(), job); err != nil {
+		t.Fatalf("deliver() error = %v", err)
+	}
+	if want := []string{"+15559876543: Hi"}; !reflect.DeepEqual(sms.sent, want) {
+		t.Errorf("sent = %v, want %v", sms.sent, want)
+	}
+
+	// Provider errors fail the job so the queue retries it
+	sms.err = errors.New("provider down")
+	if err := n.deliver(context.Background(), job); err == nil || err.Error() != "provider down" {
+		t.Errorf("deliver() error = %v, want provider error", err)
+	}
+
+	job["channel"] = "fax"
+	if err := n.deliver(context.Background(), job); err == nil {
+		t.Error("deliver() should fail for an unknown channel")
+	}
+}
+
+func TestNotifierRateLimit(t *testing.T) {
+	sms := &recordingChannel{}
+	n := New(nil, "", 0, map[string]Channel{"sms": sms}, map[string]int{"sms": 2})
+	defer n.Stop()
+	job := map[string]interface{}{"channel": "sms", "target": "+15559876543", "payload": map[string]interface{}{"body": "Hi"}}
+
+	for i := 0; i < 2; i++ {
+		if err := n.deliver(context.Background(), job); err != nil {
+			t.Fatalf("deliver() error = %v", err)
+		}
+	}
+
+	// The third message in the minute waits for a token
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := n.deliver(ctx, job); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("deliver() error = %v, want it to wait for the rate limit", err)
+	}
+	if sms.calls != 2 {
+		t.Errorf("calls = %d, want 2", sms.calls)
+	}
+}
+
+func TestSendWithoutNotifier(t *testing.T) {
+	SetNotifier(nil)
+	// Logs and returns without a configured Notifier
+	Send("sms", "+15559876543", map[string]interface{}{"body": "Hi"})
+}
+
+func TestStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	twilio := &Twilio{AccountSID: "AC123", AuthToken: "wrong", From: "+15550001234", URL: srv.URL}
+	err := twilio.Send(context.Background(), "+15559876543", Payload{"body": "Hi"})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Send() error = %v, want 401 status error", err)
+	}
+}
@@ -0,0 +1,335 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// FCM sends push notifications through the Firebase Cloud Messaging HTTP v1
+// API, authenticating as a Google service account.
+type FCM struct {
+	ProjectID   string
+	ClientEmail string
+	PrivateKey  *rsa.PrivateKey
+	TokenURL    string       // OAuth2 token endpoint of the service account
+	URL         string       // https://fcm.googleapis.com when empty
+	Client      *http.Client // http.DefaultClient when nil
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+	now     func() time.Time // time.Now, replaced in tests
+}
+
+// serviceAccount is the part of a Google service account key file FCM uses
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// NewFCMFromEnv returns an FCM channel authenticated with the service account
+// file named by GOOGLE_APPLICATION_CREDENTIALS. projectID defaults to the
+// service account's project.
+func NewFCMFromEnv(projectID string) (*FCM, error) {
+	path := os.Getenv(FCMCredentialsEnvVar)
+	if path == "" {
+		return nil, fmt.Errorf("%s is required for the fcm provider", FCMCredentialsEnvVar)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fcm credentials: %w", err)
+	}
+	return NewFCM(data, projectID)
+}
+
+// NewFCM returns an FCM channel authenticated with a service account key file.
+func NewFCM(credentials []byte, projectID string) (*FCM, error) {
+	var account serviceAccount
+	if err := json.Unmarshal(credentials, &account); err != nil {
+		return nil, fmt.Errorf("invalid fcm credentials: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, errors.New("invalid fcm credentials: client_email and private_key are required")
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid fcm credentials: %w", err)
+	}
+	if projectID == "" {
+		projectID = account.ProjectID
+	}
+	if projectID == "" {
+		return nil, errors.New("project_id is required for the fcm provider")
+	}
+	tokenURL := account.TokenURI
+	if tokenURL == "" {
+		tokenURL = "https://oauth2.googleapis.com/token"
+	}
+	return &FCM{ProjectID: projectID, ClientEmail: account.ClientEmail, PrivateKey: key, TokenURL: tokenURL}, nil
+}
+
+// Send pushes the payload to the registration token target.
+func (f *FCM) Send(ctx context.Context, target string, payload Payload) error {
+	message := map[string]interface{}{"token": target}
+	if title, body := payload.String("title"), payload.String("body"); title != "" || body != "" {
+		message["notification"] = map[string]string{"title": title, "body": body}
+	}
+	if data := payload.data(); len(data) > 0 {
+		message["data"] = data
+	}
+	body, err := json.Marshal(map[string]interface{}{"message": message})
+	if err != nil {
+		return err
+	}
+
+	token, err := f.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	base := f.URL
+	if base == "" {
+		base = "https://fcm.googleapis.com"
+	}
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/messages:send", base, url.PathEscape(f.ProjectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	return do(f.Client, req, nil)
+}
+
+// accessToken returns an OAuth2 access token for the service account,
+// exchanging a signed JWT for a new one shortly before the last expires
+func (f *FCM) accessToken(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now
+	if f.now != nil {
+		now = f.now
+	}
+	if f.token != "" && now().Add(time.Minute).Before(f.expires) {
+		return f.token, nil
+	}
+
+	issued := now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   f.ClientEmail,
+		"scope": "https://www.googleapis.com/auth/firebase.messaging",
+		"aud":   f.TokenURL,
+		"iat":   issued.Unix(),
+		"exp":   issued.Add(time.Hour).Unix(),
+	}).SignedString(f.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign fcm token request: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := do(f.Client, req, &resp); err != nil {
+		return "", fmt.Errorf("failed to get fcm access token: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return "", errors.New("failed to get fcm access token: empty response")
+	}
+
+	f.token = resp.AccessToken
+	f.expires = issued.Add(time.Duration(resp.ExpiresIn) * time.Second)
+	return f.token, nil
+}
+
+// APNs sends push notifications through the Apple Push Notification service,
+// authenticating with a token signed by a .p8 key.
+type APNs struct {
+	Topic  string // App bundle ID
+	TeamID string
+	KeyID  string
+	Key    *ecdsa.PrivateKey
+	URL    string       // Production or sandbox endpoint
+	Client *http.Client // http.DefaultClient when nil; APNs requires HTTP/2, which it negotiates over TLS
+
+	mu     sync.Mutex
+	token  string
+	issued time.Time
+	now    func() time.Time // time.Now, replaced in tests
+}
+
+// APNs endpoints
+const (
+	APNsProductionURL = "https://api.push.apple.com"
+	APNsSandboxURL    = "https://api.sandbox.push.apple.com"
+)
+
+// apnsTokenLifetime is how long a signed provider token is reused; Apple
+// rejects tokens refreshed more than every 20 minutes or older than an hour
+const apnsTokenLifetime = 30 * time.Minute
+
+// NewAPNsFromEnv returns an APNs channel for the app topic, signing with the
+// key in CONDUIT_APNS_KEY.
+func NewAPNsFromEnv(topic, teamID, keyID string, sandbox bool) (*APNs, error) {
+	if topic == "" || teamID == "" || keyID == "" {
+		return nil, errors.New("topic, team_id and key_id are required for the apns provider")
+	}
+	pem := os.Getenv(APNsKeyEnvVar)
+	if pem == "" {
+		return nil, fmt.Errorf("%s is required for the apns provider", APNsKeyEnvVar)
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM([]byte(pem))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", APNsKeyEnvVar, err)
+	}
+
+	endpoint := APNsProductionURL
+	if sandbox {
+		endpoint = APNsSandboxURL
+	}
+	return &APNs{Topic: topic, TeamID: teamID, KeyID: keyID, Key: key, URL: endpoint}, nil
+}
+
+// Send pushes the payload to the device token target. A payload without a
+// title or body is sent as a background notification.
+func (a *APNs) Send(ctx context.Context, target string, payload Payload) error {
+	aps := map[string]interface{}{}
+	pushType, priority := "alert", "10"
+	if title, body := payload.String("title"), payload.String("body"); title != "" || body != "" {
+		aps["alert"] = map[string]string{"title": title, "body": body}
+	} else {
+		aps["content-available"] = 1
+		pushType, priority = "background", "5"
+	}
+	message := map[string]interface{}{"aps": aps}
+	for key, value := range payload.data() {
+		message[key] = value
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	token, err := a.bearer()
+	if err != nil {
+		return err
+	}
+
+	base := a.URL
+	if base == "" {
+		base = APNsProductionURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/3/device/"+url.PathEscape(target), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("apns-topic", a.Topic)
+	req.Header.Set("apns-push-type", pushType)
+	req.Header.Set("apns-priority", priority)
+	return do(a.Client, req, nil)
+}
+
+// bearer returns the provider token, signing a new one when it is stale
+func (a *APNs) bearer() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now
+	if a.now != nil {
+		now = a.now
+	}
+	if a.token != "" && now().Sub(a.issued) < apnsTokenLifetime {
+		return a.token, nil
+	}
+
+	issued := now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": a.TeamID,
+		"iat": issued.Unix(),
+	})
+	token.Header["kid"] = a.KeyID
+	signed, err := token.SignedString(a.Key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign apns token: %w", err)
+	}
+
+	a.token, a.issued = signed, issued
+	return a.token, nil
+}
+
+// data returns the payload's custom keys as strings, the form push providers
+// deliver to the app
+func (p Payload) data() map[string]string {
+	data := make(map[string]string)
+	for key := range p {
+		if key != "title" && key != "body" {
+			data[key] = p.String(key)
+		}
+	}
+	return data
+}
+
+// StatusError is returned when a provider API responds with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("notification provider returned %s", e.Status)
+	}
+	return fmt.Sprintf("notification provider returned %s: %s", e.Status, e.Body)
+}
+
+// do sends req, fails on a non-2xx response and decodes a JSON response body
+// into out when it is non-nil
+func do(client *http.Client, req *http.Request, out interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(bytes.TrimSpace(detail))}
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
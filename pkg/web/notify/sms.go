@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Twilio sends SMS through the Twilio Messages API.
+type Twilio struct {
+	AccountSID string
+	AuthToken  string
+	From       string       // Sender number, or a messaging service SID (MG...)
+	URL        string       // https://api.twilio.com when empty
+	Client     *http.Client // http.DefaultClient when nil
+}
+
+// NewTwilioFromEnv returns a Twilio channel sending from from, with
+// credentials read from the environment.
+func NewTwilioFromEnv(from string) (*Twilio, error) {
+	if from == "" {
+		return nil, errors.New("from is required for the twilio provider")
+	}
+	sid, token := os.Getenv(TwilioAccountSIDEnvVar), os.Getenv(TwilioAuthTokenEnvVar)
+	if sid == "" || token == "" {
+		return nil, fmt.Errorf("%s and %s are required for the twilio provider", TwilioAccountSIDEnvVar, TwilioAuthTokenEnvVar)
+	}
+	return &Twilio{AccountSID: sid, AuthToken: token, From: from}, nil
+}
+
+// Send texts the payload's body to the phone number target.
+func (t *Twilio) Send(ctx context.Context, target string, payload Payload) error {
+	body := payload.String("body")
+	if body == "" {
+		return errors.New("sms notification has no body")
+	}
+
+	form := url.Values{"To": {target}, "Body": {body}}
+	if strings.HasPrefix(t.From, "MG") {
+		form.Set("MessagingServiceSid", t.From)
+	} else {
+		form.Set("From", t.From)
+	}
+
+	base := t.URL
+	if base == "" {
+		base = "https://api.twilio.com"
+	}
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", base, url.PathEscape(t.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+	return do(t.Client, req, nil)
+}
+
+// String returns the payload value for key as a string, or "" when unset.
+func (p Payload) String(key string) string {
+	value, ok := p[key]
+	if !ok || value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
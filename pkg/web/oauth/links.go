@@ -0,0 +1,54 @@
+package oauth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CreateTable creates the oauth_identities table, which links provider
+// identities to the user records they sign in as.
+func CreateTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS oauth_identities (
+		provider VARCHAR(50) NOT NULL,
+		subject VARCHAR(255) NOT NULL,
+		user_id VARCHAR(255) NOT NULL,
+		email VARCHAR(255),
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (provider, subject)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create oauth_identities table: %w", err)
+	}
+	return nil
+}
+
+// FindLink returns the ID of the user identity is linked to, and whether it is linked.
+func FindLink(ctx context.Context, db *sql.DB, identity *Identity) (string, bool, error) {
+	var userID string
+	err := db.QueryRowContext(ctx,
+		"SELECT user_id FROM oauth_identities WHERE provider = $1 AND subject = $2",
+		identity.Provider, identity.Subject).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to find linked identity: %w", err)
+	}
+	return userID, true, nil
+}
+
+// SaveLink links identity to the user userID. An identity that is already
+// linked keeps its user.
+func SaveLink(ctx context.Context, db *sql.DB, identity *Identity, userID string) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO oauth_identities (provider, subject, user_id, email)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, subject) DO NOTHING`,
+		identity.Provider, identity.Subject, userID, identity.Email)
+	if err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+// Package oauth serves the social login routes generated for the providers
+// listed under auth.providers in conduit.yaml:
+//
+//	auth:
+//	  resource: User
+//	  providers: [google, github]
+//	  redirect: /
+//
+// GET /auth/<provider> redirects the browser to the provider with a random
+// state and a PKCE challenge, both kept in a signed cookie. The provider
+// redirects back to GET /auth/<provider>/callback, where the state is checked,
+// the code is exchanged with the PKCE verifier and the user's identity is
+// fetched. The generated LinkFunc signs the identity in as a record of the
+// auth resource, and the response carries a token for that record: a cookie
+// and a redirect when auth.redirect is set, or JSON otherwise.
+//
+// Client credentials come from the environment of the running application
+// (GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET, GITHUB_CLIENT_ID and
+// GITHUB_CLIENT_SECRET), and CONDUIT_AUTH_SECRET signs the state cookies and
+// the issued tokens.
+package oauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/web/auth"
+)
+
+const (
+	// SecretEnvVar signs login state cookies and the tokens issued at login
+	SecretEnvVar = "CONDUIT_AUTH_SECRET"
+
+	// TokenCookie holds the token issued at login when a redirect is configured
+	TokenCookie = "auth_token"
+
+	// TokenTTL is how long a token issued at login is valid
+	TokenTTL = 24 * time.Hour
+
+	// StateTTL is how long a user has to finish signing in with the provider
+	StateTTL = 10 * time.Minute
+)
+
+// ErrUnverifiedEmail is returned by a LinkFunc when an identity has no
+// verified email to match or create a user record with.
+var ErrUnverifiedEmail = errors.New("the provider did not return a verified email")
+
+// Identity is a user as reported by a provider after sign-in.
+type Identity struct {
+	Provider      string
+	Subject       string // Provider's stable user ID
+	Email         string
+	EmailVerified bool
+	Name          string // Display name; the login or email when the user has none
+}
+
+// LinkFunc returns the ID of the user record an identity signs in as,
+// linking or creating one on first sign-in.
+type LinkFunc func(ctx context.Context, identity *Identity) (string, error)
+
+// Config selects the providers and where the browser is sent after login.
+type Config struct {
+	Providers []string // google, github
+	BaseURL   string   // Scheme and host callback URLs are built on; from the request when empty
+	Redirect  string   // Where the browser goes after login; the token is returned as JSON when empty
+}
+
+// Login serves the authorize and callback routes of the configured providers.
+type Login struct {
+	providers map[string]*Provider
+	baseURL   string
+	redirect  string
+	secret    []byte
+	tokens    *auth.AuthService
+	client    *http.Client
+	now       func() time.Time
+}
+
+// New returns a Login for providers, signing state cookies and tokens with secret.
+func New(cfg Config, providers []*Provider, secret string) (*Login, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("%s is required for social login", SecretEnvVar)
+	}
+	l := &Login{
+		providers: make(map[string]*Provider, len(providers)),
+		baseURL:   strings.TrimSuffix(cfg.BaseURL, "/"),
+		redirect:  cfg.Redirect,
+		secret:    []byte(secret),
+		tokens:    auth.NewAuthService(secret, TokenTTL),
+		now:       time.Now,
+	}
+	for _, p := range providers {
+		l.providers[p.Name] = p
+	}
+	return l, nil
+}
+
+// Configure returns a Login for the configured providers with credentials
+// read from the environment, and creates the table identities are linked in.
+func Configure(ctx context.Context, db *sql.DB, cfg Config) (*Login, error) {
+	providers := make([]*Provider, 0, len(cfg.Providers))
+	for _, name := range cfg.Providers {
+		p, err := ProviderFromEnv(name)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+
+	login, err := New(cfg, providers, os.Getenv(SecretEnvVar))
+	if err != nil {
+		return nil, err
+	}
+	if err := CreateTable(ctx, db); err != nil {
+		return nil, err
+	}
+	return login, nil
+}
+
+// AuthorizePath returns the route that starts sign-in with provider
+func AuthorizePath(provider string) string {
+	return "/auth/" + provider
+}
+
+// CallbackPath returns the route provider redirects back to
+func CallbackPath(provider string) string {
+	return "/auth/" + provider + "/callback"
+}
+
+// Authorize returns the handler that redirects the browser to provider.
+func (l *Login) Authorize(provider string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := l.providers[provider]
+		if !ok {
+			respondWithError(w, "Unknown login provider", http.StatusNotFound)
+			return
+		}
+
+		state, verifier := randomToken(), randomToken()
+		challenge := sha256.Sum256([]byte(verifier))
+		http.SetCookie(w, &http.Cookie{
+			Name:     stateCookie(provider),
+			Value:    l.sign(provider, state, verifier, l.now().Add(StateTTL)),
+			Path:     CallbackPath(provider),
+			MaxAge:   int(StateTTL.Seconds()),
+			HttpOnly: true,
+			Secure:   l.secure(r),
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		query := url.Values{
+			"client_id":             {p.ClientID},
+			"redirect_uri":          {l.callbackURL(r, provider)},
+			"response_type":         {"code"},
+			"scope":                 {strings.Join(p.Scopes, " ")},
+			"state":                 {state},
+			"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+			"code_challenge_method": {"S256"},
+		}
+		http.Redirect(w, r, p.AuthURL+"?"+query.Encode(), http.StatusFound)
+	}
+}
+
+// Callback returns the handler provider redirects back to. It verifies the
+// state, exchanges the code, links the identity with link and issues a token.
+func (l *Login) Callback(provider string, link LinkFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := l.providers[provider]
+		if !ok {
+			respondWithError(w, "Unknown login provider", http.StatusNotFound)
+			return
+		}
+
+		// The state cookie is single use
+		cookie, err := r.Cookie(stateCookie(provider))
+		http.SetCookie(w, &http.Cookie{Name: stateCookie(provider), Path: CallbackPath(provider), MaxAge: -1})
+		if err != nil {
+			respondWithError(w, "Login session expired; sign in again", http.StatusBadRequest)
+			return
+		}
+		if reason := r.URL.Query().Get("error"); reason != "" {
+			respondWithError(w, "Login was not completed: "+reason, http.StatusUnauthorized)
+			return
+		}
+		verifier, ok := l.verify(cookie.Value, provider, r.URL.Query().Get("state"))
+		if !ok {
+			respondWithError(w, "Invalid login state", http.StatusBadRequest)
+			return
+		}
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			respondWithError(w, "Missing authorization code", http.StatusBadRequest)
+			return
+		}
+
+		accessToken, err := p.exchange(r.Context(), l.client, code, l.callbackURL(r, provider), verifier)
+		if err != nil {
+			respondWithError(w, "Failed to complete login", http.StatusBadGateway)
+			return
+		}
+		identity, err := p.identity(r.Context(), l.client, accessToken)
+		if err != nil {
+			respondWithError(w, "Failed to read the user from the provider", http.StatusBadGateway)
+			return
+		}
+
+		userID, err := link(r.Context(), identity)
+		if errors.Is(err, ErrUnverifiedEmail) {
+			respondWithError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			respondWithError(w, "Failed to sign in", http.StatusInternalServerError)
+			return
+		}
+
+		token, err := l.tokens.GenerateToken(userID, identity.Email, nil)
+		if err != nil {
+			respondWithError(w, "Failed to issue token", http.StatusInternalServerError)
+			return
+		}
+
+		if l.redirect == "" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"token": token, "user_id": userID})
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     TokenCookie,
+			Value:    token,
+			Path:     "/",
+			MaxAge:   int(TokenTTL.Seconds()),
+			HttpOnly: true,
+			Secure:   l.secure(r),
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, l.redirect, http.StatusFound)
+	}
+}
+
+// callbackURL returns the redirect URI registered with the provider
+func (l *Login) callbackURL(r *http.Request, provider string) string {
+	base := l.baseURL
+	if base == "" {
+		scheme := "http"
+		if l.secure(r) {
+			scheme = "https"
+		}
+		base = scheme + "://" + r.Host
+	}
+	return base + CallbackPath(provider)
+}
+
+// secure reports whether cookies must be limited to HTTPS
+func (l *Login) secure(r *http.Request) bool {
+	if l.baseURL != "" {
+		return strings.HasPrefix(l.baseURL, "https://")
+	}
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+// stateCookie is the name of provider's login state cookie
+func stateCookie(provider string) string {
+	return "conduit_oauth_" + provider
+}
+
+// sign returns a state cookie value binding the state and PKCE verifier to
+// provider until expires
+func (l *Login) sign(provider, state, verifier string, expires time.Time) string {
+	payload := strings.Join([]string{provider, state, verifier, strconv.FormatInt(expires.Unix(), 10)}, "|")
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks a state cookie value against the provider and the state the
+// provider returned, and returns the PKCE verifier
+func (l *Login) verify(value, provider, state string) (string, bool) {
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return "", false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+	got, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return "", false
+	}
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return "", false
+	}
+
+	parts := strings.Split(string(payload), "|")
+	if len(parts) != 4 || parts[0] != provider {
+		return "", false
+	}
+	if !hmac.Equal([]byte(parts[1]), []byte(state)) {
+		return "", false
+	}
+	expires, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil || l.now().Unix() > expires {
+		return "", false
+	}
+	return parts[2], true
+}
+
+// randomToken returns 32 random bytes, base64url encoded; long enough for
+// both the state and a PKCE verifier (43 characters)
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("oauth: failed to read random bytes: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// respondWithError writes a JSON error response
+func respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,316 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProvider serves the token, userinfo and emails endpoints of a provider
+// and records the token exchange form
+type fakeProvider struct {
+	server   *httptest.Server
+	exchange url.Values
+	emails   string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	f := &fakeProvider{emails: `[{"email":"old@example.com","primary":false,"verified":true},{"email":"ada@example.com","primary":true,"verified":true}]`}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		f.exchange = r.PostForm
+		if r.PostForm.Get("code") != "good-code" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "access-123"})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"sub":"g-1","email":"ada@example.com","email_verified":true,"name":"Ada"}`))
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":42,"login":"ada","name":""}`))
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(f.emails))
+	})
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeProvider) google() *Provider {
+	p := Google("client-id", "client-secret")
+	p.AuthURL = f.server.URL + "/authorize"
+	p.TokenURL = f.server.URL + "/token"
+	p.UserInfoURL = f.server.URL + "/userinfo"
+	return p
+}
+
+func (f *fakeProvider) github() *Provider {
+	p := GitHub("client-id", "client-secret")
+	p.AuthURL = f.server.URL + "/authorize"
+	p.TokenURL = f.server.URL + "/token"
+	p.UserInfoURL = f.server.URL + "/user"
+	p.EmailsURL = f.server.URL + "/user/emails"
+	return p
+}
+
+// authorize starts a login and returns the state cookie and authorize URL
+func authorize(t *testing.T, login *Login, provider string) (*http.Cookie, *url.URL) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	login.Authorize(provider)(rec, httptest.NewRequest("GET", "http://app.test/auth/"+provider, nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("Authorize status = %d, want 302", rec.Code)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Authorize set %d cookies, want 1", len(cookies))
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("Authorize Location: %v", err)
+	}
+	return cookies[0], location
+}
+
+// callback completes a login with code and state
+func callback(login *Login, provider string, cookie *http.Cookie, code, state string, link LinkFunc) *httptest.ResponseRecorder {
+	query := url.Values{"code": {code}, "state": {state}}
+	req := httptest.NewRequest("GET", "http://app.test/auth/"+provider+"/callback?"+query.Encode(), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	login.Callback(provider, link)(rec, req)
+	return rec
+}
+
+func linkAs(userID string, got **Identity) LinkFunc {
+	return func(ctx context.Context, identity *Identity) (string, error) {
+		*got = identity
+		return userID, nil
+	}
+}
+
+func TestNewRequiresSecret(t *testing.T) {
+	if _, err := New(Config{}, nil, ""); err == nil || !strings.Contains(err.Error(), SecretEnvVar) {
+		t.Errorf("New() error = %v, want missing %s", err, SecretEnvVar)
+	}
+}
+
+func TestProviderFromEnv(t *testing.T) {
+	t.Setenv(GitHubClientIDEnvVar, "id")
+	t.Setenv(GitHubClientSecretEnvVar, "")
+	if _, err := ProviderFromEnv("github"); err == nil || !strings.Contains(err.Error(), GitHubClientSecretEnvVar) {
+		t.Errorf("ProviderFromEnv() error = %v, want missing %s", err, GitHubClientSecretEnvVar)
+	}
+
+	t.Setenv(GitHubClientSecretEnvVar, "secret")
+	p, err := ProviderFromEnv("github")
+	if err != nil {
+		t.Fatalf("ProviderFromEnv() error = %v", err)
+	}
+	if p.ClientID != "id" || p.ClientSecret != "secret" || p.OIDC {
+		t.Errorf("ProviderFromEnv() = %+v", p)
+	}
+
+	if _, err := ProviderFromEnv("myspace"); err == nil {
+		t.Error("ProviderFromEnv() should reject unknown providers")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	f := newFakeProvider(t)
+	login, err := New(Config{}, []*Provider{f.google()}, "secret")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	cookie, location := authorize(t, login, "google")
+	query := location.Query()
+
+	if got := location.Scheme + "://" + location.Host + location.Path; got != f.server.URL+"/authorize" {
+		t.Errorf("Authorize redirected to %s", got)
+	}
+	for key, want := range map[string]string{
+		"client_id":             "client-id",
+		"redirect_uri":          "http://app.test/auth/google/callback",
+		"response_type":         "code",
+		"scope":                 "openid email profile",
+		"code_challenge_method": "S256",
+	} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if cookie.Name != "conduit_oauth_google" || !cookie.HttpOnly || cookie.Path != "/auth/google/callback" {
+		t.Errorf("state cookie = %+v", cookie)
+	}
+
+	// The challenge is the S256 hash of the verifier kept in the cookie
+	verifier, ok := login.verify(cookie.Value, "google", query.Get("state"))
+	if !ok {
+		t.Fatal("state cookie does not verify against the returned state")
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	if got := query.Get("code_challenge"); got != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		t.Errorf("code_challenge = %q does not match the verifier", got)
+	}
+
+	rec := httptest.NewRecorder()
+	login.Authorize("github")(rec, httptest.NewRequest("GET", "/auth/github", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unconfigured provider status = %d, want 404", rec.Code)
+	}
+}
+
+func TestCallback_GoogleJSON(t *testing.T) {
+	f := newFakeProvider(t)
+	login, _ := New(Config{}, []*Provider{f.google()}, "secret")
+	cookie, location := authorize(t, login, "google")
+	verifier, _ := login.verify(cookie.Value, "google", location.Query().Get("state"))
+
+	var identity *Identity
+	rec := callback(login, "google", cookie, "good-code", location.Query().Get("state"), linkAs("user-1", &identity))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Callback status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if got := f.exchange.Get("code_verifier"); got != verifier {
+		t.Errorf("exchange code_verifier = %q, want %q", got, verifier)
+	}
+	if got := f.exchange.Get("redirect_uri"); got != "http://app.test/auth/google/callback" {
+		t.Errorf("exchange redirect_uri = %q", got)
+	}
+	want := Identity{Provider: "google", Subject: "g-1", Email: "ada@example.com", EmailVerified: true, Name: "Ada"}
+	if identity == nil || *identity != want {
+		t.Errorf("linked identity = %+v, want %+v", identity, want)
+	}
+
+	var body map[string]string
+	json.NewDecoder(rec.Body).Decode(&body)
+	if body["user_id"] != "user-1" {
+		t.Errorf("user_id = %q, want user-1", body["user_id"])
+	}
+	claims, err := login.tokens.ValidateToken(body["token"])
+	if err != nil {
+		t.Fatalf("issued token does not validate: %v", err)
+	}
+	if claims["user_id"] != "user-1" {
+		t.Errorf("token claims = %v", claims)
+	}
+}
+
+func TestCallback_GitHubRedirect(t *testing.T) {
+	f := newFakeProvider(t)
+	login, _ := New(Config{Redirect: "/dashboard", BaseURL: "https://example.com/"}, []*Provider{f.github()}, "secret")
+	cookie, location := authorize(t, login, "github")
+	if got := location.Query().Get("redirect_uri"); got != "https://example.com/auth/github/callback" {
+		t.Errorf("redirect_uri = %q, want the base URL's callback", got)
+	}
+	if !cookie.Secure {
+		t.Error("state cookie should be Secure behind an https base URL")
+	}
+
+	var identity *Identity
+	rec := callback(login, "github", cookie, "good-code", location.Query().Get("state"), linkAs("user-2", &identity))
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/dashboard" {
+		t.Fatalf("Callback = %d to %q, want 302 to /dashboard", rec.Code, rec.Header().Get("Location"))
+	}
+
+	// The verified primary address is used and users without a name go by their login
+	want := Identity{Provider: "github", Subject: "42", Email: "ada@example.com", EmailVerified: true, Name: "ada"}
+	if identity == nil || *identity != want {
+		t.Errorf("linked identity = %+v, want %+v", identity, want)
+	}
+
+	var token *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == TokenCookie {
+			token = c
+		}
+	}
+	if token == nil || !token.HttpOnly || token.Value == "" {
+		t.Fatalf("token cookie = %+v", token)
+	}
+}
+
+func TestCallback_Rejects(t *testing.T) {
+	f := newFakeProvider(t)
+	login, _ := New(Config{}, []*Provider{f.google(), f.github()}, "secret")
+	cookie, location := authorize(t, login, "google")
+	state := location.Query().Get("state")
+	linked := false
+	link := func(ctx context.Context, identity *Identity) (string, error) {
+		linked = true
+		return "user-1", nil
+	}
+
+	other, _ := New(Config{}, []*Provider{f.google()}, "other-secret")
+	forged := &http.Cookie{Name: cookie.Name, Value: other.sign("google", state, "verifier", time.Now().Add(time.Minute))}
+
+	tests := []struct {
+		name     string
+		provider string
+		cookie   *http.Cookie
+		code     string
+		state    string
+		want     int
+	}{
+		{"no cookie", "google", nil, "good-code", state, http.StatusBadRequest},
+		{"wrong state", "google", cookie, "good-code", "guess", http.StatusBadRequest},
+		{"forged cookie", "google", forged, "good-code", state, http.StatusBadRequest},
+		{"other provider", "github", &http.Cookie{Name: "conduit_oauth_github", Value: cookie.Value}, "good-code", state, http.StatusBadRequest},
+		{"missing code", "google", cookie, "", state, http.StatusBadRequest},
+		{"bad code", "google", cookie, "bad-code", state, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := callback(login, tt.provider, tt.cookie, tt.code, tt.state, link)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+	if linked {
+		t.Error("rejected callbacks should not link an identity")
+	}
+
+	// Expired state
+	login.now = func() time.Time { return time.Now().Add(StateTTL + time.Minute) }
+	if rec := callback(login, "google", cookie, "good-code", state, link); rec.Code != http.StatusBadRequest {
+		t.Errorf("expired state status = %d, want 400", rec.Code)
+	}
+}
+
+func TestCallback_UnverifiedEmail(t *testing.T) {
+	f := newFakeProvider(t)
+	f.emails = `[{"email":"ada@example.com","primary":true,"verified":false}]`
+	login, _ := New(Config{}, []*Provider{f.github()}, "secret")
+	cookie, location := authorize(t, login, "github")
+
+	link := func(ctx context.Context, identity *Identity) (string, error) {
+		if !identity.EmailVerified {
+			return "", ErrUnverifiedEmail
+		}
+		return "user-1", nil
+	}
+	rec := callback(login, "github", cookie, "good-code", location.Query().Get("state"), link)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", rec.Code)
+	}
+}
@@ -0,0 +1,133 @@
+// Package partition creates the range partitions of tables declared with
+// @partition. Migrations create a partitioned table with only a DEFAULT
+// partition; the application creates one partition per interval ahead of
+// time, so rows land in the partition for their timestamp and queries bounded
+// on the partition key scan only the partitions in range.
+//
+// Example:
+//
+//	partition.Maintain(ctx, db, []partition.Table{
+//		{Name: "events", Interval: partition.Month},
+//	}, time.Hour)
+//
+// A partition cannot be created while the DEFAULT partition holds rows in its
+// range. Maintenance creates partitions before they are needed, so this only
+// happens after a long outage; move those rows out of the default partition
+// to recover.
+package partition
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Ahead is the number of partitions created beyond the current one.
+const Ahead = 3
+
+// Interval is the time range covered by one partition.
+type Interval string
+
+// Intervals accepted by @partition
+const (
+	Day   Interval = "day"
+	Week  Interval = "week"
+	Month Interval = "month"
+	Year  Interval = "year"
+)
+
+// Start returns the beginning of the interval containing t, in UTC. Weeks
+// start on Monday.
+func (i Interval) Start(t time.Time) time.Time {
+	t = t.UTC()
+	switch i {
+	case Day:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case Week:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+	case Year:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Next returns the beginning of the interval after the one starting at start.
+func (i Interval) Next(start time.Time) time.Time {
+	switch i {
+	case Day:
+		return start.AddDate(0, 0, 1)
+	case Week:
+		return start.AddDate(0, 0, 7)
+	case Year:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// Table is a partitioned table and the interval of its partitions.
+type Table struct {
+	Name     string
+	Interval Interval
+}
+
+// Name returns the name of the partition of table starting at start, e.g.
+// events_p202610 for a monthly partition. Daily and weekly partitions are
+// named by their first day, yearly partitions by their year.
+func Name(table Table, start time.Time) string {
+	switch table.Interval {
+	case Day, Week:
+		return table.Name + "_p" + start.Format("20060102")
+	case Year:
+		return table.Name + "_p" + start.Format("2006")
+	default:
+		return table.Name + "_p" + start.Format("200601")
+	}
+}
+
+// Ensure creates the partition of table containing now and the Ahead
+// partitions after it, skipping those that already exist.
+func Ensure(ctx context.Context, db *sql.DB, table Table, now time.Time) error {
+	start := table.Interval.Start(now)
+	for i := 0; i <= Ahead; i++ {
+		end := table.Interval.Next(start)
+		stmt := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+			Name(table, start), table.Name, start.Format(time.RFC3339), end.Format(time.RFC3339))
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create partition %s: %w", Name(table, start), err)
+		}
+		start = end
+	}
+	return nil
+}
+
+// Maintain ensures the partitions of every table now and then every period
+// until ctx is done. Failures are logged rather than returned, so a database
+// that is migrated after the application starts is picked up on the next run.
+func Maintain(ctx context.Context, db *sql.DB, tables []Table, every time.Duration) {
+	ensureAll := func() {
+		for _, table := range tables {
+			if err := Ensure(ctx, db, table, time.Now()); err != nil {
+				log.Printf("partition maintenance for %s: %v", table.Name, err)
+			}
+		}
+	}
+
+	ensureAll()
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ensureAll()
+			}
+		}
+	}()
+}
@@ -0,0 +1,103 @@
+package partition
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestIntervalStart(t *testing.T) {
+	// Friday 16 October 2026, 23:30 in UTC-5 is Saturday 17 October in UTC
+	now := time.Date(2026, 10, 16, 23, 30, 0, 0, time.FixedZone("EST", -5*3600))
+
+	tests := []struct {
+		interval Interval
+		start    time.Time
+		next     time.Time
+	}{
+		{Day, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{Week, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{Month, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{Year, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.interval), func(t *testing.T) {
+			start := tt.interval.Start(now)
+			if !start.Equal(tt.start) {
+				t.Errorf("Start() = %v, want %v", start, tt.start)
+			}
+			if next := tt.interval.Next(start); !next.Equal(tt.next) {
+				t.Errorf("Next() = %v, want %v", next, tt.next)
+			}
+		})
+	}
+
+	// A Monday is the start of its own week
+	monday := time.Date(2026, 10, 12, 8, 0, 0, 0, time.UTC)
+	if start := Week.Start(monday); start.Day() != 12 {
+		t.Errorf("Week.Start(Monday) = %v, want the same day", start)
+	}
+}
+
+func TestName(t *testing.T) {
+	start := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	tests := map[Interval]string{
+		Day:   "events_p20261012",
+		Week:  "events_p20261012",
+		Month: "events_p202610",
+		Year:  "events_p2026",
+	}
+	for interval, want := range tests {
+		if got := Name(Table{Name: "events", Interval: interval}, start); got != want {
+			t.Errorf("Name(%s) = %q, want %q", interval, got, want)
+		}
+	}
+}
+
+func TestEnsure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	months := [][3]string{
+		{"events_p202610", "2026-10-01", "2026-11-01"},
+		{"events_p202611", "2026-11-01", "2026-12-01"},
+		{"events_p202612", "2026-12-01", "2027-01-01"},
+		{"events_p202701", "2027-01-01", "2027-02-01"},
+	}
+	for _, m := range months {
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS " + m[0] + " PARTITION OF events FOR VALUES FROM ('" + m[1] + "T00:00:00Z') TO ('" + m[2] + "T00:00:00Z')")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	if err := Ensure(context.Background(), db, Table{Name: "events", Interval: Month}, now); err != nil {
+		t.Fatalf("Ensure() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEnsure_Error(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS events_p2026").
+		WillReturnError(errors.New(`updated partition constraint for default partition "events_default" would be violated`))
+
+	err = Ensure(context.Background(), db, Table{Name: "events", Interval: Year}, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	if err == nil || !strings.Contains(err.Error(), "failed to create partition events_p2026") {
+		t.Errorf("Ensure() error = %v, want the failing partition named", err)
+	}
+}
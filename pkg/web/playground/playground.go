@@ -0,0 +1,132 @@
+// Package playground serves an interactive API explorer for a generated
+// application. The page renders the application's OpenAPI specification with
+// Swagger UI, and its Authorize dialog lets users enter a bearer token so
+// protected routes can be exercised from the browser.
+//
+// The playground is meant for development. Enabled reports false when the
+// process looks like a production deployment unless the build allowed it.
+//
+// Example:
+//
+//	if playground.Enabled(false) {
+//		r.Mount("/docs", playground.Handler(playground.Options{Path: "/docs", Spec: introspection.OpenAPI}))
+//	}
+package playground
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// EnvVar overrides the environment check: "false", "0" or "off" disables the
+// playground and "true", "1" or "on" enables it even in production.
+const EnvVar = "CONDUIT_PLAYGROUND"
+
+// environmentVars are checked for "production", matching the guards used by
+// auto-migrate.
+var environmentVars = []string{"CONDUIT_ENV", "ENV", "ENVIRONMENT", "APP_ENV", "GO_ENV"}
+
+// swaggerUIVersion pins the Swagger UI assets loaded by the playground page
+const swaggerUIVersion = "5.17.14"
+
+// Options configure the playground handler.
+type Options struct {
+	// Path is where the handler is mounted, e.g. "/docs"
+	Path string
+	// Spec is the OpenAPI specification JSON embedded in the application
+	Spec string
+	// Title is shown in the browser tab; defaults to "API Playground"
+	Title string
+}
+
+// Enabled reports whether the playground should be served. CONDUIT_PLAYGROUND
+// wins when set; otherwise the playground is disabled in production unless
+// allowProduction is true.
+func Enabled(allowProduction bool) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(EnvVar))) {
+	case "false", "0", "off":
+		return false
+	case "true", "1", "on":
+		return true
+	}
+	return allowProduction || !IsProduction()
+}
+
+// IsProduction reports whether any of the conventional environment variables
+// names a production environment.
+func IsProduction() bool {
+	for _, name := range environmentVars {
+		switch strings.ToLower(strings.TrimSpace(os.Getenv(name))) {
+		case "production", "prod", "prd":
+			return true
+		}
+	}
+	return false
+}
+
+// Handler returns the playground handler. It serves the explorer page at
+// opts.Path and the specification at opts.Path + "/openapi.json".
+func Handler(opts Options) http.Handler {
+	base := strings.TrimSuffix(opts.Path, "/")
+	title := opts.Title
+	if title == "" {
+		title = "API Playground"
+	}
+	page := []byte(renderPage(title))
+	spec := []byte(opts.Spec)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		switch strings.TrimPrefix(r.URL.Path, base) {
+		case "":
+			// The page loads the spec relative to the trailing slash
+			http.Redirect(w, r, base+"/", http.StatusMovedPermanently)
+		case "/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Write(page)
+		case "/openapi.json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Write(spec)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// renderPage returns the explorer page. Authorization entered in the page is
+// persisted in local storage so it survives reloads.
+func renderPage(title string) string {
+	assets := "https://unpkg.com/swagger-ui-dist@" + swaggerUIVersion
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>%s</title>
+  <link rel="stylesheet" href="%s/swagger-ui.css">
+</head>
+<body>
+  <div id="playground"></div>
+  <script src="%s/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({
+      url: 'openapi.json',
+      dom_id: '#playground',
+      deepLinking: true,
+      persistAuthorization: true,
+      tryItOutEnabled: true,
+    });
+  </script>
+</body>
+</html>
+`, html.EscapeString(title), assets, assets)
+}
@@ -0,0 +1,81 @@
+package playground
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func clearEnvironment(t *testing.T) {
+	t.Helper()
+	t.Setenv(EnvVar, "")
+	for _, name := range environmentVars {
+		t.Setenv(name, "")
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		name            string
+		env             map[string]string
+		allowProduction bool
+		want            bool
+	}{
+		{name: "development by default", want: true},
+		{name: "production", env: map[string]string{"CONDUIT_ENV": "production"}, want: false},
+		{name: "production abbreviated", env: map[string]string{"ENV": "PROD"}, want: false},
+		{name: "production allowed by build", env: map[string]string{"CONDUIT_ENV": "production"}, allowProduction: true, want: true},
+		{name: "forced on", env: map[string]string{"CONDUIT_ENV": "production", EnvVar: "on"}, want: true},
+		{name: "forced off", env: map[string]string{EnvVar: "off"}, allowProduction: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnvironment(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if got := Enabled(tt.allowProduction); got != tt.want {
+				t.Errorf("Enabled(%v) = %v, want %v", tt.allowProduction, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	spec := `{"openapi":"3.0.3"}`
+	handler := Handler(Options{Path: "/docs", Spec: spec, Title: "Blog <API>"})
+
+	tests := []struct {
+		method      string
+		path        string
+		status      int
+		contentType string
+		contains    string
+	}{
+		{http.MethodGet, "/docs", http.StatusMovedPermanently, "", ""},
+		{http.MethodGet, "/docs/", http.StatusOK, "text/html; charset=utf-8", "persistAuthorization: true"},
+		{http.MethodGet, "/docs/", http.StatusOK, "text/html; charset=utf-8", "<title>Blog &lt;API&gt;</title>"},
+		{http.MethodGet, "/docs/openapi.json", http.StatusOK, "application/json", spec},
+		{http.MethodGet, "/docs/missing", http.StatusNotFound, "", ""},
+		{http.MethodPost, "/docs/openapi.json", http.StatusMethodNotAllowed, "", ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, rec.Code, tt.status)
+			continue
+		}
+		if tt.contentType != "" && rec.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("%s %s: Content-Type = %q, want %q", tt.method, tt.path, rec.Header().Get("Content-Type"), tt.contentType)
+		}
+		if tt.contains != "" && !strings.Contains(rec.Body.String(), tt.contains) {
+			t.Errorf("%s %s: body missing %q", tt.method, tt.path, tt.contains)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package preflight verifies at startup that the database matches the schema a
+// generated application was built against, so a missing table, column or
+// migration fails fast with a clear error instead of surfacing as 500 responses
+// on the first query.
+//
+// Example:
+//
+//	if preflight.Enabled() {
+//		if err := preflight.Check(ctx, db, schema); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+package preflight
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvVar disables the preflight when set to "false", "0" or "off", for example
+// in ephemeral environments whose database is migrated after the app starts.
+const EnvVar = "CONDUIT_PREFLIGHT"
+
+// Table lists the columns a resource reads and writes.
+type Table struct {
+	Name    string
+	Columns []string
+}
+
+// Schema is the database shape an application expects.
+type Schema struct {
+	Tables []Table
+	// MigrationVersion is the latest migration known when the application was
+	// built. The database must have applied it; zero skips the version check.
+	MigrationVersion int64
+}
+
+// Error lists every mismatch found by Check.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("preflight failed: database does not match the application schema")
+	for _, problem := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(problem)
+	}
+	fmt.Fprintf(&b, "\nRun 'conduit migrate up', or set %s=off to skip this check", EnvVar)
+	return b.String()
+}
+
+// Enabled reports whether the preflight should run, based on CONDUIT_PREFLIGHT.
+// It is enabled unless explicitly turned off.
+func Enabled() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(EnvVar))) {
+	case "false", "0", "off", "no":
+		return false
+	default:
+		return true
+	}
+}
+
+// Check verifies that every table and column in schema exists in the current
+// PostgreSQL schema and that the expected migration has been applied. All
+// problems are reported together in an *Error; query failures are returned as-is.
+func Check(ctx context.Context, db *sql.DB, schema Schema) error {
+	existing, err := loadColumns(ctx, db)
+	if err != nil {
+		return fmt.Errorf("preflight failed: could not read database schema: %w", err)
+	}
+
+	var problems []string
+	for _, table := range schema.Tables {
+		columns, ok := existing[table.Name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("table %q does not exist", table.Name))
+			continue
+		}
+
+		var missing []string
+		for _, column := range table.Columns {
+			if !columns[column] {
+				missing = append(missing, column)
+			}
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("table %q is missing columns: %s", table.Name, strings.Join(missing, ", ")))
+		}
+	}
+
+	if schema.MigrationVersion > 0 {
+		problem, err := checkMigrationVersion(ctx, db, schema.MigrationVersion, existing)
+		if err != nil {
+			return fmt.Errorf("preflight failed: could not read migration version: %w", err)
+		}
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+	return nil
+}
+
+// loadColumns returns the columns of every table, keyed by the table's name in
+// the current schema and by its schema-qualified name, such as
+// billing.invoices, in any other
+func loadColumns(ctx context.Context, db *sql.DB) (map[string]map[string]bool, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT CASE WHEN table_schema = current_schema() THEN table_name ELSE table_schema || '.' || table_name END, column_name "+
+			"FROM information_schema.columns WHERE table_schema NOT IN ('pg_catalog', 'information_schema')")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make(map[string]map[string]bool)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+		if tables[table] == nil {
+			tables[table] = make(map[string]bool)
+		}
+		tables[table][column] = true
+	}
+
+	return tables, rows.Err()
+}
+
+// checkMigrationVersion describes the problem when the database has not applied
+// the expected migration. A database ahead of the application is accepted so
+// older instances keep running during a rolling deploy.
+func checkMigrationVersion(ctx context.Context, db *sql.DB, expected int64, existing map[string]map[string]bool) (string, error) {
+	if _, ok := existing["schema_migrations"]; !ok {
+		return fmt.Sprintf("no migrations have been applied (expected version %d)", expected), nil
+	}
+
+	var current int64
+	err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+	if err != nil {
+		return "", err
+	}
+
+	if current < expected {
+		return fmt.Sprintf("database is at migration version %d, expected %d", current, expected), nil
+	}
+	return "", nil
+}
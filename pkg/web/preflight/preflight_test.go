@@ -0,0 +1,219 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var columnsQuery = regexp.QuoteMeta("column_name FROM information_schema.columns")
+
+var testSchema = Schema{
+	Tables: []Table{
+		{Name: "posts", Columns: []string{"id", "title", "author_ref"}},
+		{Name: "comments", Columns: []string{"id", "body"}},
+	},
+	MigrationVersion: 20240102,
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		columns      [][2]string
+		version      int64 // -1 when the migration query is not expected
+		wantProblems []string
+	}{
+		{
+			name: "matching schema",
+			columns: [][2]string{
+				{"posts", "id"}, {"posts", "title"}, {"posts", "author_ref"}, {"posts", "extra"},
+				{"comments", "id"}, {"comments", "body"},
+				{"schema_migrations", "version"},
+			},
+			version: 20240102,
+		},
+		{
+			name: "database ahead of the application",
+			columns: [][2]string{
+				{"posts", "id"}, {"posts", "title"}, {"posts", "author_ref"},
+				{"comments", "id"}, {"comments", "body"},
+				{"schema_migrations", "version"},
+			},
+			version: 20240301,
+		},
+		{
+			name: "missing table and columns",
+			columns: [][2]string{
+				{"posts", "id"},
+				{"schema_migrations", "version"},
+			},
+			version: 20240102,
+			wantProblems: []string{
+				`table "posts" is missing columns: title, author_ref`,
+				`table "comments" does not exist`,
+			},
+		},
+		{
+			name: "pending migration",
+			columns: [][2]string{
+				{"posts", "id"}, {"posts", "title"}, {"posts", "author_ref"},
+				{"comments", "id"}, {"comments", "body"},
+				{"schema_migrations", "version"},
+			},
+			version:      20240101,
+			wantProblems: []string{"database is at migration version 20240101, expected 20240102"},
+		},
+		{
+			name:    "no migrations table",
+			columns: nil,
+			version: -1,
+			wantProblems: []string{
+				`table "posts" does not exist`,
+				`table "comments" does not exist`,
+				"no migrations have been applied (expected version 20240102)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to create sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			rows := sqlmock.NewRows([]string{"table_name", "column_name"})
+			for _, c := range tt.columns {
+				rows.AddRow(c[0], c[1])
+			}
+			mock.ExpectQuery(columnsQuery).WillReturnRows(rows)
+			if tt.version >= 0 {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(MAX(version), 0) FROM schema_migrations")).
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(tt.version))
+			}
+
+			err = Check(context.Background(), db, testSchema)
+
+			if len(tt.wantProblems) == 0 {
+				if err != nil {
+					t.Fatalf("Check() error = %v", err)
+				}
+			} else {
+				var pfErr *Error
+				if !errors.As(err, &pfErr) {
+					t.Fatalf("Check() error = %v, want *Error", err)
+				}
+				if strings.Join(pfErr.Problems, "\n") != strings.Join(tt.wantProblems, "\n") {
+					t.Errorf("Problems = %q, want %q", pfErr.Problems, tt.wantProblems)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheck_SkipsVersionWhenUnset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(columnsQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name"}).AddRow("posts", "id"))
+
+	schema := Schema{Tables: []Table{{Name: "posts", Columns: []string{"id"}}}}
+	if err := Check(context.Background(), db, schema); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCheck_SchemaQualifiedTables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(columnsQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name"}).
+			AddRow("billing.invoices", "id").AddRow("invoices", "id"))
+
+	schema := Schema{Tables: []Table{
+		{Name: "billing.invoices", Columns: []string{"id"}},
+		{Name: "billing.payments", Columns: []string{"id"}},
+	}}
+	err = Check(context.Background(), db, schema)
+	var pfErr *Error
+	if !errors.As(err, &pfErr) {
+		t.Fatalf("Check() error = %v, want *Error", err)
+	}
+	want := []string{`table "billing.payments" does not exist`}
+	if strings.Join(pfErr.Problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Problems = %q, want %q", pfErr.Problems, want)
+	}
+}
+
+func TestCheck_QueryError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(columnsQuery).WillReturnError(errors.New("connection reset"))
+
+	err = Check(context.Background(), db, testSchema)
+	if err == nil || !strings.Contains(err.Error(), "could not read database schema: connection reset") {
+		t.Errorf("Check() error = %v", err)
+	}
+	var pfErr *Error
+	if errors.As(err, &pfErr) {
+		t.Error("query failures should not be reported as schema problems")
+	}
+}
+
+func TestError_Message(t *testing.T) {
+	err := &Error{Problems: []string{`table "posts" does not exist`}}
+	want := "preflight failed: database does not match the application schema\n" +
+		"  - table \"posts\" does not exist\n" +
+		"Run 'conduit migrate up', or set CONDUIT_PREFLIGHT=off to skip this check"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"", true},
+		{"true", true},
+		{"on", true},
+		{"false", false},
+		{"OFF", false},
+		{"0", false},
+		{" no ", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(EnvVar, tt.value)
+			if got := Enabled(); got != tt.want {
+				t.Errorf("Enabled() with %s=%q = %v, want %v", EnvVar, tt.value, got, tt.want)
+			}
+		})
+	}
+}
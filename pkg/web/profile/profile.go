@@ -0,0 +1,144 @@
+// Package profile selects the fields of a resource a caller may see. A
+// resource declares named serialization profiles:
+//
+//	@profile(public: [id, title], admin: *)
+//
+// Every caller sees the public profile; callers whose roles name other
+// profiles also see those fields. Generated handlers pass the result to the
+// response package, which drops the other fields from JSON and JSON:API
+// responses alike.
+//
+// Roles are read from the request context when authentication middleware has
+// set them, and otherwise from the "roles" claim of a bearer token or social
+// login cookie signed with CONDUIT_AUTH_SECRET. Callers without a valid token
+// see the public profile only.
+//
+// Require restricts routes to callers with a role, for operations refined
+// with admin_only in @operations.
+package profile
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/conduit-lang/conduit/internal/web/auth"
+	webcontext "github.com/conduit-lang/conduit/internal/web/context"
+	"github.com/conduit-lang/conduit/pkg/web/oauth"
+)
+
+// Public is the profile every caller sees
+const Public = "public"
+
+// Set maps profile names to the fields they show. A nil field list shows
+// every field (* in @profile).
+type Set map[string][]string
+
+// Fields returns the fields r's caller may see; nil when every field is shown.
+func (s Set) Fields(r *http.Request) []string {
+	return s.Visible(Roles(r))
+}
+
+// Visible returns the fields shown to a caller with the given roles: the
+// public profile together with the profiles the roles name, public fields
+// first and without repeats. It returns nil when one of them shows every
+// field.
+func (s Set) Visible(roles []string) []string {
+	names := append([]string{Public}, roles...)
+
+	seen := make(map[string]bool)
+	fields := []string{}
+	for _, name := range names {
+		profile, ok := s[name]
+		if !ok {
+			continue
+		}
+		if profile == nil {
+			return nil
+		}
+		for _, field := range profile {
+			if !seen[field] {
+				seen[field] = true
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
+// Restrict returns the names in fields that visible shows, e.g. the filterable
+// fields a caller may filter by; all of them when visible is nil. Filtering or
+// sorting by a hidden field would otherwise reveal its values.
+func Restrict(fields, visible []string) []string {
+	if visible == nil {
+		return fields
+	}
+	shown := make(map[string]bool, len(visible))
+	for _, field := range visible {
+		shown[field] = true
+	}
+	restricted := []string{}
+	for _, field := range fields {
+		if shown[field] {
+			restricted = append(restricted, field)
+		}
+	}
+	return restricted
+}
+
+// Roles returns the roles of r's caller, or nil for anonymous callers and
+// tokens that do not verify.
+func Roles(r *http.Request) []string {
+	if roles := webcontext.GetUserRoles(r.Context()); roles != nil {
+		return roles
+	}
+
+	token := bearerToken(r)
+	secret := os.Getenv(oauth.SecretEnvVar)
+	if token == "" || secret == "" {
+		return nil
+	}
+	claims, err := auth.NewAuthService(secret, oauth.TokenTTL).ValidateToken(token)
+	if err != nil {
+		return nil
+	}
+
+	claimed, _ := claims["roles"].([]interface{})
+	roles := make([]string, 0, len(claimed))
+	for _, role := range claimed {
+		if name, ok := role.(string); ok {
+			roles = append(roles, name)
+		}
+	}
+	return roles
+}
+
+// Require returns middleware answering requests from callers without role
+// with 403 Forbidden. Generated routes use it for operations refined with
+// admin_only in @operations.
+func Require(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(Roles(r), role) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(map[string]string{"error": "this operation requires the " + role + " role"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bearerToken returns the token in the Authorization header or the social login cookie
+func bearerToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+	if cookie, err := r.Cookie(oauth.TokenCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package profile
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/web/auth"
+	webcontext "github.com/conduit-lang/conduit/internal/web/context"
+	"github.com/conduit-lang/conduit/pkg/web/oauth"
+)
+
+var posts = Set{
+	"public": {"id", "title"},
+	"editor": {"title", "body"},
+	"admin":  nil,
+}
+
+func TestVisible(t *testing.T) {
+	tests := []struct {
+		roles []string
+		want  []string
+	}{
+		{nil, []string{"id", "title"}},
+		{[]string{"viewer"}, []string{"id", "title"}},
+		{[]string{"editor"}, []string{"id", "title", "body"}},
+		{[]string{"editor", "admin"}, nil},
+	}
+
+	for _, tt := range tests {
+		if got := posts.Visible(tt.roles); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Visible(%v) = %v, want %v", tt.roles, got, tt.want)
+		}
+	}
+
+	if got := (Set{"admin": nil}).Visible(nil); got == nil || len(got) != 0 {
+		t.Errorf("Visible() without a public profile = %v, want no fields", got)
+	}
+}
+
+func TestRestrict(t *testing.T) {
+	filterable := []string{"title", "body", "views"}
+
+	if got := Restrict(filterable, []string{"id", "title"}); !reflect.DeepEqual(got, []string{"title"}) {
+		t.Errorf("Restrict() = %v, want [title]", got)
+	}
+	if got := Restrict(filterable, nil); !reflect.DeepEqual(got, filterable) {
+		t.Errorf("Restrict(nil) = %v, want every field", got)
+	}
+}
+
+func TestRoles(t *testing.T) {
+	t.Setenv(oauth.SecretEnvVar, "secret")
+	token, err := auth.NewAuthService("secret", time.Hour).GenerateToken("user-1", "ada@example.com", []string{"admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, _ := auth.NewAuthService("other", time.Hour).GenerateToken("user-2", "", []string{"admin"})
+
+	request := func(header, cookie string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		if header != "" {
+			req.Header.Set("Authorization", "Bearer "+header)
+		}
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: oauth.TokenCookie, Value: cookie})
+		}
+		return req
+	}
+
+	if roles := Roles(request(token, "")); !reflect.DeepEqual(roles, []string{"admin"}) {
+		t.Errorf("Roles(bearer) = %v", roles)
+	}
+	if roles := Roles(request("", token)); !reflect.DeepEqual(roles, []string{"admin"}) {
+		t.Errorf("Roles(cookie) = %v", roles)
+	}
+	if roles := Roles(request(forged, "")); roles != nil {
+		t.Errorf("Roles(forged) = %v, want nil", roles)
+	}
+	if roles := Roles(request("", "")); roles != nil {
+		t.Errorf("Roles(anonymous) = %v, want nil", roles)
+	}
+
+	req := request("", "")
+	req = req.WithContext(webcontext.SetUserRoles(req.Context(), []string{"editor"}))
+	if fields := posts.Fields(req); !reflect.DeepEqual(fields, []string{"id", "title", "body"}) {
+		t.Errorf("Fields(context roles) = %v", fields)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	handler := Require("admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	serve := func(roles []string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/posts", nil)
+		if roles != nil {
+			req = req.WithContext(webcontext.SetUserRoles(req.Context(), roles))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve([]string{"editor", "admin"}); rec.Code != http.StatusCreated {
+		t.Errorf("admin status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if rec := serve([]string{"editor"}); rec.Code != http.StatusForbidden {
+		t.Errorf("editor status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	rec := serve(nil)
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("anonymous status = %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
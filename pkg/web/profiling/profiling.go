@@ -0,0 +1,239 @@
+// Package profiling lets the performance of a generated application be
+// investigated without editing its main.go. It serves the Go profiler of
+// net/http/pprof on Path and sends continuous profiles to a profiling agent,
+// as conduit.yaml's profiling section configures:
+//
+//	profiling:
+//	  pprof: true
+//	  agent: pyroscope
+//	  server_address: http://pyroscope:4040
+//
+// The profiler is only served to callers on the same host, or to callers
+// presenting the bearer token in CONDUIT_PPROF_TOKEN:
+//
+//	go tool pprof localhost:8080/debug/pprof/heap
+//	curl -H "Authorization: Bearer $TOKEN" -o cpu.pprof app.example.com/debug/pprof/profile?seconds=20
+//
+// The built-in Pyroscope agent pushes CPU and heap profiles to a Pyroscope
+// server. Other profiling services plug in by implementing Agent.
+//
+// Example:
+//
+//	if err := profiling.Configure(profiling.Config{Agent: profiling.AgentPyroscope, Application: "blog"}); err != nil {
+//		log.Fatal(err)
+//	}
+//	defer profiling.Stop(profiling.StopTimeout)
+//
+//	if profiling.Enabled() {
+//		r.Mount(profiling.Path, profiling.Handler(os.Getenv(profiling.TokenEnvVar)))
+//	}
+package profiling
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Path is where the profiler is served. net/http/pprof names profiles by the
+// part of the path after it, so the handler must be mounted here.
+const Path = "/debug/pprof"
+
+// Environment variables read by the generated application
+const (
+	// EnvVar stops the profiler from being served when set to "false", "0"
+	// or "off"
+	EnvVar = "CONDUIT_PPROF"
+	// TokenEnvVar holds the bearer token that opens the profiler to callers
+	// on other hosts; without it only local callers are served
+	TokenEnvVar = "CONDUIT_PPROF_TOKEN"
+)
+
+// DefaultSeconds is the length of a CPU profile requested without seconds.
+// net/http/pprof defaults to 30 seconds, which the 30-second default write
+// timeout of pkg/web/server would cut off.
+const DefaultSeconds = 10
+
+// StopTimeout bounds how long generated applications wait at shutdown for
+// the agent to send the profiles taken so far
+const StopTimeout = 2 * time.Second
+
+// forwardingHeaders are set by proxies. A request carrying one of them came
+// from another host, whatever its peer address, and chi's RealIP middleware
+// rewrites RemoteAddr from them.
+var forwardingHeaders = []string{"Forwarded", "X-Forwarded-For", "X-Real-IP", "True-Client-IP"}
+
+// Enabled reports whether the profiler should be served. It is on unless
+// CONDUIT_PPROF turns it off.
+func Enabled() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(EnvVar))) {
+	case "false", "0", "off":
+		return false
+	default:
+		return true
+	}
+}
+
+// Handler returns the net/http/pprof handlers, served to local callers and to
+// callers presenting token as a bearer token. An empty token serves local
+// callers only.
+func Handler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(Path+"/", pprof.Index)
+	mux.HandleFunc(Path+"/cmdline", pprof.Cmdline)
+	mux.HandleFunc(Path+"/profile", profileCPU)
+	mux.HandleFunc(Path+"/symbol", pprof.Symbol)
+	mux.HandleFunc(Path+"/trace", pprof.Trace)
+	return &guard{next: mux, token: []byte(token)}
+}
+
+// guard serves the profiler to the callers allowed to see it. Profiles expose
+// the program's internals and a CPU profile costs the application while it
+// is taken.
+type guard struct {
+	next  http.Handler
+	token []byte
+}
+
+func (g *guard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if Local(r) || g.authorized(r) {
+		g.next.ServeHTTP(w, r)
+		return
+	}
+	if len(g.token) == 0 {
+		http.Error(w, "The profiler is only served to local callers", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer realm="pprof"`)
+	http.Error(w, "A bearer token is required", http.StatusUnauthorized)
+}
+
+// authorized reports whether r presents the bearer token
+func (g *guard) authorized(r *http.Request) bool {
+	if len(g.token) == 0 {
+		return false
+	}
+	presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(presented), g.token) == 1
+}
+
+// Local reports whether r came straight from the host the application runs
+// on: its peer is a loopback address and no proxy forwarded it.
+func Local(r *http.Request) bool {
+	for _, header := range forwardingHeaders {
+		if r.Header.Get(header) != "" {
+			return false
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// profileCPU serves a CPU profile of DefaultSeconds unless the request asks
+// for another length. Go takes one CPU profile at a time, so the agent's
+// current profile is cut short and it waits for this one to finish.
+func profileCPU(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("seconds") == "" {
+		query.Set("seconds", strconv.Itoa(DefaultSeconds))
+		r.URL.RawQuery = query.Encode()
+	}
+	exclusiveCPU(func() { pprof.Profile(w, r) })
+}
+
+var (
+	// cpu is held by the profiler's handler while it takes a CPU profile,
+	// and read-held by the agent while it takes one. A handler waiting for
+	// it keeps the agent from starting another profile.
+	cpu sync.RWMutex
+	// yield asks the agent to end its CPU profile early
+	yield = make(chan struct{}, 1)
+)
+
+// exclusiveCPU runs f, which takes a CPU profile, once the agent has
+// stopped its own. The agent is asked to stop until the lock is taken, since
+// it may start another profile while an earlier handler hands the lock over.
+func exclusiveCPU(f func()) {
+	acquired, asked := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(asked)
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case yield <- struct{}{}:
+			default:
+			}
+			select {
+			case <-acquired:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	cpu.Lock()
+	defer cpu.Unlock()
+	close(acquired)
+	<-asked
+	// The agent's next profile should run its full length
+	select {
+	case <-yield:
+	default:
+	}
+	f()
+}
+
+// Agent sends continuous profiles of the application to a profiling service.
+// Implementations must be safe for concurrent use.
+type Agent interface {
+	// Start begins profiling in the background
+	Start() error
+	// Stop ends profiling and waits up to timeout for the profiles taken so
+	// far to be sent, reporting whether they all were
+	Stop(timeout time.Duration) bool
+}
+
+var (
+	mu    sync.Mutex
+	agent Agent
+)
+
+// Start starts a and makes it the application's agent. It fails when an
+// agent is already running.
+func Start(a Agent) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if agent != nil {
+		return errors.New("profiling: an agent is already running")
+	}
+	if err := a.Start(); err != nil {
+		return err
+	}
+	agent = a
+	return nil
+}
+
+// Stop stops the running agent, waiting up to timeout for its profiles to be
+// sent. It returns true at once without an agent.
+func Stop(timeout time.Duration) bool {
+	mu.Lock()
+	a := agent
+	agent = nil
+	mu.Unlock()
+	if a == nil {
+		return true
+	}
+	return a.Stop(timeout)
+}
@@ -0,0 +1,127 @@
+package profiling
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnabled(t *testing.T) {
+	for value, want := range map[string]bool{"": true, "on": true, "off": false, "0": false, "FALSE": false} {
+		t.Setenv(EnvVar, value)
+		if got := Enabled(); got != want {
+			t.Errorf("Enabled() with %s=%q = %v, want %v", EnvVar, value, got, want)
+		}
+	}
+}
+
+func TestHandler_Access(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		remoteAddr string
+		headers    map[string]string
+		want       int
+	}{
+		{"loopback", "", "127.0.0.1:5000", nil, http.StatusOK},
+		{"ipv6 loopback", "", "[::1]:5000", nil, http.StatusOK},
+		{"remote", "", "203.0.113.7:5000", nil, http.StatusForbidden},
+		// A proxy on the same host forwards requests from anywhere
+		{"forwarded", "", "127.0.0.1:5000", map[string]string{"X-Forwarded-For": "203.0.113.7"}, http.StatusForbidden},
+		{"real ip", "", "127.0.0.1:5000", map[string]string{"X-Real-IP": "127.0.0.1"}, http.StatusForbidden},
+		{"remote without token", "s3cret", "203.0.113.7:5000", nil, http.StatusUnauthorized},
+		{"remote with wrong token", "s3cret", "203.0.113.7:5000", map[string]string{"Authorization": "Bearer nope"}, http.StatusUnauthorized},
+		{"remote with token", "s3cret", "203.0.113.7:5000", map[string]string{"Authorization": "Bearer s3cret"}, http.StatusOK},
+		{"forwarded with token", "s3cret", "127.0.0.1:5000", map[string]string{"X-Forwarded-For": "203.0.113.7", "Authorization": "Bearer s3cret"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, Path+"/cmdline", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for header, value := range tt.headers {
+				req.Header.Set(header, value)
+			}
+			rec := httptest.NewRecorder()
+			Handler(tt.token).ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want == http.StatusUnauthorized && !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Bearer") {
+				t.Errorf("WWW-Authenticate = %q", rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestHandler_Profiles(t *testing.T) {
+	handler := Handler("")
+	for _, path := range []string{Path + "/", Path + "/heap", Path + "/goroutine?debug=1"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "127.0.0.1:5000"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("GET %s: status %d, %d bytes", path, rec.Code, rec.Body.Len())
+		}
+	}
+}
+
+func TestProfileCPU(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, Path+"/profile?seconds=1", nil)
+	rec := httptest.NewRecorder()
+	profileCPU(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+		t.Fatalf("status %d, %d bytes: %s", rec.Code, rec.Body.Len(), rec.Body.String())
+	}
+
+	// Without seconds the profile is shorter than the server's write
+	// timeout; the canceled request ends it early
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req = httptest.NewRequest(http.MethodGet, Path+"/profile", nil).WithContext(ctx)
+	profileCPU(httptest.NewRecorder(), req)
+	if got := req.URL.Query().Get("seconds"); got != strconv.Itoa(DefaultSeconds) {
+		t.Errorf("seconds = %q, want %d", got, DefaultSeconds)
+	}
+}
+
+// fakeAgent records how it was started and stopped
+type fakeAgent struct {
+	started, stopped bool
+}
+
+func (a *fakeAgent) Start() error { a.started = true; return nil }
+
+func (a *fakeAgent) Stop(timeout time.Duration) bool { a.stopped = true; return true }
+
+func TestStartStop(t *testing.T) {
+	defer Stop(0)
+
+	if !Stop(time.Second) {
+		t.Error("Stop() without an agent should report success")
+	}
+
+	first := &fakeAgent{}
+	if err := Start(first); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	if !first.started {
+		t.Error("Start() should start the agent")
+	}
+	if err := Start(&fakeAgent{}); err == nil {
+		t.Error("Start() should fail while an agent is running")
+	}
+
+	if !Stop(time.Second) || !first.stopped {
+		t.Error("Stop() should stop the running agent")
+	}
+	if err := Start(&fakeAgent{}); err != nil {
+		t.Errorf("Start() after Stop() failed: %v", err)
+	}
+}
@@ -0,0 +1,348 @@
+package profiling
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AgentPyroscope is the agent pushing profiles to a Pyroscope server
+const AgentPyroscope = "pyroscope"
+
+const (
+	// ServerAddressEnvVar overrides the configured Pyroscope server
+	ServerAddressEnvVar = "PYROSCOPE_SERVER_ADDRESS"
+	// AuthTokenEnvVar holds the bearer token of the Pyroscope server, if it
+	// needs one
+	AuthTokenEnvVar = "PYROSCOPE_AUTH_TOKEN"
+
+	// DefaultInterval is how long each CPU profile runs, and how often
+	// profiles are sent, when Config has no Interval
+	DefaultInterval = 10 * time.Second
+
+	// QueueSize is how many profiles the agent buffers while sending;
+	// profiles taken while the buffer is full are dropped
+	QueueSize = 16
+
+	// UploadTimeout bounds each request to the server when
+	// PyroscopeOptions has no HTTPClient
+	UploadTimeout = 10 * time.Second
+
+	// cpuSampleRate is the rate, in Hz, Go samples CPU profiles at
+	cpuSampleRate = 100
+)
+
+// tagName is a valid Pyroscope tag name
+var tagName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.]*$`)
+
+// Config is the profiling section of conduit.yaml
+type Config struct {
+	// Agent sends continuous profiles: AgentPyroscope, or none when empty
+	Agent string
+	// Application names the application's profiles
+	Application string
+	// ServerAddress of the Pyroscope server; PYROSCOPE_SERVER_ADDRESS
+	// overrides it
+	ServerAddress string
+	// Interval between profiles; DefaultInterval when zero
+	Interval time.Duration
+	// Tags label every profile, e.g. region=eu
+	Tags map[string]string
+	// BlockRate is passed to runtime.SetBlockProfileRate; the block profile
+	// stays empty when zero
+	BlockRate int
+	// MutexFraction is passed to runtime.SetMutexProfileFraction; the mutex
+	// profile stays empty when zero
+	MutexFraction int
+}
+
+// Configure sets the block and mutex profile rates and starts the agent of
+// cfg. Without a server address the Pyroscope agent stays off, so a build
+// with an agent configured still runs where no server is set up, such as on
+// a laptop.
+func Configure(cfg Config) error {
+	if cfg.BlockRate > 0 {
+		runtime.SetBlockProfileRate(cfg.BlockRate)
+	}
+	if cfg.MutexFraction > 0 {
+		runtime.SetMutexProfileFraction(cfg.MutexFraction)
+	}
+
+	switch cfg.Agent {
+	case "":
+		return nil
+	case AgentPyroscope:
+		address := cfg.ServerAddress
+		if value := os.Getenv(ServerAddressEnvVar); value != "" {
+			address = value
+		}
+		if address == "" {
+			log.Printf("profiling: %s is not set, continuous profiling disabled", ServerAddressEnvVar)
+			return nil
+		}
+		agent, err := NewPyroscope(PyroscopeOptions{
+			ServerAddress: address,
+			AuthToken:     os.Getenv(AuthTokenEnvVar),
+			Application:   cfg.Application,
+			Interval:      cfg.Interval,
+			Tags:          cfg.Tags,
+		})
+		if err != nil {
+			return err
+		}
+		return Start(agent)
+	default:
+		return fmt.Errorf("profiling: agent must be %s, got: %s", AgentPyroscope, cfg.Agent)
+	}
+}
+
+// PyroscopeOptions configures a Pyroscope agent
+type PyroscopeOptions struct {
+	// ServerAddress of the server, e.g. http://pyroscope:4040. A user and
+	// password in it are sent with basic auth.
+	ServerAddress string
+	// AuthToken is sent as a bearer token when set
+	AuthToken   string
+	Application string // e.g. blog
+	Interval    time.Duration
+	Tags        map[string]string
+	HTTPClient  *http.Client
+}
+
+// Pyroscope takes a CPU profile over each interval and a heap profile at the
+// end of it, and pushes both to the ingest API of a Pyroscope server in the
+// background
+type Pyroscope struct {
+	ingest   string // Ingest endpoint, without credentials
+	user     *url.Userinfo
+	name     string // Application name with its tags, e.g. blog{region=eu}
+	opts     PyroscopeOptions
+	uploads  chan *upload
+	stop     chan struct{}
+	stopped  chan struct{} // Closed when profiling has ended
+	sent     chan struct{} // Closed when the queue is drained after profiling ended
+	start    sync.Once
+	stopOnce sync.Once
+}
+
+// upload is a profile waiting to be sent
+type upload struct {
+	profile     []byte
+	from, until time.Time
+	sampleRate  int // Of CPU profiles; zero for the heap
+}
+
+// NewPyroscope returns an agent sending to the server of opts.ServerAddress.
+// Errors never include the address, which may hold a password.
+func NewPyroscope(opts PyroscopeOptions) (*Pyroscope, error) {
+	u, err := url.Parse(opts.ServerAddress)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, errors.New("profiling: the Pyroscope server address must be an http or https URL")
+	}
+	if opts.Application == "" {
+		return nil, errors.New("profiling: an application name is required")
+	}
+	for tag, value := range opts.Tags {
+		if !tagName.MatchString(tag) {
+			return nil, fmt.Errorf("profiling: invalid tag name %q", tag)
+		}
+		if strings.ContainsAny(value, "{},=") {
+			return nil, fmt.Errorf("profiling: tag %s must not contain {, }, , or =", tag)
+		}
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: UploadTimeout}
+	}
+
+	user := u.User
+	u.User = nil
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/ingest"
+
+	return &Pyroscope{
+		ingest:  u.String(),
+		user:    user,
+		name:    profileName(opts.Application, opts.Tags),
+		opts:    opts,
+		uploads: make(chan *upload, QueueSize),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		sent:    make(chan struct{}),
+	}, nil
+}
+
+// profileName returns the application name with its tags in Pyroscope's
+// label syntax, sorted so every profile carries the same name
+func profileName(application string, tags map[string]string) string {
+	names := make([]string, 0, len(tags))
+	for tag := range tags {
+		names = append(names, tag)
+	}
+	sort.Strings(names)
+	labels := make([]string, len(names))
+	for i, tag := range names {
+		labels[i] = tag + "=" + tags[tag]
+	}
+	return application + "{" + strings.Join(labels, ",") + "}"
+}
+
+// Start begins profiling. Starting an agent twice has no further effect.
+func (p *Pyroscope) Start() error {
+	p.start.Do(func() {
+		go p.run()
+		go p.send()
+	})
+	return nil
+}
+
+// Stop ends the current profile, queues it and waits up to timeout for the
+// queue to be sent
+func (p *Pyroscope) Stop(timeout time.Duration) bool {
+	p.stopOnce.Do(func() { close(p.stop) })
+	deadline := time.After(timeout)
+	select {
+	case <-p.stopped:
+	case <-deadline:
+		return false
+	}
+	select {
+	case <-p.sent:
+		return true
+	case <-deadline:
+		return false
+	}
+}
+
+// run takes profiles until the agent is stopped
+func (p *Pyroscope) run() {
+	defer close(p.uploads)
+	defer close(p.stopped)
+	for {
+		from := time.Now()
+		profile, stopped := p.profileCPU()
+		until := time.Now()
+		if profile != nil {
+			p.enqueue(&upload{profile: profile, from: from, until: until, sampleRate: cpuSampleRate})
+		}
+
+		var heap bytes.Buffer
+		if err := pprof.Lookup("heap").WriteTo(&heap, 0); err == nil {
+			p.enqueue(&upload{profile: heap.Bytes(), from: from, until: until})
+		}
+
+		if stopped {
+			return
+		}
+	}
+}
+
+// profileCPU takes a CPU profile until the interval ends, the profiler's
+// handler needs the CPU profile or the agent is stopped. It returns nil
+// when another CPU profile was running, such as one started by a test.
+func (p *Pyroscope) profileCPU() (profile []byte, stopped bool) {
+	cpu.RLock()
+	defer cpu.RUnlock()
+
+	var buf bytes.Buffer
+	running := pprof.StartCPUProfile(&buf) == nil
+
+	timer := time.NewTimer(p.opts.Interval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-yield:
+	case <-p.stop:
+		stopped = true
+	}
+
+	if !running {
+		return nil, stopped
+	}
+	pprof.StopCPUProfile()
+	return buf.Bytes(), stopped
+}
+
+// enqueue queues a profile to be sent, dropping it when the queue is full
+func (p *Pyroscope) enqueue(u *upload) {
+	select {
+	case p.uploads <- u:
+	default:
+		log.Printf("profiling: send queue full, profile dropped")
+	}
+}
+
+// send delivers queued profiles until the queue is closed
+func (p *Pyroscope) send() {
+	defer close(p.sent)
+	for u := range p.uploads {
+		if err := p.upload(u); err != nil {
+			log.Printf("profiling: failed to send profile: %v", err)
+		}
+	}
+}
+
+// upload posts a profile to the ingest API
+func (p *Pyroscope) upload(u *upload) error {
+	query := url.Values{
+		"name":    {p.name},
+		"from":    {strconv.FormatInt(u.from.Unix(), 10)},
+		"until":   {strconv.FormatInt(u.until.Unix(), 10)},
+		"format":  {"pprof"},
+		"spyName": {"gospy"},
+	}
+	if u.sampleRate > 0 {
+		query.Set("sampleRate", strconv.Itoa(u.sampleRate))
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(u.profile); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.ingest+"?"+query.Encode(), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if p.opts.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.opts.AuthToken)
+	} else if p.user != nil {
+		password, _ := p.user.Password()
+		req.SetBasicAuth(p.user.Username(), password)
+	}
+
+	resp, err := p.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("server answered %s", resp.Status)
+	}
+	return nil
+}
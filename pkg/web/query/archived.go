@@ -0,0 +1,52 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArchivedFilter is the filter key that selects the archived records of an
+// @archivable resource, as in ?filter[archived]=true
+const ArchivedFilter = "archived"
+
+// Archived selects which records of an @archivable resource a list returns
+type Archived string
+
+// Values accepted by filter[archived]
+const (
+	ArchivedExclude Archived = "false" // Unarchived records only; the default
+	ArchivedOnly    Archived = "true"  // Archived records only
+	ArchivedAll     Archived = "all"   // Archived and unarchived records
+)
+
+// ParseArchived takes filter[archived] out of filters, which then name only
+// fields, and returns the records it selects. Lists leave archived records out
+// when the filter is absent.
+// Example: ?filter[archived]=true returns ArchivedOnly
+func ParseArchived(filters map[string]string) (Archived, error) {
+	value, ok := filters[ArchivedFilter]
+	if !ok {
+		return ArchivedExclude, nil
+	}
+	delete(filters, ArchivedFilter)
+
+	switch archived := Archived(strings.ToLower(value)); archived {
+	case ArchivedExclude, ArchivedOnly, ArchivedAll:
+		return archived, nil
+	}
+	return "", fmt.Errorf("invalid filter[%s] value %q: use true, false or all", ArchivedFilter, value)
+}
+
+// Archived restricts every statement to the records archived selects, by
+// whether column, which records when a record was archived, is NULL. The
+// column MUST be a trusted value from code generation.
+func (b *Builder) Archived(column string, archived Archived) *Builder {
+	switch archived {
+	case ArchivedOnly:
+		b.notNullColumns = append(b.notNullColumns, column)
+	case ArchivedAll:
+	default:
+		b.nullColumns = append(b.nullColumns, column)
+	}
+	return b
+}
@@ -0,0 +1,58 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestParseArchived(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters map[string]string
+		want    Archived
+		wantErr bool
+	}{
+		{"absent", map[string]string{"status": "published"}, ArchivedExclude, false},
+		{"false", map[string]string{"archived": "false"}, ArchivedExclude, false},
+		{"true", map[string]string{"archived": "true"}, ArchivedOnly, false},
+		{"all", map[string]string{"archived": "ALL"}, ArchivedAll, false},
+		{"invalid", map[string]string{"archived": "maybe"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseArchived(tt.filters)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseArchived() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseArchived() = %q, want %q", got, tt.want)
+			}
+			if _, ok := tt.filters[ArchivedFilter]; ok {
+				t.Error("filter[archived] should be removed from the field filters")
+			}
+		})
+	}
+}
+
+func TestBuilder_Archived(t *testing.T) {
+	tests := []struct {
+		archived Archived
+		want     string
+	}{
+		{ArchivedExclude, "SELECT COUNT(*) FROM posts WHERE posts.archived_at IS NULL"},
+		{ArchivedOnly, "SELECT COUNT(*) FROM posts WHERE posts.archived_at IS NOT NULL"},
+		{ArchivedAll, "SELECT COUNT(*) FROM posts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.archived), func(t *testing.T) {
+			sql, _, err := NewBuilder("posts", []string{"status"}).Archived("archived_at", tt.archived).BuildCount()
+			if err != nil {
+				t.Fatalf("BuildCount() error = %v", err)
+			}
+			if sql != tt.want {
+				t.Errorf("BuildCount() sql = %q, want %q", sql, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Builder composes the JSON:API list parameters for one table - filters, sorting,
+// includes, sparse fieldsets and pagination - into a single validated SQL statement.
+// Placeholders are numbered across all clauses in the builder's dialect, so the
+// returned arguments can be passed straight to db.QueryContext.
+//
+// SECURITY NOTE: tableName MUST be a trusted value from code generation, never from user input.
+// Filter, sort and fieldset names are validated against the builder's fields, include
+// paths against the valid relationship names, and all values are parameterized.
+//
+// Example:
+//
+//	sql, args, err := NewBuilder("posts", []string{"id", "title", "status"}).
+//		Filter(map[string]string{"status": "published"}).
+//		Sort([]string{"-title"}).
+//		Paginate(Page{Limit: 10, Offset: 20}).
+//		Build()
+//	// Returns: "SELECT * FROM posts WHERE posts.status = $1 ORDER BY posts.title DESC LIMIT $2 OFFSET $3",
+//	//          ["published", 10, 20], nil
+type Builder struct {
+	tableName      string
+	fieldMap       *FieldMap
+	filterable     map[string]bool // nil allows every mapped field
+	sortable       map[string]bool // nil allows every mapped field
+	dialect        Dialect
+	filters        map[string]string
+	sorts          []string
+	order          []string
+	fields         []string
+	includes       []string
+	validIncludes  []string
+	nullColumns    []string
+	notNullColumns []string
+	scopes         []string
+	spatial        map[string]bool // nil allows no near filters
+	near           map[string]string
+	ranged         map[string]bool // nil allows no range filters
+	ranges         map[string]map[string]string
+	operators      map[string]map[string]bool // nil allows no operator filters
+	comparisons    map[string]map[string]string
+	page           *Page
+}
+
+// NewBuilder creates a Builder for tableName using PostgreSQL placeholders.
+// Each valid field is stored in a column of the same name.
+func NewBuilder(tableName string, validFields []string) *Builder {
+	return NewMappedBuilder(tableName, identityFieldMap(validFields))
+}
+
+// NewMappedBuilder creates a Builder whose field names are resolved to columns
+// through fieldMap, using PostgreSQL placeholders.
+func NewMappedBuilder(tableName string, fieldMap *FieldMap) *Builder {
+	return &Builder{
+		tableName: tableName,
+		fieldMap:  fieldMap,
+		dialect:   DialectPostgres,
+	}
+}
+
+// Dialect sets the placeholder syntax of the generated SQL.
+func (b *Builder) Dialect(dialect Dialect) *Builder {
+	b.dialect = dialect
+	return b
+}
+
+// Filterable restricts filters to the given attribute names, typically the
+// fields annotated with @filterable.
+func (b *Builder) Filterable(fields []string) *Builder {
+	b.filterable = b.allowList(fields)
+	return b
+}
+
+// Sortable restricts sorting to the given attribute names, typically the
+// fields annotated with @sortable.
+func (b *Builder) Sortable(fields []string) *Builder {
+	b.sortable = b.allowList(fields)
+	return b
+}
+
+// Filter adds equality filters, typically from ParseFilter.
+func (b *Builder) Filter(filters map[string]string) *Builder {
+	b.filters = filters
+	return b
+}
+
+// Sort adds sort fields, typically from ParseSort.
+func (b *Builder) Sort(sorts []string) *Builder {
+	b.sorts = sorts
+	return b
+}
+
+// Fields restricts the selected columns to a sparse fieldset, typically
+// ParseFields(r)[resourceType]. The id column is always selected because
+// JSON:API resource objects require it. An empty list selects all columns.
+func (b *Builder) Fields(fields []string) *Builder {
+	b.fields = fields
+	return b
+}
+
+// Include records relationship paths to load, typically from ParseInclude.
+// The first segment of each path must be one of validIncludes, and a path may
+// follow at most MaxIncludeDepth relationships.
+func (b *Builder) Include(includes []string, validIncludes []string) *Builder {
+	b.includes = includes
+	b.validIncludes = validIncludes
+	return b
+}
+
+// Paginate adds a LIMIT/OFFSET window, typically from ParsePage.
+func (b *Builder) Paginate(page Page) *Builder {
+	b.page = &page
+	return b
+}
+
+// Includes returns the validated include paths for relationship loading.
+func (b *Builder) Includes() []string {
+	return b.includes
+}
+
+// Validate checks every filter, sort, fieldset and include against its whitelist.
+func (b *Builder) Validate() error {
+	if err := b.validateFilters(); err != nil {
+		return err
+	}
+	if err := b.validateNear(); err != nil {
+		return err
+	}
+	if err := b.validateRange(); err != nil {
+		return err
+	}
+	if err := b.validateOperators(); err != nil {
+		return err
+	}
+	if err := b.validateSorts(); err != nil {
+		return err
+	}
+	if err := b.validateFields(); err != nil {
+		return err
+	}
+	return b.validateIncludes()
+}
+
+// WhereNull restricts every statement to rows whose column is NULL, such as
+// the rows of a soft-deleting table that have not been deleted. The column
+// MUST be a trusted value from code generation.
+func (b *Builder) WhereNull(column string) *Builder {
+	b.nullColumns = append(b.nullColumns, column)
+	return b
+}
+
+// OrderBy sets the columns that order rows after any requested sorts, such as
+// the scope and position of an @orderable resource, so that lists come back in
+// the same order every time. A column prefixed with '-' orders descending. The
+// columns MUST be trusted values from code generation.
+func (b *Builder) OrderBy(columns ...string) *Builder {
+	b.order = columns
+	return b
+}
+
+// Build returns the complete SELECT statement and its arguments.
+func (b *Builder) Build() (string, []interface{}, error) {
+	if err := b.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	clauses := []string{fmt.Sprintf("SELECT %s FROM %s", b.selectList(), b.tableName)}
+
+	whereClause, args := b.where()
+	if whereClause != "" {
+		clauses = append(clauses, whereClause)
+	}
+
+	if orderClause := b.orderBy(); orderClause != "" {
+		clauses = append(clauses, orderClause)
+	}
+
+	if b.page != nil {
+		pageClause, pageArgs := buildPageClause(*b.page, b.dialect, len(args)+1)
+		clauses = append(clauses, pageClause)
+		args = append(args, pageArgs...)
+	}
+
+	return strings.Join(clauses, " "), args, nil
+}
+
+// BuildCount returns a SELECT COUNT(*) statement with the same filters as Build.
+// Sorting, fieldsets and pagination do not affect the count.
+func (b *Builder) BuildCount() (string, []interface{}, error) {
+	if err := b.validateFilters(); err != nil {
+		return "", nil, err
+	}
+	if err := b.validateNear(); err != nil {
+		return "", nil, err
+	}
+	if err := b.validateRange(); err != nil {
+		return "", nil, err
+	}
+	if err := b.validateOperators(); err != nil {
+		return "", nil, err
+	}
+
+	whereClause, args := b.where()
+	return withWhere("SELECT COUNT(*) FROM "+b.tableName, whereClause), args, nil
+}
+
+// Count returns the total number of records matched by the builder's filters
+// using the given strategy. See Count for the meaning of the boolean result.
+// CountEstimated relies on PostgreSQL statistics and requires DialectPostgres.
+func (b *Builder) Count(ctx context.Context, db RowQueryer, strategy CountStrategy) (int, bool, error) {
+	if err := b.validateFilters(); err != nil {
+		return 0, false, err
+	}
+	if err := b.validateNear(); err != nil {
+		return 0, false, err
+	}
+	if err := b.validateRange(); err != nil {
+		return 0, false, err
+	}
+	if err := b.validateOperators(); err != nil {
+		return 0, false, err
+	}
+
+	whereClause, args := b.where()
+	return Count(ctx, db, strategy, b.tableName, whereClause, args)
+}
+
+func (b *Builder) where() (string, []interface{}) {
+	var conditions []string
+	for _, column := range b.nullColumns {
+		conditions = append(conditions, fmt.Sprintf("%s.%s IS NULL", b.tableName, column))
+	}
+	for _, column := range b.notNullColumns {
+		conditions = append(conditions, fmt.Sprintf("%s.%s IS NOT NULL", b.tableName, column))
+	}
+	for _, scope := range b.scopes {
+		conditions = append(conditions, "("+scope+")")
+	}
+
+	var args []interface{}
+	if len(b.filters) > 0 {
+		var filterClause string
+		filterClause, args = buildWhereClause(b.filters, b.tableName, b.column, b.dialect, 1)
+		conditions = append(conditions, strings.TrimPrefix(filterClause, "WHERE "))
+	}
+
+	nearConditions, nearArgs := b.nearConditions(len(args) + 1)
+	conditions = append(conditions, nearConditions...)
+	args = append(args, nearArgs...)
+
+	rangeConditions, rangeArgs := b.rangeConditions(len(args) + 1)
+	conditions = append(conditions, rangeConditions...)
+	args = append(args, rangeArgs...)
+
+	operatorConditions, operatorArgs := b.operatorConditions(len(args) + 1)
+	conditions = append(conditions, operatorConditions...)
+	args = append(args, operatorArgs...)
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// orderBy returns the ORDER BY clause of the requested sorts followed by the
+// builder's own order, or an empty string when there is neither.
+func (b *Builder) orderBy() string {
+	var terms []string
+	if len(b.sorts) > 0 {
+		terms = append(terms, strings.TrimPrefix(buildOrderByClause(b.sorts, b.tableName, b.column), "ORDER BY "))
+	}
+	if len(b.order) > 0 {
+		trusted := func(column string) string { return column }
+		terms = append(terms, strings.TrimPrefix(buildOrderByClause(b.order, b.tableName, trusted), "ORDER BY "))
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+	return "ORDER BY " + strings.Join(terms, ", ")
+}
+
+// column resolves an already validated field name to its database column.
+func (b *Builder) column(name string) string {
+	if column, ok := b.fieldMap.Column(name); ok {
+		return column
+	}
+	return toSnakeCase(name)
+}
+
+func (b *Builder) selectList() string {
+	if len(b.fields) == 0 {
+		return "*"
+	}
+
+	columns := []string{b.tableName + ".id"}
+	for _, field := range b.fields {
+		if toSnakeCase(field) == "id" {
+			continue
+		}
+		columns = append(columns, b.tableName+"."+b.column(field))
+	}
+	return strings.Join(columns, ", ")
+}
+
+// allowList resolves attribute names to their canonical form so allow-list
+// checks accept the same spellings as the field map.
+func (b *Builder) allowList(fields []string) map[string]bool {
+	allowed := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if attribute, ok := b.fieldMap.lookup(field); ok {
+			allowed[attribute] = true
+		}
+	}
+	return allowed
+}
+
+// allowed reports whether name is a mapped field permitted by allowList.
+func (b *Builder) allowed(name string, allowList map[string]bool) bool {
+	attribute, ok := b.fieldMap.lookup(name)
+	if !ok {
+		return false
+	}
+	return allowList == nil || allowList[attribute]
+}
+
+func (b *Builder) validateFilters() error {
+	var invalidFields []string
+	for field := range b.filters {
+		if !b.allowed(field, b.filterable) {
+			invalidFields = append(invalidFields, b.fieldMap.display(field))
+		}
+	}
+
+	if len(invalidFields) > 0 {
+		sortKeys(invalidFields)
+		return fmt.Errorf("invalid filter fields: %s", strings.Join(invalidFields, ", "))
+	}
+	return nil
+}
+
+func (b *Builder) validateSorts() error {
+	var invalidFields []string
+	for _, sort := range b.sorts {
+		field := strings.TrimPrefix(sort, "-")
+		if !b.allowed(field, b.sortable) {
+			invalidFields = append(invalidFields, b.fieldMap.display(field))
+		}
+	}
+
+	if len(invalidFields) > 0 {
+		return fmt.Errorf("invalid sort fields: %s", strings.Join(invalidFields, ", "))
+	}
+	return nil
+}
+
+func (b *Builder) validateFields() error {
+	var invalidFields []string
+	for _, field := range b.fields {
+		if toSnakeCase(field) == "id" {
+			continue
+		}
+		if _, ok := b.fieldMap.Column(field); !ok {
+			invalidFields = append(invalidFields, b.fieldMap.display(field))
+		}
+	}
+
+	if len(invalidFields) > 0 {
+		return fmt.Errorf("invalid sparse fieldset fields: %s", strings.Join(invalidFields, ", "))
+	}
+	return nil
+}
+
+func (b *Builder) validateIncludes() error {
+	if err := CheckIncludeDepth(b.includes, MaxIncludeDepth); err != nil {
+		return err
+	}
+
+	validSet := make(map[string]bool, len(b.validIncludes))
+	for _, include := range b.validIncludes {
+		validSet[include] = true
+	}
+
+	var invalidIncludes []string
+	for _, include := range b.includes {
+		relationship := strings.SplitN(include, ".", 2)[0]
+		if !validSet[relationship] {
+			invalidIncludes = append(invalidIncludes, include)
+		}
+	}
+
+	if len(invalidIncludes) > 0 {
+		return fmt.Errorf("invalid include paths: %s", strings.Join(invalidIncludes, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package query
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	validFields := []string{"id", "title", "status", "author_id", "created_at"}
+
+	tests := []struct {
+		name     string
+		builder  *Builder
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "no parameters",
+			builder:  NewBuilder("posts", validFields),
+			wantSQL:  "SELECT * FROM posts",
+			wantArgs: nil,
+		},
+		{
+			name: "all clauses",
+			builder: NewBuilder("posts", validFields).
+				Filter(map[string]string{"status": "published", "authorId": "7"}).
+				Sort([]string{"-created_at", "title"}).
+				Paginate(Page{Limit: 10, Offset: 20}),
+			wantSQL: "SELECT * FROM posts WHERE posts.author_id = $1 AND posts.status = $2 " +
+				"ORDER BY posts.created_at DESC, posts.title ASC LIMIT $3 OFFSET $4",
+			wantArgs: []interface{}{"7", "published", 10, 20},
+		},
+		{
+			name: "question dialect",
+			builder: NewBuilder("posts", validFields).
+				Dialect(DialectQuestion).
+				Filter(map[string]string{"status": "draft"}).
+				Paginate(Page{Limit: 5, Offset: 0}),
+			wantSQL:  "SELECT * FROM posts WHERE posts.status = ? LIMIT ? OFFSET ?",
+			wantArgs: []interface{}{"draft", 5, 0},
+		},
+		{
+			name: "sparse fieldset always selects id",
+			builder: NewBuilder("posts", validFields).
+				Fields([]string{"title", "createdAt"}),
+			wantSQL:  "SELECT posts.id, posts.title, posts.created_at FROM posts",
+			wantArgs: nil,
+		},
+		{
+			name: "order follows requested sorts",
+			builder: NewBuilder("tasks", []string{"id", "title", "list_id", "position"}).
+				OrderBy("list_id", "position", "id").
+				Sort([]string{"-title"}),
+			wantSQL:  "SELECT * FROM tasks ORDER BY tasks.title DESC, tasks.list_id ASC, tasks.position ASC, tasks.id ASC",
+			wantArgs: nil,
+		},
+		{
+			name: "order without requested sorts",
+			builder: NewBuilder("tasks", []string{"id", "title"}).
+				OrderBy("position", "id"),
+			wantSQL:  "SELECT * FROM tasks ORDER BY tasks.position ASC, tasks.id ASC",
+			wantArgs: nil,
+		},
+		{
+			name: "includes do not change the statement",
+			builder: NewBuilder("posts", validFields).
+				Include([]string{"author", "comments.author"}, []string{"author", "comments"}),
+			wantSQL:  "SELECT * FROM posts",
+			wantArgs: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.builder.Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("Build() sql = %q, want %q", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Build() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuilder_ValidationErrors(t *testing.T) {
+	validFields := []string{"title", "status"}
+
+	tests := []struct {
+		name    string
+		builder *Builder
+		wantErr string
+	}{
+		{
+			name:    "invalid filter",
+			builder: NewBuilder("posts", validFields).Filter(map[string]string{"password": "x"}),
+			wantErr: "invalid filter fields",
+		},
+		{
+			name:    "invalid sort",
+			builder: NewBuilder("posts", validFields).Sort([]string{"-secret"}),
+			wantErr: "invalid sort fields: secret",
+		},
+		{
+			name:    "invalid sparse field",
+			builder: NewBuilder("posts", validFields).Fields([]string{"title", "passwordHash"}),
+			wantErr: "invalid sparse fieldset fields: password_hash",
+		},
+		{
+			name:    "invalid include",
+			builder: NewBuilder("posts", validFields).Include([]string{"author", "secrets.owner"}, []string{"author"}),
+			wantErr: "invalid include paths: secrets.owner",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.builder.Build()
+			if err == nil {
+				t.Fatal("Build() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Build() error = %q, want it to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuilder_BuildCount(t *testing.T) {
+	builder := NewBuilder("posts", []string{"status", "title"}).
+		Dialect(DialectQuestion).
+		Filter(map[string]string{"status": "published"}).
+		Sort([]string{"title"}).
+		Paginate(Page{Limit: 10, Offset: 0})
+
+	sql, args, err := builder.BuildCount()
+	if err != nil {
+		t.Fatalf("BuildCount() error = %v", err)
+	}
+	if sql != "SELECT COUNT(*) FROM posts WHERE posts.status = ?" {
+		t.Errorf("BuildCount() sql = %q", sql)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"published"}) {
+		t.Errorf("BuildCount() args = %v", args)
+	}
+}
+
+func TestBuilder_WhereNull(t *testing.T) {
+	builder := NewBuilder("posts", []string{"status"}).
+		WhereNull("deleted_at").
+		Paginate(Page{Limit: 10, Offset: 0})
+
+	sql, args, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if sql != "SELECT * FROM posts WHERE posts.deleted_at IS NULL LIMIT $1 OFFSET $2" {
+		t.Errorf("Build() sql = %q", sql)
+	}
+	if !reflect.DeepEqual(args, []interface{}{10, 0}) {
+		t.Errorf("Build() args = %v", args)
+	}
+
+	sql, args, err = builder.Filter(map[string]string{"status": "published"}).BuildCount()
+	if err != nil {
+		t.Fatalf("BuildCount() error = %v", err)
+	}
+	if sql != "SELECT COUNT(*) FROM posts WHERE posts.deleted_at IS NULL AND posts.status = $1" {
+		t.Errorf("BuildCount() sql = %q", sql)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"published"}) {
+		t.Errorf("BuildCount() args = %v", args)
+	}
+}
+
+func TestBuilder_Count(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM posts WHERE posts.status = $1")).
+		WithArgs("published").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	builder := NewBuilder("posts", []string{"status"}).
+		Filter(map[string]string{"status": "published"}).
+		Paginate(Page{Limit: 1, Offset: 0})
+
+	total, counted, err := builder.Count(context.Background(), db, CountExact)
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if total != 3 || !counted {
+		t.Errorf("Count() = (%d, %v), want (3, true)", total, counted)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDialectPlaceholder(t *testing.T) {
+	if got := DialectPostgres.Placeholder(3); got != "$3" {
+		t.Errorf("DialectPostgres.Placeholder(3) = %q, want $3", got)
+	}
+	if got := DialectQuestion.Placeholder(3); got != "?" {
+		t.Errorf("DialectQuestion.Placeholder(3) = %q, want ?", got)
+	}
+}
+
+func TestBuilder_AllowLists(t *testing.T) {
+	fm := NewFieldMap(map[string]string{
+		"title":     "title",
+		"status":    "status",
+		"createdAt": "created_at",
+		"secret":    "secret",
+	})
+
+	newBuilder := func() *Builder {
+		return NewMappedBuilder("posts", fm).
+			Filterable([]string{"status"}).
+			Sortable([]string{"title", "created_at"})
+	}
+
+	sql, _, err := newBuilder().
+		Filter(map[string]string{"status": "published"}).
+		Sort([]string{"-createdAt"}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if want := "SELECT * FROM posts WHERE posts.status = $1 ORDER BY posts.created_at DESC"; sql != want {
+		t.Errorf("Build() sql = %q, want %q", sql, want)
+	}
+
+	tests := []struct {
+		name    string
+		builder *Builder
+		wantErr string
+	}{
+		{
+			name:    "mapped field that is not filterable",
+			builder: newBuilder().Filter(map[string]string{"title": "x"}),
+			wantErr: "invalid filter fields: title",
+		},
+		{
+			name:    "mapped field that is not sortable",
+			builder: newBuilder().Sort([]string{"status"}),
+			wantErr: "invalid sort fields: status",
+		},
+		{
+			name:    "unmapped field",
+			builder: newBuilder().Filter(map[string]string{"password": "x"}),
+			wantErr: "invalid filter fields: password",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.builder.Build()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Build() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Sparse fieldsets are not restricted by the allow-lists
+	if _, _, err := newBuilder().Fields([]string{"secret"}).Build(); err != nil {
+		t.Errorf("Build() with sparse fieldset error = %v", err)
+	}
+}
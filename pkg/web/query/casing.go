@@ -0,0 +1,52 @@
+package query
+
+// Casing is the convention of an API's JSON attribute names, set with
+// serialization.casing in conduit.yaml. Generated handlers pass it to their
+// field maps so errors name fields the way the API spells them.
+//
+// Example:
+//
+//	CamelCase.Apply("author_id") // Returns: "authorId"
+//	SnakeCase.Apply("authorId")  // Returns: "author_id"
+type Casing string
+
+// Casings accepted by serialization.casing
+const (
+	Declared  Casing = ""           // Field names as declared in the resource
+	SnakeCase Casing = "snake_case" // author_id
+	CamelCase Casing = "camelCase"  // authorId
+)
+
+// Apply returns name in the casing. Declared returns name unchanged.
+func (c Casing) Apply(name string) string {
+	switch c {
+	case SnakeCase:
+		return toSnakeCase(name)
+	case CamelCase:
+		return toCamelCase(name)
+	default:
+		return name
+	}
+}
+
+// toCamelCase converts a string from snake_case to camelCase. Like
+// toSnakeCase it only handles ASCII letters; names without underscores are
+// returned with their first letter lowercased.
+func toCamelCase(s string) string {
+	var result []rune
+	upper := false
+	for i, r := range s {
+		switch {
+		case r == '_' && i > 0:
+			upper = true
+			continue
+		case i == 0 && r >= 'A' && r <= 'Z':
+			r += 32
+		case upper && r >= 'a' && r <= 'z':
+			r -= 32
+		}
+		upper = false
+		result = append(result, r)
+	}
+	return string(result)
+}
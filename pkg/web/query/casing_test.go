@@ -0,0 +1,26 @@
+package query
+
+import "testing"
+
+func TestCasingApply(t *testing.T) {
+	tests := []struct {
+		casing Casing
+		name   string
+		want   string
+	}{
+		{Declared, "author_id", "author_id"},
+		{Declared, "authorId", "authorId"},
+		{SnakeCase, "authorId", "author_id"},
+		{SnakeCase, "author_id", "author_id"},
+		{CamelCase, "author_id", "authorId"},
+		{CamelCase, "created_at_utc", "createdAtUtc"},
+		{CamelCase, "authorId", "authorId"},
+		{CamelCase, "Title", "title"},
+		{CamelCase, "id", "id"},
+	}
+	for _, tt := range tests {
+		if got := tt.casing.Apply(tt.name); got != tt.want {
+			t.Errorf("%q.Apply(%q) = %q, want %q", tt.casing, tt.name, got, tt.want)
+		}
+	}
+}
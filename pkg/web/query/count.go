@@ -0,0 +1,125 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// CountStrategy selects how list endpoints compute the total record count.
+type CountStrategy string
+
+const (
+	// CountExact runs SELECT COUNT(*) with the list filters applied
+	CountExact CountStrategy = "exact"
+
+	// CountEstimated uses PostgreSQL planner statistics instead of scanning the table
+	CountEstimated CountStrategy = "estimated"
+
+	// CountNone skips counting entirely, for tables too large to count per request
+	CountNone CountStrategy = "none"
+)
+
+// ParseCountStrategy converts an annotation value into a CountStrategy.
+// An empty value selects CountExact.
+func ParseCountStrategy(s string) (CountStrategy, error) {
+	switch CountStrategy(s) {
+	case "", CountExact:
+		return CountExact, nil
+	case CountEstimated, CountNone:
+		return CountStrategy(s), nil
+	default:
+		return "", fmt.Errorf("invalid count strategy %q: must be one of exact, estimated, none", s)
+	}
+}
+
+// RowQueryer is the subset of *sql.DB, *sql.Tx and *sql.Conn used for counting.
+type RowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Count returns the total number of records matched by a list query.
+// whereClause and args are the outputs of BuildFilterClause.
+// The boolean result is false when the strategy is CountNone and no count was taken.
+//
+// CountEstimated reads pg_class.reltuples for unfiltered queries and the planner's
+// row estimate from EXPLAIN for filtered ones. Tables that have never been analyzed
+// have no statistics, so the exact count is used instead.
+//
+// SECURITY NOTE: tableName MUST be a trusted value from code generation, never from user input.
+func Count(ctx context.Context, db RowQueryer, strategy CountStrategy, tableName, whereClause string, args []interface{}) (int, bool, error) {
+	switch strategy {
+	case CountNone:
+		return 0, false, nil
+	case CountEstimated:
+		total, ok, err := estimateCount(ctx, db, tableName, whereClause, args)
+		if err != nil || ok {
+			return total, ok, err
+		}
+	case "", CountExact:
+	default:
+		return 0, false, fmt.Errorf("unknown count strategy %q", strategy)
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, withWhere("SELECT COUNT(*) FROM "+tableName, whereClause), args...).Scan(&total); err != nil {
+		return 0, false, err
+	}
+	return total, true, nil
+}
+
+// estimateCount returns planner statistics for the query. The boolean result is
+// false when no usable estimate exists.
+func estimateCount(ctx context.Context, db RowQueryer, tableName, whereClause string, args []interface{}) (int, bool, error) {
+	if whereClause == "" {
+		var estimate float64
+		err := db.QueryRowContext(ctx, "SELECT reltuples FROM pg_class WHERE oid = to_regclass($1)", tableName).Scan(&estimate)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		// reltuples is -1 (PostgreSQL 14+) or 0 before the first ANALYZE
+		if estimate <= 0 {
+			return 0, false, nil
+		}
+		return int(estimate), true, nil
+	}
+
+	var plan []byte
+	if err := db.QueryRowContext(ctx, withWhere("EXPLAIN (FORMAT JSON) SELECT 1 FROM "+tableName, whereClause), args...).Scan(&plan); err != nil {
+		return 0, false, err
+	}
+
+	rows, err := planRows(plan)
+	if err != nil {
+		return 0, false, err
+	}
+	return rows, true, nil
+}
+
+// planRows extracts the top-level "Plan Rows" estimate from EXPLAIN (FORMAT JSON) output.
+func planRows(plan []byte) (int, error) {
+	var explain []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explain); err != nil {
+		return 0, fmt.Errorf("failed to parse query plan: %w", err)
+	}
+	if len(explain) == 0 {
+		return 0, fmt.Errorf("failed to parse query plan: empty plan")
+	}
+	return int(explain[0].Plan.Rows), nil
+}
+
+func withWhere(query, whereClause string) string {
+	if whereClause == "" {
+		return query
+	}
+	return query + " " + whereClause
+}
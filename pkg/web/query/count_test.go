@@ -0,0 +1,158 @@
+package query
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestParseCountStrategy(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected CountStrategy
+		wantErr  bool
+	}{
+		{input: "", expected: CountExact},
+		{input: "exact", expected: CountExact},
+		{input: "estimated", expected: CountEstimated},
+		{input: "none", expected: CountNone},
+		{input: "approximate", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseCountStrategy(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCountStrategy(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("ParseCountStrategy(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name        string
+		strategy    CountStrategy
+		whereClause string
+		args        []interface{}
+		setup       func(mock sqlmock.Sqlmock)
+		wantTotal   int
+		wantCounted bool
+	}{
+		{
+			name:        "exact with filters",
+			strategy:    CountExact,
+			whereClause: "WHERE posts.status = $1",
+			args:        []interface{}{"published"},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM posts WHERE posts.status = $1")).
+					WithArgs("published").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+			},
+			wantTotal:   42,
+			wantCounted: true,
+		},
+		{
+			name:     "estimated from table statistics",
+			strategy: CountEstimated,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT reltuples FROM pg_class WHERE oid = to_regclass($1)")).
+					WithArgs("posts").
+					WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow(1.5e6))
+			},
+			wantTotal:   1500000,
+			wantCounted: true,
+		},
+		{
+			name:     "estimated falls back to exact before analyze",
+			strategy: CountEstimated,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT reltuples FROM pg_class")).
+					WithArgs("posts").
+					WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow(-1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM posts")).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+			},
+			wantTotal:   7,
+			wantCounted: true,
+		},
+		{
+			name:        "estimated with filters uses the query plan",
+			strategy:    CountEstimated,
+			whereClause: "WHERE posts.status = $1",
+			args:        []interface{}{"published"},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("EXPLAIN (FORMAT JSON) SELECT 1 FROM posts WHERE posts.status = $1")).
+					WithArgs("published").
+					WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).
+						AddRow([]byte(`[{"Plan": {"Node Type": "Seq Scan", "Plan Rows": 1234}}]`)))
+			},
+			wantTotal:   1234,
+			wantCounted: true,
+		},
+		{
+			name:        "none skips the query",
+			strategy:    CountNone,
+			setup:       func(mock sqlmock.Sqlmock) {},
+			wantTotal:   0,
+			wantCounted: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to create sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			tt.setup(mock)
+
+			total, counted, err := Count(context.Background(), db, tt.strategy, "posts", tt.whereClause, tt.args)
+			if err != nil {
+				t.Fatalf("Count() error = %v", err)
+			}
+			if total != tt.wantTotal || counted != tt.wantCounted {
+				t.Errorf("Count() = (%d, %v), want (%d, %v)", total, counted, tt.wantTotal, tt.wantCounted)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestCount_UnknownStrategy(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	if _, _, err := Count(context.Background(), db, CountStrategy("fuzzy"), "posts", "", nil); err == nil {
+		t.Error("Count() expected error for unknown strategy")
+	}
+}
+
+func TestPageCountMeta(t *testing.T) {
+	page := Page{Limit: 10, Offset: 20}
+
+	meta := page.CountMeta(CountEstimated, 95, true)
+	if meta["count"] != "estimated" || meta["total"] != 95 || meta["total_pages"] != 10 {
+		t.Errorf("CountMeta(estimated) = %v", meta)
+	}
+
+	meta = page.CountMeta(CountNone, 0, false)
+	if meta["count"] != "none" || meta["page"] != 3 {
+		t.Errorf("CountMeta(none) = %v", meta)
+	}
+	if _, ok := meta["total"]; ok {
+		t.Errorf("CountMeta(none) should omit total, got %v", meta)
+	}
+}
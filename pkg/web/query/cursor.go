@@ -0,0 +1,219 @@
+package query
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// AfterParam is the query parameter holding the cursor of the record a
+	// page starts after
+	AfterParam = "page[after]"
+
+	// BeforeParam is the query parameter holding the cursor of the record a
+	// page ends before
+	BeforeParam = "page[before]"
+)
+
+// SortKey is a column of a keyset pagination sort. The last key of a sort
+// must be unique, such as the primary key, so the order is stable.
+type SortKey struct {
+	Column string
+	Desc   bool
+}
+
+// Cursor is a parsed page[after] or page[before] cursor: the sort key values
+// of the record the page starts after, or ends before when Before is set.
+type Cursor struct {
+	Values []interface{}
+	Before bool
+}
+
+// ParseCursor parses the keyset pagination parameters page[after] and
+// page[before]. It returns nil when neither is present, so the first page is
+// requested. Cursors are opaque to clients; they are built by EncodeCursor and
+// handed out in links.
+// Example: ?page[after]=WzQyXQ returns &Cursor{Values: []interface{}{int64(42)}}
+//
+// Returns an error if both parameters are given, if either is combined with
+// page[offset], or if the cursor does not decode.
+func ParseCursor(r *http.Request) (*Cursor, error) {
+	values := r.URL.Query()
+	after, before := values.Get(AfterParam), values.Get(BeforeParam)
+	if after == "" && before == "" {
+		return nil, nil
+	}
+	if after != "" && before != "" {
+		return nil, fmt.Errorf("%s and %s cannot be combined", AfterParam, BeforeParam)
+	}
+	if name, offset := pageParam(r, "offset"); offset != "" {
+		return nil, fmt.Errorf("%s cannot be combined with %s or %s", name, AfterParam, BeforeParam)
+	}
+
+	name, encoded := AfterParam, after
+	if before != "" {
+		name, encoded = BeforeParam, before
+	}
+
+	keyValues, err := decodeCursor(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return &Cursor{Values: keyValues, Before: before != ""}, nil
+}
+
+// EncodeCursor returns the opaque cursor of a record from its sort key values,
+// in the order of the sort keys: base64url-encoded JSON. Times are encoded in
+// RFC 3339 and compared by the database as such.
+func EncodeCursor(values ...interface{}) (string, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor reverses EncodeCursor. Integers decode as int64 so large IDs
+// keep their precision.
+func decodeCursor(encoded string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var values []interface{}
+	if err := decoder.Decode(&values); err != nil || len(values) == 0 {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+
+	for i, value := range values {
+		switch v := value.(type) {
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				values[i] = n
+			} else if f, err := v.Float64(); err == nil {
+				values[i] = f
+			}
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("malformed cursor")
+		}
+	}
+	return values, nil
+}
+
+// BuildCursorClause generates the parameterized condition selecting the
+// records after the cursor in the order of keys, or before it for a
+// page[before] cursor. For keys a, b it is (a > $1 OR (a = $2 AND b > $3)),
+// with < for descending keys, so mixed directions work. It returns "" and no
+// arguments for a nil cursor. Key columns MUST be trusted values from code
+// generation.
+//
+// Pair it with CursorOrder for the ORDER BY and a LIMIT of one more than the
+// page size, so PageCursors can tell whether another page follows.
+//
+// Example: BuildCursorClause(&Cursor{Values: []interface{}{int64(42)}}, []SortKey{{Column: "id"}}, 2)
+// Returns: "(id > $2)", [42]
+func BuildCursorClause(cursor *Cursor, keys []SortKey, paramIndex int) (string, []interface{}, error) {
+	return buildCursorClause(cursor, keys, DialectPostgres, paramIndex)
+}
+
+func buildCursorClause(cursor *Cursor, keys []SortKey, dialect Dialect, paramIndex int) (string, []interface{}, error) {
+	if cursor == nil {
+		return "", nil, nil
+	}
+	if len(cursor.Values) != len(keys) {
+		return "", nil, fmt.Errorf("invalid cursor: it does not match the sort order")
+	}
+
+	var alternatives []string
+	var args []interface{}
+	for i, key := range keys {
+		var terms []string
+		for _, equal := range keys[:i] {
+			terms = append(terms, fmt.Sprintf("%s = %s", equal.Column, dialect.Placeholder(paramIndex+len(args))))
+			args = append(args, cursor.Values[len(terms)-1])
+		}
+
+		operator := ">"
+		if key.Desc != cursor.Before {
+			operator = "<"
+		}
+		terms = append(terms, fmt.Sprintf("%s %s %s", key.Column, operator, dialect.Placeholder(paramIndex+len(args))))
+		args = append(args, cursor.Values[i])
+
+		if len(terms) == 1 {
+			alternatives = append(alternatives, terms[0])
+		} else {
+			alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+		}
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args, nil
+}
+
+// CursorOrder returns the ORDER BY clause of a keyset page. For a
+// page[before] cursor the keys are reversed, so the rows nearest the cursor
+// come first; reverse the rows read before responding.
+// Example: CursorOrder([]SortKey{{Column: "created_at", Desc: true}, {Column: "id", Desc: true}}, nil)
+// Returns: "ORDER BY created_at DESC, id DESC"
+func CursorOrder(keys []SortKey, cursor *Cursor) string {
+	before := cursor != nil && cursor.Before
+
+	columns := make([]string, len(keys))
+	for i, key := range keys {
+		if key.Desc != before {
+			columns[i] = key.Column + " DESC"
+		} else {
+			columns[i] = key.Column + " ASC"
+		}
+	}
+	return "ORDER BY " + strings.Join(columns, ", ")
+}
+
+// PageCursors returns the cursors of the pages following and preceding a
+// keyset page, "" when there is none. first and last are the sort key values
+// of the page's first and last records, in response order, or nil for an
+// empty page; more reports whether the query returned a record beyond the
+// page size in the direction the client is paging.
+// Pass them to response.BuildCursorLinks for the links object.
+func PageCursors(cursor *Cursor, first, last []interface{}, more bool) (next, prev string, err error) {
+	before := cursor != nil && cursor.Before
+
+	// Paging forward, records precede the page when it has a cursor; paging
+	// backward, records follow it, since the client came from there
+	hasNext, hasPrev := more, cursor != nil
+	if before {
+		hasNext, hasPrev = true, more
+	}
+
+	// An empty page borders the cursor itself
+	if first == nil && cursor != nil {
+		first, last = cursor.Values, cursor.Values
+		if before {
+			hasPrev = false
+		} else {
+			hasNext = false
+		}
+	}
+	if first == nil {
+		return "", "", nil
+	}
+
+	if hasNext {
+		if next, err = EncodeCursor(last...); err != nil {
+			return "", "", err
+		}
+	}
+	if hasPrev {
+		if prev, err = EncodeCursor(first...); err != nil {
+			return "", "", err
+		}
+	}
+	return next, prev, nil
+}
@@ -0,0 +1,247 @@
+package query
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseCursor(t *testing.T) {
+	encoded, err := EncodeCursor("2024-01-15T10:00:00Z", int64(9007199254740993), 1.5)
+	if err != nil {
+		t.Fatalf("EncodeCursor() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		expected *Cursor
+	}{
+		{
+			name:     "no cursor",
+			url:      "/api/posts?page[limit]=10",
+			expected: nil,
+		},
+		{
+			name:     "after cursor",
+			url:      "/api/posts?page[after]=WzQyXQ",
+			expected: &Cursor{Values: []interface{}{int64(42)}},
+		},
+		{
+			name:     "before cursor with padding",
+			url:      "/api/posts?page[before]=WzQyXQ==",
+			expected: &Cursor{Values: []interface{}{int64(42)}, Before: true},
+		},
+		{
+			name:     "round trip keeps large integers",
+			url:      "/api/posts?page[after]=" + encoded,
+			expected: &Cursor{Values: []interface{}{"2024-01-15T10:00:00Z", int64(9007199254740993), 1.5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			cursor, err := ParseCursor(req)
+			if err != nil {
+				t.Fatalf("ParseCursor() error = %v", err)
+			}
+			if !reflect.DeepEqual(cursor, tt.expected) {
+				t.Errorf("ParseCursor() = %+v, want %+v", cursor, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseCursor_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{
+			name:    "both directions",
+			url:     "/api/posts?page[after]=WzFd&page[before]=WzJd",
+			wantErr: "page[after] and page[before] cannot be combined",
+		},
+		{
+			name:    "combined with offset",
+			url:     "/api/posts?page[after]=WzFd&page[offset]=20",
+			wantErr: "page[offset] cannot be combined with page[after] or page[before]",
+		},
+		{
+			name:    "combined with legacy offset",
+			url:     "/api/posts?page[before]=WzFd&offset=20",
+			wantErr: "offset cannot be combined with page[after] or page[before]",
+		},
+		{
+			name:    "not base64",
+			url:     "/api/posts?page[after]=not*base64",
+			wantErr: "invalid page[after]: malformed cursor",
+		},
+		{
+			name:    "not a JSON array",
+			url:     "/api/posts?page[before]=eyJpZCI6MX0",
+			wantErr: "invalid page[before]: malformed cursor",
+		},
+		{
+			name:    "empty array",
+			url:     "/api/posts?page[after]=W10",
+			wantErr: "invalid page[after]: malformed cursor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			_, err := ParseCursor(req)
+			if err == nil {
+				t.Fatal("ParseCursor() expected error, got nil")
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("ParseCursor() error = %q, want %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildCursorClause(t *testing.T) {
+	byCreated := []SortKey{{Column: "created_at", Desc: true}, {Column: "id"}}
+
+	tests := []struct {
+		name       string
+		cursor     *Cursor
+		keys       []SortKey
+		paramIndex int
+		wantClause string
+		wantArgs   []interface{}
+	}{
+		{
+			name:       "no cursor",
+			keys:       byCreated,
+			paramIndex: 1,
+		},
+		{
+			name:       "single key",
+			cursor:     &Cursor{Values: []interface{}{int64(42)}},
+			keys:       []SortKey{{Column: "id"}},
+			paramIndex: 2,
+			wantClause: "(id > $2)",
+			wantArgs:   []interface{}{int64(42)},
+		},
+		{
+			name:       "mixed directions",
+			cursor:     &Cursor{Values: []interface{}{"2024-01-15T10:00:00Z", int64(7)}},
+			keys:       byCreated,
+			paramIndex: 1,
+			wantClause: "(created_at < $1 OR (created_at = $2 AND id > $3))",
+			wantArgs:   []interface{}{"2024-01-15T10:00:00Z", "2024-01-15T10:00:00Z", int64(7)},
+		},
+		{
+			name:       "before cursor flips comparisons",
+			cursor:     &Cursor{Values: []interface{}{"2024-01-15T10:00:00Z", int64(7)}, Before: true},
+			keys:       byCreated,
+			paramIndex: 3,
+			wantClause: "(created_at > $3 OR (created_at = $4 AND id < $5))",
+			wantArgs:   []interface{}{"2024-01-15T10:00:00Z", "2024-01-15T10:00:00Z", int64(7)},
+		},
+		{
+			name:       "three keys",
+			cursor:     &Cursor{Values: []interface{}{"a", "b", int64(3)}},
+			keys:       []SortKey{{Column: "last_name"}, {Column: "first_name"}, {Column: "id"}},
+			paramIndex: 1,
+			wantClause: "(last_name > $1 OR (last_name = $2 AND first_name > $3) OR (last_name = $4 AND first_name = $5 AND id > $6))",
+			wantArgs:   []interface{}{"a", "a", "b", "a", "b", int64(3)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause, args, err := BuildCursorClause(tt.cursor, tt.keys, tt.paramIndex)
+			if err != nil {
+				t.Fatalf("BuildCursorClause() error = %v", err)
+			}
+			if clause != tt.wantClause {
+				t.Errorf("BuildCursorClause() clause = %q, want %q", clause, tt.wantClause)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("BuildCursorClause() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+
+	t.Run("question dialect", func(t *testing.T) {
+		clause, _, err := buildCursorClause(&Cursor{Values: []interface{}{"x", int64(1)}}, byCreated, DialectQuestion, 1)
+		if err != nil {
+			t.Fatalf("buildCursorClause() error = %v", err)
+		}
+		if want := "(created_at < ? OR (created_at = ? AND id > ?))"; clause != want {
+			t.Errorf("buildCursorClause() clause = %q, want %q", clause, want)
+		}
+	})
+
+	t.Run("cursor from another sort", func(t *testing.T) {
+		_, _, err := BuildCursorClause(&Cursor{Values: []interface{}{int64(1)}}, byCreated, 1)
+		if err == nil || err.Error() != "invalid cursor: it does not match the sort order" {
+			t.Errorf("BuildCursorClause() error = %v, want sort order mismatch", err)
+		}
+	})
+}
+
+func TestCursorOrder(t *testing.T) {
+	keys := []SortKey{{Column: "created_at", Desc: true}, {Column: "id"}}
+
+	if got, want := CursorOrder(keys, nil), "ORDER BY created_at DESC, id ASC"; got != want {
+		t.Errorf("CursorOrder() = %q, want %q", got, want)
+	}
+	if got, want := CursorOrder(keys, &Cursor{Values: []interface{}{"x", int64(1)}, Before: true}), "ORDER BY created_at ASC, id DESC"; got != want {
+		t.Errorf("CursorOrder() before = %q, want %q", got, want)
+	}
+}
+
+func TestPageCursors(t *testing.T) {
+	after := &Cursor{Values: []interface{}{int64(5)}}
+	before := &Cursor{Values: []interface{}{int64(5)}, Before: true}
+	first, last := []interface{}{int64(6)}, []interface{}{int64(15)}
+
+	encode := func(value int64) string {
+		cursor, err := EncodeCursor(value)
+		if err != nil {
+			t.Fatalf("EncodeCursor() error = %v", err)
+		}
+		return cursor
+	}
+
+	tests := []struct {
+		name     string
+		cursor   *Cursor
+		first    []interface{}
+		last     []interface{}
+		more     bool
+		wantNext string
+		wantPrev string
+	}{
+		{name: "first page with more", first: first, last: last, more: true, wantNext: encode(15)},
+		{name: "only page", first: first, last: last},
+		{name: "empty first page"},
+		{name: "after with more", cursor: after, first: first, last: last, more: true, wantNext: encode(15), wantPrev: encode(6)},
+		{name: "last page", cursor: after, first: first, last: last, wantPrev: encode(6)},
+		{name: "before at start", cursor: before, first: first, last: last, wantNext: encode(15)},
+		{name: "before with more", cursor: before, first: first, last: last, more: true, wantNext: encode(15), wantPrev: encode(6)},
+		{name: "empty page after", cursor: after, wantPrev: encode(5)},
+		{name: "empty page before", cursor: before, wantNext: encode(5)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, prev, err := PageCursors(tt.cursor, tt.first, tt.last, tt.more)
+			if err != nil {
+				t.Fatalf("PageCursors() error = %v", err)
+			}
+			if next != tt.wantNext || prev != tt.wantPrev {
+				t.Errorf("PageCursors() = %q, %q, want %q, %q", next, prev, tt.wantNext, tt.wantPrev)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package query
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// IncludeDeletedParam is the query parameter that returns the soft-deleted
+// records of a @soft_delete resource along with the others, as in
+// ?include_deleted=true
+const IncludeDeletedParam = "include_deleted"
+
+// ParseIncludeDeleted reports whether a request asks for soft-deleted records.
+// They are left out when ?include_deleted is absent.
+// Example: ?include_deleted=true returns true
+func ParseIncludeDeleted(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get(IncludeDeletedParam)
+	if value == "" {
+		return false, nil
+	}
+
+	include, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value %q: use true or false", IncludeDeletedParam, value)
+	}
+	return include, nil
+}
+
+// Deleted restricts every statement to records that are not soft-deleted, by
+// whether column, which records when a record was deleted, is NULL, unless
+// include is true. The column MUST be a trusted value from code generation.
+func (b *Builder) Deleted(column string, include bool) *Builder {
+	if !include {
+		b.nullColumns = append(b.nullColumns, column)
+	}
+	return b
+}
@@ -0,0 +1,54 @@
+package query
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseIncludeDeleted(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    bool
+		wantErr bool
+	}{
+		{"absent", "", false, false},
+		{"true", "?include_deleted=true", true, false},
+		{"false", "?include_deleted=false", false, false},
+		{"numeric", "?include_deleted=1", true, false},
+		{"invalid", "?include_deleted=maybe", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/posts"+tt.query, nil)
+			got, err := ParseIncludeDeleted(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIncludeDeleted() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseIncludeDeleted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilder_Deleted(t *testing.T) {
+	tests := []struct {
+		include bool
+		want    string
+	}{
+		{false, "SELECT COUNT(*) FROM posts WHERE posts.deleted_at IS NULL"},
+		{true, "SELECT COUNT(*) FROM posts"},
+	}
+
+	for _, tt := range tests {
+		sql, _, err := NewBuilder("posts", []string{"status"}).Deleted("deleted_at", tt.include).BuildCount()
+		if err != nil {
+			t.Fatalf("BuildCount() error = %v", err)
+		}
+		if sql != tt.want {
+			t.Errorf("BuildCount() with include %v sql = %q, want %q", tt.include, sql, tt.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package query
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// DepthParam is the query parameter that limits how many levels of a @tree
+// resource the children and ancestors routes follow, as in ?depth=3
+const DepthParam = "depth"
+
+// ParseDepth returns the number of tree levels a request asks for:
+// defaultDepth when ?depth is absent, and at most maxDepth.
+// Example: ?depth=100 with a maxDepth of 32 returns 32
+func ParseDepth(r *http.Request, defaultDepth, maxDepth int) (int, error) {
+	value := r.URL.Query().Get(DepthParam)
+	if value == "" {
+		return min(defaultDepth, maxDepth), nil
+	}
+
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 1 {
+		return 0, fmt.Errorf("invalid %s value %q: use a positive number of levels", DepthParam, value)
+	}
+	return min(depth, maxDepth), nil
+}
@@ -0,0 +1,35 @@
+package query
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseDepth(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    int
+		wantErr bool
+	}{
+		{"absent", "", 1, false},
+		{"explicit", "?depth=3", 3, false},
+		{"clamped", "?depth=100", 32, false},
+		{"zero", "?depth=0", 0, true},
+		{"negative", "?depth=-2", 0, true},
+		{"not a number", "?depth=all", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/categories/1/children"+tt.query, nil)
+			got, err := ParseDepth(r, 1, 32)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDepth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDepth() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package query
+
+import "strconv"
+
+// Dialect selects the bind parameter syntax used in generated SQL.
+type Dialect int
+
+const (
+	// DialectPostgres numbers placeholders: $1, $2, ...
+	DialectPostgres Dialect = iota
+
+	// DialectQuestion uses positional placeholders: ?, ? (SQLite, MySQL)
+	DialectQuestion
+)
+
+// Placeholder returns the bind parameter for the n-th (1-based) argument.
+func (d Dialect) Placeholder(n int) string {
+	if d == DialectQuestion {
+		return "?"
+	}
+	return "$" + strconv.Itoa(n)
+}
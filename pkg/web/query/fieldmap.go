@@ -0,0 +1,129 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldMap maps the JSON attribute names of a resource to their database columns.
+// Generated handlers build one per resource from field metadata so filter, sort and
+// fieldset parameters can use the API names while SQL uses the real column names,
+// including columns renamed with @column("legacy_name").
+//
+// Lookups accept either the attribute name or its snake_case form, so "authorId"
+// and "author_id" resolve to the same column. Errors name unknown fields in
+// snake_case, or in the casing set with WithCasing.
+//
+// Example:
+//
+//	fm := NewFieldMap(map[string]string{"title": "title", "authorId": "author_ref"})
+//	fm.Column("author_id") // Returns: "author_ref", true
+//	fm.Attribute("author_ref") // Returns: "authorId", true
+type FieldMap struct {
+	columns    map[string]string // attribute -> column
+	attributes map[string]string // column -> attribute
+	aliases    map[string]string // attribute or snake_case attribute -> attribute
+	casing     Casing            // how errors spell field names
+}
+
+// NewFieldMap creates a FieldMap from attribute names to column names.
+func NewFieldMap(columns map[string]string) *FieldMap {
+	fm := &FieldMap{
+		columns:    make(map[string]string, len(columns)),
+		attributes: make(map[string]string, len(columns)),
+		aliases:    make(map[string]string, len(columns)*2),
+	}
+
+	for attribute, column := range columns {
+		fm.columns[attribute] = column
+		fm.attributes[column] = attribute
+		fm.aliases[attribute] = attribute
+	}
+	// Snake_case aliases never shadow an exact attribute name
+	for attribute := range columns {
+		if alias := toSnakeCase(attribute); fm.aliases[alias] == "" {
+			fm.aliases[alias] = attribute
+		}
+	}
+
+	return fm
+}
+
+// WithCasing sets the casing of the API's attribute names, used to name fields
+// in errors. It returns the FieldMap for chaining.
+func (fm *FieldMap) WithCasing(casing Casing) *FieldMap {
+	fm.casing = casing
+	return fm
+}
+
+// identityFieldMap maps each field to a column of the same name.
+func identityFieldMap(fields []string) *FieldMap {
+	columns := make(map[string]string, len(fields))
+	for _, field := range fields {
+		columns[field] = field
+	}
+	return NewFieldMap(columns)
+}
+
+// Column returns the database column for an attribute name.
+func (fm *FieldMap) Column(name string) (string, bool) {
+	attribute, ok := fm.lookup(name)
+	if !ok {
+		return "", false
+	}
+	return fm.columns[attribute], true
+}
+
+// Attribute returns the attribute name for a database column.
+func (fm *FieldMap) Attribute(column string) (string, bool) {
+	attribute, ok := fm.attributes[column]
+	return attribute, ok
+}
+
+// Columns returns all mapped columns in sorted order.
+func (fm *FieldMap) Columns() []string {
+	columns := make([]string, 0, len(fm.attributes))
+	for column := range fm.attributes {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// Attributes resolves requested field names (for example a sparse fieldset from
+// ParseFields) to their canonical attribute names. Unknown names are reported
+// together in a single error.
+func (fm *FieldMap) Attributes(names []string) ([]string, error) {
+	result := make([]string, 0, len(names))
+	var invalid []string
+	for _, name := range names {
+		attribute, ok := fm.lookup(name)
+		if !ok {
+			invalid = append(invalid, fm.display(name))
+			continue
+		}
+		result = append(result, attribute)
+	}
+
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid sparse fieldset fields: %s", strings.Join(invalid, ", "))
+	}
+	return result, nil
+}
+
+// display returns a requested field name as errors spell it
+func (fm *FieldMap) display(name string) string {
+	if fm.casing == CamelCase {
+		return toCamelCase(name)
+	}
+	return toSnakeCase(name)
+}
+
+func (fm *FieldMap) lookup(name string) (string, bool) {
+	if attribute, ok := fm.aliases[name]; ok {
+		return attribute, true
+	}
+	attribute, ok := fm.aliases[toSnakeCase(name)]
+	return attribute, ok
+}
@@ -0,0 +1,132 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFieldMap_Column(t *testing.T) {
+	fm := NewFieldMap(map[string]string{
+		"title":     "title",
+		"authorId":  "author_ref",
+		"createdAt": "created_at",
+	})
+
+	tests := []struct {
+		name       string
+		input      string
+		wantColumn string
+		wantOK     bool
+	}{
+		{name: "exact attribute", input: "title", wantColumn: "title", wantOK: true},
+		{name: "camelCase attribute", input: "authorId", wantColumn: "author_ref", wantOK: true},
+		{name: "snake_case alias", input: "author_id", wantColumn: "author_ref", wantOK: true},
+		{name: "snake_case alias of default column", input: "created_at", wantColumn: "created_at", wantOK: true},
+		{name: "unknown field", input: "password", wantOK: false},
+		{name: "column name is not an attribute", input: "author_ref", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column, ok := fm.Column(tt.input)
+			if ok != tt.wantOK || column != tt.wantColumn {
+				t.Errorf("Column(%q) = (%q, %v), want (%q, %v)", tt.input, column, ok, tt.wantColumn, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFieldMap_Attribute(t *testing.T) {
+	fm := NewFieldMap(map[string]string{"authorId": "author_ref"})
+
+	if attribute, ok := fm.Attribute("author_ref"); !ok || attribute != "authorId" {
+		t.Errorf("Attribute(author_ref) = (%q, %v), want (authorId, true)", attribute, ok)
+	}
+	if _, ok := fm.Attribute("authorId"); ok {
+		t.Error("Attribute(authorId) should not resolve an attribute name")
+	}
+}
+
+func TestFieldMap_Columns(t *testing.T) {
+	fm := NewFieldMap(map[string]string{"title": "title", "authorId": "author_ref", "body": "content"})
+
+	want := []string{"author_ref", "content", "title"}
+	if got := fm.Columns(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Columns() = %v, want %v", got, want)
+	}
+}
+
+func TestFieldMap_Attributes(t *testing.T) {
+	fm := NewFieldMap(map[string]string{"title": "title", "author_id": "author_ref"})
+
+	got, err := fm.Attributes([]string{"title", "authorId"})
+	if err != nil {
+		t.Fatalf("Attributes() error = %v", err)
+	}
+	if want := []string{"title", "author_id"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Attributes() = %v, want %v", got, want)
+	}
+
+	_, err = fm.Attributes([]string{"title", "passwordHash", "secret"})
+	if err == nil {
+		t.Fatal("Attributes() expected error for unknown fields")
+	}
+	if !strings.Contains(err.Error(), "invalid sparse fieldset fields: password_hash, secret") {
+		t.Errorf("Attributes() error = %q", err.Error())
+	}
+}
+
+func TestFieldMap_WithCasing(t *testing.T) {
+	fm := NewFieldMap(map[string]string{"title": "title", "authorId": "author_id"}).WithCasing(CamelCase)
+
+	// Both spellings still resolve
+	for _, name := range []string{"authorId", "author_id"} {
+		if column, ok := fm.Column(name); !ok || column != "author_id" {
+			t.Errorf("Column(%q) = (%q, %v), want (author_id, true)", name, column, ok)
+		}
+	}
+
+	// Errors spell unknown fields like the API
+	_, err := fm.Attributes([]string{"title", "password_hash"})
+	if err == nil || !strings.Contains(err.Error(), "invalid sparse fieldset fields: passwordHash") {
+		t.Errorf("Attributes() error = %v, want passwordHash named", err)
+	}
+	_, _, err = NewMappedBuilder("posts", fm).Filter(map[string]string{"published_at": "2024"}).Build()
+	if err == nil || !strings.Contains(err.Error(), "invalid filter fields: publishedAt") {
+		t.Errorf("Build() error = %v, want publishedAt named", err)
+	}
+}
+
+func TestMappedBuilder_Build(t *testing.T) {
+	fm := NewFieldMap(map[string]string{
+		"id":        "id",
+		"title":     "title",
+		"authorId":  "author_ref",
+		"createdAt": "created_at",
+	})
+
+	sql, args, err := NewMappedBuilder("posts", fm).
+		Filter(map[string]string{"author_id": "7"}).
+		Sort([]string{"-authorId", "title"}).
+		Fields([]string{"authorId"}).
+		Paginate(Page{Limit: 10, Offset: 0}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	wantSQL := "SELECT posts.id, posts.author_ref FROM posts WHERE posts.author_ref = $1 " +
+		"ORDER BY posts.author_ref DESC, posts.title ASC LIMIT $2 OFFSET $3"
+	if sql != wantSQL {
+		t.Errorf("Build() sql = %q, want %q", sql, wantSQL)
+	}
+	if want := []interface{}{"7", 10, 0}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Build() args = %v, want %v", args, want)
+	}
+
+	// Column names are not part of the API surface
+	if _, _, err := NewMappedBuilder("posts", fm).Sort([]string{"author_ref"}).Build(); err == nil {
+		t.Error("Build() expected error when sorting by a raw column name")
+	}
+}
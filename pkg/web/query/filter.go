@@ -37,10 +37,16 @@ func BuildFilterClause(filters map[string]string, tableName string, validFields
 		return "", nil, err
 	}
 
-	// Build WHERE clause with parameterized queries
+	whereClause, args := buildWhereClause(filters, tableName, toSnakeCase, DialectPostgres, 1)
+	return whereClause, args, nil
+}
+
+// buildWhereClause builds the WHERE clause for already validated filters.
+// Field names are converted with column, and placeholders are numbered from
+// paramIndex using the given dialect.
+func buildWhereClause(filters map[string]string, tableName string, column func(string) string, dialect Dialect, paramIndex int) (string, []interface{}) {
 	var conditions []string
 	var args []interface{}
-	paramIndex := 1
 
 	// Iterate in deterministic order for testing consistency
 	// Extract keys and sort them
@@ -53,16 +59,15 @@ func BuildFilterClause(filters map[string]string, tableName string, validFields
 
 	for _, field := range keys {
 		value := filters[field]
-		// Convert field name to snake_case and prefix with table name
-		columnName := fmt.Sprintf("%s.%s", tableName, toSnakeCase(field))
-		condition := fmt.Sprintf("%s = $%d", columnName, paramIndex)
+		// Convert field name to its column and prefix with table name
+		columnName := fmt.Sprintf("%s.%s", tableName, column(field))
+		condition := fmt.Sprintf("%s = %s", columnName, dialect.Placeholder(paramIndex))
 		conditions = append(conditions, condition)
 		args = append(args, value)
 		paramIndex++
 	}
 
-	whereClause := "WHERE " + strings.Join(conditions, " AND ")
-	return whereClause, args, nil
+	return "WHERE " + strings.Join(conditions, " AND "), args
 }
 
 // ValidateFilterFields checks if all filter fields are in the validFields whitelist.
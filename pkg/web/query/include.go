@@ -0,0 +1,61 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxIncludeDepth is how many relationships an include path may follow.
+// Example: ?include=comments.author.posts follows three
+const MaxIncludeDepth = 3
+
+// ErrInvalidInclude is wrapped by errors for include paths naming a
+// relationship that cannot be included
+var ErrInvalidInclude = errors.New("invalid include path")
+
+// IncludeGroup is the include paths that start with one relationship, with
+// that relationship removed: the paths to follow from the related records
+type IncludeGroup struct {
+	Relationship string
+	Paths        []string
+}
+
+// GroupIncludes groups include paths by their first relationship, in the
+// order the relationships first appear.
+// Example: ["author", "comments.author", "comments"] returns
+// [{author []} {comments [author]}]
+func GroupIncludes(paths []string) []IncludeGroup {
+	var groups []IncludeGroup
+	index := make(map[string]int, len(paths))
+	for _, path := range paths {
+		relationship, rest, _ := strings.Cut(path, ".")
+		i, ok := index[relationship]
+		if !ok {
+			i = len(groups)
+			index[relationship] = i
+			groups = append(groups, IncludeGroup{Relationship: relationship})
+		}
+		if rest != "" {
+			groups[i].Paths = append(groups[i].Paths, rest)
+		}
+	}
+	return groups
+}
+
+// CheckIncludeDepth rejects include paths that follow more than maxDepth
+// relationships or have an empty segment, such as "author..posts"
+func CheckIncludeDepth(paths []string, maxDepth int) error {
+	for _, path := range paths {
+		segments := strings.Split(path, ".")
+		for _, segment := range segments {
+			if segment == "" {
+				return fmt.Errorf("%w: %q has an empty relationship", ErrInvalidInclude, path)
+			}
+		}
+		if len(segments) > maxDepth {
+			return fmt.Errorf("%w: %q follows %d relationships, at most %d are allowed", ErrInvalidInclude, path, len(segments), maxDepth)
+		}
+	}
+	return nil
+}
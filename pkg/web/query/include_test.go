@@ -0,0 +1,50 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGroupIncludes(t *testing.T) {
+	got := GroupIncludes([]string{"author", "comments.author", "comments", "comments.post.author"})
+	want := []IncludeGroup{
+		{Relationship: "author"},
+		{Relationship: "comments", Paths: []string{"author", "post.author"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupIncludes() = %+v, want %+v", got, want)
+	}
+	if got := GroupIncludes(nil); len(got) != 0 {
+		t.Errorf("GroupIncludes(nil) = %+v, want no groups", got)
+	}
+}
+
+func TestCheckIncludeDepth(t *testing.T) {
+	if err := CheckIncludeDepth([]string{"author", "comments.author.posts"}, 3); err != nil {
+		t.Errorf("CheckIncludeDepth() = %v, want nil", err)
+	}
+
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{"comments.author.posts.tags", "follows 4 relationships, at most 3"},
+		{"author..posts", "empty relationship"},
+		{"author.", "empty relationship"},
+	}
+	for _, tt := range tests {
+		err := CheckIncludeDepth([]string{tt.path}, 3)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !errors.Is(err, ErrInvalidInclude) {
+			t.Errorf("CheckIncludeDepth(%q) = %v, want ErrInvalidInclude containing %q", tt.path, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBuilderIncludeDepth(t *testing.T) {
+	b := NewBuilder("posts", []string{"title"}).Include([]string{"comments.author.posts.comments"}, []string{"comments"})
+	if err := b.Validate(); err == nil || !strings.Contains(err.Error(), "at most 3") {
+		t.Errorf("Validate() = %v, want a depth error", err)
+	}
+}
@@ -3,7 +3,6 @@ package query
 import (
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"net/http/httptest"
 	"testing"
 
@@ -67,15 +66,18 @@ func TestFilteringIntegration(t *testing.T) {
 			t.Errorf("Expected status=published, got status=%s", filters["status"])
 		}
 
-		// Build SQL query with filter clause
+		// Build SQL query with filter clause (SQLite uses ? placeholders)
 		validFields := []string{"status", "author_id", "title"}
-		whereClause, args, err := BuildFilterClause(filters, "posts", validFields)
+		sqlQuery, args, err := NewBuilder("posts", validFields).
+			Dialect(DialectQuestion).
+			Filter(filters).
+			Fields([]string{"title", "status", "author_id"}).
+			Build()
 		if err != nil {
-			t.Fatalf("Failed to build filter clause: %v", err)
+			t.Fatalf("Failed to build query: %v", err)
 		}
 
-		// Execute query (note: SQLite uses ? placeholders, so we need to convert)
-		sqlQuery := "SELECT id, title, status, author_id FROM posts " + convertToSQLitePlaceholders(whereClause)
+		// Execute query
 		rows, err := db.Query(sqlQuery, args...)
 		if err != nil {
 			t.Fatalf("Failed to execute query: %v", err)
@@ -129,13 +131,16 @@ func TestFilteringIntegration(t *testing.T) {
 
 		// Build SQL query with filter clause
 		validFields := []string{"status", "author_id", "title"}
-		whereClause, args, err := BuildFilterClause(filters, "posts", validFields)
+		sqlQuery, args, err := NewBuilder("posts", validFields).
+			Dialect(DialectQuestion).
+			Filter(filters).
+			Fields([]string{"title", "status", "author_id"}).
+			Build()
 		if err != nil {
-			t.Fatalf("Failed to build filter clause: %v", err)
+			t.Fatalf("Failed to build query: %v", err)
 		}
 
 		// Execute query
-		sqlQuery := "SELECT id, title, status, author_id FROM posts " + convertToSQLitePlaceholders(whereClause)
 		rows, err := db.Query(sqlQuery, args...)
 		if err != nil {
 			t.Fatalf("Failed to execute query: %v", err)
@@ -411,21 +416,19 @@ func TestFilteringAndSorting(t *testing.T) {
 	filters := ParseFilter(req)
 	sorts := ParseSort(req)
 
-	// Build SQL clauses
+	// Build combined SQL query
 	validFields := []string{"name", "category", "price"}
-	whereClause, args, err := BuildFilterClause(filters, "products", validFields)
-	if err != nil {
-		t.Fatalf("Failed to build filter clause: %v", err)
-	}
-
-	orderByClause, err := BuildSortClause(sorts, "products", validFields)
+	sqlQuery, args, err := NewBuilder("products", validFields).
+		Dialect(DialectQuestion).
+		Filter(filters).
+		Sort(sorts).
+		Fields([]string{"name", "category", "price"}).
+		Build()
 	if err != nil {
-		t.Fatalf("Failed to build sort clause: %v", err)
+		t.Fatalf("Failed to build query: %v", err)
 	}
 
 	// Execute combined query
-	sqlQuery := "SELECT id, name, category, price FROM products " +
-		convertToSQLitePlaceholders(whereClause) + " " + orderByClause
 	rows, err := db.Query(sqlQuery, args...)
 	if err != nil {
 		t.Fatalf("Failed to execute query: %v", err)
@@ -740,19 +743,17 @@ func TestComplexScenario(t *testing.T) {
 
 	// 3. Build SQL query
 	validFields := []string{"name", "email", "role", "created_at"}
-	whereClause, args, err := BuildFilterClause(filters, "users", validFields)
+	sqlQuery, args, err := NewBuilder("users", validFields).
+		Dialect(DialectQuestion).
+		Filter(filters).
+		Sort(sorts).
+		Fields([]string{"name", "email", "role", "created_at"}).
+		Build()
 	if err != nil {
-		t.Fatalf("Failed to build filter clause: %v", err)
-	}
-
-	orderByClause, err := BuildSortClause(sorts, "users", validFields)
-	if err != nil {
-		t.Fatalf("Failed to build sort clause: %v", err)
+		t.Fatalf("Failed to build query: %v", err)
 	}
 
 	// 4. Execute query
-	sqlQuery := "SELECT id, name, email, role, created_at FROM users " +
-		convertToSQLitePlaceholders(whereClause) + " " + orderByClause
 	rows, err := db.Query(sqlQuery, args...)
 	if err != nil {
 		t.Fatalf("Failed to execute query: %v", err)
@@ -946,8 +947,16 @@ func TestCamelCaseToSnakeCase(t *testing.T) {
 		t.Errorf("Expected WHERE clause with snake_case, got: %s", whereClause)
 	}
 
-	// Execute query
-	sqlQuery := "SELECT id, author_id FROM posts " + convertToSQLitePlaceholders(whereClause)
+	// Execute the same filter through the builder using SQLite placeholders
+	sqlQuery, args, err := NewBuilder("posts", validFields).
+		Dialect(DialectQuestion).
+		Filter(filters).
+		Fields([]string{"author_id"}).
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
 	rows, err := db.Query(sqlQuery, args...)
 	if err != nil {
 		t.Fatalf("Failed to execute query: %v", err)
@@ -969,42 +978,6 @@ func TestCamelCaseToSnakeCase(t *testing.T) {
 	}
 }
 
-// Helper function to convert PostgreSQL-style placeholders ($1, $2) to SQLite-style (?, ?)
-func convertToSQLitePlaceholders(query string) string {
-	// Simple replacement for integration tests
-	// In production, use the appropriate placeholder for your database
-	result := query
-	for i := 10; i >= 1; i-- {
-		placeholder := fmt.Sprintf("$%d", i)
-		result = replaceFirst(result, placeholder, "?")
-	}
-	return result
-}
-
-// replaceFirst replaces only the first occurrence of old with new
-func replaceFirst(s, old, new string) string {
-	i := 0
-	for {
-		j := indexOf(s[i:], old)
-		if j == -1 {
-			break
-		}
-		s = s[:i+j] + new + s[i+j+len(old):]
-		i = i + j + len(new)
-	}
-	return s
-}
-
-// indexOf returns the index of the first occurrence of substr in s, or -1
-func indexOf(s, substr string) int {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return i
-		}
-	}
-	return -1
-}
-
 // TestErrorResponses tests that proper error responses are generated
 func TestErrorResponses(t *testing.T) {
 	t.Run("Invalid filter field returns structured error", func(t *testing.T) {
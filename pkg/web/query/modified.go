@@ -0,0 +1,54 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CollectionVersion identifies the state of a table for conditional GET
+// requests on list endpoints.
+type CollectionVersion struct {
+	// LastModified is the newest value of the modification column; zero for an empty table
+	LastModified time.Time
+	// Count is the number of rows, or -1 when rows were not counted
+	Count int
+}
+
+// ETag returns a weak entity tag combining the row count and modification
+// time, or "" when rows were not counted. The count catches deletes, which do
+// not change the newest modification time.
+func (v CollectionVersion) ETag() string {
+	if v.Count < 0 {
+		return ""
+	}
+	return fmt.Sprintf(`W/"%d-%d"`, v.Count, v.LastModified.UnixNano())
+}
+
+// LatestVersion reads the newest value of column, typically an @auto_update
+// timestamp, across the whole table. Rows are also counted when countRows is
+// true; resources that avoid COUNT(*) for their lists pass false.
+//
+// SECURITY NOTE: tableName and column MUST be trusted values from code generation, never from user input.
+func LatestVersion(ctx context.Context, db RowQueryer, tableName, column string, countRows bool) (CollectionVersion, error) {
+	var lastModified sql.NullTime
+	version := CollectionVersion{Count: -1}
+
+	if countRows {
+		query := fmt.Sprintf("SELECT MAX(%s), COUNT(*) FROM %s", column, tableName)
+		if err := db.QueryRowContext(ctx, query).Scan(&lastModified, &version.Count); err != nil {
+			return CollectionVersion{}, err
+		}
+	} else {
+		query := fmt.Sprintf("SELECT MAX(%s) FROM %s", column, tableName)
+		if err := db.QueryRowContext(ctx, query).Scan(&lastModified); err != nil {
+			return CollectionVersion{}, err
+		}
+	}
+
+	if lastModified.Valid {
+		version.LastModified = lastModified.Time
+	}
+	return version, nil
+}
@@ -0,0 +1,48 @@
+package query
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLatestVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+	defer db.Close()
+
+	modified := time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT MAX(updated_at), COUNT(*) FROM posts")).
+		WillReturnRows(sqlmock.NewRows([]string{"max", "count"}).AddRow(modified, 3))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT MAX(updated_at) FROM events")).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+
+	version, err := LatestVersion(context.Background(), db, "posts", "updated_at", true)
+	if err != nil {
+		t.Fatalf("LatestVersion() error = %v", err)
+	}
+	if !version.LastModified.Equal(modified) || version.Count != 3 {
+		t.Errorf("version = %+v", version)
+	}
+	if want := `W/"3-1714566615000000000"`; version.ETag() != want {
+		t.Errorf("ETag() = %q, want %q", version.ETag(), want)
+	}
+
+	// Uncounted, empty table
+	version, err = LatestVersion(context.Background(), db, "events", "updated_at", false)
+	if err != nil {
+		t.Fatalf("LatestVersion() error = %v", err)
+	}
+	if !version.LastModified.IsZero() || version.Count != -1 || version.ETag() != "" {
+		t.Errorf("version = %+v, ETag = %q", version, version.ETag())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
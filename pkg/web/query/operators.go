@@ -0,0 +1,256 @@
+package query
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Filter operators accepted as filter[field][operator]=value, beside the
+// equality filter[field]=value
+const (
+	OpNe   = "ne"   // Not equal
+	OpGt   = "gt"   // Greater than
+	OpGte  = "gte"  // Greater than or equal
+	OpLt   = "lt"   // Less than
+	OpLte  = "lte"  // Less than or equal
+	OpIn   = "in"   // One of a comma-separated list
+	OpLike = "like" // SQL LIKE pattern, with % and _ wildcards
+	OpNull = "null" // true for NULL, false for NOT NULL
+)
+
+// MaxInValues caps the values of one in filter, so a request cannot build an
+// arbitrarily long statement
+const MaxInValues = 100
+
+// operatorPattern matches query parameters like filter[views][gte]
+var operatorPattern = regexp.MustCompile(`^filter\[([^\]]+)\]\[(ne|gt|gte|lt|lte|in|like|null)\]$`)
+
+// filterOperators lists the operators in the order their conditions are
+// built, with their SQL comparison
+var filterOperators = []struct {
+	name string
+	sql  string
+}{
+	{OpNe, "<>"},
+	{OpGt, ">"},
+	{OpGte, ">="},
+	{OpLt, "<"},
+	{OpLte, "<="},
+	{OpIn, "IN"},
+	{OpLike, "LIKE"},
+	{OpNull, "IS NULL"},
+}
+
+// IsFilterOperator reports whether name is a filter operator
+func IsFilterOperator(name string) bool {
+	for _, operator := range filterOperators {
+		if operator.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseOperators parses the operator filters into a map of field names to
+// operators and their values.
+// Example: ?filter[views][gte]=100&filter[status][in]=draft,review
+// Returns: {"views": {"gte": "100"}, "status": {"in": "draft,review"}}
+// Returns an empty map if no operator filters are present.
+func ParseOperators(r *http.Request) map[string]map[string]string {
+	result := make(map[string]map[string]string)
+
+	for key, values := range r.URL.Query() {
+		matches := operatorPattern.FindStringSubmatch(key)
+		if len(matches) != 3 || len(values) == 0 {
+			continue
+		}
+		if result[matches[1]] == nil {
+			result[matches[1]] = make(map[string]string)
+		}
+		result[matches[1]][matches[2]] = values[0]
+	}
+
+	return result
+}
+
+// BuildOperatorClause generates a SQL WHERE clause from operator filters, the
+// counterpart of BuildFilterClause for filter[field][operator]=value.
+// allowed maps each field to the operators it accepts; fields and operators
+// missing from it are rejected. Values are parameterized; in filters take one
+// placeholder per value.
+//
+// SECURITY NOTE: tableName MUST be a trusted value from code generation, never from user input.
+//
+// Example:
+//
+//	filters := map[string]map[string]string{"views": {"gte": "100"}, "deleted_at": {"null": "true"}}
+//	clause, args, err := BuildOperatorClause(filters, "posts", map[string][]string{"views": {"gte"}, "deleted_at": {"null"}})
+//	// Returns: "WHERE posts.deleted_at IS NULL AND posts.views >= $1", ["100"], nil
+func BuildOperatorClause(filters map[string]map[string]string, tableName string, allowed map[string][]string) (string, []interface{}, error) {
+	if len(filters) == 0 {
+		return "", nil, nil
+	}
+
+	b := NewBuilder(tableName, mapKeys(allowed)).Operators(allowed).Compare(filters)
+	if err := b.validateOperators(); err != nil {
+		return "", nil, err
+	}
+
+	conditions, args := b.operatorConditions(1)
+	if len(conditions) == 0 {
+		return "", nil, nil
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+// Operators allows comparison operators beyond equality, typically from
+// @filterable(gte, lte, in) annotations: a map of attribute names to the
+// operators each accepts. The fields must also be filterable.
+func (b *Builder) Operators(allowed map[string][]string) *Builder {
+	b.operators = make(map[string]map[string]bool, len(allowed))
+	for field, operators := range allowed {
+		attribute, ok := b.fieldMap.lookup(field)
+		if !ok {
+			continue
+		}
+		b.operators[attribute] = make(map[string]bool, len(operators))
+		for _, operator := range operators {
+			b.operators[attribute][operator] = true
+		}
+	}
+	return b
+}
+
+// Compare adds operator filters, typically from ParseOperators. Each operator
+// restricts its field; operators on one field are combined with AND.
+// Comparisons on Ranged fields are left to Range, which parses their bounds
+// as timestamps.
+func (b *Builder) Compare(filters map[string]map[string]string) *Builder {
+	b.comparisons = filters
+	return b
+}
+
+// rangedComparison reports whether Range already handles operator on field:
+// a bound on a Ranged field
+func (b *Builder) rangedComparison(field, operator string) bool {
+	attribute, _ := b.fieldMap.lookup(field)
+	_, ok := b.ranges[field][operator]
+	return ok && b.ranged[attribute]
+}
+
+// operatorBounds reports whether the range bounds of a field that is not
+// Ranged are all operator filters Compare handles, as when one request is
+// parsed with both ParseRange and ParseOperators
+func (b *Builder) operatorBounds(field string) bool {
+	attribute, _ := b.fieldMap.lookup(field)
+	for operator, value := range b.ranges[field] {
+		compared, ok := b.comparisons[field][operator]
+		if !ok || compared != value || !b.operators[attribute][operator] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Builder) validateOperators() error {
+	var invalid []string
+	for _, field := range sortedRangeKeys(b.comparisons) {
+		attribute, _ := b.fieldMap.lookup(field)
+		for _, operator := range filterOperators {
+			value, ok := b.comparisons[field][operator.name]
+			if !ok || b.rangedComparison(field, operator.name) {
+				continue
+			}
+			if !b.allowed(field, b.filterable) || !b.operators[attribute][operator.name] {
+				invalid = append(invalid, fmt.Sprintf("%s[%s]", b.fieldMap.display(field), operator.name))
+				continue
+			}
+			if _, err := operatorArgs(operator.name, value); err != nil {
+				return fmt.Errorf("invalid %s filter for %s: %w", operator.name, b.fieldMap.display(field), err)
+			}
+		}
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid filter operators: %s", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// operatorConditions builds the conditions of already validated operator
+// filters, numbering placeholders from paramIndex.
+func (b *Builder) operatorConditions(paramIndex int) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	for _, field := range sortedRangeKeys(b.comparisons) {
+		column := b.tableName + "." + b.column(field)
+		for _, operator := range filterOperators {
+			value, ok := b.comparisons[field][operator.name]
+			if !ok || b.rangedComparison(field, operator.name) {
+				continue
+			}
+			values, _ := operatorArgs(operator.name, value)
+
+			switch operator.name {
+			case OpNull:
+				if value, _ := strconv.ParseBool(value); value {
+					conditions = append(conditions, column+" IS NULL")
+				} else {
+					conditions = append(conditions, column+" IS NOT NULL")
+				}
+			case OpIn:
+				placeholders := make([]string, len(values))
+				for i := range values {
+					placeholders[i] = b.dialect.Placeholder(paramIndex)
+					paramIndex++
+				}
+				conditions = append(conditions, fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")))
+			default:
+				conditions = append(conditions, fmt.Sprintf("%s %s %s", column, operator.sql, b.dialect.Placeholder(paramIndex)))
+				paramIndex++
+			}
+			args = append(args, values...)
+		}
+	}
+
+	return conditions, args
+}
+
+// operatorArgs returns the query arguments of an operator filter's value:
+// none for null, one per value for in, and the value itself otherwise
+func operatorArgs(operator, value string) ([]interface{}, error) {
+	switch operator {
+	case OpNull:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("must be true or false, got %q", value)
+		}
+		return nil, nil
+	case OpIn:
+		var values []interface{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("expected a comma-separated list of values")
+		}
+		if len(values) > MaxInValues {
+			return nil, fmt.Errorf("at most %d values are allowed, got %d", MaxInValues, len(values))
+		}
+		return values, nil
+	}
+	return []interface{}{value}, nil
+}
+
+func mapKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
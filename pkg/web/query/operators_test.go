@@ -0,0 +1,164 @@
+package query
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOperators(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/posts?filter[views][gte]=100&filter[status][in]=draft,review&filter[title][like]=Go%25&filter[deleted_at][null]=true&filter[kind]=news&filter[views][between]=x", nil)
+
+	got := ParseOperators(r)
+	want := map[string]map[string]string{
+		"views":      {"gte": "100"},
+		"status":     {"in": "draft,review"},
+		"title":      {"like": "Go%"},
+		"deleted_at": {"null": "true"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseOperators() = %v, want %v", got, want)
+	}
+
+	// Operator filters are not equality filters
+	if filters := ParseFilter(r); !reflect.DeepEqual(filters, map[string]string{"kind": "news"}) {
+		t.Errorf("ParseFilter() = %v", filters)
+	}
+}
+
+func TestBuildOperatorClause(t *testing.T) {
+	allowed := map[string][]string{"views": {"gte", "lt"}, "status": {"in"}, "deleted_at": {"null"}}
+	filters := map[string]map[string]string{
+		"views":      {"lt": "500", "gte": "100"},
+		"status":     {"in": "draft, review,"},
+		"deleted_at": {"null": "false"},
+	}
+
+	clause, args, err := BuildOperatorClause(filters, "posts", allowed)
+	if err != nil {
+		t.Fatalf("BuildOperatorClause() error = %v", err)
+	}
+	wantClause := "WHERE posts.deleted_at IS NOT NULL AND posts.status IN ($1, $2) AND posts.views >= $3 AND posts.views < $4"
+	if clause != wantClause {
+		t.Errorf("BuildOperatorClause() clause = %q, want %q", clause, wantClause)
+	}
+	if want := []interface{}{"draft", "review", "100", "500"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("BuildOperatorClause() args = %v, want %v", args, want)
+	}
+
+	if clause, args, err := BuildOperatorClause(nil, "posts", allowed); clause != "" || args != nil || err != nil {
+		t.Errorf("BuildOperatorClause(nil) = %q, %v, %v", clause, args, err)
+	}
+}
+
+func TestBuildOperatorClause_Invalid(t *testing.T) {
+	allowed := map[string][]string{"views": {"gte"}, "status": {"in"}, "deleted_at": {"null"}}
+	tests := []struct {
+		name    string
+		filters map[string]map[string]string
+		wantErr string
+	}{
+		{"operator not allowed", map[string]map[string]string{"views": {"like": "1%"}}, "invalid filter operators: views[like]"},
+		{"unknown field", map[string]map[string]string{"secret": {"gte": "1"}}, "invalid filter operators: secret[gte]"},
+		{"null value", map[string]map[string]string{"deleted_at": {"null": "maybe"}}, `invalid null filter for deleted_at: must be true or false, got "maybe"`},
+		{"empty in", map[string]map[string]string{"status": {"in": " , "}}, "invalid in filter for status: expected a comma-separated list of values"},
+		{"too many in values", map[string]map[string]string{"status": {"in": strings.Repeat("a,", MaxInValues+1)}}, fmt.Sprintf("at most %d values are allowed", MaxInValues)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := BuildOperatorClause(tt.filters, "posts", allowed)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("BuildOperatorClause() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuilder_Operators(t *testing.T) {
+	fieldMap := NewFieldMap(map[string]string{"kind": "kind", "title": "title", "views": "view_count"})
+	builder := NewMappedBuilder("posts", fieldMap).
+		Dialect(DialectQuestion).
+		Filterable([]string{"kind", "title", "views"}).
+		Operators(map[string][]string{"title": {"like"}, "views": {"ne", "gt"}}).
+		Filter(map[string]string{"kind": "news"}).
+		Compare(map[string]map[string]string{"views": {"gt": "10", "ne": "42"}, "title": {"like": "%Go%"}}).
+		Paginate(Page{Limit: 10, Offset: 0})
+
+	sql, args, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	wantSQL := "SELECT * FROM posts WHERE posts.kind = ? AND posts.title LIKE ? AND posts.view_count <> ? AND posts.view_count > ? LIMIT ? OFFSET ?"
+	if sql != wantSQL {
+		t.Errorf("Build() sql = %q, want %q", sql, wantSQL)
+	}
+	if want := []interface{}{"news", "%Go%", "42", "10", 10, 0}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Build() args = %v, want %v", args, want)
+	}
+
+	sql, args, err = builder.BuildCount()
+	if err != nil {
+		t.Fatalf("BuildCount() error = %v", err)
+	}
+	if want := "SELECT COUNT(*) FROM posts WHERE posts.kind = ? AND posts.title LIKE ? AND posts.view_count <> ? AND posts.view_count > ?"; sql != want {
+		t.Errorf("BuildCount() sql = %q, want %q", sql, want)
+	}
+	if len(args) != 4 {
+		t.Errorf("BuildCount() args = %v", args)
+	}
+
+	// Operators need the field to be filterable as well
+	err = NewMappedBuilder("posts", fieldMap).
+		Filterable([]string{"kind"}).
+		Operators(map[string][]string{"views": {"gt"}}).
+		Compare(map[string]map[string]string{"views": {"gt": "10"}}).
+		Validate()
+	if err == nil || err.Error() != "invalid filter operators: views[gt]" {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	// Without Operators no operator filter is allowed
+	err = NewMappedBuilder("posts", fieldMap).
+		Compare(map[string]map[string]string{"title": {"like": "%"}}).
+		Validate()
+	if err == nil || err.Error() != "invalid filter operators: title[like]" {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestBuilder_OperatorsWithRange(t *testing.T) {
+	// A request parsed with both ParseRange and ParseOperators: bounds on the
+	// Ranged field are timestamps, bounds on other fields are operator filters
+	r := httptest.NewRequest("GET", "/api/events?filter[created_at][gte]=2026-10-01&filter[views][gte]=100", nil)
+	builder := NewBuilder("events", []string{"created_at", "views"}).
+		Ranged([]string{"created_at"}).
+		Operators(map[string][]string{"views": {"gte"}, "created_at": {"gte"}}).
+		Range(ParseRange(r)).
+		Compare(ParseOperators(r))
+
+	sql, args, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if want := "SELECT * FROM events WHERE events.created_at >= $1 AND events.views >= $2"; sql != want {
+		t.Errorf("Build() sql = %q, want %q", sql, want)
+	}
+	if want := []interface{}{time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), "100"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Build() args = %v, want %v", args, want)
+	}
+
+	// A bound on a field that allows neither is still rejected by Range
+	r = httptest.NewRequest("GET", "/api/events?filter[views][lt]=100", nil)
+	err = NewBuilder("events", []string{"created_at", "views"}).
+		Ranged([]string{"created_at"}).
+		Operators(map[string][]string{"views": {"gte"}}).
+		Range(ParseRange(r)).
+		Compare(ParseOperators(r)).
+		Validate()
+	if err == nil || err.Error() != "invalid range filter fields: views" {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
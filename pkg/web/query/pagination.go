@@ -0,0 +1,156 @@
+package query
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// DefaultPageLimit is the page size used when the request does not specify one
+	DefaultPageLimit = 50
+
+	// MaxPageLimit is the largest page size a client may request by default
+	MaxPageLimit = 1000
+)
+
+// PageConfig controls how pagination parameters are interpreted.
+// Zero values fall back to DefaultPageLimit and MaxPageLimit.
+type PageConfig struct {
+	DefaultLimit int // Page size when page[limit] is absent
+	MaxLimit     int // Requested page sizes above this are clamped
+}
+
+// DefaultPageConfig returns the pagination settings used by generated handlers.
+func DefaultPageConfig() PageConfig {
+	return PageConfig{
+		DefaultLimit: DefaultPageLimit,
+		MaxLimit:     MaxPageLimit,
+	}
+}
+
+// Page is a parsed LIMIT/OFFSET window.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// ParsePage parses the JSON:API pagination parameters page[limit] and page[offset].
+// The legacy limit and offset parameters are accepted when the page[...] form is absent,
+// so links built by response.BuildPaginationLinks and older clients both work.
+// Example: ?page[limit]=20&page[offset]=40 returns Page{Limit: 20, Offset: 40}
+//
+// Missing parameters use the configured default limit and an offset of 0.
+// A limit above the configured maximum is clamped to the maximum.
+// Returns an error if limit is not a positive integer or offset is not a non-negative integer.
+func ParsePage(r *http.Request, cfg PageConfig) (Page, error) {
+	if cfg.DefaultLimit <= 0 {
+		cfg.DefaultLimit = DefaultPageLimit
+	}
+	if cfg.MaxLimit <= 0 {
+		cfg.MaxLimit = MaxPageLimit
+	}
+	if cfg.DefaultLimit > cfg.MaxLimit {
+		cfg.DefaultLimit = cfg.MaxLimit
+	}
+
+	page := Page{Limit: cfg.DefaultLimit}
+
+	if name, value := pageParam(r, "limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return Page{}, fmt.Errorf("invalid %s: must be a positive integer", name)
+		}
+		if limit > cfg.MaxLimit {
+			limit = cfg.MaxLimit
+		}
+		page.Limit = limit
+	}
+
+	if name, value := pageParam(r, "offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return Page{}, fmt.Errorf("invalid %s: must be a non-negative integer", name)
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
+
+// pageParam returns the parameter name that was used and its value,
+// preferring page[key] over the legacy bare key.
+func pageParam(r *http.Request, key string) (string, string) {
+	values := r.URL.Query()
+
+	name := "page[" + key + "]"
+	if value := values.Get(name); value != "" {
+		return name, value
+	}
+	return key, values.Get(key)
+}
+
+// BuildPageClause generates a parameterized SQL LIMIT/OFFSET clause.
+// paramIndex is the placeholder number for the limit; the offset uses paramIndex+1.
+// Callers pass len(args)+1 so the clause follows any WHERE clause arguments.
+//
+// Example: BuildPageClause(Page{Limit: 20, Offset: 40}, 3)
+// Returns: "LIMIT $3 OFFSET $4", [20, 40]
+func BuildPageClause(page Page, paramIndex int) (string, []interface{}) {
+	return buildPageClause(page, DialectPostgres, paramIndex)
+}
+
+func buildPageClause(page Page, dialect Dialect, paramIndex int) (string, []interface{}) {
+	clause := fmt.Sprintf("LIMIT %s OFFSET %s", dialect.Placeholder(paramIndex), dialect.Placeholder(paramIndex+1))
+	return clause, []interface{}{page.Limit, page.Offset}
+}
+
+// Number returns the 1-based page number containing the page's offset.
+func (p Page) Number() int {
+	if p.Limit <= 0 {
+		return 1
+	}
+	return (p.Offset / p.Limit) + 1
+}
+
+// TotalPages returns the number of pages needed to cover total records.
+// An empty result set still has one page.
+func (p Page) TotalPages(total int) int {
+	if p.Limit <= 0 || total <= 0 {
+		return 1
+	}
+	return (total + p.Limit - 1) / p.Limit
+}
+
+// Meta returns the pagination metadata for the JSON:API meta object.
+// Pair it with response.BuildPaginationLinks(path, p.Number(), p.Limit, total)
+// for the links object.
+func (p Page) Meta(total int) map[string]interface{} {
+	return map[string]interface{}{
+		"page":        p.Number(),
+		"per_page":    p.Limit,
+		"total":       total,
+		"total_pages": p.TotalPages(total),
+	}
+}
+
+// CountMeta returns Meta annotated with the count strategy under "count".
+// When counted is false (CountNone), total and total_pages are omitted because
+// no count was taken.
+func (p Page) CountMeta(strategy CountStrategy, total int, counted bool) map[string]interface{} {
+	if strategy == "" {
+		strategy = CountExact
+	}
+
+	if !counted {
+		return map[string]interface{}{
+			"page":     p.Number(),
+			"per_page": p.Limit,
+			"count":    string(strategy),
+		}
+	}
+
+	meta := p.Meta(total)
+	meta["count"] = string(strategy)
+	return meta
+}
@@ -0,0 +1,209 @@
+package query
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		cfg      PageConfig
+		expected Page
+	}{
+		{
+			name:     "defaults when not present",
+			url:      "/api/posts",
+			cfg:      DefaultPageConfig(),
+			expected: Page{Limit: 50, Offset: 0},
+		},
+		{
+			name:     "page parameters",
+			url:      "/api/posts?page[limit]=20&page[offset]=40",
+			cfg:      DefaultPageConfig(),
+			expected: Page{Limit: 20, Offset: 40},
+		},
+		{
+			name:     "legacy parameters",
+			url:      "/api/posts?limit=10&offset=30",
+			cfg:      DefaultPageConfig(),
+			expected: Page{Limit: 10, Offset: 30},
+		},
+		{
+			name:     "page parameters take precedence",
+			url:      "/api/posts?page[limit]=20&limit=10",
+			cfg:      DefaultPageConfig(),
+			expected: Page{Limit: 20, Offset: 0},
+		},
+		{
+			name:     "clamps to max limit",
+			url:      "/api/posts?page[limit]=500",
+			cfg:      PageConfig{DefaultLimit: 25, MaxLimit: 100},
+			expected: Page{Limit: 100, Offset: 0},
+		},
+		{
+			name:     "custom default limit",
+			url:      "/api/posts",
+			cfg:      PageConfig{DefaultLimit: 25, MaxLimit: 100},
+			expected: Page{Limit: 25, Offset: 0},
+		},
+		{
+			name:     "zero config uses package defaults",
+			url:      "/api/posts",
+			cfg:      PageConfig{},
+			expected: Page{Limit: DefaultPageLimit, Offset: 0},
+		},
+		{
+			name:     "default limit never exceeds max",
+			url:      "/api/posts",
+			cfg:      PageConfig{DefaultLimit: 200, MaxLimit: 100},
+			expected: Page{Limit: 100, Offset: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			result, err := ParsePage(req, tt.cfg)
+			if err != nil {
+				t.Fatalf("ParsePage() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ParsePage() = %+v, want %+v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePage_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{
+			name:    "non-numeric limit",
+			url:     "/api/posts?page[limit]=abc",
+			wantErr: "invalid page[limit]: must be a positive integer",
+		},
+		{
+			name:    "zero limit",
+			url:     "/api/posts?page[limit]=0",
+			wantErr: "invalid page[limit]: must be a positive integer",
+		},
+		{
+			name:    "negative offset",
+			url:     "/api/posts?page[offset]=-1",
+			wantErr: "invalid page[offset]: must be a non-negative integer",
+		},
+		{
+			name:    "legacy parameter name reported",
+			url:     "/api/posts?limit=-5",
+			wantErr: "invalid limit: must be a positive integer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			_, err := ParsePage(req, DefaultPageConfig())
+			if err == nil {
+				t.Fatal("ParsePage() expected error, got nil")
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("ParsePage() error = %q, want %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildPageClause(t *testing.T) {
+	tests := []struct {
+		name       string
+		page       Page
+		paramIndex int
+		wantClause string
+		wantArgs   []interface{}
+	}{
+		{
+			name:       "first parameters",
+			page:       Page{Limit: 50, Offset: 0},
+			paramIndex: 1,
+			wantClause: "LIMIT $1 OFFSET $2",
+			wantArgs:   []interface{}{50, 0},
+		},
+		{
+			name:       "after filter arguments",
+			page:       Page{Limit: 20, Offset: 40},
+			paramIndex: 3,
+			wantClause: "LIMIT $3 OFFSET $4",
+			wantArgs:   []interface{}{20, 40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause, args := BuildPageClause(tt.page, tt.paramIndex)
+			if clause != tt.wantClause {
+				t.Errorf("BuildPageClause() clause = %q, want %q", clause, tt.wantClause)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("BuildPageClause() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestPageMeta(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     Page
+		total    int
+		expected map[string]interface{}
+	}{
+		{
+			name:  "middle page",
+			page:  Page{Limit: 20, Offset: 40},
+			total: 95,
+			expected: map[string]interface{}{
+				"page":        3,
+				"per_page":    20,
+				"total":       95,
+				"total_pages": 5,
+			},
+		},
+		{
+			name:  "empty result set has one page",
+			page:  Page{Limit: 50, Offset: 0},
+			total: 0,
+			expected: map[string]interface{}{
+				"page":        1,
+				"per_page":    50,
+				"total":       0,
+				"total_pages": 1,
+			},
+		},
+		{
+			name:  "offset inside a page",
+			page:  Page{Limit: 10, Offset: 15},
+			total: 30,
+			expected: map[string]interface{}{
+				"page":        2,
+				"per_page":    10,
+				"total":       30,
+				"total_pages": 3,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if meta := tt.page.Meta(tt.total); !reflect.DeepEqual(meta, tt.expected) {
+				t.Errorf("Meta() = %v, want %v", meta, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package query
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRange(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/events?filter[created_at][gte]=2026-10-01&filter[created_at][lt]=2026-11-01&filter[kind]=click&filter[created_at][between]=x", nil)
+
+	got := ParseRange(r)
+	want := map[string]map[string]string{"created_at": {"gte": "2026-10-01", "lt": "2026-11-01"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRange() = %v, want %v", got, want)
+	}
+
+	// Range filters are not equality filters
+	if filters := ParseFilter(r); !reflect.DeepEqual(filters, map[string]string{"kind": "click"}) {
+		t.Errorf("ParseFilter() = %v", filters)
+	}
+}
+
+func TestParseRangeValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2026-10-01", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{"2026-10-01T12:30:00Z", time.Date(2026, 10, 1, 12, 30, 0, 0, time.UTC)},
+		{"2026-10-01T12:30:00.5+02:00", time.Date(2026, 10, 1, 10, 30, 0, 500000000, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseRangeValue(tt.value)
+		if err != nil {
+			t.Fatalf("ParseRangeValue(%q) error = %v", tt.value, err)
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseRangeValue(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "yesterday", "2026-13-01", "1696118400"} {
+		if _, err := ParseRangeValue(value); err == nil {
+			t.Errorf("ParseRangeValue(%q) should fail", value)
+		}
+	}
+}
+
+func TestBuilder_Range(t *testing.T) {
+	fieldMap := NewFieldMap(map[string]string{"kind": "kind", "createdAt": "created_at"})
+	builder := NewMappedBuilder("events", fieldMap).
+		Ranged([]string{"createdAt"}).
+		Filter(map[string]string{"kind": "click"}).
+		Range(map[string]map[string]string{"createdAt": {"lt": "2026-11-01", "gte": "2026-10-01"}}).
+		Paginate(Page{Limit: 10, Offset: 0})
+
+	sql, args, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	wantSQL := "SELECT * FROM events WHERE events.kind = $1 AND events.created_at >= $2 AND events.created_at < $3 LIMIT $4 OFFSET $5"
+	if sql != wantSQL {
+		t.Errorf("Build() sql = %q, want %q", sql, wantSQL)
+	}
+	wantArgs := []interface{}{
+		"click",
+		time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+		10, 0,
+	}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("Build() args = %v, want %v", args, wantArgs)
+	}
+
+	sql, args, err = builder.BuildCount()
+	if err != nil {
+		t.Fatalf("BuildCount() error = %v", err)
+	}
+	if !strings.Contains(sql, "events.created_at < $3") || len(args) != 3 {
+		t.Errorf("BuildCount() = %q, %v; want the range filter applied", sql, args)
+	}
+}
+
+func TestBuilder_RangeValidation(t *testing.T) {
+	validFields := []string{"kind", "created_at"}
+
+	tests := []struct {
+		name    string
+		builder *Builder
+		wantErr string
+	}{
+		{
+			name:    "field is not ranged",
+			builder: NewBuilder("events", validFields).Ranged([]string{"created_at"}).Range(map[string]map[string]string{"kind": {"gt": "2026-10-01"}}),
+			wantErr: "invalid range filter fields: kind",
+		},
+		{
+			name:    "no ranged fields",
+			builder: NewBuilder("events", validFields).Range(map[string]map[string]string{"created_at": {"gt": "2026-10-01"}}),
+			wantErr: "invalid range filter fields: created_at",
+		},
+		{
+			name: "field is not filterable",
+			builder: NewBuilder("events", validFields).Filterable([]string{"kind"}).
+				Ranged([]string{"created_at"}).Range(map[string]map[string]string{"created_at": {"gt": "2026-10-01"}}),
+			wantErr: "invalid range filter fields: created_at",
+		},
+		{
+			name:    "malformed bound",
+			builder: NewBuilder("events", validFields).Ranged([]string{"created_at"}).Range(map[string]map[string]string{"created_at": {"lte": "last week"}}),
+			wantErr: "invalid lte filter for created_at: expected an RFC 3339 timestamp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.builder.Build()
+			if err == nil {
+				t.Fatal("Build() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Build() error = %q, want it to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
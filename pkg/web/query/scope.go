@@ -0,0 +1,11 @@
+package query
+
+// Scope restricts every statement to the records matching condition, the SQL
+// of a resource's @default_scope, unless unscoped is true. The condition MUST
+// be a trusted value from code generation.
+func (b *Builder) Scope(condition string, unscoped bool) *Builder {
+	if !unscoped && condition != "" {
+		b.scopes = append(b.scopes, condition)
+	}
+	return b
+}
@@ -0,0 +1,27 @@
+package query
+
+import "testing"
+
+func TestBuilder_Scope(t *testing.T) {
+	tests := []struct {
+		unscoped bool
+		want     string
+	}{
+		{false, "SELECT COUNT(*) FROM posts WHERE (posts.deleted_at IS NULL OR posts.pinned IS TRUE) AND posts.status = $1"},
+		{true, "SELECT COUNT(*) FROM posts WHERE posts.status = $1"},
+	}
+
+	for _, tt := range tests {
+		builder := NewBuilder("posts", []string{"status"}).Filter(map[string]string{"status": "draft"})
+		sql, args, err := builder.Scope("posts.deleted_at IS NULL OR posts.pinned IS TRUE", tt.unscoped).BuildCount()
+		if err != nil {
+			t.Fatalf("BuildCount() error = %v", err)
+		}
+		if sql != tt.want {
+			t.Errorf("BuildCount() with unscoped %v sql = %q, want %q", tt.unscoped, sql, tt.want)
+		}
+		if len(args) != 1 || args[0] != "draft" {
+			t.Errorf("BuildCount() args = %v, want [draft]", args)
+		}
+	}
+}
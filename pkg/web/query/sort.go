@@ -24,6 +24,12 @@ func BuildSortClause(sorts []string, tableName string, validFields []string) (st
 		return "", err
 	}
 
+	return buildOrderByClause(sorts, tableName, toSnakeCase), nil
+}
+
+// buildOrderByClause builds the ORDER BY clause for already validated sorts,
+// converting field names with column.
+func buildOrderByClause(sorts []string, tableName string, column func(string) string) string {
 	var sortExpressions []string
 	for _, sort := range sorts {
 		direction := "ASC"
@@ -35,15 +41,12 @@ func BuildSortClause(sorts []string, tableName string, validFields []string) (st
 			fieldName = sort[1:] // Remove the '-' prefix
 		}
 
-		// Convert to snake_case if needed
-		fieldName = toSnakeCase(fieldName)
-
 		// Build the sort expression with table prefix
-		sortExpr := fmt.Sprintf("%s.%s %s", tableName, fieldName, direction)
+		sortExpr := fmt.Sprintf("%s.%s %s", tableName, column(fieldName), direction)
 		sortExpressions = append(sortExpressions, sortExpr)
 	}
 
-	return "ORDER BY " + strings.Join(sortExpressions, ", "), nil
+	return "ORDER BY " + strings.Join(sortExpressions, ", ")
 }
 
 // ValidateSortFields checks that all sort fields (without '-' prefix) exist in the validFields list.
@@ -0,0 +1,135 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// nearPattern matches query parameters like filter[location][near]
+var nearPattern = regexp.MustCompile(`^filter\[([^\]]+)\]\[near\]$`)
+
+// srid is the spatial reference system of point and polygon columns (WGS 84)
+const srid = 4326
+
+// Near is a proximity filter: records within Radius meters of the position.
+type Near struct {
+	Lat    float64
+	Lng    float64
+	Radius float64
+}
+
+// ParseNear parses proximity filters on point and polygon fields into a map
+// of field names to "lat,lng,radius" values.
+// Example: ?filter[location][near]=37.7749,-122.4194,5000
+// Returns: {"location": "37.7749,-122.4194,5000"}
+// Returns an empty map if no near filters are present.
+func ParseNear(r *http.Request) map[string]string {
+	result := make(map[string]string)
+
+	for key, values := range r.URL.Query() {
+		matches := nearPattern.FindStringSubmatch(key)
+		if len(matches) != 2 {
+			continue
+		}
+		if len(values) > 0 {
+			result[matches[1]] = values[0]
+		}
+	}
+
+	return result
+}
+
+// ParseNearValue parses a "lat,lng,radius" filter value. Latitude and
+// longitude are WGS 84 degrees and the radius is a positive distance in meters.
+func ParseNearValue(value string) (Near, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return Near{}, fmt.Errorf("expected lat,lng,radius, got %q", value)
+	}
+
+	var numbers [3]float64
+	for i, part := range parts {
+		number, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return Near{}, fmt.Errorf("expected lat,lng,radius, got %q", value)
+		}
+		numbers[i] = number
+	}
+
+	near := Near{Lat: numbers[0], Lng: numbers[1], Radius: numbers[2]}
+	switch {
+	case !(near.Lat >= -90 && near.Lat <= 90):
+		return Near{}, fmt.Errorf("latitude %v out of range [-90, 90]", near.Lat)
+	case !(near.Lng >= -180 && near.Lng <= 180):
+		return Near{}, fmt.Errorf("longitude %v out of range [-180, 180]", near.Lng)
+	case !(near.Radius > 0) || math.IsInf(near.Radius, 1):
+		return Near{}, fmt.Errorf("radius must be a positive number of meters, got %v", near.Radius)
+	}
+	return near, nil
+}
+
+// Spatial lists the attribute names stored as PostGIS geography - the point
+// and polygon fields - which accept near filters.
+func (b *Builder) Spatial(fields []string) *Builder {
+	b.spatial = b.allowList(fields)
+	return b
+}
+
+// Near adds proximity filters, typically from ParseNear. Each matches the
+// records whose field lies within the radius of the position.
+func (b *Builder) Near(near map[string]string) *Builder {
+	b.near = near
+	return b
+}
+
+func (b *Builder) validateNear() error {
+	var invalidFields []string
+	for _, field := range sortedKeys(b.near) {
+		attribute, _ := b.fieldMap.lookup(field)
+		if !b.allowed(field, b.filterable) || !b.spatial[attribute] {
+			invalidFields = append(invalidFields, b.fieldMap.display(field))
+			continue
+		}
+		if _, err := ParseNearValue(b.near[field]); err != nil {
+			return fmt.Errorf("invalid near filter for %s: %w", b.fieldMap.display(field), err)
+		}
+	}
+
+	if len(invalidFields) > 0 {
+		return fmt.Errorf("invalid near filter fields: %s", strings.Join(invalidFields, ", "))
+	}
+	return nil
+}
+
+// nearConditions builds the ST_DWithin conditions of already validated near
+// filters, numbering placeholders from paramIndex.
+func (b *Builder) nearConditions(paramIndex int) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	for _, field := range sortedKeys(b.near) {
+		near, _ := ParseNearValue(b.near[field])
+		conditions = append(conditions, fmt.Sprintf(
+			"ST_DWithin(%s.%s, ST_SetSRID(ST_MakePoint(%s, %s), %d)::geography, %s)",
+			b.tableName, b.column(field),
+			b.dialect.Placeholder(paramIndex), b.dialect.Placeholder(paramIndex+1), srid,
+			b.dialect.Placeholder(paramIndex+2)))
+		args = append(args, near.Lng, near.Lat, near.Radius)
+		paramIndex += 3
+	}
+
+	return conditions, args
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sortKeys(keys)
+	return keys
+}
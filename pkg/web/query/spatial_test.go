@@ -0,0 +1,115 @@
+package query
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNear(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/stores?filter[location][near]=37.7749,-122.4194,5000&filter[status]=open&filter[area][within]=x", nil)
+
+	got := ParseNear(r)
+	want := map[string]string{"location": "37.7749,-122.4194,5000"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseNear() = %v, want %v", got, want)
+	}
+
+	// Near filters are not equality filters
+	if filters := ParseFilter(r); !reflect.DeepEqual(filters, map[string]string{"status": "open"}) {
+		t.Errorf("ParseFilter() = %v", filters)
+	}
+}
+
+func TestParseNearValue(t *testing.T) {
+	near, err := ParseNearValue("37.7749, -122.4194, 5000")
+	if err != nil {
+		t.Fatalf("ParseNearValue() error = %v", err)
+	}
+	if want := (Near{Lat: 37.7749, Lng: -122.4194, Radius: 5000}); near != want {
+		t.Errorf("ParseNearValue() = %+v, want %+v", near, want)
+	}
+
+	invalid := []string{"", "37.7,-122.4", "37.7,-122.4,1,2", "north,-122.4,10", "91,0,10", "0,181,10", "0,0,0", "0,0,-5", "NaN,0,10", "0,0,Inf"}
+	for _, value := range invalid {
+		if _, err := ParseNearValue(value); err == nil {
+			t.Errorf("ParseNearValue(%q) should fail", value)
+		}
+	}
+}
+
+func TestBuilder_Near(t *testing.T) {
+	fieldMap := NewFieldMap(map[string]string{"name": "name", "status": "status", "location": "geo_location", "area": "area"})
+	builder := NewMappedBuilder("stores", fieldMap).
+		Spatial([]string{"location", "area"}).
+		Filter(map[string]string{"status": "open"}).
+		Near(map[string]string{"location": "37.7749,-122.4194,5000"}).
+		Paginate(Page{Limit: 10, Offset: 0})
+
+	sql, args, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	wantSQL := "SELECT * FROM stores WHERE stores.status = $1 AND " +
+		"ST_DWithin(stores.geo_location, ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography, $4) LIMIT $5 OFFSET $6"
+	if sql != wantSQL {
+		t.Errorf("Build() sql = %q, want %q", sql, wantSQL)
+	}
+	wantArgs := []interface{}{"open", -122.4194, 37.7749, 5000.0, 10, 0}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("Build() args = %v, want %v", args, wantArgs)
+	}
+
+	sql, args, err = builder.BuildCount()
+	if err != nil {
+		t.Fatalf("BuildCount() error = %v", err)
+	}
+	if !strings.Contains(sql, "ST_DWithin(stores.geo_location") || len(args) != 4 {
+		t.Errorf("BuildCount() = %q, %v; want the near filter applied", sql, args)
+	}
+}
+
+func TestBuilder_NearValidation(t *testing.T) {
+	validFields := []string{"name", "location"}
+
+	tests := []struct {
+		name    string
+		builder *Builder
+		wantErr string
+	}{
+		{
+			name:    "field is not spatial",
+			builder: NewBuilder("stores", validFields).Spatial([]string{"location"}).Near(map[string]string{"name": "0,0,10"}),
+			wantErr: "invalid near filter fields: name",
+		},
+		{
+			name:    "no spatial fields",
+			builder: NewBuilder("stores", validFields).Near(map[string]string{"location": "0,0,10"}),
+			wantErr: "invalid near filter fields: location",
+		},
+		{
+			name: "field is not filterable",
+			builder: NewBuilder("stores", validFields).Filterable([]string{"name"}).
+				Spatial([]string{"location"}).Near(map[string]string{"location": "0,0,10"}),
+			wantErr: "invalid near filter fields: location",
+		},
+		{
+			name:    "malformed value",
+			builder: NewBuilder("stores", validFields).Spatial([]string{"location"}).Near(map[string]string{"location": "0,0"}),
+			wantErr: "invalid near filter for location: expected lat,lng,radius",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.builder.Build()
+			if err == nil {
+				t.Fatal("Build() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Build() error = %q, want it to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
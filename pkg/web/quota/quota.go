@@ -0,0 +1,417 @@
+// Package quota meters API requests per client and enforces the limits set
+// under quota in conduit.yaml:
+//
+//	quota:
+//	  client: api_key      # api_key or user
+//	  monthly: 10000       # requests per calendar month (UTC)
+//	  rate_limit: 60       # requests per minute
+//	  clients:
+//	    acme: {monthly: 1000000, rate_limit: 600}
+//
+// Clients are identified by an API key (CONDUIT_API_KEYS lists them as
+// client=key pairs) or by the user a social login token was issued to.
+// Requests without credentials are metered by IP address under the default
+// limits. A client over its rate limit gets 429 Too Many Requests; a client
+// over its monthly quota gets 402 Payment Required until the month ends.
+//
+// Counts are kept in memory and flushed every FlushInterval into daily
+// counters and a monthly rollup, which GET /usage reports. Every instance
+// flushes its own counts and learns the others' from the rollup, so with
+// several instances a client may exceed its monthly quota by the requests
+// served between flushes.
+package quota
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/web/auth"
+	"github.com/conduit-lang/conduit/internal/web/ratelimit"
+	"github.com/conduit-lang/conduit/pkg/web/oauth"
+)
+
+const (
+	// APIKeysEnvVar lists the API keys of api_key clients as client=key pairs,
+	// separated by commas
+	APIKeysEnvVar = "CONDUIT_API_KEYS"
+
+	// DefaultHeader carries the API key of api_key clients
+	DefaultHeader = "X-API-Key"
+
+	// FlushInterval is how often counts are written to the database
+	FlushInterval = 10 * time.Second
+
+	// HistoryMonths is how many months of rollups GET /usage reports
+	HistoryMonths = 12
+)
+
+// Client identification modes
+const (
+	ClientAPIKey = "api_key"
+	ClientUser   = "user"
+)
+
+// Limits caps a client's requests. Zero is unlimited.
+type Limits struct {
+	Monthly   int64 // Requests per calendar month (UTC)
+	RateLimit int   // Requests per minute
+}
+
+// Config selects how clients are identified and their limits.
+type Config struct {
+	Client  string            // api_key or user
+	Header  string            // Header carrying the API key; DefaultHeader when empty
+	Limits  Limits            // Limits of clients without an entry in Clients
+	Clients map[string]Limits // Limits by client name (api_key) or user ID (user)
+}
+
+// Meter counts requests per client and enforces their limits.
+type Meter struct {
+	db      *sql.DB
+	cfg     Config
+	keys    map[string]string // API key to client name
+	tokens  *auth.AuthService
+	buckets map[int]*ratelimit.TokenBucket // By requests per minute
+	now     func() time.Time
+
+	mu      sync.Mutex
+	pending map[counter]int64  // Counted since the last flush
+	totals  map[string]*period // Month-to-date requests as of the last flush
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// counter is a client's requests on a day (UTC)
+type counter struct {
+	client string
+	day    string // 2006-01-02
+}
+
+// period is a client's requests in a month
+type period struct {
+	month    string // 2006-01
+	requests int64
+}
+
+// New returns a Meter for cfg. keys maps API keys to client names; secret
+// validates social login tokens.
+func New(db *sql.DB, cfg Config, keys map[string]string, secret string) *Meter {
+	if cfg.Header == "" {
+		cfg.Header = DefaultHeader
+	}
+	m := &Meter{
+		db:      db,
+		cfg:     cfg,
+		keys:    keys,
+		buckets: make(map[int]*ratelimit.TokenBucket),
+		now:     time.Now,
+		pending: make(map[counter]int64),
+		totals:  make(map[string]*period),
+	}
+	if secret != "" {
+		m.tokens = auth.NewAuthService(secret, oauth.TokenTTL)
+	}
+
+	// One bucket per distinct rate limit
+	rates := []int{cfg.Limits.RateLimit}
+	for _, limits := range cfg.Clients {
+		rates = append(rates, limits.RateLimit)
+	}
+	for _, rate := range rates {
+		if rate > 0 && m.buckets[rate] == nil {
+			m.buckets[rate] = ratelimit.NewTokenBucketWithConfig(ratelimit.TokenBucketConfig{
+				Capacity:        rate,
+				RefillRate:      time.Minute,
+				CleanupInterval: 5 * time.Minute,
+			})
+		}
+	}
+	return m
+}
+
+// Configure returns a Meter for cfg with credentials read from the
+// environment, and creates the usage tables.
+func Configure(ctx context.Context, db *sql.DB, cfg Config) (*Meter, error) {
+	var keys map[string]string
+	var secret string
+	switch cfg.Client {
+	case ClientAPIKey:
+		var err error
+		if keys, err = ParseKeys(os.Getenv(APIKeysEnvVar)); err != nil {
+			return nil, err
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("%s is required for api_key clients", APIKeysEnvVar)
+		}
+	case ClientUser:
+		if secret = os.Getenv(oauth.SecretEnvVar); secret == "" {
+			return nil, fmt.Errorf("%s is required for user clients", oauth.SecretEnvVar)
+		}
+	default:
+		return nil, fmt.Errorf("quota.client must be api_key or user, got: %s", cfg.Client)
+	}
+
+	if err := CreateTables(ctx, db); err != nil {
+		return nil, err
+	}
+	return New(db, cfg, keys, secret), nil
+}
+
+// ParseKeys parses client=key pairs separated by commas into a map from key
+// to client.
+func ParseKeys(value string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		client, key, ok := strings.Cut(pair, "=")
+		if !ok || client == "" || key == "" {
+			return nil, fmt.Errorf("%s entries must be client=key", APIKeysEnvVar)
+		}
+		keys[key] = client
+	}
+	return keys, nil
+}
+
+// Start flushes counts every FlushInterval until Stop.
+func (m *Meter) Start(ctx context.Context) {
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := m.Flush(ctx); err != nil {
+					log.Printf("quota: %v", err)
+				}
+			case <-m.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the flush loop and flushes the remaining counts.
+func (m *Meter) Stop() {
+	if m.stop != nil {
+		close(m.stop)
+		<-m.done
+		m.stop = nil
+	}
+	if err := m.Flush(context.Background()); err != nil {
+		log.Printf("quota: %v", err)
+	}
+	for _, bucket := range m.buckets {
+		bucket.Close()
+	}
+}
+
+// Middleware meters requests and rejects those over the client's limits.
+func (m *Meter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, rejected := m.identify(r)
+		if rejected != "" {
+			respondWithError(w, rejected, http.StatusUnauthorized)
+			return
+		}
+		limits := m.limits(client)
+
+		if bucket := m.buckets[limits.RateLimit]; bucket != nil {
+			info, err := bucket.Allow(r.Context(), client)
+			if err == nil {
+				w.Header().Set("X-RateLimit-Limit", strconv.Itoa(info.Limit))
+				w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(info.Remaining))
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(info.ResetAt.Unix(), 10))
+				if !info.Allowed {
+					retryAfter := int64(time.Until(info.ResetAt).Seconds())
+					if retryAfter < 0 {
+						retryAfter = 0
+					}
+					w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+					respondWithError(w, "Rate limit exceeded", http.StatusTooManyRequests)
+					return
+				}
+			}
+		}
+
+		if limits.Monthly > 0 {
+			used, err := m.Used(r.Context(), client)
+			if err != nil {
+				// Metering never takes the API down
+				log.Printf("quota: %v", err)
+			}
+			w.Header().Set("X-Quota-Limit", strconv.FormatInt(limits.Monthly, 10))
+			w.Header().Set("X-Quota-Reset", strconv.FormatInt(nextMonth(m.now()).Unix(), 10))
+			if used >= limits.Monthly {
+				w.Header().Set("X-Quota-Remaining", "0")
+				respondWithError(w, "Monthly request quota exceeded", http.StatusPaymentRequired)
+				return
+			}
+			// Remaining after this request
+			w.Header().Set("X-Quota-Remaining", strconv.FormatInt(limits.Monthly-used-1, 10))
+		}
+
+		m.record(client)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Used returns the client's requests this month, including those not yet flushed.
+func (m *Meter) Used(ctx context.Context, client string) (int64, error) {
+	month := monthOf(m.now())
+
+	m.mu.Lock()
+	total, ok := m.totals[client]
+	m.mu.Unlock()
+
+	var err error
+	if !ok || total.month != month {
+		var requests int64
+		requests, err = monthlyRequests(ctx, m.db, client, month)
+		total = &period{month: month, requests: requests}
+		if err == nil {
+			m.mu.Lock()
+			// A flush may have stored a newer total meanwhile
+			if current, ok := m.totals[client]; ok && current.month == month {
+				total = current
+			} else {
+				m.totals[client] = total
+			}
+			m.mu.Unlock()
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	used := total.requests
+	for c, n := range m.pending {
+		if c.client == client && strings.HasPrefix(c.day, month) {
+			used += n
+		}
+	}
+	return used, err
+}
+
+// Flush writes the counts since the last flush to the database.
+func (m *Meter) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[counter]int64)
+	m.mu.Unlock()
+
+	var failed error
+	for c, n := range pending {
+		month := c.day[:len("2006-01")]
+		total, err := addRequests(ctx, m.db, c.client, c.day, n)
+		m.mu.Lock()
+		if err != nil {
+			// Keep the counts for the next flush
+			m.pending[c] += n
+			failed = err
+		} else if current, ok := m.totals[c.client]; !ok || current.month <= month {
+			m.totals[c.client] = &period{month: month, requests: total}
+		}
+		m.mu.Unlock()
+	}
+	if failed != nil {
+		return fmt.Errorf("failed to flush usage: %w", failed)
+	}
+	return nil
+}
+
+// record counts a request
+func (m *Meter) record(client string) {
+	m.mu.Lock()
+	m.pending[counter{client: client, day: m.now().UTC().Format("2006-01-02")}]++
+	m.mu.Unlock()
+}
+
+// limits returns the client's limits
+func (m *Meter) limits(client string) Limits {
+	if limits, ok := m.cfg.Clients[client]; ok {
+		return limits
+	}
+	return m.cfg.Limits
+}
+
+// identify returns the client a request is metered as: the API key's client,
+// the token's user, or "ip:<address>" for requests without credentials. Bad
+// credentials are rejected with a message instead.
+func (m *Meter) identify(r *http.Request) (client string, rejected string) {
+	switch m.cfg.Client {
+	case ClientAPIKey:
+		if key := r.Header.Get(m.cfg.Header); key != "" {
+			for candidate, client := range m.keys {
+				if subtle.ConstantTimeCompare([]byte(candidate), []byte(key)) == 1 {
+					return client, ""
+				}
+			}
+			return "", "Invalid API key"
+		}
+	case ClientUser:
+		if token := bearerToken(r); token != "" && m.tokens != nil {
+			claims, err := m.tokens.ValidateToken(token)
+			if err != nil {
+				return "", "Invalid token"
+			}
+			if userID, _ := claims["user_id"].(string); userID != "" {
+				return userID, ""
+			}
+			return "", "Invalid token"
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host, ""
+}
+
+// bearerToken returns the token in the Authorization header or the social login cookie
+func bearerToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+	if cookie, err := r.Cookie(oauth.TokenCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// monthOf returns the month (UTC) t falls in
+func monthOf(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// nextMonth returns the start of the month after t (UTC), when quotas reset
+func nextMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// respondWithError writes a JSON error response
+func respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
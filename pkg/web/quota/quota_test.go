@@ -0,0 +1,288 @@
+package quota
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/conduit-lang/conduit/internal/web/auth"
+	"github.com/conduit-lang/conduit/pkg/web/oauth"
+)
+
+var (
+	monthlyQuery  = regexp.QuoteMeta("SELECT requests FROM usage_monthly WHERE client = $1 AND month = $2")
+	dailyUpsert   = regexp.QuoteMeta("INSERT INTO usage_daily (client, day, requests) VALUES ($1, $2, $3)")
+	monthlyUpsert = regexp.QuoteMeta("INSERT INTO usage_monthly (client, month, requests) VALUES ($1, $2, $3)")
+)
+
+// fixedNow is mid-October 2026 (UTC)
+var fixedNow = time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+
+func newTestMeter(t *testing.T, cfg Config) (*Meter, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m := New(db, cfg, map[string]string{"key-acme": "acme", "key-beta": "beta"}, "secret")
+	m.now = func() time.Time { return fixedNow }
+	t.Cleanup(func() {
+		for _, bucket := range m.buckets {
+			bucket.Close()
+		}
+	})
+	return m, mock
+}
+
+func serve(m *Meter, header, value string) *httptest.ResponseRecorder {
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest("GET", "/posts", nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys(" acme=k1, beta=k2 ,")
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+	if len(keys) != 2 || keys["k1"] != "acme" || keys["k2"] != "beta" {
+		t.Errorf("ParseKeys() = %v", keys)
+	}
+
+	if _, err := ParseKeys("acme"); err == nil {
+		t.Error("ParseKeys() should reject entries without a key")
+	}
+}
+
+func TestConfigure_RequiresCredentials(t *testing.T) {
+	t.Setenv(APIKeysEnvVar, "")
+	t.Setenv(oauth.SecretEnvVar, "")
+
+	for _, client := range []string{ClientAPIKey, ClientUser, "ip"} {
+		if _, err := Configure(context.Background(), nil, Config{Client: client}); err == nil {
+			t.Errorf("Configure(%s) should fail without credentials", client)
+		}
+	}
+}
+
+func TestMiddleware_Identify(t *testing.T) {
+	m, _ := newTestMeter(t, Config{Client: ClientAPIKey})
+
+	if rec := serve(m, DefaultHeader, "stolen"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown key status = %d, want 401", rec.Code)
+	}
+	if rec := serve(m, DefaultHeader, "key-acme"); rec.Code != http.StatusOK {
+		t.Errorf("known key status = %d, want 200", rec.Code)
+	}
+	// Requests without a key are metered by address
+	if rec := serve(m, "", ""); rec.Code != http.StatusOK {
+		t.Errorf("anonymous status = %d, want 200", rec.Code)
+	}
+
+	want := map[counter]int64{
+		{client: "acme", day: "2026-10-16"}:         1,
+		{client: "ip:192.0.2.1", day: "2026-10-16"}: 1,
+	}
+	if len(m.pending) != len(want) {
+		t.Fatalf("pending = %v, want %v", m.pending, want)
+	}
+	for c, n := range want {
+		if m.pending[c] != n {
+			t.Errorf("pending[%v] = %d, want %d", c, m.pending[c], n)
+		}
+	}
+}
+
+func TestMiddleware_UserTokens(t *testing.T) {
+	m, _ := newTestMeter(t, Config{Client: ClientUser})
+
+	token, err := auth.NewAuthService("secret", time.Hour).GenerateToken("user-1", "ada@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := serve(m, "Authorization", "Bearer "+token); rec.Code != http.StatusOK {
+		t.Errorf("valid token status = %d, want 200", rec.Code)
+	}
+	if m.pending[counter{client: "user-1", day: "2026-10-16"}] != 1 {
+		t.Errorf("pending = %v, want a request by user-1", m.pending)
+	}
+
+	forged, _ := auth.NewAuthService("other", time.Hour).GenerateToken("user-2", "", nil)
+	if rec := serve(m, "Authorization", "Bearer "+forged); rec.Code != http.StatusUnauthorized {
+		t.Errorf("forged token status = %d, want 401", rec.Code)
+	}
+}
+
+func TestMiddleware_RateLimit(t *testing.T) {
+	m, _ := newTestMeter(t, Config{
+		Client:  ClientAPIKey,
+		Limits:  Limits{RateLimit: 2},
+		Clients: map[string]Limits{"beta": {RateLimit: 5}},
+	})
+
+	for i := 0; i < 2; i++ {
+		if rec := serve(m, DefaultHeader, "key-acme"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want 200", i+1, rec.Code)
+		}
+	}
+	rec := serve(m, DefaultHeader, "key-acme")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" || rec.Header().Get("X-RateLimit-Limit") != "2" {
+		t.Errorf("rate limit headers = %v", rec.Header())
+	}
+
+	// Clients with their own limit are limited separately
+	rec = serve(m, DefaultHeader, "key-beta")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "5" {
+		t.Errorf("beta = %d with limit %q, want 200 with limit 5", rec.Code, rec.Header().Get("X-RateLimit-Limit"))
+	}
+
+	// Rejected requests are not counted
+	if n := m.pending[counter{client: "acme", day: "2026-10-16"}]; n != 2 {
+		t.Errorf("acme requests = %d, want 2", n)
+	}
+}
+
+func TestMiddleware_MonthlyQuota(t *testing.T) {
+	m, mock := newTestMeter(t, Config{Client: ClientAPIKey, Limits: Limits{Monthly: 100}})
+
+	mock.ExpectQuery(monthlyQuery).WithArgs("acme", time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"requests"}).AddRow(98))
+
+	rec := serve(m, DefaultHeader, "key-acme")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Quota-Remaining") != "1" {
+		t.Fatalf("first = %d with %q remaining, want 200 with 1", rec.Code, rec.Header().Get("X-Quota-Remaining"))
+	}
+	if rec := serve(m, DefaultHeader, "key-acme"); rec.Code != http.StatusOK {
+		t.Fatalf("second status = %d, want 200", rec.Code)
+	}
+
+	rec = serve(m, DefaultHeader, "key-acme")
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("status = %d, want 402", rec.Code)
+	}
+	if rec.Header().Get("X-Quota-Remaining") != "0" || rec.Header().Get("X-Quota-Limit") != "100" {
+		t.Errorf("quota headers = %v", rec.Header())
+	}
+	if reset := rec.Header().Get("X-Quota-Reset"); reset != "1793491200" {
+		t.Errorf("X-Quota-Reset = %s, want the start of November", reset)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	m, mock := newTestMeter(t, Config{Client: ClientAPIKey, Limits: Limits{Monthly: 100}})
+	m.record("acme")
+	m.record("acme")
+
+	day := time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+	month := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectExec(dailyUpsert).WithArgs("acme", day, int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(monthlyUpsert).WithArgs("acme", month, int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"requests"}).AddRow(40))
+	mock.ExpectCommit()
+
+	if err := m.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if len(m.pending) != 0 {
+		t.Errorf("pending after flush = %v", m.pending)
+	}
+
+	// The rollup's total includes other instances' requests
+	used, err := m.Used(context.Background(), "acme")
+	if err != nil || used != 40 {
+		t.Errorf("Used() = %d, %v; want 40", used, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFlush_KeepsCountsOnError(t *testing.T) {
+	m, mock := newTestMeter(t, Config{Client: ClientAPIKey})
+	m.record("acme")
+
+	mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+	if err := m.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() should fail")
+	}
+	if n := m.pending[counter{client: "acme", day: "2026-10-16"}]; n != 1 {
+		t.Errorf("pending = %d, want the request kept for the next flush", n)
+	}
+}
+
+func TestUsageHandler(t *testing.T) {
+	m, mock := newTestMeter(t, Config{
+		Client:  ClientAPIKey,
+		Limits:  Limits{Monthly: 100, RateLimit: 60},
+		Clients: map[string]Limits{"acme": {Monthly: 1000}},
+	})
+
+	start := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT day, requests FROM usage_daily WHERE client = $1 AND day >= $2 ORDER BY day")).
+		WithArgs("acme", start).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "requests"}).
+			AddRow(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC), 30).
+			AddRow(time.Date(2026, time.October, 2, 0, 0, 0, 0, time.UTC), 12))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT month, requests FROM usage_monthly WHERE client = $1 AND month >= $2 ORDER BY month DESC")).
+		WithArgs("acme", time.Date(2025, time.November, 1, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"month", "requests"}).
+			AddRow(start, 42).
+			AddRow(time.Date(2026, time.September, 1, 0, 0, 0, 0, time.UTC), 870))
+
+	req := httptest.NewRequest("GET", "/usage", nil)
+	req.Header.Set(DefaultHeader, "key-acme")
+	rec := httptest.NewRecorder()
+	m.UsageHandler()(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var usage Usage
+	if err := json.NewDecoder(rec.Body).Decode(&usage); err != nil {
+		t.Fatal(err)
+	}
+	if usage.Client != "acme" || usage.Month != "2026-10" || usage.Requests != 42 || usage.Limit != 1000 {
+		t.Errorf("usage = %+v", usage)
+	}
+	if usage.Remaining == nil || *usage.Remaining != 958 {
+		t.Errorf("remaining = %v, want 958", usage.Remaining)
+	}
+	if usage.RateLimit != 0 {
+		t.Errorf("rate_limit = %d, want acme's unlimited rate", usage.RateLimit)
+	}
+	if len(usage.Daily) != 2 || usage.Daily[1] != (DailyUsage{Date: "2026-10-02", Requests: 12}) {
+		t.Errorf("daily = %+v", usage.Daily)
+	}
+	if len(usage.Monthly) != 2 || usage.Monthly[1] != (MonthUsage{Month: "2026-09", Requests: 870}) {
+		t.Errorf("monthly = %+v", usage.Monthly)
+	}
+	if !usage.ResetsAt.Equal(time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("resets_at = %v", usage.ResetsAt)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,202 @@
+package quota
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Usage is a client's usage as reported by GET /usage.
+type Usage struct {
+	Client    string       `json:"client"`
+	Month     string       `json:"month"` // Current month (UTC), 2006-01
+	Requests  int64        `json:"requests"`
+	Limit     int64        `json:"limit,omitempty"`     // Monthly quota; omitted when unlimited
+	Remaining *int64       `json:"remaining,omitempty"` // Omitted when unlimited
+	RateLimit int          `json:"rate_limit,omitempty"`
+	ResetsAt  time.Time    `json:"resets_at"`
+	Daily     []DailyUsage `json:"daily"`   // Days of the current month
+	Monthly   []MonthUsage `json:"monthly"` // The last HistoryMonths months, newest first
+}
+
+// DailyUsage is a client's requests on a day (UTC).
+type DailyUsage struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+}
+
+// MonthUsage is a client's requests in a month (UTC).
+type MonthUsage struct {
+	Month    string `json:"month"`
+	Requests int64  `json:"requests"`
+}
+
+// CreateTables creates the usage_daily counters and the usage_monthly rollup.
+func CreateTables(ctx context.Context, db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS usage_daily (
+			client VARCHAR(255) NOT NULL,
+			day DATE NOT NULL,
+			requests BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (client, day)
+		)`,
+		`CREATE TABLE IF NOT EXISTS usage_monthly (
+			client VARCHAR(255) NOT NULL,
+			month DATE NOT NULL,
+			requests BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (client, month)
+		)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create usage tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// Report returns the client's usage, including requests not yet flushed.
+func (m *Meter) Report(ctx context.Context, client string) (*Usage, error) {
+	if err := m.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	now := m.now().UTC()
+	limits := m.limits(client)
+	usage := &Usage{
+		Client:    client,
+		Month:     monthOf(now),
+		Limit:     limits.Monthly,
+		RateLimit: limits.RateLimit,
+		ResetsAt:  nextMonth(now),
+		Daily:     []DailyUsage{},
+		Monthly:   []MonthUsage{},
+	}
+
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	rows, err := m.db.QueryContext(ctx,
+		"SELECT day, requests FROM usage_daily WHERE client = $1 AND day >= $2 ORDER BY day",
+		client, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var day time.Time
+		var requests int64
+		if err := rows.Scan(&day, &requests); err != nil {
+			return nil, fmt.Errorf("failed to read usage: %w", err)
+		}
+		usage.Daily = append(usage.Daily, DailyUsage{Date: day.Format("2006-01-02"), Requests: requests})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+
+	rows, err = m.db.QueryContext(ctx,
+		"SELECT month, requests FROM usage_monthly WHERE client = $1 AND month >= $2 ORDER BY month DESC",
+		client, start.AddDate(0, 1-HistoryMonths, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var month time.Time
+		var requests int64
+		if err := rows.Scan(&month, &requests); err != nil {
+			return nil, fmt.Errorf("failed to read usage: %w", err)
+		}
+		usage.Monthly = append(usage.Monthly, MonthUsage{Month: monthOf(month), Requests: requests})
+		if monthOf(month) == usage.Month {
+			usage.Requests = requests
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+
+	if limits.Monthly > 0 {
+		remaining := limits.Monthly - usage.Requests
+		if remaining < 0 {
+			remaining = 0
+		}
+		usage.Remaining = &remaining
+	}
+	return usage, nil
+}
+
+// UsageHandler returns the handler for GET /usage, which reports the
+// requesting client's usage. Reading usage is not metered.
+func (m *Meter) UsageHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client, rejected := m.identify(r)
+		if rejected != "" {
+			respondWithError(w, rejected, http.StatusUnauthorized)
+			return
+		}
+
+		usage, err := m.Report(r.Context(), client)
+		if err != nil {
+			respondWithError(w, "Failed to read usage", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(usage)
+	}
+}
+
+// addRequests adds requests to the client's counter for day and to its
+// monthly rollup, and returns the month's total across all instances
+func addRequests(ctx context.Context, db *sql.DB, client, day string, requests int64) (int64, error) {
+	date, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return 0, err
+	}
+	month := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO usage_daily (client, day, requests) VALUES ($1, $2, $3)
+		ON CONFLICT (client, day) DO UPDATE SET requests = usage_daily.requests + EXCLUDED.requests`,
+		client, date, requests); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	if err := tx.QueryRowContext(ctx,
+		`INSERT INTO usage_monthly (client, month, requests) VALUES ($1, $2, $3)
+		ON CONFLICT (client, month) DO UPDATE SET requests = usage_monthly.requests + EXCLUDED.requests
+		RETURNING requests`,
+		client, month, requests).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, tx.Commit()
+}
+
+// monthlyRequests returns the client's flushed requests in month (2006-01)
+func monthlyRequests(ctx context.Context, db *sql.DB, client, month string) (int64, error) {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return 0, err
+	}
+	var requests int64
+	err = db.QueryRowContext(ctx,
+		"SELECT requests FROM usage_monthly WHERE client = $1 AND month = $2",
+		client, start).Scan(&requests)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read usage: %w", err)
+	}
+	return requests, nil
+}
@@ -0,0 +1,199 @@
+// Package readonly puts generated applications into read-only maintenance
+// mode, for example while a migration runs or the database fails over. In
+// read-only mode, requests that may write (POST, PUT, PATCH and DELETE) are
+// answered with 503 Service Unavailable and a configurable message, while
+// reads continue to be served.
+//
+// The mode is set at startup from the environment:
+//
+//	CONDUIT_READ_ONLY=true CONDUIT_READ_ONLY_MESSAGE="Upgrading, back at 14:00" ./build/app
+//
+// and switched at runtime on Path, authenticated with the bearer token in
+// CONDUIT_READ_ONLY_TOKEN:
+//
+//	curl -X PUT -H "Authorization: Bearer $TOKEN" \
+//	  -d '{"read_only": true, "message": "Failing over"}' localhost:8080/debug/read-only
+package readonly
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables read by FromEnv
+const (
+	// EnvVar starts the application in read-only mode when set to a true
+	// value such as "true" or "1"
+	EnvVar = "CONDUIT_READ_ONLY"
+	// MessageEnvVar replaces DefaultMessage in the responses to rejected writes
+	MessageEnvVar = "CONDUIT_READ_ONLY_MESSAGE"
+	// TokenEnvVar holds the bearer token that may switch the mode on Path;
+	// without it the mode can only be read there
+	TokenEnvVar = "CONDUIT_READ_ONLY_TOKEN"
+)
+
+// Path serves the mode (GET) and switches it (PUT)
+const Path = "/debug/read-only"
+
+// DefaultMessage is returned for rejected writes when no message is configured
+const DefaultMessage = "The service is in read-only maintenance mode; try again later"
+
+// RetryAfter is the Retry-After hint, in seconds, sent with rejected writes
+const RetryAfter = 60
+
+// State is the mode as served on Path
+type State struct {
+	ReadOnly bool       `json:"read_only"`
+	Message  string     `json:"message,omitempty"`
+	Since    *time.Time `json:"since,omitempty"` // When read-only mode was entered
+}
+
+// Switch holds the mode of an application. It is safe for concurrent use.
+type Switch struct {
+	mu       sync.RWMutex
+	readOnly bool
+	message  string
+	since    time.Time
+	token    []byte
+}
+
+// New creates a switch in read-write mode. Callers presenting token as a
+// bearer token may switch the mode on Path; an empty token disables switching.
+func New(token string) *Switch {
+	return &Switch{token: []byte(token)}
+}
+
+// FromEnv creates a switch configured by EnvVar, MessageEnvVar and TokenEnvVar
+func FromEnv() (*Switch, error) {
+	s := New(os.Getenv(TokenEnvVar))
+	if value := os.Getenv(EnvVar); value != "" {
+		readOnly, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("readonly: %s must be true or false, got: %s", EnvVar, value)
+		}
+		if readOnly {
+			s.Enable(os.Getenv(MessageEnvVar))
+		}
+	}
+	return s, nil
+}
+
+// Enable enters read-only mode. An empty message uses DefaultMessage.
+// Enabling a switch that is already read-only only replaces its message.
+func (s *Switch) Enable(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if message == "" {
+		message = DefaultMessage
+	}
+	if !s.readOnly {
+		s.since = time.Now().UTC()
+	}
+	s.readOnly = true
+	s.message = message
+}
+
+// Disable leaves read-only mode
+func (s *Switch) Disable() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readOnly = false
+	s.message = ""
+	s.since = time.Time{}
+}
+
+// State returns the current mode
+func (s *Switch) State() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state := State{ReadOnly: s.readOnly, Message: s.message}
+	if s.readOnly {
+		since := s.since
+		state.Since = &since
+	}
+	return state
+}
+
+// Middleware rejects requests that may write while the switch is read-only.
+// Requests to Path pass, so the mode can always be switched back.
+func (s *Switch) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if safe(r.Method) || r.URL.Path == Path {
+			next.ServeHTTP(w, r)
+			return
+		}
+		state := s.State()
+		if !state.ReadOnly {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(RetryAfter))
+		respondWithError(w, state.Message, http.StatusServiceUnavailable)
+	})
+}
+
+// Handler serves the mode on GET and switches it on PUT with a JSON State body
+func (s *Switch) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut:
+			if len(s.token) == 0 {
+				respondWithError(w, fmt.Sprintf("switching read-only mode is disabled; set %s", TokenEnvVar), http.StatusForbidden)
+				return
+			}
+			if !s.authenticated(r) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				respondWithError(w, "invalid or missing bearer token", http.StatusUnauthorized)
+				return
+			}
+			var body State
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				respondWithError(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+				return
+			}
+			if body.ReadOnly {
+				s.Enable(body.Message)
+			} else {
+				s.Disable()
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			respondWithError(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(s.State())
+	}
+}
+
+// authenticated reports whether r carries the switch's bearer token
+func (s *Switch) authenticated(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), s.token) == 1
+}
+
+// safe reports whether requests with method never write
+func safe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// respondWithError writes a JSON error response
+func respondWithError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
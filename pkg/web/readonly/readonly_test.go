@@ -0,0 +1,112 @@
+package readonly
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	s := New("")
+	handler := s.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, "/posts"); rec.Code != http.StatusNoContent {
+		t.Errorf("POST in read-write mode = %d, want 204", rec.Code)
+	}
+
+	s.Enable("Upgrading, back at 14:00")
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		rec := serve(method, "/posts/1")
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s in read-only mode = %d, want 503", method, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "Upgrading, back at 14:00") || rec.Header().Get("Retry-After") == "" {
+			t.Errorf("%s response = %v %s", method, rec.Header(), rec.Body.String())
+		}
+	}
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+		if rec := serve(method, "/posts"); rec.Code != http.StatusNoContent {
+			t.Errorf("%s in read-only mode = %d, want 204", method, rec.Code)
+		}
+	}
+	if rec := serve(http.MethodPut, Path); rec.Code != http.StatusNoContent {
+		t.Errorf("PUT %s in read-only mode = %d, want 204", Path, rec.Code)
+	}
+
+	s.Disable()
+	if rec := serve(http.MethodDelete, "/posts/1"); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE after Disable() = %d, want 204", rec.Code)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	s := New("secret")
+	put := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, Path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler()(rec, req)
+		return rec
+	}
+
+	if rec := put("wrong", `{"read_only": true}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("PUT with a wrong token = %d, want 401", rec.Code)
+	}
+	if s.State().ReadOnly {
+		t.Fatal("an unauthenticated PUT switched the mode")
+	}
+
+	rec := put("secret", `{"read_only": true, "message": "Failing over"}`)
+	var state State
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatalf("PUT response %d %s: %v", rec.Code, rec.Body.String(), err)
+	}
+	if !state.ReadOnly || state.Message != "Failing over" || state.Since == nil {
+		t.Errorf("state after PUT = %+v", state)
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler()(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	if !strings.Contains(rec.Body.String(), `"read_only":true`) {
+		t.Errorf("GET = %s", rec.Body.String())
+	}
+
+	put("secret", `{"read_only": false}`)
+	if s.State().ReadOnly {
+		t.Error("PUT read_only false should leave read-only mode")
+	}
+
+	rec = httptest.NewRecorder()
+	New("").Handler()(rec, httptest.NewRequest(http.MethodPut, Path, strings.NewReader(`{"read_only": true}`)))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("PUT without a configured token = %d, want 403", rec.Code)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(EnvVar, "1")
+	t.Setenv(MessageEnvVar, "")
+	s, err := FromEnv()
+	if err != nil {
+		t.Fatalf("FromEnv() error = %v", err)
+	}
+	if state := s.State(); !state.ReadOnly || state.Message != DefaultMessage {
+		t.Errorf("state = %+v, want read-only with the default message", state)
+	}
+
+	t.Setenv(EnvVar, "maybe")
+	if _, err := FromEnv(); err == nil {
+		t.Error("FromEnv() should reject an invalid value")
+	}
+}
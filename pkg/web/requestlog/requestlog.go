@@ -0,0 +1,110 @@
+// Package requestlog writes one structured log record per request served by a
+// generated application, using log/slog. Records are JSON for log shippers
+// such as Datadog, or text for reading in a terminal:
+//
+//	{"time":"2026-10-17T09:12:03Z","level":"INFO","msg":"request","method":"GET","path":"/posts/42",
+//	 "route":"/posts/{id}","status":200,"latency_ms":3.2,"bytes":512,"request_id":"host/x1-000001",
+//	 "remote_addr":"10.0.0.7","resource":"Post","operation":"get"}
+//
+// The resource and operation are those generated handlers attribute their
+// queries to, named as in the route metadata. The format comes from
+// conduit.yaml's logging.format and can be overridden with CONDUIT_LOG_FORMAT.
+//
+// Example:
+//
+//	r.Use(middleware.RequestID)
+//	r.Use(requestlog.Middleware(requestlog.New(os.Stdout, requestlog.FormatFromEnv(requestlog.FormatJSON))))
+package requestlog
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/conduit-lang/conduit/pkg/web/instrument"
+)
+
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// FormatEnvVar overrides the configured format at runtime
+const FormatEnvVar = "CONDUIT_LOG_FORMAT"
+
+// Message is the message of every request record
+const Message = "request"
+
+// FormatFromEnv returns the format set in CONDUIT_LOG_FORMAT, or format when
+// the variable is unset or names no format
+func FormatFromEnv(format string) string {
+	switch value := strings.ToLower(strings.TrimSpace(os.Getenv(FormatEnvVar))); value {
+	case FormatText, FormatJSON:
+		return value
+	}
+	return format
+}
+
+// New returns a logger writing records to w as JSON for FormatJSON and as
+// text otherwise
+func New(w io.Writer, format string) *slog.Logger {
+	if format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(w, nil))
+	}
+	return slog.New(slog.NewTextHandler(w, nil))
+}
+
+// Middleware logs the method, path, route pattern, status, latency, response
+// size, request ID and client address of every request, with the resource
+// and operation its handler served. Server errors are logged at the error
+// level and client errors at the warning level. It must be installed on a chi
+// router after middleware.RequestID.
+func Middleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ctx, recorder := instrument.WithRecorder(r.Context())
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+			}
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				attrs = append(attrs, slog.String("route", rctx.RoutePattern()))
+			}
+			attrs = append(attrs,
+				slog.Int("status", status),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("remote_addr", r.RemoteAddr),
+			)
+			if resource, operation, ok := recorder.Operation(); ok {
+				attrs = append(attrs, slog.String("resource", resource), slog.String("operation", operation))
+			}
+
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+			logger.LogAttrs(r.Context(), level, Message, attrs...)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package requestlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/conduit-lang/conduit/pkg/web/instrument"
+)
+
+func TestMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(Middleware(New(&out, FormatJSON)))
+	r.Get("/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		instrument.WithOperation(r.Context(), "Post", "get")
+		w.Write([]byte(`{"data":{}}`))
+	})
+	r.Post("/posts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/42", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("record is not JSON: %v\n%s", err, out.String())
+	}
+	for key, want := range map[string]interface{}{
+		"level":       "INFO",
+		"msg":         Message,
+		"method":      "GET",
+		"path":        "/posts/42",
+		"route":       "/posts/{id}",
+		"status":      float64(200),
+		"bytes":       float64(11),
+		"request_id":  "req-1",
+		"remote_addr": req.RemoteAddr,
+		"resource":    "Post",
+		"operation":   "get",
+	} {
+		if record[key] != want {
+			t.Errorf("%s = %v, want %v", key, record[key], want)
+		}
+	}
+	if _, ok := record["latency_ms"].(float64); !ok {
+		t.Errorf("latency_ms = %v, want a number", record["latency_ms"])
+	}
+
+	// Client errors are warnings; handlers without an operation leave it out
+	out.Reset()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/posts", nil))
+	record = nil
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("record is not JSON: %v\n%s", err, out.String())
+	}
+	if record["level"] != "WARN" || record["status"] != float64(422) {
+		t.Errorf("level = %v, status = %v, want WARN, 422", record["level"], record["status"])
+	}
+	if _, ok := record["operation"]; ok {
+		t.Errorf("operation = %v, want none", record["operation"])
+	}
+}
+
+func TestNew_Text(t *testing.T) {
+	var out bytes.Buffer
+	New(&out, FormatText).Info(Message, "status", 200)
+	if !strings.Contains(out.String(), "msg=request status=200") {
+		t.Errorf("text record = %q", out.String())
+	}
+}
+
+func TestFormatFromEnv(t *testing.T) {
+	t.Setenv(FormatEnvVar, "")
+	if got := FormatFromEnv(FormatJSON); got != FormatJSON {
+		t.Errorf("FormatFromEnv() unset = %q, want json", got)
+	}
+	t.Setenv(FormatEnvVar, " TEXT ")
+	if got := FormatFromEnv(FormatJSON); got != FormatText {
+		t.Errorf("FormatFromEnv() = %q, want text", got)
+	}
+	t.Setenv(FormatEnvVar, "xml")
+	if got := FormatFromEnv(FormatJSON); got != FormatJSON {
+		t.Errorf("FormatFromEnv() invalid = %q, want json", got)
+	}
+}
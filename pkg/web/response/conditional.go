@@ -0,0 +1,54 @@
+package response
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NotModified handles a conditional GET. It sets Last-Modified (unless
+// lastModified is zero) and ETag (unless etag is empty), then writes
+// 304 Not Modified and returns true when the client's copy is current.
+//
+// As in RFC 9110, If-None-Match takes precedence: If-Modified-Since is only
+// evaluated when the request has no If-None-Match header.
+func NotModified(w http.ResponseWriter, r *http.Request, lastModified time.Time, etag string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	// HTTP dates have one-second resolution
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if etag == "" || !etagMatches(match, etag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || lastModified.IsZero() || lastModified.After(since) {
+			return false
+		}
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the
+// weak comparison required for If-None-Match
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 30, 15, 500, time.UTC)
+	etag := `W/"3-1714566615000000500"`
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		etag    string
+		want    bool
+	}{
+		{name: "unconditional", want: false},
+		{name: "not modified since", headers: map[string]string{"If-Modified-Since": "Wed, 01 May 2024 12:30:15 GMT"}, want: true},
+		{name: "modified since", headers: map[string]string{"If-Modified-Since": "Wed, 01 May 2024 12:30:14 GMT"}, want: false},
+		{name: "invalid date", headers: map[string]string{"If-Modified-Since": "yesterday"}, want: false},
+		{name: "matching etag", headers: map[string]string{"If-None-Match": etag}, etag: etag, want: true},
+		{name: "strong form of weak etag", headers: map[string]string{"If-None-Match": `"3-1714566615000000500"`}, etag: etag, want: true},
+		{name: "etag in list", headers: map[string]string{"If-None-Match": `W/"1-1", ` + etag}, etag: etag, want: true},
+		{
+			name:    "stale etag wins over date",
+			headers: map[string]string{"If-None-Match": `W/"2-1714566615000000500"`, "If-Modified-Since": "Wed, 01 May 2024 12:30:15 GMT"},
+			etag:    etag,
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+
+			got := NotModified(rec, req, modified, tt.etag)
+			if got != tt.want {
+				t.Fatalf("NotModified() = %v, want %v", got, tt.want)
+			}
+			if got && rec.Code != http.StatusNotModified {
+				t.Errorf("status = %d, want 304", rec.Code)
+			}
+			if lm := rec.Header().Get("Last-Modified"); lm != "Wed, 01 May 2024 12:30:15 GMT" {
+				t.Errorf("Last-Modified = %q", lm)
+			}
+			if rec.Header().Get("ETag") != tt.etag {
+				t.Errorf("ETag = %q, want %q", rec.Header().Get("ETag"), tt.etag)
+			}
+		})
+	}
+}
+
+func TestNotModified_EmptyCollection(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	req.Header.Set("If-Modified-Since", "Wed, 01 May 2024 12:30:15 GMT")
+	rec := httptest.NewRecorder()
+
+	if NotModified(rec, req, time.Time{}, "") {
+		t.Error("an empty collection without an ETag should never be reported as not modified")
+	}
+	if rec.Header().Get("Last-Modified") != "" {
+		t.Error("Last-Modified should not be set without a modification time")
+	}
+}
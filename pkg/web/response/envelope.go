@@ -0,0 +1,16 @@
+package response
+
+// Enveloped wraps a plain JSON response body as {"data": body}, the shape of
+// responses of APIs built with serialization.envelope. Lists are wrapped with
+// ListStream.Wrap instead.
+//
+// Example:
+//
+//	json.NewEncoder(w).Encode(response.Enveloped(post))
+func Enveloped(body interface{}) interface{} {
+	return envelope{Data: body}
+}
+
+type envelope struct {
+	Data interface{} `json:"data"`
+}
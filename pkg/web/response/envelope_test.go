@@ -0,0 +1,21 @@
+package response
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnveloped(t *testing.T) {
+	post := struct {
+		ID    int    `json:"id"`
+		Title string `json:"title"`
+	}{1, "Hello"}
+
+	data, err := json.Marshal(Enveloped(Masked(post, []string{"title"})))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `{"data":{"title":"Hello"}}`; string(data) != want {
+		t.Errorf("Enveloped() = %s, want %s", data, want)
+	}
+}
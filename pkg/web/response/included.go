@@ -0,0 +1,241 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/DataDog/jsonapi"
+)
+
+// Included collects the related records of a compound JSON:API document, as
+// asked for with ?include=. Each related record is included once, however
+// many records refer to it, and records refer to it through the relationship
+// linkage Included adds to their resource objects.
+//
+// Records are pointers to generated models. A record loaded twice, such as a
+// user who is both the author of a post and the editor of a comment, is
+// identified by its type and id, so its relationships are merged.
+//
+// Example:
+//
+//	included := response.NewIncluded(query.ParseFields(r))
+//	for _, post := range posts {
+//		if err := included.ToOne(post, "author", authors[post.AuthorID]); err != nil {
+//			...
+//		}
+//	}
+//	stream.Include(included)
+type Included struct {
+	fieldsets map[string][]string
+	masks     map[string][]string
+	ids       map[interface{}]resourceIdentifier
+	resources map[resourceIdentifier]map[string]interface{}
+	order     []resourceIdentifier
+	links     map[resourceIdentifier]map[string]interface{}
+}
+
+// resourceIdentifier identifies a resource object in a document
+type resourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// NewIncluded creates an Included applying the sparse fieldsets of
+// ParseFields to the included records. Fieldsets name attributes as they
+// appear in responses.
+func NewIncluded(fieldsets map[string][]string) *Included {
+	return &Included{
+		fieldsets: fieldsets,
+		masks:     make(map[string][]string),
+		ids:       make(map[interface{}]resourceIdentifier),
+		resources: make(map[resourceIdentifier]map[string]interface{}),
+		links:     make(map[resourceIdentifier]map[string]interface{}),
+	}
+}
+
+// Mask limits the included records of a JSON:API type to the given fields,
+// e.g. the fields of the caller's serialization profile for that resource;
+// nil shows every field.
+func (inc *Included) Mask(resourceType string, fields []string) {
+	if fields == nil {
+		delete(inc.masks, resourceType)
+		return
+	}
+	inc.masks[resourceType] = fields
+}
+
+// ToOne sets the to-one relationship name of record to related, which is
+// included. A nil related sets the relationship to null.
+func (inc *Included) ToOne(record interface{}, name string, related interface{}) error {
+	id, err := inc.identify(record)
+	if err != nil {
+		return err
+	}
+	if related == nil {
+		inc.link(id, name, nil)
+		return nil
+	}
+	target, err := inc.add(related)
+	if err != nil {
+		return err
+	}
+	inc.link(id, name, target)
+	return nil
+}
+
+// ToMany sets the to-many relationship name of record to related, which are
+// included. No related records set the relationship to an empty list.
+func (inc *Included) ToMany(record interface{}, name string, related []interface{}) error {
+	id, err := inc.identify(record)
+	if err != nil {
+		return err
+	}
+	linkage := make([]resourceIdentifier, 0, len(related))
+	for _, item := range related {
+		target, err := inc.add(item)
+		if err != nil {
+			return err
+		}
+		linkage = append(linkage, target)
+	}
+	inc.link(id, name, linkage)
+	return nil
+}
+
+// Len returns the number of included records.
+func (inc *Included) Len() int {
+	return len(inc.order)
+}
+
+// link sets the linkage of a relationship of the record identified by id
+func (inc *Included) link(id resourceIdentifier, name string, linkage interface{}) {
+	if inc.links[id] == nil {
+		inc.links[id] = make(map[string]interface{})
+	}
+	if linkage == nil {
+		inc.links[id][name] = map[string]interface{}{"data": nil}
+		return
+	}
+	inc.links[id][name] = map[string]interface{}{"data": linkage}
+}
+
+// add includes a related record once
+func (inc *Included) add(record interface{}) (resourceIdentifier, error) {
+	id, err := inc.identify(record)
+	if err != nil {
+		return id, err
+	}
+	if _, ok := inc.resources[id]; ok {
+		return id, nil
+	}
+	resource, err := marshalResourceObject(record)
+	if err != nil {
+		return id, err
+	}
+	inc.resources[id] = resource
+	inc.order = append(inc.order, id)
+	return id, nil
+}
+
+// identify returns the type and id of a record, marshaling it once
+func (inc *Included) identify(record interface{}) (resourceIdentifier, error) {
+	if id, ok := inc.ids[record]; ok {
+		return id, nil
+	}
+	resource, err := marshalResourceObject(record)
+	if err != nil {
+		return resourceIdentifier{}, err
+	}
+	id := identifierOf(resource)
+	inc.ids[record] = id
+	return id, nil
+}
+
+// relate adds the relationships of the record a resource object describes
+func (inc *Included) relate(resource map[string]interface{}) {
+	relationships := inc.links[identifierOf(resource)]
+	if len(relationships) == 0 {
+		return
+	}
+	members, _ := resource["relationships"].(map[string]interface{})
+	if members == nil {
+		members = make(map[string]interface{}, len(relationships))
+	}
+	for name, linkage := range relationships {
+		members[name] = linkage
+	}
+	resource["relationships"] = members
+}
+
+// objects returns the included resource objects, leaving out the records of
+// primary data, which a compound document must not repeat
+func (inc *Included) objects(primary map[resourceIdentifier]bool) []map[string]interface{} {
+	objects := make([]map[string]interface{}, 0, len(inc.order))
+	for _, id := range inc.order {
+		if primary[id] {
+			continue
+		}
+		resource := inc.resources[id]
+		filterResource(resource, inc.fieldsets)
+		if fields, ok := inc.masks[id.Type]; ok {
+			maskAttributes(resource, fields)
+		}
+		inc.relate(resource)
+		objects = append(objects, resource)
+	}
+	return objects
+}
+
+// marshalResourceObject returns the JSON:API resource object of a record
+func marshalResourceObject(record interface{}) (map[string]interface{}, error) {
+	document, err := jsonapi.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode included record: %w", err)
+	}
+	var single struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(document, &single); err != nil {
+		return nil, err
+	}
+	if single.Data == nil {
+		return nil, fmt.Errorf("failed to encode included record: %T is not a resource", record)
+	}
+	return single.Data, nil
+}
+
+// identifierOf returns the type and id of a resource object
+func identifierOf(resource map[string]interface{}) resourceIdentifier {
+	resourceType, _ := resource["type"].(string)
+	id, _ := resource["id"].(string)
+	return resourceIdentifier{Type: resourceType, ID: id}
+}
+
+// RenderJSONAPIIncluded renders a single record as a compound JSON:API
+// document: the record with its relationships, and the related records of
+// included. Like RenderJSONAPIMasked, only the attributes named by fields are
+// kept, or all of them when fields is nil.
+func RenderJSONAPIIncluded(w http.ResponseWriter, status int, payload interface{}, fields []string, included *Included) error {
+	resource, err := marshalResourceObject(payload)
+	if err != nil {
+		return err
+	}
+	if fields != nil {
+		maskAttributes(resource, fields)
+	}
+	included.relate(resource)
+
+	data, err := json.Marshal(map[string]interface{}{
+		"data":     resource,
+		"included": included.objects(map[resourceIdentifier]bool{identifierOf(resource): true}),
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", JSONAPIMediaType)
+	w.WriteHeader(status)
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,219 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type compoundDocument struct {
+	Data     []map[string]interface{} `json:"data"`
+	Included []map[string]interface{} `json:"included"`
+}
+
+func relationshipData(t *testing.T, resource map[string]interface{}, name string) interface{} {
+	t.Helper()
+	relationships, ok := resource["relationships"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("resource has no relationships: %v", resource)
+	}
+	relationship, ok := relationships[name].(map[string]interface{})
+	if !ok {
+		t.Fatalf("resource has no relationship %s: %v", name, relationships)
+	}
+	data, ok := relationship["data"]
+	if !ok {
+		t.Fatalf("relationship %s has no data: %v", name, relationship)
+	}
+	return data
+}
+
+func TestListStream_Include(t *testing.T) {
+	maker := &TestUser{ID: "u1", Name: "Ada"}
+	products := []*TestProduct{
+		{ID: "1", Name: "Widget", Price: 9.5},
+		{ID: "2", Name: "Gadget", Price: 3},
+		{ID: "3", Name: "Gizmo", Price: 1},
+	}
+
+	included := NewIncluded(nil)
+	for _, product := range products[:2] {
+		// Separately loaded copies of the same user are included once
+		if err := included.ToOne(product, "maker", &TestUser{ID: maker.ID, Name: maker.Name}); err != nil {
+			t.Fatalf("ToOne() error = %v", err)
+		}
+	}
+	if err := included.ToOne(products[2], "maker", nil); err != nil {
+		t.Fatalf("ToOne() error = %v", err)
+	}
+	// Relationships back to primary data link without repeating the record
+	if err := included.ToMany(maker, "products", []interface{}{products[0], products[1]}); err != nil {
+		t.Fatalf("ToMany() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	stream := NewListStream(rec, newListRequest(true), nil)
+	stream.Include(included)
+	for _, product := range products {
+		if err := stream.Write(product); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := stream.Close(map[string]interface{}{"total": 3}, nil); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var doc compoundDocument
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("response is not valid JSON: %v\n%s", err, rec.Body.String())
+	}
+	if len(doc.Data) != 3 {
+		t.Fatalf("len(data) = %d, want 3", len(doc.Data))
+	}
+	linkage, ok := relationshipData(t, doc.Data[0], "maker").(map[string]interface{})
+	if !ok || linkage["type"] != "test_users" || linkage["id"] != "u1" {
+		t.Errorf("maker linkage = %v, want test_users u1", linkage)
+	}
+	if data := relationshipData(t, doc.Data[2], "maker"); data != nil {
+		t.Errorf("empty to-one linkage = %v, want null", data)
+	}
+
+	if len(doc.Included) != 1 {
+		t.Fatalf("included = %v, want only the maker", doc.Included)
+	}
+	user := doc.Included[0]
+	if user["type"] != "test_users" || user["id"] != "u1" {
+		t.Errorf("included = %v, want test_users u1", user)
+	}
+	if items, ok := relationshipData(t, user, "products").([]interface{}); !ok || len(items) != 2 {
+		t.Errorf("products linkage = %v, want two products", relationshipData(t, user, "products"))
+	}
+}
+
+func TestListStream_IncludeEmpty(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := NewListStream(rec, newListRequest(true), nil)
+	stream.Include(NewIncluded(nil))
+	if err := stream.Close(nil, nil); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got, want := rec.Body.String(), "{\"data\":[],\"included\":[]}\n"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestListStream_IncludePlainJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := NewListStream(rec, newListRequest(false), nil)
+	included := NewIncluded(nil)
+	product := &TestProduct{ID: "1", Name: "Widget"}
+	if err := included.ToOne(product, "maker", &TestUser{ID: "u1"}); err != nil {
+		t.Fatalf("ToOne() error = %v", err)
+	}
+	stream.Include(included)
+	if err := stream.Write(product); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := stream.Close(nil, nil); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var products []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &products); err != nil {
+		t.Fatalf("response is not a JSON array: %v\n%s", err, rec.Body.String())
+	}
+	if _, ok := products[0]["relationships"]; ok {
+		t.Errorf("plain JSON record has relationships: %v", products[0])
+	}
+}
+
+func TestIncluded_ToMany(t *testing.T) {
+	user := &TestUser{ID: "u1", Name: "Ada"}
+	included := NewIncluded(nil)
+	if err := included.ToMany(user, "products", nil); err != nil {
+		t.Fatalf("ToMany() error = %v", err)
+	}
+	if err := included.ToMany(user, "favorites", []interface{}{
+		&TestProduct{ID: "1", Name: "Widget"},
+		&TestProduct{ID: "2", Name: "Gadget"},
+		&TestProduct{ID: "1", Name: "Widget"},
+	}); err != nil {
+		t.Fatalf("ToMany() error = %v", err)
+	}
+	if included.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", included.Len())
+	}
+
+	rec := httptest.NewRecorder()
+	if err := RenderJSONAPIIncluded(rec, http.StatusOK, user, nil, included); err != nil {
+		t.Fatalf("RenderJSONAPIIncluded() error = %v", err)
+	}
+	var doc struct {
+		Data     map[string]interface{}   `json:"data"`
+		Included []map[string]interface{} `json:"included"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("response is not valid JSON: %v\n%s", err, rec.Body.String())
+	}
+	if items, ok := relationshipData(t, doc.Data, "products").([]interface{}); !ok || len(items) != 0 {
+		t.Errorf("empty to-many linkage = %v, want []", relationshipData(t, doc.Data, "products"))
+	}
+	if items, ok := relationshipData(t, doc.Data, "favorites").([]interface{}); !ok || len(items) != 3 {
+		t.Errorf("favorites linkage = %v, want three identifiers", relationshipData(t, doc.Data, "favorites"))
+	}
+	if len(doc.Included) != 2 {
+		t.Errorf("included = %v, want two products", doc.Included)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != JSONAPIMediaType {
+		t.Errorf("Content-Type = %q, want %q", ct, JSONAPIMediaType)
+	}
+}
+
+func TestIncluded_FieldsetsAndMask(t *testing.T) {
+	product := &TestProduct{ID: "1", Name: "Widget", Price: 9.5}
+	included := NewIncluded(map[string][]string{"test_products": {"price"}})
+	included.Mask("test_users", []string{"id"})
+	user := &TestUser{ID: "u1", Name: "Ada"}
+	if err := included.ToMany(user, "products", []interface{}{product}); err != nil {
+		t.Fatalf("ToMany() error = %v", err)
+	}
+	if err := included.ToOne(product, "maker", user); err != nil {
+		t.Fatalf("ToOne() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := RenderJSONAPIIncluded(rec, http.StatusOK, &TestProduct{ID: "2", Name: "Gadget", Price: 3}, []string{"name"}, included); err != nil {
+		t.Fatalf("RenderJSONAPIIncluded() error = %v", err)
+	}
+	var doc struct {
+		Data     map[string]interface{}   `json:"data"`
+		Included []map[string]interface{} `json:"included"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("response is not valid JSON: %v\n%s", err, rec.Body.String())
+	}
+	attributes := doc.Data["attributes"].(map[string]interface{})
+	if _, ok := attributes["price"]; ok {
+		t.Errorf("masked primary attributes = %v, want name only", attributes)
+	}
+	if len(doc.Included) != 2 {
+		t.Fatalf("included = %v, want the product and the user", doc.Included)
+	}
+	for _, resource := range doc.Included {
+		attributes, _ := resource["attributes"].(map[string]interface{})
+		switch resource["type"] {
+		case "test_products":
+			if _, ok := attributes["name"]; ok || attributes["price"] == nil {
+				t.Errorf("included product attributes = %v, want price only", attributes)
+			}
+			if relationshipData(t, resource, "maker") == nil {
+				t.Errorf("included product lost its maker linkage")
+			}
+		case "test_users":
+			if _, ok := attributes["name"]; ok {
+				t.Errorf("masked user attributes = %v, want none", attributes)
+			}
+		}
+	}
+}
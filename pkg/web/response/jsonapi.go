@@ -104,6 +104,26 @@ func BuildPaginationLinks(baseURL string, page, perPage, total int) *jsonapi.Lin
 	return links
 }
 
+// BuildPaginationLinksWithoutTotal creates pagination links when the total count is unknown,
+// such as for resources using the "none" count strategy. The last link is omitted and
+// next is only included when hasNext is true (typically when a full page was returned).
+func BuildPaginationLinksWithoutTotal(baseURL string, page, perPage int, hasNext bool) *jsonapi.Link {
+	links := &jsonapi.Link{
+		Self:  buildPageURL(baseURL, page, perPage),
+		First: buildPageURL(baseURL, 1, perPage),
+	}
+
+	if page > 1 {
+		links.Prev = buildPageURL(baseURL, page-1, perPage)
+	}
+
+	if hasNext {
+		links.Next = buildPageURL(baseURL, page+1, perPage)
+	}
+
+	return links
+}
+
 func buildPageURL(baseURL string, page, perPage int) string {
 	offset := (page - 1) * perPage
 
@@ -122,6 +142,50 @@ func buildPageURL(baseURL string, page, perPage int) string {
 	return u.String()
 }
 
+// BuildCursorLinks creates pagination links for keyset (cursor) pagination. baseURL is
+// the request URL, used as is for self. next and prev are the cursors of the adjacent
+// pages from query.PageCursors; a link is omitted when its cursor is empty.
+func BuildCursorLinks(baseURL string, perPage int, next, prev string) *jsonapi.Link {
+	links := &jsonapi.Link{
+		Self:  baseURL,
+		First: buildCursorURL(baseURL, perPage, "", ""),
+	}
+
+	if next != "" {
+		links.Next = buildCursorURL(baseURL, perPage, "page[after]", next)
+	}
+
+	if prev != "" {
+		links.Prev = buildCursorURL(baseURL, perPage, "page[before]", prev)
+	}
+
+	return links
+}
+
+// buildCursorURL replaces the page parameters of baseURL with a limit and, unless
+// param is empty, a cursor
+func buildCursorURL(baseURL string, perPage int, param, cursor string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		if param == "" {
+			return fmt.Sprintf("%s?page[limit]=%d", baseURL, perPage)
+		}
+		return fmt.Sprintf("%s?page[limit]=%d&%s=%s", baseURL, perPage, param, url.QueryEscape(cursor))
+	}
+
+	q := u.Query()
+	for _, key := range []string{"page[after]", "page[before]", "page[offset]", "offset"} {
+		q.Del(key)
+	}
+	q.Set("page[limit]", strconv.Itoa(perPage))
+	if param != "" {
+		q.Set(param, cursor)
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
 // escapeJSONPointer escapes special characters per RFC 6901
 func escapeJSONPointer(token string) string {
 	// Order matters: escape ~ before /
@@ -378,6 +378,75 @@ func TestBuildPaginationLinks(t *testing.T) {
 	})
 }
 
+func TestBuildPaginationLinksWithoutTotal(t *testing.T) {
+	t.Run("first page with more results", func(t *testing.T) {
+		links := BuildPaginationLinksWithoutTotal("/api/resources", 1, 10, true)
+
+		if links.Last != "" {
+			t.Errorf("Last link should be empty without a total, got %v", links.Last)
+		}
+
+		if links.Prev != "" {
+			t.Errorf("Prev link should be empty on first page, got %v", links.Prev)
+		}
+
+		if links.Next != "/api/resources?page%5Blimit%5D=10&page%5Boffset%5D=10" {
+			t.Errorf("Next link = %v, want /api/resources?page%%5Blimit%%5D=10&page%%5Boffset%%5D=10", links.Next)
+		}
+	})
+
+	t.Run("final page", func(t *testing.T) {
+		links := BuildPaginationLinksWithoutTotal("/api/resources", 2, 10, false)
+
+		if links.Prev != "/api/resources?page%5Blimit%5D=10&page%5Boffset%5D=0" {
+			t.Errorf("Prev link = %v, want /api/resources?page%%5Blimit%%5D=10&page%%5Boffset%%5D=0", links.Prev)
+		}
+
+		if links.Next != "" {
+			t.Errorf("Next link should be empty when there are no more results, got %v", links.Next)
+		}
+	})
+}
+
+func TestBuildCursorLinks(t *testing.T) {
+	t.Run("middle page", func(t *testing.T) {
+		base := "/api/posts?filter[status]=active&page[after]=WzFd&page[limit]=10"
+		links := BuildCursorLinks(base, 10, "WzIwXQ", "WzExXQ")
+
+		if links.Self != base {
+			t.Errorf("Self link = %v, want %v", links.Self, base)
+		}
+
+		if links.First != "/api/posts?filter%5Bstatus%5D=active&page%5Blimit%5D=10" {
+			t.Errorf("First link = %v", links.First)
+		}
+
+		if links.Next != "/api/posts?filter%5Bstatus%5D=active&page%5Bafter%5D=WzIwXQ&page%5Blimit%5D=10" {
+			t.Errorf("Next link = %v", links.Next)
+		}
+
+		if links.Prev != "/api/posts?filter%5Bstatus%5D=active&page%5Bbefore%5D=WzExXQ&page%5Blimit%5D=10" {
+			t.Errorf("Prev link = %v", links.Prev)
+		}
+
+		if links.Last != "" {
+			t.Errorf("Last link should be empty for cursor pagination, got %v", links.Last)
+		}
+	})
+
+	t.Run("replaces offset and omits missing cursors", func(t *testing.T) {
+		links := BuildCursorLinks("/api/posts?page[offset]=20", 10, "", "")
+
+		if links.First != "/api/posts?page%5Blimit%5D=10" {
+			t.Errorf("First link = %v, want /api/posts?page%%5Blimit%%5D=10", links.First)
+		}
+
+		if links.Next != "" || links.Prev != "" {
+			t.Errorf("Next and Prev links should be empty, got %v and %v", links.Next, links.Prev)
+		}
+	})
+}
+
 // TestBuildPageURL verifies the internal buildPageURL function
 func TestBuildPageURL(t *testing.T) {
 	tests := []struct {
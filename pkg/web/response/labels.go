@@ -0,0 +1,63 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// EnumLabels are the display labels of an enum field's values, added to
+// records by Labeled
+type EnumLabels struct {
+	Field  string            // JSON key of the enum field, e.g. status
+	Key    string            // JSON key of its label, e.g. status_label
+	Labels map[string]string // Label by value; unlabeled values are shown as themselves
+}
+
+// Labeled returns record wrapped so that encoding/json adds the display label
+// of each enum field in labels. Null, absent and masked fields get no label,
+// and keys the record already has are left alone. A nil labels slice returns
+// record unchanged.
+//
+// Example:
+//
+//	json.NewEncoder(w).Encode(response.Labeled(post, postLabels.For(r)))
+func Labeled(record interface{}, labels []EnumLabels) interface{} {
+	if labels == nil {
+		return record
+	}
+	return labeled{record: record, labels: labels}
+}
+
+type labeled struct {
+	record interface{}
+	labels []EnumLabels
+}
+
+// MarshalJSON encodes the record and adds the label of each enum field.
+func (l labeled) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(l.record)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("labeled record is not a JSON object: %w", err)
+	}
+
+	for _, field := range l.labels {
+		var value *string
+		if err := json.Unmarshal(object[field.Field], &value); err != nil || value == nil {
+			continue
+		}
+		if _, exists := object[field.Key]; exists {
+			continue
+		}
+		label, ok := field.Labels[*value]
+		if !ok {
+			label = *value
+		}
+		object[field.Key], _ = json.Marshal(label)
+	}
+	return json.Marshal(object)
+}
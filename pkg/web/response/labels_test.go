@@ -0,0 +1,67 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type labeledPost struct {
+	Title  string  `json:"title"`
+	Status string  `json:"status"`
+	Kind   *string `json:"kind"`
+}
+
+var postLabels = []EnumLabels{
+	{Field: "status", Key: "status_label", Labels: map[string]string{"draft": "Draft"}},
+	{Field: "kind", Key: "kind_label", Labels: map[string]string{"note": "Note"}},
+}
+
+func TestLabeled(t *testing.T) {
+	tests := []struct {
+		name   string
+		record interface{}
+		labels []EnumLabels
+		want   string
+	}{
+		{"labeled value", labeledPost{Title: "Hello", Status: "draft"}, postLabels,
+			`{"kind":null,"status":"draft","status_label":"Draft","title":"Hello"}`},
+		{"unlabeled value", labeledPost{Title: "Hello", Status: "published"}, postLabels,
+			`{"kind":null,"status":"published","status_label":"published","title":"Hello"}`},
+		{"masked field", Masked(labeledPost{Title: "Hello", Status: "draft"}, []string{"title"}), postLabels,
+			`{"title":"Hello"}`},
+		{"no labels", labeledPost{Title: "Hello", Status: "draft"}, nil,
+			`{"title":"Hello","status":"draft","kind":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(Labeled(tt.record, tt.labels))
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+
+	// A key the record already has is not overwritten
+	data, _ := json.Marshal(Labeled(map[string]string{"status": "draft", "status_label": "Custom"}, postLabels))
+	if !strings.Contains(string(data), `"status_label":"Custom"`) {
+		t.Errorf("Marshal() = %s, want the record's own status_label", data)
+	}
+}
+
+func TestListStream_Label(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := NewListStream(rec, httptest.NewRequest("GET", "/posts", nil), nil)
+	stream.Label(postLabels)
+	stream.Write(labeledPost{Title: "Hello", Status: "draft"})
+	stream.Close(nil, nil)
+
+	if !strings.Contains(rec.Body.String(), `"status_label":"Draft"`) {
+		t.Errorf("list = %s, want status_label", rec.Body.String())
+	}
+}
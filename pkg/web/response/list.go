@@ -0,0 +1,235 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/DataDog/jsonapi"
+)
+
+// ListStream writes a list response one record at a time instead of marshaling
+// the full result set, so memory use stays flat regardless of the number of rows.
+// The format follows content negotiation: a JSON:API document when the request
+// accepts JSON:API, otherwise a plain JSON array.
+//
+// Nothing is written until the first record or Close, so errors raised before
+// then can still be reported with Fail as a normal error response.
+//
+// Example:
+//
+//	stream := NewListStream(w, r, fields)
+//	for rows.Next() {
+//		...
+//		if err := stream.Write(item); err != nil {
+//			return
+//		}
+//	}
+//	stream.Close(meta, links)
+type ListStream struct {
+	w         http.ResponseWriter
+	jsonapi   bool
+	fieldsets map[string][]string
+	visible   []string
+	labels    []EnumLabels
+	wrapped   bool
+	included  *Included
+	primary   map[resourceIdentifier]bool
+	started   bool
+	count     int
+}
+
+// NewListStream creates a ListStream for the request's negotiated format.
+// Sparse fieldsets apply to JSON:API responses only.
+func NewListStream(w http.ResponseWriter, r *http.Request, fieldsets map[string][]string) *ListStream {
+	return &ListStream{
+		w:         w,
+		jsonapi:   IsJSONAPI(r),
+		fieldsets: fieldsets,
+	}
+}
+
+// Mask limits every record written to the given fields, e.g. the fields of
+// the caller's serialization profile. It applies to both formats and narrows
+// sparse fieldsets further; nil shows every field.
+func (s *ListStream) Mask(fields []string) {
+	s.visible = fields
+}
+
+// Label adds the display labels of enum fields to every record written as
+// plain JSON, like Labeled; nil adds none.
+func (s *ListStream) Label(labels []EnumLabels) {
+	s.labels = labels
+}
+
+// Wrap writes a plain JSON list as an object with the records in data, like a
+// JSON:API document, so Close can add meta and links to it; e.g. for
+// ?explain=true, whose query plan is in meta, or for APIs built with
+// serialization.envelope. Call it before the first Write.
+func (s *ListStream) Wrap() {
+	s.wrapped = true
+}
+
+// Include makes the response a compound document: records written as
+// JSON:API get the relationships set in included, and Close adds its records
+// under included, leaving out those already in data. Plain JSON lists are
+// unaffected.
+func (s *ListStream) Include(included *Included) {
+	s.included = included
+	s.primary = make(map[resourceIdentifier]bool)
+}
+
+// Started reports whether any part of the response has been written.
+func (s *ListStream) Started() bool {
+	return s.started
+}
+
+// Count returns the number of records written so far.
+func (s *ListStream) Count() int {
+	return s.count
+}
+
+// Write encodes a single record and writes it to the response.
+func (s *ListStream) Write(record interface{}) error {
+	var data []byte
+	var err error
+	if s.jsonapi {
+		data, err = s.marshalResource(record)
+	} else {
+		data, err = json.Marshal(Labeled(Masked(record, s.visible), s.labels))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	s.start()
+	if s.count > 0 {
+		if _, err := s.w.Write([]byte(",")); err != nil {
+			return err
+		}
+	}
+	s.count++
+
+	_, err = s.w.Write(data)
+	return err
+}
+
+// Close ends the list. For JSON:API and wrapped responses meta and links are
+// written after the data member; they are ignored for plain JSON arrays.
+func (s *ListStream) Close(meta map[string]interface{}, links *jsonapi.Link) error {
+	s.start()
+
+	if !s.jsonapi && !s.wrapped {
+		_, err := s.w.Write([]byte("]\n"))
+		return err
+	}
+
+	if _, err := s.w.Write([]byte("]")); err != nil {
+		return err
+	}
+	if s.jsonapi && s.included != nil {
+		if err := s.writeMember("included", s.included.objects(s.primary)); err != nil {
+			return err
+		}
+	}
+	if meta != nil {
+		if err := s.writeMember("meta", meta); err != nil {
+			return err
+		}
+	}
+	if links != nil {
+		if err := s.writeMember("links", links); err != nil {
+			return err
+		}
+	}
+	_, err := s.w.Write([]byte("}\n"))
+	return err
+}
+
+// Fail reports err as a 500 response if nothing has been written yet. Once the
+// list has started the status can no longer change, so the response is left
+// incomplete and clients see a truncated document rather than a partial list
+// that looks valid.
+func (s *ListStream) Fail(err error) {
+	if s.started {
+		return
+	}
+	s.started = true
+
+	if s.jsonapi {
+		RenderJSONAPIError(s.w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.w.Header().Set("Content-Type", "application/json")
+	s.w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(s.w).Encode(map[string]string{"error": err.Error()})
+}
+
+// start writes the headers and the opening of the document once.
+func (s *ListStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+
+	if s.jsonapi {
+		s.w.Header().Set("Content-Type", JSONAPIMediaType)
+		s.w.WriteHeader(http.StatusOK)
+		s.w.Write([]byte(`{"data":[`))
+		return
+	}
+
+	s.w.Header().Set("Content-Type", "application/json")
+	s.w.WriteHeader(http.StatusOK)
+	if s.wrapped {
+		s.w.Write([]byte(`{"data":[`))
+		return
+	}
+	s.w.Write([]byte("["))
+}
+
+// marshalResource returns the JSON:API resource object for a single record,
+// with sparse fieldsets, the mask and included relationships applied.
+func (s *ListStream) marshalResource(record interface{}) ([]byte, error) {
+	document, err := jsonapi.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var single struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(document, &single); err != nil {
+		return nil, err
+	}
+	if len(s.fieldsets) == 0 && s.visible == nil && s.included == nil {
+		return single.Data, nil
+	}
+
+	var resource map[string]interface{}
+	if err := json.Unmarshal(single.Data, &resource); err != nil {
+		return nil, err
+	}
+	filterResource(resource, s.fieldsets)
+	if s.visible != nil {
+		maskAttributes(resource, s.visible)
+	}
+	if s.included != nil {
+		s.included.relate(resource)
+		s.primary[identifierOf(resource)] = true
+	}
+	return json.Marshal(resource)
+}
+
+func (s *ListStream) writeMember(name string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	if _, err := fmt.Fprintf(s.w, `,%q:`, name); err != nil {
+		return err
+	}
+	_, err = s.w.Write(data)
+	return err
+}
@@ -0,0 +1,253 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/jsonapi"
+)
+
+func newListRequest(jsonAPI bool) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/test_products", nil)
+	if jsonAPI {
+		req.Header.Set("Accept", JSONAPIMediaType)
+	}
+	return req
+}
+
+func TestListStream_JSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := NewListStream(rec, newListRequest(false), nil)
+
+	for _, product := range []*TestProduct{{ID: "1", Name: "Widget", Price: 9.5}, {ID: "2", Name: "Gadget", Price: 3}} {
+		if err := stream.Write(product); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := stream.Close(map[string]interface{}{"total": 2}, nil); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var products []TestProduct
+	if err := json.Unmarshal(rec.Body.Bytes(), &products); err != nil {
+		t.Fatalf("response is not a JSON array: %v\n%s", err, rec.Body.String())
+	}
+	if len(products) != 2 || products[1].Name != "Gadget" {
+		t.Errorf("products = %+v", products)
+	}
+	if stream.Count() != 2 {
+		t.Errorf("Count() = %d, want 2", stream.Count())
+	}
+}
+
+func TestListStream_JSONAPI(t *testing.T) {
+	rec := httptest.NewRecorder()
+	fields := map[string][]string{"test_products": {"name"}}
+	stream := NewListStream(rec, newListRequest(true), fields)
+
+	for _, product := range []*TestProduct{{ID: "1", Name: "Widget", Price: 9.5}, {ID: "2", Name: "Gadget", Price: 3}} {
+		if err := stream.Write(product); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	meta := map[string]interface{}{"page": 1, "count": "none"}
+	links := BuildPaginationLinksWithoutTotal("/test_products", 1, 2, true)
+	if err := stream.Close(meta, links); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != JSONAPIMediaType {
+		t.Errorf("Content-Type = %q, want %q", ct, JSONAPIMediaType)
+	}
+
+	var doc struct {
+		Data []struct {
+			ID         string                 `json:"id"`
+			Type       string                 `json:"type"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"data"`
+		Meta  map[string]interface{} `json:"meta"`
+		Links map[string]interface{} `json:"links"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("response is not valid JSON: %v\n%s", err, rec.Body.String())
+	}
+
+	if len(doc.Data) != 2 || doc.Data[0].Type != "test_products" || doc.Data[1].ID != "2" {
+		t.Errorf("data = %+v", doc.Data)
+	}
+	if _, ok := doc.Data[0].Attributes["price"]; ok {
+		t.Errorf("sparse fieldset not applied: %v", doc.Data[0].Attributes)
+	}
+	if doc.Meta["count"] != "none" {
+		t.Errorf("meta = %v", doc.Meta)
+	}
+	if doc.Links["next"] == nil {
+		t.Errorf("links = %v", doc.Links)
+	}
+}
+
+func TestListStream_Wrap(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := NewListStream(rec, newListRequest(false), nil)
+	stream.Wrap()
+
+	if err := stream.Write(&TestProduct{ID: "1", Name: "Widget"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := stream.Close(map[string]interface{}{"total": 1}, nil); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var doc struct {
+		Data []TestProduct          `json:"data"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("response is not a JSON object: %v\n%s", err, rec.Body.String())
+	}
+	if len(doc.Data) != 1 || doc.Data[0].Name != "Widget" {
+		t.Errorf("data = %+v", doc.Data)
+	}
+	if doc.Meta["total"] != float64(1) {
+		t.Errorf("meta = %v, want the total", doc.Meta)
+	}
+}
+
+func TestListStream_Empty(t *testing.T) {
+	for _, jsonAPI := range []bool{false, true} {
+		rec := httptest.NewRecorder()
+		stream := NewListStream(rec, newListRequest(jsonAPI), nil)
+		if err := stream.Close(nil, nil); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		want := "[]\n"
+		if jsonAPI {
+			want = `{"data":[]}` + "\n"
+		}
+		if rec.Body.String() != want {
+			t.Errorf("jsonapi=%v body = %q, want %q", jsonAPI, rec.Body.String(), want)
+		}
+	}
+}
+
+func TestListStream_Fail(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := NewListStream(rec, newListRequest(true), nil)
+	stream.Fail(errors.New("scan failed"))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if !strings.Contains(rec.Body.String(), "scan failed") {
+		t.Errorf("body = %s", rec.Body.String())
+	}
+
+	// After the list has started, Fail leaves the response untouched
+	rec = httptest.NewRecorder()
+	stream = NewListStream(rec, newListRequest(false), nil)
+	stream.Write(&TestProduct{ID: "1"})
+	stream.Fail(errors.New("scan failed"))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "scan failed") {
+		t.Errorf("Fail() after start wrote %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// discardResponseWriter is an http.ResponseWriter that drops the body, so the
+// benchmarks measure the memory held by the handler rather than the recorder.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (d *discardResponseWriter) Header() http.Header {
+	if d.header == nil {
+		d.header = make(http.Header)
+	}
+	return d.header
+}
+
+func (d *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+func (d *discardResponseWriter) WriteHeader(int) {}
+
+const exportRows = 100000
+
+func exportProduct(i int) *TestProduct {
+	return &TestProduct{ID: fmt.Sprint(i), Name: "Product", Price: float64(i)}
+}
+
+// liveHeap returns the bytes of reachable heap memory after a full collection.
+func liveHeap() uint64 {
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// BenchmarkListExport_Buffered builds a 100k-row export the way list handlers did
+// before streaming: collect every row, then marshal the whole document. The
+// live-heap-B/op metric is the memory held just before the response is written.
+func BenchmarkListExport_Buffered(b *testing.B) {
+	b.ReportAllocs()
+	var held uint64
+
+	for i := 0; i < b.N; i++ {
+		before := liveHeap()
+		results := make([]*TestProduct, 0)
+		for j := 0; j < exportRows; j++ {
+			results = append(results, exportProduct(j))
+		}
+		data, err := jsonapi.Marshal(results, jsonapi.MarshalMeta(map[string]interface{}{"page": 1}))
+		if err != nil {
+			b.Fatal(err)
+		}
+		held += liveHeap() - before
+
+		w := &discardResponseWriter{}
+		w.Write(data)
+		runtime.KeepAlive(results)
+	}
+
+	b.ReportMetric(float64(held)/float64(b.N), "live-heap-B/op")
+}
+
+// BenchmarkListExport_Streamed writes the same export one row at a time with
+// ListStream. live-heap-B/op is the memory held after the last row is written.
+func BenchmarkListExport_Streamed(b *testing.B) {
+	req := newListRequest(true)
+	b.ReportAllocs()
+	var held uint64
+
+	for i := 0; i < b.N; i++ {
+		before := liveHeap()
+		stream := NewListStream(&discardResponseWriter{}, req, nil)
+		for j := 0; j < exportRows; j++ {
+			if err := stream.Write(exportProduct(j)); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if after := liveHeap(); after > before {
+			held += after - before
+		}
+
+		if err := stream.Close(map[string]interface{}{"page": 1}, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(held)/float64(b.N), "live-heap-B/op")
+}
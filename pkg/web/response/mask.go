@@ -0,0 +1,102 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/DataDog/jsonapi"
+)
+
+// Masked returns record wrapped so that encoding/json writes only the given
+// fields, e.g. the fields of the caller's serialization profile. A nil fields
+// slice shows every field and returns record unchanged.
+//
+// Example:
+//
+//	json.NewEncoder(w).Encode(response.Masked(post, []string{"id", "title"}))
+func Masked(record interface{}, fields []string) interface{} {
+	if fields == nil {
+		return record
+	}
+	return masked{record: record, fields: fields}
+}
+
+type masked struct {
+	record interface{}
+	fields []string
+}
+
+// MarshalJSON encodes the record and drops the keys that are not shown.
+func (m masked) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(m.record)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("masked record is not a JSON object: %w", err)
+	}
+
+	visible := make(map[string]json.RawMessage, len(m.fields))
+	for _, field := range m.fields {
+		if value, ok := object[field]; ok {
+			visible[field] = value
+		}
+	}
+	return json.Marshal(visible)
+}
+
+// RenderJSONAPIMasked renders a single resource like RenderJSONAPI, keeping
+// only the attributes named by fields. id and type are always kept. A nil
+// fields slice shows every attribute.
+func RenderJSONAPIMasked(w http.ResponseWriter, status int, payload interface{}, fields []string) error {
+	data, err := jsonapi.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if fields != nil {
+		if data, err = maskDocument(data, fields); err != nil {
+			return err
+		}
+	}
+
+	w.Header().Set("Content-Type", JSONAPIMediaType)
+	w.WriteHeader(status)
+	_, err = w.Write(data)
+	return err
+}
+
+// maskDocument keeps only the attributes named by fields in the primary data
+// of a JSON:API document
+func maskDocument(data []byte, fields []string) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON:API document: %w", err)
+	}
+	if resource, ok := doc["data"].(map[string]interface{}); ok {
+		maskAttributes(resource, fields)
+	}
+	return json.Marshal(doc)
+}
+
+// maskAttributes keeps only the attributes named by fields in a resource
+// object. Attribute names are the lower-cased field names.
+func maskAttributes(resource map[string]interface{}, fields []string) {
+	attrs, ok := resource["attributes"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	allowed := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		allowed[strings.ToLower(field)] = true
+	}
+	for key := range attrs {
+		if !allowed[key] {
+			delete(attrs, key)
+		}
+	}
+}
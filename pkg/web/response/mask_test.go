@@ -0,0 +1,78 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMasked(t *testing.T) {
+	product := &TestProduct{ID: "1", Name: "Widget", Price: 9.5}
+
+	data, err := json.Marshal(Masked(product, []string{"id", "name", "missing"}))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(data) != `{"id":"1","name":"Widget"}` {
+		t.Errorf("Masked() = %s", data)
+	}
+
+	if Masked(product, nil) != product {
+		t.Error("Masked(nil) should return the record unchanged")
+	}
+	if data, _ := json.Marshal(Masked(product, []string{})); string(data) != `{}` {
+		t.Errorf("Masked(empty) = %s, want {}", data)
+	}
+}
+
+func TestRenderJSONAPIMasked(t *testing.T) {
+	rec := httptest.NewRecorder()
+	product := &TestProduct{ID: "1", Name: "Widget", Price: 9.5}
+
+	if err := RenderJSONAPIMasked(rec, http.StatusOK, product, []string{"name"}); err != nil {
+		t.Fatalf("RenderJSONAPIMasked() error = %v", err)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != JSONAPIMediaType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var doc struct {
+		Data struct {
+			ID         string                 `json:"id"`
+			Type       string                 `json:"type"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v\n%s", err, rec.Body.String())
+	}
+	if doc.Data.ID != "1" || doc.Data.Type != "test_products" {
+		t.Errorf("id and type must be kept, got %+v", doc.Data)
+	}
+	if len(doc.Data.Attributes) != 1 || doc.Data.Attributes["name"] != "Widget" {
+		t.Errorf("attributes = %v, want only name", doc.Data.Attributes)
+	}
+}
+
+func TestListStream_Mask(t *testing.T) {
+	for _, jsonAPI := range []bool{false, true} {
+		rec := httptest.NewRecorder()
+		stream := NewListStream(rec, newListRequest(jsonAPI), map[string][]string{"test_products": {"name", "price"}})
+		stream.Mask([]string{"id", "name"})
+
+		if err := stream.Write(&TestProduct{ID: "1", Name: "Widget", Price: 9.5}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		stream.Close(nil, nil)
+
+		body := rec.Body.String()
+		if !json.Valid(rec.Body.Bytes()) {
+			t.Fatalf("invalid JSON: %s", body)
+		}
+		if strings.Contains(body, "price") || !strings.Contains(body, "Widget") {
+			t.Errorf("jsonapi=%t: masked list = %s", jsonAPI, body)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Package scope lets admins see past the default scope of a resource. A
+// resource with @default_scope only lists and shows the records matching its
+// condition; a request with ?unscoped=true from a caller with the admin role
+// drops the condition, for example to review soft-deleted records.
+//
+// Example:
+//
+//	unscoped, err := scope.Unscoped(r)
+//	if err != nil {
+//		http.Error(w, err.Error(), scope.Status(err))
+//		return
+//	}
+//	builder.Scope("posts.deleted_at IS NULL", unscoped)
+package scope
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/conduit-lang/conduit/pkg/web/profile"
+)
+
+// Param is the query parameter dropping the default scope
+const Param = "unscoped"
+
+// Role is the role a caller needs to drop the default scope
+const Role = "admin"
+
+// ErrForbidden is returned for unscoped requests from callers without Role
+var ErrForbidden = errors.New("unscoped requests require the " + Role + " role")
+
+// Unscoped reports whether the request drops the default scope, e.g. with
+// ?unscoped=true or ?unscoped=1. It returns an error for values that are not
+// booleans and ErrForbidden when the caller lacks the admin role.
+func Unscoped(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get(Param)
+	if value == "" {
+		return false, nil
+	}
+
+	unscoped, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value %q: must be true or false", Param, value)
+	}
+	if unscoped && !slices.Contains(profile.Roles(r), Role) {
+		return false, ErrForbidden
+	}
+	return unscoped, nil
+}
+
+// Status returns the status of a response to an error from Unscoped: 403
+// Forbidden for ErrForbidden and 400 Bad Request otherwise.
+func Status(err error) int {
+	if errors.Is(err, ErrForbidden) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
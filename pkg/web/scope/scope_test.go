@@ -0,0 +1,51 @@
+package scope
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	webcontext "github.com/conduit-lang/conduit/internal/web/context"
+)
+
+func TestUnscoped(t *testing.T) {
+	tests := []struct {
+		target  string
+		roles   []string
+		want    bool
+		wantErr error
+	}{
+		{"/posts", nil, false, nil},
+		{"/posts?unscoped=false", nil, false, nil},
+		{"/posts?unscoped=true", []string{"editor", "admin"}, true, nil},
+		{"/posts?unscoped=1", []string{"admin"}, true, nil},
+		{"/posts?unscoped=true", []string{"editor"}, false, ErrForbidden},
+		{"/posts?unscoped=true", nil, false, ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.roles != nil {
+			req = req.WithContext(webcontext.SetUserRoles(req.Context(), tt.roles))
+		}
+		got, err := Unscoped(req)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("Unscoped(%s, %v) = %v, %v; want %v, %v", tt.target, tt.roles, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/posts?unscoped=maybe", nil)
+	if _, err := Unscoped(req); err == nil || errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected an invalid value error, got %v", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	if got := Status(ErrForbidden); got != http.StatusForbidden {
+		t.Errorf("Status(ErrForbidden) = %d, want 403", got)
+	}
+	if got := Status(errors.New("invalid")); got != http.StatusBadRequest {
+		t.Errorf("Status(invalid) = %d, want 400", got)
+	}
+}
@@ -0,0 +1,204 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Elasticsearch indexes into an Elasticsearch (or OpenSearch) cluster. Each
+// resource has its own index named after its table, created by Elasticsearch
+// on first write with dynamic mappings.
+type Elasticsearch struct {
+	URL    string
+	APIKey string       // Sent as "Authorization: ApiKey <key>" when set
+	Client *http.Client // http.DefaultClient when nil
+}
+
+// Index stores the document under the record's ID.
+func (e *Elasticsearch) Index(ctx context.Context, index, id string, doc Document) error {
+	path := "/" + url.PathEscape(index) + "/_doc/" + url.PathEscape(id)
+	return e.do(ctx, http.MethodPut, path, doc, nil)
+}
+
+// Delete removes the record's document; a document that is already gone is
+// not an error.
+func (e *Elasticsearch) Delete(ctx context.Context, index, id string) error {
+	path := "/" + url.PathEscape(index) + "/_doc/" + url.PathEscape(id)
+	err := e.do(ctx, http.MethodDelete, path, nil, nil)
+	if status, ok := err.(*StatusError); ok && status.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// Search runs a multi_match query over the query's fields.
+func (e *Elasticsearch) Search(ctx context.Context, index string, query Query) (Result, error) {
+	match := map[string]interface{}{"query": query.Text}
+	if len(query.Fields) > 0 {
+		match["fields"] = query.Fields
+	}
+	body := map[string]interface{}{
+		"query":            map[string]interface{}{"multi_match": match},
+		"from":             query.Offset,
+		"size":             query.Limit,
+		"_source":          false,
+		"track_total_hits": true,
+	}
+
+	var resp struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := e.do(ctx, http.MethodPost, "/"+url.PathEscape(index)+"/_search", body, &resp); err != nil {
+		return Result{}, err
+	}
+
+	result := Result{IDs: make([]string, 0, len(resp.Hits.Hits)), Total: resp.Hits.Total.Value}
+	for _, hit := range resp.Hits.Hits {
+		result.IDs = append(result.IDs, hit.ID)
+	}
+	return result, nil
+}
+
+func (e *Elasticsearch) do(ctx context.Context, method, path string, body, out interface{}) error {
+	header := make(http.Header)
+	if e.APIKey != "" {
+		header.Set("Authorization", "ApiKey "+e.APIKey)
+	}
+	return request(ctx, e.Client, method, e.URL+path, header, body, out)
+}
+
+// Meilisearch indexes into a Meilisearch instance. Each resource has its own
+// index named after its table, with id as the primary key. Meilisearch applies
+// writes asynchronously, so a record is searchable shortly after it is saved.
+type Meilisearch struct {
+	URL    string
+	APIKey string       // Sent as "Authorization: Bearer <key>" when set
+	Client *http.Client // http.DefaultClient when nil
+}
+
+// Index adds or replaces the record's document.
+func (m *Meilisearch) Index(ctx context.Context, index, id string, doc Document) error {
+	withID := make(Document, len(doc)+1)
+	for name, value := range doc {
+		withID[name] = value
+	}
+	withID["id"] = id
+
+	path := "/indexes/" + url.PathEscape(index) + "/documents?primaryKey=id"
+	return m.do(ctx, http.MethodPost, path, []Document{withID}, nil)
+}
+
+// Delete removes the record's document.
+func (m *Meilisearch) Delete(ctx context.Context, index, id string) error {
+	path := "/indexes/" + url.PathEscape(index) + "/documents/" + url.PathEscape(id)
+	return m.do(ctx, http.MethodDelete, path, nil, nil)
+}
+
+// Search runs a query restricted to the query's fields.
+func (m *Meilisearch) Search(ctx context.Context, index string, query Query) (Result, error) {
+	body := map[string]interface{}{
+		"q":                    query.Text,
+		"limit":                query.Limit,
+		"offset":               query.Offset,
+		"attributesToRetrieve": []string{"id"},
+	}
+	if len(query.Fields) > 0 {
+		body["attributesToSearchOn"] = query.Fields
+	}
+
+	var resp struct {
+		Hits []struct {
+			ID json.RawMessage `json:"id"`
+		} `json:"hits"`
+		EstimatedTotalHits int `json:"estimatedTotalHits"`
+	}
+	if err := m.do(ctx, http.MethodPost, "/indexes/"+url.PathEscape(index)+"/search", body, &resp); err != nil {
+		return Result{}, err
+	}
+
+	result := Result{IDs: make([]string, 0, len(resp.Hits)), Total: resp.EstimatedTotalHits}
+	for _, hit := range resp.Hits {
+		// Primary keys are strings or integers
+		var id string
+		if err := json.Unmarshal(hit.ID, &id); err != nil {
+			id = string(hit.ID)
+		}
+		result.IDs = append(result.IDs, id)
+	}
+	return result, nil
+}
+
+func (m *Meilisearch) do(ctx context.Context, method, path string, body, out interface{}) error {
+	header := make(http.Header)
+	if m.APIKey != "" {
+		header.Set("Authorization", "Bearer "+m.APIKey)
+	}
+	return request(ctx, m.Client, method, m.URL+path, header, body, out)
+}
+
+// StatusError is returned when a backend responds with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("search backend returned %s", e.Status)
+	}
+	return fmt.Sprintf("search backend returned %s: %s", e.Status, e.Body)
+}
+
+// request sends body as JSON and decodes a successful response into out
+func request(ctx context.Context, client *http.Client, method, target string, header http.Header, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(bytes.TrimSpace(detail))}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,187 @@
+// Package search keeps the records of resources declared with @search_index
+// in a search backend and queries it. Generated models index a record after
+// each committed create, update and patch, and remove it after a delete; the
+// generated GET /resources/search route queries the backend for matching IDs
+// and loads the records from the database in relevance order.
+//
+// Elasticsearch and Meilisearch are supported, configured with
+// CONDUIT_SEARCH_BACKEND, CONDUIT_SEARCH_URL and CONDUIT_SEARCH_API_KEY:
+//
+//	backend, err := search.BackendFromEnv()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	search.SetBackend(backend)
+//
+// The database stays the source of truth. Indexing failures are logged rather
+// than failing the write, and search requests fail with ErrDisabled when no
+// backend is configured.
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// BackendEnvVar selects the search backend: elasticsearch or meilisearch
+	BackendEnvVar = "CONDUIT_SEARCH_BACKEND"
+	// URLEnvVar is the base URL of the search backend, e.g. http://localhost:9200
+	URLEnvVar = "CONDUIT_SEARCH_URL"
+	// APIKeyEnvVar is an optional API key sent with every backend request
+	APIKeyEnvVar = "CONDUIT_SEARCH_API_KEY"
+
+	// DefaultLimit is the number of results returned when the request does not specify one
+	DefaultLimit = 20
+
+	// MaxLimit is the largest number of results a client may request
+	MaxLimit = 100
+
+	// IndexTimeout bounds how long a write waits for the backend to index a record
+	IndexTimeout = 5 * time.Second
+)
+
+// Backend names accepted by CONDUIT_SEARCH_BACKEND
+const (
+	BackendElasticsearch = "elasticsearch"
+	BackendMeilisearch   = "meilisearch"
+)
+
+// ErrDisabled is returned by Search when no backend is configured.
+var ErrDisabled = errors.New("search is not configured")
+
+// Document is the indexed copy of a record: its id and @search_index fields.
+type Document map[string]interface{}
+
+// Query is a search over the fields of one index.
+type Query struct {
+	Text   string
+	Fields []string // Fields to match against; all indexed fields when empty
+	Limit  int
+	Offset int
+}
+
+// Result is a page of matching record IDs in relevance order.
+type Result struct {
+	IDs   []string
+	Total int // Number of matches across all pages, as estimated by the backend
+}
+
+// Backend indexes documents and runs queries against a search engine.
+type Backend interface {
+	Index(ctx context.Context, index, id string, doc Document) error
+	Delete(ctx context.Context, index, id string) error
+	Search(ctx context.Context, index string, query Query) (Result, error)
+}
+
+var (
+	backendMu sync.RWMutex
+	backend   Backend
+)
+
+// SetBackend sets the Backend used by Index, Delete and Search. A nil Backend
+// disables search, which is the default.
+func SetBackend(b Backend) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	backend = b
+}
+
+func current() Backend {
+	backendMu.RLock()
+	defer backendMu.RUnlock()
+	return backend
+}
+
+// Index adds or replaces a record's document. It does nothing when search is
+// disabled and logs failures, so a committed write is never reported as
+// failed because the backend is unavailable.
+func Index(index, id string, doc Document) {
+	b := current()
+	if b == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), IndexTimeout)
+	defer cancel()
+	if err := b.Index(ctx, index, id, doc); err != nil {
+		log.Printf("search: failed to index %s/%s: %v", index, id, err)
+	}
+}
+
+// Delete removes a record's document, like Index logging failures.
+func Delete(index, id string) {
+	b := current()
+	if b == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), IndexTimeout)
+	defer cancel()
+	if err := b.Delete(ctx, index, id); err != nil {
+		log.Printf("search: failed to remove %s/%s: %v", index, id, err)
+	}
+}
+
+// Search runs a query against an index, or returns ErrDisabled.
+func Search(ctx context.Context, index string, query Query) (Result, error) {
+	b := current()
+	if b == nil {
+		return Result{}, ErrDisabled
+	}
+	return b.Search(ctx, index, query)
+}
+
+// ParseRequest reads the required q parameter and the optional limit and
+// offset of a search request. A limit above MaxLimit is clamped.
+func ParseRequest(r *http.Request) (Query, error) {
+	values := r.URL.Query()
+	query := Query{Text: strings.TrimSpace(values.Get("q")), Limit: DefaultLimit}
+	if query.Text == "" {
+		return Query{}, errors.New("q is required")
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return Query{}, errors.New("invalid limit: must be a positive integer")
+		}
+		query.Limit = min(limit, MaxLimit)
+	}
+	if raw := values.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return Query{}, errors.New("invalid offset: must be a non-negative integer")
+		}
+		query.Offset = offset
+	}
+	return query, nil
+}
+
+// BackendFromEnv returns the backend configured by CONDUIT_SEARCH_BACKEND,
+// CONDUIT_SEARCH_URL and CONDUIT_SEARCH_API_KEY, or nil when no URL is set.
+// The backend defaults to Elasticsearch.
+func BackendFromEnv() (Backend, error) {
+	url := strings.TrimRight(strings.TrimSpace(os.Getenv(URLEnvVar)), "/")
+	if url == "" {
+		return nil, nil
+	}
+	apiKey := strings.TrimSpace(os.Getenv(APIKeyEnvVar))
+
+	switch name := strings.ToLower(strings.TrimSpace(os.Getenv(BackendEnvVar))); name {
+	case "", BackendElasticsearch:
+		return &Elasticsearch{URL: url, APIKey: apiKey}, nil
+	case BackendMeilisearch:
+		return &Meilisearch{URL: url, APIKey: apiKey}, nil
+	default:
+		return nil, fmt.Errorf("%s must be %s or %s, got %q", BackendEnvVar, BackendElasticsearch, BackendMeilisearch, name)
+	}
+}
@@ -0,0 +1,227 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    Query
+		wantErr string
+	}{
+		{"text", "q=go+generics", Query{Text: "go generics", Limit: DefaultLimit}, ""},
+		{"limit and offset", "q=go&limit=5&offset=10", Query{Text: "go", Limit: 5, Offset: 10}, ""},
+		{"limit clamped", "q=go&limit=500", Query{Text: "go", Limit: MaxLimit}, ""},
+		{"missing", "", Query{}, "q is required"},
+		{"blank", "q=++", Query{}, "q is required"},
+		{"invalid limit", "q=go&limit=0", Query{}, "invalid limit"},
+		{"invalid offset", "q=go&offset=-1", Query{}, "invalid offset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRequest(httptest.NewRequest("GET", "/posts/search?"+tt.query, nil))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseRequest() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRequest() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackendFromEnv(t *testing.T) {
+	t.Setenv(URLEnvVar, "")
+	if backend, err := BackendFromEnv(); backend != nil || err != nil {
+		t.Errorf("BackendFromEnv() = %v, %v, want nil without a URL", backend, err)
+	}
+
+	t.Setenv(URLEnvVar, "http://localhost:7700/")
+	t.Setenv(APIKeyEnvVar, "secret")
+	t.Setenv(BackendEnvVar, "Meilisearch")
+	backend, err := BackendFromEnv()
+	if err != nil {
+		t.Fatalf("BackendFromEnv() error = %v", err)
+	}
+	if want := (&Meilisearch{URL: "http://localhost:7700", APIKey: "secret"}); !reflect.DeepEqual(backend, want) {
+		t.Errorf("BackendFromEnv() = %+v, want %+v", backend, want)
+	}
+
+	t.Setenv(BackendEnvVar, "")
+	if backend, _ := BackendFromEnv(); reflect.TypeOf(backend) != reflect.TypeOf(&Elasticsearch{}) {
+		t.Errorf("BackendFromEnv() = %T, want Elasticsearch by default", backend)
+	}
+
+	t.Setenv(BackendEnvVar, "solr")
+	if _, err := BackendFromEnv(); err == nil {
+		t.Error("BackendFromEnv() should reject an unknown backend")
+	}
+}
+
+// recordedRequest is a request received by a fake backend
+type recordedRequest struct {
+	Method string
+	Path   string
+	Auth   string
+	Body   interface{}
+}
+
+// fakeBackend records requests and answers each with the given JSON
+func fakeBackend(t *testing.T, status int, response string) (*httptest.Server, *[]recordedRequest) {
+	t.Helper()
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body interface{}
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("invalid request body: %v", err)
+			}
+		}
+		requests = append(requests, recordedRequest{Method: r.Method, Path: r.URL.RequestURI(), Auth: r.Header.Get("Authorization"), Body: body})
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestElasticsearch(t *testing.T) {
+	server, requests := fakeBackend(t, http.StatusOK, `{"hits":{"total":{"value":12},"hits":[{"_id":"b"},{"_id":"a"}]}}`)
+	es := &Elasticsearch{URL: server.URL, APIKey: "key"}
+	ctx := context.Background()
+
+	if err := es.Index(ctx, "posts", "a", Document{"title": "Hello"}); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+	result, err := es.Search(ctx, "posts", Query{Text: "hello", Fields: []string{"title"}, Limit: 2, Offset: 4})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if want := (Result{IDs: []string{"b", "a"}, Total: 12}); !reflect.DeepEqual(result, want) {
+		t.Errorf("Search() = %+v, want %+v", result, want)
+	}
+
+	got := *requests
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(got))
+	}
+	if got[0].Method != "PUT" || got[0].Path != "/posts/_doc/a" || got[0].Auth != "ApiKey key" {
+		t.Errorf("Unexpected index request: %+v", got[0])
+	}
+	if got[1].Path != "/posts/_search" {
+		t.Errorf("Unexpected search path: %s", got[1].Path)
+	}
+	body := got[1].Body.(map[string]interface{})
+	if body["from"] != float64(4) || body["size"] != float64(2) {
+		t.Errorf("Unexpected paging: %v", body)
+	}
+	match := body["query"].(map[string]interface{})["multi_match"].(map[string]interface{})
+	if match["query"] != "hello" || !reflect.DeepEqual(match["fields"], []interface{}{"title"}) {
+		t.Errorf("Unexpected query: %v", match)
+	}
+}
+
+func TestElasticsearchDeleteMissing(t *testing.T) {
+	server, _ := fakeBackend(t, http.StatusNotFound, `{"result":"not_found"}`)
+	if err := (&Elasticsearch{URL: server.URL}).Delete(context.Background(), "posts", "a"); err != nil {
+		t.Errorf("Delete() error = %v, want nil for a missing document", err)
+	}
+}
+
+func TestMeilisearch(t *testing.T) {
+	server, requests := fakeBackend(t, http.StatusAccepted, `{"hits":[{"id":"b"},{"id":7}],"estimatedTotalHits":2}`)
+	meili := &Meilisearch{URL: server.URL, APIKey: "key"}
+	ctx := context.Background()
+
+	if err := meili.Index(ctx, "posts", "a", Document{"title": "Hello"}); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+	if err := meili.Delete(ctx, "posts", "a"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	result, err := meili.Search(ctx, "posts", Query{Text: "hello", Fields: []string{"title"}, Limit: 20})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if want := (Result{IDs: []string{"b", "7"}, Total: 2}); !reflect.DeepEqual(result, want) {
+		t.Errorf("Search() = %+v, want %+v", result, want)
+	}
+
+	got := *requests
+	if got[0].Method != "POST" || got[0].Path != "/indexes/posts/documents?primaryKey=id" || got[0].Auth != "Bearer key" {
+		t.Errorf("Unexpected index request: %+v", got[0])
+	}
+	if want := []interface{}{map[string]interface{}{"id": "a", "title": "Hello"}}; !reflect.DeepEqual(got[0].Body, want) {
+		t.Errorf("Index body = %v, want %v", got[0].Body, want)
+	}
+	if got[1].Method != "DELETE" || got[1].Path != "/indexes/posts/documents/a" {
+		t.Errorf("Unexpected delete request: %+v", got[1])
+	}
+	if body := got[2].Body.(map[string]interface{}); body["q"] != "hello" || !reflect.DeepEqual(body["attributesToSearchOn"], []interface{}{"title"}) {
+		t.Errorf("Unexpected search body: %v", body)
+	}
+}
+
+type fakeIndex struct {
+	docs map[string]Document
+	err  error
+}
+
+func (f *fakeIndex) Index(ctx context.Context, index, id string, doc Document) error {
+	f.docs[index+"/"+id] = doc
+	return f.err
+}
+
+func (f *fakeIndex) Delete(ctx context.Context, index, id string) error {
+	delete(f.docs, index+"/"+id)
+	return f.err
+}
+
+func (f *fakeIndex) Search(ctx context.Context, index string, query Query) (Result, error) {
+	return Result{IDs: []string{query.Text}, Total: 1}, f.err
+}
+
+func TestDefaultBackend(t *testing.T) {
+	t.Cleanup(func() { SetBackend(nil) })
+
+	// Disabled: writes are skipped and searches fail
+	Index("posts", "a", Document{"title": "Hello"})
+	if _, err := Search(context.Background(), "posts", Query{Text: "a"}); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Search() error = %v, want ErrDisabled", err)
+	}
+
+	fake := &fakeIndex{docs: make(map[string]Document)}
+	SetBackend(fake)
+	Index("posts", "a", Document{"title": "Hello"})
+	if _, ok := fake.docs["posts/a"]; !ok {
+		t.Error("Index() did not reach the backend")
+	}
+	Delete("posts", "a")
+	if len(fake.docs) != 0 {
+		t.Error("Delete() did not reach the backend")
+	}
+
+	// Backend failures are logged, not returned
+	fake.err = errors.New("unavailable")
+	Index("posts", "b", Document{})
+
+	if result, err := Search(context.Background(), "posts", Query{Text: "b"}); err == nil || result.IDs[0] != "b" {
+		t.Errorf("Search() = %+v, %v, want the backend's result and error", result, err)
+	}
+}
@@ -0,0 +1,157 @@
+// Package server runs the HTTP server of generated applications with timeouts
+// and graceful shutdown: on SIGINT or SIGTERM it stops accepting connections,
+// waits for in-flight requests to finish, and returns so the application can
+// close its database pool.
+//
+// Example:
+//
+//	ctx, stop := server.SignalContext(context.Background())
+//	defer stop()
+//
+//	config := server.ConfigFromEnv(server.DefaultConfig())
+//	srv := server.New(addr, r, config)
+//	if err := server.Run(ctx, srv, config, srv.ListenAndServe); err != nil {
+//		log.Fatalf("Server failed: %v", err)
+//	}
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Default timeouts, used when neither conduit.yaml nor the environment sets them
+const (
+	DefaultReadTimeout     = 15 * time.Second
+	DefaultWriteTimeout    = 30 * time.Second
+	DefaultIdleTimeout     = 120 * time.Second
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
+// Environment variables read by ConfigFromEnv. They accept Go durations such
+// as "10s" or "1m"; "0" disables the timeout.
+const (
+	ReadTimeoutEnv     = "SERVER_READ_TIMEOUT"
+	WriteTimeoutEnv    = "SERVER_WRITE_TIMEOUT"
+	IdleTimeoutEnv     = "SERVER_IDLE_TIMEOUT"
+	ShutdownTimeoutEnv = "SERVER_SHUTDOWN_TIMEOUT"
+)
+
+// Config holds the server's timeouts. Zero disables a timeout.
+type Config struct {
+	// ReadTimeout limits reading a request, including its body
+	ReadTimeout time.Duration
+	// WriteTimeout limits handling a request and writing its response
+	WriteTimeout time.Duration
+	// IdleTimeout limits how long keep-alive connections wait for the next request
+	IdleTimeout time.Duration
+	// ShutdownTimeout limits how long shutdown waits for in-flight requests;
+	// connections still active then are closed
+	ShutdownTimeout time.Duration
+}
+
+// DefaultConfig returns the default timeouts
+func DefaultConfig() Config {
+	return Config{
+		ReadTimeout:     DefaultReadTimeout,
+		WriteTimeout:    DefaultWriteTimeout,
+		IdleTimeout:     DefaultIdleTimeout,
+		ShutdownTimeout: DefaultShutdownTimeout,
+	}
+}
+
+// ConfigFromEnv returns config with each timeout replaced by its environment
+// variable when that is set to a valid duration.
+func ConfigFromEnv(config Config) Config {
+	for _, setting := range []struct {
+		env   string
+		value *time.Duration
+	}{
+		{ReadTimeoutEnv, &config.ReadTimeout},
+		{WriteTimeoutEnv, &config.WriteTimeout},
+		{IdleTimeoutEnv, &config.IdleTimeout},
+		{ShutdownTimeoutEnv, &config.ShutdownTimeout},
+	} {
+		value := os.Getenv(setting.env)
+		if value == "" {
+			continue
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			log.Printf("Ignoring invalid %s %q", setting.env, value)
+			continue
+		}
+		*setting.value = timeout
+	}
+	return config
+}
+
+// New returns a server for handler on addr with the configured timeouts.
+// Request headers must arrive within the read timeout too.
+func New(addr string, handler http.Handler, config Config) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+	}
+}
+
+// SignalContext returns a context canceled on SIGINT or SIGTERM. Signals are
+// caught only until the context is canceled, so a second signal ends the
+// process at once.
+func SignalContext(parent context.Context) (ctx context.Context, stop context.CancelFunc) {
+	ctx, stop = signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// Run serves with serve, typically srv.ListenAndServe or a ListenAndServeTLS
+// closure, until serve fails or ctx is canceled. On cancellation it shuts
+// srv down gracefully: listeners close at once, and in-flight requests have
+// config.ShutdownTimeout to finish before their connections are closed. Run
+// returns nil after a shutdown that drained every request.
+func Run(ctx context.Context, srv *http.Server, config Config, serve func() error) error {
+	served := make(chan error, 1)
+	go func() {
+		served <- serve()
+	}()
+
+	select {
+	case err := <-served:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down, waiting up to %s for in-flight requests", config.ShutdownTimeout)
+	shutdownCtx := context.Background()
+	if config.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, config.ShutdownTimeout)
+		defer cancel()
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("graceful shutdown incomplete: %w", err)
+	}
+
+	if err := <-served; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
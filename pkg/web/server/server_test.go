@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(ReadTimeoutEnv, "5s")
+	t.Setenv(WriteTimeoutEnv, "0")
+	t.Setenv(IdleTimeoutEnv, "soon")
+
+	config := ConfigFromEnv(DefaultConfig())
+
+	if config.ReadTimeout != 5*time.Second {
+		t.Errorf("ReadTimeout = %s, want 5s", config.ReadTimeout)
+	}
+	if config.WriteTimeout != 0 {
+		t.Errorf("WriteTimeout = %s, want 0 to disable it", config.WriteTimeout)
+	}
+	if config.IdleTimeout != DefaultIdleTimeout {
+		t.Errorf("IdleTimeout = %s, want the default for an invalid value", config.IdleTimeout)
+	}
+	if config.ShutdownTimeout != DefaultShutdownTimeout {
+		t.Errorf("ShutdownTimeout = %s, want the default", config.ShutdownTimeout)
+	}
+}
+
+func TestNew(t *testing.T) {
+	config := Config{ReadTimeout: time.Second, WriteTimeout: 2 * time.Second, IdleTimeout: 3 * time.Second}
+	srv := New(":8080", http.NotFoundHandler(), config)
+
+	if srv.Addr != ":8080" || srv.ReadTimeout != time.Second || srv.ReadHeaderTimeout != time.Second ||
+		srv.WriteTimeout != 2*time.Second || srv.IdleTimeout != 3*time.Second {
+		t.Errorf("server = %+v", srv)
+	}
+}
+
+// startServer runs a server whose handler blocks until release is closed
+func startServer(t *testing.T, config Config, release chan struct{}) (context.CancelFunc, chan error, string, chan struct{}) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	started := make(chan struct{})
+	srv := New("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	}), config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- Run(ctx, srv, config, func() error { return srv.Serve(listener) })
+	}()
+	return cancel, result, "http://" + listener.Addr().String(), started
+}
+
+func TestRun_DrainsInFlightRequests(t *testing.T) {
+	release := make(chan struct{})
+	cancel, result, url, started := startServer(t, Config{ShutdownTimeout: 5 * time.Second}, release)
+
+	response := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			response <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		response <- string(body)
+	}()
+
+	<-started
+	cancel()
+	select {
+	case err := <-result:
+		t.Fatalf("Run returned %v before the request finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if body := <-response; body != "done" {
+		t.Errorf("in-flight request got %q, want it to complete", body)
+	}
+	if err := <-result; err != nil {
+		t.Errorf("Run() error = %v, want nil after draining", err)
+	}
+}
+
+func TestRun_ShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	cancel, result, url, started := startServer(t, Config{ShutdownTimeout: 20 * time.Millisecond}, release)
+
+	go http.Get(url)
+	<-started
+	cancel()
+
+	err := <-result
+	if err == nil || !strings.Contains(err.Error(), "graceful shutdown incomplete") {
+		t.Errorf("Run() error = %v, want the shutdown timeout", err)
+	}
+}
+
+func TestRun_ServeError(t *testing.T) {
+	failure := errors.New("address in use")
+	err := Run(context.Background(), &http.Server{}, DefaultConfig(), func() error { return failure })
+	if !errors.Is(err, failure) {
+		t.Errorf("Run() error = %v, want %v", err, failure)
+	}
+}
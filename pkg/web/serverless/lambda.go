@@ -0,0 +1,386 @@
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// RuntimeAPIEnvVar holds the host and port of the Lambda Runtime API. Lambda
+// sets it for custom runtimes.
+const RuntimeAPIEnvVar = "AWS_LAMBDA_RUNTIME_API"
+
+// runtimeAPIVersion prefixes the paths of the Lambda Runtime API
+const runtimeAPIVersion = "/2018-06-01/runtime"
+
+// Headers of the next invocation
+const (
+	requestIDHeader = "Lambda-Runtime-Aws-Request-Id"
+	deadlineHeader  = "Lambda-Runtime-Deadline-Ms"
+	traceIDHeader   = "Lambda-Runtime-Trace-Id"
+)
+
+// LambdaOptions configures StartLambda
+type LambdaOptions struct {
+	// RuntimeAPI is the host and port of the Lambda Runtime API;
+	// AWS_LAMBDA_RUNTIME_API when empty
+	RuntimeAPI string
+	// AfterInvoke runs after each response is sent and before the next event
+	// is awaited, while the instance is not yet frozen, e.g. to flush
+	// buffered error reports
+	AfterInvoke func()
+	// HTTPClient calls the Runtime API. It must have no timeout: waiting
+	// for the next event lasts until one arrives.
+	HTTPClient *http.Client
+}
+
+// StartLambda serves the API Gateway and function URL events of a Lambda
+// custom runtime with handler, one at a time, until the Runtime API fails. An
+// event that is not an HTTP request is reported to Lambda as an invocation
+// error.
+func StartLambda(handler http.Handler, opts LambdaOptions) error {
+	if opts.RuntimeAPI == "" {
+		opts.RuntimeAPI = os.Getenv(RuntimeAPIEnvVar)
+	}
+	if opts.RuntimeAPI == "" {
+		return fmt.Errorf("%s is not set; the lambda build target runs on AWS Lambda only", RuntimeAPIEnvVar)
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{}
+	}
+	runtime := &lambdaRuntime{base: "http://" + opts.RuntimeAPI + runtimeAPIVersion, client: opts.HTTPClient}
+
+	for {
+		event, err := runtime.next()
+		if err != nil {
+			return err
+		}
+		response, err := serveEvent(handler, event)
+		if err != nil {
+			log.Printf("Lambda invocation %s failed: %v", event.requestID, err)
+			err = runtime.fail(event.requestID, err)
+		} else {
+			err = runtime.respond(event.requestID, response)
+		}
+		if err != nil {
+			return err
+		}
+		if opts.AfterInvoke != nil {
+			opts.AfterInvoke()
+		}
+	}
+}
+
+// lambdaRuntime calls the Lambda Runtime API
+type lambdaRuntime struct {
+	base   string
+	client *http.Client
+}
+
+// invocation is an event to handle
+type invocation struct {
+	requestID string
+	deadline  time.Time
+	traceID   string
+	payload   []byte
+}
+
+// next waits for the next event
+func (rt *lambdaRuntime) next() (*invocation, error) {
+	resp, err := rt.client.Get(rt.base + "/invocation/next")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the next invocation: %w", err)
+	}
+	defer resp.Body.Close()
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the next invocation: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get the next invocation: runtime API answered %s", resp.Status)
+	}
+
+	event := &invocation{
+		requestID: resp.Header.Get(requestIDHeader),
+		traceID:   resp.Header.Get(traceIDHeader),
+		payload:   payload,
+	}
+	if ms, err := strconv.ParseInt(resp.Header.Get(deadlineHeader), 10, 64); err == nil {
+		event.deadline = time.UnixMilli(ms)
+	}
+	// The X-Ray SDK reads the trace of the current invocation from here
+	if event.traceID != "" {
+		os.Setenv("_X_AMZN_TRACE_ID", event.traceID)
+	}
+	return event, nil
+}
+
+// respond sends the response to an invocation
+func (rt *lambdaRuntime) respond(requestID string, response *proxyResponse) error {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to encode the response: %w", err)
+	}
+	return rt.post("/invocation/"+requestID+"/response", body, "")
+}
+
+// fail reports that an invocation could not be handled
+func (rt *lambdaRuntime) fail(requestID string, cause error) error {
+	body, err := json.Marshal(map[string]string{"errorMessage": cause.Error(), "errorType": "InvalidEvent"})
+	if err != nil {
+		return err
+	}
+	return rt.post("/invocation/"+requestID+"/error", body, "InvalidEvent")
+}
+
+func (rt *lambdaRuntime) post(path string, body []byte, errorType string) error {
+	req, err := http.NewRequest(http.MethodPost, rt.base+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if errorType != "" {
+		req.Header.Set("Lambda-Runtime-Function-Error-Type", errorType)
+	}
+	resp, err := rt.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send the invocation result: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("failed to send the invocation result: runtime API answered %s", resp.Status)
+	}
+	return nil
+}
+
+// proxyRequest is an API Gateway proxy event: a REST API event of payload
+// version 1.0, or an HTTP API or function URL event of version 2.0
+type proxyRequest struct {
+	Version string `json:"version"`
+
+	// Version 1.0
+	HTTPMethod                      string              `json:"httpMethod"`
+	Path                            string              `json:"path"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+
+	// Version 2.0
+	RawPath        string   `json:"rawPath"`
+	RawQueryString string   `json:"rawQueryString"`
+	Cookies        []string `json:"cookies"`
+
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+	RequestContext  struct {
+		RequestID string `json:"requestId"`
+		Identity  struct {
+			SourceIP string `json:"sourceIp"`
+		} `json:"identity"` // Version 1.0
+		HTTP struct {
+			Method   string `json:"method"`
+			SourceIP string `json:"sourceIp"`
+		} `json:"http"` // Version 2.0
+	} `json:"requestContext"`
+}
+
+// v2 reports whether the event has payload version 2.0
+func (p *proxyRequest) v2() bool {
+	return p.Version == "2.0"
+}
+
+// proxyResponse answers a proxy event. Version 1.0 responses carry every
+// header in MultiValueHeaders; version 2.0 responses join repeated headers
+// and carry Set-Cookie headers in Cookies.
+type proxyResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Cookies           []string            `json:"cookies,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// serveEvent serves an event with handler
+func serveEvent(handler http.Handler, event *invocation) (*proxyResponse, error) {
+	var proxy proxyRequest
+	if err := json.Unmarshal(event.payload, &proxy); err != nil {
+		return nil, fmt.Errorf("event is not JSON: %w", err)
+	}
+
+	ctx := context.Background()
+	if !event.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, event.deadline)
+		defer cancel()
+	}
+	req, err := proxy.request(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Header.Get("X-Request-Id") == "" && event.requestID != "" {
+		req.Header.Set("X-Request-Id", event.requestID)
+	}
+	if event.traceID != "" {
+		req.Header.Set("X-Amzn-Trace-Id", event.traceID)
+	}
+
+	w := &responseRecorder{header: make(http.Header)}
+	handler.ServeHTTP(w, req)
+	return w.response(proxy.v2()), nil
+}
+
+// request returns the HTTP request of the event
+func (p *proxyRequest) request(ctx context.Context) (*http.Request, error) {
+	method, path, sourceIP := p.HTTPMethod, p.Path, p.RequestContext.Identity.SourceIP
+	query := p.RawQueryString
+	if p.v2() {
+		method, path, sourceIP = p.RequestContext.HTTP.Method, p.RawPath, p.RequestContext.HTTP.SourceIP
+	} else {
+		values := url.Values{}
+		for key, value := range p.QueryStringParameters {
+			values.Set(key, value)
+		}
+		for key, list := range p.MultiValueQueryStringParameters {
+			values[key] = list
+		}
+		query = values.Encode()
+	}
+	if method == "" || path == "" {
+		return nil, errors.New("event is not an API Gateway or function URL request")
+	}
+
+	body := []byte(p.Body)
+	if p.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(p.Body)
+		if err != nil {
+			return nil, fmt.Errorf("event body is not base64: %w", err)
+		}
+		body = decoded
+	}
+
+	target := path
+	if query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("event is not a valid request: %w", err)
+	}
+	req.RequestURI = target
+
+	for key, value := range p.Headers {
+		req.Header.Set(key, value)
+	}
+	for key, values := range p.MultiValueHeaders {
+		req.Header.Del(key)
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if len(p.Cookies) > 0 {
+		req.Header.Set("Cookie", strings.Join(p.Cookies, "; "))
+	}
+	req.Host = req.Header.Get("Host")
+	if sourceIP != "" {
+		req.RemoteAddr = net.JoinHostPort(sourceIP, "0")
+	}
+	return req, nil
+}
+
+// responseRecorder buffers a response for the Runtime API
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseRecorder) Header() http.Header {
+	return w.header
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+// Flush is a no-op: the response is sent once the handler returns
+func (w *responseRecorder) Flush() {}
+
+// response returns the recorded response in the payload version of the
+// event. Bodies that are not text, such as compressed JSON, are sent in
+// base64.
+func (w *responseRecorder) response(v2 bool) *proxyResponse {
+	response := &proxyResponse{StatusCode: w.status}
+	if response.StatusCode == 0 {
+		response.StatusCode = http.StatusOK
+	}
+
+	body := w.body.Bytes()
+	if isText(w.header, body) {
+		response.Body = string(body)
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(body)
+		response.IsBase64Encoded = true
+	}
+
+	if !v2 {
+		response.MultiValueHeaders = map[string][]string(w.header)
+		return response
+	}
+	response.Headers = make(map[string]string, len(w.header))
+	for key, values := range w.header {
+		if key == "Set-Cookie" {
+			response.Cookies = values
+			continue
+		}
+		response.Headers[key] = strings.Join(values, ", ")
+	}
+	return response
+}
+
+// isText reports whether a body can be sent as it is
+func isText(header http.Header, body []byte) bool {
+	if header.Get("Content-Encoding") != "" || !utf8.Valid(body) {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	for _, suffix := range []string{"json", "xml", "javascript", "yaml", "x-www-form-urlencoded", "graphql"} {
+		if strings.HasSuffix(mediaType, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package serverless
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRuntimeAPI serves queued events like the Lambda Runtime API and records
+// what the runtime sends back. Once the events run out it answers 500, which
+// makes StartLambda return.
+type fakeRuntimeAPI struct {
+	mu        sync.Mutex
+	events    []string
+	served    int
+	deadline  time.Time
+	responses map[string]proxyResponse
+	errors    map[string]string
+	errorType string
+}
+
+func newFakeRuntimeAPI(t *testing.T, events ...string) (*fakeRuntimeAPI, string) {
+	t.Helper()
+	api := &fakeRuntimeAPI{events: events, responses: map[string]proxyResponse{}, errors: map[string]string{}}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return api, strings.TrimPrefix(srv.URL, "http://")
+}
+
+func (api *fakeRuntimeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, runtimeAPIVersion+"/invocation/")
+	switch {
+	case path == "next":
+		if len(api.events) == 0 {
+			http.Error(w, "no more events", http.StatusInternalServerError)
+			return
+		}
+		api.served++
+		w.Header().Set(requestIDHeader, fmt.Sprintf("req-%d", api.served))
+		w.Header().Set(traceIDHeader, "Root=1-abc")
+		if !api.deadline.IsZero() {
+			w.Header().Set(deadlineHeader, strconv.FormatInt(api.deadline.UnixMilli(), 10))
+		}
+		io.WriteString(w, api.events[0])
+		api.events = api.events[1:]
+	case strings.HasSuffix(path, "/response"):
+		var response proxyResponse
+		json.NewDecoder(r.Body).Decode(&response)
+		api.responses[strings.TrimSuffix(path, "/response")] = response
+		w.WriteHeader(http.StatusAccepted)
+	case strings.HasSuffix(path, "/error"):
+		body, _ := io.ReadAll(r.Body)
+		api.errors[strings.TrimSuffix(path, "/error")] = string(body)
+		api.errorType = r.Header.Get("Lambda-Runtime-Function-Error-Type")
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestStartLambda(t *testing.T) {
+	v1 := `{
+		"httpMethod": "POST",
+		"path": "/posts",
+		"multiValueQueryStringParameters": {"tag": ["go", "sql"]},
+		"multiValueHeaders": {"Content-Type": ["application/json"], "Host": ["api.example.com"]},
+		"body": "eyJ0aXRsZSI6IkhpIn0=",
+		"isBase64Encoded": true,
+		"requestContext": {"identity": {"sourceIp": "203.0.113.7"}}
+	}`
+	v2 := `{
+		"version": "2.0",
+		"rawPath": "/posts/1",
+		"rawQueryString": "include=author",
+		"cookies": ["session=abc", "theme=dark"],
+		"headers": {"accept": "application/json", "x-request-id": "from-client"},
+		"requestContext": {"http": {"method": "GET", "sourceIp": "198.51.100.2"}}
+	}`
+	api, addr := newFakeRuntimeAPI(t, v1, v2)
+
+	type seen struct {
+		method, uri, host, body, remote, cookie, requestID, trace string
+		tags                                                      []string
+	}
+	var requests []seen
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, seen{
+			method: r.Method, uri: r.RequestURI, host: r.Host, body: string(body),
+			remote: r.RemoteAddr, cookie: r.Header.Get("Cookie"),
+			requestID: r.Header.Get("X-Request-Id"), trace: r.Header.Get("X-Amzn-Trace-Id"),
+			tags: r.URL.Query()["tag"],
+		})
+		http.SetCookie(w, &http.Cookie{Name: "a", Value: "1"})
+		http.SetCookie(w, &http.Cookie{Name: "b", Value: "2"})
+		w.Header().Add("Vary", "Accept")
+		w.Header().Add("Vary", "Origin")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"ok":true}`)
+	})
+
+	invocations := 0
+	err := StartLambda(handler, LambdaOptions{RuntimeAPI: addr, AfterInvoke: func() { invocations++ }})
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Fatalf("StartLambda() error = %v, want the runtime API failure", err)
+	}
+	if invocations != 2 || len(requests) != 2 {
+		t.Fatalf("served %d requests with %d AfterInvoke calls, want 2", len(requests), invocations)
+	}
+
+	first := requests[0]
+	if first.method != "POST" || first.body != `{"title":"Hi"}` || first.host != "api.example.com" || first.remote != "203.0.113.7:0" {
+		t.Errorf("v1 request = %+v", first)
+	}
+	if strings.Join(first.tags, ",") != "go,sql" || first.requestID != "req-1" || first.trace != "Root=1-abc" {
+		t.Errorf("v1 request = %+v", first)
+	}
+	second := requests[1]
+	if second.method != "GET" || second.uri != "/posts/1?include=author" || second.cookie != "session=abc; theme=dark" || second.requestID != "from-client" {
+		t.Errorf("v2 request = %+v", second)
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	response := api.responses["req-1"]
+	if response.StatusCode != http.StatusCreated || response.Body != `{"ok":true}` || response.IsBase64Encoded {
+		t.Errorf("v1 response = %+v", response)
+	}
+	if got := response.MultiValueHeaders["Set-Cookie"]; len(got) != 2 {
+		t.Errorf("v1 Set-Cookie = %v, want both cookies", got)
+	}
+	response = api.responses["req-2"]
+	if response.Headers["Vary"] != "Accept, Origin" || len(response.Cookies) != 2 || response.Headers["Set-Cookie"] != "" {
+		t.Errorf("v2 response = %+v", response)
+	}
+}
+
+func TestStartLambda_InvalidEvent(t *testing.T) {
+	api, addr := newFakeRuntimeAPI(t, `{"source": "aws.events"}`)
+	called := false
+	handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true })
+
+	if err := StartLambda(handler, LambdaOptions{RuntimeAPI: addr}); err == nil {
+		t.Fatal("StartLambda() returned no error after the events ran out")
+	}
+	if called {
+		t.Error("handler served an event that is not an HTTP request")
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if !strings.Contains(api.errors["req-1"], "not an API Gateway") || api.errorType != "InvalidEvent" {
+		t.Errorf("reported error %q of type %q", api.errors["req-1"], api.errorType)
+	}
+}
+
+func TestStartLambda_Deadline(t *testing.T) {
+	api, addr := newFakeRuntimeAPI(t, `{"version": "2.0", "rawPath": "/", "requestContext": {"http": {"method": "GET"}}}`)
+	api.deadline = time.Now().Add(time.Minute)
+	var deadline time.Time
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ = r.Context().Deadline()
+	})
+
+	StartLambda(handler, LambdaOptions{RuntimeAPI: addr})
+	if !deadline.Equal(api.deadline.Truncate(time.Millisecond)) {
+		t.Errorf("request deadline = %v, want %v", deadline, api.deadline)
+	}
+}
+
+func TestStartLambda_NoRuntimeAPI(t *testing.T) {
+	t.Setenv(RuntimeAPIEnvVar, "")
+	err := StartLambda(http.NotFoundHandler(), LambdaOptions{})
+	if err == nil || !strings.Contains(err.Error(), RuntimeAPIEnvVar) {
+		t.Errorf("StartLambda() error = %v, want it to name %s", err, RuntimeAPIEnvVar)
+	}
+}
+
+func TestRecordedResponse_Binary(t *testing.T) {
+	w := &responseRecorder{header: make(http.Header)}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Write([]byte{0x1f, 0x8b, 0x08})
+
+	response := w.response(true)
+	if !response.IsBase64Encoded || response.Body != base64.StdEncoding.EncodeToString([]byte{0x1f, 0x8b, 0x08}) {
+		t.Errorf("compressed response = %+v, want a base64 body", response)
+	}
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", response.StatusCode)
+	}
+}
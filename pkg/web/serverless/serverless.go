@@ -0,0 +1,138 @@
+// Package serverless runs generated applications on AWS Lambda and Cloud Run,
+// as conduit.yaml's build target selects:
+//
+//	build:
+//	  target: lambda   # or cloudrun; server by default
+//
+// On Lambda the application is a custom runtime: StartLambda takes API
+// Gateway and function URL events from the Lambda Runtime API and serves them
+// with the application's router. On Cloud Run it is the usual HTTP server,
+// shutting down within the time Cloud Run allows after SIGTERM.
+//
+// On both, the database pool connects on the first query instead of at
+// startup, and is sized for many small instances rather than one server.
+//
+// Example:
+//
+//	db, err := instrument.Open("pgx", dbURL, instrument.ConfigFromEnv())
+//	...
+//	serverless.PoolFromEnv(serverless.DefaultPool(serverless.TargetLambda)).Apply(db)
+//
+//	if err := serverless.StartLambda(r, serverless.LambdaOptions{}); err != nil {
+//		log.Fatalf("Lambda runtime failed: %v", err)
+//	}
+package serverless
+
+import (
+	"database/sql"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Build targets
+const (
+	// TargetServer is a long-running HTTP server
+	TargetServer = "server"
+	// TargetLambda is an AWS Lambda custom runtime behind API Gateway or a
+	// function URL
+	TargetLambda = "lambda"
+	// TargetCloudRun is a Cloud Run service
+	TargetCloudRun = "cloudrun"
+)
+
+// CloudRunShutdownTimeout bounds graceful shutdown on Cloud Run, which kills
+// the instance 10 seconds after SIGTERM. The rest is left to close the
+// database pool.
+const CloudRunShutdownTimeout = 8 * time.Second
+
+// Environment variables read by PoolFromEnv. The durations accept Go
+// durations such as "5m"; "0" keeps connections regardless of age.
+const (
+	MaxOpenConnsEnv    = "DB_MAX_OPEN_CONNS"
+	MaxIdleConnsEnv    = "DB_MAX_IDLE_CONNS"
+	ConnMaxIdleTimeEnv = "DB_CONN_MAX_IDLE_TIME"
+	ConnMaxLifetimeEnv = "DB_CONN_MAX_LIFETIME"
+)
+
+// Pool sizes a database pool. As in database/sql, a zero MaxOpenConns,
+// ConnMaxIdleTime or ConnMaxLifetime leaves that limit off, and a zero
+// MaxIdleConns keeps no idle connections.
+type Pool struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxIdleTime time.Duration
+	ConnMaxLifetime time.Duration
+}
+
+// DefaultPool returns the pool of a build target.
+//
+// A Lambda instance serves one request at a time, so it keeps two
+// connections: one for the request and one for an async hook or job it
+// starts. Both stay open between invocations so warm starts skip the
+// connection handshake.
+//
+// A Cloud Run instance serves up to 80 requests at once, and a service may
+// run many instances, so each opens at most 10 connections and closes those
+// idle for a minute, releasing them when traffic drops.
+func DefaultPool(target string) Pool {
+	switch target {
+	case TargetLambda:
+		return Pool{MaxOpenConns: 2, MaxIdleConns: 2, ConnMaxIdleTime: 10 * time.Minute, ConnMaxLifetime: 30 * time.Minute}
+	case TargetCloudRun:
+		return Pool{MaxOpenConns: 10, MaxIdleConns: 2, ConnMaxIdleTime: time.Minute, ConnMaxLifetime: 30 * time.Minute}
+	default:
+		return Pool{MaxOpenConns: 25, MaxIdleConns: 5}
+	}
+}
+
+// PoolFromEnv returns pool with each setting replaced by its environment
+// variable when that is set to a valid value.
+func PoolFromEnv(pool Pool) Pool {
+	for _, setting := range []struct {
+		env   string
+		value *int
+	}{
+		{MaxOpenConnsEnv, &pool.MaxOpenConns},
+		{MaxIdleConnsEnv, &pool.MaxIdleConns},
+	} {
+		value := os.Getenv(setting.env)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			log.Printf("Ignoring invalid %s %q", setting.env, value)
+			continue
+		}
+		*setting.value = n
+	}
+	for _, setting := range []struct {
+		env   string
+		value *time.Duration
+	}{
+		{ConnMaxIdleTimeEnv, &pool.ConnMaxIdleTime},
+		{ConnMaxLifetimeEnv, &pool.ConnMaxLifetime},
+	} {
+		value := os.Getenv(setting.env)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			log.Printf("Ignoring invalid %s %q", setting.env, value)
+			continue
+		}
+		*setting.value = d
+	}
+	return pool
+}
+
+// Apply sizes the pool of db
+func (p Pool) Apply(db *sql.DB) {
+	db.SetMaxOpenConns(p.MaxOpenConns)
+	db.SetMaxIdleConns(p.MaxIdleConns)
+	db.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+	db.SetConnMaxLifetime(p.ConnMaxLifetime)
+}
@@ -0,0 +1,31 @@
+package serverless
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDefaultPool(t *testing.T) {
+	if pool := DefaultPool(TargetLambda); pool.MaxOpenConns != 2 || pool.MaxIdleConns != 2 {
+		t.Errorf("lambda pool = %+v, want 2 open and 2 idle connections", pool)
+	}
+	if pool := DefaultPool(TargetCloudRun); pool.MaxOpenConns != 10 || pool.ConnMaxIdleTime != time.Minute {
+		t.Errorf("cloudrun pool = %+v, want 10 open connections idle for a minute", pool)
+	}
+	if pool := DefaultPool(TargetServer); pool != (Pool{MaxOpenConns: 25, MaxIdleConns: 5}) {
+		t.Errorf("server pool = %+v, want 25 open and 5 idle connections", pool)
+	}
+}
+
+func TestPoolFromEnv(t *testing.T) {
+	t.Setenv(MaxOpenConnsEnv, "4")
+	t.Setenv(MaxIdleConnsEnv, "-1")
+	t.Setenv(ConnMaxIdleTimeEnv, "30s")
+	t.Setenv(ConnMaxLifetimeEnv, "soon")
+
+	pool := PoolFromEnv(DefaultPool(TargetLambda))
+	want := Pool{MaxOpenConns: 4, MaxIdleConns: 2, ConnMaxIdleTime: 30 * time.Second, ConnMaxLifetime: 30 * time.Minute}
+	if pool != want {
+		t.Errorf("PoolFromEnv() = %+v, want %+v", pool, want)
+	}
+}
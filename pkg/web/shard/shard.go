@@ -0,0 +1,318 @@
+// Package shard routes the requests of resources declared with
+// @shard(by: field) to the database holding their records. A shard map names
+// the databases and assigns each shard key to one of them; generated routes
+// read the key of each request and serve it with that database's connection.
+//
+// The shard map is a JSON file named by CONDUIT_SHARD_MAP:
+//
+//	{
+//	  "shards": {
+//	    "eu": "postgres://eu-db/app",
+//	    "us": "postgres://us-db/app"
+//	  },
+//	  "keys": {"acme": "eu"}
+//	}
+//
+// Keys listed under "keys" are pinned to a shard; every other key is assigned
+// by hash. Requests name their key in the X-Shard-Key header or in a query
+// parameter named after the shard field, e.g. ?tenant_id=acme. Without a shard
+// map every request is served by the default database.
+package shard
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/conduit-lang/conduit/pkg/web/instrument"
+)
+
+const (
+	// MapEnvVar is the path of the JSON shard map
+	MapEnvVar = "CONDUIT_SHARD_MAP"
+	// KeyHeader carries the shard key of a request
+	KeyHeader = "X-Shard-Key"
+	// TopologyPath is where generated applications serve the shard topology
+	TopologyPath = "/debug/shards"
+)
+
+var (
+	// ErrNoKey is returned when a request to a sharded resource names no shard key.
+	ErrNoKey = errors.New("shard key is required")
+	// ErrKeyMismatch is returned when a record is written with a shard key other
+	// than the one its request was routed by.
+	ErrKeyMismatch = errors.New("record belongs to another shard")
+)
+
+// Provider resolves the database holding a shard key.
+type Provider interface {
+	// DB returns the connection to the database holding key
+	DB(ctx context.Context, key string) (*sql.DB, error)
+	// Topology describes the shards and their connection pools
+	Topology() Topology
+}
+
+// Topology describes the shards of a provider, as served on TopologyPath.
+type Topology struct {
+	Shards []ShardInfo `json:"shards"`
+}
+
+// ShardInfo describes one shard of a topology.
+type ShardInfo struct {
+	Name  string               `json:"name"`
+	Keys  []string             `json:"keys,omitempty"` // Keys pinned to the shard; others are assigned by hash
+	Stats instrument.PoolStats `json:"stats"`
+}
+
+// Map is a shard map: the databases records are spread over, and the keys
+// pinned to each of them.
+type Map struct {
+	Shards map[string]string `json:"shards"`         // Shard name to database URL
+	Keys   map[string]string `json:"keys,omitempty"` // Shard key to shard name, overriding the hashed assignment
+}
+
+// LoadMap reads and validates the shard map at path.
+func LoadMap(path string) (*Map, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shard map: %w", err)
+	}
+	var m Map
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid shard map %s: %w", path, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid shard map %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Validate checks that the map has at least one shard and that pinned keys
+// name one of them.
+func (m *Map) Validate() error {
+	if len(m.Shards) == 0 {
+		return errors.New("no shards defined")
+	}
+	for name, url := range m.Shards {
+		if url == "" {
+			return fmt.Errorf("shard %q has no database URL", name)
+		}
+	}
+	for key, name := range m.Keys {
+		if _, ok := m.Shards[name]; !ok {
+			return fmt.Errorf("key %q is pinned to unknown shard %q", key, name)
+		}
+	}
+	return nil
+}
+
+// Names returns the shard names in sorted order.
+func (m *Map) Names() []string {
+	names := make([]string, 0, len(m.Shards))
+	for name := range m.Shards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ShardFor returns the name of the shard holding key: the shard it is pinned
+// to, or one chosen by an FNV-1a hash of the key over the sorted shard names.
+// Adding a shard moves hashed keys, so pin existing keys before adding one.
+func (m *Map) ShardFor(key string) string {
+	if name, ok := m.Keys[key]; ok {
+		return name
+	}
+	names := m.Names()
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return names[h.Sum32()%uint32(len(names))]
+}
+
+// MapProvider serves a Map with one connection pool per shard.
+type MapProvider struct {
+	shards *Map
+	dbs    map[string]*sql.DB
+}
+
+// Open opens a connection pool for every shard of m with open, typically
+// instrument.Open with the application's driver.
+func Open(m *Map, open func(url string) (*sql.DB, error)) (*MapProvider, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	p := &MapProvider{shards: m, dbs: make(map[string]*sql.DB, len(m.Shards))}
+	for _, name := range m.Names() {
+		db, err := open(m.Shards[name])
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("failed to open shard %s: %w", name, err)
+		}
+		p.dbs[name] = db
+	}
+	return p, nil
+}
+
+// ProviderFromEnv opens the shard map named by CONDUIT_SHARD_MAP. It returns
+// nil when the variable is unset, so requests use the default database.
+func ProviderFromEnv(open func(url string) (*sql.DB, error)) (*MapProvider, error) {
+	path := os.Getenv(MapEnvVar)
+	if path == "" {
+		return nil, nil
+	}
+	m, err := LoadMap(path)
+	if err != nil {
+		return nil, err
+	}
+	return Open(m, open)
+}
+
+// DB returns the connection pool of the shard holding key.
+func (p *MapProvider) DB(ctx context.Context, key string) (*sql.DB, error) {
+	if key == "" {
+		return nil, ErrNoKey
+	}
+	return p.dbs[p.shards.ShardFor(key)], nil
+}
+
+// Topology lists the shards with their pinned keys and pool statistics.
+func (p *MapProvider) Topology() Topology {
+	pinned := make(map[string][]string)
+	for key, name := range p.shards.Keys {
+		pinned[name] = append(pinned[name], key)
+	}
+	topology := Topology{Shards: make([]ShardInfo, 0, len(p.dbs))}
+	for _, name := range p.shards.Names() {
+		keys := pinned[name]
+		sort.Strings(keys)
+		topology.Shards = append(topology.Shards, ShardInfo{
+			Name:  name,
+			Keys:  keys,
+			Stats: instrument.NewPoolStats(p.dbs[name].Stats()),
+		})
+	}
+	return topology
+}
+
+// Close closes every shard's connection pool.
+func (p *MapProvider) Close() error {
+	var errs []error
+	for _, db := range p.dbs {
+		errs = append(errs, db.Close())
+	}
+	return errors.Join(errs...)
+}
+
+var (
+	providerMu sync.RWMutex
+	provider   Provider
+)
+
+// SetProvider sets the provider generated routes resolve shards with. A nil
+// provider, the default, serves every request from the default database.
+func SetProvider(p Provider) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	provider = p
+}
+
+func current() Provider {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	return provider
+}
+
+type contextKey struct{}
+
+// WithKey returns a context carrying the shard key a request was routed by.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// KeyFromContext returns the shard key a request was routed by.
+func KeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(contextKey{}).(string)
+	return key, ok
+}
+
+// CheckKey returns ErrKeyMismatch when ctx was routed by a shard key other
+// than key, so a record is never written to a shard that does not hold it.
+func CheckKey(ctx context.Context, key string) error {
+	if routed, ok := KeyFromContext(ctx); ok && routed != key {
+		return fmt.Errorf("%w: shard key is %q but the request was routed by %q", ErrKeyMismatch, key, routed)
+	}
+	return nil
+}
+
+// KeyFromRequest returns the shard key named by the X-Shard-Key header or,
+// failing that, by the query parameter named after the shard field.
+func KeyFromRequest(r *http.Request, field string) string {
+	if key := r.Header.Get(KeyHeader); key != "" {
+		return key
+	}
+	return r.URL.Query().Get(field)
+}
+
+// Route returns a handler that serves each request with the handler newHandler
+// builds for the connection of its shard, keyed by field. Handlers are built
+// once per connection. Requests without a key fail with 400 when a provider is
+// set; without one, every request is served with fallback.
+func Route(fallback *sql.DB, field string, newHandler func(*sql.DB) http.HandlerFunc) http.HandlerFunc {
+	var handlers sync.Map // *sql.DB to http.HandlerFunc
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := KeyFromRequest(r, field)
+		db := fallback
+		if p := current(); p != nil {
+			if key == "" {
+				respondWithError(w, fmt.Sprintf("%s: send the %s header or the %s query parameter", ErrNoKey, KeyHeader, field), http.StatusBadRequest)
+				return
+			}
+			resolved, err := p.DB(r.Context(), key)
+			if err != nil {
+				respondWithError(w, fmt.Sprintf("failed to resolve shard: %v", err), http.StatusServiceUnavailable)
+				return
+			}
+			db = resolved
+		}
+		if key != "" {
+			r = r.WithContext(WithKey(r.Context(), key))
+		}
+
+		handler, ok := handlers.Load(db)
+		if !ok {
+			handler, _ = handlers.LoadOrStore(db, newHandler(db))
+		}
+		handler.(http.HandlerFunc)(w, r)
+	}
+}
+
+// TopologyHandler serves the topology of the current provider as JSON; an
+// application without a shard map has no shards.
+//
+// SECURITY NOTE: the topology names the shards and their load. Mount the
+// handler on an internal route or behind authentication in production.
+func TopologyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topology := Topology{Shards: []ShardInfo{}}
+		if p := current(); p != nil {
+			topology = p.Topology()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(topology)
+	}
+}
+
+func respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
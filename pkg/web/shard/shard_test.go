@@ -0,0 +1,165 @@
+package shard
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// openMock opens a sqlmock connection per shard URL
+func openMock(t *testing.T) func(string) (*sql.DB, error) {
+	return func(url string) (*sql.DB, error) {
+		db, _, err := sqlmock.NewWithDSN(url + "?" + t.Name())
+		return db, err
+	}
+}
+
+func TestLoadMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shards.json")
+	os.WriteFile(path, []byte(`{"shards": {"eu": "postgres://eu/app", "us": "postgres://us/app"}, "keys": {"acme": "eu"}}`), 0644)
+
+	m, err := LoadMap(path)
+	if err != nil {
+		t.Fatalf("LoadMap() error = %v", err)
+	}
+	if got := strings.Join(m.Names(), ","); got != "eu,us" {
+		t.Errorf("Names() = %s, want eu,us", got)
+	}
+
+	os.WriteFile(path, []byte(`{"shards": {"eu": "postgres://eu/app"}, "keys": {"acme": "us"}}`), 0644)
+	if _, err := LoadMap(path); err == nil || !strings.Contains(err.Error(), `unknown shard "us"`) {
+		t.Errorf("LoadMap() error = %v, want unknown shard", err)
+	}
+
+	os.WriteFile(path, []byte(`{"shards": {}}`), 0644)
+	if _, err := LoadMap(path); err == nil {
+		t.Error("LoadMap() should reject a map without shards")
+	}
+}
+
+func TestMap_ShardFor(t *testing.T) {
+	m := &Map{
+		Shards: map[string]string{"a": "postgres://a", "b": "postgres://b", "c": "postgres://c"},
+		Keys:   map[string]string{"acme": "c"},
+	}
+
+	if got := m.ShardFor("acme"); got != "c" {
+		t.Errorf("pinned key went to %s, want c", got)
+	}
+
+	used := make(map[string]bool)
+	for _, key := range []string{"t1", "t2", "t3", "t4", "t5", "t6", "t7", "t8", "t9", "t10"} {
+		shard := m.ShardFor(key)
+		if shard != m.ShardFor(key) {
+			t.Fatalf("ShardFor(%q) is not stable", key)
+		}
+		used[shard] = true
+	}
+	if len(used) < 2 {
+		t.Errorf("hashed keys all went to %v", used)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	provider, err := Open(&Map{
+		Shards: map[string]string{"eu": "eu", "us": "us"},
+		Keys:   map[string]string{"acme": "eu", "globex": "us"},
+	}, openMock(t))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer provider.Close()
+	fallback, _, _ := sqlmock.New()
+	defer fallback.Close()
+
+	built := 0
+	served := make(map[*sql.DB]string)
+	handler := Route(fallback, "tenant_id", func(db *sql.DB) http.HandlerFunc {
+		built++
+		return func(w http.ResponseWriter, r *http.Request) {
+			key, _ := KeyFromContext(r.Context())
+			served[db] = key
+		}
+	})
+
+	// Without a provider everything is served by the default database
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts", nil))
+	if _, ok := served[fallback]; !ok {
+		t.Fatal("expected the fallback database without a provider")
+	}
+
+	SetProvider(provider)
+	defer SetProvider(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	req.Header.Set(KeyHeader, "acme")
+	handler(httptest.NewRecorder(), req)
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts?tenant_id=globex", nil))
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts?tenant_id=acme", nil))
+
+	eu, _ := provider.DB(context.Background(), "acme")
+	us, _ := provider.DB(context.Background(), "globex")
+	if served[eu] != "acme" || served[us] != "globex" {
+		t.Errorf("served = %v", served)
+	}
+	if built != 3 {
+		t.Errorf("built %d handlers, want one per connection (3)", built)
+	}
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/posts", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), KeyHeader) {
+		t.Errorf("request without a key: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCheckKey(t *testing.T) {
+	if err := CheckKey(context.Background(), "acme"); err != nil {
+		t.Errorf("unrouted context: %v", err)
+	}
+	ctx := WithKey(context.Background(), "acme")
+	if err := CheckKey(ctx, "acme"); err != nil {
+		t.Errorf("same key: %v", err)
+	}
+	if err := CheckKey(ctx, "globex"); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("CheckKey() error = %v, want ErrKeyMismatch", err)
+	}
+}
+
+func TestTopologyHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	TopologyHandler()(rec, httptest.NewRequest(http.MethodGet, TopologyPath, nil))
+	if strings.TrimSpace(rec.Body.String()) != `{"shards":[]}` {
+		t.Errorf("topology without a provider = %s", rec.Body.String())
+	}
+
+	provider, err := Open(&Map{
+		Shards: map[string]string{"eu": "eu", "us": "us"},
+		Keys:   map[string]string{"acme": "eu", "initech": "eu"},
+	}, openMock(t))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer provider.Close()
+	SetProvider(provider)
+	defer SetProvider(nil)
+
+	rec = httptest.NewRecorder()
+	TopologyHandler()(rec, httptest.NewRequest(http.MethodGet, TopologyPath, nil))
+	var topology Topology
+	if err := json.Unmarshal(rec.Body.Bytes(), &topology); err != nil {
+		t.Fatal(err)
+	}
+	if len(topology.Shards) != 2 || topology.Shards[0].Name != "eu" || strings.Join(topology.Shards[0].Keys, ",") != "acme,initech" {
+		t.Errorf("topology = %+v", topology)
+	}
+}
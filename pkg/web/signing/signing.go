@@ -0,0 +1,236 @@
+// Package signing verifies HMAC-signed server-to-server requests to the routes
+// of resources declared with @middleware [signed_request(SECRET_REF)]. The
+// shared secret is read from the environment variable SECRET_REF, never from
+// the source or conduit.yaml.
+//
+// A caller signs each request with the current Unix time:
+//
+//	X-Conduit-Timestamp: 1792152000
+//	X-Conduit-Signature: v1=<hex HMAC-SHA256 of "<timestamp>.<METHOD>.<request URI>.<body>">
+//
+// Several v1 signatures may be sent while a secret is rolled. Requests signed
+// more than the tolerance before or after now are rejected, and so is a second
+// delivery of the same signed request: its signature is remembered in the
+// store (memory, or Redis shared by all instances when CONDUIT_REDIS_ADDR is
+// set) until it could no longer pass the timestamp check.
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conduit-lang/conduit/internal/web/cache"
+)
+
+const (
+	// TimestampHeader carries the Unix time a request was signed at
+	TimestampHeader = "X-Conduit-Timestamp"
+
+	// SignatureHeader carries one or more v1=<hex> signatures
+	SignatureHeader = "X-Conduit-Signature"
+
+	// RedisAddrEnvVar selects a Redis store shared by all instances for
+	// replay protection; signatures are kept in memory when it is not set
+	RedisAddrEnvVar = "CONDUIT_REDIS_ADDR"
+
+	// MaxBodySize is the largest request body a signed route reads
+	MaxBodySize = 1 << 20
+
+	// DefaultTolerance is how far a request's timestamp may be from now
+	DefaultTolerance = 5 * time.Minute
+
+	// replayKeyPrefix namespaces remembered signatures in the store
+	replayKeyPrefix = "signed_request:"
+)
+
+var (
+	// ErrInvalidSignature is returned when a request is unsigned, signed with
+	// another secret or outside the tolerance.
+	ErrInvalidSignature = errors.New("invalid request signature")
+
+	// ErrReplayed is returned when a signed request was already accepted.
+	ErrReplayed = errors.New("replayed request")
+)
+
+// Verifier checks request signatures made with one shared secret.
+type Verifier struct {
+	secret    string
+	store     cache.Adder
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+// NewVerifier returns a Verifier for secret that remembers accepted
+// signatures in store. Instances sharing a store accept each signed request
+// once between them, as store adds a signature only when it is absent.
+func NewVerifier(secret string, store cache.Adder) *Verifier {
+	return &Verifier{
+		secret:    secret,
+		store:     store,
+		tolerance: DefaultTolerance,
+		now:       time.Now,
+	}
+}
+
+// Verify checks that body was signed with the verifier's secret for r's
+// method and URI, within the tolerance, and not accepted before.
+func (v *Verifier) Verify(ctx context.Context, r *http.Request, body []byte) error {
+	timestamp := r.Header.Get(TimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing or malformed timestamp", ErrInvalidSignature)
+	}
+
+	expected := signature(r.Method, r.URL.RequestURI(), body, timestamp, v.secret)
+	matched := false
+	for _, part := range strings.Split(r.Header.Get(SignatureHeader), ",") {
+		version, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || version != "v1" {
+			continue
+		}
+		decoded, err := hex.DecodeString(value)
+		if err == nil && hmac.Equal(decoded, expected) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return fmt.Errorf("%w: no matching signature", ErrInvalidSignature)
+	}
+
+	skew := v.now().Sub(time.Unix(unix, 0))
+	if skew > v.tolerance || skew < -v.tolerance {
+		return fmt.Errorf("%w: timestamp outside the tolerance", ErrInvalidSignature)
+	}
+
+	// The signature covers the timestamp, so it identifies this delivery.
+	// Checking and remembering it is one operation, so concurrent deliveries
+	// to several instances cannot all see it as new.
+	key := replayKeyPrefix + hex.EncodeToString(expected)
+	added, err := v.store.SetNX(ctx, key, []byte(timestamp), 2*v.tolerance)
+	if err != nil {
+		return fmt.Errorf("failed to check for replays: %w", err)
+	}
+	if !added {
+		return ErrReplayed
+	}
+	return nil
+}
+
+// Middleware rejects requests that fail Verify with 401 Unauthorized. The
+// body is read once and handed on to next unchanged.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+		if err != nil {
+			respondWithError(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err := v.Verify(r.Context(), r, body); err != nil {
+			switch {
+			case errors.Is(err, ErrReplayed):
+				respondWithError(w, "Request was already received", http.StatusUnauthorized)
+			case errors.Is(err, ErrInvalidSignature):
+				respondWithError(w, "Invalid request signature", http.StatusUnauthorized)
+			default:
+				respondWithError(w, "Failed to verify request", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Sign sets the timestamp and signature headers of a request to send with
+// body at t, for callers written in Go and for tests.
+func Sign(r *http.Request, body []byte, secret string, t time.Time) {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	r.Header.Set(TimestampHeader, timestamp)
+	r.Header.Set(SignatureHeader, "v1="+hex.EncodeToString(signature(r.Method, r.URL.RequestURI(), body, timestamp, secret)))
+}
+
+// signature computes the v1 signature of a request
+func signature(method, uri string, body []byte, timestamp, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + method + "." + uri + "."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+var (
+	storeMu sync.Mutex
+	store   cache.Adder
+)
+
+// Configure checks that the secret of every reference is set and opens the
+// store signatures are remembered in. Generated applications call it at
+// startup so a missing secret fails fast rather than on the first request.
+func Configure(refs ...string) error {
+	for _, ref := range refs {
+		if os.Getenv(ref) == "" {
+			return fmt.Errorf("%s is required for signed requests", ref)
+		}
+	}
+
+	var s cache.Adder
+	if addr := os.Getenv(RedisAddrEnvVar); addr != "" {
+		config := cache.DefaultRedisConfig()
+		config.Addr = addr
+		redis, err := cache.NewRedisCacheWithConfig(config)
+		if err != nil {
+			return fmt.Errorf("failed to connect to the signed request store: %w", err)
+		}
+		s = redis
+	} else {
+		s = cache.NewMemoryCache()
+	}
+
+	storeMu.Lock()
+	store = s
+	storeMu.Unlock()
+	return nil
+}
+
+// Middleware returns middleware verifying requests signed with the secret in
+// the environment variable ref, remembering signatures in the configured
+// store. Without the secret every request is refused.
+func Middleware(ref string) func(http.Handler) http.Handler {
+	secret := os.Getenv(ref)
+	if secret == "" {
+		return func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				respondWithError(w, "Signed requests are not configured", http.StatusInternalServerError)
+			})
+		}
+	}
+
+	storeMu.Lock()
+	if store == nil {
+		store = cache.NewMemoryCache()
+	}
+	verifier := NewVerifier(secret, store)
+	storeMu.Unlock()
+	return verifier.Middleware
+}
+
+func respondWithError(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,215 @@
+package signing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/conduit-lang/conduit/internal/web/cache"
+)
+
+const testSecret = "partner-secret"
+
+var signedAt = time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+
+func newTestVerifier(t *testing.T) *Verifier {
+	t.Helper()
+	store := cache.NewMemoryCache()
+	t.Cleanup(func() { store.Close() })
+	v := NewVerifier(testSecret, store)
+	v.now = func() time.Time { return signedAt.Add(time.Minute) }
+	return v
+}
+
+func signedRequest(body, secret string, t time.Time) *http.Request {
+	req := httptest.NewRequest("POST", "/partner_events?source=acme", strings.NewReader(body))
+	Sign(req, []byte(body), secret, t)
+	return req
+}
+
+func serve(v *Verifier, req *http.Request) (*httptest.ResponseRecorder, string) {
+	var received string
+	handler := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, received
+}
+
+func TestMiddleware_Accepts(t *testing.T) {
+	v := newTestVerifier(t)
+
+	rec, body := serve(v, signedRequest(`{"id":1}`, testSecret, signedAt))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if body != `{"id":1}` {
+		t.Errorf("handler read %q, want the signed body", body)
+	}
+}
+
+func TestMiddleware_Rejects(t *testing.T) {
+	tampered := signedRequest(`{"id":1}`, testSecret, signedAt)
+	tampered.Body = io.NopCloser(strings.NewReader(`{"id":2}`))
+
+	otherPath := signedRequest(`{"id":1}`, testSecret, signedAt)
+	otherPath.URL.Path = "/admin_events"
+
+	unsigned := httptest.NewRequest("POST", "/partner_events", strings.NewReader(`{}`))
+
+	tests := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"unsigned", unsigned},
+		{"other secret", signedRequest(`{"id":1}`, "guess", signedAt)},
+		{"tampered body", tampered},
+		{"other path", otherPath},
+		{"too old", signedRequest(`{"id":1}`, testSecret, signedAt.Add(-10*time.Minute))},
+		{"too far ahead", signedRequest(`{"id":1}`, testSecret, signedAt.Add(10*time.Minute))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, _ := serve(newTestVerifier(t), tt.req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want 401", rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), "Invalid request signature") {
+				t.Errorf("body = %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMiddleware_RotatedSecret(t *testing.T) {
+	req := signedRequest(`{}`, testSecret, signedAt)
+	rotated := req.Header.Get(SignatureHeader)
+	Sign(req, []byte(`{}`), "old-secret", signedAt)
+	req.Header.Set(SignatureHeader, req.Header.Get(SignatureHeader)+", "+rotated)
+
+	if rec, _ := serve(newTestVerifier(t), req); rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want any v1 signature to match", rec.Code)
+	}
+}
+
+func TestMiddleware_Replay(t *testing.T) {
+	v := newTestVerifier(t)
+
+	if rec, _ := serve(v, signedRequest(`{"id":1}`, testSecret, signedAt)); rec.Code != http.StatusCreated {
+		t.Fatalf("first status = %d", rec.Code)
+	}
+	rec, _ := serve(v, signedRequest(`{"id":1}`, testSecret, signedAt))
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "already received") {
+		t.Errorf("replay = %d %s, want 401", rec.Code, rec.Body.String())
+	}
+
+	// A new timestamp makes a new request
+	if rec, _ := serve(v, signedRequest(`{"id":1}`, testSecret, signedAt.Add(time.Second))); rec.Code != http.StatusCreated {
+		t.Errorf("re-signed status = %d, want 201", rec.Code)
+	}
+}
+
+// TestMiddleware_ConcurrentReplay delivers one signed request to several
+// instances at once; only one of them may accept it.
+func TestMiddleware_ConcurrentReplay(t *testing.T) {
+	mr := miniredis.RunT(t)
+	memory := cache.NewMemoryCache()
+	t.Cleanup(func() { memory.Close() })
+
+	stores := map[string]func() cache.Adder{
+		"memory": func() cache.Adder { return memory },
+		"redis": func() cache.Adder {
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			t.Cleanup(func() { client.Close() })
+			return cache.NewRedisCacheWithClient(client, cache.DefaultCacheConfig())
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			// Each instance has its own verifier and connection to the store
+			instances := make([]*Verifier, 4)
+			for i := range instances {
+				instances[i] = NewVerifier(testSecret, newStore())
+				instances[i].now = func() time.Time { return signedAt.Add(time.Minute) }
+			}
+
+			var wg sync.WaitGroup
+			var accepted, replayed atomic.Int32
+			for i := 0; i < 40; i++ {
+				wg.Add(1)
+				go func(v *Verifier) {
+					defer wg.Done()
+					switch rec, _ := serve(v, signedRequest(`{"id":1}`, testSecret, signedAt)); rec.Code {
+					case http.StatusCreated:
+						accepted.Add(1)
+					case http.StatusUnauthorized:
+						replayed.Add(1)
+					}
+				}(instances[i%len(instances)])
+			}
+			wg.Wait()
+
+			if accepted.Load() != 1 || replayed.Load() != 39 {
+				t.Errorf("accepted %d and replayed %d deliveries, want 1 and 39", accepted.Load(), replayed.Load())
+			}
+		})
+	}
+}
+
+// failingStore is a store that is unavailable
+type failingStore struct{}
+
+func (failingStore) SetNX(context.Context, string, []byte, time.Duration) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestMiddleware_StoreUnavailable(t *testing.T) {
+	v := newTestVerifier(t)
+	v.store = failingStore{}
+
+	rec, _ := serve(v, signedRequest(`{}`, testSecret, signedAt))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want requests refused without replay protection", rec.Code)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	t.Setenv(RedisAddrEnvVar, "")
+	t.Setenv("PARTNER_SECRET", "")
+
+	if err := Configure("PARTNER_SECRET"); err == nil || !strings.Contains(err.Error(), "PARTNER_SECRET") {
+		t.Errorf("Configure() error = %v, want the missing secret named", err)
+	}
+
+	// Routes refuse requests rather than accept them unverified
+	rec := httptest.NewRecorder()
+	Middleware("PARTNER_SECRET")(http.NotFoundHandler()).ServeHTTP(rec, signedRequest(`{}`, "", signedAt))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500 without a secret", rec.Code)
+	}
+
+	t.Setenv("PARTNER_SECRET", testSecret)
+	if err := Configure("PARTNER_SECRET"); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	rec = httptest.NewRecorder()
+	Middleware("PARTNER_SECRET")(http.NotFoundHandler()).ServeHTTP(rec, signedRequest(`{}`, testSecret, time.Now()))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want the request passed on", rec.Code)
+	}
+}
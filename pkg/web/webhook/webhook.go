@@ -0,0 +1,76 @@
+// Package webhook verifies the signed event deliveries received by the routes
+// of resources declared with @webhook. The generated POST /webhooks/<provider>
+// handler reads the request body, verifies it and records the event in the
+// resource's table once per event ID:
+//
+//	event, err := webhook.Verify("stripe", r.Header, body)
+//	if err != nil {
+//		// ErrInvalidSignature and ErrInvalidEvent reject the delivery;
+//		// ErrNotConfigured is a server error
+//	}
+//
+// Signing secrets are read from the environment (STRIPE_WEBHOOK_SECRET), never
+// from conduit.yaml.
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// StripeSecretEnvVar holds the Stripe endpoint signing secret (whsec_...)
+	StripeSecretEnvVar = "STRIPE_WEBHOOK_SECRET"
+
+	// MaxBodySize is the largest request body a webhook route reads
+	MaxBodySize = 1 << 20
+
+	// DefaultTolerance is how old a signed delivery may be before it is
+	// rejected as a possible replay
+	DefaultTolerance = 5 * time.Minute
+)
+
+// Providers accepted by Verify
+const (
+	ProviderStripe = "stripe"
+)
+
+var (
+	// ErrNotConfigured is returned when the provider's signing secret is not
+	// set or the provider is unsupported.
+	ErrNotConfigured = errors.New("webhook is not configured")
+
+	// ErrInvalidSignature is returned when a delivery is unsigned, signed with
+	// another secret or older than the tolerance.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+
+	// ErrInvalidEvent is returned when a verified body is not an event.
+	ErrInvalidEvent = errors.New("invalid webhook event")
+)
+
+// Event identifies a verified delivery.
+type Event struct {
+	ID   string // Provider's event ID, the same on every redelivery
+	Type string // Event type, e.g. "payment_intent.succeeded"
+}
+
+// Verify checks that body was signed by provider with the secret configured
+// in the environment and returns the event it carries.
+func Verify(provider string, header http.Header, body []byte) (*Event, error) {
+	switch provider {
+	case ProviderStripe:
+		secret := os.Getenv(StripeSecretEnvVar)
+		if secret == "" {
+			return nil, fmt.Errorf("%w: %s is not set", ErrNotConfigured, StripeSecretEnvVar)
+		}
+		if err := VerifyStripe(body, header.Get(StripeSignatureHeader), secret, DefaultTolerance, time.Now()); err != nil {
+			return nil, err
+		}
+		return ParseStripeEvent(body)
+	default:
+		return nil, fmt.Errorf("%w: unsupported provider %s", ErrNotConfigured, provider)
+	}
+}
@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "whsec_test"
+
+var testEvent = []byte(`{"id":"evt_123","object":"event","type":"payment_intent.succeeded","data":{"object":{"id":"pi_1"}}}`)
+
+func TestVerifyStripe(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	signed := SignStripe(testEvent, testSecret, now)
+	_, signature, _ := strings.Cut(signed, ",v1=")
+
+	tests := []struct {
+		name    string
+		body    []byte
+		header  string
+		now     time.Time
+		wantErr bool
+	}{
+		{"valid", testEvent, signed, now, false},
+		{"within tolerance", testEvent, signed, now.Add(4 * time.Minute), false},
+		{"rolled secret", testEvent, "t=1700000000,v1=" + strings.Repeat("0", 64) + ",v1=" + signature, now, false},
+		{"v0 ignored", testEvent, "t=1700000000,v0=" + signature, now, true},
+		{"tampered body", []byte(strings.Replace(string(testEvent), "succeeded", "failed", 1)), signed, now, true},
+		{"other secret", testEvent, SignStripe(testEvent, "whsec_other", now), now, true},
+		{"replayed", testEvent, signed, now.Add(6 * time.Minute), true},
+		{"missing header", testEvent, "", now, true},
+		{"missing timestamp", testEvent, "v1=" + signature, now, true},
+		{"malformed timestamp", testEvent, "t=soon,v1=" + signature, now, true},
+		{"malformed signature", testEvent, "t=1700000000,v1=zz", now, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyStripe(tt.body, tt.header, testSecret, DefaultTolerance, tt.now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSignature) {
+					t.Errorf("VerifyStripe() error = %v, want ErrInvalidSignature", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("VerifyStripe() error = %v", err)
+			}
+		})
+	}
+
+	// A zero tolerance accepts old deliveries
+	if err := VerifyStripe(testEvent, signed, testSecret, 0, now.Add(24*time.Hour)); err != nil {
+		t.Errorf("VerifyStripe() with zero tolerance error = %v", err)
+	}
+}
+
+func TestParseStripeEvent(t *testing.T) {
+	event, err := ParseStripeEvent(testEvent)
+	if err != nil {
+		t.Fatalf("ParseStripeEvent() error = %v", err)
+	}
+	if event.ID != "evt_123" || event.Type != "payment_intent.succeeded" {
+		t.Errorf("ParseStripeEvent() = %+v", event)
+	}
+
+	for _, body := range []string{`not json`, `{"type":"charge.refunded"}`, `{"id":"evt_1"}`} {
+		if _, err := ParseStripeEvent([]byte(body)); !errors.Is(err, ErrInvalidEvent) {
+			t.Errorf("ParseStripeEvent(%s) error = %v, want ErrInvalidEvent", body, err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	header := http.Header{}
+	header.Set(StripeSignatureHeader, SignStripe(testEvent, testSecret, time.Now()))
+
+	t.Setenv(StripeSecretEnvVar, "")
+	if _, err := Verify(ProviderStripe, header, testEvent); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Verify() without a secret error = %v, want ErrNotConfigured", err)
+	}
+
+	t.Setenv(StripeSecretEnvVar, testSecret)
+	event, err := Verify(ProviderStripe, header, testEvent)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if event.ID != "evt_123" {
+		t.Errorf("Verify() event ID = %q, want evt_123", event.ID)
+	}
+
+	if _, err := Verify(ProviderStripe, http.Header{}, testEvent); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() unsigned error = %v, want ErrInvalidSignature", err)
+	}
+	if _, err := Verify("paypal", header, testEvent); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Verify() unsupported provider error = %v, want ErrNotConfigured", err)
+	}
+}
@@ -1,5 +1,11 @@
 package metadata
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 // GetRegistry returns the global registry singleton.
 // This is the primary entry point for runtime introspection.
 //
@@ -21,7 +27,8 @@ func GetRegistry() *RegistryAPI {
 // It wraps the internal registry implementation with type-safe, error-safe methods.
 //
 // All query methods leverage pre-computed indexes for fast O(1) or O(log n) lookups.
-// The underlying metadata never changes at runtime, so results are cached for performance.
+// Results are cached for performance until new metadata is registered, for
+// example by Watch.
 //
 // Example usage:
 //
@@ -214,7 +221,7 @@ func (r *RegistryAPI) Patterns(category string) []PatternMetadata {
 //   - Reverse: If true, finds what depends on this resource (reverse dependencies)
 //   - Types: Filter edges by relationship type (e.g., ["belongs_to", "has_many"])
 //
-// Results are cached for performance since metadata never changes at runtime.
+// Results are cached for performance until new metadata is registered.
 //
 // Example usage:
 //
@@ -249,6 +256,43 @@ func (r *RegistryAPI) Dependencies(resource string, opts DependencyOptions) (*De
 	return QueryDependencies(resource, opts)
 }
 
+// Search finds text across the metadata: resource and field names,
+// documentation, hook source code and pattern templates. Results are ranked
+// with the best match first; see Search for how.
+//
+// Returns an error if the query is empty, a kind or the resource in opts is
+// unknown, or the registry is not initialized.
+//
+// Example usage:
+//
+//	registry := metadata.GetRegistry()
+//
+//	// Find everything about slugs
+//	results, err := registry.Search("slug", metadata.SearchOptions{})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, r := range results {
+//		fmt.Printf("%s %s.%s: %s\n", r.Kind, r.Resource, r.Name, r.Snippet)
+//	}
+//
+//	// Find hooks of Post sending email
+//	results, err := registry.Search("send email", metadata.SearchOptions{
+//		Kinds:    []string{metadata.SearchHook},
+//		Resource: "Post",
+//	})
+func (r *RegistryAPI) Search(query string, opts SearchOptions) ([]SearchResult, error) {
+	// Hook source code and patterns may not be decoded yet
+	if err := globalRegistry.complete(); err != nil {
+		return nil, err
+	}
+	meta := registered()
+	if meta == nil {
+		return nil, fmt.Errorf("registry not initialized")
+	}
+	return Search(meta, query, opts)
+}
+
 // GetSchema returns the complete metadata schema.
 //
 // This returns the entire Metadata structure containing all resources,
@@ -273,3 +317,37 @@ func (r *RegistryAPI) Dependencies(resource string, opts DependencyOptions) (*De
 func (r *RegistryAPI) GetSchema() *Metadata {
 	return GetMetadata()
 }
+
+// ETag returns a quoted entity tag identifying the registered metadata, or
+// "" when none is registered. It changes only when the metadata does, so
+// polling tools can compare it with the tag they last saw instead of
+// reparsing the metadata.
+//
+// Example usage:
+//
+//	registry := metadata.GetRegistry()
+//	if registry.Changed(lastETag) {
+//		refreshContext(registry.GetSchema())
+//		lastETag = registry.ETag()
+//	}
+func (r *RegistryAPI) ETag() string {
+	globalRegistry.mu.RLock()
+	defer globalRegistry.mu.RUnlock()
+	return globalRegistry.etag
+}
+
+// LastModified returns when the registered metadata was generated, to the
+// second, or when it was registered if it carries no generation time. It is
+// zero when no metadata is registered.
+func (r *RegistryAPI) LastModified() time.Time {
+	globalRegistry.mu.RLock()
+	defer globalRegistry.mu.RUnlock()
+	return globalRegistry.lastModified
+}
+
+// Changed reports whether the registered metadata differs from the version
+// tagged etag. Weak and strong forms of a tag compare equal.
+func (r *RegistryAPI) Changed(etag string) bool {
+	current := r.ETag()
+	return current == "" || strings.TrimPrefix(etag, "W/") != current
+}
@@ -385,6 +385,46 @@ func TestRegistryGetSchema(t *testing.T) {
 }
 
 // TestRegistryUninitializedRegistry tests behavior when registry is not initialized
+// TestRegistryETag tests the version tag polling consumers compare
+func TestRegistryETag(t *testing.T) {
+	Reset()
+	registry := GetRegistry()
+	if registry.ETag() != "" || !registry.LastModified().IsZero() {
+		t.Error("expected no version from uninitialized registry")
+	}
+	if !registry.Changed(`"test-hash"`) {
+		t.Error("expected an uninitialized registry to report a change")
+	}
+
+	setupTestMetadata(t)
+	defer Reset()
+
+	if etag := registry.ETag(); etag != `"test-hash"` {
+		t.Errorf("expected ETag from the source hash, got %s", etag)
+	}
+	if modified := registry.LastModified(); modified.IsZero() || modified.Nanosecond() != 0 {
+		t.Errorf("expected the generation time to the second, got %v", modified)
+	}
+	if registry.Changed(`"test-hash"`) || registry.Changed(`W/"test-hash"`) {
+		t.Error("expected no change for the current tag")
+	}
+	if !registry.Changed(`"old-hash"`) {
+		t.Error("expected a change for an old tag")
+	}
+
+	// Without a source hash or generation time the content is hashed
+	Reset()
+	if err := RegisterMetadata([]byte(`{"version": "1.0", "resources": []}`)); err != nil {
+		t.Fatal(err)
+	}
+	if etag := registry.ETag(); len(etag) != 66 {
+		t.Errorf("expected a quoted sha256 tag, got %s", etag)
+	}
+	if registry.LastModified().IsZero() {
+		t.Error("expected the registration time")
+	}
+}
+
 func TestRegistryUninitializedRegistry(t *testing.T) {
 	// Ensure registry is reset
 	Reset()
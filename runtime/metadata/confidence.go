@@ -0,0 +1,184 @@
+package metadata
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// FrequencySaturation is the number of occurrences at which frequency
+	// stops adding confidence
+	FrequencySaturation = 10
+
+	// RecencyHalfLife is the age at which an example counts half as much
+	// towards recency as one changed today
+	RecencyHalfLife = 90 * 24 * time.Hour
+)
+
+// ConfidenceFactors are the inputs of a pattern's confidence score. Each is
+// normalized to 0.0-1.0 except Frequency.
+type ConfidenceFactors struct {
+	Frequency   int     // Occurrences of the pattern
+	Consistency float64 // Share of examples that follow the template
+	Recency     float64 // Mean freshness of the examples; 1.0 when unknown
+}
+
+// ConfidenceScore is a confidence score with the factors it was computed
+// from, so tools can show how it was reached.
+type ConfidenceScore struct {
+	ConfidenceFactors
+	Score float64
+}
+
+// ScoreConfidence computes the confidence of a pattern, from 0.0 to 1.0:
+//
+//	score = min(frequency/FrequencySaturation, 1)
+//	      × (0.5 + 0.5 × consistency)
+//	      × (0.75 + 0.25 × recency)
+//
+// Frequency dominates. A pattern whose examples all stray from its template
+// keeps half its score, and one whose examples are all long untouched keeps
+// three quarters. With full consistency and recency the score is the
+// frequency share alone. Scores are rounded to two decimals.
+func ScoreConfidence(factors ConfidenceFactors) ConfidenceScore {
+	return ConfidenceScore{
+		ConfidenceFactors: factors,
+		Score:             math.Round(frequencyFactor(factors.Frequency)*consistencyFactor(factors.Consistency)*recencyFactor(factors.Recency)*100) / 100,
+	}
+}
+
+// PatternConfidence scores pattern from its frequency, how many of its
+// examples follow its template, and how recently their files changed as
+// of now. Scores meant to be reproducible, such as those in metadata.json,
+// are taken as of a time fixed by the sources, like LatestModified.
+func PatternConfidence(pattern PatternMetadata, now time.Time) ConfidenceScore {
+	return ScoreConfidence(ConfidenceFactors{
+		Frequency:   pattern.Frequency,
+		Consistency: TemplateConsistency(pattern.Template, pattern.Examples),
+		Recency:     ExampleRecency(pattern.Examples, now),
+	})
+}
+
+// Explain describes how the score was reached, one factor at a time.
+func (s ConfidenceScore) Explain() string {
+	return fmt.Sprintf("%.2f = frequency %.2f (%d of %d) × consistency %.2f (%.0f%% follow the template) × recency %.2f (%.0f%% fresh)",
+		s.Score,
+		frequencyFactor(s.Frequency), s.Frequency, FrequencySaturation,
+		consistencyFactor(s.Consistency), clamp(s.Consistency)*100,
+		recencyFactor(s.Recency), clamp(s.Recency)*100)
+}
+
+// TemplateConsistency returns the share of examples whose code follows
+// template. Placeholders such as <operation> and "..." in the template match
+// any text, and runs of whitespace match any whitespace. Examples without
+// code are not counted; with none, the examples are taken as consistent.
+func TemplateConsistency(template string, examples []PatternExample) float64 {
+	pattern := templatePattern(template)
+	total, matched := 0, 0
+	for _, example := range examples {
+		code := strings.TrimSpace(example.Code)
+		if code == "" {
+			continue
+		}
+		total++
+		if pattern.MatchString(code) {
+			matched++
+		}
+	}
+	if total == 0 {
+		return 1.0
+	}
+	return float64(matched) / float64(total)
+}
+
+// ExampleRecency returns the mean freshness of the examples as of now. An
+// example changed now counts 1.0, halving every RecencyHalfLife. Examples
+// without a modification time are not counted; with none, recency is 1.0.
+func ExampleRecency(examples []PatternExample, now time.Time) float64 {
+	total, count := 0.0, 0
+	for _, example := range examples {
+		if example.ModifiedAt == nil {
+			continue
+		}
+		age := now.Sub(*example.ModifiedAt)
+		if age < 0 {
+			age = 0
+		}
+		total += math.Pow(0.5, float64(age)/float64(RecencyHalfLife))
+		count++
+	}
+	if count == 0 {
+		return 1.0
+	}
+	return total / float64(count)
+}
+
+// LatestModified returns the latest modification time of the examples, or
+// the zero time when none has one. Recency measured as of it depends only on
+// the examples, not on when they are scored.
+func LatestModified(examples []PatternExample) time.Time {
+	var latest time.Time
+	for _, example := range examples {
+		if example.ModifiedAt != nil && example.ModifiedAt.After(latest) {
+			latest = *example.ModifiedAt
+		}
+	}
+	return latest
+}
+
+// templatePlaceholder matches the parts of a template standing for any text
+var templatePlaceholder = regexp.MustCompile(`<[^<>\s]+>|\.\.\.`)
+
+// templatePattern compiles template into a regular expression matching the
+// code that follows it
+func templatePattern(template string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+	last := 0
+	for _, loc := range templatePlaceholder.FindAllStringIndex(template, -1) {
+		b.WriteString(literalPattern(template[last:loc[0]]))
+		b.WriteString(`.*?`)
+		last = loc[1]
+	}
+	b.WriteString(literalPattern(template[last:]))
+	b.WriteString(`$`)
+	return regexp.MustCompile(b.String())
+}
+
+// literalPattern quotes text, letting each run of whitespace match any
+func literalPattern(text string) string {
+	fields := strings.Fields(text)
+	for i, field := range fields {
+		fields[i] = regexp.QuoteMeta(field)
+	}
+	pattern := strings.Join(fields, `\s+`)
+	if len(fields) > 0 && strings.TrimLeft(text, " \t\n") != text {
+		pattern = `\s*` + pattern
+	}
+	if len(fields) > 0 && strings.TrimRight(text, " \t\n") != text {
+		pattern += `\s*`
+	}
+	if len(fields) == 0 && text != "" {
+		pattern = `\s*`
+	}
+	return pattern
+}
+
+func frequencyFactor(frequency int) float64 {
+	return math.Min(float64(frequency)/FrequencySaturation, 1.0)
+}
+
+func consistencyFactor(consistency float64) float64 {
+	return 0.5 + 0.5*clamp(consistency)
+}
+
+func recencyFactor(recency float64) float64 {
+	return 0.75 + 0.25*clamp(recency)
+}
+
+func clamp(value float64) float64 {
+	return math.Max(0, math.Min(value, 1))
+}
@@ -0,0 +1,116 @@
+package metadata
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScoreConfidence(t *testing.T) {
+	tests := []struct {
+		name    string
+		factors ConfidenceFactors
+		want    float64
+	}{
+		{"frequency alone", ConfidenceFactors{Frequency: 5, Consistency: 1, Recency: 1}, 0.5},
+		{"saturated", ConfidenceFactors{Frequency: 40, Consistency: 1, Recency: 1}, 1.0},
+		{"inconsistent", ConfidenceFactors{Frequency: 10, Consistency: 0, Recency: 1}, 0.5},
+		{"stale", ConfidenceFactors{Frequency: 10, Consistency: 1, Recency: 0}, 0.75},
+		{"half of each", ConfidenceFactors{Frequency: 8, Consistency: 0.5, Recency: 0.5}, 0.53},
+		{"out of range", ConfidenceFactors{Frequency: 10, Consistency: 2, Recency: -1}, 0.75},
+		{"never seen", ConfidenceFactors{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScoreConfidence(tt.factors).Score; got != tt.want {
+				t.Errorf("ScoreConfidence(%+v) = %v, want %v", tt.factors, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateConsistency(t *testing.T) {
+	examples := []PatternExample{
+		{Code: "@on create: [auth]"},
+		{Code: "@on  update:\n[auth]"},
+		{Code: "@on delete: [auth, cache]"},
+		{Resource: "NoCode"},
+	}
+	if got := TemplateConsistency("@on <operation>: [auth]", examples); got != 2.0/3 {
+		t.Errorf("TemplateConsistency() = %v, want 2/3", got)
+	}
+	if got := TemplateConsistency("@on <operation>: [auth, ...]", examples); got != 1.0/3 {
+		t.Errorf("with a trailing wildcard = %v, want 1/3", got)
+	}
+	if got := TemplateConsistency("@before create { ... }", []PatternExample{{Code: "@before create {\n  self.slug = slugify(self.title)\n}"}}); got != 1 {
+		t.Errorf("with a body wildcard = %v, want 1", got)
+	}
+	if got := TemplateConsistency("field: type! @unique", []PatternExample{{Resource: "Post"}}); got != 1 {
+		t.Errorf("without code = %v, want 1", got)
+	}
+}
+
+func TestExampleRecency(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(age time.Duration) *time.Time {
+		modified := now.Add(-age)
+		return &modified
+	}
+
+	examples := []PatternExample{
+		{ModifiedAt: at(0)},
+		{ModifiedAt: at(RecencyHalfLife)},
+		{Resource: "Unknown"},
+	}
+	if got := ExampleRecency(examples, now); got != 0.75 {
+		t.Errorf("ExampleRecency() = %v, want 0.75", got)
+	}
+	if got := ExampleRecency([]PatternExample{{ModifiedAt: at(-time.Hour)}}, now); got != 1 {
+		t.Errorf("future modification = %v, want 1", got)
+	}
+	if got := ExampleRecency([]PatternExample{{Resource: "Unknown"}}, now); got != 1 {
+		t.Errorf("without times = %v, want 1", got)
+	}
+}
+
+func TestLatestModified(t *testing.T) {
+	earlier := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(RecencyHalfLife)
+	examples := []PatternExample{{ModifiedAt: &earlier}, {Resource: "Unknown"}, {ModifiedAt: &later}}
+	if got := LatestModified(examples); !got.Equal(later) {
+		t.Errorf("LatestModified() = %v, want %v", got, later)
+	}
+	if got := LatestModified([]PatternExample{{Resource: "Unknown"}}); !got.IsZero() {
+		t.Errorf("without times = %v, want the zero time", got)
+	}
+
+	// Scores as of the latest change do not depend on when they are computed
+	if got := ExampleRecency(examples, LatestModified(examples)); got != 0.75 {
+		t.Errorf("ExampleRecency() = %v, want 0.75", got)
+	}
+}
+
+func TestPatternConfidence(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-10 * RecencyHalfLife)
+	pattern := PatternMetadata{
+		Template:  "@on <operation>: [auth]",
+		Frequency: 10,
+		Examples: []PatternExample{
+			{Code: "@on create: [auth]", ModifiedAt: &now},
+			{Code: "@on update: [cache]", ModifiedAt: &old},
+		},
+	}
+
+	score := PatternConfidence(pattern, now)
+	if score.Consistency != 0.5 || score.Frequency != 10 || score.Score != 0.66 {
+		t.Errorf("PatternConfidence() = %+v", score)
+	}
+
+	explanation := score.Explain()
+	for _, want := range []string{"0.66 = frequency 1.00 (10 of 10)", "consistency 0.75 (50% follow the template)", "recency 0.88"} {
+		if !strings.Contains(explanation, want) {
+			t.Errorf("Explain() = %q, missing %q", explanation, want)
+		}
+	}
+}
@@ -148,6 +148,11 @@ func BuildDependencyGraph(meta *Metadata) *DependencyGraph {
 
 // QueryDependencies finds dependencies of a resource with configurable options
 func QueryDependencies(resourceName string, opts DependencyOptions) (*DependencyGraph, error) {
+	// Hook source code adds the functions resources call
+	if err := globalRegistry.complete(); err != nil {
+		return nil, err
+	}
+
 	globalRegistry.mu.RLock()
 	defer globalRegistry.mu.RUnlock()
 
@@ -356,6 +361,37 @@ func findCycles(graph *DependencyGraph, nodeID string, visited, recStack map[str
 	recStack[nodeID] = false
 }
 
+// GraphDepth returns the length of the longest chain of edges reachable from
+// startNode, visiting each node once. This is the complexity metric reported by
+// the dependency-analyzer example and enforced by `conduit lint` budgets.
+func GraphDepth(graph *DependencyGraph, startNode string) int {
+	if graph == nil || len(graph.Edges) == 0 {
+		return 0
+	}
+
+	maxDepth := 0
+	visited := make(map[string]int)
+
+	var dfs func(node string, depth int)
+	dfs = func(node string, depth int) {
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		visited[node] = depth
+
+		for _, edge := range graph.Edges {
+			if edge.From == node {
+				if _, seen := visited[edge.To]; !seen {
+					dfs(edge.To, depth+1)
+				}
+			}
+		}
+	}
+
+	dfs(startNode, 0)
+	return maxDepth
+}
+
 // GetDependencyDepth calculates the maximum dependency depth for a resource
 func GetDependencyDepth(resourceName string) (int, error) {
 	opts := DependencyOptions{
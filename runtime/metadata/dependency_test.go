@@ -1215,3 +1215,35 @@ func TestFindEdges_Fallback(t *testing.T) {
 		t.Errorf("Fallback findIncomingEdges failed: expected 1 edge, got %d", len(edges))
 	}
 }
+
+func TestGraphDepth(t *testing.T) {
+	graph := &DependencyGraph{
+		Nodes: map[string]*DependencyNode{},
+		Edges: []DependencyEdge{
+			{From: "Comment", To: "Post", Relationship: "belongs_to"},
+			{From: "Post", To: "User", Relationship: "belongs_to"},
+			{From: "User", To: "Comment", Relationship: "has_many"},
+			{From: "Post", To: "auth", Relationship: "uses"},
+		},
+	}
+
+	tests := []struct {
+		start string
+		want  int
+	}{
+		{"Comment", 2},
+		{"Post", 2},
+		{"auth", 0},
+		{"Missing", 0},
+	}
+
+	for _, tt := range tests {
+		if got := GraphDepth(graph, tt.start); got != tt.want {
+			t.Errorf("GraphDepth(%s) = %d, want %d", tt.start, got, tt.want)
+		}
+	}
+
+	if got := GraphDepth(nil, "Post"); got != 0 {
+		t.Errorf("GraphDepth(nil) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,267 @@
+package metadata
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Kinds of schema changes reported by Diff
+const (
+	ResourceAdded           = "resource_added"
+	ResourceRemoved         = "resource_removed"
+	FieldAdded              = "field_added"
+	FieldRemoved            = "field_removed"
+	FieldTypeChanged        = "field_type_changed"
+	FieldNullabilityChanged = "field_nullability_changed"
+	RouteAdded              = "route_added"
+	RouteRemoved            = "route_removed"
+	RelationshipAdded       = "relationship_added"
+	RelationshipRemoved     = "relationship_removed"
+	RelationshipChanged     = "relationship_changed"
+)
+
+// SchemaChange is one difference between two versions of the metadata
+type SchemaChange struct {
+	Kind        string `json:"kind"`               // One of the kinds above, e.g. field_type_changed
+	Resource    string `json:"resource,omitempty"` // Resource changed; for routes, the resource serving the route
+	Field       string `json:"field,omitempty"`    // Field or relationship changed
+	Route       string `json:"route,omitempty"`    // Route added or removed, as "METHOD /path"
+	Old         string `json:"old,omitempty"`      // Value before the change, e.g. the old type
+	New         string `json:"new,omitempty"`      // Value after the change
+	Breaking    bool   `json:"breaking"`           // Whether existing clients may stop working
+	Description string `json:"description"`        // Human-readable summary
+}
+
+// SchemaDiff lists the changes between two versions of the metadata
+type SchemaDiff struct {
+	Changes  []SchemaChange `json:"changes"`  // Resource changes first, by resource name, then route changes by path
+	Breaking int            `json:"breaking"` // Number of breaking changes
+}
+
+// HasBreaking reports whether any change may break existing clients.
+func (d *SchemaDiff) HasBreaking() bool {
+	return d.Breaking > 0
+}
+
+// Diff compares two versions of the metadata, such as the metadata of the
+// deployed application and of a new build, and returns a structured
+// changelog. Removals, type and nullability changes, relationship changes and
+// new required fields clients must send are breaking; a renamed resource or
+// field is reported as removed and added.
+func Diff(old, new *Metadata) *SchemaDiff {
+	d := &SchemaDiff{Changes: []SchemaChange{}}
+
+	oldResources := make(map[string]*ResourceMetadata, len(old.Resources))
+	for i := range old.Resources {
+		oldResources[old.Resources[i].Name] = &old.Resources[i]
+	}
+	newResources := make(map[string]*ResourceMetadata, len(new.Resources))
+	for i := range new.Resources {
+		newResources[new.Resources[i].Name] = &new.Resources[i]
+	}
+
+	names := make([]string, 0, len(oldResources)+len(newResources))
+	for name := range oldResources {
+		names = append(names, name)
+	}
+	for name := range newResources {
+		if oldResources[name] == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		before, after := oldResources[name], newResources[name]
+		switch {
+		case before == nil:
+			d.add(SchemaChange{Kind: ResourceAdded, Resource: name,
+				Description: fmt.Sprintf("Added resource %s", name)})
+		case after == nil:
+			d.add(SchemaChange{Kind: ResourceRemoved, Resource: name, Breaking: true,
+				Description: fmt.Sprintf("Removed resource %s", name)})
+		default:
+			d.diffResource(before, after)
+		}
+	}
+
+	d.diffRoutes(old.Routes, new.Routes)
+	return d
+}
+
+// add appends a change, counting it when it is breaking
+func (d *SchemaDiff) add(change SchemaChange) {
+	d.Changes = append(d.Changes, change)
+	if change.Breaking {
+		d.Breaking++
+	}
+}
+
+// diffResource adds the field and relationship changes of a resource present
+// in both versions
+func (d *SchemaDiff) diffResource(before, after *ResourceMetadata) {
+	resource := after.Name
+
+	oldFields := make(map[string]*FieldMetadata, len(before.Fields))
+	for i := range before.Fields {
+		oldFields[before.Fields[i].Name] = &before.Fields[i]
+	}
+	newFields := make(map[string]bool, len(after.Fields))
+	for _, field := range after.Fields {
+		newFields[field.Name] = true
+	}
+
+	for i := range after.Fields {
+		field := &after.Fields[i]
+		previous := oldFields[field.Name]
+
+		if previous == nil {
+			breaking := !field.Nullable && !optionalOnWrite(field)
+			description := fmt.Sprintf("Added field %s.%s (%s)", resource, field.Name, field.Type)
+			if breaking {
+				description += "; clients must now send it"
+			}
+			d.add(SchemaChange{Kind: FieldAdded, Resource: resource, Field: field.Name, New: field.Type, Breaking: breaking,
+				Description: description})
+			continue
+		}
+
+		oldBase, newBase := baseType(previous.Type), baseType(field.Type)
+		if oldBase != newBase {
+			d.add(SchemaChange{Kind: FieldTypeChanged, Resource: resource, Field: field.Name, Old: oldBase, New: newBase, Breaking: true,
+				Description: fmt.Sprintf("Changed the type of %s.%s from %s to %s", resource, field.Name, oldBase, newBase)})
+		}
+		if previous.Nullable != field.Nullable {
+			description := fmt.Sprintf("Made %s.%s required; clients must now send it", resource, field.Name)
+			if field.Nullable {
+				description = fmt.Sprintf("Made %s.%s nullable; clients may now receive null", resource, field.Name)
+			}
+			d.add(SchemaChange{Kind: FieldNullabilityChanged, Resource: resource, Field: field.Name,
+				Old: nullability(previous.Nullable), New: nullability(field.Nullable), Breaking: true,
+				Description: description})
+		}
+	}
+
+	for _, field := range before.Fields {
+		if !newFields[field.Name] {
+			d.add(SchemaChange{Kind: FieldRemoved, Resource: resource, Field: field.Name, Old: field.Type, Breaking: true,
+				Description: fmt.Sprintf("Removed field %s.%s", resource, field.Name)})
+		}
+	}
+
+	d.diffRelationships(resource, before.Relationships, after.Relationships)
+}
+
+// diffRelationships adds the relationship changes of a resource
+func (d *SchemaDiff) diffRelationships(resource string, before, after []RelationshipMetadata) {
+	oldRels := make(map[string]RelationshipMetadata, len(before))
+	for _, rel := range before {
+		oldRels[rel.Name] = rel
+	}
+	newRels := make(map[string]bool, len(after))
+
+	for _, rel := range after {
+		newRels[rel.Name] = true
+		previous, ok := oldRels[rel.Name]
+		if !ok {
+			d.add(SchemaChange{Kind: RelationshipAdded, Resource: resource, Field: rel.Name, New: describeRelationship(rel),
+				Description: fmt.Sprintf("Added relationship %s.%s (%s)", resource, rel.Name, describeRelationship(rel))})
+			continue
+		}
+		if previous.Type != rel.Type || previous.TargetResource != rel.TargetResource ||
+			previous.ForeignKey != rel.ForeignKey || previous.ThroughTable != rel.ThroughTable {
+			d.add(SchemaChange{Kind: RelationshipChanged, Resource: resource, Field: rel.Name,
+				Old: describeRelationship(previous), New: describeRelationship(rel), Breaking: true,
+				Description: fmt.Sprintf("Changed relationship %s.%s from %s to %s",
+					resource, rel.Name, describeRelationship(previous), describeRelationship(rel))})
+		}
+	}
+
+	for _, rel := range before {
+		if !newRels[rel.Name] {
+			d.add(SchemaChange{Kind: RelationshipRemoved, Resource: resource, Field: rel.Name, Old: describeRelationship(rel), Breaking: true,
+				Description: fmt.Sprintf("Removed relationship %s.%s", resource, rel.Name)})
+		}
+	}
+}
+
+// diffRoutes adds the routes added and removed, by path and method
+func (d *SchemaDiff) diffRoutes(before, after []RouteMetadata) {
+	oldRoutes := make(map[string]RouteMetadata, len(before))
+	for _, route := range before {
+		oldRoutes[routeKey(route)] = route
+	}
+	newRoutes := make(map[string]RouteMetadata, len(after))
+	for _, route := range after {
+		newRoutes[routeKey(route)] = route
+	}
+
+	var changes []SchemaChange
+	for key, route := range newRoutes {
+		if _, ok := oldRoutes[key]; !ok {
+			changes = append(changes, SchemaChange{Kind: RouteAdded, Resource: route.Resource, Route: key,
+				Description: fmt.Sprintf("Added route %s", key)})
+		}
+	}
+	for key, route := range oldRoutes {
+		if _, ok := newRoutes[key]; !ok {
+			changes = append(changes, SchemaChange{Kind: RouteRemoved, Resource: route.Resource, Route: key, Breaking: true,
+				Description: fmt.Sprintf("Removed route %s", key)})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := strings.SplitN(changes[i].Route, " ", 2), strings.SplitN(changes[j].Route, " ", 2)
+		if a[1] != b[1] {
+			return a[1] < b[1]
+		}
+		return a[0] < b[0]
+	})
+	for _, change := range changes {
+		d.add(change)
+	}
+}
+
+// routeKey identifies a route as "METHOD /path"
+func routeKey(route RouteMetadata) string {
+	return strings.ToUpper(route.Method) + " " + route.Path
+}
+
+// baseType returns a field type without its nullability marker
+func baseType(fieldType string) string {
+	return strings.TrimRight(fieldType, "!?")
+}
+
+// nullability names whether a field is nullable
+func nullability(nullable bool) string {
+	if nullable {
+		return "nullable"
+	}
+	return "required"
+}
+
+// optionalOnWrite reports whether clients may leave a required field out of
+// creates: the application sets it (@auto, @auto_update) or it has a default
+func optionalOnWrite(field *FieldMetadata) bool {
+	if field.DefaultValue != "" {
+		return true
+	}
+	return slices.ContainsFunc(field.Constraints, func(constraint string) bool {
+		name, _, _ := strings.Cut(strings.TrimPrefix(constraint, "@"), "(")
+		return name == "auto" || name == "auto_update" || name == "default"
+	})
+}
+
+// describeRelationship summarizes a relationship, e.g. "belongs_to User via author_id"
+func describeRelationship(rel RelationshipMetadata) string {
+	description := rel.Type + " " + rel.TargetResource
+	if rel.ThroughTable != "" {
+		return description + " through " + rel.ThroughTable
+	}
+	if rel.ForeignKey != "" {
+		description += " via " + rel.ForeignKey
+	}
+	return description
+}
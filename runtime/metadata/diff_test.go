@@ -0,0 +1,123 @@
+package metadata
+
+import "testing"
+
+func diffTestMetadata() *Metadata {
+	return &Metadata{
+		Resources: []ResourceMetadata{
+			{
+				Name: "Post",
+				Fields: []FieldMetadata{
+					{Name: "id", Type: "uuid!", Constraints: []string{"primary", "auto"}},
+					{Name: "title", Type: "string!"},
+					{Name: "views", Type: "int!"},
+					{Name: "summary", Type: "string?", Nullable: true},
+					{Name: "legacy", Type: "string?", Nullable: true},
+				},
+				Relationships: []RelationshipMetadata{
+					{Name: "author", Type: "belongs_to", TargetResource: "User", ForeignKey: "author_id"},
+					{Name: "editor", Type: "belongs_to", TargetResource: "User", ForeignKey: "editor_id"},
+				},
+			},
+			{Name: "Tag", Fields: []FieldMetadata{{Name: "id", Type: "int!"}}},
+		},
+		Routes: []RouteMetadata{
+			{Method: "GET", Path: "/posts", Resource: "Post", Operation: "list"},
+			{Method: "DELETE", Path: "/posts/:id", Resource: "Post", Operation: "delete"},
+			{Method: "GET", Path: "/tags", Resource: "Tag", Operation: "list"},
+		},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := diffTestMetadata()
+	new := diffTestMetadata()
+
+	post := &new.Resources[0]
+	post.Fields = []FieldMetadata{
+		{Name: "id", Type: "uuid!", Constraints: []string{"primary", "auto"}},
+		{Name: "title", Type: "text!"},
+		{Name: "views", Type: "int?", Nullable: true},
+		{Name: "summary", Type: "string!"},
+		{Name: "body", Type: "string!"},
+		{Name: "pinned", Type: "bool!", Constraints: []string{"default(false)"}},
+		{Name: "updated_at", Type: "timestamp!", Constraints: []string{"auto_update"}},
+		{Name: "subtitle", Type: "string?", Nullable: true},
+	}
+	post.Relationships = []RelationshipMetadata{
+		{Name: "author", Type: "belongs_to", TargetResource: "Account", ForeignKey: "author_id"},
+		{Name: "comments", Type: "has_many", TargetResource: "Comment", ForeignKey: "post_id"},
+	}
+	new.Resources = append(new.Resources[:1], ResourceMetadata{Name: "Comment"})
+	new.Routes = []RouteMetadata{
+		{Method: "GET", Path: "/posts", Resource: "Post", Operation: "list"},
+		{Method: "GET", Path: "/comments", Resource: "Comment", Operation: "list"},
+	}
+
+	want := []struct {
+		kind     string
+		subject  string
+		breaking bool
+	}{
+		{ResourceAdded, "Comment", false},
+		{FieldTypeChanged, "title", true},
+		{FieldNullabilityChanged, "views", true},
+		{FieldNullabilityChanged, "summary", true},
+		{FieldAdded, "body", true},
+		{FieldAdded, "pinned", false},
+		{FieldAdded, "updated_at", false},
+		{FieldAdded, "subtitle", false},
+		{FieldRemoved, "legacy", true},
+		{RelationshipChanged, "author", true},
+		{RelationshipAdded, "comments", false},
+		{RelationshipRemoved, "editor", true},
+		{ResourceRemoved, "Tag", true},
+		{RouteAdded, "GET /comments", false},
+		{RouteRemoved, "DELETE /posts/:id", true},
+		{RouteRemoved, "GET /tags", true},
+	}
+
+	diff := Diff(old, new)
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("Diff() returned %d changes, want %d: %+v", len(diff.Changes), len(want), diff.Changes)
+	}
+	breaking := 0
+	for i, w := range want {
+		change := diff.Changes[i]
+		subject := change.Field
+		switch {
+		case change.Route != "":
+			subject = change.Route
+		case subject == "":
+			subject = change.Resource
+		}
+		if change.Kind != w.kind || subject != w.subject || change.Breaking != w.breaking {
+			t.Errorf("change %d = %s %s (breaking %v), want %s %s (breaking %v)",
+				i, change.Kind, subject, change.Breaking, w.kind, w.subject, w.breaking)
+		}
+		if change.Description == "" {
+			t.Errorf("change %d has no description", i)
+		}
+		if w.breaking {
+			breaking++
+		}
+	}
+	if diff.Breaking != breaking || !diff.HasBreaking() {
+		t.Errorf("Breaking = %d, want %d", diff.Breaking, breaking)
+	}
+
+	title := diff.Changes[1]
+	if title.Old != "string" || title.New != "text" {
+		t.Errorf("type change Old/New = %q/%q, want string/text", title.Old, title.New)
+	}
+	if author := diff.Changes[9]; author.Old != "belongs_to User via author_id" || author.New != "belongs_to Account via author_id" {
+		t.Errorf("relationship change Old/New = %q/%q", author.Old, author.New)
+	}
+}
+
+func TestDiff_Unchanged(t *testing.T) {
+	diff := Diff(diffTestMetadata(), diffTestMetadata())
+	if len(diff.Changes) != 0 || diff.HasBreaking() {
+		t.Errorf("Expected no changes, got %+v", diff.Changes)
+	}
+}
@@ -113,7 +113,7 @@
 //	  ],
 //	  "patterns": [
 //	    {
-//	      "id": "pattern-1",
+//	      "id": "pattern-5d41402abc4b2a76",
 //	      "name": "slug-generation",
 //	      "category": "hook",
 //	      "description": "Auto-generate URL slug from title",
@@ -166,6 +166,22 @@
 //
 // Optional fields use `omitempty` JSON tags to reduce size when not needed.
 //
+// # Other Encodings
+//
+// Consumers that cannot read JSON easily get the same metadata through an
+// Exporter, as `conduit introspect export --format cbor` does:
+//
+//	exporter, err := metadata.ExporterFor(metadata.FormatCBOR)
+//	if err != nil {
+//		return err
+//	}
+//	data, err := exporter.Export(metadata.GetMetadata())
+//
+// JSON, YAML, CBOR and protobuf (a google.protobuf.Struct message) are
+// built in. Every format is derived from the JSON encoding, so keys and
+// omitted fields match metadata.json. Other formats plug in through
+// RegisterExporter.
+//
 // # Schema Versioning
 //
 // The Metadata.Version field supports schema evolution. Future versions
@@ -188,6 +204,7 @@
 // RelationshipMetadata.Type supports:
 //
 //   - belongs_to: N:1 relationship with foreign key
+//   - has_one: 1:1 relationship via a unique foreign key on the target
 //   - has_many: 1:N relationship via foreign key
 //   - has_many_through: M:N relationship via join table
 //
@@ -0,0 +1,371 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Exporter encodes metadata for consumers that cannot read JSON easily.
+// Implementations must be deterministic: the same metadata always produces
+// the same bytes.
+type Exporter interface {
+	Export(metadata *Metadata) ([]byte, error)
+}
+
+// Built-in export formats
+const (
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+	FormatCBOR     = "cbor"
+	FormatProtobuf = "protobuf"
+)
+
+var (
+	exportersMu sync.RWMutex
+	exporters   = map[string]Exporter{
+		FormatJSON:     JSONExporter{},
+		FormatYAML:     YAMLExporter{},
+		FormatCBOR:     CBORExporter{},
+		FormatProtobuf: ProtobufExporter{},
+	}
+)
+
+// RegisterExporter makes an exporter available under a format name,
+// replacing any exporter already registered for it.
+func RegisterExporter(format string, exporter Exporter) {
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+	exporters[format] = exporter
+}
+
+// ExporterFor returns the exporter registered for a format.
+func ExporterFor(format string) (Exporter, error) {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+	exporter, ok := exporters[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown metadata format %q (available: %s)", format, strings.Join(exportFormats(), ", "))
+	}
+	return exporter, nil
+}
+
+// ExportFormats returns the registered format names in sorted order.
+func ExportFormats() []string {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+	return exportFormats()
+}
+
+func exportFormats() []string {
+	formats := make([]string, 0, len(exporters))
+	for format := range exporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// JSONExporter encodes metadata as indented JSON.
+type JSONExporter struct{}
+
+// Export implements Exporter.
+func (JSONExporter) Export(metadata *Metadata) ([]byte, error) {
+	if metadata == nil {
+		return nil, fmt.Errorf("metadata cannot be nil")
+	}
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize metadata: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// YAMLExporter encodes metadata as YAML with the keys of the JSON encoding,
+// in the same order.
+type YAMLExporter struct{}
+
+// Export implements Exporter.
+func (YAMLExporter) Export(metadata *Metadata) ([]byte, error) {
+	tree, err := exportTree(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(yamlNode(tree)); err != nil {
+		return nil, fmt.Errorf("failed to encode metadata as YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode metadata as YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// CBORExporter encodes metadata as CBOR (RFC 8949) with the keys of the
+// JSON encoding. Maps use definite lengths and keep the JSON key order;
+// integers use their shortest form and other numbers are float64.
+type CBORExporter struct{}
+
+// Export implements Exporter.
+func (CBORExporter) Export(metadata *Metadata) ([]byte, error) {
+	tree, err := exportTree(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return appendCBOR(nil, tree), nil
+}
+
+// ProtobufExporter encodes metadata as a google.protobuf.Struct message,
+// so any protobuf runtime can decode it with the well-known types and no
+// generated schema. Numbers become double values, as in Struct's JSON
+// mapping.
+type ProtobufExporter struct{}
+
+// Export implements Exporter.
+func (ProtobufExporter) Export(metadata *Metadata) ([]byte, error) {
+	tree, err := exportTree(metadata)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := tree.(exportObject)
+	if !ok {
+		return nil, fmt.Errorf("metadata must encode as an object")
+	}
+	return appendProtoStruct(nil, object), nil
+}
+
+// exportObject is a JSON object with its keys in encoding order
+type exportObject []exportMember
+
+type exportMember struct {
+	key   string
+	value any
+}
+
+// exportTree converts metadata to the value tree of its JSON encoding:
+// exportObject, []any, string, json.Number, bool or nil. Going through
+// JSON keeps every format's keys and omitted fields the same as the JSON
+// metadata.
+func exportTree(metadata *Metadata) (any, error) {
+	if metadata == nil {
+		return nil, fmt.Errorf("metadata cannot be nil")
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize metadata: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	tree, err := decodeExportValue(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize metadata: %w", err)
+	}
+	return tree, nil
+}
+
+func decodeExportValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := exportObject{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeExportValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, exportMember{key: key.(string), value: value})
+		}
+		_, err := decoder.Token()
+		return object, err
+	case json.Delim('['):
+		list := []any{}
+		for decoder.More() {
+			value, err := decodeExportValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := decoder.Token()
+		return list, err
+	case nil:
+		return nil, nil
+	}
+	if _, ok := token.(json.Delim); ok {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return token, nil
+}
+
+// yamlNode builds the YAML node of a value of the export tree
+func yamlNode(value any) *yaml.Node {
+	switch v := value.(type) {
+	case exportObject:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, member := range v {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: member.key},
+				yamlNode(member.value))
+		}
+		return node
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: v.String()}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: v.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
+
+// CBOR major types
+const (
+	cborUnsigned = 0 << 5
+	cborNegative = 1 << 5
+	cborText     = 3 << 5
+	cborArray    = 4 << 5
+	cborMap      = 5 << 5
+	cborSimple   = 7 << 5
+)
+
+// appendCBOR appends the CBOR encoding of a value of the export tree
+func appendCBOR(buf []byte, value any) []byte {
+	switch v := value.(type) {
+	case exportObject:
+		buf = appendCBORHead(buf, cborMap, uint64(len(v)))
+		for _, member := range v {
+			buf = appendCBORHead(buf, cborText, uint64(len(member.key)))
+			buf = append(buf, member.key...)
+			buf = appendCBOR(buf, member.value)
+		}
+		return buf
+	case []any:
+		buf = appendCBORHead(buf, cborArray, uint64(len(v)))
+		for _, item := range v {
+			buf = appendCBOR(buf, item)
+		}
+		return buf
+	case string:
+		buf = appendCBORHead(buf, cborText, uint64(len(v)))
+		return append(buf, v...)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			if n < 0 {
+				return appendCBORHead(buf, cborNegative, uint64(-1-n))
+			}
+			return appendCBORHead(buf, cborUnsigned, uint64(n))
+		}
+		f, _ := v.Float64()
+		buf = append(buf, cborSimple|27)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(f))
+	case bool:
+		if v {
+			return append(buf, cborSimple|21)
+		}
+		return append(buf, cborSimple|20)
+	}
+	return append(buf, cborSimple|22)
+}
+
+// appendCBORHead appends the initial byte of a data item and its argument
+// in the shortest form
+func appendCBORHead(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, major|26), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, major|27), n)
+}
+
+// Protobuf wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+)
+
+// appendProtoStruct appends the fields of a google.protobuf.Struct:
+// map<string, Value> fields = 1
+func appendProtoStruct(buf []byte, object exportObject) []byte {
+	for _, member := range object {
+		var entry []byte
+		entry = appendProtoBytes(entry, 1, []byte(member.key))
+		entry = appendProtoBytes(entry, 2, appendProtoValue(nil, member.value))
+		buf = appendProtoBytes(buf, 1, entry)
+	}
+	return buf
+}
+
+// appendProtoValue appends the fields of a google.protobuf.Value
+func appendProtoValue(buf []byte, value any) []byte {
+	switch v := value.(type) {
+	case exportObject:
+		return appendProtoBytes(buf, 5, appendProtoStruct(nil, v))
+	case []any:
+		// ListValue: repeated Value values = 1
+		var list []byte
+		for _, item := range v {
+			list = appendProtoBytes(list, 1, appendProtoValue(nil, item))
+		}
+		return appendProtoBytes(buf, 6, list)
+	case string:
+		return appendProtoBytes(buf, 3, []byte(v))
+	case json.Number:
+		f, _ := v.Float64()
+		buf = appendProtoTag(buf, 2, protoFixed64)
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(f))
+	case bool:
+		buf = appendProtoTag(buf, 4, protoVarint)
+		if v {
+			return append(buf, 1)
+		}
+		return append(buf, 0)
+	}
+	// null_value: NULL_VALUE, the enum's zero
+	return append(appendProtoTag(buf, 1, protoVarint), 0)
+}
+
+func appendProtoTag(buf []byte, field int, wireType int) []byte {
+	return binary.AppendUvarint(buf, uint64(field<<3|wireType))
+}
+
+func appendProtoBytes(buf []byte, field int, data []byte) []byte {
+	buf = appendProtoTag(buf, field, protoBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
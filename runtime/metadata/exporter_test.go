@@ -0,0 +1,209 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func exportTestMetadata() *Metadata {
+	return &Metadata{
+		Version:    "1.0.0",
+		Generated:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		SourceHash: "abc123",
+		Resources: []ResourceMetadata{
+			{
+				Name:     "User",
+				FilePath: "app/user.cdt",
+				Fields: []FieldMetadata{
+					{Name: "id", Type: "uuid!", Constraints: []string{"@primary", "@auto"}},
+					{Name: "bio", Type: "text?", Nullable: true},
+				},
+			},
+		},
+		Routes: []RouteMetadata{
+			{Method: "GET", Path: "/users", Handler: "Index", Resource: "User"},
+		},
+	}
+}
+
+func TestExporterFor(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatYAML, FormatCBOR, FormatProtobuf} {
+		if _, err := ExporterFor(format); err != nil {
+			t.Errorf("ExporterFor(%q): %v", format, err)
+		}
+	}
+
+	_, err := ExporterFor("xml")
+	if err == nil || !strings.Contains(err.Error(), "cbor, json, protobuf, yaml") {
+		t.Errorf("expected an error listing the formats, got %v", err)
+	}
+}
+
+type upperExporter struct{}
+
+func (upperExporter) Export(metadata *Metadata) ([]byte, error) {
+	return []byte(strings.ToUpper(metadata.Version)), nil
+}
+
+func TestRegisterExporter(t *testing.T) {
+	RegisterExporter("upper", upperExporter{})
+	defer func() {
+		exportersMu.Lock()
+		delete(exporters, "upper")
+		exportersMu.Unlock()
+	}()
+
+	exporter, err := ExporterFor("upper")
+	if err != nil {
+		t.Fatalf("ExporterFor: %v", err)
+	}
+	data, err := exporter.Export(&Metadata{Version: "v1"})
+	if err != nil || string(data) != "V1" {
+		t.Errorf("unexpected export %q (%v)", data, err)
+	}
+	if formats := ExportFormats(); !reflect.DeepEqual(formats, []string{"cbor", "json", "protobuf", "upper", "yaml"}) {
+		t.Errorf("unexpected formats %v", formats)
+	}
+}
+
+func TestExporters_NilMetadata(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatYAML, FormatCBOR, FormatProtobuf} {
+		exporter, _ := ExporterFor(format)
+		if _, err := exporter.Export(nil); err == nil {
+			t.Errorf("%s: expected an error for nil metadata", format)
+		}
+	}
+}
+
+func TestJSONExporter(t *testing.T) {
+	meta := exportTestMetadata()
+	data, err := JSONExporter{}.Export(meta)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	want, _ := json.MarshalIndent(meta, "", "  ")
+	if !bytes.Equal(data, append(want, '\n')) {
+		t.Errorf("expected indented JSON, got:\n%s", data)
+	}
+}
+
+func TestYAMLExporter(t *testing.T) {
+	meta := exportTestMetadata()
+	data, err := YAMLExporter{}.Export(meta)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	text := string(data)
+	if !strings.HasPrefix(text, "version: 1.0.0\ngenerated: \"2026-01-02T03:04:05Z\"\nsource_hash: abc123\nresources:\n") {
+		t.Errorf("expected the JSON keys in order:\n%s", text)
+	}
+	if !strings.Contains(text, "patterns: null\n") {
+		t.Errorf("expected nil slices to stay null, as in JSON:\n%s", text)
+	}
+
+	// Decoded, the YAML holds the same values as the JSON
+	var fromYAML any
+	if err := yaml.Unmarshal(data, &fromYAML); err != nil {
+		t.Fatalf("YAML does not decode: %v", err)
+	}
+	yamlJSON, _ := json.Marshal(fromYAML)
+	jsonData, _ := json.Marshal(meta)
+
+	var got, want any
+	_ = json.Unmarshal(yamlJSON, &got)
+	_ = json.Unmarshal(jsonData, &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("YAML and JSON disagree:\n%s\n%s", yamlJSON, jsonData)
+	}
+}
+
+func TestYAMLExporter_QuotesAmbiguousStrings(t *testing.T) {
+	data, err := YAMLExporter{}.Export(&Metadata{Version: "1.0", SourceHash: "true"})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("YAML does not decode: %v", err)
+	}
+	if decoded["version"] != "1.0" || decoded["source_hash"] != "true" {
+		t.Errorf("strings must stay strings:\n%s", data)
+	}
+}
+
+func TestAppendCBOR(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  []byte
+	}{
+		{"small int", json.Number("10"), []byte{0x0a}},
+		{"one byte int", json.Number("500"), []byte{0x19, 0x01, 0xf4}},
+		{"negative int", json.Number("-500"), []byte{0x39, 0x01, 0xf3}},
+		{"float", json.Number("1.5"), []byte{0xfb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"text", "IETF", []byte{0x64, 'I', 'E', 'T', 'F'}},
+		{"array", []any{true, false, nil}, []byte{0x83, 0xf5, 0xf4, 0xf6}},
+		{"map in order", exportObject{{"b", json.Number("1")}, {"a", []any{}}}, []byte{0xa2, 0x61, 'b', 0x01, 0x61, 'a', 0x80}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendCBOR(nil, tt.value); !bytes.Equal(got, tt.want) {
+				t.Errorf("got % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCBORExporter(t *testing.T) {
+	meta := exportTestMetadata()
+	first, err := CBORExporter{}.Export(meta)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	second, _ := CBORExporter{}.Export(meta)
+	if !bytes.Equal(first, second) {
+		t.Error("expected deterministic output")
+	}
+
+	// A map of 7 entries: version, generated, source_hash, resources,
+	// routes, patterns and dependencies
+	if first[0] != 0xa7 || !bytes.HasPrefix(first[1:], []byte("\x67version\x651.0.0")) {
+		t.Errorf("unexpected start % x", first[:16])
+	}
+}
+
+func TestProtobufExporter(t *testing.T) {
+	// {"a": "x", "b": [true]} as a google.protobuf.Struct
+	object := exportObject{{"a", "x"}, {"b", []any{true}}}
+	want := []byte{
+		0x0a, 0x08, 0x0a, 0x01, 'a', 0x12, 0x03, 0x1a, 0x01, 'x',
+		0x0a, 0x0b, 0x0a, 0x01, 'b', 0x12, 0x06, 0x32, 0x04, 0x0a, 0x02, 0x20, 0x01,
+	}
+	if got := appendProtoStruct(nil, object); !bytes.Equal(got, want) {
+		t.Errorf("got % x, want % x", got, want)
+	}
+
+	number := appendProtoValue(nil, json.Number("1"))
+	if !bytes.Equal(number, []byte{0x11, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f}) {
+		t.Errorf("expected a double number_value, got % x", number)
+	}
+	if null := appendProtoValue(nil, nil); !bytes.Equal(null, []byte{0x08, 0x00}) {
+		t.Errorf("expected a null_value, got % x", null)
+	}
+
+	data, err := ProtobufExporter{}.Export(exportTestMetadata())
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("\x0a\x12\x0a\x07version\x12\x07\x1a\x051.0.0")) {
+		t.Errorf("unexpected start % x", data[:24])
+	}
+}
@@ -0,0 +1,405 @@
+package metadata
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// graphQLScalars describes the custom scalars ToGraphQLSchema may declare
+var graphQLScalars = map[string]string{
+	"BigInt":   "64-bit integer",
+	"Date":     "Date in ISO 8601 format, e.g. 2024-01-31",
+	"DateTime": "Timestamp in RFC 3339 format, e.g. 2024-01-31T09:30:00Z",
+	"GeoJSON":  "GeoJSON Point or Polygon geometry (WGS 84)",
+	"JSON":     "Arbitrary JSON value",
+	"Time":     "Time of day in ISO 8601 format, e.g. 09:30:00",
+}
+
+// ToGraphQLSchema maps the metadata to a GraphQL schema in SDL, so a Conduit
+// application can be placed behind a GraphQL gateway. Each resource becomes
+// an object type with its fields, computed fields and relationships, and an
+// input type when it accepts writes; enum fields get enum types with the
+// declared values. The Query type has a field for every show and list route
+// and every scope, and the Mutation type one for every create, update and
+// delete route. Types keep the nullability of the fields they come from.
+// Other routes, such as archive or move, have no GraphQL counterpart.
+func ToGraphQLSchema(meta *Metadata) string {
+	g := &graphQLExporter{
+		resources: make(map[string]*ResourceMetadata, len(meta.Resources)),
+		scalars:   make(map[string]bool),
+	}
+	for i := range meta.Resources {
+		g.resources[meta.Resources[i].Name] = &meta.Resources[i]
+	}
+
+	routes := make(map[string]map[string]RouteMetadata)
+	for _, route := range meta.Routes {
+		if routes[route.Resource] == nil {
+			routes[route.Resource] = make(map[string]RouteMetadata)
+		}
+		operation := route.Operation
+		if operation == "get" {
+			operation = "show"
+		}
+		routes[route.Resource][operation] = route
+	}
+
+	var types, queries, mutations strings.Builder
+	for i := range meta.Resources {
+		res := &meta.Resources[i]
+		resRoutes := routes[res.Name]
+
+		g.objectType(&types, res)
+		if _, ok := resRoutes["create"]; ok {
+			g.inputType(&types, res)
+		} else if _, ok := resRoutes["update"]; ok {
+			g.inputType(&types, res)
+		}
+		if _, ok := resRoutes["list"]; ok && hasFilterable(res) {
+			g.filterType(&types, res)
+		}
+
+		g.queries(&queries, res, resRoutes)
+		g.mutations(&mutations, res, resRoutes)
+	}
+
+	var sdl strings.Builder
+	scalars := make([]string, 0, len(g.scalars))
+	for scalar := range g.scalars {
+		scalars = append(scalars, scalar)
+	}
+	sort.Strings(scalars)
+	for _, scalar := range scalars {
+		writeDescription(&sdl, "", graphQLScalars[scalar])
+		fmt.Fprintf(&sdl, "scalar %s\n\n", scalar)
+	}
+	for _, enum := range g.enums {
+		sdl.WriteString(enum)
+	}
+	sdl.WriteString(types.String())
+	if queries.Len() > 0 {
+		fmt.Fprintf(&sdl, "type Query {\n%s}\n\n", queries.String())
+	}
+	if mutations.Len() > 0 {
+		fmt.Fprintf(&sdl, "type Mutation {\n%s}\n\n", mutations.String())
+	}
+	return strings.TrimRight(sdl.String(), "\n") + "\n"
+}
+
+type graphQLExporter struct {
+	resources map[string]*ResourceMetadata
+	scalars   map[string]bool // Custom scalars the schema uses
+	enums     []string        // Enum type definitions, in order of first use
+}
+
+// objectType writes the type of a resource's records: its fields, belongs_to
+// foreign keys, computed fields and relationships
+func (g *graphQLExporter) objectType(w *strings.Builder, res *ResourceMetadata) {
+	writeDescription(w, "", res.Documentation)
+	fmt.Fprintf(w, "type %s {\n", res.Name)
+	for _, field := range res.Fields {
+		writeDescription(w, "  ", field.Documentation)
+		fmt.Fprintf(w, "  %s: %s\n", field.Name, g.fieldType(res, field, !fieldNullable(field)))
+	}
+	for _, key := range g.foreignKeys(res) {
+		fmt.Fprintf(w, "  %s: ID!\n", key)
+	}
+	for _, computed := range res.ComputedFields {
+		fmt.Fprintf(w, "  %s: %s\n", computed.Name, g.typeRef(computed.Type, res.Name+pascalCase(computed.Name), !strings.HasSuffix(computed.Type, "?")))
+	}
+	for _, rel := range res.Relationships {
+		if _, ok := g.resources[rel.TargetResource]; !ok {
+			continue
+		}
+		switch rel.Type {
+		case "belongs_to":
+			fmt.Fprintf(w, "  %s: %s!\n", rel.Name, rel.TargetResource)
+		case "has_one":
+			fmt.Fprintf(w, "  %s: %s\n", rel.Name, rel.TargetResource)
+		default:
+			fmt.Fprintf(w, "  %s: [%s!]!\n", rel.Name, rel.TargetResource)
+		}
+	}
+	w.WriteString("}\n\n")
+}
+
+// inputType writes the input create and update mutations accept: the fields
+// the database does not fill in, required unless they are nullable or have a
+// default, as with the REST input
+func (g *graphQLExporter) inputType(w *strings.Builder, res *ResourceMetadata) {
+	fmt.Fprintf(w, "input %sInput {\n", res.Name)
+	for _, field := range res.Fields {
+		if hasConstraint(field, "@auto") || hasConstraint(field, "@auto_update") {
+			continue
+		}
+		writeDescription(w, "  ", field.Documentation)
+		fmt.Fprintf(w, "  %s: %s\n", field.Name, g.fieldType(res, field, field.Required && field.DefaultValue == ""))
+	}
+	for _, key := range g.foreignKeys(res) {
+		fmt.Fprintf(w, "  %s: ID!\n", key)
+	}
+	w.WriteString("}\n\n")
+}
+
+// filterType writes the input of the list query's filter argument, with the
+// resource's filterable fields
+func (g *graphQLExporter) filterType(w *strings.Builder, res *ResourceMetadata) {
+	fmt.Fprintf(w, "input %sFilter {\n", res.Name)
+	for _, field := range res.Fields {
+		if field.Filterable {
+			fmt.Fprintf(w, "  %s: %s\n", field.Name, g.fieldType(res, field, false))
+		}
+	}
+	w.WriteString("}\n\n")
+}
+
+// queries writes the Query fields of a resource's show and list routes and
+// scopes
+func (g *graphQLExporter) queries(w *strings.Builder, res *ResourceMetadata, routes map[string]RouteMetadata) {
+	deprecated := deprecation(res)
+
+	if route, ok := routes["show"]; ok {
+		fmt.Fprintf(w, "  %s(%s): %s%s\n", lowerCamelCase(res.Name), g.pathArgs(res, route), res.Name, deprecated)
+	}
+
+	route, ok := routes["list"]
+	if !ok {
+		return
+	}
+	list := lowerCamelCase(path.Base(route.Path))
+	args := g.listArgs(res)
+	fmt.Fprintf(w, "  %s(%s): [%s!]!%s\n", list, args, res.Name, deprecated)
+
+	for _, scope := range res.Scopes {
+		// Scope parameters are untyped in the metadata
+		var scopeArgs []string
+		for _, param := range scope.Parameters {
+			scopeArgs = append(scopeArgs, param+": String!")
+		}
+		scopeArgs = append(scopeArgs, args)
+		fmt.Fprintf(w, "  %s%s(%s): [%s!]!%s\n", lowerCamelCase(scope.Name), pascalCase(list), strings.Join(scopeArgs, ", "), res.Name, deprecated)
+	}
+}
+
+// mutations writes the Mutation fields of a resource's create, update and
+// delete routes
+func (g *graphQLExporter) mutations(w *strings.Builder, res *ResourceMetadata, routes map[string]RouteMetadata) {
+	deprecated := deprecation(res)
+
+	if _, ok := routes["create"]; ok {
+		fmt.Fprintf(w, "  create%s(input: %sInput!): %s!%s\n", res.Name, res.Name, res.Name, deprecated)
+	}
+	if route, ok := routes["update"]; ok {
+		args := strings.TrimPrefix(g.pathArgs(res, route)+", input: "+res.Name+"Input!", ", ")
+		fmt.Fprintf(w, "  update%s(%s): %s!%s\n", res.Name, args, res.Name, deprecated)
+	}
+	if route, ok := routes["delete"]; ok {
+		fmt.Fprintf(w, "  delete%s(%s): Boolean!%s\n", res.Name, g.pathArgs(res, route), deprecated)
+	}
+}
+
+// pathArgs returns the arguments of a route's path parameters, typed like
+// the fields they name
+func (g *graphQLExporter) pathArgs(res *ResourceMetadata, route RouteMetadata) string {
+	var args []string
+	for _, match := range routeParamPattern.FindAllStringSubmatch(route.Path, -1) {
+		typ := "ID!"
+		if field := res.field(match[1]); field != nil {
+			typ = g.fieldType(res, *field, true)
+		}
+		args = append(args, match[1]+": "+typ)
+	}
+	return strings.Join(args, ", ")
+}
+
+// listArgs returns the arguments of a list query, which mirror the REST
+// list parameters
+func (g *graphQLExporter) listArgs(res *ResourceMetadata) string {
+	args := []string{"limit: Int", "offset: Int"}
+	for _, field := range res.Fields {
+		if field.Sortable {
+			args = append(args, "sort: String")
+			break
+		}
+	}
+	if hasFilterable(res) {
+		args = append(args, "filter: "+res.Name+"Filter")
+	}
+	return strings.Join(args, ", ")
+}
+
+// foreignKeys returns the belongs_to foreign keys the resource does not
+// declare as fields
+func (g *graphQLExporter) foreignKeys(res *ResourceMetadata) []string {
+	var keys []string
+	for _, rel := range res.Relationships {
+		if rel.Type != "belongs_to" {
+			continue
+		}
+		name := rel.ForeignKey
+		if name == "" {
+			name = rel.Name + "_id"
+		}
+		if res.field(name) == nil {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// fieldType returns the GraphQL type of a field, non-null when required is
+// set. The id field is an ID whatever its type, as are foreign keys.
+func (g *graphQLExporter) fieldType(res *ResourceMetadata, field FieldMetadata, required bool) string {
+	if field.Name == "id" {
+		if required {
+			return "ID!"
+		}
+		return "ID"
+	}
+	return g.typeRef(field.Type, res.Name+pascalCase(field.Name), required)
+}
+
+// typeRef returns the GraphQL type of a Conduit type such as "string!",
+// "enum[draft|published]!" or "array<int!>!". Enum types are declared as
+// enumName the first time they are used; required adds the non-null marker.
+func (g *graphQLExporter) typeRef(conduitType, enumName string, required bool) string {
+	base := strings.TrimRight(conduitType, "!?")
+	suffix := ""
+	if required {
+		suffix = "!"
+	}
+
+	if strings.HasPrefix(base, "array<") && strings.HasSuffix(base, ">") {
+		element := base[len("array<") : len(base)-1]
+		return "[" + g.typeRef(element, enumName, !strings.HasSuffix(element, "?")) + "]" + suffix
+	}
+	if strings.HasPrefix(base, "enum[") && strings.HasSuffix(base, "]") {
+		g.declareEnum(enumName, strings.Split(base[len("enum["):len(base)-1], "|"))
+		return enumName + suffix
+	}
+	if strings.HasPrefix(base, "hash<") {
+		base = "hash"
+	}
+
+	var name string
+	switch base {
+	case "uuid", "ulid":
+		name = "ID"
+	case "int", "integer":
+		name = "Int"
+	case "bigint":
+		name = "BigInt"
+	case "float", "decimal":
+		name = "Float"
+	case "bool", "boolean":
+		name = "Boolean"
+	case "timestamp":
+		name = "DateTime"
+	case "date":
+		name = "Date"
+	case "time":
+		name = "Time"
+	case "json", "hash", "array":
+		name = "JSON"
+	case "point", "polygon":
+		name = "GeoJSON"
+	default:
+		if _, ok := g.resources[base]; ok {
+			return base + suffix
+		}
+		name = "String"
+	}
+	if _, ok := graphQLScalars[name]; ok {
+		g.scalars[name] = true
+	}
+	return name + suffix
+}
+
+// declareEnum adds an enum type with the given values, which are kept as
+// they are unless they are not valid GraphQL names
+func (g *graphQLExporter) declareEnum(name string, values []string) {
+	prefix := "enum " + name + " {"
+	for _, enum := range g.enums {
+		if strings.HasPrefix(enum, prefix) {
+			return
+		}
+	}
+
+	var w strings.Builder
+	w.WriteString(prefix + "\n")
+	for _, value := range values {
+		fmt.Fprintf(&w, "  %s\n", graphQLName(strings.Trim(strings.TrimSpace(value), `"`)))
+	}
+	w.WriteString("}\n\n")
+	g.enums = append(g.enums, w.String())
+}
+
+// graphQLName replaces the characters a GraphQL name cannot contain with
+// underscores and prefixes names GraphQL reserves or that start with a digit
+func graphQLName(value string) string {
+	name := []rune(value)
+	for i, r := range name {
+		if !(r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))) {
+			name[i] = '_'
+		}
+	}
+	result := string(name)
+	switch {
+	case result == "":
+		return "_"
+	case unicode.IsDigit(name[0]), result == "true", result == "false", result == "null":
+		return "_" + result
+	}
+	return result
+}
+
+// writeDescription writes a block string description, if there is one
+func writeDescription(w *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+	description = strings.ReplaceAll(description, `"""`, `\"""`)
+	fmt.Fprintf(w, "%s\"\"\"\n%s%s\n%s\"\"\"\n", indent, indent, strings.ReplaceAll(description, "\n", "\n"+indent), indent)
+}
+
+// deprecation returns the @deprecated directive of a deprecated resource's
+// operations
+func deprecation(res *ResourceMetadata) string {
+	if res.Annotations().Stability != "deprecated" {
+		return ""
+	}
+	return fmt.Sprintf(` @deprecated(reason: "%s is deprecated")`, res.Name)
+}
+
+// hasFilterable reports whether any of the resource's fields are filterable
+func hasFilterable(res *ResourceMetadata) bool {
+	for _, field := range res.Fields {
+		if field.Filterable {
+			return true
+		}
+	}
+	return false
+}
+
+// pascalCase converts snake_case and kebab-case names to PascalCase, e.g.
+// "blog_posts" to "BlogPosts"
+func pascalCase(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' })
+	for i, part := range parts {
+		parts[i] = strings.ToUpper(part[:1]) + part[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// lowerCamelCase converts names to lowerCamelCase, e.g. "blog_posts" or
+// "BlogPost" to "blogPosts" and "blogPost"
+func lowerCamelCase(name string) string {
+	name = pascalCase(name)
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
@@ -0,0 +1,136 @@
+package metadata
+
+import (
+	"strings"
+	"testing"
+)
+
+func graphQLTestMetadata() *Metadata {
+	meta := openAPITestMetadata()
+	post := &meta.Resources[1]
+	post.Documentation = "Blog posts"
+	post.Fields = append(post.Fields,
+		FieldMetadata{Name: "status", Type: "enum[draft|published|2fa]!", Required: true, DefaultValue: "draft", Filterable: true},
+		FieldMetadata{Name: "tags", Type: "array<string!>?", Nullable: true},
+		FieldMetadata{Name: "views", Type: "bigint!", Required: true, Documentation: "Times the post was read"},
+	)
+	post.ComputedFields = []ComputedFieldMetadata{{Name: "excerpt", Type: "string!"}}
+	post.Scopes = []ScopeMetadata{
+		{Name: "published"},
+		{Name: "by_author", Parameters: []string{"author_id"}},
+	}
+	meta.Routes = append(meta.Routes,
+		RouteMetadata{Method: "PUT", Path: "/posts/:id", Handler: "UpdatePost", Resource: "Post", Operation: "update", RequestBody: "PostInput", ResponseBody: "Post"},
+		RouteMetadata{Method: "POST", Path: "/posts/:id/archive", Handler: "ArchivePost", Resource: "Post", Operation: "archive", ResponseBody: "Post"},
+		RouteMetadata{Method: "GET", Path: "/users/:id", Handler: "ShowUser", Resource: "User", Operation: "show", ResponseBody: "User"},
+	)
+	return meta
+}
+
+// block returns the definition starting with header, up to its closing brace
+func block(t *testing.T, sdl, header string) string {
+	t.Helper()
+	start := strings.Index(sdl, header+" {\n")
+	if start < 0 {
+		t.Fatalf("schema has no %q:\n%s", header, sdl)
+	}
+	end := strings.Index(sdl[start:], "\n}\n")
+	return sdl[start : start+end+3]
+}
+
+func assertLines(t *testing.T, definition string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(definition, "\n  "+line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, definition)
+		}
+	}
+}
+
+func TestToGraphQLSchema_Types(t *testing.T) {
+	sdl := ToGraphQLSchema(graphQLTestMetadata())
+
+	post := block(t, sdl, "type Post")
+	assertLines(t, post,
+		"id: ID!",
+		"title: String!",
+		"rating: Int",
+		"created_at: DateTime!",
+		"status: PostStatus!",
+		"tags: [String!]",
+		"views: BigInt!",
+		"author_id: ID!",
+		"excerpt: String!",
+		"author: User!",
+	)
+	if !strings.Contains(sdl, "\"\"\"\nBlog posts\n\"\"\"\ntype Post {") {
+		t.Error("expected Post's documentation as its description")
+	}
+	if !strings.Contains(post, "  \"\"\"\n  Times the post was read\n  \"\"\"\n  views") {
+		t.Errorf("expected views' documentation as its description:\n%s", post)
+	}
+
+	assertLines(t, block(t, sdl, "enum PostStatus"), "draft", "published", "_2fa")
+	for _, scalar := range []string{"BigInt", "DateTime"} {
+		if !strings.Contains(sdl, "scalar "+scalar+"\n") {
+			t.Errorf("expected the %s scalar to be declared", scalar)
+		}
+	}
+	if strings.Contains(sdl, "scalar GeoJSON") {
+		t.Error("unused scalars should not be declared")
+	}
+}
+
+func TestToGraphQLSchema_Inputs(t *testing.T) {
+	sdl := ToGraphQLSchema(graphQLTestMetadata())
+
+	input := block(t, sdl, "input PostInput")
+	assertLines(t, input, "title: String!", "rating: Int", "status: PostStatus", "author_id: ID!")
+	for _, auto := range []string{"id", "created_at"} {
+		if strings.Contains(input, "\n  "+auto+":") {
+			t.Errorf("PostInput should leave out the @auto field %s", auto)
+		}
+	}
+	if strings.Contains(sdl, "input UserInput") {
+		t.Error("User has no create or update route and should have no input")
+	}
+
+	assertLines(t, block(t, sdl, "input PostFilter"), "title: String", "status: PostStatus")
+}
+
+func TestToGraphQLSchema_Operations(t *testing.T) {
+	sdl := ToGraphQLSchema(graphQLTestMetadata())
+
+	assertLines(t, block(t, sdl, "type Query"),
+		"post(id: ID!): Post",
+		"posts(limit: Int, offset: Int, sort: String, filter: PostFilter): [Post!]!",
+		"publishedPosts(limit: Int, offset: Int, sort: String, filter: PostFilter): [Post!]!",
+		"byAuthorPosts(author_id: String!, limit: Int, offset: Int, sort: String, filter: PostFilter): [Post!]!",
+		`user(id: ID!): User @deprecated(reason: "User is deprecated")`,
+	)
+
+	mutation := block(t, sdl, "type Mutation")
+	assertLines(t, mutation,
+		"createPost(input: PostInput!): Post!",
+		"updatePost(id: ID!, input: PostInput!): Post!",
+		"deletePost(id: ID!): Boolean!",
+	)
+	if strings.Contains(mutation, "archive") {
+		t.Errorf("routes other than CRUD should not become mutations:\n%s", mutation)
+	}
+}
+
+func TestGraphQLName(t *testing.T) {
+	tests := map[string]string{
+		"draft":     "draft",
+		"in-review": "in_review",
+		"2fa":       "_2fa",
+		"null":      "_null",
+		"":          "_",
+	}
+	for value, want := range tests {
+		if got := graphQLName(value); got != want {
+			t.Errorf("graphQLName(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LazyEnvVar registers embedded metadata lazily when set to a true value such
+// as "true" or "1". Serverless deployments that only query routes set it to
+// keep startup memory down.
+const LazyEnvVar = "CONDUIT_METADATA_LAZY"
+
+// RegisterOptions configure RegisterMetadataWithOptions
+type RegisterOptions struct {
+	// Lazy leaves patterns and the source code of hooks out of the
+	// registered metadata, with the indexes built from them, until something
+	// first queries them. Routes, relationships and fields are decoded and
+	// indexed at once.
+	Lazy bool
+
+	// Reload returns the metadata document again when the sections left out
+	// are first queried, e.g. by decompressing the embedded copy. Required
+	// when Lazy is set, so the document is not kept in memory meanwhile.
+	Reload func() ([]byte, error)
+}
+
+// LazyFromEnv reports whether CONDUIT_METADATA_LAZY asks for lazy registration
+func LazyFromEnv() bool {
+	lazy, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(LazyEnvVar)))
+	return err == nil && lazy
+}
+
+// RegisterMetadataWithOptions registers metadata in the global registry like
+// RegisterMetadata, decoding it as opts says.
+//
+// With opts.Lazy, queries that return patterns, hook source code or data
+// derived from them (QueryPattern, QueryPatterns, QueryResource,
+// QueryResources, QueryResourcesByPattern, QueryDependencies and
+// GetMetadata) first reload and decode the whole document, once. If the
+// reload fails, those returning an error report it and the others answer
+// from the metadata registered.
+func RegisterMetadataWithOptions(data []byte, opts RegisterOptions) error {
+	if !opts.Lazy {
+		return register(data, nil)
+	}
+	if opts.Reload == nil {
+		return errors.New("lazy metadata registration needs a Reload function")
+	}
+	return register(data, opts.Reload)
+}
+
+// lazyMetadata decodes a metadata document without its patterns and hook
+// source code. Its fields shadow the Metadata, ResourceMetadata and
+// HookMetadata fields of the same JSON name, which are left empty.
+type lazyMetadata struct {
+	Metadata
+	Resources []lazyResource `json:"resources"`
+	Patterns  skipped        `json:"patterns"`
+}
+
+type lazyResource struct {
+	ResourceMetadata
+	Hooks []lazyHook `json:"hooks"`
+}
+
+type lazyHook struct {
+	HookMetadata
+	SourceCode skipped `json:"source_code"`
+}
+
+// skipped consumes a JSON value without decoding it
+type skipped struct{}
+
+func (skipped) UnmarshalJSON([]byte) error { return nil }
+
+// metadata returns the decoded sections as Metadata
+func (l *lazyMetadata) metadata() *Metadata {
+	meta := l.Metadata
+	meta.Resources = make([]ResourceMetadata, len(l.Resources))
+	for i := range l.Resources {
+		res := l.Resources[i].ResourceMetadata
+		if hooks := l.Resources[i].Hooks; hooks != nil {
+			res.Hooks = make([]HookMetadata, len(hooks))
+			for j := range hooks {
+				res.Hooks[j] = hooks[j].HookMetadata
+			}
+		}
+		meta.Resources[i] = res
+	}
+	return &meta
+}
+
+// complete decodes the sections a lazy registration left out before they
+// are read. Once they are decoded it only loads an atomic flag.
+func (r *Registry) complete() error {
+	if !r.partial.Load() {
+		return nil
+	}
+
+	r.completeMutex.Lock()
+	defer r.completeMutex.Unlock()
+
+	r.mu.RLock()
+	reload, generation := r.reload, r.generation
+	r.mu.RUnlock()
+	if reload == nil {
+		return nil
+	}
+
+	data, err := reload()
+	if err != nil {
+		return fmt.Errorf("failed to reload metadata: %w", err)
+	}
+	meta, err := decodeMetadata(data, false)
+	if err != nil {
+		return err
+	}
+	next := newRegistry()
+	next.metadata = meta
+	next.buildIndexes()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Metadata registered while reloading replaces what was reloaded
+	if r.generation == generation {
+		r.adopt(next)
+	}
+	return nil
+}
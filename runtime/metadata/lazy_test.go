@@ -0,0 +1,249 @@
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func lazyTestMetadata(t testing.TB) []byte {
+	t.Helper()
+	meta := &Metadata{
+		Version: "1.0.0",
+		Resources: []ResourceMetadata{
+			{
+				Name: "Post",
+				Fields: []FieldMetadata{
+					{Name: "title", Type: "string", Required: true},
+				},
+				Relationships: []RelationshipMetadata{
+					{Name: "author", Type: "belongs_to", TargetResource: "User"},
+				},
+				Hooks: []HookMetadata{
+					{Type: "before_create", Transaction: true, SourceCode: "self.slug = String.slugify(self.title)"},
+				},
+			},
+			{Name: "User"},
+		},
+		Patterns: []PatternMetadata{
+			{Name: "slug_generation", Template: "self.slug = String.slugify(self.title)"},
+		},
+		Routes: []RouteMetadata{
+			{Method: "GET", Path: "/posts", Resource: "Post"},
+			{Method: "POST", Path: "/posts", Resource: "Post"},
+		},
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatalf("Failed to marshal metadata: %v", err)
+	}
+	return data
+}
+
+// registerLazily registers data lazily, counting the reloads
+func registerLazily(t *testing.T, data []byte, err error) *atomic.Int32 {
+	t.Helper()
+	reloads := new(atomic.Int32)
+	if regErr := RegisterMetadataWithOptions(data, RegisterOptions{
+		Lazy: true,
+		Reload: func() ([]byte, error) {
+			reloads.Add(1)
+			return data, err
+		},
+	}); regErr != nil {
+		t.Fatalf("RegisterMetadataWithOptions failed: %v", regErr)
+	}
+	return reloads
+}
+
+func TestRegisterMetadataWithOptions_Lazy(t *testing.T) {
+	defer Reset()
+
+	reloads := registerLazily(t, lazyTestMetadata(t), nil)
+
+	// Patterns and hook source code are left out
+	meta := registered()
+	if len(meta.Patterns) != 0 || len(globalRegistry.patternsByName) != 0 {
+		t.Errorf("Patterns should not be decoded: %v", meta.Patterns)
+	}
+	hook := meta.Resources[0].Hooks[0]
+	if hook.SourceCode != "" {
+		t.Errorf("Hook source code should not be decoded: %q", hook.SourceCode)
+	}
+	if hook.Type != "before_create" || !hook.Transaction {
+		t.Errorf("Hook should keep its other fields: %+v", hook)
+	}
+
+	// Routes, relationships and fields are answered without a reload
+	if got := QueryRoutes(); len(got) != 2 {
+		t.Errorf("QueryRoutes: got %d routes, want 2", len(got))
+	}
+	if got := QueryRoutesByMethod("POST"); len(got) != 1 {
+		t.Errorf("QueryRoutesByMethod: got %d routes, want 1", len(got))
+	}
+	if got := QueryRoutesByPath("/posts"); len(got) != 2 {
+		t.Errorf("QueryRoutesByPath: got %d routes, want 2", len(got))
+	}
+	if got := QueryRelationshipsTo("User"); len(got) != 1 {
+		t.Errorf("QueryRelationshipsTo: got %d relationships, want 1", len(got))
+	}
+	if got := QueryFieldsByType("string"); len(got) != 1 {
+		t.Errorf("QueryFieldsByType: got %d fields, want 1", len(got))
+	}
+	if n := reloads.Load(); n != 0 {
+		t.Errorf("Route queries reloaded the metadata %d times", n)
+	}
+}
+
+func TestRegisterMetadataWithOptions_CompletesOnce(t *testing.T) {
+	defer Reset()
+
+	reloads := registerLazily(t, lazyTestMetadata(t), nil)
+
+	pattern, err := QueryPattern("slug_generation")
+	if err != nil {
+		t.Fatalf("QueryPattern failed: %v", err)
+	}
+	if pattern.Template == "" {
+		t.Error("Pattern should be decoded")
+	}
+
+	res, err := QueryResource("Post")
+	if err != nil {
+		t.Fatalf("QueryResource failed: %v", err)
+	}
+	if res.Hooks[0].SourceCode == "" {
+		t.Error("Hook source code should be decoded")
+	}
+	if got := QueryPatterns(); len(got) != 1 {
+		t.Errorf("QueryPatterns: got %d patterns, want 1", len(got))
+	}
+	if n := reloads.Load(); n != 1 {
+		t.Errorf("Metadata reloaded %d times, want 1", n)
+	}
+}
+
+func TestRegisterMetadataWithOptions_ConcurrentCompletion(t *testing.T) {
+	defer Reset()
+
+	reloads := registerLazily(t, lazyTestMetadata(t), nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := QueryPattern("slug_generation"); err != nil {
+				t.Errorf("QueryPattern failed: %v", err)
+			}
+			QueryRoutes()
+		}()
+	}
+	wg.Wait()
+
+	if n := reloads.Load(); n != 1 {
+		t.Errorf("Metadata reloaded %d times, want 1", n)
+	}
+}
+
+func TestRegisterMetadataWithOptions_ReloadError(t *testing.T) {
+	defer Reset()
+
+	reloads := registerLazily(t, lazyTestMetadata(t), errors.New("disk gone"))
+
+	_, err := QueryPattern("slug_generation")
+	if err == nil || !strings.Contains(err.Error(), "disk gone") {
+		t.Fatalf("QueryPattern should report the reload error, got: %v", err)
+	}
+	if _, err := QueryDependencies("Post", DependencyOptions{}); err == nil {
+		t.Error("QueryDependencies should report the reload error")
+	}
+
+	// The registered sections are still answered, and a later query retries
+	if got := QueryRoutes(); len(got) != 2 {
+		t.Errorf("QueryRoutes: got %d routes, want 2", len(got))
+	}
+	if GetMetadata() == nil {
+		t.Error("GetMetadata should return the metadata registered")
+	}
+	if n := reloads.Load(); n < 2 {
+		t.Errorf("Failed reloads should be retried, got %d", n)
+	}
+}
+
+func TestRegisterMetadataWithOptions_Replaced(t *testing.T) {
+	defer Reset()
+
+	reloads := registerLazily(t, lazyTestMetadata(t), nil)
+	if err := RegisterMetadata(lazyTestMetadata(t)); err != nil {
+		t.Fatalf("RegisterMetadata failed: %v", err)
+	}
+
+	if _, err := QueryPattern("slug_generation"); err != nil {
+		t.Fatalf("QueryPattern failed: %v", err)
+	}
+	if n := reloads.Load(); n != 0 {
+		t.Errorf("Metadata registered eagerly should not be reloaded, got %d reloads", n)
+	}
+}
+
+func TestRegisterMetadataWithOptions_Errors(t *testing.T) {
+	defer Reset()
+
+	if err := RegisterMetadataWithOptions(lazyTestMetadata(t), RegisterOptions{Lazy: true}); err == nil {
+		t.Error("Lazy registration without Reload should fail")
+	}
+	if err := RegisterMetadataWithOptions([]byte("{invalid"), RegisterOptions{
+		Lazy:   true,
+		Reload: func() ([]byte, error) { return nil, nil },
+	}); err == nil {
+		t.Error("Invalid JSON should fail")
+	}
+	if registered() != nil {
+		t.Error("Failed registrations should not register metadata")
+	}
+}
+
+func TestRegisterMetadataWithOptions_SavesMemory(t *testing.T) {
+	defer Reset()
+
+	meta := &Metadata{Version: "1.0.0"}
+	source := strings.Repeat("self.total = self.items.sum(fn(i) { i.price })\n", 40)
+	for i := 0; i < 50; i++ {
+		meta.Resources = append(meta.Resources, ResourceMetadata{
+			Name:  "Resource" + string(rune('A'+i%26)) + string(rune('a'+i/26)),
+			Hooks: []HookMetadata{{Type: "before_create", SourceCode: source}},
+		})
+		meta.Patterns = append(meta.Patterns, PatternMetadata{Name: meta.Resources[i].Name, Template: source})
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatalf("Failed to marshal metadata: %v", err)
+	}
+
+	allocated := func(register func() error) uint64 {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		if err := register(); err != nil {
+			t.Fatalf("Registration failed: %v", err)
+		}
+		runtime.ReadMemStats(&after)
+		return after.TotalAlloc - before.TotalAlloc
+	}
+
+	eager := allocated(func() error { return RegisterMetadata(data) })
+	lazy := allocated(func() error {
+		return RegisterMetadataWithOptions(data, RegisterOptions{
+			Lazy:   true,
+			Reload: func() ([]byte, error) { return data, nil },
+		})
+	})
+	if lazy >= eager/2 {
+		t.Errorf("Lazy registration allocated %d bytes, eager %d", lazy, eager)
+	}
+}
@@ -0,0 +1,591 @@
+package metadata
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIVersion is the OpenAPI specification version ExportOpenAPI produces.
+const OpenAPIVersion = "3.1.0"
+
+// BearerAuthScheme is the security scheme required by routes with auth
+// middleware.
+const BearerAuthScheme = "bearerAuth"
+
+// OpenAPIOptions configures the document ExportOpenAPI produces.
+type OpenAPIOptions struct {
+	Title       string // info.title; "Conduit API" when empty
+	Version     string // info.version; "1.0.0" when empty
+	Description string // info.description
+	ServerURL   string // URL routes are served under, such as "/api/v1"; no servers when empty
+	Envelope    bool   // Responses wrap records and lists in {"data": ...}, as with serialization.envelope
+}
+
+// routeParamPattern matches ":name" path parameters, which OpenAPI spells "{name}"
+var routeParamPattern = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)`)
+
+// constraintPattern matches a field constraint with an argument, such as "@min(5)"
+var constraintPattern = regexp.MustCompile(`^@([a-z_]+)\((.*)\)$`)
+
+// ExportOpenAPI maps the metadata to an OpenAPI 3.1 document. Each resource
+// gets a schema for its records and one for the input its create and update
+// routes accept, each route an operation with its path and list parameters,
+// request body and responses, and routes with auth middleware require a
+// bearer token. The result marshals to JSON or YAML as it is.
+func ExportOpenAPI(meta *Metadata, opts OpenAPIOptions) map[string]interface{} {
+	if opts.Title == "" {
+		opts.Title = "Conduit API"
+	}
+	if opts.Version == "" {
+		opts.Version = "1.0.0"
+	}
+
+	exporter := &openAPIExporter{
+		opts:      opts,
+		resources: make(map[string]*ResourceMetadata, len(meta.Resources)),
+	}
+	for i := range meta.Resources {
+		exporter.resources[meta.Resources[i].Name] = &meta.Resources[i]
+	}
+
+	info := map[string]interface{}{
+		"title":   opts.Title,
+		"version": opts.Version,
+	}
+	if opts.Description != "" {
+		info["description"] = opts.Description
+	}
+
+	doc := map[string]interface{}{
+		"openapi": OpenAPIVersion,
+		"info":    info,
+		"tags":    exporter.tags(meta.Resources),
+		"paths":   exporter.paths(meta.Routes),
+		"components": map[string]interface{}{
+			"schemas": exporter.schemas(meta.Resources),
+			"securitySchemes": map[string]interface{}{
+				BearerAuthScheme: map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+	if opts.ServerURL != "" {
+		doc["servers"] = []map[string]interface{}{{"url": opts.ServerURL}}
+	}
+	return doc
+}
+
+type openAPIExporter struct {
+	opts      OpenAPIOptions
+	resources map[string]*ResourceMetadata
+}
+
+// tags returns one tag per resource, with the owning team as x-owner and the
+// @stability level as x-stability
+func (e *openAPIExporter) tags(resources []ResourceMetadata) []map[string]interface{} {
+	tags := make([]map[string]interface{}, 0, len(resources))
+	for _, res := range resources {
+		tag := map[string]interface{}{"name": res.Name}
+		if res.Documentation != "" {
+			tag["description"] = res.Documentation
+		}
+		if res.Owner != "" {
+			tag["x-owner"] = res.Owner
+		}
+		if stability := res.Annotations().Stability; stability != "" {
+			tag["x-stability"] = stability
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// schemas returns the record and input schemas of every resource and the
+// error schemas every operation responds with
+func (e *openAPIExporter) schemas(resources []ResourceMetadata) map[string]interface{} {
+	schemas := map[string]interface{}{
+		"Error": map[string]interface{}{
+			"type":     "object",
+			"required": []string{"error", "message"},
+			"properties": map[string]interface{}{
+				"error":   map[string]interface{}{"type": "string"},
+				"message": map[string]interface{}{"type": "string"},
+				"code":    map[string]interface{}{"type": "string"},
+				"details": map[string]interface{}{"type": "object"},
+			},
+		},
+		"ValidationError": map[string]interface{}{
+			"type":     "object",
+			"required": []string{"error", "message", "code", "fields"},
+			"properties": map[string]interface{}{
+				"error":   map[string]interface{}{"type": "string"},
+				"message": map[string]interface{}{"type": "string"},
+				"code":    map[string]interface{}{"type": "string"},
+				"fields": map[string]interface{}{
+					"type":                 "object",
+					"additionalProperties": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+				},
+			},
+		},
+	}
+
+	for i := range resources {
+		res := &resources[i]
+		schemas[res.Name] = e.recordSchema(res)
+		if !res.readOnly() {
+			schemas[res.Name+"Input"] = e.inputSchema(res)
+		}
+	}
+	return schemas
+}
+
+// recordSchema returns the schema of a record as routes respond with it:
+// every field, computed field and belongs_to foreign key
+func (e *openAPIExporter) recordSchema(res *ResourceMetadata) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+
+	for _, field := range res.Fields {
+		properties[field.Name] = e.fieldSchema(res, field)
+		if !fieldNullable(field) {
+			required = append(required, field.Name)
+		}
+	}
+	for _, computed := range res.ComputedFields {
+		schema := typeSchema(computed.Type)
+		schema["readOnly"] = true
+		properties[computed.Name] = schema
+	}
+	for name, schema := range e.foreignKeys(res) {
+		properties[name] = schema
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	if res.Documentation != "" {
+		schema["description"] = res.Documentation
+	}
+	return schema
+}
+
+// inputSchema returns the schema of the body create and update routes accept:
+// the fields the database does not fill in, required unless they are
+// nullable or have a default
+func (e *openAPIExporter) inputSchema(res *ResourceMetadata) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+
+	for _, field := range res.Fields {
+		if hasConstraint(field, "@auto") || hasConstraint(field, "@auto_update") {
+			continue
+		}
+		properties[field.Name] = e.fieldSchema(res, field)
+		if field.Required {
+			required = append(required, field.Name)
+		}
+	}
+	for name, schema := range e.foreignKeys(res) {
+		properties[name] = schema
+		required = append(required, name)
+	}
+	sort.Strings(required)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// foreignKeys returns the schemas of the belongs_to foreign keys the resource
+// does not declare as fields, typed like the ID of the resource they point at
+func (e *openAPIExporter) foreignKeys(res *ResourceMetadata) map[string]interface{} {
+	keys := make(map[string]interface{})
+	for _, rel := range res.Relationships {
+		if rel.Type != "belongs_to" {
+			continue
+		}
+		name := rel.ForeignKey
+		if name == "" {
+			name = rel.Name + "_id"
+		}
+		if res.field(name) != nil {
+			continue
+		}
+		schema := map[string]interface{}{"type": "string"}
+		if target, ok := e.resources[rel.TargetResource]; ok {
+			if id := target.field("id"); id != nil {
+				schema = typeSchema(id.Type)
+			}
+		}
+		schema["description"] = fmt.Sprintf("ID of the %s this %s belongs to", rel.TargetResource, res.Name)
+		keys[name] = schema
+	}
+	return keys
+}
+
+// fieldSchema returns the schema of a field, with its documentation, default
+// and the bounds and pattern its constraints and validations impose
+func (e *openAPIExporter) fieldSchema(res *ResourceMetadata, field FieldMetadata) map[string]interface{} {
+	schema := typeSchema(field.Type)
+	if fieldNullable(field) {
+		schema["type"] = []interface{}{schema["type"], "null"}
+	}
+	if field.Documentation != "" {
+		schema["description"] = field.Documentation
+	}
+	if field.DefaultValue != "" {
+		schema["default"] = field.DefaultValue
+	}
+	if hasConstraint(field, "@auto") || hasConstraint(field, "@auto_update") {
+		schema["readOnly"] = true
+	}
+
+	for _, constraint := range field.Constraints {
+		if match := constraintPattern.FindStringSubmatch(constraint); match != nil {
+			applyBound(schema, match[1], match[2])
+		}
+	}
+	for _, validation := range res.Validations {
+		if validation.Field == field.Name {
+			applyBound(schema, validation.Type, validation.Value)
+		}
+	}
+	return schema
+}
+
+// applyBound adds a min, max or pattern rule to a schema: a length for
+// strings and arrays, a value for numbers
+func applyBound(schema map[string]interface{}, rule, value string) {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	baseType, _ := schema["type"].(string)
+	if types, ok := schema["type"].([]interface{}); ok && len(types) > 0 {
+		baseType, _ = types[0].(string)
+	}
+
+	switch rule {
+	case "min", "max":
+		bound, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return
+		}
+		switch baseType {
+		case "string":
+			schema[rule+"Length"] = int(bound)
+		case "array":
+			schema[rule+"Items"] = int(bound)
+		case "integer", "number":
+			if rule == "min" {
+				schema["minimum"] = bound
+			} else {
+				schema["maximum"] = bound
+			}
+		}
+	case "pattern":
+		if baseType == "string" && value != "" {
+			schema["pattern"] = value
+		}
+	}
+}
+
+// typeSchema returns the JSON Schema of a Conduit type such as "string!",
+// "timestamp?" or "array<int!>!"; nullability is left to the caller
+func typeSchema(conduitType string) map[string]interface{} {
+	base := strings.TrimRight(conduitType, "!?")
+	if strings.HasPrefix(base, "array<") && strings.HasSuffix(base, ">") {
+		return map[string]interface{}{"type": "array", "items": typeSchema(base[len("array<") : len(base)-1])}
+	}
+	if strings.HasPrefix(base, "hash<") || base == "hash" {
+		return map[string]interface{}{"type": "object"}
+	}
+
+	switch base {
+	case "int", "integer", "bigint":
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case "float":
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case "decimal":
+		return map[string]interface{}{"type": "number"}
+	case "bool", "boolean":
+		return map[string]interface{}{"type": "boolean"}
+	case "uuid":
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	case "email":
+		return map[string]interface{}{"type": "string", "format": "email"}
+	case "url":
+		return map[string]interface{}{"type": "string", "format": "uri"}
+	case "timestamp":
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case "date":
+		return map[string]interface{}{"type": "string", "format": "date"}
+	case "time":
+		return map[string]interface{}{"type": "string", "format": "time"}
+	case "json":
+		return map[string]interface{}{"type": "object"}
+	case "array":
+		return map[string]interface{}{"type": "array"}
+	case "point":
+		return geometrySchema("Point")
+	case "polygon":
+		return geometrySchema("Polygon")
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
+// geometrySchema returns the schema of a GeoJSON geometry of the given type
+func geometrySchema(geometry string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"description": "GeoJSON " + geometry + " (WGS 84)",
+		"required":    []string{"type", "coordinates"},
+		"properties": map[string]interface{}{
+			"type":        map[string]interface{}{"const": geometry},
+			"coordinates": map[string]interface{}{"type": "array"},
+		},
+	}
+}
+
+// paths returns the path items of every route
+func (e *openAPIExporter) paths(routes []RouteMetadata) map[string]interface{} {
+	paths := make(map[string]interface{})
+	for _, route := range routes {
+		path := routeParamPattern.ReplaceAllString(route.Path, "{$1}")
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = e.operation(route)
+	}
+	return paths
+}
+
+// operation returns the operation serving a route
+func (e *openAPIExporter) operation(route RouteMetadata) map[string]interface{} {
+	operation := map[string]interface{}{
+		"operationId": route.Handler,
+		"summary":     operationSummary(route),
+		"tags":        []string{route.Resource},
+		"responses":   e.responses(route),
+	}
+
+	parameters := e.parameters(route)
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+	if route.RequestBody != "" {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": e.bodySchema(route.RequestBody)},
+			},
+		}
+	}
+	if len(route.Middleware) > 0 {
+		operation["x-middleware"] = route.Middleware
+	}
+	if requiresAuth(route.Middleware) {
+		operation["security"] = []map[string][]string{{BearerAuthScheme: {}}}
+	}
+	if res, ok := e.resources[route.Resource]; ok && res.Annotations().Stability == "deprecated" {
+		operation["deprecated"] = true
+	}
+	return operation
+}
+
+// parameters returns the path parameters of a route and, for lists, the
+// pagination, sorting, filtering and include parameters
+func (e *openAPIExporter) parameters(route RouteMetadata) []map[string]interface{} {
+	res := e.resources[route.Resource]
+	parameters := make([]map[string]interface{}, 0)
+
+	for _, match := range routeParamPattern.FindAllStringSubmatch(route.Path, -1) {
+		schema := map[string]interface{}{"type": "string"}
+		if res != nil {
+			if field := res.field(match[1]); field != nil {
+				schema = typeSchema(field.Type)
+			}
+		}
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+	}
+
+	if route.Operation != "list" || res == nil {
+		return parameters
+	}
+
+	query := func(name, description string, schema map[string]interface{}) {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        name,
+			"in":          "query",
+			"description": description,
+			"schema":      schema,
+		})
+	}
+	query("page[limit]", "Records per page", map[string]interface{}{"type": "integer", "minimum": 1})
+	query("page[offset]", "Records to skip", map[string]interface{}{"type": "integer", "minimum": 0})
+
+	var sortable []string
+	for _, field := range res.Fields {
+		if field.Sortable {
+			sortable = append(sortable, field.Name)
+		}
+		if field.Filterable {
+			query(fmt.Sprintf("filter[%s]", field.Name), "Only records whose "+field.Name+" matches", typeSchema(field.Type))
+		}
+	}
+	if len(sortable) > 0 {
+		query("sort", "Comma-separated fields to sort by, prefixed with - for descending: "+strings.Join(sortable, ", "),
+			map[string]interface{}{"type": "string"})
+	}
+	if len(res.Relationships) > 0 {
+		var names []string
+		for _, rel := range res.Relationships {
+			names = append(names, rel.Name)
+		}
+		query("include", "Comma-separated relationships to include: "+strings.Join(names, ", "),
+			map[string]interface{}{"type": "string"})
+	}
+	return parameters
+}
+
+// responses returns the responses of a route: its success response and the
+// errors it can fail with
+func (e *openAPIExporter) responses(route RouteMetadata) map[string]interface{} {
+	responses := make(map[string]interface{})
+
+	status := "200"
+	switch route.Operation {
+	case "create", "create_batch":
+		status = "201"
+	case "delete":
+		status = "204"
+	}
+	success := map[string]interface{}{"description": operationSummary(route)}
+	if route.ResponseBody != "" && status != "204" {
+		schema := e.bodySchema(route.ResponseBody)
+		if e.opts.Envelope {
+			schema = map[string]interface{}{
+				"type":       "object",
+				"required":   []string{"data"},
+				"properties": map[string]interface{}{"data": schema, "meta": map[string]interface{}{"type": "object"}},
+			}
+		}
+		success["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		}
+	}
+	responses[status] = success
+
+	errorResponse := func(status, description, schema string) {
+		responses[status] = map[string]interface{}{
+			"description": description,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schemaRef(schema)},
+			},
+		}
+	}
+	errorResponse("400", "Bad request", "Error")
+	if strings.Contains(route.Path, ":") {
+		errorResponse("404", "Not found", "Error")
+	}
+	if route.RequestBody != "" {
+		errorResponse("422", "Validation failed", "ValidationError")
+	}
+	if requiresAuth(route.Middleware) {
+		errorResponse("401", "Authentication required", "Error")
+	}
+	if hasMiddleware(route.Middleware, "rate_limit") {
+		errorResponse("429", "Rate limit exceeded", "Error")
+	}
+	return responses
+}
+
+// bodySchema returns the schema of a request or response body type such as
+// "Post", "PostInput" or "[]Post"
+func (e *openAPIExporter) bodySchema(body string) map[string]interface{} {
+	if strings.HasPrefix(body, "[]") {
+		return map[string]interface{}{"type": "array", "items": e.bodySchema(body[2:])}
+	}
+	name := strings.TrimSuffix(body, "Input")
+	if _, ok := e.resources[name]; ok {
+		return schemaRef(body)
+	}
+	return map[string]interface{}{"type": "object"}
+}
+
+// schemaRef returns a reference to a component schema
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// operationSummary describes a route, e.g. "List Post" or "Archive Post"
+func operationSummary(route RouteMetadata) string {
+	operation := strings.ReplaceAll(route.Operation, "_", " ")
+	if operation == "" {
+		operation = strings.ToLower(route.Method)
+	}
+	return strings.ToUpper(operation[:1]) + operation[1:] + " " + route.Resource
+}
+
+// requiresAuth reports whether the middleware includes authentication
+func requiresAuth(middleware []string) bool {
+	return hasMiddleware(middleware, "auth")
+}
+
+// hasMiddleware reports whether the middleware includes name, with or
+// without arguments, e.g. "rate_limit" for "rate_limit(100/hour)"
+func hasMiddleware(middleware []string, name string) bool {
+	for _, mw := range middleware {
+		if mw == name || strings.HasPrefix(mw, name+"(") || strings.HasPrefix(mw, name+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasConstraint reports whether the field has a constraint such as "@auto"
+func hasConstraint(field FieldMetadata, constraint string) bool {
+	for _, c := range field.Constraints {
+		if c == constraint {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldNullable reports whether a field may be null, from its type or flag
+func fieldNullable(field FieldMetadata) bool {
+	return field.Nullable || strings.HasSuffix(field.Type, "?")
+}
+
+// field returns the resource's field with the given name, or nil
+func (r *ResourceMetadata) field(name string) *FieldMetadata {
+	for i := range r.Fields {
+		if r.Fields[i].Name == name {
+			return &r.Fields[i]
+		}
+	}
+	return nil
+}
+
+// readOnly reports whether the resource is served by read routes only
+func (r *ResourceMetadata) readOnly() bool {
+	annotations := r.Annotations()
+	return annotations.Materialized != nil || annotations.External != ""
+}
@@ -0,0 +1,195 @@
+package metadata
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func openAPITestMetadata() *Metadata {
+	return &Metadata{
+		Version: "1.0",
+		Resources: []ResourceMetadata{
+			{
+				Name:          "User",
+				Documentation: "Registered users",
+				Owner:         "identity-team",
+				ResourceAnnotations: &ResourceAnnotations{
+					Stability: "deprecated",
+				},
+				Fields: []FieldMetadata{
+					{Name: "id", Type: "uuid!", Required: true, Constraints: []string{"@primary", "@auto"}},
+					{Name: "email", Type: "email!", Required: true, Constraints: []string{"@unique"}},
+				},
+			},
+			{
+				Name: "Post",
+				Fields: []FieldMetadata{
+					{Name: "id", Type: "uuid!", Required: true, Constraints: []string{"@primary", "@auto"}},
+					{Name: "title", Type: "string!", Required: true, Constraints: []string{"@min(5)", "@max(200)"}, Filterable: true, Sortable: true},
+					{Name: "rating", Type: "int?", Nullable: true},
+					{Name: "created_at", Type: "timestamp!", Required: true, Constraints: []string{"@auto"}, Sortable: true},
+				},
+				Relationships: []RelationshipMetadata{
+					{Name: "author", Type: "belongs_to", TargetResource: "User", ForeignKey: "author_id"},
+				},
+				Validations: []ValidationMetadata{
+					{Field: "rating", Type: "max", Value: "5"},
+				},
+			},
+		},
+		Routes: []RouteMetadata{
+			{Method: "GET", Path: "/posts", Handler: "ListPost", Resource: "Post", Operation: "list", ResponseBody: "[]Post"},
+			{Method: "GET", Path: "/posts/:id", Handler: "ShowPost", Resource: "Post", Operation: "show", ResponseBody: "Post"},
+			{Method: "POST", Path: "/posts", Handler: "CreatePost", Resource: "Post", Operation: "create", Middleware: []string{"auth", "rate_limit(10/minute)"}, RequestBody: "PostInput", ResponseBody: "Post"},
+			{Method: "DELETE", Path: "/posts/:id", Handler: "DeletePost", Resource: "Post", Operation: "delete", Middleware: []string{"auth"}},
+		},
+	}
+}
+
+// exportOpenAPIJSON exports the document and decodes it again, so tests see
+// what consumers of the JSON see
+func exportOpenAPIJSON(t *testing.T, meta *Metadata, opts OpenAPIOptions) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(ExportOpenAPI(meta, opts))
+	if err != nil {
+		t.Fatalf("document does not marshal: %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("document is not valid JSON: %v", err)
+	}
+	return doc
+}
+
+// lookup follows a path of keys through a decoded document
+func lookup(t *testing.T, doc interface{}, keys ...string) interface{} {
+	t.Helper()
+	current := doc
+	for _, key := range keys {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			t.Fatalf("%v: %q is not inside an object", keys, key)
+		}
+		current, ok = object[key]
+		if !ok {
+			t.Fatalf("%v: missing %q", keys, key)
+		}
+	}
+	return current
+}
+
+func TestExportOpenAPI_Document(t *testing.T) {
+	doc := exportOpenAPIJSON(t, openAPITestMetadata(), OpenAPIOptions{Title: "Blog", ServerURL: "/api/v1"})
+
+	if doc["openapi"] != OpenAPIVersion {
+		t.Errorf("openapi = %v, want %s", doc["openapi"], OpenAPIVersion)
+	}
+	if title := lookup(t, doc, "info", "title"); title != "Blog" {
+		t.Errorf("info.title = %v, want Blog", title)
+	}
+	if version := lookup(t, doc, "info", "version"); version != "1.0.0" {
+		t.Errorf("info.version = %v, want the 1.0.0 default", version)
+	}
+	servers := doc["servers"].([]interface{})
+	if url := servers[0].(map[string]interface{})["url"]; url != "/api/v1" {
+		t.Errorf("servers[0].url = %v, want /api/v1", url)
+	}
+
+	tags := doc["tags"].([]interface{})
+	user := tags[0].(map[string]interface{})
+	if user["name"] != "User" || user["description"] != "Registered users" || user["x-owner"] != "identity-team" || user["x-stability"] != "deprecated" {
+		t.Errorf("User tag = %v, want its documentation, owner and stability", user)
+	}
+
+	if scheme := lookup(t, doc, "components", "securitySchemes", BearerAuthScheme, "scheme"); scheme != "bearer" {
+		t.Errorf("bearer scheme = %v", scheme)
+	}
+}
+
+func TestExportOpenAPI_Schemas(t *testing.T) {
+	doc := exportOpenAPIJSON(t, openAPITestMetadata(), OpenAPIOptions{})
+	post := lookup(t, doc, "components", "schemas", "Post")
+
+	title := lookup(t, post, "properties", "title").(map[string]interface{})
+	if title["type"] != "string" || title["minLength"] != 5.0 || title["maxLength"] != 200.0 {
+		t.Errorf("title schema = %v, want a string of 5 to 200 characters", title)
+	}
+	rating := lookup(t, post, "properties", "rating").(map[string]interface{})
+	if !reflect.DeepEqual(rating["type"], []interface{}{"integer", "null"}) || rating["maximum"] != 5.0 {
+		t.Errorf("rating schema = %v, want a nullable integer of at most 5", rating)
+	}
+	if format := lookup(t, post, "properties", "created_at", "format"); format != "date-time" {
+		t.Errorf("created_at format = %v, want date-time", format)
+	}
+	if format := lookup(t, post, "properties", "author_id", "format"); format != "uuid" {
+		t.Errorf("author_id format = %v, want the uuid of User's id", format)
+	}
+	if required := lookup(t, post, "required"); !reflect.DeepEqual(required, []interface{}{"id", "title", "created_at"}) {
+		t.Errorf("Post required = %v", required)
+	}
+
+	input := lookup(t, doc, "components", "schemas", "PostInput")
+	properties := lookup(t, input, "properties").(map[string]interface{})
+	for _, auto := range []string{"id", "created_at"} {
+		if _, ok := properties[auto]; ok {
+			t.Errorf("PostInput should leave out the @auto field %s", auto)
+		}
+	}
+	if required := lookup(t, input, "required"); !reflect.DeepEqual(required, []interface{}{"author_id", "title"}) {
+		t.Errorf("PostInput required = %v, want author_id and title", required)
+	}
+}
+
+func TestExportOpenAPI_Operations(t *testing.T) {
+	doc := exportOpenAPIJSON(t, openAPITestMetadata(), OpenAPIOptions{})
+
+	list := lookup(t, doc, "paths", "/posts", "get").(map[string]interface{})
+	var params []string
+	for _, param := range list["parameters"].([]interface{}) {
+		params = append(params, param.(map[string]interface{})["name"].(string))
+	}
+	want := []string{"page[limit]", "page[offset]", "filter[title]", "sort", "include"}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("list parameters = %v, want %v", params, want)
+	}
+	if ref := lookup(t, list, "responses", "200", "content", "application/json", "schema", "items", "$ref"); ref != "#/components/schemas/Post" {
+		t.Errorf("list response items = %v", ref)
+	}
+	if _, ok := list["security"]; ok {
+		t.Error("list has no auth middleware and should not require a token")
+	}
+
+	show := lookup(t, doc, "paths", "/posts/{id}", "get")
+	if format := lookup(t, show.(map[string]interface{})["parameters"].([]interface{})[0], "schema", "format"); format != "uuid" {
+		t.Errorf("id parameter format = %v, want uuid", format)
+	}
+
+	create := lookup(t, doc, "paths", "/posts", "post").(map[string]interface{})
+	if ref := lookup(t, create, "requestBody", "content", "application/json", "schema", "$ref"); ref != "#/components/schemas/PostInput" {
+		t.Errorf("create request body = %v", ref)
+	}
+	if !reflect.DeepEqual(create["security"], []interface{}{map[string]interface{}{BearerAuthScheme: []interface{}{}}}) {
+		t.Errorf("create security = %v, want the bearer scheme", create["security"])
+	}
+	responses := create["responses"].(map[string]interface{})
+	for _, status := range []string{"201", "400", "401", "422", "429"} {
+		if _, ok := responses[status]; !ok {
+			t.Errorf("create should respond with %s, got %v", status, responses)
+		}
+	}
+
+	remove := lookup(t, doc, "paths", "/posts/{id}", "delete")
+	if _, ok := lookup(t, remove, "responses", "204").(map[string]interface{})["content"]; ok {
+		t.Error("delete's 204 response should have no content")
+	}
+}
+
+func TestExportOpenAPI_Envelope(t *testing.T) {
+	doc := exportOpenAPIJSON(t, openAPITestMetadata(), OpenAPIOptions{Envelope: true})
+
+	schema := lookup(t, doc, "paths", "/posts/{id}", "get", "responses", "200", "content", "application/json", "schema")
+	if ref := lookup(t, schema, "properties", "data", "$ref"); ref != "#/components/schemas/Post" {
+		t.Errorf("enveloped record = %v, want Post in data", ref)
+	}
+}
@@ -1,11 +1,11 @@
 package metadata
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"sort"
 	"strings"
-
-	"github.com/google/uuid"
 )
 
 // PatternExtractionParams controls how patterns are extracted and what quality bar they must meet.
@@ -155,16 +155,26 @@ func (pe *PatternExtractor) generateMiddlewarePattern(chain *middlewareChain) Pa
 		description = fmt.Sprintf("Handler with %s middleware", strings.Join(chain.middleware, " + "))
 	}
 
-	return PatternMetadata{
-		ID:          uuid.New().String(),
+	pattern := PatternMetadata{
+		ID:          PatternID(template),
 		Name:        name,
 		Category:    category,
 		Description: description,
 		Template:    template,
 		Examples:    examples,
 		Frequency:   len(chain.usages),
-		Confidence:  pe.calculateConfidence(len(chain.usages)),
 	}
+	pattern.Confidence = PatternConfidence(pattern, LatestModified(examples)).Score
+	return pattern
+}
+
+// PatternID returns the identifier of the pattern with the given template.
+// It is derived from the template alone, so the same pattern keeps its ID
+// across builds however many other patterns are found, and diffs between
+// builds can follow it.
+func PatternID(template string) string {
+	sum := sha256.Sum256([]byte(template))
+	return "pattern-" + hex.EncodeToString(sum[:8])
 }
 
 // generatePatternName creates a descriptive name for a middleware pattern.
@@ -289,23 +299,6 @@ func (pe *PatternExtractor) inferCategory(middleware []string) string {
 	return "general"
 }
 
-// calculateConfidence calculates a confidence score for a pattern based on
-// how frequently it appears in the codebase.
-//
-// Formula: confidence = frequency / 10.0, capped at 1.0
-//
-// Examples:
-//   - frequency=3 → confidence=0.3
-//   - frequency=5 → confidence=0.5
-//   - frequency=12 → confidence=1.0 (capped)
-func (pe *PatternExtractor) calculateConfidence(frequency int) float64 {
-	confidence := float64(frequency) / 10.0
-	if confidence > 1.0 {
-		confidence = 1.0
-	}
-	return confidence
-}
-
 // middlewareChain represents a unique middleware chain and all places it's used.
 type middlewareChain struct {
 	middleware []string       // The middleware in this chain
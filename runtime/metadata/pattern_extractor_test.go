@@ -1,6 +1,9 @@
 package metadata
 
 import (
+	"fmt"
+	"math"
+	"strings"
 	"testing"
 	"time"
 )
@@ -413,29 +416,25 @@ func TestInferCategory(t *testing.T) {
 	}
 }
 
-// TestCalculateConfidence tests confidence calculation for various frequencies.
-func TestCalculateConfidence(t *testing.T) {
-	pe := NewPatternExtractor()
-
-	tests := []struct {
-		frequency int
-		expected  float64
-	}{
-		{frequency: 1, expected: 0.1},
-		{frequency: 3, expected: 0.3},
-		{frequency: 5, expected: 0.5},
-		{frequency: 10, expected: 1.0},
-		{frequency: 12, expected: 1.0}, // Capped at 1.0
-		{frequency: 100, expected: 1.0}, // Capped at 1.0
-	}
+// TestExtractMiddlewarePatterns_Confidence tests that extracted patterns are
+// scored with ScoreConfidence. Middleware examples always follow their
+// template and carry no modification time, so frequency decides.
+func TestExtractMiddlewarePatterns_Confidence(t *testing.T) {
+	pe := NewPatternExtractorWithParams(PatternExtractionParams{MinFrequency: 1, MaxExamples: 20})
 
-	for _, tt := range tests {
-		t.Run("", func(t *testing.T) {
-			result := pe.calculateConfidence(tt.frequency)
-			if result != tt.expected {
-				t.Errorf("For frequency %d, expected confidence %f, got %f", tt.frequency, tt.expected, result)
-			}
-		})
+	for _, frequency := range []int{1, 3, 5, 10, 12} {
+		resources := make([]ResourceMetadata, frequency)
+		for i := range resources {
+			resources[i] = ResourceMetadata{Name: fmt.Sprintf("R%d", i), Middleware: map[string][]string{"create": {"auth"}}}
+		}
+		patterns := pe.ExtractMiddlewarePatterns(resources)
+		if len(patterns) != 1 {
+			t.Fatalf("Expected 1 pattern, got %d", len(patterns))
+		}
+		want := math.Min(float64(frequency)/10, 1)
+		if patterns[0].Confidence != want {
+			t.Errorf("For frequency %d, expected confidence %f, got %f", frequency, want, patterns[0].Confidence)
+		}
 	}
 }
 
@@ -549,6 +548,40 @@ func TestExtractMiddlewarePatterns_UniqueIDs(t *testing.T) {
 	}
 }
 
+// TestExtractMiddlewarePatterns_StableIDs tests that a pattern keeps its ID
+// when other patterns come and go between builds.
+func TestExtractMiddlewarePatterns_StableIDs(t *testing.T) {
+	pe := NewPatternExtractor()
+
+	auth := []ResourceMetadata{
+		{Name: "Post", FilePath: "/app/post.cdt", Middleware: map[string][]string{
+			"create": {"auth"}, "update": {"auth"}, "delete": {"auth"},
+		}},
+	}
+	before := pe.ExtractMiddlewarePatterns(auth)
+	after := pe.ExtractMiddlewarePatterns(append([]ResourceMetadata{
+		{Name: "Article", FilePath: "/app/article.cdt", Middleware: map[string][]string{
+			"list": {"cache"}, "show": {"cache"}, "search": {"cache"},
+		}},
+	}, auth...))
+
+	if len(before) != 1 || len(after) != 2 {
+		t.Fatalf("Expected 1 then 2 patterns, got %d and %d", len(before), len(after))
+	}
+	found := false
+	for _, pattern := range after {
+		if pattern.Template == before[0].Template {
+			found = pattern.ID == before[0].ID
+		}
+	}
+	if !found {
+		t.Errorf("Expected pattern %s to keep its ID", before[0].ID)
+	}
+	if before[0].ID != PatternID(before[0].Template) || !strings.HasPrefix(before[0].ID, "pattern-") {
+		t.Errorf("Expected ID derived from the template, got %s", before[0].ID)
+	}
+}
+
 // TestExtractMiddlewarePatterns_BlogExample tests extraction with a realistic blog example.
 func TestExtractMiddlewarePatterns_BlogExample(t *testing.T) {
 	pe := NewPatternExtractor()
@@ -231,7 +231,10 @@ func TestPerformance_MinimalAllocations(t *testing.T) {
 				}
 			},
 			maxAllocs: 2,
-			maxBytes:  2000,
+			// One copy of the ten benchmark routes, 184 bytes each, rounded
+			// up to the 2048-byte allocation size class. Queries and Allow
+			// are set on nearly every route, so they are part of the budget.
+			maxBytes: 2048,
 		},
 		{
 			name: "Pattern lookup",
@@ -2,11 +2,14 @@ package metadata
 
 import (
 	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 const (
@@ -154,6 +157,10 @@ type Registry struct {
 	mu       sync.RWMutex
 	metadata *Metadata
 
+	// Version of the registered metadata, for consumers polling for changes
+	etag         string
+	lastModified time.Time
+
 	// Pre-computed indexes for fast queries (built at initialization)
 	resourcesByName   map[string]*ResourceMetadata
 	routesByPath      map[string][]*RouteMetadata
@@ -161,13 +168,21 @@ type Registry struct {
 	patternsByName    map[string]*PatternMetadata
 	relationshipIndex map[string][]*RelationshipRef // resource name -> relationships
 
-	// LRU cache for query results (metadata never changes at runtime)
+	// LRU cache for query results, cleared whenever metadata is registered
 	cache      *lruCache
 	cacheMutex sync.RWMutex
 
 	// Lazy initialization state
 	initialized atomic.Bool
 	initMutex   sync.Mutex
+
+	// Lazy registration state: reload returns the document again while
+	// patterns and hook source code are left out, and generation counts
+	// registrations so a reload never replaces newer metadata
+	reload        func() ([]byte, error)
+	generation    uint64
+	partial       atomic.Bool
+	completeMutex sync.Mutex
 }
 
 // RelationshipRef references a relationship and its source resource
@@ -177,37 +192,111 @@ type RelationshipRef struct {
 }
 
 // Global registry instance
-var globalRegistry = &Registry{
-	resourcesByName:   make(map[string]*ResourceMetadata),
-	routesByPath:      make(map[string][]*RouteMetadata),
-	routesByMethod:    make(map[string][]*RouteMetadata),
-	patternsByName:    make(map[string]*PatternMetadata),
-	relationshipIndex: make(map[string][]*RelationshipRef),
-	cache:             newLRUCache(),
+var globalRegistry = newRegistry()
+
+// newRegistry creates a registry with empty indexes
+func newRegistry() *Registry {
+	return &Registry{
+		resourcesByName:   make(map[string]*ResourceMetadata),
+		routesByPath:      make(map[string][]*RouteMetadata),
+		routesByMethod:    make(map[string][]*RouteMetadata),
+		patternsByName:    make(map[string]*PatternMetadata),
+		relationshipIndex: make(map[string][]*RelationshipRef),
+		cache:             newLRUCache(),
+	}
 }
 
 // RegisterMetadata registers metadata in the global registry.
-// This is called from the generated init() function at application startup.
+// This is called from the generated init() function at application startup,
+// and again by Watch whenever the metadata file changes.
 // Builds all indexes for fast query performance (<1ms for typical queries).
+// RegisterMetadataWithOptions can leave patterns and hook source code until
+// they are needed.
 func RegisterMetadata(data []byte) error {
-	var meta Metadata
-	if err := json.Unmarshal(data, &meta); err != nil {
-		return fmt.Errorf("failed to unmarshal metadata: %w", err)
+	return register(data, nil)
+}
+
+// register decodes and registers data. With reload, patterns and hook source
+// code are left out until complete reloads them.
+func register(data []byte, reload func() ([]byte, error)) error {
+	meta, err := decodeMetadata(data, reload != nil)
+	if err != nil {
+		return err
 	}
 
+	// Build the indexes before taking the lock and swap them in at once, so
+	// queries running during a reload see either the old or the new metadata
+	next := newRegistry()
+	next.metadata = meta
+	next.reload = reload
+	next.buildIndexes()
+	etag, lastModified := metadataVersion(meta, data)
+
 	globalRegistry.mu.Lock()
 	defer globalRegistry.mu.Unlock()
-	globalRegistry.metadata = &meta
-
-	// Build indexes for fast queries
-	globalRegistry.buildIndexes()
-	globalRegistry.initialized.Store(true)
+	globalRegistry.adopt(next)
+	globalRegistry.etag, globalRegistry.lastModified = etag, lastModified
 
 	return nil
 }
 
+// decodeMetadata decodes a metadata document, without its patterns and hook
+// source code when lazy
+func decodeMetadata(data []byte, lazy bool) (*Metadata, error) {
+	if lazy {
+		var doc lazyMetadata
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+		return doc.metadata(), nil
+	}
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// adopt replaces the metadata and indexes of r with those of next. The
+// caller holds r.mu.
+func (r *Registry) adopt(next *Registry) {
+	r.metadata = next.metadata
+	r.resourcesByName = next.resourcesByName
+	r.routesByPath = next.routesByPath
+	r.routesByMethod = next.routesByMethod
+	r.patternsByName = next.patternsByName
+	r.relationshipIndex = next.relationshipIndex
+	r.reload = next.reload
+	r.partial.Store(next.reload != nil)
+	r.generation++
+
+	// Cached results describe the metadata registered before
+	r.cacheMutex.Lock()
+	r.cache.clear()
+	r.cacheMutex.Unlock()
+
+	r.initialized.Store(true)
+}
+
+// metadataVersion returns the entity tag and modification time of registered
+// metadata. The tag is the source hash, or a hash of data when the metadata
+// has none; the time is when the metadata was generated, or now when it
+// does not say.
+func metadataVersion(meta *Metadata, data []byte) (string, time.Time) {
+	tag := meta.SourceHash
+	if tag == "" {
+		sum := sha256.Sum256(data)
+		tag = hex.EncodeToString(sum[:])
+	}
+	modified := meta.Generated
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	return `"` + tag + `"`, modified.UTC().Truncate(time.Second)
+}
+
 // buildIndexes builds all pre-computed indexes for fast queries.
-// This is called once during RegisterMetadata.
+// This is called on a new registry during each RegisterMetadata.
 // Target time: <10ms for typical applications (50 resources).
 func (r *Registry) buildIndexes() {
 	if r.metadata == nil {
@@ -248,7 +337,15 @@ func (r *Registry) buildIndexes() {
 
 // GetMetadata returns the registered metadata.
 // Returns nil if no metadata has been registered.
+// Metadata registered lazily is completed first.
 func GetMetadata() *Metadata {
+	globalRegistry.complete()
+	return registered()
+}
+
+// registered returns the registered metadata without completing a lazy
+// registration, for queries that read neither patterns nor hooks
+func registered() *Metadata {
 	globalRegistry.mu.RLock()
 	defer globalRegistry.mu.RUnlock()
 	return globalRegistry.metadata
@@ -298,6 +395,11 @@ Then run: conduit build`)
 		globalRegistry.initMutex.Unlock()
 	}
 
+	// Hook source code may not be decoded yet
+	if err := globalRegistry.complete(); err != nil {
+		return nil, err
+	}
+
 	// Now safe to read
 	globalRegistry.mu.RLock()
 	defer globalRegistry.mu.RUnlock()
@@ -327,7 +429,7 @@ func QueryPatterns() []PatternMetadata {
 // QueryRoutes returns all registered routes.
 // Returns a copy to prevent external mutation.
 func QueryRoutes() []RouteMetadata {
-	meta := GetMetadata()
+	meta := registered()
 	if meta == nil {
 		return nil
 	}
@@ -342,11 +444,16 @@ func Reset() {
 	globalRegistry.mu.Lock()
 	defer globalRegistry.mu.Unlock()
 	globalRegistry.metadata = nil
+	globalRegistry.etag = ""
+	globalRegistry.lastModified = time.Time{}
 	globalRegistry.resourcesByName = make(map[string]*ResourceMetadata)
 	globalRegistry.routesByPath = make(map[string][]*RouteMetadata)
 	globalRegistry.routesByMethod = make(map[string][]*RouteMetadata)
 	globalRegistry.patternsByName = make(map[string]*PatternMetadata)
 	globalRegistry.relationshipIndex = make(map[string][]*RelationshipRef)
+	globalRegistry.reload = nil
+	globalRegistry.partial.Store(false)
+	globalRegistry.generation++
 	globalRegistry.cache.clear()
 	globalRegistry.initialized.Store(false)
 }
@@ -452,6 +559,11 @@ Then run: conduit build`)
 		globalRegistry.initMutex.Unlock()
 	}
 
+	// Patterns may not be decoded yet
+	if err := globalRegistry.complete(); err != nil {
+		return nil, err
+	}
+
 	// Now safe to read
 	globalRegistry.mu.RLock()
 	defer globalRegistry.mu.RUnlock()
@@ -536,6 +648,9 @@ func QueryResourcesByPattern(pattern string) []ResourceMetadata {
 		globalRegistry.initMutex.Unlock()
 	}
 
+	// Resources are returned with their hooks
+	globalRegistry.complete()
+
 	// Now safe to read
 	globalRegistry.mu.RLock()
 	defer globalRegistry.mu.RUnlock()
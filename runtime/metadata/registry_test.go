@@ -39,6 +39,47 @@ func TestRegisterMetadata_Success(t *testing.T) {
 	}
 }
 
+func TestRegisterMetadata_Replaces(t *testing.T) {
+	defer Reset()
+
+	register := func(meta *Metadata) {
+		t.Helper()
+		data, err := json.Marshal(meta)
+		if err != nil {
+			t.Fatalf("Failed to marshal metadata: %v", err)
+		}
+		if err := RegisterMetadata(data); err != nil {
+			t.Fatalf("RegisterMetadata failed: %v", err)
+		}
+	}
+
+	register(&Metadata{
+		Resources: []ResourceMetadata{{Name: "User"}},
+		Routes:    []RouteMetadata{{Method: "GET", Path: "/users", Resource: "User"}},
+	})
+	if got := QueryResourcesByPattern("*"); len(got) != 1 {
+		t.Fatalf("Expected 1 resource, got %d", len(got))
+	}
+
+	register(&Metadata{
+		Resources: []ResourceMetadata{{Name: "Post"}},
+		Routes:    []RouteMetadata{{Method: "GET", Path: "/posts", Resource: "Post"}},
+	})
+
+	if _, err := QueryResource("User"); err == nil {
+		t.Error("Expected User to be gone after registering new metadata")
+	}
+	if _, err := QueryResource("Post"); err != nil {
+		t.Errorf("Expected Post to be registered: %v", err)
+	}
+	if routes := QueryRoutesByMethod("GET"); len(routes) != 1 || routes[0].Path != "/posts" {
+		t.Errorf("Expected only the new route, got %v", routes)
+	}
+	if got := QueryResourcesByPattern("*"); len(got) != 1 || got[0].Name != "Post" {
+		t.Errorf("Expected cached queries to see the new metadata, got %v", got)
+	}
+}
+
 func TestRegisterMetadata_InvalidJSON(t *testing.T) {
 	defer Reset()
 
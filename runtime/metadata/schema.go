@@ -8,13 +8,15 @@ import "time"
 // It captures complete information about compiled resources, routes,
 // patterns, and dependencies for use by LLMs and developer tooling.
 type Metadata struct {
-	Version      string             `json:"version"`      // Schema version for evolution
-	Generated    time.Time          `json:"generated"`    // Timestamp of metadata generation
-	SourceHash   string             `json:"source_hash"`  // Hash of source files for cache invalidation
-	Resources    []ResourceMetadata `json:"resources"`    // All resource definitions
-	Routes       []RouteMetadata    `json:"routes"`       // Auto-generated HTTP routes
-	Patterns     []PatternMetadata  `json:"patterns"`     // Discovered usage patterns
-	Dependencies DependencyGraph    `json:"dependencies"` // Resource dependency graph
+	Version      string             `json:"version"`          // Schema version for evolution
+	Generated    time.Time          `json:"generated"`        // Timestamp of metadata generation
+	SourceHash   string             `json:"source_hash"`      // Hash of source files for cache invalidation
+	Resources    []ResourceMetadata `json:"resources"`        // All resource definitions
+	Routes       []RouteMetadata    `json:"routes"`           // Auto-generated HTTP routes
+	Patterns     []PatternMetadata  `json:"patterns"`         // Discovered usage patterns
+	Dependencies DependencyGraph    `json:"dependencies"`     // Resource dependency graph
+	Auth         *AuthMetadata      `json:"auth,omitempty"`   // Social login; nil when no providers are configured
+	Shards       []ShardMetadata    `json:"shards,omitempty"` // Groups of co-located @shard resources; empty when nothing is sharded
 }
 
 // ResourceMetadata captures complete information about a single Conduit resource.
@@ -30,6 +32,212 @@ type ResourceMetadata struct {
 	Middleware     map[string][]string     `json:"middleware,omitempty"`      // Middleware per operation
 	Scopes         []ScopeMetadata         `json:"scopes,omitempty"`          // Query scopes
 	ComputedFields []ComputedFieldMetadata `json:"computed_fields,omitempty"` // Computed fields
+	Indexes        []IndexMetadata         `json:"indexes,omitempty"`         // Database indexes from @index fields and index blocks
+	Owner          string                  `json:"owner,omitempty"`           // Team owning the resource from @owner, or the first CODEOWNERS owner of its file
+	*ResourceAnnotations
+}
+
+// ResourceAnnotations holds what a resource's less common annotations
+// declare. Most resources declare none of them, so ResourceMetadata keeps them
+// behind one pointer that is nil until one is set; in JSON they appear as
+// members of the resource itself.
+type ResourceAnnotations struct {
+	Aliases       []string               `json:"aliases,omitempty"`        // Former resource names kept for API compatibility
+	CountStrategy string                 `json:"count_strategy,omitempty"` // List count strategy: exact, estimated or none
+	SLO           *SLOMetadata           `json:"slo,omitempty"`            // Service level objectives from @slo
+	CacheControl  *CacheControlMetadata  `json:"cache_control,omitempty"`  // HTTP caching policy from @cache_control
+	Changes       *ChangesMetadata       `json:"changes,omitempty"`        // Change feed for sync clients from @changes
+	Conflict      *ConflictMetadata      `json:"conflict,omitempty"`       // Concurrent update policy from @conflict
+	Partition     *PartitionMetadata     `json:"partition,omitempty"`      // Range partitioning of the table from @partition
+	Materialized  *MaterializedMetadata  `json:"materialized,omitempty"`   // Read-only materialized view from @materialized
+	CounterCaches []CounterCacheMetadata `json:"counter_caches,omitempty"` // Counts of this resource kept on parents from @counter_cache
+	SearchIndex   *SearchIndexMetadata   `json:"search_index,omitempty"`   // Full-text search index from @search_index
+	Profiles      []ProfileMetadata      `json:"profiles,omitempty"`       // Fields rendered per caller role from @profile
+	Archivable    bool                   `json:"archivable,omitempty"`     // Archive and restore routes from @archivable; lists hide archived records
+	SoftDelete    bool                   `json:"soft_delete,omitempty"`    // Deletes set deleted_at instead of removing rows, from @soft_delete; reads hide deleted records unless ?include_deleted=true
+	DefaultScope  string                 `json:"default_scope,omitempty"`  // Condition from @default_scope every list and show applies; admins can drop it with ?unscoped=true
+	Orderable     *OrderableMetadata     `json:"orderable,omitempty"`      // Position and move route from @orderable; lists follow the order
+	Tree          *TreeMetadata          `json:"tree,omitempty"`           // Children and ancestors routes from @tree
+	Schema        string                 `json:"schema,omitempty"`         // PostgreSQL schema holding the table from @schema
+	External      string                 `json:"external,omitempty"`       // Existing table or view read from @external_table; never migrated, list and show routes only
+	Stability     string                 `json:"stability,omitempty"`      // Lifecycle status from @stability: experimental, stable or deprecated; empty for stable
+	Custom        map[string]string      `json:"custom,omitempty"`         // Key-value pairs from @meta, verbatim; never interpreted by Conduit
+}
+
+// Annotations returns what the resource's less common annotations declare,
+// all unset when it declares none of them
+func (r *ResourceMetadata) Annotations() ResourceAnnotations {
+	if r.ResourceAnnotations == nil {
+		return ResourceAnnotations{}
+	}
+	return *r.ResourceAnnotations
+}
+
+// SetAnnotations sets what the resource's less common annotations declare,
+// leaving ResourceAnnotations nil when they declare nothing
+func (r *ResourceMetadata) SetAnnotations(annotations ResourceAnnotations) {
+	if annotations.empty() {
+		r.ResourceAnnotations = nil
+		return
+	}
+	r.ResourceAnnotations = &annotations
+}
+
+// empty reports whether no annotation is set
+func (a *ResourceAnnotations) empty() bool {
+	return len(a.Aliases) == 0 && a.CountStrategy == "" && a.SLO == nil && a.CacheControl == nil &&
+		a.Changes == nil && a.Conflict == nil && a.Partition == nil && a.Materialized == nil &&
+		len(a.CounterCaches) == 0 && a.SearchIndex == nil && len(a.Profiles) == 0 && !a.Archivable &&
+		!a.SoftDelete && a.DefaultScope == "" && a.Orderable == nil && a.Tree == nil &&
+		a.Schema == "" && a.External == "" && a.Stability == "" && len(a.Custom) == 0
+}
+
+// ShardMetadata describes a group of resources declared with @shard(by: Key).
+// Each record lives on the shard the shard map assigns to its Key value, and
+// requests name that value in the X-Shard-Key header or the ?key= query
+// parameter. Resources in a group are co-located: records with the same key
+// share a shard, so relationships between them never cross shards. The shards
+// themselves are configured at deploy time and served by the application on
+// /debug/shards.
+type ShardMetadata struct {
+	Key       string   `json:"key"`       // Field whose value selects the shard
+	Resources []string `json:"resources"` // Resources sharded by Key, in declaration order
+}
+
+// ShardOf returns the shard group of the named resource, or nil when its
+// records live in the default database
+func (m *Metadata) ShardOf(resource string) *ShardMetadata {
+	for i := range m.Shards {
+		for _, name := range m.Shards[i].Resources {
+			if name == resource {
+				return &m.Shards[i]
+			}
+		}
+	}
+	return nil
+}
+
+// TreeMetadata describes the hierarchy of a @tree resource, whose records
+// point at their parent through ForeignKey. GET /resources/:id/children and
+// /ancestors walk it, following ?depth levels and never more than MaxDepth.
+type TreeMetadata struct {
+	Parent     string `json:"parent"`      // belongs_to relationship to the parent record
+	ForeignKey string `json:"foreign_key"` // Field holding the parent's ID; null for roots
+	MaxDepth   int    `json:"max_depth"`   // Levels the routes follow at most
+}
+
+// OrderableMetadata describes the order an @orderable resource keeps. Records
+// are ordered by Position within each value of Scope, new records go last and
+// POST /resources/:id/move places a record before or after another.
+type OrderableMetadata struct {
+	Scope    string `json:"scope,omitempty"` // Field records are ordered within; empty for one order across the table
+	Position string `json:"position"`        // Field holding each record's position
+}
+
+// ProfileMetadata describes a serialization profile declared with @profile:
+// the fields rendered for callers with the role Name. Every caller sees the
+// "public" profile; a caller whose roles name other profiles also sees their
+// fields. Responses, filters and sorts leave out every other field.
+type ProfileMetadata struct {
+	Name   string   `json:"name"`          // Role, or "public" for every caller
+	Fields []string `json:"fields"`        // Visible fields in declaration order; every field when All is set
+	All    bool     `json:"all,omitempty"` // Declared with *, so fields added later are shown too
+}
+
+// SearchIndexMetadata describes the search backend index declared with
+// @search_index. Records are indexed into Index with their Fields after each
+// committed write; Path (GET, ?q=) matches the query against Fields and
+// returns records in relevance order. Relevance tuning tools can use Fields
+// to configure searchable attributes and boosts on the backend.
+type SearchIndexMetadata struct {
+	Index  string   `json:"index"`  // Backend index name, the resource's table
+	Path   string   `json:"path"`   // Search route, e.g. "/posts/search"
+	Fields []string `json:"fields"` // Indexed fields, in declaration order
+}
+
+// IndexMetadata describes a database index declared with @index on a field
+// or with an index [fields] block. Queries that filter or sort on a prefix of
+// Columns can use the index; tools flag filters and sorts that use none.
+type IndexMetadata struct {
+	Name    string   `json:"name"`             // Index name in the database
+	Fields  []string `json:"fields"`           // Fields and belongs_to relationships, in column order
+	Columns []string `json:"columns"`          // Indexed columns, in order
+	Unique  bool     `json:"unique,omitempty"` // Rejects two records with the same values
+}
+
+// CounterCacheMetadata describes a denormalized count of a resource kept on
+// a parent with @counter_cache. The Column is a read-only int field of the
+// parent Resource, incremented when a record referencing the parent through
+// ForeignKey is created and decremented when it is deleted or moved.
+type CounterCacheMetadata struct {
+	Column       string `json:"column"`       // Counter column on the parent
+	Resource     string `json:"resource"`     // Parent resource
+	Relationship string `json:"relationship"` // Parent's name for the counted collection
+	ForeignKey   string `json:"foreign_key"`  // Column of this resource referencing the parent
+}
+
+// MaterializedMetadata describes a read-only resource declared with
+// @materialized. It is backed by a materialized view over Query, refreshed on
+// the Refresh schedule, and serves only list and show routes. Sources are the
+// resources the query reads, which also appear as "reads" edges in the
+// dependency graph.
+type MaterializedMetadata struct {
+	Refresh string   `json:"refresh"`           // hourly, daily or weekly
+	Query   string   `json:"query"`             // SELECT statement the view is defined by
+	Sources []string `json:"sources,omitempty"` // Resources read by the query
+}
+
+// PartitionMetadata describes the table partitioning declared with
+// @partition. The table holds one partition per Interval of Field; list
+// requests bounded with ?filter[field][gte]= and ?filter[field][lt]= only
+// scan the partitions in range.
+type PartitionMetadata struct {
+	Field    string `json:"field"`    // Timestamp the table is range partitioned by
+	Interval string `json:"interval"` // day, week, month or year
+}
+
+// ConflictMetadata describes the concurrent update policy declared with
+// @conflict, so client SDKs can resolve conflicts the same way. Responses carry
+// the record version as an ETag derived from VersionField; updates send it
+// back in If-Match. A stale update is applied (last_write_wins), rejected with
+// 412 (reject), or applied only when it writes nothing but MergeFields (merge).
+type ConflictMetadata struct {
+	Strategy     string   `json:"strategy"`                // last_write_wins, reject or merge
+	MergeFields  []string `json:"merge_fields,omitempty"`  // Fields a stale update may still write, for merge
+	VersionField string   `json:"version_field,omitempty"` // @auto_update timestamp the ETag is derived from
+}
+
+// ChangesMetadata describes the change feed declared with @changes. Clients
+// poll Path with ?since= and then ?cursor= to receive created, updated and
+// deleted records; deletes are soft deletes that set DeletedField.
+type ChangesMetadata struct {
+	Path         string `json:"path"`          // Feed route, e.g. "/posts/changes"
+	CursorField  string `json:"cursor_field"`  // @auto_update field the feed is ordered by
+	DeletedField string `json:"deleted_field"` // Timestamp set on soft-deleted records, e.g. "deleted_at"
+}
+
+// CacheControlMetadata describes the caching policy declared with @cache_control.
+// Reads carry the Cache-Control header and surrogate keys; writes purge the keys.
+type CacheControlMetadata struct {
+	MaxAge               int      `json:"max_age"`                          // max-age in seconds
+	SharedMaxAge         int      `json:"s_maxage,omitempty"`               // s-maxage in seconds for CDNs
+	StaleWhileRevalidate int      `json:"stale_while_revalidate,omitempty"` // stale-while-revalidate in seconds
+	Public               bool     `json:"public"`                           // public or private
+	Header               string   `json:"header"`                           // Cache-Control header, e.g. "public, max-age=60"
+	SurrogateKeys        []string `json:"surrogate_keys"`                   // Keys for list and single-record responses, e.g. "posts", "posts/{id}"
+}
+
+// SLOMetadata describes the service level objectives declared with @slo.
+// Generated Prometheus rules alert when they are breached.
+type SLOMetadata struct {
+	Latency      []LatencyObjectiveMetadata `json:"latency,omitempty"`      // Latency targets
+	Availability float64                    `json:"availability,omitempty"` // Percentage of non-5xx responses, e.g. 99.9
+}
+
+// LatencyObjectiveMetadata is a latency target such as latency_p99: 300ms.
+type LatencyObjectiveMetadata struct {
+	Percentile  int     `json:"percentile"`   // e.g. 99
+	ThresholdMs float64 `json:"threshold_ms"` // Maximum latency in milliseconds
 }
 
 // FieldMetadata captures metadata about a single field in a resource.
@@ -42,12 +250,67 @@ type FieldMetadata struct {
 	Constraints   []string `json:"constraints,omitempty"`   // Applied constraints (e.g., "@min(5)", "@max(200)")
 	Documentation string   `json:"documentation,omitempty"` // Field-level doc comments
 	Tags          []string `json:"tags,omitempty"`          // Additional metadata tags
+	Aliases       []string `json:"aliases,omitempty"`       // Former field names kept for API compatibility
+	Column        string   `json:"column,omitempty"`        // Database column name (may differ from Name via @column)
+	Filterable    bool     `json:"filterable,omitempty"`    // Accepted by ?filter[...] on the list endpoint
+	Sortable      bool     `json:"sortable,omitempty"`      // Accepted by ?sort= on the list endpoint
+	IDStrategy    string   `json:"id_strategy,omitempty"`   // On the id field: ulid, uuidv7 or snowflake from @id; clients may generate IDs the same way and send them on create
+
+	DualWrite *DualWriteMetadata `json:"dual_write,omitempty"` // Set while the field is also written to a legacy column
+	Geometry  *GeometryMetadata  `json:"geometry,omitempty"`   // Set for point and polygon fields
+	Custom    map[string]string  `json:"custom,omitempty"`     // Key-value pairs from @meta, verbatim; never interpreted by Conduit
+	Labels    map[string]string  `json:"labels,omitempty"`     // Display labels of enum values from @labels; unlabeled values are shown as themselves
+}
+
+// GeometryMetadata describes a point or polygon field. Values are exchanged as
+// GeoJSON geometries and stored as PostGIS geography; list endpoints accept
+// ?filter[field][near]=lat,lng,radius on filterable geometry fields.
+type GeometryMetadata struct {
+	Type   string `json:"type"`   // GeoJSON geometry type: "Point" or "Polygon"
+	Format string `json:"format"` // Wire format, always "geojson"
+	SRID   int    `json:"srid"`   // Spatial reference system, 4326 (WGS 84)
+}
+
+// DualWriteMetadata describes a field renamed with @dual_write: generated code
+// writes it to both its column and the legacy Column so instances deployed
+// before the rename keep working during a blue/green rollout.
+type DualWriteMetadata struct {
+	Column string `json:"column"`          // Legacy column still being written
+	Until  string `json:"until,omitempty"` // Planned cleanup date (YYYY-MM-DD)
+}
+
+// CleanupSafe reports whether the transition period has ended at now, meaning
+// the @dual_write annotation and the legacy column can be removed. Without a
+// cleanup date the decision is left to the operator and it reports false.
+func (d DualWriteMetadata) CleanupSafe(now time.Time) bool {
+	if d.Until == "" {
+		return false
+	}
+	until, err := time.Parse("2006-01-02", d.Until)
+	if err != nil {
+		return false
+	}
+	return !now.Before(until)
+}
+
+// FieldMap returns the resource's field names mapped to their database columns,
+// suitable for query.NewFieldMap. Fields without a recorded column map to their name.
+func (r ResourceMetadata) FieldMap() map[string]string {
+	fieldMap := make(map[string]string, len(r.Fields))
+	for _, field := range r.Fields {
+		column := field.Column
+		if column == "" {
+			column = field.Name
+		}
+		fieldMap[field.Name] = column
+	}
+	return fieldMap
 }
 
 // RelationshipMetadata captures metadata about relationships between resources.
 type RelationshipMetadata struct {
 	Name           string `json:"name"`                    // Relationship field name
-	Type           string `json:"type"`                    // "belongs_to", "has_many", "has_many_through"
+	Type           string `json:"type"`                    // "belongs_to", "has_one", "has_many", "has_many_through"
 	TargetResource string `json:"target_resource"`         // Target resource name
 	ForeignKey     string `json:"foreign_key,omitempty"`   // Foreign key column name
 	ThroughTable   string `json:"through_table,omitempty"` // Join table for has_many_through
@@ -57,11 +320,17 @@ type RelationshipMetadata struct {
 
 // HookMetadata captures metadata about lifecycle hooks.
 type HookMetadata struct {
-	Type        string `json:"type"`                  // Hook type (e.g., "before_create", "after_update")
-	Transaction bool   `json:"transaction"`           // Whether hook runs in transaction
-	Async       bool   `json:"async"`                 // Whether hook runs asynchronously
-	SourceCode  string `json:"source_code,omitempty"` // Hook implementation source
-	LineNumber  int    `json:"line_number"`           // Source file line number
+	Type         string `json:"type"`                   // Hook type (e.g., "before_create", "after_update", "after_attach")
+	Relationship string `json:"relationship,omitempty"` // has_many_through relationship of attach and detach hooks
+	Batch        bool   `json:"batch,omitempty"`        // Whether hook runs once with all the records created
+	Transaction  bool   `json:"transaction"`            // Whether hook runs in transaction
+	Async        bool   `json:"async"`                  // Whether hook runs asynchronously
+	Policy       string `json:"policy,omitempty"`       // Whether a failing hook fails the operation ("abort") or is logged ("warn")
+	Retry        int    `json:"retry,omitempty"`        // Retries of a failing hook
+	Backoff      string `json:"backoff,omitempty"`      // Delay between retries ("constant" or "exponential")
+	Timeout      string `json:"timeout,omitempty"`      // Time after which the hook's context is canceled (e.g., "2s")
+	SourceCode   string `json:"source_code,omitempty"`  // Hook implementation source
+	LineNumber   int    `json:"line_number"`            // Source file line number
 }
 
 // ValidationMetadata captures field-level validation rules.
@@ -105,10 +374,27 @@ type RouteMetadata struct {
 	Path         string   `json:"path"`                    // URL path pattern
 	Handler      string   `json:"handler"`                 // Handler function name
 	Resource     string   `json:"resource"`                // Associated resource name
-	Operation    string   `json:"operation"`               // CRUD operation (list, show, create, update, delete)
+	Operation    string   `json:"operation"`               // CRUD operation (list, show, create, update, delete), upsert, archive, restore, move, children, ancestors, webhook, login, login_callback or usage
 	Middleware   []string `json:"middleware,omitempty"`    // Applied middleware
 	RequestBody  string   `json:"request_body,omitempty"`  // Expected request body type
 	ResponseBody string   `json:"response_body,omitempty"` // Response body type
+	Queries      []string `json:"queries,omitempty"`       // SQL templates the route executes, in order
+	Allow        []string `json:"allow,omitempty"`         // Methods served on Path, including HEAD and OPTIONS
+}
+
+// AuthMetadata describes the social login configured in conduit.yaml.
+type AuthMetadata struct {
+	Resource  string                 `json:"resource"`  // Resource users sign in as
+	Providers []AuthProviderMetadata `json:"providers"` // Configured login providers
+}
+
+// AuthProviderMetadata describes a login provider and its routes.
+type AuthProviderMetadata struct {
+	Name          string   `json:"name"`           // Provider name (google, github)
+	Protocol      string   `json:"protocol"`       // oidc or oauth2
+	AuthorizePath string   `json:"authorize_path"` // Route that starts sign-in
+	CallbackPath  string   `json:"callback_path"`  // Route the provider redirects back to
+	Scopes        []string `json:"scopes"`         // Scopes requested at sign-in
 }
 
 // PatternMetadata captures discovered usage patterns for LLM learning.
@@ -125,10 +411,11 @@ type PatternMetadata struct {
 
 // PatternExample captures a single example of a pattern in use.
 type PatternExample struct {
-	Resource   string `json:"resource"`              // Resource where pattern is used
-	FilePath   string `json:"file_path"`             // Source file path
-	LineNumber int    `json:"line_number,omitempty"` // Line number in source
-	Code       string `json:"code"`                  // Example code snippet
+	Resource   string     `json:"resource"`              // Resource where pattern is used
+	FilePath   string     `json:"file_path"`             // Source file path
+	LineNumber int        `json:"line_number,omitempty"` // Line number in source
+	Code       string     `json:"code"`                  // Example code snippet
+	ModifiedAt *time.Time `json:"modified_at,omitempty"` // When the source file last changed, for recency scoring
 }
 
 // DependencyGraph captures the dependency relationships between resources.
@@ -153,6 +440,6 @@ type DependencyNode struct {
 type DependencyEdge struct {
 	From         string `json:"from"`         // Source node ID
 	To           string `json:"to"`           // Target node ID
-	Relationship string `json:"relationship"` // Relationship type (uses, calls, belongs_to)
+	Relationship string `json:"relationship"` // Relationship type (uses, calls, belongs_to, reads)
 	Weight       int    `json:"weight"`       // Relationship weight/importance
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
 
@@ -351,6 +352,17 @@ func TestFieldMetadataTypes(t *testing.T) {
 			},
 			wantJSON: `{"name":"age","type":"integer","nullable":false,"required":true,"constraints":["@min(0)","@max(120)"]}`,
 		},
+		{
+			name: "with_column",
+			field: FieldMetadata{
+				Name:     "body",
+				Type:     "text",
+				Nullable: false,
+				Required: true,
+				Column:   "legacy_body",
+			},
+			wantJSON: `{"name":"body","type":"text","nullable":false,"required":true,"column":"legacy_body"}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -377,6 +389,72 @@ func TestFieldMetadataTypes(t *testing.T) {
 	}
 }
 
+// TestResourceMetadataFieldMap tests mapping field names to database columns.
+func TestResourceMetadataFieldMap(t *testing.T) {
+	resource := ResourceMetadata{
+		Name: "Post",
+		Fields: []FieldMetadata{
+			{Name: "title", Column: "title"},
+			{Name: "body", Column: "legacy_body"},
+			{Name: "status"},
+		},
+	}
+
+	want := map[string]string{
+		"title":  "title",
+		"body":   "legacy_body",
+		"status": "status",
+	}
+	if got := resource.FieldMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldMap() = %v, want %v", got, want)
+	}
+}
+
+func TestDualWriteMetadataCleanupSafe(t *testing.T) {
+	day := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name string
+		dw   DualWriteMetadata
+		now  time.Time
+		want bool
+	}{
+		{"before cleanup date", DualWriteMetadata{Column: "title", Until: "2026-12-01"}, day("2026-11-30"), false},
+		{"on cleanup date", DualWriteMetadata{Column: "title", Until: "2026-12-01"}, day("2026-12-01"), true},
+		{"after cleanup date", DualWriteMetadata{Column: "title", Until: "2026-12-01"}, day("2027-01-15"), true},
+		{"no cleanup date", DualWriteMetadata{Column: "title"}, day("2030-01-01"), false},
+		{"invalid cleanup date", DualWriteMetadata{Column: "title", Until: "soon"}, day("2030-01-01"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dw.CleanupSafe(tt.now); got != tt.want {
+				t.Errorf("CleanupSafe(%s) = %v, want %v", tt.now.Format("2006-01-02"), got, tt.want)
+			}
+		})
+	}
+
+	// The transition round-trips through JSON
+	field := FieldMetadata{Name: "headline", DualWrite: &DualWriteMetadata{Column: "title", Until: "2026-12-01"}}
+	data, err := json.Marshal(field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded FieldMetadata
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.DualWrite, field.DualWrite) {
+		t.Errorf("DualWrite after round-trip = %+v, want %+v", decoded.DualWrite, field.DualWrite)
+	}
+}
+
 // TestRelationshipTypes tests different relationship scenarios.
 func TestRelationshipTypes(t *testing.T) {
 	tests := []struct {
@@ -746,6 +824,44 @@ func TestOmitEmptyFields(t *testing.T) {
 	t.Logf("Minimal field JSON: %s", jsonStr)
 }
 
+// TestResourceAnnotationsJSON tests that annotations are members of the
+// resource in JSON and stay unallocated when a resource declares none.
+func TestResourceAnnotationsJSON(t *testing.T) {
+	resource := ResourceMetadata{Name: "Post"}
+	resource.SetAnnotations(ResourceAnnotations{CounterCaches: []CounterCacheMetadata{}})
+	if resource.ResourceAnnotations != nil {
+		t.Errorf("ResourceAnnotations = %+v, want nil without annotations", resource.ResourceAnnotations)
+	}
+	if resource.Annotations().Stability != "" {
+		t.Error("Annotations() should be empty without annotations")
+	}
+
+	resource.SetAnnotations(ResourceAnnotations{Stability: "deprecated", SoftDelete: true})
+	data, err := json.Marshal(resource)
+	if err != nil {
+		t.Fatalf("Failed to marshal resource: %v", err)
+	}
+	if !contains(string(data), `"soft_delete":true,"stability":"deprecated"`) {
+		t.Errorf("annotations should be members of the resource: %s", data)
+	}
+
+	var decoded ResourceMetadata
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal resource: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Annotations(), resource.Annotations()) {
+		t.Errorf("Annotations() = %+v, want %+v", decoded.Annotations(), resource.Annotations())
+	}
+
+	var plain ResourceMetadata
+	if err := json.Unmarshal([]byte(`{"name":"Post"}`), &plain); err != nil {
+		t.Fatalf("Failed to unmarshal resource: %v", err)
+	}
+	if plain.ResourceAnnotations != nil {
+		t.Errorf("ResourceAnnotations = %+v, want nil without annotations", plain.ResourceAnnotations)
+	}
+}
+
 // Helper function to check if string contains substring
 func contains(s, substr string) bool {
 	return bytes.Contains([]byte(s), []byte(substr))
@@ -0,0 +1,243 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kinds of matches reported by Search
+const (
+	SearchResource      = "resource"      // A resource name
+	SearchField         = "field"         // A field name
+	SearchDocumentation = "documentation" // Doc comments of a resource or field
+	SearchPattern       = "pattern"       // A pattern name, description or template
+	SearchHook          = "hook"          // Hook source code
+)
+
+// searchKinds lists the kinds in the order results of equal score are listed
+var searchKinds = []string{SearchResource, SearchField, SearchDocumentation, SearchPattern, SearchHook}
+
+// searchWeights rank matches of each kind: a name says more about what
+// matched than a mention in documentation or code does
+var searchWeights = map[string]float64{
+	SearchResource:      100,
+	SearchField:         90,
+	SearchDocumentation: 60,
+	SearchPattern:       50,
+	SearchHook:          40,
+}
+
+// DefaultSearchLimit is how many results Search returns when SearchOptions
+// has no Limit
+const DefaultSearchLimit = 50
+
+// SearchOptions narrows a search.
+// All fields are optional.
+type SearchOptions struct {
+	Kinds    []string // Kinds of matches to return, e.g. ["field", "hook"]; all when empty
+	Resource string   // Only search this resource; patterns match when one of their examples is in it
+	Limit    int      // Maximum number of results; DefaultSearchLimit when zero, all when negative
+}
+
+// SearchResult is one match of a search
+type SearchResult struct {
+	Kind     string  `json:"kind"`               // One of the kinds above, e.g. field
+	Resource string  `json:"resource,omitempty"` // Resource matched in; empty for patterns
+	Name     string  `json:"name"`               // Resource, field or pattern name, or hook type such as before_create
+	Line     int     `json:"line,omitempty"`     // Line of the snippet in multi-line text, from 1
+	Snippet  string  `json:"snippet"`            // Line of text that matched, trimmed
+	Score    float64 `json:"score"`              // Relevance; higher is better, at most 100
+}
+
+// Search finds the query in resource and field names, documentation, hook
+// source code and pattern templates of meta. The query is split into words,
+// and text matches when it contains every word, ignoring case. Results are
+// ranked by score: names rank above documentation, patterns and code, and a
+// whole-text match above a prefix, a word and then any substring.
+func Search(meta *Metadata, query string, opts SearchOptions) ([]SearchResult, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, errors.New("search query is empty")
+	}
+	kinds := make(map[string]bool, len(searchKinds))
+	for _, kind := range opts.Kinds {
+		if searchWeights[kind] == 0 {
+			return nil, fmt.Errorf("unknown search kind: %s (supported: %s)", kind, strings.Join(searchKinds, ", "))
+		}
+		kinds[kind] = true
+	}
+	wanted := func(kind string) bool { return len(kinds) == 0 || kinds[kind] }
+
+	found := false
+	results := []SearchResult{}
+	add := func(kind, resource, name, text string) {
+		if !wanted(kind) {
+			return
+		}
+		if result, ok := searchText(terms, text); ok {
+			result.Kind, result.Resource, result.Name = kind, resource, name
+			result.Score = searchScore(result.Score, kind)
+			results = append(results, result)
+		}
+	}
+
+	for i := range meta.Resources {
+		res := &meta.Resources[i]
+		if opts.Resource != "" && res.Name != opts.Resource {
+			continue
+		}
+		found = true
+		add(SearchResource, res.Name, res.Name, res.Name)
+		add(SearchDocumentation, res.Name, res.Name, res.Documentation)
+		for _, field := range res.Fields {
+			add(SearchField, res.Name, field.Name, field.Name)
+			add(SearchDocumentation, res.Name, field.Name, field.Documentation)
+		}
+		for _, hook := range res.Hooks {
+			add(SearchHook, res.Name, hook.Type, hook.SourceCode)
+		}
+	}
+	if opts.Resource != "" && !found {
+		return nil, fmt.Errorf("resource not found: %s", opts.Resource)
+	}
+
+	if wanted(SearchPattern) {
+		for _, pattern := range meta.Patterns {
+			if opts.Resource != "" && !patternUsedIn(pattern, opts.Resource) {
+				continue
+			}
+			// One result per pattern, for the text it matches best
+			var best SearchResult
+			for _, text := range []string{pattern.Name, pattern.Description, pattern.Template} {
+				if result, ok := searchText(terms, text); ok && result.Score > best.Score {
+					best = result
+				}
+			}
+			if best.Score > 0 {
+				best.Kind, best.Name = SearchPattern, pattern.Name
+				best.Score = searchScore(best.Score, SearchPattern)
+				results = append(results, best)
+			}
+		}
+	}
+
+	order := make(map[string]int, len(searchKinds))
+	for i, kind := range searchKinds {
+		order[kind] = i
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Kind != b.Kind {
+			return order[a.Kind] < order[b.Kind]
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Name < b.Name
+	})
+
+	limit := opts.Limit
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// searchScore weighs how well text of a kind matched, rounded to a tenth
+func searchScore(quality float64, kind string) float64 {
+	return math.Round(quality*searchWeights[kind]*10) / 10
+}
+
+// patternUsedIn reports whether one of the pattern's examples is in resource
+func patternUsedIn(pattern PatternMetadata, resource string) bool {
+	for _, example := range pattern.Examples {
+		if example.Resource == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// searchText matches the lowercase terms against text. The score, from 0
+// to 1, averages how well each term matches; the snippet is the first line
+// holding the most terms.
+func searchText(terms []string, text string) (SearchResult, bool) {
+	if text == "" {
+		return SearchResult{}, false
+	}
+	lower := strings.ToLower(text)
+	var score float64
+	for _, term := range terms {
+		quality := termQuality(lower, term)
+		if quality == 0 {
+			return SearchResult{}, false
+		}
+		score += quality
+	}
+
+	result := SearchResult{Score: score / float64(len(terms))}
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		result.Snippet = strings.TrimSpace(text)
+		return result, true
+	}
+	most := -1
+	for i, line := range lines {
+		line = strings.ToLower(line)
+		count := 0
+		for _, term := range terms {
+			if strings.Contains(line, term) {
+				count++
+			}
+		}
+		if count > most {
+			most = count
+			result.Line, result.Snippet = i+1, strings.TrimSpace(lines[i])
+		}
+	}
+	return result, true
+}
+
+// termQuality rates the best occurrence of term in text: 1 when it is the
+// whole text, 0.8 at its start, 0.6 at the start of a word, 0.4 elsewhere
+// and 0 when it does not occur
+func termQuality(text, term string) float64 {
+	if strings.TrimSpace(text) == term {
+		return 1
+	}
+	best := 0.0
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], term)
+		if i < 0 {
+			return best
+		}
+		i += offset
+		switch {
+		case i == 0:
+			return 0.8
+		case wordStart(text, i):
+			best = 0.6
+		case best == 0:
+			best = 0.4
+		}
+		offset = i + len(term)
+	}
+}
+
+// wordStart reports whether the text at i follows a character that is not a
+// letter or digit, such as the underscore of created_at
+func wordStart(text string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(text[:i])
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
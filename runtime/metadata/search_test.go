@@ -0,0 +1,183 @@
+package metadata
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func searchTestMetadata() *Metadata {
+	return &Metadata{
+		Resources: []ResourceMetadata{
+			{
+				Name:          "Post",
+				Documentation: "A blog post, published with a slug",
+				Fields: []FieldMetadata{
+					{Name: "title", Type: "string!"},
+					{Name: "slug", Type: "string!", Documentation: "URL-friendly title"},
+					{Name: "author_id", Type: "uuid!"},
+				},
+				Hooks: []HookMetadata{
+					{Type: "before_create", SourceCode: "self.published_at = Time.now()\nself.slug = String.slugify(self.title)"},
+					{Type: "after_create", SourceCode: "Email.send(self.author.email, \"Published\")"},
+				},
+			},
+			{
+				Name:   "Author",
+				Fields: []FieldMetadata{{Name: "email", Type: "email!"}},
+			},
+		},
+		Patterns: []PatternMetadata{
+			{
+				Name:     "slug_generation",
+				Template: "self.slug = String.slugify(self.{{field}})",
+				Examples: []PatternExample{{Resource: "Post"}},
+			},
+			{Name: "email_notification", Description: "Sends an email after create"},
+		},
+	}
+}
+
+func TestSearch(t *testing.T) {
+	results, err := Search(searchTestMetadata(), "Slug", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	want := []struct{ kind, resource, name string }{
+		{SearchField, "Post", "slug"},          // The whole name
+		{SearchPattern, "", "slug_generation"}, // Start of the name
+		{SearchDocumentation, "Post", "Post"},  // A word in documentation
+		{SearchHook, "Post", "before_create"},  // A word in code
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Got %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Kind != w.kind || got.Resource != w.resource || got.Name != w.name {
+			t.Errorf("Result %d: got %s %s.%s, want %s %s.%s", i, got.Kind, got.Resource, got.Name, w.kind, w.resource, w.name)
+		}
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Score > results[i-1].Score {
+			t.Errorf("Results not ranked: %v before %v", results[i-1].Score, results[i].Score)
+		}
+	}
+
+	// The snippet is the matching line of multi-line text
+	hook := results[3]
+	if hook.Line != 2 || hook.Snippet != "self.slug = String.slugify(self.title)" {
+		t.Errorf("Hook snippet: got line %d %q", hook.Line, hook.Snippet)
+	}
+	if results[0].Score != 90 {
+		t.Errorf("An exact field name should score 90, got %v", results[0].Score)
+	}
+}
+
+func TestSearch_EveryTerm(t *testing.T) {
+	results, err := Search(searchTestMetadata(), "send email", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	// The email field matches one term only
+	if len(results) != 2 {
+		t.Fatalf("Got %d results, want 2: %+v", len(results), results)
+	}
+	if results[0].Kind != SearchPattern || results[1].Kind != SearchHook {
+		t.Errorf("Got %s then %s", results[0].Kind, results[1].Kind)
+	}
+}
+
+func TestSearch_Options(t *testing.T) {
+	meta := searchTestMetadata()
+
+	results, err := Search(meta, "slug", SearchOptions{Kinds: []string{SearchHook, SearchField}})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Kinds: got %d results, want 2", len(results))
+	}
+
+	// Patterns used in the resource are searched with it
+	results, err = Search(meta, "slug", SearchOptions{Resource: "Author"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Author should have no slugs: %+v", results)
+	}
+	results, err = Search(meta, "email", SearchOptions{Resource: "Author"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Name != "email" {
+		t.Errorf("Resource: got %+v", results)
+	}
+	results, err = Search(meta, "slug", SearchOptions{Resource: "Post", Kinds: []string{SearchPattern}})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Patterns used in Post: got %+v", results)
+	}
+
+	results, err = Search(meta, "slug", SearchOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Name != "slug" {
+		t.Errorf("Limit: got %+v", results)
+	}
+}
+
+func TestSearch_Errors(t *testing.T) {
+	meta := searchTestMetadata()
+	for name, search := range map[string]func() error{
+		"empty query": func() error {
+			_, err := Search(meta, "  ", SearchOptions{})
+			return err
+		},
+		"unknown kind": func() error {
+			_, err := Search(meta, "slug", SearchOptions{Kinds: []string{"route"}})
+			return err
+		},
+		"unknown resource": func() error {
+			_, err := Search(meta, "slug", SearchOptions{Resource: "Comment"})
+			return err
+		},
+	} {
+		if err := search(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRegistryAPI_Search(t *testing.T) {
+	defer Reset()
+
+	registry := GetRegistry()
+	if _, err := registry.Search("slug", SearchOptions{}); err == nil || !strings.Contains(err.Error(), "not initialized") {
+		t.Errorf("Expected not initialized error, got %v", err)
+	}
+
+	// Hook source code and patterns registered lazily are searched
+	data, err := json.Marshal(searchTestMetadata())
+	if err != nil {
+		t.Fatalf("Failed to marshal metadata: %v", err)
+	}
+	if err := RegisterMetadataWithOptions(data, RegisterOptions{
+		Lazy:   true,
+		Reload: func() ([]byte, error) { return data, nil },
+	}); err != nil {
+		t.Fatalf("RegisterMetadataWithOptions failed: %v", err)
+	}
+	results, err := registry.Search("slugify", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Got %d results, want 2: %+v", len(results), results)
+	}
+}
@@ -0,0 +1,212 @@
+package metadata
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchDebounce is how long a Watcher waits after the last change to the
+// metadata file before reloading it, so a build writing the file in several
+// steps causes a single reload
+const WatchDebounce = 100 * time.Millisecond
+
+// MetadataUpdate is sent to a Watcher's subscribers after each change to the
+// metadata file
+type MetadataUpdate struct {
+	Metadata *Metadata // Newly registered metadata; nil when Err is set
+	ETag     string    // Entity tag of the newly registered metadata
+	Err      error     // Why the file could not be reloaded; the registry keeps the previous metadata
+}
+
+// Watcher reloads the global registry whenever a metadata file changes.
+// Create one with RegistryAPI.Watch and stop it with Close.
+type Watcher struct {
+	path    string
+	watcher *fsnotify.Watcher
+	last    []byte // Contents of the file last registered
+
+	mu          sync.Mutex
+	subscribers []chan MetadataUpdate
+	closed      bool
+
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Watch registers the metadata file at path, typically
+// build/introspection/metadata.json, and reloads it whenever it changes, so
+// long-running tools such as editors and dashboards see the metadata of each
+// new build without restarting.
+//
+// Each reload builds new indexes and swaps them in at once: queries see
+// either the old or the new metadata, never a mix. A file that cannot be
+// read or parsed, such as one a build is still writing, leaves the current
+// metadata in place. The file need not exist yet, but its directory must.
+//
+// Only one Watcher should be active at a time, since all of them register
+// into the same global registry.
+//
+// Example usage:
+//
+//	watcher, err := metadata.GetRegistry().Watch("build/introspection/metadata.json")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer watcher.Close()
+//
+//	for update := range watcher.Subscribe() {
+//		if update.Err != nil {
+//			log.Printf("metadata reload failed: %v", update.Err)
+//			continue
+//		}
+//		refreshContext(update.Metadata)
+//	}
+func (r *RegistryAPI) Watch(path string) (*Watcher, error) {
+	w := &Watcher{
+		path: filepath.Clean(path),
+		done: make(chan struct{}),
+	}
+
+	data, err := os.ReadFile(w.path)
+	switch {
+	case err == nil:
+		if err := RegisterMetadata(data); err != nil {
+			return nil, err
+		}
+		w.last = data
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+
+	w.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metadata watcher: %w", err)
+	}
+	// Watch the directory rather than the file, so the watch survives builds
+	// replacing the file
+	if err := w.watcher.Add(filepath.Dir(w.path)); err != nil {
+		w.watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", filepath.Dir(w.path), err)
+	}
+
+	w.wg.Add(1)
+	go w.run()
+
+	return w, nil
+}
+
+// Subscribe returns a channel receiving an update after each reload. The
+// channel holds only the latest update: a subscriber that falls behind skips
+// to the newest metadata. It is closed by Close.
+func (w *Watcher) Subscribe() <-chan MetadataUpdate {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ch := make(chan MetadataUpdate, 1)
+	if w.closed {
+		close(ch)
+		return ch
+	}
+	w.subscribers = append(w.subscribers, ch)
+	return ch
+}
+
+// Close stops watching and closes the subscription channels. The registry
+// keeps the metadata registered last.
+func (w *Watcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		w.wg.Wait()
+		w.closeErr = w.watcher.Close()
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.closed = true
+		for _, ch := range w.subscribers {
+			close(ch)
+		}
+		w.subscribers = nil
+	})
+	return w.closeErr
+}
+
+// run reloads the metadata once changes to the file have settled
+func (w *Watcher) run() {
+	defer w.wg.Done()
+
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != w.path || !(event.Has(fsnotify.Write) || event.Has(fsnotify.Create)) {
+				continue
+			}
+			settled = time.After(WatchDebounce)
+		case <-settled:
+			settled = nil
+			w.reload()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.publish(MetadataUpdate{Err: err})
+		}
+	}
+}
+
+// reload registers the file's metadata if its contents changed since they
+// were last registered
+func (w *Watcher) reload() {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		w.publish(MetadataUpdate{Err: fmt.Errorf("failed to read metadata: %w", err)})
+		return
+	}
+	if bytes.Equal(data, w.last) {
+		return
+	}
+	if err := RegisterMetadata(data); err != nil {
+		w.publish(MetadataUpdate{Err: err})
+		return
+	}
+	w.last = data
+
+	registry := GetRegistry()
+	w.publish(MetadataUpdate{Metadata: registry.GetSchema(), ETag: registry.ETag()})
+}
+
+// publish sends the update to every subscriber, replacing an update the
+// subscriber has not received yet
+func (w *Watcher) publish(update MetadataUpdate) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, ch := range w.subscribers {
+		select {
+		case ch <- update:
+		default:
+			// run is the only sender, so once the stale update is gone
+			// the send cannot block
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- update
+		}
+	}
+}
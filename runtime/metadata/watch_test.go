@@ -0,0 +1,156 @@
+package metadata
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeMetadataFile writes metadata with the given resources to path
+func writeMetadataFile(t *testing.T, path string, resources ...string) {
+	t.Helper()
+	meta := &Metadata{Version: "1.0.0", SourceHash: filepath.Base(path)}
+	for _, name := range resources {
+		meta.Resources = append(meta.Resources, ResourceMetadata{Name: name})
+		meta.SourceHash += "-" + name
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatalf("Failed to marshal metadata: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write metadata: %v", err)
+	}
+}
+
+// nextUpdate waits for the next update on the subscription
+func nextUpdate(t *testing.T, updates <-chan MetadataUpdate) MetadataUpdate {
+	t.Helper()
+	select {
+	case update, ok := <-updates:
+		if !ok {
+			t.Fatal("Subscription closed before an update arrived")
+		}
+		return update
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a metadata update")
+		return MetadataUpdate{}
+	}
+}
+
+func TestWatch_Reloads(t *testing.T) {
+	defer Reset()
+
+	path := filepath.Join(t.TempDir(), "metadata.json")
+	writeMetadataFile(t, path, "User")
+
+	watcher, err := GetRegistry().Watch(path)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if _, err := QueryResource("User"); err != nil {
+		t.Fatalf("Expected Watch to register the existing file: %v", err)
+	}
+
+	updates := watcher.Subscribe()
+	writeMetadataFile(t, path, "User", "Post")
+
+	update := nextUpdate(t, updates)
+	if update.Err != nil {
+		t.Fatalf("Unexpected reload error: %v", update.Err)
+	}
+	if len(update.Metadata.Resources) != 2 {
+		t.Errorf("Expected 2 resources in the update, got %d", len(update.Metadata.Resources))
+	}
+	if update.ETag != `"metadata.json-User-Post"` {
+		t.Errorf("ETag = %s, want the new source hash", update.ETag)
+	}
+	if _, err := QueryResource("Post"); err != nil {
+		t.Errorf("Expected Post to be queryable after the reload: %v", err)
+	}
+}
+
+func TestWatch_KeepsMetadataOnInvalidFile(t *testing.T) {
+	defer Reset()
+
+	path := filepath.Join(t.TempDir(), "metadata.json")
+	writeMetadataFile(t, path, "User")
+
+	watcher, err := GetRegistry().Watch(path)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer watcher.Close()
+
+	updates := watcher.Subscribe()
+	if err := os.WriteFile(path, []byte(`{"resources": [`), 0644); err != nil {
+		t.Fatalf("Failed to write metadata: %v", err)
+	}
+
+	if update := nextUpdate(t, updates); update.Err == nil {
+		t.Error("Expected an update reporting the invalid file")
+	}
+	if _, err := QueryResource("User"); err != nil {
+		t.Errorf("Expected the previous metadata to stay registered: %v", err)
+	}
+}
+
+func TestWatch_FileCreatedLater(t *testing.T) {
+	defer Reset()
+
+	path := filepath.Join(t.TempDir(), "metadata.json")
+	watcher, err := GetRegistry().Watch(path)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if GetMetadata() != nil {
+		t.Fatal("Expected no metadata before the file exists")
+	}
+
+	updates := watcher.Subscribe()
+	writeMetadataFile(t, path, "Comment")
+
+	if update := nextUpdate(t, updates); update.Err != nil {
+		t.Fatalf("Unexpected reload error: %v", update.Err)
+	}
+	if _, err := QueryResource("Comment"); err != nil {
+		t.Errorf("Expected Comment to be registered: %v", err)
+	}
+}
+
+func TestWatch_Close(t *testing.T) {
+	defer Reset()
+
+	watcher, err := GetRegistry().Watch(filepath.Join(t.TempDir(), "metadata.json"))
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	updates := watcher.Subscribe()
+
+	if err := watcher.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, ok := <-updates; ok {
+		t.Error("Expected Close to close subscriptions")
+	}
+	if _, ok := <-watcher.Subscribe(); ok {
+		t.Error("Expected subscriptions after Close to be closed")
+	}
+	if err := watcher.Close(); err != nil {
+		t.Errorf("Expected a second Close to succeed, got %v", err)
+	}
+}
+
+func TestWatch_MissingDirectory(t *testing.T) {
+	defer Reset()
+
+	if _, err := GetRegistry().Watch(filepath.Join(t.TempDir(), "missing", "metadata.json")); err == nil {
+		t.Error("Expected an error when the directory does not exist")
+	}
+}
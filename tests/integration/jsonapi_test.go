@@ -40,9 +40,9 @@ resource Product {
 		t.Error("LIST handler should check for JSON:API Accept header using response.IsJSONAPI")
 	}
 
-	// Test 2: Verify handler returns JSON:API format when requested
-	if !strings.Contains(handlerContent, "response.RenderJSONAPIWithMeta") {
-		t.Error("LIST handler should use response.RenderJSONAPIWithMeta for JSON:API responses")
+	// Test 2: Verify handler streams JSON:API or legacy JSON based on the request
+	if !strings.Contains(handlerContent, "response.NewListStream(w, r, fields)") {
+		t.Error("LIST handler should stream results with response.NewListStream")
 	}
 
 	// Test 3: Verify handler includes pagination metadata
@@ -144,25 +144,14 @@ resource Article {
 
 	handlerContent := result.Files["handlers/handlers.go"]
 
-	// Verify handler parses limit parameter
-	if !strings.Contains(handlerContent, `r.URL.Query().Get("limit")`) &&
-		!strings.Contains(handlerContent, `r.URL.Query().Get("page[limit]")`) {
-		t.Error("LIST handler should parse limit pagination parameter")
+	// Verify handler parses page[limit]/page[offset] with the default page config
+	if !strings.Contains(handlerContent, "query.ParsePage(r, query.DefaultPageConfig())") {
+		t.Error("LIST handler should parse pagination parameters with query.ParsePage")
 	}
 
-	// Verify handler parses offset parameter
-	if !strings.Contains(handlerContent, `r.URL.Query().Get("offset")`) &&
-		!strings.Contains(handlerContent, `r.URL.Query().Get("page[offset]")`) {
-		t.Error("LIST handler should parse offset pagination parameter")
-	}
-
-	// Verify default values
-	if !strings.Contains(handlerContent, "limit := 50") && !strings.Contains(handlerContent, "limit = 50") {
-		t.Error("LIST handler should have default limit value")
-	}
-
-	if !strings.Contains(handlerContent, "offset := 0") && !strings.Contains(handlerContent, "offset = 0") {
-		t.Error("LIST handler should have default offset value")
+	// Verify the parsed page is applied to the list query
+	if !strings.Contains(handlerContent, "Paginate(pagination)") {
+		t.Error("LIST handler should apply pagination to the query builder")
 	}
 }
 
@@ -484,13 +473,13 @@ resource Task {
 
 	handlerContent := result.Files["handlers/handlers.go"]
 
-	// Verify both LIST and GET handlers have legacy JSON fallback
-	// Count occurrences of legacy JSON encoding
-	legacyEncodings := strings.Count(handlerContent, "json.NewEncoder(w).Encode(results)") +
-		strings.Count(handlerContent, "json.NewEncoder(w).Encode(result)")
-
-	if legacyEncodings < 2 {
-		t.Error("Both LIST and GET handlers should support legacy JSON encoding")
+	// Verify both LIST and GET handlers have legacy JSON fallback.
+	// LIST streams legacy JSON arrays through response.NewListStream.
+	if !strings.Contains(handlerContent, "json.NewEncoder(w).Encode(result)") {
+		t.Error("GET handler should support legacy JSON encoding")
+	}
+	if !strings.Contains(handlerContent, "response.NewListStream(w, r, fields)") {
+		t.Error("LIST handler should support legacy JSON encoding via response.NewListStream")
 	}
 
 	// Verify Content-Type is set for legacy responses